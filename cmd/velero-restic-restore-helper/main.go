@@ -21,9 +21,23 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"time"
 )
 
+// restoresDir returns the directory that the volumes being restored are
+// mounted under, which is different in Windows containers.
+func restoresDir() string {
+	if runtime.GOOS == "windows" {
+		return `C:\restores`
+	}
+	return "/restores"
+}
+
+// blockRestoresDir is the directory that the done files of the restores of
+// block volumes, which can't hold files themselves, are written under.
+const blockRestoresDir = "/block-restores"
+
 func main() {
 	if len(os.Args) != 2 {
 		fmt.Fprintln(os.Stderr, "ERROR: exactly one argument must be provided, the restore's UID")
@@ -44,24 +58,29 @@ func main() {
 	}
 }
 
-// done returns true if for each directory under /restores, a file exists
-// within the .velero/ subdirectory whose name is equal to os.Args[1], or
-// false otherwise
+// done returns true if for each directory under the restores directory, a
+// file exists within the .velero/ subdirectory whose name is equal to
+// os.Args[1], and for each block device under it, such a file exists within
+// its directory under the block restores directory, or false otherwise
 func done() bool {
-	children, err := ioutil.ReadDir("/restores")
+	children, err := ioutil.ReadDir(restoresDir())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR reading /restores directory: %s\n", err)
+		fmt.Fprintf(os.Stderr, "ERROR reading %s directory: %s\n", restoresDir(), err)
 		return false
 	}
 
 	for _, child := range children {
-		if !child.IsDir() {
-			fmt.Printf("%s is not a directory, skipping.\n", child.Name())
+		var doneFile string
+		switch {
+		case child.IsDir():
+			doneFile = filepath.Join(restoresDir(), child.Name(), ".velero", os.Args[1])
+		case child.Mode()&os.ModeDevice != 0:
+			doneFile = filepath.Join(blockRestoresDir, child.Name(), ".velero", os.Args[1])
+		default:
+			fmt.Printf("%s is not a directory or block device, skipping.\n", child.Name())
 			continue
 		}
 
-		doneFile := filepath.Join("/restores", child.Name(), ".velero", os.Args[1])
-
 		if _, err := os.Stat(doneFile); os.IsNotExist(err) {
 			fmt.Printf("Not found: %s\n", doneFile)
 			return false
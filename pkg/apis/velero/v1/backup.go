@@ -43,11 +43,31 @@ type BackupSpec struct {
 	// or nil, all objects are included. Optional.
 	LabelSelector *metav1.LabelSelector `json:"labelSelector"`
 
+	// OrLabelSelectors is a list of metav1.LabelSelectors to filter with
+	// when adding individual objects to the backup. Objects matching
+	// any of them are included. Can't be set with LabelSelector.
+	// Optional.
+	OrLabelSelectors []metav1.LabelSelector `json:"orLabelSelectors,omitempty"`
+
 	// SnapshotVolumes specifies whether to take cloud snapshots
 	// of any PV's referenced in the set of objects included
 	// in the Backup.
 	SnapshotVolumes *bool `json:"snapshotVolumes,omitempty"`
 
+	// DefaultVolumesToRestic specifies whether all pod volumes should be
+	// backed up with restic, except those listed in a pod's
+	// backup.velero.io/backup-volumes-excludes annotation, instead of only
+	// those listed in its backup.velero.io/backup-volumes annotation. If
+	// nil, the server's default is used. Optional.
+	DefaultVolumesToRestic *bool `json:"defaultVolumesToRestic,omitempty"`
+
+	// SnapshotMoveData specifies whether the data of the backup's volume
+	// snapshots should be moved into the backup storage location with
+	// restic once they're taken, so the backup doesn't depend on snapshots
+	// that only exist in the source cloud. Snapshots whose data is moved
+	// are deleted. Optional.
+	SnapshotMoveData bool `json:"snapshotMoveData,omitempty"`
+
 	// TTL is a time.Duration-parseable string describing how long
 	// the Backup should be retained for.
 	TTL metav1.Duration `json:"ttl"`
@@ -56,16 +76,131 @@ type BackupSpec struct {
 	// should be included for consideration in the backup.
 	IncludeClusterResources *bool `json:"includeClusterResources"`
 
+	// IncludedClusterResourceNames is a list of cluster-scoped items to
+	// include, each given as <resource>/<name>, e.g. clusterroles/admin.
+	// For each resource that has items in the list, only those items are
+	// included; other resources are unaffected. Names may contain
+	// wildcards. Naming an item includes its resource even when only
+	// specific namespaces are included, unless IncludeClusterResources
+	// is false. Optional.
+	IncludedClusterResourceNames []string `json:"includedClusterResourceNames,omitempty"`
+
+	// ExcludedClusterResourceNames is a list of cluster-scoped items to
+	// exclude, each given as <resource>/<name>. Optional.
+	ExcludedClusterResourceNames []string `json:"excludedClusterResourceNames,omitempty"`
+
+	// ResourcePolicy is the name of a ConfigMap, in the Velero namespace,
+	// of resource policies. Items that match the conditions of a policy
+	// whose action is skip aren't backed up. Optional.
+	ResourcePolicy string `json:"resourcePolicy,omitempty"`
+
 	// Hooks represent custom behaviors that should be executed at different phases of the backup.
 	Hooks BackupHooks `json:"hooks"`
 
 	// StorageLocation is a string containing the name of a BackupStorageLocation where the backup should be stored.
 	StorageLocation string `json:"storageLocation"`
 
+	// StoragePrefix is a sub-prefix, under the storage location's prefix,
+	// that the backup is stored under, so that the backups of teams that
+	// share a bucket can be kept apart by the object store's access
+	// policies. It's one or more path segments, e.g. "team-a/prod". Optional.
+	StoragePrefix string `json:"storagePrefix,omitempty"`
+
 	// VolumeSnapshotLocations is a list containing names of VolumeSnapshotLocations associated with this backup.
 	VolumeSnapshotLocations []string `json:"volumeSnapshotLocations"`
+
+	// ReplicaStorageLocations is a list containing names of additional BackupStorageLocations
+	// that the backup should be copied to once it has been stored in StorageLocation. Optional.
+	ReplicaStorageLocations []string `json:"replicaStorageLocations,omitempty"`
+
+	// FailoverStorageLocations is a prioritized list of names of
+	// BackupStorageLocations to store the backup in if it can't be stored
+	// in StorageLocation. They're tried in order, and the backup is stored
+	// in the first one that it can be stored in. Optional.
+	FailoverStorageLocations []string `json:"failoverStorageLocations,omitempty"`
+
+	// Mode specifies what the backup captures. If empty, defaults
+	// to Full. Optional.
+	Mode BackupMode `json:"mode,omitempty"`
+
+	// SkipUnchangedItems specifies whether items that haven't changed since
+	// the previous completed backup of the same schedule should be stored as
+	// references to that backup instead of being written to this backup's
+	// tarball. Only applies to backups created by a schedule. Optional.
+	SkipUnchangedItems bool `json:"skipUnchangedItems,omitempty"`
+
+	// PodVolumeFailurePolicy specifies what happens to the backup when a
+	// pod volume can't be backed up with restic. If empty, defaults to
+	// PartiallyFail. Optional.
+	PodVolumeFailurePolicy PodVolumeFailurePolicy `json:"podVolumeFailurePolicy,omitempty"`
+
+	// DeleteProtection specifies whether the backup is protected from
+	// deletion. Requests to delete a protected backup are refused, and it
+	// isn't garbage-collected when it expires, until this is set to false.
+	// If the backup storage location's object store supports object lock,
+	// the backup's files are also locked until the backup expires. Optional.
+	DeleteProtection bool `json:"deleteProtection,omitempty"`
+
+	// LogLevel is the level at which the backup is logged, e.g. "debug",
+	// overriding the server's log level for this backup only. Optional.
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// RetryPolicy specifies how many times the backup is attempted if it
+	// fails because the backup store or the Kubernetes API server is
+	// temporarily unavailable. If nil, the backup isn't retried. Optional.
+	RetryPolicy *BackupRetryPolicy `json:"retryPolicy,omitempty"`
+}
+
+// BackupRetryPolicy specifies how a backup that fails due to a transient
+// error is retried.
+type BackupRetryPolicy struct {
+	// MaxAttempts is the total number of times the backup is attempted,
+	// including the first attempt.
+	MaxAttempts int `json:"maxAttempts"`
+
+	// Backoff is how long to wait before the first retry. It's doubled
+	// for each retry after that, up to an hour. If zero, defaults to one
+	// minute.
+	Backoff metav1.Duration `json:"backoff,omitempty"`
 }
 
+// PodVolumeFailurePolicy is a string representation of what happens to
+// a Velero backup when a pod volume can't be backed up with restic.
+type PodVolumeFailurePolicy string
+
+const (
+	// PodVolumeFailurePolicyPartiallyFail means the rest of the backup
+	// continues, and the backup is marked PartiallyFailed.
+	PodVolumeFailurePolicyPartiallyFail PodVolumeFailurePolicy = "PartiallyFail"
+
+	// PodVolumeFailurePolicyFail means the rest of the backup continues,
+	// but the backup is marked Failed.
+	PodVolumeFailurePolicyFail PodVolumeFailurePolicy = "Fail"
+)
+
+// BackupMode is a string representation of what a Velero backup
+// captures.
+type BackupMode string
+
+const (
+	// BackupModeFull means the backup captures the Kubernetes resources
+	// and, using volume snapshots or restic, the data of their volumes.
+	BackupModeFull BackupMode = "Full"
+
+	// BackupModeObjectsOnly means the backup only captures the Kubernetes
+	// resources. No volume snapshots or restic backups are taken, and each
+	// persistent volume claim is recorded in the backup's volume coverage
+	// report as having had its data intentionally skipped.
+	BackupModeObjectsOnly BackupMode = "ObjectsOnly"
+
+	// BackupModeVolumeSnapshotOnly means the backup only takes volume
+	// snapshots of the persistent volumes bound to the selected persistent
+	// volume claims. No Kubernetes resources are serialized, so the backup
+	// can't be restored with Velero; it's meant to be scheduled frequently
+	// between full backups.
+	BackupModeVolumeSnapshotOnly BackupMode = "VolumeSnapshotOnly"
+)
+
 // BackupHooks contains custom behaviors that should be executed at different phases of the backup.
 type BackupHooks struct {
 	// Resources are hooks that should be executed when backing up individual instances of a resource.
@@ -104,6 +239,8 @@ type BackupResourceHook struct {
 }
 
 // ExecHook is a hook that uses the pod exec API to execute a command in a container in a pod.
+// When it's applied to a Deployment or StatefulSet, it's executed in the workload's pods chosen
+// by PodSelection.
 type ExecHook struct {
 	// Container is the container in the pod where the command should be executed. If not specified,
 	// the pod's first container is used.
@@ -115,8 +252,36 @@ type ExecHook struct {
 	// Timeout defines the maximum amount of time Velero should wait for the hook to complete before
 	// considering the execution a failure.
 	Timeout metav1.Duration `json:"timeout"`
+	// Commands are further commands to execute in the container, in order, after Command. Each
+	// command's OnError and Timeout default to the hook's.
+	Commands []ExecHookCommand `json:"commands,omitempty"`
+	// PodSelection specifies which of the pods of a Deployment or StatefulSet the hook is executed
+	// in. If empty, defaults to First.
+	PodSelection HookPodSelection `json:"podSelection,omitempty"`
+}
+
+// ExecHookCommand is one of the commands of an ExecHook.
+type ExecHookCommand struct {
+	// Command is the command and arguments to execute.
+	Command []string `json:"command"`
+	// OnError specifies how Velero should behave if it encounters an error executing this command.
+	// A failed command with the Continue mode doesn't stop the hook's later commands from executing.
+	OnError HookErrorMode `json:"onError,omitempty"`
+	// Timeout defines the maximum amount of time Velero should wait for the command to complete.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
 }
 
+// HookPodSelection defines which pods of a workload a hook is executed in.
+type HookPodSelection string
+
+const (
+	// HookPodSelectionFirst means the hook is executed in the workload's first running pod, by name,
+	// which is ordinal 0 of a StatefulSet.
+	HookPodSelectionFirst HookPodSelection = "First"
+	// HookPodSelectionAll means the hook is executed in each of the workload's running pods.
+	HookPodSelectionAll HookPodSelection = "All"
+)
+
 // HookErrorMode defines how Velero should treat an error from a hook.
 type HookErrorMode string
 
@@ -205,6 +370,159 @@ type BackupStatus struct {
 	// execution of the backup.  The actual errors are in the backup's log
 	// file in object storage.
 	Errors int `json:"errors"`
+
+	// Replicas records the status of copying the backup to each of the
+	// backup's ReplicaStorageLocations.
+	Replicas []BackupReplicaStatus `json:"replicas,omitempty"`
+
+	// ReferencedBackups is the list of earlier backups whose tarballs
+	// contain unchanged items that this backup refers to instead of
+	// storing them itself. These backups are needed to restore this one.
+	ReferencedBackups []string `json:"referencedBackups,omitempty"`
+
+	// FailedPodVolumes is the list of pod volumes, as
+	// <namespace>/<pod>/<volume>, that couldn't be backed up with restic.
+	FailedPodVolumes []string `json:"failedPodVolumes,omitempty"`
+
+	// PluginCrashes describes each time a plugin process crashed during
+	// the backup, as <plugin command>: <cause>. Crashed plugin processes
+	// are restarted, but the calls they were handling failed.
+	PluginCrashes []string `json:"pluginCrashes,omitempty"`
+
+	// Progress is how far along the backup is. It's updated while the
+	// backup is in progress.
+	Progress *BackupProgress `json:"progress,omitempty"`
+
+	// StorageLocationFailover records that the backup couldn't be stored
+	// in the storage location its spec named, and which of its failover
+	// storage locations it was stored in instead.
+	StorageLocationFailover *StorageLocationFailover `json:"storageLocationFailover,omitempty"`
+
+	// ObjectLockedUntil is when the lock on the backup's files in object
+	// storage expires, for a delete-protected backup whose files were
+	// locked.
+	ObjectLockedUntil *metav1.Time `json:"objectLockedUntil,omitempty"`
+
+	// FailureReason is an error that caused the entire backup to fail.
+	FailureReason string `json:"failureReason,omitempty"`
+
+	// Conditions are the latest observations of the backup's state, e.g.
+	// whether it has completed and been uploaded to object storage.
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// StorageUsage is how much storage the backup takes up in its backup
+	// storage location. It's recorded when the backup is uploaded.
+	StorageUsage *BackupStorageUsage `json:"storageUsage,omitempty"`
+
+	// Attempts records the earlier attempts of a backup that's been
+	// retried under its retry policy, oldest first.
+	Attempts []BackupAttempt `json:"attempts,omitempty"`
+
+	// NextAttempt is when a backup that's waiting to be retried will be
+	// attempted again.
+	NextAttempt *metav1.Time `json:"nextAttempt,omitempty"`
+}
+
+// BackupAttempt records a failed attempt of a backup.
+type BackupAttempt struct {
+	// StartTimestamp records when the attempt was started.
+	StartTimestamp metav1.Time `json:"startTimestamp"`
+
+	// CompletionTimestamp records when the attempt failed.
+	CompletionTimestamp metav1.Time `json:"completionTimestamp"`
+
+	// FailureReason is the error that caused the attempt to fail.
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+// BackupStorageUsage records the sizes of the files a backup uploaded to
+// its backup storage location, and of the data its pod volumes added to
+// their restic repositories.
+type BackupStorageUsage struct {
+	// TotalBytes is the total size of the backup's files, other than its
+	// metadata file, and of the data its pod volumes added.
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+
+	// TarballBytes is the size of the backup's tarball of Kubernetes
+	// resources.
+	TarballBytes int64 `json:"tarballBytes,omitempty"`
+
+	// PodVolumes are the sizes of the data that each pod volume backed up
+	// with restic added to its restic repository.
+	PodVolumes []PodVolumeStorageUsage `json:"podVolumes,omitempty"`
+}
+
+// PodVolumeStorageUsage is the size of the data that a pod volume's backup
+// added to its restic repository.
+type PodVolumeStorageUsage struct {
+	// Name identifies the pod volume, as <namespace>/<pod>/<volume>.
+	Name string `json:"name"`
+
+	// Bytes is the size of the data that the backup added.
+	Bytes int64 `json:"bytes"`
+}
+
+// StorageLocationFailover records that a backup was stored in one of its
+// FailoverStorageLocations. The backup's StorageLocation is changed to the
+// failover location, so that the backup is found there.
+type StorageLocationFailover struct {
+	// From is the name of the storage location the backup's spec named,
+	// which the backup couldn't be stored in.
+	From string `json:"from"`
+
+	// To is the name of the failover storage location the backup was
+	// stored in.
+	To string `json:"to"`
+}
+
+// BackupProgress stores information about the progress of a Backup's
+// execution.
+type BackupProgress struct {
+	// TotalItems is the number of items that the backup has found to back
+	// up so far. Items are found as each resource is listed, so it grows
+	// until the backup has listed every resource.
+	TotalItems int `json:"totalItems,omitempty"`
+
+	// ItemsBackedUp is the number of items that have been backed up so far.
+	ItemsBackedUp int `json:"itemsBackedUp,omitempty"`
+}
+
+// BackupReplicaPhase is a string representation of the lifecycle phase
+// of a copy of a Velero backup in a replica storage location.
+type BackupReplicaPhase string
+
+const (
+	// BackupReplicaPhaseInProgress means the backup is currently being
+	// copied to the replica storage location.
+	BackupReplicaPhaseInProgress BackupReplicaPhase = "InProgress"
+
+	// BackupReplicaPhaseCompleted means the backup has been copied to the
+	// replica storage location and can be restored from it.
+	BackupReplicaPhaseCompleted BackupReplicaPhase = "Completed"
+
+	// BackupReplicaPhaseFailed means the backup could not be copied to the
+	// replica storage location.
+	BackupReplicaPhaseFailed BackupReplicaPhase = "Failed"
+)
+
+// BackupReplicaStatus captures the status of a copy of a Velero backup in
+// a replica storage location.
+type BackupReplicaStatus struct {
+	// StorageLocation is the name of the replica BackupStorageLocation.
+	StorageLocation string `json:"storageLocation"`
+
+	// Phase is the current state of the copy.
+	Phase BackupReplicaPhase `json:"phase,omitempty"`
+
+	// Message is a human-readable explanation of a failed copy.
+	Message string `json:"message,omitempty"`
+
+	// CompletionTimestamp records the time the copy completed or failed.
+	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
+
+	// Attempts is the number of times the backup has been copied, or has
+	// failed to be copied, to the replica storage location.
+	Attempts int `json:"attempts,omitempty"`
 }
 
 // +genclient
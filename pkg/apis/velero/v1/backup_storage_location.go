@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -43,9 +44,24 @@ type BackupStorageLocationList struct {
 }
 
 // StorageType represents the type of storage that a backup location uses.
-// ObjectStorage must be non-nil, since it is currently the only supported StorageType.
+// Exactly one of ObjectStorage or Filesystem must be non-nil.
 type StorageType struct {
 	ObjectStorage *ObjectStorageLocation `json:"objectStorage,omitempty"`
+
+	// Filesystem is a directory on a filesystem mounted into the Velero
+	// server pod (e.g. a PVC or NFS share). Optional.
+	Filesystem *FilesystemLocation `json:"filesystem,omitempty"`
+}
+
+// FilesystemLocation specifies the settings necessary to store backups in a
+// directory on a mounted filesystem.
+type FilesystemLocation struct {
+	// Path is the absolute path of the directory, as mounted in the Velero
+	// server pod, to use for Velero storage.
+	Path string `json:"path"`
+
+	// Prefix is the path inside the directory to use for Velero storage. Optional.
+	Prefix string `json:"prefix"`
 }
 
 // ObjectStorageLocation specifies the settings necessary to connect to a provider's object storage.
@@ -67,8 +83,80 @@ type BackupStorageLocationSpec struct {
 
 	StorageType `json:",inline"`
 
+	// Credential is a key in a secret, in the Velero namespace, with the
+	// credentials to use for the location's object storage instead of the
+	// provider's default credentials, so that different locations of the
+	// same provider can use different accounts. The key's value has the
+	// same format as the provider's credentials file. Optional.
+	Credential *corev1api.SecretKeySelector `json:"credential,omitempty"`
+
 	// AccessMode defines the permissions for the backup storage location.
 	AccessMode BackupStorageLocationAccessMode `json:"accessMode,omitempty"`
+
+	// Deduplication, if true, stores the contents of new backups as chunks
+	// that are shared between all of the location's backups, rather than as
+	// a tarball per backup.
+	Deduplication bool `json:"deduplication,omitempty"`
+
+	// RateLimit, if set, limits the rate of the requests that the Velero
+	// server makes to the location's object storage, across all of its
+	// controllers. Optional.
+	RateLimit *BackupStorageLocationRateLimit `json:"rateLimit,omitempty"`
+
+	// UploaderType is the uploader that backs up pod volumes into, and
+	// restores them from, the location. If empty, defaults to restic.
+	// Optional.
+	UploaderType UploaderType `json:"uploaderType,omitempty"`
+
+	// SyncPeriod is how often the location is checked for backups to sync
+	// into the cluster. If not set, the server's --backup-sync-period is
+	// used. Periods shorter than a minute are treated as a minute. Optional.
+	SyncPeriod *metav1.Duration `json:"syncPeriod,omitempty"`
+
+	// Standby, if set, keeps a warm copy of the location's backups in the
+	// cluster by restoring each new backup that's synced from the location
+	// into standby namespaces. The location must be read-only. Optional.
+	Standby *BackupStorageLocationStandby `json:"standby,omitempty"`
+}
+
+// BackupStorageLocationStandby selects the backups of a read-only backup
+// storage location that are restored into standby namespaces, for example
+// in a secondary cluster kept ready for disaster recovery.
+type BackupStorageLocationStandby struct {
+	// BackupSelector selects the backups that are restored. If empty, all
+	// of the location's backups are. Optional.
+	BackupSelector *metav1.LabelSelector `json:"backupSelector,omitempty"`
+
+	// NamespacePrefix is prepended to the name of each namespace that's
+	// restored. If empty, namespaces are restored under their own names.
+	// Optional.
+	NamespacePrefix string `json:"namespacePrefix,omitempty"`
+}
+
+// UploaderType is the tool that backs up pod volumes into a backup storage
+// location, and restores them from it.
+type UploaderType string
+
+const (
+	// UploaderTypeRestic backs up pod volumes with restic.
+	UploaderTypeRestic UploaderType = "restic"
+
+	// UploaderTypeKopia backs up pod volumes with kopia.
+	UploaderTypeKopia UploaderType = "kopia"
+)
+
+// BackupStorageLocationRateLimit limits the rate of requests to a backup
+// storage location's object storage with a token bucket, which fills at
+// RequestsPerSecond and holds up to Burst requests.
+type BackupStorageLocationRateLimit struct {
+	// RequestsPerSecond is the average number of requests per second that
+	// can be made. It must be greater than zero.
+	RequestsPerSecond int `json:"requestsPerSecond"`
+
+	// Burst is the number of requests that can be made at once, after no
+	// requests have been made for a while. If zero, it defaults to
+	// RequestsPerSecond. Optional.
+	Burst int `json:"burst,omitempty"`
 }
 
 // BackupStorageLocationPhase is the lifecyle phase of a Velero BackupStorageLocation.
@@ -101,6 +189,22 @@ type BackupStorageLocationStatus struct {
 	LastSyncedRevision types.UID                  `json:"lastSyncedRevision,omitempty"`
 	LastSyncedTime     metav1.Time                `json:"lastSyncedTime,omitempty"`
 
+	// LastValidationTime is the last time the backup store was checked
+	// for availability.
+	LastValidationTime *metav1.Time `json:"lastValidationTime,omitempty"`
+
+	// Message is a human-readable explanation of why the location
+	// is unavailable.
+	Message string `json:"message,omitempty"`
+
+	// LastStandbyBackup is the name of the backup that was most recently
+	// restored into the location's standby namespaces.
+	LastStandbyBackup string `json:"lastStandbyBackup,omitempty"`
+
+	// LastStandbyRestoreTime is when the restore of LastStandbyBackup was
+	// created.
+	LastStandbyRestoreTime *metav1.Time `json:"lastStandbyRestoreTime,omitempty"`
+
 	// AccessMode is an unused field.
 	//
 	// Deprecated: there is now an AccessMode field on the Spec and this field
@@ -0,0 +1,141 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ComplianceReport is a report, generated periodically by the Velero
+// server, of whether each schedule's backups meet the schedule's
+// frequency and retention requirements.
+type ComplianceReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   ComplianceReportSpec   `json:"spec"`
+	Status ComplianceReportStatus `json:"status,omitempty"`
+}
+
+// ComplianceReportSpec is the specification for a ComplianceReport.
+type ComplianceReportSpec struct {
+}
+
+// ComplianceReportStatus is the content of a ComplianceReport.
+type ComplianceReportStatus struct {
+	// GenerationTimestamp is when the report was generated.
+	GenerationTimestamp metav1.Time `json:"generationTimestamp"`
+
+	// Compliant is true if all of the schedules are compliant.
+	Compliant bool `json:"compliant"`
+
+	// Schedules is the report for each schedule.
+	Schedules []ScheduleCompliance `json:"schedules"`
+}
+
+// BackupVerificationPhase is whether a schedule's newest backup was
+// found in backup storage.
+type BackupVerificationPhase string
+
+const (
+	// BackupVerificationPhaseVerified means the backup was found in its
+	// backup storage location.
+	BackupVerificationPhaseVerified BackupVerificationPhase = "Verified"
+
+	// BackupVerificationPhaseNotFound means the backup wasn't found in
+	// its backup storage location.
+	BackupVerificationPhaseNotFound BackupVerificationPhase = "NotFound"
+
+	// BackupVerificationPhaseUnverified means the backup storage location
+	// couldn't be checked, e.g. because it's unavailable.
+	BackupVerificationPhaseUnverified BackupVerificationPhase = "Unverified"
+)
+
+// ScheduleCompliance is the part of a ComplianceReport about one schedule.
+type ScheduleCompliance struct {
+	// Schedule is the name of the schedule.
+	Schedule string `json:"schedule"`
+
+	// CronSchedule is the schedule's Cron expression.
+	CronSchedule string `json:"cronSchedule"`
+
+	// RequiredRetention is how long the schedule's backups must be kept,
+	// which is the TTL of its backups.
+	RequiredRetention metav1.Duration `json:"requiredRetention"`
+
+	// CompletedBackups is the number of the schedule's backups that
+	// completed.
+	CompletedBackups int `json:"completedBackups"`
+
+	// OldestBackup is the schedule's oldest completed backup.
+	// +optional
+	OldestBackup *ComplianceBackupReference `json:"oldestBackup,omitempty"`
+
+	// NewestBackup is the schedule's newest completed backup.
+	// +optional
+	NewestBackup *ComplianceBackupReference `json:"newestBackup,omitempty"`
+
+	// Verification is whether the newest backup was found in its backup
+	// storage location.
+	// +optional
+	Verification BackupVerificationPhase `json:"verification,omitempty"`
+
+	// VerificationMessage explains why the newest backup couldn't be
+	// verified.
+	// +optional
+	VerificationMessage string `json:"verificationMessage,omitempty"`
+
+	// FailedBackups are the names of the schedule's backups from within
+	// its required retention that failed or partially failed.
+	// +optional
+	FailedBackups []string `json:"failedBackups,omitempty"`
+
+	// Compliant is true if the schedule has no problems.
+	Compliant bool `json:"compliant"`
+
+	// Problems are the reasons that the schedule isn't compliant.
+	// +optional
+	Problems []string `json:"problems,omitempty"`
+}
+
+// ComplianceBackupReference identifies a backup in a ComplianceReport.
+type ComplianceBackupReference struct {
+	// Name is the name of the backup.
+	Name string `json:"name"`
+
+	// StartTimestamp is when the backup started.
+	StartTimestamp metav1.Time `json:"startTimestamp"`
+
+	// Expiration is when the backup is eligible for garbage collection.
+	// +optional
+	Expiration metav1.Time `json:"expiration,omitempty"`
+
+	// StorageLocation is the backup storage location of the backup.
+	StorageLocation string `json:"storageLocation"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ComplianceReportList is a list of ComplianceReports.
+type ComplianceReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ComplianceReport `json:"items"`
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a condition of a Backup or Restore.
+type ConditionType string
+
+const (
+	// ConditionCompleted is True once the backup or restore has finished
+	// running, whether or not it succeeded, and False while it's running.
+	// Its reason is the backup's or restore's phase.
+	ConditionCompleted ConditionType = "Completed"
+
+	// ConditionFailed is True if the backup or restore failed or failed
+	// validation, with the failure reason as its message, and False if it
+	// completed or partially failed.
+	ConditionFailed ConditionType = "Failed"
+
+	// ConditionStoreUploaded is True once a backup's contents and log, or
+	// a restore's log and results, have been uploaded to the backup
+	// storage location, and False if they couldn't be.
+	ConditionStoreUploaded ConditionType = "StoreUploaded"
+
+	// ConditionVerified is True once a backup has been verified by
+	// restoring part of it, and False, with the reason VerificationFailed,
+	// if the restore didn't complete. It's Unknown while the restore runs.
+	// Only the backups of schedules with a verification policy have it.
+	ConditionVerified ConditionType = "Verified"
+)
+
+// Condition is an observation of the state of a Backup or Restore, in the
+// format used by Kubernetes objects, so that tools like `kubectl wait` can
+// wait for it.
+type Condition struct {
+	// Type is the type of the condition.
+	Type ConditionType `json:"type"`
+
+	// Status is True, False or Unknown.
+	Status corev1api.ConditionStatus `json:"status"`
+
+	// LastTransitionTime is when the condition last changed status.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason is a CamelCase reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human-readable explanation of the condition.
+	Message string `json:"message,omitempty"`
+}
@@ -22,18 +22,28 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 type DownloadRequestSpec struct {
 	// Target is what to download (e.g. logs for a backup).
 	Target DownloadTarget `json:"target"`
+
+	// TTL is how long the download URL is valid for. If not specified,
+	// the server's default is used. Optional.
+	TTL metav1.Duration `json:"ttl,omitempty"`
 }
 
 // DownloadTargetKind represents what type of file to download.
 type DownloadTargetKind string
 
 const (
-	DownloadTargetKindBackupLog             DownloadTargetKind = "BackupLog"
-	DownloadTargetKindBackupContents        DownloadTargetKind = "BackupContents"
-	DownloadTargetKindBackupVolumeSnapshots DownloadTargetKind = "BackupVolumeSnapshots"
-	DownloadTargetKindBackupResourceList    DownloadTargetKind = "BackupResourceList"
-	DownloadTargetKindRestoreLog            DownloadTargetKind = "RestoreLog"
-	DownloadTargetKindRestoreResults        DownloadTargetKind = "RestoreResults"
+	DownloadTargetKindBackupMetadata         DownloadTargetKind = "BackupMetadata"
+	DownloadTargetKindBackupLog              DownloadTargetKind = "BackupLog"
+	DownloadTargetKindBackupStructuredLog    DownloadTargetKind = "BackupStructuredLog"
+	DownloadTargetKindBackupContents         DownloadTargetKind = "BackupContents"
+	DownloadTargetKindBackupVolumeSnapshots  DownloadTargetKind = "BackupVolumeSnapshots"
+	DownloadTargetKindBackupPodVolumeBackups DownloadTargetKind = "BackupPodVolumeBackups"
+	DownloadTargetKindBackupResourceList     DownloadTargetKind = "BackupResourceList"
+	DownloadTargetKindBackupVolumeCoverage   DownloadTargetKind = "BackupVolumeCoverage"
+	DownloadTargetKindRestoreLog             DownloadTargetKind = "RestoreLog"
+	DownloadTargetKindRestoreStructuredLog   DownloadTargetKind = "RestoreStructuredLog"
+	DownloadTargetKindRestoreResults         DownloadTargetKind = "RestoreResults"
+	DownloadTargetKindRestoreManifest        DownloadTargetKind = "RestoreManifest"
 )
 
 // DownloadTarget is the specification for what kind of file to download, and the name of the
@@ -63,6 +73,12 @@ type DownloadRequestStatus struct {
 	Phase DownloadRequestPhase `json:"phase"`
 	// DownloadURL contains the pre-signed URL for the target file.
 	DownloadURL string `json:"downloadURL"`
+	// URLExpiration is when DownloadURL expires. The URL is regenerated
+	// shortly before it expires for as long as the DownloadRequest exists.
+	URLExpiration metav1.Time `json:"urlExpiration,omitempty"`
+	// URLsIssued is the number of download URLs that have been generated
+	// for this DownloadRequest.
+	URLsIssued int `json:"urlsIssued,omitempty"`
 	// Expiration is when this DownloadRequest expires and can be deleted by the system.
 	Expiration metav1.Time `json:"expiration"`
 }
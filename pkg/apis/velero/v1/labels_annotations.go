@@ -29,6 +29,10 @@ const (
 	// ScheduleNameLabel is the label key used to identify a schedule by name.
 	ScheduleNameLabel = "velero.io/schedule-name"
 
+	// VerifiedBackupLabel is the label key used to identify the restores
+	// that verify a backup, by the backup's name.
+	VerifiedBackupLabel = "velero.io/verified-backup"
+
 	// RestoreUIDLabel is the label key used to identify a restore by uid.
 	RestoreUIDLabel = "velero.io/restore-uid"
 
@@ -44,7 +48,31 @@ const (
 	// location of a backup.
 	StorageLocationLabel = "velero.io/storage-location"
 
+	// StandbyLocationLabel is the label key used to identify the restores
+	// that keep a backup storage location's standby namespaces up to date,
+	// by the location's name.
+	StandbyLocationLabel = "velero.io/standby-location"
+
 	// ResticVolumeNamespaceLabel is the label key used to identify which
 	// namespace a restic repository stores pod volume backups for.
 	ResticVolumeNamespaceLabel = "velero.io/volume-namespace"
+
+	// ResticRepositoryUnlockAnnotation is the annotation key used to request
+	// that the Velero server unlocks a restic repository. Its value is a
+	// ResticRepositoryUnlockMode, and it's removed once the repository has
+	// been unlocked.
+	ResticRepositoryUnlockAnnotation = "velero.io/unlock"
+
+	// BackupStorageLocationSyncAnnotation is the annotation key used to
+	// request that the Velero server syncs a backup storage location's
+	// backups into the cluster right away, even if its contents haven't
+	// changed. Its value is the time of the request, and it's removed once
+	// the location has been synced.
+	BackupStorageLocationSyncAnnotation = "velero.io/sync"
+
+	// KMSEnvelopeAnnotation is the annotation key used to identify a
+	// credential secret whose values are KMS envelopes, which the Velero
+	// server decrypts with a key management service before giving them to
+	// plugins. Its value is "true".
+	KMSEnvelopeAnnotation = "velero.io/kms-envelope"
 )
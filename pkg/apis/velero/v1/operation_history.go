@@ -0,0 +1,114 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OperationHistory is a compact record, kept by the Velero server, of the
+// backups and restores that finished in a calendar month. It's kept after
+// the backups and restores themselves are deleted.
+type OperationHistory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   OperationHistorySpec   `json:"spec"`
+	Status OperationHistoryStatus `json:"status,omitempty"`
+}
+
+// OperationHistorySpec is the specification for an OperationHistory.
+type OperationHistorySpec struct {
+	// Month is the calendar month, in the form YYYY-MM, of the operations
+	// in the history.
+	Month string `json:"month"`
+}
+
+// OperationHistoryStatus is the content of an OperationHistory.
+type OperationHistoryStatus struct {
+	// Operations are the backups and restores that started in the month,
+	// oldest first.
+	// +optional
+	Operations []OperationRecord `json:"operations,omitempty"`
+
+	// DroppedOperations is how many of the month's oldest operations were
+	// removed from the history to keep it within its maximum size.
+	// +optional
+	DroppedOperations int `json:"droppedOperations,omitempty"`
+}
+
+// OperationKind is the kind of operation in an OperationHistory.
+type OperationKind string
+
+const (
+	// OperationKindBackup means the operation is a backup.
+	OperationKindBackup OperationKind = "Backup"
+
+	// OperationKindRestore means the operation is a restore.
+	OperationKindRestore OperationKind = "Restore"
+)
+
+// OperationRecord is a summary of a finished backup or restore.
+type OperationRecord struct {
+	// Kind is whether the operation is a backup or a restore.
+	Kind OperationKind `json:"kind"`
+
+	// Name is the name of the backup or restore.
+	Name string `json:"name"`
+
+	// Phase is the phase the backup or restore finished in.
+	Phase string `json:"phase"`
+
+	// StartTimestamp is when the backup started, or when the restore was
+	// created.
+	StartTimestamp metav1.Time `json:"startTimestamp"`
+
+	// CompletionTimestamp is when the backup completed. It's not set for
+	// restores.
+	// +optional
+	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
+
+	// Schedule is the name of the schedule that created the backup.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// Backup is the name of the backup that the restore restored from.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+
+	// StorageLocation is the backup storage location of the backup.
+	// +optional
+	StorageLocation string `json:"storageLocation,omitempty"`
+
+	// Warnings is how many warnings the operation had.
+	Warnings int `json:"warnings"`
+
+	// Errors is how many errors the operation had.
+	Errors int `json:"errors"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OperationHistoryList is a list of OperationHistories.
+type OperationHistoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []OperationHistory `json:"items"`
+}
@@ -43,6 +43,15 @@ type PodVolumeBackupSpec struct {
 	// Tags are a map of key-value pairs that should be applied to the
 	// volume backup as tags.
 	Tags map[string]string `json:"tags"`
+
+	// UploaderType is the uploader that backs up the volume. If empty,
+	// defaults to restic.
+	UploaderType UploaderType `json:"uploaderType,omitempty"`
+
+	// VolumeMode is the mode of the volume. Block volumes are backed up
+	// by streaming the raw content of their device. If empty, defaults
+	// to Filesystem.
+	VolumeMode corev1api.PersistentVolumeMode `json:"volumeMode,omitempty"`
 }
 
 // PodVolumeBackupPhase represents the lifecycle phase of a PodVolumeBackup.
@@ -80,6 +89,43 @@ type PodVolumeBackupStatus struct {
 	// Completion time is recorded before uploading the backup object.
 	// The server's time is used for CompletionTimestamps
 	CompletionTimestamp metav1.Time `json:"completionTimestamp"`
+
+	// Attempts is the number of times restic was run to back up the
+	// volume, if it was retried after transient failures.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Progress holds the total number of bytes of the volume and the
+	// current number of backed up bytes. This can be used to display
+	// progress information about the backup operation.
+	Progress PodVolumeOperationProgress `json:"progress,omitempty"`
+
+	// ParentSnapshotID is the identifier of the snapshot of the volume's
+	// previous backup that the volume was backed up incrementally from.
+	// Empty if the volume was backed up in full.
+	ParentSnapshotID string `json:"parentSnapshotID,omitempty"`
+
+	// ParentPodVolumeBackup is the name of the PodVolumeBackup that took
+	// the parent snapshot.
+	ParentPodVolumeBackup string `json:"parentPodVolumeBackup,omitempty"`
+
+	// Changes holds how much of the volume changed since the parent
+	// snapshot, as reported by the uploader.
+	Changes PodVolumeBackupChanges `json:"changes,omitempty"`
+}
+
+// PodVolumeBackupChanges holds how much of a volume changed since the
+// previous backup of it.
+type PodVolumeBackupChanges struct {
+	// FilesChanged is the number of files that are new or were modified.
+	FilesChanged int `json:"filesChanged,omitempty"`
+
+	// FilesUnmodified is the number of files that weren't read again
+	// because they hadn't been modified.
+	FilesUnmodified int `json:"filesUnmodified,omitempty"`
+
+	// BytesAdded is the number of bytes of new data that were uploaded
+	// to the repository.
+	BytesAdded int64 `json:"bytesAdded,omitempty"`
 }
 
 // +genclient
@@ -0,0 +1,27 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// PodVolumeOperationProgress represents the progress of a
+// PodVolumeBackup/Restore (restic) operation
+type PodVolumeOperationProgress struct {
+	// TotalBytes is the number of bytes the operation has to transfer.
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+
+	// BytesDone is the number of bytes transferred so far.
+	BytesDone int64 `json:"bytesDone,omitempty"`
+}
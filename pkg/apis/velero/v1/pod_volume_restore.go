@@ -38,6 +38,14 @@ type PodVolumeRestoreSpec struct {
 
 	// SnapshotID is the ID of the volume snapshot to be restored.
 	SnapshotID string `json:"snapshotID"`
+
+	// UploaderType is the uploader that restores the volume, which must
+	// be the one that backed it up. If empty, defaults to restic.
+	UploaderType UploaderType `json:"uploaderType,omitempty"`
+
+	// VolumeMode is the mode of the volume, which must be the mode it
+	// was backed up from. If empty, defaults to Filesystem.
+	VolumeMode corev1api.PersistentVolumeMode `json:"volumeMode,omitempty"`
 }
 
 // PodVolumeRestorePhase represents the lifecycle phase of a PodVolumeRestore.
@@ -66,6 +74,11 @@ type PodVolumeRestoreStatus struct {
 	// Completion time is recorded even on failed restores.
 	// The server's time is used for CompletionTimestamps
 	CompletionTimestamp metav1.Time `json:"completionTimestamp"`
+
+	// Progress holds the total number of bytes of the snapshot and the
+	// current number of restored bytes. This can be used to display
+	// progress information about the restore operation.
+	Progress PodVolumeOperationProgress `json:"progress,omitempty"`
 }
 
 // +genclient
@@ -70,6 +70,8 @@ func CustomResources() map[string]typeInfo {
 		"BackupStorageLocation":  newTypeInfo("backupstoragelocations", &BackupStorageLocation{}, &BackupStorageLocationList{}),
 		"VolumeSnapshotLocation": newTypeInfo("volumesnapshotlocations", &VolumeSnapshotLocation{}, &VolumeSnapshotLocationList{}),
 		"ServerStatusRequest":    newTypeInfo("serverstatusrequests", &ServerStatusRequest{}, &ServerStatusRequestList{}),
+		"ComplianceReport":       newTypeInfo("compliancereports", &ComplianceReport{}, &ComplianceReportList{}),
+		"OperationHistory":       newTypeInfo("operationhistories", &OperationHistory{}, &OperationHistoryList{}),
 	}
 }
 
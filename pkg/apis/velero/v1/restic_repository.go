@@ -35,7 +35,14 @@ type ResticRepositorySpec struct {
 	ResticIdentifier string `json:"resticIdentifier"`
 
 	// MaintenanceFrequency is how often maintenance should be run.
+	// Maintenance prunes unused data from the repository (restic prune),
+	// and checks it for errors (restic check) before and after.
 	MaintenanceFrequency metav1.Duration `json:"maintenanceFrequency"`
+
+	// CheckFrequency is how often the repository should be checked for
+	// errors between maintenance runs. If zero, it's only checked as part
+	// of maintenance.
+	CheckFrequency metav1.Duration `json:"checkFrequency,omitempty"`
 }
 
 // ResticRepositoryPhase represents the lifecycle phase of a ResticRepository.
@@ -47,6 +54,15 @@ const (
 	ResticRepositoryPhaseNotReady ResticRepositoryPhase = "NotReady"
 )
 
+// ResticRepositoryCheckResult is the result of checking a restic
+// repository for errors.
+type ResticRepositoryCheckResult string
+
+const (
+	ResticRepositoryCheckResultPassed ResticRepositoryCheckResult = "Passed"
+	ResticRepositoryCheckResultFailed ResticRepositoryCheckResult = "Failed"
+)
+
 // ResticRepositoryStatus is the current status of a ResticRepository.
 type ResticRepositoryStatus struct {
 	// Phase is the current state of the ResticRepository.
@@ -57,8 +73,57 @@ type ResticRepositoryStatus struct {
 
 	// LastMaintenanceTime is the last time maintenance was run.
 	LastMaintenanceTime metav1.Time `json:"lastMaintenanceTime"`
+
+	// LastPruneTime is the last time unused data was successfully pruned
+	// from the repository.
+	LastPruneTime metav1.Time `json:"lastPruneTime,omitempty"`
+
+	// LastCheckTime is the last time the repository was checked for errors.
+	LastCheckTime metav1.Time `json:"lastCheckTime,omitempty"`
+
+	// LastCheckResult is the result of the last check of the repository.
+	// If the check failed, Message has its error.
+	LastCheckResult ResticRepositoryCheckResult `json:"lastCheckResult,omitempty"`
+
+	// Locks are the locks that were held on the repository when it was
+	// last checked, after stale locks were removed. Locks that are held
+	// for a long time can block maintenance and backups.
+	Locks []ResticRepositoryLock `json:"locks,omitempty"`
+}
+
+// ResticRepositoryLock is a lock held on a restic repository.
+type ResticRepositoryLock struct {
+	// ID is restic's ID for the lock.
+	ID string `json:"id"`
+
+	// Exclusive is whether the lock is an exclusive lock, which is held
+	// by maintenance operations, rather than a shared one.
+	Exclusive bool `json:"exclusive"`
+
+	// Hostname is the host of the restic process holding the lock.
+	Hostname string `json:"hostname"`
+
+	// PID is the process ID of the restic process holding the lock.
+	PID int `json:"pid"`
+
+	// Time is when the lock was created or last refreshed.
+	Time metav1.Time `json:"time"`
 }
 
+// ResticRepositoryUnlockMode is how the locks on a restic repository are
+// removed when unlocking it is requested.
+type ResticRepositoryUnlockMode string
+
+const (
+	// ResticRepositoryUnlockModeStale removes the locks that restic
+	// considers stale.
+	ResticRepositoryUnlockModeStale ResticRepositoryUnlockMode = "Stale"
+
+	// ResticRepositoryUnlockModeAll removes all of the locks, including
+	// the ones held by running restic processes.
+	ResticRepositoryUnlockModeAll ResticRepositoryUnlockMode = "All"
+)
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
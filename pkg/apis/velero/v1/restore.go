@@ -16,7 +16,11 @@ limitations under the License.
 
 package v1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
 
 // RestoreSpec defines the specification for a Velero restore.
 type RestoreSpec struct {
@@ -29,6 +33,30 @@ type RestoreSpec struct {
 	// from the most recent successful backup created from this schedule.
 	ScheduleName string `json:"scheduleName,omitempty"`
 
+	// BackupStorageLocation is the name of the BackupStorageLocation to
+	// read the backup from. It must be the backup's own storage location
+	// or one of its completed replicas. If empty, the backup's own storage
+	// location is used, falling back to a completed replica if that
+	// location no longer exists. Optional.
+	BackupStorageLocation string `json:"backupStorageLocation,omitempty"`
+
+	// BackupVersion is the version ID of the backup's metadata file to
+	// restore from, in a backup storage location whose bucket has
+	// versioning enabled. The backup's files are read as they were when
+	// that version was current, so a backup that was later overwritten or
+	// deleted can be restored. If the backup no longer exists in the
+	// cluster, BackupStorageLocation must be set. Optional.
+	BackupVersion string `json:"backupVersion,omitempty"`
+
+	// Credential is a key in a secret, in the Velero namespace, with
+	// credentials to read the backup with instead of the backup storage
+	// location's usual credentials, e.g. read-only break-glass credentials
+	// for when the usual ones have been rotated or compromised. The key's
+	// value has the same format as the location's usual credentials file.
+	// The restore's log and results are still uploaded with the location's
+	// usual credentials. Optional.
+	Credential *corev1api.SecretKeySelector `json:"credential,omitempty"`
+
 	// IncludedNamespaces is a slice of namespace names to include objects
 	// from. If empty, all namespaces are included.
 	IncludedNamespaces []string `json:"includedNamespaces"`
@@ -51,6 +79,12 @@ type RestoreSpec struct {
 	// namespaces of the same name.
 	NamespaceMapping map[string]string `json:"namespaceMapping"`
 
+	// StorageClassMapping is a map of source storage class names to
+	// target storage class names for the persistent volumes and claims
+	// that are restored. Storage classes not included in the map are
+	// left as is. Optional.
+	StorageClassMapping map[string]string `json:"storageClassMapping,omitempty"`
+
 	// LabelSelector is a metav1.LabelSelector to filter with
 	// when restoring individual objects from the backup. If empty
 	// or nil, all objects are included. Optional.
@@ -64,8 +98,268 @@ type RestoreSpec struct {
 	// should be included for consideration in the restore. If null, defaults
 	// to true.
 	IncludeClusterResources *bool `json:"includeClusterResources,omitempty"`
+
+	// IncludedClusterResourceNames is a list of cluster-scoped items to
+	// include, each given as <resource>/<name>, e.g. clusterroles/admin.
+	// For each resource that has items in the list, only those items are
+	// included; other resources are unaffected. Names may contain
+	// wildcards. Optional.
+	IncludedClusterResourceNames []string `json:"includedClusterResourceNames,omitempty"`
+
+	// ExcludedClusterResourceNames is a list of cluster-scoped items to
+	// exclude, each given as <resource>/<name>. Optional.
+	ExcludedClusterResourceNames []string `json:"excludedClusterResourceNames,omitempty"`
+
+	// ResourcePolicy is the name of a ConfigMap, in the Velero namespace,
+	// of resource policies. Items that match the conditions of a policy
+	// whose action is skip aren't restored. Optional.
+	ResourcePolicy string `json:"resourcePolicy,omitempty"`
+
+	// Mode specifies what is restored from the backup. If empty,
+	// defaults to Full. Optional.
+	Mode RestoreMode `json:"mode,omitempty"`
+
+	// ControlPlaneConfigPolicy specifies whether API priority and
+	// fairness and admission webhook configuration in the backup is
+	// restored. If empty, defaults to Skip. Optional.
+	ControlPlaneConfigPolicy ControlPlaneConfigPolicy `json:"controlPlaneConfigPolicy,omitempty"`
+
+	// ExtractionPolicy specifies how much of the backup's tarball is
+	// extracted to disk for the restore. If empty, defaults to Full.
+	// Optional.
+	ExtractionPolicy RestoreExtractionPolicy `json:"extractionPolicy,omitempty"`
+
+	// ResourceModifiers are rules for changing items from the backup,
+	// such as their storage classes or image registries, before they're
+	// restored. Optional.
+	ResourceModifiers []RestoreResourceModifier `json:"resourceModifiers,omitempty"`
+
+	// ResourcePriorities is the ordered list of resources, e.g.
+	// "customresourcedefinitions" or "foos.example.com", to restore
+	// before all others, overriding the server's restore resource
+	// priorities. Resources that aren't known to the cluster when the
+	// restore starts, such as custom resources whose definitions are in
+	// the backup, are ordered once their definitions are restored and
+	// WaitForReady is set, and are otherwise skipped. Optional.
+	ResourcePriorities []string `json:"resourcePriorities,omitempty"`
+
+	// WaitForReady specifies whether, before restoring the next resource,
+	// the restore waits for the restored custom resource definitions to
+	// be established and the restored persistent volume claims to be
+	// bound, so that the resources that depend on them can be restored.
+	// Optional.
+	WaitForReady bool `json:"waitForReady,omitempty"`
+
+	// ReadyTimeout is how long the restore waits for each resource's
+	// restored items to be ready when WaitForReady is set. Items that
+	// aren't ready in time are recorded as warnings. If zero, defaults
+	// to 10 minutes. Optional.
+	ReadyTimeout metav1.Duration `json:"readyTimeout,omitempty"`
+
+	// DryRun specifies whether the restore only sends server-side dry-run
+	// creates, patches and applies for the items in the backup, without
+	// changing the cluster or restoring any volumes. What the restore would
+	// have created, skipped, or found in conflict with the cluster is
+	// stored in its results. Optional.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Hooks are custom behaviors that are executed for the restored
+	// pods, such as init containers that prepare the pods' volumes and
+	// commands that run in the pods once they're running. Optional.
+	Hooks RestoreHooks `json:"hooks,omitempty"`
+
+	// LogLevel is the level at which the restore is logged, e.g. "debug",
+	// overriding the server's log level for this restore only. Optional.
+	LogLevel string `json:"logLevel,omitempty"`
+}
+
+// RestoreHooks contains custom behaviors that should be executed for the
+// restored pods.
+type RestoreHooks struct {
+	// Resources are the hooks that apply to the restored pods that match
+	// them.
+	Resources []RestoreResourceHookSpec `json:"resources,omitempty"`
+}
+
+// RestoreResourceHookSpec defines one or more RestoreResourceHooks that
+// are executed for the restored pods that match its namespaces, resources
+// and label selector.
+type RestoreResourceHookSpec struct {
+	// Name is the name of this hook.
+	Name string `json:"name"`
+
+	// IncludedNamespaces are the namespaces, in the backup, of the pods
+	// the hooks apply to. If empty, they apply to pods in all namespaces.
+	// Optional.
+	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
+
+	// ExcludedNamespaces are the namespaces, in the backup, of the pods
+	// the hooks don't apply to. Optional.
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+
+	// IncludedResources are the resources the hooks apply to. If empty,
+	// they apply to all resources. Hooks are only executed for pods.
+	// Optional.
+	IncludedResources []string `json:"includedResources,omitempty"`
+
+	// ExcludedResources are the resources the hooks don't apply to.
+	// Optional.
+	ExcludedResources []string `json:"excludedResources,omitempty"`
+
+	// LabelSelector, if specified, filters the pods the hooks apply to.
+	// Optional.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// PostHooks are the hooks executed for each matching pod after it's
+	// restored.
+	PostHooks []RestoreResourceHook `json:"postHooks,omitempty"`
 }
 
+// RestoreResourceHook defines a restore hook for a pod. Exactly one of
+// Exec and Init is set.
+type RestoreResourceHook struct {
+	// Exec defines a command that's executed in a container of the pod
+	// once the container is running.
+	Exec *ExecRestoreHook `json:"exec,omitempty"`
+
+	// Init defines init containers that are added to the pod before it's
+	// created.
+	Init *InitRestoreHook `json:"init,omitempty"`
+}
+
+// ExecRestoreHook is a hook that uses the pod exec API to execute a
+// command in a container of a restored pod.
+type ExecRestoreHook struct {
+	// Container is the container in the pod where the command should be
+	// executed. If not specified, the pod's first container is used.
+	Container string `json:"container,omitempty"`
+
+	// Command is the command and arguments to execute.
+	Command []string `json:"command"`
+
+	// OnError specifies how Velero should behave if it encounters an
+	// error executing this hook. If Fail, the error is added to the
+	// restore's errors; if Continue, it's only logged. Defaults to Fail.
+	OnError HookErrorMode `json:"onError,omitempty"`
+
+	// ExecTimeout is how long Velero waits for the command to complete
+	// before considering the execution a failure. If zero, defaults to
+	// 30 seconds. Optional.
+	ExecTimeout metav1.Duration `json:"execTimeout,omitempty"`
+
+	// WaitTimeout is how long Velero waits for the container to be
+	// running before considering the execution a failure. If zero,
+	// defaults to 10 minutes. Optional.
+	WaitTimeout metav1.Duration `json:"waitTimeout,omitempty"`
+}
+
+// InitRestoreHook is a hook that adds init containers to a restored pod.
+type InitRestoreHook struct {
+	// InitContainers are the init containers to add. They run after
+	// Velero's restic restore init container, if the pod has one, and
+	// before the pod's own init containers.
+	InitContainers []corev1api.Container `json:"initContainers"`
+}
+
+// RestoreResourceModifier is a rule that changes the items from a backup
+// that match it with a JSON patch before they're restored.
+type RestoreResourceModifier struct {
+	// GroupResource is the resource of the items the rule applies to,
+	// e.g. "persistentvolumeclaims" or "deployments.apps".
+	GroupResource string `json:"groupResource"`
+
+	// Namespaces are the namespaces, in the backup, of the items the
+	// rule applies to. If empty, the rule applies to items in all
+	// namespaces and to cluster-scoped items. Optional.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Name is the name of the item the rule applies to. If empty, the
+	// rule applies to items with any name. Optional.
+	Name string `json:"name,omitempty"`
+
+	// LabelSelector is a metav1.LabelSelector to filter the items the
+	// rule applies to. If nil, the rule applies to items with any labels.
+	// Optional.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// Patches are the JSON patch (RFC 6902) operations applied to each
+	// item that matches the rule. Test operations are conditions: if any
+	// of them fails for an item, none of the rule's other operations are
+	// applied to it.
+	Patches []JSONPatchOperation `json:"patches"`
+}
+
+// JSONPatchOperation is an operation of a JSON patch (RFC 6902).
+type JSONPatchOperation struct {
+	// Operation is the operation to perform: add, remove, replace, move,
+	// copy or test.
+	Operation string `json:"op"`
+
+	// Path is the JSON pointer to the part of the item to operate on,
+	// e.g. "/spec/storageClassName".
+	Path string `json:"path"`
+
+	// From is the JSON pointer to the part of the item to move or copy
+	// from, for move and copy operations.
+	// +optional
+	From string `json:"from,omitempty"`
+
+	// Value is the value to add, replace or test, for add, replace and
+	// test operations.
+	// +optional
+	Value *runtime.RawExtension `json:"value,omitempty"`
+}
+
+// RestoreMode is a string representation of what a Velero restore
+// restores from its backup.
+type RestoreMode string
+
+const (
+	// RestoreModeFull means the restore creates the Kubernetes resources
+	// in the backup and restores their volume data.
+	RestoreModeFull RestoreMode = "Full"
+
+	// RestoreModeDataOnly means the restore doesn't create or modify any
+	// of the Kubernetes resources in the backup. Instead, the restic
+	// backups of persistent volume claims are restored into existing
+	// claims with the same name in the target namespace.
+	RestoreModeDataOnly RestoreMode = "DataOnly"
+)
+
+// ControlPlaneConfigPolicy is a string representation of whether a
+// Velero restore restores cluster control-plane configuration, i.e.
+// FlowSchemas, PriorityLevelConfigurations, and validating and
+// mutating webhook configurations. Restoring these can lock clients,
+// including Velero itself, out of the API server.
+type ControlPlaneConfigPolicy string
+
+const (
+	// ControlPlaneConfigPolicySkip means control-plane configuration in
+	// the backup is not restored, and a warning is recorded for each
+	// skipped resource.
+	ControlPlaneConfigPolicySkip ControlPlaneConfigPolicy = "Skip"
+
+	// ControlPlaneConfigPolicyRestore means control-plane configuration
+	// in the backup is restored, after all other resources.
+	ControlPlaneConfigPolicyRestore ControlPlaneConfigPolicy = "Restore"
+)
+
+// RestoreExtractionPolicy is a string representation of how much of a
+// backup's tarball a Velero restore extracts to disk.
+type RestoreExtractionPolicy string
+
+const (
+	// RestoreExtractionPolicyFull means the backup's tarball is downloaded
+	// and extracted in full before the restore starts.
+	RestoreExtractionPolicyFull RestoreExtractionPolicy = "Full"
+
+	// RestoreExtractionPolicySelective means the backup's tarball is
+	// streamed from backup storage, and only the items matching the
+	// restore's included and excluded resources and namespaces, and the
+	// backup's namespaces, are extracted.
+	RestoreExtractionPolicySelective RestoreExtractionPolicy = "Selective"
+)
+
 // RestorePhase is a string representation of the lifecycle phase
 // of a Velero restore
 type RestorePhase string
@@ -114,6 +408,16 @@ type RestoreStatus struct {
 
 	// FailureReason is an error that caused the entire restore to fail.
 	FailureReason string `json:"failureReason"`
+
+	// PluginCrashes describes each time a plugin process crashed during
+	// the restore, as <plugin command>: <cause>. Crashed plugin processes
+	// are restarted, but the calls they were handling failed.
+	PluginCrashes []string `json:"pluginCrashes,omitempty"`
+
+	// Conditions are the latest observations of the restore's state, e.g.
+	// whether it has completed and its results have been uploaded to
+	// object storage.
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -27,8 +27,104 @@ type ScheduleSpec struct {
 	// Schedule is a Cron expression defining when to run
 	// the Backup.
 	Schedule string `json:"schedule"`
+
+	// Schedules are additional Cron expressions defining when
+	// to run the Backup. The Backup is run whenever Schedule or
+	// any of Schedules is due. Optional.
+	Schedules []string `json:"schedules,omitempty"`
+
+	// TimeZone is the IANA time zone name, e.g. "Europe/Berlin",
+	// that the Cron expressions are evaluated in. If empty,
+	// defaults to UTC. Optional.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// OverlapPolicy specifies what happens when the schedule is due
+	// while the previous backup it created is still New or InProgress.
+	// If empty, defaults to Queue. Optional.
+	OverlapPolicy ScheduleOverlapPolicy `json:"overlapPolicy,omitempty"`
+
+	// Retention is the grandfather-father-son retention policy for
+	// the schedule's completed backups. If set, they're deleted when
+	// the policy no longer keeps them, rather than when their TTL
+	// expires. Optional.
+	Retention *ScheduleRetention `json:"retention,omitempty"`
+
+	// Verification specifies how the schedule's backups are verified,
+	// once they've completed, by restoring part of them. If nil, they
+	// aren't verified. Optional.
+	Verification *ScheduleVerification `json:"verification,omitempty"`
+}
+
+// ScheduleVerification specifies how a schedule's backups are verified.
+// Each backup that completes or partially fails is restored with the
+// given mode, and its Verified condition records whether the restore
+// completed.
+type ScheduleVerification struct {
+	// Mode is how the backup is restored. If empty, defaults to DryRun.
+	Mode ScheduleVerificationMode `json:"mode,omitempty"`
+
+	// SampleNamespaces is the number of the backup's namespaces, picked
+	// at random, that are restored. If zero, all of them are restored.
+	SampleNamespaces int `json:"sampleNamespaces,omitempty"`
+}
+
+// ScheduleVerificationMode is a string representation of how a schedule's
+// backups are restored to verify them.
+type ScheduleVerificationMode string
+
+const (
+	// ScheduleVerificationModeDryRun means the backup is restored with
+	// server-side dry-run requests, without changing the cluster.
+	ScheduleVerificationModeDryRun ScheduleVerificationMode = "DryRun"
+
+	// ScheduleVerificationModeScratchNamespace means the backup's
+	// namespaced items, other than pods and persistent volume claims, are
+	// restored into temporary namespaces, which are deleted once the
+	// restore has finished.
+	ScheduleVerificationModeScratchNamespace ScheduleVerificationMode = "ScratchNamespace"
+)
+
+// ScheduleRetention is a grandfather-father-son retention policy. Each
+// field keeps the newest completed backup of that many of the most
+// recent hours, days, weeks, months or years that have one, evaluated in
+// the schedule's time zone. A backup is kept if any of them keeps it.
+type ScheduleRetention struct {
+	// KeepHourly is the number of hourly backups to keep.
+	KeepHourly int `json:"keepHourly,omitempty"`
+
+	// KeepDaily is the number of daily backups to keep.
+	KeepDaily int `json:"keepDaily,omitempty"`
+
+	// KeepWeekly is the number of weekly backups to keep. Weeks
+	// start on Monday.
+	KeepWeekly int `json:"keepWeekly,omitempty"`
+
+	// KeepMonthly is the number of monthly backups to keep.
+	KeepMonthly int `json:"keepMonthly,omitempty"`
+
+	// KeepYearly is the number of yearly backups to keep.
+	KeepYearly int `json:"keepYearly,omitempty"`
 }
 
+// ScheduleOverlapPolicy is a string representation of what a Velero
+// schedule does when it's due while its previous backup is still running.
+type ScheduleOverlapPolicy string
+
+const (
+	// ScheduleOverlapPolicyQueue means the backup is created as soon as
+	// the previous backup finishes. Runs that are due in the meantime
+	// aren't caught up on, so at most one backup is waiting.
+	ScheduleOverlapPolicyQueue ScheduleOverlapPolicy = "Queue"
+
+	// ScheduleOverlapPolicySkip means the backup is skipped, and the
+	// schedule next runs at the following scheduled time.
+	ScheduleOverlapPolicySkip ScheduleOverlapPolicy = "Skip"
+
+	// ScheduleOverlapPolicyAllow means the backup is created regardless
+	// of the previous backup.
+	ScheduleOverlapPolicyAllow ScheduleOverlapPolicy = "Allow"
+)
+
 // SchedulePhase is a string representation of the lifecycle phase
 // of a Velero schedule
 type SchedulePhase string
@@ -56,6 +152,12 @@ type ScheduleStatus struct {
 	// Schedule schedule
 	LastBackup metav1.Time `json:"lastBackup"`
 
+	// LastSkipped is the last time the schedule was due but its backup
+	// was skipped, because the previous backup was still running and
+	// the overlap policy is Skip.
+	// +optional
+	LastSkipped *metav1.Time `json:"lastSkipped,omitempty"`
+
 	// ValidationErrors is a slice of all validation errors (if
 	// applicable)
 	ValidationErrors []string `json:"validationErrors"`
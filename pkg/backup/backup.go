@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -33,10 +34,13 @@ import (
 
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/csi"
 	"github.com/heptio/velero/pkg/discovery"
+	"github.com/heptio/velero/pkg/kuberesource"
+	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/velero"
 	"github.com/heptio/velero/pkg/podexec"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/util/collections"
 )
 
@@ -56,8 +60,11 @@ type kubernetesBackupper struct {
 	discoveryHelper        discovery.Helper
 	podCommandExecutor     podexec.PodCommandExecutor
 	groupBackupperFactory  groupBackupperFactory
-	resticBackupperFactory restic.BackupperFactory
+	resticBackupperFactory podvolume.BackupperFactory
 	resticTimeout          time.Duration
+	csiSnapshotTimeout     time.Duration
+	itemConcurrency        int
+	itemComparer           ItemComparer
 }
 
 type resolvedAction struct {
@@ -87,8 +94,10 @@ func NewKubernetesBackupper(
 	discoveryHelper discovery.Helper,
 	dynamicFactory client.DynamicFactory,
 	podCommandExecutor podexec.PodCommandExecutor,
-	resticBackupperFactory restic.BackupperFactory,
+	resticBackupperFactory podvolume.BackupperFactory,
 	resticTimeout time.Duration,
+	csiSnapshotTimeout time.Duration,
+	itemConcurrency int,
 ) (Backupper, error) {
 	return &kubernetesBackupper{
 		discoveryHelper:        discoveryHelper,
@@ -97,6 +106,9 @@ func NewKubernetesBackupper(
 		groupBackupperFactory:  &defaultGroupBackupperFactory{},
 		resticBackupperFactory: resticBackupperFactory,
 		resticTimeout:          resticTimeout,
+		csiSnapshotTimeout:     csiSnapshotTimeout,
+		itemConcurrency:        itemConcurrency,
+		itemComparer:           NewContentItemComparer(),
 	}, nil
 }
 
@@ -219,10 +231,25 @@ func (kb *kubernetesBackupper) Backup(log logrus.FieldLogger, backupRequest *Req
 	log.Infof("Including namespaces: %s", backupRequest.NamespaceIncludesExcludes.IncludesString())
 	log.Infof("Excluding namespaces: %s", backupRequest.NamespaceIncludesExcludes.ExcludesString())
 
-	backupRequest.ResourceIncludesExcludes = getResourceIncludesExcludes(kb.discoveryHelper, backupRequest.Spec.IncludedResources, backupRequest.Spec.ExcludedResources)
+	if backupRequest.VolumeSnapshotOnly() {
+		// persistent volumes are only backed up as additional items of the
+		// claims they're bound to, see defaultResourceBackupper.backupResource.
+		backupRequest.ResourceIncludesExcludes = collections.NewIncludesExcludes().Includes(
+			kuberesource.PersistentVolumeClaims.String(),
+			kuberesource.PersistentVolumes.String(),
+		)
+	} else {
+		backupRequest.ResourceIncludesExcludes = getResourceIncludesExcludes(kb.discoveryHelper, backupRequest.Spec.IncludedResources, backupRequest.Spec.ExcludedResources)
+	}
 	log.Infof("Including resources: %s", backupRequest.ResourceIncludesExcludes.IncludesString())
 	log.Infof("Excluding resources: %s", backupRequest.ResourceIncludesExcludes.ExcludesString())
 
+	backupRequest.ClusterResourceNames = collections.GetClusterResourceNames(kb.discoveryHelper, backupRequest.Spec.IncludedClusterResourceNames, backupRequest.Spec.ExcludedClusterResourceNames)
+	if len(backupRequest.Spec.IncludedClusterResourceNames) > 0 || len(backupRequest.Spec.ExcludedClusterResourceNames) > 0 {
+		log.Infof("Including cluster-scoped items: %s", strings.Join(backupRequest.Spec.IncludedClusterResourceNames, ", "))
+		log.Infof("Excluding cluster-scoped items: %s", strings.Join(backupRequest.Spec.ExcludedClusterResourceNames, ", "))
+	}
+
 	var err error
 	backupRequest.ResourceHooks, err = getResourceHooks(backupRequest.Spec.Hooks.Resources, kb.discoveryHelper)
 	if err != nil {
@@ -235,6 +262,16 @@ func (kb *kubernetesBackupper) Backup(log logrus.FieldLogger, backupRequest *Req
 	}
 
 	backupRequest.BackedUpItems = map[itemKey]struct{}{}
+	backupRequest.itemConcurrency = kb.itemConcurrency
+	backupRequest.csiSnapshotter = csi.NewSnapshotter(kb.dynamicFactory, kb.csiSnapshotTimeout)
+
+	if backupRequest.SkipUnchangedItems() {
+		backupRequest.ItemIndex = persistence.BackupItemIndex{}
+		backupRequest.itemComparer = kb.itemComparer
+		if backupRequest.PreviousItemIndex == nil {
+			log.Info("Writing every item to the backup tarball because there's no previous backup to compare items against")
+		}
+	}
 
 	podVolumeTimeout := kb.resticTimeout
 	if val := backupRequest.Annotations[api.PodVolumeOperationTimeoutAnnotation]; val != "" {
@@ -249,8 +286,8 @@ func (kb *kubernetesBackupper) Backup(log logrus.FieldLogger, backupRequest *Req
 	ctx, cancelFunc := context.WithTimeout(context.Background(), podVolumeTimeout)
 	defer cancelFunc()
 
-	var resticBackupper restic.Backupper
-	if kb.resticBackupperFactory != nil {
+	var resticBackupper podvolume.Backupper
+	if kb.resticBackupperFactory != nil && !backupRequest.ObjectsOnly() && !backupRequest.VolumeSnapshotOnly() {
 		resticBackupper, err = kb.resticBackupperFactory.NewBackupper(ctx, backupRequest.Backup)
 		if err != nil {
 			return errors.WithStack(err)
@@ -276,6 +313,10 @@ func (kb *kubernetesBackupper) Backup(log logrus.FieldLogger, backupRequest *Req
 		}
 	}
 
+	backupRequest.setItemsBackedUp(len(backupRequest.BackedUpItems))
+	progress := backupRequest.Progress()
+	backupRequest.Status.Progress = &progress
+
 	return nil
 }
 
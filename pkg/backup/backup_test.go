@@ -22,6 +22,7 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"sort"
@@ -35,18 +36,23 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubetesting "k8s.io/client-go/testing"
 
 	velerov1 "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/builder"
 	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/csi"
 	"github.com/heptio/velero/pkg/discovery"
 	"github.com/heptio/velero/pkg/kuberesource"
+	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/velero"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/resourcepolicies"
 	"github.com/heptio/velero/pkg/test"
 	testutil "github.com/heptio/velero/pkg/test"
 	kubeutil "github.com/heptio/velero/pkg/util/kube"
@@ -103,6 +109,106 @@ func TestBackedUpItemsMatchesTarballContents(t *testing.T) {
 	assertTarballContents(t, backupFile, append(expectedFiles, "metadata/version")...)
 }
 
+// TestBackupProgressIsUpdated verifies that after a backup has run, its
+// status has a progress that counts each item that was backed up.
+func TestBackupProgressIsUpdated(t *testing.T) {
+	h := newHarness(t)
+	req := &Request{Backup: defaultBackup().Result()}
+	backupFile := bytes.NewBuffer([]byte{})
+
+	apiResources := []*test.APIResource{
+		test.Pods(
+			builder.ForPod("foo", "bar").Result(),
+			builder.ForPod("zoo", "raz").Result(),
+		),
+		test.Deployments(
+			builder.ForDeployment("foo", "bar").Result(),
+			builder.ForDeployment("zoo", "raz").Result(),
+		),
+		test.PVs(
+			builder.ForPersistentVolume("bar").Result(),
+			builder.ForPersistentVolume("baz").Result(),
+		),
+	}
+	for _, resource := range apiResources {
+		h.addItems(t, resource)
+	}
+
+	require.NoError(t, h.backupper.Backup(h.log, req, backupFile, nil, nil))
+
+	require.NotNil(t, req.Status.Progress)
+	assert.Len(t, req.BackedUpItems, 6)
+	assert.Equal(t, velerov1.BackupProgress{TotalItems: 6, ItemsBackedUp: 6}, *req.Status.Progress)
+}
+
+// TestBackupWithItemConcurrency verifies that when a backup's items are
+// backed up by several workers, every item is still written to the backup
+// tarball exactly once.
+func TestBackupWithItemConcurrency(t *testing.T) {
+	h := newHarness(t)
+	h.backupper.itemConcurrency = 4
+	req := &Request{Backup: defaultBackup().Result()}
+	backupFile := bytes.NewBuffer([]byte{})
+
+	var (
+		pods []metav1.Object
+		want []string
+	)
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("pod-%d", i)
+		pods = append(pods, builder.ForPod("ns-1", name).Result())
+		want = append(want, "resources/pods/namespaces/ns-1/"+name+".json")
+	}
+	h.addItems(t, test.Pods(pods...))
+
+	require.NoError(t, h.backupper.Backup(h.log, req, backupFile, nil, nil))
+
+	assert.Len(t, req.BackedUpItems, 20)
+	assert.Equal(t, velerov1.BackupProgress{TotalItems: 20, ItemsBackedUp: 20}, *req.Status.Progress)
+	assertTarballContents(t, backupFile, append(want, "metadata/version")...)
+}
+
+// TestBackupWithResourcePolicies runs a backup with resource policies and
+// verifies that the items matching a skip policy aren't backed up.
+func TestBackupWithResourcePolicies(t *testing.T) {
+	policies, err := resourcepolicies.Parse([]byte(`
+version: v1
+resourcePolicies:
+- conditions:
+    csiDrivers: [nfs.csi.k8s.io]
+  action:
+    type: skip
+- conditions:
+    resources: [pods]
+    annotations:
+      example.com/skip: ""
+  action:
+    type: skip
+`))
+	require.NoError(t, err)
+
+	h := newHarness(t)
+	req := &Request{Backup: defaultBackup().Result(), ResourcePolicies: policies}
+	backupFile := bytes.NewBuffer([]byte{})
+
+	h.addItems(t, test.Pods(
+		builder.ForPod("ns-1", "pod-1").Result(),
+		builder.ForPod("ns-1", "pod-2").ObjectMeta(builder.WithAnnotations("example.com/skip", "true")).Result(),
+	))
+	h.addItems(t, test.PVs(
+		builder.ForPersistentVolume("pv-1").CSI("ebs.csi.aws.com", "vol-1").Result(),
+		builder.ForPersistentVolume("pv-2").CSI("nfs.csi.k8s.io", "vol-2").Result(),
+	))
+
+	require.NoError(t, h.backupper.Backup(h.log, req, backupFile, nil, nil))
+
+	assertTarballContents(t, backupFile,
+		"metadata/version",
+		"resources/pods/namespaces/ns-1/pod-1.json",
+		"resources/persistentvolumes/cluster/pv-1.json",
+	)
+}
+
 // TestBackupResourceFiltering runs backups with different combinations
 // of resource filters (included/excluded resources, included/excluded
 // namespaces, label selectors, "include cluster resources" flag), and
@@ -267,6 +373,31 @@ func TestBackupResourceFiltering(t *testing.T) {
 				"resources/persistentvolumes/cluster/bar.json",
 			},
 		},
+		{
+			name: "OR-able label selectors back up resources matching any of them, once",
+			backup: defaultBackup().
+				OrLabelSelectors(
+					metav1.LabelSelector{MatchLabels: map[string]string{"a": "b"}},
+					metav1.LabelSelector{MatchLabels: map[string]string{"c": "d"}},
+				).
+				Result(),
+			apiResources: []*test.APIResource{
+				test.Pods(
+					builder.ForPod("foo", "bar").ObjectMeta(builder.WithLabels("a", "b")).Result(),
+					builder.ForPod("zoo", "raz").ObjectMeta(builder.WithLabels("c", "d")).Result(),
+					builder.ForPod("zoo", "baz").Result(),
+				),
+				test.PVs(
+					builder.ForPersistentVolume("bar").ObjectMeta(builder.WithLabels("a", "b", "c", "d")).Result(),
+					builder.ForPersistentVolume("baz").ObjectMeta(builder.WithLabels("a", "c", "velero.io/exclude-from-backup", "true")).Result(),
+				),
+			},
+			want: []string{
+				"resources/pods/namespaces/foo/bar.json",
+				"resources/pods/namespaces/zoo/raz.json",
+				"resources/persistentvolumes/cluster/bar.json",
+			},
+		},
 		{
 			name: "resources with velero.io/exclude-from-backup=true label are not included",
 			backup: defaultBackup().
@@ -562,6 +693,62 @@ func TestBackupResourceFiltering(t *testing.T) {
 				"resources/pods/namespaces/zoo/raz.json",
 			},
 		},
+		{
+			name: "only included cluster-scoped items of a resource are backed up",
+			backup: defaultBackup().
+				IncludedClusterResourceNames("persistentvolumes/pv-1").
+				Result(),
+			apiResources: []*test.APIResource{
+				test.Pods(
+					builder.ForPod("ns-1", "pod-1").Result(),
+				),
+				test.PVs(
+					builder.ForPersistentVolume("pv-1").Result(),
+					builder.ForPersistentVolume("pv-2").Result(),
+				),
+			},
+			want: []string{
+				"resources/pods/namespaces/ns-1/pod-1.json",
+				"resources/persistentvolumes/cluster/pv-1.json",
+			},
+		},
+		{
+			name: "excluded cluster-scoped items are not backed up",
+			backup: defaultBackup().
+				ExcludedClusterResourceNames("persistentvolumes/pv-2*").
+				Result(),
+			apiResources: []*test.APIResource{
+				test.PVs(
+					builder.ForPersistentVolume("pv-1").Result(),
+					builder.ForPersistentVolume("pv-2").Result(),
+					builder.ForPersistentVolume("pv-20").Result(),
+				),
+			},
+			want: []string{
+				"resources/persistentvolumes/cluster/pv-1.json",
+			},
+		},
+		{
+			name: "included cluster-scoped items are backed up when backing up a subset of namespaces and IncludeClusterResources=nil",
+			backup: defaultBackup().
+				IncludedNamespaces("ns-1").
+				IncludedClusterResourceNames("persistentvolumes/pv-2").
+				Result(),
+			apiResources: []*test.APIResource{
+				test.Pods(
+					builder.ForPod("ns-1", "pod-1").Result(),
+					builder.ForPod("ns-2", "pod-1").Result(),
+				),
+				test.PVs(
+					builder.ForPersistentVolume("pv-1").Result(),
+					builder.ForPersistentVolume("pv-2").Result(),
+				),
+			},
+			want: []string{
+				"resources/pods/namespaces/ns-1/pod-1.json",
+				"resources/persistentvolumes/cluster/pv-2.json",
+			},
+		},
 		{
 			name:   "terminating resources are not backed up",
 			backup: defaultBackup().Result(),
@@ -1539,6 +1726,7 @@ func TestBackupWithSnapshots(t *testing.T) {
 					Spec: volume.SnapshotSpec{
 						BackupName:           "backup-1",
 						Location:             "default",
+						Provider:             "default",
 						PersistentVolumeName: "pv-1",
 						ProviderVolumeID:     "vol-1",
 						VolumeType:           "type-1",
@@ -1572,6 +1760,7 @@ func TestBackupWithSnapshots(t *testing.T) {
 					Spec: volume.SnapshotSpec{
 						BackupName:           "backup-1",
 						Location:             "default",
+						Provider:             "default",
 						PersistentVolumeName: "pv-1",
 						ProviderVolumeID:     "vol-1",
 						VolumeAZ:             "zone-1",
@@ -1606,6 +1795,7 @@ func TestBackupWithSnapshots(t *testing.T) {
 					Spec: volume.SnapshotSpec{
 						BackupName:           "backup-1",
 						Location:             "default",
+						Provider:             "default",
 						PersistentVolumeName: "pv-1",
 						ProviderVolumeID:     "vol-1",
 						VolumeType:           "type-1",
@@ -1708,6 +1898,7 @@ func TestBackupWithSnapshots(t *testing.T) {
 					Spec: volume.SnapshotSpec{
 						BackupName:           "backup-1",
 						Location:             "default",
+						Provider:             "default",
 						PersistentVolumeName: "pv-1",
 						ProviderVolumeID:     "vol-1",
 						VolumeType:           "type-1",
@@ -1722,6 +1913,7 @@ func TestBackupWithSnapshots(t *testing.T) {
 					Spec: volume.SnapshotSpec{
 						BackupName:           "backup-1",
 						Location:             "another",
+						Provider:             "another",
 						PersistentVolumeName: "pv-2",
 						ProviderVolumeID:     "vol-2",
 						VolumeType:           "type-2",
@@ -1755,6 +1947,324 @@ func TestBackupWithSnapshots(t *testing.T) {
 	}
 }
 
+// TestBackupWithCSISnapshots runs backups of CSI persistent volumes that no
+// volume snapshot location supports and verifies that the volumes whose
+// drivers have a VolumeSnapshotClass are snapshotted through the
+// VolumeSnapshot API.
+// newCSIHarness returns a harness whose dynamic client has a volume snapshot
+// class for the CSI driver "driver-1" and binds new volume snapshots to the
+// content "snapcontent-1".
+func newCSIHarness(t *testing.T) *harness {
+	h := newHarness(t)
+
+	unstructuredObj := func(kind, name string, fields map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: fields}
+		obj.SetAPIVersion(csi.GroupVersion.String())
+		obj.SetKind(kind)
+		obj.SetName(name)
+		return obj
+	}
+	_, err := h.DynamicClient.Resource(csi.GroupVersion.WithResource("volumesnapshotclasses")).Create(
+		unstructuredObj("VolumeSnapshotClass", "class-1", map[string]interface{}{"driver": "driver-1"}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = h.DynamicClient.Resource(csi.GroupVersion.WithResource("volumesnapshotcontents")).Create(
+		unstructuredObj("VolumeSnapshotContent", "snapcontent-1", map[string]interface{}{
+			"spec":   map[string]interface{}{"deletionPolicy": "Delete"},
+			"status": map[string]interface{}{"snapshotHandle": "handle-1"},
+		}), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Stand in for the snapshot controller, which binds new volume
+	// snapshots to their content once they're ready to use.
+	h.DynamicClient.PrependReactor("create", "volumesnapshots", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		obj := action.(kubetesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		if _, found, _ := unstructured.NestedString(obj.Object, "spec", "source", "persistentVolumeClaimName"); found {
+			obj.Object["status"] = map[string]interface{}{
+				"readyToUse":                     true,
+				"boundVolumeSnapshotContentName": "snapcontent-1",
+			}
+		}
+		return false, nil, nil
+	})
+
+	return h
+}
+
+func TestBackupWithCSISnapshots(t *testing.T) {
+	h := newCSIHarness(t)
+
+	h.addItems(t, test.PVs(
+		builder.ForPersistentVolume("pv-1").CSI("driver-1", "vol-1").ClaimRef("ns-1", "pvc-1").Result(),
+		builder.ForPersistentVolume("pv-2").CSI("driver-2", "vol-2").ClaimRef("ns-1", "pvc-2").Result(),
+	))
+
+	req := &Request{Backup: defaultBackup().Result()}
+	require.NoError(t, h.backupper.Backup(h.log, req, bytes.NewBuffer([]byte{}), nil, nil))
+
+	want := []*volume.Snapshot{
+		{
+			Spec: volume.SnapshotSpec{
+				BackupName:             "backup-1",
+				PersistentVolumeName:   "pv-1",
+				ProviderVolumeID:       "vol-1",
+				CSIDriver:              "driver-1",
+				CSIVolumeSnapshotClass: "class-1",
+			},
+			Status: volume.SnapshotStatus{
+				Phase:                    volume.SnapshotPhaseCompleted,
+				ProviderSnapshotID:       "handle-1",
+				CSIVolumeSnapshotContent: "snapcontent-1",
+			},
+		},
+	}
+	assert.Equal(t, want, req.VolumeSnapshots)
+	assert.Equal(t, VolumeCoverageSnapshot, req.VolumeCoverage["ns-1/pvc-1"])
+}
+
+// TestBackupWithSnapshotDataMovement runs backups that move the data of their CSI
+// snapshots, and verifies that the data is backed up with restic through a data
+// mover pod and that snapshots whose data was moved are deleted.
+func TestBackupWithSnapshotDataMovement(t *testing.T) {
+	tests := []struct {
+		name                 string
+		resticEnabled        bool
+		wantSnapshots        int
+		wantPodVolumeBackups []*velerov1.PodVolumeBackup
+		wantCoverage         string
+	}{
+		{
+			name:                 "snapshot data is moved with restic and the snapshot is deleted",
+			resticEnabled:        true,
+			wantSnapshots:        0,
+			wantPodVolumeBackups: []*velerov1.PodVolumeBackup{builder.ForPodVolumeBackup("velero", "pvb-1").Result()},
+			wantCoverage:         VolumeCoverageMovedSnapshot,
+		},
+		{
+			name:          "snapshot is kept if restic isn't enabled",
+			resticEnabled: false,
+			wantSnapshots: 1,
+			wantCoverage:  VolumeCoverageSnapshot,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newCSIHarness(t)
+			if tc.resticEnabled {
+				h.backupper.resticBackupperFactory = &fakeResticBackupperFactory{
+					podVolumeBackups: tc.wantPodVolumeBackups,
+				}
+			}
+
+			// Stand in for the scheduler and kubelet, which start the data
+			// mover pod.
+			var moverPod *unstructured.Unstructured
+			h.DynamicClient.PrependReactor("create", "pods", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				moverPod = action.(kubetesting.CreateAction).GetObject().(*unstructured.Unstructured)
+				moverPod.Object["status"] = map[string]interface{}{"phase": "Running"}
+				return false, nil, unstructured.SetNestedField(moverPod.Object, "node-1", "spec", "nodeName")
+			})
+
+			h.addItems(t, test.PVs(
+				builder.ForPersistentVolume("pv-1").CSI("driver-1", "vol-1").ClaimRef("ns-1", "pvc-1").Result(),
+			))
+
+			req := &Request{Backup: defaultBackup().SnapshotMoveData(true).Result()}
+			require.NoError(t, h.backupper.Backup(h.log, req, bytes.NewBuffer([]byte{}), nil, nil))
+
+			assert.Len(t, req.VolumeSnapshots, tc.wantSnapshots)
+			assert.Equal(t, tc.wantPodVolumeBackups, req.PodVolumeBackups)
+			assert.Equal(t, tc.wantCoverage, req.VolumeCoverage["ns-1/pvc-1"])
+
+			_, err := h.DynamicClient.Resource(csi.GroupVersion.WithResource("volumesnapshotcontents")).Get("snapcontent-1", metav1.GetOptions{})
+			assert.Equal(t, tc.wantSnapshots == 0, apierrors.IsNotFound(err))
+
+			if !tc.resticEnabled {
+				assert.Nil(t, moverPod)
+				return
+			}
+
+			require.NotNil(t, moverPod)
+			assert.Equal(t, "pvc-1", moverPod.GetAnnotations()[podvolume.MovedPVCAnnotation])
+			assert.Equal(t, "true", moverPod.GetLabels()["velero.io/exclude-from-backup"])
+
+			// the data mover's pod, claim and volume snapshot are cleaned up
+			_, err = h.DynamicClient.Resource(corev1.SchemeGroupVersion.WithResource("pods")).Namespace("ns-1").Get(moverPod.GetName(), metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err))
+			_, err = h.DynamicClient.Resource(corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims")).Namespace("ns-1").Get(moverPod.GetName(), metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err))
+			_, err = h.DynamicClient.Resource(csi.GroupVersion.WithResource("volumesnapshots")).Namespace("ns-1").Get(moverPod.GetName(), metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err))
+		})
+	}
+}
+
+// TestBackupVolumeCoverage runs backups of persistent volume claims and verifies that
+// the backup's volume coverage report records how each claim's data was captured.
+func TestBackupVolumeCoverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		backup *velerov1.Backup
+		want   map[string]string
+		// wantSnapshots is the number of volume snapshots the backup should take
+		wantSnapshots int
+	}{
+		{
+			name:   "claims are recorded as snapshotted or not captured in a full backup",
+			backup: defaultBackup().Result(),
+			want: map[string]string{
+				"ns-1/pvc-1": VolumeCoverageSnapshot,
+				"ns-1/pvc-2": VolumeCoverageNotCaptured,
+			},
+			wantSnapshots: 1,
+		},
+		{
+			name:   "claims are recorded as skipped in an objects-only backup",
+			backup: defaultBackup().Mode(velerov1.BackupModeObjectsOnly).Result(),
+			want: map[string]string{
+				"ns-1/pvc-1": VolumeCoverageSkipped,
+				"ns-1/pvc-2": VolumeCoverageSkipped,
+			},
+			wantSnapshots: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				h          = newHarness(t)
+				backupFile = bytes.NewBuffer([]byte{})
+				req        = &Request{
+					Backup: tc.backup,
+					SnapshotLocations: []*velerov1.VolumeSnapshotLocation{
+						newSnapshotLocation("velero", "default", "default"),
+					},
+				}
+				snapshotterGetter = volumeSnapshotterGetter{
+					"default": new(fakeVolumeSnapshotter).WithVolume("pv-1", "vol-1", "", "type-1", 100, false),
+				}
+			)
+
+			h.addItems(t, test.PVCs(
+				builder.ForPersistentVolumeClaim("ns-1", "pvc-1").VolumeName("pv-1").Result(),
+				builder.ForPersistentVolumeClaim("ns-1", "pvc-2").VolumeName("pv-2").Result(),
+			))
+			h.addItems(t, test.PVs(
+				builder.ForPersistentVolume("pv-1").ClaimRef("ns-1", "pvc-1").Result(),
+				builder.ForPersistentVolume("pv-2").ClaimRef("ns-1", "pvc-2").Result(),
+			))
+
+			err := h.backupper.Backup(h.log, req, backupFile, nil, snapshotterGetter)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.want, req.VolumeCoverage)
+			assert.Len(t, req.VolumeSnapshots, tc.wantSnapshots)
+		})
+	}
+}
+
+// TestBackupVolumeSnapshotOnly runs volume-snapshot-only backups and verifies that the
+// persistent volumes bound to the selected claims are snapshotted, and that no resources
+// are written to the backup tarball.
+func TestBackupVolumeSnapshotOnly(t *testing.T) {
+	var (
+		h          = newHarness(t)
+		backupFile = bytes.NewBuffer([]byte{})
+		req        = &Request{
+			Backup: defaultBackup().Mode(velerov1.BackupModeVolumeSnapshotOnly).IncludedNamespaces("ns-1").Result(),
+			SnapshotLocations: []*velerov1.VolumeSnapshotLocation{
+				newSnapshotLocation("velero", "default", "default"),
+			},
+		}
+		snapshotterGetter = volumeSnapshotterGetter{
+			"default": new(fakeVolumeSnapshotter).
+				WithVolume("pv-1", "vol-1", "", "type-1", 100, false).
+				WithVolume("pv-2", "vol-2", "", "type-1", 100, false).
+				WithVolume("pv-3", "vol-3", "", "type-1", 100, false),
+		}
+	)
+
+	h.addItems(t, test.Pods(
+		builder.ForPod("ns-1", "pod-1").Result(),
+	))
+	h.addItems(t, test.PVCs(
+		builder.ForPersistentVolumeClaim("ns-1", "pvc-1").VolumeName("pv-1").Phase(corev1.ClaimBound).Result(),
+		builder.ForPersistentVolumeClaim("ns-2", "pvc-2").VolumeName("pv-2").Phase(corev1.ClaimBound).Result(),
+	))
+	h.addItems(t, test.PVs(
+		builder.ForPersistentVolume("pv-1").ClaimRef("ns-1", "pvc-1").Result(),
+		builder.ForPersistentVolume("pv-2").ClaimRef("ns-2", "pvc-2").Result(),
+		builder.ForPersistentVolume("pv-3").Result(),
+	))
+
+	actions := []velero.BackupItemAction{NewPVCAction(h.log)}
+
+	err := h.backupper.Backup(h.log, req, backupFile, actions, snapshotterGetter)
+	assert.NoError(t, err)
+
+	require.Len(t, req.VolumeSnapshots, 1)
+	assert.Equal(t, "pv-1", req.VolumeSnapshots[0].Spec.PersistentVolumeName)
+	assert.Equal(t, map[string]string{"ns-1/pvc-1": VolumeCoverageSnapshot}, req.VolumeCoverage)
+	assert.Empty(t, req.BackupResourceList())
+
+	assertTarballContents(t, backupFile, "metadata/version")
+}
+
+// TestBackupSkipUnchangedItems runs two backups that skip unchanged items, and
+// verifies that the second one only writes new and changed items to its tarball
+// and refers to the first one for the rest.
+func TestBackupSkipUnchangedItems(t *testing.T) {
+	h := newHarness(t)
+
+	h.addItems(t, test.Pods(
+		builder.ForPod("ns-1", "pod-1").Result(),
+		builder.ForPod("ns-1", "pod-2").Result(),
+	))
+
+	first := &Request{Backup: defaultBackup().SkipUnchangedItems(true).Result()}
+	firstFile := bytes.NewBuffer([]byte{})
+	require.NoError(t, h.backupper.Backup(h.log, first, firstFile, nil, nil))
+
+	assertTarballContents(t, firstFile,
+		"metadata/version",
+		"resources/pods/namespaces/ns-1/pod-1.json",
+		"resources/pods/namespaces/ns-1/pod-2.json",
+	)
+	require.Len(t, first.ItemIndex, 2)
+	assert.Empty(t, first.ItemIndex.ReferencedBackups("backup-1"))
+
+	h.addItems(t, test.Pods(
+		builder.ForPod("ns-1", "pod-3").Result(),
+	))
+
+	// simulate a change to pod-2 since the first backup.
+	previous := persistence.BackupItemIndex{}
+	for path, entry := range first.ItemIndex {
+		previous[path] = entry
+	}
+	previous["resources/pods/namespaces/ns-1/pod-2.json"] = persistence.BackupItemIndexEntry{Hash: "changed", Backup: "backup-1"}
+
+	second := &Request{
+		Backup:            builder.ForBackup(velerov1.DefaultNamespace, "backup-2").SkipUnchangedItems(true).Result(),
+		PreviousItemIndex: previous,
+	}
+	secondFile := bytes.NewBuffer([]byte{})
+	require.NoError(t, h.backupper.Backup(h.log, second, secondFile, nil, nil))
+
+	assertTarballContents(t, secondFile,
+		"metadata/version",
+		"resources/pods/namespaces/ns-1/pod-2.json",
+		"resources/pods/namespaces/ns-1/pod-3.json",
+	)
+	assert.Equal(t, "backup-1", second.ItemIndex["resources/pods/namespaces/ns-1/pod-1.json"].Backup)
+	assert.Equal(t, "backup-2", second.ItemIndex["resources/pods/namespaces/ns-1/pod-2.json"].Backup)
+	assert.Equal(t, "backup-2", second.ItemIndex["resources/pods/namespaces/ns-1/pod-3.json"].Backup)
+	assert.Equal(t, []string{"backup-1"}, second.ItemIndex.ReferencedBackups("backup-2"))
+
+	// unchanged items are still part of the backup.
+	assert.Equal(t, map[string][]string{"v1/Pod": {"ns-1/pod-1", "ns-1/pod-2", "ns-1/pod-3"}}, second.BackupResourceList())
+}
+
 // TestBackupWithInvalidHooks runs backups with invalid hook specifications and verifies
 // that an error is returned.
 func TestBackupWithInvalidHooks(t *testing.T) {
@@ -2073,7 +2583,7 @@ type fakeResticBackupperFactory struct {
 	podVolumeBackups []*velerov1.PodVolumeBackup
 }
 
-func (f *fakeResticBackupperFactory) NewBackupper(context.Context, *velerov1.Backup) (restic.Backupper, error) {
+func (f *fakeResticBackupperFactory) NewBackupper(context.Context, *velerov1.Backup) (podvolume.Backupper, error) {
 	return &fakeResticBackupper{
 		podVolumeBackups: f.podVolumeBackups,
 	}, nil
@@ -2237,6 +2747,7 @@ func newHarness(t *testing.T) *harness {
 			dynamicFactory:        client.NewDynamicFactory(apiServer.DynamicClient),
 			discoveryHelper:       discoveryHelper,
 			groupBackupperFactory: new(defaultGroupBackupperFactory),
+			itemComparer:          NewContentItemComparer(),
 
 			// unsupported
 			podCommandExecutor:     nil,
@@ -0,0 +1,368 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/buildinfo"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/csi"
+	"github.com/heptio/velero/pkg/label"
+	"github.com/heptio/velero/pkg/plugin/velero"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/volume"
+)
+
+const (
+	// dataMoverImageBase is the image of the pods that mount the volumes
+	// provisioned from snapshots while their data is backed up with restic.
+	dataMoverImageBase = "gcr.io/heptio-images/velero-restic-restore-helper"
+
+	dataMoverVolume = "data"
+
+	excludeFromBackupLabel = "velero.io/exclude-from-backup"
+)
+
+var (
+	// dataMoverPodTimeout is how long to wait for a data mover pod to be
+	// running.
+	dataMoverPodTimeout = 10 * time.Minute
+
+	dataMoverPollInterval = time.Second
+)
+
+// provisionFunc provisions a volume from a snapshot for the given claim,
+// setting the claim's data source or volume name as needed, before the
+// claim is created.
+type provisionFunc func(claim *corev1api.PersistentVolumeClaim) error
+
+// moveSnapshotData backs up the data of a persistent volume's snapshot with
+// restic. A claim for a new volume provisioned from the snapshot is mounted
+// by a temporary pod, whose volume is backed up as the data of the persistent
+// volume's original claim. The claim and pod are deleted afterwards.
+func (ib *defaultItemBackupper) moveSnapshotData(pv *corev1api.PersistentVolume, provision provisionFunc, log logrus.FieldLogger) error {
+	if ib.resticBackupper == nil {
+		return errors.Errorf("unable to move snapshot data of persistent volume %s: restic isn't enabled", pv.Name)
+	}
+
+	claimRef := pv.Spec.ClaimRef
+	name := dataMoverName(ib.backupRequest.Name, claimRef.Name)
+	log = log.WithField("dataMover", name)
+
+	claim := newDataMoverClaim(pv, name, ib.dataMoverLabels())
+	if err := provision(claim); err != nil {
+		return err
+	}
+
+	pvcClient, err := ib.clientFor("persistentvolumeclaims", claimRef.Namespace)
+	if err != nil {
+		return err
+	}
+	if err := createTyped(pvcClient, claim); err != nil {
+		return errors.Wrapf(err, "error creating persistent volume claim %s/%s", claimRef.Namespace, name)
+	}
+	defer deleteDataMoverObject(pvcClient, name, log)
+
+	podClient, err := ib.clientFor("pods", claimRef.Namespace)
+	if err != nil {
+		return err
+	}
+	if err := createTyped(podClient, newDataMoverPod(claimRef.Namespace, name, claimRef.Name, ib.dataMoverLabels())); err != nil {
+		return errors.Wrapf(err, "error creating pod %s/%s", claimRef.Namespace, name)
+	}
+	defer deleteDataMoverObject(podClient, name, log)
+
+	log.Info("Waiting for data mover pod to be running")
+	pod := new(corev1api.Pod)
+	err = wait.PollImmediate(dataMoverPollInterval, dataMoverPodTimeout, func() (bool, error) {
+		obj, err := podClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "error getting pod %s/%s", claimRef.Namespace, name)
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), pod); err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		switch pod.Status.Phase {
+		case corev1api.PodFailed, corev1api.PodSucceeded:
+			return false, errors.Errorf("pod %s/%s exited", claimRef.Namespace, name)
+		case corev1api.PodRunning:
+			return pod.Spec.NodeName != "", nil
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		err = errors.Errorf("timed out after %v waiting for pod %s/%s to be running", dataMoverPodTimeout, claimRef.Namespace, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info("Backing up snapshot data with restic")
+	podVolumeBackups, errs := ib.resticBackupper.BackupPodVolumes(ib.backupRequest.Backup, pod, log)
+	ib.backupRequest.addPodVolumeBackups(podVolumeBackups...)
+	if len(errs) > 0 {
+		return kubeerrs.NewAggregate(errs)
+	}
+
+	ib.backupRequest.recordVolumeCoverage(claimRef.Namespace, claimRef.Name, VolumeCoverageMovedSnapshot)
+	return nil
+}
+
+// moveProviderSnapshotData moves the data of a snapshot taken by a volume
+// snapshotter, provisioning a volume from it through the volume snapshotter,
+// and deletes the snapshot if its data was moved.
+func (ib *defaultItemBackupper) moveProviderSnapshotData(obj runtime.Unstructured, pv *corev1api.PersistentVolume, snapshot *volume.Snapshot, volumeSnapshotter velero.VolumeSnapshotter, log logrus.FieldLogger) (bool, error) {
+	provision := func(claim *corev1api.PersistentVolumeClaim) error {
+		volumeID, err := volumeSnapshotter.CreateVolumeFromSnapshot(snapshot.Status.ProviderSnapshotID, snapshot.Spec.VolumeType, snapshot.Spec.VolumeAZ, snapshot.Spec.VolumeIOPS)
+		if err != nil {
+			return errors.WithMessage(err, "error creating volume from snapshot")
+		}
+
+		updated, err := volumeSnapshotter.SetVolumeID(&unstructured.Unstructured{Object: runtime.DeepCopyJSON(obj.UnstructuredContent())}, volumeID)
+		if err != nil {
+			return errors.WithMessage(err, "error setting volume ID of data mover persistent volume")
+		}
+
+		moverPV := new(corev1api.PersistentVolume)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(updated.UnstructuredContent(), moverPV); err != nil {
+			return errors.WithStack(err)
+		}
+		resetDataMoverVolume(moverPV, claim)
+
+		pvClient, err := ib.clientFor("persistentvolumes", "")
+		if err != nil {
+			return err
+		}
+		if err := createTyped(pvClient, moverPV); err != nil {
+			return errors.Wrapf(err, "error creating persistent volume %s", moverPV.Name)
+		}
+
+		// the volume is deleted along with the claim it's bound to.
+		claim.Spec.VolumeName = moverPV.Name
+		return nil
+	}
+
+	if err := ib.moveSnapshotData(pv, provision, log); err != nil {
+		return false, err
+	}
+
+	if err := volumeSnapshotter.DeleteSnapshot(snapshot.Status.ProviderSnapshotID); err != nil {
+		log.WithError(err).Warn("Error deleting volume snapshot whose data was moved, keeping it in the backup")
+		return false, nil
+	}
+	return true, nil
+}
+
+// moveCSISnapshotData moves the data of a snapshot taken through the
+// VolumeSnapshot API, provisioning a volume from it by importing it into the
+// claim's namespace, and deletes the snapshot if its data was moved.
+func (ib *defaultItemBackupper) moveCSISnapshotData(pv *corev1api.PersistentVolume, csiSnapshot *csi.Snapshot, log logrus.FieldLogger) (bool, error) {
+	csiSnapshotter := ib.backupRequest.csiSnapshotter
+	namespace := pv.Spec.ClaimRef.Namespace
+
+	var imported string
+	provision := func(claim *corev1api.PersistentVolumeClaim) error {
+		if err := csiSnapshotter.ImportSnapshot(namespace, claim.Name, csiSnapshot, ib.dataMoverLabels()); err != nil {
+			return err
+		}
+		imported = claim.Name
+
+		apiGroup := csi.GroupName
+		claim.Spec.DataSource = &corev1api.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     csi.VolumeSnapshots.Kind,
+			Name:     claim.Name,
+		}
+		return nil
+	}
+
+	err := ib.moveSnapshotData(pv, provision, log)
+	if imported != "" {
+		if err := csiSnapshotter.RemoveImportedSnapshot(namespace, imported); err != nil {
+			log.WithError(err).Warn("Error removing data mover volume snapshot")
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := csiSnapshotter.DeleteSnapshot(csiSnapshot); err != nil {
+		log.WithError(err).Warn("Error deleting volume snapshot whose data was moved, keeping it in the backup")
+		return false, nil
+	}
+	return true, nil
+}
+
+func (ib *defaultItemBackupper) dataMoverLabels() map[string]string {
+	return map[string]string{
+		api.BackupNameLabel:    label.GetValidName(ib.backupRequest.Name),
+		excludeFromBackupLabel: "true",
+	}
+}
+
+func (ib *defaultItemBackupper) clientFor(resource, namespace string) (client.Dynamic, error) {
+	return ib.dynamicFactory.ClientForGroupVersionResource(
+		schema.GroupVersion{Group: "", Version: "v1"},
+		metav1.APIResource{Name: resource, Namespaced: namespace != ""},
+		namespace,
+	)
+}
+
+func createTyped(c client.Dynamic, obj interface{}) error {
+	res, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = c.Create(&unstructured.Unstructured{Object: res})
+	return err
+}
+
+func deleteDataMoverObject(c client.Dynamic, name string, log logrus.FieldLogger) {
+	if err := c.Delete(name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		log.WithError(err).Warnf("Error deleting data mover object %s", name)
+	}
+}
+
+func dataMoverName(backupName, claimName string) string {
+	return label.GetValidName(fmt.Sprintf("velero-%s-%s", backupName, claimName))
+}
+
+// newDataMoverClaim returns a claim in the namespace of the persistent
+// volume's claim that requests a volume like the persistent volume.
+func newDataMoverClaim(pv *corev1api.PersistentVolume, name string, labels map[string]string) *corev1api.PersistentVolumeClaim {
+	storageClassName := pv.Spec.StorageClassName
+
+	return &corev1api.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1api.SchemeGroupVersion.String(),
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pv.Spec.ClaimRef.Namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+		Spec: corev1api.PersistentVolumeClaimSpec{
+			AccessModes:      pv.Spec.AccessModes,
+			StorageClassName: &storageClassName,
+			VolumeMode:       pv.Spec.VolumeMode,
+			Resources: corev1api.ResourceRequirements{
+				Requests: corev1api.ResourceList{
+					corev1api.ResourceStorage: pv.Spec.Capacity[corev1api.ResourceStorage],
+				},
+			},
+		},
+	}
+}
+
+// resetDataMoverVolume turns a copy of a persistent volume into a new
+// persistent volume that's bound to the data mover claim and deleted with it.
+func resetDataMoverVolume(pv *corev1api.PersistentVolume, claim *corev1api.PersistentVolumeClaim) {
+	labels := make(map[string]string)
+	for k, v := range pv.Labels {
+		labels[k] = v
+	}
+	for k, v := range claim.Labels {
+		labels[k] = v
+	}
+
+	var annotations map[string]string
+	if provisioner := pv.Annotations["pv.kubernetes.io/provisioned-by"]; provisioner != "" {
+		annotations = map[string]string{"pv.kubernetes.io/provisioned-by": provisioner}
+	}
+
+	pv.ObjectMeta = metav1.ObjectMeta{
+		Name:        claim.Name,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+	pv.Spec.ClaimRef = &corev1api.ObjectReference{
+		Namespace: claim.Namespace,
+		Name:      claim.Name,
+	}
+	pv.Spec.PersistentVolumeReclaimPolicy = corev1api.PersistentVolumeReclaimDelete
+	pv.Status = corev1api.PersistentVolumeStatus{}
+}
+
+// newDataMoverPod returns a pod that mounts the data mover claim, annotated
+// so that restic backs up its volume as the data of the original claim.
+func newDataMoverPod(namespace, name, claimName string, labels map[string]string) *corev1api.Pod {
+	tag := buildinfo.Version
+	if tag == "" {
+		tag = "latest"
+	}
+
+	return &corev1api.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1api.SchemeGroupVersion.String(),
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    labels,
+			Annotations: map[string]string{
+				"backup.velero.io/backup-volumes": dataMoverVolume,
+				podvolume.MovedPVCAnnotation:      claimName,
+			},
+		},
+		Spec: corev1api.PodSpec{
+			RestartPolicy: corev1api.RestartPolicyNever,
+			Containers: []corev1api.Container{
+				{
+					Name:    "data-mover",
+					Image:   fmt.Sprintf("%s:%s", dataMoverImageBase, tag),
+					Command: []string{"/bin/sleep", "infinity"},
+					VolumeMounts: []corev1api.VolumeMount{
+						{
+							Name:      dataMoverVolume,
+							MountPath: "/" + dataMoverVolume,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []corev1api.Volume{
+				{
+					Name: dataMoverVolume,
+					VolumeSource: corev1api.VolumeSource{
+						PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{
+							ClaimName: name,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
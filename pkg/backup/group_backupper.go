@@ -28,7 +28,7 @@ import (
 	"github.com/heptio/velero/pkg/client"
 	"github.com/heptio/velero/pkg/discovery"
 	"github.com/heptio/velero/pkg/podexec"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 )
 
 type groupBackupperFactory interface {
@@ -40,7 +40,7 @@ type groupBackupperFactory interface {
 		cohabitatingResources map[string]*cohabitatingResource,
 		podCommandExecutor podexec.PodCommandExecutor,
 		tarWriter tarWriter,
-		resticBackupper restic.Backupper,
+		resticBackupper podvolume.Backupper,
 		resticSnapshotTracker *pvcSnapshotTracker,
 		volumeSnapshotterGetter VolumeSnapshotterGetter,
 	) groupBackupper
@@ -56,7 +56,7 @@ func (f *defaultGroupBackupperFactory) newGroupBackupper(
 	cohabitatingResources map[string]*cohabitatingResource,
 	podCommandExecutor podexec.PodCommandExecutor,
 	tarWriter tarWriter,
-	resticBackupper restic.Backupper,
+	resticBackupper podvolume.Backupper,
 	resticSnapshotTracker *pvcSnapshotTracker,
 	volumeSnapshotterGetter VolumeSnapshotterGetter,
 ) groupBackupper {
@@ -88,7 +88,7 @@ type defaultGroupBackupper struct {
 	cohabitatingResources    map[string]*cohabitatingResource
 	podCommandExecutor       podexec.PodCommandExecutor
 	tarWriter                tarWriter
-	resticBackupper          restic.Backupper
+	resticBackupper          podvolume.Backupper
 	resticSnapshotTracker    *pvcSnapshotTracker
 	resourceBackupperFactory resourceBackupperFactory
 	volumeSnapshotterGetter  VolumeSnapshotterGetter
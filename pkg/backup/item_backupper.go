@@ -36,11 +36,14 @@ import (
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/credentials"
 	"github.com/heptio/velero/pkg/discovery"
 	"github.com/heptio/velero/pkg/kuberesource"
+	"github.com/heptio/velero/pkg/label"
+	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/velero"
 	"github.com/heptio/velero/pkg/podexec"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/volume"
 )
 
@@ -51,7 +54,7 @@ type itemBackupperFactory interface {
 		tarWriter tarWriter,
 		dynamicFactory client.DynamicFactory,
 		discoveryHelper discovery.Helper,
-		resticBackupper restic.Backupper,
+		resticBackupper podvolume.Backupper,
 		resticSnapshotTracker *pvcSnapshotTracker,
 		volumeSnapshotterGetter VolumeSnapshotterGetter,
 	) ItemBackupper
@@ -65,7 +68,7 @@ func (f *defaultItemBackupperFactory) newItemBackupper(
 	tarWriter tarWriter,
 	dynamicFactory client.DynamicFactory,
 	discoveryHelper discovery.Helper,
-	resticBackupper restic.Backupper,
+	resticBackupper podvolume.Backupper,
 	resticSnapshotTracker *pvcSnapshotTracker,
 	volumeSnapshotterGetter VolumeSnapshotterGetter,
 ) ItemBackupper {
@@ -80,6 +83,7 @@ func (f *defaultItemBackupperFactory) newItemBackupper(
 
 		itemHookHandler: &defaultItemHookHandler{
 			podCommandExecutor: podCommandExecutor,
+			dynamicFactory:     dynamicFactory,
 		},
 	}
 
@@ -98,7 +102,7 @@ type defaultItemBackupper struct {
 	tarWriter               tarWriter
 	dynamicFactory          client.DynamicFactory
 	discoveryHelper         discovery.Helper
-	resticBackupper         restic.Backupper
+	resticBackupper         podvolume.Backupper
 	resticSnapshotTracker   *pvcSnapshotTracker
 	volumeSnapshotterGetter VolumeSnapshotterGetter
 
@@ -142,6 +146,16 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		return nil
 	}
 
+	if namespace == "" && groupResource != kuberesource.Namespaces && !ib.backupRequest.ClusterResourceNames.ShouldInclude(groupResource.String(), name) {
+		log.Info("Excluding item because it's not an included cluster-scoped item")
+		return nil
+	}
+
+	if ib.backupRequest.ResourcePolicies.ShouldSkip(groupResource, obj) {
+		log.Info("Excluding item because it matches a skip policy in the backup's resource policies")
+		return nil
+	}
+
 	if metadata.GetDeletionTimestamp() != nil {
 		log.Info("Skipping item because it's being deleted.")
 		return nil
@@ -153,11 +167,11 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		name:      name,
 	}
 
-	if _, exists := ib.backupRequest.BackedUpItems[key]; exists {
+	if !ib.backupRequest.markBackedUp(key) {
 		log.Info("Skipping item because it's already been backed up.")
 		return nil
 	}
-	ib.backupRequest.BackedUpItems[key] = struct{}{}
+	defer ib.backupRequest.addItemBackedUp()
 
 	log.Info("Backing up item")
 
@@ -179,16 +193,27 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 			backupErrs = append(backupErrs, errors.WithStack(err))
 			// nil it on error since it's not valid
 			pod = nil
-		} else {
+		} else if !ib.backupRequest.ObjectsOnly() {
 			// get the volumes to backup using restic, and add any of them that are PVCs to the pvc snapshot
 			// tracker, so that when we backup PVCs/PVs via an item action in the next step, we don't snapshot
 			// PVs that will have their data backed up with restic.
-			resticVolumesToBackup = restic.GetVolumesToBackup(pod)
+			resticVolumesToBackup = podvolume.GetPodVolumesToBackup(pod, podvolume.DefaultVolumesToRestic(ib.backupRequest.Backup))
 
 			ib.resticSnapshotTracker.Track(pod, resticVolumesToBackup)
 		}
 	}
 
+	if groupResource == kuberesource.PersistentVolumeClaims {
+		// the claim's volume data is captured afterwards, when its persistent volume is
+		// snapshotted or its pod's volumes are backed up with restic, and the report is
+		// updated then.
+		if ib.backupRequest.ObjectsOnly() {
+			ib.backupRequest.recordVolumeCoverage(namespace, name, VolumeCoverageSkipped)
+		} else if _, ok := ib.backupRequest.volumeCoverage(namespace, name); !ok {
+			ib.backupRequest.recordVolumeCoverage(namespace, name, VolumeCoverageNotCaptured)
+		}
+	}
+
 	updatedObj, err := ib.executeActions(log, obj, groupResource, name, namespace, metadata)
 	if err != nil {
 		backupErrs = append(backupErrs, err)
@@ -220,8 +245,14 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		// even if there are errors.
 		podVolumeBackups, errs := ib.backupPodVolumes(log, pod, resticVolumesToBackup)
 
-		ib.backupRequest.PodVolumeBackups = append(ib.backupRequest.PodVolumeBackups, podVolumeBackups...)
+		ib.backupRequest.addPodVolumeBackups(podVolumeBackups...)
 		backupErrs = append(backupErrs, errs...)
+
+		for _, pvb := range podVolumeBackups {
+			if claimName := pvb.Annotations[podvolume.PVCNameAnnotation]; claimName != "" {
+				ib.backupRequest.recordVolumeCoverage(pod.Namespace, claimName, VolumeCoverageRestic)
+			}
+		}
 	}
 
 	log.Debug("Executing post hooks")
@@ -233,6 +264,11 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		return kubeerrs.NewAggregate(backupErrs)
 	}
 
+	if ib.backupRequest.VolumeSnapshotOnly() {
+		log.Debug("Not writing item to backup tarball because the backup is in VolumeSnapshotOnly mode")
+		return nil
+	}
+
 	var filePath string
 	if namespace != "" {
 		filePath = filepath.Join(api.ResourcesDir, groupResource.String(), api.NamespaceScopedDir, namespace, name+".json")
@@ -240,6 +276,17 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		filePath = filepath.Join(api.ResourcesDir, groupResource.String(), api.ClusterScopedDir, name+".json")
 	}
 
+	if ib.backupRequest.SkipUnchangedItems() {
+		unchanged, err := ib.indexItem(filePath, obj)
+		if err != nil {
+			return err
+		}
+		if unchanged {
+			log.Debug("Not writing item to backup tarball because it hasn't changed since the previous backup")
+			return nil
+		}
+	}
+
 	itemBytes, err := json.Marshal(obj.UnstructuredContent())
 	if err != nil {
 		return errors.WithStack(err)
@@ -253,6 +300,11 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		ModTime:  time.Now(),
 	}
 
+	// items may be backed up concurrently, so the header and content are
+	// written together.
+	ib.backupRequest.lock.Lock()
+	defer ib.backupRequest.lock.Unlock()
+
 	if err := ib.tarWriter.WriteHeader(hdr); err != nil {
 		return errors.WithStack(err)
 	}
@@ -264,6 +316,31 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 	return nil
 }
 
+// indexItem records the item in the backup's item index, and returns true if
+// the item hasn't changed since the previous backup. An unchanged item's entry
+// refers to the backup whose tarball already contains its content, so that
+// restores never have to follow more than one reference.
+func (ib *defaultItemBackupper) indexItem(filePath string, obj runtime.Unstructured) (bool, error) {
+	hash, err := ib.backupRequest.itemComparer.Hash(obj)
+	if err != nil {
+		return false, errors.Wrap(err, "error computing item hash")
+	}
+
+	ib.backupRequest.lock.Lock()
+	defer ib.backupRequest.lock.Unlock()
+
+	if previous, ok := ib.backupRequest.PreviousItemIndex[filePath]; ok && previous.Hash == hash {
+		ib.backupRequest.ItemIndex[filePath] = previous
+		return true, nil
+	}
+
+	ib.backupRequest.ItemIndex[filePath] = persistence.BackupItemIndexEntry{
+		Hash:   hash,
+		Backup: ib.backupRequest.Name,
+	}
+	return false, nil
+}
+
 // backupPodVolumes triggers restic backups of the specified pod volumes, and returns a list of PodVolumeBackups
 // for volumes that were successfully backed up, and a slice of any errors that were encountered.
 func (ib *defaultItemBackupper) backupPodVolumes(log logrus.FieldLogger, pod *corev1api.Pod, volumes []string) ([]*velerov1api.PodVolumeBackup, []error) {
@@ -352,7 +429,12 @@ func (ib *defaultItemBackupper) volumeSnapshotter(snapshotLocation *api.VolumeSn
 		return nil, err
 	}
 
-	if err := bs.Init(snapshotLocation.Spec.Config); err != nil {
+	config, err := credentials.WithCredentialsFile(snapshotLocation.Spec.Config, snapshotLocation.Spec.Credential)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bs.Init(config); err != nil {
 		return nil, err
 	}
 
@@ -374,6 +456,11 @@ const zoneLabel = "failure-domain.beta.kubernetes.io/zone"
 func (ib *defaultItemBackupper) takePVSnapshot(obj runtime.Unstructured, log logrus.FieldLogger) error {
 	log.Info("Executing takePVSnapshot")
 
+	if ib.backupRequest.ObjectsOnly() {
+		log.Info("Backup only captures Kubernetes objects; skipping volume snapshot action.")
+		return nil
+	}
+
 	if ib.backupRequest.Spec.SnapshotVolumes != nil && !*ib.backupRequest.Spec.SnapshotVolumes {
 		log.Info("Backup has volume snapshots disabled; skipping volume snapshot action.")
 		return nil
@@ -401,8 +488,8 @@ func (ib *defaultItemBackupper) takePVSnapshot(obj runtime.Unstructured, log log
 	}
 
 	var (
-		volumeID, location string
-		volumeSnapshotter  velero.VolumeSnapshotter
+		volumeID, location, provider string
+		volumeSnapshotter            velero.VolumeSnapshotter
 	)
 
 	for _, snapshotLocation := range ib.backupRequest.SnapshotLocations {
@@ -426,10 +513,15 @@ func (ib *defaultItemBackupper) takePVSnapshot(obj runtime.Unstructured, log log
 		log.Infof("Got volume ID for persistent volume")
 		volumeSnapshotter = bs
 		location = snapshotLocation.Name
+		provider = snapshotLocation.Spec.Provider
 		break
 	}
 
 	if volumeSnapshotter == nil {
+		if pv.Spec.CSI != nil && pv.Spec.ClaimRef != nil && ib.backupRequest.csiSnapshotter != nil {
+			return ib.takeCSISnapshot(pv, pvFailureDomainZone, log)
+		}
+
 		log.Info("Persistent volume is not a supported volume type for snapshots, skipping.")
 		return nil
 	}
@@ -451,6 +543,7 @@ func (ib *defaultItemBackupper) takePVSnapshot(obj runtime.Unstructured, log log
 
 	log.Info("Snapshotting persistent volume")
 	snapshot := volumeSnapshot(ib.backupRequest.Backup, pv.Name, volumeID, volumeType, pvFailureDomainZone, location, iops)
+	snapshot.Spec.Provider = provider
 
 	var errs []error
 	snapshotID, err := volumeSnapshotter.CreateSnapshot(snapshot.Spec.ProviderVolumeID, snapshot.Spec.VolumeAZ, tags)
@@ -460,8 +553,75 @@ func (ib *defaultItemBackupper) takePVSnapshot(obj runtime.Unstructured, log log
 	} else {
 		snapshot.Status.Phase = volume.SnapshotPhaseCompleted
 		snapshot.Status.ProviderSnapshotID = snapshotID
+
+		if pv.Spec.ClaimRef != nil {
+			ib.backupRequest.recordVolumeCoverage(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name, VolumeCoverageSnapshot)
+		}
+
+		if ib.backupRequest.Spec.SnapshotMoveData && pv.Spec.ClaimRef != nil {
+			log.Info("Moving snapshot data")
+			moved, err := ib.moveProviderSnapshotData(obj, pv, snapshot, volumeSnapshotter, log)
+			if err != nil {
+				errs = append(errs, errors.WithMessage(err, "error moving snapshot data, keeping the snapshot"))
+			}
+			if moved {
+				return nil
+			}
+		}
+	}
+	ib.backupRequest.addVolumeSnapshot(snapshot)
+
+	// nil errors are automatically removed
+	return kubeerrs.NewAggregate(errs)
+}
+
+// takeCSISnapshot snapshots a CSI persistent volume through the Kubernetes
+// VolumeSnapshot API, if its driver has a VolumeSnapshotClass.
+func (ib *defaultItemBackupper) takeCSISnapshot(pv *corev1api.PersistentVolume, az string, log logrus.FieldLogger) error {
+	log = log.WithField("csiDriver", pv.Spec.CSI.Driver)
+
+	class, err := ib.backupRequest.csiSnapshotter.SnapshotClass(pv.Spec.CSI.Driver)
+	if err != nil {
+		return errors.WithMessage(err, "error getting volume snapshot class")
+	}
+	if class == "" {
+		log.Info("Persistent volume's CSI driver has no volume snapshot class, skipping.")
+		return nil
+	}
+
+	log.WithField("volumeSnapshotClass", class).Info("Snapshotting persistent volume through the VolumeSnapshot API")
+	snapshot := volumeSnapshot(ib.backupRequest.Backup, pv.Name, pv.Spec.CSI.VolumeHandle, "", az, "", nil)
+	snapshot.Spec.CSIDriver = pv.Spec.CSI.Driver
+	snapshot.Spec.CSIVolumeSnapshotClass = class
+
+	var errs []error
+	claim := pv.Spec.ClaimRef
+	labels := map[string]string{
+		api.BackupNameLabel: label.GetValidName(ib.backupRequest.Name),
+	}
+	csiSnapshot, err := ib.backupRequest.csiSnapshotter.CreateSnapshot(claim.Namespace, claim.Name, pv.Spec.CSI.Driver, class, fmt.Sprintf("velero-%s-%s", ib.backupRequest.Name, claim.Name), labels)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "error taking CSI snapshot of volume"))
+		snapshot.Status.Phase = volume.SnapshotPhaseFailed
+	} else {
+		snapshot.Status.Phase = volume.SnapshotPhaseCompleted
+		snapshot.Status.ProviderSnapshotID = csiSnapshot.Handle
+		snapshot.Status.CSIVolumeSnapshotContent = csiSnapshot.ContentName
+
+		ib.backupRequest.recordVolumeCoverage(claim.Namespace, claim.Name, VolumeCoverageSnapshot)
+
+		if ib.backupRequest.Spec.SnapshotMoveData {
+			log.Info("Moving snapshot data")
+			moved, err := ib.moveCSISnapshotData(pv, csiSnapshot, log)
+			if err != nil {
+				errs = append(errs, errors.WithMessage(err, "error moving snapshot data, keeping the snapshot"))
+			}
+			if moved {
+				return nil
+			}
+		}
 	}
-	ib.backupRequest.VolumeSnapshots = append(ib.backupRequest.VolumeSnapshots, snapshot)
+	ib.backupRequest.addVolumeSnapshot(snapshot)
 
 	// nil errors are automatically removed
 	return kubeerrs.NewAggregate(errs)
@@ -0,0 +1,72 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ItemComparer decides whether an item has changed since an earlier backup,
+// for backups that skip unchanged items.
+type ItemComparer interface {
+	// Hash returns a hash of the item. Two versions of an item with the
+	// same hash are considered unchanged, so only the content of the first
+	// one is stored.
+	Hash(obj runtime.Unstructured) (string, error)
+}
+
+// contentItemComparer is the default ItemComparer. It hashes an item's JSON,
+// ignoring the metadata fields that the API server updates on every write.
+type contentItemComparer struct{}
+
+// NewContentItemComparer returns an ItemComparer that considers an item
+// changed if anything other than its resource version or managed fields
+// has changed.
+func NewContentItemComparer() ItemComparer {
+	return &contentItemComparer{}
+}
+
+func (c *contentItemComparer) Hash(obj runtime.Unstructured) (string, error) {
+	// round-trip the item through JSON to get a copy that the ignored
+	// fields can be removed from.
+	data, err := json.Marshal(obj.UnstructuredContent())
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	content := make(map[string]interface{})
+	if err := json.Unmarshal(data, &content); err != nil {
+		return "", errors.WithStack(err)
+	}
+	unstructured.RemoveNestedField(content, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(content, "metadata", "managedFields")
+
+	// maps are marshaled with sorted keys, so the same content always has
+	// the same hash.
+	if data, err = json.Marshal(content); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/heptio/velero/pkg/builder"
+)
+
+func TestContentItemComparer(t *testing.T) {
+	comparer := NewContentItemComparer()
+
+	hash := func(obj interface{}) string {
+		res, err := comparer.Hash(&unstructured.Unstructured{Object: toUnstructuredOrFail(t, obj)})
+		require.NoError(t, err)
+		return res
+	}
+
+	original := hash(builder.ForPod("ns-1", "pod-1").ObjectMeta(builder.WithLabels("a", "b")).Result())
+
+	tests := []struct {
+		name    string
+		obj     interface{}
+		changed bool
+	}{
+		{
+			name: "same content",
+			obj:  builder.ForPod("ns-1", "pod-1").ObjectMeta(builder.WithLabels("a", "b")).Result(),
+		},
+		{
+			name: "different resource version",
+			obj:  builder.ForPod("ns-1", "pod-1").ObjectMeta(builder.WithLabels("a", "b"), builder.WithResourceVersion("2")).Result(),
+		},
+		{
+			name:    "different labels",
+			obj:     builder.ForPod("ns-1", "pod-1").ObjectMeta(builder.WithLabels("a", "c")).Result(),
+			changed: true,
+		},
+		{
+			name:    "different name",
+			obj:     builder.ForPod("ns-1", "pod-2").ObjectMeta(builder.WithLabels("a", "b")).Result(),
+			changed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.changed, hash(tc.obj) != original)
+		})
+	}
+}
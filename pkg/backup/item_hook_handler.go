@@ -19,17 +19,21 @@ package backup
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
 	"github.com/heptio/velero/pkg/kuberesource"
 	"github.com/heptio/velero/pkg/podexec"
 	"github.com/heptio/velero/pkg/util/collections"
@@ -60,6 +64,7 @@ type itemHookHandler interface {
 // defaultItemHookHandler is the default itemHookHandler.
 type defaultItemHookHandler struct {
 	podCommandExecutor podexec.PodCommandExecutor
+	dynamicFactory     client.DynamicFactory
 }
 
 func (h *defaultItemHookHandler) handleHooks(
@@ -69,8 +74,9 @@ func (h *defaultItemHookHandler) handleHooks(
 	resourceHooks []resourceHook,
 	phase hookPhase,
 ) error {
-	// We only support hooks on pods right now
-	if groupResource != kuberesource.Pods {
+	// Hooks are executed in pods, which are either the item itself or the
+	// pods of a workload.
+	if groupResource != kuberesource.Pods && !isWorkload(groupResource) {
 		return nil
 	}
 
@@ -83,10 +89,13 @@ func (h *defaultItemHookHandler) handleHooks(
 	name := metadata.GetName()
 
 	// If the pod has the hook specified via annotations, that takes priority.
-	hookFromAnnotations := getPodExecHookFromAnnotations(metadata.GetAnnotations(), phase)
-	if phase == hookPhasePre && hookFromAnnotations == nil {
-		// See if the pod has the legacy hook annotation keys (i.e. without a phase specified)
-		hookFromAnnotations = getPodExecHookFromAnnotations(metadata.GetAnnotations(), "")
+	var hookFromAnnotations *api.ExecHook
+	if groupResource == kuberesource.Pods {
+		hookFromAnnotations = getPodExecHookFromAnnotations(metadata.GetAnnotations(), phase)
+		if phase == hookPhasePre && hookFromAnnotations == nil {
+			// See if the pod has the legacy hook annotation keys (i.e. without a phase specified)
+			hookFromAnnotations = getPodExecHookFromAnnotations(metadata.GetAnnotations(), "")
+		}
 	}
 	if hookFromAnnotations != nil {
 		hookLog := log.WithFields(
@@ -120,22 +129,59 @@ func (h *defaultItemHookHandler) handleHooks(
 			hooks = resourceHook.post
 		}
 		for _, hook := range hooks {
-			if groupResource == kuberesource.Pods {
-				if hook.Exec != nil {
-					hookLog := log.WithFields(
-						logrus.Fields{
-							"hookSource": "backupSpec",
-							"hookType":   "exec",
-							"hookPhase":  phase,
-						},
-					)
-					err := h.podCommandExecutor.ExecutePodCommand(hookLog, obj.UnstructuredContent(), namespace, name, resourceHook.name, hook.Exec)
-					if err != nil {
-						hookLog.WithError(err).Error("Error executing hook")
-						if hook.Exec.OnError == api.HookErrorModeFail {
-							return err
-						}
-					}
+			if hook.Exec != nil {
+				hookLog := log.WithFields(
+					logrus.Fields{
+						"hookSource": "backupSpec",
+						"hookType":   "exec",
+						"hookPhase":  phase,
+					},
+				)
+				if err := h.executeExecHook(hookLog, groupResource, obj, resourceHook.name, hook.Exec); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// isWorkload returns true if hooks applied to items of the resource are
+// executed in the items' pods, or false otherwise.
+func isWorkload(groupResource schema.GroupResource) bool {
+	return groupResource == kuberesource.Deployments || groupResource == kuberesource.StatefulSets
+}
+
+// executeExecHook executes the commands of an exec hook, in order, in the
+// item if it's a pod, or in the selected pods of the item if it's a
+// workload. It returns the error of the first command that fails whose
+// OnError mode is Fail, and logs the errors of the others.
+func (h *defaultItemHookHandler) executeExecHook(log logrus.FieldLogger, groupResource schema.GroupResource, obj runtime.Unstructured, hookName string, hook *api.ExecHook) error {
+	pods := []runtime.Unstructured{obj}
+	if groupResource != kuberesource.Pods {
+		var err error
+		if pods, err = h.workloadPods(obj, hook.PodSelection); err != nil {
+			log.WithError(err).Error("Error getting pods to execute hook in")
+			if hook.OnError == api.HookErrorModeContinue {
+				return nil
+			}
+			return err
+		}
+	}
+
+	for _, pod := range pods {
+		metadata, err := meta.Accessor(pod)
+		if err != nil {
+			return errors.Wrap(err, "unable to get a metadata accessor")
+		}
+
+		for _, command := range execHookCommands(hook) {
+			err := h.podCommandExecutor.ExecutePodCommand(log, pod.UnstructuredContent(), metadata.GetNamespace(), metadata.GetName(), hookName, command)
+			if err != nil {
+				log.WithError(err).Error("Error executing hook")
+				if command.OnError == api.HookErrorModeFail {
+					return err
 				}
 			}
 		}
@@ -144,6 +190,118 @@ func (h *defaultItemHookHandler) handleHooks(
 	return nil
 }
 
+// execHookCommands returns an ExecHook for each of an exec hook's commands,
+// in order, with the hook's container, and the hook's OnError mode and
+// timeout unless the command has its own.
+func execHookCommands(hook *api.ExecHook) []*api.ExecHook {
+	var res []*api.ExecHook
+
+	// a hook without any commands is still returned, so it fails to execute.
+	if len(hook.Command) > 0 || len(hook.Commands) == 0 {
+		res = append(res, &api.ExecHook{
+			Container: hook.Container,
+			Command:   hook.Command,
+			OnError:   hook.OnError,
+			Timeout:   hook.Timeout,
+		})
+	}
+
+	for _, command := range hook.Commands {
+		execHook := &api.ExecHook{
+			Container: hook.Container,
+			Command:   command.Command,
+			OnError:   command.OnError,
+			Timeout:   command.Timeout,
+		}
+		if execHook.OnError == "" {
+			execHook.OnError = hook.OnError
+		}
+		if execHook.Timeout.Duration == 0 {
+			execHook.Timeout = hook.Timeout
+		}
+		res = append(res, execHook)
+	}
+
+	return res
+}
+
+// workloadPods returns the running pods, sorted by name, of a workload that's
+// selected by its spec.selector: all of them for HookPodSelectionAll, or
+// only the first otherwise.
+func (h *defaultItemHookHandler) workloadPods(obj runtime.Unstructured, selection api.HookPodSelection) ([]runtime.Unstructured, error) {
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get a metadata accessor")
+	}
+
+	selectorMap, found, err := unstructured.NestedMap(obj.UnstructuredContent(), "spec", "selector")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !found {
+		return nil, errors.New("workload has no pod selector")
+	}
+
+	labelSelector := new(metav1.LabelSelector)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorMap, labelSelector); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	podClient, err := h.dynamicFactory.ClientForGroupVersionResource(
+		schema.GroupVersion{Group: "", Version: "v1"},
+		metav1.APIResource{Name: "pods", Namespaced: true},
+		metadata.GetNamespace(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := podClient.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing workload's pods")
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var running []*unstructured.Unstructured
+	for _, item := range items {
+		pod, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			return nil, errors.Errorf("unexpected type %T", item)
+		}
+
+		phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+		if phase != string(corev1api.PodRunning) || pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		running = append(running, pod)
+	}
+
+	if len(running) == 0 {
+		return nil, errors.New("workload has no running pods")
+	}
+
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].GetName() < running[j].GetName()
+	})
+
+	if selection != api.HookPodSelectionAll {
+		running = running[:1]
+	}
+
+	pods := make([]runtime.Unstructured, 0, len(running))
+	for _, pod := range running {
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
 const (
 	podBackupHookContainerAnnotationKey = "hook.backup.velero.io/container"
 	podBackupHookCommandAnnotationKey   = "hook.backup.velero.io/command"
@@ -27,6 +27,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -502,6 +503,120 @@ func TestHandleHooks(t *testing.T) {
 	}
 }
 
+func TestHandleHooksForWorkload(t *testing.T) {
+	deployment := velerotest.UnstructuredOrDie(`
+	{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"metadata": {
+			"namespace": "ns",
+			"name": "db"
+		},
+		"spec": {
+			"selector": {
+				"matchLabels": {
+					"app": "db"
+				}
+			}
+		}
+	}`)
+
+	pod := func(name, phase string) *unstructured.Unstructured {
+		return velerotest.UnstructuredOrDie(fmt.Sprintf(`
+		{
+			"apiVersion": "v1",
+			"kind": "Pod",
+			"metadata": {
+				"namespace": "ns",
+				"name": %q
+			},
+			"status": {
+				"phase": %q
+			}
+		}`, name, phase))
+	}
+
+	tests := []struct {
+		name          string
+		podSelection  v1.HookPodSelection
+		pods          []*unstructured.Unstructured
+		expectedPods  []string
+		expectedError bool
+	}{
+		{
+			name:         "hook is executed in the first running pod by default",
+			pods:         []*unstructured.Unstructured{pod("db-b", "Running"), pod("db-a", "Pending"), pod("db-c", "Running")},
+			expectedPods: []string{"db-b"},
+		},
+		{
+			name:         "hook is executed in all running pods",
+			podSelection: v1.HookPodSelectionAll,
+			pods:         []*unstructured.Unstructured{pod("db-b", "Running"), pod("db-a", "Pending"), pod("db-c", "Running")},
+			expectedPods: []string{"db-b", "db-c"},
+		},
+		{
+			name:          "workload without running pods fails the hook",
+			pods:          []*unstructured.Unstructured{pod("db-a", "Pending")},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podCommandExecutor := &velerotest.MockPodCommandExecutor{}
+			defer podCommandExecutor.AssertExpectations(t)
+
+			dynamicFactory := &velerotest.FakeDynamicFactory{}
+			podClient := &velerotest.FakeDynamicClient{}
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Version: "v1"}, metav1.APIResource{Name: "pods", Namespaced: true}, "ns").Return(podClient, nil)
+
+			list := &unstructured.UnstructuredList{}
+			for _, pod := range test.pods {
+				list.Items = append(list.Items, *pod)
+			}
+			podClient.On("List", metav1.ListOptions{LabelSelector: "app=db"}).Return(list, nil)
+
+			h := &defaultItemHookHandler{
+				podCommandExecutor: podCommandExecutor,
+				dynamicFactory:     dynamicFactory,
+			}
+
+			hook := &v1.ExecHook{Command: []string{"freeze"}, PodSelection: test.podSelection}
+			for _, name := range test.expectedPods {
+				podCommandExecutor.On("ExecutePodCommand", mock.Anything, mock.Anything, "ns", name, "hook1", &v1.ExecHook{Command: []string{"freeze"}}).Return(nil)
+			}
+
+			hooks := []resourceHook{{name: "hook1", pre: []v1.BackupResourceHook{{Exec: hook}}}}
+			err := h.handleHooks(velerotest.NewLogger(), schema.GroupResource{Group: "apps", Resource: "deployments"}, deployment, hooks, hookPhasePre)
+			assert.Equal(t, test.expectedError, err != nil)
+		})
+	}
+}
+
+func TestExecHookCommands(t *testing.T) {
+	hook := &v1.ExecHook{
+		Container: "db",
+		Command:   []string{"flush"},
+		OnError:   v1.HookErrorModeFail,
+		Timeout:   metav1.Duration{Duration: time.Minute},
+		Commands: []v1.ExecHookCommand{
+			{Command: []string{"freeze"}},
+			{Command: []string{"notify"}, OnError: v1.HookErrorModeContinue, Timeout: metav1.Duration{Duration: time.Second}},
+		},
+	}
+
+	expected := []*v1.ExecHook{
+		{Container: "db", Command: []string{"flush"}, OnError: v1.HookErrorModeFail, Timeout: metav1.Duration{Duration: time.Minute}},
+		{Container: "db", Command: []string{"freeze"}, OnError: v1.HookErrorModeFail, Timeout: metav1.Duration{Duration: time.Minute}},
+		{Container: "db", Command: []string{"notify"}, OnError: v1.HookErrorModeContinue, Timeout: metav1.Duration{Duration: time.Second}},
+	}
+	assert.Equal(t, expected, execHookCommands(hook))
+
+	// a hook with only its commands doesn't execute an empty command.
+	hook.Command = nil
+	assert.Equal(t, expected[1:], execHookCommands(hook))
+}
+
 func TestGetPodExecHookFromAnnotations(t *testing.T) {
 	phases := []hookPhase{"", hookPhasePre, hookPhasePost}
 	for _, phase := range phases {
@@ -19,8 +19,12 @@ package backup
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/csi"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/resourcepolicies"
 	"github.com/heptio/velero/pkg/util/collections"
 	"github.com/heptio/velero/pkg/volume"
 )
@@ -43,15 +47,81 @@ type Request struct {
 	ResourceHooks             []resourceHook
 	ResolvedActions           []resolvedAction
 
+	// ClusterResourceNames holds the cluster-scoped items, given as
+	// <resource>/<name>, that are included in or excluded from the backup.
+	ClusterResourceNames *collections.ResourceNameIncludesExcludes
+
+	// ResourcePolicies are the resource policies from the backup's
+	// spec.resourcePolicy ConfigMap, if it has one.
+	ResourcePolicies *resourcepolicies.Policies
+
 	VolumeSnapshots  []*volume.Snapshot
 	PodVolumeBackups []*velerov1api.PodVolumeBackup
 	BackedUpItems    map[itemKey]struct{}
+
+	// VolumeCoverage is the backup's volume coverage report, which maps each
+	// backed-up persistent volume claim, as <namespace>/<name>, to a note
+	// saying how its data was captured.
+	VolumeCoverage map[string]string
+
+	// PreviousItemIndex is the item index of the backup that items are
+	// compared against when the backup skips unchanged items. If it's nil,
+	// every item is written to the backup tarball.
+	PreviousItemIndex persistence.BackupItemIndex
+
+	// ItemIndex is the backup's item index. It's only recorded for backups
+	// that skip unchanged items.
+	ItemIndex persistence.BackupItemIndex
+
+	itemComparer ItemComparer
+
+	// itemConcurrency is how many items of a resource are backed up at a
+	// time.
+	itemConcurrency int
+
+	// csiSnapshotter snapshots CSI volumes that no volume snapshot location's
+	// VolumeSnapshotter supports through the Kubernetes VolumeSnapshot API.
+	csiSnapshotter *csi.Snapshotter
+
+	// lock guards the fields that are written as items are backed up,
+	// which they may be concurrently: BackedUpItems, VolumeSnapshots,
+	// PodVolumeBackups, VolumeCoverage and ItemIndex. It's also held while
+	// an item is written to the backup tarball.
+	lock sync.Mutex
+
+	// progress is how many items the backup has found and backed up so
+	// far. It's read by the backup controller while the backup runs.
+	progress itemProgress
 }
 
+// itemProgress counts the items a backup has found and backed up. It's safe
+// for concurrent use.
+type itemProgress struct {
+	lock          sync.Mutex
+	totalItems    int
+	itemsBackedUp int
+}
+
+// Notes recorded in a backup's volume coverage report.
+const (
+	VolumeCoverageSnapshot      = "captured by volume snapshot"
+	VolumeCoverageRestic        = "captured by restic"
+	VolumeCoverageMovedSnapshot = "captured by volume snapshot, data moved with restic"
+	VolumeCoverageNotCaptured   = "not captured"
+	VolumeCoverageSkipped       = "data intentionally skipped"
+)
+
 // BackupResourceList returns the list of backed up resources grouped by the API
 // Version and Kind
 func (r *Request) BackupResourceList() map[string][]string {
 	resources := map[string][]string{}
+
+	// the items processed by a volume-snapshot-only backup aren't
+	// written to the backup tarball.
+	if r.VolumeSnapshotOnly() {
+		return resources
+	}
+
 	for i := range r.BackedUpItems {
 		entry := i.name
 		if i.namespace != "" {
@@ -67,3 +137,118 @@ func (r *Request) BackupResourceList() map[string][]string {
 
 	return resources
 }
+
+// ObjectsOnly returns true if the backup only captures Kubernetes resources,
+// and not the data of their volumes.
+func (r *Request) ObjectsOnly() bool {
+	return r.Spec.Mode == velerov1api.BackupModeObjectsOnly
+}
+
+// VolumeSnapshotOnly returns true if the backup only takes volume snapshots
+// of the selected persistent volume claims, and doesn't capture any
+// Kubernetes resources.
+func (r *Request) VolumeSnapshotOnly() bool {
+	return r.Backup != nil && r.Spec.Mode == velerov1api.BackupModeVolumeSnapshotOnly
+}
+
+// SkipUnchangedItems returns true if items that haven't changed since the
+// previous backup are stored as references to it rather than written to
+// the backup tarball.
+func (r *Request) SkipUnchangedItems() bool {
+	return r.Spec.SkipUnchangedItems && !r.VolumeSnapshotOnly()
+}
+
+// volumeCoverage returns how the data of the persistent volume claim with
+// the given namespace and name was captured, if it's been recorded.
+func (r *Request) volumeCoverage(namespace, name string) (string, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	note, ok := r.VolumeCoverage[key(namespace, name)]
+	return note, ok
+}
+
+// recordVolumeCoverage records how the data of the persistent volume claim
+// with the given namespace and name was captured.
+func (r *Request) recordVolumeCoverage(namespace, name, note string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.VolumeCoverage == nil {
+		r.VolumeCoverage = make(map[string]string)
+	}
+	r.VolumeCoverage[key(namespace, name)] = note
+}
+
+// markBackedUp records that the item is being backed up, and returns false
+// if it already has been.
+func (r *Request) markBackedUp(key itemKey) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, exists := r.BackedUpItems[key]; exists {
+		return false
+	}
+	r.BackedUpItems[key] = struct{}{}
+	return true
+}
+
+// addVolumeSnapshot records a volume snapshot taken by the backup.
+func (r *Request) addVolumeSnapshot(snapshot *volume.Snapshot) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.VolumeSnapshots = append(r.VolumeSnapshots, snapshot)
+}
+
+// addPodVolumeBackups records pod volume backups taken by the backup.
+func (r *Request) addPodVolumeBackups(podVolumeBackups ...*velerov1api.PodVolumeBackup) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.PodVolumeBackups = append(r.PodVolumeBackups, podVolumeBackups...)
+}
+
+// Progress returns how many items the backup has found and backed up so far.
+// It's safe to call while the backup is running.
+func (r *Request) Progress() velerov1api.BackupProgress {
+	r.progress.lock.Lock()
+	defer r.progress.lock.Unlock()
+
+	return velerov1api.BackupProgress{
+		TotalItems:    r.progress.totalItems,
+		ItemsBackedUp: r.progress.itemsBackedUp,
+	}
+}
+
+// addItemsFound records that the backup has found more items to back up.
+func (r *Request) addItemsFound(count int) {
+	r.progress.lock.Lock()
+	defer r.progress.lock.Unlock()
+
+	r.progress.totalItems += count
+}
+
+// addItemBackedUp records that the backup has backed up an item. Items that
+// weren't found by listing a resource, such as the additional items returned
+// by backup item actions, are added to the total when they're backed up.
+func (r *Request) addItemBackedUp() {
+	r.progress.lock.Lock()
+	defer r.progress.lock.Unlock()
+
+	r.progress.itemsBackedUp++
+	if r.progress.itemsBackedUp > r.progress.totalItems {
+		r.progress.totalItems = r.progress.itemsBackedUp
+	}
+}
+
+// setItemsBackedUp records the final number of items the backup has backed
+// up, which is also the number of items it found once it has listed every
+// resource.
+func (r *Request) setItemsBackedUp(count int) {
+	r.progress.lock.Lock()
+	defer r.progress.lock.Unlock()
+
+	r.progress.totalItems = count
+	r.progress.itemsBackedUp = count
+}
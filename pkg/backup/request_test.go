@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
 )
 
 func TestRequest_BackupResourceList(t *testing.T) {
@@ -80,3 +82,21 @@ func TestRequest_BackupResourceListEntriesSorted(t *testing.T) {
 		"v1/Pod": {"ns1/pod1", "ns2/pod2"},
 	}, req.BackupResourceList())
 }
+
+func TestRequest_Progress(t *testing.T) {
+	req := new(Request)
+	assert.Equal(t, velerov1api.BackupProgress{}, req.Progress())
+
+	req.addItemsFound(2)
+	req.addItemBackedUp()
+	assert.Equal(t, velerov1api.BackupProgress{TotalItems: 2, ItemsBackedUp: 1}, req.Progress())
+
+	// items that weren't found by listing a resource are added to the total
+	// when they're backed up.
+	req.addItemBackedUp()
+	req.addItemBackedUp()
+	assert.Equal(t, velerov1api.BackupProgress{TotalItems: 3, ItemsBackedUp: 3}, req.Progress())
+
+	req.setItemsBackedUp(4)
+	assert.Equal(t, velerov1api.BackupProgress{TotalItems: 4, ItemsBackedUp: 4}, req.Progress())
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package backup
 
 import (
+	"sync"
+
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -26,11 +28,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
 
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/client"
 	"github.com/heptio/velero/pkg/discovery"
 	"github.com/heptio/velero/pkg/kuberesource"
 	"github.com/heptio/velero/pkg/podexec"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/util/collections"
 )
 
@@ -43,7 +46,7 @@ type resourceBackupperFactory interface {
 		cohabitatingResources map[string]*cohabitatingResource,
 		podCommandExecutor podexec.PodCommandExecutor,
 		tarWriter tarWriter,
-		resticBackupper restic.Backupper,
+		resticBackupper podvolume.Backupper,
 		resticSnapshotTracker *pvcSnapshotTracker,
 		volumeSnapshotterGetter VolumeSnapshotterGetter,
 	) resourceBackupper
@@ -59,7 +62,7 @@ func (f *defaultResourceBackupperFactory) newResourceBackupper(
 	cohabitatingResources map[string]*cohabitatingResource,
 	podCommandExecutor podexec.PodCommandExecutor,
 	tarWriter tarWriter,
-	resticBackupper restic.Backupper,
+	resticBackupper podvolume.Backupper,
 	resticSnapshotTracker *pvcSnapshotTracker,
 	volumeSnapshotterGetter VolumeSnapshotterGetter,
 ) resourceBackupper {
@@ -91,7 +94,7 @@ type defaultResourceBackupper struct {
 	cohabitatingResources   map[string]*cohabitatingResource
 	podCommandExecutor      podexec.PodCommandExecutor
 	tarWriter               tarWriter
-	resticBackupper         restic.Backupper
+	resticBackupper         podvolume.Backupper
 	resticSnapshotTracker   *pvcSnapshotTracker
 	itemBackupperFactory    itemBackupperFactory
 	volumeSnapshotterGetter VolumeSnapshotterGetter
@@ -111,11 +114,16 @@ func (rb *defaultResourceBackupper) backupResource(group *metav1.APIResourceList
 
 	clusterScoped := !resource.Namespaced
 
+	if rb.backupRequest.VolumeSnapshotOnly() && gr != kuberesource.PersistentVolumeClaims {
+		log.Info("Skipping resource because only persistent volume claims are backed up in VolumeSnapshotOnly mode")
+		return nil
+	}
+
 	// If the resource we are backing up is NOT namespaces, and it is cluster-scoped, check to see if
 	// we should include it based on the IncludeClusterResources setting.
 	if gr != kuberesource.Namespaces && clusterScoped {
 		if rb.backupRequest.Spec.IncludeClusterResources == nil {
-			if !rb.backupRequest.NamespaceIncludesExcludes.IncludeEverything() {
+			if !rb.backupRequest.NamespaceIncludesExcludes.IncludeEverything() && !rb.backupRequest.ClusterResourceNames.IncludesResource(gr.String()) {
 				// when IncludeClusterResources == nil (auto), only directly
 				// back up cluster-scoped resources if we're doing a full-cluster
 				// (all namespaces) backup. Note that in the case of a subset of
@@ -123,6 +131,8 @@ func (rb *defaultResourceBackupper) backupResource(group *metav1.APIResourceList
 				// may still be backed up if triggered by a custom action (e.g. PVC->PV).
 				// If we're processing namespaces themselves, we will not skip here, they may be
 				// filtered out later.
+				// Resources that have items included by name in the backup's
+				// spec.includedClusterResourceNames are always backed up.
 				log.Info("Skipping resource because it's cluster-scoped and only specific namespaces are included in the backup")
 				return nil
 			}
@@ -150,17 +160,6 @@ func (rb *defaultResourceBackupper) backupResource(group *metav1.APIResourceList
 		cohabitator.seen = true
 	}
 
-	itemBackupper := rb.itemBackupperFactory.newItemBackupper(
-		rb.backupRequest,
-		rb.podCommandExecutor,
-		rb.tarWriter,
-		rb.dynamicFactory,
-		rb.discoveryHelper,
-		rb.resticBackupper,
-		rb.resticSnapshotTracker,
-		rb.volumeSnapshotterGetter,
-	)
-
 	namespacesToList := getNamespacesToList(rb.backupRequest.NamespaceIncludesExcludes)
 
 	// Check if we're backing up namespaces, and only certain ones
@@ -169,15 +168,20 @@ func (rb *defaultResourceBackupper) backupResource(group *metav1.APIResourceList
 		if err != nil {
 			log.WithError(err).Error("Error getting dynamic client")
 		} else {
-			var labelSelector labels.Selector
-			if rb.backupRequest.Spec.LabelSelector != nil {
-				labelSelector, err = metav1.LabelSelectorAsSelector(rb.backupRequest.Spec.LabelSelector)
+			var labelSelectors []labels.Selector
+			for _, selector := range backupLabelSelectors(&rb.backupRequest.Spec) {
+				if selector == nil {
+					continue
+				}
+				labelSelector, err := metav1.LabelSelectorAsSelector(selector)
 				if err != nil {
 					// This should never happen...
 					return errors.Wrap(err, "invalid label selector")
 				}
+				labelSelectors = append(labelSelectors, labelSelector)
 			}
 
+			itemBackupper := rb.newItemBackupper()
 			for _, ns := range namespacesToList {
 				log = log.WithField("namespace", ns)
 				log.Info("Getting namespace")
@@ -187,11 +191,11 @@ func (rb *defaultResourceBackupper) backupResource(group *metav1.APIResourceList
 					continue
 				}
 
-				labels := labels.Set(unstructured.GetLabels())
-				if labelSelector != nil && !labelSelector.Matches(labels) {
+				if !matchesAny(labelSelectors, labels.Set(unstructured.GetLabels())) {
 					log.Info("Skipping namespace because it does not match the backup's label selector")
 					continue
 				}
+				rb.backupRequest.addItemsFound(1)
 
 				if err := itemBackupper.backupItem(log, unstructured, gr); err != nil {
 					log.WithError(errors.WithStack(err)).Error("Error backing up namespace")
@@ -207,8 +211,9 @@ func (rb *defaultResourceBackupper) backupResource(group *metav1.APIResourceList
 		namespacesToList = []string{""}
 	}
 
+	var itemsToBackUp []itemToBackUp
 	for _, namespace := range namespacesToList {
-		log = log.WithField("namespace", namespace)
+		log := log.WithField("namespace", namespace)
 
 		resourceClient, err := rb.dynamicFactory.ClientForGroupVersionResource(gv, resource, namespace)
 		if err != nil {
@@ -216,26 +221,15 @@ func (rb *defaultResourceBackupper) backupResource(group *metav1.APIResourceList
 			continue
 		}
 
-		labelSelector := "velero.io/exclude-from-backup!=true"
-		if selector := rb.backupRequest.Spec.LabelSelector; selector != nil {
-			labelSelector = labelSelector + "," + metav1.FormatLabelSelector(selector)
-		}
-
 		log.Info("Listing items")
-		unstructuredList, err := resourceClient.List(metav1.ListOptions{LabelSelector: labelSelector})
+		items, err := listItems(resourceClient, backupLabelSelectors(&rb.backupRequest.Spec))
 		if err != nil {
-			log.WithError(errors.WithStack(err)).Error("Error listing items")
-			continue
-		}
-
-		// do the backup
-		items, err := meta.ExtractList(unstructuredList)
-		if err != nil {
-			log.WithError(errors.WithStack(err)).Error("Error extracting list")
+			log.WithError(err).Error("Error listing items")
 			continue
 		}
 
 		log.Infof("Retrieved %d items", len(items))
+		rb.backupRequest.addItemsFound(len(items))
 
 		for _, item := range items {
 			unstructured, ok := item.(runtime.Unstructured)
@@ -255,25 +249,170 @@ func (rb *defaultResourceBackupper) backupResource(group *metav1.APIResourceList
 				continue
 			}
 
-			err = itemBackupper.backupItem(log, unstructured, gr)
-			if aggregate, ok := err.(kubeerrs.Aggregate); ok {
-				log.WithField("name", metadata.GetName()).Infof("%d errors encountered backup up item", len(aggregate.Errors()))
-				// log each error separately so we get error location info in the log, and an
-				// accurate count of errors
-				for _, err = range aggregate.Errors() {
-					log.WithError(err).WithField("name", metadata.GetName()).Error("Error backing up item")
-				}
-
+			if clusterScoped && gr != kuberesource.Namespaces && !rb.backupRequest.ClusterResourceNames.ShouldInclude(gr.String(), metadata.GetName()) {
+				log.WithField("name", metadata.GetName()).Info("Skipping item because it's not an included cluster-scoped item")
 				continue
 			}
+
+			itemsToBackUp = append(itemsToBackUp, itemToBackUp{log: log, name: metadata.GetName(), item: unstructured})
+		}
+	}
+
+	rb.backupItems(gr, itemsToBackUp)
+
+	return nil
+}
+
+// backupLabelSelectors returns the label selectors that a backup's items
+// must match any of: its OR-able label selectors if it has any, or else its
+// label selector, which may be nil to match every item.
+func backupLabelSelectors(spec *velerov1api.BackupSpec) []*metav1.LabelSelector {
+	if len(spec.OrLabelSelectors) == 0 {
+		return []*metav1.LabelSelector{spec.LabelSelector}
+	}
+
+	selectors := make([]*metav1.LabelSelector, 0, len(spec.OrLabelSelectors))
+	for i := range spec.OrLabelSelectors {
+		selectors = append(selectors, &spec.OrLabelSelectors[i])
+	}
+	return selectors
+}
+
+// matchesAny returns whether the given labels match any of the selectors.
+// An empty list of selectors matches all labels.
+func matchesAny(selectors []labels.Selector, set labels.Set) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+
+	for _, selector := range selectors {
+		if selector.Matches(set) {
+			return true
+		}
+	}
+	return false
+}
+
+// listItems lists the items of a resource that match any of the given label
+// selectors, which may include a nil selector to match every item, leaving
+// out items labeled to be excluded from backups. Items that match several
+// selectors are only returned once.
+func listItems(resourceClient client.Dynamic, selectors []*metav1.LabelSelector) ([]runtime.Object, error) {
+	var (
+		items []runtime.Object
+		seen  = make(map[string]struct{})
+	)
+
+	for _, selector := range selectors {
+		labelSelector := "velero.io/exclude-from-backup!=true"
+		if selector != nil {
+			labelSelector = labelSelector + "," + metav1.FormatLabelSelector(selector)
+		}
+
+		unstructuredList, err := resourceClient.List(metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		listed, err := meta.ExtractList(unstructuredList)
+		if err != nil {
+			return nil, errors.Wrap(err, "error extracting list")
+		}
+
+		if len(selectors) == 1 {
+			return listed, nil
+		}
+
+		for _, item := range listed {
+			metadata, err := meta.Accessor(item)
 			if err != nil {
-				log.WithError(err).WithField("name", metadata.GetName()).Error("Error backing up item")
+				return nil, errors.WithStack(err)
+			}
+
+			key := metadata.GetNamespace() + "/" + metadata.GetName()
+			if _, ok := seen[key]; ok {
 				continue
 			}
+			seen[key] = struct{}{}
+			items = append(items, item)
 		}
 	}
 
-	return nil
+	return items, nil
+}
+
+// itemToBackUp is an item of a resource that's been listed and is to be
+// backed up.
+type itemToBackUp struct {
+	log  logrus.FieldLogger
+	name string
+	item runtime.Unstructured
+}
+
+// backupItems backs up a resource's items, up to the backup's item
+// concurrency at a time, and returns once they've all been backed up, so
+// that the resources of a group are still backed up in order. Each worker
+// has its own item backupper, so only the state they share through the
+// backup request needs to be safe for concurrent use.
+func (rb *defaultResourceBackupper) backupItems(gr schema.GroupResource, items []itemToBackUp) {
+	workers := rb.backupRequest.itemConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	queue := make(chan itemToBackUp)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		itemBackupper := rb.newItemBackupper()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				backupItem(itemBackupper, gr, item)
+			}
+		}()
+	}
+
+	for _, item := range items {
+		queue <- item
+	}
+	close(queue)
+	wg.Wait()
+}
+
+func backupItem(itemBackupper ItemBackupper, gr schema.GroupResource, item itemToBackUp) {
+	log := item.log.WithField("name", item.name)
+
+	err := itemBackupper.backupItem(item.log, item.item, gr)
+	if aggregate, ok := err.(kubeerrs.Aggregate); ok {
+		log.Infof("%d errors encountered backup up item", len(aggregate.Errors()))
+		// log each error separately so we get error location info in the log, and an
+		// accurate count of errors
+		for _, err = range aggregate.Errors() {
+			log.WithError(err).Error("Error backing up item")
+		}
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Error backing up item")
+	}
+}
+
+func (rb *defaultResourceBackupper) newItemBackupper() ItemBackupper {
+	return rb.itemBackupperFactory.newItemBackupper(
+		rb.backupRequest,
+		rb.podCommandExecutor,
+		rb.tarWriter,
+		rb.dynamicFactory,
+		rb.discoveryHelper,
+		rb.resticBackupper,
+		rb.resticSnapshotTracker,
+		rb.volumeSnapshotterGetter,
+	)
 }
 
 // getNamespacesToList examines ie and resolves the includes and excludes to a full list of
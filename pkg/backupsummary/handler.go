@@ -0,0 +1,272 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupsummary
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/util/httpauth"
+)
+
+var groupResource = schema.GroupResource{Group: GroupName, Resource: Resource}
+
+// Authorizer decides whether the user making a request is allowed to
+// perform the given verb on backup summaries.
+type Authorizer interface {
+	Authorize(req *http.Request, verb, namespace, name string) (allowed bool, reason string, err error)
+}
+
+// NewAuthorizer returns an Authorizer that identifies the user making each
+// request with authenticator, e.g. from the X-Remote-* headers set by the
+// Kubernetes API aggregator, and checks their access to backup summaries
+// with authorizer.
+func NewAuthorizer(authenticator httpauth.Authenticator, authorizer httpauth.Authorizer) Authorizer {
+	return &requestAuthorizer{
+		authenticator: authenticator,
+		authorizer:    authorizer,
+	}
+}
+
+type requestAuthorizer struct {
+	authenticator httpauth.Authenticator
+	authorizer    httpauth.Authorizer
+}
+
+func (a *requestAuthorizer) Authorize(req *http.Request, verb, namespace, name string) (bool, string, error) {
+	user, err := a.authenticator.AuthenticateRequest(req)
+	if err != nil {
+		return false, "", err
+	}
+	if user == nil {
+		return false, "no user information in request", nil
+	}
+
+	return a.authorizer.Authorize(user, httpauth.Attributes{
+		Verb:      verb,
+		Group:     GroupName,
+		Version:   Version,
+		Resource:  Resource,
+		Namespace: namespace,
+		Name:      name,
+	})
+}
+
+// Handler serves the backup summaries API, including the discovery
+// documents the Kubernetes API aggregator requires.
+type Handler struct {
+	lister     listers.BackupLister
+	authorizer Authorizer
+	logger     logrus.FieldLogger
+}
+
+// NewHandler returns a Handler that serves summaries of the backups
+// in the given lister.
+func NewHandler(lister listers.BackupLister, authorizer Authorizer, logger logrus.FieldLogger) *Handler {
+	return &Handler{
+		lister:     lister,
+		authorizer: authorizer,
+		logger:     logger,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeStatus(w, apierrors.NewMethodNotSupported(groupResource, req.Method))
+		return
+	}
+
+	groupPath := "/apis/" + GroupName
+	versionPath := groupPath + "/" + Version
+
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case path == groupPath:
+		writeJSON(w, http.StatusOK, apiGroup())
+		return
+	case path == versionPath:
+		writeJSON(w, http.StatusOK, apiResourceList())
+		return
+	case !strings.HasPrefix(path, versionPath+"/"):
+		writeStatus(w, apierrors.NewNotFound(groupResource, ""))
+		return
+	}
+
+	var namespace, name string
+	switch parts := strings.Split(strings.TrimPrefix(path, versionPath+"/"), "/"); {
+	case len(parts) == 1 && parts[0] == Resource:
+	case len(parts) == 3 && parts[0] == "namespaces" && parts[2] == Resource:
+		namespace = parts[1]
+	case len(parts) == 4 && parts[0] == "namespaces" && parts[2] == Resource:
+		namespace, name = parts[1], parts[3]
+	default:
+		writeStatus(w, apierrors.NewNotFound(groupResource, ""))
+		return
+	}
+
+	verb := "list"
+	if name != "" {
+		verb = "get"
+	}
+
+	allowed, reason, err := h.authorizer.Authorize(req, verb, namespace, name)
+	if err != nil {
+		h.logger.WithError(err).Error("Error authorizing backup summaries request")
+		writeStatus(w, apierrors.NewInternalError(err))
+		return
+	}
+	if !allowed {
+		writeStatus(w, apierrors.NewForbidden(groupResource, name, errors.New(reason)))
+		return
+	}
+
+	if name != "" {
+		h.get(w, namespace, name)
+		return
+	}
+
+	h.list(w, req, namespace)
+}
+
+func (h *Handler) get(w http.ResponseWriter, namespace, name string) {
+	backup, err := h.lister.Backups(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		writeStatus(w, apierrors.NewNotFound(groupResource, name))
+		return
+	}
+	if err != nil {
+		writeStatus(w, apierrors.NewInternalError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Summarize(backup))
+}
+
+func (h *Handler) list(w http.ResponseWriter, req *http.Request, namespace string) {
+	selector, err := labels.Parse(req.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeStatus(w, apierrors.NewBadRequest(fmt.Sprintf("invalid label selector: %v", err)))
+		return
+	}
+
+	var backups []*velerov1api.Backup
+	if namespace == "" {
+		backups, err = h.lister.List(selector)
+	} else {
+		backups, err = h.lister.Backups(namespace).List(selector)
+	}
+	if err != nil {
+		writeStatus(w, apierrors.NewInternalError(err))
+		return
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].Namespace != backups[j].Namespace {
+			return backups[i].Namespace < backups[j].Namespace
+		}
+		return backups[i].Name < backups[j].Name
+	})
+
+	list := BackupSummaryList{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupName + "/" + Version,
+			Kind:       listKind,
+		},
+		Items: make([]BackupSummary, 0, len(backups)),
+	}
+	for _, backup := range backups {
+		list.Items = append(list.Items, Summarize(backup))
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func apiGroup() *metav1.APIGroup {
+	version := metav1.GroupVersionForDiscovery{
+		GroupVersion: GroupName + "/" + Version,
+		Version:      Version,
+	}
+
+	return &metav1.APIGroup{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "APIGroup",
+		},
+		Name:             GroupName,
+		Versions:         []metav1.GroupVersionForDiscovery{version},
+		PreferredVersion: version,
+	}
+}
+
+func apiResourceList() *metav1.APIResourceList {
+	return &metav1.APIResourceList{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "APIResourceList",
+		},
+		GroupVersion: GroupName + "/" + Version,
+		APIResources: []metav1.APIResource{
+			{
+				Name:       Resource,
+				Namespaced: true,
+				Kind:       kind,
+				Verbs:      metav1.Verbs{"get", "list"},
+			},
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(obj)
+}
+
+func writeStatus(w http.ResponseWriter, err *apierrors.StatusError) {
+	status := err.Status()
+	status.TypeMeta = metav1.TypeMeta{
+		APIVersion: "v1",
+		Kind:       "Status",
+	}
+
+	writeJSON(w, int(status.Code), status)
+}
+
+// NewServer returns a server for the given handler. It must be started
+// with ListenAndServeTLS, with a TLS configuration that verifies the
+// certificates of the clients, such as the Kubernetes API aggregator, that
+// requests are authenticated with.
+func NewServer(address string, tlsConfig *tls.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:      address,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupsummary
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	velerotest "github.com/heptio/velero/pkg/test"
+	"github.com/heptio/velero/pkg/util/httpauth"
+)
+
+type fakeAuthorizer struct {
+	allowed bool
+	verb    string
+	name    string
+}
+
+func (a *fakeAuthorizer) Authorize(req *http.Request, verb, namespace, name string) (bool, string, error) {
+	a.verb, a.name = verb, name
+	return a.allowed, "denied for test", nil
+}
+
+func newTestHandler(t *testing.T, authorizer Authorizer, backups ...*velerov1api.Backup) *Handler {
+	sharedInformers := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	for _, backup := range backups {
+		require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup))
+	}
+
+	return NewHandler(sharedInformers.Velero().V1().Backups().Lister(), authorizer, velerotest.NewLogger())
+}
+
+func serve(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, httptest.NewRequest(method, path, nil))
+	return res
+}
+
+func TestHandlerDiscovery(t *testing.T) {
+	h := newTestHandler(t, &fakeAuthorizer{})
+
+	res := serve(h, http.MethodGet, "/apis/summaries.velero.io/v1")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var resources metav1.APIResourceList
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&resources))
+	assert.Equal(t, "summaries.velero.io/v1", resources.GroupVersion)
+	require.Len(t, resources.APIResources, 1)
+	assert.Equal(t, "backupsummaries", resources.APIResources[0].Name)
+
+	res = serve(h, http.MethodGet, "/apis/summaries.velero.io")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	res = serve(h, http.MethodGet, "/apis/summaries.velero.io/v2")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestHandlerListAndGet(t *testing.T) {
+	authorizer := &fakeAuthorizer{allowed: true}
+	h := newTestHandler(t, authorizer,
+		builder.ForBackup(velerov1api.DefaultNamespace, "backup-2").StorageLocation("default").Phase(velerov1api.BackupPhaseFailed).Result(),
+		builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").Phase(velerov1api.BackupPhaseCompleted).
+			ObjectMeta(builder.WithLabels("app", "nginx")).Result(),
+	)
+
+	res := serve(h, http.MethodGet, "/apis/summaries.velero.io/v1/namespaces/velero/backupsummaries")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "list", authorizer.verb)
+
+	var list BackupSummaryList
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&list))
+	assert.Equal(t, "BackupSummaryList", list.Kind)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "backup-1", list.Items[0].Name)
+	assert.Equal(t, velerov1api.BackupPhaseCompleted, list.Items[0].Phase)
+	assert.Equal(t, "backup-2", list.Items[1].Name)
+
+	res = serve(h, http.MethodGet, "/apis/summaries.velero.io/v1/backupsummaries?labelSelector=app%3Dnginx")
+	require.Equal(t, http.StatusOK, res.Code)
+	list = BackupSummaryList{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&list))
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "backup-1", list.Items[0].Name)
+
+	res = serve(h, http.MethodGet, "/apis/summaries.velero.io/v1/namespaces/velero/backupsummaries/backup-2")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "get", authorizer.verb)
+	assert.Equal(t, "backup-2", authorizer.name)
+
+	var summary BackupSummary
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&summary))
+	assert.Equal(t, "BackupSummary", summary.Kind)
+	assert.Equal(t, "default", summary.StorageLocation)
+	assert.Equal(t, velerov1api.BackupPhaseFailed, summary.Phase)
+
+	res = serve(h, http.MethodGet, "/apis/summaries.velero.io/v1/namespaces/velero/backupsummaries/missing")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = serve(h, http.MethodDelete, "/apis/summaries.velero.io/v1/namespaces/velero/backupsummaries/backup-2")
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+}
+
+func TestHandlerForbidden(t *testing.T) {
+	h := newTestHandler(t, &fakeAuthorizer{allowed: false})
+
+	res := serve(h, http.MethodGet, "/apis/summaries.velero.io/v1/namespaces/velero/backupsummaries")
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	var status metav1.Status
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&status))
+	assert.Equal(t, metav1.StatusReasonForbidden, status.Reason)
+}
+
+func TestAuthorizer(t *testing.T) {
+	client := kubefake.NewSimpleClientset()
+
+	var review *authorizationv1.SubjectAccessReview
+	client.PrependReactor("create", "subjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review = action.(core.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		res := review.DeepCopy()
+		res.Status.Allowed = review.Spec.User == "alice"
+		return true, res, nil
+	})
+
+	authorizer := NewAuthorizer(
+		httpauth.NewAuthenticator([]string{httpauth.MethodRequestHeader}, nil),
+		httpauth.NewSubjectAccessReviewAuthorizer(client.AuthorizationV1().SubjectAccessReviews()),
+	)
+
+	// the aggregator's client certificate has been verified
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	req.Header.Set("X-Remote-User", "alice")
+	req.Header.Add("X-Remote-Group", "system:authenticated")
+	req.Header.Add("X-Remote-Group", "dashboards")
+	req.Header.Set("X-Remote-Extra-Scopes", "view")
+
+	allowed, _, err := authorizer.Authorize(req, "list", "velero", "")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	require.NotNil(t, review)
+	assert.Equal(t, []string{"system:authenticated", "dashboards"}, review.Spec.Groups)
+	assert.Equal(t, authorizationv1.ExtraValue{"view"}, review.Spec.Extra["scopes"])
+	assert.Equal(t, &authorizationv1.ResourceAttributes{
+		Namespace: "velero",
+		Verb:      "list",
+		Group:     "summaries.velero.io",
+		Version:   "v1",
+		Resource:  "backupsummaries",
+	}, review.Spec.ResourceAttributes)
+
+	// requests without user information are never allowed
+	allowed, _, err = authorizer.Authorize(httptest.NewRequest(http.MethodGet, "/", nil), "list", "velero", "")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backupsummary serves lightweight, read-only summaries of Velero
+// backups from the Velero server's informer cache, as a Kubernetes
+// aggregated API.
+package backupsummary
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	// GroupName is the API group that backup summaries are served under.
+	// Backup summaries can't be served under velero.io itself because
+	// that group is served by Velero's CRDs.
+	GroupName = "summaries.velero.io"
+
+	// Version is the API version that backup summaries are served under.
+	Version = "v1"
+
+	// Resource is the name of the backup summary resource.
+	Resource = "backupsummaries"
+
+	kind     = "BackupSummary"
+	listKind = "BackupSummaryList"
+)
+
+// BackupSummary is a lightweight summary of a Velero backup.
+type BackupSummary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Phase is the current state of the backup.
+	Phase velerov1api.BackupPhase `json:"phase,omitempty"`
+
+	// StorageLocation is the name of the backup's storage location.
+	StorageLocation string `json:"storageLocation,omitempty"`
+
+	// StartTimestamp records the time the backup was started.
+	StartTimestamp metav1.Time `json:"startTimestamp,omitempty"`
+
+	// CompletionTimestamp records the time the backup was completed.
+	CompletionTimestamp metav1.Time `json:"completionTimestamp,omitempty"`
+
+	// Expiration is when the backup is eligible for garbage collection.
+	Expiration metav1.Time `json:"expiration,omitempty"`
+
+	// Warnings is a count of the warnings logged by the backup.
+	Warnings int `json:"warnings"`
+
+	// Errors is a count of the errors logged by the backup.
+	Errors int `json:"errors"`
+}
+
+// BackupSummaryList is a list of BackupSummaries.
+type BackupSummaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []BackupSummary `json:"items"`
+}
+
+// Summarize returns a summary of the given backup. Only the backup's
+// identifying metadata and labels are copied, to keep summaries small.
+func Summarize(backup *velerov1api.Backup) BackupSummary {
+	summary := BackupSummary{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupName + "/" + Version,
+			Kind:       kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         backup.Namespace,
+			Name:              backup.Name,
+			UID:               backup.UID,
+			ResourceVersion:   backup.ResourceVersion,
+			CreationTimestamp: backup.CreationTimestamp,
+		},
+		Phase:               backup.Status.Phase,
+		StorageLocation:     backup.Spec.StorageLocation,
+		StartTimestamp:      backup.Status.StartTimestamp,
+		CompletionTimestamp: backup.Status.CompletionTimestamp,
+		Expiration:          backup.Status.Expiration,
+		Warnings:            backup.Status.Warnings,
+		Errors:              backup.Status.Errors,
+	}
+
+	if len(backup.Labels) > 0 {
+		summary.Labels = make(map[string]string, len(backup.Labels))
+		for k, v := range backup.Labels {
+			summary.Labels[k] = v
+		}
+	}
+
+	return summary
+}
@@ -103,18 +103,90 @@ func (b *BackupBuilder) IncludeClusterResources(val bool) *BackupBuilder {
 	return b
 }
 
+// IncludedClusterResourceNames appends to the Backup's included cluster-scoped items.
+func (b *BackupBuilder) IncludedClusterResourceNames(items ...string) *BackupBuilder {
+	b.object.Spec.IncludedClusterResourceNames = append(b.object.Spec.IncludedClusterResourceNames, items...)
+	return b
+}
+
+// ExcludedClusterResourceNames appends to the Backup's excluded cluster-scoped items.
+func (b *BackupBuilder) ExcludedClusterResourceNames(items ...string) *BackupBuilder {
+	b.object.Spec.ExcludedClusterResourceNames = append(b.object.Spec.ExcludedClusterResourceNames, items...)
+	return b
+}
+
 // LabelSelector sets the Backup's label selector.
 func (b *BackupBuilder) LabelSelector(selector *metav1.LabelSelector) *BackupBuilder {
 	b.object.Spec.LabelSelector = selector
 	return b
 }
 
+// OrLabelSelectors appends to the Backup's OR-able label selectors.
+func (b *BackupBuilder) OrLabelSelectors(selectors ...metav1.LabelSelector) *BackupBuilder {
+	b.object.Spec.OrLabelSelectors = append(b.object.Spec.OrLabelSelectors, selectors...)
+	return b
+}
+
+// SnapshotMoveData sets the Backup's "snapshot move data" flag.
+func (b *BackupBuilder) SnapshotMoveData(val bool) *BackupBuilder {
+	b.object.Spec.SnapshotMoveData = val
+	return b
+}
+
 // SnapshotVolumes sets the Backup's "snapshot volumes" flag.
 func (b *BackupBuilder) SnapshotVolumes(val bool) *BackupBuilder {
 	b.object.Spec.SnapshotVolumes = &val
 	return b
 }
 
+// Mode sets the Backup's mode.
+func (b *BackupBuilder) Mode(mode velerov1api.BackupMode) *BackupBuilder {
+	b.object.Spec.Mode = mode
+	return b
+}
+
+// DefaultVolumesToRestic sets the Backup's "default volumes to restic" flag.
+func (b *BackupBuilder) DefaultVolumesToRestic(val bool) *BackupBuilder {
+	b.object.Spec.DefaultVolumesToRestic = &val
+	return b
+}
+
+// PodVolumeFailurePolicy sets the Backup's pod volume failure policy.
+func (b *BackupBuilder) PodVolumeFailurePolicy(policy velerov1api.PodVolumeFailurePolicy) *BackupBuilder {
+	b.object.Spec.PodVolumeFailurePolicy = policy
+	return b
+}
+
+// SkipUnchangedItems sets the Backup's "skip unchanged items" flag.
+func (b *BackupBuilder) SkipUnchangedItems(val bool) *BackupBuilder {
+	b.object.Spec.SkipUnchangedItems = val
+	return b
+}
+
+// DeleteProtection sets the Backup's delete protection flag.
+func (b *BackupBuilder) DeleteProtection(val bool) *BackupBuilder {
+	b.object.Spec.DeleteProtection = val
+	return b
+}
+
+// LogLevel sets the Backup's log level.
+func (b *BackupBuilder) LogLevel(level string) *BackupBuilder {
+	b.object.Spec.LogLevel = level
+	return b
+}
+
+// RetryPolicy sets the Backup's retry policy.
+func (b *BackupBuilder) RetryPolicy(maxAttempts int, backoff time.Duration) *BackupBuilder {
+	b.object.Spec.RetryPolicy = &velerov1api.BackupRetryPolicy{MaxAttempts: maxAttempts, Backoff: metav1.Duration{Duration: backoff}}
+	return b
+}
+
+// ReferencedBackups sets the Backup's referenced backups.
+func (b *BackupBuilder) ReferencedBackups(backups ...string) *BackupBuilder {
+	b.object.Status.ReferencedBackups = backups
+	return b
+}
+
 // Phase sets the Backup's phase.
 func (b *BackupBuilder) Phase(phase velerov1api.BackupPhase) *BackupBuilder {
 	b.object.Status.Phase = phase
@@ -127,12 +199,36 @@ func (b *BackupBuilder) StorageLocation(location string) *BackupBuilder {
 	return b
 }
 
+// StoragePrefix sets the Backup's storage prefix.
+func (b *BackupBuilder) StoragePrefix(prefix string) *BackupBuilder {
+	b.object.Spec.StoragePrefix = prefix
+	return b
+}
+
 // VolumeSnapshotLocations sets the Backup's volume snapshot locations.
 func (b *BackupBuilder) VolumeSnapshotLocations(locations ...string) *BackupBuilder {
 	b.object.Spec.VolumeSnapshotLocations = locations
 	return b
 }
 
+// ReplicaStorageLocations sets the Backup's replica storage locations.
+func (b *BackupBuilder) ReplicaStorageLocations(locations ...string) *BackupBuilder {
+	b.object.Spec.ReplicaStorageLocations = locations
+	return b
+}
+
+// FailoverStorageLocations sets the Backup's failover storage locations.
+func (b *BackupBuilder) FailoverStorageLocations(locations ...string) *BackupBuilder {
+	b.object.Spec.FailoverStorageLocations = locations
+	return b
+}
+
+// Replicas appends to the Backup's replica statuses.
+func (b *BackupBuilder) Replicas(replicas ...velerov1api.BackupReplicaStatus) *BackupBuilder {
+	b.object.Status.Replicas = append(b.object.Status.Replicas, replicas...)
+	return b
+}
+
 // TTL sets the Backup's TTL.
 func (b *BackupBuilder) TTL(ttl time.Duration) *BackupBuilder {
 	b.object.Spec.TTL.Duration = ttl
@@ -151,6 +247,12 @@ func (b *BackupBuilder) StartTimestamp(val time.Time) *BackupBuilder {
 	return b
 }
 
+// CompletionTimestamp sets the Backup's completion timestamp.
+func (b *BackupBuilder) CompletionTimestamp(val time.Time) *BackupBuilder {
+	b.object.Status.CompletionTimestamp.Time = val
+	return b
+}
+
 // NoTypeMeta removes the type meta from the Backup.
 func (b *BackupBuilder) NoTypeMeta() *BackupBuilder {
 	b.object.TypeMeta = metav1.TypeMeta{}
@@ -162,3 +264,9 @@ func (b *BackupBuilder) Hooks(hooks velerov1api.BackupHooks) *BackupBuilder {
 	b.object.Spec.Hooks = hooks
 	return b
 }
+
+// ResourcePolicy sets the Backup's resource policies ConfigMap.
+func (b *BackupBuilder) ResourcePolicy(name string) *BackupBuilder {
+	b.object.Spec.ResourcePolicy = name
+	return b
+}
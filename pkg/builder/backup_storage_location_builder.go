@@ -17,6 +17,7 @@ limitations under the License.
 package builder
 
 import (
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -81,8 +82,23 @@ func (b *BackupStorageLocationBuilder) Prefix(val string) *BackupStorageLocation
 	return b
 }
 
+// Credential sets the BackupStorageLocation's credential to a key in a secret.
+func (b *BackupStorageLocationBuilder) Credential(secretName, key string) *BackupStorageLocationBuilder {
+	b.object.Spec.Credential = &corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: secretName},
+		Key:                  key,
+	}
+	return b
+}
+
 // AccessMode sets the BackupStorageLocation's access mode.
 func (b *BackupStorageLocationBuilder) AccessMode(accessMode velerov1api.BackupStorageLocationAccessMode) *BackupStorageLocationBuilder {
 	b.object.Spec.AccessMode = accessMode
 	return b
 }
+
+// Standby sets the BackupStorageLocation's standby policy.
+func (b *BackupStorageLocationBuilder) Standby(standby velerov1api.BackupStorageLocationStandby) *BackupStorageLocationBuilder {
+	b.object.Spec.Standby = &standby
+	return b
+}
@@ -54,6 +54,14 @@ func (b *ContainerBuilder) VolumeMounts(volumeMounts ...*corev1api.VolumeMount)
 	return b
 }
 
+// VolumeDevices sets the container's VolumeDevices.
+func (b *ContainerBuilder) VolumeDevices(volumeDevices ...*corev1api.VolumeDevice) *ContainerBuilder {
+	for _, v := range volumeDevices {
+		b.object.VolumeDevices = append(b.object.VolumeDevices, *v)
+	}
+	return b
+}
+
 // Resources sets the container's Resources.
 func (b *ContainerBuilder) Resources(resources *corev1api.ResourceRequirements) *ContainerBuilder {
 	b.object.Resources = *resources
@@ -70,9 +70,23 @@ func (b *PodBuilder) NodeName(val string) *PodBuilder {
 	return b
 }
 
+// NodeSelector sets the pod's node selector.
+func (b *PodBuilder) NodeSelector(selector map[string]string) *PodBuilder {
+	b.object.Spec.NodeSelector = selector
+	return b
+}
+
 func (b *PodBuilder) InitContainers(containers ...*corev1api.Container) *PodBuilder {
 	for _, c := range containers {
 		b.object.Spec.InitContainers = append(b.object.Spec.InitContainers, *c)
 	}
 	return b
 }
+
+// Containers appends to the pod's containers.
+func (b *PodBuilder) Containers(containers ...*corev1api.Container) *PodBuilder {
+	for _, c := range containers {
+		b.object.Spec.Containers = append(b.object.Spec.Containers, *c)
+	}
+	return b
+}
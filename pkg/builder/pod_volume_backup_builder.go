@@ -17,6 +17,7 @@ limitations under the License.
 package builder
 
 import (
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -62,3 +63,28 @@ func (b *PodVolumeBackupBuilder) Phase(phase velerov1api.PodVolumeBackupPhase) *
 	b.object.Status.Phase = phase
 	return b
 }
+
+// Pod sets the namespace and name of the PodVolumeBackup's pod.
+func (b *PodVolumeBackupBuilder) Pod(ns, name string) *PodVolumeBackupBuilder {
+	b.object.Spec.Pod.Namespace = ns
+	b.object.Spec.Pod.Name = name
+	return b
+}
+
+// Volume sets the PodVolumeBackup's volume.
+func (b *PodVolumeBackupBuilder) Volume(volume string) *PodVolumeBackupBuilder {
+	b.object.Spec.Volume = volume
+	return b
+}
+
+// VolumeMode sets the PodVolumeBackup's volume mode.
+func (b *PodVolumeBackupBuilder) VolumeMode(mode corev1api.PersistentVolumeMode) *PodVolumeBackupBuilder {
+	b.object.Spec.VolumeMode = mode
+	return b
+}
+
+// SnapshotID sets the PodVolumeBackup's snapshot ID.
+func (b *PodVolumeBackupBuilder) SnapshotID(id string) *PodVolumeBackupBuilder {
+	b.object.Status.SnapshotID = id
+	return b
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package builder
 
 import (
+	"time"
+
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -63,6 +66,19 @@ func (b *RestoreBuilder) Backup(name string) *RestoreBuilder {
 	return b
 }
 
+// BackupVersion sets the version of the Restore's backup to restore from.
+func (b *RestoreBuilder) BackupVersion(versionID string) *RestoreBuilder {
+	b.object.Spec.BackupVersion = versionID
+	return b
+}
+
+// StorageLocation sets the backup storage location that the Restore reads
+// its backup from.
+func (b *RestoreBuilder) StorageLocation(location string) *RestoreBuilder {
+	b.object.Spec.BackupStorageLocation = location
+	return b
+}
+
 // Schedule sets the Restore's schedule name.
 func (b *RestoreBuilder) Schedule(name string) *RestoreBuilder {
 	b.object.Spec.ScheduleName = name
@@ -99,6 +115,18 @@ func (b *RestoreBuilder) IncludeClusterResources(val bool) *RestoreBuilder {
 	return b
 }
 
+// IncludedClusterResourceNames appends to the Restore's included cluster-scoped items.
+func (b *RestoreBuilder) IncludedClusterResourceNames(items ...string) *RestoreBuilder {
+	b.object.Spec.IncludedClusterResourceNames = append(b.object.Spec.IncludedClusterResourceNames, items...)
+	return b
+}
+
+// ExcludedClusterResourceNames appends to the Restore's excluded cluster-scoped items.
+func (b *RestoreBuilder) ExcludedClusterResourceNames(items ...string) *RestoreBuilder {
+	b.object.Spec.ExcludedClusterResourceNames = append(b.object.Spec.ExcludedClusterResourceNames, items...)
+	return b
+}
+
 // LabelSelector sets the Restore's label selector.
 func (b *RestoreBuilder) LabelSelector(selector *metav1.LabelSelector) *RestoreBuilder {
 	b.object.Spec.LabelSelector = selector
@@ -122,6 +150,23 @@ func (b *RestoreBuilder) NamespaceMappings(mapping ...string) *RestoreBuilder {
 	return b
 }
 
+// StorageClassMappings sets the Restore's storage class mappings.
+func (b *RestoreBuilder) StorageClassMappings(mapping ...string) *RestoreBuilder {
+	if b.object.Spec.StorageClassMapping == nil {
+		b.object.Spec.StorageClassMapping = make(map[string]string)
+	}
+
+	if len(mapping)%2 != 0 {
+		panic("mapping must contain an even number of values")
+	}
+
+	for i := 0; i < len(mapping); i += 2 {
+		b.object.Spec.StorageClassMapping[mapping[i]] = mapping[i+1]
+	}
+
+	return b
+}
+
 // Phase sets the Restore's phase.
 func (b *RestoreBuilder) Phase(phase velerov1api.RestorePhase) *RestoreBuilder {
 	b.object.Status.Phase = phase
@@ -133,3 +178,78 @@ func (b *RestoreBuilder) RestorePVs(val bool) *RestoreBuilder {
 	b.object.Spec.RestorePVs = &val
 	return b
 }
+
+// Mode sets the Restore's mode.
+func (b *RestoreBuilder) Mode(mode velerov1api.RestoreMode) *RestoreBuilder {
+	b.object.Spec.Mode = mode
+	return b
+}
+
+// ExtractionPolicy sets the Restore's extraction policy.
+func (b *RestoreBuilder) ExtractionPolicy(policy velerov1api.RestoreExtractionPolicy) *RestoreBuilder {
+	b.object.Spec.ExtractionPolicy = policy
+	return b
+}
+
+// Credential sets the Restore's credential to a key in a secret.
+func (b *RestoreBuilder) Credential(secretName, key string) *RestoreBuilder {
+	b.object.Spec.Credential = &corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: secretName},
+		Key:                  key,
+	}
+	return b
+}
+
+// ResourceModifiers appends to the Restore's resource modifier rules.
+func (b *RestoreBuilder) ResourceModifiers(modifiers ...velerov1api.RestoreResourceModifier) *RestoreBuilder {
+	b.object.Spec.ResourceModifiers = append(b.object.Spec.ResourceModifiers, modifiers...)
+	return b
+}
+
+// ControlPlaneConfigPolicy sets the Restore's control-plane configuration policy.
+func (b *RestoreBuilder) ControlPlaneConfigPolicy(policy velerov1api.ControlPlaneConfigPolicy) *RestoreBuilder {
+	b.object.Spec.ControlPlaneConfigPolicy = policy
+	return b
+}
+
+// ResourcePriorities sets the Restore's resource priorities.
+func (b *RestoreBuilder) ResourcePriorities(resources ...string) *RestoreBuilder {
+	b.object.Spec.ResourcePriorities = append(b.object.Spec.ResourcePriorities, resources...)
+	return b
+}
+
+// WaitForReady sets the Restore's wait for ready flag.
+func (b *RestoreBuilder) WaitForReady(val bool) *RestoreBuilder {
+	b.object.Spec.WaitForReady = val
+	return b
+}
+
+// ReadyTimeout sets the Restore's ready timeout.
+func (b *RestoreBuilder) ReadyTimeout(timeout time.Duration) *RestoreBuilder {
+	b.object.Spec.ReadyTimeout.Duration = timeout
+	return b
+}
+
+// DryRun sets the Restore's dry run flag.
+func (b *RestoreBuilder) DryRun(val bool) *RestoreBuilder {
+	b.object.Spec.DryRun = val
+	return b
+}
+
+// LogLevel sets the Restore's log level.
+func (b *RestoreBuilder) LogLevel(level string) *RestoreBuilder {
+	b.object.Spec.LogLevel = level
+	return b
+}
+
+// Hooks appends to the Restore's resource hook specs.
+func (b *RestoreBuilder) Hooks(hooks ...velerov1api.RestoreResourceHookSpec) *RestoreBuilder {
+	b.object.Spec.Hooks.Resources = append(b.object.Spec.Hooks.Resources, hooks...)
+	return b
+}
+
+// ResourcePolicy sets the Restore's resource policies ConfigMap.
+func (b *RestoreBuilder) ResourcePolicy(name string) *RestoreBuilder {
+	b.object.Spec.ResourcePolicy = name
+	return b
+}
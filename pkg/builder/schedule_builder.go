@@ -77,6 +77,18 @@ func (b *ScheduleBuilder) CronSchedule(expression string) *ScheduleBuilder {
 	return b
 }
 
+// AdditionalCronSchedules appends to the Schedule's additional cron schedules.
+func (b *ScheduleBuilder) AdditionalCronSchedules(expressions ...string) *ScheduleBuilder {
+	b.object.Spec.Schedules = append(b.object.Spec.Schedules, expressions...)
+	return b
+}
+
+// TimeZone sets the Schedule's time zone.
+func (b *ScheduleBuilder) TimeZone(name string) *ScheduleBuilder {
+	b.object.Spec.TimeZone = name
+	return b
+}
+
 // LastBackupTime sets the Schedule's last backup time.
 func (b *ScheduleBuilder) LastBackupTime(val string) *ScheduleBuilder {
 	t, _ := time.Parse("2006-01-02 15:04:05", val)
@@ -84,6 +96,31 @@ func (b *ScheduleBuilder) LastBackupTime(val string) *ScheduleBuilder {
 	return b
 }
 
+// LastSkippedTime sets the Schedule's last skipped time.
+func (b *ScheduleBuilder) LastSkippedTime(val string) *ScheduleBuilder {
+	t, _ := time.Parse("2006-01-02 15:04:05", val)
+	b.object.Status.LastSkipped = &metav1.Time{Time: t}
+	return b
+}
+
+// OverlapPolicy sets the Schedule's overlap policy.
+func (b *ScheduleBuilder) OverlapPolicy(policy velerov1api.ScheduleOverlapPolicy) *ScheduleBuilder {
+	b.object.Spec.OverlapPolicy = policy
+	return b
+}
+
+// Retention sets the Schedule's retention policy.
+func (b *ScheduleBuilder) Retention(retention velerov1api.ScheduleRetention) *ScheduleBuilder {
+	b.object.Spec.Retention = &retention
+	return b
+}
+
+// Verification sets the Schedule's verification policy.
+func (b *ScheduleBuilder) Verification(verification velerov1api.ScheduleVerification) *ScheduleBuilder {
+	b.object.Spec.Verification = &verification
+	return b
+}
+
 // Template sets the Schedule's template.
 func (b *ScheduleBuilder) Template(spec velerov1api.BackupSpec) *ScheduleBuilder {
 	b.object.Spec.Template = spec
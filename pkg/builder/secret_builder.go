@@ -55,3 +55,9 @@ func (b *SecretBuilder) ObjectMeta(opts ...ObjectMetaOpt) *SecretBuilder {
 
 	return b
 }
+
+// Data sets the Secret's data.
+func (b *SecretBuilder) Data(data map[string][]byte) *SecretBuilder {
+	b.object.Data = data
+	return b
+}
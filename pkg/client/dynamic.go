@@ -17,6 +17,9 @@ limitations under the License.
 package client
 
 import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -37,6 +40,7 @@ type DynamicFactory interface {
 // dynamicFactory implements DynamicFactory.
 type dynamicFactory struct {
 	dynamicClient dynamic.Interface
+	dryRun        []string
 }
 
 // NewDynamicFactory returns a new ClientPool-based dynamic factory.
@@ -44,9 +48,17 @@ func NewDynamicFactory(dynamicClient dynamic.Interface) DynamicFactory {
 	return &dynamicFactory{dynamicClient: dynamicClient}
 }
 
+// NewDryRunDynamicFactory returns a new ClientPool-based dynamic factory whose clients
+// send creates, patches, applies and deletes as server-side dry runs, which are
+// validated and admitted by the API server but not persisted.
+func NewDryRunDynamicFactory(dynamicClient dynamic.Interface) DynamicFactory {
+	return &dynamicFactory{dynamicClient: dynamicClient, dryRun: []string{metav1.DryRunAll}}
+}
+
 func (f *dynamicFactory) ClientForGroupVersionResource(gv schema.GroupVersion, resource metav1.APIResource, namespace string) (Dynamic, error) {
 	return &dynamicResourceClient{
 		resourceClient: f.dynamicClient.Resource(gv.WithResource(resource.Name)).Namespace(namespace),
+		dryRun:         f.dryRun,
 	}, nil
 }
 
@@ -81,6 +93,20 @@ type Patcher interface {
 	Patch(name string, data []byte) (*unstructured.Unstructured, error)
 }
 
+// Applier applies an object using server-side apply.
+type Applier interface {
+	// Apply creates or updates the named object so that the fields set in obj are owned by
+	// fieldManager. If force is true, conflicting fields owned by other managers are taken over.
+	// The applied object is returned.
+	Apply(name string, obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error)
+}
+
+// Deleter deletes an object.
+type Deleter interface {
+	// Delete deletes the named object.
+	Delete(name string, opts *metav1.DeleteOptions) error
+}
+
 // Dynamic contains client methods that Velero needs for backing up and restoring resources.
 type Dynamic interface {
 	Creator
@@ -88,17 +114,20 @@ type Dynamic interface {
 	Watcher
 	Getter
 	Patcher
+	Applier
+	Deleter
 }
 
 // dynamicResourceClient implements Dynamic.
 type dynamicResourceClient struct {
 	resourceClient dynamic.ResourceInterface
+	dryRun         []string
 }
 
 var _ Dynamic = &dynamicResourceClient{}
 
 func (d *dynamicResourceClient) Create(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
-	return d.resourceClient.Create(obj, metav1.CreateOptions{})
+	return d.resourceClient.Create(obj, metav1.CreateOptions{DryRun: d.dryRun})
 }
 
 func (d *dynamicResourceClient) List(options metav1.ListOptions) (runtime.Object, error) {
@@ -114,5 +143,31 @@ func (d *dynamicResourceClient) Get(name string, opts metav1.GetOptions) (*unstr
 }
 
 func (d *dynamicResourceClient) Patch(name string, data []byte) (*unstructured.Unstructured, error) {
-	return d.resourceClient.Patch(name, types.MergePatchType, data, metav1.PatchOptions{})
+	return d.resourceClient.Patch(name, types.MergePatchType, data, metav1.PatchOptions{DryRun: d.dryRun})
+}
+
+func (d *dynamicResourceClient) Delete(name string, opts *metav1.DeleteOptions) error {
+	if len(d.dryRun) > 0 {
+		dryRunOpts := metav1.DeleteOptions{}
+		if opts != nil {
+			dryRunOpts = *opts
+		}
+		dryRunOpts.DryRun = d.dryRun
+		opts = &dryRunOpts
+	}
+	return d.resourceClient.Delete(name, opts)
+}
+
+func (d *dynamicResourceClient) Apply(name string, obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	// JSON is a subset of YAML, so the object can be sent as-is in the apply patch body.
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return d.resourceClient.Patch(name, types.ApplyPatchType, data, metav1.PatchOptions{
+		DryRun:       d.dryRun,
+		FieldManager: fieldManager,
+		Force:        &force,
+	})
 }
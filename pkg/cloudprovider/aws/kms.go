@@ -0,0 +1,162 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/pkg/errors"
+)
+
+// KMS encrypts and decrypts data with AWS KMS keys. The vendored AWS SDK
+// doesn't include a KMS client, so its JSON API is called directly.
+type KMS struct {
+	endpoint   func(region string) string
+	newSession func(region string) (*session.Session, error)
+	httpClient *http.Client
+}
+
+// NewKMS returns a KMS that calls AWS KMS with the default credentials, or
+// the pod's IAM role if its service account has one.
+func NewKMS() *KMS {
+	return &KMS{
+		endpoint: func(region string) string {
+			return fmt.Sprintf("https://kms.%s.amazonaws.com/", region)
+		},
+		newSession: func(region string) (*session.Session, error) {
+			return getSession(aws.NewConfig().WithRegion(region), "")
+		},
+		httpClient: http.DefaultClient,
+	}
+}
+
+type kmsEncryptRequest struct {
+	KeyId     string
+	Plaintext []byte
+}
+
+type kmsEncryptResponse struct {
+	CiphertextBlob []byte
+}
+
+type kmsDecryptRequest struct {
+	KeyId          string
+	CiphertextBlob []byte
+}
+
+type kmsDecryptResponse struct {
+	Plaintext []byte
+}
+
+type kmsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Encrypt encrypts plaintext with the KMS key with the given ARN.
+func (k *KMS) Encrypt(keyID string, plaintext []byte) ([]byte, error) {
+	var res kmsEncryptResponse
+	if err := k.call(keyID, "Encrypt", &kmsEncryptRequest{KeyId: keyID, Plaintext: plaintext}, &res); err != nil {
+		return nil, err
+	}
+
+	return res.CiphertextBlob, nil
+}
+
+// Decrypt decrypts ciphertext that was encrypted with the KMS key with the
+// given ARN.
+func (k *KMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	var res kmsDecryptResponse
+	if err := k.call(keyID, "Decrypt", &kmsDecryptRequest{KeyId: keyID, CiphertextBlob: ciphertext}, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Plaintext, nil
+}
+
+func (k *KMS) call(keyID, operation string, input, output interface{}) error {
+	region, err := kmsKeyRegion(keyID)
+	if err != nil {
+		return err
+	}
+
+	sess, err := k.newSession(region)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, k.endpoint(region), bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+operation)
+
+	if _, err := v4.NewSigner(sess.Config.Credentials).Sign(req, bytes.NewReader(body), "kms", region, time.Now()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	res, err := k.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "error calling KMS %s", operation)
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var kmsErr kmsError
+		if err := json.Unmarshal(resBody, &kmsErr); err != nil || kmsErr.Type == "" {
+			return errors.Errorf("error calling KMS %s: %s", operation, res.Status)
+		}
+		return errors.Errorf("error calling KMS %s: %s: %s", operation, kmsErr.Type, kmsErr.Message)
+	}
+
+	if err := json.Unmarshal(resBody, output); err != nil {
+		return errors.Wrapf(err, "error decoding KMS %s response", operation)
+	}
+
+	return nil
+}
+
+// kmsKeyRegion returns the region of a KMS key or alias from its ARN,
+// e.g. arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab.
+func kmsKeyRegion(keyID string) (string, error) {
+	parts := strings.SplitN(keyID, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "kms" || parts[3] == "" {
+		return "", errors.Errorf("KMS key %q is not a key or alias ARN", keyID)
+	}
+
+	return parts[3], nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKMS(t *testing.T) {
+	keyID := "arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-2/kms/aws4_request")
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			var req kmsEncryptRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, keyID, req.KeyId)
+			json.NewEncoder(w).Encode(kmsEncryptResponse{CiphertextBlob: append([]byte("encrypted:"), req.Plaintext...)})
+		case "TrentService.Decrypt":
+			var req kmsDecryptRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if !strings.HasPrefix(string(req.CiphertextBlob), "encrypted:") {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"InvalidCiphertextException","message":"bad ciphertext"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(kmsDecryptResponse{Plaintext: req.CiphertextBlob[len("encrypted:"):]})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	kms := &KMS{
+		endpoint: func(string) string { return server.URL },
+		newSession: func(region string) (*session.Session, error) {
+			return session.NewSession(aws.NewConfig().WithRegion(region).WithCredentials(credentials.NewStaticCredentials("id", "secret", "")))
+		},
+		httpClient: server.Client(),
+	}
+
+	ciphertext, err := kms.Encrypt(keyID, []byte("data key"))
+	require.NoError(t, err)
+	assert.Equal(t, "encrypted:data key", string(ciphertext))
+
+	plaintext, err := kms.Decrypt(keyID, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "data key", string(plaintext))
+
+	_, err = kms.Decrypt(keyID, []byte("garbage"))
+	assert.EqualError(t, err, "error calling KMS Decrypt: InvalidCiphertextException: bad ciphertext")
+
+	_, err = kms.Decrypt("1234abcd-12ab-34cd-56ef-1234567890ab", ciphertext)
+	assert.EqualError(t, err, `KMS key "1234abcd-12ab-34cd-56ef-1234567890ab" is not a key or alias ARN`)
+}
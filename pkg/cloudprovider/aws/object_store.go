@@ -17,13 +17,16 @@ limitations under the License.
 package aws
 
 import (
+	"fmt"
 	"io"
+	"net/url"
 	"sort"
 	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/request"
 	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
@@ -31,8 +34,10 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/heptio/velero/pkg/cloudprovider"
+	"github.com/heptio/velero/pkg/plugin/velero"
 )
 
 const (
@@ -50,7 +55,14 @@ type s3Interface interface {
 	GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error)
 	ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error
 	DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+	DeleteObjects(input *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error)
 	GetObjectRequest(input *s3.GetObjectInput) (req *request.Request, output *s3.GetObjectOutput)
+	CopyObject(input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error)
+	CreateMultipartUpload(input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error)
+	UploadPartCopy(input *s3.UploadPartCopyInput) (*s3.UploadPartCopyOutput, error)
+	CompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error)
+	ListObjectVersionsPages(input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool) error
 }
 
 type ObjectStore struct {
@@ -83,6 +95,7 @@ func (o *ObjectStore) Init(config map[string]string) error {
 		s3ForcePathStyleKey,
 		signatureVersionKey,
 		credentialProfileKey,
+		cloudprovider.CredentialsFileConfigKey,
 	); err != nil {
 		return err
 	}
@@ -95,6 +108,7 @@ func (o *ObjectStore) Init(config map[string]string) error {
 		s3ForcePathStyleVal = config[s3ForcePathStyleKey]
 		signatureVersion    = config[signatureVersionKey]
 		credentialProfile   = config[credentialProfileKey]
+		credentialsFile     = config[cloudprovider.CredentialsFileConfigKey]
 
 		// note that bucket is automatically added to the config map
 		// by the server from the ObjectStorageProviderConfig so
@@ -122,7 +136,7 @@ func (o *ObjectStore) Init(config map[string]string) error {
 		}
 	}
 
-	serverConfig, err := newAWSConfig(s3URL, region, s3ForcePathStyle)
+	serverConfig, err := newAWSConfig(s3URL, region, s3ForcePathStyle, credentialsFile, credentialProfile)
 	if err != nil {
 		return err
 	}
@@ -144,7 +158,7 @@ func (o *ObjectStore) Init(config map[string]string) error {
 	}
 
 	if publicURL != "" {
-		publicConfig, err := newAWSConfig(publicURL, region, s3ForcePathStyle)
+		publicConfig, err := newAWSConfig(publicURL, region, s3ForcePathStyle, credentialsFile, credentialProfile)
 		if err != nil {
 			return err
 		}
@@ -160,11 +174,18 @@ func (o *ObjectStore) Init(config map[string]string) error {
 	return nil
 }
 
-func newAWSConfig(url, region string, forcePathStyle bool) (*aws.Config, error) {
+// newAWSConfig returns the config for an S3 client. If credentialsFile
+// is non-empty, the client uses the given profile in that shared
+// credentials file instead of the default credential chain.
+func newAWSConfig(url, region string, forcePathStyle bool, credentialsFile, profile string) (*aws.Config, error) {
 	awsConfig := aws.NewConfig().
 		WithRegion(region).
 		WithS3ForcePathStyle(forcePathStyle)
 
+	if credentialsFile != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewSharedCredentials(credentialsFile, profile))
+	}
+
 	if url != "" {
 		if !IsValidS3URLScheme(url) {
 			return nil, errors.Errorf("Invalid s3 url %s, URL must be valid according to https://golang.org/pkg/net/url/#Parse and start with http:// or https://", url)
@@ -318,6 +339,45 @@ func (o *ObjectStore) DeleteObject(bucket, key string) error {
 	return errors.Wrapf(err, "error deleting object %s", key)
 }
 
+// maxDeleteObjectsKeys is the most objects that S3 can delete in a single
+// request.
+const maxDeleteObjectsKeys = 1000
+
+// DeleteObjects deletes objects in batches of up to maxDeleteObjectsKeys.
+func (o *ObjectStore) DeleteObjects(bucket string, keys []string) error {
+	var errs []error
+	for start := 0; start < len(keys); start += maxDeleteObjectsKeys {
+		end := start + maxDeleteObjectsKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		var objects []*s3.ObjectIdentifier
+		for _, key := range keys[start:end] {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		res, err := o.s3.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: &bucket,
+			Delete: &s3.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true),
+			},
+		})
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "error deleting %d objects", len(objects)))
+			continue
+		}
+
+		// a successful response lists the objects that couldn't be deleted.
+		for _, deleteErr := range res.Errors {
+			errs = append(errs, errors.Errorf("error deleting object %s: %s: %s", aws.StringValue(deleteErr.Key), aws.StringValue(deleteErr.Code), aws.StringValue(deleteErr.Message)))
+		}
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
 func (o *ObjectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (string, error) {
 	req, _ := o.preSignS3.GetObjectRequest(&s3.GetObjectInput{
 		Bucket: aws.String(bucket),
@@ -331,3 +391,185 @@ func (o *ObjectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (st
 
 	return req.Presign(ttl)
 }
+
+// maxCopyObjectSize is the size of the largest object that S3 can copy in
+// a single request. Larger objects are copied in parts of copyPartSize.
+const (
+	maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+	copyPartSize      = 512 * 1024 * 1024
+)
+
+// CopyObject copies an object within a bucket on the S3 side.
+func (o *ObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	head, err := o.s3.HeadObject(&s3.HeadObjectInput{
+		Bucket: &bucket,
+		Key:    &srcKey,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error getting size of object %s", srcKey)
+	}
+
+	copySource := url.PathEscape(bucket + "/" + srcKey)
+
+	if aws.Int64Value(head.ContentLength) > maxCopyObjectSize {
+		return o.copyObjectInParts(bucket, copySource, destKey, aws.Int64Value(head.ContentLength))
+	}
+
+	req := &s3.CopyObjectInput{
+		Bucket:     &bucket,
+		CopySource: &copySource,
+		Key:        &destKey,
+	}
+	if o.kmsKeyID != "" {
+		req.ServerSideEncryption = aws.String("aws:kms")
+		req.SSEKMSKeyId = &o.kmsKeyID
+	}
+
+	_, err = o.s3.CopyObject(req)
+
+	return errors.Wrapf(err, "error copying object %s to %s", srcKey, destKey)
+}
+
+func (o *ObjectStore) copyObjectInParts(bucket, copySource, destKey string, size int64) error {
+	createReq := &s3.CreateMultipartUploadInput{
+		Bucket: &bucket,
+		Key:    &destKey,
+	}
+	if o.kmsKeyID != "" {
+		createReq.ServerSideEncryption = aws.String("aws:kms")
+		createReq.SSEKMSKeyId = &o.kmsKeyID
+	}
+
+	upload, err := o.s3.CreateMultipartUpload(createReq)
+	if err != nil {
+		return errors.Wrapf(err, "error starting multipart copy to %s", destKey)
+	}
+
+	var parts []*s3.CompletedPart
+	for start, partNumber := int64(0), int64(1); start < size; start, partNumber = start+copyPartSize, partNumber+1 {
+		end := start + copyPartSize - 1
+		if end >= size {
+			end = size - 1
+		}
+
+		res, err := o.s3.UploadPartCopy(&s3.UploadPartCopyInput{
+			Bucket:          &bucket,
+			Key:             &destKey,
+			CopySource:      &copySource,
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+			PartNumber:      aws.Int64(partNumber),
+			UploadId:        upload.UploadId,
+		})
+		if err != nil {
+			o.abortMultipartUpload(bucket, destKey, upload.UploadId)
+			return errors.Wrapf(err, "error copying part %d to %s", partNumber, destKey)
+		}
+
+		parts = append(parts, &s3.CompletedPart{
+			ETag:       res.CopyPartResult.ETag,
+			PartNumber: aws.Int64(partNumber),
+		})
+	}
+
+	_, err = o.s3.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          &bucket,
+		Key:             &destKey,
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		o.abortMultipartUpload(bucket, destKey, upload.UploadId)
+		return errors.Wrapf(err, "error completing multipart copy to %s", destKey)
+	}
+
+	return nil
+}
+
+func (o *ObjectStore) abortMultipartUpload(bucket, key string, uploadID *string) {
+	if _, err := o.s3.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   &bucket,
+		Key:      &key,
+		UploadId: uploadID,
+	}); err != nil {
+		o.log.WithError(errors.WithStack(err)).WithField("key", key).Warn("Error aborting multipart copy")
+	}
+}
+
+// Capabilities returns the optional features that S3 supports. Large
+// objects are uploaded in parts by the S3 upload manager.
+func (o *ObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	return velero.ObjectStoreCapabilities{
+		SignedURLs:      true,
+		MultipartUpload: true,
+		Tagging:         true,
+		BatchDelete:     true,
+		ServerSideCopy:  true,
+		Versioning:      true,
+	}, nil
+}
+
+// ListObjectVersions lists the versions of the object with the given key,
+// including delete markers, newest first. The bucket must have versioning
+// enabled for it to have any previous versions.
+func (o *ObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	req := &s3.ListObjectVersionsInput{
+		Bucket: &bucket,
+		Prefix: &key,
+	}
+
+	var ret []velero.ObjectVersion
+	err := o.s3.ListObjectVersionsPages(req, func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		// the prefix also matches any longer keys that start with the key.
+		for _, version := range page.Versions {
+			if aws.StringValue(version.Key) == key {
+				ret = append(ret, velero.ObjectVersion{
+					VersionID:    aws.StringValue(version.VersionId),
+					LastModified: aws.TimeValue(version.LastModified),
+					IsLatest:     aws.BoolValue(version.IsLatest),
+				})
+			}
+		}
+		for _, marker := range page.DeleteMarkers {
+			if aws.StringValue(marker.Key) == key {
+				ret = append(ret, velero.ObjectVersion{
+					VersionID:      aws.StringValue(marker.VersionId),
+					LastModified:   aws.TimeValue(marker.LastModified),
+					IsLatest:       aws.BoolValue(marker.IsLatest),
+					IsDeleteMarker: true,
+				})
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing versions of object %s", key)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].LastModified.After(ret[j].LastModified)
+	})
+
+	return ret, nil
+}
+
+// GetObjectVersion retrieves the given version of the object with the given key.
+func (o *ObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	req := &s3.GetObjectInput{
+		Bucket:    &bucket,
+		Key:       &key,
+		VersionId: &versionID,
+	}
+
+	res, err := o.s3.GetObject(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting version %s of object %s", versionID, key)
+	}
+
+	return res.Body, nil
+}
+
+// LockObject isn't supported, since S3 Object Lock requires a newer version
+// of the AWS SDK than the plugin is built with.
+func (o *ObjectStore) LockObject(bucket, key string, until time.Time) error {
+	return errors.New("object lock is not supported for S3")
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package aws
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -28,6 +30,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/heptio/velero/pkg/plugin/velero"
 	"github.com/heptio/velero/pkg/test"
 )
 
@@ -61,11 +64,46 @@ func (m *mockS3) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutp
 	return args.Get(0).(*s3.DeleteObjectOutput), args.Error(1)
 }
 
+func (m *mockS3) DeleteObjects(input *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.DeleteObjectsOutput), args.Error(1)
+}
+
 func (m *mockS3) GetObjectRequest(input *s3.GetObjectInput) (req *request.Request, output *s3.GetObjectOutput) {
 	args := m.Called(input)
 	return args.Get(0).(*request.Request), args.Get(1).(*s3.GetObjectOutput)
 }
 
+func (m *mockS3) CopyObject(input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.CopyObjectOutput), args.Error(1)
+}
+
+func (m *mockS3) CreateMultipartUpload(input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.CreateMultipartUploadOutput), args.Error(1)
+}
+
+func (m *mockS3) UploadPartCopy(input *s3.UploadPartCopyInput) (*s3.UploadPartCopyOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.UploadPartCopyOutput), args.Error(1)
+}
+
+func (m *mockS3) CompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.CompleteMultipartUploadOutput), args.Error(1)
+}
+
+func (m *mockS3) AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.AbortMultipartUploadOutput), args.Error(1)
+}
+
+func (m *mockS3) ListObjectVersionsPages(input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool) error {
+	args := m.Called(input, fn)
+	return args.Error(0)
+}
+
 func TestObjectExists(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -123,3 +161,114 @@ func TestObjectExists(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyObject(t *testing.T) {
+	t.Run("small object is copied in one request", func(t *testing.T) {
+		s := new(mockS3)
+		defer s.AssertExpectations(t)
+
+		o := &ObjectStore{log: test.NewLogger(), s3: s, kmsKeyID: "key-1"}
+
+		s.On("HeadObject", &s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("src key")}).
+			Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(1024)}, nil)
+		s.On("CopyObject", &s3.CopyObjectInput{
+			Bucket:               aws.String("b"),
+			CopySource:           aws.String("b%2Fsrc%20key"),
+			Key:                  aws.String("dest"),
+			ServerSideEncryption: aws.String("aws:kms"),
+			SSEKMSKeyId:          aws.String("key-1"),
+		}).Return(&s3.CopyObjectOutput{}, nil)
+
+		require.NoError(t, o.CopyObject("b", "src key", "dest"))
+	})
+
+	t.Run("large object is copied in parts", func(t *testing.T) {
+		s := new(mockS3)
+		defer s.AssertExpectations(t)
+
+		o := &ObjectStore{log: test.NewLogger(), s3: s}
+
+		size := int64(maxCopyObjectSize + 1)
+		s.On("HeadObject", mock.Anything).Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(size)}, nil)
+		s.On("CreateMultipartUpload", mock.Anything).Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		s.On("UploadPartCopy", mock.Anything).Return(&s3.UploadPartCopyOutput{CopyPartResult: &s3.CopyPartResult{ETag: aws.String("etag")}}, nil).Times(11)
+		s.On("CompleteMultipartUpload", mock.MatchedBy(func(input *s3.CompleteMultipartUploadInput) bool {
+			return aws.StringValue(input.UploadId) == "upload-1" && len(input.MultipartUpload.Parts) == 11
+		})).Return(&s3.CompleteMultipartUploadOutput{}, nil)
+
+		require.NoError(t, o.CopyObject("b", "src", "dest"))
+
+		var lastPart *s3.UploadPartCopyInput
+		for _, call := range s.Calls {
+			if call.Method == "UploadPartCopy" {
+				lastPart = call.Arguments.Get(0).(*s3.UploadPartCopyInput)
+			}
+		}
+		require.NotNil(t, lastPart)
+		assert.Equal(t, int64(11), aws.Int64Value(lastPart.PartNumber))
+		assert.Equal(t, fmt.Sprintf("bytes=%d-%d", size-1, size-1), aws.StringValue(lastPart.CopySourceRange))
+	})
+}
+
+func TestDeleteObjects(t *testing.T) {
+	s := new(mockS3)
+	defer s.AssertExpectations(t)
+
+	o := &ObjectStore{log: test.NewLogger(), s3: s}
+
+	var keys []string
+	for i := 0; i < maxDeleteObjectsKeys+1; i++ {
+		keys = append(keys, fmt.Sprintf("key-%d", i))
+	}
+
+	// the keys are deleted in batches of up to maxDeleteObjectsKeys, and
+	// the objects that couldn't be deleted are reported.
+	s.On("DeleteObjects", mock.MatchedBy(func(input *s3.DeleteObjectsInput) bool {
+		return len(input.Delete.Objects) == maxDeleteObjectsKeys
+	})).Return(&s3.DeleteObjectsOutput{
+		Errors: []*s3.Error{{Key: aws.String("key-1"), Code: aws.String("AccessDenied"), Message: aws.String("Access Denied")}},
+	}, nil).Once()
+	s.On("DeleteObjects", &s3.DeleteObjectsInput{
+		Bucket: aws.String("b"),
+		Delete: &s3.Delete{
+			Objects: []*s3.ObjectIdentifier{{Key: aws.String(keys[maxDeleteObjectsKeys])}},
+			Quiet:   aws.Bool(true),
+		},
+	}).Return(&s3.DeleteObjectsOutput{}, nil).Once()
+
+	err := o.DeleteObjects("b", keys)
+	assert.EqualError(t, err, "error deleting object key-1: AccessDenied: Access Denied")
+}
+
+func TestListObjectVersions(t *testing.T) {
+	s := new(mockS3)
+	defer s.AssertExpectations(t)
+
+	o := &ObjectStore{log: test.NewLogger(), s3: s}
+
+	now := time.Now().UTC()
+	s.On("ListObjectVersionsPages", &s3.ListObjectVersionsInput{Bucket: aws.String("b"), Prefix: aws.String("key")}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(1).(func(*s3.ListObjectVersionsOutput, bool) bool)
+			fn(&s3.ListObjectVersionsOutput{
+				Versions: []*s3.ObjectVersion{
+					{Key: aws.String("key"), VersionId: aws.String("2"), LastModified: aws.Time(now.Add(-time.Hour))},
+					{Key: aws.String("key"), VersionId: aws.String("1"), LastModified: aws.Time(now.Add(-2 * time.Hour))},
+					{Key: aws.String("key-2"), VersionId: aws.String("a"), LastModified: aws.Time(now), IsLatest: aws.Bool(true)},
+				},
+				DeleteMarkers: []*s3.DeleteMarkerEntry{
+					{Key: aws.String("key"), VersionId: aws.String("3"), LastModified: aws.Time(now), IsLatest: aws.Bool(true)},
+				},
+			}, true)
+		}).
+		Return(nil)
+
+	versions, err := o.ListObjectVersions("b", "key")
+	require.NoError(t, err)
+
+	assert.Equal(t, []velero.ObjectVersion{
+		{VersionID: "3", LastModified: now, IsLatest: true, IsDeleteMarker: true},
+		{VersionID: "2", LastModified: now.Add(-time.Hour)},
+		{VersionID: "1", LastModified: now.Add(-2 * time.Hour)},
+	}, versions)
+}
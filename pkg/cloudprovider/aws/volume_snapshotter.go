@@ -24,6 +24,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
@@ -50,6 +51,18 @@ type VolumeSnapshotter struct {
 
 // takes AWS credential config & a profile to create a new session
 func getSession(config *aws.Config, profile string) (*session.Session, error) {
+	// if the pod's service account has an IAM role, it's assumed, unless
+	// the config already has credentials, e.g. from a credentials file.
+	if config.Credentials == nil {
+		webIdentityCredentials, err := newWebIdentityCredentials(config)
+		if err != nil {
+			return nil, err
+		}
+		if webIdentityCredentials != nil {
+			config = config.Copy().WithCredentials(webIdentityCredentials)
+		}
+	}
+
 	sessionOptions := session.Options{Config: *config, Profile: profile}
 	sess, err := session.NewSessionWithOptions(sessionOptions)
 	if err != nil {
@@ -68,7 +81,7 @@ func NewVolumeSnapshotter(logger logrus.FieldLogger) *VolumeSnapshotter {
 }
 
 func (b *VolumeSnapshotter) Init(config map[string]string) error {
-	if err := cloudprovider.ValidateVolumeSnapshotterConfigKeys(config, regionKey, credentialProfileKey); err != nil {
+	if err := cloudprovider.ValidateVolumeSnapshotterConfigKeys(config, regionKey, credentialProfileKey, cloudprovider.CredentialsFileConfigKey); err != nil {
 		return err
 	}
 
@@ -79,6 +92,9 @@ func (b *VolumeSnapshotter) Init(config map[string]string) error {
 	}
 
 	awsConfig := aws.NewConfig().WithRegion(region)
+	if credentialsFile := config[cloudprovider.CredentialsFileConfigKey]; credentialsFile != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewSharedCredentials(credentialsFile, credentialProfile))
+	}
 
 	sess, err := getSession(awsConfig, credentialProfile)
 	if err != nil {
@@ -262,6 +278,37 @@ func (b *VolumeSnapshotter) DeleteSnapshot(snapshotID string) error {
 	return nil
 }
 
+// ListSnapshots returns the IDs of the snapshots owned by this account that have a
+// tag with the given key, mapped to the tag's value.
+func (b *VolumeSnapshotter) ListSnapshots(tagKey string) (map[string]string, error) {
+	req := &ec2.DescribeSnapshotsInput{
+		OwnerIds: []*string{aws.String("self")},
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []*string{&tagKey},
+			},
+		},
+	}
+
+	snapshots := make(map[string]string)
+	err := b.ec2.DescribeSnapshotsPages(req, func(res *ec2.DescribeSnapshotsOutput, lastPage bool) bool {
+		for _, snapshot := range res.Snapshots {
+			for _, tag := range snapshot.Tags {
+				if aws.StringValue(tag.Key) == tagKey {
+					snapshots[aws.StringValue(snapshot.SnapshotId)] = aws.StringValue(tag.Value)
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return snapshots, nil
+}
+
 var ebsVolumeIDRegex = regexp.MustCompile("vol-.*")
 
 func (b *VolumeSnapshotter) GetVolumeID(unstructuredPV runtime.Unstructured) (string, error) {
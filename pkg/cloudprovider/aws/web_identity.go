@@ -0,0 +1,124 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+const (
+	// roleARNEnvVar and webIdentityTokenFileEnvVar are set in pods whose
+	// service account is annotated with an IAM role (IAM Roles for Service
+	// Accounts), by the EKS pod identity webhook.
+	roleARNEnvVar              = "AWS_ROLE_ARN"
+	webIdentityTokenFileEnvVar = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	roleSessionNameEnvVar      = "AWS_ROLE_SESSION_NAME"
+
+	webIdentityProviderName = "WebIdentityProvider"
+
+	// webIdentityExpiryWindow is how long before the role's temporary
+	// credentials expire that they're refreshed.
+	webIdentityExpiryWindow = 5 * time.Minute
+)
+
+// webIdentityRoleAssumer is the part of the STS client that's used to
+// assume a role with a web identity token, so that it can be faked for
+// testing.
+type webIdentityRoleAssumer interface {
+	AssumeRoleWithWebIdentity(input *sts.AssumeRoleWithWebIdentityInput) (*sts.AssumeRoleWithWebIdentityOutput, error)
+}
+
+// webIdentityRoleProvider is a credentials.Provider that assumes an IAM
+// role with the service account token that's projected into the pod. The
+// token file is read each time the role is assumed, since the kubelet
+// rotates it, and the role's temporary credentials are refreshed before
+// they expire.
+type webIdentityRoleProvider struct {
+	credentials.Expiry
+
+	client          webIdentityRoleAssumer
+	roleARN         string
+	roleSessionName string
+	tokenFile       string
+	readFile        func(string) ([]byte, error)
+}
+
+// newWebIdentityCredentials returns credentials for the IAM role in
+// $AWS_ROLE_ARN, assumed with the token in $AWS_WEB_IDENTITY_TOKEN_FILE,
+// or nil if either isn't set.
+func newWebIdentityCredentials(config *aws.Config) (*credentials.Credentials, error) {
+	roleARN, tokenFile := os.Getenv(roleARNEnvVar), os.Getenv(webIdentityTokenFileEnvVar)
+	if roleARN == "" || tokenFile == "" {
+		return nil, nil
+	}
+
+	// assuming a role with a web identity token is an unsigned request,
+	// so the STS client doesn't need credentials of its own.
+	stsSession, err := session.NewSession(aws.NewConfig().WithRegion(aws.StringValue(config.Region)).WithCredentials(credentials.AnonymousCredentials))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	roleSessionName := os.Getenv(roleSessionNameEnvVar)
+	if roleSessionName == "" {
+		roleSessionName = fmt.Sprintf("velero-%d", time.Now().UnixNano())
+	}
+
+	return credentials.NewCredentials(&webIdentityRoleProvider{
+		client:          sts.New(stsSession),
+		roleARN:         roleARN,
+		roleSessionName: roleSessionName,
+		tokenFile:       tokenFile,
+		readFile:        ioutil.ReadFile,
+	}), nil
+}
+
+// Retrieve assumes the role with the current token, and returns the role's
+// temporary credentials.
+func (p *webIdentityRoleProvider) Retrieve() (credentials.Value, error) {
+	token, err := p.readFile(p.tokenFile)
+	if err != nil {
+		return credentials.Value{ProviderName: webIdentityProviderName}, errors.Wrapf(err, "error reading web identity token file %s", p.tokenFile)
+	}
+
+	res, err := p.client.AssumeRoleWithWebIdentity(&sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(p.roleARN),
+		RoleSessionName:  aws.String(p.roleSessionName),
+		WebIdentityToken: aws.String(string(token)),
+	})
+	if err != nil {
+		return credentials.Value{ProviderName: webIdentityProviderName}, errors.Wrapf(err, "error assuming role %s with web identity token", p.roleARN)
+	}
+
+	p.SetExpiration(aws.TimeValue(res.Credentials.Expiration), webIdentityExpiryWindow)
+
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(res.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(res.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(res.Credentials.SessionToken),
+		ProviderName:    webIdentityProviderName,
+	}, nil
+}
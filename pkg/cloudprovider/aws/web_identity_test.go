@@ -0,0 +1,91 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRoleAssumer struct {
+	inputs []*sts.AssumeRoleWithWebIdentityInput
+	expiry time.Time
+}
+
+func (f *fakeRoleAssumer) AssumeRoleWithWebIdentity(input *sts.AssumeRoleWithWebIdentityInput) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+	f.inputs = append(f.inputs, input)
+
+	return &sts.AssumeRoleWithWebIdentityOutput{
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String(fmt.Sprintf("key-%d", len(f.inputs))),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("session-token"),
+			Expiration:      aws.Time(f.expiry),
+		},
+	}, nil
+}
+
+func TestWebIdentityRoleProvider(t *testing.T) {
+	now := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+	token := "token-1"
+
+	client := &fakeRoleAssumer{expiry: now.Add(time.Hour)}
+	provider := &webIdentityRoleProvider{
+		client:          client,
+		roleARN:         "arn:aws:iam::123456789012:role/velero",
+		roleSessionName: "velero-1",
+		tokenFile:       "/var/run/secrets/eks.amazonaws.com/serviceaccount/token",
+		readFile: func(string) ([]byte, error) {
+			return []byte(token), nil
+		},
+	}
+	provider.CurrentTime = func() time.Time { return now }
+
+	value, err := provider.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", value.AccessKeyID)
+	assert.Equal(t, "session-token", value.SessionToken)
+	require.Len(t, client.inputs, 1)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/velero", aws.StringValue(client.inputs[0].RoleArn))
+	assert.Equal(t, "token-1", aws.StringValue(client.inputs[0].WebIdentityToken))
+
+	// the credentials are refreshed shortly before they expire, with the
+	// token that's current by then.
+	now = now.Add(50 * time.Minute)
+	assert.False(t, provider.IsExpired())
+	now = now.Add(6 * time.Minute)
+	assert.True(t, provider.IsExpired())
+
+	token = "token-2"
+	value, err = provider.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", value.AccessKeyID)
+	assert.Equal(t, "token-2", aws.StringValue(client.inputs[1].WebIdentityToken))
+
+	provider.readFile = func(string) ([]byte, error) {
+		return nil, errors.New("no such file")
+	}
+	_, err = provider.Retrieve()
+	assert.EqualError(t, err, "error reading web identity token file /var/run/secrets/eks.amazonaws.com/serviceaccount/token: no such file")
+}
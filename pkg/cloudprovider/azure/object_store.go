@@ -27,10 +27,12 @@ import (
 	"github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/heptio/velero/pkg/cloudprovider"
+	"github.com/heptio/velero/pkg/plugin/velero"
 )
 
 const (
@@ -98,6 +100,8 @@ type blob interface {
 	Get(options *storage.GetBlobOptions) (io.ReadCloser, error)
 	Delete(options *storage.DeleteBlobOptions) error
 	GetSASURI(options *storage.BlobSASOptions) (string, error)
+	GetURL() string
+	Copy(sourceBlob string, options *storage.CopyOptions) error
 }
 
 type azureBlob struct {
@@ -124,6 +128,14 @@ func (b *azureBlob) GetSASURI(options *storage.BlobSASOptions) (string, error) {
 	return b.blob.GetSASURI(*options)
 }
 
+func (b *azureBlob) GetURL() string {
+	return b.blob.GetURL()
+}
+
+func (b *azureBlob) Copy(sourceBlob string, options *storage.CopyOptions) error {
+	return b.blob.Copy(sourceBlob, options)
+}
+
 type ObjectStore struct {
 	containerGetter containerGetter
 	blobGetter      blobGetter
@@ -135,13 +147,23 @@ func NewObjectStore(logger logrus.FieldLogger) *ObjectStore {
 }
 
 func getStorageAccountKey(config map[string]string) (string, error) {
-	// load environment vars from $AZURE_CREDENTIALS_FILE, if it exists
-	if err := loadEnv(); err != nil {
+	getEnv := os.Getenv
+	if credentialsFile := config[cloudprovider.CredentialsFileConfigKey]; credentialsFile != "" {
+		// the credentials file has the same format as $AZURE_CREDENTIALS_FILE,
+		// but it's read without loading it into the environment, since its
+		// credentials are only for this object store.
+		values, err := godotenv.Read(credentialsFile)
+		if err != nil {
+			return "", errors.Wrapf(err, "error reading credentials file %s", credentialsFile)
+		}
+		getEnv = mapLookup(values)
+	} else if err := loadEnv(); err != nil {
+		// load environment vars from $AZURE_CREDENTIALS_FILE, if it exists
 		return "", err
 	}
 
 	// 1. we need AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_CLIENT_SECRET, AZURE_SUBSCRIPTION_ID
-	envVars, err := getRequiredValues(os.Getenv, tenantIDEnvVar, clientIDEnvVar, clientSecretEnvVar, subscriptionIDEnvVar)
+	envVars, err := getRequiredValues(getEnv, tenantIDEnvVar, clientIDEnvVar, clientSecretEnvVar, subscriptionIDEnvVar)
 	if err != nil {
 		return "", errors.Wrap(err, "unable to get all required environment variables")
 	}
@@ -194,7 +216,7 @@ func mapLookup(data map[string]string) func(string) string {
 }
 
 func (o *ObjectStore) Init(config map[string]string) error {
-	if err := cloudprovider.ValidateObjectStoreConfigKeys(config, resourceGroupConfigKey, storageAccountConfigKey); err != nil {
+	if err := cloudprovider.ValidateObjectStoreConfigKeys(config, resourceGroupConfigKey, storageAccountConfigKey, cloudprovider.CredentialsFileConfigKey); err != nil {
 		return err
 	}
 
@@ -325,3 +347,46 @@ func (o *ObjectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (st
 
 	return blob.GetSASURI(&opts)
 }
+
+// CopyObject copies a blob within a container on the Azure side, and waits
+// for the copy to complete.
+func (o *ObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	src, err := o.blobGetter.getBlob(bucket, srcKey)
+	if err != nil {
+		return err
+	}
+
+	dest, err := o.blobGetter.getBlob(bucket, destKey)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrapf(dest.Copy(src.GetURL(), nil), "error copying blob %s to %s", srcKey, destKey)
+}
+
+// Capabilities returns the optional features that Azure Blob Storage
+// supports. Blobs are uploaded in a single request.
+func (o *ObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	return velero.ObjectStoreCapabilities{
+		SignedURLs:     true,
+		ServerSideCopy: true,
+	}, nil
+}
+
+// ListObjectVersions isn't supported, since Azure Blob Storage keeps
+// snapshots of blobs rather than versions.
+func (o *ObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	return nil, errors.New("object versioning is not supported for Azure Blob Storage")
+}
+
+// GetObjectVersion isn't supported, since Azure Blob Storage keeps
+// snapshots of blobs rather than versions.
+func (o *ObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	return nil, errors.New("object versioning is not supported for Azure Blob Storage")
+}
+
+// LockObject isn't supported, since Azure Blob Storage's immutability
+// policies apply to whole containers rather than to individual blobs.
+func (o *ObjectStore) LockObject(bucket, key string, until time.Time) error {
+	return errors.New("object lock is not supported for Azure Blob Storage")
+}
@@ -133,6 +133,16 @@ func (m *mockBlob) GetSASURI(options *storage.BlobSASOptions) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *mockBlob) GetURL() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *mockBlob) Copy(sourceBlob string, options *storage.CopyOptions) error {
+	args := m.Called(sourceBlob, options)
+	return args.Error(0)
+}
+
 type mockContainerGetter struct {
 	mock.Mock
 }
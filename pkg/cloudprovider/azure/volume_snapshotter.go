@@ -28,6 +28,7 @@ import (
 	disk "github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-04-01/compute"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
@@ -72,17 +73,27 @@ func NewVolumeSnapshotter(logger logrus.FieldLogger) *VolumeSnapshotter {
 }
 
 func (b *VolumeSnapshotter) Init(config map[string]string) error {
-	if err := cloudprovider.ValidateVolumeSnapshotterConfigKeys(config, resourceGroupConfigKey, apiTimeoutConfigKey); err != nil {
+	if err := cloudprovider.ValidateVolumeSnapshotterConfigKeys(config, resourceGroupConfigKey, apiTimeoutConfigKey, cloudprovider.CredentialsFileConfigKey); err != nil {
 		return err
 	}
 
-	// load environment vars from $AZURE_CREDENTIALS_FILE, if it exists
-	if err := loadEnv(); err != nil {
+	getEnv := os.Getenv
+	if credentialsFile := config[cloudprovider.CredentialsFileConfigKey]; credentialsFile != "" {
+		// the credentials file has the same format as $AZURE_CREDENTIALS_FILE,
+		// but it's read without loading it into the environment, since its
+		// credentials are only for this volume snapshotter.
+		values, err := godotenv.Read(credentialsFile)
+		if err != nil {
+			return errors.Wrapf(err, "error reading credentials file %s", credentialsFile)
+		}
+		getEnv = mapLookup(values)
+	} else if err := loadEnv(); err != nil {
+		// load environment vars from $AZURE_CREDENTIALS_FILE, if it exists
 		return err
 	}
 
 	// 1. we need AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_CLIENT_SECRET, AZURE_SUBSCRIPTION_ID, AZURE_RESOURCE_GROUP
-	envVars, err := getRequiredValues(os.Getenv, tenantIDEnvVar, clientIDEnvVar, clientSecretEnvVar, subscriptionIDEnvVar, resourceGroupEnvVar)
+	envVars, err := getRequiredValues(getEnv, tenantIDEnvVar, clientIDEnvVar, clientSecretEnvVar, subscriptionIDEnvVar, resourceGroupEnvVar)
 	if err != nil {
 		return errors.Wrap(err, "unable to get all required environment variables")
 	}
@@ -310,6 +321,41 @@ func (b *VolumeSnapshotter) DeleteSnapshot(snapshotID string) error {
 	return nil
 }
 
+// ListSnapshots returns the fully-qualified names of the snapshots in the snapshot
+// resource group that have a tag with the given key, mapped to the tag's value.
+func (b *VolumeSnapshotter) ListSnapshots(tagKey string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.apiTimeout)
+	defer cancel()
+
+	// tag keys are stored with slashes replaced by dashes (see getSnapshotTags)
+	key := strings.Replace(tagKey, "/", "-", -1)
+
+	iter, err := b.snaps.ListByResourceGroupComplete(ctx, b.snapsResourceGroup)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	snapshots := make(map[string]string)
+	for ; iter.NotDone(); err = iter.Next() {
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		snapshot := iter.Value()
+		if snapshot.Name == nil {
+			continue
+		}
+		if val, ok := snapshot.Tags[key]; ok && val != nil {
+			snapshots[getComputeResourceName(b.subscription, b.snapsResourceGroup, snapshotsResource, *snapshot.Name)] = *val
+		}
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return snapshots, nil
+}
+
 func getComputeResourceName(subscription, resourceGroup, resource, name string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/%s/%s", subscription, resourceGroup, resource, name)
 }
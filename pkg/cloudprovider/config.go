@@ -21,6 +21,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
+// CredentialsFileConfigKey is the object store config key that the Velero
+// server sets to the path of a file with credentials to use instead of the
+// object store's usual credentials, e.g. for a restore with break-glass
+// credentials. The file has the same format as the object store's usual
+// credentials file. Object stores that support it list it as a valid key.
+const CredentialsFileConfigKey = "credentialsFile"
+
 // ValidateObjectStoreConfigKeys ensures that an object store's config
 // is valid by making sure each `config` key is in the `validKeys` list.
 // The special key "bucket" is always considered valid.
@@ -0,0 +1,88 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	iamCredentialsEndpoint = "https://iamcredentials.googleapis.com/v1"
+	iamCredentialsScope    = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// iamSigner signs bytes as a service account with the IAM Credentials
+// API's signBlob method, for signing URLs without the service account's
+// private key, e.g. with GKE Workload Identity. The service account needs
+// the iam.serviceAccounts.signBlob permission on itself, which is part of
+// the Service Account Token Creator role.
+type iamSigner struct {
+	// client is an HTTP client that authenticates its requests, and
+	// refreshes its access token as needed.
+	client         *http.Client
+	endpoint       string
+	serviceAccount string
+}
+
+type signBlobRequest struct {
+	Payload string `json:"payload"`
+}
+
+type signBlobResponse struct {
+	SignedBlob string `json:"signedBlob"`
+}
+
+func (s *iamSigner) signBytes(payload []byte) ([]byte, error) {
+	body, err := json.Marshal(signBlobRequest{Payload: base64.StdEncoding.EncodeToString(payload)})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	url := fmt.Sprintf("%s/projects/-/serviceAccounts/%s:signBlob", s.endpoint, s.serviceAccount)
+	res, err := s.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "error signing blob with the IAM credentials API")
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("error signing blob as service account %s with the IAM credentials API: %s: %s", s.serviceAccount, res.Status, bytes.TrimSpace(resBody))
+	}
+
+	var signed signBlobResponse
+	if err := json.Unmarshal(resBody, &signed); err != nil {
+		return nil, errors.Wrap(err, "error decoding the IAM credentials API's signBlob response")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signed.SignedBlob)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding signed blob")
+	}
+
+	return signature, nil
+}
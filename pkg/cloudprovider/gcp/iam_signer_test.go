@@ -0,0 +1,64 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIAMSignerSignBytes(t *testing.T) {
+	var (
+		gotPath    string
+		gotRequest signBlobRequest
+		status     = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotRequest))
+
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			w.Write([]byte("permission denied\n"))
+			return
+		}
+		json.NewEncoder(w).Encode(signBlobResponse{SignedBlob: base64.StdEncoding.EncodeToString([]byte("signature"))})
+	}))
+	defer server.Close()
+
+	signer := &iamSigner{
+		client:         server.Client(),
+		endpoint:       server.URL,
+		serviceAccount: "velero@my-project.iam.gserviceaccount.com",
+	}
+
+	signature, err := signer.signBytes([]byte("payload"))
+	require.NoError(t, err)
+	assert.Equal(t, "signature", string(signature))
+	assert.Equal(t, "/projects/-/serviceAccounts/velero@my-project.iam.gserviceaccount.com:signBlob", gotPath)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("payload")), gotRequest.Payload)
+
+	status = http.StatusForbidden
+	_, err = signer.signBytes([]byte("payload"))
+	assert.EqualError(t, err, "error signing blob as service account velero@my-project.iam.gserviceaccount.com with the IAM credentials API: 403 Forbidden: permission denied")
+}
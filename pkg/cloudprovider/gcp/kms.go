@@ -0,0 +1,124 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	cloudKMSEndpoint = "https://cloudkms.googleapis.com/v1"
+	cloudKMSScope    = "https://www.googleapis.com/auth/cloudkms"
+)
+
+// KMS encrypts and decrypts data with Cloud KMS keys. The vendored Google
+// API client doesn't include Cloud KMS, so its REST API is called directly.
+type KMS struct {
+	endpoint  string
+	newClient func() (*http.Client, error)
+}
+
+// NewKMS returns a KMS that calls Cloud KMS with the application default
+// credentials, i.e. $GOOGLE_APPLICATION_CREDENTIALS or the service account
+// from the metadata server.
+func NewKMS() *KMS {
+	return &KMS{
+		endpoint: cloudKMSEndpoint,
+		newClient: func() (*http.Client, error) {
+			return google.DefaultClient(context.Background(), cloudKMSScope)
+		},
+	}
+}
+
+type kmsEncryptRequest struct {
+	Plaintext []byte `json:"plaintext"`
+}
+
+type kmsEncryptResponse struct {
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type kmsDecryptRequest struct {
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type kmsDecryptResponse struct {
+	Plaintext []byte `json:"plaintext"`
+}
+
+// Encrypt encrypts plaintext with the Cloud KMS key with the given
+// resource name, e.g. projects/my-project/locations/global/keyRings/velero/cryptoKeys/credentials.
+func (k *KMS) Encrypt(keyID string, plaintext []byte) ([]byte, error) {
+	var res kmsEncryptResponse
+	if err := k.call(keyID, "encrypt", &kmsEncryptRequest{Plaintext: plaintext}, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Ciphertext, nil
+}
+
+// Decrypt decrypts ciphertext that was encrypted with the Cloud KMS key
+// with the given resource name.
+func (k *KMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	var res kmsDecryptResponse
+	if err := k.call(keyID, "decrypt", &kmsDecryptRequest{Ciphertext: ciphertext}, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Plaintext, nil
+}
+
+func (k *KMS) call(keyID, method string, input, output interface{}) error {
+	client, err := k.newClient()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	url := fmt.Sprintf("%s/%s:%s", k.endpoint, keyID, method)
+	res, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "error calling Cloud KMS %s", method)
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("error calling Cloud KMS %s with key %s: %s: %s", method, keyID, res.Status, bytes.TrimSpace(resBody))
+	}
+
+	if err := json.Unmarshal(resBody, output); err != nil {
+		return errors.Wrapf(err, "error decoding Cloud KMS %s response", method)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKMS(t *testing.T) {
+	keyID := "projects/my-project/locations/global/keyRings/velero/cryptoKeys/credentials"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + keyID + ":encrypt":
+			var req kmsEncryptRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			json.NewEncoder(w).Encode(kmsEncryptResponse{Ciphertext: append([]byte("encrypted:"), req.Plaintext...)})
+		case "/" + keyID + ":decrypt":
+			var req kmsDecryptRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if !strings.HasPrefix(string(req.Ciphertext), "encrypted:") {
+				http.Error(w, "bad ciphertext", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(kmsDecryptResponse{Plaintext: req.Ciphertext[len("encrypted:"):]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	kms := &KMS{
+		endpoint:  server.URL,
+		newClient: func() (*http.Client, error) { return server.Client(), nil },
+	}
+
+	ciphertext, err := kms.Encrypt(keyID, []byte("data key"))
+	require.NoError(t, err)
+	assert.Equal(t, "encrypted:data key", string(ciphertext))
+
+	plaintext, err := kms.Decrypt(keyID, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "data key", string(plaintext))
+
+	_, err = kms.Decrypt(keyID, []byte("garbage"))
+	assert.EqualError(t, err, "error calling Cloud KMS decrypt with key "+keyID+": 400 Bad Request: bad ciphertext")
+}
@@ -21,8 +21,11 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strconv"
 	"time"
 
+	"cloud.google.com/go/compute/metadata"
 	"cloud.google.com/go/storage"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -31,6 +34,7 @@ import (
 	"google.golang.org/api/option"
 
 	"github.com/heptio/velero/pkg/cloudprovider"
+	"github.com/heptio/velero/pkg/plugin/velero"
 )
 
 const credentialsEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
@@ -59,7 +63,9 @@ type ObjectStore struct {
 	client         *storage.Client
 	googleAccessID string
 	privateKey     []byte
-	bucketWriter   bucketWriter
+	// signBytes signs URLs instead of privateKey, if it's set.
+	signBytes    func([]byte) ([]byte, error)
+	bucketWriter bucketWriter
 }
 
 func NewObjectStore(logger logrus.FieldLogger) *ObjectStore {
@@ -67,16 +73,41 @@ func NewObjectStore(logger logrus.FieldLogger) *ObjectStore {
 }
 
 func (o *ObjectStore) Init(config map[string]string) error {
-	if err := cloudprovider.ValidateObjectStoreConfigKeys(config); err != nil {
+	if err := cloudprovider.ValidateObjectStoreConfigKeys(config, cloudprovider.CredentialsFileConfigKey); err != nil {
 		return err
 	}
 
-	credentialsFile := os.Getenv(credentialsEnvVar)
-	if credentialsFile == "" {
-		return errors.Errorf("%s is undefined", credentialsEnvVar)
+	clientOptions := []option.ClientOption{option.WithScopes(storage.ScopeReadWrite)}
+
+	credentialsFile := config[cloudprovider.CredentialsFileConfigKey]
+	if credentialsFile != "" {
+		clientOptions = append(clientOptions, option.WithCredentialsFile(credentialsFile))
+	} else {
+		credentialsFile = os.Getenv(credentialsEnvVar)
+	}
+
+	if credentialsFile != "" {
+		if err := o.initKeySigning(credentialsFile); err != nil {
+			return err
+		}
+	} else if err := o.initWorkloadIdentitySigning(); err != nil {
+		return err
 	}
 
-	// Get the email and private key from the credentials file so we can pre-sign download URLs
+	client, err := storage.NewClient(context.Background(), clientOptions...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	o.client = client
+
+	o.bucketWriter = &writer{client: o.client}
+
+	return nil
+}
+
+// initKeySigning gets the email and private key from a service account's
+// credentials file, to pre-sign download URLs with.
+func (o *ObjectStore) initKeySigning(credentialsFile string) error {
 	creds, err := ioutil.ReadFile(credentialsFile)
 	if err != nil {
 		return errors.WithStack(err)
@@ -86,22 +117,40 @@ func (o *ObjectStore) Init(config map[string]string) error {
 		return errors.WithStack(err)
 	}
 	if jwtConfig.Email == "" {
-		return errors.Errorf("credentials file pointed to by %s does not contain an email", credentialsEnvVar)
+		return errors.Errorf("credentials file %s does not contain an email", credentialsFile)
 	}
 	if len(jwtConfig.PrivateKey) == 0 {
-		return errors.Errorf("credentials file pointed to by %s does not contain a private key", credentialsEnvVar)
+		return errors.Errorf("credentials file %s does not contain a private key", credentialsFile)
 	}
 
 	o.googleAccessID = jwtConfig.Email
 	o.privateKey = jwtConfig.PrivateKey
 
-	client, err := storage.NewClient(context.Background(), option.WithScopes(storage.ScopeReadWrite))
+	return nil
+}
+
+// initWorkloadIdentitySigning sets up pre-signing download URLs when
+// there's no credentials file, so the client's credentials come from the
+// metadata server: the GKE Workload Identity of the pod's service account,
+// or else the node's service account. There's no private key to sign URLs
+// with, so they're signed by the IAM credentials API.
+func (o *ObjectStore) initWorkloadIdentitySigning() error {
+	if !metadata.OnGCE() {
+		return errors.Errorf("%s is undefined, and the metadata server for Workload Identity isn't available", credentialsEnvVar)
+	}
+
+	email, err := metadata.Get("instance/service-accounts/default/email")
+	if err != nil {
+		return errors.Wrap(err, "error getting the service account's email from the metadata server")
+	}
+
+	client, err := google.DefaultClient(context.Background(), iamCredentialsScope)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	o.client = client
 
-	o.bucketWriter = &writer{client: o.client}
+	o.googleAccessID = email
+	o.signBytes = (&iamSigner{client: client, endpoint: iamCredentialsEndpoint, serviceAccount: email}).signBytes
 
 	return nil
 }
@@ -198,7 +247,94 @@ func (o *ObjectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (st
 	return storage.SignedURL(bucket, key, &storage.SignedURLOptions{
 		GoogleAccessID: o.googleAccessID,
 		PrivateKey:     o.privateKey,
+		SignBytes:      o.signBytes,
 		Method:         "GET",
 		Expires:        time.Now().Add(ttl),
 	})
 }
+
+// CopyObject copies an object within a bucket on the Google Cloud Storage
+// side, by rewriting it.
+func (o *ObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	src := o.client.Bucket(bucket).Object(srcKey)
+	dest := o.client.Bucket(bucket).Object(destKey)
+
+	_, err := dest.CopierFrom(src).Run(context.Background())
+
+	return errors.Wrapf(err, "error copying object %s to %s", srcKey, destKey)
+}
+
+// Capabilities returns the optional features that Google Cloud Storage
+// supports. Objects are written with resumable uploads, in chunks.
+func (o *ObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	return velero.ObjectStoreCapabilities{
+		SignedURLs:      true,
+		MultipartUpload: true,
+		ServerSideCopy:  true,
+		Versioning:      true,
+	}, nil
+}
+
+// ListObjectVersions lists the generations of the object with the given key,
+// newest first. The bucket must have object versioning enabled for it to keep
+// noncurrent generations. Google Cloud Storage doesn't have delete markers;
+// when an object is deleted, its live generation becomes noncurrent.
+func (o *ObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	q := &storage.Query{
+		Prefix:   key,
+		Versions: true,
+	}
+
+	var res []velero.ObjectVersion
+
+	iter := o.client.Bucket(bucket).Objects(context.Background(), q)
+
+	for {
+		obj, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error listing versions of object %s", key)
+		}
+
+		// the prefix also matches any longer keys that start with the key.
+		if obj.Name != key {
+			continue
+		}
+
+		res = append(res, velero.ObjectVersion{
+			VersionID:    strconv.FormatInt(obj.Generation, 10),
+			LastModified: obj.Created,
+			IsLatest:     obj.Deleted.IsZero(),
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].LastModified.After(res[j].LastModified)
+	})
+
+	return res, nil
+}
+
+// GetObjectVersion retrieves the given generation of the object with the
+// given key.
+func (o *ObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	generation, err := strconv.ParseInt(versionID, 10, 64)
+	if err != nil {
+		return nil, errors.Errorf("invalid version %q of object %s: versions are object generations", versionID, key)
+	}
+
+	r, err := o.client.Bucket(bucket).Object(key).Generation(generation).NewReader(context.Background())
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting version %s of object %s", versionID, key)
+	}
+
+	return r, nil
+}
+
+// LockObject isn't supported, since Google Cloud Storage only supports
+// retention policies for whole buckets rather than for individual objects.
+func (o *ObjectStore) LockObject(bucket, key string, until time.Time) error {
+	return errors.New("object lock is not supported for Google Cloud Storage")
+}
@@ -17,12 +17,14 @@ limitations under the License.
 package gcp
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
 
+	"cloud.google.com/go/compute/metadata"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
@@ -56,29 +58,31 @@ func NewVolumeSnapshotter(logger logrus.FieldLogger) *VolumeSnapshotter {
 }
 
 func (b *VolumeSnapshotter) Init(config map[string]string) error {
-	if err := cloudprovider.ValidateVolumeSnapshotterConfigKeys(config, snapshotLocationKey, projectKey); err != nil {
+	if err := cloudprovider.ValidateVolumeSnapshotterConfigKeys(config, snapshotLocationKey, projectKey, cloudprovider.CredentialsFileConfigKey); err != nil {
 		return err
 	}
 
 	b.snapshotLocation = config[snapshotLocationKey]
 
-	project, err := extractProjectFromCreds()
+	credentialsFile := config[cloudprovider.CredentialsFileConfigKey]
+	if credentialsFile == "" {
+		credentialsFile = os.Getenv(credentialsEnvVar)
+	}
+
+	creds, err := getCredentials(credentialsFile)
 	if err != nil {
 		return err
 	}
-	b.volumeProject = project
+	b.volumeProject = creds.ProjectID
 
 	// get snapshot project from 'project' config key if specified,
-	// otherwise from the credentials file
+	// otherwise from the credentials
 	b.snapshotProject = config[projectKey]
 	if b.snapshotProject == "" {
 		b.snapshotProject = b.volumeProject
 	}
 
-	client, err := google.DefaultClient(oauth2.NoContext, compute.ComputeScope)
-	if err != nil {
-		return errors.WithStack(err)
-	}
+	client := oauth2.NewClient(oauth2.NoContext, creds.TokenSource)
 
 	gce, err := compute.New(client)
 	if err != nil {
@@ -90,12 +94,47 @@ func (b *VolumeSnapshotter) Init(config map[string]string) error {
 	return nil
 }
 
-func extractProjectFromCreds() (string, error) {
-	credsBytes, err := ioutil.ReadFile(os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
+// getCredentials returns the credentials in the given file or, if there
+// isn't one, the credentials of the GKE Workload Identity or node service
+// account from the metadata server. The credentials' project is the project
+// that volumes are in.
+func getCredentials(credentialsFile string) (*google.Credentials, error) {
+	if credentialsFile == "" {
+		if !metadata.OnGCE() {
+			return nil, errors.Errorf("%s is undefined, and the metadata server for Workload Identity isn't available", credentialsEnvVar)
+		}
+
+		creds, err := google.FindDefaultCredentials(oauth2.NoContext, compute.ComputeScope)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if creds.ProjectID == "" {
+			return nil, errors.New("cannot fetch project ID from the metadata server")
+		}
+
+		return creds, nil
+	}
+
+	credsBytes, err := ioutil.ReadFile(credentialsFile)
 	if err != nil {
-		return "", errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 
+	project, err := extractProjectFromCreds(credsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := google.CredentialsFromJSON(oauth2.NoContext, credsBytes, compute.ComputeScope)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	creds.ProjectID = project
+
+	return creds, nil
+}
+
+func extractProjectFromCreds(credsBytes []byte) (string, error) {
 	type credentials struct {
 		ProjectID string `json:"project_id"`
 	}
@@ -345,6 +384,32 @@ func (b *VolumeSnapshotter) DeleteSnapshot(snapshotID string) error {
 	return nil
 }
 
+// ListSnapshots returns the names of the snapshots in the snapshot project that have
+// a tag with the given key in their description, mapped to the tag's value.
+func (b *VolumeSnapshotter) ListSnapshots(tagKey string) (map[string]string, error) {
+	snapshots := make(map[string]string)
+
+	err := b.gce.Snapshots.List(b.snapshotProject).Pages(context.Background(), func(res *compute.SnapshotList) error {
+		for _, snapshot := range res.Items {
+			// snapshots whose description isn't a JSON doc weren't tagged by Velero
+			var tags map[string]string
+			if err := json.Unmarshal([]byte(snapshot.Description), &tags); err != nil {
+				continue
+			}
+
+			if val, ok := tags[tagKey]; ok {
+				snapshots[snapshot.Name] = val
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return snapshots, nil
+}
+
 func (b *VolumeSnapshotter) GetVolumeID(unstructuredPV runtime.Unstructured) (string, error) {
 	pv := new(v1.PersistentVolume)
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredPV.UnstructuredContent(), pv); err != nil {
@@ -23,6 +23,8 @@ import (
 	"io/ioutil"
 	"strings"
 	"time"
+
+	"github.com/heptio/velero/pkg/plugin/velero"
 )
 
 type BucketData map[string][]byte
@@ -94,6 +96,22 @@ func (o *InMemoryObjectStore) GetObject(bucket, key string) (io.ReadCloser, erro
 	return ioutil.NopCloser(bytes.NewReader(obj)), nil
 }
 
+func (o *InMemoryObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	bucketData, ok := o.Data[bucket]
+	if !ok {
+		return errors.New("bucket not found")
+	}
+
+	obj, ok := bucketData[srcKey]
+	if !ok {
+		return errors.New("key not found")
+	}
+
+	bucketData[destKey] = append([]byte(nil), obj...)
+
+	return nil
+}
+
 func (o *InMemoryObjectStore) ListCommonPrefixes(bucket, prefix, delimiter string) ([]string, error) {
 	keys, err := o.ListObjects(bucket, prefix)
 	if err != nil {
@@ -104,6 +122,7 @@ func (o *InMemoryObjectStore) ListCommonPrefixes(bucket, prefix, delimiter strin
 	// If not, skip it; if so, return the prefix of the key up to/including the delimiter.
 
 	var prefixes []string
+	seen := make(map[string]bool)
 	for _, key := range keys {
 		// everything after 'prefix'
 		afterPrefix := key[len(prefix):]
@@ -118,6 +137,12 @@ func (o *InMemoryObjectStore) ListCommonPrefixes(bucket, prefix, delimiter strin
 		// the delimiter, plus the delimiter
 		fullPrefix := prefix + afterPrefix[0:delimiterStart] + delimiter
 
+		// like object stores' APIs, return each common prefix once.
+		if seen[fullPrefix] {
+			continue
+		}
+		seen[fullPrefix] = true
+
 		prefixes = append(prefixes, fullPrefix)
 	}
 
@@ -176,3 +201,19 @@ func (o *InMemoryObjectStore) ClearBucket(bucket string) {
 
 	o.Data[bucket] = make(map[string][]byte)
 }
+
+func (o *InMemoryObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	return velero.ObjectStoreCapabilities{SignedURLs: true, ServerSideCopy: true}, nil
+}
+
+func (o *InMemoryObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	return nil, errors.New("versioning not supported")
+}
+
+func (o *InMemoryObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	return nil, errors.New("versioning not supported")
+}
+
+func (o *InMemoryObjectStore) LockObject(bucket, key string, until time.Time) error {
+	return errors.New("object lock not supported")
+}
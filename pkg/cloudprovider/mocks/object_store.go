@@ -20,12 +20,48 @@ package mocks
 import io "io"
 import mock "github.com/stretchr/testify/mock"
 import time "time"
+import velero "github.com/heptio/velero/pkg/plugin/velero"
 
 // ObjectStore is an autogenerated mock type for the ObjectStore type
 type ObjectStore struct {
 	mock.Mock
 }
 
+// Capabilities provides a mock function with given fields:
+func (_m *ObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	ret := _m.Called()
+
+	var r0 velero.ObjectStoreCapabilities
+	if rf, ok := ret.Get(0).(func() velero.ObjectStoreCapabilities); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(velero.ObjectStoreCapabilities)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CopyObject provides a mock function with given fields: bucket, srcKey, destKey
+func (_m *ObjectStore) CopyObject(bucket string, srcKey string, destKey string) error {
+	ret := _m.Called(bucket, srcKey, destKey)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(bucket, srcKey, destKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateSignedURL provides a mock function with given fields: bucket, key, ttl
 func (_m *ObjectStore) CreateSignedURL(bucket string, key string, ttl time.Duration) (string, error) {
 	ret := _m.Called(bucket, key, ttl)
@@ -84,6 +120,29 @@ func (_m *ObjectStore) GetObject(bucket string, key string) (io.ReadCloser, erro
 	return r0, r1
 }
 
+// GetObjectVersion provides a mock function with given fields: bucket, key, versionID
+func (_m *ObjectStore) GetObjectVersion(bucket string, key string, versionID string) (io.ReadCloser, error) {
+	ret := _m.Called(bucket, key, versionID)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(string, string, string) io.ReadCloser); ok {
+		r0 = rf(bucket, key, versionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(bucket, key, versionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Init provides a mock function with given fields: config
 func (_m *ObjectStore) Init(config map[string]string) error {
 	ret := _m.Called(config)
@@ -144,6 +203,43 @@ func (_m *ObjectStore) ListObjects(bucket string, prefix string) ([]string, erro
 	return r0, r1
 }
 
+// ListObjectVersions provides a mock function with given fields: bucket, key
+func (_m *ObjectStore) ListObjectVersions(bucket string, key string) ([]velero.ObjectVersion, error) {
+	ret := _m.Called(bucket, key)
+
+	var r0 []velero.ObjectVersion
+	if rf, ok := ret.Get(0).(func(string, string) []velero.ObjectVersion); ok {
+		r0 = rf(bucket, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]velero.ObjectVersion)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(bucket, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockObject provides a mock function with given fields: bucket, key, until
+func (_m *ObjectStore) LockObject(bucket string, key string, until time.Time) error {
+	ret := _m.Called(bucket, key, until)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) error); ok {
+		r0 = rf(bucket, key, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ObjectExists provides a mock function with given fields: bucket, key
 func (_m *ObjectStore) ObjectExists(bucket string, key string) (bool, error) {
 	ret := _m.Called(bucket, key)
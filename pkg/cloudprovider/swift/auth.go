@@ -0,0 +1,217 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package swift
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+)
+
+const (
+	authURLEnvVar           = "OS_AUTH_URL"
+	usernameEnvVar          = "OS_USERNAME"
+	passwordEnvVar          = "OS_PASSWORD"
+	projectNameEnvVar       = "OS_PROJECT_NAME"
+	projectIDEnvVar         = "OS_PROJECT_ID"
+	userDomainNameEnvVar    = "OS_USER_DOMAIN_NAME"
+	projectDomainNameEnvVar = "OS_PROJECT_DOMAIN_NAME"
+	regionNameEnvVar        = "OS_REGION_NAME"
+	tempURLKeyEnvVar        = "OS_SWIFT_TEMP_URL_KEY"
+
+	credentialsFileEnvVar = "SWIFT_CREDENTIALS_FILE"
+
+	objectStoreServiceType = "object-store"
+	publicInterface        = "public"
+)
+
+// credentials holds the Keystone v3 password credentials used to
+// obtain a token for the Swift API.
+type credentials struct {
+	authURL           string
+	username          string
+	password          string
+	projectName       string
+	projectID         string
+	userDomainName    string
+	projectDomainName string
+	region            string
+}
+
+// loadEnv loads environment variables from $SWIFT_CREDENTIALS_FILE,
+// if it exists.
+func loadEnv() error {
+	envFile := os.Getenv(credentialsFileEnvVar)
+	if envFile == "" {
+		return nil
+	}
+
+	if err := godotenv.Overload(envFile); err != nil {
+		return errors.Wrapf(err, "error loading environment from %s (%s)", credentialsFileEnvVar, envFile)
+	}
+
+	return nil
+}
+
+func credentialsFromEnv(getenv func(string) string) (*credentials, error) {
+	creds := &credentials{
+		authURL:           getenv(authURLEnvVar),
+		username:          getenv(usernameEnvVar),
+		password:          getenv(passwordEnvVar),
+		projectName:       getenv(projectNameEnvVar),
+		projectID:         getenv(projectIDEnvVar),
+		userDomainName:    getenv(userDomainNameEnvVar),
+		projectDomainName: getenv(projectDomainNameEnvVar),
+		region:            getenv(regionNameEnvVar),
+	}
+
+	var missing []string
+	if creds.authURL == "" {
+		missing = append(missing, authURLEnvVar)
+	}
+	if creds.username == "" {
+		missing = append(missing, usernameEnvVar)
+	}
+	if creds.password == "" {
+		missing = append(missing, passwordEnvVar)
+	}
+	if creds.projectName == "" && creds.projectID == "" {
+		missing = append(missing, projectNameEnvVar+" or "+projectIDEnvVar)
+	}
+	if len(missing) > 0 {
+		return nil, errors.Errorf("the following environment variables must be defined: %s", strings.Join(missing, ", "))
+	}
+
+	if creds.userDomainName == "" {
+		creds.userDomainName = "Default"
+	}
+	if creds.projectDomainName == "" {
+		creds.projectDomainName = creds.userDomainName
+	}
+
+	return creds, nil
+}
+
+type authDomain struct {
+	Name string `json:"name,omitempty"`
+}
+
+type authProject struct {
+	ID     string      `json:"id,omitempty"`
+	Name   string      `json:"name,omitempty"`
+	Domain *authDomain `json:"domain,omitempty"`
+}
+
+type authRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods  []string `json:"methods"`
+			Password struct {
+				User struct {
+					Name     string     `json:"name"`
+					Password string     `json:"password"`
+					Domain   authDomain `json:"domain"`
+				} `json:"user"`
+			} `json:"password"`
+		} `json:"identity"`
+		Scope struct {
+			Project authProject `json:"project"`
+		} `json:"scope"`
+	} `json:"auth"`
+}
+
+type authResponse struct {
+	Token struct {
+		Catalog []struct {
+			Type      string `json:"type"`
+			Endpoints []struct {
+				Interface string `json:"interface"`
+				Region    string `json:"region"`
+				URL       string `json:"url"`
+			} `json:"endpoints"`
+		} `json:"catalog"`
+	} `json:"token"`
+}
+
+// authenticate obtains a project-scoped Keystone v3 token and returns it
+// along with the public object-store endpoint for the configured region.
+func authenticate(client *http.Client, creds *credentials) (token, storageURL string, err error) {
+	var req authRequest
+	req.Auth.Identity.Methods = []string{"password"}
+	req.Auth.Identity.Password.User.Name = creds.username
+	req.Auth.Identity.Password.User.Password = creds.password
+	req.Auth.Identity.Password.User.Domain.Name = creds.userDomainName
+	if creds.projectID != "" {
+		req.Auth.Scope.Project.ID = creds.projectID
+	} else {
+		req.Auth.Scope.Project.Name = creds.projectName
+		req.Auth.Scope.Project.Domain = &authDomain{Name: creds.projectDomainName}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	tokensURL := strings.TrimSuffix(creds.authURL, "/")
+	if !strings.HasSuffix(tokensURL, "/v3") {
+		tokensURL += "/v3"
+	}
+	tokensURL += "/auth/tokens"
+
+	res, err := client.Post(tokensURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", "", errors.Wrap(err, "error authenticating with keystone")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		return "", "", errors.Errorf("error authenticating with keystone: unexpected status %s", res.Status)
+	}
+
+	token = res.Header.Get("X-Subject-Token")
+	if token == "" {
+		return "", "", errors.New("keystone response did not contain an X-Subject-Token header")
+	}
+
+	var authRes authResponse
+	if err := json.NewDecoder(res.Body).Decode(&authRes); err != nil {
+		return "", "", errors.Wrap(err, "error decoding keystone response")
+	}
+
+	for _, service := range authRes.Token.Catalog {
+		if service.Type != objectStoreServiceType {
+			continue
+		}
+		for _, endpoint := range service.Endpoints {
+			if endpoint.Interface != publicInterface {
+				continue
+			}
+			if creds.region != "" && endpoint.Region != creds.region {
+				continue
+			}
+			return token, strings.TrimSuffix(endpoint.URL, "/"), nil
+		}
+	}
+
+	return "", "", errors.Errorf("no public %s endpoint found in service catalog for region %q", objectStoreServiceType, creds.region)
+}
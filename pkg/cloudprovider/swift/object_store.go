@@ -0,0 +1,447 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package swift
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/heptio/velero/pkg/cloudprovider"
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+const (
+	regionKey = "region"
+
+	// listLimit is the maximum number of entries requested per
+	// container listing call.
+	listLimit = 10000
+)
+
+type ObjectStore struct {
+	log        logrus.FieldLogger
+	client     *http.Client
+	creds      *credentials
+	tempURLKey string
+
+	mu         sync.Mutex
+	token      string
+	storageURL string
+}
+
+func NewObjectStore(logger logrus.FieldLogger) *ObjectStore {
+	return &ObjectStore{log: logger}
+}
+
+func (o *ObjectStore) Init(config map[string]string) error {
+	if err := cloudprovider.ValidateObjectStoreConfigKeys(config, regionKey); err != nil {
+		return err
+	}
+
+	if err := loadEnv(); err != nil {
+		return err
+	}
+
+	creds, err := credentialsFromEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+	if region := config[regionKey]; region != "" {
+		creds.region = region
+	}
+
+	o.creds = creds
+	o.tempURLKey = os.Getenv(tempURLKeyEnvVar)
+	if o.client == nil {
+		o.client = &http.Client{}
+	}
+
+	return o.reauthenticate()
+}
+
+func (o *ObjectStore) reauthenticate() error {
+	token, storageURL, err := authenticate(o.client, o.creds)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.token = token
+	o.storageURL = storageURL
+
+	return nil
+}
+
+func (o *ObjectStore) getStorageURL() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.storageURL
+}
+
+// objectURL returns the URL for the given container and optional object key,
+// escaping each path segment of the key.
+func objectURL(storageURL, container, key string) string {
+	u := storageURL + "/" + url.PathEscape(container)
+	if key == "" {
+		return u
+	}
+
+	segments := strings.Split(key, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	return u + "/" + strings.Join(segments, "/")
+}
+
+// do executes an authenticated request against the Swift API, re-authenticating
+// and retrying once if the token has expired. newBody is called for each attempt
+// and may be nil for requests with no body.
+func (o *ObjectStore) do(method, container, key string, query url.Values, newBody func() (io.Reader, error)) (*http.Response, error) {
+	return o.doWithHeader(method, container, key, nil, query, newBody)
+}
+
+// doWithHeader is like do, but sets the given headers on the request.
+func (o *ObjectStore) doWithHeader(method, container, key string, header http.Header, query url.Values, newBody func() (io.Reader, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if newBody != nil {
+			var err error
+			if body, err = newBody(); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
+		o.mu.Lock()
+		token := o.token
+		reqURL := objectURL(o.storageURL, container, key)
+		o.mu.Unlock()
+
+		if len(query) > 0 {
+			reqURL += "?" + query.Encode()
+		}
+
+		req, err := http.NewRequest(method, reqURL, body)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.Header.Set("X-Auth-Token", token)
+
+		res, err := o.client.Do(req)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if res.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return res, nil
+		}
+		res.Body.Close()
+
+		o.log.Debug("Swift token rejected, re-authenticating")
+		if err := o.reauthenticate(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func statusError(res *http.Response, method, container, key string) error {
+	return errors.Errorf("%s %s/%s: unexpected status %s", method, container, key, res.Status)
+}
+
+func (o *ObjectStore) PutObject(bucket, key string, body io.Reader) error {
+	first := true
+	res, err := o.do(http.MethodPut, bucket, key, nil, func() (io.Reader, error) {
+		if !first {
+			seeker, ok := body.(io.Seeker)
+			if !ok {
+				return nil, errors.New("request body can not be replayed")
+			}
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		first = false
+		return body, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return statusError(res, http.MethodPut, bucket, key)
+	}
+
+	return nil
+}
+
+func (o *ObjectStore) ObjectExists(bucket, key string) (bool, error) {
+	res, err := o.do(http.MethodHead, bucket, key, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, statusError(res, http.MethodHead, bucket, key)
+	}
+}
+
+func (o *ObjectStore) GetObject(bucket, key string) (io.ReadCloser, error) {
+	res, err := o.do(http.MethodGet, bucket, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, statusError(res, http.MethodGet, bucket, key)
+	}
+
+	return res.Body, nil
+}
+
+type listEntry struct {
+	Name   string `json:"name"`
+	Subdir string `json:"subdir"`
+}
+
+// list pages through a container listing, calling fn for each entry.
+func (o *ObjectStore) list(bucket, prefix, delimiter string, fn func(listEntry)) error {
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("format", "json")
+		query.Set("limit", fmt.Sprintf("%d", listLimit))
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		res, err := o.do(http.MethodGet, bucket, "", query, nil)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode == http.StatusNoContent {
+			res.Body.Close()
+			return nil
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return statusError(res, http.MethodGet, bucket, "")
+		}
+
+		var entries []listEntry
+		err = json.NewDecoder(res.Body).Decode(&entries)
+		res.Body.Close()
+		if err != nil {
+			return errors.Wrap(err, "error decoding container listing")
+		}
+
+		for _, entry := range entries {
+			fn(entry)
+		}
+
+		if len(entries) < listLimit {
+			return nil
+		}
+
+		last := entries[len(entries)-1]
+		if last.Subdir != "" {
+			marker = last.Subdir
+		} else {
+			marker = last.Name
+		}
+	}
+}
+
+func (o *ObjectStore) ListCommonPrefixes(bucket, prefix, delimiter string) ([]string, error) {
+	var res []string
+
+	err := o.list(bucket, prefix, delimiter, func(entry listEntry) {
+		if entry.Subdir != "" {
+			res = append(res, entry.Subdir)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (o *ObjectStore) ListObjects(bucket, prefix string) ([]string, error) {
+	var res []string
+
+	err := o.list(bucket, prefix, "", func(entry listEntry) {
+		if entry.Name != "" {
+			res = append(res, entry.Name)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (o *ObjectStore) DeleteObject(bucket, key string) error {
+	res, err := o.do(http.MethodDelete, bucket, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return errors.Wrapf(statusError(res, http.MethodDelete, bucket, key), "error deleting object %s", key)
+	}
+
+	return nil
+}
+
+// CopyObject copies an object within a container on the Swift side, with a
+// PUT request that has an X-Copy-From header.
+func (o *ObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	header := http.Header{}
+	header.Set("X-Copy-From", strings.TrimPrefix(objectURL("", bucket, srcKey), "/"))
+
+	res, err := o.doWithHeader(http.MethodPut, bucket, destKey, header, nil, func() (io.Reader, error) {
+		return strings.NewReader(""), nil
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return errors.Wrapf(statusError(res, http.MethodPut, bucket, destKey), "error copying object %s to %s", srcKey, destKey)
+	}
+
+	return nil
+}
+
+// getTempURLKey returns the temp URL key to sign URLs with. If one was not
+// provided via the environment, the account's Temp-URL-Key metadata is used.
+func (o *ObjectStore) getTempURLKey() (string, error) {
+	if o.tempURLKey != "" {
+		return o.tempURLKey, nil
+	}
+
+	o.mu.Lock()
+	token, storageURL := o.token, o.storageURL
+	o.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodHead, storageURL, nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("X-Auth-Token", token)
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if key := res.Header.Get("X-Account-Meta-Temp-Url-Key"); key != "" {
+		return key, nil
+	}
+
+	return "", errors.Errorf("no temp URL key found: set %s or the account's X-Account-Meta-Temp-URL-Key metadata", tempURLKeyEnvVar)
+}
+
+func (o *ObjectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (string, error) {
+	tempURLKey, err := o.getTempURLKey()
+	if err != nil {
+		return "", err
+	}
+
+	objURL, err := url.Parse(objectURL(o.getStorageURL(), bucket, key))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	expires := time.Now().Add(ttl).Unix()
+
+	mac := hmac.New(sha1.New, []byte(tempURLKey))
+	// swift computes the signature over the unescaped request path
+	fmt.Fprintf(mac, "%s\n%d\n%s", http.MethodGet, expires, objURL.Path)
+
+	query := url.Values{}
+	query.Set("temp_url_sig", hex.EncodeToString(mac.Sum(nil)))
+	query.Set("temp_url_expires", fmt.Sprintf("%d", expires))
+	objURL.RawQuery = query.Encode()
+
+	return objURL.String(), nil
+}
+
+// Capabilities returns the optional features that Swift supports. Signed
+// URLs are temp URLs, which need a temp URL key.
+func (o *ObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	return velero.ObjectStoreCapabilities{
+		SignedURLs:     true,
+		ServerSideCopy: true,
+	}, nil
+}
+
+// ListObjectVersions isn't supported, since Swift keeps an object's previous
+// versions in a separate container rather than listing them by version ID.
+func (o *ObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	return nil, errors.New("object versioning is not supported for Swift")
+}
+
+// GetObjectVersion isn't supported, since Swift keeps an object's previous
+// versions in a separate container rather than listing them by version ID.
+func (o *ObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	return nil, errors.New("object versioning is not supported for Swift")
+}
+
+// LockObject isn't supported, since Swift doesn't have a way to lock objects
+// against deletion.
+func (o *ObjectStore) LockObject(bucket, key string, until time.Time) error {
+	return errors.New("object lock is not supported for Swift")
+}
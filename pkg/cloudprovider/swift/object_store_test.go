@@ -0,0 +1,260 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package swift
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+// fakeSwift is a minimal in-memory implementation of the Keystone v3
+// token API and the Swift object API.
+type fakeSwift struct {
+	server  *httptest.Server
+	objects map[string]string
+	tokens  int
+	expired bool
+}
+
+func newFakeSwift(t *testing.T) *fakeSwift {
+	f := &fakeSwift{objects: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		f.tokens++
+		f.expired = false
+		w.Header().Set("X-Subject-Token", fmt.Sprintf("token-%d", f.tokens))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":{"catalog":[{"type":"object-store","endpoints":[
+			{"interface":"internal","region":"r1","url":"http://internal"},
+			{"interface":"public","region":"r1","url":"%s/v1/AUTH_test"}]}]}}`, f.server.URL)
+	})
+	mux.HandleFunc("/v1/AUTH_test/", func(w http.ResponseWriter, r *http.Request) {
+		if f.expired || r.Header.Get("X-Auth-Token") != fmt.Sprintf("token-%d", f.tokens) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/v1/AUTH_test/"), "/", 2)
+		if len(parts) == 1 {
+			f.list(w, r)
+			return
+		}
+		key := parts[1]
+
+		switch r.Method {
+		case http.MethodPut:
+			data, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			if copyFrom := r.Header.Get("X-Copy-From"); copyFrom != "" {
+				src, ok := f.objects[strings.SplitN(copyFrom, "/", 2)[1]]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				data = []byte(src)
+			}
+			f.objects[key] = string(data)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodHead, http.MethodGet:
+			data, ok := f.objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(data))
+		case http.MethodDelete:
+			delete(f.objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	f.server = httptest.NewServer(mux)
+	return f
+}
+
+func (f *fakeSwift) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	delimiter := r.URL.Query().Get("delimiter")
+
+	var keys []string
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	seen := map[string]bool{}
+	var entries []listEntry
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			rest := strings.TrimPrefix(key, prefix)
+			if i := strings.Index(rest, delimiter); i >= 0 {
+				subdir := prefix + rest[:i+len(delimiter)]
+				if !seen[subdir] {
+					seen[subdir] = true
+					entries = append(entries, listEntry{Subdir: subdir})
+				}
+				continue
+			}
+		}
+		entries = append(entries, listEntry{Name: key})
+	}
+
+	json.NewEncoder(w).Encode(entries)
+}
+
+func newTestObjectStore(t *testing.T, f *fakeSwift) *ObjectStore {
+	o := NewObjectStore(velerotest.NewLogger())
+	o.client = f.server.Client()
+	o.creds = &credentials{
+		authURL:     f.server.URL,
+		username:    "user",
+		password:    "pass",
+		projectName: "project",
+		region:      "r1",
+	}
+	require.NoError(t, o.reauthenticate())
+
+	return o
+}
+
+func TestAuthenticateSelectsPublicEndpoint(t *testing.T) {
+	f := newFakeSwift(t)
+	defer f.server.Close()
+
+	o := newTestObjectStore(t, f)
+
+	assert.Equal(t, f.server.URL+"/v1/AUTH_test", o.storageURL)
+	assert.Equal(t, "token-1", o.token)
+}
+
+func TestAuthenticateNoEndpointForRegion(t *testing.T) {
+	f := newFakeSwift(t)
+	defer f.server.Close()
+
+	_, _, err := authenticate(f.server.Client(), &credentials{authURL: f.server.URL, region: "other"})
+	assert.EqualError(t, err, `no public object-store endpoint found in service catalog for region "other"`)
+}
+
+func TestObjectOperations(t *testing.T) {
+	f := newFakeSwift(t)
+	defer f.server.Close()
+
+	o := newTestObjectStore(t, f)
+
+	require.NoError(t, o.PutObject("bucket", "backups/b1/velero-backup.json", strings.NewReader("b1")))
+	require.NoError(t, o.PutObject("bucket", "backups/b2/velero-backup.json", strings.NewReader("b2")))
+	require.NoError(t, o.PutObject("bucket", "metadata/revision", strings.NewReader("rev")))
+
+	exists, err := o.ObjectExists("bucket", "backups/b1/velero-backup.json")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = o.ObjectExists("bucket", "backups/b3/velero-backup.json")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	rdr, err := o.GetObject("bucket", "backups/b2/velero-backup.json")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(rdr)
+	require.NoError(t, err)
+	assert.Equal(t, "b2", string(data))
+
+	prefixes, err := o.ListCommonPrefixes("bucket", "backups/", "/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backups/b1/", "backups/b2/"}, prefixes)
+
+	objects, err := o.ListObjects("bucket", "backups/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backups/b1/velero-backup.json", "backups/b2/velero-backup.json"}, objects)
+
+	require.NoError(t, o.CopyObject("bucket", "backups/b2/velero-backup.json", "backups/b3/velero-backup.json"))
+	assert.Equal(t, "b2", f.objects["backups/b3/velero-backup.json"])
+	assert.Error(t, o.CopyObject("bucket", "backups/b4/velero-backup.json", "backups/b5/velero-backup.json"))
+
+	require.NoError(t, o.DeleteObject("bucket", "backups/b1/velero-backup.json"))
+	_, err = o.GetObject("bucket", "backups/b1/velero-backup.json")
+	assert.Error(t, err)
+}
+
+func TestExpiredTokenIsRefreshed(t *testing.T) {
+	f := newFakeSwift(t)
+	defer f.server.Close()
+
+	o := newTestObjectStore(t, f)
+	f.expired = true
+
+	require.NoError(t, o.PutObject("bucket", "key", strings.NewReader("data")))
+	assert.Equal(t, 2, f.tokens)
+	assert.Equal(t, "data", f.objects["key"])
+}
+
+func TestCreateSignedURL(t *testing.T) {
+	f := newFakeSwift(t)
+	defer f.server.Close()
+
+	o := newTestObjectStore(t, f)
+	o.tempURLKey = "secret"
+
+	signed, err := o.CreateSignedURL("bucket", "backups/b1/b1.tar.gz", 10*time.Minute)
+	require.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/AUTH_test/bucket/backups/b1/b1.tar.gz", u.Path)
+
+	expires := u.Query().Get("temp_url_expires")
+	mac := hmac.New(sha1.New, []byte("secret"))
+	fmt.Fprintf(mac, "GET\n%s\n%s", expires, u.Path)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), u.Query().Get("temp_url_sig"))
+}
+
+func TestCredentialsFromEnv(t *testing.T) {
+	env := map[string]string{
+		authURLEnvVar:     "http://keystone",
+		usernameEnvVar:    "user",
+		passwordEnvVar:    "pass",
+		projectNameEnvVar: "project",
+	}
+
+	creds, err := credentialsFromEnv(func(key string) string { return env[key] })
+	require.NoError(t, err)
+	assert.Equal(t, "Default", creds.userDomainName)
+	assert.Equal(t, "Default", creds.projectDomainName)
+
+	_, err = credentialsFromEnv(func(string) string { return "" })
+	assert.EqualError(t, err, "the following environment variables must be defined: OS_AUTH_URL, OS_USERNAME, OS_PASSWORD, OS_PROJECT_NAME or OS_PROJECT_ID")
+}
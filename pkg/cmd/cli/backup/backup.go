@@ -34,7 +34,10 @@ func NewCommand(f client.Factory) *cobra.Command {
 		NewGetCommand(f, "get"),
 		NewLogsCommand(f),
 		NewDescribeCommand(f, "describe"),
+		NewDiffCommand(f),
 		NewDownloadCommand(f),
+		NewImportCommand(f),
+		NewSetTTLCommand(f),
 		NewDeleteCommand(f, "delete"),
 	)
 
@@ -18,8 +18,10 @@ package backup
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,6 +34,7 @@ import (
 	"github.com/heptio/velero/pkg/cmd/util/output"
 	veleroclient "github.com/heptio/velero/pkg/generated/clientset/versioned"
 	v1 "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	"github.com/heptio/velero/pkg/util/logging"
 )
 
 func NewCreateCommand(f client.Factory, use string) *cobra.Command {
@@ -71,19 +74,36 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 }
 
 type CreateOptions struct {
-	Name                    string
-	TTL                     time.Duration
-	SnapshotVolumes         flag.OptionalBool
-	IncludeNamespaces       flag.StringArray
-	ExcludeNamespaces       flag.StringArray
-	IncludeResources        flag.StringArray
-	ExcludeResources        flag.StringArray
-	Labels                  flag.Map
-	Selector                flag.LabelSelector
-	IncludeClusterResources flag.OptionalBool
-	Wait                    bool
-	StorageLocation         string
-	SnapshotLocations       []string
+	Name                        string
+	TTL                         time.Duration
+	SnapshotVolumes             flag.OptionalBool
+	SnapshotMoveData            bool
+	DefaultVolumesToRestic      flag.OptionalBool
+	IncludeNamespaces           flag.StringArray
+	ExcludeNamespaces           flag.StringArray
+	IncludeResources            flag.StringArray
+	ExcludeResources            flag.StringArray
+	IncludeClusterResourceNames flag.StringArray
+	ExcludeClusterResourceNames flag.StringArray
+	ResourcePolicy              string
+	Labels                      flag.Map
+	Selector                    flag.LabelSelector
+	OrSelector                  flag.OrLabelSelector
+	IncludeClusterResources     flag.OptionalBool
+	ObjectsOnly                 bool
+	VolumeSnapshotsOnly         bool
+	SkipUnchangedItems          bool
+	PodVolumeFailurePolicy      *flag.Enum
+	DeleteProtection            bool
+	LogLevel                    *flag.Enum
+	MaxAttempts                 int
+	RetryBackoff                time.Duration
+	Wait                        bool
+	StorageLocation             string
+	StoragePrefix               string
+	ReplicaLocations            []string
+	FailoverLocations           []string
+	SnapshotLocations           []string
 
 	client veleroclient.Interface
 }
@@ -94,7 +114,14 @@ func NewCreateOptions() *CreateOptions {
 		IncludeNamespaces:       flag.NewStringArray("*"),
 		Labels:                  flag.NewMap(),
 		SnapshotVolumes:         flag.NewOptionalBool(nil),
+		DefaultVolumesToRestic:  flag.NewOptionalBool(nil),
 		IncludeClusterResources: flag.NewOptionalBool(nil),
+		PodVolumeFailurePolicy: flag.NewEnum(
+			"",
+			string(api.PodVolumeFailurePolicyPartiallyFail),
+			string(api.PodVolumeFailurePolicyFail),
+		),
+		LogLevel: flag.NewEnum("", logging.LogLevels()...),
 	}
 }
 
@@ -104,17 +131,54 @@ func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.Var(&o.ExcludeNamespaces, "exclude-namespaces", "namespaces to exclude from the backup")
 	flags.Var(&o.IncludeResources, "include-resources", "resources to include in the backup, formatted as resource.group, such as storageclasses.storage.k8s.io (use '*' for all resources)")
 	flags.Var(&o.ExcludeResources, "exclude-resources", "resources to exclude from the backup, formatted as resource.group, such as storageclasses.storage.k8s.io")
+	flags.Var(&o.IncludeClusterResourceNames, "include-cluster-resource-names", "cluster-scoped items to include in the backup, formatted as resource.group/name, such as clusterroles.rbac.authorization.k8s.io/team-a-*; other items of their resources are excluded")
+	flags.Var(&o.ExcludeClusterResourceNames, "exclude-cluster-resource-names", "cluster-scoped items to exclude from the backup, formatted as resource.group/name, such as clusterroles.rbac.authorization.k8s.io/system:*")
+	flags.StringVar(&o.ResourcePolicy, "resource-policies-configmap", "", "name of a ConfigMap, in the Velero namespace, of resource policies; items matching a skip policy aren't backed up")
 	flags.Var(&o.Labels, "labels", "labels to apply to the backup")
 	flags.StringVar(&o.StorageLocation, "storage-location", "", "location in which to store the backup")
+	flags.StringVar(&o.StoragePrefix, "storage-prefix", "", "sub-prefix, under the storage location's prefix, to store the backup under, such as team-a/prod")
+	flags.StringSliceVar(&o.ReplicaLocations, "replica-locations", o.ReplicaLocations, "list of additional backup storage locations to copy the backup to once it has completed")
+	flags.StringSliceVar(&o.FailoverLocations, "failover-locations", o.FailoverLocations, "prioritized list of backup storage locations to store the backup in if it can't be stored in its storage location")
 	flags.StringSliceVar(&o.SnapshotLocations, "volume-snapshot-locations", o.SnapshotLocations, "list of locations (at most one per provider) where volume snapshots should be stored")
 	flags.VarP(&o.Selector, "selector", "l", "only back up resources matching this label selector")
+	flags.Var(&o.OrSelector, "or-selector", "only back up resources matching any of these label selectors, separated by ' or ', such as 'app=foo or app=bar'. Can't be used with --selector")
 	f := flags.VarPF(&o.SnapshotVolumes, "snapshot-volumes", "", "take snapshots of PersistentVolumes as part of the backup")
 	// this allows the user to just specify "--snapshot-volumes" as shorthand for "--snapshot-volumes=true"
 	// like a normal bool flag
 	f.NoOptDefVal = "true"
 
+	flags.BoolVar(&o.SnapshotMoveData, "snapshot-move-data", o.SnapshotMoveData, "move the data of the backup's volume snapshots into the backup storage location with restic, and delete the snapshots")
+
+	f = flags.VarPF(&o.DefaultVolumesToRestic, "default-volumes-to-restic", "", "back up all pod volumes with restic, except those listed in a pod's backup.velero.io/backup-volumes-excludes annotation. If not set, the server's default is used")
+	f.NoOptDefVal = "true"
+
 	f = flags.VarPF(&o.IncludeClusterResources, "include-cluster-resources", "", "include cluster-scoped resources in the backup")
 	f.NoOptDefVal = "true"
+
+	flags.BoolVar(&o.ObjectsOnly, "objects-only", o.ObjectsOnly, "only back up Kubernetes resources, intentionally skipping all volume data (no snapshots or restic backups)")
+	flags.BoolVar(&o.VolumeSnapshotsOnly, "volume-snapshots-only", o.VolumeSnapshotsOnly, "only take snapshots of the persistent volumes bound to the selected persistent volume claims, without backing up any Kubernetes resources. These backups can't be restored with Velero")
+	flags.BoolVar(&o.SkipUnchangedItems, "skip-unchanged-items", o.SkipUnchangedItems, "store items that haven't changed since the previous backup of the schedule as references to it instead of writing them to the backup. Only applies to backups created by a schedule")
+	flags.Var(
+		o.PodVolumeFailurePolicy,
+		"pod-volume-failure-policy",
+		fmt.Sprintf("what happens to the backup when a pod volume can't be backed up with restic. Valid values are %s (default %s)", strings.Join(o.PodVolumeFailurePolicy.AllowedValues(), ","), api.PodVolumeFailurePolicyPartiallyFail),
+	)
+	flags.BoolVar(&o.DeleteProtection, "delete-protection", o.DeleteProtection, "protect the backup from deletion until its spec.deleteProtection is set to false. Its files are also locked in object storage until it expires, if the object store supports object lock")
+	flags.Var(o.LogLevel, "log-level", fmt.Sprintf("the level at which to log the backup, overriding the server's log level. Valid values are %s", strings.Join(o.LogLevel.AllowedValues(), ", ")))
+	flags.IntVar(&o.MaxAttempts, "max-attempts", o.MaxAttempts, "how many times to attempt the backup if it fails because the backup store or the Kubernetes API server is temporarily unavailable. If not set, the backup isn't retried")
+	flags.DurationVar(&o.RetryBackoff, "retry-backoff", o.RetryBackoff, "how long to wait before retrying a failed backup, doubled for each retry after the first. Only applies with --max-attempts (default 1m)")
+}
+
+// RetryPolicy returns the retry policy set by the --max-attempts and
+// --retry-backoff flags, or nil if the backup isn't retried.
+func (o *CreateOptions) RetryPolicy() *api.BackupRetryPolicy {
+	if o.MaxAttempts == 0 {
+		return nil
+	}
+	return &api.BackupRetryPolicy{
+		MaxAttempts: o.MaxAttempts,
+		Backoff:     metav1.Duration{Duration: o.RetryBackoff},
+	}
 }
 
 // BindWait binds the wait flag separately so it is not called by other create
@@ -128,12 +192,61 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Facto
 		return err
 	}
 
+	if o.Selector.LabelSelector != nil && len(o.OrSelector.OrLabelSelectors) > 0 {
+		return errors.New("--selector and --or-selector can't be used together")
+	}
+
+	if o.ObjectsOnly && o.SnapshotVolumes.Value != nil && *o.SnapshotVolumes.Value {
+		return errors.New("--snapshot-volumes can't be used with --objects-only")
+	}
+
+	if o.ObjectsOnly && o.DefaultVolumesToRestic.Value != nil && *o.DefaultVolumesToRestic.Value {
+		return errors.New("--default-volumes-to-restic can't be used with --objects-only")
+	}
+
+	if o.SnapshotMoveData {
+		switch {
+		case o.ObjectsOnly:
+			return errors.New("--snapshot-move-data can't be used with --objects-only")
+		case o.VolumeSnapshotsOnly:
+			return errors.New("--snapshot-move-data can't be used with --volume-snapshots-only")
+		case o.SnapshotVolumes.Value != nil && !*o.SnapshotVolumes.Value:
+			return errors.New("--snapshot-move-data can't be used with --snapshot-volumes=false")
+		}
+	}
+
+	if o.VolumeSnapshotsOnly {
+		switch {
+		case o.ObjectsOnly:
+			return errors.New("--volume-snapshots-only can't be used with --objects-only")
+		case o.SnapshotVolumes.Value != nil && !*o.SnapshotVolumes.Value:
+			return errors.New("--snapshot-volumes=false can't be used with --volume-snapshots-only")
+		case len(o.IncludeResources) > 0 || len(o.ExcludeResources) > 0:
+			return errors.New("--include-resources and --exclude-resources can't be used with --volume-snapshots-only")
+		case o.SkipUnchangedItems:
+			return errors.New("--skip-unchanged-items can't be used with --volume-snapshots-only")
+		}
+	}
+
+	if o.MaxAttempts < 0 {
+		return errors.New("--max-attempts must not be negative")
+	}
+	if o.RetryBackoff != 0 && o.MaxAttempts == 0 {
+		return errors.New("--retry-backoff can only be used with --max-attempts")
+	}
+
 	if o.StorageLocation != "" {
 		if _, err := o.client.VeleroV1().BackupStorageLocations(f.Namespace()).Get(o.StorageLocation, metav1.GetOptions{}); err != nil {
 			return err
 		}
 	}
 
+	for _, loc := range append(o.ReplicaLocations, o.FailoverLocations...) {
+		if _, err := o.client.VeleroV1().BackupStorageLocations(f.Namespace()).Get(loc, metav1.GetOptions{}); err != nil {
+			return err
+		}
+	}
+
 	for _, loc := range o.SnapshotLocations {
 		if _, err := o.client.VeleroV1().VolumeSnapshotLocations(f.Namespace()).Get(loc, metav1.GetOptions{}); err != nil {
 			return err
@@ -143,6 +256,17 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Facto
 	return nil
 }
 
+// BackupMode returns the mode of the backup to create.
+func (o *CreateOptions) BackupMode() api.BackupMode {
+	if o.ObjectsOnly {
+		return api.BackupModeObjectsOnly
+	}
+	if o.VolumeSnapshotsOnly {
+		return api.BackupModeVolumeSnapshotOnly
+	}
+	return ""
+}
+
 func (o *CreateOptions) Complete(args []string, f client.Factory) error {
 	o.Name = args[0]
 	client, err := f.Client()
@@ -161,16 +285,31 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 			Labels:    o.Labels.Data(),
 		},
 		Spec: api.BackupSpec{
-			IncludedNamespaces:      o.IncludeNamespaces,
-			ExcludedNamespaces:      o.ExcludeNamespaces,
-			IncludedResources:       o.IncludeResources,
-			ExcludedResources:       o.ExcludeResources,
-			LabelSelector:           o.Selector.LabelSelector,
-			SnapshotVolumes:         o.SnapshotVolumes.Value,
-			TTL:                     metav1.Duration{Duration: o.TTL},
-			IncludeClusterResources: o.IncludeClusterResources.Value,
-			StorageLocation:         o.StorageLocation,
-			VolumeSnapshotLocations: o.SnapshotLocations,
+			IncludedNamespaces:           o.IncludeNamespaces,
+			ExcludedNamespaces:           o.ExcludeNamespaces,
+			IncludedResources:            o.IncludeResources,
+			ExcludedResources:            o.ExcludeResources,
+			LabelSelector:                o.Selector.LabelSelector,
+			OrLabelSelectors:             o.OrSelector.OrLabelSelectors,
+			SnapshotVolumes:              o.SnapshotVolumes.Value,
+			SnapshotMoveData:             o.SnapshotMoveData,
+			DefaultVolumesToRestic:       o.DefaultVolumesToRestic.Value,
+			TTL:                          metav1.Duration{Duration: o.TTL},
+			IncludeClusterResources:      o.IncludeClusterResources.Value,
+			IncludedClusterResourceNames: o.IncludeClusterResourceNames,
+			ExcludedClusterResourceNames: o.ExcludeClusterResourceNames,
+			ResourcePolicy:               o.ResourcePolicy,
+			StorageLocation:              o.StorageLocation,
+			StoragePrefix:                o.StoragePrefix,
+			ReplicaStorageLocations:      o.ReplicaLocations,
+			FailoverStorageLocations:     o.FailoverLocations,
+			VolumeSnapshotLocations:      o.SnapshotLocations,
+			Mode:                         o.BackupMode(),
+			SkipUnchangedItems:           o.SkipUnchangedItems,
+			PodVolumeFailurePolicy:       api.PodVolumeFailurePolicy(o.PodVolumeFailurePolicy.String()),
+			DeleteProtection:             o.DeleteProtection,
+			LogLevel:                     o.LogLevel.String(),
+			RetryPolicy:                  o.RetryPolicy(),
 		},
 	}
 
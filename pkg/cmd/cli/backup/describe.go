@@ -28,7 +28,7 @@ import (
 	"github.com/heptio/velero/pkg/client"
 	"github.com/heptio/velero/pkg/cmd"
 	"github.com/heptio/velero/pkg/cmd/util/output"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 )
 
 func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
@@ -41,6 +41,9 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 		Use:   use + " [NAME1] [NAME2] [NAME...]",
 		Short: "Describe backups",
 		Run: func(c *cobra.Command, args []string) {
+			err := output.ValidateDescribeFlags(c)
+			cmd.CheckError(err)
+
 			veleroClient, err := f.Client()
 			cmd.CheckError(err)
 
@@ -57,6 +60,9 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 				cmd.CheckError(err)
 			}
 
+			format := output.GetOutputFlagValue(c)
+			var descriptions []interface{}
+
 			first := true
 			for _, backup := range backups.Items {
 				deleteRequestListOptions := pkgbackup.NewDeleteBackupRequestListOptions(backup.Name, string(backup.UID))
@@ -65,12 +71,17 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 					fmt.Fprintf(os.Stderr, "error getting DeleteBackupRequests for backup %s: %v\n", backup.Name, err)
 				}
 
-				opts := restic.NewPodVolumeBackupListOptions(backup.Name)
+				opts := podvolume.NewPodVolumeBackupListOptions(backup.Name)
 				podVolumeBackupList, err := veleroClient.VeleroV1().PodVolumeBackups(f.Namespace()).List(opts)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "error getting PodVolumeBackups for backup %s: %v\n", backup.Name, err)
 				}
 
+				if format != "" {
+					descriptions = append(descriptions, output.NewBackupDescription(backup.DeepCopy(), deleteRequestList.Items, podVolumeBackupList.Items, details, veleroClient))
+					continue
+				}
+
 				s := output.DescribeBackup(&backup, deleteRequestList.Items, podVolumeBackupList.Items, details, veleroClient)
 				if first {
 					first = false
@@ -80,11 +91,16 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 				}
 			}
 			cmd.CheckError(err)
+
+			if format != "" {
+				cmd.CheckError(output.PrintDescriptions(os.Stdout, format, descriptions))
+			}
 		},
 	}
 
 	c.Flags().StringVarP(&listOptions.LabelSelector, "selector", "l", listOptions.LabelSelector, "only show items matching this label selector")
 	c.Flags().BoolVar(&details, "details", details, "display additional detail in the command output")
+	output.BindDescribeFlags(c.Flags())
 
 	return c
 }
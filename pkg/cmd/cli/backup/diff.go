@@ -0,0 +1,352 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/util/downloadrequest"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+)
+
+func NewDiffCommand(f client.Factory) *cobra.Command {
+	o := NewDiffOptions()
+	c := &cobra.Command{
+		Use:   "diff BACKUP_A BACKUP_B",
+		Short: "Show the differences between two backups",
+		Long: `Show the resources that were added to or removed from backup B compared to backup A.
+
+With --contents, both backups' tarballs are also downloaded and the items that are in both
+are compared, ignoring their status and the metadata that the API server sets, so that
+changes in the configuration of the backed up resources are listed too.`,
+		Example: `	velero backup diff nightly-20190601000000 nightly-20190602000000 --contents`,
+		Args:    cobra.ExactArgs(2),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(args))
+			cmd.CheckError(o.Validate(f))
+			cmd.CheckError(o.Run(f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+type DiffOptions struct {
+	BackupA  string
+	BackupB  string
+	Contents bool
+	Timeout  time.Duration
+}
+
+func NewDiffOptions() *DiffOptions {
+	return &DiffOptions{
+		Timeout: time.Minute,
+	}
+}
+
+func (o *DiffOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.Contents, "contents", o.Contents, "also download both backups' tarballs and list the items whose contents changed")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "maximum time to wait to process each download request")
+}
+
+func (o *DiffOptions) Complete(args []string) error {
+	o.BackupA = args[0]
+	o.BackupB = args[1]
+	return nil
+}
+
+func (o *DiffOptions) Validate(f client.Factory) error {
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{o.BackupA, o.BackupB} {
+		if _, err := veleroClient.VeleroV1().Backups(f.Namespace()).Get(name, metav1.GetOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *DiffOptions) Run(f client.Factory) error {
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+	client := veleroClient.VeleroV1()
+
+	resourcesA, err := o.downloadResourceList(client, f.Namespace(), o.BackupA)
+	if err != nil {
+		return err
+	}
+	resourcesB, err := o.downloadResourceList(client, f.Namespace(), o.BackupB)
+	if err != nil {
+		return err
+	}
+
+	diffs := diffResourceLists(resourcesA, resourcesB)
+
+	if o.Contents {
+		itemsA, err := o.downloadItems(client, f.Namespace(), o.BackupA)
+		if err != nil {
+			return err
+		}
+		itemsB, err := o.downloadItems(client, f.Namespace(), o.BackupB)
+		if err != nil {
+			return err
+		}
+
+		diffs = append(diffs, diffItems(itemsA, itemsB)...)
+		sortItemDiffs(diffs)
+	}
+
+	return printItemDiffs(os.Stdout, o.BackupA, o.BackupB, diffs)
+}
+
+func (o *DiffOptions) downloadResourceList(client velerov1client.DownloadRequestsGetter, namespace, backup string) (map[string][]string, error) {
+	buf := new(bytes.Buffer)
+	if err := downloadrequest.Stream(client, namespace, backup, velerov1api.DownloadTargetKindBackupResourceList, buf, o.Timeout); err != nil {
+		if err == downloadrequest.ErrNotFound {
+			return nil, errors.Errorf("backup %s has no resource list, this could be because it was taken prior to Velero 1.1.0", backup)
+		}
+		return nil, errors.Wrapf(err, "error getting resource list of backup %s", backup)
+	}
+
+	var resourceList map[string][]string
+	if err := json.NewDecoder(buf).Decode(&resourceList); err != nil {
+		return nil, errors.Wrapf(err, "error reading resource list of backup %s", backup)
+	}
+
+	return resourceList, nil
+}
+
+// downloadItems streams the backup's tarball through readBackupItems, rather
+// than storing it, since only the items' normalized contents are kept.
+func (o *DiffOptions) downloadItems(client velerov1client.DownloadRequestsGetter, namespace, backup string) (map[backupItemKey][]byte, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(downloadrequest.Stream(client, namespace, backup, velerov1api.DownloadTargetKindBackupContents, pw, o.Timeout))
+	}()
+
+	items, err := readBackupItems(pr)
+	// closing the reader stops the download if reading stopped early.
+	pr.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading contents of backup %s", backup)
+	}
+
+	return items, nil
+}
+
+// backupItemKey identifies an item in a backup the same way as its
+// resource list does: by group-resource and "namespace/name", or "name" for
+// cluster-scoped items.
+type backupItemKey struct {
+	resource string
+	item     string
+}
+
+const (
+	itemAdded   = "+"
+	itemRemoved = "-"
+	itemChanged = "~"
+)
+
+// itemDiff is an item that was added, removed or changed between two backups.
+type itemDiff struct {
+	backupItemKey
+	change string
+}
+
+// diffResourceLists returns the items that are only in one of two backups'
+// resource lists, sorted by resource and item.
+func diffResourceLists(a, b map[string][]string) []itemDiff {
+	inA := map[backupItemKey]bool{}
+	for resource, items := range a {
+		for _, item := range items {
+			inA[backupItemKey{resource: resource, item: item}] = true
+		}
+	}
+
+	var diffs []itemDiff
+	for resource, items := range b {
+		for _, item := range items {
+			key := backupItemKey{resource: resource, item: item}
+			if inA[key] {
+				delete(inA, key)
+				continue
+			}
+			diffs = append(diffs, itemDiff{backupItemKey: key, change: itemAdded})
+		}
+	}
+	for key := range inA {
+		diffs = append(diffs, itemDiff{backupItemKey: key, change: itemRemoved})
+	}
+
+	sortItemDiffs(diffs)
+	return diffs
+}
+
+// diffItems returns the items that are in both backups with different
+// contents.
+func diffItems(a, b map[backupItemKey][]byte) []itemDiff {
+	var diffs []itemDiff
+	for key, contentsA := range a {
+		if contentsB, ok := b[key]; ok && !bytes.Equal(contentsA, contentsB) {
+			diffs = append(diffs, itemDiff{backupItemKey: key, change: itemChanged})
+		}
+	}
+	return diffs
+}
+
+func sortItemDiffs(diffs []itemDiff) {
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].resource != diffs[j].resource {
+			return diffs[i].resource < diffs[j].resource
+		}
+		return diffs[i].item < diffs[j].item
+	})
+}
+
+// readBackupItems reads the items in a backup tarball, keyed by their
+// resource and name, and returns their contents normalized by
+// normalizeItem.
+func readBackupItems(r io.Reader) (map[backupItemKey][]byte, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer gzr.Close()
+
+	items := map[backupItemKey][]byte{}
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		key, ok := backupItemKeyForPath(header.Name)
+		if !ok {
+			continue
+		}
+
+		contents, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if items[key], err = normalizeItem(contents); err != nil {
+			return nil, errors.Wrapf(err, "error reading %s", header.Name)
+		}
+	}
+}
+
+// backupItemKeyForPath returns the key of the item stored at the given path
+// in a backup tarball, which is either
+// resources/<resource>/namespaces/<namespace>/<name>.json or
+// resources/<resource>/cluster/<name>.json.
+func backupItemKeyForPath(filePath string) (backupItemKey, bool) {
+	parts := strings.Split(strings.TrimPrefix(filePath, "./"), "/")
+	if len(parts) < 4 || parts[0] != velerov1api.ResourcesDir || path.Ext(filePath) != ".json" {
+		return backupItemKey{}, false
+	}
+
+	name := strings.TrimSuffix(parts[len(parts)-1], ".json")
+	switch {
+	case len(parts) == 4 && parts[2] == velerov1api.ClusterScopedDir:
+		return backupItemKey{resource: parts[1], item: name}, true
+	case len(parts) == 5 && parts[2] == velerov1api.NamespaceScopedDir:
+		return backupItemKey{resource: parts[1], item: parts[3] + "/" + name}, true
+	default:
+		return backupItemKey{}, false
+	}
+}
+
+// normalizeItem removes an item's status and the metadata that's set by the
+// API server, which differ between backups even when the item's
+// configuration hasn't changed, and re-encodes it with sorted keys.
+func normalizeItem(contents []byte) ([]byte, error) {
+	var item map[string]interface{}
+	if err := json.Unmarshal(contents, &item); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	delete(item, "status")
+	if metadata, ok := item["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"creationTimestamp", "generation", "managedFields", "resourceVersion", "selfLink", "uid"} {
+			delete(metadata, field)
+		}
+	}
+
+	normalized, err := json.Marshal(item)
+	return normalized, errors.WithStack(err)
+}
+
+func printItemDiffs(w io.Writer, backupA, backupB string, diffs []itemDiff) error {
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintf(w, "Backups %s and %s contain the same resources.\n", backupA, backupB)
+		return errors.WithStack(err)
+	}
+
+	counts := map[string]int{}
+	resource := ""
+	for _, diff := range diffs {
+		if diff.resource != resource {
+			resource = diff.resource
+			if _, err := fmt.Fprintf(w, "%s:\n", resource); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if _, err := fmt.Fprintf(w, "  %s %s\n", diff.change, diff.item); err != nil {
+			return errors.WithStack(err)
+		}
+		counts[diff.change]++
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n", counts[itemAdded], counts[itemRemoved], counts[itemChanged])
+	return errors.WithStack(err)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffResourceLists(t *testing.T) {
+	a := map[string][]string{
+		"deployments.apps": {"ns-1/deploy-1", "ns-1/deploy-2"},
+		"namespaces":       {"ns-1"},
+		"secrets":          {"ns-1/secret-1"},
+	}
+	b := map[string][]string{
+		"deployments.apps": {"ns-1/deploy-1", "ns-1/deploy-3"},
+		"namespaces":       {"ns-1", "ns-2"},
+	}
+
+	expected := []itemDiff{
+		{backupItemKey: backupItemKey{resource: "deployments.apps", item: "ns-1/deploy-2"}, change: itemRemoved},
+		{backupItemKey: backupItemKey{resource: "deployments.apps", item: "ns-1/deploy-3"}, change: itemAdded},
+		{backupItemKey: backupItemKey{resource: "namespaces", item: "ns-2"}, change: itemAdded},
+		{backupItemKey: backupItemKey{resource: "secrets", item: "ns-1/secret-1"}, change: itemRemoved},
+	}
+
+	assert.Equal(t, expected, diffResourceLists(a, b))
+	assert.Empty(t, diffResourceLists(a, a))
+}
+
+func TestReadBackupItems(t *testing.T) {
+	tarball := func(files map[string]string) *bytes.Buffer {
+		buf := new(bytes.Buffer)
+		gzw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gzw)
+		for name, contents := range files {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(contents))}))
+			_, err := tw.Write([]byte(contents))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gzw.Close())
+		return buf
+	}
+
+	itemsA, err := readBackupItems(tarball(map[string]string{
+		"metadata/version": "1",
+		"resources/configmaps/namespaces/ns-1/cm-1.json":    `{"metadata":{"name":"cm-1","resourceVersion":"1","uid":"a"},"data":{"k":"v"}}`,
+		"resources/configmaps/namespaces/ns-1/cm-2.json":    `{"metadata":{"name":"cm-2"},"data":{"k":"v"}}`,
+		"resources/persistentvolumes/cluster/pv-1.json":     `{"metadata":{"name":"pv-1"},"status":{"phase":"Bound"}}`,
+		"resources/persistentvolumes/cluster/pv-1.json.bak": `not an item`,
+	}))
+	require.NoError(t, err)
+	assert.Len(t, itemsA, 3)
+	assert.Equal(t, `{"data":{"k":"v"},"metadata":{"name":"cm-1"}}`, string(itemsA[backupItemKey{resource: "configmaps", item: "ns-1/cm-1"}]))
+
+	itemsB, err := readBackupItems(tarball(map[string]string{
+		"resources/configmaps/namespaces/ns-1/cm-1.json": `{"metadata":{"name":"cm-1","resourceVersion":"2","uid":"b"},"data":{"k":"v"}}`,
+		"resources/configmaps/namespaces/ns-1/cm-2.json": `{"metadata":{"name":"cm-2"},"data":{"k":"changed"}}`,
+		"resources/persistentvolumes/cluster/pv-1.json":  `{"metadata":{"name":"pv-1"},"status":{"phase":"Released"}}`,
+	}))
+	require.NoError(t, err)
+
+	expected := []itemDiff{
+		{backupItemKey: backupItemKey{resource: "configmaps", item: "ns-1/cm-2"}, change: itemChanged},
+	}
+	assert.Equal(t, expected, diffItems(itemsA, itemsB))
+
+	_, err = readBackupItems(bytes.NewBufferString("not a tarball"))
+	assert.Error(t, err)
+}
+
+func TestPrintItemDiffs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, printItemDiffs(buf, "backup-a", "backup-b", nil))
+	assert.Equal(t, "Backups backup-a and backup-b contain the same resources.\n", buf.String())
+
+	buf.Reset()
+	diffs := []itemDiff{
+		{backupItemKey: backupItemKey{resource: "configmaps", item: "ns-1/cm-1"}, change: itemChanged},
+		{backupItemKey: backupItemKey{resource: "configmaps", item: "ns-1/cm-2"}, change: itemAdded},
+		{backupItemKey: backupItemKey{resource: "namespaces", item: "ns-2"}, change: itemRemoved},
+	}
+	require.NoError(t, printItemDiffs(buf, "backup-a", "backup-b", diffs))
+	assert.Equal(t, "configmaps:\n  ~ ns-1/cm-1\n  + ns-1/cm-2\nnamespaces:\n  - ns-2\n\n1 added, 1 removed, 1 changed\n", buf.String())
+}
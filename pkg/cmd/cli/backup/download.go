@@ -56,6 +56,8 @@ type DownloadOptions struct {
 	Output       string
 	Force        bool
 	Timeout      time.Duration
+	AllArtifacts bool
+	Archive      bool
 	writeOptions int
 }
 
@@ -66,12 +68,18 @@ func NewDownloadOptions() *DownloadOptions {
 }
 
 func (o *DownloadOptions) BindFlags(flags *pflag.FlagSet) {
-	flags.StringVarP(&o.Output, "output", "o", o.Output, "path to output file. Defaults to <NAME>-data.tar.gz in the current directory")
+	flags.StringVarP(&o.Output, "output", "o", o.Output, "path to output file. Defaults to <NAME>-data.tar.gz in the current directory, or with --all-artifacts, to the <NAME> directory or <NAME>-artifacts.tar archive")
 	flags.BoolVar(&o.Force, "force", o.Force, "forces the download and will overwrite file if it exists already")
 	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "maximum time to wait to process download request")
+	flags.BoolVar(&o.AllArtifacts, "all-artifacts", o.AllArtifacts, "download all of the backup's files from object storage (metadata, contents, logs and snapshot, restic and resource lists) into a directory")
+	flags.BoolVar(&o.Archive, "archive", o.Archive, "with --all-artifacts, write the backup's files into a single tar archive instead of a directory")
 }
 
 func (o *DownloadOptions) Validate(c *cobra.Command, args []string, f client.Factory) error {
+	if o.Archive && !o.AllArtifacts {
+		return errors.New("--archive can only be used with --all-artifacts")
+	}
+
 	veleroClient, err := f.Client()
 	cmd.CheckError(err)
 
@@ -95,7 +103,14 @@ func (o *DownloadOptions) Complete(args []string) error {
 		if err != nil {
 			return errors.Wrapf(err, "error getting current directory")
 		}
-		o.Output = filepath.Join(path, fmt.Sprintf("%s-data.tar.gz", o.Name))
+		switch {
+		case o.Archive:
+			o.Output = filepath.Join(path, fmt.Sprintf("%s-artifacts.tar", o.Name))
+		case o.AllArtifacts:
+			o.Output = filepath.Join(path, o.Name)
+		default:
+			o.Output = filepath.Join(path, fmt.Sprintf("%s-data.tar.gz", o.Name))
+		}
 	}
 
 	return nil
@@ -105,6 +120,10 @@ func (o *DownloadOptions) Run(c *cobra.Command, f client.Factory) error {
 	veleroClient, err := f.Client()
 	cmd.CheckError(err)
 
+	if o.AllArtifacts {
+		return o.downloadAllArtifacts(veleroClient.VeleroV1(), f.Namespace())
+	}
+
 	backupDest, err := os.OpenFile(o.Output, o.writeOptions, 0600)
 	if err != nil {
 		return err
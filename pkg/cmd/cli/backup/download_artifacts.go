@@ -0,0 +1,239 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/cmd/util/downloadrequest"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+)
+
+// backupArtifact is one of the files stored in object storage for a backup.
+type backupArtifact struct {
+	kind     v1.DownloadTargetKind
+	fileName string
+	// required is true if every backup has the file. Other files are
+	// only stored for some backups, or only by newer versions of Velero.
+	required bool
+}
+
+// backupArtifacts returns the files stored for the named backup, using the
+// same file names as the backup's directory in object storage.
+func backupArtifacts(backup string) []backupArtifact {
+	return []backupArtifact{
+		{kind: v1.DownloadTargetKindBackupMetadata, fileName: "velero-backup.json", required: true},
+		{kind: v1.DownloadTargetKindBackupContents, fileName: backup + ".tar.gz", required: true},
+		{kind: v1.DownloadTargetKindBackupLog, fileName: backup + "-logs.gz"},
+		{kind: v1.DownloadTargetKindBackupStructuredLog, fileName: backup + "-logs.jsonl.gz"},
+		{kind: v1.DownloadTargetKindBackupVolumeSnapshots, fileName: backup + "-volumesnapshots.json.gz"},
+		{kind: v1.DownloadTargetKindBackupPodVolumeBackups, fileName: backup + "-podvolumebackups.json.gz"},
+		{kind: v1.DownloadTargetKindBackupResourceList, fileName: backup + "-resource-list.json.gz"},
+		{kind: v1.DownloadTargetKindBackupVolumeCoverage, fileName: backup + "-volume-coverage.json.gz"},
+	}
+}
+
+// artifactSink stores the files of a backup that's being downloaded.
+type artifactSink interface {
+	// add stores a file whose contents are written by write. If write
+	// returns an error, the file isn't stored.
+	add(fileName string, write func(io.Writer) error) error
+	close() error
+	// abort removes anything that's been stored.
+	abort()
+}
+
+func (o *DownloadOptions) downloadAllArtifacts(client velerov1client.DownloadRequestsGetter, namespace string) error {
+	var (
+		sink artifactSink
+		err  error
+	)
+	if o.Archive {
+		sink, err = newArchiveArtifactSink(o.Output, o.writeOptions, o.Name)
+	} else {
+		sink, err = newDirArtifactSink(o.Output, o.writeOptions, o.Force)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := addBackupArtifacts(sink, backupArtifacts(o.Name), func(kind v1.DownloadTargetKind, w io.Writer) error {
+		return downloadrequest.Download(client, namespace, o.Name, kind, w, o.Timeout)
+	}); err != nil {
+		sink.abort()
+		return err
+	}
+
+	if err := sink.close(); err != nil {
+		sink.abort()
+		return err
+	}
+
+	fmt.Printf("Backup %s has been successfully downloaded to %s\n", o.Name, o.Output)
+	return nil
+}
+
+// addBackupArtifacts downloads each of the artifacts into sink. Optional
+// artifacts that don't exist are skipped.
+func addBackupArtifacts(sink artifactSink, artifacts []backupArtifact, download func(v1.DownloadTargetKind, io.Writer) error) error {
+	for _, artifact := range artifacts {
+		kind := artifact.kind
+		err := sink.add(artifact.fileName, func(w io.Writer) error {
+			return download(kind, w)
+		})
+
+		if err == downloadrequest.ErrNotFound && !artifact.required {
+			fmt.Printf("Skipping %s because it doesn't exist for this backup\n", artifact.fileName)
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error downloading %s", artifact.fileName)
+		}
+	}
+
+	return nil
+}
+
+// dirArtifactSink stores files in a directory.
+type dirArtifactSink struct {
+	dir          string
+	writeOptions int
+	created      bool
+}
+
+func newDirArtifactSink(dir string, writeOptions int, force bool) (*dirArtifactSink, error) {
+	err := os.Mkdir(dir, 0755)
+	switch {
+	case err == nil:
+		return &dirArtifactSink{dir: dir, writeOptions: writeOptions, created: true}, nil
+	case os.IsExist(err) && force:
+		return &dirArtifactSink{dir: dir, writeOptions: writeOptions}, nil
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+func (s *dirArtifactSink) add(fileName string, write func(io.Writer) error) error {
+	path := filepath.Join(s.dir, fileName)
+
+	file, err := os.OpenFile(path, s.writeOptions, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+
+	return err
+}
+
+func (s *dirArtifactSink) close() error {
+	return nil
+}
+
+func (s *dirArtifactSink) abort() {
+	// only remove the directory if it was created for this download.
+	if s.created {
+		os.RemoveAll(s.dir)
+	}
+}
+
+// archiveArtifactSink stores files in a tar archive, under a directory
+// named after the backup.
+type archiveArtifactSink struct {
+	file   *os.File
+	tw     *tar.Writer
+	prefix string
+}
+
+func newArchiveArtifactSink(path string, writeOptions int, prefix string) (*archiveArtifactSink, error) {
+	file, err := os.OpenFile(path, writeOptions, 0600)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &archiveArtifactSink{
+		file:   file,
+		tw:     tar.NewWriter(file),
+		prefix: prefix,
+	}, nil
+}
+
+func (s *archiveArtifactSink) add(fileName string, write func(io.Writer) error) error {
+	// the size of a tar entry has to be known before its contents are
+	// written, so download the file to a temp file first.
+	tmp, err := ioutil.TempFile("", "")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return errors.WithStack(err)
+	}
+
+	hdr := &tar.Header{
+		Name:     filepath.Join(s.prefix, fileName),
+		Size:     size,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		ModTime:  time.Now(),
+	}
+	if err := s.tw.WriteHeader(hdr); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := io.Copy(s.tw, tmp); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (s *archiveArtifactSink) close() error {
+	if err := s.tw.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(s.file.Close())
+}
+
+func (s *archiveArtifactSink) abort() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/cmd/util/downloadrequest"
+)
+
+// fakeDownload returns a download func that writes each kind's name as the
+// file's contents, or returns ErrNotFound for the missing kinds.
+func fakeDownload(missing ...v1.DownloadTargetKind) func(v1.DownloadTargetKind, io.Writer) error {
+	return func(kind v1.DownloadTargetKind, w io.Writer) error {
+		for _, m := range missing {
+			if kind == m {
+				return downloadrequest.ErrNotFound
+			}
+		}
+		_, err := w.Write([]byte(kind))
+		return err
+	}
+}
+
+func TestAddBackupArtifactsToDir(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "backup-1")
+	sink, err := newDirArtifactSink(dir, os.O_RDWR|os.O_CREATE|os.O_EXCL, false)
+	require.NoError(t, err)
+
+	require.NoError(t, addBackupArtifacts(sink, backupArtifacts("backup-1"), fakeDownload(v1.DownloadTargetKindBackupStructuredLog)))
+	require.NoError(t, sink.close())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "velero-backup.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "BackupMetadata", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "backup-1-podvolumebackups.json.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, "BackupPodVolumeBackups", string(data))
+
+	// missing optional files are skipped
+	_, err = os.Stat(filepath.Join(dir, "backup-1-logs.jsonl.gz"))
+	assert.True(t, os.IsNotExist(err))
+
+	// the directory isn't reused unless forced
+	_, err = newDirArtifactSink(dir, os.O_RDWR|os.O_CREATE|os.O_EXCL, false)
+	assert.Error(t, err)
+}
+
+func TestAddBackupArtifactsToArchive(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	path := filepath.Join(tmp, "backup-1-artifacts.tar")
+	sink, err := newArchiveArtifactSink(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, "backup-1")
+	require.NoError(t, err)
+
+	require.NoError(t, addBackupArtifacts(sink, backupArtifacts("backup-1"), fakeDownload(v1.DownloadTargetKindBackupStructuredLog)))
+	require.NoError(t, sink.close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	got := map[string]string{}
+	tr := tar.NewReader(file)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		got[hdr.Name] = string(data)
+	}
+
+	assert.Len(t, got, len(backupArtifacts("backup-1"))-1)
+	assert.Equal(t, "BackupContents", got["backup-1/backup-1.tar.gz"])
+	assert.Equal(t, "BackupVolumeCoverage", got["backup-1/backup-1-volume-coverage.json.gz"])
+}
+
+func TestAddBackupArtifactsMissingRequiredFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "backup-1")
+	sink, err := newDirArtifactSink(dir, os.O_RDWR|os.O_CREATE|os.O_EXCL, false)
+	require.NoError(t, err)
+
+	err = addBackupArtifacts(sink, backupArtifacts("backup-1"), fakeDownload(v1.DownloadTargetKindBackupContents))
+	assert.Equal(t, downloadrequest.ErrNotFound, errors.Cause(err))
+
+	sink.abort()
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
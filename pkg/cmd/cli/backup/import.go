@@ -0,0 +1,271 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/scheme"
+	"github.com/heptio/velero/pkg/label"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	"github.com/heptio/velero/pkg/util/encode"
+	"github.com/heptio/velero/pkg/util/logging"
+)
+
+func NewImportCommand(f client.Factory) *cobra.Command {
+	o := NewImportOptions()
+	c := &cobra.Command{
+		Use:   "import",
+		Short: "Import a backup into a backup storage location",
+		Long: `Import a backup that was produced outside of this Velero installation, or downloaded
+with 'velero backup download', into a backup storage location. Once it's been imported,
+the backup is synced into the cluster like any other backup in the location and can be restored.
+
+The backup is uploaded directly from this machine using the location's object store
+plugin, so the credentials for the location's object storage must be available locally.`,
+		Example: `	velero backup import --file backup-1-data.tar.gz --metadata velero-backup.json`,
+		Args:    cobra.ExactArgs(0),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(f))
+			cmd.CheckError(o.Validate(f))
+			cmd.CheckError(o.Run(f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+	c.MarkFlagRequired("file")
+	c.MarkFlagRequired("metadata")
+
+	return c
+}
+
+type ImportOptions struct {
+	File            string
+	Metadata        string
+	StorageLocation string
+	PluginDir       string
+
+	backup   *velerov1api.Backup
+	location *velerov1api.BackupStorageLocation
+}
+
+func NewImportOptions() *ImportOptions {
+	return &ImportOptions{
+		StorageLocation: "default",
+	}
+}
+
+func (o *ImportOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.File, "file", o.File, "path to the backup tarball to import")
+	flags.StringVar(&o.Metadata, "metadata", o.Metadata, "path to the backup's metadata file (velero-backup.json)")
+	flags.StringVar(&o.StorageLocation, "storage-location", o.StorageLocation, "backup storage location to import the backup into")
+	flags.StringVar(&o.PluginDir, "plugin-dir", o.PluginDir, "directory containing Velero plugins to use in addition to the built-in ones")
+}
+
+func (o *ImportOptions) Complete(f client.Factory) error {
+	metadata, err := os.Open(o.Metadata)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer metadata.Close()
+
+	backup, err := readImportedBackup(metadata)
+	if err != nil {
+		return errors.Wrapf(err, "error reading backup metadata from %s", o.Metadata)
+	}
+	o.backup = backup
+
+	return nil
+}
+
+func (o *ImportOptions) Validate(f client.Factory) error {
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	location, err := veleroClient.VeleroV1().BackupStorageLocations(f.Namespace()).Get(o.StorageLocation, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if location.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+		return errors.Errorf("backup storage location %q is read-only", location.Name)
+	}
+	o.location = location
+
+	if _, err := veleroClient.VeleroV1().Backups(f.Namespace()).Get(o.backup.Name, metav1.GetOptions{}); err == nil {
+		return errors.Errorf("backup %q already exists", o.backup.Name)
+	} else if !apierrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+
+	contents, err := os.Open(o.File)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer contents.Close()
+
+	if err := validateBackupContents(contents); err != nil {
+		return errors.Wrapf(err, "%s is not a valid backup tarball", o.File)
+	}
+
+	return nil
+}
+
+func (o *ImportOptions) Run(f client.Factory) error {
+	logger := logging.DefaultLogger(logrus.WarnLevel, logging.FormatText)
+
+	registry := clientmgmt.NewRegistry(o.PluginDir, logger, logger.Level)
+	if err := registry.DiscoverPlugins(); err != nil {
+		return errors.Wrap(err, "error discovering plugins")
+	}
+	pluginManager := clientmgmt.NewManager(logger, logger.Level, registry)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := persistence.NewBackupStore(o.location, pluginManager, logger)
+	if err != nil {
+		return errors.Wrap(err, "error getting backup store")
+	}
+
+	var bucket string
+	if o.location.Spec.ObjectStorage != nil {
+		bucket = o.location.Spec.ObjectStorage.Bucket
+	}
+	exists, err := backupStore.BackupExists(bucket, o.backup.Name)
+	if err != nil {
+		return errors.Wrap(err, "error checking if backup already exists in backup storage location")
+	}
+	if exists {
+		return errors.Errorf("backup %q already exists in backup storage location %q", o.backup.Name, o.location.Name)
+	}
+
+	prepareImportedBackup(o.backup, o.location.Name)
+
+	metadata := new(bytes.Buffer)
+	if err := encode.EncodeTo(o.backup, "json", metadata); err != nil {
+		return errors.Wrap(err, "error encoding backup metadata")
+	}
+
+	contents, err := os.Open(o.File)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer contents.Close()
+
+	if err := backupStore.PutBackup(persistence.BackupInfo{
+		Name:     o.backup.Name,
+		Backup:   o.backup,
+		Metadata: metadata,
+		Contents: contents,
+	}); err != nil {
+		return errors.Wrap(err, "error uploading backup")
+	}
+
+	fmt.Printf("Backup %q imported into backup storage location %q. It will be available once the location has been synced.\n", o.backup.Name, o.location.Name)
+	return nil
+}
+
+// readImportedBackup decodes the metadata file of a backup that's being
+// imported, and checks that it describes a backup that can be restored.
+func readImportedBackup(r io.Reader) (*velerov1api.Backup, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	obj, _, err := scheme.Codecs.UniversalDecoder(velerov1api.SchemeGroupVersion).Decode(data, nil, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	backup, ok := obj.(*velerov1api.Backup)
+	if !ok {
+		return nil, errors.Errorf("unexpected type %T, expected a backup", obj)
+	}
+
+	if backup.Name == "" {
+		return nil, errors.New("backup has no name")
+	}
+
+	switch backup.Status.Phase {
+	case "":
+		// backups produced outside of Velero may not have a status.
+		backup.Status.Phase = velerov1api.BackupPhaseCompleted
+	case velerov1api.BackupPhaseCompleted, velerov1api.BackupPhasePartiallyFailed:
+	default:
+		return nil, errors.Errorf("backup has phase %s, only Completed and PartiallyFailed backups can be imported", backup.Status.Phase)
+	}
+
+	return backup, nil
+}
+
+// prepareImportedBackup updates an imported backup's metadata so that it
+// refers to the backup storage location it's being imported into.
+func prepareImportedBackup(backup *velerov1api.Backup, location string) {
+	backup.ResourceVersion = ""
+	backup.UID = ""
+	backup.SelfLink = ""
+
+	backup.Spec.StorageLocation = location
+	if backup.Labels == nil {
+		backup.Labels = make(map[string]string)
+	}
+	backup.Labels[velerov1api.StorageLocationLabel] = label.GetValidName(location)
+}
+
+// validateBackupContents checks that r is a gzipped tarball with the
+// resources directory that restores read from.
+func validateBackupContents(r io.Reader) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return errors.Errorf("no %s directory found", velerov1api.ResourcesDir)
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if strings.HasPrefix(strings.TrimPrefix(header.Name, "./"), velerov1api.ResourcesDir+"/") {
+			return nil
+		}
+	}
+}
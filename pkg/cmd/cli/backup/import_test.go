@@ -0,0 +1,112 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+func TestReadImportedBackup(t *testing.T) {
+	tests := []struct {
+		name      string
+		metadata  string
+		wantPhase velerov1api.BackupPhase
+		wantErr   bool
+	}{
+		{
+			name:      "completed backup",
+			metadata:  `{"apiVersion":"velero.io/v1","kind":"Backup","metadata":{"name":"backup-1"},"status":{"phase":"Completed"}}`,
+			wantPhase: velerov1api.BackupPhaseCompleted,
+		},
+		{
+			name:      "backup without a status is completed",
+			metadata:  `{"apiVersion":"velero.io/v1","kind":"Backup","metadata":{"name":"backup-1"}}`,
+			wantPhase: velerov1api.BackupPhaseCompleted,
+		},
+		{
+			name:     "failed backup",
+			metadata: `{"apiVersion":"velero.io/v1","kind":"Backup","metadata":{"name":"backup-1"},"status":{"phase":"Failed"}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "backup without a name",
+			metadata: `{"apiVersion":"velero.io/v1","kind":"Backup","metadata":{}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "not a backup",
+			metadata: `{"apiVersion":"velero.io/v1","kind":"Restore","metadata":{"name":"restore-1"}}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			backup, err := readImportedBackup(strings.NewReader(tc.metadata))
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "backup-1", backup.Name)
+			assert.Equal(t, tc.wantPhase, backup.Status.Phase)
+		})
+	}
+}
+
+func TestPrepareImportedBackup(t *testing.T) {
+	backup := &velerov1api.Backup{}
+	backup.Name = "backup-1"
+	backup.ResourceVersion = "123"
+	backup.UID = "uid"
+	backup.Spec.StorageLocation = "other"
+
+	prepareImportedBackup(backup, "default")
+
+	assert.Empty(t, backup.ResourceVersion)
+	assert.Empty(t, backup.UID)
+	assert.Equal(t, "default", backup.Spec.StorageLocation)
+	assert.Equal(t, "default", backup.Labels[velerov1api.StorageLocationLabel])
+}
+
+func TestValidateBackupContents(t *testing.T) {
+	tarball := func(names ...string) *bytes.Buffer {
+		buf := new(bytes.Buffer)
+		gzw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gzw)
+		for _, name := range names {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}))
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gzw.Close())
+		return buf
+	}
+
+	assert.NoError(t, validateBackupContents(tarball("metadata/version", "resources/pods/namespaces/ns-1/pod-1.json")))
+	assert.NoError(t, validateBackupContents(tarball("./resources/pods/namespaces/ns-1/pod-1.json")))
+	assert.Error(t, validateBackupContents(tarball("metadata/version")))
+	assert.Error(t, validateBackupContents(strings.NewReader("not a tarball")))
+}
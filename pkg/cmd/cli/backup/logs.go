@@ -32,6 +32,7 @@ import (
 
 func NewLogsCommand(f client.Factory) *cobra.Command {
 	timeout := time.Minute
+	structured := false
 
 	c := &cobra.Command{
 		Use:   "logs BACKUP",
@@ -58,12 +59,18 @@ func NewLogsCommand(f client.Factory) *cobra.Command {
 					"until the backup has a phase of Completed or Failed and try again.", backupName)
 			}
 
-			err = downloadrequest.Stream(veleroClient.VeleroV1(), f.Namespace(), backupName, v1.DownloadTargetKindBackupLog, os.Stdout, timeout)
+			kind := v1.DownloadTargetKindBackupLog
+			if structured {
+				kind = v1.DownloadTargetKindBackupStructuredLog
+			}
+
+			err = downloadrequest.Stream(veleroClient.VeleroV1(), f.Namespace(), backupName, kind, os.Stdout, timeout)
 			cmd.CheckError(err)
 		},
 	}
 
 	c.Flags().DurationVar(&timeout, "timeout", timeout, "how long to wait to receive logs")
+	c.Flags().BoolVar(&structured, "structured", structured, "get the JSON-lines log instead of the text log. Only available if the server was run with --structured-logs")
 
 	return c
 }
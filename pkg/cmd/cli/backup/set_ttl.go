@@ -0,0 +1,118 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+)
+
+func NewSetTTLCommand(f client.Factory) *cobra.Command {
+	o := NewSetTTLOptions()
+	c := &cobra.Command{
+		Use:   "set-ttl NAME --ttl DURATION",
+		Short: "Change the TTL of a backup",
+		Long: `Change how long a finished backup is kept, counted from when it started, for example to keep
+a routine backup as a long-term archive. The Velero server updates the backup's expiration, both
+in the cluster and in its metadata file in object storage, shortly after the TTL is changed.`,
+		Example: `	# Keep a backup for 90 days from when it started
+	velero backup set-ttl backup-1 --ttl 2160h`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(args))
+			cmd.CheckError(o.Validate())
+			cmd.CheckError(o.Run(f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+	c.MarkFlagRequired("ttl")
+
+	return c
+}
+
+type SetTTLOptions struct {
+	Name string
+	TTL  time.Duration
+}
+
+func NewSetTTLOptions() *SetTTLOptions {
+	return &SetTTLOptions{}
+}
+
+func (o *SetTTLOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.DurationVar(&o.TTL, "ttl", o.TTL, "how long the backup is kept, counted from when it started")
+}
+
+func (o *SetTTLOptions) Complete(args []string) error {
+	o.Name = args[0]
+	return nil
+}
+
+func (o *SetTTLOptions) Validate() error {
+	if o.TTL <= 0 {
+		return errors.New("--ttl must be greater than zero")
+	}
+	return nil
+}
+
+func (o *SetTTLOptions) Run(f client.Factory) error {
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	backups := veleroClient.VeleroV1().Backups(f.Namespace())
+
+	backup, err := backups.Get(o.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	switch backup.Status.Phase {
+	case velerov1api.BackupPhaseCompleted, velerov1api.BackupPhasePartiallyFailed, velerov1api.BackupPhaseFailed:
+	default:
+		return errors.Errorf("backup %q's TTL can't be changed until it's finished processing", o.Name)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ttl": metav1.Duration{Duration: o.TTL},
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := backups.Patch(o.Name, types.MergePatchType, patch); err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Printf("Backup %q's TTL set to %s. It will expire at %s.\n", o.Name, o.TTL, backup.Status.StartTimestamp.Add(o.TTL))
+	return nil
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuplocation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	"github.com/heptio/velero/pkg/util/logging"
+)
+
+func NewAuditCommand(f client.Factory, use string) *cobra.Command {
+	o := NewAuditOptions()
+
+	c := &cobra.Command{
+		Use:   use + " NAME",
+		Short: "Compare the backups in the cluster with the contents of a backup storage location",
+		Long: `Compare the Backup resources in the cluster with the backups stored in a backup storage location,
+and print a report of the differences:
+
+- missing backups: backups in the cluster whose files aren't in the location
+- orphaned backups: backups in the location that aren't in the cluster
+- checksum mismatches: backups whose spec in the location doesn't match the spec in the cluster
+- invalid layout: top-level directories in the location that Velero doesn't use
+
+The location is read directly from this machine using its object store plugin, so the
+credentials for the location's object storage must be available locally. The command exits
+with a non-zero status if any differences are found.`,
+		Example: `	velero backup-location audit default
+	velero backup-location audit default -o yaml`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(args, f))
+			cmd.CheckError(o.Validate())
+			cmd.CheckError(o.Run(f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+type AuditOptions struct {
+	Name      string
+	Output    string
+	PluginDir string
+
+	location *velerov1api.BackupStorageLocation
+	backups  []*velerov1api.Backup
+}
+
+func NewAuditOptions() *AuditOptions {
+	return &AuditOptions{
+		Output: "json",
+	}
+}
+
+func (o *AuditOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.Output, "output", "o", o.Output, "report format. Valid formats are 'json' and 'yaml'")
+	flags.StringVar(&o.PluginDir, "plugin-dir", o.PluginDir, "directory containing Velero plugins to use in addition to the built-in ones")
+}
+
+func (o *AuditOptions) Complete(args []string, f client.Factory) error {
+	o.Name = args[0]
+
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	location, err := veleroClient.VeleroV1().BackupStorageLocations(f.Namespace()).Get(o.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	o.location = location
+
+	backups, err := veleroClient.VeleroV1().Backups(f.Namespace()).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i := range backups.Items {
+		if backups.Items[i].Spec.StorageLocation == o.Name {
+			o.backups = append(o.backups, &backups.Items[i])
+		}
+	}
+
+	return nil
+}
+
+func (o *AuditOptions) Validate() error {
+	switch o.Output {
+	case "json", "yaml":
+		return nil
+	default:
+		return errors.Errorf("invalid output format %q, must be 'json' or 'yaml'", o.Output)
+	}
+}
+
+func (o *AuditOptions) Run(f client.Factory) error {
+	logger := logging.DefaultLogger(logrus.WarnLevel, logging.FormatText)
+
+	registry := clientmgmt.NewRegistry(o.PluginDir, logger, logger.Level)
+	if err := registry.DiscoverPlugins(); err != nil {
+		return errors.Wrap(err, "error discovering plugins")
+	}
+	pluginManager := clientmgmt.NewManager(logger, logger.Level, registry)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := persistence.NewBackupStore(o.location, pluginManager, logger)
+	if err != nil {
+		return errors.Wrap(err, "error getting backup store")
+	}
+
+	report, err := auditBackupStore(o.Name, o.backups, backupStore)
+	if err != nil {
+		return err
+	}
+
+	if err := printAuditReport(os.Stdout, report, o.Output); err != nil {
+		return err
+	}
+
+	if n := report.problems(); n > 0 {
+		return errors.Errorf("found %d differences between the cluster and backup storage location %q", n, o.Name)
+	}
+	return nil
+}
+
+// auditReport is the result of comparing the backups in the cluster with
+// the contents of a backup storage location.
+type auditReport struct {
+	StorageLocation string `json:"storageLocation"`
+
+	// InvalidLayout is the error returned when validating the location's
+	// top-level directories, if any.
+	InvalidLayout string `json:"invalidLayout,omitempty"`
+
+	// MissingBackups are the backups in the cluster whose metadata isn't
+	// stored in the location.
+	MissingBackups []string `json:"missingBackups"`
+
+	// OrphanedBackups are the backups stored in the location that don't
+	// exist in the cluster.
+	OrphanedBackups []string `json:"orphanedBackups"`
+
+	// ChecksumMismatches are the backups whose spec in the location is
+	// different from their spec in the cluster.
+	ChecksumMismatches []auditChecksumMismatch `json:"checksumMismatches"`
+
+	// Errors are the backups whose metadata couldn't be read from the
+	// location.
+	Errors []auditError `json:"errors"`
+}
+
+type auditChecksumMismatch struct {
+	Backup          string `json:"backup"`
+	ClusterChecksum string `json:"clusterChecksum"`
+	StoredChecksum  string `json:"storedChecksum"`
+}
+
+type auditError struct {
+	Backup string `json:"backup"`
+	Error  string `json:"error"`
+}
+
+func (r *auditReport) problems() int {
+	n := len(r.MissingBackups) + len(r.OrphanedBackups) + len(r.ChecksumMismatches) + len(r.Errors)
+	if r.InvalidLayout != "" {
+		n++
+	}
+	return n
+}
+
+// auditBackupStore compares the given backups, which are the backups in the
+// cluster that belong to the location, with the contents of the location's
+// backup store.
+func auditBackupStore(location string, backups []*velerov1api.Backup, backupStore persistence.BackupStore) (*auditReport, error) {
+	report := &auditReport{
+		StorageLocation:    location,
+		MissingBackups:     []string{},
+		OrphanedBackups:    []string{},
+		ChecksumMismatches: []auditChecksumMismatch{},
+		Errors:             []auditError{},
+	}
+
+	if err := backupStore.IsValid(); err != nil {
+		report.InvalidLayout = err.Error()
+	}
+
+	stored, err := backupStore.ListBackups()
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing backups in backup storage location")
+	}
+	storedNames := sets.NewString(stored...)
+
+	clusterNames := sets.NewString()
+	for _, backup := range backups {
+		clusterNames.Insert(backup.Name)
+
+		if !storedNames.Has(backup.Name) {
+			if isPersisted(backup) {
+				report.MissingBackups = append(report.MissingBackups, backup.Name)
+			}
+			continue
+		}
+
+		metadata, err := backupStore.GetBackupMetadata(backup.Name)
+		if err != nil {
+			report.Errors = append(report.Errors, auditError{Backup: backup.Name, Error: err.Error()})
+			continue
+		}
+
+		clusterChecksum, err := specChecksum(backup)
+		if err != nil {
+			return nil, err
+		}
+		storedChecksum, err := specChecksum(metadata)
+		if err != nil {
+			return nil, err
+		}
+		if clusterChecksum != storedChecksum {
+			report.ChecksumMismatches = append(report.ChecksumMismatches, auditChecksumMismatch{
+				Backup:          backup.Name,
+				ClusterChecksum: clusterChecksum,
+				StoredChecksum:  storedChecksum,
+			})
+		}
+	}
+
+	report.OrphanedBackups = append(report.OrphanedBackups, storedNames.Difference(clusterNames).List()...)
+
+	sort.Strings(report.MissingBackups)
+	sort.Slice(report.ChecksumMismatches, func(i, j int) bool {
+		return report.ChecksumMismatches[i].Backup < report.ChecksumMismatches[j].Backup
+	})
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Backup < report.Errors[j].Backup
+	})
+
+	return report, nil
+}
+
+// isPersisted returns true if the backup's files should have been stored in
+// its backup storage location, based on its phase.
+func isPersisted(backup *velerov1api.Backup) bool {
+	switch backup.Status.Phase {
+	case velerov1api.BackupPhaseCompleted, velerov1api.BackupPhasePartiallyFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// specChecksum returns the SHA-256 checksum of the JSON encoding of the
+// backup's spec, which doesn't change once the backup has been created.
+func specChecksum(backup *velerov1api.Backup) (string, error) {
+	data, err := json.Marshal(backup.Spec)
+	if err != nil {
+		return "", errors.Wrapf(err, "error encoding spec of backup %s", backup.Name)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func printAuditReport(w io.Writer, report *auditReport, format string) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch format {
+	case "yaml":
+		data, err = yaml.Marshal(report)
+	default:
+		data, err = json.MarshalIndent(report, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return errors.Wrap(err, "error encoding audit report")
+	}
+
+	_, err = fmt.Fprint(w, string(data))
+	return errors.WithStack(err)
+}
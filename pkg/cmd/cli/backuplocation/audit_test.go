@@ -0,0 +1,90 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuplocation
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+)
+
+func TestAuditBackupStore(t *testing.T) {
+	backup := func(name string, phase velerov1api.BackupPhase) *builder.BackupBuilder {
+		return builder.ForBackup(velerov1api.DefaultNamespace, name).StorageLocation("default").Phase(phase)
+	}
+
+	backupStore := new(persistencemocks.BackupStore)
+	backupStore.On("IsValid").Return(errors.New("Backup store contains invalid top-level directories: [foo]"))
+	backupStore.On("ListBackups").Return([]string{"backup-1", "backup-2", "backup-3", "orphan-1"}, nil)
+	backupStore.On("GetBackupMetadata", "backup-1").Return(backup("backup-1", velerov1api.BackupPhaseCompleted).Result(), nil)
+	backupStore.On("GetBackupMetadata", "backup-2").Return(backup("backup-2", velerov1api.BackupPhaseCompleted).TTL(time.Hour).Result(), nil)
+	backupStore.On("GetBackupMetadata", "backup-3").Return(nil, errors.New("access denied"))
+
+	report, err := auditBackupStore("default", []*velerov1api.Backup{
+		// the phase isn't part of the checksum, since it's updated after
+		// the backup has been uploaded.
+		backup("backup-1", velerov1api.BackupPhaseDeleting).Result(),
+		backup("backup-2", velerov1api.BackupPhaseCompleted).TTL(2 * time.Hour).Result(),
+		backup("backup-3", velerov1api.BackupPhaseCompleted).Result(),
+		backup("missing-1", velerov1api.BackupPhaseCompleted).Result(),
+		backup("missing-2", velerov1api.BackupPhasePartiallyFailed).Result(),
+		// backups that haven't been uploaded aren't missing.
+		backup("in-progress", velerov1api.BackupPhaseInProgress).Result(),
+		backup("failed-validation", velerov1api.BackupPhaseFailedValidation).Result(),
+	}, backupStore)
+	require.NoError(t, err)
+
+	assert.Equal(t, "default", report.StorageLocation)
+	assert.Equal(t, "Backup store contains invalid top-level directories: [foo]", report.InvalidLayout)
+	assert.Equal(t, []string{"missing-1", "missing-2"}, report.MissingBackups)
+	assert.Equal(t, []string{"orphan-1"}, report.OrphanedBackups)
+	require.Len(t, report.ChecksumMismatches, 1)
+	assert.Equal(t, "backup-2", report.ChecksumMismatches[0].Backup)
+	assert.NotEqual(t, report.ChecksumMismatches[0].ClusterChecksum, report.ChecksumMismatches[0].StoredChecksum)
+	assert.Equal(t, []auditError{{Backup: "backup-3", Error: "access denied"}}, report.Errors)
+	assert.Equal(t, 6, report.problems())
+}
+
+func TestPrintAuditReport(t *testing.T) {
+	report := &auditReport{
+		StorageLocation:    "default",
+		MissingBackups:     []string{},
+		OrphanedBackups:    []string{"orphan-1"},
+		ChecksumMismatches: []auditChecksumMismatch{},
+		Errors:             []auditError{},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, printAuditReport(buf, report, "json"))
+
+	decoded := new(auditReport)
+	require.NoError(t, json.Unmarshal(buf.Bytes(), decoded))
+	assert.Equal(t, report, decoded)
+
+	buf.Reset()
+	require.NoError(t, printAuditReport(buf, report, "yaml"))
+	assert.Contains(t, buf.String(), "orphanedBackups:\n- orphan-1\n")
+}
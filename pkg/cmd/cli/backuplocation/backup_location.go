@@ -32,6 +32,9 @@ func NewCommand(f client.Factory) *cobra.Command {
 	c.AddCommand(
 		NewCreateCommand(f, "create"),
 		NewGetCommand(f, "get"),
+		NewDescribeCommand(f, "describe"),
+		NewAuditCommand(f, "audit"),
+		NewSyncCommand(f, "sync"),
 	)
 
 	return c
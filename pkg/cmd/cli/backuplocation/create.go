@@ -18,11 +18,14 @@ package backuplocation
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -30,6 +33,7 @@ import (
 	"github.com/heptio/velero/pkg/cmd"
 	"github.com/heptio/velero/pkg/cmd/util/flag"
 	"github.com/heptio/velero/pkg/cmd/util/output"
+	"github.com/heptio/velero/pkg/persistence"
 )
 
 func NewCreateCommand(f client.Factory, use string) *cobra.Command {
@@ -54,37 +58,67 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 }
 
 type CreateOptions struct {
-	Name       string
-	Provider   string
-	Bucket     string
-	Prefix     string
-	Config     flag.Map
-	Labels     flag.Map
-	AccessMode *flag.Enum
+	Name              string
+	Provider          string
+	Bucket            string
+	Path              string
+	Prefix            string
+	Config            flag.Map
+	Credential        flag.Map
+	Labels            flag.Map
+	AccessMode        *flag.Enum
+	Deduplication     bool
+	RequestsPerSecond int
+	Burst             int
+	UploaderType      *flag.Enum
+	SyncPeriod        time.Duration
+	Standby           bool
+	StandbySelector   flag.LabelSelector
+	StandbyPrefix     string
 }
 
 func NewCreateOptions() *CreateOptions {
 	return &CreateOptions{
-		Config: flag.NewMap(),
+		Config:     flag.NewMap(),
+		Credential: flag.NewMap(),
 		AccessMode: flag.NewEnum(
 			string(velerov1api.BackupStorageLocationAccessModeReadWrite),
 			string(velerov1api.BackupStorageLocationAccessModeReadWrite),
 			string(velerov1api.BackupStorageLocationAccessModeReadOnly),
 		),
+		UploaderType: flag.NewEnum(
+			string(velerov1api.UploaderTypeRestic),
+			string(velerov1api.UploaderTypeRestic),
+			string(velerov1api.UploaderTypeKopia),
+		),
 	}
 }
 
 func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&o.Provider, "provider", o.Provider, "name of the backup storage provider (e.g. aws, azure, gcp)")
 	flags.StringVar(&o.Bucket, "bucket", o.Bucket, "name of the object storage bucket where backups should be stored")
-	flags.StringVar(&o.Prefix, "prefix", o.Prefix, "prefix under which all Velero data should be stored within the bucket. Optional.")
+	flags.StringVar(&o.Path, "path", o.Path, "absolute path of a directory on a filesystem mounted into the Velero server pod where backups should be stored, for use instead of object storage")
+	flags.StringVar(&o.Prefix, "prefix", o.Prefix, "prefix under which all Velero data should be stored within the bucket or path. Optional.")
 	flags.Var(&o.Config, "config", "configuration key-value pairs")
 	flags.Var(&o.Labels, "labels", "labels to apply to the backup storage location")
+	flags.Var(&o.Credential, "credential", "key in a secret in the Velero namespace, in the form SECRET_NAME=KEY, with the credentials to use for the location instead of the provider's default credentials. Optional.")
 	flags.Var(
 		o.AccessMode,
 		"access-mode",
 		fmt.Sprintf("access mode for the backup storage location. Valid values are %s", strings.Join(o.AccessMode.AllowedValues(), ",")),
 	)
+	flags.BoolVar(&o.Deduplication, "deduplication", o.Deduplication, "store the contents of backups as chunks that are shared between the location's backups. Optional.")
+	flags.IntVar(&o.RequestsPerSecond, "rate-limit", o.RequestsPerSecond, "maximum average number of requests per second that the Velero server makes to the location's object storage. Optional.")
+	flags.IntVar(&o.Burst, "rate-limit-burst", o.Burst, "number of requests that can be made at once under --rate-limit; defaults to the rate limit. Optional.")
+	flags.Var(
+		o.UploaderType,
+		"uploader-type",
+		fmt.Sprintf("uploader that backs up pod volumes into the location. Valid values are %s", strings.Join(o.UploaderType.AllowedValues(), ",")),
+	)
+	flags.DurationVar(&o.SyncPeriod, "sync-period", o.SyncPeriod, "how often to sync backups from the location into the cluster; defaults to the server's --backup-sync-period. Optional.")
+	flags.BoolVar(&o.Standby, "standby", o.Standby, "restore each new backup that's synced from the location, to keep a warm copy of it in this cluster. Requires --access-mode=ReadOnly. Optional.")
+	flags.Var(&o.StandbySelector, "standby-selector", "only restore backups matching this label selector in standby mode. Optional.")
+	flags.StringVar(&o.StandbyPrefix, "standby-namespace-prefix", o.StandbyPrefix, "prefix to add to the name of each namespace restored in standby mode. Optional.")
 }
 
 func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Factory) error {
@@ -92,12 +126,71 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Facto
 		return err
 	}
 
+	if len(o.Credential.Data()) > 1 {
+		return errors.New("--credential can only have one value")
+	}
+	for secretName, key := range o.Credential.Data() {
+		if secretName == "" || key == "" {
+			return errors.New("--credential must be in the form SECRET_NAME=KEY")
+		}
+	}
+
+	if o.Burst != 0 && o.RequestsPerSecond == 0 {
+		return errors.New("--rate-limit-burst requires --rate-limit")
+	}
+	if err := persistence.ValidateRateLimit(o.rateLimit()); err != nil {
+		return err
+	}
+
+	if o.SyncPeriod < 0 {
+		return errors.New("--sync-period must be non-negative")
+	}
+
+	if !o.Standby && (o.StandbySelector.LabelSelector != nil || o.StandbyPrefix != "") {
+		return errors.New("--standby-selector and --standby-namespace-prefix require --standby")
+	}
+	if o.Standby && o.AccessMode.String() != string(velerov1api.BackupStorageLocationAccessModeReadOnly) {
+		return errors.New("--standby requires --access-mode=ReadOnly")
+	}
+
+	if o.Path != "" {
+		if o.Bucket != "" {
+			return errors.New("--bucket and --path are mutually exclusive")
+		}
+		return nil
+	}
+
 	if o.Provider == "" {
 		return errors.New("--provider is required")
 	}
 
 	if o.Bucket == "" {
-		return errors.New("--bucket is required")
+		return errors.New("--bucket or --path is required")
+	}
+
+	return nil
+}
+
+// rateLimit returns the location's rate limit, or nil if it doesn't have one.
+func (o *CreateOptions) rateLimit() *velerov1api.BackupStorageLocationRateLimit {
+	if o.RequestsPerSecond == 0 && o.Burst == 0 {
+		return nil
+	}
+
+	return &velerov1api.BackupStorageLocationRateLimit{
+		RequestsPerSecond: o.RequestsPerSecond,
+		Burst:             o.Burst,
+	}
+}
+
+// credential returns the secret key selector for the --credential flag, or
+// nil if it wasn't set.
+func (o *CreateOptions) credential() *corev1api.SecretKeySelector {
+	for secretName, key := range o.Credential.Data() {
+		return &corev1api.SecretKeySelector{
+			LocalObjectReference: corev1api.LocalObjectReference{Name: secretName},
+			Key:                  key,
+		}
 	}
 
 	return nil
@@ -116,18 +209,39 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 			Labels:    o.Labels.Data(),
 		},
 		Spec: velerov1api.BackupStorageLocationSpec{
-			Provider: o.Provider,
-			StorageType: velerov1api.StorageType{
-				ObjectStorage: &velerov1api.ObjectStorageLocation{
-					Bucket: o.Bucket,
-					Prefix: o.Prefix,
-				},
-			},
-			Config:     o.Config.Data(),
-			AccessMode: velerov1api.BackupStorageLocationAccessMode(o.AccessMode.String()),
+			Provider:      o.Provider,
+			Config:        o.Config.Data(),
+			Credential:    o.credential(),
+			AccessMode:    velerov1api.BackupStorageLocationAccessMode(o.AccessMode.String()),
+			Deduplication: o.Deduplication,
+			RateLimit:     o.rateLimit(),
+			UploaderType:  velerov1api.UploaderType(o.UploaderType.String()),
 		},
 	}
 
+	if o.Path != "" {
+		backupStorageLocation.Spec.Filesystem = &velerov1api.FilesystemLocation{
+			Path:   o.Path,
+			Prefix: o.Prefix,
+		}
+	} else {
+		backupStorageLocation.Spec.ObjectStorage = &velerov1api.ObjectStorageLocation{
+			Bucket: o.Bucket,
+			Prefix: o.Prefix,
+		}
+	}
+
+	if o.SyncPeriod > 0 {
+		backupStorageLocation.Spec.SyncPeriod = &metav1.Duration{Duration: o.SyncPeriod}
+	}
+
+	if o.Standby {
+		backupStorageLocation.Spec.Standby = &velerov1api.BackupStorageLocationStandby{
+			BackupSelector:  o.StandbySelector.LabelSelector,
+			NamespacePrefix: o.StandbyPrefix,
+		}
+	}
+
 	if printed, err := output.PrintWithFormat(c, backupStorageLocation); printed || err != nil {
 		return err
 	}
@@ -137,6 +251,25 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 		return err
 	}
 
+	// locations that overlap with an existing location would be marked as
+	// unavailable by the server, so reject them up front.
+	locations, err := client.VeleroV1().BackupStorageLocations(backupStorageLocation.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i := range locations.Items {
+		existing := &locations.Items[i]
+		if !persistence.LocationsOverlap(backupStorageLocation, existing) {
+			continue
+		}
+		if backupStorageLocation.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly &&
+			existing.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+			fmt.Fprintf(os.Stderr, "Warning: backup storage location overlaps with read-only backup storage location %q\n", existing.Name)
+			continue
+		}
+		return errors.Errorf("backup storage location overlaps with backup storage location %q, which uses the same bucket and prefix", existing.Name)
+	}
+
 	if _, err := client.VeleroV1().BackupStorageLocations(backupStorageLocation.Namespace).Create(backupStorageLocation); err != nil {
 		return errors.WithStack(err)
 	}
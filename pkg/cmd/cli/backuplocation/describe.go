@@ -0,0 +1,79 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuplocation
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/util/output"
+)
+
+func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
+	var listOptions metav1.ListOptions
+
+	c := &cobra.Command{
+		Use:   use + " [NAME1] [NAME2] [NAME...]",
+		Short: "Describe backup storage locations",
+		Run: func(c *cobra.Command, args []string) {
+			err := output.ValidateDescribeFlags(c)
+			cmd.CheckError(err)
+
+			veleroClient, err := f.Client()
+			cmd.CheckError(err)
+
+			var locations *api.BackupStorageLocationList
+			if len(args) > 0 {
+				locations = new(api.BackupStorageLocationList)
+				for _, name := range args {
+					location, err := veleroClient.VeleroV1().BackupStorageLocations(f.Namespace()).Get(name, metav1.GetOptions{})
+					cmd.CheckError(err)
+					locations.Items = append(locations.Items, *location)
+				}
+			} else {
+				locations, err = veleroClient.VeleroV1().BackupStorageLocations(f.Namespace()).List(listOptions)
+				cmd.CheckError(err)
+			}
+
+			if printed, err := output.PrintWithFormat(c, locations); printed || err != nil {
+				cmd.CheckError(err)
+				return
+			}
+
+			first := true
+			for _, location := range locations.Items {
+				s := output.DescribeBackupStorageLocation(&location)
+				if first {
+					first = false
+					fmt.Print(s)
+				} else {
+					fmt.Printf("\n\n%s", s)
+				}
+			}
+		},
+	}
+
+	c.Flags().StringVarP(&listOptions.LabelSelector, "selector", "l", listOptions.LabelSelector, "only show items matching this label selector")
+	output.BindDescribeFlags(c.Flags())
+
+	return c
+}
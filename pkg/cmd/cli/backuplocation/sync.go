@@ -0,0 +1,84 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuplocation
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+)
+
+// NewSyncCommand creates a new command that requests that the Velero
+// server syncs backup storage locations.
+func NewSyncCommand(f client.Factory, use string) *cobra.Command {
+	c := &cobra.Command{
+		Use:   use + " NAME [NAME...]",
+		Short: "Sync backups from backup storage locations",
+		Long: `Sync backups from backup storage locations into the cluster now, rather than waiting for the
+location's next scheduled sync. Locations are synced even if their contents don't appear to have
+changed since they were last synced.`,
+		Example: `  # sync the backups from the backup storage location "default"
+  velero backup-location sync default`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(runSync(f, args))
+		},
+	}
+
+	return c
+}
+
+// runSync requests that the Velero server syncs each of the named
+// locations, by annotating them.
+func runSync(f client.Factory, names []string) error {
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				velerov1api.BackupStorageLocationSyncAnnotation: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var errs []error
+	for _, name := range names {
+		if _, err := veleroClient.VeleroV1().BackupStorageLocations(f.Namespace()).Patch(name, types.MergePatchType, patch); err != nil {
+			errs = append(errs, errors.WithStack(err))
+			continue
+		}
+
+		fmt.Printf("Request to sync backup storage location %q submitted successfully.\nRun `velero backup-location describe %s` to see when it was last synced.\n", name, name)
+	}
+
+	return kubeerrs.NewAggregate(errs)
+}
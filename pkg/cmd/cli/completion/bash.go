@@ -0,0 +1,202 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// argNames maps the commands whose arguments are names of existing
+// objects, by their path below the root command, to the kind of the objects.
+var argNames = map[string]string{
+	"backup delete":            kindBackups,
+	"backup describe":          kindBackups,
+	"backup diff":              kindBackups,
+	"backup download":          kindBackups,
+	"backup get":               kindBackups,
+	"backup logs":              kindBackups,
+	"delete backup":            kindBackups,
+	"describe backups":         kindBackups,
+	"get backups":              kindBackups,
+	"restore delete":           kindRestores,
+	"restore describe":         kindRestores,
+	"restore get":              kindRestores,
+	"restore logs":             kindRestores,
+	"restore undo":             kindRestores,
+	"delete restore":           kindRestores,
+	"describe restores":        kindRestores,
+	"get restores":             kindRestores,
+	"schedule delete":          kindSchedules,
+	"schedule describe":        kindSchedules,
+	"schedule get":             kindSchedules,
+	"delete schedule":          kindSchedules,
+	"describe schedules":       kindSchedules,
+	"get schedules":            kindSchedules,
+	"backup-location audit":    kindBackupLocations,
+	"backup-location describe": kindBackupLocations,
+	"backup-location get":      kindBackupLocations,
+	"get backup-locations":     kindBackupLocations,
+	"snapshot-location get":    kindSnapshotLocations,
+	"get snapshot-locations":   kindSnapshotLocations,
+}
+
+// flagNames maps the flags whose values are names of existing objects to
+// the kind of the objects.
+var flagNames = map[string]string{
+	"from-backup":               kindBackups,
+	"from-schedule":             kindSchedules,
+	"from-location":             kindBackupLocations,
+	"storage-location":          kindBackupLocations,
+	"replica-locations":         kindBackupLocations,
+	"failover-locations":        kindBackupLocations,
+	"volume-snapshot-locations": kindSnapshotLocations,
+}
+
+// skipFlagNames is the commands, by their path below the root command,
+// whose flags in flagNames name objects that don't exist yet.
+var skipFlagNames = map[string]bool{
+	"install": true,
+}
+
+// nameCompletions returns the kind of object whose names complete the
+// arguments of each of root's commands, keyed by the command, and the kind
+// whose names complete the values of each of their flags in flagNames.
+func nameCompletions(root *cobra.Command) (args map[*cobra.Command]string, flags map[*cobra.Command]map[string]string) {
+	args = map[*cobra.Command]string{}
+	flags = map[*cobra.Command]map[string]string{}
+
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		path := strings.TrimPrefix(strings.TrimPrefix(c.CommandPath(), root.Name()), " ")
+
+		if kind, ok := argNames[path]; ok {
+			args[c] = kind
+		}
+
+		if !skipFlagNames[strings.SplitN(path, " ", 2)[0]] {
+			c.LocalNonPersistentFlags().VisitAll(func(flag *pflag.Flag) {
+				if kind, ok := flagNames[flag.Name]; ok {
+					if flags[c] == nil {
+						flags[c] = map[string]string{}
+					}
+					flags[c][flag.Name] = kind
+				}
+			})
+		}
+
+		for _, child := range c.Commands() {
+			if child.IsAvailableCommand() {
+				visit(child)
+			}
+		}
+	}
+	visit(root)
+
+	return args, flags
+}
+
+// addBashNameCompletions sets up root's bash completion code to complete
+// the names of objects with `velero completion names`.
+func addBashNameCompletions(root *cobra.Command) {
+	args, flags := nameCompletions(root)
+
+	for c, names := range flags {
+		for flag, kind := range names {
+			c.Flags().SetAnnotation(flag, cobra.BashCompCustom, []string{fmt.Sprintf("__%s_get_names %s", root.Name(), kind)})
+		}
+	}
+
+	root.BashCompletionFunction = bashCompletionFunction(root.Name(), args)
+}
+
+// bashCompletionFunction returns the bash functions that list the names of
+// objects, and the __custom_func that the generated completion code calls
+// to complete the arguments of a command.
+func bashCompletionFunction(rootName string, args map[*cobra.Command]string) string {
+	commandsByKind := map[string][]string{}
+	for c, kind := range args {
+		// the generated completion code identifies commands by their
+		// path, joined with underscores.
+		commandsByKind[kind] = append(commandsByKind[kind], strings.Replace(c.CommandPath(), " ", "_", -1))
+	}
+
+	kinds := make([]string, 0, len(commandsByKind))
+	for kind := range commandsByKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, `__%[1]s_override_flags()
+{
+    local w two_word_of
+    for w in "${words[@]}"; do
+        if [ -n "${two_word_of}" ]; then
+            echo "--${two_word_of}=${w}"
+            two_word_of=
+            continue
+        fi
+        case "${w}" in
+            -n|--namespace)
+                two_word_of=namespace
+                ;;
+            --kubeconfig|--kubecontext)
+                two_word_of="${w#--}"
+                ;;
+            --namespace=*|--kubeconfig=*|--kubecontext=*)
+                echo "${w}"
+                ;;
+        esac
+    done
+}
+
+__%[1]s_get_names()
+{
+    local %[1]s_out
+    if %[1]s_out=$(%[1]s completion names "$1" $(__%[1]s_override_flags) 2>/dev/null); then
+        COMPREPLY=( $( compgen -W "${%[1]s_out[*]}" -- "$cur" ) )
+    fi
+}
+
+__custom_func()
+{
+    case ${last_command} in
+`, rootName)
+
+	for _, kind := range kinds {
+		commands := commandsByKind[kind]
+		sort.Strings(commands)
+		fmt.Fprintf(buf, "        %s)\n", strings.Join(commands, " | "))
+		fmt.Fprintf(buf, "            __%s_get_names %s\n", rootName, kind)
+		fmt.Fprintf(buf, "            return\n")
+		fmt.Fprintf(buf, "            ;;\n")
+	}
+
+	fmt.Fprintf(buf, `        *)
+            ;;
+    esac
+}
+`)
+
+	return buf.String()
+}
@@ -22,38 +22,54 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/heptio/velero/pkg/client"
 	kubectlcmd "github.com/heptio/velero/third_party/kubernetes/pkg/kubectl/cmd"
 )
 
-func NewCommand() *cobra.Command {
+func NewCommand(f client.Factory) *cobra.Command {
 	c := &cobra.Command{
 		Use:   "completion SHELL",
-		Short: "Output shell completion code for the specified shell (bash or zsh)",
+		Short: "Output shell completion code for the specified shell (bash, zsh or fish)",
 		Long: `Generate shell completion code.
 
-Auto completion supports both bash and zsh. Output is to STDOUT.
+Auto completion supports bash, zsh and fish. Output is to STDOUT.
+
+The names of backups, restores, schedules and locations are completed by
+querying the API server, using the --namespace, --kubeconfig and --kubecontext
+flags given on the command line.
 
 Load the velero completion code for bash into the current shell -
 source <(velero completion bash)
 
 Load the velero completion code for zsh into the current shell -
 source <(velero completion zsh)
+
+Load the velero completion code for fish into the current shell -
+velero completion fish | source
 `,
 		Args:      cobra.ExactArgs(1),
-		ValidArgs: []string{"bash", "zsh"},
+		ValidArgs: []string{"bash", "zsh", "fish"},
 		Run: func(cmd *cobra.Command, args []string) {
+			root := cmd.Root()
+
 			shell := args[0]
 			switch shell {
 			case "bash":
-				cmd.Root().GenBashCompletion(os.Stdout)
+				addBashNameCompletions(root)
+				root.GenBashCompletion(os.Stdout)
 			case "zsh":
-				kubectlcmd.GenZshCompletion(os.Stdout, cmd.Root())
+				addBashNameCompletions(root)
+				kubectlcmd.GenZshCompletion(os.Stdout, root)
+			case "fish":
+				genFishCompletion(os.Stdout, root)
 			default:
-				fmt.Printf("Invalid shell specified, specify bash or zsh\n")
+				fmt.Printf("Invalid shell specified, specify bash, zsh or fish\n")
 				os.Exit(1)
 			}
 		},
 	}
 
+	c.AddCommand(NewNamesCommand(f))
+
 	return c
 }
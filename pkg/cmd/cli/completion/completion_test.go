@@ -0,0 +1,119 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package completion
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+)
+
+func newTestCommands() (root, describe, create, install *cobra.Command) {
+	run := func(*cobra.Command, []string) {}
+
+	root = &cobra.Command{Use: "velero"}
+	root.PersistentFlags().StringP("namespace", "n", "", "The namespace in which Velero should operate")
+
+	backup := &cobra.Command{Use: "backup", Short: "Work with backups"}
+	describe = &cobra.Command{Use: "describe [NAME...]", Short: "Describe backups", Run: run}
+	describe.Flags().Bool("details", false, "display additional detail")
+	backup.AddCommand(describe)
+
+	restore := &cobra.Command{Use: "restore", Short: "Work with restores"}
+	create = &cobra.Command{Use: "create", Short: "Create a restore", Run: run}
+	create.Flags().String("from-backup", "", "backup to restore from")
+	restore.AddCommand(create)
+
+	install = &cobra.Command{Use: "install", Short: "Install Velero", Run: run}
+	install.Flags().String("storage-location", "", "name of the backup storage location to create")
+
+	root.AddCommand(backup, restore, install)
+	return root, describe, create, install
+}
+
+func TestNameCompletions(t *testing.T) {
+	root, describe, create, _ := newTestCommands()
+
+	args, flags := nameCompletions(root)
+	assert.Equal(t, map[*cobra.Command]string{describe: kindBackups}, args)
+	assert.Equal(t, map[*cobra.Command]map[string]string{create: {"from-backup": kindBackups}}, flags)
+}
+
+func TestBashNameCompletions(t *testing.T) {
+	root, _, _, _ := newTestCommands()
+	addBashNameCompletions(root)
+
+	assert.Contains(t, root.BashCompletionFunction, "        velero_backup_describe)\n            __velero_get_names backups\n")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, root.GenBashCompletion(buf))
+	assert.Contains(t, buf.String(), `flags_completion+=("__velero_get_names backups")`)
+}
+
+func TestGenFishCompletion(t *testing.T) {
+	root, _, _, _ := newTestCommands()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, genFishCompletion(buf, root))
+
+	for _, line := range []string{
+		"complete -c velero -l namespace -s n -r -d 'The namespace in which Velero should operate'\n",
+		"complete -c velero -f -n '__velero_using_command velero' -a backup -d 'Work with backups'\n",
+		"complete -c velero -f -n '__velero_using_command velero backup' -a describe -d 'Describe backups'\n",
+		"complete -c velero -n '__velero_using_command_prefix velero backup describe' -l details -d 'display additional detail'\n",
+		"complete -c velero -f -n '__velero_using_command_prefix velero backup describe' -a '(__velero_names backups)'\n",
+		"complete -c velero -n '__velero_using_command_prefix velero restore create' -l from-backup -r -f -a '(__velero_names backups)' -d 'backup to restore from'\n",
+		"complete -c velero -n '__velero_using_command_prefix velero install' -l storage-location -r -d 'name of the backup storage location to create'\n",
+	} {
+		assert.Contains(t, buf.String(), line)
+	}
+}
+
+func TestFishQuote(t *testing.T) {
+	assert.Equal(t, `'it\'s a \\ test'`, fishQuote(`it's a \ test`))
+}
+
+func TestListNames(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&velerov1api.Backup{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "backup-2"}},
+		&velerov1api.Backup{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "backup-1"}},
+		&velerov1api.Backup{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "backup-3"}},
+		&velerov1api.BackupStorageLocation{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "default"}},
+	)
+
+	names, err := listNames(client, "velero", kindBackups)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup-1", "backup-2"}, names)
+
+	names, err = listNames(client, "velero", kindBackupLocations)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, names)
+
+	names, err = listNames(client, "velero", kindRestores)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	_, err = listNames(client, "velero", "pods")
+	assert.Error(t, err)
+}
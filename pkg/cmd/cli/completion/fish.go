@@ -0,0 +1,157 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// genFishCompletion writes fish completion code for root's commands and
+// their flags, which completes the names of objects with
+// `velero completion names`.
+func genFishCompletion(w io.Writer, root *cobra.Command) error {
+	args, flags := nameCompletions(root)
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, `# fish completion for %[1]s
+
+# __%[1]s_words prints the words of the command line before the one being
+# completed, without flags and the values of the global flags.
+function __%[1]s_words
+    set -l skip_next
+    for w in (commandline -opc)
+        if test -n "$skip_next"
+            set skip_next
+            continue
+        end
+        switch $w
+            case -n --namespace --kubeconfig --kubecontext
+                set skip_next 1
+            case '-*'
+            case '*'
+                echo $w
+        end
+    end
+end
+
+function __%[1]s_using_command
+    test (string join ' ' (__%[1]s_words)) = "$argv"
+end
+
+function __%[1]s_using_command_prefix
+    set -l words (__%[1]s_words)
+    test (count $words) -ge (count $argv); and test (string join ' ' $words[1..(count $argv)]) = "$argv"
+end
+
+function __%[1]s_names
+    set -l args
+    set -l words (commandline -opc)
+    for i in (seq (count $words))
+        switch $words[$i]
+            case -n --namespace --kubeconfig --kubecontext
+                set -l next (math $i + 1)
+                if test $next -le (count $words)
+                    set args $args $words[$i] $words[$next]
+                end
+            case '--namespace=*' '--kubeconfig=*' '--kubecontext=*'
+                set args $args $words[$i]
+        end
+    end
+    %[1]s completion names $argv $args 2>/dev/null
+end
+
+`, root.Name())
+
+	root.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		writeFishFlag(buf, root.Name(), "", flag, "")
+	})
+
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		path := c.CommandPath()
+
+		// the flags of a command with subcommands, and the commands
+		// themselves, are only completed right after the command.
+		condition := fmt.Sprintf("__%s_using_command %s", root.Name(), path)
+		if !c.HasAvailableSubCommands() {
+			condition = fmt.Sprintf("__%s_using_command_prefix %s", root.Name(), path)
+		}
+
+		for _, child := range c.Commands() {
+			if !child.IsAvailableCommand() {
+				continue
+			}
+			fmt.Fprintf(buf, "complete -c %s -f -n %s -a %s -d %s\n", root.Name(), fishQuote(fmt.Sprintf("__%s_using_command %s", root.Name(), path)), child.Name(), fishQuote(child.Short))
+		}
+
+		if c != root {
+			c.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+				writeFishFlag(buf, root.Name(), condition, flag, flags[c][flag.Name])
+			})
+		}
+
+		if kind, ok := args[c]; ok {
+			fmt.Fprintf(buf, "complete -c %s -f -n %s -a %s\n", root.Name(), fishQuote(condition), fishQuote(fmt.Sprintf("(__%s_names %s)", root.Name(), kind)))
+		}
+
+		for _, child := range c.Commands() {
+			if child.IsAvailableCommand() {
+				visit(child)
+			}
+		}
+	}
+	visit(root)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeFishFlag writes the completion of a flag when condition holds, or
+// always if it's empty. If kind isn't empty, the flag's value is completed
+// with the names of the objects of that kind.
+func writeFishFlag(w io.Writer, rootName, condition string, flag *pflag.Flag, kind string) {
+	if flag.Hidden || flag.Deprecated != "" {
+		return
+	}
+
+	fmt.Fprintf(w, "complete -c %s", rootName)
+	if condition != "" {
+		fmt.Fprintf(w, " -n %s", fishQuote(condition))
+	}
+	fmt.Fprintf(w, " -l %s", flag.Name)
+	if flag.Shorthand != "" {
+		fmt.Fprintf(w, " -s %s", flag.Shorthand)
+	}
+	if flag.Value.Type() != "bool" && flag.NoOptDefVal == "" {
+		fmt.Fprintf(w, " -r")
+	}
+	if kind != "" {
+		fmt.Fprintf(w, " -f -a %s", fishQuote(fmt.Sprintf("(__%s_names %s)", rootName, kind)))
+	}
+	fmt.Fprintf(w, " -d %s\n", fishQuote(strings.SplitN(flag.Usage, "\n", 2)[0]))
+}
+
+// fishQuote quotes s as a single-quoted fish string.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package completion
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	clientset "github.com/heptio/velero/pkg/generated/clientset/versioned"
+)
+
+// The kinds of objects whose names are completed.
+const (
+	kindBackups           = "backups"
+	kindRestores          = "restores"
+	kindSchedules         = "schedules"
+	kindBackupLocations   = "backup-locations"
+	kindSnapshotLocations = "snapshot-locations"
+)
+
+// NewNamesCommand returns the hidden command that the completion code runs
+// to list the names of the objects of a kind.
+func NewNamesCommand(f client.Factory) *cobra.Command {
+	c := &cobra.Command{
+		Use:    "names KIND",
+		Short:  "List the names of backups, restores, schedules, backup-locations or snapshot-locations for shell completion",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			veleroClient, err := f.Client()
+			cmd.CheckError(err)
+
+			names, err := listNames(veleroClient, f.Namespace(), args[0])
+			cmd.CheckError(err)
+
+			for _, name := range names {
+				fmt.Fprintln(os.Stdout, name)
+			}
+		},
+	}
+
+	return c
+}
+
+// listNames returns the sorted names of the objects of a kind in a namespace.
+func listNames(veleroClient clientset.Interface, namespace, kind string) ([]string, error) {
+	var (
+		names []string
+		err   error
+	)
+
+	client := veleroClient.VeleroV1()
+	switch kind {
+	case kindBackups:
+		var list *velerov1api.BackupList
+		if list, err = client.Backups(namespace).List(metav1.ListOptions{}); err == nil {
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+		}
+	case kindRestores:
+		var list *velerov1api.RestoreList
+		if list, err = client.Restores(namespace).List(metav1.ListOptions{}); err == nil {
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+		}
+	case kindSchedules:
+		var list *velerov1api.ScheduleList
+		if list, err = client.Schedules(namespace).List(metav1.ListOptions{}); err == nil {
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+		}
+	case kindBackupLocations:
+		var list *velerov1api.BackupStorageLocationList
+		if list, err = client.BackupStorageLocations(namespace).List(metav1.ListOptions{}); err == nil {
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+		}
+	case kindSnapshotLocations:
+		var list *velerov1api.VolumeSnapshotLocationList
+		if list, err = client.VolumeSnapshotLocations(namespace).List(metav1.ListOptions{}); err == nil {
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+		}
+	default:
+		return nil, errors.Errorf("unsupported kind %q", kind)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compliancereport
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+)
+
+func NewCommand(f client.Factory) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "compliance-report",
+		Short: "Work with compliance reports",
+		Long:  "Work with the compliance reports of whether each schedule's backups meet its frequency and retention, which the Velero server generates periodically",
+	}
+
+	c.AddCommand(
+		NewGetCommand(f, "get"),
+		NewDescribeCommand(f, "describe"),
+		NewDownloadCommand(f, "download"),
+	)
+
+	return c
+}
+
+// getReport gets the named compliance report, or the newest one if name is
+// empty.
+func getReport(client velerov1client.ComplianceReportsGetter, namespace, name string) (*velerov1api.ComplianceReport, error) {
+	if name != "" {
+		report, err := client.ComplianceReports(namespace).Get(name, metav1.GetOptions{})
+		return report, errors.WithStack(err)
+	}
+
+	reports, err := client.ComplianceReports(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(reports.Items) == 0 {
+		return nil, errors.New("no compliance reports have been generated yet")
+	}
+
+	newest := &reports.Items[0]
+	for i := range reports.Items {
+		if reports.Items[i].Status.GenerationTimestamp.After(newest.Status.GenerationTimestamp.Time) {
+			newest = &reports.Items[i]
+		}
+	}
+
+	return newest, nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compliancereport
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/util/output"
+)
+
+func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
+	c := &cobra.Command{
+		Use:   use + " [NAME]",
+		Short: "Describe a compliance report",
+		Long:  "Describe a compliance report. If no name is given, the newest report is described.",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			veleroClient, err := f.Client()
+			cmd.CheckError(err)
+
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+
+			report, err := getReport(veleroClient.VeleroV1(), f.Namespace(), name)
+			cmd.CheckError(err)
+
+			fmt.Print(output.DescribeComplianceReport(report))
+		},
+	}
+
+	return c
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compliancereport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/util/encode"
+)
+
+func NewDownloadCommand(f client.Factory, use string) *cobra.Command {
+	o := NewDownloadOptions()
+
+	c := &cobra.Command{
+		Use:   use + " [NAME]",
+		Short: "Download a compliance report",
+		Long:  "Download a compliance report as a JSON, YAML or CSV file. If no name is given, the newest report is downloaded.",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Validate())
+			cmd.CheckError(o.Run(f, args))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+type DownloadOptions struct {
+	Format string
+	Output string
+	Force  bool
+}
+
+func NewDownloadOptions() *DownloadOptions {
+	return &DownloadOptions{
+		Format: "json",
+	}
+}
+
+func (o *DownloadOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.Format, "format", o.Format, "format of the downloaded report. Valid values are 'json', 'yaml' and 'csv'.")
+	flags.StringVarP(&o.Output, "output", "o", o.Output, "path to output file. Defaults to <NAME>.<FORMAT> in the current directory")
+	flags.BoolVar(&o.Force, "force", o.Force, "forces the download and will overwrite file if it exists already")
+}
+
+func (o *DownloadOptions) Validate() error {
+	switch o.Format {
+	case "json", "yaml", "csv":
+		return nil
+	default:
+		return errors.Errorf("invalid format %q - valid values are 'json', 'yaml' and 'csv'", o.Format)
+	}
+}
+
+func (o *DownloadOptions) Run(f client.Factory, args []string) error {
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	report, err := getReport(veleroClient.VeleroV1(), f.Namespace(), name)
+	if err != nil {
+		return err
+	}
+
+	path := o.Output
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return errors.Wrap(err, "error getting current directory")
+		}
+		path = filepath.Join(wd, fmt.Sprintf("%s.%s", report.Name, o.Format))
+	}
+
+	flags := os.O_RDWR | os.O_CREATE | os.O_EXCL
+	if o.Force {
+		flags = os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	if err := writeReport(report, o.Format, file); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	fmt.Printf("Compliance report %s has been successfully downloaded to %s\n", report.Name, path)
+	return nil
+}
+
+func writeReport(report *velerov1api.ComplianceReport, format string, w io.Writer) error {
+	if format == "csv" {
+		return writeReportCSV(report, w)
+	}
+
+	encoded, err := encode.Encode(report, format)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(encoded)
+	return errors.WithStack(err)
+}
+
+var csvHeader = []string{
+	"report",
+	"generated",
+	"schedule",
+	"compliant",
+	"cron schedule",
+	"required retention",
+	"completed backups",
+	"oldest backup",
+	"oldest backup started",
+	"newest backup",
+	"newest backup started",
+	"verification",
+	"verification message",
+	"failed backups",
+	"problems",
+}
+
+// writeReportCSV writes a report as CSV, with a row for each schedule.
+func writeReportCSV(report *velerov1api.ComplianceReport, w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(csvHeader); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, schedule := range report.Status.Schedules {
+		oldestName, oldestStart := csvBackup(schedule.OldestBackup)
+		newestName, newestStart := csvBackup(schedule.NewestBackup)
+
+		row := []string{
+			report.Name,
+			csvTime(report.Status.GenerationTimestamp.Time),
+			schedule.Schedule,
+			strconv.FormatBool(schedule.Compliant),
+			schedule.CronSchedule,
+			schedule.RequiredRetention.Duration.String(),
+			strconv.Itoa(schedule.CompletedBackups),
+			oldestName,
+			oldestStart,
+			newestName,
+			newestStart,
+			string(schedule.Verification),
+			schedule.VerificationMessage,
+			strings.Join(schedule.FailedBackups, ";"),
+			strings.Join(schedule.Problems, "; "),
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	csvWriter.Flush()
+	return errors.WithStack(csvWriter.Error())
+}
+
+func csvBackup(backup *velerov1api.ComplianceBackupReference) (string, string) {
+	if backup == nil {
+		return "", ""
+	}
+	return backup.Name, csvTime(backup.StartTimestamp.Time)
+}
+
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compliancereport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+func TestWriteReportCSV(t *testing.T) {
+	generated := time.Date(2019, 10, 1, 12, 30, 0, 0, time.UTC)
+
+	report := &velerov1api.ComplianceReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: velerov1api.DefaultNamespace,
+			Name:      "compliance-20191001123000",
+		},
+		Status: velerov1api.ComplianceReportStatus{
+			GenerationTimestamp: metav1.NewTime(generated),
+			Schedules: []velerov1api.ScheduleCompliance{
+				{
+					Schedule:          "daily",
+					CronSchedule:      "0 1 * * *",
+					RequiredRetention: metav1.Duration{Duration: 720 * time.Hour},
+					CompletedBackups:  30,
+					OldestBackup: &velerov1api.ComplianceBackupReference{
+						Name:           "daily-20190902010000",
+						StartTimestamp: metav1.NewTime(time.Date(2019, 9, 2, 1, 0, 0, 0, time.UTC)),
+					},
+					NewestBackup: &velerov1api.ComplianceBackupReference{
+						Name:           "daily-20191001010000",
+						StartTimestamp: metav1.NewTime(time.Date(2019, 10, 1, 1, 0, 0, 0, time.UTC)),
+					},
+					Verification:  velerov1api.BackupVerificationPhaseVerified,
+					FailedBackups: []string{"daily-20190910010000", "daily-20190911010000"},
+					Compliant:     true,
+				},
+				{
+					Schedule:          "hourly",
+					CronSchedule:      "0 * * * *",
+					RequiredRetention: metav1.Duration{Duration: 24 * time.Hour},
+					Problems:          []string{"schedule has no completed backups", "schedule isn't enabled, its phase is \"FailedValidation\""},
+				},
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeReport(report, "csv", buf))
+
+	expected := `report,generated,schedule,compliant,cron schedule,required retention,completed backups,oldest backup,oldest backup started,newest backup,newest backup started,verification,verification message,failed backups,problems
+compliance-20191001123000,2019-10-01T12:30:00Z,daily,true,0 1 * * *,720h0m0s,30,daily-20190902010000,2019-09-02T01:00:00Z,daily-20191001010000,2019-10-01T01:00:00Z,Verified,,daily-20190910010000;daily-20190911010000,
+compliance-20191001123000,2019-10-01T12:30:00Z,hourly,false,0 * * * *,24h0m0s,0,,,,,,,,"schedule has no completed backups; schedule isn't enabled, its phase is ""FailedValidation"""
+`
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/util/flag"
+	"github.com/heptio/velero/pkg/cmd/util/output"
+	"github.com/heptio/velero/pkg/credentials"
+)
+
+func NewCreateCommand(f client.Factory, use string) *cobra.Command {
+	o := NewCreateOptions()
+
+	c := &cobra.Command{
+		Use:   use + " NAME",
+		Short: "Create a credential secret",
+		Long: `Create a secret in the Velero namespace holding credentials, for backup storage and volume snapshot locations' --credential.
+
+With --kms-provider and --kms-key, each file is encrypted with a new data key, which is encrypted with the KMS key,
+and the secret is annotated so that the Velero server decrypts it with the KMS before using it. The secret can then
+be stored anywhere that only the KMS key's users should be able to read credentials from, e.g. with '-o yaml'.
+The KMS key is used with your default credentials for the provider, and the Velero server needs permission to
+decrypt with it.`,
+		Example: `	# velero credential create bucket-2-credentials --from-file cloud=./credentials-velero
+
+	# velero credential create bucket-2-credentials --from-file cloud=./credentials-velero --kms-provider aws --kms-key arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab
+
+	# velero credential create bucket-2-credentials --from-file cloud=./credentials-velero --kms-provider gcp --kms-key projects/my-project/locations/global/keyRings/velero/cryptoKeys/credentials -o yaml`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(args, f))
+			cmd.CheckError(o.Validate(c, args, f))
+			cmd.CheckError(o.Run(c, f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+	output.BindFlags(c.Flags())
+	output.ClearOutputFlagDefault(c)
+
+	return c
+}
+
+type CreateOptions struct {
+	Name        string
+	Files       flag.Map
+	KMSProvider string
+	KMSKey      string
+	Labels      flag.Map
+
+	// newKMS returns the KMS to encrypt with. It's a field so that it can be
+	// faked for testing.
+	newKMS func(provider string) (credentials.KMS, error)
+}
+
+func NewCreateOptions() *CreateOptions {
+	return &CreateOptions{
+		Files:  flag.NewMap(),
+		Labels: flag.NewMap(),
+		newKMS: credentials.NewKMS,
+	}
+}
+
+func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.Var(&o.Files, "from-file", "keys of the secret and the files to read their values from, in the form KEY1=FILE1,KEY2=FILE2. Required.")
+	flags.StringVar(&o.KMSProvider, "kms-provider", o.KMSProvider, "cloud provider whose key management service encrypts the credentials (aws or gcp). Optional.")
+	flags.StringVar(&o.KMSKey, "kms-key", o.KMSKey, "KMS key to encrypt the credentials with: an AWS KMS key or alias ARN, or a Cloud KMS key's resource name. Required with --kms-provider.")
+	flags.Var(&o.Labels, "labels", "labels to apply to the secret")
+}
+
+func (o *CreateOptions) Complete(args []string, f client.Factory) error {
+	o.Name = args[0]
+	return nil
+}
+
+func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Factory) error {
+	if err := output.ValidateFlags(c); err != nil {
+		return err
+	}
+
+	if len(o.Files.Data()) == 0 {
+		return errors.New("--from-file is required")
+	}
+
+	if (o.KMSProvider == "") != (o.KMSKey == "") {
+		return errors.New("--kms-provider and --kms-key must be used together")
+	}
+
+	return nil
+}
+
+// buildSecret returns the secret with the files' contents, encrypted if a
+// KMS key was given.
+func (o *CreateOptions) buildSecret(namespace string) (*corev1api.Secret, error) {
+	secret := &corev1api.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: corev1api.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      o.Name,
+			Labels:    o.Labels.Data(),
+		},
+		Type: corev1api.SecretTypeOpaque,
+		Data: make(map[string][]byte),
+	}
+
+	var kms credentials.KMS
+	if o.KMSProvider != "" {
+		var err error
+		if kms, err = o.newKMS(o.KMSProvider); err != nil {
+			return nil, err
+		}
+		secret.Annotations = map[string]string{velerov1api.KMSEnvelopeAnnotation: "true"}
+	}
+
+	for key, file := range o.Files.Data() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading file for key %s", key)
+		}
+
+		if kms != nil {
+			if data, err = credentials.SealEnvelope(kms, o.KMSProvider, o.KMSKey, data); err != nil {
+				return nil, err
+			}
+		}
+
+		secret.Data[key] = data
+	}
+
+	return secret, nil
+}
+
+func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
+	secret, err := o.buildSecret(f.Namespace())
+	if err != nil {
+		return err
+	}
+
+	if printed, err := output.PrintWithFormat(c, secret); printed || err != nil {
+		return err
+	}
+
+	kubeClient, err := f.KubeClient()
+	if err != nil {
+		return err
+	}
+
+	if _, err := kubeClient.CoreV1().Secrets(secret.Namespace).Create(secret); err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Printf("Credential secret %q created successfully.\n", secret.Name)
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/credentials"
+)
+
+// prefixKMS "encrypts" by prefixing data with the key ID.
+type prefixKMS struct{}
+
+func (prefixKMS) Encrypt(keyID string, plaintext []byte) ([]byte, error) {
+	return append([]byte(keyID+":"), plaintext...), nil
+}
+
+func (prefixKMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, []byte(keyID+":")) {
+		return nil, errors.New("wrong key")
+	}
+	return ciphertext[len(keyID)+1:], nil
+}
+
+func TestBuildSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "velero-credential")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "credentials-velero")
+	require.NoError(t, ioutil.WriteFile(file, []byte("creds"), 0600))
+
+	o := NewCreateOptions()
+	o.Name = "bucket-2-credentials"
+	require.NoError(t, o.Files.Set("cloud="+file))
+
+	secret, err := o.buildSecret("velero")
+	require.NoError(t, err)
+	assert.Equal(t, "velero", secret.Namespace)
+	assert.Equal(t, "creds", string(secret.Data["cloud"]))
+	assert.Empty(t, secret.Annotations)
+
+	newKMS := func(string) (credentials.KMS, error) { return prefixKMS{}, nil }
+	o.KMSProvider = "aws"
+	o.KMSKey = "arn:aws:kms:us-east-2:111122223333:key/1234abcd"
+	o.newKMS = newKMS
+
+	secret, err = o.buildSecret("velero")
+	require.NoError(t, err)
+	assert.Equal(t, "true", secret.Annotations[velerov1api.KMSEnvelopeAnnotation])
+	assert.NotContains(t, string(secret.Data["cloud"]), "creds")
+
+	plaintext, err := credentials.OpenEnvelope(secret.Data["cloud"], newKMS)
+	require.NoError(t, err)
+	assert.Equal(t, "creds", string(plaintext))
+
+	require.NoError(t, o.Files.Set("cloud="+filepath.Join(dir, "missing")))
+	_, err = o.buildSecret("velero")
+	assert.Error(t, err)
+}
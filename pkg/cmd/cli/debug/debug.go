@@ -0,0 +1,355 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/heptio/velero/pkg/buildinfo"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/cli/serverstatus"
+	clientset "github.com/heptio/velero/pkg/generated/clientset/versioned"
+)
+
+// podSelector selects the pods of the Velero deployment and restic daemonset
+// that `velero install` creates.
+const podSelector = "component=velero"
+
+// NewCommand returns the debug command, which collects information about a
+// Velero installation into a tarball for attaching to bug reports.
+func NewCommand(f client.Factory) *cobra.Command {
+	o := NewOptions()
+
+	c := &cobra.Command{
+		Use:   "debug",
+		Short: "Collect information about the Velero installation for a bug report",
+		Long: `Collect information about the Velero installation into a gzipped tarball that can be attached to a bug report.
+
+The tarball contains the client and server versions, the Velero deployments and daemonsets and the logs of their
+pods, the backups, restores, schedules, storage and snapshot locations, and pod volume backups and restores in the
+Velero namespace, and the namespace's recent events. Secrets aren't collected, but the tarball should be reviewed
+before it's shared, since locations' config and the logs may contain information about your environment.`,
+		Example: `	# collect the last day's logs and events into velero-debug-<timestamp>.tar.gz
+	velero debug
+
+	# collect the last hour's logs and events into debug.tar.gz
+	velero debug --since 1h --output debug.tar.gz`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete())
+			cmd.CheckError(o.Validate())
+
+			kubeClient, err := f.KubeClient()
+			cmd.CheckError(err)
+			veleroClient, err := f.Client()
+			cmd.CheckError(err)
+
+			col := &collector{
+				kubeClient:   kubeClient,
+				veleroClient: veleroClient,
+				namespace:    f.Namespace(),
+				since:        o.Since,
+				now:          time.Now(),
+				serverStatusGetter: &serverstatus.DefaultServerStatusGetter{
+					Namespace: f.Namespace(),
+					Timeout:   o.Timeout,
+				},
+			}
+			col.podLogs = col.streamPodLogs
+
+			cmd.CheckError(o.Run(col))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+// Options are the options of the debug command.
+type Options struct {
+	Output  string
+	Since   time.Duration
+	Timeout time.Duration
+}
+
+// NewOptions returns the default options of the debug command.
+func NewOptions() *Options {
+	return &Options{
+		Since:   24 * time.Hour,
+		Timeout: 5 * time.Second,
+	}
+}
+
+// BindFlags binds the options to command-line flags.
+func (o *Options) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.Output, "output", "o", o.Output, "path of the tarball to write. Defaults to velero-debug-<timestamp>.tar.gz in the current directory.")
+	flags.DurationVar(&o.Since, "since", o.Since, "only collect the logs and events from this far back")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "maximum time to wait for the server version to be reported")
+}
+
+// Complete fills in the default output path.
+func (o *Options) Complete() error {
+	if o.Output == "" {
+		o.Output = fmt.Sprintf("velero-debug-%s.tar.gz", time.Now().Format("20060102150405"))
+	}
+	return nil
+}
+
+// Validate returns an error if the options are invalid.
+func (o *Options) Validate() error {
+	if o.Since <= 0 {
+		return errors.New("--since must be positive")
+	}
+	return nil
+}
+
+// Run collects the bundle into the output file.
+func (o *Options) Run(col *collector) error {
+	file, err := os.OpenFile(o.Output, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	prefix := strings.TrimSuffix(path.Base(o.Output), ".tar.gz")
+	if err := col.writeBundle(file, prefix); err != nil {
+		file.Close()
+		os.Remove(o.Output)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Printf("Debug information was written to %s.\n", o.Output)
+	if len(col.errs) > 0 {
+		fmt.Printf("Some information couldn't be collected, see errors.txt in the tarball for details.\n")
+	}
+	return nil
+}
+
+// collector collects the files of a debug bundle. Errors collecting
+// a file are recorded in errs, and written to the bundle's errors.txt,
+// rather than stopping the collection.
+type collector struct {
+	kubeClient         kubernetes.Interface
+	veleroClient       clientset.Interface
+	namespace          string
+	since              time.Duration
+	now                time.Time
+	serverStatusGetter serverstatus.ServerStatusGetter
+	// podLogs returns the logs of a container of a pod in the namespace.
+	podLogs func(pod, container string) (io.ReadCloser, error)
+
+	errs []string
+}
+
+// writeBundle writes the bundle to w as a gzipped tarball whose files are
+// in the prefix directory.
+func (c *collector) writeBundle(w io.Writer, prefix string) error {
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	add := func(name string, data []byte) error {
+		header := &tar.Header{
+			Name:     path.Join(prefix, name),
+			Mode:     0644,
+			Typeflag: tar.TypeReg,
+			Size:     int64(len(data)),
+			ModTime:  c.now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.WithStack(err)
+		}
+		_, err := tw.Write(data)
+		return errors.WithStack(err)
+	}
+
+	for _, file := range c.collect() {
+		if err := add(file.name, file.data); err != nil {
+			return err
+		}
+	}
+
+	if len(c.errs) > 0 {
+		if err := add("errors.txt", []byte(strings.Join(c.errs, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(gzw.Close())
+}
+
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+func (c *collector) collect() []bundleFile {
+	var files []bundleFile
+	addYAML := func(name string, obj interface{}, err error) {
+		if err != nil {
+			c.errorf("error getting %s: %v", name, err)
+			return
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			c.errorf("error encoding %s: %v", name, err)
+			return
+		}
+		files = append(files, bundleFile{name: name, data: data})
+	}
+
+	files = append(files, bundleFile{name: "version.txt", data: c.version()})
+
+	listOptions := metav1.ListOptions{}
+	velero := c.veleroClient.VeleroV1()
+
+	backups, err := velero.Backups(c.namespace).List(listOptions)
+	addYAML("resources/backups.yaml", backups, err)
+	restores, err := velero.Restores(c.namespace).List(listOptions)
+	addYAML("resources/restores.yaml", restores, err)
+	schedules, err := velero.Schedules(c.namespace).List(listOptions)
+	addYAML("resources/schedules.yaml", schedules, err)
+	backupLocations, err := velero.BackupStorageLocations(c.namespace).List(listOptions)
+	addYAML("resources/backupstoragelocations.yaml", backupLocations, err)
+	snapshotLocations, err := velero.VolumeSnapshotLocations(c.namespace).List(listOptions)
+	addYAML("resources/volumesnapshotlocations.yaml", snapshotLocations, err)
+	podVolumeBackups, err := velero.PodVolumeBackups(c.namespace).List(listOptions)
+	addYAML("resources/podvolumebackups.yaml", podVolumeBackups, err)
+	podVolumeRestores, err := velero.PodVolumeRestores(c.namespace).List(listOptions)
+	addYAML("resources/podvolumerestores.yaml", podVolumeRestores, err)
+
+	deployments, err := c.kubeClient.AppsV1().Deployments(c.namespace).List(listOptions)
+	addYAML("resources/deployments.yaml", deployments, err)
+	daemonSets, err := c.kubeClient.AppsV1().DaemonSets(c.namespace).List(listOptions)
+	addYAML("resources/daemonsets.yaml", daemonSets, err)
+
+	pods, err := c.kubeClient.CoreV1().Pods(c.namespace).List(metav1.ListOptions{LabelSelector: podSelector})
+	addYAML("resources/pods.yaml", pods, err)
+	if err == nil {
+		files = append(files, c.logs(pods.Items)...)
+	}
+
+	events, err := c.kubeClient.CoreV1().Events(c.namespace).List(listOptions)
+	if err == nil {
+		events.Items = c.recentEvents(events.Items)
+	}
+	addYAML("events.yaml", events, err)
+
+	return files
+}
+
+func (c *collector) errorf(format string, args ...interface{}) {
+	c.errs = append(c.errs, fmt.Sprintf(format, args...))
+}
+
+// version returns the client, server and Kubernetes versions.
+func (c *collector) version() []byte {
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, "Client:")
+	fmt.Fprintf(buf, "\tVersion: %s\n", buildinfo.Version)
+	fmt.Fprintf(buf, "\tGit commit: %s\n", buildinfo.FormattedGitSHA())
+
+	fmt.Fprintln(buf, "Server:")
+	if serverStatus, err := c.serverStatusGetter.GetServerStatus(c.veleroClient.VeleroV1()); err != nil {
+		fmt.Fprintf(buf, "\t<error getting server version: %v>\n", err)
+		c.errorf("error getting server version: %v", err)
+	} else {
+		fmt.Fprintf(buf, "\tVersion: %s\n", serverStatus.Status.ServerVersion)
+	}
+
+	fmt.Fprintln(buf, "Kubernetes:")
+	if kubeVersion, err := c.kubeClient.Discovery().ServerVersion(); err != nil {
+		fmt.Fprintf(buf, "\t<error getting Kubernetes version: %v>\n", err)
+		c.errorf("error getting Kubernetes version: %v", err)
+	} else {
+		fmt.Fprintf(buf, "\tVersion: %s\n", kubeVersion.GitVersion)
+	}
+
+	return buf.Bytes()
+}
+
+// logs returns the logs of each container of the pods, since c.since.
+func (c *collector) logs(pods []corev1api.Pod) []bundleFile {
+	var files []bundleFile
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			name := fmt.Sprintf("logs/%s/%s.log", pod.Name, container.Name)
+
+			logs, err := c.podLogs(pod.Name, container.Name)
+			if err != nil {
+				c.errorf("error getting %s: %v", name, err)
+				continue
+			}
+			data, err := ioutil.ReadAll(logs)
+			logs.Close()
+			if err != nil {
+				c.errorf("error reading %s: %v", name, err)
+				continue
+			}
+
+			files = append(files, bundleFile{name: name, data: data})
+		}
+	}
+	return files
+}
+
+func (c *collector) streamPodLogs(pod, container string) (io.ReadCloser, error) {
+	sinceSeconds := int64(c.since.Seconds())
+	req := c.kubeClient.CoreV1().Pods(c.namespace).GetLogs(pod, &corev1api.PodLogOptions{
+		Container:    container,
+		SinceSeconds: &sinceSeconds,
+	})
+	return req.Stream()
+}
+
+// recentEvents returns the events that last happened since c.since.
+func (c *collector) recentEvents(events []corev1api.Event) []corev1api.Event {
+	var recent []corev1api.Event
+	for _, event := range events {
+		last := event.LastTimestamp.Time
+		if last.IsZero() {
+			last = event.EventTime.Time
+		}
+		if c.now.Sub(last) <= c.since {
+			recent = append(recent, event)
+		}
+	}
+	return recent
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+)
+
+type fakeServerStatusGetter struct{}
+
+func (fakeServerStatusGetter) GetServerStatus(velerov1client.ServerStatusRequestsGetter) (*velerov1api.ServerStatusRequest, error) {
+	return &velerov1api.ServerStatusRequest{Status: velerov1api.ServerStatusRequestStatus{ServerVersion: "v1.2.3"}}, nil
+}
+
+func TestWriteBundle(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	pod := func(name string, containers ...string) *corev1api.Pod {
+		p := &corev1api.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: name, Labels: map[string]string{"component": "velero"}},
+		}
+		for _, container := range containers {
+			p.Spec.Containers = append(p.Spec.Containers, corev1api.Container{Name: container})
+		}
+		return p
+	}
+	event := func(name string, last time.Time) *corev1api.Event {
+		return &corev1api.Event{
+			ObjectMeta:    metav1.ObjectMeta{Namespace: "velero", Name: name},
+			LastTimestamp: metav1.NewTime(last),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		pod("velero-abc", "velero"),
+		pod("restic-xyz", "restic"),
+		&corev1api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "unrelated"}},
+		event("recent", now.Add(-time.Hour)),
+		event("old", now.Add(-48*time.Hour)),
+	)
+	veleroClient := fake.NewSimpleClientset(
+		&velerov1api.Backup{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "backup-1"}},
+	)
+
+	c := &collector{
+		kubeClient:         kubeClient,
+		veleroClient:       veleroClient,
+		namespace:          "velero",
+		since:              24 * time.Hour,
+		now:                now,
+		serverStatusGetter: fakeServerStatusGetter{},
+		podLogs: func(pod, container string) (io.ReadCloser, error) {
+			if pod == "restic-xyz" {
+				return nil, errors.New("container not started")
+			}
+			return ioutil.NopCloser(strings.NewReader("level=info msg=hello\n")), nil
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, c.writeBundle(buf, "velero-debug"))
+
+	files := map[string]string{}
+	gzr, err := gzip.NewReader(buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+
+	for _, name := range []string{
+		"version.txt",
+		"resources/backups.yaml",
+		"resources/restores.yaml",
+		"resources/schedules.yaml",
+		"resources/backupstoragelocations.yaml",
+		"resources/volumesnapshotlocations.yaml",
+		"resources/podvolumebackups.yaml",
+		"resources/podvolumerestores.yaml",
+		"resources/deployments.yaml",
+		"resources/daemonsets.yaml",
+		"resources/pods.yaml",
+		"logs/velero-abc/velero.log",
+		"events.yaml",
+		"errors.txt",
+	} {
+		assert.Contains(t, files, "velero-debug/"+name)
+	}
+
+	assert.Contains(t, files["velero-debug/version.txt"], "Server:\n\tVersion: v1.2.3\n")
+	assert.Contains(t, files["velero-debug/resources/backups.yaml"], "name: backup-1")
+	assert.NotContains(t, files["velero-debug/resources/pods.yaml"], "unrelated")
+	assert.Equal(t, "level=info msg=hello\n", files["velero-debug/logs/velero-abc/velero.log"])
+	assert.Contains(t, files["velero-debug/events.yaml"], "name: recent")
+	assert.NotContains(t, files["velero-debug/events.yaml"], "name: old")
+	assert.Equal(t, "error getting logs/restic-xyz/restic.log: container not started\n", files["velero-debug/errors.txt"])
+}
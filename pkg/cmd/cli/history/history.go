@@ -0,0 +1,121 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/util/output"
+)
+
+func NewCommand(f client.Factory) *cobra.Command {
+	var (
+		months int
+		kind   string
+	)
+
+	c := &cobra.Command{
+		Use:   "history",
+		Short: "Show the history of backups and restores",
+		Long:  "Show the backups and restores that finished in recent months, including ones that have since been deleted, from the operation histories that the Velero server keeps",
+		Args:  cobra.NoArgs,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(output.ValidateFlags(c))
+
+			operationKind, err := parseKind(kind)
+			cmd.CheckError(err)
+
+			veleroClient, err := f.Client()
+			cmd.CheckError(err)
+
+			histories, err := veleroClient.VeleroV1().OperationHistories(f.Namespace()).List(metav1.ListOptions{})
+			cmd.CheckError(err)
+
+			_, err = output.PrintWithFormat(c, filterHistories(histories, months, operationKind, time.Now()))
+			cmd.CheckError(err)
+		},
+	}
+
+	c.Flags().IntVar(&months, "months", months, "how many months of history to show, including the current month. If zero, all of the history the server keeps is shown.")
+	c.Flags().StringVar(&kind, "kind", kind, "only show operations of this kind: backup or restore")
+
+	output.BindFlags(c.Flags())
+
+	return c
+}
+
+func parseKind(kind string) (velerov1api.OperationKind, error) {
+	switch strings.ToLower(kind) {
+	case "":
+		return "", nil
+	case "backup", "backups":
+		return velerov1api.OperationKindBackup, nil
+	case "restore", "restores":
+		return velerov1api.OperationKindRestore, nil
+	default:
+		return "", errors.Errorf("invalid kind %q, must be backup or restore", kind)
+	}
+}
+
+// filterHistories returns the histories of the last months months, oldest
+// first, with only the operations of the given kind. If months is zero or
+// kind is empty, they aren't filtered by them.
+func filterHistories(histories *velerov1api.OperationHistoryList, months int, kind velerov1api.OperationKind, now time.Time) *velerov1api.OperationHistoryList {
+	var oldestMonth string
+	if months > 0 {
+		now = now.UTC()
+		oldestMonth = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -(months - 1), 0).Format("2006-01")
+	}
+
+	res := &velerov1api.OperationHistoryList{
+		TypeMeta: histories.TypeMeta,
+		ListMeta: histories.ListMeta,
+	}
+
+	for _, history := range histories.Items {
+		if history.Spec.Month < oldestMonth {
+			continue
+		}
+
+		filtered := history.DeepCopy()
+		if kind != "" {
+			filtered.Status.Operations = nil
+			for _, op := range history.Status.Operations {
+				if op.Kind == kind {
+					filtered.Status.Operations = append(filtered.Status.Operations, op)
+				}
+			}
+		}
+
+		res.Items = append(res.Items, *filtered)
+	}
+
+	sort.Slice(res.Items, func(i, j int) bool {
+		return res.Items[i].Spec.Month < res.Items[j].Spec.Month
+	})
+
+	return res
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+func TestFilterHistories(t *testing.T) {
+	history := func(month string, kinds ...velerov1api.OperationKind) velerov1api.OperationHistory {
+		res := velerov1api.OperationHistory{
+			ObjectMeta: metav1.ObjectMeta{Name: "operations-" + month},
+			Spec:       velerov1api.OperationHistorySpec{Month: month},
+		}
+		for _, kind := range kinds {
+			res.Status.Operations = append(res.Status.Operations, velerov1api.OperationRecord{Kind: kind})
+		}
+		return res
+	}
+
+	histories := &velerov1api.OperationHistoryList{
+		Items: []velerov1api.OperationHistory{
+			history("2019-10", velerov1api.OperationKindBackup, velerov1api.OperationKindRestore),
+			history("2019-08", velerov1api.OperationKindBackup),
+			history("2019-09", velerov1api.OperationKindRestore),
+		},
+	}
+	now := time.Date(2019, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		months   int
+		kind     velerov1api.OperationKind
+		expected []velerov1api.OperationHistory
+	}{
+		{
+			name:     "all histories are shown oldest first",
+			expected: []velerov1api.OperationHistory{histories.Items[1], histories.Items[2], histories.Items[0]},
+		},
+		{
+			name:     "only the last months are shown",
+			months:   2,
+			expected: []velerov1api.OperationHistory{histories.Items[2], histories.Items[0]},
+		},
+		{
+			name:   "only operations of the kind are shown",
+			months: 2,
+			kind:   velerov1api.OperationKindBackup,
+			expected: []velerov1api.OperationHistory{
+				history("2019-09"),
+				history("2019-10", velerov1api.OperationKindBackup),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := filterHistories(histories, test.months, test.kind, now)
+			assert.Equal(t, test.expected, res.Items)
+		})
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	kind, err := parseKind("Restore")
+	assert.NoError(t, err)
+	assert.Equal(t, velerov1api.OperationKindRestore, kind)
+
+	_, err = parseKind("schedule")
+	assert.EqualError(t, err, `invalid kind "schedule", must be backup or restore`)
+}
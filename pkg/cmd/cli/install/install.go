@@ -34,33 +34,37 @@ import (
 	"github.com/heptio/velero/pkg/cmd/util/output"
 	"github.com/heptio/velero/pkg/install"
 	kubeutil "github.com/heptio/velero/pkg/util/kube"
+	"github.com/heptio/velero/pkg/util/logging"
 )
 
 // InstallOptions collects all the options for installing Velero into a Kubernetes cluster.
 type InstallOptions struct {
-	Namespace            string
-	Image                string
-	BucketName           string
-	Prefix               string
-	ProviderName         string
-	PodAnnotations       flag.Map
-	VeleroPodCPURequest  string
-	VeleroPodMemRequest  string
-	VeleroPodCPULimit    string
-	VeleroPodMemLimit    string
-	ResticPodCPURequest  string
-	ResticPodMemRequest  string
-	ResticPodCPULimit    string
-	ResticPodMemLimit    string
-	RestoreOnly          bool
-	SecretFile           string
-	NoSecret             bool
-	DryRun               bool
-	BackupStorageConfig  flag.Map
-	VolumeSnapshotConfig flag.Map
-	UseRestic            bool
-	Wait                 bool
-	UseVolumeSnapshots   bool
+	Namespace                 string
+	Image                     string
+	BucketName                string
+	Prefix                    string
+	ProviderName              string
+	PodAnnotations            flag.Map
+	ServiceAccountAnnotations flag.Map
+	VeleroPodCPURequest       string
+	VeleroPodMemRequest       string
+	VeleroPodCPULimit         string
+	VeleroPodMemLimit         string
+	ResticPodCPURequest       string
+	ResticPodMemRequest       string
+	ResticPodCPULimit         string
+	ResticPodMemLimit         string
+	RestoreOnly               bool
+	SecretFile                string
+	NoSecret                  bool
+	DryRun                    bool
+	BackupStorageConfig       flag.Map
+	VolumeSnapshotConfig      flag.Map
+	UseRestic                 bool
+	ResticWindowsImage        string
+	Wait                      bool
+	UseVolumeSnapshots        bool
+	LogFormat                 *logging.FormatFlag
 }
 
 // BindFlags adds command line values to the options struct.
@@ -72,6 +76,7 @@ func (o *InstallOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&o.Image, "image", o.Image, "image to use for the Velero and restic server pods. Optional.")
 	flags.StringVar(&o.Prefix, "prefix", o.Prefix, "prefix under which all Velero data should be stored within the bucket. Optional.")
 	flags.Var(&o.PodAnnotations, "pod-annotations", "annotations to add to the Velero and restic pods. Optional. Format is key1=value1,key2=value2")
+	flags.Var(&o.ServiceAccountAnnotations, "service-account-annotations", "annotations to add to the Velero service account, e.g. to give it an IAM role (IAM Roles for Service Accounts) or a GCP service account (GKE Workload Identity). Optional. Format is key1=value1,key2=value2")
 	flags.StringVar(&o.VeleroPodCPURequest, "velero-pod-cpu-request", o.VeleroPodCPURequest, `CPU request for Velero pod. A value of "0" is treated as unbounded. Optional.`)
 	flags.StringVar(&o.VeleroPodMemRequest, "velero-pod-mem-request", o.VeleroPodMemRequest, `memory request for Velero pod. A value of "0" is treated as unbounded. Optional.`)
 	flags.StringVar(&o.VeleroPodCPULimit, "velero-pod-cpu-limit", o.VeleroPodCPULimit, `CPU limit for Velero pod. A value of "0" is treated as unbounded. Optional.`)
@@ -86,25 +91,29 @@ func (o *InstallOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.BoolVar(&o.RestoreOnly, "restore-only", o.RestoreOnly, "run the server in restore-only mode. Optional.")
 	flags.BoolVar(&o.DryRun, "dry-run", o.DryRun, "generate resources, but don't send them to the cluster. Use with -o. Optional.")
 	flags.BoolVar(&o.UseRestic, "use-restic", o.UseRestic, "create restic deployment. Optional.")
+	flags.StringVar(&o.ResticWindowsImage, "restic-windows-image", o.ResticWindowsImage, "Windows image of Velero to run restic on Windows nodes with. If set, a restic daemonset is also created for Windows nodes. Requires --use-restic. Optional.")
 	flags.BoolVar(&o.Wait, "wait", o.Wait, "wait for Velero deployment to be ready. Optional.")
+	flags.Var(o.LogFormat, "log-format", fmt.Sprintf("the format for the log output of the Velero and restic server pods. Valid values are %s. Optional.", strings.Join(o.LogFormat.AllowedValues(), ", ")))
 }
 
 // NewInstallOptions instantiates a new, default InstallOptions struct.
 func NewInstallOptions() *InstallOptions {
 	return &InstallOptions{
-		Namespace:            velerov1api.DefaultNamespace,
-		Image:                install.DefaultImage,
-		BackupStorageConfig:  flag.NewMap(),
-		VolumeSnapshotConfig: flag.NewMap(),
-		PodAnnotations:       flag.NewMap(),
-		VeleroPodCPURequest:  install.DefaultVeleroPodCPURequest,
-		VeleroPodMemRequest:  install.DefaultVeleroPodMemRequest,
-		VeleroPodCPULimit:    install.DefaultVeleroPodCPULimit,
-		VeleroPodMemLimit:    install.DefaultVeleroPodMemLimit,
-		ResticPodCPURequest:  install.DefaultResticPodCPURequest,
-		ResticPodMemRequest:  install.DefaultResticPodMemRequest,
-		ResticPodCPULimit:    install.DefaultResticPodCPULimit,
-		ResticPodMemLimit:    install.DefaultResticPodMemLimit,
+		Namespace:                 velerov1api.DefaultNamespace,
+		Image:                     install.DefaultImage,
+		BackupStorageConfig:       flag.NewMap(),
+		VolumeSnapshotConfig:      flag.NewMap(),
+		PodAnnotations:            flag.NewMap(),
+		ServiceAccountAnnotations: flag.NewMap(),
+		LogFormat:                 logging.NewFormatFlag(),
+		VeleroPodCPURequest:       install.DefaultVeleroPodCPURequest,
+		VeleroPodMemRequest:       install.DefaultVeleroPodMemRequest,
+		VeleroPodCPULimit:         install.DefaultVeleroPodCPULimit,
+		VeleroPodMemLimit:         install.DefaultVeleroPodMemLimit,
+		ResticPodCPURequest:       install.DefaultResticPodCPURequest,
+		ResticPodMemRequest:       install.DefaultResticPodMemRequest,
+		ResticPodCPULimit:         install.DefaultResticPodCPULimit,
+		ResticPodMemLimit:         install.DefaultResticPodMemLimit,
 		// Default to creating a VSL unless we're told otherwise
 		UseVolumeSnapshots: true,
 	}
@@ -133,20 +142,23 @@ func (o *InstallOptions) AsVeleroOptions() (*install.VeleroOptions, error) {
 	}
 
 	return &install.VeleroOptions{
-		Namespace:          o.Namespace,
-		Image:              o.Image,
-		ProviderName:       o.ProviderName,
-		Bucket:             o.BucketName,
-		Prefix:             o.Prefix,
-		PodAnnotations:     o.PodAnnotations.Data(),
-		VeleroPodResources: veleroPodResources,
-		ResticPodResources: resticPodResources,
-		SecretData:         secretData,
-		RestoreOnly:        o.RestoreOnly,
-		UseRestic:          o.UseRestic,
-		UseVolumeSnapshots: o.UseVolumeSnapshots,
-		BSLConfig:          o.BackupStorageConfig.Data(),
-		VSLConfig:          o.VolumeSnapshotConfig.Data(),
+		Namespace:                 o.Namespace,
+		Image:                     o.Image,
+		ProviderName:              o.ProviderName,
+		Bucket:                    o.BucketName,
+		Prefix:                    o.Prefix,
+		PodAnnotations:            o.PodAnnotations.Data(),
+		ServiceAccountAnnotations: o.ServiceAccountAnnotations.Data(),
+		VeleroPodResources:        veleroPodResources,
+		ResticPodResources:        resticPodResources,
+		SecretData:                secretData,
+		RestoreOnly:               o.RestoreOnly,
+		UseRestic:                 o.UseRestic,
+		ResticWindowsImage:        o.ResticWindowsImage,
+		UseVolumeSnapshots:        o.UseVolumeSnapshots,
+		BSLConfig:                 o.BackupStorageConfig.Data(),
+		VSLConfig:                 o.VolumeSnapshotConfig.Data(),
+		LogFormat:                 o.LogFormat.String(),
 	}, nil
 }
 
@@ -186,6 +198,10 @@ This is useful as a starting point for more customized installations.
 
 	# velero install --bucket backups --provider aws --backup-location-config region=us-west-2 --snapshot-location-config region=us-west-2 --no-secret --pod-annotations iam.amazonaws.com/role=arn:aws:iam::<AWS_ACCOUNT_ID>:role/<VELERO_ROLE_NAME>
 
+	# velero install --bucket backups --provider aws --backup-location-config region=us-west-2 --snapshot-location-config region=us-west-2 --no-secret --service-account-annotations eks.amazonaws.com/role-arn=arn:aws:iam::<AWS_ACCOUNT_ID>:role/<VELERO_ROLE_NAME>
+
+	# velero install --bucket gcp-backups --provider gcp --no-secret --service-account-annotations iam.gke.io/gcp-service-account=<VELERO_SERVICE_ACCOUNT>@<PROJECT_ID>.iam.gserviceaccount.com
+
 	# velero install --bucket gcp-backups --provider gcp --secret-file ./gcp-creds.json --velero-pod-cpu-request=1000m --velero-pod-cpu-limit=5000m --velero-pod-mem-request=512Mi --velero-pod-mem-limit=1024Mi
 
 	# velero install --bucket gcp-backups --provider gcp --secret-file ./gcp-creds.json --restic-pod-cpu-request=1000m --restic-pod-cpu-limit=5000m --restic-pod-mem-request=512Mi --restic-pod-mem-limit=1024Mi
@@ -250,7 +266,7 @@ func (o *InstallOptions) Run(c *cobra.Command, f client.Factory) error {
 	return nil
 }
 
-//Complete completes options for a command.
+// Complete completes options for a command.
 func (o *InstallOptions) Complete(args []string, f client.Factory) error {
 	o.Namespace = f.Namespace()
 	return nil
@@ -284,5 +300,9 @@ func (o *InstallOptions) Validate(c *cobra.Command, args []string, f client.Fact
 		return errors.New("Cannot use both --secret-file and --no-secret")
 	}
 
+	if o.ResticWindowsImage != "" && !o.UseRestic {
+		return errors.New("--restic-windows-image requires --use-restic")
+	}
+
 	return nil
 }
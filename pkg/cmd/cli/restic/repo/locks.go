@@ -0,0 +1,90 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/podvolume"
+)
+
+// NewLocksCommand creates a new command that shows the locks held on
+// restic repositories.
+func NewLocksCommand(f client.Factory, use string) *cobra.Command {
+	c := &cobra.Command{
+		Use:   use + " NAME [NAME...]",
+		Short: "Show the locks held on restic repositories",
+		Long: `Show the locks that were held on restic repositories when the Velero server last checked them,
+after removing stale locks. Locks that are held for a long time block maintenance and backups, and
+can be removed with 'velero restic repo unlock'.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			veleroClient, err := f.Client()
+			cmd.CheckError(err)
+
+			var repos []*velerov1api.ResticRepository
+			for _, name := range args {
+				repo, err := veleroClient.VeleroV1().ResticRepositories(f.Namespace()).Get(name, metav1.GetOptions{})
+				cmd.CheckError(err)
+				repos = append(repos, repo)
+			}
+
+			cmd.CheckError(printLocks(os.Stdout, repos, time.Now()))
+		},
+	}
+
+	return c
+}
+
+func printLocks(w io.Writer, repos []*velerov1api.ResticRepository, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "REPOSITORY\tLOCK\tTYPE\tHOST\tPID\tAGE\tSTALE")
+	for _, repo := range repos {
+		for _, lock := range repo.Status.Locks {
+			lockType := "shared"
+			if lock.Exclusive {
+				lockType = "exclusive"
+			}
+
+			age := now.Sub(lock.Time.Time)
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%t\n",
+				repo.Name,
+				lock.ID,
+				lockType,
+				lock.Hostname,
+				lock.PID,
+				duration.ShortHumanDuration(age),
+				age > podvolume.StaleLockTimeout,
+			)
+		}
+	}
+
+	return tw.Flush()
+}
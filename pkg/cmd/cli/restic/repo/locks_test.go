@@ -0,0 +1,51 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+func TestPrintLocks(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := &velerov1api.ResticRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "default-kgvs2"},
+		Status: velerov1api.ResticRepositoryStatus{
+			Locks: []velerov1api.ResticRepositoryLock{
+				{ID: "lock-1", Exclusive: true, Hostname: "velero-abc", PID: 42, Time: metav1.NewTime(now.Add(-2 * time.Hour))},
+				{ID: "lock-2", Hostname: "restic-def", PID: 7, Time: metav1.NewTime(now.Add(-time.Minute))},
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, printLocks(buf, []*velerov1api.ResticRepository{repo}, now))
+
+	assert.Equal(t, `REPOSITORY     LOCK    TYPE       HOST        PID  AGE  STALE
+default-kgvs2  lock-1  exclusive  velero-abc  42   2h   true
+default-kgvs2  lock-2  shared     restic-def  7    1m   false
+`, buf.String())
+}
@@ -31,6 +31,8 @@ func NewRepositoryCommand(f client.Factory) *cobra.Command {
 
 	c.AddCommand(
 		NewGetCommand(f, "get"),
+		NewLocksCommand(f, "locks"),
+		NewUnlockCommand(f, "unlock"),
 	)
 
 	return c
@@ -0,0 +1,119 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/types"
+	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/cli"
+)
+
+// NewUnlockCommand creates a new command that requests that the Velero
+// server unlocks restic repositories.
+func NewUnlockCommand(f client.Factory, use string) *cobra.Command {
+	o := NewUnlockOptions()
+
+	c := &cobra.Command{
+		Use:   use + " NAME [NAME...]",
+		Short: "Remove locks from restic repositories",
+		Long: `Remove locks from restic repositories. By default, only the locks that restic considers stale are
+removed, which the Velero server also does before maintaining a repository. Use --remove-all to also
+remove the locks held by restic processes that are still running, for example ones that are stuck.`,
+		Example: `  # remove the stale locks from the restic repository "default-kgvs2"
+  velero restic repo unlock default-kgvs2
+
+  # remove all of the locks from the restic repository "default-kgvs2"
+  velero restic repo unlock default-kgvs2 --remove-all`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Run(f, args))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+// UnlockOptions contains the options for the unlock command.
+type UnlockOptions struct {
+	RemoveAll bool
+	Confirm   bool
+}
+
+// NewUnlockOptions returns a new UnlockOptions.
+func NewUnlockOptions() *UnlockOptions {
+	return &UnlockOptions{}
+}
+
+// BindFlags binds the options to the given flag set.
+func (o *UnlockOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.RemoveAll, "remove-all", o.RemoveAll, "remove all locks, including the ones held by running restic processes")
+	flags.BoolVar(&o.Confirm, "confirm", o.Confirm, "confirm removing all locks")
+}
+
+// Run requests that the Velero server unlocks each of the named
+// repositories, by annotating them.
+func (o *UnlockOptions) Run(f client.Factory, names []string) error {
+	mode := velerov1api.ResticRepositoryUnlockModeStale
+	if o.RemoveAll {
+		fmt.Println("Removing the locks held by running restic processes can corrupt the repository if those processes are still writing to it.")
+		if !o.Confirm && !cli.GetConfirmation() {
+			// Don't do anything unless we get confirmation
+			return nil
+		}
+		mode = velerov1api.ResticRepositoryUnlockModeAll
+	}
+
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				velerov1api.ResticRepositoryUnlockAnnotation: string(mode),
+			},
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var errs []error
+	for _, name := range names {
+		if _, err := veleroClient.VeleroV1().ResticRepositories(f.Namespace()).Patch(name, types.MergePatchType, patch); err != nil {
+			errs = append(errs, errors.WithStack(err))
+			continue
+		}
+
+		fmt.Printf("Request to unlock restic repository %q submitted successfully.\nRun `velero restic repo locks %s` to see the locks that are left once it has been processed.\n", name, name)
+	}
+
+	return kubeerrs.NewAggregate(errs)
+}
@@ -19,13 +19,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	kubeinformers "k8s.io/client-go/informers"
@@ -40,14 +45,45 @@ import (
 	"github.com/heptio/velero/pkg/controller"
 	clientset "github.com/heptio/velero/pkg/generated/clientset/versioned"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/util/filesystem"
 	"github.com/heptio/velero/pkg/util/logging"
 )
 
+const (
+	defaultPodVolumeBackupRetries      = 2
+	defaultPodVolumeBackupRetryBackoff = 30 * time.Second
+	defaultPodVolumeBackupConcurrency  = 1
+	defaultPodVolumeBackupMemory       = "512Mi"
+
+	// concurrencyConfigMap is the name of the optional ConfigMap, in the
+	// Velero namespace, that sets the pod volume backup concurrency of
+	// individual nodes. Its keys are node names, and its values are
+	// how many pod volume backups to run at once on those nodes.
+	concurrencyConfigMap = "restic-pod-volume-backup-concurrency"
+
+	// memoryLimitEnvVar is set to the restic container's memory limit by
+	// the downward API, or to the node's allocatable memory if the
+	// container doesn't have a limit.
+	memoryLimitEnvVar = "VELERO_MEMORY_LIMIT"
+)
+
+type resticServerConfig struct {
+	podVolumeBackupRetries      int
+	podVolumeBackupRetryBackoff time.Duration
+	podVolumeBackupConcurrency  int
+	podVolumeBackupMemory       string
+}
+
 func NewServerCommand(f client.Factory) *cobra.Command {
 	logLevelFlag := logging.LogLevelFlag(logrus.InfoLevel)
 	formatFlag := logging.NewFormatFlag()
+	config := resticServerConfig{
+		podVolumeBackupRetries:      defaultPodVolumeBackupRetries,
+		podVolumeBackupRetryBackoff: defaultPodVolumeBackupRetryBackoff,
+		podVolumeBackupConcurrency:  defaultPodVolumeBackupConcurrency,
+		podVolumeBackupMemory:       defaultPodVolumeBackupMemory,
+	}
 
 	command := &cobra.Command{
 		Use:    "server",
@@ -62,7 +98,7 @@ func NewServerCommand(f client.Factory) *cobra.Command {
 			logger.Infof("Starting Velero restic server %s (%s)", buildinfo.Version, buildinfo.FormattedGitSHA())
 
 			f.SetBasename(fmt.Sprintf("%s-%s", c.Parent().Name(), c.Name()))
-			s, err := newResticServer(logger, f)
+			s, err := newResticServer(logger, f, config)
 			cmd.CheckError(err)
 
 			s.run()
@@ -71,6 +107,10 @@ func NewServerCommand(f client.Factory) *cobra.Command {
 
 	command.Flags().Var(logLevelFlag, "log-level", fmt.Sprintf("the level at which to log. Valid values are %s.", strings.Join(logLevelFlag.AllowedValues(), ", ")))
 	command.Flags().Var(formatFlag, "log-format", fmt.Sprintf("the format for log output. Valid values are %s.", strings.Join(formatFlag.AllowedValues(), ", ")))
+	command.Flags().IntVar(&config.podVolumeBackupRetries, "pod-volume-backup-retries", config.podVolumeBackupRetries, "how many times to retry backing up a pod volume with restic after a transient failure, such as a network error or a locked repository")
+	command.Flags().DurationVar(&config.podVolumeBackupRetryBackoff, "pod-volume-backup-retry-backoff", config.podVolumeBackupRetryBackoff, "how long to wait before the first retry of a pod volume backup. The wait doubles after each retry")
+	command.Flags().IntVar(&config.podVolumeBackupConcurrency, "pod-volume-backup-concurrency", config.podVolumeBackupConcurrency, fmt.Sprintf("how many pod volume backups to run at once on each node, unless the node has an entry in the %s ConfigMap", concurrencyConfigMap))
+	command.Flags().StringVar(&config.podVolumeBackupMemory, "pod-volume-backup-memory", config.podVolumeBackupMemory, "how much memory each pod volume backup is expected to use. The number of pod volume backups run at once is limited to how many fit in the restic container's memory limit. If zero, it isn't limited.")
 
 	return command
 }
@@ -83,12 +123,14 @@ type resticServer struct {
 	podInformer           cache.SharedIndexInformer
 	secretInformer        cache.SharedIndexInformer
 	logger                logrus.FieldLogger
+	namespace             string
 	ctx                   context.Context
 	cancelFunc            context.CancelFunc
 	fileSystem            filesystem.Interface
+	config                resticServerConfig
 }
 
-func newResticServer(logger logrus.FieldLogger, factory client.Factory) (*resticServer, error) {
+func newResticServer(logger logrus.FieldLogger, factory client.Factory, config resticServerConfig) (*resticServer, error) {
 
 	kubeClient, err := factory.KubeClient()
 	if err != nil {
@@ -124,7 +166,7 @@ func newResticServer(logger logrus.FieldLogger, factory client.Factory) (*restic
 		0,
 		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
 		func(opts *metav1.ListOptions) {
-			opts.FieldSelector = fmt.Sprintf("metadata.name=%s", restic.CredentialsSecretName)
+			opts.FieldSelector = fmt.Sprintf("metadata.name=%s", podvolume.CredentialsSecretName)
 		},
 	)
 
@@ -138,9 +180,11 @@ func newResticServer(logger logrus.FieldLogger, factory client.Factory) (*restic
 		podInformer:           podInformer,
 		secretInformer:        secretInformer,
 		logger:                logger,
+		namespace:             factory.Namespace(),
 		ctx:                   ctx,
 		cancelFunc:            cancelFunc,
 		fileSystem:            filesystem.NewFileSystem(),
+		config:                config,
 	}
 
 	if err := s.validatePodVolumesHostPath(); err != nil {
@@ -167,11 +211,16 @@ func (s *resticServer) run() {
 		s.kubeInformerFactory.Core().V1().PersistentVolumes(),
 		s.veleroInformerFactory.Velero().V1().BackupStorageLocations(),
 		os.Getenv("NODE_NAME"),
+		s.config.podVolumeBackupRetries,
+		s.config.podVolumeBackupRetryBackoff,
 	)
+	backupConcurrency := s.podVolumeBackupConcurrency()
+	s.logger.Infof("Running up to %d pod volume backups at once", backupConcurrency)
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		backupController.Run(s.ctx, 1)
+		backupController.Run(s.ctx, backupConcurrency)
 	}()
 
 	restoreController := controller.NewPodVolumeRestoreController(
@@ -204,10 +253,87 @@ func (s *resticServer) run() {
 	wg.Wait()
 }
 
+// podVolumeBackupConcurrency returns how many pod volume backups to run at
+// once on this node: the node's entry in the concurrency ConfigMap if it has
+// one, or the configured concurrency otherwise, limited to how many backups
+// fit in the restic container's memory limit. Invalid settings are logged
+// and ignored.
+func (s *resticServer) podVolumeBackupConcurrency() int {
+	concurrency := s.config.podVolumeBackupConcurrency
+	if concurrency < 1 {
+		s.logger.Warnf("Invalid --pod-volume-backup-concurrency %d, running one pod volume backup at a time", concurrency)
+		concurrency = 1
+	}
+
+	configMap, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(concurrencyConfigMap, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		s.logger.WithError(errors.WithStack(err)).Warnf("Error getting ConfigMap %s, using the default pod volume backup concurrency", concurrencyConfigMap)
+	}
+	if err == nil {
+		if val, err := nodeConcurrency(configMap, os.Getenv("NODE_NAME"), concurrency); err != nil {
+			s.logger.WithError(err).Warn("Invalid pod volume backup concurrency, using the default")
+		} else {
+			concurrency = val
+		}
+	}
+
+	memoryLimit, err := resource.ParseQuantity(os.Getenv(memoryLimitEnvVar))
+	if err != nil {
+		s.logger.Debugf("%s isn't set, not limiting pod volume backup concurrency by memory", memoryLimitEnvVar)
+		return concurrency
+	}
+
+	backupMemory, err := resource.ParseQuantity(s.config.podVolumeBackupMemory)
+	if err != nil {
+		s.logger.WithError(errors.WithStack(err)).Warn("Invalid --pod-volume-backup-memory, not limiting pod volume backup concurrency by memory")
+		return concurrency
+	}
+
+	if limited := throttleConcurrency(concurrency, memoryLimit.Value(), backupMemory.Value()); limited < concurrency {
+		s.logger.Infof("Limiting pod volume backup concurrency from %d to %d, since each backup may use %s of the container's %s of memory", concurrency, limited, backupMemory.String(), memoryLimit.String())
+		concurrency = limited
+	}
+
+	return concurrency
+}
+
+// nodeConcurrency returns the node's concurrency in the concurrency
+// ConfigMap, or defaultConcurrency if it doesn't have an entry.
+func nodeConcurrency(configMap *v1.ConfigMap, nodeName string, defaultConcurrency int) (int, error) {
+	val, ok := configMap.Data[nodeName]
+	if !ok {
+		return defaultConcurrency, nil
+	}
+
+	concurrency, err := strconv.Atoi(val)
+	if err != nil || concurrency < 1 {
+		return 0, errors.Errorf("ConfigMap %s has invalid concurrency %q for node %s, it must be a positive integer", configMap.Name, val, nodeName)
+	}
+
+	return concurrency, nil
+}
+
+// throttleConcurrency limits concurrency to how many backups that each use
+// backupMemory fit in memoryLimit, but always allows one backup.
+func throttleConcurrency(concurrency int, memoryLimit, backupMemory int64) int {
+	if memoryLimit <= 0 || backupMemory <= 0 {
+		return concurrency
+	}
+
+	if fit := memoryLimit / backupMemory; fit < int64(concurrency) {
+		if fit < 1 {
+			return 1
+		}
+		return int(fit)
+	}
+
+	return concurrency
+}
+
 // validatePodVolumesHostPath validates that the pod volumes path contains a
 // directory for each Pod running on this node
 func (s *resticServer) validatePodVolumesHostPath() error {
-	files, err := s.fileSystem.ReadDir("/host_pods/")
+	files, err := s.fileSystem.ReadDir(podvolume.HostPodsDir())
 	if err != nil {
 		return errors.Wrap(err, "could not read pod volumes host path")
 	}
@@ -239,7 +365,7 @@ func (s *resticServer) validatePodVolumesHostPath() error {
 			valid = false
 			s.logger.WithFields(logrus.Fields{
 				"pod":  fmt.Sprintf("%s/%s", pod.GetNamespace(), pod.GetName()),
-				"path": "/host_pods/" + dirName,
+				"path": filepath.Join(podvolume.HostPodsDir(), dirName),
 			}).Debug("could not find volumes for pod in host path")
 		}
 	}
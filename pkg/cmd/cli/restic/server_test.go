@@ -107,3 +107,65 @@ func Test_validatePodVolumesHostPath(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeConcurrency(t *testing.T) {
+	configMap := builder.ForConfigMap("velero", concurrencyConfigMap).Data("node-1", "4", "node-2", "zero").Result()
+
+	concurrency, err := nodeConcurrency(configMap, "node-1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, concurrency)
+
+	concurrency, err = nodeConcurrency(configMap, "node-3", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, concurrency)
+
+	_, err = nodeConcurrency(configMap, "node-2", 1)
+	assert.Error(t, err)
+}
+
+func TestThrottleConcurrency(t *testing.T) {
+	const mi = 1024 * 1024
+
+	tests := []struct {
+		name         string
+		concurrency  int
+		memoryLimit  int64
+		backupMemory int64
+		expected     int
+	}{
+		{
+			name:         "concurrency that fits in the memory limit isn't changed",
+			concurrency:  4,
+			memoryLimit:  2048 * mi,
+			backupMemory: 512 * mi,
+			expected:     4,
+		},
+		{
+			name:         "concurrency is limited to the backups that fit in the memory limit",
+			concurrency:  4,
+			memoryLimit:  1024 * mi,
+			backupMemory: 512 * mi,
+			expected:     2,
+		},
+		{
+			name:         "one backup is always allowed",
+			concurrency:  4,
+			memoryLimit:  256 * mi,
+			backupMemory: 512 * mi,
+			expected:     1,
+		},
+		{
+			name:         "zero backup memory doesn't limit concurrency",
+			concurrency:  4,
+			memoryLimit:  256 * mi,
+			backupMemory: 0,
+			expected:     4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, throttleConcurrency(test.concurrency, test.memoryLimit, test.backupMemory))
+		})
+	}
+}
@@ -18,13 +18,18 @@ package restore
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
 
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/client"
@@ -33,6 +38,7 @@ import (
 	"github.com/heptio/velero/pkg/cmd/util/output"
 	veleroclient "github.com/heptio/velero/pkg/generated/clientset/versioned"
 	v1 "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	"github.com/heptio/velero/pkg/util/logging"
 )
 
 func NewCreateCommand(f client.Factory, use string) *cobra.Command {
@@ -52,6 +58,16 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 
   # create a restore for only persistentvolumeclaims and persistentvolumes within a backup
   velero restore create --from-backup backup-2 --include-resources persistentvolumeclaims,persistentvolumes
+
+  # create a restore from backup "backup-1" that reads the backup with the break-glass
+  # credentials in the "cloud" key of the "break-glass" secret in the Velero namespace
+  velero restore create --from-backup backup-1 --credential break-glass=cloud
+
+  # create a restore that changes items with the resource modifier rules in modifiers.yaml
+  velero restore create --from-backup backup-1 --resource-modifiers modifiers.yaml
+
+  # pick the namespaces and resources to restore from backup "backup-1" from its resource list
+  velero restore create --from-backup backup-1 --interactive
   `,
 		Args: cobra.MaximumNArgs(1),
 		Run: func(c *cobra.Command, args []string) {
@@ -69,21 +85,39 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 }
 
 type CreateOptions struct {
-	BackupName              string
-	ScheduleName            string
-	RestoreName             string
-	RestoreVolumes          flag.OptionalBool
-	Labels                  flag.Map
-	IncludeNamespaces       flag.StringArray
-	ExcludeNamespaces       flag.StringArray
-	IncludeResources        flag.StringArray
-	ExcludeResources        flag.StringArray
-	NamespaceMappings       flag.Map
-	Selector                flag.LabelSelector
-	IncludeClusterResources flag.OptionalBool
-	Wait                    bool
-
-	client veleroclient.Interface
+	BackupName                  string
+	ScheduleName                string
+	StorageLocation             string
+	BackupVersion               string
+	Credential                  string
+	RestoreName                 string
+	RestoreVolumes              flag.OptionalBool
+	Labels                      flag.Map
+	IncludeNamespaces           flag.StringArray
+	ExcludeNamespaces           flag.StringArray
+	IncludeResources            flag.StringArray
+	ExcludeResources            flag.StringArray
+	IncludeClusterResourceNames flag.StringArray
+	ExcludeClusterResourceNames flag.StringArray
+	ResourcePolicy              string
+	NamespaceMappings           flag.Map
+	StorageClassMappings        flag.Map
+	Selector                    flag.LabelSelector
+	IncludeClusterResources     flag.OptionalBool
+	DataOnly                    bool
+	ControlPlaneConfig          bool
+	SelectiveExtraction         bool
+	ResourceModifiersFile       string
+	ResourcePriorities          flag.StringArray
+	WaitForReady                bool
+	ReadyTimeout                time.Duration
+	DryRunServer                bool
+	LogLevel                    *flag.Enum
+	Interactive                 bool
+	Wait                        bool
+
+	client            veleroclient.Interface
+	resourceModifiers []api.RestoreResourceModifier
 }
 
 func NewCreateOptions() *CreateOptions {
@@ -91,20 +125,29 @@ func NewCreateOptions() *CreateOptions {
 		Labels:                  flag.NewMap(),
 		IncludeNamespaces:       flag.NewStringArray("*"),
 		NamespaceMappings:       flag.NewMap().WithEntryDelimiter(",").WithKeyValueDelimiter(":"),
+		StorageClassMappings:    flag.NewMap().WithEntryDelimiter(",").WithKeyValueDelimiter(":"),
 		RestoreVolumes:          flag.NewOptionalBool(nil),
 		IncludeClusterResources: flag.NewOptionalBool(nil),
+		LogLevel:                flag.NewEnum("", logging.LogLevels()...),
 	}
 }
 
 func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&o.BackupName, "from-backup", "", "backup to restore from")
 	flags.StringVar(&o.ScheduleName, "from-schedule", "", "schedule to restore from")
+	flags.StringVar(&o.StorageLocation, "from-location", "", "backup storage location to read the backup from; must be the backup's storage location or one of its replica locations")
+	flags.StringVar(&o.BackupVersion, "backup-version", "", "version ID of the backup's velero-backup.json file to restore from, in a backup storage location whose bucket has versioning enabled; if the backup no longer exists, --from-location must also be given")
+	flags.StringVar(&o.Credential, "credential", "", "key in a secret in the Velero namespace, in the form SECRET_NAME=KEY, with credentials to read the backup with instead of the backup storage location's usual credentials")
 	flags.Var(&o.IncludeNamespaces, "include-namespaces", "namespaces to include in the restore (use '*' for all namespaces)")
 	flags.Var(&o.ExcludeNamespaces, "exclude-namespaces", "namespaces to exclude from the restore")
 	flags.Var(&o.NamespaceMappings, "namespace-mappings", "namespace mappings from name in the backup to desired restored name in the form src1:dst1,src2:dst2,...")
+	flags.Var(&o.StorageClassMappings, "storage-class-mappings", "storage class mappings from name in the backup to desired restored name for persistent volumes and claims in the form src1:dst1,src2:dst2,...")
 	flags.Var(&o.Labels, "labels", "labels to apply to the restore")
 	flags.Var(&o.IncludeResources, "include-resources", "resources to include in the restore, formatted as resource.group, such as storageclasses.storage.k8s.io (use '*' for all resources)")
 	flags.Var(&o.ExcludeResources, "exclude-resources", "resources to exclude from the restore, formatted as resource.group, such as storageclasses.storage.k8s.io")
+	flags.Var(&o.IncludeClusterResourceNames, "include-cluster-resource-names", "cluster-scoped items to include in the restore, formatted as resource.group/name, such as clusterroles.rbac.authorization.k8s.io/team-a-*; other items of their resources are excluded")
+	flags.Var(&o.ExcludeClusterResourceNames, "exclude-cluster-resource-names", "cluster-scoped items to exclude from the restore, formatted as resource.group/name, such as clusterroles.rbac.authorization.k8s.io/system:*")
+	flags.StringVar(&o.ResourcePolicy, "resource-policies-configmap", "", "name of a ConfigMap, in the Velero namespace, of resource policies; items matching a skip policy aren't restored")
 	flags.VarP(&o.Selector, "selector", "l", "only restore resources matching this label selector")
 	f := flags.VarPF(&o.RestoreVolumes, "restore-volumes", "", "whether to restore volumes from snapshots")
 	// this allows the user to just specify "--restore-volumes" as shorthand for "--restore-volumes=true"
@@ -114,6 +157,18 @@ func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	f = flags.VarPF(&o.IncludeClusterResources, "include-cluster-resources", "", "include cluster-scoped resources in the restore")
 	f.NoOptDefVal = "true"
 
+	flags.BoolVar(&o.DataOnly, "data-only", o.DataOnly, "only restore the restic backups of persistent volume claims, into existing claims with the same names, without restoring any Kubernetes resources")
+	flags.BoolVar(&o.SelectiveExtraction, "selective-extraction", o.SelectiveExtraction, "stream the backup from backup storage and only extract the included resources and namespaces, rather than downloading and extracting the whole backup")
+	flags.StringVar(&o.ResourceModifiersFile, "resource-modifiers", "", "YAML file with a list of resource modifier rules, which change the items that match them with JSON patches before they're restored")
+	flags.Var(&o.ResourcePriorities, "resource-priorities", "resources to restore before all others, in order, formatted as resource.group, such as customresourcedefinitions,foos.example.com; overrides the server's restore resource priorities")
+	flags.BoolVar(&o.WaitForReady, "wait-for-ready", o.WaitForReady, "wait for restored custom resource definitions to be established and persistent volume claims to be bound before restoring the resources that depend on them")
+	flags.DurationVar(&o.ReadyTimeout, "ready-timeout", o.ReadyTimeout, "how long to wait for each resource's restored items to be ready with --wait-for-ready; defaults to 10m")
+	flags.BoolVar(&o.DryRunServer, "dry-run-server", o.DryRunServer, "only send server-side dry-run creates, patches and applies for the items in the backup, without changing the cluster or restoring volumes; 'velero restore describe' shows what would be created, skipped, or in conflict")
+	flags.BoolVar(&o.ControlPlaneConfig, "include-control-plane-config", o.ControlPlaneConfig, "restore FlowSchemas, PriorityLevelConfigurations, and admission webhook configurations from the backup; these are skipped by default since they can lock clients out of the API server")
+	flags.Var(o.LogLevel, "log-level", fmt.Sprintf("the level at which to log the restore, overriding the server's log level. Valid values are %s", strings.Join(o.LogLevel.AllowedValues(), ", ")))
+
+	flags.BoolVar(&o.Interactive, "interactive", o.Interactive, "pick the namespaces and resources to restore from the backup's resource list, instead of with the include and exclude flags")
+
 	flags.BoolVarP(&o.Wait, "wait", "w", o.Wait, "wait for the operation to complete")
 }
 
@@ -129,6 +184,16 @@ func (o *CreateOptions) Complete(args []string, f client.Factory) error {
 		o.RestoreName = fmt.Sprintf("%s-%s", sourceName, time.Now().Format("20060102150405"))
 	}
 
+	if o.ResourceModifiersFile != "" {
+		data, err := ioutil.ReadFile(o.ResourceModifiersFile)
+		if err != nil {
+			return errors.Wrap(err, "error reading resource modifiers file")
+		}
+		if err := yaml.UnmarshalStrict(data, &o.resourceModifiers); err != nil {
+			return errors.Wrap(err, "error parsing resource modifiers file")
+		}
+	}
+
 	client, err := f.Client()
 	if err != nil {
 		return err
@@ -151,12 +216,48 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Facto
 		return err
 	}
 
+	if o.BackupVersion != "" && o.BackupName == "" {
+		return errors.New("--backup-version can only be used with --from-backup")
+	}
+
+	if o.Credential != "" {
+		if _, _, err := parseCredential(o.Credential); err != nil {
+			return err
+		}
+	}
+
+	if o.Interactive {
+		if o.BackupName == "" {
+			return errors.New("--interactive can only be used with --from-backup")
+		}
+		for _, name := range []string{"include-namespaces", "exclude-namespaces", "include-resources", "exclude-resources", "include-cluster-resources"} {
+			if c.Flags().Changed(name) {
+				return errors.Errorf("--%s can't be used with --interactive, which picks the namespaces and resources to restore", name)
+			}
+		}
+	}
+
+	if o.ReadyTimeout != 0 && !o.WaitForReady {
+		return errors.New("--ready-timeout can only be used with --wait-for-ready")
+	}
+
+	if o.DryRunServer && o.DataOnly {
+		return errors.New("--dry-run-server can't be used with --data-only")
+	}
+
+	if o.DataOnly && o.RestoreVolumes.Value != nil {
+		return errors.New("--restore-volumes can't be used with --data-only, since volume snapshots can't be restored into existing claims")
+	}
+
 	if o.client == nil {
 		// This should never happen
 		return errors.New("Velero client is not set; unable to proceed")
 	}
 
 	switch {
+	case o.BackupVersion != "" && o.StorageLocation != "":
+		// a version of a backup that was deleted is read from the
+		// location, so the backup doesn't need to exist.
 	case o.BackupName != "":
 		if _, err := o.client.VeleroV1().Backups(f.Namespace()).Get(o.BackupName, metav1.GetOptions{}); err != nil {
 			return err
@@ -176,6 +277,27 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 		return errors.New("Velero client is not set; unable to proceed")
 	}
 
+	if o.Interactive {
+		resourceList, err := downloadResourceList(o.client.VeleroV1(), f.Namespace(), o.BackupName)
+		if err != nil {
+			return err
+		}
+
+		filters, ok, err := newResourcePicker(os.Stdin, os.Stdout).pick(newResourceTree(resourceList))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Restore not created.")
+			return nil
+		}
+
+		o.IncludeNamespaces = filters.namespaces
+		o.ExcludeNamespaces = filters.excludedNamespaces
+		o.IncludeResources = filters.resources
+		o.IncludeClusterResources.Value = &filters.includeClusterResources
+	}
+
 	restore := &api.Restore{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: f.Namespace(),
@@ -183,19 +305,51 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 			Labels:    o.Labels.Data(),
 		},
 		Spec: api.RestoreSpec{
-			BackupName:              o.BackupName,
-			ScheduleName:            o.ScheduleName,
-			IncludedNamespaces:      o.IncludeNamespaces,
-			ExcludedNamespaces:      o.ExcludeNamespaces,
-			IncludedResources:       o.IncludeResources,
-			ExcludedResources:       o.ExcludeResources,
-			NamespaceMapping:        o.NamespaceMappings.Data(),
-			LabelSelector:           o.Selector.LabelSelector,
-			RestorePVs:              o.RestoreVolumes.Value,
-			IncludeClusterResources: o.IncludeClusterResources.Value,
+			BackupName:                   o.BackupName,
+			ScheduleName:                 o.ScheduleName,
+			BackupStorageLocation:        o.StorageLocation,
+			BackupVersion:                o.BackupVersion,
+			IncludedNamespaces:           o.IncludeNamespaces,
+			ExcludedNamespaces:           o.ExcludeNamespaces,
+			IncludedResources:            o.IncludeResources,
+			ExcludedResources:            o.ExcludeResources,
+			NamespaceMapping:             o.NamespaceMappings.Data(),
+			StorageClassMapping:          o.StorageClassMappings.Data(),
+			LabelSelector:                o.Selector.LabelSelector,
+			RestorePVs:                   o.RestoreVolumes.Value,
+			IncludeClusterResources:      o.IncludeClusterResources.Value,
+			IncludedClusterResourceNames: o.IncludeClusterResourceNames,
+			ExcludedClusterResourceNames: o.ExcludeClusterResourceNames,
+			ResourcePolicy:               o.ResourcePolicy,
+			ResourceModifiers:            o.resourceModifiers,
+			ResourcePriorities:           o.ResourcePriorities,
+			WaitForReady:                 o.WaitForReady,
+			ReadyTimeout:                 metav1.Duration{Duration: o.ReadyTimeout},
+			DryRun:                       o.DryRunServer,
+			LogLevel:                     o.LogLevel.String(),
 		},
 	}
 
+	if o.DataOnly {
+		restore.Spec.Mode = api.RestoreModeDataOnly
+	}
+
+	if o.ControlPlaneConfig {
+		restore.Spec.ControlPlaneConfigPolicy = api.ControlPlaneConfigPolicyRestore
+	}
+
+	if o.SelectiveExtraction {
+		restore.Spec.ExtractionPolicy = api.RestoreExtractionPolicySelective
+	}
+
+	if o.Credential != "" {
+		secretName, key, _ := parseCredential(o.Credential)
+		restore.Spec.Credential = &corev1api.SecretKeySelector{
+			LocalObjectReference: corev1api.LocalObjectReference{Name: secretName},
+			Key:                  key,
+		}
+	}
+
 	if printed, err := output.PrintWithFormat(c, restore); printed || err != nil {
 		return err
 	}
@@ -275,3 +429,12 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 
 	return nil
 }
+
+// parseCredential parses a credential flag of the form SECRET_NAME=KEY.
+func parseCredential(credential string) (string, string, error) {
+	parts := strings.SplitN(credential, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.Errorf("invalid credential %q, must be in the form SECRET_NAME=KEY", credential)
+	}
+	return parts[0], parts[1], nil
+}
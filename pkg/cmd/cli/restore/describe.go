@@ -27,7 +27,7 @@ import (
 	"github.com/heptio/velero/pkg/client"
 	"github.com/heptio/velero/pkg/cmd"
 	"github.com/heptio/velero/pkg/cmd/util/output"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 )
 
 func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
@@ -40,6 +40,9 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 		Use:   use + " [NAME1] [NAME2] [NAME...]",
 		Short: "Describe restores",
 		Run: func(c *cobra.Command, args []string) {
+			err := output.ValidateDescribeFlags(c)
+			cmd.CheckError(err)
+
 			veleroClient, err := f.Client()
 			cmd.CheckError(err)
 
@@ -56,14 +59,22 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 				cmd.CheckError(err)
 			}
 
+			format := output.GetOutputFlagValue(c)
+			var descriptions []interface{}
+
 			first := true
 			for _, restore := range restores.Items {
-				opts := restic.NewPodVolumeRestoreListOptions(restore.Name)
+				opts := podvolume.NewPodVolumeRestoreListOptions(restore.Name)
 				podvolumeRestoreList, err := veleroClient.VeleroV1().PodVolumeRestores(f.Namespace()).List(opts)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "error getting PodVolumeRestores for restore %s: %v\n", restore.Name, err)
 				}
 
+				if format != "" {
+					descriptions = append(descriptions, output.NewRestoreDescription(restore.DeepCopy(), podvolumeRestoreList.Items, veleroClient))
+					continue
+				}
+
 				s := output.DescribeRestore(&restore, podvolumeRestoreList.Items, details, veleroClient)
 				if first {
 					first = false
@@ -73,11 +84,16 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 				}
 			}
 			cmd.CheckError(err)
+
+			if format != "" {
+				cmd.CheckError(output.PrintDescriptions(os.Stdout, format, descriptions))
+			}
 		},
 	}
 
 	c.Flags().StringVarP(&listOptions.LabelSelector, "selector", "l", listOptions.LabelSelector, "only show items matching this label selector")
 	c.Flags().BoolVar(&details, "details", details, "display additional detail in the command output")
+	output.BindDescribeFlags(c.Flags())
 
 	return c
 }
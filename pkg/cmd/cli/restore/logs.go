@@ -32,6 +32,7 @@ import (
 
 func NewLogsCommand(f client.Factory) *cobra.Command {
 	timeout := time.Minute
+	structured := false
 
 	c := &cobra.Command{
 		Use:   "logs RESTORE",
@@ -58,12 +59,18 @@ func NewLogsCommand(f client.Factory) *cobra.Command {
 					"until the restore has a phase of Completed or Failed and try again.", restoreName)
 			}
 
-			err = downloadrequest.Stream(veleroClient.VeleroV1(), f.Namespace(), restoreName, v1.DownloadTargetKindRestoreLog, os.Stdout, timeout)
+			kind := v1.DownloadTargetKindRestoreLog
+			if structured {
+				kind = v1.DownloadTargetKindRestoreStructuredLog
+			}
+
+			err = downloadrequest.Stream(veleroClient.VeleroV1(), f.Namespace(), restoreName, kind, os.Stdout, timeout)
 			cmd.CheckError(err)
 		},
 	}
 
 	c.Flags().DurationVar(&timeout, "timeout", timeout, "how long to wait to receive logs")
+	c.Flags().BoolVar(&structured, "structured", structured, "get the JSON-lines log instead of the text log. Only available if the server was run with --structured-logs")
 
 	return c
 }
@@ -0,0 +1,306 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/cmd/util/downloadrequest"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+)
+
+const resourceListTimeout = time.Minute
+
+// resourceTree is a backup's resource list arranged by namespace, for
+// picking what to restore.
+type resourceTree struct {
+	// namespaces maps each namespace in the backup to the number of items
+	// of each resource in it.
+	namespaces map[string]map[string]int
+	// clusterResources maps each cluster-scoped resource in the backup,
+	// other than namespaces, to its number of items.
+	clusterResources map[string]int
+}
+
+// newResourceTree arranges a backup resource list, which maps each
+// resource to its items' "namespace/name", or "name" for cluster-scoped
+// items.
+func newResourceTree(resourceList map[string][]string) *resourceTree {
+	tree := &resourceTree{
+		namespaces:       map[string]map[string]int{},
+		clusterResources: map[string]int{},
+	}
+
+	for resource, items := range resourceList {
+		for _, item := range items {
+			parts := strings.SplitN(item, "/", 2)
+			switch {
+			case len(parts) == 2:
+				if tree.namespaces[parts[0]] == nil {
+					tree.namespaces[parts[0]] = map[string]int{}
+				}
+				tree.namespaces[parts[0]][resource]++
+			case resource == "namespaces":
+				// namespaces are picked by name, along with their items.
+				if tree.namespaces[item] == nil {
+					tree.namespaces[item] = map[string]int{}
+				}
+			default:
+				tree.clusterResources[resource]++
+			}
+		}
+	}
+
+	return tree
+}
+
+// pickedFilters are the include filters for the parts of a backup that were
+// picked.
+type pickedFilters struct {
+	namespaces              []string
+	excludedNamespaces      []string
+	resources               []string
+	includeClusterResources bool
+}
+
+// flags returns the `velero restore create` flags for the filters.
+func (f *pickedFilters) flags() string {
+	flags := fmt.Sprintf("--include-namespaces %s", strings.Join(f.namespaces, ","))
+	if len(f.excludedNamespaces) > 0 {
+		flags += fmt.Sprintf(" --exclude-namespaces %s", strings.Join(f.excludedNamespaces, ","))
+	}
+	return flags + fmt.Sprintf(" --include-resources %s --include-cluster-resources=%t", strings.Join(f.resources, ","), f.includeClusterResources)
+}
+
+// resourcePicker asks which namespaces and resources of a backup to restore.
+type resourcePicker struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+func newResourcePicker(in io.Reader, out io.Writer) *resourcePicker {
+	return &resourcePicker{
+		in:  bufio.NewScanner(in),
+		out: out,
+	}
+}
+
+// pick asks which of the tree's namespaces and then which of the resources
+// in them, and which of its cluster-scoped resources, to restore, and
+// returns the filters that restore them. ok is false if the restore
+// wasn't confirmed.
+func (p *resourcePicker) pick(tree *resourceTree) (filters *pickedFilters, ok bool, err error) {
+	filters = new(pickedFilters)
+
+	namespaces := make([]string, 0, len(tree.namespaces))
+	for ns := range tree.namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	pickedNamespaces, err := p.pickFrom("Namespaces", namespaces, func(ns string) int {
+		count := 0
+		for _, n := range tree.namespaces[ns] {
+			count += n
+		}
+		return count
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	resourceCounts := map[string]int{}
+	for _, ns := range pickedNamespaces {
+		for resource, n := range tree.namespaces[ns] {
+			resourceCounts[resource] += n
+		}
+	}
+	for resource, n := range tree.clusterResources {
+		resourceCounts[resource] += n
+	}
+
+	resources := make([]string, 0, len(resourceCounts))
+	for resource := range resourceCounts {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	pickedResources, err := p.pickFrom("Resources", resources, func(resource string) int {
+		return resourceCounts[resource]
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(pickedResources) == 0 {
+		return nil, false, errors.New("nothing was picked to restore")
+	}
+
+	switch {
+	case len(pickedNamespaces) == len(namespaces):
+		filters.namespaces = []string{"*"}
+	case len(pickedNamespaces) == 0:
+		// an empty list of included namespaces includes all of them,
+		// so only cluster-scoped items are restored by excluding them.
+		filters.namespaces = []string{"*"}
+		filters.excludedNamespaces = namespaces
+	default:
+		filters.namespaces = pickedNamespaces
+	}
+
+	if len(pickedResources) == len(resources) {
+		filters.resources = []string{"*"}
+	} else {
+		filters.resources = pickedResources
+	}
+
+	for _, resource := range pickedResources {
+		if tree.clusterResources[resource] > 0 {
+			filters.includeClusterResources = true
+			break
+		}
+	}
+
+	if len(pickedNamespaces) == 0 && !filters.includeClusterResources {
+		return nil, false, errors.New("nothing was picked to restore")
+	}
+
+	fmt.Fprintf(p.out, "\nThe restore will use: %s\n", filters.flags())
+	answer, err := p.ask("Create the restore? [y/N]: ")
+	if err != nil {
+		return nil, false, err
+	}
+
+	return filters, strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes"), nil
+}
+
+// pickFrom lists choices with their item counts and returns the ones that
+// are picked, asking again until the answer is valid.
+func (p *resourcePicker) pickFrom(heading string, choices []string, count func(string) int) ([]string, error) {
+	fmt.Fprintf(p.out, "\n%s:\n", heading)
+	for i, choice := range choices {
+		fmt.Fprintf(p.out, "  %3d) %s (%d items)\n", i+1, choice, count(choice))
+	}
+
+	for {
+		answer, err := p.ask(fmt.Sprintf("%s to restore (e.g. 1,3-5, 'all' or 'none') [all]: ", heading))
+		if err != nil {
+			return nil, err
+		}
+
+		picked, err := parseSelection(answer, len(choices))
+		if err != nil {
+			fmt.Fprintf(p.out, "%v\n", err)
+			continue
+		}
+
+		var result []string
+		for _, i := range picked {
+			result = append(result, choices[i])
+		}
+		return result, nil
+	}
+}
+
+func (p *resourcePicker) ask(prompt string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+	if !p.in.Scan() {
+		if err := p.in.Err(); err != nil {
+			return "", errors.WithStack(err)
+		}
+		return "", errors.New("no answer was given")
+	}
+	return strings.TrimSpace(p.in.Text()), nil
+}
+
+// parseSelection parses a comma-separated list of 1-based numbers and
+// ranges of numbers, such as "1,3-5", into sorted 0-based indexes less than
+// n. An empty selection, "all" or "*" selects everything, and "none"
+// selects nothing.
+func parseSelection(selection string, n int) ([]int, error) {
+	var picked []int
+
+	switch strings.ToLower(selection) {
+	case "", "all", "*":
+		for i := 0; i < n; i++ {
+			picked = append(picked, i)
+		}
+		return picked, nil
+	case "none":
+		return nil, nil
+	}
+
+	seen := map[int]bool{}
+	for _, part := range strings.Split(selection, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, "-", 2)
+
+		first, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, errors.Errorf("invalid selection %q", part)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+				return nil, errors.Errorf("invalid selection %q", part)
+			}
+		}
+		if first < 1 || last > n || first > last {
+			return nil, errors.Errorf("invalid selection %q, must be between 1 and %d", part, n)
+		}
+
+		for i := first - 1; i < last; i++ {
+			if !seen[i] {
+				seen[i] = true
+				picked = append(picked, i)
+			}
+		}
+	}
+
+	sort.Ints(picked)
+	return picked, nil
+}
+
+// downloadResourceList gets the list of resources in a backup from its
+// backup storage location.
+func downloadResourceList(client velerov1client.DownloadRequestsGetter, namespace, backup string) (map[string][]string, error) {
+	buf := new(bytes.Buffer)
+	if err := downloadrequest.Stream(client, namespace, backup, api.DownloadTargetKindBackupResourceList, buf, resourceListTimeout); err != nil {
+		if err == downloadrequest.ErrNotFound {
+			return nil, errors.Errorf("backup %s has no resource list, this could be because it was taken prior to Velero 1.1.0", backup)
+		}
+		return nil, errors.Wrapf(err, "error getting resource list of backup %s", backup)
+	}
+
+	var resourceList map[string][]string
+	if err := json.NewDecoder(buf).Decode(&resourceList); err != nil {
+		return nil, errors.Wrapf(err, "error reading resource list of backup %s", backup)
+	}
+
+	return resourceList, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		selection string
+		want      []int
+		wantErr   bool
+	}{
+		{selection: "", want: []int{0, 1, 2, 3, 4}},
+		{selection: "all", want: []int{0, 1, 2, 3, 4}},
+		{selection: "none", want: nil},
+		{selection: "2", want: []int{1}},
+		{selection: "5, 1-2,2", want: []int{0, 1, 4}},
+		{selection: "3-5", want: []int{2, 3, 4}},
+		{selection: "0", wantErr: true},
+		{selection: "6", wantErr: true},
+		{selection: "4-2", wantErr: true},
+		{selection: "a", wantErr: true},
+		{selection: "1-b", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.selection, func(t *testing.T) {
+			picked, err := parseSelection(tc.selection, 5)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, picked)
+		})
+	}
+}
+
+func TestNewResourceTree(t *testing.T) {
+	tree := newResourceTree(map[string][]string{
+		"namespaces":             {"ns-1", "ns-2", "ns-3"},
+		"pods":                   {"ns-1/pod-1", "ns-1/pod-2", "ns-2/pod-1"},
+		"deployments.apps":       {"ns-1/deploy-1"},
+		"persistentvolumes":      {"pv-1"},
+		"storageclasses.storage": {"standard", "fast"},
+	})
+
+	assert.Equal(t, map[string]map[string]int{
+		"ns-1": {"pods": 2, "deployments.apps": 1},
+		"ns-2": {"pods": 1},
+		"ns-3": {},
+	}, tree.namespaces)
+	assert.Equal(t, map[string]int{"persistentvolumes": 1, "storageclasses.storage": 2}, tree.clusterResources)
+}
+
+func TestPick(t *testing.T) {
+	tree := newResourceTree(map[string][]string{
+		"namespaces":        {"ns-1", "ns-2"},
+		"pods":              {"ns-1/pod-1", "ns-2/pod-1"},
+		"configmaps":        {"ns-2/cm-1"},
+		"persistentvolumes": {"pv-1"},
+	})
+
+	tests := []struct {
+		name    string
+		answers []string
+		want    *pickedFilters
+		wantOK  bool
+		wantErr bool
+	}{
+		{
+			name:    "everything",
+			answers: []string{"", "", "y"},
+			want:    &pickedFilters{namespaces: []string{"*"}, resources: []string{"*"}, includeClusterResources: true},
+			wantOK:  true,
+		},
+		{
+			// resources are listed for the picked namespace only:
+			// 1) persistentvolumes 2) pods
+			name:    "namespaced resources in one namespace",
+			answers: []string{"1", "2", "yes"},
+			want:    &pickedFilters{namespaces: []string{"ns-1"}, resources: []string{"pods"}},
+			wantOK:  true,
+		},
+		{
+			name:    "only cluster-scoped resources",
+			answers: []string{"none", "1", "y"},
+			want:    &pickedFilters{namespaces: []string{"*"}, excludedNamespaces: []string{"ns-1", "ns-2"}, resources: []string{"*"}, includeClusterResources: true},
+			wantOK:  true,
+		},
+		{
+			name:    "invalid answers are asked again",
+			answers: []string{"3", "2", "7", "2-3", "n"},
+			want:    &pickedFilters{namespaces: []string{"ns-2"}, resources: []string{"persistentvolumes", "pods"}, includeClusterResources: true},
+			wantOK:  false,
+		},
+		{
+			name:    "nothing picked",
+			answers: []string{"", "none"},
+			wantErr: true,
+		},
+		{
+			name:    "no answer",
+			answers: []string{"1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			filters, ok, err := newResourcePicker(strings.NewReader(strings.Join(tc.answers, "\n")+"\n"), out).pick(tree)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, filters)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Contains(t, out.String(), "The restore will use: "+tc.want.flags())
+		})
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/cli"
+	"github.com/heptio/velero/pkg/cmd/util/downloadrequest"
+	"github.com/heptio/velero/pkg/label"
+	pkgrestore "github.com/heptio/velero/pkg/restore"
+)
+
+// NewUndoCommand creates and returns a new cobra command for undoing a restore.
+func NewUndoCommand(f client.Factory, use string) *cobra.Command {
+	o := NewUndoOptions()
+
+	c := &cobra.Command{
+		Use:   use + " NAME",
+		Short: "Delete the items that a restore created",
+		Long: `Delete the items that a restore created, as recorded in its manifest.
+
+Items are only deleted if they still have the UID they were created with, and
+are still labeled with the restore's name. Items that are owned by another item
+that the restore created are left for the Kubernetes garbage collector to delete
+along with their owner.`,
+		Example: `	# delete the items that the restore "restore-1" created
+	velero restore undo restore-1
+
+	# list the items that would be deleted, without deleting them
+	velero restore undo restore-1 --dry-run`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(args))
+			cmd.CheckError(o.Run(f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+// UndoOptions are the options for undoing a restore.
+type UndoOptions struct {
+	Name    string
+	Confirm bool
+	DryRun  bool
+	Timeout time.Duration
+}
+
+// NewUndoOptions returns an UndoOptions with default values.
+func NewUndoOptions() *UndoOptions {
+	return &UndoOptions{
+		Timeout: time.Minute,
+	}
+}
+
+// BindFlags binds the options to a flag set.
+func (o *UndoOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.Confirm, "confirm", o.Confirm, "confirm deletion")
+	flags.BoolVar(&o.DryRun, "dry-run", o.DryRun, "list the items that would be deleted, without deleting them")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "how long to wait to receive the restore's manifest")
+}
+
+// Complete completes the options from the command's arguments.
+func (o *UndoOptions) Complete(args []string) error {
+	o.Name = args[0]
+	return nil
+}
+
+// Run undoes the restore.
+func (o *UndoOptions) Run(f client.Factory) error {
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	restore, err := veleroClient.VeleroV1().Restores(f.Namespace()).Get(o.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	switch restore.Status.Phase {
+	case velerov1api.RestorePhaseCompleted, velerov1api.RestorePhaseFailed, velerov1api.RestorePhasePartiallyFailed:
+	default:
+		return errors.Errorf("restore %q can't be undone until it's finished processing", o.Name)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := downloadrequest.Stream(veleroClient.VeleroV1(), f.Namespace(), o.Name, velerov1api.DownloadTargetKindRestoreManifest, buf, o.Timeout); err != nil {
+		return errors.Wrap(err, "error getting restore manifest")
+	}
+
+	manifest := new(pkgrestore.Manifest)
+	if err := json.NewDecoder(buf).Decode(manifest); err != nil {
+		return errors.Wrap(err, "error decoding restore manifest")
+	}
+
+	if len(manifest.Items) == 0 {
+		fmt.Printf("Restore %q didn't create any items\n", o.Name)
+		return nil
+	}
+
+	if !o.DryRun && !o.Confirm {
+		fmt.Printf("Restore %q created %d items, which will be deleted.\n", o.Name, len(manifest.Items))
+		if !cli.GetConfirmation() {
+			return nil
+		}
+	}
+
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+
+	return undoRestore(o.Name, manifest, dynamicClient, o.DryRun, os.Stdout)
+}
+
+// undoRestore deletes the items in a restore's manifest, in the reverse of the
+// order they were created in. Items that were deleted or replaced since, or that
+// are no longer labeled with the restore's name, are skipped, as are items owned
+// by another item in the manifest, which are deleted along with their owner.
+func undoRestore(restoreName string, manifest *pkgrestore.Manifest, dynamicClient dynamic.Interface, dryRun bool, w io.Writer) error {
+	uids := sets.NewString()
+	for _, item := range manifest.Items {
+		uids.Insert(string(item.UID))
+	}
+
+	var errs []error
+	for i := len(manifest.Items) - 1; i >= 0; i-- {
+		item := manifest.Items[i]
+
+		id := item.Name
+		if item.Namespace != "" {
+			id = item.Namespace + "/" + item.Name
+		}
+
+		gvr, err := item.GroupVersionResource()
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "error getting resource of %s %s", item.Kind, id))
+			continue
+		}
+
+		var resourceClient dynamic.ResourceInterface = dynamicClient.Resource(gvr)
+		if item.Namespace != "" {
+			resourceClient = dynamicClient.Resource(gvr).Namespace(item.Namespace)
+		}
+
+		obj, err := resourceClient.Get(item.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(w, "Skipped %s %s: already deleted\n", item.Kind, id)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "error getting %s %s", item.Kind, id))
+			continue
+		}
+
+		if obj.GetUID() != item.UID {
+			fmt.Fprintf(w, "Skipped %s %s: replaced since it was restored\n", item.Kind, id)
+			continue
+		}
+
+		if restore := obj.GetLabels()[velerov1api.RestoreNameLabel]; restore != label.GetValidName(restoreName) {
+			fmt.Fprintf(w, "Skipped %s %s: restored again by restore %q\n", item.Kind, id, restore)
+			continue
+		}
+
+		if owner := ownerInManifest(obj.GetOwnerReferences(), uids); owner != nil {
+			fmt.Fprintf(w, "Skipped %s %s: deleted along with its owner %s %s\n", item.Kind, id, owner.Kind, owner.Name)
+			continue
+		}
+
+		if dryRun {
+			fmt.Fprintf(w, "Would delete %s %s\n", item.Kind, id)
+			continue
+		}
+
+		uid := item.UID
+		propagation := metav1.DeletePropagationBackground
+		err = resourceClient.Delete(item.Name, &metav1.DeleteOptions{
+			Preconditions:     &metav1.Preconditions{UID: &uid},
+			PropagationPolicy: &propagation,
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "error deleting %s %s", item.Kind, id))
+			continue
+		}
+
+		fmt.Fprintf(w, "Deleted %s %s\n", item.Kind, id)
+	}
+
+	return kubeerrs.NewAggregate(errs)
+}
+
+func ownerInManifest(owners []metav1.OwnerReference, uids sets.String) *metav1.OwnerReference {
+	for i := range owners {
+		if uids.Has(string(owners[i].UID)) {
+			return &owners[i]
+		}
+	}
+	return nil
+}
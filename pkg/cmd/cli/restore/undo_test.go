@@ -0,0 +1,116 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	pkgrestore "github.com/heptio/velero/pkg/restore"
+)
+
+func TestUndoRestore(t *testing.T) {
+	item := func(apiVersion, kind, namespace, name, uid, restore string) *unstructured.Unstructured {
+		obj := new(unstructured.Unstructured)
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		obj.SetUID(types.UID(uid))
+		obj.SetLabels(map[string]string{velerov1api.RestoreNameLabel: restore})
+		return obj
+	}
+
+	replicaSet := item("apps/v1", "ReplicaSet", "ns-1", "deploy-1-abc", "uid-rs", "restore-1")
+	replicaSet.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "deploy-1", UID: "uid-deploy"}})
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		item("v1", "Namespace", "", "ns-1", "uid-ns", "restore-1"),
+		item("v1", "ConfigMap", "ns-1", "cm-1", "uid-cm-1", "restore-1"),
+		item("v1", "ConfigMap", "ns-1", "cm-3", "uid-other", "restore-1"),
+		item("v1", "ConfigMap", "ns-1", "cm-4", "uid-cm-4", "restore-2"),
+		item("apps/v1", "Deployment", "ns-1", "deploy-1", "uid-deploy", "restore-1"),
+		replicaSet,
+	)
+
+	manifest := &pkgrestore.Manifest{
+		Items: []pkgrestore.ManifestItem{
+			{APIVersion: "v1", Kind: "Namespace", Resource: "namespaces", Name: "ns-1", UID: "uid-ns"},
+			{APIVersion: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "ns-1", Name: "cm-1", UID: "uid-cm-1"},
+			{APIVersion: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "ns-1", Name: "cm-2", UID: "uid-cm-2"},
+			{APIVersion: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "ns-1", Name: "cm-3", UID: "uid-cm-3"},
+			{APIVersion: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "ns-1", Name: "cm-4", UID: "uid-cm-4"},
+			{APIVersion: "apps/v1", Kind: "Deployment", Resource: "deployments", Namespace: "ns-1", Name: "deploy-1", UID: "uid-deploy"},
+			{APIVersion: "apps/v1", Kind: "ReplicaSet", Resource: "replicasets", Namespace: "ns-1", Name: "deploy-1-abc", UID: "uid-rs"},
+		},
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		require.NoError(t, undoRestore("restore-1", manifest, dynamicClient, true, out))
+
+		assert.Equal(t, `Skipped ReplicaSet ns-1/deploy-1-abc: deleted along with its owner Deployment deploy-1
+Would delete Deployment ns-1/deploy-1
+Skipped ConfigMap ns-1/cm-4: restored again by restore "restore-2"
+Skipped ConfigMap ns-1/cm-3: replaced since it was restored
+Skipped ConfigMap ns-1/cm-2: already deleted
+Would delete ConfigMap ns-1/cm-1
+Would delete Namespace ns-1
+`, out.String())
+	})
+
+	t.Run("undo", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		require.NoError(t, undoRestore("restore-1", manifest, dynamicClient, false, out))
+
+		assert.Equal(t, `Skipped ReplicaSet ns-1/deploy-1-abc: deleted along with its owner Deployment deploy-1
+Deleted Deployment ns-1/deploy-1
+Skipped ConfigMap ns-1/cm-4: restored again by restore "restore-2"
+Skipped ConfigMap ns-1/cm-3: replaced since it was restored
+Skipped ConfigMap ns-1/cm-2: already deleted
+Deleted ConfigMap ns-1/cm-1
+Deleted Namespace ns-1
+`, out.String())
+
+		exists := func(gvr schema.GroupVersionResource, namespace, name string) bool {
+			_, err := dynamicClient.Resource(gvr).Namespace(namespace).Get(name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return false
+			}
+			require.NoError(t, err)
+			return true
+		}
+
+		configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+		assert.False(t, exists(configMaps, "ns-1", "cm-1"))
+		assert.True(t, exists(configMaps, "ns-1", "cm-3"))
+		assert.True(t, exists(configMaps, "ns-1", "cm-4"))
+		assert.False(t, exists(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, "ns-1", "deploy-1"))
+		assert.False(t, exists(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, "", "ns-1"))
+	})
+}
@@ -18,6 +18,7 @@ package schedule
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -28,6 +29,7 @@ import (
 	"github.com/heptio/velero/pkg/client"
 	"github.com/heptio/velero/pkg/cmd"
 	"github.com/heptio/velero/pkg/cmd/cli/backup"
+	"github.com/heptio/velero/pkg/cmd/util/flag"
 	"github.com/heptio/velero/pkg/cmd/util/output"
 )
 
@@ -37,7 +39,7 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 	c := &cobra.Command{
 		Use:   use + " NAME --schedule",
 		Short: "Create a schedule",
-		Long: `The --schedule flag is required, in cron notation, using UTC time:
+		Long: `The --schedule flag is required, in cron notation, using UTC time unless --timezone is set:
 
 | Character Position | Character Period | Acceptable Values |
 | -------------------|:----------------:| -----------------:|
@@ -49,7 +51,10 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 
 The schedule can also be expressed using "@every <duration>" syntax. The duration
 can be specified using a combination of seconds (s), minutes (m), and hours (h), for
-example: "@every 2h30m".`,
+example: "@every 2h30m".
+
+Use --additional-schedule, which can be repeated, to run the backup on more than one
+schedule, for example hourly on weekdays and daily on weekends.`,
 
 		Example: `	# Create a backup every 6 hours
 	velero create schedule NAME --schedule="0 */6 * * *"
@@ -62,6 +67,18 @@ example: "@every 2h30m".`,
 
 	# Create a weekly backup, each living for 90 days (2160 hours)
 	velero create schedule NAME --schedule="@every 168h" --ttl 2160h0m0s
+
+	# Create an hourly backup that's skipped when the previous one is still running
+	velero create schedule NAME --schedule="@every 1h" --overlap-policy Skip
+
+	# Create a backup hourly on weekdays and daily on weekends, at midnight in Berlin
+	velero create schedule NAME --schedule="0 * * * 1-5" --additional-schedule="0 0 * * 0,6" --timezone Europe/Berlin
+
+	# Create a daily backup, keeping 7 daily, 4 weekly and 12 monthly backups
+	velero create schedule NAME --schedule="0 1 * * *" --keep-daily 7 --keep-weekly 4 --keep-monthly 12
+
+	# Create a daily backup that's verified by restoring 3 of its namespaces into scratch namespaces
+	velero create schedule NAME --schedule="0 1 * * *" --verify ScratchNamespace --verify-sample-namespaces 3
 	`,
 		Args: cobra.ExactArgs(1),
 		Run: func(c *cobra.Command, args []string) {
@@ -79,8 +96,14 @@ example: "@every 2h30m".`,
 }
 
 type CreateOptions struct {
-	BackupOptions *backup.CreateOptions
-	Schedule      string
+	BackupOptions       *backup.CreateOptions
+	Schedule            string
+	AdditionalSchedules []string
+	TimeZone            string
+	OverlapPolicy       *flag.Enum
+	Retention           api.ScheduleRetention
+	Verify              *flag.Enum
+	VerifySamples       int
 
 	labelSelector *metav1.LabelSelector
 }
@@ -88,12 +111,41 @@ type CreateOptions struct {
 func NewCreateOptions() *CreateOptions {
 	return &CreateOptions{
 		BackupOptions: backup.NewCreateOptions(),
+		OverlapPolicy: flag.NewEnum(
+			"",
+			string(api.ScheduleOverlapPolicyQueue),
+			string(api.ScheduleOverlapPolicySkip),
+			string(api.ScheduleOverlapPolicyAllow),
+		),
+		Verify: flag.NewEnum(
+			"",
+			string(api.ScheduleVerificationModeDryRun),
+			string(api.ScheduleVerificationModeScratchNamespace),
+		),
 	}
 }
 
 func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	o.BackupOptions.BindFlags(flags)
 	flags.StringVar(&o.Schedule, "schedule", o.Schedule, "a cron expression specifying a recurring schedule for this backup to run")
+	flags.StringArrayVar(&o.AdditionalSchedules, "additional-schedule", o.AdditionalSchedules, "an additional cron expression on which this backup also runs. Can be specified more than once. Optional.")
+	flags.StringVar(&o.TimeZone, "timezone", o.TimeZone, "the IANA time zone, e.g. Europe/Berlin, that the cron expressions are evaluated in (default UTC). Optional.")
+	flags.Var(
+		o.OverlapPolicy,
+		"overlap-policy",
+		fmt.Sprintf("what happens when the schedule is due while its previous backup is still running. Valid values are %s (default %s)", strings.Join(o.OverlapPolicy.AllowedValues(), ","), api.ScheduleOverlapPolicyQueue),
+	)
+	flags.IntVar(&o.Retention.KeepHourly, "keep-hourly", o.Retention.KeepHourly, "number of hourly backups to keep. Setting any of the --keep flags deletes completed backups that none of them keep, instead of when their TTL expires. Optional.")
+	flags.IntVar(&o.Retention.KeepDaily, "keep-daily", o.Retention.KeepDaily, "number of daily backups to keep. Optional.")
+	flags.IntVar(&o.Retention.KeepWeekly, "keep-weekly", o.Retention.KeepWeekly, "number of weekly backups to keep. Optional.")
+	flags.IntVar(&o.Retention.KeepMonthly, "keep-monthly", o.Retention.KeepMonthly, "number of monthly backups to keep. Optional.")
+	flags.IntVar(&o.Retention.KeepYearly, "keep-yearly", o.Retention.KeepYearly, "number of yearly backups to keep. Optional.")
+	flags.Var(
+		o.Verify,
+		"verify",
+		fmt.Sprintf("verify each backup once it completes by restoring it. Valid values are %s. Optional.", strings.Join(o.Verify.AllowedValues(), ",")),
+	)
+	flags.IntVar(&o.VerifySamples, "verify-sample-namespaces", o.VerifySamples, "number of randomly chosen namespaces to restore when verifying a backup (default all). Optional.")
 }
 
 func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Factory) error {
@@ -101,6 +153,13 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Facto
 		return errors.New("--schedule is required")
 	}
 
+	if o.VerifySamples != 0 && o.Verify.String() == "" {
+		return errors.New("--verify-sample-namespaces can only be used with --verify")
+	}
+	if o.VerifySamples < 0 {
+		return errors.New("--verify-sample-namespaces must not be negative")
+	}
+
 	return o.BackupOptions.Validate(c, args, f)
 }
 
@@ -122,21 +181,53 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 		},
 		Spec: api.ScheduleSpec{
 			Template: api.BackupSpec{
-				IncludedNamespaces:      o.BackupOptions.IncludeNamespaces,
-				ExcludedNamespaces:      o.BackupOptions.ExcludeNamespaces,
-				IncludedResources:       o.BackupOptions.IncludeResources,
-				ExcludedResources:       o.BackupOptions.ExcludeResources,
-				IncludeClusterResources: o.BackupOptions.IncludeClusterResources.Value,
-				LabelSelector:           o.BackupOptions.Selector.LabelSelector,
-				SnapshotVolumes:         o.BackupOptions.SnapshotVolumes.Value,
-				TTL:                     metav1.Duration{Duration: o.BackupOptions.TTL},
-				StorageLocation:         o.BackupOptions.StorageLocation,
-				VolumeSnapshotLocations: o.BackupOptions.SnapshotLocations,
+				IncludedNamespaces:           o.BackupOptions.IncludeNamespaces,
+				ExcludedNamespaces:           o.BackupOptions.ExcludeNamespaces,
+				IncludedResources:            o.BackupOptions.IncludeResources,
+				ExcludedResources:            o.BackupOptions.ExcludeResources,
+				IncludeClusterResources:      o.BackupOptions.IncludeClusterResources.Value,
+				IncludedClusterResourceNames: o.BackupOptions.IncludeClusterResourceNames,
+				ExcludedClusterResourceNames: o.BackupOptions.ExcludeClusterResourceNames,
+				ResourcePolicy:               o.BackupOptions.ResourcePolicy,
+				LabelSelector:                o.BackupOptions.Selector.LabelSelector,
+				OrLabelSelectors:             o.BackupOptions.OrSelector.OrLabelSelectors,
+				SnapshotVolumes:              o.BackupOptions.SnapshotVolumes.Value,
+				SnapshotMoveData:             o.BackupOptions.SnapshotMoveData,
+				DefaultVolumesToRestic:       o.BackupOptions.DefaultVolumesToRestic.Value,
+				TTL:                          metav1.Duration{Duration: o.BackupOptions.TTL},
+				StorageLocation:              o.BackupOptions.StorageLocation,
+				StoragePrefix:                o.BackupOptions.StoragePrefix,
+				ReplicaStorageLocations:      o.BackupOptions.ReplicaLocations,
+				FailoverStorageLocations:     o.BackupOptions.FailoverLocations,
+				VolumeSnapshotLocations:      o.BackupOptions.SnapshotLocations,
+				Mode:                         o.BackupOptions.BackupMode(),
+				SkipUnchangedItems:           o.BackupOptions.SkipUnchangedItems,
+				PodVolumeFailurePolicy:       api.PodVolumeFailurePolicy(o.BackupOptions.PodVolumeFailurePolicy.String()),
+				DeleteProtection:             o.BackupOptions.DeleteProtection,
+				LogLevel:                     o.BackupOptions.LogLevel.String(),
+				RetryPolicy:                  o.BackupOptions.RetryPolicy(),
 			},
-			Schedule: o.Schedule,
+			Schedule:      o.Schedule,
+			Schedules:     o.AdditionalSchedules,
+			TimeZone:      o.TimeZone,
+			OverlapPolicy: api.ScheduleOverlapPolicy(o.OverlapPolicy.String()),
 		},
 	}
 
+	for _, name := range []string{"keep-hourly", "keep-daily", "keep-weekly", "keep-monthly", "keep-yearly"} {
+		if c.Flags().Changed(name) {
+			schedule.Spec.Retention = &o.Retention
+			break
+		}
+	}
+
+	if o.Verify.String() != "" {
+		schedule.Spec.Verification = &api.ScheduleVerification{
+			Mode:             api.ScheduleVerificationMode(o.Verify.String()),
+			SampleNamespaces: o.VerifySamples,
+		}
+	}
+
 	if printed, err := output.PrintWithFormat(c, schedule); printed || err != nil {
 		return err
 	}
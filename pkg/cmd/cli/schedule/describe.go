@@ -35,6 +35,9 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 		Use:   use + " [NAME1] [NAME2] [NAME...]",
 		Short: "Describe schedules",
 		Run: func(c *cobra.Command, args []string) {
+			err := output.ValidateDescribeFlags(c)
+			cmd.CheckError(err)
+
 			veleroClient, err := f.Client()
 			cmd.CheckError(err)
 
@@ -51,6 +54,11 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 				cmd.CheckError(err)
 			}
 
+			if printed, err := output.PrintWithFormat(c, schedules); printed || err != nil {
+				cmd.CheckError(err)
+				return
+			}
+
 			first := true
 			for _, schedule := range schedules.Items {
 				s := output.DescribeSchedule(&schedule)
@@ -66,6 +74,7 @@ func NewDescribeCommand(f client.Factory, use string) *cobra.Command {
 	}
 
 	c.Flags().StringVarP(&listOptions.LabelSelector, "selector", "l", listOptions.LabelSelector, "only show items matching this label selector")
+	output.BindDescribeFlags(c.Flags())
 
 	return c
 }
@@ -22,6 +22,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -53,15 +54,17 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 }
 
 type CreateOptions struct {
-	Name     string
-	Provider string
-	Config   flag.Map
-	Labels   flag.Map
+	Name       string
+	Provider   string
+	Config     flag.Map
+	Labels     flag.Map
+	Credential flag.Map
 }
 
 func NewCreateOptions() *CreateOptions {
 	return &CreateOptions{
-		Config: flag.NewMap(),
+		Config:     flag.NewMap(),
+		Credential: flag.NewMap(),
 	}
 }
 
@@ -69,6 +72,7 @@ func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&o.Provider, "provider", o.Provider, "name of the volume snapshot provider (e.g. aws, azure, gcp)")
 	flags.Var(&o.Config, "config", "configuration key-value pairs")
 	flags.Var(&o.Labels, "labels", "labels to apply to the volume snapshot location")
+	flags.Var(&o.Credential, "credential", "key in a secret in the Velero namespace, in the form SECRET_NAME=KEY, with the credentials to take snapshots with instead of the provider's default credentials. Optional.")
 }
 
 func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Factory) error {
@@ -80,6 +84,28 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Facto
 		return errors.New("--provider is required")
 	}
 
+	if len(o.Credential.Data()) > 1 {
+		return errors.New("--credential can only have one value")
+	}
+	for secretName, key := range o.Credential.Data() {
+		if secretName == "" || key == "" {
+			return errors.New("--credential must be in the form SECRET_NAME=KEY")
+		}
+	}
+
+	return nil
+}
+
+// credential returns the secret key selector for the --credential flag, or
+// nil if it wasn't set.
+func (o *CreateOptions) credential() *corev1api.SecretKeySelector {
+	for secretName, key := range o.Credential.Data() {
+		return &corev1api.SecretKeySelector{
+			LocalObjectReference: corev1api.LocalObjectReference{Name: secretName},
+			Key:                  key,
+		}
+	}
+
 	return nil
 }
 
@@ -96,8 +122,9 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 			Labels:    o.Labels.Data(),
 		},
 		Spec: api.VolumeSnapshotLocationSpec{
-			Provider: o.Provider,
-			Config:   o.Config.Data(),
+			Provider:   o.Provider,
+			Config:     o.Config.Data(),
+			Credential: o.credential(),
 		},
 	}
 
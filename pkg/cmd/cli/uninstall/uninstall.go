@@ -0,0 +1,115 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/cmd/cli"
+	"github.com/heptio/velero/pkg/install"
+)
+
+// UninstallOptions collects all the options for uninstalling Velero from a Kubernetes cluster.
+type UninstallOptions struct {
+	Namespace string
+	KeepCRs   bool
+	Force     bool
+}
+
+// NewUninstallOptions instantiates a new, default UninstallOptions struct.
+func NewUninstallOptions() *UninstallOptions {
+	return &UninstallOptions{}
+}
+
+// BindFlags adds command line values to the options struct.
+func (o *UninstallOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.KeepCRs, "keep-crs", o.KeepCRs, "keep the Velero namespace and CRDs, and the backups, restores, schedules and locations in the namespace, so that a later install picks them up. Optional.")
+	flags.BoolVar(&o.Force, "force", o.Force, "uninstall without asking for confirmation. Optional.")
+}
+
+// NewCommand creates a cobra command.
+func NewCommand(f client.Factory) *cobra.Command {
+	o := NewUninstallOptions()
+	c := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Uninstall Velero",
+		Long: `
+Uninstall Velero from a Kubernetes cluster by deleting the Velero Deployment and Restic DaemonSets, the
+ClusterRoleBinding, the Velero namespace and all the Velero CustomResourceDefinitions, along with the backups,
+restores, schedules and locations in the cluster.
+
+The Velero Deployment and Restic DaemonSets are deleted first, and their pods are waited on, so that no server
+is left running to process the custom resources as they're deleted. Backups in object storage and volume
+snapshots are never deleted, so a later install using the same backup storage location syncs the backups back.
+
+Use '--keep-crs' to only delete the Deployment, DaemonSets and ClusterRoleBinding, keeping the namespace, CRDs
+and custom resources.
+		`,
+		Example: `	# velero uninstall
+
+	# velero uninstall --keep-crs --force`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(args, f))
+			cmd.CheckError(o.Run(f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+// Complete completes options for a command.
+func (o *UninstallOptions) Complete(args []string, f client.Factory) error {
+	o.Namespace = f.Namespace()
+	return nil
+}
+
+// Run executes a command in the context of the provided arguments.
+func (o *UninstallOptions) Run(f client.Factory) error {
+	if o.KeepCRs {
+		fmt.Printf("The Velero deployment, restic daemonsets and velero ClusterRoleBinding will be deleted from namespace %q.\n", o.Namespace)
+	} else {
+		fmt.Printf("Namespace %q and the Velero CRDs will be deleted, along with all backups, restores, schedules and locations in the cluster.\n", o.Namespace)
+	}
+	fmt.Println("Backups in object storage and volume snapshots will not be deleted.")
+	if !o.Force && !cli.GetConfirmation() {
+		// Don't do anything unless we get confirmation
+		return nil
+	}
+
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+	factory := client.NewDynamicFactory(dynamicClient)
+
+	if err := install.Uninstall(factory, install.UninstallResources(o.Namespace, o.KeepCRs), os.Stdout); err != nil {
+		return errors.Wrap(err, "\n\nError uninstalling Velero")
+	}
+
+	fmt.Println("Velero is uninstalled.")
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/util/kube"
+)
+
+// migrateArkLabels renames the Ark labels and annotations of resources
+// created before the rename to Velero.
+func migrateArkLabels(obj metav1.Object) []string {
+	if kube.MigrateArkLabelsAndAnnotations(obj) {
+		return []string{"renamed ark.heptio.com labels and annotations"}
+	}
+	return nil
+}
+
+// migrateBackupStorageLocation moves a location's access mode from its
+// deprecated status field to its spec, in addition to migrateArkLabels.
+func migrateBackupStorageLocation(location *velerov1api.BackupStorageLocation) []string {
+	changes := migrateArkLabels(location)
+
+	if location.Status.AccessMode != "" {
+		if location.Spec.AccessMode == "" {
+			location.Spec.AccessMode = location.Status.AccessMode
+			changes = append(changes, fmt.Sprintf("moved accessMode %s from status to spec", location.Spec.AccessMode))
+		} else {
+			changes = append(changes, "removed deprecated status.accessMode")
+		}
+		location.Status.AccessMode = ""
+	}
+
+	return changes
+}
+
+// migrator migrates the Velero custom resources in a namespace, writing
+// each change to w, and only updating the resources if dryRun is false.
+type migrator struct {
+	client    velerov1client.VeleroV1Interface
+	namespace string
+	dryRun    bool
+	w         io.Writer
+
+	// changes is the number of resources that were, or would be, changed.
+	changes int
+}
+
+// record writes the changes made to a resource, and returns whether it
+// should be updated.
+func (m *migrator) record(kind, name string, changes []string) bool {
+	if len(changes) == 0 {
+		return false
+	}
+
+	m.changes++
+	verb := "migrated"
+	if m.dryRun {
+		verb = "would be migrated"
+	}
+	fmt.Fprintf(m.w, "%s/%s: %s: %s\n", kind, name, verb, strings.Join(changes, ", "))
+
+	return !m.dryRun
+}
+
+func (m *migrator) migrateBackups() error {
+	list, err := m.client.Backups(m.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		if m.record("Backup", item.Name, migrateArkLabels(item)) {
+			if _, err := m.client.Backups(m.namespace).Update(item); err != nil {
+				return errors.Wrapf(err, "error updating backup %s", item.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *migrator) migrateRestores() error {
+	list, err := m.client.Restores(m.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		if m.record("Restore", item.Name, migrateArkLabels(item)) {
+			if _, err := m.client.Restores(m.namespace).Update(item); err != nil {
+				return errors.Wrapf(err, "error updating restore %s", item.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *migrator) migrateSchedules() error {
+	list, err := m.client.Schedules(m.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		if m.record("Schedule", item.Name, migrateArkLabels(item)) {
+			if _, err := m.client.Schedules(m.namespace).Update(item); err != nil {
+				return errors.Wrapf(err, "error updating schedule %s", item.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *migrator) migrateBackupStorageLocations() error {
+	list, err := m.client.BackupStorageLocations(m.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		if m.record("BackupStorageLocation", item.Name, migrateBackupStorageLocation(item)) {
+			if _, err := m.client.BackupStorageLocations(m.namespace).Update(item); err != nil {
+				return errors.Wrapf(err, "error updating backup storage location %s", item.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// migrate migrates all the backups, restores, schedules and backup storage
+// locations in the namespace.
+func (m *migrator) migrate() error {
+	for _, migrate := range []func() error{
+		m.migrateBackups,
+		m.migrateRestores,
+		m.migrateSchedules,
+		m.migrateBackupStorageLocations,
+	} {
+		if err := migrate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upgradeLayout records the current layout version in a location's backup
+// store, and returns whether it was, or would be, changed.
+func upgradeLayout(location string, backupStore persistence.BackupStore, dryRun bool, w io.Writer) (bool, error) {
+	version, err := backupStore.GetLayoutVersion()
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case version == persistence.LayoutVersion:
+		fmt.Fprintf(w, "BackupStorageLocation/%s: layout version %d is up to date\n", location, version)
+		return false, nil
+	case version > persistence.LayoutVersion:
+		return false, errors.Errorf("backup storage location %s has layout version %d, which is newer than version %d that this version of Velero supports", location, version, persistence.LayoutVersion)
+	}
+
+	verb := "upgraded"
+	if dryRun {
+		verb = "would be upgraded"
+	}
+	fmt.Fprintf(w, "BackupStorageLocation/%s: layout version %s from %d to %d\n", location, verb, version, persistence.LayoutVersion)
+
+	if dryRun {
+		return true, nil
+	}
+	return true, backupStore.PutLayoutVersion(persistence.LayoutVersion)
+}
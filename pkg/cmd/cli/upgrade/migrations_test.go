@@ -0,0 +1,144 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+)
+
+func TestMigrator(t *testing.T) {
+	newObjects := func() *fake.Clientset {
+		statusAccessMode := builder.ForBackupStorageLocation("velero", "status-access-mode").Result()
+		statusAccessMode.Status.AccessMode = velerov1api.BackupStorageLocationAccessModeReadOnly
+
+		return fake.NewSimpleClientset(
+			builder.ForBackup("velero", "ark-backup").ObjectMeta(builder.WithLabels("ark.heptio.com/schedule-name", "daily")).Result(),
+			builder.ForBackup("velero", "velero-backup").ObjectMeta(builder.WithLabels("velero.io/schedule-name", "daily")).Result(),
+			builder.ForRestore("velero", "ark-restore").ObjectMeta(builder.WithAnnotations("ark.heptio.com/foo", "bar")).Result(),
+			builder.ForSchedule("velero", "daily").Result(),
+			builder.ForBackupStorageLocation("velero", "default").Result(),
+			statusAccessMode,
+		)
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		client := newObjects()
+		buf := new(bytes.Buffer)
+		m := &migrator{client: client.VeleroV1(), namespace: "velero", dryRun: true, w: buf}
+
+		require.NoError(t, m.migrate())
+		assert.Equal(t, 3, m.changes)
+		assert.Equal(t, "Backup/ark-backup: would be migrated: renamed ark.heptio.com labels and annotations\n"+
+			"Restore/ark-restore: would be migrated: renamed ark.heptio.com labels and annotations\n"+
+			"BackupStorageLocation/status-access-mode: would be migrated: moved accessMode ReadOnly from status to spec\n", buf.String())
+
+		backup, err := client.VeleroV1().Backups("velero").Get("ark-backup", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"ark.heptio.com/schedule-name": "daily"}, backup.Labels)
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		client := newObjects()
+		m := &migrator{client: client.VeleroV1(), namespace: "velero", w: new(bytes.Buffer)}
+
+		require.NoError(t, m.migrate())
+		assert.Equal(t, 3, m.changes)
+
+		backup, err := client.VeleroV1().Backups("velero").Get("ark-backup", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"velero.io/schedule-name": "daily"}, backup.Labels)
+
+		restore, err := client.VeleroV1().Restores("velero").Get("ark-restore", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"velero.io/foo": "bar"}, restore.Annotations)
+
+		location, err := client.VeleroV1().BackupStorageLocations("velero").Get("status-access-mode", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, velerov1api.BackupStorageLocationAccessModeReadOnly, location.Spec.AccessMode)
+		assert.Empty(t, location.Status.AccessMode)
+
+		// migrating again is a no-op
+		m = &migrator{client: client.VeleroV1(), namespace: "velero", w: new(bytes.Buffer)}
+		require.NoError(t, m.migrate())
+		assert.Equal(t, 0, m.changes)
+	})
+}
+
+func TestUpgradeLayout(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     int
+		dryRun      bool
+		expectPut   bool
+		expectErr   bool
+		expectedOut string
+	}{
+		{
+			name:        "store without a marker is upgraded",
+			version:     0,
+			expectPut:   true,
+			expectedOut: "BackupStorageLocation/default: layout version upgraded from 0 to 1\n",
+		},
+		{
+			name:        "dry run doesn't put the marker",
+			version:     0,
+			dryRun:      true,
+			expectedOut: "BackupStorageLocation/default: layout version would be upgraded from 0 to 1\n",
+		},
+		{
+			name:        "store at the current version is up to date",
+			version:     1,
+			expectedOut: "BackupStorageLocation/default: layout version 1 is up to date\n",
+		},
+		{
+			name:      "store at a newer version is an error",
+			version:   2,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			backupStore := new(persistencemocks.BackupStore)
+			backupStore.On("GetLayoutVersion").Return(tc.version, nil)
+			if tc.expectPut {
+				backupStore.On("PutLayoutVersion", 1).Return(nil)
+			}
+
+			buf := new(bytes.Buffer)
+			changed, err := upgradeLayout("default", backupStore, tc.dryRun, buf)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.version < 1, changed)
+			assert.Equal(t, tc.expectedOut, buf.String())
+			backupStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	"github.com/heptio/velero/pkg/install"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	"github.com/heptio/velero/pkg/util/logging"
+)
+
+// UpgradeOptions collects all the options for upgrading a Velero installation.
+type UpgradeOptions struct {
+	Namespace        string
+	DryRun           bool
+	SkipBackupStores bool
+	PluginDir        string
+}
+
+// NewUpgradeOptions instantiates a new, default UpgradeOptions struct.
+func NewUpgradeOptions() *UpgradeOptions {
+	return &UpgradeOptions{}
+}
+
+// BindFlags adds command line values to the options struct.
+func (o *UpgradeOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.DryRun, "dry-run", o.DryRun, "print the changes that would be made, without making them. Optional.")
+	flags.BoolVar(&o.SkipBackupStores, "skip-backup-stores", o.SkipBackupStores, "don't upgrade the layout version of the backup storage locations' object storage, which requires their credentials to be available locally. Optional.")
+	flags.StringVar(&o.PluginDir, "plugin-dir", o.PluginDir, "directory containing Velero plugins to use in addition to the built-in ones. Optional.")
+}
+
+// NewCommand creates a cobra command.
+func NewCommand(f client.Factory) *cobra.Command {
+	o := NewUpgradeOptions()
+	c := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade the Velero CRDs, custom resources and backup storage locations",
+		Long: `
+Upgrade a Velero installation to the version of this client, after upgrading the Velero server image:
+
+1. The Velero CustomResourceDefinitions are created or patched to match this version.
+2. Existing backups, restores, schedules and backup storage locations are migrated: Ark labels and
+   annotations are renamed to Velero's, and backup storage locations' deprecated status.accessMode is
+   moved to spec.accessMode.
+3. The layout version marker in each backup storage location's object storage is updated. The object
+   storage is read and written directly from this machine using the location's object store plugin, so
+   the credentials for it must be available locally, or '--skip-backup-stores' must be used.
+   Read-only locations are skipped.
+
+Use '--dry-run' to print the changes that would be made without making them.
+		`,
+		Example: `	# velero upgrade --dry-run
+
+	# velero upgrade --skip-backup-stores`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(args, f))
+			cmd.CheckError(o.Run(f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+// Complete completes options for a command.
+func (o *UpgradeOptions) Complete(args []string, f client.Factory) error {
+	o.Namespace = f.Namespace()
+	return nil
+}
+
+// Run executes a command in the context of the provided arguments.
+func (o *UpgradeOptions) Run(f client.Factory) error {
+	w := os.Stdout
+	if o.DryRun {
+		fmt.Fprintln(w, "Dry run: no changes will be made.")
+	}
+
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+	crdChanges, err := install.UpgradeCRDs(client.NewDynamicFactory(dynamicClient), o.DryRun, w)
+	if err != nil {
+		return errors.Wrap(err, "error upgrading CRDs")
+	}
+
+	veleroClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+	m := &migrator{
+		client:    veleroClient.VeleroV1(),
+		namespace: o.Namespace,
+		dryRun:    o.DryRun,
+		w:         w,
+	}
+	if err := m.migrate(); err != nil {
+		return errors.Wrap(err, "error migrating custom resources")
+	}
+
+	var storeChanges int
+	if !o.SkipBackupStores {
+		if storeChanges, err = o.upgradeBackupStores(m.client.BackupStorageLocations(o.Namespace), w); err != nil {
+			return err
+		}
+	}
+
+	changes := crdChanges + m.changes + storeChanges
+	switch {
+	case changes == 0:
+		fmt.Fprintln(w, "Velero is up to date.")
+	case o.DryRun:
+		fmt.Fprintf(w, "%d changes would be made.\n", changes)
+	default:
+		fmt.Fprintf(w, "Velero is upgraded, %d changes were made.\n", changes)
+	}
+	return nil
+}
+
+// upgradeBackupStores upgrades the layout version of the backup stores of
+// all the backup storage locations that aren't read-only, and returns the
+// number that were, or would be, upgraded.
+func (o *UpgradeOptions) upgradeBackupStores(client velerov1client.BackupStorageLocationInterface, w io.Writer) (int, error) {
+	locations, err := client.List(metav1.ListOptions{})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	logger := logging.DefaultLogger(logrus.WarnLevel, logging.FormatText)
+
+	registry := clientmgmt.NewRegistry(o.PluginDir, logger, logger.Level)
+	if err := registry.DiscoverPlugins(); err != nil {
+		return 0, errors.Wrap(err, "error discovering plugins")
+	}
+	pluginManager := clientmgmt.NewManager(logger, logger.Level, registry)
+	defer pluginManager.CleanupClients()
+
+	var changes int
+	for i := range locations.Items {
+		location := &locations.Items[i]
+		if location.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+			fmt.Fprintf(w, "BackupStorageLocation/%s: read-only, skipping layout version\n", location.Name)
+			continue
+		}
+
+		backupStore, err := persistence.NewBackupStore(location, pluginManager, logger)
+		if err != nil {
+			return changes, errors.Wrapf(err, "error getting backup store for location %s", location.Name)
+		}
+
+		changed, err := upgradeLayout(location.Name, backupStore, o.DryRun, w)
+		if err != nil {
+			return changes, errors.Wrapf(err, "error upgrading layout version of location %s", location.Name)
+		}
+		if changed {
+			changes++
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifyinstall
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Report is the result of verifying an installation.
+type Report struct {
+	// Namespace is the namespace the sample app was deployed to.
+	Namespace string `json:"namespace"`
+	// Backup and Restore are the names of the backup and restore of the
+	// sample app.
+	Backup  string `json:"backup"`
+	Restore string `json:"restore"`
+
+	// Passed is true if every step passed.
+	Passed bool `json:"passed"`
+	// Duration is how long the steps took altogether.
+	Duration metav1.Duration `json:"duration"`
+	// Steps are the results of the steps that were run. The steps after
+	// one that failed aren't run.
+	Steps []StepResult `json:"steps"`
+
+	// CleanupErrors are the errors deleting the sample app, backup and
+	// restore afterwards.
+	CleanupErrors []string `json:"cleanupErrors,omitempty"`
+}
+
+// StepResult is the result of a step of the verification.
+type StepResult struct {
+	Name     string          `json:"name"`
+	Passed   bool            `json:"passed"`
+	Duration metav1.Duration `json:"duration"`
+	// Message describes what the step did, or why it failed.
+	Message string `json:"message"`
+}
+
+// printReport writes the report to w in the output format, which is json,
+// yaml, or, if it's empty, a table.
+func printReport(w io.Writer, report *Report, format string) error {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return errors.Wrap(err, "error encoding report")
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case "yaml":
+		out, err := yaml.Marshal(report)
+		if err != nil {
+			return errors.Wrap(err, "error encoding report")
+		}
+		_, err = w.Write(out)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tRESULT\tDURATION\tMESSAGE")
+	for _, step := range report.Steps {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", step.Name, result(step.Passed), step.Duration.Duration, step.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	if report.Passed {
+		fmt.Fprintf(w, "Verification passed in %s.\n", report.Duration.Duration)
+	} else {
+		fmt.Fprintf(w, "Verification failed after %s. Run with --keep-resources to keep the sample app in namespace %s, backup %s and restore %s for investigation.\n", report.Duration.Duration, report.Namespace, report.Backup, report.Restore)
+	}
+
+	for _, err := range report.CleanupErrors {
+		fmt.Fprintf(w, "Cleanup error: %s\n", err)
+	}
+
+	return nil
+}
+
+func result(passed bool) string {
+	if passed {
+		return "Passed"
+	}
+	return "Failed"
+}
@@ -0,0 +1,469 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifyinstall
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/cmd"
+	"github.com/heptio/velero/pkg/sdk"
+)
+
+const (
+	appName       = "verify-app"
+	configMapName = "verify-token"
+	claimName     = "verify-data"
+	volumeName    = "data"
+	tokenKey      = "token"
+)
+
+// NewCommand returns the verify-install command, which checks that backups
+// and restores work end to end with the installation's storage location,
+// volume snapshotter and plugins.
+func NewCommand(f client.Factory) *cobra.Command {
+	o := NewOptions()
+
+	c := &cobra.Command{
+		Use:   "verify-install",
+		Short: "Verify that backups and restores work end to end",
+		Long: `Verify that backups and restores work end to end with the installation's storage location, volume
+snapshots or restic, and plugins.
+
+A sample app, a pod that writes a random token to a persistent volume, is deployed to a new namespace and
+backed up. The namespace is then deleted and restored from the backup, and the restored app's volume data
+and config are checked against the token. The sample app, backup and restore are deleted afterwards.`,
+		Example: `	# verify the installation with volume snapshots and the default storage location
+	velero verify-install
+
+	# verify the installation with restic and a report in JSON
+	velero verify-install --use-restic -o json`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Validate())
+
+			kubeClient, err := f.KubeClient()
+			cmd.CheckError(err)
+			veleroClient, err := f.Client()
+			cmd.CheckError(err)
+
+			v := &verifier{
+				options:      o,
+				kubeClient:   kubeClient,
+				veleroClient: sdk.New(veleroClient, f.Namespace()),
+				pollInterval: time.Second,
+			}
+
+			if o.Output == "" {
+				fmt.Printf("Verifying Velero installation using namespace %s...\n", o.AppNamespace)
+			}
+			report := v.run()
+			cmd.CheckError(printReport(os.Stdout, report, o.Output))
+
+			if !report.Passed {
+				cmd.CheckError(errors.New("verification failed"))
+			}
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+// Options are the options of the verify-install command.
+type Options struct {
+	AppNamespace    string
+	StorageLocation string
+	StorageClass    string
+	UseRestic       bool
+	Image           string
+	Timeout         time.Duration
+	KeepResources   bool
+	Output          string
+}
+
+// NewOptions returns the default options of the verify-install command.
+func NewOptions() *Options {
+	return &Options{
+		AppNamespace: fmt.Sprintf("velero-verify-%d", time.Now().Unix()),
+		Image:        "busybox:1.31",
+		Timeout:      10 * time.Minute,
+	}
+}
+
+// BindFlags binds the options to command-line flags.
+func (o *Options) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.AppNamespace, "app-namespace", o.AppNamespace, "namespace to deploy the sample app to, which must not exist. It's also the name of the backup and restore.")
+	flags.StringVar(&o.StorageLocation, "storage-location", o.StorageLocation, "location to store the backup in. If empty, the server's default location is used.")
+	flags.StringVar(&o.StorageClass, "storage-class", o.StorageClass, "storage class of the sample app's persistent volume. If empty, the cluster's default storage class is used.")
+	flags.BoolVar(&o.UseRestic, "use-restic", o.UseRestic, "back up the sample app's volume with restic instead of a volume snapshot")
+	flags.StringVar(&o.Image, "image", o.Image, "container image of the sample app, which must have a shell")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "how long to wait for each step to finish")
+	flags.BoolVar(&o.KeepResources, "keep-resources", o.KeepResources, "don't delete the sample app, backup and restore afterwards, e.g. to investigate a failure")
+	flags.StringVarP(&o.Output, "output", "o", o.Output, "output format of the report: json or yaml. If empty, a table is printed.")
+}
+
+// Validate returns an error if the options are invalid.
+func (o *Options) Validate() error {
+	switch o.Output {
+	case "", "json", "yaml":
+	default:
+		return errors.Errorf("invalid output format %q, must be json or yaml", o.Output)
+	}
+	if o.Timeout <= 0 {
+		return errors.New("--timeout must be positive")
+	}
+	return nil
+}
+
+// verifier runs the steps of an installation's verification.
+type verifier struct {
+	options      *Options
+	kubeClient   kubernetes.Interface
+	veleroClient *sdk.Client
+	pollInterval time.Duration
+
+	// token is written to the sample app's volume and config, and checked
+	// after the restore.
+	token string
+}
+
+// step is a step of the verification. It returns a message describing what
+// it did, or an error if it failed.
+type step struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// run verifies the installation and returns the report. The steps are run
+// in order until one fails, after which the sample app, backup and restore
+// are deleted unless they're to be kept.
+func (v *verifier) run() *Report {
+	report := &Report{
+		Namespace: v.options.AppNamespace,
+		Backup:    v.options.AppNamespace,
+		Restore:   v.options.AppNamespace,
+		Passed:    true,
+	}
+
+	steps := []step{
+		{name: "Deploy sample app", run: v.deployApp},
+		{name: "Back up sample app", run: v.backUpApp},
+		{name: "Delete sample app", run: v.deleteApp},
+		{name: "Restore sample app", run: v.restoreApp},
+		{name: "Validate restored app", run: v.validateApp},
+	}
+
+	started := time.Now()
+	for _, s := range steps {
+		report.Steps = append(report.Steps, v.runStep(s))
+		if !report.Steps[len(report.Steps)-1].Passed {
+			report.Passed = false
+			break
+		}
+	}
+	report.Duration = metav1.Duration{Duration: time.Since(started).Round(time.Second)}
+
+	if !v.options.KeepResources {
+		report.CleanupErrors = v.cleanUp()
+	}
+
+	return report
+}
+
+func (v *verifier) runStep(s step) StepResult {
+	ctx, cancel := context.WithTimeout(context.Background(), v.options.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	message, err := s.run(ctx)
+
+	res := StepResult{
+		Name:     s.name,
+		Passed:   err == nil,
+		Duration: metav1.Duration{Duration: time.Since(started).Round(time.Second)},
+		Message:  message,
+	}
+	if err != nil {
+		res.Message = err.Error()
+	}
+	return res
+}
+
+func (v *verifier) deployApp(ctx context.Context) (string, error) {
+	ns := v.options.AppNamespace
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", errors.Wrap(err, "error generating token")
+	}
+	v.token = hex.EncodeToString(token)
+
+	if _, err := v.kubeClient.CoreV1().Namespaces().Create(builder.ForNamespace(ns).Result()); err != nil {
+		return "", errors.Wrapf(err, "error creating namespace %s", ns)
+	}
+
+	configMap := builder.ForConfigMap(ns, configMapName).Data(tokenKey, v.token).Result()
+	if _, err := v.kubeClient.CoreV1().ConfigMaps(ns).Create(configMap); err != nil {
+		return "", errors.Wrapf(err, "error creating config map %s", configMapName)
+	}
+
+	claim := builder.ForPersistentVolumeClaim(ns, claimName).Result()
+	claim.Spec.AccessModes = []corev1api.PersistentVolumeAccessMode{corev1api.ReadWriteOnce}
+	claim.Spec.Resources.Requests = corev1api.ResourceList{
+		corev1api.ResourceStorage: resource.MustParse("1Gi"),
+	}
+	if v.options.StorageClass != "" {
+		claim.Spec.StorageClassName = &v.options.StorageClass
+	}
+	if _, err := v.kubeClient.CoreV1().PersistentVolumeClaims(ns).Create(claim); err != nil {
+		return "", errors.Wrapf(err, "error creating persistent volume claim %s", claimName)
+	}
+
+	if _, err := v.kubeClient.CoreV1().Pods(ns).Create(v.appPod()); err != nil {
+		return "", errors.Wrapf(err, "error creating pod %s", appName)
+	}
+
+	written, err := v.waitForTokenLog(ctx)
+	if err != nil {
+		return "", err
+	}
+	if written != "written "+v.token {
+		return "", errors.Errorf("sample app logged %q instead of writing the token to its volume", written)
+	}
+
+	return fmt.Sprintf("pod %s wrote a token to persistent volume claim %s", appName, claimName), nil
+}
+
+// appPod returns the sample app's pod, which writes the token to its volume
+// if the volume doesn't have it yet, logs whether it wrote or found it, and
+// then sleeps. A restored pod finds the token if the volume's data was
+// restored.
+func (v *verifier) appPod() *corev1api.Pod {
+	pod := builder.ForPod(v.options.AppNamespace, appName).
+		Volumes(builder.ForVolume(volumeName).PersistentVolumeClaimSource(claimName).Result()).
+		Result()
+
+	if v.options.UseRestic {
+		pod.Annotations = map[string]string{"backup.velero.io/backup-volumes": volumeName}
+	}
+
+	pod.Spec.Containers = []corev1api.Container{
+		{
+			Name:  appName,
+			Image: v.options.Image,
+			Command: []string{
+				"/bin/sh",
+				"-c",
+				`if [ -f /data/token ]; then echo "restored $(cat /data/token)"; else echo "$TOKEN" > /data/token && echo "written $TOKEN"; fi; while true; do sleep 3600; done`,
+			},
+			Env:          []corev1api.EnvVar{{Name: "TOKEN", Value: v.token}},
+			VolumeMounts: []corev1api.VolumeMount{*builder.ForVolumeMount(volumeName, "/data").Result()},
+		},
+	}
+
+	return pod
+}
+
+// waitForTokenLog waits for the sample app to log whether it wrote or found
+// the token, and returns the log line.
+func (v *verifier) waitForTokenLog(ctx context.Context) (string, error) {
+	var line string
+	err := wait.PollImmediateUntil(v.pollInterval, func() (bool, error) {
+		pod, err := v.kubeClient.CoreV1().Pods(v.options.AppNamespace).Get(appName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, errors.Wrapf(err, "error getting pod %s", appName)
+		}
+		if pod.Status.Phase != corev1api.PodRunning {
+			return false, nil
+		}
+
+		logs, err := v.kubeClient.CoreV1().Pods(v.options.AppNamespace).GetLogs(appName, &corev1api.PodLogOptions{Container: appName}).Do().Raw()
+		if err != nil {
+			return false, nil
+		}
+
+		line = tokenLogLine(string(logs))
+		return line != "", nil
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		return "", errors.Errorf("timed out waiting for pod %s to be running and log its token", appName)
+	}
+	return line, err
+}
+
+// tokenLogLine returns the line of the sample app's logs that says whether
+// it wrote or found the token, or an empty string if it hasn't logged it
+// yet.
+func tokenLogLine(logs string) string {
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "written ") || strings.HasPrefix(line, "restored ") {
+			return line
+		}
+	}
+	return ""
+}
+
+func (v *verifier) backUpApp(ctx context.Context) (string, error) {
+	backup := builder.ForBackup(v.veleroClient.Namespace(), v.options.AppNamespace).
+		IncludedNamespaces(v.options.AppNamespace).
+		StorageLocation(v.options.StorageLocation).
+		Result()
+	if v.options.UseRestic {
+		backup.Spec.SnapshotVolumes = new(bool)
+	}
+
+	if _, err := v.veleroClient.CreateBackup(backup); err != nil {
+		return "", err
+	}
+
+	backup, err := v.veleroClient.WaitForBackup(ctx, backup.Name)
+	if err != nil {
+		return "", err
+	}
+	if backup.Status.Phase != velerov1api.BackupPhaseCompleted {
+		return "", errors.Errorf("backup %s finished with phase %s, %d errors and %d warnings; run `velero backup describe %s` and `velero backup logs %s` for details", backup.Name, backup.Status.Phase, backup.Status.Errors, backup.Status.Warnings, backup.Name, backup.Name)
+	}
+
+	if v.options.UseRestic {
+		return fmt.Sprintf("backup %s completed", backup.Name), nil
+	}
+	if backup.Status.VolumeSnapshotsCompleted == 0 {
+		return "", errors.Errorf("backup %s completed without taking a volume snapshot; check that a volume snapshot location is configured for the storage class's provider, or use --use-restic", backup.Name)
+	}
+	return fmt.Sprintf("backup %s completed with %d volume snapshots", backup.Name, backup.Status.VolumeSnapshotsCompleted), nil
+}
+
+func (v *verifier) deleteApp(ctx context.Context) (string, error) {
+	ns := v.options.AppNamespace
+
+	if err := v.kubeClient.CoreV1().Namespaces().Delete(ns, &metav1.DeleteOptions{}); err != nil {
+		return "", errors.Wrapf(err, "error deleting namespace %s", ns)
+	}
+	if err := v.waitForNamespaceDeletion(ctx); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("namespace %s was deleted", ns), nil
+}
+
+func (v *verifier) waitForNamespaceDeletion(ctx context.Context) error {
+	ns := v.options.AppNamespace
+
+	err := wait.PollImmediateUntil(v.pollInterval, func() (bool, error) {
+		_, err := v.kubeClient.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, errors.Wrapf(err, "error getting namespace %s", ns)
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("timed out waiting for namespace %s to be deleted", ns)
+	}
+	return err
+}
+
+func (v *verifier) restoreApp(ctx context.Context) (string, error) {
+	restore := builder.ForRestore(v.veleroClient.Namespace(), v.options.AppNamespace).
+		Backup(v.options.AppNamespace).
+		Result()
+
+	if _, err := v.veleroClient.CreateRestore(restore); err != nil {
+		return "", err
+	}
+
+	restore, err := v.veleroClient.WaitForRestore(ctx, restore.Name)
+	if err != nil {
+		return "", err
+	}
+	if restore.Status.Phase != velerov1api.RestorePhaseCompleted {
+		return "", errors.Errorf("restore %s finished with phase %s, %d errors and %d warnings; run `velero restore describe %s` and `velero restore logs %s` for details", restore.Name, restore.Status.Phase, restore.Status.Errors, restore.Status.Warnings, restore.Name, restore.Name)
+	}
+
+	return fmt.Sprintf("restore %s completed", restore.Name), nil
+}
+
+func (v *verifier) validateApp(ctx context.Context) (string, error) {
+	ns := v.options.AppNamespace
+
+	configMap, err := v.kubeClient.CoreV1().ConfigMaps(ns).Get(configMapName, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting restored config map %s", configMapName)
+	}
+	if configMap.Data[tokenKey] != v.token {
+		return "", errors.Errorf("restored config map %s has token %q, expected %q", configMapName, configMap.Data[tokenKey], v.token)
+	}
+
+	line, err := v.waitForTokenLog(ctx)
+	if err != nil {
+		return "", err
+	}
+	if line != "restored "+v.token {
+		if strings.HasPrefix(line, "written ") {
+			return "", errors.Errorf("pod %s didn't find the token on its restored volume, so the volume's data wasn't restored", appName)
+		}
+		return "", errors.Errorf("pod %s found %q on its restored volume, expected %q", appName, strings.TrimPrefix(line, "restored "), v.token)
+	}
+
+	return fmt.Sprintf("config map %s and the data of persistent volume claim %s were restored", configMapName, claimName), nil
+}
+
+// cleanUp deletes the sample app, backup and restore, and returns the errors
+// of any that couldn't be deleted. The backup and restore are deleted by the
+// Velero server in the background.
+func (v *verifier) cleanUp() []string {
+	var errs []string
+
+	err := v.kubeClient.CoreV1().Namespaces().Delete(v.options.AppNamespace, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, errors.Wrapf(err, "error deleting namespace %s", v.options.AppNamespace).Error())
+	}
+
+	err = v.veleroClient.Clientset().VeleroV1().Restores(v.veleroClient.Namespace()).Delete(v.options.AppNamespace, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, errors.Wrapf(err, "error deleting restore %s", v.options.AppNamespace).Error())
+	}
+
+	if err := v.veleroClient.DeleteBackup(v.options.AppNamespace); err != nil && !apierrors.IsNotFound(errors.Cause(err)) {
+		errs = append(errs, err.Error())
+	}
+
+	return errs
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifyinstall
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	"github.com/heptio/velero/pkg/sdk"
+)
+
+func TestTokenLogLine(t *testing.T) {
+	assert.Equal(t, "", tokenLogLine(""))
+	assert.Equal(t, "written abc", tokenLogLine("written abc\n"))
+	assert.Equal(t, "restored abc", tokenLogLine("restic-wait done\nrestored abc\n"))
+}
+
+func TestValidateOptions(t *testing.T) {
+	o := NewOptions()
+	assert.NoError(t, o.Validate())
+
+	o.Output = "table"
+	assert.EqualError(t, o.Validate(), `invalid output format "table", must be json or yaml`)
+
+	o = NewOptions()
+	o.Timeout = 0
+	assert.EqualError(t, o.Validate(), "--timeout must be positive")
+}
+
+// newTestVerifier returns a verifier whose backups and restores finish with
+// the given phases as soon as they're created.
+func newTestVerifier(backupPhase velerov1api.BackupPhase, snapshots int, restorePhase velerov1api.RestorePhase) (*verifier, *fake.Clientset) {
+	veleroClient := fake.NewSimpleClientset()
+	veleroClient.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+		backup := builder.ForBackup(velerov1api.DefaultNamespace, action.(core.GetAction).GetName()).Phase(backupPhase).Result()
+		backup.Status.VolumeSnapshotsCompleted = snapshots
+		return true, backup, nil
+	})
+	veleroClient.PrependReactor("get", "restores", func(action core.Action) (bool, runtime.Object, error) {
+		return true, builder.ForRestore(velerov1api.DefaultNamespace, action.(core.GetAction).GetName()).Phase(restorePhase).Result(), nil
+	})
+
+	o := NewOptions()
+	o.AppNamespace = "velero-verify-1"
+	o.Timeout = time.Second
+
+	return &verifier{
+		options:      o,
+		kubeClient:   kubefake.NewSimpleClientset(),
+		veleroClient: sdk.New(veleroClient, velerov1api.DefaultNamespace, sdk.WithPollInterval(time.Millisecond)),
+		pollInterval: time.Millisecond,
+		token:        "abc",
+	}, veleroClient
+}
+
+func TestBackUpApp(t *testing.T) {
+	v, veleroClient := newTestVerifier(velerov1api.BackupPhaseCompleted, 1, "")
+	res := v.runStep(step{name: "Back up sample app", run: v.backUpApp})
+	assert.True(t, res.Passed)
+	assert.Equal(t, "backup velero-verify-1 completed with 1 volume snapshots", res.Message)
+
+	backups, err := veleroClient.VeleroV1().Backups(velerov1api.DefaultNamespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, backups.Items, 1)
+	assert.Equal(t, []string{"velero-verify-1"}, backups.Items[0].Spec.IncludedNamespaces)
+
+	v, _ = newTestVerifier(velerov1api.BackupPhaseCompleted, 0, "")
+	res = v.runStep(step{name: "Back up sample app", run: v.backUpApp})
+	assert.False(t, res.Passed)
+	assert.Contains(t, res.Message, "completed without taking a volume snapshot")
+
+	v, _ = newTestVerifier(velerov1api.BackupPhasePartiallyFailed, 1, "")
+	res = v.runStep(step{name: "Back up sample app", run: v.backUpApp})
+	assert.False(t, res.Passed)
+	assert.Contains(t, res.Message, "backup velero-verify-1 finished with phase PartiallyFailed")
+}
+
+func TestRestoreApp(t *testing.T) {
+	v, _ := newTestVerifier("", 0, velerov1api.RestorePhaseCompleted)
+	res := v.runStep(step{name: "Restore sample app", run: v.restoreApp})
+	assert.True(t, res.Passed)
+
+	v, _ = newTestVerifier("", 0, velerov1api.RestorePhaseFailed)
+	res = v.runStep(step{name: "Restore sample app", run: v.restoreApp})
+	assert.False(t, res.Passed)
+	assert.Contains(t, res.Message, "restore velero-verify-1 finished with phase Failed")
+}
+
+func TestValidateAppChecksRestoredConfigMap(t *testing.T) {
+	v, _ := newTestVerifier("", 0, "")
+	_, err := v.kubeClient.CoreV1().ConfigMaps("velero-verify-1").Create(builder.ForConfigMap("velero-verify-1", configMapName).Data(tokenKey, "xyz").Result())
+	require.NoError(t, err)
+
+	res := v.runStep(step{name: "Validate restored app", run: v.validateApp})
+	assert.False(t, res.Passed)
+	assert.Equal(t, `restored config map verify-token has token "xyz", expected "abc"`, res.Message)
+}
+
+func TestRunStopsAtFailedStep(t *testing.T) {
+	v, _ := newTestVerifier("", 0, "")
+	_, err := v.kubeClient.CoreV1().Namespaces().Create(builder.ForNamespace("velero-verify-1").Result())
+	require.NoError(t, err)
+
+	report := v.run()
+	assert.False(t, report.Passed)
+	require.Len(t, report.Steps, 1)
+	assert.Equal(t, "Deploy sample app", report.Steps[0].Name)
+	assert.Contains(t, report.Steps[0].Message, "error creating namespace velero-verify-1")
+
+	// the namespace is cleaned up even though the verifier didn't create
+	// it, and the missing backup and restore are ignored.
+	assert.Empty(t, report.CleanupErrors)
+}
+
+func TestPrintReport(t *testing.T) {
+	report := &Report{
+		Namespace: "velero-verify-1",
+		Backup:    "velero-verify-1",
+		Restore:   "velero-verify-1",
+		Passed:    true,
+		Duration:  metav1.Duration{Duration: time.Minute},
+		Steps: []StepResult{
+			{Name: "Deploy sample app", Passed: true, Duration: metav1.Duration{Duration: 10 * time.Second}, Message: "deployed"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printReport(&buf, report, ""))
+	assert.Equal(t, `STEP               RESULT  DURATION  MESSAGE
+Deploy sample app  Passed  10s       deployed
+
+Verification passed in 1m0s.
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, printReport(&buf, report, "yaml"))
+	assert.Contains(t, buf.String(), "passed: true\n")
+}
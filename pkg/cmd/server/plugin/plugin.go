@@ -25,6 +25,7 @@ import (
 	"github.com/heptio/velero/pkg/cloudprovider/aws"
 	"github.com/heptio/velero/pkg/cloudprovider/azure"
 	"github.com/heptio/velero/pkg/cloudprovider/gcp"
+	"github.com/heptio/velero/pkg/cloudprovider/swift"
 	velerodiscovery "github.com/heptio/velero/pkg/discovery"
 	veleroplugin "github.com/heptio/velero/pkg/plugin/framework"
 	"github.com/heptio/velero/pkg/restore"
@@ -41,6 +42,7 @@ func NewCommand(f client.Factory) *cobra.Command {
 				RegisterObjectStore("velero.io/aws", newAwsObjectStore).
 				RegisterObjectStore("velero.io/azure", newAzureObjectStore).
 				RegisterObjectStore("velero.io/gcp", newGcpObjectStore).
+				RegisterObjectStore("velero.io/swift", newSwiftObjectStore).
 				RegisterVolumeSnapshotter("velero.io/aws", newAwsVolumeSnapshotter).
 				RegisterVolumeSnapshotter("velero.io/azure", newAzureVolumeSnapshotter).
 				RegisterVolumeSnapshotter("velero.io/gcp", newGcpVolumeSnapshotter).
@@ -55,6 +57,7 @@ func NewCommand(f client.Factory) *cobra.Command {
 				RegisterRestoreItemAction("velero.io/add-pvc-from-pod", newAddPVCFromPodRestoreItemAction).
 				RegisterRestoreItemAction("velero.io/add-pv-from-pvc", newAddPVFromPVCRestoreItemAction).
 				RegisterRestoreItemAction("velero.io/change-storage-class", newChangeStorageClassRestoreItemAction(f)).
+				RegisterRestoreItemAction("velero.io/change-rbac-subjects", newChangeRBACSubjectsRestoreItemAction(f)).
 				Serve()
 		},
 	}
@@ -76,6 +79,10 @@ func newGcpObjectStore(logger logrus.FieldLogger) (interface{}, error) {
 	return gcp.NewObjectStore(logger), nil
 }
 
+func newSwiftObjectStore(logger logrus.FieldLogger) (interface{}, error) {
+	return swift.NewObjectStore(logger), nil
+}
+
 func newAwsVolumeSnapshotter(logger logrus.FieldLogger) (interface{}, error) {
 	return aws.NewVolumeSnapshotter(logger), nil
 }
@@ -175,3 +182,14 @@ func newChangeStorageClassRestoreItemAction(f client.Factory) veleroplugin.Handl
 		), nil
 	}
 }
+
+func newChangeRBACSubjectsRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		client, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeRBACSubjectsAction(logger, client.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
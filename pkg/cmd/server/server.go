@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +32,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	corev1api "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
@@ -45,22 +47,32 @@ import (
 
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/backup"
+	"github.com/heptio/velero/pkg/backupsummary"
 	"github.com/heptio/velero/pkg/buildinfo"
 	"github.com/heptio/velero/pkg/client"
 	"github.com/heptio/velero/pkg/cmd"
 	"github.com/heptio/velero/pkg/cmd/util/flag"
 	"github.com/heptio/velero/pkg/cmd/util/signals"
 	"github.com/heptio/velero/pkg/controller"
+	"github.com/heptio/velero/pkg/credentials"
+	"github.com/heptio/velero/pkg/csi"
 	velerodiscovery "github.com/heptio/velero/pkg/discovery"
+	"github.com/heptio/velero/pkg/downloadproxy"
 	clientset "github.com/heptio/velero/pkg/generated/clientset/versioned"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
 	"github.com/heptio/velero/pkg/metrics"
+	"github.com/heptio/velero/pkg/notifications"
 	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/clientmgmt"
 	"github.com/heptio/velero/pkg/podexec"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/restore"
+	"github.com/heptio/velero/pkg/util/filesystem"
+	"github.com/heptio/velero/pkg/util/httpauth"
+	kubeutil "github.com/heptio/velero/pkg/util/kube"
 	"github.com/heptio/velero/pkg/util/logging"
+	"github.com/heptio/velero/pkg/webui"
 )
 
 const (
@@ -68,8 +80,18 @@ const (
 	defaultMetricsAddress = ":8085"
 
 	defaultBackupSyncPeriod           = time.Minute
+	defaultStoreValidationFrequency   = time.Minute
 	defaultPodVolumeOperationTimeout  = 60 * time.Minute
 	defaultResourceTerminatingTimeout = 10 * time.Minute
+	defaultPartialBackupGCGracePeriod = 24 * time.Hour
+	defaultSnapshotGCGracePeriod      = 24 * time.Hour
+	defaultComplianceReportFrequency  = 24 * time.Hour
+	defaultComplianceReportsToKeep    = 30
+	defaultOperationHistoryMonths     = 12
+	defaultOperationHistoryMaxOps     = 2000
+	defaultDownloadRequestTTL         = time.Hour
+	defaultItemBackupConcurrency      = 1
+	defaultItemRestoreConcurrency     = 1
 
 	// server's client default qps and burst
 	defaultClientQPS   float32 = 20.0
@@ -78,15 +100,26 @@ const (
 	defaultProfilerAddress = "localhost:6060"
 
 	// keys used to map out available controllers with disable-controllers flag
-	BackupControllerKey              = "backup"
-	BackupSyncControllerKey          = "backup-sync"
-	ScheduleControllerKey            = "schedule"
-	GcControllerKey                  = "gc"
-	BackupDeletionControllerKey      = "backup-deletion"
-	RestoreControllerKey             = "restore"
-	DownloadRequestControllerKey     = "download-request"
-	ResticRepoControllerKey          = "restic-repo"
-	ServerStatusRequestControllerKey = "server-status-request"
+	BackupControllerKey                = "backup"
+	BackupSyncControllerKey            = "backup-sync"
+	ScheduleControllerKey              = "schedule"
+	GcControllerKey                    = "gc"
+	BackupDeletionControllerKey        = "backup-deletion"
+	RestoreControllerKey               = "restore"
+	DownloadRequestControllerKey       = "download-request"
+	ResticRepoControllerKey            = "restic-repo"
+	ServerStatusRequestControllerKey   = "server-status-request"
+	BackupReplicationControllerKey     = "backup-replication"
+	BackupStorageLocationControllerKey = "backup-storage-location"
+	PartialBackupGCControllerKey       = "partial-backup-gc"
+	SnapshotGCControllerKey            = "snapshot-gc"
+	ComplianceReportControllerKey      = "compliance-report"
+	OperationHistoryControllerKey      = "operation-history"
+	ScheduleRetentionControllerKey     = "schedule-retention"
+	BackupTTLControllerKey             = "backup-ttl"
+	BackupVerificationControllerKey    = "backup-verification"
+	StandbyRestoreControllerKey        = "standby-restore"
+	CredentialsControllerKey           = "credentials"
 
 	defaultControllerWorkers = 1
 	// the default TTL for a backup
@@ -104,12 +137,24 @@ var disableControllerList = []string{
 	DownloadRequestControllerKey,
 	ResticRepoControllerKey,
 	ServerStatusRequestControllerKey,
+	BackupReplicationControllerKey,
+	BackupStorageLocationControllerKey,
+	PartialBackupGCControllerKey,
+	SnapshotGCControllerKey,
+	ComplianceReportControllerKey,
+	OperationHistoryControllerKey,
+	ScheduleRetentionControllerKey,
+	BackupTTLControllerKey,
+	BackupVerificationControllerKey,
+	StandbyRestoreControllerKey,
+	CredentialsControllerKey,
 }
 
 type serverConfig struct {
 	pluginDir, metricsAddress, defaultBackupLocation                        string
 	backupSyncPeriod, podVolumeOperationTimeout, resourceTerminatingTimeout time.Duration
-	defaultBackupTTL                                                        time.Duration
+	storeValidationFrequency, csiSnapshotTimeout                            time.Duration
+	defaultBackupTTL, defaultDownloadURLTTL, downloadRequestTTL             time.Duration
 	restoreResourcePriorities                                               []string
 	defaultVolumeSnapshotLocations                                          map[string]string
 	restoreOnly                                                             bool
@@ -118,6 +163,31 @@ type serverConfig struct {
 	clientBurst                                                             int
 	profilerAddress                                                         string
 	formatFlag                                                              *logging.FormatFlag
+	structuredLogs                                                          bool
+	defaultVolumesToRestic                                                  bool
+	restoreServerSideApply                                                  bool
+	restoreProtectedNamespaces, restoreDeniedResources                      []string
+	partialBackupGCGracePeriod                                              time.Duration
+	partialBackupGCDryRun                                                   bool
+	snapshotGCFrequency, snapshotGCGracePeriod                              time.Duration
+	snapshotGCDryRun                                                        bool
+	resticMaintenanceFrequency, resticCheckFrequency                        time.Duration
+	backupSummaryAPIAddress, backupSummaryAPICertFile                       string
+	backupSummaryAPIKeyFile, backupSummaryAPIClientCAFile                   string
+	downloadProxyAddress, downloadProxyURL                                  string
+	downloadProxyCertFile, downloadProxyKeyFile                             string
+	alwaysProxyDownloads                                                    bool
+	webUIAddress, webUICertFile, webUIKeyFile                               string
+	webUIClientCAFile                                                       string
+	metricsCertFile, metricsKeyFile, metricsClientCAFile                    string
+	metricsAuthentication, webUIAuthentication                              []string
+	backupSummaryAPIAuthentication                                          []string
+	complianceReportFrequency                                               time.Duration
+	complianceReportsToKeep                                                 int
+	operationHistoryMonths, operationHistoryMaxOperations                   int
+	itemBackupConcurrency, itemRestoreConcurrency                           int
+	scheduleDeleteProtection                                                bool
+	defaultCredential                                                       string
 }
 
 type controllerRunInfo struct {
@@ -132,16 +202,31 @@ func NewCommand(f client.Factory) *cobra.Command {
 		config                  = serverConfig{
 			pluginDir:                      "/plugins",
 			metricsAddress:                 defaultMetricsAddress,
+			webUIAuthentication:            []string{httpauth.MethodToken},
+			backupSummaryAPIAuthentication: []string{httpauth.MethodRequestHeader},
 			defaultBackupLocation:          "default",
 			defaultVolumeSnapshotLocations: make(map[string]string),
 			backupSyncPeriod:               defaultBackupSyncPeriod,
+			storeValidationFrequency:       defaultStoreValidationFrequency,
 			defaultBackupTTL:               defaultBackupTTL,
+			defaultDownloadURLTTL:          persistence.DefaultDownloadURLTTL,
+			downloadRequestTTL:             defaultDownloadRequestTTL,
 			podVolumeOperationTimeout:      defaultPodVolumeOperationTimeout,
+			csiSnapshotTimeout:             csi.DefaultTimeout,
 			restoreResourcePriorities:      defaultRestorePriorities,
 			clientQPS:                      defaultClientQPS,
 			clientBurst:                    defaultClientBurst,
 			profilerAddress:                defaultProfilerAddress,
 			resourceTerminatingTimeout:     defaultResourceTerminatingTimeout,
+			partialBackupGCGracePeriod:     defaultPartialBackupGCGracePeriod,
+			snapshotGCGracePeriod:          defaultSnapshotGCGracePeriod,
+			resticMaintenanceFrequency:     podvolume.DefaultMaintenanceFrequency,
+			complianceReportFrequency:      defaultComplianceReportFrequency,
+			complianceReportsToKeep:        defaultComplianceReportsToKeep,
+			operationHistoryMonths:         defaultOperationHistoryMonths,
+			operationHistoryMaxOperations:  defaultOperationHistoryMaxOps,
+			itemBackupConcurrency:          defaultItemBackupConcurrency,
+			itemRestoreConcurrency:         defaultItemRestoreConcurrency,
 			formatFlag:                     logging.NewFormatFlag(),
 		}
 	)
@@ -184,10 +269,20 @@ func NewCommand(f client.Factory) *cobra.Command {
 
 	command.Flags().Var(logLevelFlag, "log-level", fmt.Sprintf("the level at which to log. Valid values are %s.", strings.Join(logLevelFlag.AllowedValues(), ", ")))
 	command.Flags().Var(config.formatFlag, "log-format", fmt.Sprintf("the format for log output. Valid values are %s.", strings.Join(config.formatFlag.AllowedValues(), ", ")))
+	command.Flags().BoolVar(&config.structuredLogs, "structured-logs", config.structuredLogs, "also write backup and restore logs as JSON lines and upload them to object storage")
 	command.Flags().StringVar(&config.pluginDir, "plugin-dir", config.pluginDir, "directory containing Velero plugins")
 	command.Flags().StringVar(&config.metricsAddress, "metrics-address", config.metricsAddress, "the address to expose prometheus metrics")
+	command.Flags().StringSliceVar(&config.metricsAuthentication, "metrics-authentication", config.metricsAuthentication, fmt.Sprintf("methods to authenticate requests for metrics with, from %s and %s. If empty, metrics are served without authentication.", httpauth.MethodToken, httpauth.MethodClientCert))
+	command.Flags().StringVar(&config.metricsCertFile, "metrics-tls-cert-file", config.metricsCertFile, "file containing the TLS certificate for the metrics server. If empty, metrics are served over HTTP.")
+	command.Flags().StringVar(&config.metricsKeyFile, "metrics-tls-key-file", config.metricsKeyFile, "file containing the TLS private key for the metrics server")
+	command.Flags().StringVar(&config.metricsClientCAFile, "metrics-client-ca-file", config.metricsClientCAFile, "file containing the CA used to verify client certificates for the metrics server")
 	command.Flags().DurationVar(&config.backupSyncPeriod, "backup-sync-period", config.backupSyncPeriod, "how often to ensure all Velero backups in object storage exist as Backup API objects in the cluster")
+	command.Flags().DurationVar(&config.storeValidationFrequency, "store-validation-frequency", config.storeValidationFrequency, "how often to verify that each backup storage location is available")
 	command.Flags().DurationVar(&config.podVolumeOperationTimeout, "restic-timeout", config.podVolumeOperationTimeout, "how long backups/restores of pod volumes should be allowed to run before timing out")
+	command.Flags().DurationVar(&config.resticMaintenanceFrequency, "restic-maintenance-frequency", config.resticMaintenanceFrequency, "how often new restic repositories are pruned and checked for errors, unless their maintenanceFrequency is set")
+	command.Flags().DurationVar(&config.resticCheckFrequency, "restic-check-frequency", config.resticCheckFrequency, "how often new restic repositories are checked for errors between maintenance runs, unless their checkFrequency is set. If zero, they're only checked during maintenance.")
+	command.Flags().DurationVar(&config.csiSnapshotTimeout, "csi-snapshot-timeout", config.csiSnapshotTimeout, "how long to wait for a CSI volume snapshot taken through the Kubernetes VolumeSnapshot API to be ready before failing it")
+	command.Flags().IntVar(&config.itemBackupConcurrency, "item-backup-concurrency", config.itemBackupConcurrency, "how many items of each resource a backup backs up at a time. Resources are still backed up one at a time, in order.")
 	command.Flags().BoolVar(&config.restoreOnly, "restore-only", config.restoreOnly, "run in a mode where only restores are allowed; backups, schedules, and garbage-collection are all disabled. DEPRECATED: this flag will be removed in v2.0. Use read-only backup storage locations instead.")
 	command.Flags().StringSliceVar(&config.disabledControllers, "disable-controllers", config.disabledControllers, fmt.Sprintf("list of controllers to disable on startup. Valid values are %s", strings.Join(disableControllerList, ",")))
 	command.Flags().StringSliceVar(&config.restoreResourcePriorities, "restore-resource-priorities", config.restoreResourcePriorities, "desired order of resource restores; any resource not in the list will be restored alphabetically after the prioritized resources")
@@ -197,7 +292,40 @@ func NewCommand(f client.Factory) *cobra.Command {
 	command.Flags().IntVar(&config.clientBurst, "client-burst", config.clientBurst, "maximum number of requests by the server to the Kubernetes API in a short period of time")
 	command.Flags().StringVar(&config.profilerAddress, "profiler-address", config.profilerAddress, "the address to expose the pprof profiler")
 	command.Flags().DurationVar(&config.resourceTerminatingTimeout, "terminating-resource-timeout", config.resourceTerminatingTimeout, "how long to wait on persistent volumes and namespaces to terminate during a restore before timing out")
+	command.Flags().IntVar(&config.itemRestoreConcurrency, "item-restore-concurrency", config.itemRestoreConcurrency, "how many namespaced items of each resource a restore restores into a namespace at a time. Resources are still restored one at a time, in priority order.")
+	command.Flags().StringVar(&config.defaultCredential, "default-credential", config.defaultCredential, "credential for backup storage and volume snapshot locations that don't have their own, as <secret-name>/<key> of a secret in the server's namespace. It's reloaded without restarting the server when the secret changes. If empty, plugins use the credentials in their environment, e.g. the mounted cloud-credentials secret.")
+	command.Flags().BoolVar(&config.scheduleDeleteProtection, "schedule-delete-protection", config.scheduleDeleteProtection, "protect the backups that schedules create from deletion, regardless of their schedule's backup template. They can't be deleted until their spec.deleteProtection is set to false.")
+	command.Flags().BoolVar(&config.defaultVolumesToRestic, "default-volumes-to-restic", config.defaultVolumesToRestic, "back up all pod volumes with restic by default, except those listed in a pod's backup.velero.io/backup-volumes-excludes annotation")
 	command.Flags().DurationVar(&config.defaultBackupTTL, "default-backup-ttl", config.defaultBackupTTL, "how long to wait by default before backups can be garbage collected")
+	command.Flags().DurationVar(&config.defaultDownloadURLTTL, "default-download-url-ttl", config.defaultDownloadURLTTL, "how long download URLs are valid for when a download request doesn't specify a TTL")
+	command.Flags().DurationVar(&config.downloadRequestTTL, "download-request-ttl", config.downloadRequestTTL, "how long to keep processed download requests, and regenerate their download URLs before they expire, before deleting them")
+	command.Flags().BoolVar(&config.restoreServerSideApply, "restore-server-side-apply", config.restoreServerSideApply, "restore items using server-side apply rather than create, so that re-run restores update existing items. Requires server-side apply to be enabled in the cluster.")
+	command.Flags().StringSliceVar(&config.restoreProtectedNamespaces, "restore-protected-namespaces", config.restoreProtectedNamespaces, "list of namespaces that restores may never restore items into or from, regardless of the restore's spec")
+	command.Flags().StringSliceVar(&config.restoreDeniedResources, "restore-denied-resources", config.restoreDeniedResources, "list of resources that restores may never restore, regardless of the restore's spec")
+	command.Flags().DurationVar(&config.partialBackupGCGracePeriod, "partial-backup-gc-grace-period", config.partialBackupGCGracePeriod, "how long a backup directory without a metadata file, left behind by a failed upload, is kept in object storage before it's deleted")
+	command.Flags().DurationVar(&config.complianceReportFrequency, "compliance-report-frequency", config.complianceReportFrequency, "how often to generate a compliance report of whether each schedule's backups meet its frequency and retention")
+	command.Flags().IntVar(&config.complianceReportsToKeep, "compliance-reports-to-keep", config.complianceReportsToKeep, "how many of the newest compliance reports to keep. If zero, all of them are kept.")
+	command.Flags().IntVar(&config.operationHistoryMonths, "operation-history-months", config.operationHistoryMonths, "how many months of backup and restore history to keep, including the current month. If zero, all of it is kept.")
+	command.Flags().IntVar(&config.operationHistoryMaxOperations, "operation-history-max-operations", config.operationHistoryMaxOperations, "how many backups and restores to keep in each month's history; the oldest ones are dropped. If zero, there's no limit.")
+	command.Flags().BoolVar(&config.partialBackupGCDryRun, "partial-backup-gc-dry-run", config.partialBackupGCDryRun, "log the partially uploaded backups that would be deleted from object storage, without deleting them")
+	command.Flags().DurationVar(&config.snapshotGCFrequency, "snapshot-gc-frequency", config.snapshotGCFrequency, "how often to look for volume snapshots taken by Velero whose backups no longer exist, and delete them. If zero, orphaned snapshots aren't looked for. Don't enable this if other clusters take volume snapshots in the same cloud account into backup storage locations this server doesn't have.")
+	command.Flags().DurationVar(&config.snapshotGCGracePeriod, "snapshot-gc-grace-period", config.snapshotGCGracePeriod, "how long a volume snapshot whose backup no longer exists is kept before it's deleted")
+	command.Flags().BoolVar(&config.snapshotGCDryRun, "snapshot-gc-dry-run", config.snapshotGCDryRun, "log the volume snapshots whose backups no longer exist that would be deleted, without deleting them")
+	command.Flags().StringVar(&config.backupSummaryAPIAddress, "backup-summary-api-address", config.backupSummaryAPIAddress, "the address to serve the backup summaries aggregated API on. If empty, the API is not served.")
+	command.Flags().StringVar(&config.backupSummaryAPICertFile, "backup-summary-api-tls-cert-file", config.backupSummaryAPICertFile, "file containing the TLS certificate for the backup summaries aggregated API")
+	command.Flags().StringVar(&config.backupSummaryAPIKeyFile, "backup-summary-api-tls-key-file", config.backupSummaryAPIKeyFile, "file containing the TLS private key for the backup summaries aggregated API")
+	command.Flags().StringVar(&config.backupSummaryAPIClientCAFile, "backup-summary-api-client-ca-file", config.backupSummaryAPIClientCAFile, "file containing the CA used to verify the Kubernetes API aggregator's client certificate (the requestheader-client-ca-file), or other clients' certificates")
+	command.Flags().StringSliceVar(&config.backupSummaryAPIAuthentication, "backup-summary-api-authentication", config.backupSummaryAPIAuthentication, fmt.Sprintf("methods to authenticate requests to the backup summaries aggregated API with, from %s, %s and %s", httpauth.MethodRequestHeader, httpauth.MethodToken, httpauth.MethodClientCert))
+	command.Flags().StringVar(&config.downloadProxyAddress, "download-proxy-address", config.downloadProxyAddress, "the address to serve the download proxy on. If empty, the download proxy is not served.")
+	command.Flags().StringVar(&config.downloadProxyURL, "download-proxy-url", config.downloadProxyURL, "the base URL at which Velero clients can reach the download proxy, e.g. https://velero.example.com:8443")
+	command.Flags().StringVar(&config.downloadProxyCertFile, "download-proxy-tls-cert-file", config.downloadProxyCertFile, "file containing the TLS certificate for the download proxy")
+	command.Flags().StringVar(&config.downloadProxyKeyFile, "download-proxy-tls-key-file", config.downloadProxyKeyFile, "file containing the TLS private key for the download proxy")
+	command.Flags().BoolVar(&config.alwaysProxyDownloads, "always-proxy-downloads", config.alwaysProxyDownloads, "return download proxy URLs for all download requests, rather than only when the object store can't create a pre-signed URL")
+	command.Flags().StringVar(&config.webUIAddress, "web-ui-address", config.webUIAddress, "the address to serve the web UI on. If empty, the web UI is not served.")
+	command.Flags().StringVar(&config.webUICertFile, "web-ui-tls-cert-file", config.webUICertFile, "file containing the TLS certificate for the web UI")
+	command.Flags().StringVar(&config.webUIKeyFile, "web-ui-tls-key-file", config.webUIKeyFile, "file containing the TLS private key for the web UI")
+	command.Flags().StringVar(&config.webUIClientCAFile, "web-ui-client-ca-file", config.webUIClientCAFile, "file containing the CA used to verify client certificates for the web UI")
+	command.Flags().StringSliceVar(&config.webUIAuthentication, "web-ui-authentication", config.webUIAuthentication, fmt.Sprintf("methods to authenticate web UI users with, from %s and %s", httpauth.MethodToken, httpauth.MethodClientCert))
 
 	return command
 }
@@ -218,7 +346,9 @@ type server struct {
 	logLevel              logrus.Level
 	pluginRegistry        clientmgmt.Registry
 	pluginManager         clientmgmt.Manager
-	resticManager         restic.RepositoryManager
+	downloadProxy         *downloadproxy.Signer
+	resticManager         podvolume.RepositoryManager
+	credentialsFileStore  credentials.FileStore
 	metrics               *metrics.ServerMetrics
 	config                serverConfig
 }
@@ -232,8 +362,71 @@ func newServer(f client.Factory, config serverConfig, logger *logrus.Logger) (*s
 	if config.clientBurst <= 0 {
 		return nil, errors.New("client-burst must be positive")
 	}
+
+	if config.defaultDownloadURLTTL <= 0 {
+		return nil, errors.New("default-download-url-ttl must be positive")
+	}
+
+	if config.downloadRequestTTL <= 0 {
+		return nil, errors.New("download-request-ttl must be positive")
+	}
+
+	if config.itemBackupConcurrency <= 0 {
+		return nil, errors.New("item-backup-concurrency must be positive")
+	}
+
+	if config.itemRestoreConcurrency <= 0 {
+		return nil, errors.New("item-restore-concurrency must be positive")
+	}
 	f.SetClientBurst(config.clientBurst)
 
+	if config.backupSummaryAPIAddress != "" && (config.backupSummaryAPICertFile == "" || config.backupSummaryAPIKeyFile == "") {
+		return nil, errors.New("backup-summary-api-tls-cert-file and backup-summary-api-tls-key-file must be set when backup-summary-api-address is set")
+	}
+	if err := httpauth.ValidateMethods(config.backupSummaryAPIAuthentication, httpauth.MethodRequestHeader, httpauth.MethodToken, httpauth.MethodClientCert); err != nil {
+		return nil, errors.WithMessage(err, "invalid backup-summary-api-authentication")
+	}
+	if len(config.backupSummaryAPIAuthentication) == 0 {
+		return nil, errors.New("backup-summary-api-authentication must not be empty")
+	}
+
+	if err := httpauth.ValidateMethods(config.metricsAuthentication, httpauth.MethodToken, httpauth.MethodClientCert); err != nil {
+		return nil, errors.WithMessage(err, "invalid metrics-authentication")
+	}
+	if (config.metricsCertFile == "") != (config.metricsKeyFile == "") {
+		return nil, errors.New("metrics-tls-cert-file and metrics-tls-key-file must be set together")
+	}
+	if len(config.metricsAuthentication) > 0 && config.metricsCertFile == "" {
+		return nil, errors.New("metrics-tls-cert-file and metrics-tls-key-file must be set when metrics-authentication is set, since credentials must not be sent over HTTP")
+	}
+
+	if config.webUIAddress != "" && (config.webUICertFile == "" || config.webUIKeyFile == "") {
+		return nil, errors.New("web-ui-tls-cert-file and web-ui-tls-key-file must be set when web-ui-address is set")
+	}
+	if err := httpauth.ValidateMethods(config.webUIAuthentication, httpauth.MethodToken, httpauth.MethodClientCert); err != nil {
+		return nil, errors.WithMessage(err, "invalid web-ui-authentication")
+	}
+	if len(config.webUIAuthentication) == 0 {
+		return nil, errors.New("web-ui-authentication must not be empty")
+	}
+
+	var downloadProxy *downloadproxy.Signer
+	if config.downloadProxyAddress != "" {
+		if config.downloadProxyURL == "" || config.downloadProxyCertFile == "" || config.downloadProxyKeyFile == "" {
+			return nil, errors.New("download-proxy-url, download-proxy-tls-cert-file and download-proxy-tls-key-file must be set when download-proxy-address is set")
+		}
+
+		// proxy URLs are signed with a key that only lives as long as this
+		// process, so they stop working if the server restarts.
+		key, err := downloadproxy.NewRandomKey()
+		if err != nil {
+			return nil, err
+		}
+		downloadProxy = downloadproxy.NewSigner(config.downloadProxyURL, key)
+	} else if config.alwaysProxyDownloads {
+		return nil, errors.New("download-proxy-address must be set when always-proxy-downloads is set")
+	}
+
 	kubeClient, err := f.KubeClient()
 	if err != nil {
 		return nil, err
@@ -280,6 +473,7 @@ func newServer(f client.Factory, config serverConfig, logger *logrus.Logger) (*s
 		logLevel:              logger.Level,
 		pluginRegistry:        pluginRegistry,
 		pluginManager:         pluginManager,
+		downloadProxy:         downloadProxy,
 		config:                config,
 	}
 
@@ -302,6 +496,24 @@ func (s *server) run() error {
 		return err
 	}
 
+	// locations' credentials are secrets in the server's namespace, which
+	// are written to files for plugins to read.
+	s.credentialsFileStore = credentials.NewNamespacedFileStore(
+		s.kubeClient.CoreV1(),
+		s.namespace,
+		filepath.Join(os.TempDir(), "velero-credentials"),
+		filesystem.NewFileSystem(),
+	)
+	credentials.SetDefaultFileStore(s.credentialsFileStore)
+
+	if s.config.defaultCredential != "" {
+		defaultCredential, err := parseDefaultCredential(s.config.defaultCredential)
+		if err != nil {
+			return err
+		}
+		credentials.SetDefaultCredential(defaultCredential)
+	}
+
 	if err := s.initDiscoveryHelper(); err != nil {
 		return err
 	}
@@ -330,6 +542,19 @@ func (s *server) run() error {
 	return nil
 }
 
+// getClusterID returns the UID of the kube-system namespace, which
+// identifies the cluster in backup stores' ownership markers, or an empty
+// string if it can't be gotten.
+func (s *server) getClusterID() string {
+	ns, err := s.kubeClient.CoreV1().Namespaces().Get("kube-system", metav1.GetOptions{})
+	if err != nil {
+		s.logger.WithError(errors.WithStack(err)).Warn("Unable to get the kube-system namespace to identify the cluster in backup stores' ownership markers")
+		return ""
+	}
+
+	return string(ns.UID)
+}
+
 // namespaceExists returns nil if namespace can be successfully
 // gotten from the kubernetes API, or an error otherwise.
 func (s *server) namespaceExists(namespace string) error {
@@ -406,6 +631,20 @@ func (s *server) veleroResourcesExist() error {
 	return nil
 }
 
+// parseDefaultCredential parses the --default-credential flag's
+// <secret-name>/<key> value.
+func parseDefaultCredential(value string) (*corev1api.SecretKeySelector, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.Errorf("invalid --default-credential %q: must be <secret-name>/<key>", value)
+	}
+
+	return &corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: parts[0]},
+		Key:                  parts[1],
+	}, nil
+}
+
 // validateBackupStorageLocations checks to ensure all backup storage locations exist
 // and have a compatible layout, and returns an error if not.
 func (s *server) validateBackupStorageLocations() error {
@@ -418,7 +657,7 @@ func (s *server) validateBackupStorageLocations() error {
 
 	var invalid []string
 	for _, location := range locations.Items {
-		backupStore, err := persistence.NewObjectBackupStore(&location, s.pluginManager, s.logger)
+		backupStore, err := persistence.NewBackupStore(&location, s.pluginManager, s.logger)
 		if err != nil {
 			invalid = append(invalid, errors.Wrapf(err, "error getting backup store for location %q", location.Name).Error())
 			continue
@@ -464,14 +703,14 @@ var defaultRestorePriorities = []string{
 
 func (s *server) initRestic() error {
 	// warn if restic daemonset does not exist
-	if _, err := s.kubeClient.AppsV1().DaemonSets(s.namespace).Get(restic.DaemonSet, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+	if _, err := s.kubeClient.AppsV1().DaemonSets(s.namespace).Get(podvolume.DaemonSet, metav1.GetOptions{}); apierrors.IsNotFound(err) {
 		s.logger.Warn("Velero restic daemonset not found; restic backups/restores will not work until it's created")
 	} else if err != nil {
 		s.logger.WithError(errors.WithStack(err)).Warn("Error checking for existence of velero restic daemonset")
 	}
 
 	// ensure the repo key secret is set up
-	if err := restic.EnsureCommonRepositoryKey(s.kubeClient.CoreV1(), s.namespace); err != nil {
+	if err := podvolume.EnsureCommonRepositoryKey(s.kubeClient.CoreV1(), s.namespace); err != nil {
 		return err
 	}
 
@@ -487,12 +726,12 @@ func (s *server) initRestic() error {
 		0,
 		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
 		func(opts *metav1.ListOptions) {
-			opts.FieldSelector = fmt.Sprintf("metadata.name=%s", restic.CredentialsSecretName)
+			opts.FieldSelector = fmt.Sprintf("metadata.name=%s", podvolume.CredentialsSecretName)
 		},
 	)
 	go secretsInformer.Run(s.ctx.Done())
 
-	res, err := restic.NewRepositoryManager(
+	res, err := podvolume.NewRepositoryManager(
 		s.ctx,
 		s.namespace,
 		s.veleroClient,
@@ -518,19 +757,17 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 	ctx := s.ctx
 	var wg sync.WaitGroup
 
-	go func() {
-		metricsMux := http.NewServeMux()
-		metricsMux.Handle("/metrics", promhttp.Handler())
-		s.logger.Infof("Starting metric server at address [%s]", s.metricsAddress)
-		if err := http.ListenAndServe(s.metricsAddress, metricsMux); err != nil {
-			s.logger.Fatalf("Failed to start metric server at [%s]: %v", s.metricsAddress, err)
-		}
-	}()
+	if err := s.runMetricsServer(); err != nil {
+		return err
+	}
 	s.metrics = metrics.NewServerMetrics()
 	s.metrics.RegisterAllMetrics()
 	// Initialize manual backup metrics
 	s.metrics.InitSchedule("")
 
+	notifier := notifications.NewNotifier(s.kubeClient.CoreV1().ConfigMaps(s.namespace), s.logger)
+	eventRecorder := kubeutil.NewEventRecorder(s.kubeClient.CoreV1(), scheme.Scheme, "velero", s.logger)
+
 	newPluginManager := func(logger logrus.FieldLogger) clientmgmt.Manager {
 		return clientmgmt.NewManager(logger, s.logLevel, s.pluginRegistry)
 	}
@@ -540,9 +777,11 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			s.veleroClient.VeleroV1(),
 			s.veleroClient.VeleroV1(),
 			s.veleroClient.VeleroV1(),
+			s.veleroClient.VeleroV1(),
 			s.sharedInformerFactory.Velero().V1().Backups(),
 			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
 			s.sharedInformerFactory.Velero().V1().PodVolumeBackups(),
+			s.sharedInformerFactory.Velero().V1().ResticRepositories(),
 			s.config.backupSyncPeriod,
 			s.namespace,
 			s.config.defaultBackupLocation,
@@ -565,12 +804,15 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			podexec.NewPodCommandExecutor(s.kubeClientConfig, s.kubeClient.CoreV1().RESTClient()),
 			s.resticManager,
 			s.config.podVolumeOperationTimeout,
+			s.config.csiSnapshotTimeout,
+			s.config.itemBackupConcurrency,
 		)
 		cmd.CheckError(err)
 
 		backupController := controller.NewBackupController(
 			s.sharedInformerFactory.Velero().V1().Backups(),
 			s.veleroClient.VeleroV1(),
+			s.kubeClient.CoreV1(),
 			backupper,
 			s.logger,
 			s.logLevel,
@@ -581,8 +823,12 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			s.config.defaultBackupTTL,
 			s.sharedInformerFactory.Velero().V1().VolumeSnapshotLocations(),
 			defaultVolumeSnapshotLocations,
+			s.config.defaultVolumesToRestic,
 			s.metrics,
+			notifier,
+			eventRecorder,
 			s.config.formatFlag.Parse(),
+			s.config.structuredLogs,
 		)
 
 		return controllerRunInfo{
@@ -597,6 +843,8 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			s.veleroClient.VeleroV1(),
 			s.veleroClient.VeleroV1(),
 			s.sharedInformerFactory.Velero().V1().Schedules(),
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.config.scheduleDeleteProtection,
 			s.logger,
 			s.metrics,
 		)
@@ -614,6 +862,7 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			s.sharedInformerFactory.Velero().V1().DeleteBackupRequests(),
 			s.veleroClient.VeleroV1(),
 			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			s.sharedInformerFactory.Velero().V1().Schedules(),
 		)
 
 		return controllerRunInfo{
@@ -622,12 +871,130 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 		}
 	}
 
+	scheduleRetentionControllerRunInfo := func() controllerRunInfo {
+		scheduleRetentionController := controller.NewScheduleRetentionController(
+			s.logger,
+			s.sharedInformerFactory.Velero().V1().Schedules(),
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.sharedInformerFactory.Velero().V1().DeleteBackupRequests(),
+			s.veleroClient.VeleroV1(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+		)
+
+		return controllerRunInfo{
+			controller: scheduleRetentionController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	backupTTLControllerRunInfo := func() controllerRunInfo {
+		backupTTLController := controller.NewBackupTTLController(
+			s.logger,
+			s.veleroClient.VeleroV1(),
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			newPluginManager,
+		)
+
+		return controllerRunInfo{
+			controller: backupTTLController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	backupVerificationControllerRunInfo := func() controllerRunInfo {
+		backupVerificationController := controller.NewBackupVerificationController(
+			s.logger,
+			s.veleroClient.VeleroV1(),
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.sharedInformerFactory.Velero().V1().Schedules(),
+			s.veleroClient.VeleroV1(),
+			s.sharedInformerFactory.Velero().V1().Restores(),
+			s.kubeClient.CoreV1(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			newPluginManager,
+		)
+
+		return controllerRunInfo{
+			controller: backupVerificationController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	standbyRestoreControllerRunInfo := func() controllerRunInfo {
+		standbyRestoreController := controller.NewStandbyRestoreController(
+			s.logger,
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.veleroClient.VeleroV1(),
+			s.sharedInformerFactory.Velero().V1().Restores(),
+			s.veleroClient.VeleroV1(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			newPluginManager,
+		)
+
+		return controllerRunInfo{
+			controller: standbyRestoreController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	backupStorageLocationControllerRunInfo := func() controllerRunInfo {
+		backupStorageLocationController := controller.NewBackupStorageLocationController(
+			s.logger,
+			s.getClusterID(),
+			s.veleroClient.VeleroV1(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			s.config.storeValidationFrequency,
+			newPluginManager,
+			notifier,
+		)
+
+		return controllerRunInfo{
+			controller: backupStorageLocationController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	partialBackupGCControllerRunInfo := func() controllerRunInfo {
+		partialBackupGCController := controller.NewPartialBackupGCController(
+			s.namespace,
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			s.config.partialBackupGCGracePeriod,
+			s.config.partialBackupGCDryRun,
+			newPluginManager,
+			s.metrics,
+			s.logger,
+		)
+
+		return controllerRunInfo{
+			controller: partialBackupGCController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	replicationControllerRunInfo := func() controllerRunInfo {
+		replicationController := controller.NewBackupReplicationController(
+			s.logger,
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.veleroClient.VeleroV1(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			newPluginManager,
+		)
+
+		return controllerRunInfo{
+			controller: replicationController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
 	deletionControllerRunInfo := func() controllerRunInfo {
 		deletionController := controller.NewBackupDeletionController(
 			s.logger,
 			s.sharedInformerFactory.Velero().V1().DeleteBackupRequests(),
 			s.veleroClient.VeleroV1(), // deleteBackupRequestClient
 			s.veleroClient.VeleroV1(), // backupClient
+			s.sharedInformerFactory.Velero().V1().Backups(),
 			s.sharedInformerFactory.Velero().V1().Restores(),
 			s.veleroClient.VeleroV1(), // restoreClient
 			backupTracker,
@@ -635,6 +1002,7 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			s.sharedInformerFactory.Velero().V1().PodVolumeBackups(),
 			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
 			s.sharedInformerFactory.Velero().V1().VolumeSnapshotLocations(),
+			client.NewDynamicFactory(s.dynamicClient),
 			newPluginManager,
 			s.metrics,
 		)
@@ -650,11 +1018,17 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 		restorer, err := restore.NewKubernetesRestorer(
 			s.discoveryHelper,
 			client.NewDynamicFactory(s.dynamicClient),
+			client.NewDryRunDynamicFactory(s.dynamicClient),
 			s.config.restoreResourcePriorities,
 			s.kubeClient.CoreV1().Namespaces(),
 			s.resticManager,
 			s.config.podVolumeOperationTimeout,
 			s.config.resourceTerminatingTimeout,
+			s.config.restoreServerSideApply,
+			s.config.restoreProtectedNamespaces,
+			s.config.restoreDeniedResources,
+			podexec.NewPodCommandExecutor(s.kubeClientConfig, s.kubeClient.CoreV1().RESTClient()),
+			s.config.itemRestoreConcurrency,
 			s.logger,
 		)
 		cmd.CheckError(err)
@@ -664,6 +1038,8 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			s.sharedInformerFactory.Velero().V1().Restores(),
 			s.veleroClient.VeleroV1(),
 			s.veleroClient.VeleroV1(),
+			s.kubeClient.CoreV1(),
+			s.kubeClient.CoreV1(),
 			restorer,
 			s.sharedInformerFactory.Velero().V1().Backups(),
 			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
@@ -673,7 +1049,10 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			newPluginManager,
 			s.config.defaultBackupLocation,
 			s.metrics,
+			notifier,
+			eventRecorder,
 			s.config.formatFlag.Parse(),
+			s.config.structuredLogs,
 		)
 
 		return controllerRunInfo{
@@ -689,6 +1068,8 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			s.veleroClient.VeleroV1(),
 			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
 			s.resticManager,
+			s.config.resticMaintenanceFrequency,
+			s.config.resticCheckFrequency,
 		)
 
 		return controllerRunInfo{
@@ -705,6 +1086,10 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
 			s.sharedInformerFactory.Velero().V1().Backups(),
 			newPluginManager,
+			s.downloadProxy,
+			s.config.alwaysProxyDownloads,
+			s.config.defaultDownloadURLTTL,
+			s.config.downloadRequestTTL,
 			s.logger,
 		)
 
@@ -728,25 +1113,118 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 		}
 	}
 
+	complianceReportControllerRunInfo := func() controllerRunInfo {
+		complianceReportController := controller.NewComplianceReportController(
+			s.namespace,
+			s.sharedInformerFactory.Velero().V1().Schedules(),
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			s.sharedInformerFactory.Velero().V1().ComplianceReports(),
+			s.veleroClient.VeleroV1(),
+			s.config.complianceReportFrequency,
+			s.config.defaultBackupTTL,
+			s.config.complianceReportsToKeep,
+			newPluginManager,
+			s.logger,
+		)
+
+		return controllerRunInfo{
+			controller: complianceReportController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	snapshotGCControllerRunInfo := func() controllerRunInfo {
+		snapshotGCController := controller.NewSnapshotGCController(
+			s.namespace,
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+			s.sharedInformerFactory.Velero().V1().VolumeSnapshotLocations(),
+			s.config.snapshotGCFrequency,
+			s.config.snapshotGCGracePeriod,
+			s.config.snapshotGCDryRun,
+			newPluginManager,
+			s.metrics,
+			s.logger,
+		)
+
+		return controllerRunInfo{
+			controller: snapshotGCController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	operationHistoryControllerRunInfo := func() controllerRunInfo {
+		operationHistoryController := controller.NewOperationHistoryController(
+			s.namespace,
+			s.sharedInformerFactory.Velero().V1().Backups(),
+			s.sharedInformerFactory.Velero().V1().Restores(),
+			s.sharedInformerFactory.Velero().V1().OperationHistories(),
+			s.veleroClient.VeleroV1(),
+			s.config.operationHistoryMonths,
+			s.config.operationHistoryMaxOperations,
+			s.logger,
+		)
+
+		return controllerRunInfo{
+			controller: operationHistoryController,
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
+	credentialsControllerRunInfo := func() controllerRunInfo {
+		// use a stand-alone secrets informer so that only the secrets in the
+		// velero namespace are watched.
+		secretsInformer := corev1informers.NewSecretInformer(
+			s.kubeClient,
+			s.namespace,
+			0,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		)
+		go secretsInformer.Run(ctx.Done())
+
+		return controllerRunInfo{
+			controller: controller.NewCredentialsController(secretsInformer, s.credentialsFileStore, s.logger),
+			numWorkers: defaultControllerWorkers,
+		}
+	}
+
 	enabledControllers := map[string]func() controllerRunInfo{
-		BackupSyncControllerKey:          backupSyncControllerRunInfo,
-		BackupControllerKey:              backupControllerRunInfo,
-		ScheduleControllerKey:            scheduleControllerRunInfo,
-		GcControllerKey:                  gcControllerRunInfo,
-		BackupDeletionControllerKey:      deletionControllerRunInfo,
-		RestoreControllerKey:             restoreControllerRunInfo,
-		ResticRepoControllerKey:          resticRepoControllerRunInfo,
-		DownloadRequestControllerKey:     downloadrequestControllerRunInfo,
-		ServerStatusRequestControllerKey: serverStatusRequestControllerRunInfo,
+		BackupSyncControllerKey:            backupSyncControllerRunInfo,
+		BackupControllerKey:                backupControllerRunInfo,
+		ScheduleControllerKey:              scheduleControllerRunInfo,
+		GcControllerKey:                    gcControllerRunInfo,
+		BackupDeletionControllerKey:        deletionControllerRunInfo,
+		RestoreControllerKey:               restoreControllerRunInfo,
+		ResticRepoControllerKey:            resticRepoControllerRunInfo,
+		DownloadRequestControllerKey:       downloadrequestControllerRunInfo,
+		ServerStatusRequestControllerKey:   serverStatusRequestControllerRunInfo,
+		BackupReplicationControllerKey:     replicationControllerRunInfo,
+		BackupStorageLocationControllerKey: backupStorageLocationControllerRunInfo,
+		PartialBackupGCControllerKey:       partialBackupGCControllerRunInfo,
+		SnapshotGCControllerKey:            snapshotGCControllerRunInfo,
+		ComplianceReportControllerKey:      complianceReportControllerRunInfo,
+		OperationHistoryControllerKey:      operationHistoryControllerRunInfo,
+		ScheduleRetentionControllerKey:     scheduleRetentionControllerRunInfo,
+		BackupTTLControllerKey:             backupTTLControllerRunInfo,
+		BackupVerificationControllerKey:    backupVerificationControllerRunInfo,
+		StandbyRestoreControllerKey:        standbyRestoreControllerRunInfo,
+		CredentialsControllerKey:           credentialsControllerRunInfo,
 	}
 
 	if s.config.restoreOnly {
-		s.logger.Info("Restore only mode - not starting the backup, schedule, delete-backup, or GC controllers")
+		s.logger.Info("Restore only mode - not starting the backup, schedule, delete-backup, GC, backup-replication, partial-backup-gc, snapshot-gc, schedule-retention, backup-ttl, or backup-verification controllers")
 		s.config.disabledControllers = append(s.config.disabledControllers,
 			BackupControllerKey,
 			ScheduleControllerKey,
 			GcControllerKey,
 			BackupDeletionControllerKey,
+			BackupReplicationControllerKey,
+			PartialBackupGCControllerKey,
+			SnapshotGCControllerKey,
+			ScheduleRetentionControllerKey,
+			BackupTTLControllerKey,
+			BackupVerificationControllerKey,
 		)
 	}
 
@@ -760,6 +1238,13 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 		}
 	}
 
+	// orphaned volume snapshots are only garbage-collected when it's been
+	// turned on, since snapshots taken by other clusters in the same cloud
+	// account would look orphaned.
+	if s.config.snapshotGCFrequency <= 0 {
+		delete(enabledControllers, SnapshotGCControllerKey)
+	}
+
 	for _, newController := range enabledControllers {
 		controllerRunInfo := newController()
 		wg.Add(1)
@@ -769,6 +1254,28 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 		}()
 	}
 
+	if s.config.backupSummaryAPIAddress != "" {
+		// this must happen before the shared informers are started so that
+		// the backup informer is registered.
+		if err := s.runBackupSummaryAPI(); err != nil {
+			return err
+		}
+	}
+
+	if s.downloadProxy != nil {
+		// this must happen before the shared informers are started so that
+		// the backup storage location informer is registered.
+		s.runDownloadProxy(newPluginManager)
+	}
+
+	if s.config.webUIAddress != "" {
+		// this must happen before the shared informers are started so that
+		// the backup, restore and schedule informers are registered.
+		if err := s.runWebUI(); err != nil {
+			return err
+		}
+	}
+
 	// SHARED INFORMERS HAVE TO BE STARTED AFTER ALL CONTROLLERS
 	go s.sharedInformerFactory.Start(ctx.Done())
 
@@ -782,6 +1289,135 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 	return nil
 }
 
+// runMetricsServer serves Prometheus metrics. If metrics authentication is
+// configured, scrapers need permission to get the /metrics non-resource URL,
+// as they do for the Kubernetes components' metrics.
+func (s *server) runMetricsServer() error {
+	var handler http.Handler = promhttp.Handler()
+	if len(s.config.metricsAuthentication) > 0 {
+		handler = httpauth.WithAuth(
+			handler,
+			httpauth.NewAuthenticator(s.config.metricsAuthentication, httpauth.NewTokenReviewAuthenticator(s.kubeClient.AuthenticationV1().TokenReviews())),
+			httpauth.NewSubjectAccessReviewAuthorizer(s.kubeClient.AuthorizationV1().SubjectAccessReviews()),
+			httpauth.NonResource("get"),
+			s.logger,
+		)
+	}
+
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", handler)
+
+	srv := &http.Server{
+		Addr:    s.metricsAddress,
+		Handler: metricsMux,
+	}
+
+	if s.config.metricsCertFile != "" {
+		tlsConfig, err := httpauth.TLSConfig(s.config.metricsAuthentication, s.config.metricsClientCAFile)
+		if err != nil {
+			return errors.WithMessage(err, "error configuring metrics server")
+		}
+		srv.TLSConfig = tlsConfig
+	}
+
+	go func() {
+		s.logger.Infof("Starting metric server at address [%s]", s.metricsAddress)
+
+		var err error
+		if s.config.metricsCertFile != "" {
+			err = srv.ListenAndServeTLS(s.config.metricsCertFile, s.config.metricsKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil {
+			s.logger.Fatalf("Failed to start metric server at [%s]: %v", s.metricsAddress, err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *server) runBackupSummaryAPI() error {
+	tlsConfig, err := httpauth.TLSConfig(s.config.backupSummaryAPIAuthentication, s.config.backupSummaryAPIClientCAFile)
+	if err != nil {
+		return errors.WithMessage(err, "error configuring backup summaries API server")
+	}
+
+	handler := backupsummary.NewHandler(
+		s.sharedInformerFactory.Velero().V1().Backups().Lister(),
+		backupsummary.NewAuthorizer(
+			httpauth.NewAuthenticator(s.config.backupSummaryAPIAuthentication, httpauth.NewTokenReviewAuthenticator(s.kubeClient.AuthenticationV1().TokenReviews())),
+			httpauth.NewSubjectAccessReviewAuthorizer(s.kubeClient.AuthorizationV1().SubjectAccessReviews()),
+		),
+		s.logger,
+	)
+
+	srv := backupsummary.NewServer(s.config.backupSummaryAPIAddress, tlsConfig, handler)
+
+	go func() {
+		s.logger.Infof("Starting backup summaries API server at address [%s]", s.config.backupSummaryAPIAddress)
+		if err := srv.ListenAndServeTLS(s.config.backupSummaryAPICertFile, s.config.backupSummaryAPIKeyFile); err != nil {
+			s.logger.Fatalf("Failed to start backup summaries API server at [%s]: %v", s.config.backupSummaryAPIAddress, err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *server) runDownloadProxy(newPluginManager func(logrus.FieldLogger) clientmgmt.Manager) {
+	handler := downloadproxy.NewHandler(
+		s.downloadProxy,
+		s.sharedInformerFactory.Velero().V1().BackupStorageLocations().Lister(),
+		newPluginManager,
+		s.logger,
+	)
+
+	srv := downloadproxy.NewServer(s.config.downloadProxyAddress, handler)
+
+	go func() {
+		s.logger.Infof("Starting download proxy at address [%s]", s.config.downloadProxyAddress)
+		if err := srv.ListenAndServeTLS(s.config.downloadProxyCertFile, s.config.downloadProxyKeyFile); err != nil {
+			s.logger.Fatalf("Failed to start download proxy at [%s]: %v", s.config.downloadProxyAddress, err)
+		}
+	}()
+}
+
+func (s *server) runWebUI() error {
+	tlsConfig, err := httpauth.TLSConfig(s.config.webUIAuthentication, s.config.webUIClientCAFile)
+	if err != nil {
+		return errors.WithMessage(err, "error configuring web UI server")
+	}
+
+	csrfKey, err := webui.NewRandomKey()
+	if err != nil {
+		return err
+	}
+
+	handler := webui.NewHandler(
+		s.namespace,
+		s.sharedInformerFactory.Velero().V1().Backups().Lister(),
+		s.sharedInformerFactory.Velero().V1().Restores().Lister(),
+		s.sharedInformerFactory.Velero().V1().Schedules().Lister(),
+		s.veleroClient,
+		s.config.webUIAuthentication,
+		httpauth.NewTokenReviewAuthenticator(s.kubeClient.AuthenticationV1().TokenReviews()),
+		httpauth.NewSubjectAccessReviewAuthorizer(s.kubeClient.AuthorizationV1().SubjectAccessReviews()),
+		csrfKey,
+		s.logger,
+	)
+
+	srv := webui.NewServer(s.config.webUIAddress, tlsConfig, handler)
+
+	go func() {
+		s.logger.Infof("Starting web UI at address [%s]", s.config.webUIAddress)
+		if err := srv.ListenAndServeTLS(s.config.webUICertFile, s.config.webUIKeyFile); err != nil {
+			s.logger.Fatalf("Failed to start web UI at [%s]: %v", s.config.webUIAddress, err)
+		}
+	}()
+
+	return nil
+}
+
 func (s *server) runProfiler() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -69,3 +70,15 @@ func TestVeleroResourcesExist(t *testing.T) {
 	veleroAPIResourceList.APIResources = veleroAPIResourceList.APIResources[:3]
 	assert.Error(t, server.veleroResourcesExist())
 }
+
+func TestParseDefaultCredential(t *testing.T) {
+	credential, err := parseDefaultCredential("cloud-credentials/cloud")
+	require.NoError(t, err)
+	assert.Equal(t, "cloud-credentials", credential.Name)
+	assert.Equal(t, "cloud", credential.Key)
+
+	for _, value := range []string{"cloud-credentials", "cloud-credentials/", "/cloud", "a/b/c"} {
+		_, err := parseDefaultCredential(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -36,7 +36,20 @@ import (
 // not found
 var ErrNotFound = errors.New("file not found")
 
+// Stream writes the contents of the download target to w. Gzipped files
+// other than the backup tarball are decompressed.
 func Stream(client velerov1client.DownloadRequestsGetter, namespace, name string, kind v1.DownloadTargetKind, w io.Writer, timeout time.Duration) error {
+	decompress := kind != v1.DownloadTargetKindBackupContents && kind != v1.DownloadTargetKindBackupMetadata
+	return download(client, namespace, name, kind, w, timeout, decompress)
+}
+
+// Download writes the download target's file to w exactly as it's stored
+// in object storage, without decompressing it.
+func Download(client velerov1client.DownloadRequestsGetter, namespace, name string, kind v1.DownloadTargetKind, w io.Writer, timeout time.Duration) error {
+	return download(client, namespace, name, kind, w, timeout, false)
+}
+
+func download(client velerov1client.DownloadRequestsGetter, namespace, name string, kind v1.DownloadTargetKind, w io.Writer, timeout time.Duration, decompress bool) error {
 	req := &v1.DownloadRequest{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
@@ -104,23 +117,25 @@ Loop:
 		return ErrNotFound
 	}
 
-	httpClient := new(http.Client)
-
-	httpReq, err := http.NewRequest("GET", req.Status.DownloadURL, nil)
+	resp, err := get(req.Status.DownloadURL)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	// Manually set this header so the net/http library does not automatically try to decompress. We
-	// need to handle this manually because it's not currently possible to set the MIME type for the
-	// pre-signed URLs for GCP or Azure.
-	httpReq.Header.Set("Accept-Encoding", "gzip")
+	// The server regenerates the download URL shortly before it expires, so if the
+	// URL was rejected, try again with the current one.
+	if resp.StatusCode == http.StatusForbidden {
+		current, err := client.DownloadRequests(namespace).Get(req.Name, metav1.GetOptions{})
+		if err == nil && current.Status.DownloadURL != "" && current.Status.DownloadURL != req.Status.DownloadURL {
+			resp.Body.Close()
 
-	resp, err := httpClient.Do(httpReq)
-	if err != nil {
-		return err
+			if resp, err = get(current.Status.DownloadURL); err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+		}
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, err := ioutil.ReadAll(resp.Body)
@@ -136,7 +151,7 @@ Loop:
 	}
 
 	reader := resp.Body
-	if kind != v1.DownloadTargetKindBackupContents {
+	if decompress {
 		// need to decompress logs
 		gzipReader, err := gzip.NewReader(resp.Body)
 		if err != nil {
@@ -149,3 +164,17 @@ Loop:
 	_, err = io.Copy(w, reader)
 	return err
 }
+
+func get(url string) (*http.Response, error) {
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Manually set this header so the net/http library does not automatically try to decompress. We
+	// need to handle this manually because it's not currently possible to set the MIME type for the
+	// pre-signed URLs for GCP or Azure.
+	httpReq.Header.Set("Accept-Encoding", "gzip")
+
+	return new(http.Client).Do(httpReq)
+}
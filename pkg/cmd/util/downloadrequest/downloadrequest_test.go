@@ -209,6 +209,62 @@ func TestStream(t *testing.T) {
 	}
 }
 
+func TestStreamRetriesRegeneratedURL(t *testing.T) {
+	expired := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, "request has expired")
+	}))
+	defer expired.Close()
+
+	regenerated := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gzipWriter := gzip.NewWriter(w)
+		fmt.Fprintf(gzipWriter, "download body")
+		gzipWriter.Close()
+	}))
+	defer regenerated.Close()
+
+	client := fake.NewSimpleClientset()
+
+	created := make(chan *v1.DownloadRequest, 1)
+	client.PrependReactor("create", "downloadrequests", func(action core.Action) (bool, runtime.Object, error) {
+		createAction := action.(core.CreateAction)
+		created <- createAction.GetObject().(*v1.DownloadRequest)
+		return true, createAction.GetObject(), nil
+	})
+
+	client.PrependReactor("get", "downloadrequests", func(action core.Action) (bool, runtime.Object, error) {
+		req := newDownloadRequest(action.(core.GetAction).GetName()).DownloadRequest
+		req.Status.DownloadURL = regenerated.URL
+		return true, req, nil
+	})
+
+	fakeWatch := watch.NewFake()
+	client.PrependWatchReactor("downloadrequests", core.DefaultWatchReactor(fakeWatch, nil))
+
+	output := new(bytes.Buffer)
+	errCh := make(chan error)
+	go func() {
+		errCh <- Stream(client.VeleroV1(), "namespace", "name", v1.DownloadTargetKindBackupLog, output, 30*time.Second)
+	}()
+
+	select {
+	case r := <-created:
+		r.Status.DownloadURL = expired.URL
+		fakeWatch.Modify(r)
+	case <-time.After(30 * time.Second):
+		t.Fatalf("created object not received")
+	}
+
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("test timed out")
+	}
+
+	assert.Equal(t, "download body", output.String())
+}
+
 type downloadRequest struct {
 	*v1.DownloadRequest
 }
@@ -17,6 +17,8 @@ limitations under the License.
 package flag
 
 import (
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -49,3 +51,42 @@ func (ls *LabelSelector) Set(s string) error {
 func (ls *LabelSelector) Type() string {
 	return "labelSelector"
 }
+
+// OrLabelSelector is a Cobra-compatible wrapper for defining
+// a flag with a list of Kubernetes label-selectors, separated
+// by " or ", such as "app=foo or app=bar".
+type OrLabelSelector struct {
+	OrLabelSelectors []metav1.LabelSelector
+}
+
+// String returns a string representation of the OR-able
+// label-selector flag.
+func (ls *OrLabelSelector) String() string {
+	var selectors []string
+	for i := range ls.OrLabelSelectors {
+		selectors = append(selectors, metav1.FormatLabelSelector(&ls.OrLabelSelectors[i]))
+	}
+	return strings.Join(selectors, " or ")
+}
+
+// Set parses the provided string and assigns the result
+// to the OR-able label-selector receiver. It returns an error
+// if any of the label selectors is not parseable.
+func (ls *OrLabelSelector) Set(s string) error {
+	var selectors []metav1.LabelSelector
+	for _, item := range strings.Split(s, " or ") {
+		parsed, err := metav1.ParseToLabelSelector(strings.TrimSpace(item))
+		if err != nil {
+			return err
+		}
+		selectors = append(selectors, *parsed)
+	}
+	ls.OrLabelSelectors = selectors
+	return nil
+}
+
+// Type returns a string representation of the
+// OrLabelSelector type.
+func (ls *OrLabelSelector) Type() string {
+	return "orLabelSelector"
+}
@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strings"
 
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -122,22 +123,61 @@ func DescribeBackupSpec(d *Describer, spec velerov1api.BackupSpec) {
 	d.Printf("\tExcluded:\t%s\n", s)
 
 	d.Printf("\tCluster-scoped:\t%s\n", BoolPointerString(spec.IncludeClusterResources, "excluded", "included", "auto"))
+	if len(spec.IncludedClusterResourceNames) > 0 {
+		d.Printf("\tIncluded cluster-scoped items:\t%s\n", strings.Join(spec.IncludedClusterResourceNames, ", "))
+	}
+	if len(spec.ExcludedClusterResourceNames) > 0 {
+		d.Printf("\tExcluded cluster-scoped items:\t%s\n", strings.Join(spec.ExcludedClusterResourceNames, ", "))
+	}
 
 	d.Println()
-	s = "<none>"
-	if spec.LabelSelector != nil {
-		s = metav1.FormatLabelSelector(spec.LabelSelector)
+	d.Printf("Label selector:\t%s\n", formatLabelSelectors(&spec))
+
+	if spec.ResourcePolicy != "" {
+		d.Println()
+		d.Printf("Resource Policies:\t%s\n", spec.ResourcePolicy)
 	}
-	d.Printf("Label selector:\t%s\n", s)
 
 	d.Println()
 	d.Printf("Storage Location:\t%s\n", spec.StorageLocation)
+	if spec.StoragePrefix != "" {
+		d.Printf("Storage Prefix:\t%s\n", spec.StoragePrefix)
+	}
+	if len(spec.ReplicaStorageLocations) > 0 {
+		d.Printf("Replica Locations:\t%s\n", strings.Join(spec.ReplicaStorageLocations, ", "))
+	}
+	if len(spec.FailoverStorageLocations) > 0 {
+		d.Printf("Failover Locations:\t%s\n", strings.Join(spec.FailoverStorageLocations, ", "))
+	}
+
+	d.Println()
+	s = string(spec.Mode)
+	if s == "" {
+		s = string(velerov1api.BackupModeFull)
+	}
+	d.Printf("Mode:\t%s\n", s)
+	if spec.SkipUnchangedItems {
+		d.Printf("Skip Unchanged Items:\ttrue\n")
+	}
+	if spec.PodVolumeFailurePolicy != "" {
+		d.Printf("Pod Volume Failure Policy:\t%s\n", spec.PodVolumeFailurePolicy)
+	}
 
 	d.Println()
 	d.Printf("Snapshot PVs:\t%s\n", BoolPointerString(spec.SnapshotVolumes, "false", "true", "auto"))
+	if spec.SnapshotMoveData {
+		d.Printf("Snapshot Move Data:\ttrue\n")
+	}
+	d.Printf("Default Volumes to Restic:\t%s\n", BoolPointerString(spec.DefaultVolumesToRestic, "false", "true", "auto"))
 
 	d.Println()
 	d.Printf("TTL:\t%s\n", spec.TTL.Duration)
+	if spec.DeleteProtection {
+		d.Printf("Delete Protection:\tenabled\n")
+	}
+	if spec.RetryPolicy != nil {
+		d.Printf("Retry Policy:\tmax attempts %d, backoff %s\n", spec.RetryPolicy.MaxAttempts, spec.RetryPolicy.Backoff.Duration)
+	}
 
 	d.Println()
 	if len(spec.Hooks.Resources) == 0 {
@@ -229,13 +269,107 @@ func DescribeBackupStatus(d *Describer, backup *velerov1api.Backup, details bool
 		d.Printf("Completed:\t%s\n", status.CompletionTimestamp.Time)
 	}
 
+	if len(status.Attempts) > 0 {
+		d.Println()
+		d.Printf("Failed Attempts:\n")
+		for _, attempt := range status.Attempts {
+			d.Printf("\t%s - %s:\t%s\n", attempt.StartTimestamp.Time, attempt.CompletionTimestamp.Time, attempt.FailureReason)
+		}
+		if status.NextAttempt != nil {
+			d.Printf("Next Attempt:\t%s\n", status.NextAttempt.Time)
+		}
+	}
+
+	for _, condition := range status.Conditions {
+		if condition.Type != velerov1api.ConditionVerified {
+			continue
+		}
+		d.Println()
+		switch condition.Status {
+		case corev1api.ConditionTrue:
+			d.Printf("Verified:	yes (%s)\n", condition.Message)
+		case corev1api.ConditionFalse:
+			d.Printf("Verified:	no (%s)\n", condition.Message)
+		default:
+			d.Printf("Verified:	in progress (%s)\n", condition.Message)
+		}
+	}
+
 	d.Println()
 	d.Printf("Expiration:\t%s\n", status.Expiration.Time)
+	if status.ObjectLockedUntil != nil {
+		d.Printf("Object Locked Until:\t%s\n", status.ObjectLockedUntil.Time)
+	}
 	d.Println()
 
+	if status.Progress != nil {
+		if status.Phase == velerov1api.BackupPhaseInProgress {
+			d.Printf("Estimated total items to be backed up:\t%d\n", status.Progress.TotalItems)
+			d.Printf("Items backed up so far:\t%d\n", status.Progress.ItemsBackedUp)
+		} else {
+			d.Printf("Total items to be backed up:\t%d\n", status.Progress.TotalItems)
+			d.Printf("Items backed up:\t%d\n", status.Progress.ItemsBackedUp)
+		}
+		d.Println()
+	}
+
+	if usage := status.StorageUsage; usage != nil {
+		d.Printf("Storage Usage:\n")
+		d.Printf("\tTotal:\t%s\n", BytesString(usage.TotalBytes))
+		d.Printf("\tTarball:\t%s\n", BytesString(usage.TarballBytes))
+		if len(usage.PodVolumes) > 0 {
+			d.Printf("\tPod Volumes:\n")
+			for _, podVolume := range usage.PodVolumes {
+				d.Printf("\t\t%s:\t%s\n", podVolume.Name, BytesString(podVolume.Bytes))
+			}
+		}
+		d.Println()
+	}
+
+	if len(status.ReferencedBackups) > 0 {
+		d.Printf("Referenced Backups:\t%s\n", strings.Join(status.ReferencedBackups, ", "))
+		d.Println()
+	}
+
+	if len(status.FailedPodVolumes) > 0 {
+		d.Printf("Failed Pod Volumes:\n")
+		for _, podVolume := range status.FailedPodVolumes {
+			d.Printf("\t%s\n", podVolume)
+		}
+		d.Println()
+	}
+
+	if len(status.PluginCrashes) > 0 {
+		d.Printf("Plugin Crashes:\n")
+		for _, crash := range status.PluginCrashes {
+			d.Printf("\t%s\n", crash)
+		}
+		d.Println()
+	}
+
+	if failover := status.StorageLocationFailover; failover != nil {
+		d.Printf("Storage Location Failover:\tstored in %s because it couldn't be stored in %s\n", failover.To, failover.From)
+		d.Println()
+	}
+
+	if len(status.Replicas) > 0 {
+		d.Printf("Replicas:\n")
+		for _, replica := range status.Replicas {
+			if replica.Message != "" {
+				d.Printf("\t%s:\t%s (%s)\n", replica.StorageLocation, replica.Phase, replica.Message)
+			} else {
+				d.Printf("\t%s:\t%s\n", replica.StorageLocation, replica.Phase)
+			}
+		}
+		d.Println()
+	}
+
 	if details {
 		describeBackupResourceList(d, backup, veleroClient)
 		d.Println()
+
+		describeBackupVolumeCoverage(d, backup, veleroClient)
+		d.Println()
 	}
 
 	if status.VolumeSnapshotsAttempted > 0 {
@@ -258,6 +392,10 @@ func DescribeBackupStatus(d *Describer, backup *velerov1api.Backup, details bool
 
 		d.Printf("Persistent Volumes:\n")
 		for _, snap := range snapshots {
+			if snap.Spec.CSIDriver != "" {
+				describeCSISnapshot(d, snap)
+				continue
+			}
 			describeSnapshot(d, snap.Spec.PersistentVolumeName, snap.Status.ProviderSnapshotID, snap.Spec.VolumeType, snap.Spec.VolumeAZ, snap.Spec.VolumeIOPS)
 		}
 		return
@@ -266,6 +404,41 @@ func DescribeBackupStatus(d *Describer, backup *velerov1api.Backup, details bool
 	d.Printf("Persistent Volumes: <none included>\n")
 }
 
+func describeBackupVolumeCoverage(d *Describer, backup *velerov1api.Backup, veleroClient clientset.Interface) {
+	buf := new(bytes.Buffer)
+	if err := downloadrequest.Stream(veleroClient.VeleroV1(), backup.Namespace, backup.Name, velerov1api.DownloadTargetKindBackupVolumeCoverage, buf, downloadRequestTimeout); err != nil {
+		if err == downloadrequest.ErrNotFound {
+			d.Println("Volume Coverage:\t<volume coverage report not found, this could be because this backup was taken by an older version of Velero>")
+		} else {
+			d.Printf("Volume Coverage:\t<error getting volume coverage report: %v>\n", err)
+		}
+		return
+	}
+
+	var coverage map[string]string
+	if err := json.NewDecoder(buf).Decode(&coverage); err != nil {
+		d.Printf("Volume Coverage:\t<error reading volume coverage report: %v>\n", err)
+		return
+	}
+
+	if len(coverage) == 0 {
+		d.Println("Volume Coverage:\t<no persistent volume claims included>")
+		return
+	}
+
+	d.Println("Volume Coverage:")
+
+	claims := make([]string, 0, len(coverage))
+	for claim := range coverage {
+		claims = append(claims, claim)
+	}
+	sort.Strings(claims)
+
+	for _, claim := range claims {
+		d.Printf("\t%s:\t%s\n", claim, coverage[claim])
+	}
+}
+
 func describeBackupResourceList(d *Describer, backup *velerov1api.Backup, veleroClient clientset.Interface) {
 	buf := new(bytes.Buffer)
 	if err := downloadrequest.Stream(veleroClient.VeleroV1(), backup.Namespace, backup.Name, velerov1api.DownloadTargetKindBackupResourceList, buf, downloadRequestTimeout); err != nil {
@@ -309,6 +482,16 @@ func describeSnapshot(d *Describer, pvName, snapshotID, volumeType, volumeAZ str
 	d.Printf("\t\tIOPS:\t%s\n", iopsString)
 }
 
+// describeCSISnapshot describes a snapshot taken through the Kubernetes
+// VolumeSnapshot API.
+func describeCSISnapshot(d *Describer, snapshot *volume.Snapshot) {
+	d.Printf("\t%s:\n", snapshot.Spec.PersistentVolumeName)
+	d.Printf("\t\tSnapshot Handle:\t%s\n", snapshot.Status.ProviderSnapshotID)
+	d.Printf("\t\tCSI Driver:\t%s\n", snapshot.Spec.CSIDriver)
+	d.Printf("\t\tVolume Snapshot Class:\t%s\n", snapshot.Spec.CSIVolumeSnapshotClass)
+	d.Printf("\t\tVolume Snapshot Content:\t%s\n", snapshot.Status.CSIVolumeSnapshotContent)
+}
+
 // DescribeDeleteBackupRequests describes delete backup requests in human-readable format.
 func DescribeDeleteBackupRequests(d *Describer, requests []velerov1api.DeleteBackupRequest) {
 	d.Printf("Deletion Attempts")
@@ -377,7 +560,14 @@ func DescribePodVolumeBackups(d *Describer, backups []velerov1api.PodVolumeBacku
 		backupsByPod := new(volumesByPod)
 
 		for _, backup := range backupsByPhase[phase] {
-			backupsByPod.Add(backup.Spec.Pod.Namespace, backup.Spec.Pod.Name, backup.Spec.Volume)
+			volume := backup.Spec.Volume
+			if backup.Status.Phase == velerov1api.PodVolumeBackupPhaseInProgress {
+				volume = volumeWithProgress(volume, backup.Status.Progress)
+			}
+			if backup.Status.Phase == velerov1api.PodVolumeBackupPhaseCompleted && backup.Status.ParentSnapshotID != "" {
+				volume = fmt.Sprintf("%s (incremental, %d bytes added)", volume, backup.Status.Changes.BytesAdded)
+			}
+			backupsByPod.Add(backup.Spec.Pod.Namespace, backup.Spec.Pod.Name, volume)
 		}
 
 		d.Printf("\t%s:\n", phase)
@@ -390,6 +580,15 @@ func DescribePodVolumeBackups(d *Describer, backups []velerov1api.PodVolumeBacku
 	}
 }
 
+// volumeWithProgress returns the name of a volume that's being backed up
+// or restored with restic, with its progress if any has been reported.
+func volumeWithProgress(volume string, progress velerov1api.PodVolumeOperationProgress) string {
+	if progress.TotalBytes == 0 {
+		return volume
+	}
+	return fmt.Sprintf("%s (%d of %d bytes done)", volume, progress.BytesDone, progress.TotalBytes)
+}
+
 func groupByPhase(backups []velerov1api.PodVolumeBackup) map[string][]velerov1api.PodVolumeBackup {
 	backupsByPhase := make(map[string][]velerov1api.PodVolumeBackup)
 
@@ -21,6 +21,7 @@ import (
 	"io"
 	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -103,7 +104,7 @@ func printBackup(backup *velerov1api.Backup, w io.Writer, options printers.Print
 
 	location := backup.Spec.StorageLocation
 
-	if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s", name, status, backup.Status.StartTimestamp.Time, humanReadableTimeFromNow(expiration), location, metav1.FormatLabelSelector(backup.Spec.LabelSelector)); err != nil {
+	if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s", name, status, backup.Status.StartTimestamp.Time, humanReadableTimeFromNow(expiration), location, formatLabelSelectors(&backup.Spec)); err != nil {
 		return err
 	}
 
@@ -115,6 +116,20 @@ func printBackup(backup *velerov1api.Backup, w io.Writer, options printers.Print
 	return err
 }
 
+// formatLabelSelectors returns a backup's label selector, or its OR-able
+// label selectors separated by " or " if it has any.
+func formatLabelSelectors(spec *velerov1api.BackupSpec) string {
+	if len(spec.OrLabelSelectors) == 0 {
+		return metav1.FormatLabelSelector(spec.LabelSelector)
+	}
+
+	var selectors []string
+	for i := range spec.OrLabelSelectors {
+		selectors = append(selectors, metav1.FormatLabelSelector(&spec.OrLabelSelectors[i]))
+	}
+	return strings.Join(selectors, " or ")
+}
+
 func humanReadableTimeFromNow(when time.Time) string {
 	if when.IsZero() {
 		return "n/a"
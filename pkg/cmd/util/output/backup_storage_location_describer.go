@@ -0,0 +1,113 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+// DescribeBackupStorageLocation describes a backup storage location in human-readable format.
+func DescribeBackupStorageLocation(location *velerov1api.BackupStorageLocation) string {
+	return Describe(func(d *Describer) {
+		d.DescribeMetadata(location.ObjectMeta)
+
+		d.Println()
+		phase := string(location.Status.Phase)
+		if phase == "" {
+			phase = "<unknown>"
+		}
+		d.Printf("Phase:\t%s\n", phase)
+		if location.Status.Message != "" {
+			d.Printf("Message:\t%s\n", location.Status.Message)
+		}
+
+		d.Println()
+		d.Printf("Provider:\t%s\n", location.Spec.Provider)
+		switch {
+		case location.Spec.ObjectStorage != nil:
+			d.Printf("Bucket:\t%s\n", location.Spec.ObjectStorage.Bucket)
+			d.Printf("Prefix:\t%s\n", location.Spec.ObjectStorage.Prefix)
+		case location.Spec.Filesystem != nil:
+			d.Printf("Path:\t%s\n", location.Spec.Filesystem.Path)
+			d.Printf("Prefix:\t%s\n", location.Spec.Filesystem.Prefix)
+		}
+
+		accessMode := location.Spec.AccessMode
+		if accessMode == "" {
+			accessMode = velerov1api.BackupStorageLocationAccessModeReadWrite
+		}
+		d.Printf("Access Mode:\t%s\n", accessMode)
+
+		if location.Spec.Credential != nil {
+			d.Printf("Credential:\t%s/%s\n", location.Spec.Credential.Name, location.Spec.Credential.Key)
+		}
+
+		if location.Spec.UploaderType != "" {
+			d.Printf("Uploader Type:\t%s\n", location.Spec.UploaderType)
+		}
+		if location.Spec.Deduplication {
+			d.Printf("Deduplication:\tenabled\n")
+		}
+		if rateLimit := location.Spec.RateLimit; rateLimit != nil {
+			d.Printf("Rate Limit:\t%d requests/second (burst %d)\n", rateLimit.RequestsPerSecond, rateLimit.Burst)
+		}
+		if location.Spec.SyncPeriod != nil {
+			d.Printf("Sync Period:\t%s\n", location.Spec.SyncPeriod.Duration)
+		}
+		if standby := location.Spec.Standby; standby != nil {
+			backups := "all backups"
+			if standby.BackupSelector != nil {
+				backups = "backups matching " + metav1.FormatLabelSelector(standby.BackupSelector)
+			}
+			if standby.NamespacePrefix != "" {
+				backups += ", into namespaces prefixed with " + standby.NamespacePrefix
+			}
+			d.Printf("Standby:\t%s\n", backups)
+		}
+
+		d.Println()
+		d.DescribeMap("Config", location.Spec.Config)
+
+		d.Println()
+		lastValidated := "<never>"
+		if location.Status.LastValidationTime != nil {
+			lastValidated = location.Status.LastValidationTime.Time.String()
+		}
+		d.Printf("Last Validated:\t%s\n", lastValidated)
+
+		lastSynced := "<never>"
+		if !location.Status.LastSyncedTime.Time.IsZero() {
+			lastSynced = location.Status.LastSyncedTime.Time.String()
+		}
+		d.Printf("Last Synced:\t%s\n", lastSynced)
+		if requested, ok := location.Annotations[velerov1api.BackupStorageLocationSyncAnnotation]; ok {
+			d.Printf("Sync Requested:\t%s\n", requested)
+		}
+
+		if location.Spec.Standby != nil {
+			lastStandby := "<never>"
+			if location.Status.LastStandbyRestoreTime != nil {
+				lastStandby = fmt.Sprintf("%s at %s", location.Status.LastStandbyBackup, location.Status.LastStandbyRestoreTime.Time)
+			}
+			d.Printf("Last Standby Restore:\t%s\n", lastStandby)
+		}
+	})
+}
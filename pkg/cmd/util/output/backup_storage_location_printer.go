@@ -26,7 +26,7 @@ import (
 )
 
 var (
-	backupStorageLocationColumns = []string{"NAME", "PROVIDER", "BUCKET/PREFIX", "ACCESS MODE"}
+	backupStorageLocationColumns = []string{"NAME", "PROVIDER", "BUCKET/PREFIX", "ACCESS MODE", "PHASE", "LAST VALIDATED"}
 )
 
 func printBackupStorageLocationList(list *v1.BackupStorageLocationList, w io.Writer, options printers.PrintOptions) error {
@@ -47,9 +47,18 @@ func printBackupStorageLocation(location *v1.BackupStorageLocation, w io.Writer,
 		}
 	}
 
-	bucketAndPrefix := location.Spec.ObjectStorage.Bucket
-	if location.Spec.ObjectStorage.Prefix != "" {
-		bucketAndPrefix += "/" + location.Spec.ObjectStorage.Prefix
+	var bucketAndPrefix string
+	switch {
+	case location.Spec.ObjectStorage != nil:
+		bucketAndPrefix = location.Spec.ObjectStorage.Bucket
+		if location.Spec.ObjectStorage.Prefix != "" {
+			bucketAndPrefix += "/" + location.Spec.ObjectStorage.Prefix
+		}
+	case location.Spec.Filesystem != nil:
+		bucketAndPrefix = location.Spec.Filesystem.Path
+		if location.Spec.Filesystem.Prefix != "" {
+			bucketAndPrefix += "/" + location.Spec.Filesystem.Prefix
+		}
 	}
 
 	accessMode := location.Spec.AccessMode
@@ -57,13 +66,25 @@ func printBackupStorageLocation(location *v1.BackupStorageLocation, w io.Writer,
 		accessMode = v1.BackupStorageLocationAccessModeReadWrite
 	}
 
+	phase := string(location.Status.Phase)
+	if phase == "" {
+		phase = "<unknown>"
+	}
+
+	lastValidated := "<never>"
+	if location.Status.LastValidationTime != nil {
+		lastValidated = location.Status.LastValidationTime.Time.String()
+	}
+
 	if _, err := fmt.Fprintf(
 		w,
-		"%s\t%s\t%s\t%s",
+		"%s\t%s\t%s\t%s\t%s\t%s",
 		name,
 		location.Spec.Provider,
 		bucketAndPrefix,
 		accessMode,
+		phase,
+		lastValidated,
 	); err != nil {
 		return err
 	}
@@ -0,0 +1,79 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"fmt"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+// DescribeComplianceReport describes a compliance report in human-readable
+// format.
+func DescribeComplianceReport(report *velerov1api.ComplianceReport) string {
+	return Describe(func(d *Describer) {
+		d.DescribeMetadata(report.ObjectMeta)
+
+		d.Println()
+		d.Printf("Generated:\t%s\n", report.Status.GenerationTimestamp.Time)
+		d.Printf("Compliant:\t%t\n", report.Status.Compliant)
+
+		for _, schedule := range report.Status.Schedules {
+			d.Println()
+			describeScheduleCompliance(d, schedule)
+		}
+	})
+}
+
+func describeScheduleCompliance(d *Describer, schedule velerov1api.ScheduleCompliance) {
+	d.Printf("Schedule:\t%s\n", schedule.Schedule)
+	d.Printf("  Compliant:\t%t\n", schedule.Compliant)
+	d.Printf("  Cron Schedule:\t%s\n", schedule.CronSchedule)
+	d.Printf("  Required Retention:\t%s\n", schedule.RequiredRetention.Duration)
+	d.Printf("  Completed Backups:\t%d\n", schedule.CompletedBackups)
+	d.Printf("  Oldest Backup:\t%s\n", describeComplianceBackup(schedule.OldestBackup))
+	d.Printf("  Newest Backup:\t%s\n", describeComplianceBackup(schedule.NewestBackup))
+
+	if schedule.Verification != "" {
+		verification := string(schedule.Verification)
+		if schedule.VerificationMessage != "" {
+			verification = fmt.Sprintf("%s (%s)", verification, schedule.VerificationMessage)
+		}
+		d.Printf("  Verification:\t%s\n", verification)
+	}
+
+	if len(schedule.FailedBackups) > 0 {
+		d.Println("  Failed Backups:")
+		for _, backup := range schedule.FailedBackups {
+			d.Printf("    %s\n", backup)
+		}
+	}
+
+	if len(schedule.Problems) > 0 {
+		d.Println("  Problems:")
+		for _, problem := range schedule.Problems {
+			d.Printf("    %s\n", problem)
+		}
+	}
+}
+
+func describeComplianceBackup(backup *velerov1api.ComplianceBackupReference) string {
+	if backup == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%s (started %s, in %s)", backup.Name, backup.StartTimestamp.Time, backup.StorageLocation)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/kubernetes/pkg/printers"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+var (
+	complianceReportColumns = []string{"NAME", "GENERATED", "COMPLIANT", "SCHEDULES", "NON-COMPLIANT SCHEDULES"}
+)
+
+func printComplianceReportList(list *velerov1api.ComplianceReportList, w io.Writer, options printers.PrintOptions) error {
+	for i := range list.Items {
+		if err := printComplianceReport(&list.Items[i], w, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printComplianceReport(report *velerov1api.ComplianceReport, w io.Writer, options printers.PrintOptions) error {
+	name := printers.FormatResourceName(options.Kind, report.Name, options.WithKind)
+
+	if options.WithNamespace {
+		if _, err := fmt.Fprintf(w, "%s\t", report.Namespace); err != nil {
+			return err
+		}
+	}
+
+	var nonCompliant int
+	for _, schedule := range report.Status.Schedules {
+		if !schedule.Compliant {
+			nonCompliant++
+		}
+	}
+
+	_, err := fmt.Fprintf(
+		w,
+		"%s\t%s\t%t\t%d\t%d",
+		name,
+		report.Status.GenerationTimestamp.Time,
+		report.Status.Compliant,
+		len(report.Status.Schedules),
+		nonCompliant,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprint(w, printers.AppendLabels(report.Labels, options.ColumnLabels)); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(w, printers.AppendAllLabels(options.ShowLabels, report.Labels))
+	return err
+}
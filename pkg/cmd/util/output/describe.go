@@ -118,3 +118,19 @@ func BoolPointerString(b *bool, falseString, trueString, nilString string) strin
 	}
 	return falseString
 }
+
+// BytesString returns a human-readable representation of a number of bytes,
+// using binary units, e.g. "1.5 MiB".
+func BytesString(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for q := n / unit; q >= unit; q /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/cmd/util/downloadrequest"
+	clientset "github.com/heptio/velero/pkg/generated/clientset/versioned"
+	pkgrestore "github.com/heptio/velero/pkg/restore"
+	"github.com/heptio/velero/pkg/volume"
+)
+
+// BackupDescription is the structured form of `velero backup describe`,
+// printed with -o json|yaml.
+type BackupDescription struct {
+	Backup               *velerov1api.Backup               `json:"backup"`
+	DeleteBackupRequests []velerov1api.DeleteBackupRequest `json:"deleteBackupRequests,omitempty"`
+	PodVolumeBackups     []velerov1api.PodVolumeBackup     `json:"podVolumeBackups,omitempty"`
+
+	// ResourceList, VolumeSnapshots and VolumeCoverage are downloaded from
+	// the backup storage location, and are only included with --details.
+	ResourceList    map[string][]string `json:"resourceList,omitempty"`
+	VolumeSnapshots []*volume.Snapshot  `json:"volumeSnapshots,omitempty"`
+	VolumeCoverage  map[string]string   `json:"volumeCoverage,omitempty"`
+
+	// Errors lists any problems downloading the details.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// NewBackupDescription returns the structured description of a backup.
+func NewBackupDescription(
+	backup *velerov1api.Backup,
+	deleteRequests []velerov1api.DeleteBackupRequest,
+	podVolumeBackups []velerov1api.PodVolumeBackup,
+	details bool,
+	veleroClient clientset.Interface,
+) *BackupDescription {
+	description := &BackupDescription{
+		Backup:               backup,
+		DeleteBackupRequests: deleteRequests,
+		PodVolumeBackups:     podVolumeBackups,
+	}
+
+	if !details {
+		return description
+	}
+
+	if err := downloadJSON(veleroClient, backup.Namespace, backup.Name, velerov1api.DownloadTargetKindBackupResourceList, &description.ResourceList); err != nil {
+		description.Errors = append(description.Errors, fmt.Sprintf("error getting backup resource list: %v", err))
+	}
+
+	if err := downloadJSON(veleroClient, backup.Namespace, backup.Name, velerov1api.DownloadTargetKindBackupVolumeCoverage, &description.VolumeCoverage); err != nil {
+		description.Errors = append(description.Errors, fmt.Sprintf("error getting volume coverage report: %v", err))
+	}
+
+	if backup.Status.VolumeSnapshotsAttempted > 0 {
+		if err := downloadJSON(veleroClient, backup.Namespace, backup.Name, velerov1api.DownloadTargetKindBackupVolumeSnapshots, &description.VolumeSnapshots); err != nil {
+			description.Errors = append(description.Errors, fmt.Sprintf("error getting volume snapshot info: %v", err))
+		}
+	}
+
+	return description
+}
+
+// RestoreDescription is the structured form of `velero restore describe`,
+// printed with -o json|yaml.
+type RestoreDescription struct {
+	Restore           *velerov1api.Restore           `json:"restore"`
+	PodVolumeRestores []velerov1api.PodVolumeRestore `json:"podVolumeRestores,omitempty"`
+
+	// Results are downloaded from the backup storage location when the
+	// restore has warnings or errors, or is a finished dry run.
+	Results map[string]pkgrestore.Result `json:"results,omitempty"`
+
+	// Errors lists any problems downloading the results.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// NewRestoreDescription returns the structured description of a restore.
+func NewRestoreDescription(restore *velerov1api.Restore, podVolumeRestores []velerov1api.PodVolumeRestore, veleroClient clientset.Interface) *RestoreDescription {
+	description := &RestoreDescription{
+		Restore:           restore,
+		PodVolumeRestores: podVolumeRestores,
+	}
+
+	dryRunFinished := restore.Spec.DryRun && (restore.Status.Phase == velerov1api.RestorePhaseCompleted || restore.Status.Phase == velerov1api.RestorePhasePartiallyFailed)
+	if restore.Status.Warnings == 0 && restore.Status.Errors == 0 && !dryRunFinished {
+		return description
+	}
+
+	if err := downloadJSON(veleroClient, restore.Namespace, restore.Name, velerov1api.DownloadTargetKindRestoreResults, &description.Results); err != nil {
+		description.Errors = append(description.Errors, fmt.Sprintf("error getting restore results: %v", err))
+	}
+
+	return description
+}
+
+// downloadJSON downloads the given target and decodes it into v.
+func downloadJSON(veleroClient clientset.Interface, namespace, name string, kind velerov1api.DownloadTargetKind, v interface{}) error {
+	buf := new(bytes.Buffer)
+	if err := downloadrequest.Stream(veleroClient.VeleroV1(), namespace, name, kind, buf, downloadRequestTimeout); err != nil {
+		return err
+	}
+
+	return errors.Wrap(json.NewDecoder(buf).Decode(v), "error decoding")
+}
+
+// PrintDescriptions writes descriptions to w in the given format, which must
+// be "json" or "yaml". Like PrintWithFormat does for lists, a single
+// description is printed on its own rather than as a list.
+func PrintDescriptions(w io.Writer, format string, descriptions []interface{}) error {
+	var toPrint interface{} = map[string]interface{}{"items": descriptions}
+	if len(descriptions) == 1 {
+		toPrint = descriptions[0]
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	switch format {
+	case "json":
+		data, err = json.MarshalIndent(toPrint, "", "    ")
+		data = append(data, '\n')
+	case "yaml":
+		data, err = yaml.Marshal(toPrint)
+	default:
+		return errors.Errorf("unsupported output format %q; valid values are 'json' and 'yaml'", format)
+	}
+	if err != nil {
+		return errors.Wrap(err, "error encoding description")
+	}
+
+	_, err = w.Write(data)
+	return errors.WithStack(err)
+}
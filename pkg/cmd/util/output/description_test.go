@@ -0,0 +1,77 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+func TestPrintDescriptions(t *testing.T) {
+	backup := NewBackupDescription(
+		&velerov1api.Backup{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "backup-1"}},
+		nil,
+		[]velerov1api.PodVolumeBackup{{ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "backup-1-pvb"}}},
+		false,
+		nil,
+	)
+	restores := []interface{}{
+		&RestoreDescription{Restore: &velerov1api.Restore{ObjectMeta: metav1.ObjectMeta{Name: "restore-1"}}},
+		&RestoreDescription{Restore: &velerov1api.Restore{ObjectMeta: metav1.ObjectMeta{Name: "restore-2"}}, Errors: []string{"boom"}},
+	}
+
+	// a single description is printed on its own
+	buf := new(bytes.Buffer)
+	require.NoError(t, PrintDescriptions(buf, "yaml", []interface{}{backup}))
+
+	var single struct {
+		Backup           velerov1api.Backup
+		PodVolumeBackups []velerov1api.PodVolumeBackup
+		ResourceList     map[string][]string
+		Errors           []string
+	}
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &single))
+	assert.Equal(t, "backup-1", single.Backup.Name)
+	require.Len(t, single.PodVolumeBackups, 1)
+	assert.Equal(t, "backup-1-pvb", single.PodVolumeBackups[0].Name)
+	assert.Nil(t, single.ResourceList)
+	assert.Empty(t, single.Errors)
+
+	// multiple descriptions are printed as a list
+	buf.Reset()
+	require.NoError(t, PrintDescriptions(buf, "json", restores))
+
+	var list struct {
+		Items []RestoreDescription
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &list))
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "restore-1", list.Items[0].Restore.Name)
+	assert.Equal(t, "restore-2", list.Items[1].Restore.Name)
+	assert.Equal(t, []string{"boom"}, list.Items[1].Errors)
+
+	// table isn't a structured format
+	assert.Error(t, PrintDescriptions(buf, "table", restores))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/kubernetes/pkg/printers"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+var (
+	operationHistoryColumns = []string{"KIND", "NAME", "STATUS", "WARNINGS", "ERRORS", "STARTED", "COMPLETED", "FROM"}
+)
+
+func printOperationHistoryList(list *velerov1api.OperationHistoryList, w io.Writer, options printers.PrintOptions) error {
+	for i := range list.Items {
+		if err := printOperationHistory(&list.Items[i], w, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printOperationHistory prints a row for each of a history's operations,
+// rather than one for the history.
+func printOperationHistory(history *velerov1api.OperationHistory, w io.Writer, options printers.PrintOptions) error {
+	for _, op := range history.Status.Operations {
+		if options.WithNamespace {
+			if _, err := fmt.Fprintf(w, "%s\t", history.Namespace); err != nil {
+				return err
+			}
+		}
+
+		completed := "<n/a>"
+		if op.CompletionTimestamp != nil {
+			completed = op.CompletionTimestamp.Time.String()
+		}
+
+		// backups are from their schedule, and restores from their backup.
+		from := op.Schedule
+		if op.Kind == velerov1api.OperationKindRestore {
+			from = op.Backup
+		}
+
+		if _, err := fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			op.Kind,
+			op.Name,
+			op.Phase,
+			op.Warnings,
+			op.Errors,
+			op.StartTimestamp.Time,
+			completed,
+			from,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -48,6 +48,13 @@ func BindFlagsSimple(flags *pflag.FlagSet) {
 	flags.StringP("output", "o", "table", "Output display format. For create commands, display the object but do not send it to the server. Valid formats are 'table', 'json', and 'yaml'. 'table' is not valid for the install command.")
 }
 
+// BindDescribeFlags defines the output format flag for describe commands,
+// which print a human-readable description unless 'json' or 'yaml' is
+// requested.
+func BindDescribeFlags(flags *pflag.FlagSet) {
+	flags.StringP("output", "o", "", "Output display format. Valid formats are 'json' and 'yaml'. If not specified, a human-readable description is displayed.")
+}
+
 // ClearOutputFlagDefault sets the current and default value
 // of the "output" flag to the empty string.
 func ClearOutputFlagDefault(cmd *cobra.Command) {
@@ -86,6 +93,17 @@ func ValidateFlags(cmd *cobra.Command) error {
 	return nil
 }
 
+// ValidateDescribeFlags returns an error if the output flag of a describe
+// command was specified with an invalid value, or nil otherwise.
+func ValidateDescribeFlags(cmd *cobra.Command) error {
+	switch output := GetOutputFlagValue(cmd); output {
+	case "", "json", "yaml":
+		return nil
+	default:
+		return errors.Errorf("invalid output format %q - valid values are 'json' and 'yaml'", output)
+	}
+}
+
 func validateOutputFlag(cmd *cobra.Command) error {
 	output := GetOutputFlagValue(cmd)
 	switch output {
@@ -159,6 +177,10 @@ func printTable(cmd *cobra.Command, obj runtime.Object) (bool, error) {
 	printer.Handler(volumeSnapshotLocationColumns, nil, printVolumeSnapshotLocation)
 	printer.Handler(volumeSnapshotLocationColumns, nil, printVolumeSnapshotLocationList)
 	printer.Handler(pluginColumns, nil, printPluginList)
+	printer.Handler(complianceReportColumns, nil, printComplianceReport)
+	printer.Handler(complianceReportColumns, nil, printComplianceReportList)
+	printer.Handler(operationHistoryColumns, nil, printOperationHistory)
+	printer.Handler(operationHistoryColumns, nil, printOperationHistoryList)
 
 	err = printer.PrintObj(obj, os.Stdout)
 	if err != nil {
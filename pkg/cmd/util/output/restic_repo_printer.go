@@ -26,7 +26,7 @@ import (
 )
 
 var (
-	resticRepoColumns = []string{"NAME", "STATUS", "LAST MAINTENANCE"}
+	resticRepoColumns = []string{"NAME", "STATUS", "LAST MAINTENANCE", "LAST CHECK"}
 )
 
 func printResticRepoList(list *v1.ResticRepositoryList, w io.Writer, options printers.PrintOptions) error {
@@ -57,12 +57,18 @@ func printResticRepo(repo *v1.ResticRepository, w io.Writer, options printers.Pr
 		lastMaintenance = "<never>"
 	}
 
+	lastCheck := "<never>"
+	if !repo.Status.LastCheckTime.IsZero() {
+		lastCheck = fmt.Sprintf("%s (%s)", repo.Status.LastCheckResult, repo.Status.LastCheckTime.String())
+	}
+
 	if _, err := fmt.Fprintf(
 		w,
-		"%s\t%s\t%s",
+		"%s\t%s\t%s\t%s",
 		name,
 		status,
 		lastMaintenance,
+		lastCheck,
 	); err != nil {
 		return err
 	}
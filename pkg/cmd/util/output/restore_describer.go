@@ -56,11 +56,24 @@ func DescribeRestore(restore *v1.Restore, podVolumeRestores []v1.PodVolumeRestor
 			}
 		}
 
+		if len(restore.Status.PluginCrashes) > 0 {
+			d.Println()
+			d.Printf("Plugin crashes:\n")
+			for _, crash := range restore.Status.PluginCrashes {
+				d.Printf("\t%s\n", crash)
+			}
+		}
+
 		describeRestoreResults(d, restore, veleroClient)
 
 		d.Println()
 		d.Printf("Backup:\t%s\n", restore.Spec.BackupName)
 
+		if restore.Spec.Credential != nil {
+			d.Println()
+			d.Printf("Credential:\t%s/%s\n", restore.Spec.Credential.Name, restore.Spec.Credential.Key)
+		}
+
 		d.Println()
 		d.Printf("Namespaces:\n")
 		var s string
@@ -93,10 +106,19 @@ func DescribeRestore(restore *v1.Restore, podVolumeRestores []v1.PodVolumeRestor
 		d.Printf("\tExcluded:\t%s\n", s)
 
 		d.Printf("\tCluster-scoped:\t%s\n", BoolPointerString(restore.Spec.IncludeClusterResources, "excluded", "included", "auto"))
+		if len(restore.Spec.IncludedClusterResourceNames) > 0 {
+			d.Printf("\tIncluded cluster-scoped items:\t%s\n", strings.Join(restore.Spec.IncludedClusterResourceNames, ", "))
+		}
+		if len(restore.Spec.ExcludedClusterResourceNames) > 0 {
+			d.Printf("\tExcluded cluster-scoped items:\t%s\n", strings.Join(restore.Spec.ExcludedClusterResourceNames, ", "))
+		}
 
 		d.Println()
 		d.DescribeMap("Namespace mappings", restore.Spec.NamespaceMapping)
 
+		d.Println()
+		d.DescribeMap("Storage class mappings", restore.Spec.StorageClassMapping)
+
 		d.Println()
 		s = "<none>"
 		if restore.Spec.LabelSelector != nil {
@@ -104,6 +126,59 @@ func DescribeRestore(restore *v1.Restore, podVolumeRestores []v1.PodVolumeRestor
 		}
 		d.Printf("Label selector:\t%s\n", s)
 
+		if restore.Spec.ResourcePolicy != "" {
+			d.Println()
+			d.Printf("Resource Policies:\t%s\n", restore.Spec.ResourcePolicy)
+		}
+
+		d.Println()
+		describeResourceModifiers(d, restore.Spec.ResourceModifiers)
+
+		d.Println()
+		s = string(restore.Spec.Mode)
+		if s == "" {
+			s = string(v1.RestoreModeFull)
+		}
+		d.Printf("Mode:\t%s\n", s)
+
+		d.Println()
+		s = string(restore.Spec.ControlPlaneConfigPolicy)
+		if s == "" {
+			s = string(v1.ControlPlaneConfigPolicySkip)
+		}
+		d.Printf("Control-plane configuration:\t%s\n", s)
+
+		d.Println()
+		s = string(restore.Spec.ExtractionPolicy)
+		if s == "" {
+			s = string(v1.RestoreExtractionPolicyFull)
+		}
+		d.Printf("Extraction:\t%s\n", s)
+
+		d.Println()
+		d.Printf("Dry run:\t%t\n", restore.Spec.DryRun)
+
+		d.Println()
+		s = "<server default>"
+		if len(restore.Spec.ResourcePriorities) > 0 {
+			s = strings.Join(restore.Spec.ResourcePriorities, ", ")
+		}
+		d.Printf("Resource priorities:\t%s\n", s)
+
+		d.Println()
+		s = "false"
+		if restore.Spec.WaitForReady {
+			timeout := restore.Spec.ReadyTimeout.Duration
+			if timeout == 0 {
+				timeout = pkgrestore.DefaultReadyTimeout
+			}
+			s = fmt.Sprintf("true (timeout %s)", timeout)
+		}
+		d.Printf("Wait for ready:\t%s\n", s)
+
+		d.Println()
+		describeRestoreHooks(d, restore.Spec.Hooks)
+
 		d.Println()
 		d.Printf("Restore PVs:\t%s\n", BoolPointerString(restore.Spec.RestorePVs, "false", "true", "auto"))
 
@@ -115,7 +190,10 @@ func DescribeRestore(restore *v1.Restore, podVolumeRestores []v1.PodVolumeRestor
 }
 
 func describeRestoreResults(d *Describer, restore *v1.Restore, veleroClient clientset.Interface) {
-	if restore.Status.Warnings == 0 && restore.Status.Errors == 0 {
+	// a dry run's results have what it would have done, so they're described
+	// once it's finished, even without warnings or errors.
+	dryRunFinished := restore.Spec.DryRun && (restore.Status.Phase == v1.RestorePhaseCompleted || restore.Status.Phase == v1.RestorePhasePartiallyFailed)
+	if restore.Status.Warnings == 0 && restore.Status.Errors == 0 && !dryRunFinished {
 		return
 	}
 
@@ -140,6 +218,14 @@ func describeRestoreResults(d *Describer, restore *v1.Restore, veleroClient clie
 		d.Println()
 		describeRestoreResult(d, "Errors", resultMap["errors"])
 	}
+	if dryRunFinished {
+		d.Println()
+		describeRestoreResult(d, "Would Create", resultMap["created"])
+		d.Println()
+		describeRestoreResult(d, "Would Skip", resultMap["skipped"])
+		d.Println()
+		describeRestoreResult(d, "Conflicts", resultMap["conflicts"])
+	}
 }
 
 func describeRestoreResult(d *Describer, name string, result pkgrestore.Result) {
@@ -188,7 +274,11 @@ func describePodVolumeRestores(d *Describer, restores []v1.PodVolumeRestore, det
 		restoresByPod := new(volumesByPod)
 
 		for _, restore := range restoresByPhase[phase] {
-			restoresByPod.Add(restore.Spec.Pod.Namespace, restore.Spec.Pod.Name, restore.Spec.Volume)
+			volume := restore.Spec.Volume
+			if restore.Status.Phase == v1.PodVolumeRestorePhaseInProgress {
+				volume = volumeWithProgress(volume, restore.Status.Progress)
+			}
+			restoresByPod.Add(restore.Spec.Pod.Namespace, restore.Spec.Pod.Name, volume)
 		}
 
 		d.Printf("\t%s:\n", phase)
@@ -219,3 +309,101 @@ func groupRestoresByPhase(restores []v1.PodVolumeRestore) map[string][]v1.PodVol
 
 	return restoresByPhase
 }
+
+// describeResourceModifiers describes a restore's resource modifier rules,
+// with the operations of each rule's patch indented under it.
+func describeResourceModifiers(d *Describer, modifiers []v1.RestoreResourceModifier) {
+	if len(modifiers) == 0 {
+		d.Printf("Resource modifiers:\t<none>\n")
+		return
+	}
+
+	d.Printf("Resource modifiers:\n")
+	for _, modifier := range modifiers {
+		var filters []string
+		if len(modifier.Namespaces) > 0 {
+			filters = append(filters, "namespaces: "+strings.Join(modifier.Namespaces, ", "))
+		}
+		if modifier.Name != "" {
+			filters = append(filters, "name: "+modifier.Name)
+		}
+		if modifier.LabelSelector != nil {
+			filters = append(filters, "label selector: "+metav1.FormatLabelSelector(modifier.LabelSelector))
+		}
+
+		if len(filters) > 0 {
+			d.Printf("\t%s (%s)\n", modifier.GroupResource, strings.Join(filters, "; "))
+		} else {
+			d.Printf("\t%s\n", modifier.GroupResource)
+		}
+
+		for _, patch := range modifier.Patches {
+			operation := patch.Operation + " " + patch.Path
+			if patch.From != "" {
+				operation += " from " + patch.From
+			}
+			if patch.Value != nil {
+				value := string(patch.Value.Raw)
+				if value == "" {
+					value = "null"
+				}
+				operation += " " + value
+			}
+			d.Printf("\t\t%s\n", operation)
+		}
+	}
+}
+
+func describeRestoreHooks(d *Describer, hooks v1.RestoreHooks) {
+	if len(hooks.Resources) == 0 {
+		d.Printf("Hooks:\t<none>\n")
+		return
+	}
+
+	d.Printf("Hooks:\n")
+	d.Printf("\tResources:\n")
+	for _, spec := range hooks.Resources {
+		d.Printf("\t\t%s:\n", spec.Name)
+		d.Printf("\t\t\tNamespaces:\n")
+		d.Printf("\t\t\t\tIncluded:\t%s\n", listOrDefault(spec.IncludedNamespaces, "*"))
+		d.Printf("\t\t\t\tExcluded:\t%s\n", listOrDefault(spec.ExcludedNamespaces, "<none>"))
+
+		d.Println()
+		d.Printf("\t\t\tResources:\n")
+		d.Printf("\t\t\t\tIncluded:\t%s\n", listOrDefault(spec.IncludedResources, "*"))
+		d.Printf("\t\t\t\tExcluded:\t%s\n", listOrDefault(spec.ExcludedResources, "<none>"))
+
+		d.Println()
+		s := "<none>"
+		if spec.LabelSelector != nil {
+			s = metav1.FormatLabelSelector(spec.LabelSelector)
+		}
+		d.Printf("\t\t\tLabel selector:\t%s\n", s)
+
+		for _, hook := range spec.PostHooks {
+			switch {
+			case hook.Init != nil:
+				d.Println()
+				d.Printf("\t\t\tInit Hook:\n")
+				for _, container := range hook.Init.InitContainers {
+					d.Printf("\t\t\t\tContainer:\t%s (%s)\n", container.Name, container.Image)
+				}
+			case hook.Exec != nil:
+				d.Println()
+				d.Printf("\t\t\tExec Hook:\n")
+				d.Printf("\t\t\t\tContainer:\t%s\n", hook.Exec.Container)
+				d.Printf("\t\t\t\tCommand:\t%s\n", strings.Join(hook.Exec.Command, " "))
+				d.Printf("\t\t\t\tOn Error:\t%s\n", hook.Exec.OnError)
+				d.Printf("\t\t\t\tExec Timeout:\t%s\n", hook.Exec.ExecTimeout.Duration)
+				d.Printf("\t\t\t\tWait Timeout:\t%s\n", hook.Exec.WaitTimeout.Duration)
+			}
+		}
+	}
+}
+
+func listOrDefault(list []string, def string) string {
+	if len(list) == 0 {
+		return def
+	}
+	return strings.Join(list, ", ")
+}
@@ -52,6 +52,29 @@ func DescribeSchedule(schedule *v1.Schedule) string {
 
 func DescribeScheduleSpec(d *Describer, spec v1.ScheduleSpec) {
 	d.Printf("Schedule:\t%s\n", spec.Schedule)
+	for _, schedule := range spec.Schedules {
+		d.Printf("\t%s\n", schedule)
+	}
+	if spec.TimeZone != "" {
+		d.Printf("Time Zone:\t%s\n", spec.TimeZone)
+	}
+	if spec.OverlapPolicy != "" {
+		d.Printf("Overlap Policy:\t%s\n", spec.OverlapPolicy)
+	}
+	if r := spec.Retention; r != nil {
+		d.Printf("Retention:\thourly %d, daily %d, weekly %d, monthly %d, yearly %d\n", r.KeepHourly, r.KeepDaily, r.KeepWeekly, r.KeepMonthly, r.KeepYearly)
+	}
+	if v := spec.Verification; v != nil {
+		mode := v.Mode
+		if mode == "" {
+			mode = v1.ScheduleVerificationModeDryRun
+		}
+		namespaces := "all namespaces"
+		if v.SampleNamespaces > 0 {
+			namespaces = fmt.Sprintf("%d sampled namespaces", v.SampleNamespaces)
+		}
+		d.Printf("Verification:\t%s, %s\n", mode, namespaces)
+	}
 
 	d.Println()
 	d.Println("Backup Template:")
@@ -66,4 +89,7 @@ func DescribeScheduleStatus(d *Describer, status v1.ScheduleStatus) {
 		lastBackup = fmt.Sprintf("%v", status.LastBackup.Time)
 	}
 	d.Printf("Last Backup:\t%s\n", lastBackup)
+	if status.LastSkipped != nil {
+		d.Printf("Last Skipped:\t%v\n", status.LastSkipped.Time)
+	}
 }
@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"io"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/printers"
 
 	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -62,7 +61,7 @@ func printSchedule(schedule *v1.Schedule, w io.Writer, options printers.PrintOpt
 		schedule.Spec.Schedule,
 		schedule.Spec.Template.TTL.Duration,
 		humanReadableTimeFromNow(schedule.Status.LastBackup.Time),
-		metav1.FormatLabelSelector(schedule.Spec.Template.LabelSelector),
+		formatLabelSelectors(&schedule.Spec.Template),
 	)
 
 	if err != nil {
@@ -28,16 +28,23 @@ import (
 	"github.com/heptio/velero/pkg/cmd/cli/bug"
 	cliclient "github.com/heptio/velero/pkg/cmd/cli/client"
 	"github.com/heptio/velero/pkg/cmd/cli/completion"
+	"github.com/heptio/velero/pkg/cmd/cli/compliancereport"
 	"github.com/heptio/velero/pkg/cmd/cli/create"
+	"github.com/heptio/velero/pkg/cmd/cli/credential"
+	"github.com/heptio/velero/pkg/cmd/cli/debug"
 	"github.com/heptio/velero/pkg/cmd/cli/delete"
 	"github.com/heptio/velero/pkg/cmd/cli/describe"
 	"github.com/heptio/velero/pkg/cmd/cli/get"
+	"github.com/heptio/velero/pkg/cmd/cli/history"
 	"github.com/heptio/velero/pkg/cmd/cli/install"
 	"github.com/heptio/velero/pkg/cmd/cli/plugin"
 	"github.com/heptio/velero/pkg/cmd/cli/restic"
 	"github.com/heptio/velero/pkg/cmd/cli/restore"
 	"github.com/heptio/velero/pkg/cmd/cli/schedule"
 	"github.com/heptio/velero/pkg/cmd/cli/snapshotlocation"
+	"github.com/heptio/velero/pkg/cmd/cli/uninstall"
+	"github.com/heptio/velero/pkg/cmd/cli/upgrade"
+	"github.com/heptio/velero/pkg/cmd/cli/verifyinstall"
 	"github.com/heptio/velero/pkg/cmd/cli/version"
 	"github.com/heptio/velero/pkg/cmd/server"
 	runplugin "github.com/heptio/velero/pkg/cmd/server/plugin"
@@ -67,17 +74,24 @@ operations can also be performed as 'velero backup get' and 'velero schedule cre
 		version.NewCommand(f),
 		get.NewCommand(f),
 		install.NewCommand(f),
+		uninstall.NewCommand(f),
+		upgrade.NewCommand(f),
 		describe.NewCommand(f),
 		create.NewCommand(f),
 		runplugin.NewCommand(f),
 		plugin.NewCommand(f),
 		delete.NewCommand(f),
 		cliclient.NewCommand(),
-		completion.NewCommand(),
+		completion.NewCommand(f),
 		restic.NewCommand(f),
 		bug.NewCommand(),
+		debug.NewCommand(f),
 		backuplocation.NewCommand(f),
 		snapshotlocation.NewCommand(f),
+		credential.NewCommand(f),
+		compliancereport.NewCommand(f),
+		history.NewCommand(f),
+		verifyinstall.NewCommand(f),
 	)
 
 	// init and add the klog flags
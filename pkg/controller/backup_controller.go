@@ -23,42 +23,65 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
+	"strings"
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/clock"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
 	pkgbackup "github.com/heptio/velero/pkg/backup"
+	"github.com/heptio/velero/pkg/csi"
 	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
 	"github.com/heptio/velero/pkg/label"
 	"github.com/heptio/velero/pkg/metrics"
+	"github.com/heptio/velero/pkg/notifications"
 	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/clientmgmt"
-	"github.com/heptio/velero/pkg/util/collections"
+	"github.com/heptio/velero/pkg/plugin/velero"
+	"github.com/heptio/velero/pkg/resourcepolicies"
 	"github.com/heptio/velero/pkg/util/encode"
 	kubeutil "github.com/heptio/velero/pkg/util/kube"
 	"github.com/heptio/velero/pkg/util/logging"
+	"github.com/heptio/velero/pkg/validation"
 	"github.com/heptio/velero/pkg/volume"
 )
 
+// backupProgressUpdateInterval is how often a running backup's progress is
+// recorded in its status.
+var backupProgressUpdateInterval = 10 * time.Second
+
+const (
+	// defaultBackupRetryBackoff is how long to wait before the first retry
+	// of a backup whose retry policy doesn't specify a backoff.
+	defaultBackupRetryBackoff = time.Minute
+
+	// maxBackupRetryBackoff is the longest time to wait between attempts
+	// of a backup.
+	maxBackupRetryBackoff = time.Hour
+)
+
 type backupController struct {
 	*genericController
 
 	backupper                pkgbackup.Backupper
 	lister                   listers.BackupLister
 	client                   velerov1client.BackupsGetter
+	configMapsClient         corev1client.ConfigMapsGetter
 	clock                    clock.Clock
 	backupLogLevel           logrus.Level
 	newPluginManager         func(logrus.FieldLogger) clientmgmt.Manager
@@ -68,14 +91,19 @@ type backupController struct {
 	defaultBackupTTL         time.Duration
 	snapshotLocationLister   listers.VolumeSnapshotLocationLister
 	defaultSnapshotLocations map[string]string
+	defaultVolumesToRestic   bool
 	metrics                  *metrics.ServerMetrics
+	notifier                 notifications.Notifier
+	eventRecorder            kubeutil.EventRecorder
 	newBackupStore           func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
 	formatFlag               logging.Format
+	structuredLogs           bool
 }
 
 func NewBackupController(
 	backupInformer informers.BackupInformer,
 	client velerov1client.BackupsGetter,
+	configMapsClient corev1client.ConfigMapsGetter,
 	backupper pkgbackup.Backupper,
 	logger logrus.FieldLogger,
 	backupLogLevel logrus.Level,
@@ -86,14 +114,19 @@ func NewBackupController(
 	defaultBackupTTL time.Duration,
 	volumeSnapshotLocationInformer informers.VolumeSnapshotLocationInformer,
 	defaultSnapshotLocations map[string]string,
+	defaultVolumesToRestic bool,
 	metrics *metrics.ServerMetrics,
+	notifier notifications.Notifier,
+	eventRecorder kubeutil.EventRecorder,
 	formatFlag logging.Format,
+	structuredLogs bool,
 ) Interface {
 	c := &backupController{
 		genericController:        newGenericController("backup", logger),
 		backupper:                backupper,
 		lister:                   backupInformer.Lister(),
 		client:                   client,
+		configMapsClient:         configMapsClient,
 		clock:                    &clock.RealClock{},
 		backupLogLevel:           backupLogLevel,
 		newPluginManager:         newPluginManager,
@@ -103,10 +136,14 @@ func NewBackupController(
 		defaultBackupTTL:         defaultBackupTTL,
 		snapshotLocationLister:   volumeSnapshotLocationInformer.Lister(),
 		defaultSnapshotLocations: defaultSnapshotLocations,
+		defaultVolumesToRestic:   defaultVolumesToRestic,
 		metrics:                  metrics,
+		notifier:                 notifier,
+		eventRecorder:            eventRecorder,
 		formatFlag:               formatFlag,
+		structuredLogs:           structuredLogs,
 
-		newBackupStore: persistence.NewObjectBackupStore,
+		newBackupStore: persistence.NewBackupStore,
 	}
 
 	c.syncHandler = c.processBackup
@@ -153,7 +190,26 @@ func (c *backupController) resync() {
 		c.logger.Error(err, "Error computing backup_total metric")
 	} else {
 		c.metrics.SetBackupTotal(int64(len(backups)))
+		c.metrics.SetScheduleLastSuccessfulBackupTimestamps(lastSuccessfulScheduledBackups(backups))
+	}
+}
+
+// lastSuccessfulScheduledBackups returns the completion time of the most
+// recent Completed backup of each schedule, keyed by schedule name.
+func lastSuccessfulScheduledBackups(backups []*velerov1api.Backup) map[string]time.Time {
+	lastSuccessful := make(map[string]time.Time)
+	for _, backup := range backups {
+		schedule := backup.GetLabels()[velerov1api.ScheduleNameLabel]
+		if schedule == "" || backup.Status.Phase != velerov1api.BackupPhaseCompleted {
+			continue
+		}
+
+		completed := backup.Status.CompletionTimestamp.Time
+		if completed.After(lastSuccessful[schedule]) {
+			lastSuccessful[schedule] = completed
+		}
 	}
+	return lastSuccessful
 }
 
 func (c *backupController) processBackup(key string) error {
@@ -191,6 +247,16 @@ func (c *backupController) processBackup(key string) error {
 		return nil
 	}
 
+	// a backup that's waiting to be retried isn't processed until its next
+	// attempt is due.
+	if next := original.Status.NextAttempt; next != nil {
+		if wait := next.Time.Sub(c.clock.Now()); wait > 0 {
+			log.Debugf("Backup will be retried in %s", wait)
+			c.queue.AddAfter(key, wait)
+			return nil
+		}
+	}
+
 	log.Debug("Preparing backup request")
 	request := c.prepareBackupRequest(original)
 
@@ -199,7 +265,9 @@ func (c *backupController) processBackup(key string) error {
 	} else {
 		request.Status.Phase = velerov1api.BackupPhaseInProgress
 		request.Status.StartTimestamp.Time = c.clock.Now()
+		request.Status.NextAttempt = nil
 	}
+	setBackupPhaseConditions(request.Backup, c.clock.Now())
 
 	// update status
 	updatedBackup, err := patchBackup(original, request.Backup, c.client)
@@ -209,21 +277,32 @@ func (c *backupController) processBackup(key string) error {
 	// store ref to just-updated item for creating patch
 	original = updatedBackup
 	request.Backup = updatedBackup.DeepCopy()
+	c.recordPhaseEvent(request.Backup)
+
+	backupScheduleName := request.GetLabels()[velerov1api.ScheduleNameLabel]
 
 	if request.Status.Phase == velerov1api.BackupPhaseFailedValidation {
+		// count backups that fail validation so that a schedule whose
+		// backups never start is still visible in the failure metrics.
+		c.metrics.RegisterBackupAttempt(backupScheduleName)
+		c.metrics.RegisterBackupFailed(backupScheduleName)
+		c.notifier.Notify(notifications.BackupEvent(notifications.EventBackupFailed, request.Backup))
 		return nil
 	}
+	c.notifier.Notify(notifications.BackupEvent(notifications.EventBackupStarted, request.Backup))
 
 	c.backupTracker.Add(request.Namespace, request.Name)
 	defer c.backupTracker.Delete(request.Namespace, request.Name)
 
 	log.Debug("Running backup")
 
-	backupScheduleName := request.GetLabels()[velerov1api.ScheduleNameLabel]
 	c.metrics.RegisterBackupAttempt(backupScheduleName)
 
 	// execution & upload of backup
-	if err := c.runBackup(request); err != nil {
+	stopProgressUpdates := c.startProgressUpdates(request, log)
+	err = c.runBackup(request)
+	stopProgressUpdates()
+	if err != nil {
 		// even though runBackup sets the backup's phase prior
 		// to uploading artifacts to object storage, we have to
 		// check for an error again here and update the phase if
@@ -232,11 +311,21 @@ func (c *backupController) processBackup(key string) error {
 		// result in the backup being Failed.
 		log.WithError(err).Error("backup failed")
 		request.Status.Phase = velerov1api.BackupPhaseFailed
+		request.Status.FailureReason = err.Error()
+
+		if backoff, retry := retryBackoff(request.Backup, err); retry {
+			c.retryBackup(key, original, request, backoff, log)
+			return nil
+		}
 	}
+	setBackupPhaseConditions(request.Backup, c.clock.Now())
 
 	switch request.Status.Phase {
 	case velerov1api.BackupPhaseCompleted:
 		c.metrics.RegisterBackupSuccess(backupScheduleName)
+		if backupScheduleName != "" {
+			c.metrics.SetScheduleLastSuccessfulBackupTimestamp(backupScheduleName, request.Status.CompletionTimestamp.Time)
+		}
 	case velerov1api.BackupPhasePartiallyFailed:
 		c.metrics.RegisterBackupPartialFailure(backupScheduleName)
 	case velerov1api.BackupPhaseFailed:
@@ -247,6 +336,250 @@ func (c *backupController) processBackup(key string) error {
 	if _, err := patchBackup(original, request.Backup, c.client); err != nil {
 		log.WithError(err).Error("error updating backup's final status")
 	}
+	c.recordPhaseEvent(request.Backup)
+
+	switch request.Status.Phase {
+	case velerov1api.BackupPhaseCompleted:
+		c.notifier.Notify(notifications.BackupEvent(notifications.EventBackupCompleted, request.Backup))
+	case velerov1api.BackupPhasePartiallyFailed:
+		c.notifier.Notify(notifications.BackupEvent(notifications.EventBackupPartiallyFailed, request.Backup))
+	case velerov1api.BackupPhaseFailed:
+		c.notifier.Notify(notifications.BackupEvent(notifications.EventBackupFailed, request.Backup))
+	}
+
+	return nil
+}
+
+// retryBackoff returns how long to wait before retrying a backup whose
+// attempt failed with err, and false if it shouldn't be retried because it
+// has no retry policy, it's run out of attempts or err isn't transient.
+func retryBackoff(backup *velerov1api.Backup, err error) (time.Duration, bool) {
+	policy := backup.Spec.RetryPolicy
+	if policy == nil || !isTransientBackupError(err) {
+		return 0, false
+	}
+
+	// the backup's status only records its earlier attempts, not the one
+	// that just failed.
+	retries := len(backup.Status.Attempts)
+	if retries+1 >= policy.MaxAttempts {
+		return 0, false
+	}
+
+	backoff := policy.Backoff.Duration
+	if backoff == 0 {
+		backoff = defaultBackupRetryBackoff
+	}
+	for i := 0; i < retries && backoff < maxBackupRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackupRetryBackoff {
+		backoff = maxBackupRetryBackoff
+	}
+
+	return backoff, true
+}
+
+// isTransientBackupError returns true if err, and every error it
+// aggregates, means that the backup store or the Kubernetes API server was
+// temporarily unavailable, so the backup may succeed if it's retried.
+func isTransientBackupError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// check the error's kind before unwrapping it, since the kinds of
+	// backup store errors are recorded by the wrappers.
+	if persistence.Is(err, persistence.ErrStoreUnavailable) {
+		return true
+	}
+
+	cause := errors.Cause(err)
+	if agg, ok := cause.(kerrors.Aggregate); ok {
+		for _, err := range agg.Errors() {
+			if !isTransientBackupError(err) {
+				return false
+			}
+		}
+		return len(agg.Errors()) > 0
+	}
+
+	switch {
+	case apierrors.IsServerTimeout(cause),
+		apierrors.IsTimeout(cause),
+		apierrors.IsTooManyRequests(cause),
+		apierrors.IsServiceUnavailable(cause),
+		apierrors.IsInternalError(cause):
+		return true
+	}
+
+	_, ok := cause.(net.Error)
+	return ok
+}
+
+// retryBackup records a backup's failed attempt in its status and resets
+// it to New, so that it's attempted again once backoff has passed.
+func (c *backupController) retryBackup(key string, original *velerov1api.Backup, request *pkgbackup.Request, backoff time.Duration, log logrus.FieldLogger) {
+	backup := request.Backup
+	c.deleteAttemptSnapshots(request, log)
+
+	now := c.clock.Now()
+	next := metav1.NewTime(now.Add(backoff))
+
+	updated := backup.DeepCopy()
+	updated.Status = velerov1api.BackupStatus{
+		Phase:      velerov1api.BackupPhaseNew,
+		Expiration: backup.Status.Expiration,
+		Attempts: append(backup.Status.Attempts, velerov1api.BackupAttempt{
+			StartTimestamp:      backup.Status.StartTimestamp,
+			CompletionTimestamp: metav1.NewTime(now),
+			FailureReason:       backup.Status.FailureReason,
+		}),
+		NextAttempt: &next,
+	}
+
+	log.WithField("nextAttempt", next.Time).Infof("Backup failed with a transient error, retrying in %s", backoff)
+	if _, err := patchBackup(original, updated, c.client); err != nil {
+		log.WithError(err).Error("error updating backup's status for its retry")
+		return
+	}
+	c.eventRecorder.Event(updated, corev1api.EventTypeWarning, "BackupRetrying", fmt.Sprintf("Backup attempt %d failed, retrying in %s: %s", len(updated.Status.Attempts), backoff, backup.Status.FailureReason))
+
+	c.queue.AddAfter(key, backoff)
+}
+
+// deleteAttemptSnapshots deletes the volume snapshots taken by a backup's
+// failed attempt, since they aren't recorded anywhere once it's retried.
+// Snapshots taken through the CSI VolumeSnapshot API aren't deleted.
+func (c *backupController) deleteAttemptSnapshots(request *pkgbackup.Request, log logrus.FieldLogger) {
+	var snapshots []*volume.Snapshot
+	for _, snapshot := range request.VolumeSnapshots {
+		if snapshot.Status.ProviderSnapshotID != "" && csi.SnapshotFor(snapshot) == nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	if len(snapshots) == 0 {
+		return
+	}
+
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	volumeSnapshotters := make(map[string]velero.VolumeSnapshotter)
+	for _, snapshot := range snapshots {
+		log := log.WithField("providerSnapshotID", snapshot.Status.ProviderSnapshotID)
+		log.Info("Removing snapshot taken by backup's failed attempt")
+
+		volumeSnapshotter, ok := volumeSnapshotters[snapshot.Spec.Location]
+		if !ok {
+			var err error
+			if volumeSnapshotter, err = volumeSnapshotterForSnapshotLocation(request.Namespace, snapshot.Spec.Location, c.snapshotLocationLister, pluginManager); err != nil {
+				log.WithError(err).Warn("Error removing snapshot taken by backup's failed attempt")
+				continue
+			}
+			volumeSnapshotters[snapshot.Spec.Location] = volumeSnapshotter
+		}
+
+		if err := volumeSnapshotter.DeleteSnapshot(snapshot.Status.ProviderSnapshotID); err != nil {
+			log.WithError(err).Warn("Error removing snapshot taken by backup's failed attempt")
+		}
+	}
+}
+
+// setBackupPhaseConditions sets the backup's Completed and Failed
+// conditions from its phase.
+func setBackupPhaseConditions(backup *velerov1api.Backup, now time.Time) {
+	phase := string(backup.Status.Phase)
+
+	switch backup.Status.Phase {
+	case velerov1api.BackupPhaseInProgress:
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionCompleted, corev1api.ConditionFalse, phase, "", now)
+	case velerov1api.BackupPhaseCompleted, velerov1api.BackupPhasePartiallyFailed:
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionCompleted, corev1api.ConditionTrue, phase, "", now)
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionFailed, corev1api.ConditionFalse, phase, "", now)
+	case velerov1api.BackupPhaseFailed:
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionCompleted, corev1api.ConditionTrue, phase, "", now)
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionFailed, corev1api.ConditionTrue, phase, backup.Status.FailureReason, now)
+	case velerov1api.BackupPhaseFailedValidation:
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionCompleted, corev1api.ConditionTrue, phase, "", now)
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionFailed, corev1api.ConditionTrue, phase, strings.Join(backup.Status.ValidationErrors, "; "), now)
+	}
+}
+
+// recordPhaseEvent records a Kubernetes event about the backup's phase.
+func (c *backupController) recordPhaseEvent(backup *velerov1api.Backup) {
+	switch backup.Status.Phase {
+	case velerov1api.BackupPhaseInProgress:
+		c.eventRecorder.Event(backup, corev1api.EventTypeNormal, "BackupStarted", "Backup started")
+	case velerov1api.BackupPhaseCompleted:
+		c.eventRecorder.Event(backup, corev1api.EventTypeNormal, "BackupCompleted", "Backup completed")
+	case velerov1api.BackupPhasePartiallyFailed:
+		c.eventRecorder.Event(backup, corev1api.EventTypeWarning, "BackupPartiallyFailed", fmt.Sprintf("Backup partially failed with %d errors", backup.Status.Errors))
+	case velerov1api.BackupPhaseFailed:
+		c.eventRecorder.Event(backup, corev1api.EventTypeWarning, "BackupFailed", "Backup failed: "+backup.Status.FailureReason)
+	case velerov1api.BackupPhaseFailedValidation:
+		c.eventRecorder.Event(backup, corev1api.EventTypeWarning, "BackupFailedValidation", "Backup failed validation: "+strings.Join(backup.Status.ValidationErrors, "; "))
+	}
+}
+
+// startProgressUpdates records the progress of a running backup in its
+// status and in the server's metrics every backupProgressUpdateInterval,
+// until the returned function is called.
+func (c *backupController) startProgressUpdates(request *pkgbackup.Request, log logrus.FieldLogger) func() {
+	namespace, name := request.Namespace, request.Name
+	backupScheduleName := request.GetLabels()[velerov1api.ScheduleNameLabel]
+
+	ticker := c.clock.NewTicker(backupProgressUpdateInterval)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+
+		var last velerov1api.BackupProgress
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C():
+				progress := request.Progress()
+				if progress == last {
+					continue
+				}
+
+				if err := patchBackupProgress(namespace, name, progress, c.client); err != nil {
+					log.WithError(err).Warn("Error updating backup's progress")
+					continue
+				}
+				c.metrics.SetBackupItemsProgress(backupScheduleName, progress.TotalItems, progress.ItemsBackedUp)
+				last = progress
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// patchBackupProgress records a running backup's progress in its status.
+func patchBackupProgress(namespace, name string, progress velerov1api.BackupProgress, client velerov1client.BackupsGetter) error {
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"progress": progress,
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling backup progress patch")
+	}
+
+	if _, err := client.Backups(namespace).Patch(name, types.MergePatchType, patchBytes); err != nil {
+		return errors.Wrap(err, "error patching backup")
+	}
 
 	return nil
 }
@@ -288,6 +621,12 @@ func (c *backupController) prepareBackupRequest(backup *velerov1api.Backup) *pkg
 		request.Spec.TTL.Duration = c.defaultBackupTTL
 	}
 
+	// default whether all pod volumes are backed up with restic if not specified
+	if request.Spec.DefaultVolumesToRestic == nil {
+		defaultVolumesToRestic := c.defaultVolumesToRestic
+		request.Spec.DefaultVolumesToRestic = &defaultVolumesToRestic
+	}
+
 	// calculate expiration
 	request.Status.Expiration = metav1.NewTime(c.clock.Now().Add(request.Spec.TTL.Duration))
 
@@ -302,15 +641,7 @@ func (c *backupController) prepareBackupRequest(backup *velerov1api.Backup) *pkg
 	}
 	request.Labels[velerov1api.StorageLocationLabel] = label.GetValidName(request.Spec.StorageLocation)
 
-	// validate the included/excluded resources
-	for _, err := range collections.ValidateIncludesExcludes(request.Spec.IncludedResources, request.Spec.ExcludedResources) {
-		request.Status.ValidationErrors = append(request.Status.ValidationErrors, fmt.Sprintf("Invalid included/excluded resource lists: %v", err))
-	}
-
-	// validate the included/excluded namespaces
-	for _, err := range collections.ValidateIncludesExcludes(request.Spec.IncludedNamespaces, request.Spec.ExcludedNamespaces) {
-		request.Status.ValidationErrors = append(request.Status.ValidationErrors, fmt.Sprintf("Invalid included/excluded namespace lists: %v", err))
-	}
+	request.Status.ValidationErrors = append(request.Status.ValidationErrors, validation.ValidateBackupSpec(&request.Spec)...)
 
 	// validate the storage location, and store the BackupStorageLocation API obj on the request
 	if storageLocation, err := c.backupLocationLister.BackupStorageLocations(request.Namespace).Get(request.Spec.StorageLocation); err != nil {
@@ -326,8 +657,26 @@ func (c *backupController) prepareBackupRequest(backup *velerov1api.Backup) *pkg
 			request.Status.ValidationErrors = append(request.Status.ValidationErrors,
 				fmt.Sprintf("backup can't be created because backup storage location %s is currently in read-only mode", request.StorageLocation.Name))
 		}
+
+		if request.StorageLocation.Status.Phase == velerov1api.BackupStorageLocationPhaseUnavailable {
+			request.Status.ValidationErrors = append(request.Status.ValidationErrors,
+				fmt.Sprintf("backup can't be created because backup storage location %s is currently unavailable: %s", request.StorageLocation.Name, request.StorageLocation.Status.Message))
+		}
+	}
+
+	// get the backup's resource policies, if it has any
+	if request.Spec.ResourcePolicy != "" {
+		if policies, err := resourcepolicies.Get(c.configMapsClient.ConfigMaps(request.Namespace), request.Spec.ResourcePolicy); err != nil {
+			request.Status.ValidationErrors = append(request.Status.ValidationErrors, fmt.Sprintf("invalid resource policies: %v", err))
+		} else {
+			request.ResourcePolicies = policies
+		}
 	}
 
+	// validate the replica and failover storage locations
+	request.Status.ValidationErrors = append(request.Status.ValidationErrors, validation.ValidateReplicaStorageLocations(request.Namespace, &request.Spec, c.backupLocationLister)...)
+	request.Status.ValidationErrors = append(request.Status.ValidationErrors, validation.ValidateFailoverStorageLocations(request.Namespace, &request.Spec, c.backupLocationLister)...)
+
 	// validate and get the backup's VolumeSnapshotLocations, and store the
 	// VolumeSnapshotLocation API objs on the request
 	if locs, errs := c.validateAndGetSnapshotLocations(request.Backup); len(errs) > 0 {
@@ -451,12 +800,29 @@ func (c *backupController) runBackup(backup *pkgbackup.Request) error {
 
 	// Log the backup to both a backup log file and to stdout. This will help see what happened if the upload of the
 	// backup log failed for whatever reason.
-	logger := logging.DefaultLogger(c.backupLogLevel, c.formatFlag)
+	logger := logging.DefaultLogger(logging.LevelOrDefault(backup.Spec.LogLevel, c.backupLogLevel), c.formatFlag)
 	logger.Out = io.MultiWriter(os.Stdout, gzippedLogFile)
 
 	logCounter := logging.NewLogCounterHook()
 	logger.Hooks.Add(logCounter)
 
+	// When enabled, also write the backup log as JSON lines to a separate file
+	// so it can be indexed by log pipelines without parsing the text log.
+	var structuredLog io.Reader
+	var gzippedStructuredLogFile *gzip.Writer
+	if c.structuredLogs {
+		structuredLogFile, err := ioutil.TempFile("", "")
+		if err != nil {
+			return errors.Wrap(err, "error creating temp file for structured backup log")
+		}
+		gzippedStructuredLogFile = gzip.NewWriter(structuredLogFile)
+		defer gzippedStructuredLogFile.Close()
+		defer closeAndRemoveFile(structuredLogFile, c.logger)
+
+		logger.Hooks.Add(logging.NewJSONLinesHook(gzippedStructuredLogFile))
+		structuredLog = structuredLogFile
+	}
+
 	backupLog := logger.WithField("backup", kubeutil.NamespaceAndName(backup))
 
 	backupLog.Info("Setting up backup temp file")
@@ -482,7 +848,29 @@ func (c *backupController) runBackup(backup *pkgbackup.Request) error {
 		return err
 	}
 
-	exists, err := backupStore.BackupExists(backup.StorageLocation.Spec.StorageType.ObjectStorage.Bucket, backup.Name)
+	var bucket string
+	if backup.StorageLocation.Spec.StorageType.ObjectStorage != nil {
+		bucket = backup.StorageLocation.Spec.StorageType.ObjectStorage.Bucket
+	}
+
+	exists, err := backupStore.BackupExists(bucket, backup.Name)
+	if err != nil && len(backup.Spec.FailoverStorageLocations) > 0 {
+		// the location may be unreachable, in which case the backup is
+		// stored in one of its failover locations instead.
+		backupLog.WithError(err).Warnf("Error checking if backup already exists in backup storage location %s", backup.StorageLocation.Name)
+		exists, err = false, nil
+	}
+	if exists && err == nil && len(backup.Status.Attempts) > 0 {
+		// an earlier attempt of this backup failed after uploading its
+		// files, so they're replaced by this attempt's.
+		backupLog.Info("Deleting the files of backup's failed attempt from object storage")
+		if err := backupStore.DeleteBackup(backup.Name); err != nil {
+			backup.Status.Phase = velerov1api.BackupPhaseFailed
+			backup.Status.CompletionTimestamp.Time = c.clock.Now()
+			return errors.Wrap(err, "error deleting the files of backup's failed attempt from object storage")
+		}
+		exists = false
+	}
 	if exists || err != nil {
 		backup.Status.Phase = velerov1api.BackupPhaseFailed
 		backup.Status.CompletionTimestamp.Time = c.clock.Now()
@@ -492,6 +880,10 @@ func (c *backupController) runBackup(backup *pkgbackup.Request) error {
 		return errors.Errorf("backup already exists in object storage")
 	}
 
+	if backup.SkipUnchangedItems() {
+		backup.PreviousItemIndex = c.previousItemIndex(backup, backupStore, backupLog)
+	}
+
 	var fatalErrs []error
 	if err := c.backupper.Backup(backupLog, backup, backupFile, actions, pluginManager); err != nil {
 		fatalErrs = append(fatalErrs, err)
@@ -508,14 +900,31 @@ func (c *backupController) runBackup(backup *pkgbackup.Request) error {
 		}
 	}
 
+	for _, pvb := range backup.PodVolumeBackups {
+		if pvb.Status.Phase == velerov1api.PodVolumeBackupPhaseFailed {
+			backup.Status.FailedPodVolumes = append(backup.Status.FailedPodVolumes, fmt.Sprintf("%s/%s/%s", pvb.Spec.Pod.Namespace, pvb.Spec.Pod.Name, pvb.Spec.Volume))
+		}
+	}
+	if len(backup.Status.FailedPodVolumes) > 0 && backup.Spec.PodVolumeFailurePolicy == velerov1api.PodVolumeFailurePolicyFail {
+		fatalErrs = append(fatalErrs, errors.Errorf("pod volumes %s couldn't be backed up, and the backup's pod volume failure policy is %s", strings.Join(backup.Status.FailedPodVolumes, ", "), velerov1api.PodVolumeFailurePolicyFail))
+	}
+
+	backup.Status.ReferencedBackups = backup.ItemIndex.ReferencedBackups(backup.Name)
+
 	recordBackupMetrics(backupLog, backup.Backup, backupFile, c.metrics)
 
 	if err := gzippedLogFile.Close(); err != nil {
 		c.logger.WithError(err).Error("error closing gzippedLogFile")
 	}
+	if gzippedStructuredLogFile != nil {
+		if err := gzippedStructuredLogFile.Close(); err != nil {
+			c.logger.WithError(err).Error("error closing gzippedStructuredLogFile")
+		}
+	}
 
 	backup.Status.Warnings = logCounter.GetCount(logrus.WarnLevel)
 	backup.Status.Errors = logCounter.GetCount(logrus.ErrorLevel)
+	backup.Status.PluginCrashes = pluginManager.PluginCrashes()
 
 	// Assign finalize phase as close to end as possible so that any errors
 	// logged to backupLog are captured. This is done before uploading the
@@ -530,8 +939,26 @@ func (c *backupController) runBackup(backup *pkgbackup.Request) error {
 		backup.Status.Phase = velerov1api.BackupPhaseCompleted
 	}
 
-	if errs := persistBackup(backup, backupFile, logFile, backupStore, c.logger); len(errs) > 0 {
-		fatalErrs = append(fatalErrs, errs...)
+	errs := persistBackup(backup, backupFile, logFile, structuredLog, backupStore, c.logger)
+	if len(errs) > 0 && len(backup.Spec.FailoverStorageLocations) > 0 {
+		errs = c.failOverBackup(backup, errs, pluginManager, func(backupStore persistence.BackupStore) []error {
+			return persistBackup(backup, backupFile, logFile, structuredLog, backupStore, c.logger)
+		})
+	}
+	fatalErrs = append(fatalErrs, errs...)
+
+	if len(errs) == 0 && backup.Status.StorageUsage != nil {
+		c.metrics.SetBackupTotalSizeBytesGauge(backup.GetLabels()[velerov1api.ScheduleNameLabel], backup.Status.StorageUsage.TotalBytes)
+	}
+
+	if len(errs) == 0 {
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionStoreUploaded, corev1api.ConditionTrue, "Uploaded", "", c.clock.Now())
+	} else {
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionStoreUploaded, corev1api.ConditionFalse, "UploadFailed", kerrors.NewAggregate(errs).Error(), c.clock.Now())
+	}
+
+	if len(errs) == 0 && backup.Spec.DeleteProtection {
+		c.lockBackup(backup, pluginManager)
 	}
 
 	c.logger.Info("Backup completed")
@@ -541,6 +968,165 @@ func (c *backupController) runBackup(backup *pkgbackup.Request) error {
 	return kerrors.NewAggregate(fatalErrs)
 }
 
+// lockBackup locks a delete-protected backup's files in object storage
+// until the backup expires, so that they can't be deleted even by someone
+// with access to the bucket. Backups whose storage location's object store
+// doesn't support object lock are only protected by Velero.
+func (c *backupController) lockBackup(backup *pkgbackup.Request, pluginManager clientmgmt.Manager) {
+	log := c.logger.WithField("backup", kubeutil.NamespaceAndName(backup))
+
+	until := backup.Status.Expiration.Time
+	if !until.After(c.clock.Now()) {
+		log.Info("Not locking delete-protected backup's files because it has already expired")
+		return
+	}
+
+	backupStore, err := c.newBackupStore(backup.StorageLocation, pluginManager, log)
+	if err != nil {
+		log.WithError(err).Warn("Error getting backup store to lock delete-protected backup's files")
+		return
+	}
+
+	if err := backupStore.LockBackup(backup.Name, until); err != nil {
+		log.WithError(err).Warn("Error locking delete-protected backup's files in object storage")
+		return
+	}
+
+	backup.Status.ObjectLockedUntil = &metav1.Time{Time: until}
+}
+
+// failOverBackup stores a backup that couldn't be stored in its storage
+// location in the first of its failover storage locations that it can be
+// stored in, using persist. The backup's storage location is changed to the
+// failover location, so that it's found there, and the failover is recorded
+// in its status. It returns errs, along with the errors from each failover
+// location, if the backup couldn't be stored in any of them.
+func (c *backupController) failOverBackup(backup *pkgbackup.Request, errs []error, pluginManager clientmgmt.Manager, persist func(persistence.BackupStore) []error) []error {
+	log := c.logger.WithField("backup", kubeutil.NamespaceAndName(backup))
+
+	// restic backups and unchanged items are stored with the backup's
+	// storage location, so the backup can't be restored from any other.
+	if len(backup.PodVolumeBackups) > 0 {
+		log.Warn("Not failing over backup because its restic backups are stored with its storage location")
+		return errs
+	}
+	if len(backup.Status.ReferencedBackups) > 0 {
+		log.Warn("Not failing over backup because the backups it refers to are stored in its storage location")
+		return errs
+	}
+
+	originalLocation := backup.StorageLocation
+	for _, locationName := range backup.Spec.FailoverStorageLocations {
+		log := log.WithField("failoverStorageLocation", locationName)
+
+		location, err := c.backupLocationLister.BackupStorageLocations(backup.Namespace).Get(locationName)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "error getting failover storage location %s", locationName))
+			continue
+		}
+		if location.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+			errs = append(errs, errors.Errorf("failover storage location %s is currently in read-only mode", locationName))
+			continue
+		}
+
+		backupStore, err := c.newBackupStore(location, pluginManager, log)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "error getting backup store for failover storage location %s", locationName))
+			continue
+		}
+
+		var bucket string
+		if location.Spec.StorageType.ObjectStorage != nil {
+			bucket = location.Spec.StorageType.ObjectStorage.Bucket
+		}
+		if exists, err := backupStore.BackupExists(bucket, backup.Name); err != nil {
+			errs = append(errs, errors.Wrapf(err, "error checking if backup already exists in failover storage location %s", locationName))
+			continue
+		} else if exists {
+			errs = append(errs, errors.Errorf("backup already exists in failover storage location %s", locationName))
+			continue
+		}
+
+		log.Info("Storing backup in failover storage location")
+		setBackupStorageLocation(backup, location)
+		backup.Status.StorageLocationFailover = &velerov1api.StorageLocationFailover{
+			From: originalLocation.Name,
+			To:   location.Name,
+		}
+
+		if persistErrs := persist(backupStore); len(persistErrs) > 0 {
+			errs = append(errs, persistErrs...)
+			continue
+		}
+
+		return nil
+	}
+
+	setBackupStorageLocation(backup, originalLocation)
+	backup.Status.StorageLocationFailover = nil
+
+	return errs
+}
+
+// setBackupStorageLocation sets the storage location of a backup, along
+// with the label that identifies it.
+func setBackupStorageLocation(backup *pkgbackup.Request, location *velerov1api.BackupStorageLocation) {
+	backup.StorageLocation = location
+	backup.Spec.StorageLocation = location.Name
+	backup.Labels[velerov1api.StorageLocationLabel] = label.GetValidName(location.Name)
+}
+
+// previousItemIndex returns the item index of the most recent completed
+// backup from the same schedule and storage location as the given backup,
+// which the backup's items are compared against to skip unchanged items.
+// It returns nil if there's no such backup, in which case every item is
+// written to the backup tarball.
+func (c *backupController) previousItemIndex(backup *pkgbackup.Request, backupStore persistence.BackupStore, log logrus.FieldLogger) persistence.BackupItemIndex {
+	schedule := backup.GetLabels()[velerov1api.ScheduleNameLabel]
+	if schedule == "" {
+		log.Info("Not skipping unchanged items because the backup wasn't created by a schedule")
+		return nil
+	}
+
+	backups, err := c.lister.Backups(backup.Namespace).List(labels.SelectorFromSet(labels.Set{
+		velerov1api.ScheduleNameLabel: schedule,
+	}))
+	if err != nil {
+		log.WithError(errors.WithStack(err)).Warn("Error listing backups to find the previous backup of the schedule")
+		return nil
+	}
+
+	var previous *velerov1api.Backup
+	for _, candidate := range backups {
+		if candidate.Name == backup.Name ||
+			candidate.Status.Phase != velerov1api.BackupPhaseCompleted ||
+			candidate.Spec.StorageLocation != backup.Spec.StorageLocation ||
+			!candidate.Spec.SkipUnchangedItems {
+			continue
+		}
+		if previous == nil || candidate.Status.StartTimestamp.After(previous.Status.StartTimestamp.Time) {
+			previous = candidate
+		}
+	}
+	if previous == nil {
+		log.Info("Not skipping unchanged items because there's no previous completed backup of the schedule")
+		return nil
+	}
+
+	index, err := backupStore.GetBackupItemIndex(previous.Name)
+	if err != nil {
+		log.WithError(err).Warnf("Error getting the item index of previous backup %s, writing every item to the backup tarball", previous.Name)
+		return nil
+	}
+	if index == nil {
+		log.Infof("Not skipping unchanged items because previous backup %s doesn't have an item index", previous.Name)
+		return nil
+	}
+
+	log.Infof("Skipping items that haven't changed since backup %s", previous.Name)
+	return index
+}
+
 func recordBackupMetrics(log logrus.FieldLogger, backup *velerov1api.Backup, backupFile *os.File, serverMetrics *metrics.ServerMetrics) {
 	backupScheduleName := backup.GetLabels()[velerov1api.ScheduleNameLabel]
 
@@ -558,15 +1144,13 @@ func recordBackupMetrics(log logrus.FieldLogger, backup *velerov1api.Backup, bac
 	serverMetrics.RegisterVolumeSnapshotAttempts(backupScheduleName, backup.Status.VolumeSnapshotsAttempted)
 	serverMetrics.RegisterVolumeSnapshotSuccesses(backupScheduleName, backup.Status.VolumeSnapshotsCompleted)
 	serverMetrics.RegisterVolumeSnapshotFailures(backupScheduleName, backup.Status.VolumeSnapshotsAttempted-backup.Status.VolumeSnapshotsCompleted)
+	if backup.Status.Progress != nil {
+		serverMetrics.SetBackupItemsProgress(backupScheduleName, backup.Status.Progress.TotalItems, backup.Status.Progress.ItemsBackedUp)
+	}
 }
 
-func persistBackup(backup *pkgbackup.Request, backupContents, backupLog *os.File, backupStore persistence.BackupStore, log logrus.FieldLogger) []error {
+func persistBackup(backup *pkgbackup.Request, backupContents, backupLog *os.File, structuredLog io.Reader, backupStore persistence.BackupStore, log logrus.FieldLogger) []error {
 	errs := []error{}
-	backupJSON := new(bytes.Buffer)
-
-	if err := encode.EncodeTo(backup.Backup, "json", backupJSON); err != nil {
-		errs = append(errs, errors.Wrap(err, "error encoding backup"))
-	}
 
 	volumeSnapshots := new(bytes.Buffer)
 	gzw := gzip.NewWriter(volumeSnapshots)
@@ -598,22 +1182,62 @@ func persistBackup(backup *pkgbackup.Request, backupContents, backupLog *os.File
 		errs = append(errs, errors.Wrap(err, "error closing gzip writer"))
 	}
 
+	volumeCoverage := new(bytes.Buffer)
+	gzw = gzip.NewWriter(volumeCoverage)
+
+	if err := json.NewEncoder(gzw).Encode(backup.VolumeCoverage); err != nil {
+		errs = append(errs, errors.Wrap(err, "error encoding volume coverage report"))
+	}
+	if err := gzw.Close(); err != nil {
+		errs = append(errs, errors.Wrap(err, "error closing gzip writer"))
+	}
+
+	// only backups that skip unchanged items have an item index.
+	var itemIndex io.Reader
+	if backup.ItemIndex != nil {
+		itemIndexBuf := new(bytes.Buffer)
+		gzw = gzip.NewWriter(itemIndexBuf)
+
+		if err := json.NewEncoder(gzw).Encode(backup.ItemIndex); err != nil {
+			errs = append(errs, errors.Wrap(err, "error encoding item index"))
+		}
+		if err := gzw.Close(); err != nil {
+			errs = append(errs, errors.Wrap(err, "error closing gzip writer"))
+		}
+		itemIndex = itemIndexBuf
+	}
+
+	// the backup's storage usage is recorded in its metadata file, so it's
+	// encoded once the sizes of its other files are known.
+	backup.Status.StorageUsage = backupStorageUsage(backup.PodVolumeBackups, backupContents, backupLog, structuredLog, volumeSnapshots, podVolumeBackups, backupResourceList, volumeCoverage, itemIndex)
+
+	backupJSON := new(bytes.Buffer)
+	if err := encode.EncodeTo(backup.Backup, "json", backupJSON); err != nil {
+		errs = append(errs, errors.Wrap(err, "error encoding backup"))
+	}
+
 	if len(errs) > 0 {
 		// Don't upload the JSON files or backup tarball if encoding to json fails.
 		backupJSON = nil
 		backupContents = nil
 		volumeSnapshots = nil
 		backupResourceList = nil
+		volumeCoverage = nil
+		itemIndex = nil
 	}
 
 	backupInfo := persistence.BackupInfo{
 		Name:               backup.Name,
+		Backup:             backup.Backup,
 		Metadata:           backupJSON,
 		Contents:           backupContents,
 		Log:                backupLog,
+		StructuredLog:      structuredLog,
 		PodVolumeBackups:   podVolumeBackups,
 		VolumeSnapshots:    volumeSnapshots,
 		BackupResourceList: backupResourceList,
+		VolumeCoverage:     volumeCoverage,
+		ItemIndex:          itemIndex,
 	}
 	if err := backupStore.PutBackup(backupInfo); err != nil {
 		errs = append(errs, err)
@@ -622,6 +1246,57 @@ func persistBackup(backup *pkgbackup.Request, backupContents, backupLog *os.File
 	return errs
 }
 
+// backupStorageUsage returns the sizes of a backup's tarball, of all of
+// the files it uploads, and of the data its completed pod volume backups
+// added to their restic repositories.
+func backupStorageUsage(podVolumeBackups []*velerov1api.PodVolumeBackup, backupContents *os.File, files ...io.Reader) *velerov1api.BackupStorageUsage {
+	usage := &velerov1api.BackupStorageUsage{
+		TarballBytes: uploadSize(backupContents),
+	}
+	usage.TotalBytes = usage.TarballBytes
+
+	for _, file := range files {
+		usage.TotalBytes += uploadSize(file)
+	}
+
+	for _, pvb := range podVolumeBackups {
+		if pvb.Status.Phase != velerov1api.PodVolumeBackupPhaseCompleted {
+			continue
+		}
+		usage.PodVolumes = append(usage.PodVolumes, velerov1api.PodVolumeStorageUsage{
+			Name:  fmt.Sprintf("%s/%s/%s", pvb.Spec.Pod.Namespace, pvb.Spec.Pod.Name, pvb.Spec.Volume),
+			Bytes: pvb.Status.Changes.BytesAdded,
+		})
+		usage.TotalBytes += pvb.Status.Changes.BytesAdded
+	}
+
+	return usage
+}
+
+// uploadSize returns the number of bytes that will be uploaded from file,
+// which is a file or a buffer. It's 0 if file is nil or its size can't be
+// found.
+func uploadSize(file io.Reader) int64 {
+	switch f := file.(type) {
+	case *os.File:
+		if f == nil {
+			return 0
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return 0
+		}
+		return info.Size()
+	case *bytes.Buffer:
+		if f == nil {
+			return 0
+		}
+		return int64(f.Len())
+	default:
+		return 0
+	}
+}
+
 func closeAndRemoveFile(file *os.File, log logrus.FieldLogger) {
 	if err := file.Close(); err != nil {
 		log.WithError(err).WithField("file", file.Name()).Error("error closing file")
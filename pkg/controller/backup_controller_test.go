@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"sort"
 	"strings"
 	"testing"
@@ -30,8 +32,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/clock"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
 	pkgbackup "github.com/heptio/velero/pkg/backup"
@@ -39,12 +47,16 @@ import (
 	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
 	"github.com/heptio/velero/pkg/metrics"
+	"github.com/heptio/velero/pkg/notifications"
 	"github.com/heptio/velero/pkg/persistence"
 	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
 	"github.com/heptio/velero/pkg/plugin/clientmgmt"
 	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
 	"github.com/heptio/velero/pkg/plugin/velero"
+	velerotest "github.com/heptio/velero/pkg/test"
+	"github.com/heptio/velero/pkg/util/boolptr"
 	"github.com/heptio/velero/pkg/util/logging"
+	"github.com/heptio/velero/pkg/volume"
 )
 
 type fakeBackupper struct {
@@ -140,6 +152,36 @@ func TestProcessBackupValidationFailures(t *testing.T) {
 			backupLocation: defaultBackupLocation,
 			expectedErrs:   []string{"Invalid included/excluded resource lists: excludes list cannot contain an item in the includes list: foo"},
 		},
+		{
+			name:           "invalid backup mode fails validation",
+			backup:         defaultBackup().Mode("DataOnly").Result(),
+			backupLocation: defaultBackupLocation,
+			expectedErrs:   []string{"Invalid backup mode \"DataOnly\", must be one of Full, ObjectsOnly or VolumeSnapshotOnly"},
+		},
+		{
+			name:           "objects-only backup with volume snapshots enabled fails validation",
+			backup:         defaultBackup().Mode(velerov1api.BackupModeObjectsOnly).SnapshotVolumes(true).Result(),
+			backupLocation: defaultBackupLocation,
+			expectedErrs:   []string{"snapshotVolumes can't be true for a backup in ObjectsOnly mode"},
+		},
+		{
+			name:           "volume-snapshot-only backup with volume snapshots disabled fails validation",
+			backup:         defaultBackup().Mode(velerov1api.BackupModeVolumeSnapshotOnly).SnapshotVolumes(false).Result(),
+			backupLocation: defaultBackupLocation,
+			expectedErrs:   []string{"snapshotVolumes can't be false for a backup in VolumeSnapshotOnly mode"},
+		},
+		{
+			name:           "volume-snapshot-only backup with included resources fails validation",
+			backup:         defaultBackup().Mode(velerov1api.BackupModeVolumeSnapshotOnly).IncludedResources("pods").Result(),
+			backupLocation: defaultBackupLocation,
+			expectedErrs:   []string{"includedResources and excludedResources can't be set for a backup in VolumeSnapshotOnly mode"},
+		},
+		{
+			name:           "volume-snapshot-only backup that skips unchanged items fails validation",
+			backup:         defaultBackup().Mode(velerov1api.BackupModeVolumeSnapshotOnly).SkipUnchangedItems(true).Result(),
+			backupLocation: defaultBackupLocation,
+			expectedErrs:   []string{"skipUnchangedItems can't be set for a backup in VolumeSnapshotOnly mode"},
+		},
 		{
 			name:           "invalid included/excluded namespaces fails validation",
 			backup:         defaultBackup().IncludedNamespaces("foo").ExcludedNamespaces("foo").Result(),
@@ -157,6 +199,35 @@ func TestProcessBackupValidationFailures(t *testing.T) {
 			backupLocation: builder.ForBackupStorageLocation("velero", "read-only").AccessMode(velerov1api.BackupStorageLocationAccessModeReadOnly).Result(),
 			expectedErrs:   []string{"backup can't be created because backup storage location read-only is currently in read-only mode"},
 		},
+		{
+			name:   "backup for unavailable backup location fails validation",
+			backup: defaultBackup().StorageLocation("unavailable").Result(),
+			backupLocation: func() *velerov1api.BackupStorageLocation {
+				location := builder.ForBackupStorageLocation("velero", "unavailable").Result()
+				location.Status.Phase = velerov1api.BackupStorageLocationPhaseUnavailable
+				location.Status.Message = "bucket not found"
+				return location
+			}(),
+			expectedErrs: []string{"backup can't be created because backup storage location unavailable is currently unavailable: bucket not found"},
+		},
+		{
+			name:           "replica storage location that is the backup's own location fails validation",
+			backup:         defaultBackup().StorageLocation("loc-1").ReplicaStorageLocations("loc-1").Result(),
+			backupLocation: defaultBackupLocation,
+			expectedErrs:   []string{"replica storage location loc-1 must be different from the backup's storage location"},
+		},
+		{
+			name:           "non-existent replica storage location fails validation",
+			backup:         defaultBackup().StorageLocation("loc-1").ReplicaStorageLocations("nonexistent").Result(),
+			backupLocation: defaultBackupLocation,
+			expectedErrs:   []string{"error getting replica storage location nonexistent: backupstoragelocation.velero.io \"nonexistent\" not found"},
+		},
+		{
+			name:           "non-existent resource policies ConfigMap fails validation",
+			backup:         defaultBackup().ResourcePolicy("nonexistent").Result(),
+			backupLocation: defaultBackupLocation,
+			expectedErrs:   []string{"invalid resource policies: error getting resource policies ConfigMap nonexistent: configmaps \"nonexistent\" not found"},
+		},
 	}
 
 	for _, test := range tests {
@@ -166,15 +237,21 @@ func TestProcessBackupValidationFailures(t *testing.T) {
 				clientset       = fake.NewSimpleClientset(test.backup)
 				sharedInformers = informers.NewSharedInformerFactory(clientset, 0)
 				logger          = logging.DefaultLogger(logrus.DebugLevel, formatFlag)
+				notifier        = &fakeNotifier{}
+				eventRecorder   = &fakeEventRecorder{}
 			)
 
 			c := &backupController{
 				genericController:      newGenericController("backup-test", logger),
 				client:                 clientset.VeleroV1(),
+				configMapsClient:       kubefake.NewSimpleClientset().CoreV1(),
 				lister:                 sharedInformers.Velero().V1().Backups().Lister(),
 				backupLocationLister:   sharedInformers.Velero().V1().BackupStorageLocations().Lister(),
 				snapshotLocationLister: sharedInformers.Velero().V1().VolumeSnapshotLocations().Lister(),
 				defaultBackupLocation:  defaultBackupLocation.Name,
+				metrics:                metrics.NewServerMetrics(),
+				notifier:               notifier,
+				eventRecorder:          eventRecorder,
 				clock:                  &clock.RealClock{},
 				formatFlag:             formatFlag,
 			}
@@ -197,6 +274,17 @@ func TestProcessBackupValidationFailures(t *testing.T) {
 			assert.Equal(t, velerov1api.BackupPhaseFailedValidation, res.Status.Phase)
 			assert.Equal(t, test.expectedErrs, res.Status.ValidationErrors)
 
+			require.Len(t, notifier.events, 1)
+			assert.Equal(t, notifications.EventBackupFailed, notifier.events[0].Type)
+
+			require.Len(t, res.Status.Conditions, 2)
+			assert.Equal(t, velerov1api.ConditionCompleted, res.Status.Conditions[0].Type)
+			assert.Equal(t, corev1api.ConditionTrue, res.Status.Conditions[0].Status)
+			assert.Equal(t, velerov1api.ConditionFailed, res.Status.Conditions[1].Type)
+			assert.Equal(t, corev1api.ConditionTrue, res.Status.Conditions[1].Status)
+			assert.Equal(t, strings.Join(test.expectedErrs, "; "), res.Status.Conditions[1].Message)
+			assert.Equal(t, []string{"BackupFailedValidation"}, eventRecorder.reasons)
+
 			// Any backup that would actually proceed to processing will cause a segfault because this
 			// test hasn't set up the necessary controller dependencies for running backups. So the lack
 			// of segfaults during test execution here imply that backups are not being processed, which
@@ -205,6 +293,34 @@ func TestProcessBackupValidationFailures(t *testing.T) {
 	}
 }
 
+func TestLastSuccessfulScheduledBackups(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	backup := func(name, schedule string, phase velerov1api.BackupPhase, completed time.Time) *velerov1api.Backup {
+		b := &velerov1api.Backup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: velerov1api.DefaultNamespace, Name: name},
+			Status:     velerov1api.BackupStatus{Phase: phase, CompletionTimestamp: metav1.NewTime(completed)},
+		}
+		if schedule != "" {
+			b.Labels = map[string]string{velerov1api.ScheduleNameLabel: schedule}
+		}
+		return b
+	}
+
+	backups := []*velerov1api.Backup{
+		backup("daily-1", "daily", velerov1api.BackupPhaseCompleted, now.Add(-48*time.Hour)),
+		backup("daily-2", "daily", velerov1api.BackupPhaseCompleted, now.Add(-24*time.Hour)),
+		backup("daily-3", "daily", velerov1api.BackupPhaseFailed, now),
+		backup("hourly-1", "hourly", velerov1api.BackupPhasePartiallyFailed, now),
+		backup("weekly-1", "weekly", velerov1api.BackupPhaseCompleted, now.Add(-72*time.Hour)),
+		backup("manual", "", velerov1api.BackupPhaseCompleted, now),
+	}
+
+	assert.Equal(t, map[string]time.Time{
+		"daily":  now.Add(-24 * time.Hour),
+		"weekly": now.Add(-72 * time.Hour),
+	}, lastSuccessfulScheduledBackups(backups))
+}
+
 func TestBackupLocationLabel(t *testing.T) {
 	tests := []struct {
 		name                   string
@@ -310,6 +426,204 @@ func TestDefaultBackupTTL(t *testing.T) {
 	}
 }
 
+func TestDefaultVolumesToRestic(t *testing.T) {
+	tests := []struct {
+		name                   string
+		backup                 *velerov1api.Backup
+		defaultVolumesToRestic bool
+		expected               bool
+	}{
+		{
+			name:                   "backup without the flag uses the server's default",
+			backup:                 defaultBackup().Result(),
+			defaultVolumesToRestic: true,
+			expected:               true,
+		},
+		{
+			name:                   "backup with the flag overrides the server's default",
+			backup:                 defaultBackup().DefaultVolumesToRestic(false).Result(),
+			defaultVolumesToRestic: true,
+			expected:               false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sharedInformers := informers.NewSharedInformerFactory(fake.NewSimpleClientset(test.backup), 0)
+
+			c := &backupController{
+				genericController:      newGenericController("backup-test", logging.DefaultLogger(logrus.DebugLevel, logging.FormatText)),
+				backupLocationLister:   sharedInformers.Velero().V1().BackupStorageLocations().Lister(),
+				snapshotLocationLister: sharedInformers.Velero().V1().VolumeSnapshotLocations().Lister(),
+				defaultVolumesToRestic: test.defaultVolumesToRestic,
+				clock:                  clock.NewFakeClock(time.Now()),
+				formatFlag:             logging.FormatText,
+			}
+
+			res := c.prepareBackupRequest(test.backup)
+			require.NotNil(t, res.Spec.DefaultVolumesToRestic)
+			assert.Equal(t, test.expected, *res.Spec.DefaultVolumesToRestic)
+		})
+	}
+}
+
+func TestPatchBackupProgress(t *testing.T) {
+	backup := defaultBackup().Phase(velerov1api.BackupPhaseInProgress).Result()
+	clientset := fake.NewSimpleClientset(backup)
+
+	progress := velerov1api.BackupProgress{TotalItems: 10, ItemsBackedUp: 4}
+	require.NoError(t, patchBackupProgress(backup.Namespace, backup.Name, progress, clientset.VeleroV1()))
+
+	res, err := clientset.VeleroV1().Backups(backup.Namespace).Get(backup.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, res.Status.Progress)
+	assert.Equal(t, progress, *res.Status.Progress)
+	assert.Equal(t, velerov1api.BackupPhaseInProgress, res.Status.Phase)
+
+	assert.Error(t, patchBackupProgress(backup.Namespace, "missing", progress, clientset.VeleroV1()))
+}
+
+func TestFailOverBackup(t *testing.T) {
+	tests := []struct {
+		name             string
+		backup           *pkgbackup.Request
+		failingLocations []string
+		wantLocation     string
+		wantFailover     *velerov1api.StorageLocationFailover
+		wantErrs         int
+	}{
+		{
+			name:         "backup is stored in the first failover location that isn't read-only",
+			backup:       &pkgbackup.Request{Backup: defaultBackup().StorageLocation("primary").FailoverStorageLocations("read-only", "failover-1", "failover-2").Result()},
+			wantLocation: "failover-1",
+			wantFailover: &velerov1api.StorageLocationFailover{From: "primary", To: "failover-1"},
+		},
+		{
+			name:             "failover locations are tried in order",
+			backup:           &pkgbackup.Request{Backup: defaultBackup().StorageLocation("primary").FailoverStorageLocations("failover-1", "failover-2").Result()},
+			failingLocations: []string{"failover-1"},
+			wantLocation:     "failover-2",
+			wantFailover:     &velerov1api.StorageLocationFailover{From: "primary", To: "failover-2"},
+		},
+		{
+			name:             "backup keeps its storage location if it can't be stored in any failover location",
+			backup:           &pkgbackup.Request{Backup: defaultBackup().StorageLocation("primary").FailoverStorageLocations("failover-1", "missing").Result()},
+			failingLocations: []string{"failover-1"},
+			wantLocation:     "primary",
+			wantErrs:         3,
+		},
+		{
+			name: "backup with restic backups doesn't fail over",
+			backup: &pkgbackup.Request{
+				Backup:           defaultBackup().StorageLocation("primary").FailoverStorageLocations("failover-1").Result(),
+				PodVolumeBackups: []*velerov1api.PodVolumeBackup{builder.ForPodVolumeBackup("velero", "pvb-1").Result()},
+			},
+			wantLocation: "primary",
+			wantErrs:     1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sharedInformers := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+			locations := []*velerov1api.BackupStorageLocation{
+				builder.ForBackupStorageLocation("velero", "primary").Bucket("primary").Result(),
+				builder.ForBackupStorageLocation("velero", "read-only").Bucket("read-only").AccessMode(velerov1api.BackupStorageLocationAccessModeReadOnly).Result(),
+				builder.ForBackupStorageLocation("velero", "failover-1").Bucket("failover-1").Result(),
+				builder.ForBackupStorageLocation("velero", "failover-2").Bucket("failover-2").Result(),
+			}
+			for _, location := range locations {
+				require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+			}
+
+			stores := make(map[persistence.BackupStore]string)
+			c := &backupController{
+				genericController:    newGenericController("backup-test", logging.DefaultLogger(logrus.DebugLevel, logging.FormatText)),
+				backupLocationLister: sharedInformers.Velero().V1().BackupStorageLocations().Lister(),
+				newBackupStore: func(location *velerov1api.BackupStorageLocation, _ persistence.ObjectStoreGetter, _ logrus.FieldLogger) (persistence.BackupStore, error) {
+					backupStore := new(persistencemocks.BackupStore)
+					backupStore.On("BackupExists", location.Name, "backup-1").Return(false, nil)
+					stores[backupStore] = location.Name
+					return backupStore, nil
+				},
+			}
+
+			test.backup.StorageLocation = locations[0]
+			test.backup.Labels = map[string]string{velerov1api.StorageLocationLabel: "primary"}
+
+			persist := func(backupStore persistence.BackupStore) []error {
+				for _, location := range test.failingLocations {
+					if stores[backupStore] == location {
+						return []error{errors.New("error uploading backup")}
+					}
+				}
+				return nil
+			}
+
+			errs := c.failOverBackup(test.backup, []error{errors.New("error uploading backup")}, nil, persist)
+
+			assert.Len(t, errs, test.wantErrs)
+			assert.Equal(t, test.wantLocation, test.backup.Spec.StorageLocation)
+			assert.Equal(t, test.wantLocation, test.backup.StorageLocation.Name)
+			assert.Equal(t, test.wantLocation, test.backup.Labels[velerov1api.StorageLocationLabel])
+			assert.Equal(t, test.wantFailover, test.backup.Status.StorageLocationFailover)
+		})
+	}
+}
+
+func TestLockBackup(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expiration time.Time
+		lockErr    error
+		wantLocked *metav1.Time
+	}{
+		{
+			name:       "backup's files are locked until it expires",
+			expiration: now.Add(time.Hour),
+			wantLocked: &metav1.Time{Time: now.Add(time.Hour)},
+		},
+		{
+			name:       "backup whose object store doesn't support object lock isn't locked",
+			expiration: now.Add(time.Hour),
+			lockErr:    errors.New("object store doesn't support locking objects"),
+		},
+		{
+			name:       "expired backup isn't locked",
+			expiration: now,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backupStore := new(persistencemocks.BackupStore)
+			if test.expiration.After(now) {
+				backupStore.On("LockBackup", "backup-1", test.expiration).Return(test.lockErr)
+			}
+
+			c := &backupController{
+				genericController: newGenericController("backup-test", logging.DefaultLogger(logrus.DebugLevel, logging.FormatText)),
+				clock:             clock.NewFakeClock(now),
+				newBackupStore: func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+					return backupStore, nil
+				},
+			}
+
+			backup := &pkgbackup.Request{
+				Backup:          defaultBackup().DeleteProtection(true).Expiration(test.expiration).Result(),
+				StorageLocation: builder.ForBackupStorageLocation("velero", "default").Result(),
+			}
+
+			c.lockBackup(backup, nil)
+
+			backupStore.AssertExpectations(t)
+			assert.Equal(t, test.wantLocked, backup.Status.ObjectLockedUntil)
+		})
+	}
+}
+
 func TestProcessBackupCompletions(t *testing.T) {
 	defaultBackupLocation := builder.ForBackupStorageLocation("velero", "loc-1").Bucket("store-1").Result()
 
@@ -343,7 +657,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 					},
 				},
 				Spec: velerov1api.BackupSpec{
-					StorageLocation: defaultBackupLocation.Name,
+					StorageLocation:        defaultBackupLocation.Name,
+					DefaultVolumesToRestic: boolptr.False(),
 				},
 				Status: velerov1api.BackupStatus{
 					Phase:               velerov1api.BackupPhaseCompleted,
@@ -351,6 +666,7 @@ func TestProcessBackupCompletions(t *testing.T) {
 					StartTimestamp:      metav1.NewTime(now),
 					CompletionTimestamp: metav1.NewTime(now),
 					Expiration:          metav1.NewTime(now),
+					Conditions:          completedBackupConditions(now),
 				},
 			},
 		},
@@ -371,7 +687,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 					},
 				},
 				Spec: velerov1api.BackupSpec{
-					StorageLocation: "alt-loc",
+					StorageLocation:        "alt-loc",
+					DefaultVolumesToRestic: boolptr.False(),
 				},
 				Status: velerov1api.BackupStatus{
 					Phase:               velerov1api.BackupPhaseCompleted,
@@ -379,6 +696,7 @@ func TestProcessBackupCompletions(t *testing.T) {
 					StartTimestamp:      metav1.NewTime(now),
 					CompletionTimestamp: metav1.NewTime(now),
 					Expiration:          metav1.NewTime(now),
+					Conditions:          completedBackupConditions(now),
 				},
 			},
 		},
@@ -402,7 +720,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 					},
 				},
 				Spec: velerov1api.BackupSpec{
-					StorageLocation: "read-write",
+					StorageLocation:        "read-write",
+					DefaultVolumesToRestic: boolptr.False(),
 				},
 				Status: velerov1api.BackupStatus{
 					Phase:               velerov1api.BackupPhaseCompleted,
@@ -410,6 +729,7 @@ func TestProcessBackupCompletions(t *testing.T) {
 					StartTimestamp:      metav1.NewTime(now),
 					CompletionTimestamp: metav1.NewTime(now),
 					Expiration:          metav1.NewTime(now),
+					Conditions:          completedBackupConditions(now),
 				},
 			},
 		},
@@ -431,7 +751,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 				},
 				Spec: velerov1api.BackupSpec{
 					TTL:             metav1.Duration{Duration: 10 * time.Minute},
-					StorageLocation: defaultBackupLocation.Name,
+					StorageLocation:        defaultBackupLocation.Name,
+					DefaultVolumesToRestic: boolptr.False(),
 				},
 				Status: velerov1api.BackupStatus{
 					Phase:               velerov1api.BackupPhaseCompleted,
@@ -439,6 +760,7 @@ func TestProcessBackupCompletions(t *testing.T) {
 					Expiration:          metav1.NewTime(now.Add(10 * time.Minute)),
 					StartTimestamp:      metav1.NewTime(now),
 					CompletionTimestamp: metav1.NewTime(now),
+					Conditions:          completedBackupConditions(now),
 				},
 			},
 		},
@@ -460,7 +782,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 					},
 				},
 				Spec: velerov1api.BackupSpec{
-					StorageLocation: defaultBackupLocation.Name,
+					StorageLocation:        defaultBackupLocation.Name,
+					DefaultVolumesToRestic: boolptr.False(),
 				},
 				Status: velerov1api.BackupStatus{
 					Phase:               velerov1api.BackupPhaseCompleted,
@@ -468,6 +791,7 @@ func TestProcessBackupCompletions(t *testing.T) {
 					StartTimestamp:      metav1.NewTime(now),
 					CompletionTimestamp: metav1.NewTime(now),
 					Expiration:          metav1.NewTime(now),
+					Conditions:          completedBackupConditions(now),
 				},
 			},
 		},
@@ -491,7 +815,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 					},
 				},
 				Spec: velerov1api.BackupSpec{
-					StorageLocation: defaultBackupLocation.Name,
+					StorageLocation:        defaultBackupLocation.Name,
+					DefaultVolumesToRestic: boolptr.False(),
 				},
 				Status: velerov1api.BackupStatus{
 					Phase:               velerov1api.BackupPhaseFailed,
@@ -499,6 +824,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 					StartTimestamp:      metav1.NewTime(now),
 					CompletionTimestamp: metav1.NewTime(now),
 					Expiration:          metav1.NewTime(now),
+					FailureReason:       "backup already exists in object storage",
+					Conditions:          failedBackupConditions(now, "backup already exists in object storage"),
 				},
 			},
 		},
@@ -520,7 +847,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 					},
 				},
 				Spec: velerov1api.BackupSpec{
-					StorageLocation: defaultBackupLocation.Name,
+					StorageLocation:        defaultBackupLocation.Name,
+					DefaultVolumesToRestic: boolptr.False(),
 				},
 				Status: velerov1api.BackupStatus{
 					Phase:               velerov1api.BackupPhaseFailed,
@@ -528,6 +856,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 					StartTimestamp:      metav1.NewTime(now),
 					CompletionTimestamp: metav1.NewTime(now),
 					Expiration:          metav1.NewTime(now),
+					FailureReason:       "error checking if backup already exists in object storage: Backup already exists in object storage",
+					Conditions:          failedBackupConditions(now, "error checking if backup already exists in object storage: Backup already exists in object storage"),
 				},
 			},
 		},
@@ -554,6 +884,8 @@ func TestProcessBackupCompletions(t *testing.T) {
 				defaultBackupLocation:  defaultBackupLocation.Name,
 				backupTracker:          NewBackupTracker(),
 				metrics:                metrics.NewServerMetrics(),
+				notifier:               &fakeNotifier{},
+				eventRecorder:          &fakeEventRecorder{},
 				clock:                  clock.NewFakeClock(now),
 				newPluginManager:       func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
 				newBackupStore: func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
@@ -565,6 +897,7 @@ func TestProcessBackupCompletions(t *testing.T) {
 
 			pluginManager.On("GetBackupItemActions").Return(nil, nil)
 			pluginManager.On("CleanupClients").Return(nil)
+			pluginManager.On("PluginCrashes").Return(nil)
 			backupper.On("Backup", mock.Anything, mock.Anything, mock.Anything, []velero.BackupItemAction(nil), pluginManager).Return(nil)
 			backupStore.On("BackupExists", test.backupLocation.Spec.StorageType.ObjectStorage.Bucket, test.backup.Name).Return(test.backupExists, test.existenceCheckError)
 
@@ -602,11 +935,403 @@ func TestProcessBackupCompletions(t *testing.T) {
 			res, err := clientset.VeleroV1().Backups(test.backup.Namespace).Get(test.backup.Name, metav1.GetOptions{})
 			require.NoError(t, err)
 
+			// the backup's storage usage includes the size of its log, so
+			// it's only checked for having been recorded when it's uploaded.
+			if test.expectedResult.Status.Phase == velerov1api.BackupPhaseCompleted {
+				require.NotNil(t, res.Status.StorageUsage)
+				assert.True(t, res.Status.StorageUsage.TotalBytes > 0)
+			}
+			res.Status.StorageUsage = nil
+
 			assert.Equal(t, test.expectedResult, res)
 		})
 	}
 }
 
+func TestRetryBackoff(t *testing.T) {
+	storeUnavailable := errors.Wrap(persistence.ErrStoreUnavailable, "error uploading backup")
+
+	tests := []struct {
+		name          string
+		backup        *velerov1api.Backup
+		err           error
+		expectRetry   bool
+		expectBackoff time.Duration
+	}{
+		{
+			name:   "backup without a retry policy isn't retried",
+			backup: defaultBackup().Result(),
+			err:    storeUnavailable,
+		},
+		{
+			name:   "backup that failed with an error that isn't transient isn't retried",
+			backup: defaultBackup().RetryPolicy(3, time.Minute).Result(),
+			err:    errors.New("backup already exists in object storage"),
+		},
+		{
+			name:          "first retry waits for the backoff",
+			backup:        defaultBackup().RetryPolicy(3, 5*time.Minute).Result(),
+			err:           storeUnavailable,
+			expectRetry:   true,
+			expectBackoff: 5 * time.Minute,
+		},
+		{
+			name:          "backoff defaults to a minute",
+			backup:        defaultBackup().RetryPolicy(3, 0).Result(),
+			err:           storeUnavailable,
+			expectRetry:   true,
+			expectBackoff: time.Minute,
+		},
+		{
+			name:          "backoff is doubled for each retry",
+			backup:        withAttempts(defaultBackup().RetryPolicy(4, 5*time.Minute).Result(), 2),
+			err:           storeUnavailable,
+			expectRetry:   true,
+			expectBackoff: 20 * time.Minute,
+		},
+		{
+			name:          "backoff is capped",
+			backup:        withAttempts(defaultBackup().RetryPolicy(20, 5*time.Minute).Result(), 10),
+			err:           storeUnavailable,
+			expectRetry:   true,
+			expectBackoff: maxBackupRetryBackoff,
+		},
+		{
+			name:   "backup that's run out of attempts isn't retried",
+			backup: withAttempts(defaultBackup().RetryPolicy(3, time.Minute).Result(), 2),
+			err:    storeUnavailable,
+		},
+		{
+			name:   "backup with a single attempt isn't retried",
+			backup: defaultBackup().RetryPolicy(1, time.Minute).Result(),
+			err:    storeUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backoff, retry := retryBackoff(test.backup, test.err)
+			assert.Equal(t, test.expectRetry, retry)
+			assert.Equal(t, test.expectBackoff, backoff)
+		})
+	}
+}
+
+// withAttempts records n failed attempts in the backup's status.
+func withAttempts(backup *velerov1api.Backup, n int) *velerov1api.Backup {
+	for i := 0; i < n; i++ {
+		backup.Status.Attempts = append(backup.Status.Attempts, velerov1api.BackupAttempt{FailureReason: "backup store is unavailable"})
+	}
+	return backup
+}
+
+func TestIsTransientBackupError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil error",
+		},
+		{
+			name: "backup store is unavailable",
+			err:  errors.Wrap(persistence.ErrStoreUnavailable, "error checking if backup already exists in object storage"),
+			want: true,
+		},
+		{
+			name: "backup store denied permission",
+			err:  errors.WithStack(persistence.ErrPermissionDenied),
+		},
+		{
+			name: "API server is unavailable",
+			err:  errors.Wrap(apierrors.NewServiceUnavailable("try again later"), "error listing items"),
+			want: true,
+		},
+		{
+			name: "API server is throttling requests",
+			err:  apierrors.NewTooManyRequests("slow down", 1),
+			want: true,
+		},
+		{
+			name: "API server couldn't be reached",
+			err:  errors.WithStack(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}),
+			want: true,
+		},
+		{
+			name: "API server rejected the request",
+			err:  apierrors.NewBadRequest("invalid"),
+		},
+		{
+			name: "all aggregated errors are transient",
+			err:  kerrors.NewAggregate([]error{errors.WithStack(persistence.ErrStoreUnavailable), apierrors.NewServiceUnavailable("try again later")}),
+			want: true,
+		},
+		{
+			name: "one aggregated error isn't transient",
+			err:  kerrors.NewAggregate([]error{errors.WithStack(persistence.ErrStoreUnavailable), errors.New("pod volumes couldn't be backed up")}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, isTransientBackupError(test.err))
+		})
+	}
+}
+
+func TestProcessBackupRetries(t *testing.T) {
+	location := builder.ForBackupStorageLocation("velero", "loc-1").Bucket("store-1").Result()
+
+	now, err := time.Parse(time.RFC1123Z, time.RFC1123Z)
+	require.NoError(t, err)
+	now = now.Local()
+
+	storeUnavailable := errors.Wrap(persistence.ErrStoreUnavailable, "connection reset")
+
+	tests := []struct {
+		name                string
+		backup              *velerov1api.Backup
+		backupExists        bool
+		existenceCheckError error
+		expectPhase         velerov1api.BackupPhase
+		expectAttempts      int
+		expectRetryIn       time.Duration
+		expectDeleted       bool
+	}{
+		{
+			name:                "backup that fails with a transient error is reset for its next attempt",
+			backup:              defaultBackup().StorageLocation("loc-1").RetryPolicy(3, 5*time.Minute).Result(),
+			existenceCheckError: storeUnavailable,
+			expectPhase:         velerov1api.BackupPhaseNew,
+			expectAttempts:      1,
+			expectRetryIn:       5 * time.Minute,
+		},
+		{
+			name:                "backup that fails on its last attempt is failed",
+			backup:              withAttempts(defaultBackup().StorageLocation("loc-1").RetryPolicy(2, 5*time.Minute).Result(), 1),
+			existenceCheckError: storeUnavailable,
+			expectPhase:         velerov1api.BackupPhaseFailed,
+			expectAttempts:      1,
+		},
+		{
+			name:           "retried backup replaces the files of its failed attempt",
+			backup:         withAttempts(defaultBackup().StorageLocation("loc-1").RetryPolicy(2, 5*time.Minute).Result(), 1),
+			backupExists:   true,
+			expectPhase:    velerov1api.BackupPhaseCompleted,
+			expectAttempts: 1,
+			expectDeleted:  true,
+		},
+		{
+			name:         "backup that hasn't been retried isn't replaced",
+			backup:       defaultBackup().StorageLocation("loc-1").RetryPolicy(2, 5*time.Minute).Result(),
+			backupExists: true,
+			expectPhase:  velerov1api.BackupPhaseFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				clientset       = fake.NewSimpleClientset(test.backup)
+				sharedInformers = informers.NewSharedInformerFactory(clientset, 0)
+				logger          = logging.DefaultLogger(logrus.DebugLevel, logging.FormatText)
+				pluginManager   = new(pluginmocks.Manager)
+				backupStore     = new(persistencemocks.BackupStore)
+				backupper       = new(fakeBackupper)
+			)
+
+			c := &backupController{
+				genericController:      newGenericController("backup-test", logger),
+				client:                 clientset.VeleroV1(),
+				lister:                 sharedInformers.Velero().V1().Backups().Lister(),
+				backupLocationLister:   sharedInformers.Velero().V1().BackupStorageLocations().Lister(),
+				snapshotLocationLister: sharedInformers.Velero().V1().VolumeSnapshotLocations().Lister(),
+				defaultBackupLocation:  location.Name,
+				backupTracker:          NewBackupTracker(),
+				metrics:                metrics.NewServerMetrics(),
+				notifier:               &fakeNotifier{},
+				eventRecorder:          &fakeEventRecorder{},
+				clock:                  clock.NewFakeClock(now),
+				newPluginManager:       func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+				newBackupStore: func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+					return backupStore, nil
+				},
+				backupper:  backupper,
+				formatFlag: logging.FormatText,
+			}
+
+			pluginManager.On("GetBackupItemActions").Return(nil, nil)
+			pluginManager.On("CleanupClients").Return(nil)
+			pluginManager.On("PluginCrashes").Return(nil)
+			backupper.On("Backup", mock.Anything, mock.Anything, mock.Anything, []velero.BackupItemAction(nil), pluginManager).Return(nil)
+			backupStore.On("BackupExists", "store-1", test.backup.Name).Return(test.backupExists, test.existenceCheckError)
+			backupStore.On("DeleteBackup", test.backup.Name).Return(nil)
+			backupStore.On("PutBackup", mock.Anything).Return(nil)
+
+			require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(test.backup))
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+
+			require.NoError(t, c.processBackup(fmt.Sprintf("%s/%s", test.backup.Namespace, test.backup.Name)))
+
+			res, err := clientset.VeleroV1().Backups(test.backup.Namespace).Get(test.backup.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectPhase, res.Status.Phase)
+			assert.Len(t, res.Status.Attempts, test.expectAttempts)
+			if test.expectRetryIn > 0 {
+				require.NotNil(t, res.Status.NextAttempt)
+				assert.True(t, res.Status.NextAttempt.Time.Equal(now.Add(test.expectRetryIn)))
+				assert.Contains(t, res.Status.Attempts[0].FailureReason, storeUnavailable.Error())
+			} else {
+				assert.Nil(t, res.Status.NextAttempt)
+			}
+
+			if test.expectDeleted {
+				backupStore.AssertCalled(t, "DeleteBackup", test.backup.Name)
+			} else {
+				backupStore.AssertNotCalled(t, "DeleteBackup", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestProcessBackupWaitsForNextAttempt(t *testing.T) {
+	now := time.Date(2019, 7, 1, 1, 0, 0, 0, time.UTC)
+	next := metav1.NewTime(now.Add(time.Minute))
+
+	backup := withAttempts(defaultBackup().Phase(velerov1api.BackupPhaseNew).RetryPolicy(3, time.Minute).Result(), 1)
+	backup.Status.NextAttempt = &next
+
+	var (
+		clientset       = fake.NewSimpleClientset(backup)
+		sharedInformers = informers.NewSharedInformerFactory(clientset, 0)
+	)
+
+	c := &backupController{
+		genericController: newGenericController("backup-test", velerotest.NewLogger()),
+		client:            clientset.VeleroV1(),
+		lister:            sharedInformers.Velero().V1().Backups().Lister(),
+		clock:             clock.NewFakeClock(now),
+	}
+
+	require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup))
+	require.NoError(t, c.processBackup(velerov1api.DefaultNamespace+"/backup-1"))
+
+	assert.Empty(t, clientset.Actions())
+}
+
+func TestProcessBackupRetryDeletesAttemptSnapshots(t *testing.T) {
+	now := time.Date(2019, 7, 1, 1, 0, 0, 0, time.UTC)
+
+	var (
+		backup            = defaultBackup().StorageLocation("loc-1").VolumeSnapshotLocations("vsl-1").RetryPolicy(3, time.Minute).Result()
+		location          = builder.ForBackupStorageLocation("velero", "loc-1").Bucket("store-1").Result()
+		snapshotLocation  = builder.ForVolumeSnapshotLocation(velerov1api.DefaultNamespace, "vsl-1").Provider("provider-1").Result()
+		clientset         = fake.NewSimpleClientset(backup)
+		sharedInformers   = informers.NewSharedInformerFactory(clientset, 0)
+		pluginManager     = new(pluginmocks.Manager)
+		backupStore       = new(persistencemocks.BackupStore)
+		backupper         = new(fakeBackupper)
+		volumeSnapshotter = &velerotest.FakeVolumeSnapshotter{SnapshotsTaken: sets.NewString("snap-1")}
+	)
+
+	c := &backupController{
+		genericController:      newGenericController("backup-test", velerotest.NewLogger()),
+		client:                 clientset.VeleroV1(),
+		lister:                 sharedInformers.Velero().V1().Backups().Lister(),
+		backupLocationLister:   sharedInformers.Velero().V1().BackupStorageLocations().Lister(),
+		snapshotLocationLister: sharedInformers.Velero().V1().VolumeSnapshotLocations().Lister(),
+		defaultBackupLocation:  location.Name,
+		backupTracker:          NewBackupTracker(),
+		metrics:                metrics.NewServerMetrics(),
+		notifier:               &fakeNotifier{},
+		eventRecorder:          &fakeEventRecorder{},
+		clock:                  clock.NewFakeClock(now),
+		newPluginManager:       func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+		newBackupStore: func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+			return backupStore, nil
+		},
+		backupper:  backupper,
+		formatFlag: logging.FormatText,
+	}
+
+	pluginManager.On("GetBackupItemActions").Return(nil, nil)
+	pluginManager.On("GetVolumeSnapshotter", "provider-1").Return(volumeSnapshotter, nil)
+	pluginManager.On("CleanupClients").Return(nil)
+	pluginManager.On("PluginCrashes").Return(nil)
+	backupStore.On("BackupExists", "store-1", backup.Name).Return(false, nil)
+	backupStore.On("PutBackup", mock.Anything).Return(errors.WithStack(persistence.ErrStoreUnavailable))
+
+	// the attempt takes a snapshot before its upload fails.
+	backupper.On("Backup", mock.Anything, mock.Anything, mock.Anything, []velero.BackupItemAction(nil), pluginManager).
+		Run(func(args mock.Arguments) {
+			request := args.Get(1).(*pkgbackup.Request)
+			request.VolumeSnapshots = append(request.VolumeSnapshots, &volume.Snapshot{
+				Spec:   volume.SnapshotSpec{Location: "vsl-1"},
+				Status: volume.SnapshotStatus{ProviderSnapshotID: "snap-1", Phase: volume.SnapshotPhaseCompleted},
+			})
+		}).
+		Return(nil)
+
+	require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup))
+	require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+	require.NoError(t, sharedInformers.Velero().V1().VolumeSnapshotLocations().Informer().GetStore().Add(snapshotLocation))
+
+	require.NoError(t, c.processBackup(velerov1api.DefaultNamespace+"/backup-1"))
+
+	res, err := clientset.VeleroV1().Backups(velerov1api.DefaultNamespace).Get("backup-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, velerov1api.BackupPhaseNew, res.Status.Phase)
+	assert.Len(t, res.Status.Attempts, 1)
+	assert.False(t, volumeSnapshotter.SnapshotsTaken.Has("snap-1"))
+}
+
+func TestBackupStorageUsage(t *testing.T) {
+	tarball, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer closeAndRemoveFile(tarball, velerotest.NewLogger())
+
+	_, err = tarball.Write(make([]byte, 100))
+	require.NoError(t, err)
+
+	podVolumeBackups := []*velerov1api.PodVolumeBackup{
+		builder.ForPodVolumeBackup(velerov1api.DefaultNamespace, "pvb-1").Pod("ns-1", "pod-1").Volume("data").Phase(velerov1api.PodVolumeBackupPhaseCompleted).Result(),
+		builder.ForPodVolumeBackup(velerov1api.DefaultNamespace, "pvb-2").Pod("ns-1", "pod-1").Volume("logs").Phase(velerov1api.PodVolumeBackupPhaseFailed).Result(),
+	}
+	podVolumeBackups[0].Status.Changes.BytesAdded = 1000
+
+	var nilIndex io.Reader
+	usage := backupStorageUsage(podVolumeBackups, tarball, bytes.NewBufferString("log"), new(bytes.Buffer), nilIndex)
+
+	assert.Equal(t, &velerov1api.BackupStorageUsage{
+		TotalBytes:   1103,
+		TarballBytes: 100,
+		PodVolumes: []velerov1api.PodVolumeStorageUsage{
+			{Name: "ns-1/pod-1/data", Bytes: 1000},
+		},
+	}, usage)
+}
+
+// completedBackupConditions returns the conditions of a backup that was
+// started, uploaded and completed at now.
+func completedBackupConditions(now time.Time) []velerov1api.Condition {
+	return []velerov1api.Condition{
+		{Type: velerov1api.ConditionCompleted, Status: corev1api.ConditionTrue, LastTransitionTime: metav1.NewTime(now), Reason: "Completed"},
+		{Type: velerov1api.ConditionStoreUploaded, Status: corev1api.ConditionTrue, LastTransitionTime: metav1.NewTime(now), Reason: "Uploaded"},
+		{Type: velerov1api.ConditionFailed, Status: corev1api.ConditionFalse, LastTransitionTime: metav1.NewTime(now), Reason: "Completed"},
+	}
+}
+
+// failedBackupConditions returns the conditions of a backup that was
+// started and failed at now, before it was uploaded.
+func failedBackupConditions(now time.Time, reason string) []velerov1api.Condition {
+	return []velerov1api.Condition{
+		{Type: velerov1api.ConditionCompleted, Status: corev1api.ConditionTrue, LastTransitionTime: metav1.NewTime(now), Reason: "Failed"},
+		{Type: velerov1api.ConditionFailed, Status: corev1api.ConditionTrue, LastTransitionTime: metav1.NewTime(now), Reason: "Failed", Message: reason},
+	}
+}
+
 func TestValidateAndGetSnapshotLocations(t *testing.T) {
 	tests := []struct {
 		name                                string
@@ -737,3 +1462,13 @@ func TestValidateAndGetSnapshotLocations(t *testing.T) {
 		})
 	}
 }
+
+// fakeEventRecorder records the reasons of the events it's asked to
+// record.
+type fakeEventRecorder struct {
+	reasons []string
+}
+
+func (r *fakeEventRecorder) Event(obj runtime.Object, eventType, reason, message string) {
+	r.reasons = append(r.reasons, reason)
+}
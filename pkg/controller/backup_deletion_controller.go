@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
@@ -35,6 +36,9 @@ import (
 
 	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
 	pkgbackup "github.com/heptio/velero/pkg/backup"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/credentials"
+	"github.com/heptio/velero/pkg/csi"
 	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
@@ -43,7 +47,7 @@ import (
 	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/clientmgmt"
 	"github.com/heptio/velero/pkg/plugin/velero"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/util/kube"
 )
 
@@ -55,13 +59,15 @@ type backupDeletionController struct {
 	deleteBackupRequestClient velerov1client.DeleteBackupRequestsGetter
 	deleteBackupRequestLister listers.DeleteBackupRequestLister
 	backupClient              velerov1client.BackupsGetter
+	backupLister              listers.BackupLister
 	restoreLister             listers.RestoreLister
 	restoreClient             velerov1client.RestoresGetter
 	backupTracker             BackupTracker
-	resticMgr                 restic.RepositoryManager
+	resticMgr                 podvolume.RepositoryManager
 	podvolumeBackupLister     listers.PodVolumeBackupLister
 	backupLocationLister      listers.BackupStorageLocationLister
 	snapshotLocationLister    listers.VolumeSnapshotLocationLister
+	csiSnapshotter            *csi.Snapshotter
 	processRequestFunc        func(*v1.DeleteBackupRequest) error
 	clock                     clock.Clock
 	newPluginManager          func(logrus.FieldLogger) clientmgmt.Manager
@@ -75,13 +81,15 @@ func NewBackupDeletionController(
 	deleteBackupRequestInformer informers.DeleteBackupRequestInformer,
 	deleteBackupRequestClient velerov1client.DeleteBackupRequestsGetter,
 	backupClient velerov1client.BackupsGetter,
+	backupInformer informers.BackupInformer,
 	restoreInformer informers.RestoreInformer,
 	restoreClient velerov1client.RestoresGetter,
 	backupTracker BackupTracker,
-	resticMgr restic.RepositoryManager,
+	resticMgr podvolume.RepositoryManager,
 	podvolumeBackupInformer informers.PodVolumeBackupInformer,
 	backupLocationInformer informers.BackupStorageLocationInformer,
 	snapshotLocationInformer informers.VolumeSnapshotLocationInformer,
+	dynamicFactory client.DynamicFactory,
 	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
 	metrics *metrics.ServerMetrics,
 ) Interface {
@@ -90,6 +98,7 @@ func NewBackupDeletionController(
 		deleteBackupRequestClient: deleteBackupRequestClient,
 		deleteBackupRequestLister: deleteBackupRequestInformer.Lister(),
 		backupClient:              backupClient,
+		backupLister:              backupInformer.Lister(),
 		restoreLister:             restoreInformer.Lister(),
 		restoreClient:             restoreClient,
 		backupTracker:             backupTracker,
@@ -97,11 +106,12 @@ func NewBackupDeletionController(
 		podvolumeBackupLister:     podvolumeBackupInformer.Lister(),
 		backupLocationLister:      backupLocationInformer.Lister(),
 		snapshotLocationLister:    snapshotLocationInformer.Lister(),
+		csiSnapshotter:            csi.NewSnapshotter(dynamicFactory, 0),
 		metrics:                   metrics,
 		// use variables to refer to these functions so they can be
 		// replaced with fakes for testing.
 		newPluginManager: newPluginManager,
-		newBackupStore:   persistence.NewObjectBackupStore,
+		newBackupStore:   persistence.NewBackupStore,
 
 		clock: &clock.RealClock{},
 	}
@@ -110,6 +120,7 @@ func NewBackupDeletionController(
 	c.cacheSyncWaiters = append(
 		c.cacheSyncWaiters,
 		deleteBackupRequestInformer.Informer().HasSynced,
+		backupInformer.Informer().HasSynced,
 		restoreInformer.Informer().HasSynced,
 		podvolumeBackupInformer.Informer().HasSynced,
 		backupLocationInformer.Informer().HasSynced,
@@ -208,6 +219,15 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 		return errors.Wrap(err, "error getting backup")
 	}
 
+	// Don't allow deleting a delete-protected backup
+	if backup.Spec.DeleteProtection {
+		_, err := c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
+			r.Status.Phase = v1.DeleteBackupRequestPhaseProcessed
+			r.Status.Errors = append(r.Status.Errors, "cannot delete backup because it's delete-protected; set its spec.deleteProtection to false to delete it")
+		})
+		return err
+	}
+
 	// Don't allow deleting backups in read-only storage locations
 	location, err := c.backupLocationLister.BackupStorageLocations(backup.Namespace).Get(backup.Spec.StorageLocation)
 	if apierrors.IsNotFound(err) {
@@ -229,6 +249,19 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 		return err
 	}
 
+	// Don't allow deleting a backup that other backups refer to for their unchanged items
+	referencing, err := referencingBackups(c.backupLister, backup)
+	if err != nil {
+		return err
+	}
+	if len(referencing) > 0 {
+		_, err := c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
+			r.Status.Phase = v1.DeleteBackupRequestPhaseProcessed
+			r.Status.Errors = append(r.Status.Errors, fmt.Sprintf("cannot delete backup because backups %s refer to its unchanged items", strings.Join(referencing, ", ")))
+		})
+		return err
+	}
+
 	// if the request object has no labels defined, initialise an empty map since
 	// we will be updating labels
 	if req.Labels == nil {
@@ -290,6 +323,17 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 			for _, snapshot := range snapshots {
 				log.WithField("providerSnapshotID", snapshot.Status.ProviderSnapshotID).Info("Removing snapshot associated with backup")
 
+				if csiSnapshot := csi.SnapshotFor(snapshot); csiSnapshot != nil {
+					// a failed CSI snapshot has no content left to delete.
+					if csiSnapshot.ContentName == "" {
+						continue
+					}
+					if err := c.csiSnapshotter.DeleteSnapshot(csiSnapshot); err != nil {
+						errs = append(errs, errors.Wrapf(err, "error deleting CSI snapshot %s", snapshot.Status.ProviderSnapshotID).Error())
+					}
+					continue
+				}
+
 				volumeSnapshotter, ok := volumeSnapshotters[snapshot.Spec.Location]
 				if !ok {
 					if volumeSnapshotter, err = volumeSnapshotterForSnapshotLocation(backup.Namespace, snapshot.Spec.Location, c.snapshotLocationLister, pluginManager); err != nil {
@@ -320,6 +364,39 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 		}
 	}
 
+	for _, replica := range backup.Status.Replicas {
+		if replica.Phase != v1.BackupReplicaPhaseCompleted {
+			continue
+		}
+
+		replicaLog := log.WithField("replicaLocation", replica.StorageLocation)
+
+		replicaLocation, err := c.backupLocationLister.BackupStorageLocations(backup.Namespace).Get(replica.StorageLocation)
+		if apierrors.IsNotFound(err) {
+			replicaLog.Warn("Replica backup storage location not found, skipping removal of backup replica")
+			continue
+		}
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "error getting backup storage location %s", replica.StorageLocation).Error())
+			continue
+		}
+		if replicaLocation.Spec.AccessMode == v1.BackupStorageLocationAccessModeReadOnly {
+			replicaLog.Warn("Replica backup storage location is in read-only mode, skipping removal of backup replica")
+			continue
+		}
+
+		replicaStore, err := c.newBackupStore(replicaLocation, pluginManager, replicaLog)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		replicaLog.Info("Removing backup replica from backup storage")
+		if err := replicaStore.DeleteBackup(backup.Name); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
 	log.Info("Removing restores")
 	if restores, err := c.restoreLister.Restores(backup.Namespace).List(labels.Everything()); err != nil {
 		log.WithError(errors.WithStack(err)).Error("Error listing restore API objects")
@@ -355,6 +432,7 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 
 	if len(errs) == 0 {
 		c.metrics.RegisterBackupDeletionSuccess(backupScheduleName)
+		c.metrics.DeleteRestoreItemsRestored(backupScheduleName, backup.Name)
 	} else {
 		c.metrics.RegisterBackupDeletionFailed(backupScheduleName)
 	}
@@ -396,7 +474,12 @@ func volumeSnapshotterForSnapshotLocation(
 		return nil, errors.Wrapf(err, "error getting volume snapshotter for provider %s", snapshotLocation.Spec.Provider)
 	}
 
-	if err = volumeSnapshotter.Init(snapshotLocation.Spec.Config); err != nil {
+	config, err := credentials.WithCredentialsFile(snapshotLocation.Spec.Config, snapshotLocation.Spec.Credential)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting credential for volume snapshot location %s", snapshotLocationName)
+	}
+
+	if err = volumeSnapshotter.Init(config); err != nil {
 		return nil, errors.Wrapf(err, "error initializing volume snapshotter for volume snapshot location %s", snapshotLocationName)
 	}
 
@@ -432,7 +515,7 @@ func (c *backupDeletionController) deleteResticSnapshots(backup *v1.Backup) []er
 		return nil
 	}
 
-	snapshots, err := restic.GetSnapshotsInBackup(backup, c.podvolumeBackupLister)
+	snapshots, err := podvolume.GetSnapshotsInBackup(backup, c.podvolumeBackupLister)
 	if err != nil {
 		return []error{err}
 	}
@@ -25,16 +25,21 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/sets"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	core "k8s.io/client-go/testing"
 
 	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
 	pkgbackup "github.com/heptio/velero/pkg/backup"
 	"github.com/heptio/velero/pkg/builder"
+	veleroclient "github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/csi"
 	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
 	"github.com/heptio/velero/pkg/metrics"
@@ -55,6 +60,7 @@ func TestBackupDeletionControllerProcessQueueItem(t *testing.T) {
 		sharedInformers.Velero().V1().DeleteBackupRequests(),
 		client.VeleroV1(), // deleteBackupRequestClient
 		client.VeleroV1(), // backupClient
+		sharedInformers.Velero().V1().Backups(),
 		sharedInformers.Velero().V1().Restores(),
 		client.VeleroV1(), // restoreClient
 		NewBackupTracker(),
@@ -62,6 +68,7 @@ func TestBackupDeletionControllerProcessQueueItem(t *testing.T) {
 		sharedInformers.Velero().V1().PodVolumeBackups(),
 		sharedInformers.Velero().V1().BackupStorageLocations(),
 		sharedInformers.Velero().V1().VolumeSnapshotLocations(),
+		nil, // dynamicFactory
 		nil, // new plugin manager func
 		metrics.NewServerMetrics(),
 	).(*backupDeletionController)
@@ -145,6 +152,7 @@ func setupBackupDeletionControllerTest(objects ...runtime.Object) *backupDeletio
 			sharedInformers.Velero().V1().DeleteBackupRequests(),
 			client.VeleroV1(), // deleteBackupRequestClient
 			client.VeleroV1(), // backupClient
+			sharedInformers.Velero().V1().Backups(),
 			sharedInformers.Velero().V1().Restores(),
 			client.VeleroV1(), // restoreClient
 			NewBackupTracker(),
@@ -152,6 +160,7 @@ func setupBackupDeletionControllerTest(objects ...runtime.Object) *backupDeletio
 			sharedInformers.Velero().V1().PodVolumeBackups(),
 			sharedInformers.Velero().V1().BackupStorageLocations(),
 			sharedInformers.Velero().V1().VolumeSnapshotLocations(),
+			nil, // dynamicFactory
 			func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
 			metrics.NewServerMetrics(),
 		).(*backupDeletionController),
@@ -390,6 +399,32 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 		assert.Equal(t, expectedActions, td.client.Actions())
 	})
 
+	t.Run("delete-protected backup is not deleted", func(t *testing.T) {
+		backup := builder.ForBackup(v1.DefaultNamespace, "foo").StorageLocation("default").DeleteProtection(true).Result()
+
+		td := setupBackupDeletionControllerTest(backup)
+
+		err := td.controller.processRequest(td.req)
+		require.NoError(t, err)
+
+		expectedActions := []core.Action{
+			core.NewGetAction(
+				v1.SchemeGroupVersion.WithResource("backups"),
+				td.req.Namespace,
+				td.req.Spec.BackupName,
+			),
+			core.NewPatchAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				td.req.Name,
+				types.MergePatchType,
+				[]byte(`{"status":{"errors":["cannot delete backup because it's delete-protected; set its spec.deleteProtection to false to delete it"],"phase":"Processed"}}`),
+			),
+		}
+
+		assert.Equal(t, expectedActions, td.client.Actions())
+	})
+
 	t.Run("backup storage location is in read-only mode", func(t *testing.T) {
 		backup := builder.ForBackup(v1.DefaultNamespace, "foo").StorageLocation("default").Result()
 		location := builder.ForBackupStorageLocation("velero", "default").AccessMode(v1.BackupStorageLocationAccessModeReadOnly).Result()
@@ -419,6 +454,38 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 		assert.Equal(t, expectedActions, td.client.Actions())
 	})
 
+	t.Run("backup that other backups refer to is not deleted", func(t *testing.T) {
+		backup := builder.ForBackup(v1.DefaultNamespace, "foo").StorageLocation("default").Result()
+		location := builder.ForBackupStorageLocation("velero", "default").Result()
+
+		td := setupBackupDeletionControllerTest(backup)
+
+		td.sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location)
+		td.sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(
+			builder.ForBackup(v1.DefaultNamespace, "bar").StorageLocation("default").ReferencedBackups("foo").Result(),
+		)
+
+		err := td.controller.processRequest(td.req)
+		require.NoError(t, err)
+
+		expectedActions := []core.Action{
+			core.NewGetAction(
+				v1.SchemeGroupVersion.WithResource("backups"),
+				td.req.Namespace,
+				td.req.Spec.BackupName,
+			),
+			core.NewPatchAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				td.req.Name,
+				types.MergePatchType,
+				[]byte(`{"status":{"errors":["cannot delete backup because backups bar refer to its unchanged items"],"phase":"Processed"}}`),
+			),
+		}
+
+		assert.Equal(t, expectedActions, td.client.Actions())
+	})
+
 	t.Run("full delete, no errors", func(t *testing.T) {
 		backup := builder.ForBackup(v1.DefaultNamespace, "foo").Result()
 		backup.UID = "uid"
@@ -488,8 +555,27 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 					ProviderSnapshotID: "snap-1",
 				},
 			},
+			{
+				Spec: volume.SnapshotSpec{
+					CSIDriver:              "driver-1",
+					CSIVolumeSnapshotClass: "class-1",
+				},
+				Status: volume.SnapshotStatus{
+					ProviderSnapshotID:       "handle-1",
+					CSIVolumeSnapshotContent: "snapcontent-1",
+				},
+			},
 		}
 
+		content := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "snapshot.storage.k8s.io/v1beta1",
+			"kind":       "VolumeSnapshotContent",
+			"metadata":   map[string]interface{}{"name": "snapcontent-1"},
+			"spec":       map[string]interface{}{"deletionPolicy": "Retain"},
+		}}
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), content)
+		td.controller.csiSnapshotter = csi.NewSnapshotter(veleroclient.NewDynamicFactory(dynamicClient), 0)
+
 		pluginManager := &pluginmocks.Manager{}
 		pluginManager.On("GetVolumeSnapshotter", "provider-1").Return(td.volumeSnapshotter, nil)
 		pluginManager.On("CleanupClients")
@@ -563,6 +649,10 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 
 		// Make sure snapshot was deleted
 		assert.Equal(t, 0, td.volumeSnapshotter.SnapshotsTaken.Len())
+
+		// Make sure the CSI snapshot's content was deleted
+		_, err = dynamicClient.Resource(csi.GroupVersion.WithResource("volumesnapshotcontents")).Get("snapcontent-1", metav1.GetOptions{})
+		assert.True(t, apierrors.IsNotFound(err))
 	})
 
 	t.Run("full delete, no errors, with backup name greater than 63 chars", func(t *testing.T) {
@@ -843,6 +933,7 @@ func TestBackupDeletionControllerDeleteExpiredRequests(t *testing.T) {
 				sharedInformers.Velero().V1().DeleteBackupRequests(),
 				client.VeleroV1(), // deleteBackupRequestClient
 				client.VeleroV1(), // backupClient
+				sharedInformers.Velero().V1().Backups(),
 				sharedInformers.Velero().V1().Restores(),
 				client.VeleroV1(), // restoreClient
 				NewBackupTracker(),
@@ -850,6 +941,7 @@ func TestBackupDeletionControllerDeleteExpiredRequests(t *testing.T) {
 				sharedInformers.Velero().V1().PodVolumeBackups(),
 				sharedInformers.Velero().V1().BackupStorageLocations(),
 				sharedInformers.Velero().V1().VolumeSnapshotLocations(),
+				nil, // dynamicFactory
 				nil, // new plugin manager func
 				metrics.NewServerMetrics(),
 			).(*backupDeletionController)
@@ -0,0 +1,282 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+)
+
+const (
+	backupReplicationResyncPeriod = 5 * time.Minute
+
+	// maxBackupReplicaAttempts is the number of times a failed replica is
+	// copied before it's left failed.
+	maxBackupReplicaAttempts = 5
+)
+
+// backupReplicationController copies completed backups from their storage
+// location to each of their replica storage locations.
+type backupReplicationController struct {
+	*genericController
+
+	backupClient         velerov1client.BackupsGetter
+	backupLister         listers.BackupLister
+	backupLocationLister listers.BackupStorageLocationLister
+	newPluginManager     func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore       func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	copyBackup           func(src, dst persistence.BackupStore, name string) error
+
+	clock clock.Clock
+}
+
+// NewBackupReplicationController constructs a new backupReplicationController.
+func NewBackupReplicationController(
+	logger logrus.FieldLogger,
+	backupInformer informers.BackupInformer,
+	backupClient velerov1client.BackupsGetter,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+) Interface {
+	c := &backupReplicationController{
+		genericController:    newGenericController("backup-replication", logger),
+		backupClient:         backupClient,
+		backupLister:         backupInformer.Lister(),
+		backupLocationLister: backupLocationInformer.Lister(),
+		newPluginManager:     newPluginManager,
+		newBackupStore:       persistence.NewBackupStore,
+		copyBackup:           persistence.CopyBackup,
+		clock:                clock.RealClock{},
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters,
+		backupInformer.Informer().HasSynced,
+		backupLocationInformer.Informer().HasSynced,
+	)
+
+	c.resyncPeriod = backupReplicationResyncPeriod
+	c.resyncFunc = c.enqueueAllBackups
+
+	backupInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueue,
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		},
+	)
+
+	return c
+}
+
+// enqueueAllBackups lists all backups from cache and enqueues all of them so that
+// replicas that previously could not be processed are retried. Failed replicas are
+// retried with a backoff, up to maxBackupReplicaAttempts times.
+func (c *backupReplicationController) enqueueAllBackups() {
+	backups, err := c.backupLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("error listing backups")
+		return
+	}
+
+	for _, backup := range backups {
+		c.enqueue(backup)
+	}
+}
+
+func (c *backupReplicationController) processQueueItem(key string) error {
+	log := c.logger.WithField("backup", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	backup, err := c.backupLister.Backups(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find backup")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup")
+	}
+
+	if backup.Status.Phase != velerov1api.BackupPhaseCompleted || len(backup.Spec.ReplicaStorageLocations) == 0 {
+		return nil
+	}
+
+	var pending []string
+	for _, locationName := range backup.Spec.ReplicaStorageLocations {
+		if c.replicaPending(findReplicaStatus(backup, locationName), log.WithField("replicaLocation", locationName)) {
+			pending = append(pending, locationName)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	location, err := c.backupLocationLister.BackupStorageLocations(ns).Get(backup.Spec.StorageLocation)
+	if err != nil {
+		return errors.Wrapf(err, "error getting backup storage location %s", backup.Spec.StorageLocation)
+	}
+
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	srcStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		return err
+	}
+
+	for _, locationName := range pending {
+		replicaLog := log.WithField("replicaLocation", locationName)
+
+		// a failed copy is a new attempt, while an interrupted one is
+		// resumed as the same attempt.
+		attempts := 1
+		if replica := findReplicaStatus(backup, locationName); replica != nil {
+			attempts = replicaAttempts(replica)
+			if replica.Phase == velerov1api.BackupReplicaPhaseFailed {
+				attempts++
+			}
+		}
+
+		updated := backup.DeepCopy()
+		setReplicaStatus(updated, velerov1api.BackupReplicaStatus{
+			StorageLocation: locationName,
+			Phase:           velerov1api.BackupReplicaPhaseInProgress,
+			Attempts:        attempts,
+		})
+		if backup, err = patchBackup(backup, updated, c.backupClient); err != nil {
+			return err
+		}
+
+		replicaLog.Info("Replicating backup")
+
+		status := velerov1api.BackupReplicaStatus{
+			StorageLocation: locationName,
+			Phase:           velerov1api.BackupReplicaPhaseCompleted,
+			Attempts:        attempts,
+		}
+		if err := c.replicate(srcStore, backup, locationName, pluginManager, replicaLog); err != nil {
+			replicaLog.WithError(err).Error("Error replicating backup")
+			status.Phase = velerov1api.BackupReplicaPhaseFailed
+			status.Message = err.Error()
+		}
+		status.CompletionTimestamp = &metav1.Time{Time: c.clock.Now()}
+
+		updated = backup.DeepCopy()
+		setReplicaStatus(updated, status)
+		if backup, err = patchBackup(backup, updated, c.backupClient); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *backupReplicationController) replicate(srcStore persistence.BackupStore, backup *velerov1api.Backup, locationName string, pluginManager clientmgmt.Manager, log logrus.FieldLogger) error {
+	if locationName == backup.Spec.StorageLocation {
+		return errors.Errorf("backup storage location %s is the backup's own storage location", locationName)
+	}
+
+	location, err := c.backupLocationLister.BackupStorageLocations(backup.Namespace).Get(locationName)
+	if err != nil {
+		return errors.Wrapf(err, "error getting backup storage location %s", locationName)
+	}
+
+	if location.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+		return errors.Errorf("backup storage location %s is currently in read-only mode", locationName)
+	}
+
+	dstStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		return err
+	}
+
+	return c.copyBackup(srcStore, dstStore, backup.Name)
+}
+
+// replicaPending returns whether a replica still needs to be copied: it
+// hasn't been copied yet, its copy was interrupted, or its copy failed and
+// is due to be retried. Failed copies are retried after a backoff that
+// doubles with each attempt, starting at the resync period.
+func (c *backupReplicationController) replicaPending(replica *velerov1api.BackupReplicaStatus, log logrus.FieldLogger) bool {
+	if replica == nil || replica.Phase == velerov1api.BackupReplicaPhaseInProgress {
+		return true
+	}
+	if replica.Phase != velerov1api.BackupReplicaPhaseFailed {
+		return false
+	}
+
+	attempts := replicaAttempts(replica)
+	if attempts >= maxBackupReplicaAttempts {
+		log.Debugf("Not retrying failed replica after %d attempts", attempts)
+		return false
+	}
+	if replica.CompletionTimestamp == nil {
+		return true
+	}
+
+	backoff := backupReplicationResyncPeriod << uint(attempts-1)
+	return !c.clock.Now().Before(replica.CompletionTimestamp.Add(backoff))
+}
+
+// replicaAttempts returns the number of times a replica has been copied.
+// Replicas recorded before attempts were counted have been copied once.
+func replicaAttempts(replica *velerov1api.BackupReplicaStatus) int {
+	if replica.Attempts < 1 {
+		return 1
+	}
+	return replica.Attempts
+}
+
+// findReplicaStatus returns the backup's replica status for the named
+// storage location, or nil if there isn't one.
+func findReplicaStatus(backup *velerov1api.Backup, locationName string) *velerov1api.BackupReplicaStatus {
+	for i := range backup.Status.Replicas {
+		if backup.Status.Replicas[i].StorageLocation == locationName {
+			return &backup.Status.Replicas[i]
+		}
+	}
+
+	return nil
+}
+
+// setReplicaStatus adds or replaces the backup's replica status for the
+// status's storage location.
+func setReplicaStatus(backup *velerov1api.Backup, status velerov1api.BackupReplicaStatus) {
+	if existing := findReplicaStatus(backup, status.StorageLocation); existing != nil {
+		*existing = status
+		return
+	}
+
+	backup.Status.Replicas = append(backup.Status.Replicas, status)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestBackupReplicationControllerProcessQueueItem(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	completedAt := &metav1.Time{Time: now}
+
+	tests := []struct {
+		name             string
+		backup           *velerov1api.Backup
+		copyErrors       map[string]error
+		expectedCopies   []string
+		expectedReplicas []velerov1api.BackupReplicaStatus
+	}{
+		{
+			name:   "backup without replica locations is skipped",
+			backup: builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").Phase(velerov1api.BackupPhaseCompleted).Result(),
+		},
+		{
+			name:   "backup that isn't completed is skipped",
+			backup: builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").ReplicaStorageLocations("secondary").Phase(velerov1api.BackupPhaseInProgress).Result(),
+		},
+		{
+			name:           "completed backup is copied to each replica location",
+			backup:         builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").ReplicaStorageLocations("secondary", "tertiary").Phase(velerov1api.BackupPhaseCompleted).Result(),
+			expectedCopies: []string{"secondary", "tertiary"},
+			expectedReplicas: []velerov1api.BackupReplicaStatus{
+				{StorageLocation: "secondary", Phase: velerov1api.BackupReplicaPhaseCompleted, CompletionTimestamp: completedAt, Attempts: 1},
+				{StorageLocation: "tertiary", Phase: velerov1api.BackupReplicaPhaseCompleted, CompletionTimestamp: completedAt, Attempts: 1},
+			},
+		},
+		{
+			name: "replicas that completed or recently failed are not copied again",
+			backup: builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").ReplicaStorageLocations("secondary", "tertiary").Phase(velerov1api.BackupPhaseCompleted).
+				Replicas(
+					velerov1api.BackupReplicaStatus{StorageLocation: "secondary", Phase: velerov1api.BackupReplicaPhaseCompleted, Attempts: 1},
+					velerov1api.BackupReplicaStatus{StorageLocation: "tertiary", Phase: velerov1api.BackupReplicaPhaseFailed, CompletionTimestamp: &metav1.Time{Time: now.Add(-9 * time.Minute)}, Attempts: 2},
+				).Result(),
+		},
+		{
+			name: "failed replica is copied again once its backoff has passed",
+			backup: builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").ReplicaStorageLocations("secondary", "tertiary").Phase(velerov1api.BackupPhaseCompleted).
+				Replicas(
+					velerov1api.BackupReplicaStatus{StorageLocation: "secondary", Phase: velerov1api.BackupReplicaPhaseCompleted, Attempts: 1},
+					velerov1api.BackupReplicaStatus{StorageLocation: "tertiary", Phase: velerov1api.BackupReplicaPhaseFailed, CompletionTimestamp: &metav1.Time{Time: now.Add(-10 * time.Minute)}, Attempts: 2},
+				).Result(),
+			expectedCopies: []string{"tertiary"},
+			expectedReplicas: []velerov1api.BackupReplicaStatus{
+				{StorageLocation: "secondary", Phase: velerov1api.BackupReplicaPhaseCompleted, Attempts: 1},
+				{StorageLocation: "tertiary", Phase: velerov1api.BackupReplicaPhaseCompleted, CompletionTimestamp: completedAt, Attempts: 3},
+			},
+		},
+		{
+			name: "failed replica is not copied again after the maximum attempts",
+			backup: builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").ReplicaStorageLocations("secondary").Phase(velerov1api.BackupPhaseCompleted).
+				Replicas(
+					velerov1api.BackupReplicaStatus{StorageLocation: "secondary", Phase: velerov1api.BackupReplicaPhaseFailed, CompletionTimestamp: &metav1.Time{Time: now.Add(-24 * time.Hour)}, Attempts: maxBackupReplicaAttempts},
+				).Result(),
+		},
+		{
+			name: "failed replica that fails again counts the attempt",
+			backup: builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").ReplicaStorageLocations("secondary").Phase(velerov1api.BackupPhaseCompleted).
+				Replicas(
+					velerov1api.BackupReplicaStatus{StorageLocation: "secondary", Phase: velerov1api.BackupReplicaPhaseFailed, Message: "copy failed", CompletionTimestamp: &metav1.Time{Time: now.Add(-time.Hour)}},
+				).Result(),
+			copyErrors:     map[string]error{"secondary": errors.New("copy failed again")},
+			expectedCopies: []string{"secondary"},
+			expectedReplicas: []velerov1api.BackupReplicaStatus{
+				{StorageLocation: "secondary", Phase: velerov1api.BackupReplicaPhaseFailed, Message: "copy failed again", CompletionTimestamp: completedAt, Attempts: 2},
+			},
+		},
+		{
+			name:           "copy errors, read-only and missing locations fail the replica",
+			backup:         builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").StorageLocation("default").ReplicaStorageLocations("secondary", "read-only", "missing").Phase(velerov1api.BackupPhaseCompleted).Result(),
+			copyErrors:     map[string]error{"secondary": errors.New("copy failed")},
+			expectedCopies: []string{"secondary"},
+			expectedReplicas: []velerov1api.BackupReplicaStatus{
+				{StorageLocation: "secondary", Phase: velerov1api.BackupReplicaPhaseFailed, Message: "copy failed", CompletionTimestamp: completedAt, Attempts: 1},
+				{StorageLocation: "read-only", Phase: velerov1api.BackupReplicaPhaseFailed, Message: "backup storage location read-only is currently in read-only mode", CompletionTimestamp: completedAt, Attempts: 1},
+				{StorageLocation: "missing", Phase: velerov1api.BackupReplicaPhaseFailed, Message: `error getting backup storage location missing: backupstoragelocation.velero.io "missing" not found`, CompletionTimestamp: completedAt, Attempts: 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset(test.backup)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &pluginmocks.Manager{}
+				stores          = map[string]*persistencemocks.BackupStore{}
+			)
+
+			c := NewBackupReplicationController(
+				velerotest.NewLogger(),
+				sharedInformers.Velero().V1().Backups(),
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+			).(*backupReplicationController)
+			c.clock = clock.NewFakeClock(now)
+
+			pluginManager.On("CleanupClients").Return(nil)
+
+			c.newBackupStore = func(location *velerov1api.BackupStorageLocation, _ persistence.ObjectStoreGetter, _ logrus.FieldLogger) (persistence.BackupStore, error) {
+				store := &persistencemocks.BackupStore{}
+				stores[location.Name] = store
+				return store, nil
+			}
+
+			var copies []string
+			c.copyBackup = func(src, dst persistence.BackupStore, name string) error {
+				assert.Equal(t, test.backup.Name, name)
+				assert.True(t, src == stores["default"])
+				for locationName, store := range stores {
+					if dst == store {
+						copies = append(copies, locationName)
+						return test.copyErrors[locationName]
+					}
+				}
+				return errors.New("unexpected destination store")
+			}
+
+			for _, location := range []*velerov1api.BackupStorageLocation{
+				builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result(),
+				builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "secondary").Provider("myCloud").Bucket("bucket-2").Result(),
+				builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "tertiary").Provider("otherCloud").Bucket("bucket-3").Result(),
+				builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "read-only").Provider("myCloud").Bucket("bucket-4").AccessMode(velerov1api.BackupStorageLocationAccessModeReadOnly).Result(),
+			} {
+				require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+			}
+			require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(test.backup))
+
+			require.NoError(t, c.processQueueItem(test.backup.Namespace+"/"+test.backup.Name))
+
+			assert.Equal(t, test.expectedCopies, copies)
+
+			res, err := client.VeleroV1().Backups(test.backup.Namespace).Get(test.backup.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+
+			expected := test.expectedReplicas
+			if expected == nil {
+				expected = test.backup.Status.Replicas
+			}
+			require.Len(t, res.Status.Replicas, len(expected))
+			for i := range expected {
+				assert.Equal(t, expected[i].StorageLocation, res.Status.Replicas[i].StorageLocation)
+				assert.Equal(t, expected[i].Phase, res.Status.Replicas[i].Phase)
+				assert.Equal(t, expected[i].Message, res.Status.Replicas[i].Message)
+				assert.Equal(t, expected[i].Attempts, res.Status.Replicas[i].Attempts)
+				if expected[i].CompletionTimestamp != nil {
+					require.NotNil(t, res.Status.Replicas[i].CompletionTimestamp)
+					assert.True(t, expected[i].CompletionTimestamp.Equal(res.Status.Replicas[i].CompletionTimestamp))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/notifications"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+)
+
+// backupStorageLocationController periodically checks that each backup
+// storage location can be reached, and that no other location, in this
+// cluster or another, writes to the same bucket and prefix, and records the
+// result in the location's status.
+type backupStorageLocationController struct {
+	*genericController
+
+	clusterID            string
+	backupLocationClient velerov1client.BackupStorageLocationsGetter
+	backupLocationLister listers.BackupStorageLocationLister
+	newPluginManager     func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore       func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	notifier             notifications.Notifier
+
+	clock clock.Clock
+}
+
+// NewBackupStorageLocationController constructs a new backupStorageLocationController.
+func NewBackupStorageLocationController(
+	logger logrus.FieldLogger,
+	clusterID string,
+	backupLocationClient velerov1client.BackupStorageLocationsGetter,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+	validationFrequency time.Duration,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+	notifier notifications.Notifier,
+) Interface {
+	if validationFrequency < time.Second {
+		logger.Infof("Provided backup storage location validation frequency %v is too short. Setting to 1 second", validationFrequency)
+		validationFrequency = time.Second
+	}
+
+	c := &backupStorageLocationController{
+		genericController:    newGenericController("backup-storage-location", logger),
+		clusterID:            clusterID,
+		backupLocationClient: backupLocationClient,
+		backupLocationLister: backupLocationInformer.Lister(),
+		newPluginManager:     newPluginManager,
+		newBackupStore:       persistence.NewBackupStore,
+		notifier:             notifier,
+		clock:                clock.RealClock{},
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, backupLocationInformer.Informer().HasSynced)
+
+	c.resyncPeriod = validationFrequency
+	c.resyncFunc = c.enqueueAllLocations
+
+	backupLocationInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueue,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				// status updates made by this controller shouldn't trigger
+				// another validation, so only re-validate on spec changes.
+				oldLocation := oldObj.(*velerov1api.BackupStorageLocation)
+				newLocation := newObj.(*velerov1api.BackupStorageLocation)
+				if !reflect.DeepEqual(oldLocation.Spec, newLocation.Spec) {
+					c.enqueue(newObj)
+				}
+			},
+		},
+	)
+
+	return c
+}
+
+func (c *backupStorageLocationController) enqueueAllLocations() {
+	locations, err := c.backupLocationLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing backup storage locations")
+		return
+	}
+
+	for _, location := range locations {
+		c.enqueue(location)
+	}
+}
+
+func (c *backupStorageLocationController) processQueueItem(key string) error {
+	log := c.logger.WithField("backupLocation", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	location, err := c.backupLocationLister.BackupStorageLocations(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find backup storage location")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup storage location")
+	}
+
+	phase := velerov1api.BackupStorageLocationPhaseAvailable
+	var message string
+	if err := c.validate(location, log); err != nil {
+		log.WithError(err).Warn("Backup storage location is unavailable")
+		phase = velerov1api.BackupStorageLocationPhaseUnavailable
+		message = err.Error()
+
+		// only notify when the location becomes unavailable, rather
+		// than every time it's validated while it stays that way.
+		if location.Status.Phase != phase {
+			c.notifier.Notify(notifications.LocationUnavailableEvent(location, message))
+		}
+	} else if location.Status.Phase != phase {
+		log.Info("Backup storage location is available")
+	}
+
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":              phase,
+			"lastValidationTime": c.clock.Now().UTC(),
+			"message":            message,
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling backup storage location status patch to JSON")
+	}
+
+	if _, err := c.backupLocationClient.BackupStorageLocations(ns).Patch(name, types.MergePatchType, patchBytes); err != nil {
+		return errors.Wrap(err, "error patching backup storage location status")
+	}
+
+	return nil
+}
+
+func (c *backupStorageLocationController) validate(location *velerov1api.BackupStorageLocation, log logrus.FieldLogger) error {
+	if err := c.checkOverlappingLocations(location, log); err != nil {
+		return err
+	}
+
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		return errors.Wrap(err, "error getting backup store")
+	}
+
+	if err := backupStore.IsValid(); err != nil {
+		return err
+	}
+
+	// read-only locations don't write to the backup store, so they can
+	// share it with the location that does.
+	if location.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+		return nil
+	}
+
+	return backupStore.ClaimOwnership(persistence.LocationOwner{
+		ClusterID: c.clusterID,
+		Namespace: location.Namespace,
+		Name:      location.Name,
+	})
+}
+
+// checkOverlappingLocations returns an error if a location stores its data
+// in the same bucket and prefix as, or within the prefix of, an older
+// location, since they'd each sync and garbage-collect the other's backups.
+// Locations that are both read-only only get a warning.
+func (c *backupStorageLocationController) checkOverlappingLocations(location *velerov1api.BackupStorageLocation, log logrus.FieldLogger) error {
+	locations, err := c.backupLocationLister.List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "error listing backup storage locations")
+	}
+
+	for _, other := range locations {
+		if other.Namespace == location.Namespace && other.Name == location.Name {
+			continue
+		}
+		if !persistence.LocationsOverlap(location, other) {
+			continue
+		}
+
+		if location.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly &&
+			other.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+			log.Warnf("Backup storage location overlaps with read-only backup storage location %s/%s", other.Namespace, other.Name)
+			continue
+		}
+
+		if isOlderLocation(other, location) {
+			return errors.Errorf("backup storage location overlaps with backup storage location %s/%s, which uses the same bucket and prefix", other.Namespace, other.Name)
+		}
+		log.Warnf("Backup storage location %s/%s overlaps with this backup storage location, and is unavailable", other.Namespace, other.Name)
+	}
+
+	return nil
+}
+
+// isOlderLocation returns whether location a was created before location b,
+// breaking ties by namespace and name.
+func isOlderLocation(a, b *velerov1api.BackupStorageLocation) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/notifications"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestBackupStorageLocationControllerProcessQueueItem(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newLocation := func(name, prefix string, created time.Time, accessMode velerov1api.BackupStorageLocationAccessMode) *velerov1api.BackupStorageLocation {
+		location := builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, name).Provider("myCloud").Bucket("bucket").Prefix(prefix).AccessMode(accessMode).Result()
+		location.CreationTimestamp = metav1.NewTime(created)
+		return location
+	}
+
+	tests := []struct {
+		name            string
+		location        *velerov1api.BackupStorageLocation
+		otherLocations  []*velerov1api.BackupStorageLocation
+		overlaps        bool
+		newStoreErr     error
+		isValidErr      error
+		claimErr        error
+		expectedPhase   velerov1api.BackupStorageLocationPhase
+		expectedMessage string
+	}{
+		{
+			name:          "valid backup store is available",
+			location:      builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result(),
+			expectedPhase: velerov1api.BackupStorageLocationPhaseAvailable,
+		},
+		{
+			name:            "invalid backup store is unavailable",
+			location:        builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result(),
+			isValidErr:      errors.New("bucket not found"),
+			expectedPhase:   velerov1api.BackupStorageLocationPhaseUnavailable,
+			expectedMessage: "bucket not found",
+		},
+		{
+			name:            "backup store that can't be created is unavailable",
+			location:        builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result(),
+			newStoreErr:     errors.New("plugin not found"),
+			expectedPhase:   velerov1api.BackupStorageLocationPhaseUnavailable,
+			expectedMessage: "error getting backup store: plugin not found",
+		},
+		{
+			name: "message is cleared when location becomes available again",
+			location: func() *velerov1api.BackupStorageLocation {
+				location := builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result()
+				location.Status.Phase = velerov1api.BackupStorageLocationPhaseUnavailable
+				location.Status.Message = "bucket not found"
+				return location
+			}(),
+			expectedPhase: velerov1api.BackupStorageLocationPhaseAvailable,
+		},
+		{
+			name:            "backup store owned by another location is unavailable",
+			location:        builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result(),
+			claimErr:        errors.New("backup store is owned by backup storage location velero/default in cluster cluster-2"),
+			expectedPhase:   velerov1api.BackupStorageLocationPhaseUnavailable,
+			expectedMessage: "backup store is owned by backup storage location velero/default in cluster cluster-2",
+		},
+		{
+			name:          "read-only location doesn't claim ownership of backup store",
+			location:      builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").AccessMode(velerov1api.BackupStorageLocationAccessModeReadOnly).Result(),
+			expectedPhase: velerov1api.BackupStorageLocationPhaseAvailable,
+		},
+		{
+			name:     "location within the prefix of an older location is unavailable",
+			location: newLocation("new", "velero/cluster-1", now, ""),
+			otherLocations: []*velerov1api.BackupStorageLocation{
+				newLocation("old", "velero", now.Add(-time.Hour), ""),
+			},
+			overlaps:        true,
+			expectedPhase:   velerov1api.BackupStorageLocationPhaseUnavailable,
+			expectedMessage: "backup storage location overlaps with backup storage location velero/old, which uses the same bucket and prefix",
+		},
+		{
+			name:     "older location that a newer location overlaps with is available",
+			location: newLocation("old", "velero", now.Add(-time.Hour), ""),
+			otherLocations: []*velerov1api.BackupStorageLocation{
+				newLocation("new", "velero/", now, ""),
+			},
+			expectedPhase: velerov1api.BackupStorageLocationPhaseAvailable,
+		},
+		{
+			name:     "location with a sibling prefix is available",
+			location: newLocation("new", "velero/cluster-2", now, ""),
+			otherLocations: []*velerov1api.BackupStorageLocation{
+				newLocation("old", "velero/cluster-1", now.Add(-time.Hour), ""),
+			},
+			expectedPhase: velerov1api.BackupStorageLocationPhaseAvailable,
+		},
+		{
+			name:     "read-only locations that overlap are available",
+			location: newLocation("new", "velero", now, velerov1api.BackupStorageLocationAccessModeReadOnly),
+			otherLocations: []*velerov1api.BackupStorageLocation{
+				newLocation("old", "velero", now.Add(-time.Hour), velerov1api.BackupStorageLocationAccessModeReadOnly),
+			},
+			expectedPhase: velerov1api.BackupStorageLocationPhaseAvailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset(test.location)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+				notifier        = &fakeNotifier{}
+			)
+
+			c := NewBackupStorageLocationController(
+				velerotest.NewLogger(),
+				"cluster-1",
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				time.Minute,
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+				notifier,
+			).(*backupStorageLocationController)
+			c.clock = clock.NewFakeClock(now)
+
+			c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+				if test.newStoreErr != nil {
+					return nil, test.newStoreErr
+				}
+				return backupStore, nil
+			}
+
+			if !test.overlaps {
+				pluginManager.On("CleanupClients").Return(nil)
+			}
+			if !test.overlaps && test.newStoreErr == nil {
+				backupStore.On("IsValid").Return(test.isValidErr)
+			}
+			if !test.overlaps && test.newStoreErr == nil && test.isValidErr == nil && test.location.Spec.AccessMode != velerov1api.BackupStorageLocationAccessModeReadOnly {
+				backupStore.On("ClaimOwnership", persistence.LocationOwner{
+					ClusterID: "cluster-1",
+					Namespace: test.location.Namespace,
+					Name:      test.location.Name,
+				}).Return(test.claimErr)
+			}
+
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(test.location))
+			for _, location := range test.otherLocations {
+				require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+			}
+
+			require.NoError(t, c.processQueueItem(test.location.Namespace+"/"+test.location.Name))
+
+			res, err := client.VeleroV1().BackupStorageLocations(test.location.Namespace).Get(test.location.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedPhase, res.Status.Phase)
+			assert.Equal(t, test.expectedMessage, res.Status.Message)
+			require.NotNil(t, res.Status.LastValidationTime)
+			assert.True(t, now.Equal(res.Status.LastValidationTime.Time))
+
+			if test.expectedPhase == velerov1api.BackupStorageLocationPhaseUnavailable {
+				require.Len(t, notifier.events, 1)
+				assert.Equal(t, notifications.EventBackupStorageLocationUnavailable, notifier.events[0].Type)
+				assert.Equal(t, test.expectedMessage, notifier.events[0].Message)
+			} else {
+				assert.Empty(t, notifier.events)
+			}
+
+			backupStore.AssertExpectations(t)
+		})
+	}
+}
+
+// fakeNotifier records the events it's notified of.
+type fakeNotifier struct {
+	events []notifications.Event
+}
+
+func (n *fakeNotifier) Notify(event notifications.Event) {
+	n.events = append(n.events, event)
+}
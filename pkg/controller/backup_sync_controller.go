@@ -18,6 +18,7 @@ package controller
 
 import (
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -26,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 
@@ -36,30 +38,48 @@ import (
 	"github.com/heptio/velero/pkg/label"
 	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	"github.com/heptio/velero/pkg/podvolume"
 )
 
+// backupSyncCheckInterval is how often the backup sync controller checks
+// whether any locations are due to be synced, unless the server's sync
+// period is shorter.
+const backupSyncCheckInterval = time.Minute
+
 type backupSyncController struct {
 	*genericController
 
 	backupClient                velerov1client.BackupsGetter
 	backupLocationClient        velerov1client.BackupStorageLocationsGetter
 	podVolumeBackupClient       velerov1client.PodVolumeBackupsGetter
+	resticRepositoryClient      velerov1client.ResticRepositoriesGetter
 	backupLister                listers.BackupLister
 	backupStorageLocationLister listers.BackupStorageLocationLister
 	podVolumeBackupLister       listers.PodVolumeBackupLister
+	resticRepositoryLister      listers.ResticRepositoryLister
 	namespace                   string
 	defaultBackupLocation       string
 	newPluginManager            func(logrus.FieldLogger) clientmgmt.Manager
 	newBackupStore              func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	syncPeriod                  time.Duration
+	clock                       clock.Clock
+
+	// syncLock serializes the syncs of locations that are due, which run
+	// periodically, with the syncs that users request, which are queued.
+	syncLock sync.Mutex
+	// lastChecked is when each location was last checked, by name.
+	lastChecked map[string]time.Time
 }
 
 func NewBackupSyncController(
 	backupClient velerov1client.BackupsGetter,
 	backupLocationClient velerov1client.BackupStorageLocationsGetter,
 	podVolumeBackupClient velerov1client.PodVolumeBackupsGetter,
+	resticRepositoryClient velerov1client.ResticRepositoriesGetter,
 	backupInformer informers.BackupInformer,
 	backupStorageLocationInformer informers.BackupStorageLocationInformer,
 	podVolumeBackupInformer informers.PodVolumeBackupInformer,
+	resticRepositoryInformer informers.ResticRepositoryInformer,
 	syncPeriod time.Duration,
 	namespace string,
 	defaultBackupLocation string,
@@ -76,28 +96,70 @@ func NewBackupSyncController(
 		backupClient:                backupClient,
 		backupLocationClient:        backupLocationClient,
 		podVolumeBackupClient:       podVolumeBackupClient,
+		resticRepositoryClient:      resticRepositoryClient,
 		namespace:                   namespace,
 		defaultBackupLocation:       defaultBackupLocation,
 		backupLister:                backupInformer.Lister(),
 		backupStorageLocationLister: backupStorageLocationInformer.Lister(),
 		podVolumeBackupLister:       podVolumeBackupInformer.Lister(),
+		resticRepositoryLister:      resticRepositoryInformer.Lister(),
+		syncPeriod:                  syncPeriod,
+		clock:                       clock.RealClock{},
+		lastChecked:                 make(map[string]time.Time),
 
 		// use variables to refer to these functions so they can be
 		// replaced with fakes for testing.
 		newPluginManager: newPluginManager,
-		newBackupStore:   persistence.NewObjectBackupStore,
+		newBackupStore:   persistence.NewBackupStore,
 	}
 
+	c.syncHandler = c.processSyncRequest
 	c.resyncFunc = c.run
-	c.resyncPeriod = syncPeriod
+	c.resyncPeriod = backupSyncCheckInterval
+	if syncPeriod > 0 && syncPeriod < c.resyncPeriod {
+		c.resyncPeriod = syncPeriod
+	}
 	c.cacheSyncWaiters = []cache.InformerSynced{
 		backupInformer.Informer().HasSynced,
 		backupStorageLocationInformer.Informer().HasSynced,
+		resticRepositoryInformer.Informer().HasSynced,
 	}
 
+	backupStorageLocationInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) {
+				// process sync requests without waiting for the next check.
+				location := obj.(*velerov1api.BackupStorageLocation)
+				if _, ok := location.Annotations[velerov1api.BackupStorageLocationSyncAnnotation]; ok {
+					c.enqueue(obj)
+				}
+			},
+		},
+	)
+
 	return c
 }
 
+// locationSyncPeriod returns how often the location is checked for
+// backups to sync.
+func (c *backupSyncController) locationSyncPeriod(location *velerov1api.BackupStorageLocation) time.Duration {
+	if location.Spec.SyncPeriod == nil || location.Spec.SyncPeriod.Duration <= 0 {
+		return c.syncPeriod
+	}
+	if location.Spec.SyncPeriod.Duration < backupSyncCheckInterval {
+		return backupSyncCheckInterval
+	}
+	return location.Spec.SyncPeriod.Duration
+}
+
+// isDue returns whether the location's sync period has passed since it was
+// last checked. Checks are resyncPeriod apart, give or take, so a location
+// is due up to half of that early.
+func (c *backupSyncController) isDue(location *velerov1api.BackupStorageLocation, now time.Time) bool {
+	last, ok := c.lastChecked[location.Name]
+	return !ok || now.Sub(last) >= c.locationSyncPeriod(location)-c.resyncPeriod/2
+}
+
 func shouldSync(location *velerov1api.BackupStorageLocation, now time.Time, backupStore persistence.BackupStore, log logrus.FieldLogger) (bool, string) {
 	log = log.WithFields(map[string]interface{}{
 		"lastSyncedRevision": location.Status.LastSyncedRevision,
@@ -161,138 +223,271 @@ func (c *backupSyncController) run() {
 	defer pluginManager.CleanupClients()
 
 	for _, location := range locations {
-		log := c.logger.WithField("backupLocation", location.Name)
+		// locations with a pending sync request are synced regardless of
+		// their sync period.
+		_, requested := location.Annotations[velerov1api.BackupStorageLocationSyncAnnotation]
+		c.syncLocation(location, pluginManager, requested)
+	}
+}
 
-		backupStore, err := c.newBackupStore(location, pluginManager, log)
-		if err != nil {
-			log.WithError(err).Error("Error getting backup store for this location")
+// processSyncRequest syncs a location that a user asked to sync.
+func (c *backupSyncController) processSyncRequest(key string) error {
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+	if ns != c.namespace {
+		return nil
+	}
+
+	location, err := c.backupStorageLocationLister.BackupStorageLocations(ns).Get(name)
+	if kuberrs.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup storage location")
+	}
+	if _, ok := location.Annotations[velerov1api.BackupStorageLocationSyncAnnotation]; !ok {
+		return nil
+	}
+
+	pluginManager := c.newPluginManager(c.logger)
+	defer pluginManager.CleanupClients()
+
+	c.syncLocation(location, pluginManager, true)
+	return nil
+}
+
+// syncLocation syncs the backups in a location's backup store into the
+// cluster, if its sync period has passed and its contents have changed,
+// or regardless of either if force is true.
+func (c *backupSyncController) syncLocation(location *velerov1api.BackupStorageLocation, pluginManager clientmgmt.Manager, force bool) {
+	c.syncLock.Lock()
+	defer c.syncLock.Unlock()
+
+	log := c.logger.WithField("backupLocation", location.Name)
+
+	now := c.clock.Now()
+	if !force && !c.isDue(location, now) {
+		return
+	}
+	c.lastChecked[location.Name] = now
+
+	// if the backup store can't be read from, the location is checked
+	// again at the next check rather than after its sync period.
+	storeUnavailable := false
+	defer func() {
+		if storeUnavailable {
+			delete(c.lastChecked, location.Name)
+		}
+	}()
+
+	backupStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		log.WithError(err).Error("Error getting backup store for this location")
+		storeUnavailable = true
+		return
+	}
+
+	var revision string
+	if force {
+		log.Info("Backup location sync was requested")
+		// a missing revision file is handled the same way as in shouldSync.
+		revision, _ = backupStore.GetRevision()
+	} else {
+		var ok bool
+		if ok, revision = shouldSync(location, now.UTC(), backupStore, log); !ok {
+			return
+		}
+	}
+	log.Info("Syncing contents of backup store into cluster")
+
+	res, err := backupStore.ListBackups()
+	if err != nil {
+		log.WithError(err).Error("Error listing backups in backup store")
+		storeUnavailable = persistence.IsTransient(err)
+		return
+	}
+	backupStoreBackups := sets.NewString(res...)
+	log.WithField("backupCount", len(backupStoreBackups)).Info("Got backups from backup store")
+
+	// if the backup store can't be read from, the rest of the backups
+	// are left for the next sync, which is done even if the revision
+	// hasn't changed.
+
+backups:
+	for backupName := range backupStoreBackups {
+		log = log.WithField("backup", backupName)
+		log.Debug("Checking this backup to see if it needs to be synced into the cluster")
+
+		// use the controller's namespace when getting the backup because that's where we
+		// are syncing backups to, regardless of the namespace of the cloud backup.
+		backup, err := c.backupClient.Backups(c.namespace).Get(backupName, metav1.GetOptions{})
+		if err == nil {
+			log.Debug("Backup already exists in cluster")
+			continue
+		}
+
+		if !kuberrs.IsNotFound(err) {
+			log.WithError(errors.WithStack(err)).Error("Error getting backup from client, proceeding with sync into cluster")
+		}
+
+		backup, err = backupStore.GetBackupMetadata(backupName)
+		switch {
+		case persistence.Is(err, persistence.ErrBackupNotFound):
+			log.Debug("Backup was deleted from backup store after listing backups")
+			continue
+		case persistence.IsTransient(err):
+			log.WithError(errors.WithStack(err)).Error("Error getting backup metadata from backup store, will retry at next sync")
+			storeUnavailable = true
+			break backups
+		case err != nil:
+			log.WithError(errors.WithStack(err)).Error("Error getting backup metadata from backup store")
 			continue
 		}
 
-		ok, revision := shouldSync(location, time.Now().UTC(), backupStore, log)
-		if !ok {
+		backup.Namespace = c.namespace
+		backup.ResourceVersion = ""
+
+		// update the StorageLocation field and label since the name of the location
+		// may be different in this cluster than in the cluster that created the
+		// backup.
+		backup.Spec.StorageLocation = location.Name
+		if backup.Labels == nil {
+			backup.Labels = make(map[string]string)
+		}
+		backup.Labels[velerov1api.StorageLocationLabel] = label.GetValidName(backup.Spec.StorageLocation)
+		// process the regular velero backup
+		backup, err = c.backupClient.Backups(backup.Namespace).Create(backup)
+		switch {
+		case err != nil && kuberrs.IsAlreadyExists(err):
+			log.Debug("Backup already exists in cluster")
 			continue
+		case err != nil && !kuberrs.IsAlreadyExists(err):
+			log.WithError(errors.WithStack(err)).Error("Error syncing backup into cluster")
+			continue
+		default:
+			log.Debug("Synced backup into cluster")
 		}
-		log.Info("Syncing contents of backup store into cluster")
 
-		res, err := backupStore.ListBackups()
+		// process the pod volume backups from object store, if any
+		podVolumeBackups, err := backupStore.GetPodVolumeBackups(backupName)
 		if err != nil {
-			log.WithError(err).Error("Error listing backups in backup store")
+			log.WithError(errors.WithStack(err)).Error("Error getting pod volume backups for this backup from backup store")
+			if persistence.IsTransient(err) {
+				storeUnavailable = true
+				break backups
+			}
 			continue
 		}
-		backupStoreBackups := sets.NewString(res...)
-		log.WithField("backupCount", len(backupStoreBackups)).Info("Got backups from backup store")
-
-		for backupName := range backupStoreBackups {
-			log = log.WithField("backup", backupName)
-			log.Debug("Checking this backup to see if it needs to be synced into the cluster")
-
-			// use the controller's namespace when getting the backup because that's where we
-			// are syncing backups to, regardless of the namespace of the cloud backup.
-			backup, err := c.backupClient.Backups(c.namespace).Get(backupName, metav1.GetOptions{})
-			if err == nil {
-				log.Debug("Backup already exists in cluster")
-				continue
-			}
 
-			if !kuberrs.IsNotFound(err) {
-				log.WithError(errors.WithStack(err)).Error("Error getting backup from client, proceeding with sync into cluster")
+		for _, podVolumeBackup := range podVolumeBackups {
+			log = log.WithField("podVolumeBackup", podVolumeBackup.Name)
+			log.Debug("Checking this pod volume backup to see if it needs to be synced into the cluster")
+
+			for i, ownerRef := range podVolumeBackup.OwnerReferences {
+				if ownerRef.APIVersion == velerov1api.SchemeGroupVersion.String() && ownerRef.Kind == "Backup" && ownerRef.Name == backup.Name {
+					log.WithField("uid", backup.UID).Debugf("Updating pod volume backup's owner reference UID")
+					podVolumeBackup.OwnerReferences[i].UID = backup.UID
+				}
 			}
 
-			backup, err = backupStore.GetBackupMetadata(backupName)
-			if err != nil {
-				log.WithError(errors.WithStack(err)).Error("Error getting backup metadata from backup store")
-				continue
+			if _, ok := podVolumeBackup.Labels[velerov1api.BackupUIDLabel]; ok {
+				podVolumeBackup.Labels[velerov1api.BackupUIDLabel] = string(backup.UID)
 			}
 
-			backup.Namespace = c.namespace
-			backup.ResourceVersion = ""
+			podVolumeBackup.Namespace = backup.Namespace
+			podVolumeBackup.ResourceVersion = ""
 
-			// update the StorageLocation field and label since the name of the location
-			// may be different in this cluster than in the cluster that created the
-			// backup.
-			backup.Spec.StorageLocation = location.Name
-			if backup.Labels == nil {
-				backup.Labels = make(map[string]string)
-			}
-			backup.Labels[velerov1api.StorageLocationLabel] = label.GetValidName(backup.Spec.StorageLocation)
-			// process the regular velero backup
-			backup, err = c.backupClient.Backups(backup.Namespace).Create(backup)
+			_, err = c.podVolumeBackupClient.PodVolumeBackups(backup.Namespace).Create(podVolumeBackup)
 			switch {
 			case err != nil && kuberrs.IsAlreadyExists(err):
-				log.Debug("Backup already exists in cluster")
+				log.Debug("Pod volume backup already exists in cluster")
 				continue
 			case err != nil && !kuberrs.IsAlreadyExists(err):
-				log.WithError(errors.WithStack(err)).Error("Error syncing backup into cluster")
+				log.WithError(errors.WithStack(err)).Error("Error syncing pod volume backup into cluster")
 				continue
 			default:
-				log.Debug("Synced backup into cluster")
-			}
-
-			// process the pod volume backups from object store, if any
-			podVolumeBackups, err := backupStore.GetPodVolumeBackups(backupName)
-			if err != nil {
-				log.WithError(errors.WithStack(err)).Error("Error getting pod volume backups for this backup from backup store")
-				continue
+				log.Debug("Synced pod volume backup into cluster")
 			}
+		}
+	}
 
-			for _, podVolumeBackup := range podVolumeBackups {
-				log = log.WithField("podVolumeBackup", podVolumeBackup.Name)
-				log.Debug("Checking this pod volume backup to see if it needs to be synced into the cluster")
-
-				for i, ownerRef := range podVolumeBackup.OwnerReferences {
-					if ownerRef.APIVersion == velerov1api.SchemeGroupVersion.String() && ownerRef.Kind == "Backup" && ownerRef.Name == backup.Name {
-						log.WithField("uid", backup.UID).Debugf("Updating pod volume backup's owner reference UID")
-						podVolumeBackup.OwnerReferences[i].UID = backup.UID
-					}
-				}
-
-				if _, ok := podVolumeBackup.Labels[velerov1api.BackupUIDLabel]; ok {
-					podVolumeBackup.Labels[velerov1api.BackupUIDLabel] = string(backup.UID)
-				}
+	c.deleteOrphanedBackups(location.Name, backupStoreBackups, log)
 
-				podVolumeBackup.Namespace = backup.Namespace
-				podVolumeBackup.ResourceVersion = ""
-
-				_, err = c.podVolumeBackupClient.PodVolumeBackups(backup.Namespace).Create(podVolumeBackup)
-				switch {
-				case err != nil && kuberrs.IsAlreadyExists(err):
-					log.Debug("Pod volume backup already exists in cluster")
-					continue
-				case err != nil && !kuberrs.IsAlreadyExists(err):
-					log.WithError(errors.WithStack(err)).Error("Error syncing pod volume backup into cluster")
-					continue
-				default:
-					log.Debug("Synced pod volume backup into cluster")
-				}
-			}
+	if !storeUnavailable {
+		if err := c.syncResticRepositories(location, backupStore, log); err != nil {
+			log.WithError(err).Error("Error syncing restic repositories into cluster")
+			storeUnavailable = persistence.IsTransient(err)
 		}
+	}
 
-		c.deleteOrphanedBackups(location.Name, backupStoreBackups, log)
+	if storeUnavailable {
+		return
+	}
 
-		// update the location's status's last-synced fields
-		patch := map[string]interface{}{
-			"status": map[string]interface{}{
-				"lastSyncedTime":     time.Now().UTC(),
-				"lastSyncedRevision": revision,
+	// update the location's status's last-synced fields, and remove its
+	// sync request, if it has one.
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"lastSyncedTime":     c.clock.Now().UTC(),
+			"lastSyncedRevision": revision,
+		},
+	}
+	if _, ok := location.Annotations[velerov1api.BackupStorageLocationSyncAnnotation]; ok {
+		patch["metadata"] = map[string]interface{}{
+			"annotations": map[string]interface{}{
+				velerov1api.BackupStorageLocationSyncAnnotation: nil,
 			},
 		}
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		log.WithError(errors.WithStack(err)).Error("Error marshaling last-synced patch to JSON")
+		return
+	}
+
+	if _, err = c.backupLocationClient.BackupStorageLocations(c.namespace).Patch(
+		location.Name,
+		types.MergePatchType,
+		patchBytes,
+	); err != nil {
+		log.WithError(errors.WithStack(err)).Error("Error patching backup location's last-synced time and revision")
+	}
+}
+
+// syncResticRepositories creates a ResticRepository for each restic
+// repository in the backup store that doesn't have one in the cluster, e.g.
+// because the cluster was re-created, so that the repository is connected
+// to rather than initialized again.
+func (c *backupSyncController) syncResticRepositories(location *velerov1api.BackupStorageLocation, backupStore persistence.BackupStore, log logrus.FieldLogger) error {
+	volumeNamespaces, err := backupStore.ListResticRepositories()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, volumeNamespace := range volumeNamespaces {
+		log := log.WithField("volumeNamespace", volumeNamespace)
 
-		patchBytes, err := json.Marshal(patch)
+		selector := labels.SelectorFromSet(podvolume.RepoLabels(volumeNamespace, location.Name))
+		repos, err := c.resticRepositoryLister.ResticRepositories(c.namespace).List(selector)
 		if err != nil {
-			log.WithError(errors.WithStack(err)).Error("Error marshaling last-synced patch to JSON")
+			return errors.WithStack(err)
+		}
+		if len(repos) > 0 {
+			log.Debug("Restic repository already exists in cluster")
 			continue
 		}
 
-		if _, err = c.backupLocationClient.BackupStorageLocations(c.namespace).Patch(
-			location.Name,
-			types.MergePatchType,
-			patchBytes,
-		); err != nil {
-			log.WithError(errors.WithStack(err)).Error("Error patching backup location's last-synced time and revision")
+		if _, err := c.resticRepositoryClient.ResticRepositories(c.namespace).Create(podvolume.NewRepository(c.namespace, volumeNamespace, location.Name)); err != nil {
+			log.WithError(errors.WithStack(err)).Error("Error syncing restic repository into cluster")
 			continue
 		}
+		log.Info("Synced restic repository into cluster")
 	}
+
+	return nil
 }
 
 func patchStorageLocation(backup *velerov1api.Backup, client velerov1client.BackupInterface, location string) error {
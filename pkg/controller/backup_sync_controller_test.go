@@ -17,6 +17,7 @@ limitations under the License.
 package controller
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -40,6 +41,7 @@ import (
 	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
 	"github.com/heptio/velero/pkg/plugin/clientmgmt"
 	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	"github.com/heptio/velero/pkg/podvolume"
 	velerotest "github.com/heptio/velero/pkg/test"
 )
 
@@ -344,9 +346,11 @@ func TestBackupSyncControllerRun(t *testing.T) {
 				client.VeleroV1(),
 				client.VeleroV1(),
 				client.VeleroV1(),
+				client.VeleroV1(),
 				sharedInformers.Velero().V1().Backups(),
 				sharedInformers.Velero().V1().BackupStorageLocations(),
 				sharedInformers.Velero().V1().PodVolumeBackups(),
+				sharedInformers.Velero().V1().ResticRepositories(),
 				time.Duration(0),
 				test.namespace,
 				"",
@@ -379,6 +383,7 @@ func TestBackupSyncControllerRun(t *testing.T) {
 					backupStore.On("GetPodVolumeBackups", bucket.backup.Name).Return(bucket.podVolumeBackups, nil)
 				}
 				backupStore.On("ListBackups").Return(backupNames, nil)
+				backupStore.On("ListResticRepositories").Return(nil, nil)
 			}
 
 			for _, existingBackup := range test.existingBackups {
@@ -472,6 +477,239 @@ func TestBackupSyncControllerRun(t *testing.T) {
 	}
 }
 
+func TestBackupSyncControllerStoreErrors(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		pluginManager   = &pluginmocks.Manager{}
+		backupStore     = &persistencemocks.BackupStore{}
+		location        = defaultLocationsList("ns-1")[0]
+	)
+
+	c := NewBackupSyncController(
+		client.VeleroV1(),
+		client.VeleroV1(),
+		client.VeleroV1(),
+		client.VeleroV1(),
+		sharedInformers.Velero().V1().Backups(),
+		sharedInformers.Velero().V1().BackupStorageLocations(),
+		sharedInformers.Velero().V1().PodVolumeBackups(),
+		sharedInformers.Velero().V1().ResticRepositories(),
+		time.Duration(0),
+		"ns-1",
+		"",
+		func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+		velerotest.NewLogger(),
+	).(*backupSyncController)
+
+	c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+		return backupStore, nil
+	}
+	fakeClock := clock.NewFakeClock(time.Now())
+	c.clock = fakeClock
+
+	pluginManager.On("CleanupClients").Return(nil)
+	require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+
+	backupStore.On("GetRevision").Return("foo", nil)
+	backupStore.On("ListBackups").Return([]string{"backup-1"}, nil)
+	backupStore.On("ListResticRepositories").Return(nil, nil)
+
+	// a backup that was deleted after listing backups is skipped, and the
+	// location is marked as synced.
+	backupStore.On("GetBackupMetadata", "backup-1").Return(nil, errors.Wrap(persistence.ErrBackupNotFound, "key not found")).Once()
+	c.run()
+
+	var patches int
+	for _, action := range client.Actions() {
+		if action.Matches("patch", "backupstoragelocations") {
+			patches++
+		}
+	}
+	assert.Equal(t, 1, patches)
+
+	// if the backup store is unavailable, the location isn't marked as
+	// synced, so that it's synced again at the next run.
+	client.ClearActions()
+	fakeClock.Step(time.Minute)
+	backupStore.On("GetBackupMetadata", "backup-1").Return(nil, errors.Wrap(persistence.ErrStoreUnavailable, "connection refused")).Once()
+	c.run()
+	backupStore.AssertNumberOfCalls(t, "GetBackupMetadata", 2)
+
+	for _, action := range client.Actions() {
+		assert.False(t, action.Matches("patch", "backupstoragelocations"))
+		assert.False(t, action.Matches("create", "backups"))
+	}
+}
+
+func TestBackupSyncControllerSyncPeriod(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		pluginManager   = &pluginmocks.Manager{}
+		backupStore     = &persistencemocks.BackupStore{}
+		fakeClock       = clock.NewFakeClock(time.Now())
+		location        = defaultLocationsList("ns-1")[0]
+	)
+	location.Spec.SyncPeriod = &metav1.Duration{Duration: 10 * time.Minute}
+
+	c := NewBackupSyncController(
+		client.VeleroV1(),
+		client.VeleroV1(),
+		client.VeleroV1(),
+		client.VeleroV1(),
+		sharedInformers.Velero().V1().Backups(),
+		sharedInformers.Velero().V1().BackupStorageLocations(),
+		sharedInformers.Velero().V1().PodVolumeBackups(),
+		sharedInformers.Velero().V1().ResticRepositories(),
+		time.Minute,
+		"ns-1",
+		"",
+		func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+		velerotest.NewLogger(),
+	).(*backupSyncController)
+
+	c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+		return backupStore, nil
+	}
+	c.clock = fakeClock
+
+	pluginManager.On("CleanupClients").Return(nil)
+	require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+
+	// the revision never matches the location's, so each check syncs.
+	backupStore.On("GetRevision").Return("foo", nil)
+	backupStore.On("ListBackups").Return(nil, nil)
+	backupStore.On("ListResticRepositories").Return(nil, nil)
+
+	c.run()
+	backupStore.AssertNumberOfCalls(t, "ListBackups", 1)
+
+	// the location isn't checked again until its sync period has passed,
+	// even though the server's sync period has.
+	fakeClock.Step(5 * time.Minute)
+	c.run()
+	backupStore.AssertNumberOfCalls(t, "ListBackups", 1)
+
+	fakeClock.Step(5 * time.Minute)
+	c.run()
+	backupStore.AssertNumberOfCalls(t, "ListBackups", 2)
+}
+
+func TestBackupSyncControllerSyncRequest(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		pluginManager   = &pluginmocks.Manager{}
+		backupStore     = &persistencemocks.BackupStore{}
+		now             = time.Now()
+		location        = defaultLocationsList("ns-1")[0]
+	)
+	// the location was just synced, and its contents haven't changed.
+	location.Status.LastSyncedRevision = types.UID("foo")
+	location.Status.LastSyncedTime = metav1.Time{Time: now.Add(-time.Minute)}
+	location.Annotations = map[string]string{velerov1api.BackupStorageLocationSyncAnnotation: now.Format(time.RFC3339)}
+
+	c := NewBackupSyncController(
+		client.VeleroV1(),
+		client.VeleroV1(),
+		client.VeleroV1(),
+		client.VeleroV1(),
+		sharedInformers.Velero().V1().Backups(),
+		sharedInformers.Velero().V1().BackupStorageLocations(),
+		sharedInformers.Velero().V1().PodVolumeBackups(),
+		sharedInformers.Velero().V1().ResticRepositories(),
+		time.Minute,
+		"ns-1",
+		"",
+		func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+		velerotest.NewLogger(),
+	).(*backupSyncController)
+
+	c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+		return backupStore, nil
+	}
+	c.clock = clock.NewFakeClock(now)
+	c.lastChecked[location.Name] = now
+
+	pluginManager.On("CleanupClients").Return(nil)
+	require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+
+	backupStore.On("GetRevision").Return("foo", nil)
+	backupStore.On("ListBackups").Return(nil, nil)
+	backupStore.On("ListResticRepositories").Return(nil, nil)
+
+	require.NoError(t, c.processSyncRequest("ns-1/"+location.Name))
+	backupStore.AssertNumberOfCalls(t, "ListBackups", 1)
+
+	// the annotation is removed when the location's status is patched.
+	var patches []map[string]interface{}
+	for _, action := range client.Actions() {
+		if patchAction, ok := action.(core.PatchAction); ok {
+			patch := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(patchAction.GetPatch(), &patch))
+			patches = append(patches, patch)
+		}
+	}
+	require.Len(t, patches, 1)
+	assert.Equal(t, map[string]interface{}{velerov1api.BackupStorageLocationSyncAnnotation: nil}, patches[0]["metadata"].(map[string]interface{})["annotations"])
+	assert.Equal(t, "foo", patches[0]["status"].(map[string]interface{})["lastSyncedRevision"])
+}
+
+func TestBackupSyncControllerResticRepositories(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		pluginManager   = &pluginmocks.Manager{}
+		backupStore     = &persistencemocks.BackupStore{}
+		location        = defaultLocationsList("ns-1")[0]
+	)
+
+	c := NewBackupSyncController(
+		client.VeleroV1(),
+		client.VeleroV1(),
+		client.VeleroV1(),
+		client.VeleroV1(),
+		sharedInformers.Velero().V1().Backups(),
+		sharedInformers.Velero().V1().BackupStorageLocations(),
+		sharedInformers.Velero().V1().PodVolumeBackups(),
+		sharedInformers.Velero().V1().ResticRepositories(),
+		time.Duration(0),
+		"ns-1",
+		"",
+		func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+		velerotest.NewLogger(),
+	).(*backupSyncController)
+
+	c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+		return backupStore, nil
+	}
+
+	pluginManager.On("CleanupClients").Return(nil)
+	require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+
+	// the repository for app-1 is already in the cluster, so only the one
+	// for app-2 is created.
+	existing := podvolume.NewRepository("ns-1", "app-1", location.Name)
+	existing.Name = "app-1-location-1-abcde"
+	require.NoError(t, sharedInformers.Velero().V1().ResticRepositories().Informer().GetStore().Add(existing))
+
+	backupStore.On("GetRevision").Return("foo", nil)
+	backupStore.On("ListBackups").Return([]string{}, nil)
+	backupStore.On("ListResticRepositories").Return([]string{"app-1", "app-2"}, nil)
+
+	c.run()
+
+	var created []*velerov1api.ResticRepository
+	for _, action := range client.Actions() {
+		if action.Matches("create", "resticrepositories") {
+			created = append(created, action.(core.CreateAction).GetObject().(*velerov1api.ResticRepository))
+		}
+	}
+	require.Len(t, created, 1)
+	assert.Equal(t, podvolume.NewRepository("ns-1", "app-2", location.Name), created[0])
+}
+
 func TestDeleteOrphanedBackups(t *testing.T) {
 	baseBuilder := func(name string) *builder.BackupBuilder {
 		return builder.ForBackup("ns-1", name).ObjectMeta(builder.WithLabels(velerov1api.StorageLocationLabel, "default"))
@@ -570,9 +808,11 @@ func TestDeleteOrphanedBackups(t *testing.T) {
 				client.VeleroV1(),
 				client.VeleroV1(),
 				client.VeleroV1(),
+				client.VeleroV1(),
 				sharedInformers.Velero().V1().Backups(),
 				sharedInformers.Velero().V1().BackupStorageLocations(),
 				sharedInformers.Velero().V1().PodVolumeBackups(),
+				sharedInformers.Velero().V1().ResticRepositories(),
 				time.Duration(0),
 				test.namespace,
 				"",
@@ -662,9 +902,11 @@ func TestStorageLabelsInDeleteOrphanedBackups(t *testing.T) {
 				client.VeleroV1(),
 				client.VeleroV1(),
 				client.VeleroV1(),
+				client.VeleroV1(),
 				sharedInformers.Velero().V1().Backups(),
 				sharedInformers.Velero().V1().BackupStorageLocations(),
 				sharedInformers.Velero().V1().PodVolumeBackups(),
+				sharedInformers.Velero().V1().ResticRepositories(),
 				time.Duration(0),
 				test.namespace,
 				"",
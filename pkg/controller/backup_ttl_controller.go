@@ -0,0 +1,211 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+)
+
+const (
+	backupTTLSyncPeriod = time.Hour
+
+	// backupTTLTolerance is how far a backup's expiration can be from its
+	// start time plus its TTL before it's updated. The expiration is
+	// calculated from the time the backup is prepared, just before it's
+	// started.
+	backupTTLTolerance = time.Minute
+)
+
+// backupTTLController updates the expiration of a finished backup, both
+// in the cluster and in its metadata file in object storage, when its TTL
+// is changed, for example to keep a routine backup as a long-term archive.
+type backupTTLController struct {
+	*genericController
+
+	backupClient         velerov1client.BackupsGetter
+	backupLister         listers.BackupLister
+	backupLocationLister listers.BackupStorageLocationLister
+	newPluginManager     func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore       func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+}
+
+// NewBackupTTLController constructs a new backupTTLController.
+func NewBackupTTLController(
+	logger logrus.FieldLogger,
+	backupClient velerov1client.BackupsGetter,
+	backupInformer informers.BackupInformer,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+) Interface {
+	c := &backupTTLController{
+		genericController:    newGenericController("backup-ttl", logger),
+		backupClient:         backupClient,
+		backupLister:         backupInformer.Lister(),
+		backupLocationLister: backupLocationInformer.Lister(),
+		newPluginManager:     newPluginManager,
+		newBackupStore:       persistence.NewBackupStore,
+	}
+
+	c.syncHandler = c.processBackup
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters,
+		backupInformer.Informer().HasSynced,
+		backupLocationInformer.Informer().HasSynced,
+	)
+
+	c.resyncPeriod = backupTTLSyncPeriod
+	c.resyncFunc = c.enqueueAllBackups
+
+	backupInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) {
+				if _, changed := desiredExpiration(obj.(*velerov1api.Backup)); changed {
+					c.enqueue(obj)
+				}
+			},
+		},
+	)
+
+	return c
+}
+
+func (c *backupTTLController) enqueueAllBackups() {
+	backups, err := c.backupLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing backups")
+		return
+	}
+
+	for _, backup := range backups {
+		if _, changed := desiredExpiration(backup); changed {
+			c.enqueue(backup)
+		}
+	}
+}
+
+// desiredExpiration returns a finished backup's start time plus its TTL,
+// and whether that's different from its expiration.
+func desiredExpiration(backup *velerov1api.Backup) (time.Time, bool) {
+	switch backup.Status.Phase {
+	case velerov1api.BackupPhaseCompleted, velerov1api.BackupPhasePartiallyFailed, velerov1api.BackupPhaseFailed:
+	default:
+		return time.Time{}, false
+	}
+
+	if backup.Status.StartTimestamp.IsZero() || backup.Spec.TTL.Duration <= 0 {
+		return time.Time{}, false
+	}
+
+	desired := backup.Status.StartTimestamp.Add(backup.Spec.TTL.Duration)
+	diff := desired.Sub(backup.Status.Expiration.Time)
+	return desired, diff > backupTTLTolerance || diff < -backupTTLTolerance
+}
+
+func (c *backupTTLController) processBackup(key string) error {
+	log := c.logger.WithField("backup", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	original, err := c.backupLister.Backups(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find backup")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup")
+	}
+
+	expiration, changed := desiredExpiration(original)
+	if !changed {
+		return nil
+	}
+
+	log = log.WithFields(logrus.Fields{
+		"ttl":        original.Spec.TTL.Duration,
+		"expiration": expiration,
+	})
+
+	location, err := c.backupLocationLister.BackupStorageLocations(ns).Get(original.Spec.StorageLocation)
+	if err != nil {
+		return errors.Wrapf(err, "error getting backup storage location %s", original.Spec.StorageLocation)
+	}
+
+	if location.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+		log.Infof("Backup's expiration cannot be updated because backup storage location %s is currently in read-only mode", location.Name)
+		return nil
+	}
+
+	backup := original.DeepCopy()
+	backup.Status.Expiration = metav1.NewTime(expiration)
+
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		return errors.Wrap(err, "error getting backup store")
+	}
+
+	var bucket string
+	if location.Spec.ObjectStorage != nil {
+		bucket = location.Spec.ObjectStorage.Bucket
+	}
+
+	exists, err := backupStore.BackupExists(bucket, backup.Name)
+	if err != nil {
+		return errors.Wrap(err, "error checking if backup's metadata file exists")
+	}
+	// failed backups may not have a metadata file.
+	if exists {
+		if err := backupStore.PutBackupMetadata(backup); err != nil {
+			return errors.Wrap(err, "error updating backup's metadata file")
+		}
+	}
+
+	// the files of a delete-protected backup are locked until it expires, so
+	// extend the lock along with the expiration. Locks can't be shortened.
+	if locked := backup.Status.ObjectLockedUntil; locked != nil && exists && expiration.After(locked.Time) {
+		if err := backupStore.LockBackup(backup.Name, expiration); err != nil {
+			log.WithError(err).Warn("Error extending the lock on backup's files in object storage")
+		} else {
+			backup.Status.ObjectLockedUntil = &metav1.Time{Time: expiration}
+		}
+	}
+
+	log.Info("Updating backup's expiration to match its TTL")
+	if _, err := patchBackup(original, backup, c.backupClient); err != nil {
+		return errors.Wrap(err, "error updating backup's expiration")
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestBackupTTLControllerProcessBackup(t *testing.T) {
+	var (
+		start    = time.Date(2019, 7, 1, 1, 0, 0, 0, time.UTC)
+		archive  = 90 * 24 * time.Hour
+		extended = start.Add(archive)
+	)
+
+	// a backup whose TTL was changed from 30 days to 90 days.
+	extendedBackup := func() *builder.BackupBuilder {
+		return builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").
+			StorageLocation("default").
+			Phase(velerov1api.BackupPhaseCompleted).
+			StartTimestamp(start).
+			TTL(archive).
+			Expiration(start.Add(30 * 24 * time.Hour))
+	}
+
+	lockedBackup := extendedBackup().DeleteProtection(true).Result()
+	lockedBackup.Status.ObjectLockedUntil = &metav1.Time{Time: start.Add(30 * 24 * time.Hour)}
+
+	tests := []struct {
+		name             string
+		backup           *velerov1api.Backup
+		readOnly         bool
+		metadataExists   bool
+		expectUpdate     bool
+		expectLockedTime *time.Time
+	}{
+		{
+			name:   "backup whose expiration matches its TTL isn't updated",
+			backup: extendedBackup().Expiration(extended).Result(),
+		},
+		{
+			name:   "backup whose expiration is within the tolerance isn't updated",
+			backup: extendedBackup().Expiration(extended.Add(-time.Second)).Result(),
+		},
+		{
+			name:   "backup that's in progress isn't updated",
+			backup: extendedBackup().Phase(velerov1api.BackupPhaseInProgress).Result(),
+		},
+		{
+			name:     "backup in a read-only location isn't updated",
+			backup:   extendedBackup().Result(),
+			readOnly: true,
+		},
+		{
+			name:           "backup whose TTL changed is updated in the cluster and object storage",
+			backup:         extendedBackup().Result(),
+			metadataExists: true,
+			expectUpdate:   true,
+		},
+		{
+			name:         "failed backup without a metadata file is only updated in the cluster",
+			backup:       extendedBackup().Phase(velerov1api.BackupPhaseFailed).Result(),
+			expectUpdate: true,
+		},
+		{
+			name:             "lock on a delete-protected backup's files is extended",
+			backup:           lockedBackup,
+			metadataExists:   true,
+			expectUpdate:     true,
+			expectLockedTime: &extended,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset(test.backup)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+				location        = builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket")
+			)
+			if test.readOnly {
+				location.AccessMode(velerov1api.BackupStorageLocationAccessModeReadOnly)
+			}
+
+			c := NewBackupTTLController(
+				velerotest.NewLogger(),
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+			).(*backupTTLController)
+			c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+				return backupStore, nil
+			}
+
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location.Result()))
+			require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(test.backup))
+
+			pluginManager.On("CleanupClients").Return(nil)
+			backupStore.On("BackupExists", "bucket", "backup-1").Return(test.metadataExists, nil)
+			backupStore.On("PutBackupMetadata", mock.Anything).Return(nil)
+			backupStore.On("LockBackup", "backup-1", mock.Anything).Return(nil)
+
+			require.NoError(t, c.processBackup(velerov1api.DefaultNamespace+"/backup-1"))
+
+			if !test.expectUpdate {
+				assert.Empty(t, client.Actions())
+				backupStore.AssertNotCalled(t, "PutBackupMetadata", mock.Anything)
+				return
+			}
+
+			if test.metadataExists {
+				backupStore.AssertCalled(t, "PutBackupMetadata", mock.MatchedBy(func(backup *velerov1api.Backup) bool {
+					return backup.Status.Expiration.Time.Equal(extended)
+				}))
+			} else {
+				backupStore.AssertNotCalled(t, "PutBackupMetadata", mock.Anything)
+			}
+
+			if test.expectLockedTime != nil {
+				backupStore.AssertCalled(t, "LockBackup", "backup-1", *test.expectLockedTime)
+			} else {
+				backupStore.AssertNotCalled(t, "LockBackup", mock.Anything, mock.Anything)
+			}
+
+			res, err := client.VeleroV1().Backups(velerov1api.DefaultNamespace).Get("backup-1", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.True(t, res.Status.Expiration.Time.Equal(extended))
+			if test.expectLockedTime != nil {
+				require.NotNil(t, res.Status.ObjectLockedUntil)
+				assert.True(t, res.Status.ObjectLockedUntil.Time.Equal(*test.expectLockedTime))
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/label"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	"github.com/heptio/velero/pkg/util/boolptr"
+)
+
+const backupVerificationSyncPeriod = time.Hour
+
+// backupVerificationController verifies the backups of schedules with a
+// verification policy, once they've completed, by restoring part of them
+// with a dry-run or scratch-namespace restore, and records the outcome in
+// the backups' Verified condition.
+type backupVerificationController struct {
+	*genericController
+
+	backupClient         velerov1client.BackupsGetter
+	backupLister         listers.BackupLister
+	scheduleLister       listers.ScheduleLister
+	restoreClient        velerov1client.RestoresGetter
+	restoreLister        listers.RestoreLister
+	namespaceClient      corev1client.NamespacesGetter
+	backupLocationLister listers.BackupStorageLocationLister
+	newPluginManager     func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore       func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	clock                clock.Clock
+	rand                 *rand.Rand
+}
+
+// NewBackupVerificationController constructs a new backupVerificationController.
+func NewBackupVerificationController(
+	logger logrus.FieldLogger,
+	backupClient velerov1client.BackupsGetter,
+	backupInformer informers.BackupInformer,
+	scheduleInformer informers.ScheduleInformer,
+	restoreClient velerov1client.RestoresGetter,
+	restoreInformer informers.RestoreInformer,
+	namespaceClient corev1client.NamespacesGetter,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+) Interface {
+	c := &backupVerificationController{
+		genericController:    newGenericController("backup-verification", logger),
+		backupClient:         backupClient,
+		backupLister:         backupInformer.Lister(),
+		scheduleLister:       scheduleInformer.Lister(),
+		restoreClient:        restoreClient,
+		restoreLister:        restoreInformer.Lister(),
+		namespaceClient:      namespaceClient,
+		backupLocationLister: backupLocationInformer.Lister(),
+		newPluginManager:     newPluginManager,
+		newBackupStore:       persistence.NewBackupStore,
+		clock:                clock.RealClock{},
+		rand:                 rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	c.syncHandler = c.processBackup
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters,
+		backupInformer.Informer().HasSynced,
+		scheduleInformer.Informer().HasSynced,
+		restoreInformer.Informer().HasSynced,
+		backupLocationInformer.Informer().HasSynced,
+	)
+
+	c.resyncPeriod = backupVerificationSyncPeriod
+	c.resyncFunc = c.enqueueUnverifiedBackups
+
+	// only backups that finish while the server is running are verified,
+	// so that turning verification on doesn't restore every backup the
+	// schedule already has.
+	backupInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldBackup, backup := oldObj.(*velerov1api.Backup), newObj.(*velerov1api.Backup)
+				if backup.Labels[velerov1api.ScheduleNameLabel] == "" || isVerifiable(oldBackup) || !isVerifiable(backup) {
+					return
+				}
+				c.enqueue(backup)
+			},
+		},
+	)
+
+	restoreInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) {
+				restore := obj.(*velerov1api.Restore)
+				if _, ok := restore.Labels[velerov1api.VerifiedBackupLabel]; ok {
+					c.queue.Add(restore.Namespace + "/" + restore.Spec.BackupName)
+				}
+			},
+		},
+	)
+
+	return c
+}
+
+// isVerifiable returns true if the backup has finished running and can be
+// restored.
+func isVerifiable(backup *velerov1api.Backup) bool {
+	return backup.Status.Phase == velerov1api.BackupPhaseCompleted || backup.Status.Phase == velerov1api.BackupPhasePartiallyFailed
+}
+
+// enqueueUnverifiedBackups enqueues the backups whose verification has
+// started but hasn't finished, in case an update to their restore was
+// missed.
+func (c *backupVerificationController) enqueueUnverifiedBackups() {
+	backups, err := c.backupLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing backups")
+		return
+	}
+
+	for _, backup := range backups {
+		if condition := verifiedCondition(backup); condition != nil && condition.Status == corev1api.ConditionUnknown {
+			c.enqueue(backup)
+		}
+	}
+}
+
+func verifiedCondition(backup *velerov1api.Backup) *velerov1api.Condition {
+	for i := range backup.Status.Conditions {
+		if backup.Status.Conditions[i].Type == velerov1api.ConditionVerified {
+			return &backup.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// verificationRestoreName returns the name of the restore that verifies
+// the named backup.
+func verificationRestoreName(backup string) string {
+	return backup + "-verification"
+}
+
+// scratchNamespace returns the name of the temporary namespace that a
+// backup's namespace is restored into to verify the backup.
+func scratchNamespace(backup, namespace string) string {
+	hash := sha256.Sum256([]byte(backup + "/" + namespace))
+	return "velero-verify-" + hex.EncodeToString(hash[:])[:12]
+}
+
+func (c *backupVerificationController) processBackup(key string) error {
+	log := c.logger.WithField("backup", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	original, err := c.backupLister.Backups(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find backup")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup")
+	}
+
+	if !isVerifiable(original) {
+		return nil
+	}
+
+	if condition := verifiedCondition(original); condition != nil && condition.Status != corev1api.ConditionUnknown {
+		return nil
+	}
+
+	scheduleName := original.Labels[velerov1api.ScheduleNameLabel]
+	if scheduleName == "" {
+		return nil
+	}
+	schedule, err := c.scheduleLister.Schedules(ns).Get(scheduleName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup's schedule")
+	}
+	if schedule.Spec.Verification == nil {
+		return nil
+	}
+
+	backup := original.DeepCopy()
+	now := c.clock.Now()
+
+	restoreName := verificationRestoreName(backup.Name)
+	restore, err := c.restoreLister.Restores(ns).Get(restoreName)
+	switch {
+	case apierrors.IsNotFound(err):
+		restore, err := c.verificationRestore(backup, schedule.Spec.Verification, log)
+		if err != nil {
+			return err
+		}
+
+		if restore == nil {
+			log.Info("Backup has no namespaced items, so there's nothing to verify")
+			backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionVerified, corev1api.ConditionTrue, "NothingToRestore", "Backup has no namespaced items to restore", now)
+			break
+		}
+
+		log.WithField("restore", restore.Name).Info("Creating restore to verify backup")
+		if _, err := c.restoreClient.Restores(ns).Create(restore); err != nil && !apierrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, "error creating restore to verify backup")
+		}
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionVerified, corev1api.ConditionUnknown, "InProgress", fmt.Sprintf("Restore %s is verifying the backup", restore.Name), now)
+	case err != nil:
+		return errors.Wrap(err, "error getting verification restore")
+	default:
+		var status corev1api.ConditionStatus
+		var reason, message string
+
+		switch restore.Status.Phase {
+		case velerov1api.RestorePhaseCompleted:
+			status, reason, message = corev1api.ConditionTrue, "Verified", fmt.Sprintf("Restore %s completed", restore.Name)
+		case velerov1api.RestorePhasePartiallyFailed:
+			status, reason, message = corev1api.ConditionFalse, "VerificationFailed", fmt.Sprintf("Restore %s partially failed with %d errors", restore.Name, restore.Status.Errors)
+		case velerov1api.RestorePhaseFailed:
+			status, reason, message = corev1api.ConditionFalse, "VerificationFailed", fmt.Sprintf("Restore %s failed: %s", restore.Name, restore.Status.FailureReason)
+		case velerov1api.RestorePhaseFailedValidation:
+			status, reason, message = corev1api.ConditionFalse, "VerificationFailed", fmt.Sprintf("Restore %s failed validation: %s", restore.Name, strings.Join(restore.Status.ValidationErrors, "; "))
+		default:
+			// the restore is still running.
+			return nil
+		}
+
+		if err := c.deleteScratchNamespaces(restore, log); err != nil {
+			return err
+		}
+
+		if status == corev1api.ConditionTrue {
+			log.Info("Backup verified")
+		} else {
+			log.WithField("restore", restore.Name).Warn("Backup failed verification")
+		}
+		backup.Status.Conditions = setCondition(backup.Status.Conditions, velerov1api.ConditionVerified, status, reason, message, now)
+	}
+
+	if _, err := patchBackup(original, backup, c.backupClient); err != nil {
+		return errors.Wrap(err, "error updating backup's Verified condition")
+	}
+
+	return nil
+}
+
+// verificationRestore returns the restore that verifies the backup. It
+// returns nil if the backup has to be restored into scratch namespaces
+// but has no namespaced items.
+func (c *backupVerificationController) verificationRestore(backup *velerov1api.Backup, verification *velerov1api.ScheduleVerification, log logrus.FieldLogger) (*velerov1api.Restore, error) {
+	restore := &velerov1api.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: backup.Namespace,
+			Name:      verificationRestoreName(backup.Name),
+			Labels: map[string]string{
+				velerov1api.VerifiedBackupLabel: label.GetValidName(backup.Name),
+			},
+		},
+		Spec: velerov1api.RestoreSpec{
+			BackupName: backup.Name,
+		},
+	}
+
+	scratch := verification.Mode == velerov1api.ScheduleVerificationModeScratchNamespace
+	if !scratch && verification.SampleNamespaces == 0 {
+		restore.Spec.DryRun = true
+		return restore, nil
+	}
+
+	namespaces, err := c.backupNamespaces(backup, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if n := verification.SampleNamespaces; n > 0 && n < len(namespaces) {
+		c.rand.Shuffle(len(namespaces), func(i, j int) { namespaces[i], namespaces[j] = namespaces[j], namespaces[i] })
+		namespaces = namespaces[:n]
+		sort.Strings(namespaces)
+	}
+	restore.Spec.IncludedNamespaces = namespaces
+
+	if !scratch {
+		restore.Spec.DryRun = true
+		return restore, nil
+	}
+
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	// cluster-scoped items and volumes would be restored into the cluster
+	// itself rather than the scratch namespaces, and pods would wait for
+	// volumes that aren't restored.
+	restore.Spec.IncludeClusterResources = boolptr.False()
+	restore.Spec.RestorePVs = boolptr.False()
+	restore.Spec.ExcludedResources = []string{"pods", "persistentvolumeclaims"}
+	restore.Spec.NamespaceMapping = make(map[string]string)
+	for _, namespace := range namespaces {
+		restore.Spec.NamespaceMapping[namespace] = scratchNamespace(backup.Name, namespace)
+	}
+
+	return restore, nil
+}
+
+// backupNamespaces returns the sorted namespaces of the backup's items,
+// read from its resource list in object storage.
+func (c *backupVerificationController) backupNamespaces(backup *velerov1api.Backup, log logrus.FieldLogger) ([]string, error) {
+	location, err := c.backupLocationLister.BackupStorageLocations(backup.Namespace).Get(backup.Spec.StorageLocation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting backup storage location %s", backup.Spec.StorageLocation)
+	}
+
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting backup store")
+	}
+
+	return resourceListNamespaces(backupStore, backup.Name)
+}
+
+// resourceListNamespaces returns the sorted namespaces of the named
+// backup's items, read from its resource list in the backup store.
+func resourceListNamespaces(backupStore persistence.BackupStore, backupName string) ([]string, error) {
+	rc, err := backupStore.GetDownload(velerov1api.DownloadTarget{Kind: velerov1api.DownloadTargetKindBackupResourceList, Name: backupName})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting backup's resource list")
+	}
+	defer rc.Close()
+
+	gzr, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading backup's resource list")
+	}
+	defer gzr.Close()
+
+	var resourceList map[string][]string
+	if err := json.NewDecoder(gzr).Decode(&resourceList); err != nil {
+		return nil, errors.Wrap(err, "error decoding backup's resource list")
+	}
+
+	namespaces := sets.NewString()
+	for _, items := range resourceList {
+		for _, item := range items {
+			if i := strings.Index(item, "/"); i > 0 {
+				namespaces.Insert(item[:i])
+			}
+		}
+	}
+
+	return namespaces.List(), nil
+}
+
+// deleteScratchNamespaces deletes the namespaces that a scratch-namespace
+// verification restore restored into.
+func (c *backupVerificationController) deleteScratchNamespaces(restore *velerov1api.Restore, log logrus.FieldLogger) error {
+	for _, namespace := range restore.Spec.NamespaceMapping {
+		log.WithField("namespace", namespace).Info("Deleting scratch namespace of verification restore")
+		if err := c.namespaceClient.Namespaces().Delete(namespace, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "error deleting scratch namespace %s", namespace)
+		}
+	}
+	return nil
+}
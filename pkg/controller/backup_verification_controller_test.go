@@ -0,0 +1,279 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func gzippedResourceList(t *testing.T, resourceList map[string][]string) []byte {
+	buf := new(bytes.Buffer)
+	gzw := gzip.NewWriter(buf)
+	require.NoError(t, json.NewEncoder(gzw).Encode(resourceList))
+	require.NoError(t, gzw.Close())
+	return buf.Bytes()
+}
+
+func TestBackupVerificationControllerProcessBackup(t *testing.T) {
+	now := time.Date(2019, 7, 1, 2, 0, 0, 0, time.UTC)
+
+	completedBackup := func() *builder.BackupBuilder {
+		return scheduledBackup(time.Date(2019, 7, 1, 1, 0, 0, 0, time.UTC))
+	}
+	backupName := completedBackup().Result().Name
+	restoreName := verificationRestoreName(backupName)
+
+	schedule := func(verification *velerov1api.ScheduleVerification) *velerov1api.Schedule {
+		b := builder.ForSchedule(velerov1api.DefaultNamespace, "schedule-1").Phase(velerov1api.SchedulePhaseEnabled)
+		if verification != nil {
+			b.Verification(*verification)
+		}
+		return b.Result()
+	}
+
+	verificationRestore := func() *builder.RestoreBuilder {
+		return builder.ForRestore(velerov1api.DefaultNamespace, restoreName).
+			ObjectMeta(builder.WithLabels(velerov1api.VerifiedBackupLabel, backupName)).
+			Backup(backupName)
+	}
+
+	inProgress := []velerov1api.Condition{{Type: velerov1api.ConditionVerified, Status: corev1api.ConditionUnknown, Reason: "InProgress"}}
+
+	resourceList := map[string][]string{
+		"v1/ConfigMap":             {"ns-1/cm-1", "ns-2/cm-1"},
+		"v1/Namespace":             {"ns-1", "ns-2"},
+		"apps/v1/Deployment":       {"ns-2/deploy-1"},
+		"v1/PersistentVolume":      {"pv-1"},
+		"rbac/v1/ClusterRole":      {"role-1"},
+		"v1/PersistentVolumeClaim": {"ns-1/pvc-1"},
+	}
+
+	tests := []struct {
+		name               string
+		backup             *velerov1api.Backup
+		schedule           *velerov1api.Schedule
+		restore            *velerov1api.Restore
+		resourceList       map[string][]string
+		expectRestore      func(*velerov1api.Restore)
+		expectCondition    *velerov1api.Condition
+		expectNamespaceDel []string
+	}{
+		{
+			name:     "backup of a schedule without a verification policy isn't verified",
+			backup:   completedBackup().Result(),
+			schedule: schedule(nil),
+		},
+		{
+			name:     "backup that's in progress isn't verified",
+			backup:   completedBackup().Phase(velerov1api.BackupPhaseInProgress).Result(),
+			schedule: schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeDryRun}),
+		},
+		{
+			name: "backup that's already been verified isn't verified again",
+			backup: func() *velerov1api.Backup {
+				b := completedBackup().Result()
+				b.Status.Conditions = []velerov1api.Condition{{Type: velerov1api.ConditionVerified, Status: corev1api.ConditionTrue, Reason: "Verified"}}
+				return b
+			}(),
+			schedule: schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeDryRun}),
+		},
+		{
+			name:     "dry-run verification restores the whole backup",
+			backup:   completedBackup().Result(),
+			schedule: schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeDryRun}),
+			expectRestore: func(restore *velerov1api.Restore) {
+				assert.True(t, restore.Spec.DryRun)
+				assert.Empty(t, restore.Spec.IncludedNamespaces)
+				assert.Empty(t, restore.Spec.NamespaceMapping)
+			},
+			expectCondition: &velerov1api.Condition{Type: velerov1api.ConditionVerified, Status: corev1api.ConditionUnknown, Reason: "InProgress"},
+		},
+		{
+			name:         "dry-run verification restores a sample of the backup's namespaces",
+			backup:       completedBackup().Result(),
+			schedule:     schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeDryRun, SampleNamespaces: 1}),
+			resourceList: resourceList,
+			expectRestore: func(restore *velerov1api.Restore) {
+				assert.True(t, restore.Spec.DryRun)
+				require.Len(t, restore.Spec.IncludedNamespaces, 1)
+				assert.Contains(t, []string{"ns-1", "ns-2"}, restore.Spec.IncludedNamespaces[0])
+			},
+			expectCondition: &velerov1api.Condition{Type: velerov1api.ConditionVerified, Status: corev1api.ConditionUnknown, Reason: "InProgress"},
+		},
+		{
+			name:         "scratch-namespace verification restores namespaced items into scratch namespaces",
+			backup:       completedBackup().Result(),
+			schedule:     schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeScratchNamespace}),
+			resourceList: resourceList,
+			expectRestore: func(restore *velerov1api.Restore) {
+				assert.False(t, restore.Spec.DryRun)
+				assert.Equal(t, []string{"ns-1", "ns-2"}, restore.Spec.IncludedNamespaces)
+				assert.Equal(t, map[string]string{
+					"ns-1": scratchNamespace(backupName, "ns-1"),
+					"ns-2": scratchNamespace(backupName, "ns-2"),
+				}, restore.Spec.NamespaceMapping)
+				assert.Equal(t, []string{"pods", "persistentvolumeclaims"}, restore.Spec.ExcludedResources)
+				require.NotNil(t, restore.Spec.IncludeClusterResources)
+				assert.False(t, *restore.Spec.IncludeClusterResources)
+				require.NotNil(t, restore.Spec.RestorePVs)
+				assert.False(t, *restore.Spec.RestorePVs)
+			},
+			expectCondition: &velerov1api.Condition{Type: velerov1api.ConditionVerified, Status: corev1api.ConditionUnknown, Reason: "InProgress"},
+		},
+		{
+			name:            "scratch-namespace verification of a backup without namespaced items has nothing to restore",
+			backup:          completedBackup().Result(),
+			schedule:        schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeScratchNamespace}),
+			resourceList:    map[string][]string{"v1/PersistentVolume": {"pv-1"}},
+			expectCondition: &velerov1api.Condition{Type: velerov1api.ConditionVerified, Status: corev1api.ConditionTrue, Reason: "NothingToRestore"},
+		},
+		{
+			name: "backup is left unverified while its restore runs",
+			backup: func() *velerov1api.Backup {
+				b := completedBackup().Result()
+				b.Status.Conditions = inProgress
+				return b
+			}(),
+			schedule: schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeDryRun}),
+			restore:  verificationRestore().DryRun(true).Phase(velerov1api.RestorePhaseInProgress).Result(),
+		},
+		{
+			name: "backup is verified once its restore completes and the scratch namespaces are deleted",
+			backup: func() *velerov1api.Backup {
+				b := completedBackup().Result()
+				b.Status.Conditions = inProgress
+				return b
+			}(),
+			schedule:           schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeScratchNamespace}),
+			restore:            verificationRestore().NamespaceMappings("ns-1", scratchNamespace(backupName, "ns-1")).Phase(velerov1api.RestorePhaseCompleted).Result(),
+			expectCondition:    &velerov1api.Condition{Type: velerov1api.ConditionVerified, Status: corev1api.ConditionTrue, Reason: "Verified"},
+			expectNamespaceDel: []string{scratchNamespace(backupName, "ns-1")},
+		},
+		{
+			name: "backup fails verification if its restore partially fails",
+			backup: func() *velerov1api.Backup {
+				b := completedBackup().Result()
+				b.Status.Conditions = inProgress
+				return b
+			}(),
+			schedule:        schedule(&velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeDryRun}),
+			restore:         verificationRestore().DryRun(true).Phase(velerov1api.RestorePhasePartiallyFailed).Result(),
+			expectCondition: &velerov1api.Condition{Type: velerov1api.ConditionVerified, Status: corev1api.ConditionFalse, Reason: "VerificationFailed"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset(test.backup)
+				kubeClient      = kubefake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+			)
+			if test.restore != nil {
+				for _, namespace := range test.restore.Spec.NamespaceMapping {
+					_, err := kubeClient.CoreV1().Namespaces().Create(builder.ForNamespace(namespace).Result())
+					require.NoError(t, err)
+				}
+			}
+
+			c := NewBackupVerificationController(
+				velerotest.NewLogger(),
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().Schedules(),
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().Restores(),
+				kubeClient.CoreV1(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+			).(*backupVerificationController)
+			c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+				return backupStore, nil
+			}
+			c.clock = clock.NewFakeClock(now)
+			c.rand = rand.New(rand.NewSource(1))
+
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Result()))
+			require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(test.backup))
+			require.NoError(t, sharedInformers.Velero().V1().Schedules().Informer().GetStore().Add(test.schedule))
+			if test.restore != nil {
+				require.NoError(t, sharedInformers.Velero().V1().Restores().Informer().GetStore().Add(test.restore))
+			}
+
+			pluginManager.On("CleanupClients").Return(nil)
+			if test.resourceList != nil {
+				target := velerov1api.DownloadTarget{Kind: velerov1api.DownloadTargetKindBackupResourceList, Name: backupName}
+				backupStore.On("GetDownload", target).Return(ioutil.NopCloser(bytes.NewReader(gzippedResourceList(t, test.resourceList))), nil)
+			}
+
+			require.NoError(t, c.processBackup(velerov1api.DefaultNamespace+"/"+backupName))
+
+			restore, err := client.VeleroV1().Restores(velerov1api.DefaultNamespace).Get(restoreName, metav1.GetOptions{})
+			if test.expectRestore != nil {
+				require.NoError(t, err)
+				assert.Equal(t, backupName, restore.Spec.BackupName)
+				assert.Equal(t, backupName, restore.Labels[velerov1api.VerifiedBackupLabel])
+				test.expectRestore(restore)
+			} else {
+				assert.True(t, apierrors.IsNotFound(err))
+			}
+
+			res, err := client.VeleroV1().Backups(velerov1api.DefaultNamespace).Get(backupName, metav1.GetOptions{})
+			require.NoError(t, err)
+			if test.expectCondition == nil {
+				assert.Equal(t, test.backup.Status.Conditions, res.Status.Conditions)
+			} else {
+				condition := verifiedCondition(res)
+				require.NotNil(t, condition)
+				assert.Equal(t, test.expectCondition.Status, condition.Status)
+				assert.Equal(t, test.expectCondition.Reason, condition.Reason)
+			}
+
+			for _, namespace := range test.expectNamespaceDel {
+				_, err := kubeClient.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+				assert.True(t, apierrors.IsNotFound(err), "expected namespace %s to be deleted", namespace)
+			}
+		})
+	}
+}
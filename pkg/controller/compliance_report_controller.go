@@ -0,0 +1,391 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	"github.com/heptio/velero/pkg/validation"
+)
+
+// complianceReportController periodically generates a ComplianceReport of
+// whether each schedule's backups meet the schedule's requirements, and
+// deletes the oldest reports.
+type complianceReportController struct {
+	*genericController
+
+	namespace              string
+	scheduleLister         listers.ScheduleLister
+	backupLister           listers.BackupLister
+	backupLocationLister   listers.BackupStorageLocationLister
+	complianceReportLister listers.ComplianceReportLister
+	complianceReportClient velerov1client.ComplianceReportsGetter
+	frequency              time.Duration
+	defaultBackupTTL       time.Duration
+	reportsToKeep          int
+	newPluginManager       func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore         func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	clock                  clock.Clock
+}
+
+// NewComplianceReportController constructs a controller that generates a
+// ComplianceReport every frequency, and keeps the reportsToKeep newest ones.
+func NewComplianceReportController(
+	namespace string,
+	scheduleInformer informers.ScheduleInformer,
+	backupInformer informers.BackupInformer,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+	complianceReportInformer informers.ComplianceReportInformer,
+	complianceReportClient velerov1client.ComplianceReportsGetter,
+	frequency time.Duration,
+	defaultBackupTTL time.Duration,
+	reportsToKeep int,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+	logger logrus.FieldLogger,
+) Interface {
+	if frequency < time.Minute {
+		logger.Infof("Provided compliance report frequency %v is too short. Setting to 1 minute", frequency)
+		frequency = time.Minute
+	}
+
+	c := &complianceReportController{
+		genericController:      newGenericController("compliance-report", logger),
+		namespace:              namespace,
+		scheduleLister:         scheduleInformer.Lister(),
+		backupLister:           backupInformer.Lister(),
+		backupLocationLister:   backupLocationInformer.Lister(),
+		complianceReportLister: complianceReportInformer.Lister(),
+		complianceReportClient: complianceReportClient,
+		frequency:              frequency,
+		defaultBackupTTL:       defaultBackupTTL,
+		reportsToKeep:          reportsToKeep,
+		newPluginManager:       newPluginManager,
+		newBackupStore:         persistence.NewBackupStore,
+		clock:                  clock.RealClock{},
+	}
+
+	c.resyncFunc = c.run
+	c.resyncPeriod = frequency
+	c.cacheSyncWaiters = []cache.InformerSynced{
+		scheduleInformer.Informer().HasSynced,
+		backupInformer.Informer().HasSynced,
+		backupLocationInformer.Informer().HasSynced,
+		complianceReportInformer.Informer().HasSynced,
+	}
+
+	return c
+}
+
+func (c *complianceReportController) run() {
+	// the controller runs when the server starts, so a restarted server
+	// doesn't generate another report until it's due.
+	if latest := c.latestReportTime(); c.clock.Since(latest) < c.frequency/2 {
+		c.logger.WithField("generationTimestamp", latest).Debug("Compliance report was generated recently, skipping")
+		return
+	}
+
+	report, err := c.generateReport()
+	if err != nil {
+		c.logger.WithError(err).Error("Error generating compliance report")
+		return
+	}
+
+	log := c.logger.WithField("complianceReport", report.Name)
+	if _, err := c.complianceReportClient.ComplianceReports(c.namespace).Create(report); err != nil {
+		log.WithError(errors.WithStack(err)).Error("Error creating compliance report")
+		return
+	}
+	log.WithField("compliant", report.Status.Compliant).Info("Generated compliance report")
+
+	c.deleteOldReports(report.Name)
+}
+
+// latestReportTime returns when the newest report was generated, or the
+// zero time if there aren't any.
+func (c *complianceReportController) latestReportTime() time.Time {
+	var latest time.Time
+
+	reports, err := c.complianceReportLister.ComplianceReports(c.namespace).List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing compliance reports")
+		return latest
+	}
+
+	for _, report := range reports {
+		if report.Status.GenerationTimestamp.After(latest) {
+			latest = report.Status.GenerationTimestamp.Time
+		}
+	}
+
+	return latest
+}
+
+func (c *complianceReportController) generateReport() (*velerov1api.ComplianceReport, error) {
+	schedules, err := c.scheduleLister.Schedules(c.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].Name < schedules[j].Name
+	})
+
+	backups, err := c.backupLister.Backups(c.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	scheduleBackups := make(map[string][]*velerov1api.Backup)
+	for _, backup := range backups {
+		if schedule := backup.Labels[velerov1api.ScheduleNameLabel]; schedule != "" {
+			scheduleBackups[schedule] = append(scheduleBackups[schedule], backup)
+		}
+	}
+
+	pluginManager := c.newPluginManager(c.logger)
+	defer pluginManager.CleanupClients()
+
+	now := c.clock.Now()
+	report := &velerov1api.ComplianceReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.namespace,
+			Name:      fmt.Sprintf("compliance-%s", now.UTC().Format("20060102150405")),
+		},
+		Status: velerov1api.ComplianceReportStatus{
+			GenerationTimestamp: metav1.NewTime(now),
+			Compliant:           true,
+			Schedules:           []velerov1api.ScheduleCompliance{},
+		},
+	}
+
+	verify := func(backup *velerov1api.Backup) (velerov1api.BackupVerificationPhase, string) {
+		return c.verifyBackup(backup, pluginManager)
+	}
+
+	for _, schedule := range schedules {
+		compliance := scheduleCompliance(schedule, scheduleBackups[schedule.Name], c.defaultBackupTTL, now, verify)
+		if !compliance.Compliant {
+			report.Status.Compliant = false
+		}
+		report.Status.Schedules = append(report.Status.Schedules, compliance)
+	}
+
+	return report, nil
+}
+
+// verifyBackup checks that a backup's metadata file exists in its backup
+// storage location.
+func (c *complianceReportController) verifyBackup(backup *velerov1api.Backup, pluginManager clientmgmt.Manager) (velerov1api.BackupVerificationPhase, string) {
+	location, err := c.backupLocationLister.BackupStorageLocations(c.namespace).Get(backup.Spec.StorageLocation)
+	if err != nil {
+		return velerov1api.BackupVerificationPhaseUnverified, fmt.Sprintf("error getting backup storage location %s: %v", backup.Spec.StorageLocation, err)
+	}
+
+	backupStore, err := c.newBackupStore(location, pluginManager, c.logger)
+	if err != nil {
+		return velerov1api.BackupVerificationPhaseUnverified, fmt.Sprintf("error getting backup store for backup storage location %s: %v", location.Name, err)
+	}
+
+	var bucket string
+	if location.Spec.ObjectStorage != nil {
+		bucket = location.Spec.ObjectStorage.Bucket
+	}
+
+	exists, err := backupStore.BackupExists(bucket, backup.Name)
+	if err != nil {
+		return velerov1api.BackupVerificationPhaseUnverified, fmt.Sprintf("error checking backup storage location %s: %v", location.Name, err)
+	}
+	if !exists {
+		return velerov1api.BackupVerificationPhaseNotFound, fmt.Sprintf("backup wasn't found in backup storage location %s", location.Name)
+	}
+
+	return velerov1api.BackupVerificationPhaseVerified, ""
+}
+
+// scheduleCompliance reports whether a schedule's backups meet its
+// requirements: it must have a completed backup from no earlier than two
+// runs ago, so that at most one run was missed or is still in progress;
+// its oldest completed backup must be from no later than its second run
+// within its required retention, unless the schedule is newer than that;
+// and its newest completed backup must be verified to exist in backup
+// storage.
+func scheduleCompliance(
+	schedule *velerov1api.Schedule,
+	backups []*velerov1api.Backup,
+	defaultBackupTTL time.Duration,
+	now time.Time,
+	verify func(*velerov1api.Backup) (velerov1api.BackupVerificationPhase, string),
+) velerov1api.ScheduleCompliance {
+	retention := schedule.Spec.Template.TTL.Duration
+	if retention == 0 {
+		retention = defaultBackupTTL
+	}
+
+	res := velerov1api.ScheduleCompliance{
+		Schedule:          schedule.Name,
+		CronSchedule:      schedule.Spec.Schedule,
+		RequiredRetention: metav1.Duration{Duration: retention},
+	}
+
+	if schedule.Status.Phase != velerov1api.SchedulePhaseEnabled {
+		res.Problems = append(res.Problems, fmt.Sprintf("schedule isn't enabled; its phase is %q", schedule.Status.Phase))
+	}
+
+	cronSchedule, validationErrors := validation.ParseScheduleSpec(&schedule.Spec)
+	if len(validationErrors) > 0 {
+		res.Problems = append(res.Problems, fmt.Sprintf("schedule's Cron expressions or time zone are invalid: %s", strings.Join(validationErrors, "; ")))
+	}
+
+	windowStart := now.Add(-retention)
+
+	var completed []*velerov1api.Backup
+	for _, backup := range backups {
+		switch backup.Status.Phase {
+		case velerov1api.BackupPhaseCompleted:
+			completed = append(completed, backup)
+		case velerov1api.BackupPhaseFailed, velerov1api.BackupPhasePartiallyFailed:
+			if backupStartTime(backup).After(windowStart) {
+				res.FailedBackups = append(res.FailedBackups, backup.Name)
+			}
+		}
+	}
+	sort.Strings(res.FailedBackups)
+
+	res.CompletedBackups = len(completed)
+	if len(completed) == 0 {
+		res.Problems = append(res.Problems, "schedule has no completed backups")
+		return finishScheduleCompliance(res)
+	}
+
+	sort.Slice(completed, func(i, j int) bool {
+		return backupStartTime(completed[i]).Before(backupStartTime(completed[j]))
+	})
+	oldest, newest := completed[0], completed[len(completed)-1]
+	res.OldestBackup = complianceBackupReference(oldest)
+	res.NewestBackup = complianceBackupReference(newest)
+
+	if cronSchedule != nil {
+		newestStart := backupStartTime(newest)
+		if due := nextRun(cronSchedule, newestStart, 2); now.After(due) {
+			res.Problems = append(res.Problems, fmt.Sprintf("newest completed backup %s started at %s, but a newer backup was due to complete by %s", newest.Name, formatComplianceTime(newestStart), formatComplianceTime(due)))
+		}
+
+		if schedule.CreationTimestamp.Time.Before(windowStart) {
+			oldestStart := backupStartTime(oldest)
+			if bound := nextRun(cronSchedule, windowStart, 2); oldestStart.After(bound) {
+				res.Problems = append(res.Problems, fmt.Sprintf("oldest completed backup %s started at %s, so backups aren't kept for the required retention of %s", oldest.Name, formatComplianceTime(oldestStart), retention))
+			}
+		}
+	}
+
+	res.Verification, res.VerificationMessage = verify(newest)
+	if res.Verification != velerov1api.BackupVerificationPhaseVerified {
+		res.Problems = append(res.Problems, fmt.Sprintf("newest completed backup %s couldn't be verified: %s", newest.Name, res.VerificationMessage))
+	}
+
+	return finishScheduleCompliance(res)
+}
+
+func finishScheduleCompliance(res velerov1api.ScheduleCompliance) velerov1api.ScheduleCompliance {
+	res.Compliant = len(res.Problems) == 0
+	return res
+}
+
+// nextRun returns the time of the n-th run of the schedule after t.
+func nextRun(schedule cron.Schedule, t time.Time, n int) time.Time {
+	for i := 0; i < n; i++ {
+		t = schedule.Next(t)
+	}
+	return t
+}
+
+// backupStartTime returns when a backup started, or when it was created
+// if it didn't start.
+func backupStartTime(backup *velerov1api.Backup) time.Time {
+	if !backup.Status.StartTimestamp.IsZero() {
+		return backup.Status.StartTimestamp.Time
+	}
+	return backup.CreationTimestamp.Time
+}
+
+func complianceBackupReference(backup *velerov1api.Backup) *velerov1api.ComplianceBackupReference {
+	return &velerov1api.ComplianceBackupReference{
+		Name:            backup.Name,
+		StartTimestamp:  metav1.NewTime(backupStartTime(backup)),
+		Expiration:      backup.Status.Expiration,
+		StorageLocation: backup.Spec.StorageLocation,
+	}
+}
+
+func formatComplianceTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// deleteOldReports deletes all but the newest reportsToKeep reports,
+// including the one that was just created, which is named newest.
+func (c *complianceReportController) deleteOldReports(newest string) {
+	if c.reportsToKeep <= 0 {
+		return
+	}
+
+	listed, err := c.complianceReportLister.ComplianceReports(c.namespace).List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing compliance reports")
+		return
+	}
+
+	// the newest report might not be in the lister's cache yet.
+	var reports []*velerov1api.ComplianceReport
+	for _, report := range listed {
+		if report.Name != newest {
+			reports = append(reports, report)
+		}
+	}
+
+	keep := c.reportsToKeep - 1
+	if len(reports) <= keep {
+		return
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Status.GenerationTimestamp.After(reports[j].Status.GenerationTimestamp.Time)
+	})
+
+	for _, report := range reports[keep:] {
+		log := c.logger.WithField("complianceReport", report.Name)
+		if err := c.complianceReportClient.ComplianceReports(c.namespace).Delete(report.Name, nil); err != nil {
+			log.WithError(errors.WithStack(err)).Error("Error deleting old compliance report")
+			continue
+		}
+		log.Debug("Deleted old compliance report")
+	}
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func complianceTestTime(val string) time.Time {
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func complianceTestSchedule(created string) *velerov1api.Schedule {
+	schedule := builder.ForSchedule(velerov1api.DefaultNamespace, "hourly").
+		CronSchedule("0 * * * *").
+		Phase(velerov1api.SchedulePhaseEnabled).
+		Template(velerov1api.BackupSpec{TTL: metav1.Duration{Duration: 24 * time.Hour}}).
+		Result()
+	schedule.CreationTimestamp = metav1.NewTime(complianceTestTime(created))
+	return schedule
+}
+
+func complianceTestBackup(name string, phase velerov1api.BackupPhase, started string) *velerov1api.Backup {
+	return builder.ForBackup(velerov1api.DefaultNamespace, name).
+		ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "hourly")).
+		StorageLocation("default").
+		Phase(phase).
+		StartTimestamp(complianceTestTime(started)).
+		Result()
+}
+
+func TestScheduleCompliance(t *testing.T) {
+	now := complianceTestTime("2019-10-01T12:30:00Z")
+
+	tests := []struct {
+		name                string
+		schedule            *velerov1api.Schedule
+		backups             []*velerov1api.Backup
+		verification        velerov1api.BackupVerificationPhase
+		expectedCompliant   bool
+		expectedProblems    int
+		expectedOldest      string
+		expectedNewest      string
+		expectedFailed      []string
+		expectedCompletions int
+	}{
+		{
+			name:     "schedule with backups covering its retention is compliant",
+			schedule: complianceTestSchedule("2019-09-01T00:00:00Z"),
+			backups: []*velerov1api.Backup{
+				complianceTestBackup("hourly-2", velerov1api.BackupPhaseCompleted, "2019-10-01T12:00:00Z"),
+				complianceTestBackup("hourly-1", velerov1api.BackupPhaseCompleted, "2019-09-30T13:00:00Z"),
+			},
+			verification:        velerov1api.BackupVerificationPhaseVerified,
+			expectedCompliant:   true,
+			expectedOldest:      "hourly-1",
+			expectedNewest:      "hourly-2",
+			expectedCompletions: 2,
+		},
+		{
+			name:              "schedule with no backups isn't compliant",
+			schedule:          complianceTestSchedule("2019-09-01T00:00:00Z"),
+			expectedCompliant: false,
+			expectedProblems:  1,
+		},
+		{
+			name:     "schedule whose newest backup is more than two runs old isn't compliant",
+			schedule: complianceTestSchedule("2019-09-01T00:00:00Z"),
+			backups: []*velerov1api.Backup{
+				complianceTestBackup("hourly-2", velerov1api.BackupPhaseCompleted, "2019-10-01T10:00:00Z"),
+				complianceTestBackup("hourly-1", velerov1api.BackupPhaseCompleted, "2019-09-30T13:00:00Z"),
+			},
+			verification:        velerov1api.BackupVerificationPhaseVerified,
+			expectedCompliant:   false,
+			expectedProblems:    1,
+			expectedOldest:      "hourly-1",
+			expectedNewest:      "hourly-2",
+			expectedCompletions: 2,
+		},
+		{
+			name:     "schedule whose backups don't cover its retention isn't compliant",
+			schedule: complianceTestSchedule("2019-09-01T00:00:00Z"),
+			backups: []*velerov1api.Backup{
+				complianceTestBackup("hourly-2", velerov1api.BackupPhaseCompleted, "2019-10-01T12:00:00Z"),
+				complianceTestBackup("hourly-1", velerov1api.BackupPhaseCompleted, "2019-09-30T20:00:00Z"),
+			},
+			verification:        velerov1api.BackupVerificationPhaseVerified,
+			expectedCompliant:   false,
+			expectedProblems:    1,
+			expectedOldest:      "hourly-1",
+			expectedNewest:      "hourly-2",
+			expectedCompletions: 2,
+		},
+		{
+			name:     "schedule newer than its retention isn't required to cover it",
+			schedule: complianceTestSchedule("2019-10-01T00:30:00Z"),
+			backups: []*velerov1api.Backup{
+				complianceTestBackup("hourly-2", velerov1api.BackupPhaseCompleted, "2019-10-01T12:00:00Z"),
+				complianceTestBackup("hourly-1", velerov1api.BackupPhaseCompleted, "2019-10-01T01:00:00Z"),
+			},
+			verification:        velerov1api.BackupVerificationPhaseVerified,
+			expectedCompliant:   true,
+			expectedOldest:      "hourly-1",
+			expectedNewest:      "hourly-2",
+			expectedCompletions: 2,
+		},
+		{
+			name:     "schedule whose newest backup isn't in backup storage isn't compliant",
+			schedule: complianceTestSchedule("2019-10-01T00:30:00Z"),
+			backups: []*velerov1api.Backup{
+				complianceTestBackup("hourly-1", velerov1api.BackupPhaseCompleted, "2019-10-01T12:00:00Z"),
+			},
+			verification:        velerov1api.BackupVerificationPhaseNotFound,
+			expectedCompliant:   false,
+			expectedProblems:    1,
+			expectedOldest:      "hourly-1",
+			expectedNewest:      "hourly-1",
+			expectedCompletions: 1,
+		},
+		{
+			name:     "failed backups within the retention are reported, but don't affect compliance",
+			schedule: complianceTestSchedule("2019-10-01T00:30:00Z"),
+			backups: []*velerov1api.Backup{
+				complianceTestBackup("hourly-4", velerov1api.BackupPhasePartiallyFailed, "2019-10-01T12:00:00Z"),
+				complianceTestBackup("hourly-3", velerov1api.BackupPhaseCompleted, "2019-10-01T11:00:00Z"),
+				complianceTestBackup("hourly-2", velerov1api.BackupPhaseFailed, "2019-10-01T10:00:00Z"),
+				complianceTestBackup("hourly-1", velerov1api.BackupPhaseFailed, "2019-09-30T10:00:00Z"),
+			},
+			verification:        velerov1api.BackupVerificationPhaseVerified,
+			expectedCompliant:   true,
+			expectedOldest:      "hourly-3",
+			expectedNewest:      "hourly-3",
+			expectedFailed:      []string{"hourly-2", "hourly-4"},
+			expectedCompletions: 1,
+		},
+		{
+			name: "disabled schedule isn't compliant",
+			schedule: func() *velerov1api.Schedule {
+				schedule := complianceTestSchedule("2019-10-01T00:30:00Z")
+				schedule.Status.Phase = velerov1api.SchedulePhaseFailedValidation
+				return schedule
+			}(),
+			backups: []*velerov1api.Backup{
+				complianceTestBackup("hourly-1", velerov1api.BackupPhaseCompleted, "2019-10-01T12:00:00Z"),
+			},
+			verification:        velerov1api.BackupVerificationPhaseVerified,
+			expectedCompliant:   false,
+			expectedProblems:    1,
+			expectedOldest:      "hourly-1",
+			expectedNewest:      "hourly-1",
+			expectedCompletions: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verify := func(*velerov1api.Backup) (velerov1api.BackupVerificationPhase, string) {
+				return test.verification, ""
+			}
+
+			res := scheduleCompliance(test.schedule, test.backups, 30*24*time.Hour, now, verify)
+
+			assert.Equal(t, "hourly", res.Schedule)
+			assert.Equal(t, 24*time.Hour, res.RequiredRetention.Duration)
+			assert.Equal(t, test.expectedCompliant, res.Compliant)
+			assert.Len(t, res.Problems, test.expectedProblems, "problems: %v", res.Problems)
+			assert.Equal(t, test.expectedCompletions, res.CompletedBackups)
+			assert.Equal(t, test.expectedFailed, res.FailedBackups)
+
+			if test.expectedOldest == "" {
+				assert.Nil(t, res.OldestBackup)
+				assert.Nil(t, res.NewestBackup)
+				return
+			}
+			require.NotNil(t, res.OldestBackup)
+			require.NotNil(t, res.NewestBackup)
+			assert.Equal(t, test.expectedOldest, res.OldestBackup.Name)
+			assert.Equal(t, test.expectedNewest, res.NewestBackup.Name)
+			assert.Equal(t, test.verification, res.Verification)
+		})
+	}
+}
+
+func complianceTestReport(name, generated string) *velerov1api.ComplianceReport {
+	return &velerov1api.ComplianceReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: velerov1api.DefaultNamespace,
+			Name:      name,
+		},
+		Status: velerov1api.ComplianceReportStatus{
+			GenerationTimestamp: metav1.NewTime(complianceTestTime(generated)),
+		},
+	}
+}
+
+func TestComplianceReportControllerRun(t *testing.T) {
+	tests := []struct {
+		name            string
+		existingReports []*velerov1api.ComplianceReport
+		reportsToKeep   int
+		expectCreate    bool
+		expectDeleted   []string
+	}{
+		{
+			name:         "report is generated when there are no reports",
+			expectCreate: true,
+		},
+		{
+			name:            "report isn't generated when the newest report is recent",
+			existingReports: []*velerov1api.ComplianceReport{complianceTestReport("compliance-20191001060000", "2019-10-01T06:00:00Z")},
+			expectCreate:    false,
+		},
+		{
+			name: "oldest reports are deleted after a report is generated",
+			existingReports: []*velerov1api.ComplianceReport{
+				complianceTestReport("compliance-20190928123000", "2019-09-28T12:30:00Z"),
+				complianceTestReport("compliance-20190930123000", "2019-09-30T12:30:00Z"),
+				complianceTestReport("compliance-20190929123000", "2019-09-29T12:30:00Z"),
+			},
+			reportsToKeep: 2,
+			expectCreate:  true,
+			expectDeleted: []string{"compliance-20190929123000", "compliance-20190928123000"},
+		},
+		{
+			name: "no reports are deleted when reportsToKeep is zero",
+			existingReports: []*velerov1api.ComplianceReport{
+				complianceTestReport("compliance-20190928123000", "2019-09-28T12:30:00Z"),
+				complianceTestReport("compliance-20190929123000", "2019-09-29T12:30:00Z"),
+			},
+			expectCreate: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+			)
+
+			c := NewComplianceReportController(
+				velerov1api.DefaultNamespace,
+				sharedInformers.Velero().V1().Schedules(),
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				sharedInformers.Velero().V1().ComplianceReports(),
+				client.VeleroV1(),
+				24*time.Hour,
+				30*24*time.Hour,
+				test.reportsToKeep,
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+				velerotest.NewLogger(),
+			).(*complianceReportController)
+
+			c.clock = clock.NewFakeClock(complianceTestTime("2019-10-01T12:30:00Z"))
+			c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+				return backupStore, nil
+			}
+
+			pluginManager.On("CleanupClients").Return(nil)
+			backupStore.On("BackupExists", "bucket", "hourly-1").Return(true, nil)
+
+			require.NoError(t, sharedInformers.Velero().V1().Schedules().Informer().GetStore().Add(complianceTestSchedule("2019-10-01T00:30:00Z")))
+			require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(complianceTestBackup("hourly-1", velerov1api.BackupPhaseCompleted, "2019-10-01T12:00:00Z")))
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Bucket("bucket").Result()))
+			for _, report := range test.existingReports {
+				require.NoError(t, sharedInformers.Velero().V1().ComplianceReports().Informer().GetStore().Add(report))
+			}
+
+			c.run()
+
+			var (
+				created *velerov1api.ComplianceReport
+				deleted []string
+			)
+			for _, action := range client.Actions() {
+				switch action := action.(type) {
+				case core.CreateAction:
+					created = action.GetObject().(*velerov1api.ComplianceReport)
+				case core.DeleteAction:
+					deleted = append(deleted, action.GetName())
+				}
+			}
+
+			assert.Equal(t, test.expectDeleted, deleted)
+
+			if !test.expectCreate {
+				assert.Nil(t, created)
+				return
+			}
+			require.NotNil(t, created)
+			assert.Equal(t, "compliance-20191001123000", created.Name)
+			assert.True(t, created.Status.Compliant)
+			require.Len(t, created.Status.Schedules, 1)
+			assert.Equal(t, velerov1api.BackupVerificationPhaseVerified, created.Status.Schedules[0].Verification)
+		})
+	}
+}
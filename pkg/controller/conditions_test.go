@@ -0,0 +1,46 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	start := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	later := start.Add(time.Minute)
+
+	var conditions []velerov1api.Condition
+	conditions = setCondition(conditions, velerov1api.ConditionCompleted, corev1api.ConditionFalse, "InProgress", "", start)
+	conditions = setCondition(conditions, velerov1api.ConditionStoreUploaded, corev1api.ConditionFalse, "UploadFailed", "boom", start)
+
+	// the same status keeps its transition time, a new one changes it.
+	conditions = setCondition(conditions, velerov1api.ConditionStoreUploaded, corev1api.ConditionFalse, "UploadFailed", "bang", later)
+	conditions = setCondition(conditions, velerov1api.ConditionCompleted, corev1api.ConditionTrue, "Completed", "", later)
+
+	assert.Equal(t, []velerov1api.Condition{
+		{Type: velerov1api.ConditionCompleted, Status: corev1api.ConditionTrue, LastTransitionTime: metav1.NewTime(later), Reason: "Completed"},
+		{Type: velerov1api.ConditionStoreUploaded, Status: corev1api.ConditionFalse, LastTransitionTime: metav1.NewTime(start), Reason: "UploadFailed", Message: "bang"},
+	}, conditions)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/heptio/velero/pkg/credentials"
+	kubeutil "github.com/heptio/velero/pkg/util/kube"
+)
+
+// credentialsController rewrites the files holding credentials when their
+// secrets change, so that plugins are reinitialized with the new values
+// without waiting for the next time that the files are used.
+type credentialsController struct {
+	*genericController
+
+	secretLister corev1listers.SecretLister
+	fileStore    credentials.FileStore
+}
+
+// NewCredentialsController returns a controller that refreshes fileStore's
+// files from the secrets in secretInformer.
+func NewCredentialsController(
+	secretInformer cache.SharedIndexInformer,
+	fileStore credentials.FileStore,
+	logger logrus.FieldLogger,
+) Interface {
+	c := &credentialsController{
+		genericController: newGenericController("credentials", logger),
+		secretLister:      corev1listers.NewSecretLister(secretInformer.GetIndexer()),
+		fileStore:         fileStore,
+	}
+
+	c.syncHandler = c.processSecret
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, secretInformer.HasSynced)
+
+	secretInformer.AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: c.enqueueSecond,
+		},
+	)
+
+	return c
+}
+
+func (c *credentialsController) processSecret(key string) error {
+	log := c.logger.WithField("key", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	secret, err := c.secretLister.Secrets(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find secret")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting secret")
+	}
+
+	changed, err := c.fileStore.Refresh(secret)
+	if err != nil {
+		return errors.Wrapf(err, "error refreshing credentials from secret %s", kubeutil.NamespaceAndName(secret))
+	}
+	if changed {
+		log.Info("Credentials changed - plugins will be reinitialized with them")
+	}
+
+	return nil
+}
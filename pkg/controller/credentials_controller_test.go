@@ -0,0 +1,70 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+type refreshingFileStore struct {
+	refreshed []string
+	changed   bool
+	err       error
+}
+
+func (s *refreshingFileStore) Path(*corev1api.SecretKeySelector) (string, error) {
+	return "", nil
+}
+
+func (s *refreshingFileStore) Refresh(secret *corev1api.Secret) (bool, error) {
+	s.refreshed = append(s.refreshed, secret.Name)
+	return s.changed, s.err
+}
+
+func TestCredentialsControllerProcessSecret(t *testing.T) {
+	secret := &corev1api.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "cloud-credentials"},
+		Data:       map[string][]byte{"cloud": []byte("creds")},
+	}
+
+	informer := corev1informers.NewSecretInformer(kubefake.NewSimpleClientset(), "velero", 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, informer.GetIndexer().Add(secret))
+
+	fileStore := &refreshingFileStore{changed: true}
+	c := NewCredentialsController(informer, fileStore, velerotest.NewLogger()).(*credentialsController)
+
+	require.NoError(t, c.processSecret("velero/cloud-credentials"))
+	assert.Equal(t, []string{"cloud-credentials"}, fileStore.refreshed)
+
+	// deleted secrets are ignored.
+	require.NoError(t, c.processSecret("velero/deleted"))
+	assert.Len(t, fileStore.refreshed, 1)
+
+	fileStore.err = errors.New("KMS unavailable")
+	assert.EqualError(t, c.processSecret("velero/cloud-credentials"), "error refreshing credentials from secret velero/cloud-credentials: KMS unavailable")
+}
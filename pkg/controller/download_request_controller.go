@@ -31,6 +31,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/downloadproxy"
 	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
@@ -50,9 +51,19 @@ type downloadRequestController struct {
 	backupLister          listers.BackupLister
 	newPluginManager      func(logrus.FieldLogger) clientmgmt.Manager
 	newBackupStore        func(*v1.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	downloadProxy         *downloadproxy.Signer
+	alwaysProxyDownloads  bool
+	defaultDownloadURLTTL time.Duration
+	downloadRequestTTL    time.Duration
 }
 
-// NewDownloadRequestController creates a new DownloadRequestController.
+// NewDownloadRequestController creates a new DownloadRequestController. If
+// downloadProxy is not nil, a download proxy URL is returned for requests
+// whose object store can't create a pre-signed URL, or for all requests if
+// alwaysProxyDownloads is true. Download URLs are valid for the request's
+// TTL, or defaultDownloadURLTTL if it doesn't specify one, and are
+// regenerated before they expire. Processed requests are deleted after
+// downloadRequestTTL, or once their first URL expires if that's later.
 func NewDownloadRequestController(
 	downloadRequestClient velerov1client.DownloadRequestsGetter,
 	downloadRequestInformer informers.DownloadRequestInformer,
@@ -60,6 +71,10 @@ func NewDownloadRequestController(
 	backupLocationInformer informers.BackupStorageLocationInformer,
 	backupInformer informers.BackupInformer,
 	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+	downloadProxy *downloadproxy.Signer,
+	alwaysProxyDownloads bool,
+	defaultDownloadURLTTL time.Duration,
+	downloadRequestTTL time.Duration,
 	logger logrus.FieldLogger,
 ) Interface {
 	c := &downloadRequestController{
@@ -69,16 +84,22 @@ func NewDownloadRequestController(
 		restoreLister:         restoreInformer.Lister(),
 		backupLocationLister:  backupLocationInformer.Lister(),
 		backupLister:          backupInformer.Lister(),
+		downloadProxy:         downloadProxy,
+		alwaysProxyDownloads:  alwaysProxyDownloads,
+		defaultDownloadURLTTL: defaultDownloadURLTTL,
+		downloadRequestTTL:    downloadRequestTTL,
 
 		// use variables to refer to these functions so they can be
 		// replaced with fakes for testing.
 		newPluginManager: newPluginManager,
-		newBackupStore:   persistence.NewObjectBackupStore,
+		newBackupStore:   persistence.NewBackupStore,
 
 		clock: &clock.RealClock{},
 	}
 
 	c.syncHandler = c.processDownloadRequest
+	c.resyncFunc = c.resync
+	c.resyncPeriod = time.Minute
 	c.cacheSyncWaiters = append(
 		c.cacheSyncWaiters,
 		downloadRequestInformer.Informer().HasSynced,
@@ -107,7 +128,8 @@ func NewDownloadRequestController(
 }
 
 // processDownloadRequest is the default per-item sync handler. It generates a pre-signed URL for
-// a new DownloadRequest or deletes the DownloadRequest if it has expired.
+// a new DownloadRequest, regenerates the URL of a processed DownloadRequest that's about to expire,
+// or deletes the DownloadRequest if it has expired.
 func (c *downloadRequestController) processDownloadRequest(key string) error {
 	log := c.logger.WithField("key", key)
 
@@ -131,16 +153,21 @@ func (c *downloadRequestController) processDownloadRequest(key string) error {
 	case "", v1.DownloadRequestPhaseNew:
 		return c.generatePreSignedURL(downloadRequest, log)
 	case v1.DownloadRequestPhaseProcessed:
-		return c.deleteIfExpired(downloadRequest)
+		if c.isExpired(downloadRequest) {
+			return c.deleteIfExpired(downloadRequest)
+		}
+		if c.needsNewURL(downloadRequest) {
+			return c.generatePreSignedURL(downloadRequest, log)
+		}
+		c.requeue(downloadRequest)
 	}
 
 	return nil
 }
 
-const signedURLTTL = 10 * time.Minute
-
 // generatePreSignedURL generates a pre-signed URL for downloadRequest, changes the phase to
-// Processed, and persists the changes to storage.
+// Processed, and persists the changes to storage. The DownloadRequest's expiration is only
+// set the first time a URL is generated for it.
 func (c *downloadRequestController) generatePreSignedURL(downloadRequest *v1.DownloadRequest, log logrus.FieldLogger) error {
 	update := downloadRequest.DeepCopy()
 
@@ -150,7 +177,7 @@ func (c *downloadRequestController) generatePreSignedURL(downloadRequest *v1.Dow
 	)
 
 	switch downloadRequest.Spec.Target.Kind {
-	case v1.DownloadTargetKindRestoreLog, v1.DownloadTargetKindRestoreResults:
+	case v1.DownloadTargetKindRestoreLog, v1.DownloadTargetKindRestoreStructuredLog, v1.DownloadTargetKindRestoreResults, v1.DownloadTargetKindRestoreManifest:
 		restore, err := c.restoreLister.Restores(downloadRequest.Namespace).Get(downloadRequest.Spec.Target.Name)
 		if err != nil {
 			return errors.Wrap(err, "error getting Restore")
@@ -179,22 +206,110 @@ func (c *downloadRequestController) generatePreSignedURL(downloadRequest *v1.Dow
 		return errors.WithStack(err)
 	}
 
-	if update.Status.DownloadURL, err = backupStore.GetDownloadURL(downloadRequest.Spec.Target); err != nil {
-		return err
+	ttl := c.urlTTL(downloadRequest)
+
+	proxyDownload := downloadproxy.Download{
+		Namespace: backupLocation.Namespace,
+		Location:  backupLocation.Name,
+		Target:    downloadRequest.Spec.Target,
+	}
+
+	if c.downloadProxy != nil && c.alwaysProxyDownloads {
+		update.Status.DownloadURL = c.downloadProxy.URL(proxyDownload, ttl)
+	} else if update.Status.DownloadURL, err = backupStore.GetDownloadURL(downloadRequest.Spec.Target, ttl); err != nil {
+		if c.downloadProxy == nil {
+			return err
+		}
+
+		log.WithError(err).Info("Unable to get a pre-signed download URL from the backup store, returning a download proxy URL")
+		update.Status.DownloadURL = c.downloadProxy.URL(proxyDownload, ttl)
+	}
+
+	now := c.clock.Now()
+
+	update.Status.URLExpiration = metav1.NewTime(now.Add(ttl))
+	update.Status.URLsIssued++
+
+	if update.Status.Phase != v1.DownloadRequestPhaseProcessed {
+		update.Status.Phase = v1.DownloadRequestPhaseProcessed
+
+		// keep the request at least as long as its first URL is valid for.
+		requestTTL := c.downloadRequestTTL
+		if requestTTL < ttl {
+			requestTTL = ttl
+		}
+		update.Status.Expiration = metav1.NewTime(now.Add(requestTTL))
+	} else {
+		log.WithField("urlsIssued", update.Status.URLsIssued).Debug("Regenerated download URL for DownloadRequest")
+	}
+
+	updated, err := patchDownloadRequest(downloadRequest, update, c.downloadRequestClient)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.requeue(updated)
+	return nil
+}
+
+// urlTTL returns how long the URLs generated for downloadRequest are valid for.
+func (c *downloadRequestController) urlTTL(downloadRequest *v1.DownloadRequest) time.Duration {
+	if ttl := downloadRequest.Spec.TTL.Duration; ttl > 0 {
+		return ttl
+	}
+	return c.defaultDownloadURLTTL
+}
+
+// urlRefreshTime returns when the URL of a processed DownloadRequest should be regenerated,
+// which is a tenth of its TTL before it expires. It returns the zero time if the URL doesn't
+// need to be regenerated, either because it's valid for as long as the DownloadRequest exists
+// or because the DownloadRequest was processed by a server that didn't track URL expiration.
+func (c *downloadRequestController) urlRefreshTime(downloadRequest *v1.DownloadRequest) time.Time {
+	urlExpiration := downloadRequest.Status.URLExpiration.Time
+	if urlExpiration.IsZero() || !urlExpiration.Before(downloadRequest.Status.Expiration.Time) {
+		return time.Time{}
 	}
 
-	update.Status.Phase = v1.DownloadRequestPhaseProcessed
-	update.Status.Expiration = metav1.NewTime(c.clock.Now().Add(persistence.DownloadURLTTL))
+	return urlExpiration.Add(-c.urlTTL(downloadRequest) / 10)
+}
+
+// needsNewURL returns true if the URL of a processed DownloadRequest is about to expire.
+func (c *downloadRequestController) needsNewURL(downloadRequest *v1.DownloadRequest) bool {
+	refresh := c.urlRefreshTime(downloadRequest)
+	return !refresh.IsZero() && !refresh.After(c.clock.Now())
+}
 
-	_, err = patchDownloadRequest(downloadRequest, update, c.downloadRequestClient)
-	return errors.WithStack(err)
+// isExpired returns true if a processed DownloadRequest has expired and can be deleted.
+func (c *downloadRequestController) isExpired(downloadRequest *v1.DownloadRequest) bool {
+	return !downloadRequest.Status.Expiration.Time.After(c.clock.Now())
+}
+
+// requeue adds a processed DownloadRequest back to the queue for its next processing time.
+func (c *downloadRequestController) requeue(downloadRequest *v1.DownloadRequest) {
+	key, err := cache.MetaNamespaceKeyFunc(downloadRequest)
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).WithField("downloadRequest", downloadRequest.Name).Error("error generating key for download request")
+		return
+	}
+
+	c.queue.AddAfter(key, c.nextProcessingTime(downloadRequest).Sub(c.clock.Now()))
+}
+
+// nextProcessingTime returns when a processed DownloadRequest's URL needs to be regenerated or
+// it expires, whichever comes first.
+func (c *downloadRequestController) nextProcessingTime(downloadRequest *v1.DownloadRequest) time.Time {
+	next := downloadRequest.Status.Expiration.Time
+	if refresh := c.urlRefreshTime(downloadRequest); !refresh.IsZero() && refresh.Before(next) {
+		next = refresh
+	}
+	return next
 }
 
 // deleteIfExpired deletes downloadRequest if it has expired.
 func (c *downloadRequestController) deleteIfExpired(downloadRequest *v1.DownloadRequest) error {
 	log := c.logger.WithField("key", kube.NamespaceAndName(downloadRequest))
 	log.Info("checking for expiration of DownloadRequest")
-	if downloadRequest.Status.Expiration.Time.After(c.clock.Now()) {
+	if !c.isExpired(downloadRequest) {
 		log.Debug("DownloadRequest has not expired")
 		return nil
 	}
@@ -204,7 +319,8 @@ func (c *downloadRequestController) deleteIfExpired(downloadRequest *v1.Download
 }
 
 // resync requeues all the DownloadRequests in the lister's cache. This is mostly to handle deleting
-// any expired requests that were not deleted as part of the normal client flow for whatever reason.
+// any expired requests that were not deleted as part of the normal client flow for whatever reason,
+// and to pick up processed requests again after the server restarts.
 func (c *downloadRequestController) resync() {
 	list, err := c.downloadRequestLister.List(labels.Everything())
 	if err != nil {
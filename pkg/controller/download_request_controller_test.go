@@ -17,9 +17,12 @@ limitations under the License.
 package controller
 
 import (
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,6 +32,7 @@ import (
 
 	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/downloadproxy"
 	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
 	"github.com/heptio/velero/pkg/persistence"
@@ -61,6 +65,10 @@ func newDownloadRequestTestHarness(t *testing.T) *downloadRequestTestHarness {
 			informerFactory.Velero().V1().BackupStorageLocations(),
 			informerFactory.Velero().V1().Backups(),
 			func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+			nil,   // download proxy
+			false, // always proxy downloads
+			persistence.DefaultDownloadURLTTL,
+			time.Hour, // download request TTL
 			velerotest.NewLogger(),
 		).(*downloadRequestController)
 	)
@@ -132,6 +140,7 @@ func TestProcessDownloadRequest(t *testing.T) {
 		restore         *v1.Restore
 		backupLocation  *v1.BackupStorageLocation
 		expired         bool
+		ttl             time.Duration
 		expectedErr     string
 		expectGetsURL   bool
 	}{
@@ -229,6 +238,14 @@ func TestProcessDownloadRequest(t *testing.T) {
 			backupLocation:  newBackupLocation("a-location", "a-provider", "a-bucket"),
 			expectGetsURL:   true,
 		},
+		{
+			name:            "backup log request with a TTL gets a url that expires after the TTL",
+			downloadRequest: newDownloadRequest("", v1.DownloadTargetKindBackupLog, "a-backup"),
+			backup:          defaultBackup(),
+			backupLocation:  newBackupLocation("a-location", "a-provider", "a-bucket"),
+			ttl:             2 * time.Hour,
+			expectGetsURL:   true,
+		},
 		{
 			name:            "request with phase 'Processed' is not deleted if not expired",
 			downloadRequest: newDownloadRequest(v1.DownloadRequestPhaseProcessed, v1.DownloadTargetKindBackupLog, "a-backup-20170912150214"),
@@ -258,6 +275,12 @@ func TestProcessDownloadRequest(t *testing.T) {
 				}
 			}
 
+			expectedTTL := persistence.DefaultDownloadURLTTL
+			if tc.ttl > 0 {
+				tc.downloadRequest.Spec.TTL.Duration = tc.ttl
+				expectedTTL = tc.ttl
+			}
+
 			if tc.downloadRequest != nil {
 				require.NoError(t, harness.informerFactory.Velero().V1().DownloadRequests().Informer().GetStore().Add(tc.downloadRequest))
 
@@ -278,7 +301,7 @@ func TestProcessDownloadRequest(t *testing.T) {
 			}
 
 			if tc.expectGetsURL {
-				harness.backupStore.On("GetDownloadURL", tc.downloadRequest.Spec.Target).Return("a-url", nil)
+				harness.backupStore.On("GetDownloadURL", tc.downloadRequest.Spec.Target, expectedTTL).Return("a-url", nil)
 			}
 
 			// exercise method under test
@@ -302,7 +325,14 @@ func TestProcessDownloadRequest(t *testing.T) {
 
 				assert.Equal(t, string(v1.DownloadRequestPhaseProcessed), string(output.Status.Phase))
 				assert.Equal(t, "a-url", output.Status.DownloadURL)
-				assert.True(t, velerotest.TimesAreEqual(harness.controller.clock.Now().Add(signedURLTTL), output.Status.Expiration.Time), "expiration does not match")
+				assert.True(t, velerotest.TimesAreEqual(harness.controller.clock.Now().Add(expectedTTL), output.Status.URLExpiration.Time), "URL expiration does not match")
+				assert.Equal(t, 1, output.Status.URLsIssued)
+
+				expectedExpiration := harness.controller.clock.Now().Add(time.Hour)
+				if expectedTTL > time.Hour {
+					expectedExpiration = harness.controller.clock.Now().Add(expectedTTL)
+				}
+				assert.True(t, velerotest.TimesAreEqual(expectedExpiration, output.Status.Expiration.Time), "expiration does not match")
 			}
 
 			if tc.downloadRequest != nil && tc.downloadRequest.Status.Phase == v1.DownloadRequestPhaseProcessed {
@@ -318,3 +348,158 @@ func TestProcessDownloadRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessDownloadRequestRegeneratesURL(t *testing.T) {
+	tests := []struct {
+		name              string
+		urlExpiresIn      time.Duration
+		requestExpiresIn  time.Duration
+		expectNewURL      bool
+		expectedRequeueIn time.Duration
+	}{
+		{
+			name:              "URL that's valid for more than a tenth of its TTL is not regenerated",
+			urlExpiresIn:      5 * time.Minute,
+			requestExpiresIn:  30 * time.Minute,
+			expectedRequeueIn: 4 * time.Minute,
+		},
+		{
+			name:              "URL that expires within a tenth of its TTL is regenerated",
+			urlExpiresIn:      30 * time.Second,
+			requestExpiresIn:  30 * time.Minute,
+			expectNewURL:      true,
+			expectedRequeueIn: 9 * time.Minute,
+		},
+		{
+			name:              "URL that has expired is regenerated",
+			urlExpiresIn:      -time.Minute,
+			requestExpiresIn:  30 * time.Minute,
+			expectNewURL:      true,
+			expectedRequeueIn: 9 * time.Minute,
+		},
+		{
+			name:              "URL that's valid for as long as the request exists is not regenerated",
+			urlExpiresIn:      30 * time.Second,
+			requestExpiresIn:  20 * time.Second,
+			expectedRequeueIn: 20 * time.Second,
+		},
+		{
+			name:              "regenerated URL is requeued for when the request expires if that's sooner",
+			urlExpiresIn:      30 * time.Second,
+			requestExpiresIn:  5 * time.Minute,
+			expectNewURL:      true,
+			expectedRequeueIn: 5 * time.Minute,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			harness := newDownloadRequestTestHarness(t)
+			now := harness.controller.clock.Now()
+
+			downloadRequest := newDownloadRequest(v1.DownloadRequestPhaseProcessed, v1.DownloadTargetKindBackupLog, "a-backup")
+			downloadRequest.Status.DownloadURL = "an-old-url"
+			downloadRequest.Status.URLsIssued = 1
+			downloadRequest.Status.URLExpiration = metav1.NewTime(now.Add(tc.urlExpiresIn))
+			downloadRequest.Status.Expiration = metav1.NewTime(now.Add(tc.requestExpiresIn))
+
+			require.NoError(t, harness.informerFactory.Velero().V1().DownloadRequests().Informer().GetStore().Add(downloadRequest))
+			_, err := harness.client.VeleroV1().DownloadRequests(downloadRequest.Namespace).Create(downloadRequest)
+			require.NoError(t, err)
+
+			backup := builder.ForBackup(v1.DefaultNamespace, "a-backup").StorageLocation("a-location").Result()
+			require.NoError(t, harness.informerFactory.Velero().V1().Backups().Informer().GetStore().Add(backup))
+			require.NoError(t, harness.informerFactory.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(newBackupLocation("a-location", "a-provider", "a-bucket")))
+
+			if tc.expectNewURL {
+				harness.backupStore.On("GetDownloadURL", downloadRequest.Spec.Target, persistence.DefaultDownloadURLTTL).Return("a-new-url", nil)
+			}
+
+			require.NoError(t, harness.controller.processDownloadRequest(kubeutil.NamespaceAndName(downloadRequest)))
+
+			output, err := harness.client.VeleroV1().DownloadRequests(downloadRequest.Namespace).Get(downloadRequest.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+
+			if tc.expectNewURL {
+				assert.Equal(t, "a-new-url", output.Status.DownloadURL)
+				assert.Equal(t, 2, output.Status.URLsIssued)
+				assert.True(t, velerotest.TimesAreEqual(now.Add(persistence.DefaultDownloadURLTTL), output.Status.URLExpiration.Time), "URL expiration does not match")
+			} else {
+				assert.Equal(t, "an-old-url", output.Status.DownloadURL)
+				assert.Equal(t, 1, output.Status.URLsIssued)
+			}
+			assert.True(t, velerotest.TimesAreEqual(downloadRequest.Status.Expiration.Time, output.Status.Expiration.Time), "expiration should not change")
+
+			harness.backupStore.AssertExpectations(t)
+
+			requeueIn := harness.controller.nextProcessingTime(output).Sub(now)
+			assert.Equal(t, tc.expectedRequeueIn, requeueIn)
+		})
+	}
+}
+
+func TestProcessDownloadRequestWithDownloadProxy(t *testing.T) {
+	tests := []struct {
+		name                 string
+		alwaysProxyDownloads bool
+		signedURLErr         error
+		expectProxyURL       bool
+	}{
+		{
+			name:         "pre-signed URL is returned when the object store can create one",
+			signedURLErr: nil,
+		},
+		{
+			name:           "proxy URL is returned when the object store can't create a pre-signed URL",
+			signedURLErr:   errors.New("signed URLs are not supported"),
+			expectProxyURL: true,
+		},
+		{
+			name:                 "proxy URL is returned when downloads are always proxied",
+			alwaysProxyDownloads: true,
+			expectProxyURL:       true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			harness := newDownloadRequestTestHarness(t)
+
+			signer := downloadproxy.NewSigner("https://velero.example.com:8443", []byte("key"))
+			harness.controller.downloadProxy = signer
+			harness.controller.alwaysProxyDownloads = tc.alwaysProxyDownloads
+
+			downloadRequest := newDownloadRequest("", v1.DownloadTargetKindBackupLog, "a-backup")
+			require.NoError(t, harness.informerFactory.Velero().V1().DownloadRequests().Informer().GetStore().Add(downloadRequest))
+			_, err := harness.client.VeleroV1().DownloadRequests(downloadRequest.Namespace).Create(downloadRequest)
+			require.NoError(t, err)
+
+			backup := builder.ForBackup(v1.DefaultNamespace, "a-backup").StorageLocation("a-location").Result()
+			require.NoError(t, harness.informerFactory.Velero().V1().Backups().Informer().GetStore().Add(backup))
+			require.NoError(t, harness.informerFactory.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(newBackupLocation("a-location", "a-provider", "a-bucket")))
+
+			if !tc.alwaysProxyDownloads {
+				harness.backupStore.On("GetDownloadURL", downloadRequest.Spec.Target, persistence.DefaultDownloadURLTTL).Return("a-url", tc.signedURLErr)
+			}
+
+			require.NoError(t, harness.controller.processDownloadRequest(kubeutil.NamespaceAndName(downloadRequest)))
+
+			output, err := harness.client.VeleroV1().DownloadRequests(downloadRequest.Namespace).Get(downloadRequest.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, v1.DownloadRequestPhaseProcessed, output.Status.Phase)
+
+			if !tc.expectProxyURL {
+				assert.Equal(t, "a-url", output.Status.DownloadURL)
+				return
+			}
+
+			require.True(t, strings.HasPrefix(output.Status.DownloadURL, "https://velero.example.com:8443"+downloadproxy.DownloadPath+"?"))
+			proxyURL, err := url.Parse(output.Status.DownloadURL)
+			require.NoError(t, err)
+
+			download, err := signer.Verify(proxyURL.Query())
+			require.NoError(t, err)
+			assert.Equal(t, downloadproxy.Download{Namespace: v1.DefaultNamespace, Location: "a-location", Target: downloadRequest.Spec.Target}, download)
+		})
+	}
+}
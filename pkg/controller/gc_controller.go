@@ -17,6 +17,8 @@ limitations under the License.
 package controller
 
 import (
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -46,6 +48,7 @@ type gcController struct {
 	deleteBackupRequestLister listers.DeleteBackupRequestLister
 	deleteBackupRequestClient velerov1client.DeleteBackupRequestsGetter
 	backupLocationLister      listers.BackupStorageLocationLister
+	scheduleLister            listers.ScheduleLister
 
 	clock clock.Clock
 }
@@ -57,6 +60,7 @@ func NewGCController(
 	deleteBackupRequestInformer informers.DeleteBackupRequestInformer,
 	deleteBackupRequestClient velerov1client.DeleteBackupRequestsGetter,
 	backupLocationInformer informers.BackupStorageLocationInformer,
+	scheduleInformer informers.ScheduleInformer,
 ) Interface {
 	c := &gcController{
 		genericController:         newGenericController("gc-controller", logger),
@@ -65,6 +69,7 @@ func NewGCController(
 		deleteBackupRequestLister: deleteBackupRequestInformer.Lister(),
 		deleteBackupRequestClient: deleteBackupRequestClient,
 		backupLocationLister:      backupLocationInformer.Lister(),
+		scheduleLister:            scheduleInformer.Lister(),
 	}
 
 	c.syncHandler = c.processQueueItem
@@ -72,6 +77,7 @@ func NewGCController(
 		backupInformer.Informer().HasSynced,
 		deleteBackupRequestInformer.Informer().HasSynced,
 		backupLocationInformer.Informer().HasSynced,
+		scheduleInformer.Informer().HasSynced,
 	)
 
 	c.resyncPeriod = GCSyncPeriod
@@ -137,16 +143,75 @@ func (c *gcController) processQueueItem(key string) error {
 
 	log.Info("Backup has expired")
 
-	loc, err := c.backupLocationLister.BackupStorageLocations(ns).Get(backup.Spec.StorageLocation)
+	if retained, err := c.retainedBySchedule(backup); err != nil {
+		return err
+	} else if retained {
+		log.Info("Backup is not garbage-collected because its schedule's retention policy decides when it's deleted")
+		return nil
+	}
+
+	return requestDeletion(backup, c.backupLister, c.backupLocationLister, c.deleteBackupRequestLister, c.deleteBackupRequestClient, log)
+}
+
+// retainedBySchedule returns whether a completed backup was created by a
+// schedule that has a retention policy, in which case the policy decides
+// when it's deleted rather than its expiration. Backups that didn't
+// complete still expire.
+func (c *gcController) retainedBySchedule(backup *velerov1api.Backup) (bool, error) {
+	scheduleName := backup.Labels[velerov1api.ScheduleNameLabel]
+	if scheduleName == "" || backup.Status.Phase != velerov1api.BackupPhaseCompleted {
+		return false, nil
+	}
+
+	schedule, err := c.scheduleLister.Schedules(backup.Namespace).Get(scheduleName)
 	if apierrors.IsNotFound(err) {
-		log.Warnf("Backup cannot be garbage-collected because backup storage location %s does not exist", backup.Spec.StorageLocation)
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "error getting backup's schedule")
+	}
+
+	// the retention controller skips schedules that aren't enabled.
+	return schedule.Spec.Retention != nil && schedule.Status.Phase == velerov1api.SchedulePhaseEnabled, nil
+}
+
+// requestDeletion creates a DeleteBackupRequest for a backup, unless it's
+// delete-protected, its storage location is read-only, other backups refer
+// to it, or it already has a pending deletion request.
+func requestDeletion(
+	backup *velerov1api.Backup,
+	backupLister listers.BackupLister,
+	backupLocationLister listers.BackupStorageLocationLister,
+	deleteBackupRequestLister listers.DeleteBackupRequestLister,
+	deleteBackupRequestClient velerov1client.DeleteBackupRequestsGetter,
+	log logrus.FieldLogger,
+) error {
+	ns := backup.Namespace
+
+	if backup.Spec.DeleteProtection {
+		log.Info("Backup cannot be deleted because it's delete-protected")
+		return nil
+	}
+
+	loc, err := backupLocationLister.BackupStorageLocations(ns).Get(backup.Spec.StorageLocation)
+	if apierrors.IsNotFound(err) {
+		log.Warnf("Backup cannot be deleted because backup storage location %s does not exist", backup.Spec.StorageLocation)
 	}
 	if err != nil {
 		return errors.Wrap(err, "error getting backup storage location")
 	}
 
 	if loc.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
-		log.Infof("Backup cannot be garbage-collected because backup storage location %s is currently in read-only mode", loc.Name)
+		log.Infof("Backup cannot be deleted because backup storage location %s is currently in read-only mode", loc.Name)
+		return nil
+	}
+
+	referencing, err := referencingBackups(backupLister, backup)
+	if err != nil {
+		return err
+	}
+	if len(referencing) > 0 {
+		log.Infof("Backup cannot be deleted because backups %s refer to its unchanged items", strings.Join(referencing, ", "))
 		return nil
 	}
 
@@ -155,7 +220,7 @@ func (c *gcController) processQueueItem(key string) error {
 		velerov1api.BackupUIDLabel:  string(backup.UID),
 	}))
 
-	dbrs, err := c.deleteBackupRequestLister.DeleteBackupRequests(ns).List(selector)
+	dbrs, err := deleteBackupRequestLister.DeleteBackupRequests(ns).List(selector)
 	if err != nil {
 		return errors.Wrap(err, "error listing existing DeleteBackupRequests for backup")
 	}
@@ -173,9 +238,35 @@ func (c *gcController) processQueueItem(key string) error {
 	log.Info("Creating a new deletion request")
 	req := pkgbackup.NewDeleteBackupRequest(backup.Name, string(backup.UID))
 
-	if _, err = c.deleteBackupRequestClient.DeleteBackupRequests(ns).Create(req); err != nil {
+	if _, err = deleteBackupRequestClient.DeleteBackupRequests(ns).Create(req); err != nil {
 		return errors.Wrap(err, "error creating DeleteBackupRequest")
 	}
 
 	return nil
 }
+
+// referencingBackups returns the names of the backups in the same storage
+// location as the given backup that refer to unchanged items stored in the
+// backup's tarball, and so can't be restored without it.
+func referencingBackups(lister listers.BackupLister, backup *velerov1api.Backup) ([]string, error) {
+	backups, err := lister.Backups(backup.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing backups")
+	}
+
+	var res []string
+	for _, candidate := range backups {
+		if candidate.Name == backup.Name || candidate.Spec.StorageLocation != backup.Spec.StorageLocation {
+			continue
+		}
+		for _, referenced := range candidate.Status.ReferencedBackups {
+			if referenced == backup.Name {
+				res = append(res, candidate.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
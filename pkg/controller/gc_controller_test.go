@@ -51,6 +51,7 @@ func TestGCControllerEnqueueAllBackups(t *testing.T) {
 			sharedInformers.Velero().V1().DeleteBackupRequests(),
 			client.VeleroV1(),
 			sharedInformers.Velero().V1().BackupStorageLocations(),
+			sharedInformers.Velero().V1().Schedules(),
 		).(*gcController)
 	)
 
@@ -115,6 +116,7 @@ func TestGCControllerHasUpdateFunc(t *testing.T) {
 		sharedInformers.Velero().V1().DeleteBackupRequests(),
 		client.VeleroV1(),
 		sharedInformers.Velero().V1().BackupStorageLocations(),
+		sharedInformers.Velero().V1().Schedules(),
 	).(*gcController)
 
 	keys := make(chan string)
@@ -157,8 +159,10 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 	tests := []struct {
 		name                           string
 		backup                         *api.Backup
+		otherBackups                   []*api.Backup
 		deleteBackupRequests           []*api.DeleteBackupRequest
 		backupLocation                 *api.BackupStorageLocation
+		schedule                       *api.Schedule
 		expectDeletion                 bool
 		createDeleteBackupRequestError bool
 		expectError                    bool
@@ -190,6 +194,47 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 			backupLocation: defaultBackupLocation,
 			expectDeletion: true,
 		},
+		{
+			name:           "expired delete-protected backup is not deleted",
+			backup:         defaultBackup().Expiration(fakeClock.Now().Add(-time.Second)).StorageLocation("default").DeleteProtection(true).Result(),
+			backupLocation: defaultBackupLocation,
+			expectDeletion: false,
+		},
+		{
+			name:           "expired backup that another backup refers to is not deleted",
+			backup:         defaultBackup().Expiration(fakeClock.Now().Add(-time.Second)).StorageLocation("default").Result(),
+			otherBackups:   []*api.Backup{builder.ForBackup(api.DefaultNamespace, "backup-2").StorageLocation("default").ReferencedBackups("backup-1").Result()},
+			backupLocation: defaultBackupLocation,
+			expectDeletion: false,
+		},
+		{
+			name:           "expired backup that a backup in another location refers to is deleted",
+			backup:         defaultBackup().Expiration(fakeClock.Now().Add(-time.Second)).StorageLocation("default").Result(),
+			otherBackups:   []*api.Backup{builder.ForBackup(api.DefaultNamespace, "backup-2").StorageLocation("other").ReferencedBackups("backup-1").Result()},
+			backupLocation: defaultBackupLocation,
+			expectDeletion: true,
+		},
+		{
+			name:           "expired completed backup of a schedule with a retention policy is not deleted",
+			backup:         defaultBackup().Expiration(fakeClock.Now().Add(-time.Second)).StorageLocation("default").Phase(api.BackupPhaseCompleted).ObjectMeta(builder.WithLabels(api.ScheduleNameLabel, "schedule-1")).Result(),
+			backupLocation: defaultBackupLocation,
+			schedule:       builder.ForSchedule(api.DefaultNamespace, "schedule-1").Phase(api.SchedulePhaseEnabled).Retention(api.ScheduleRetention{KeepDaily: 7}).Result(),
+			expectDeletion: false,
+		},
+		{
+			name:           "expired failed backup of a schedule with a retention policy is deleted",
+			backup:         defaultBackup().Expiration(fakeClock.Now().Add(-time.Second)).StorageLocation("default").Phase(api.BackupPhaseFailed).ObjectMeta(builder.WithLabels(api.ScheduleNameLabel, "schedule-1")).Result(),
+			backupLocation: defaultBackupLocation,
+			schedule:       builder.ForSchedule(api.DefaultNamespace, "schedule-1").Phase(api.SchedulePhaseEnabled).Retention(api.ScheduleRetention{KeepDaily: 7}).Result(),
+			expectDeletion: true,
+		},
+		{
+			name:           "expired completed backup of a schedule without a retention policy is deleted",
+			backup:         defaultBackup().Expiration(fakeClock.Now().Add(-time.Second)).StorageLocation("default").Phase(api.BackupPhaseCompleted).ObjectMeta(builder.WithLabels(api.ScheduleNameLabel, "schedule-1")).Result(),
+			backupLocation: defaultBackupLocation,
+			schedule:       builder.ForSchedule(api.DefaultNamespace, "schedule-1").Result(),
+			expectDeletion: true,
+		},
 		{
 			name:           "expired backup with a pending deletion request is not deleted",
 			backup:         defaultBackup().Expiration(fakeClock.Now().Add(-time.Second)).StorageLocation("default").Result(),
@@ -255,6 +300,7 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 				sharedInformers.Velero().V1().DeleteBackupRequests(),
 				client.VeleroV1(),
 				sharedInformers.Velero().V1().BackupStorageLocations(),
+				sharedInformers.Velero().V1().Schedules(),
 			).(*gcController)
 			controller.clock = fakeClock
 
@@ -264,10 +310,18 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 				sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(test.backup)
 			}
 
+			for _, backup := range test.otherBackups {
+				sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup)
+			}
+
 			if test.backupLocation != nil {
 				sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(test.backupLocation)
 			}
 
+			if test.schedule != nil {
+				sharedInformers.Velero().V1().Schedules().Informer().GetStore().Add(test.schedule)
+			}
+
 			for _, dbr := range test.deleteBackupRequests {
 				sharedInformers.Velero().V1().DeleteBackupRequests().Informer().GetStore().Add(dbr)
 			}
@@ -0,0 +1,351 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+)
+
+// operationHistoryMonthFormat is the format of an OperationHistory's month.
+const operationHistoryMonthFormat = "2006-01"
+
+// operationHistoryController records each finished backup and restore in
+// the OperationHistory for the month it started in, and deletes the
+// histories of months older than its retention.
+type operationHistoryController struct {
+	*genericController
+
+	namespace              string
+	backupLister           listers.BackupLister
+	restoreLister          listers.RestoreLister
+	operationHistoryLister listers.OperationHistoryLister
+	operationHistoryClient velerov1client.OperationHistoriesGetter
+	months                 int
+	maxOperations          int
+	clock                  clock.Clock
+}
+
+// NewOperationHistoryController constructs a controller that keeps the
+// OperationHistories of the last months months, each with up to
+// maxOperations operations. If months or maxOperations is zero, there's
+// no limit.
+func NewOperationHistoryController(
+	namespace string,
+	backupInformer informers.BackupInformer,
+	restoreInformer informers.RestoreInformer,
+	operationHistoryInformer informers.OperationHistoryInformer,
+	operationHistoryClient velerov1client.OperationHistoriesGetter,
+	months int,
+	maxOperations int,
+	logger logrus.FieldLogger,
+) Interface {
+	c := &operationHistoryController{
+		genericController:      newGenericController("operation-history", logger),
+		namespace:              namespace,
+		backupLister:           backupInformer.Lister(),
+		restoreLister:          restoreInformer.Lister(),
+		operationHistoryLister: operationHistoryInformer.Lister(),
+		operationHistoryClient: operationHistoryClient,
+		months:                 months,
+		maxOperations:          maxOperations,
+		clock:                  clock.RealClock{},
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.resyncFunc = c.deleteOldHistories
+	c.resyncPeriod = time.Hour
+	c.cacheSyncWaiters = []cache.InformerSynced{
+		backupInformer.Informer().HasSynced,
+		restoreInformer.Informer().HasSynced,
+		operationHistoryInformer.Informer().HasSynced,
+	}
+
+	backupInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueueBackup,
+			UpdateFunc: func(_, obj interface{}) {
+				c.enqueueBackup(obj)
+			},
+		},
+	)
+
+	restoreInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueueRestore,
+			UpdateFunc: func(_, obj interface{}) {
+				c.enqueueRestore(obj)
+			},
+		},
+	)
+
+	return c
+}
+
+func (c *operationHistoryController) enqueueBackup(obj interface{}) {
+	backup := obj.(*velerov1api.Backup)
+
+	switch backup.Status.Phase {
+	case velerov1api.BackupPhaseCompleted, velerov1api.BackupPhasePartiallyFailed, velerov1api.BackupPhaseFailed, velerov1api.BackupPhaseFailedValidation:
+		c.enqueueOperation(velerov1api.OperationKindBackup, backup)
+	}
+}
+
+func (c *operationHistoryController) enqueueRestore(obj interface{}) {
+	restore := obj.(*velerov1api.Restore)
+
+	switch restore.Status.Phase {
+	case velerov1api.RestorePhaseCompleted, velerov1api.RestorePhasePartiallyFailed, velerov1api.RestorePhaseFailed, velerov1api.RestorePhaseFailedValidation:
+		c.enqueueOperation(velerov1api.OperationKindRestore, restore)
+	}
+}
+
+// enqueueOperation adds a finished backup or restore to the queue, with
+// its kind as the first part of its key.
+func (c *operationHistoryController) enqueueOperation(kind velerov1api.OperationKind, obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error creating queue key, item not added to queue")
+		return
+	}
+	c.queue.Add(fmt.Sprintf("%s/%s", kind, key))
+}
+
+func (c *operationHistoryController) processQueueItem(key string) error {
+	log := c.logger.WithField("key", key)
+
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		log.Error("Invalid queue key")
+		return nil
+	}
+	ns, name, err := cache.SplitMetaNamespaceKey(parts[1])
+	if err != nil {
+		log.WithError(errors.WithStack(err)).Error("Error splitting queue key")
+		return nil
+	}
+
+	var record velerov1api.OperationRecord
+	switch velerov1api.OperationKind(parts[0]) {
+	case velerov1api.OperationKindBackup:
+		backup, err := c.backupLister.Backups(ns).Get(name)
+		if apierrors.IsNotFound(err) {
+			log.Debug("Unable to find backup")
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "error getting backup")
+		}
+		record = backupOperationRecord(backup)
+	case velerov1api.OperationKindRestore:
+		restore, err := c.restoreLister.Restores(ns).Get(name)
+		if apierrors.IsNotFound(err) {
+			log.Debug("Unable to find restore")
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "error getting restore")
+		}
+		record = restoreOperationRecord(restore)
+	default:
+		log.Error("Invalid queue key")
+		return nil
+	}
+
+	return c.recordOperation(record, log)
+}
+
+func backupOperationRecord(backup *velerov1api.Backup) velerov1api.OperationRecord {
+	record := velerov1api.OperationRecord{
+		Kind:            velerov1api.OperationKindBackup,
+		Name:            backup.Name,
+		Phase:           string(backup.Status.Phase),
+		StartTimestamp:  backup.Status.StartTimestamp,
+		Schedule:        backup.Labels[velerov1api.ScheduleNameLabel],
+		StorageLocation: backup.Spec.StorageLocation,
+		Warnings:        backup.Status.Warnings,
+		Errors:          backup.Status.Errors,
+	}
+
+	// backups that fail validation never start.
+	if record.StartTimestamp.IsZero() {
+		record.StartTimestamp = backup.CreationTimestamp
+	}
+	if !backup.Status.CompletionTimestamp.IsZero() {
+		completion := backup.Status.CompletionTimestamp
+		record.CompletionTimestamp = &completion
+	}
+
+	return record
+}
+
+func restoreOperationRecord(restore *velerov1api.Restore) velerov1api.OperationRecord {
+	return velerov1api.OperationRecord{
+		Kind:           velerov1api.OperationKindRestore,
+		Name:           restore.Name,
+		Phase:          string(restore.Status.Phase),
+		StartTimestamp: restore.CreationTimestamp,
+		Backup:         restore.Spec.BackupName,
+		Warnings:       restore.Status.Warnings,
+		Errors:         restore.Status.Errors,
+	}
+}
+
+// recordOperation adds an operation to the history for the month it
+// started in, unless it's already there.
+func (c *operationHistoryController) recordOperation(record velerov1api.OperationRecord, log logrus.FieldLogger) error {
+	start := record.StartTimestamp.UTC()
+	if start.Before(c.retentionStart()) {
+		log.Debug("Operation is older than the operation history's retention, not recording it")
+		return nil
+	}
+
+	month := start.Format(operationHistoryMonthFormat)
+	name := operationHistoryName(month)
+	log = log.WithField("operationHistory", name)
+
+	// all of the operations are queued when the server starts, and most
+	// of them are already recorded, so check the cache before getting the
+	// history from the API.
+	if history, err := c.operationHistoryLister.OperationHistories(c.namespace).Get(name); err == nil && hasOperation(history, record) {
+		return nil
+	}
+
+	history, err := c.operationHistoryClient.OperationHistories(c.namespace).Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		history = &velerov1api.OperationHistory{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: c.namespace,
+				Name:      name,
+			},
+			Spec: velerov1api.OperationHistorySpec{
+				Month: month,
+			},
+			Status: velerov1api.OperationHistoryStatus{
+				Operations: []velerov1api.OperationRecord{record},
+			},
+		}
+
+		if _, err := c.operationHistoryClient.OperationHistories(c.namespace).Create(history); err != nil {
+			return errors.Wrap(err, "error creating operation history")
+		}
+		log.Debug("Recorded operation in new operation history")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting operation history")
+	}
+
+	if hasOperation(history, record) {
+		return nil
+	}
+
+	history.Status.Operations = append(history.Status.Operations, record)
+	sort.SliceStable(history.Status.Operations, func(i, j int) bool {
+		return history.Status.Operations[i].StartTimestamp.Before(&history.Status.Operations[j].StartTimestamp)
+	})
+
+	// drop the oldest operations to keep the history within its maximum
+	// size.
+	if c.maxOperations > 0 && len(history.Status.Operations) > c.maxOperations {
+		dropped := len(history.Status.Operations) - c.maxOperations
+		history.Status.Operations = history.Status.Operations[dropped:]
+		history.Status.DroppedOperations += dropped
+	}
+
+	// a conflict means the history was updated since it was fetched, so
+	// the operation is requeued and recorded in the updated history.
+	if _, err := c.operationHistoryClient.OperationHistories(c.namespace).Update(history); err != nil {
+		return errors.Wrap(err, "error updating operation history")
+	}
+	log.Debug("Recorded operation in operation history")
+
+	return nil
+}
+
+func operationHistoryName(month string) string {
+	return "operations-" + month
+}
+
+// hasOperation returns true if an operation is recorded in a history. A
+// backup or restore is identified by its name and start time, since its
+// UID changes if it's synced into another cluster.
+func hasOperation(history *velerov1api.OperationHistory, record velerov1api.OperationRecord) bool {
+	for _, op := range history.Status.Operations {
+		if op.Kind == record.Kind && op.Name == record.Name && op.StartTimestamp.Equal(&record.StartTimestamp) {
+			return true
+		}
+	}
+	return false
+}
+
+// retentionStart returns the start of the oldest month that's kept, or the
+// zero time if all of them are.
+func (c *operationHistoryController) retentionStart() time.Time {
+	if c.months <= 0 {
+		return time.Time{}
+	}
+
+	now := c.clock.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return thisMonth.AddDate(0, -(c.months - 1), 0)
+}
+
+// deleteOldHistories deletes the histories of months older than the
+// retention.
+func (c *operationHistoryController) deleteOldHistories() {
+	if c.months <= 0 {
+		return
+	}
+
+	histories, err := c.operationHistoryLister.OperationHistories(c.namespace).List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing operation histories")
+		return
+	}
+
+	oldestMonth := c.retentionStart().Format(operationHistoryMonthFormat)
+	for _, history := range histories {
+		// months in the YYYY-MM format sort chronologically.
+		if history.Spec.Month >= oldestMonth {
+			continue
+		}
+
+		log := c.logger.WithField("operationHistory", history.Name)
+		if err := c.operationHistoryClient.OperationHistories(c.namespace).Delete(history.Name, nil); err != nil {
+			log.WithError(errors.WithStack(err)).Error("Error deleting old operation history")
+			continue
+		}
+		log.Info("Deleted old operation history")
+	}
+}
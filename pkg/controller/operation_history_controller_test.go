@@ -0,0 +1,234 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func operationHistoryTestTime(val string) metav1.Time {
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		panic(err)
+	}
+	return metav1.NewTime(t)
+}
+
+func operationHistoryTestRecord(name, started string) velerov1api.OperationRecord {
+	return velerov1api.OperationRecord{
+		Kind:           velerov1api.OperationKindBackup,
+		Name:           name,
+		Phase:          string(velerov1api.BackupPhaseCompleted),
+		StartTimestamp: operationHistoryTestTime(started),
+	}
+}
+
+func operationHistoryTestHistory(month string, records ...velerov1api.OperationRecord) *velerov1api.OperationHistory {
+	return &velerov1api.OperationHistory{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: velerov1api.DefaultNamespace,
+			Name:      operationHistoryName(month),
+		},
+		Spec:   velerov1api.OperationHistorySpec{Month: month},
+		Status: velerov1api.OperationHistoryStatus{Operations: records},
+	}
+}
+
+func TestOperationHistoryControllerProcessQueueItem(t *testing.T) {
+	completed := builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").
+		ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "daily")).
+		StorageLocation("default").
+		Phase(velerov1api.BackupPhaseCompleted).
+		StartTimestamp(operationHistoryTestTime("2019-10-01T12:00:00Z").Time).
+		CompletionTimestamp(operationHistoryTestTime("2019-10-01T12:05:00Z").Time).
+		Result()
+	completedRecord := velerov1api.OperationRecord{
+		Kind:                velerov1api.OperationKindBackup,
+		Name:                "backup-1",
+		Phase:               string(velerov1api.BackupPhaseCompleted),
+		StartTimestamp:      operationHistoryTestTime("2019-10-01T12:00:00Z"),
+		CompletionTimestamp: &completed.Status.CompletionTimestamp,
+		Schedule:            "daily",
+		StorageLocation:     "default",
+	}
+
+	restore := builder.ForRestore(velerov1api.DefaultNamespace, "restore-1").
+		Backup("backup-1").
+		Phase(velerov1api.RestorePhasePartiallyFailed).
+		Result()
+	restore.CreationTimestamp = operationHistoryTestTime("2019-10-02T08:00:00Z")
+	restore.Status.Errors = 2
+
+	old := builder.ForBackup(velerov1api.DefaultNamespace, "backup-old").
+		Phase(velerov1api.BackupPhaseCompleted).
+		StartTimestamp(operationHistoryTestTime("2018-01-01T12:00:00Z").Time).
+		Result()
+
+	tests := []struct {
+		name              string
+		key               string
+		existingHistories []*velerov1api.OperationHistory
+		maxOperations     int
+		expectedHistory   *velerov1api.OperationHistory
+		expectNoActions   bool
+	}{
+		{
+			name:            "a finished backup is recorded in a new history for its month",
+			key:             "Backup/velero/backup-1",
+			expectedHistory: operationHistoryTestHistory("2019-10", completedRecord),
+		},
+		{
+			name: "a finished restore is added to its month's history",
+			key:  "Restore/velero/restore-1",
+			existingHistories: []*velerov1api.OperationHistory{
+				operationHistoryTestHistory("2019-10", completedRecord),
+			},
+			expectedHistory: operationHistoryTestHistory("2019-10", completedRecord, velerov1api.OperationRecord{
+				Kind:           velerov1api.OperationKindRestore,
+				Name:           "restore-1",
+				Phase:          string(velerov1api.RestorePhasePartiallyFailed),
+				StartTimestamp: operationHistoryTestTime("2019-10-02T08:00:00Z"),
+				Backup:         "backup-1",
+				Errors:         2,
+			}),
+		},
+		{
+			name: "an operation that's already recorded isn't recorded again",
+			key:  "Backup/velero/backup-1",
+			existingHistories: []*velerov1api.OperationHistory{
+				operationHistoryTestHistory("2019-10", completedRecord),
+			},
+			expectNoActions: true,
+		},
+		{
+			name: "the oldest operations are dropped when the history is full",
+			key:  "Backup/velero/backup-1",
+			existingHistories: []*velerov1api.OperationHistory{
+				operationHistoryTestHistory("2019-10",
+					operationHistoryTestRecord("backup-a", "2019-10-01T01:00:00Z"),
+					operationHistoryTestRecord("backup-b", "2019-10-01T02:00:00Z"),
+				),
+			},
+			maxOperations: 2,
+			expectedHistory: func() *velerov1api.OperationHistory {
+				history := operationHistoryTestHistory("2019-10", operationHistoryTestRecord("backup-b", "2019-10-01T02:00:00Z"), completedRecord)
+				history.Status.DroppedOperations = 1
+				return history
+			}(),
+		},
+		{
+			name:            "an operation older than the retention isn't recorded",
+			key:             "Backup/velero/backup-old",
+			expectNoActions: true,
+		},
+		{
+			name:            "a backup that no longer exists isn't recorded",
+			key:             "Backup/velero/nonexistent",
+			expectNoActions: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			c := NewOperationHistoryController(
+				velerov1api.DefaultNamespace,
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().Restores(),
+				sharedInformers.Velero().V1().OperationHistories(),
+				client.VeleroV1(),
+				12,
+				test.maxOperations,
+				velerotest.NewLogger(),
+			).(*operationHistoryController)
+			c.clock = clock.NewFakeClock(operationHistoryTestTime("2019-10-15T00:00:00Z").Time)
+
+			for _, backup := range []*velerov1api.Backup{completed, old} {
+				require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup))
+			}
+			require.NoError(t, sharedInformers.Velero().V1().Restores().Informer().GetStore().Add(restore))
+			for _, history := range test.existingHistories {
+				require.NoError(t, sharedInformers.Velero().V1().OperationHistories().Informer().GetStore().Add(history))
+				_, err := client.VeleroV1().OperationHistories(history.Namespace).Create(history)
+				require.NoError(t, err)
+			}
+			client.ClearActions()
+
+			require.NoError(t, c.processQueueItem(test.key))
+
+			if test.expectNoActions {
+				assert.Empty(t, client.Actions())
+				return
+			}
+
+			history, err := client.VeleroV1().OperationHistories(velerov1api.DefaultNamespace).Get(test.expectedHistory.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedHistory.Spec, history.Spec)
+			assert.Equal(t, test.expectedHistory.Status, history.Status)
+		})
+	}
+}
+
+func TestOperationHistoryControllerDeleteOldHistories(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+	)
+
+	c := NewOperationHistoryController(
+		velerov1api.DefaultNamespace,
+		sharedInformers.Velero().V1().Backups(),
+		sharedInformers.Velero().V1().Restores(),
+		sharedInformers.Velero().V1().OperationHistories(),
+		client.VeleroV1(),
+		3,
+		0,
+		velerotest.NewLogger(),
+	).(*operationHistoryController)
+	c.clock = clock.NewFakeClock(operationHistoryTestTime("2019-10-15T00:00:00Z").Time)
+
+	for _, month := range []string{"2019-07", "2019-08", "2019-09", "2019-10"} {
+		require.NoError(t, sharedInformers.Velero().V1().OperationHistories().Informer().GetStore().Add(operationHistoryTestHistory(month)))
+	}
+
+	c.deleteOldHistories()
+
+	var deleted []string
+	for _, action := range client.Actions() {
+		if action, ok := action.(core.DeleteAction); ok {
+			deleted = append(deleted, action.GetName())
+		}
+	}
+	assert.Equal(t, []string{"operations-2019-07"}, deleted)
+}
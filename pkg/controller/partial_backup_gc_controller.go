@@ -0,0 +1,239 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/metrics"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+)
+
+const partialBackupGCSyncPeriod = time.Hour
+
+// partialBackupGCController periodically deletes the backup directories in
+// object storage that don't have a metadata file, which are left behind when
+// uploading a backup fails part way and cleaning up the uploaded files fails
+// too. Directories that belong to a backup in the cluster are kept, since
+// failed backups upload their logs without a metadata file. It also deletes
+// the chunks of deduplicated backup contents that no backup references.
+type partialBackupGCController struct {
+	*genericController
+
+	namespace            string
+	backupLister         listers.BackupLister
+	backupLocationLister listers.BackupStorageLocationLister
+	gracePeriod          time.Duration
+	dryRun               bool
+	newPluginManager     func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore       func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	metrics              *metrics.ServerMetrics
+	clock                clock.Clock
+
+	// firstSeen is when each partially uploaded backup or unreferenced
+	// chunk, keyed by location and name, was first found. Object stores don't report when an
+	// object was written, so the grace period starts from when it's found.
+	firstSeen map[string]time.Time
+}
+
+// NewPartialBackupGCController constructs a new partialBackupGCController.
+func NewPartialBackupGCController(
+	namespace string,
+	backupInformer informers.BackupInformer,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+	gracePeriod time.Duration,
+	dryRun bool,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+	metrics *metrics.ServerMetrics,
+	logger logrus.FieldLogger,
+) Interface {
+	c := &partialBackupGCController{
+		genericController:    newGenericController("partial-backup-gc", logger),
+		namespace:            namespace,
+		backupLister:         backupInformer.Lister(),
+		backupLocationLister: backupLocationInformer.Lister(),
+		gracePeriod:          gracePeriod,
+		dryRun:               dryRun,
+		newPluginManager:     newPluginManager,
+		newBackupStore:       persistence.NewBackupStore,
+		metrics:              metrics,
+		clock:                clock.RealClock{},
+		firstSeen:            make(map[string]time.Time),
+	}
+
+	c.resyncFunc = c.run
+	c.resyncPeriod = partialBackupGCSyncPeriod
+	c.cacheSyncWaiters = []cache.InformerSynced{
+		backupInformer.Informer().HasSynced,
+		backupLocationInformer.Informer().HasSynced,
+	}
+
+	return c
+}
+
+func (c *partialBackupGCController) run() {
+	locations, err := c.backupLocationLister.BackupStorageLocations(c.namespace).List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing backup storage locations")
+		return
+	}
+
+	found := sets.NewString()
+	for _, location := range locations {
+		log := c.logger.WithField("backupLocation", location.Name)
+
+		if location.Spec.AccessMode == velerov1api.BackupStorageLocationAccessModeReadOnly {
+			log.Debug("Skipping backup storage location because it's read-only")
+			continue
+		}
+
+		keys, err := c.processLocation(location, log)
+		if err != nil {
+			log.WithError(err).Error("Error garbage-collecting partially uploaded backups")
+			continue
+		}
+		found.Insert(keys...)
+	}
+
+	// forget the partially uploaded backups that have been deleted, or
+	// whose location has been removed.
+	for key := range c.firstSeen {
+		if !found.Has(key) {
+			delete(c.firstSeen, key)
+		}
+	}
+}
+
+// processLocation deletes the partially uploaded backups and unreferenced
+// chunks in the location that are older than the grace period, and returns
+// the keys of those that are left.
+func (c *partialBackupGCController) processLocation(location *velerov1api.BackupStorageLocation, log logrus.FieldLogger) ([]string, error) {
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting backup store")
+	}
+
+	backups, err := backupStore.ListBackups()
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing backups in backup store")
+	}
+
+	var bucket string
+	if location.Spec.ObjectStorage != nil {
+		bucket = location.Spec.ObjectStorage.Bucket
+	}
+
+	var (
+		now  = c.clock.Now()
+		keys []string
+	)
+	for _, name := range backups {
+		log := log.WithField("backup", name)
+
+		exists, err := backupStore.BackupExists(bucket, name)
+		if err != nil {
+			log.WithError(err).Error("Error checking if backup's metadata file exists")
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := c.backupLister.Backups(c.namespace).Get(name); err == nil {
+			continue
+		} else if !apierrors.IsNotFound(err) {
+			log.WithError(errors.WithStack(err)).Error("Error getting backup")
+			continue
+		}
+
+		key := location.Name + "/" + name
+		deleted, err := c.expire(key, now, "partially uploaded backup", func() error { return backupStore.DeleteBackup(name) }, log)
+		if err != nil {
+			c.metrics.RegisterPartialBackupDeletionFailure(location.Name)
+		}
+		if !deleted {
+			keys = append(keys, key)
+			continue
+		}
+		c.metrics.RegisterPartialBackupDeletion(location.Name)
+	}
+
+	c.metrics.SetPartialBackups(location.Name, len(keys))
+
+	// chunks of deduplicated backup contents are left behind when the
+	// backups that reference them are deleted.
+	chunks, err := backupStore.ListUnreferencedChunks()
+	if err != nil {
+		log.WithError(err).Error("Error listing unreferenced chunks")
+		return keys, nil
+	}
+
+	for _, hash := range chunks {
+		log := log.WithField("chunk", hash)
+
+		key := location.Name + "/chunks/" + hash
+		if deleted, _ := c.expire(key, now, "unreferenced chunk", func() error { return backupStore.DeleteChunk(hash) }, log); !deleted {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// expire records when the object with the given key was first found, and
+// deletes it once it's been found for longer than the grace period. It
+// returns whether the object was deleted.
+func (c *partialBackupGCController) expire(key string, now time.Time, description string, deleteFunc func() error, log logrus.FieldLogger) (bool, error) {
+	firstSeen, ok := c.firstSeen[key]
+	if !ok {
+		log.Infof("Found %s", description)
+		firstSeen = now
+		c.firstSeen[key] = now
+	}
+
+	if now.Sub(firstSeen) < c.gracePeriod {
+		return false, nil
+	}
+
+	if c.dryRun {
+		log.Infof("Not deleting %s because partial backup garbage collection is in dry-run mode", description)
+		return false, nil
+	}
+
+	log.Infof("Deleting %s", description)
+	if err := deleteFunc(); err != nil {
+		log.WithError(err).Errorf("Error deleting %s", description)
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/metrics"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestPartialBackupGCControllerRun(t *testing.T) {
+	tests := []struct {
+		name            string
+		dryRun          bool
+		readOnly        bool
+		expectedDeleted bool
+	}{
+		{
+			name:            "partial backup is deleted after the grace period",
+			expectedDeleted: true,
+		},
+		{
+			name:   "partial backup isn't deleted in dry-run mode",
+			dryRun: true,
+		},
+		{
+			name:     "read-only location is skipped",
+			readOnly: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+				fakeClock       = clock.NewFakeClock(time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC))
+				location        = builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket")
+			)
+			if test.readOnly {
+				location.AccessMode(velerov1api.BackupStorageLocationAccessModeReadOnly)
+			}
+
+			c := NewPartialBackupGCController(
+				velerov1api.DefaultNamespace,
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				time.Hour,
+				test.dryRun,
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+				metrics.NewServerMetrics(),
+				velerotest.NewLogger(),
+			).(*partialBackupGCController)
+			c.clock = fakeClock
+			c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+				return backupStore, nil
+			}
+
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location.Result()))
+			// a failed backup has logs but no metadata file in object storage.
+			require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(
+				builder.ForBackup(velerov1api.DefaultNamespace, "failed").Phase(velerov1api.BackupPhaseFailed).Result(),
+			))
+
+			pluginManager.On("CleanupClients").Return(nil)
+			backupStore.On("ListBackups").Return([]string{"completed", "failed", "partial"}, nil)
+			backupStore.On("BackupExists", "bucket", "completed").Return(true, nil)
+			backupStore.On("BackupExists", "bucket", "failed").Return(false, nil)
+			backupStore.On("BackupExists", "bucket", "partial").Return(false, nil)
+			backupStore.On("DeleteBackup", "partial").Return(nil)
+			backupStore.On("ListUnreferencedChunks").Return([]string{"unreferenced"}, nil)
+			backupStore.On("DeleteChunk", "unreferenced").Return(nil)
+
+			// the partial backup is only found on the first run, so it's
+			// within the grace period.
+			c.run()
+			backupStore.AssertNotCalled(t, "DeleteBackup", "partial")
+			backupStore.AssertNotCalled(t, "DeleteChunk", "unreferenced")
+
+			fakeClock.Step(2 * time.Hour)
+			c.run()
+
+			if test.expectedDeleted {
+				backupStore.AssertCalled(t, "DeleteBackup", "partial")
+				backupStore.AssertCalled(t, "DeleteChunk", "unreferenced")
+				assert.Empty(t, c.firstSeen)
+			} else {
+				backupStore.AssertNotCalled(t, "DeleteBackup", "partial")
+				backupStore.AssertNotCalled(t, "DeleteChunk", "unreferenced")
+			}
+			backupStore.AssertNotCalled(t, "DeleteBackup", "completed")
+			backupStore.AssertNotCalled(t, "DeleteBackup", "failed")
+
+			if test.readOnly {
+				backupStore.AssertNotCalled(t, "ListBackups")
+			}
+		})
+	}
+}
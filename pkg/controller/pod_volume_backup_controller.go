@@ -21,12 +21,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/clock"
 	corev1informers "k8s.io/client-go/informers/core/v1"
@@ -37,8 +39,7 @@ import (
 	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
-	"github.com/heptio/velero/pkg/restic"
-	veleroexec "github.com/heptio/velero/pkg/util/exec"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/util/filesystem"
 	"github.com/heptio/velero/pkg/util/kube"
 )
@@ -54,8 +55,11 @@ type podVolumeBackupController struct {
 	pvLister              corev1listers.PersistentVolumeLister
 	backupLocationLister  listers.BackupStorageLocationLister
 	nodeName              string
+	retries               int
+	retryBackoff          time.Duration
 
 	processBackupFunc func(*velerov1api.PodVolumeBackup) error
+	newUploader       func(velerov1api.UploaderType, *velerov1api.BackupStorageLocation, string, string, string) (podvolume.Uploader, error)
 	fileSystem        filesystem.Interface
 	clock             clock.Clock
 }
@@ -71,6 +75,8 @@ func NewPodVolumeBackupController(
 	pvInformer corev1informers.PersistentVolumeInformer,
 	backupLocationInformer informers.BackupStorageLocationInformer,
 	nodeName string,
+	retries int,
+	retryBackoff time.Duration,
 ) Interface {
 	c := &podVolumeBackupController{
 		genericController:     newGenericController("pod-volume-backup", logger),
@@ -82,9 +88,12 @@ func NewPodVolumeBackupController(
 		pvLister:              pvInformer.Lister(),
 		backupLocationLister:  backupLocationInformer.Lister(),
 		nodeName:              nodeName,
+		retries:               retries,
+		retryBackoff:          retryBackoff,
 
-		fileSystem: filesystem.NewFileSystem(),
-		clock:      &clock.RealClock{},
+		newUploader: podvolume.NewUploader,
+		fileSystem:  filesystem.NewFileSystem(),
+		clock:       &clock.RealClock{},
 	}
 
 	c.syncHandler = c.processQueueItem
@@ -194,13 +203,24 @@ func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBack
 		return c.fail(req, errors.Wrap(err, "error getting pod").Error(), log)
 	}
 
-	volumeDir, err := kube.GetVolumeDirectory(pod, req.Spec.Volume, c.pvcLister, c.pvLister)
-	if err != nil {
-		log.WithError(err).Error("Error getting volume directory name")
-		return c.fail(req, errors.Wrap(err, "error getting volume directory name").Error(), log)
-	}
+	block := req.Spec.VolumeMode == corev1api.PersistentVolumeBlock
 
-	pathGlob := fmt.Sprintf("/host_pods/%s/volumes/*/%s", string(req.Spec.Pod.UID), volumeDir)
+	var pathGlob string
+	if block {
+		deviceName, err := kube.GetVolumeDeviceName(pod, req.Spec.Volume, c.pvcLister)
+		if err != nil {
+			log.WithError(err).Error("Error getting volume device name")
+			return c.fail(req, errors.Wrap(err, "error getting volume device name").Error(), log)
+		}
+		pathGlob = podvolume.PodVolumeDevicePathGlob(req.Spec.Pod.UID, deviceName)
+	} else {
+		volumeDir, err := kube.GetVolumeDirectory(pod, req.Spec.Volume, c.pvcLister, c.pvLister)
+		if err != nil {
+			log.WithError(err).Error("Error getting volume directory name")
+			return c.fail(req, errors.Wrap(err, "error getting volume directory name").Error(), log)
+		}
+		pathGlob = podvolume.PodVolumePathGlob(req.Spec.Pod.UID, volumeDir)
+	}
 	log.WithField("pathGlob", pathGlob).Debug("Looking for path matching glob")
 
 	path, err := singlePathMatch(pathGlob)
@@ -211,7 +231,7 @@ func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBack
 	log.WithField("path", path).Debugf("Found path matching glob")
 
 	// temp creds
-	file, err := restic.TempCredentialsFile(c.secretLister, req.Namespace, req.Spec.Pod.Namespace, c.fileSystem)
+	file, err := podvolume.TempCredentialsFile(c.secretLister, req.Namespace, req.Spec.Pod.Namespace, c.fileSystem)
 	if err != nil {
 		log.WithError(err).Error("Error creating temp restic credentials file")
 		return c.fail(req, errors.Wrap(err, "error creating temp restic credentials file").Error(), log)
@@ -219,51 +239,61 @@ func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBack
 	// ignore error since there's nothing we can do and it's a temp file.
 	defer os.Remove(file)
 
-	resticCmd := restic.BackupCommand(
-		req.Spec.RepoIdentifier,
-		file,
-		path,
-		req.Spec.Tags,
-	)
+	location, err := c.backupLocationLister.BackupStorageLocations(req.Namespace).Get(req.Spec.BackupStorageLocation)
+	if err != nil {
+		log.WithError(err).Error("Error getting backup storage location")
+		return c.fail(req, errors.Wrap(err, "error getting backup storage location").Error(), log)
+	}
 
-	// if this is azure, set resticCmd.Env appropriately
-	var env []string
-	if strings.HasPrefix(req.Spec.RepoIdentifier, "azure") {
-		if env, err = restic.AzureCmdEnv(c.backupLocationLister, req.Namespace, req.Spec.BackupStorageLocation); err != nil {
-			return c.fail(req, errors.Wrap(err, "error setting restic cmd env").Error(), log)
-		}
-		resticCmd.Env = env
+	uploader, err := c.newUploader(req.Spec.UploaderType, location, req.Spec.Pod.Namespace, req.Spec.RepoIdentifier, file)
+	if err != nil {
+		log.WithError(err).Error("Error creating uploader")
+		return c.fail(req, errors.Wrap(err, "error creating uploader").Error(), log)
 	}
 
-	var stdout, stderr string
+	// block volumes are backed up by streaming the content of their device.
+	backup := uploader.Backup
+	if block {
+		backup = uploader.BackupBlock
+	}
 
-	var emptySnapshot bool
-	if stdout, stderr, err = veleroexec.RunCommand(resticCmd.Cmd()); err != nil {
-		if strings.Contains(stderr, "snapshot is empty") {
-			emptySnapshot = true
-		} else {
-			log.WithError(errors.WithStack(err)).Errorf("Error running command=%s, stdout=%s, stderr=%s", resticCmd.String(), stdout, stderr)
-			return c.fail(req, fmt.Sprintf("error running restic backup, stderr=%s: %s", stderr, err.Error()), log)
-		}
+	// back up incrementally from the snapshot of the volume's previous backup,
+	// so only the data that changed since it is moved.
+	var parentSnapshotID, parentName string
+	pvbs, err := c.podVolumeBackupLister.PodVolumeBackups(req.Namespace).List(labels.Everything())
+	if err != nil {
+		log.WithError(err).Warn("Error listing PodVolumeBackups, backing up volume in full")
+	} else if parent := podvolume.GetParentPodVolumeBackup(pvbs, req); parent != nil {
+		parentSnapshotID, parentName = parent.Status.SnapshotID, parent.Name
+		log.WithField("parentSnapshotID", parentSnapshotID).Info("Backing up volume incrementally")
 	}
-	log.Debugf("Ran command=%s, stdout=%s, stderr=%s", resticCmd.String(), stdout, stderr)
 
-	var snapshotID string
-	if !emptySnapshot {
-		snapshotID, err = restic.GetSnapshotID(req.Spec.RepoIdentifier, file, req.Spec.Tags, env)
-		if err != nil {
-			log.WithError(err).Error("Error getting SnapshotID")
-			return c.fail(req, errors.Wrap(err, "error getting snapshot id").Error(), log)
+	result, attempts, err := c.backupWithRetries(backup, path, parentSnapshotID, req.Spec.Tags, log, c.updateBackupProgressFunc(req, log))
+	if attempts > 1 {
+		patched, patchErr := c.patchPodVolumeBackup(req, func(r *velerov1api.PodVolumeBackup) {
+			r.Status.Attempts = attempts
+		})
+		if patchErr != nil {
+			log.WithError(patchErr).Error("Error setting PodVolumeBackup attempts")
+			return patchErr
 		}
+		req = patched
+	}
+	if err != nil {
+		log.WithError(err).Error("Error backing up volume")
+		return c.fail(req, errors.Wrap(err, "error backing up volume").Error(), log)
 	}
 
 	// update status to Completed with path & snapshot id
 	req, err = c.patchPodVolumeBackup(req, func(r *velerov1api.PodVolumeBackup) {
 		r.Status.Path = path
 		r.Status.Phase = velerov1api.PodVolumeBackupPhaseCompleted
-		r.Status.SnapshotID = snapshotID
+		r.Status.SnapshotID = result.SnapshotID
+		r.Status.ParentSnapshotID = parentSnapshotID
+		r.Status.ParentPodVolumeBackup = parentName
+		r.Status.Changes = result.Changes
 		r.Status.CompletionTimestamp.Time = c.clock.Now()
-		if emptySnapshot {
+		if result.SnapshotID == "" {
 			r.Status.Message = "volume was empty so no snapshot was taken"
 		}
 	})
@@ -277,6 +307,40 @@ func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBack
 	return nil
 }
 
+// uploaderBackupFunc is the signature of an Uploader's Backup and
+// BackupBlock funcs.
+type uploaderBackupFunc func(path, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (podvolume.BackupResult, error)
+
+// backupWithRetries backs up a volume with an uploader's backup func, and
+// backs it up again, up to the controller's number of retries, with an
+// exponential backoff if it fails for a reason that's likely to be transient.
+// It returns the result of the last attempt and the number of attempts.
+func (c *podVolumeBackupController) backupWithRetries(backup uploaderBackupFunc, path, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (podvolume.BackupResult, int, error) {
+	backoff := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := backup(path, parentSnapshotID, tags, log, updateFunc)
+		if err == nil || attempt > c.retries || !podvolume.IsTransientError(err.Error()) {
+			return result, attempt, err
+		}
+
+		log.WithError(err).Warnf("Error backing up volume, retrying in %s (attempt %d of %d)", backoff, attempt, c.retries+1)
+		c.clock.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// updateBackupProgressFunc returns a func that takes progress info and patches
+// the PVB with the new progress
+func (c *podVolumeBackupController) updateBackupProgressFunc(req *velerov1api.PodVolumeBackup, log logrus.FieldLogger) func(velerov1api.PodVolumeOperationProgress) {
+	return func(progress velerov1api.PodVolumeOperationProgress) {
+		if _, err := c.patchPodVolumeBackup(req, func(r *velerov1api.PodVolumeBackup) {
+			r.Status.Progress = progress
+		}); err != nil {
+			log.WithError(err).Error("error updating PodVolumeBackup progress")
+		}
+	}
+}
+
 func (c *podVolumeBackupController) patchPodVolumeBackup(req *velerov1api.PodVolumeBackup, mutate func(*velerov1api.PodVolumeBackup)) (*velerov1api.PodVolumeBackup, error) {
 	// Record original json
 	oldData, err := json.Marshal(req)
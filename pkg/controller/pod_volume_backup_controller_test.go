@@ -18,11 +18,16 @@ package controller
 
 import (
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/clock"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/podvolume"
 	velerotest "github.com/heptio/velero/pkg/test"
 )
 
@@ -169,3 +174,73 @@ func TestPVBHandler(t *testing.T) {
 		})
 	}
 }
+
+// fakeUploader's backups fail with each of its stderrs in turn, and
+// succeed when the stderr is empty.
+type fakeUploader struct {
+	stderrs []string
+	calls   int
+}
+
+func (u *fakeUploader) Backup(string, string, map[string]string, logrus.FieldLogger, func(velerov1api.PodVolumeOperationProgress)) (podvolume.BackupResult, error) {
+	stderr := u.stderrs[u.calls]
+	u.calls++
+	if stderr == "" {
+		return podvolume.BackupResult{SnapshotID: "snapshot-1"}, nil
+	}
+	return podvolume.BackupResult{}, errors.Errorf("error running restic backup, stderr=%s: exit status 1", stderr)
+}
+
+func TestBackupWithRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		stderrs          []string
+		expectedAttempts int
+		expectedErr      bool
+		expectedSleep    time.Duration
+	}{
+		{
+			name:             "successful backup isn't retried",
+			stderrs:          []string{""},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "transient failure is retried with a backoff",
+			stderrs:          []string{"repository is already locked", "connection reset by peer", ""},
+			expectedAttempts: 3,
+			expectedSleep:    3 * time.Second,
+		},
+		{
+			name:             "non-transient failure isn't retried",
+			stderrs:          []string{"Fatal: wrong password or no key found"},
+			expectedAttempts: 1,
+			expectedErr:      true,
+		},
+		{
+			name:             "transient failure fails once the retries are used up",
+			stderrs:          []string{"i/o timeout", "i/o timeout", "i/o timeout", ""},
+			expectedAttempts: 3,
+			expectedErr:      true,
+			expectedSleep:    3 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+			fakeClock := clock.NewFakeClock(start)
+
+			c := &podVolumeBackupController{
+				genericController: newGenericController("pod-volume-backup", velerotest.NewLogger()),
+				retries:           2,
+				retryBackoff:      time.Second,
+				clock:             fakeClock,
+			}
+
+			_, attempts, err := c.backupWithRetries((&fakeUploader{stderrs: test.stderrs}).Backup, "/path", "", nil, velerotest.NewLogger(), func(velerov1api.PodVolumeOperationProgress) {})
+			assert.Equal(t, test.expectedAttempts, attempts)
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expectedSleep, fakeClock.Since(start))
+		})
+	}
+}
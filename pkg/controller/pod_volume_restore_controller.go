@@ -22,7 +22,6 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
@@ -40,9 +39,8 @@ import (
 	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/util/boolptr"
-	veleroexec "github.com/heptio/velero/pkg/util/exec"
 	"github.com/heptio/velero/pkg/util/filesystem"
 	"github.com/heptio/velero/pkg/util/kube"
 )
@@ -206,7 +204,7 @@ func isPodOnNode(pod *corev1api.Pod, node string) bool {
 
 func isResticInitContainerRunning(pod *corev1api.Pod) bool {
 	// no init containers, or the first one is not the velero restic one: return false
-	if len(pod.Spec.InitContainers) == 0 || pod.Spec.InitContainers[0].Name != restic.InitContainer {
+	if len(pod.Spec.InitContainers) == 0 || pod.Spec.InitContainers[0].Name != podvolume.InitContainer {
 		return false
 	}
 
@@ -279,13 +277,20 @@ func (c *podVolumeRestoreController) processRestore(req *velerov1api.PodVolumeRe
 		return c.failRestore(req, errors.Wrap(err, "error getting pod").Error(), log)
 	}
 
-	volumeDir, err := kube.GetVolumeDirectory(pod, req.Spec.Volume, c.pvcLister, c.pvLister)
-	if err != nil {
-		log.WithError(err).Error("Error getting volume directory name")
-		return c.failRestore(req, errors.Wrap(err, "error getting volume directory name").Error(), log)
+	var volumeDir string
+	if req.Spec.VolumeMode == corev1api.PersistentVolumeBlock {
+		if volumeDir, err = kube.GetVolumeDeviceName(pod, req.Spec.Volume, c.pvcLister); err != nil {
+			log.WithError(err).Error("Error getting volume device name")
+			return c.failRestore(req, errors.Wrap(err, "error getting volume device name").Error(), log)
+		}
+	} else {
+		if volumeDir, err = kube.GetVolumeDirectory(pod, req.Spec.Volume, c.pvcLister, c.pvLister); err != nil {
+			log.WithError(err).Error("Error getting volume directory name")
+			return c.failRestore(req, errors.Wrap(err, "error getting volume directory name").Error(), log)
+		}
 	}
 
-	credsFile, err := restic.TempCredentialsFile(c.secretLister, req.Namespace, req.Spec.Pod.Namespace, c.fileSystem)
+	credsFile, err := podvolume.TempCredentialsFile(c.secretLister, req.Namespace, req.Spec.Pod.Namespace, c.fileSystem)
 	if err != nil {
 		log.WithError(err).Error("Error creating temp restic credentials file")
 		return c.failRestore(req, errors.Wrap(err, "error creating temp restic credentials file").Error(), log)
@@ -314,42 +319,51 @@ func (c *podVolumeRestoreController) processRestore(req *velerov1api.PodVolumeRe
 }
 
 func (c *podVolumeRestoreController) restorePodVolume(req *velerov1api.PodVolumeRestore, credsFile, volumeDir string, log logrus.FieldLogger) error {
+	block := req.Spec.VolumeMode == corev1api.PersistentVolumeBlock
+
 	// Get the full path of the new volume's directory as mounted in the daemonset pod, which
-	// will look like: /host_pods/<new-pod-uid>/volumes/<volume-plugin-name>/<volume-dir>
-	volumePath, err := singlePathMatch(fmt.Sprintf("/host_pods/%s/volumes/*/%s", string(req.Spec.Pod.UID), volumeDir))
+	// will look like: /host_pods/<new-pod-uid>/volumes/<volume-plugin-name>/<volume-dir>, or
+	// of its device for block volumes: /host_pods/<new-pod-uid>/volumeDevices/<volume-plugin-name>/<device-name>
+	pathGlob := podvolume.PodVolumePathGlob(req.Spec.Pod.UID, volumeDir)
+	if block {
+		pathGlob = podvolume.PodVolumeDevicePathGlob(req.Spec.Pod.UID, volumeDir)
+	}
+
+	volumePath, err := singlePathMatch(pathGlob)
 	if err != nil {
 		return errors.Wrap(err, "error identifying path of volume")
 	}
 
-	resticCmd := restic.RestoreCommand(
-		req.Spec.RepoIdentifier,
-		credsFile,
-		req.Spec.SnapshotID,
-		volumePath,
-	)
-
-	// if this is azure, set resticCmd.Env appropriately
-	if strings.HasPrefix(req.Spec.RepoIdentifier, "azure") {
-		env, err := restic.AzureCmdEnv(c.backupLocationLister, req.Namespace, req.Spec.BackupStorageLocation)
-		if err != nil {
-			return c.failRestore(req, errors.Wrap(err, "error setting restic cmd env").Error(), log)
-		}
-		resticCmd.Env = env
+	location, err := c.backupLocationLister.BackupStorageLocations(req.Namespace).Get(req.Spec.BackupStorageLocation)
+	if err != nil {
+		return errors.Wrap(err, "error getting backup storage location")
 	}
 
-	var stdout, stderr string
+	uploader, err := podvolume.NewUploader(req.Spec.UploaderType, location, req.Spec.Pod.Namespace, req.Spec.RepoIdentifier, credsFile)
+	if err != nil {
+		return errors.Wrap(err, "error creating uploader")
+	}
 
-	if stdout, stderr, err = veleroexec.RunCommand(resticCmd.Cmd()); err != nil {
-		return errors.Wrapf(err, "error running restic restore, cmd=%s, stdout=%s, stderr=%s", resticCmd.String(), stdout, stderr)
+	// Block volumes can't hold the done file, so it's written to a directory
+	// for the volume in the pod's emptyDir volume for block restores instead.
+	doneDir := volumePath
+	if block {
+		if err := uploader.RestoreBlock(req.Spec.SnapshotID, volumePath, log, c.updateRestoreProgressFunc(req, log)); err != nil {
+			return err
+		}
+		doneDir = podvolume.BlockRestoreDoneDir(req.Spec.Pod.UID, req.Spec.Volume)
+	} else {
+		if err := uploader.Restore(req.Spec.SnapshotID, volumePath, log, c.updateRestoreProgressFunc(req, log)); err != nil {
+			return err
+		}
 	}
-	log.Debugf("Ran command=%s, stdout=%s, stderr=%s", resticCmd.String(), stdout, stderr)
 
 	// Remove the .velero directory from the restored volume (it may contain done files from previous restores
 	// of this volume, which we don't want to carry over). If this fails for any reason, log and continue, since
 	// this is non-essential cleanup (the done files are named based on restore UID and the init container looks
 	// for the one specific to the restore being executed).
-	if err := os.RemoveAll(filepath.Join(volumePath, ".velero")); err != nil {
-		log.WithError(err).Warnf("error removing .velero directory from directory %s", volumePath)
+	if err := os.RemoveAll(filepath.Join(doneDir, ".velero")); err != nil {
+		log.WithError(err).Warnf("error removing .velero directory from directory %s", doneDir)
 	}
 
 	var restoreUID types.UID
@@ -362,20 +376,32 @@ func (c *podVolumeRestoreController) restorePodVolume(req *velerov1api.PodVolume
 
 	// Create the .velero directory within the volume dir so we can write a done file
 	// for this restore.
-	if err := os.MkdirAll(filepath.Join(volumePath, ".velero"), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Join(doneDir, ".velero"), 0755); err != nil {
 		return errors.Wrap(err, "error creating .velero directory for done file")
 	}
 
 	// Write a done file with name=<restore-uid> into the just-created .velero dir
 	// within the volume. The velero restic init container on the pod is waiting
 	// for this file to exist in each restored volume before completing.
-	if err := ioutil.WriteFile(filepath.Join(volumePath, ".velero", string(restoreUID)), nil, 0644); err != nil {
+	if err := ioutil.WriteFile(filepath.Join(doneDir, ".velero", string(restoreUID)), nil, 0644); err != nil {
 		return errors.Wrap(err, "error writing done file")
 	}
 
 	return nil
 }
 
+// updateRestoreProgressFunc returns a func that takes progress info and patches
+// the PVR with the new progress
+func (c *podVolumeRestoreController) updateRestoreProgressFunc(req *velerov1api.PodVolumeRestore, log logrus.FieldLogger) func(velerov1api.PodVolumeOperationProgress) {
+	return func(progress velerov1api.PodVolumeOperationProgress) {
+		if _, err := c.patchPodVolumeRestore(req, func(r *velerov1api.PodVolumeRestore) {
+			r.Status.Progress = progress
+		}); err != nil {
+			log.WithError(err).Error("error updating PodVolumeRestore progress")
+		}
+	}
+}
+
 func (c *podVolumeRestoreController) patchPodVolumeRestore(req *velerov1api.PodVolumeRestore, mutate func(*velerov1api.PodVolumeRestore)) (*velerov1api.PodVolumeRestore, error) {
 	// Record original json
 	oldData, err := json.Marshal(req)
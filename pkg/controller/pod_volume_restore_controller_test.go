@@ -33,7 +33,7 @@ import (
 	velerofake "github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
 	veleroinformers "github.com/heptio/velero/pkg/generated/informers/externalversions"
 	velerov1listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	velerotest "github.com/heptio/velero/pkg/test"
 )
 
@@ -110,7 +110,7 @@ func TestPVRHandler(t *testing.T) {
 					NodeName: "some-other-node",
 					InitContainers: []corev1api.Container{
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 					},
 				},
@@ -150,7 +150,7 @@ func TestPVRHandler(t *testing.T) {
 					NodeName: controllerNode,
 					InitContainers: []corev1api.Container{
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 					},
 				},
@@ -186,7 +186,7 @@ func TestPVRHandler(t *testing.T) {
 					NodeName: controllerNode,
 					InitContainers: []corev1api.Container{
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 					},
 				},
@@ -254,7 +254,7 @@ func TestPodHandler(t *testing.T) {
 					NodeName: controllerNode,
 					InitContainers: []corev1api.Container{
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 					},
 				},
@@ -323,7 +323,7 @@ func TestPodHandler(t *testing.T) {
 					NodeName: controllerNode,
 					InitContainers: []corev1api.Container{
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 					},
 				},
@@ -359,7 +359,7 @@ func TestPodHandler(t *testing.T) {
 					NodeName: "some-other-node",
 					InitContainers: []corev1api.Container{
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 					},
 				},
@@ -503,7 +503,7 @@ func TestIsResticContainerRunning(t *testing.T) {
 							Name: "non-restic-init",
 						},
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 					},
 				},
@@ -534,7 +534,7 @@ func TestIsResticContainerRunning(t *testing.T) {
 				Spec: corev1api.PodSpec{
 					InitContainers: []corev1api.Container{
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 						{
 							Name: "non-restic-init",
@@ -566,7 +566,7 @@ func TestIsResticContainerRunning(t *testing.T) {
 				Spec: corev1api.PodSpec{
 					InitContainers: []corev1api.Container{
 						{
-							Name: restic.InitContainer,
+							Name: podvolume.InitContainer,
 						},
 						{
 							Name: "non-restic-init",
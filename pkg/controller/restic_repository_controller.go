@@ -18,6 +18,7 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
@@ -35,7 +36,7 @@ import (
 	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 )
 
 type resticRepositoryController struct {
@@ -44,7 +45,12 @@ type resticRepositoryController struct {
 	resticRepositoryClient velerov1client.ResticRepositoriesGetter
 	resticRepositoryLister listers.ResticRepositoryLister
 	backupLocationLister   listers.BackupStorageLocationLister
-	repositoryManager      restic.RepositoryManager
+	repositoryManager      podvolume.RepositoryManager
+
+	// defaultMaintenanceFrequency and defaultCheckFrequency are set on
+	// new repositories that don't specify their own.
+	defaultMaintenanceFrequency time.Duration
+	defaultCheckFrequency       time.Duration
 
 	clock clock.Clock
 }
@@ -55,15 +61,19 @@ func NewResticRepositoryController(
 	resticRepositoryInformer informers.ResticRepositoryInformer,
 	resticRepositoryClient velerov1client.ResticRepositoriesGetter,
 	backupLocationInformer informers.BackupStorageLocationInformer,
-	repositoryManager restic.RepositoryManager,
+	repositoryManager podvolume.RepositoryManager,
+	defaultMaintenanceFrequency time.Duration,
+	defaultCheckFrequency time.Duration,
 ) Interface {
 	c := &resticRepositoryController{
-		genericController:      newGenericController("restic-repository", logger),
-		resticRepositoryClient: resticRepositoryClient,
-		resticRepositoryLister: resticRepositoryInformer.Lister(),
-		backupLocationLister:   backupLocationInformer.Lister(),
-		repositoryManager:      repositoryManager,
-		clock:                  &clock.RealClock{},
+		genericController:           newGenericController("restic-repository", logger),
+		resticRepositoryClient:      resticRepositoryClient,
+		resticRepositoryLister:      resticRepositoryInformer.Lister(),
+		backupLocationLister:        backupLocationInformer.Lister(),
+		repositoryManager:           repositoryManager,
+		defaultMaintenanceFrequency: defaultMaintenanceFrequency,
+		defaultCheckFrequency:       defaultCheckFrequency,
+		clock:                       &clock.RealClock{},
 	}
 
 	c.syncHandler = c.processQueueItem
@@ -72,6 +82,13 @@ func NewResticRepositoryController(
 	resticRepositoryInformer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: c.enqueue,
+			UpdateFunc: func(_, obj interface{}) {
+				// process unlock requests without waiting for the next resync.
+				repo := obj.(*v1.ResticRepository)
+				if _, ok := repo.Annotations[v1.ResticRepositoryUnlockAnnotation]; ok {
+					c.enqueue(obj)
+				}
+			},
 		},
 	)
 
@@ -125,6 +142,12 @@ func (c *resticRepositoryController) processQueueItem(key string) error {
 		return c.initializeRepo(reqCopy, log)
 	}
 
+	if mode, ok := req.Annotations[v1.ResticRepositoryUnlockAnnotation]; ok {
+		if err := c.processUnlockRequest(reqCopy, v1.ResticRepositoryUnlockMode(mode), log); err != nil {
+			return err
+		}
+	}
+
 	// If the repository is ready or not-ready, check it for stale locks, but if
 	// this fails for any reason, it's non-critical so we still continue on to the
 	// rest of the "process" logic.
@@ -133,6 +156,17 @@ func (c *resticRepositoryController) processQueueItem(key string) error {
 		log.WithError(err).Error("Error checking repository for stale locks")
 	}
 
+	// Record the locks that are left, since they block maintenance (and
+	// backups, if they're exclusive). This is non-critical too.
+	log.Debug("Checking repository for locks")
+	if locks, err := c.repositoryManager.GetRepoLocks(reqCopy); err != nil {
+		log.WithError(err).Error("Error checking repository for locks")
+	} else if err := c.patchResticRepository(reqCopy, func(r *v1.ResticRepository) {
+		r.Status.Locks = locks
+	}); err != nil {
+		return err
+	}
+
 	switch req.Status.Phase {
 	case v1.ResticRepositoryPhaseReady:
 		return c.runMaintenanceIfDue(reqCopy, log)
@@ -143,6 +177,33 @@ func (c *resticRepositoryController) processQueueItem(key string) error {
 	return nil
 }
 
+// processUnlockRequest removes the locks from a repository that a user
+// asked to unlock, and removes the request's annotation.
+func (c *resticRepositoryController) processUnlockRequest(req *v1.ResticRepository, mode v1.ResticRepositoryUnlockMode, log logrus.FieldLogger) error {
+	log = log.WithField("mode", mode)
+	log.Info("Unlocking restic repository")
+
+	var err error
+	switch mode {
+	case v1.ResticRepositoryUnlockModeStale:
+		err = c.repositoryManager.UnlockRepo(req)
+	case v1.ResticRepositoryUnlockModeAll:
+		err = c.repositoryManager.RemoveAllRepoLocks(req)
+	default:
+		err = errors.Errorf("invalid unlock mode %q, must be one of %s or %s", mode, v1.ResticRepositoryUnlockModeStale, v1.ResticRepositoryUnlockModeAll)
+	}
+	if err != nil {
+		log.WithError(err).Error("Error unlocking restic repository")
+	}
+
+	return c.patchResticRepository(req, func(r *v1.ResticRepository) {
+		delete(r.Annotations, v1.ResticRepositoryUnlockAnnotation)
+		if err != nil {
+			r.Status.Message = fmt.Sprintf("error unlocking repository: %v", err)
+		}
+	})
+}
+
 func (c *resticRepositoryController) initializeRepo(req *v1.ResticRepository, log logrus.FieldLogger) error {
 	log.Info("Initializing restic repository")
 
@@ -154,10 +215,13 @@ func (c *resticRepositoryController) initializeRepo(req *v1.ResticRepository, lo
 
 	// defaulting - if the patch fails, return an error so the item is returned to the queue
 	if err := c.patchResticRepository(req, func(r *v1.ResticRepository) {
-		r.Spec.ResticIdentifier = restic.GetRepoIdentifier(loc, r.Spec.VolumeNamespace)
+		r.Spec.ResticIdentifier = podvolume.GetRepoIdentifier(loc, r.Spec.VolumeNamespace)
 
 		if r.Spec.MaintenanceFrequency.Duration <= 0 {
-			r.Spec.MaintenanceFrequency = metav1.Duration{Duration: restic.DefaultMaintenanceFrequency}
+			r.Spec.MaintenanceFrequency = metav1.Duration{Duration: c.defaultMaintenanceFrequency}
+		}
+		if r.Spec.CheckFrequency.Duration <= 0 {
+			r.Spec.CheckFrequency = metav1.Duration{Duration: c.defaultCheckFrequency}
 		}
 	}); err != nil {
 		return err
@@ -169,14 +233,14 @@ func (c *resticRepositoryController) initializeRepo(req *v1.ResticRepository, lo
 
 	return c.patchResticRepository(req, func(req *v1.ResticRepository) {
 		req.Status.Phase = v1.ResticRepositoryPhaseReady
-		req.Status.LastMaintenanceTime = metav1.Time{Time: time.Now()}
+		req.Status.LastMaintenanceTime = metav1.Time{Time: c.clock.Now()}
 	})
 }
 
 // ensureRepo checks to see if a repository exists, and attempts to initialize it if
 // it does not exist. An error is returned if the repository can't be connected to
 // or initialized.
-func ensureRepo(repo *v1.ResticRepository, repoManager restic.RepositoryManager) error {
+func ensureRepo(repo *v1.ResticRepository, repoManager podvolume.RepositoryManager) error {
 	if err := repoManager.ConnectToRepo(repo); err != nil {
 		// If the repository has not yet been initialized, the error message will always include
 		// the following string. This is the only scenario where we should try to initialize it.
@@ -198,6 +262,12 @@ func (c *resticRepositoryController) runMaintenanceIfDue(req *v1.ResticRepositor
 	now := c.clock.Now()
 
 	if !dueForMaintenance(req, now) {
+		if dueForCheck(req, now) {
+			log.Info("Checking restic repository for errors")
+			_, err := c.checkRepo(req, now)
+			return err
+		}
+
 		log.Debug("not due for maintenance")
 		return nil
 	}
@@ -205,8 +275,8 @@ func (c *resticRepositoryController) runMaintenanceIfDue(req *v1.ResticRepositor
 	log.Info("Running maintenance on restic repository")
 
 	log.Debug("Checking repo before prune")
-	if err := c.repositoryManager.CheckRepo(req); err != nil {
-		return c.patchResticRepository(req, repoNotReady(err.Error()))
+	if passed, err := c.checkRepo(req, now); !passed || err != nil {
+		return err
 	}
 
 	// prune failures should be displayed in the `.status.message` field but
@@ -219,11 +289,15 @@ func (c *resticRepositoryController) runMaintenanceIfDue(req *v1.ResticRepositor
 		}); patchErr != nil {
 			return patchErr
 		}
+	} else if err := c.patchResticRepository(req, func(r *v1.ResticRepository) {
+		r.Status.LastPruneTime = metav1.Time{Time: now}
+	}); err != nil {
+		return err
 	}
 
 	log.Debug("Checking repo after prune")
-	if err := c.repositoryManager.CheckRepo(req); err != nil {
-		return c.patchResticRepository(req, repoNotReady(err.Error()))
+	if passed, err := c.checkRepo(req, now); !passed || err != nil {
+		return err
 	}
 
 	return c.patchResticRepository(req, func(req *v1.ResticRepository) {
@@ -231,10 +305,38 @@ func (c *resticRepositoryController) runMaintenanceIfDue(req *v1.ResticRepositor
 	})
 }
 
+// checkRepo checks the repository for errors and records the result. If
+// the check fails, the repository is marked not ready. It returns whether
+// the check passed.
+func (c *resticRepositoryController) checkRepo(req *v1.ResticRepository, now time.Time) (bool, error) {
+	checkErr := c.repositoryManager.CheckRepo(req)
+
+	err := c.patchResticRepository(req, func(r *v1.ResticRepository) {
+		r.Status.LastCheckTime = metav1.Time{Time: now}
+		if checkErr != nil {
+			r.Status.LastCheckResult = v1.ResticRepositoryCheckResultFailed
+			repoNotReady(checkErr.Error())(r)
+		} else {
+			r.Status.LastCheckResult = v1.ResticRepositoryCheckResultPassed
+		}
+	})
+
+	return checkErr == nil, err
+}
+
 func dueForMaintenance(req *v1.ResticRepository, now time.Time) bool {
 	return req.Status.LastMaintenanceTime.Add(req.Spec.MaintenanceFrequency.Duration).Before(now)
 }
 
+// dueForCheck returns whether the repository should be checked for errors
+// on its own, which is only done if it has a check frequency.
+func dueForCheck(req *v1.ResticRepository, now time.Time) bool {
+	if req.Spec.CheckFrequency.Duration <= 0 {
+		return false
+	}
+	return req.Status.LastCheckTime.Add(req.Spec.CheckFrequency.Duration).Before(now)
+}
+
 func (c *resticRepositoryController) checkNotReadyRepo(req *v1.ResticRepository, log logrus.FieldLogger) error {
 	log.Info("Checking restic repository for readiness")
 
@@ -0,0 +1,189 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/podvolume"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+// fakeRepositoryManager counts the checks and prunes that are run. It
+// embeds podvolume.RepositoryManager so that calling any other method panics.
+type fakeRepositoryManager struct {
+	podvolume.RepositoryManager
+
+	checkErr, pruneErr error
+	checks, prunes     int
+}
+
+func (m *fakeRepositoryManager) CheckRepo(*velerov1api.ResticRepository) error {
+	m.checks++
+	return m.checkErr
+}
+
+func (m *fakeRepositoryManager) PruneRepo(*velerov1api.ResticRepository) error {
+	m.prunes++
+	return m.pruneErr
+}
+
+func TestResticRepositoryControllerRunMaintenanceIfDue(t *testing.T) {
+	var (
+		now         = time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+		lastWeek    = metav1.Time{Time: now.Add(-7 * 24 * time.Hour)}
+		lastHour    = metav1.Time{Time: now.Add(-time.Hour)}
+		nowMetaTime = metav1.Time{Time: now}
+	)
+
+	tests := []struct {
+		name            string
+		checkFrequency  time.Duration
+		lastMaintenance metav1.Time
+		lastCheck       metav1.Time
+		checkErr        error
+		pruneErr        error
+		expectedChecks  int
+		expectedPrunes  int
+		expectedStatus  velerov1api.ResticRepositoryStatus
+	}{
+		{
+			name:            "repository due for maintenance is checked, pruned and checked again",
+			lastMaintenance: lastWeek,
+			expectedChecks:  2,
+			expectedPrunes:  1,
+			expectedStatus: velerov1api.ResticRepositoryStatus{
+				Phase:               velerov1api.ResticRepositoryPhaseReady,
+				LastMaintenanceTime: nowMetaTime,
+				LastPruneTime:       nowMetaTime,
+				LastCheckTime:       nowMetaTime,
+				LastCheckResult:     velerov1api.ResticRepositoryCheckResultPassed,
+			},
+		},
+		{
+			name:            "repository due for a check between maintenance runs is only checked",
+			checkFrequency:  30 * time.Minute,
+			lastMaintenance: lastHour,
+			lastCheck:       lastHour,
+			expectedChecks:  1,
+			expectedStatus: velerov1api.ResticRepositoryStatus{
+				Phase:               velerov1api.ResticRepositoryPhaseReady,
+				LastMaintenanceTime: lastHour,
+				LastCheckTime:       nowMetaTime,
+				LastCheckResult:     velerov1api.ResticRepositoryCheckResultPassed,
+			},
+		},
+		{
+			name:            "repository without a check frequency is only checked during maintenance",
+			lastMaintenance: lastHour,
+			lastCheck:       lastWeek,
+			expectedStatus: velerov1api.ResticRepositoryStatus{
+				Phase:               velerov1api.ResticRepositoryPhaseReady,
+				LastMaintenanceTime: lastHour,
+				LastCheckTime:       lastWeek,
+			},
+		},
+		{
+			name:            "repository that fails its check isn't pruned and is marked not ready",
+			lastMaintenance: lastWeek,
+			checkErr:        errors.New("pack is damaged"),
+			expectedChecks:  1,
+			expectedStatus: velerov1api.ResticRepositoryStatus{
+				Phase:               velerov1api.ResticRepositoryPhaseNotReady,
+				Message:             "pack is damaged",
+				LastMaintenanceTime: lastWeek,
+				LastCheckTime:       nowMetaTime,
+				LastCheckResult:     velerov1api.ResticRepositoryCheckResultFailed,
+			},
+		},
+		{
+			name:            "failed prune is recorded in the message without a prune time",
+			lastMaintenance: lastWeek,
+			pruneErr:        errors.New("repository is already locked"),
+			expectedChecks:  2,
+			expectedPrunes:  1,
+			expectedStatus: velerov1api.ResticRepositoryStatus{
+				Phase:               velerov1api.ResticRepositoryPhaseReady,
+				Message:             "repository is already locked",
+				LastMaintenanceTime: nowMetaTime,
+				LastCheckTime:       nowMetaTime,
+				LastCheckResult:     velerov1api.ResticRepositoryCheckResultPassed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &velerov1api.ResticRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: velerov1api.DefaultNamespace,
+					Name:      "repo-1",
+				},
+				Spec: velerov1api.ResticRepositorySpec{
+					MaintenanceFrequency: metav1.Duration{Duration: 24 * time.Hour},
+					CheckFrequency:       metav1.Duration{Duration: test.checkFrequency},
+				},
+				Status: velerov1api.ResticRepositoryStatus{
+					Phase:               velerov1api.ResticRepositoryPhaseReady,
+					LastMaintenanceTime: test.lastMaintenance,
+					LastCheckTime:       test.lastCheck,
+				},
+			}
+
+			var (
+				client          = fake.NewSimpleClientset(repo)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				repoManager     = &fakeRepositoryManager{checkErr: test.checkErr, pruneErr: test.pruneErr}
+			)
+
+			c := NewResticRepositoryController(
+				velerotest.NewLogger(),
+				sharedInformers.Velero().V1().ResticRepositories(),
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				repoManager,
+				podvolume.DefaultMaintenanceFrequency,
+				0,
+			).(*resticRepositoryController)
+			c.clock = clock.NewFakeClock(now)
+
+			require.NoError(t, c.runMaintenanceIfDue(repo.DeepCopy(), velerotest.NewLogger()))
+
+			assert.Equal(t, test.expectedChecks, repoManager.checks)
+			assert.Equal(t, test.expectedPrunes, repoManager.prunes)
+
+			res, err := client.VeleroV1().ResticRepositories(repo.Namespace).Get(repo.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.True(t, test.expectedStatus.LastMaintenanceTime.Equal(&res.Status.LastMaintenanceTime))
+			assert.True(t, test.expectedStatus.LastPruneTime.Equal(&res.Status.LastPruneTime))
+			assert.True(t, test.expectedStatus.LastCheckTime.Equal(&res.Status.LastCheckTime))
+			assert.Equal(t, test.expectedStatus.LastCheckResult, res.Status.LastCheckResult)
+			assert.Equal(t, test.expectedStatus.Phase, res.Status.Phase)
+			assert.Equal(t, test.expectedStatus.Message, res.Status.Message)
+		})
+	}
+}
@@ -25,58 +25,49 @@ import (
 	"io/ioutil"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/cloudprovider"
 	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
 	"github.com/heptio/velero/pkg/metrics"
+	"github.com/heptio/velero/pkg/notifications"
 	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/clientmgmt"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/resourcepolicies"
 	pkgrestore "github.com/heptio/velero/pkg/restore"
-	"github.com/heptio/velero/pkg/util/collections"
+	"github.com/heptio/velero/pkg/util/filesystem"
 	kubeutil "github.com/heptio/velero/pkg/util/kube"
 	"github.com/heptio/velero/pkg/util/logging"
+	"github.com/heptio/velero/pkg/validation"
 )
 
-// nonRestorableResources is a blacklist for the restoration process. Any resources
-// included here are explicitly excluded from the restoration process.
-var nonRestorableResources = []string{
-	"nodes",
-	"events",
-	"events.events.k8s.io",
-
-	// Don't ever restore backups - if appropriate, they'll be synced in from object storage.
-	// https://github.com/heptio/velero/issues/622
-	"backups.velero.io",
-
-	// Restores are cluster-specific, and don't have value moving across clusters.
-	// https://github.com/heptio/velero/issues/622
-	"restores.velero.io",
-
-	// Restic repositories are automatically managed by Velero and will be automatically
-	// created as needed if they don't exist.
-	// https://github.com/heptio/velero/issues/1113
-	"resticrepositories.velero.io",
-}
-
 type restoreController struct {
 	*genericController
 
 	namespace              string
 	restoreClient          velerov1client.RestoresGetter
 	podVolumeBackupClient  velerov1client.PodVolumeBackupsGetter
+	secretsClient          corev1client.SecretsGetter
+	configMapsClient       corev1client.ConfigMapsGetter
 	restorer               pkgrestore.Restorer
 	backupLister           listers.BackupLister
 	restoreLister          listers.RestoreLister
@@ -85,10 +76,15 @@ type restoreController struct {
 	restoreLogLevel        logrus.Level
 	defaultBackupLocation  string
 	metrics                *metrics.ServerMetrics
+	notifier               notifications.Notifier
+	eventRecorder          kubeutil.EventRecorder
 	logFormat              logging.Format
+	structuredLogs         bool
+	clock                  clock.Clock
 
 	newPluginManager func(logger logrus.FieldLogger) clientmgmt.Manager
 	newBackupStore   func(*api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	fileSystem       filesystem.Interface
 }
 
 func NewRestoreController(
@@ -96,6 +92,8 @@ func NewRestoreController(
 	restoreInformer informers.RestoreInformer,
 	restoreClient velerov1client.RestoresGetter,
 	podVolumeBackupClient velerov1client.PodVolumeBackupsGetter,
+	secretsClient corev1client.SecretsGetter,
+	configMapsClient corev1client.ConfigMapsGetter,
 	restorer pkgrestore.Restorer,
 	backupInformer informers.BackupInformer,
 	backupLocationInformer informers.BackupStorageLocationInformer,
@@ -105,13 +103,18 @@ func NewRestoreController(
 	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
 	defaultBackupLocation string,
 	metrics *metrics.ServerMetrics,
+	notifier notifications.Notifier,
+	eventRecorder kubeutil.EventRecorder,
 	logFormat logging.Format,
+	structuredLogs bool,
 ) Interface {
 	c := &restoreController{
 		genericController:      newGenericController("restore", logger),
 		namespace:              namespace,
 		restoreClient:          restoreClient,
 		podVolumeBackupClient:  podVolumeBackupClient,
+		secretsClient:          secretsClient,
+		configMapsClient:       configMapsClient,
 		restorer:               restorer,
 		backupLister:           backupInformer.Lister(),
 		restoreLister:          restoreInformer.Lister(),
@@ -120,12 +123,17 @@ func NewRestoreController(
 		restoreLogLevel:        restoreLogLevel,
 		defaultBackupLocation:  defaultBackupLocation,
 		metrics:                metrics,
+		notifier:               notifier,
+		eventRecorder:          eventRecorder,
 		logFormat:              logFormat,
+		structuredLogs:         structuredLogs,
+		clock:                  &clock.RealClock{},
 
 		// use variables to refer to these functions so they can be
 		// replaced with fakes for testing.
 		newPluginManager: newPluginManager,
-		newBackupStore:   persistence.NewObjectBackupStore,
+		newBackupStore:   persistence.NewBackupStore,
+		fileSystem:       filesystem.NewFileSystem(),
 	}
 
 	c.syncHandler = c.processQueueItem
@@ -226,8 +234,14 @@ func (c *restoreController) processRestore(restore *api.Restore) error {
 	// since within that function we want the plugin manager to log to
 	// our per-restore log (which is instantiated within c.runValidatedRestore).
 	pluginManager := c.newPluginManager(c.logger)
-	info := c.validateAndComplete(restore, pluginManager)
+	info, err := c.validateAndComplete(restore, pluginManager)
 	pluginManager.CleanupClients()
+	defer c.removeCredentialsFile(info)
+	if err != nil {
+		// the backup couldn't be read from the backup store right now, so
+		// leave the restore as New to validate it again when it's retried.
+		return err
+	}
 
 	// Register attempts after validation so we don't have to fetch the backup multiple times
 	backupScheduleName := restore.Spec.ScheduleName
@@ -239,6 +253,7 @@ func (c *restoreController) processRestore(restore *api.Restore) error {
 	} else {
 		restore.Status.Phase = api.RestorePhaseInProgress
 	}
+	setRestorePhaseConditions(restore, c.clock.Now())
 
 	// patch to update status and persist to API
 	updatedRestore, err := patchRestore(original, restore, c.restoreClient)
@@ -250,12 +265,18 @@ func (c *restoreController) processRestore(restore *api.Restore) error {
 	// store ref to just-updated item for creating patch
 	original = updatedRestore
 	restore = updatedRestore.DeepCopy()
+	c.recordPhaseEvent(restore)
 
 	if restore.Status.Phase == api.RestorePhaseFailedValidation {
+		c.notifier.Notify(notifications.RestoreEvent(notifications.EventRestoreFailed, restore))
 		return nil
 	}
 
-	if err := c.runValidatedRestore(restore, info); err != nil {
+	restoreStart := c.clock.Now()
+	err = c.runValidatedRestore(restore, info)
+	c.metrics.RegisterRestoreDuration(backupScheduleName, c.clock.Since(restoreStart).Seconds())
+
+	if err != nil {
 		c.logger.WithError(err).Debug("Restore failed")
 		restore.Status.Phase = api.RestorePhaseFailed
 		restore.Status.FailureReason = err.Error()
@@ -269,11 +290,22 @@ func (c *restoreController) processRestore(restore *api.Restore) error {
 		restore.Status.Phase = api.RestorePhaseCompleted
 		c.metrics.RegisterRestoreSuccess(backupScheduleName)
 	}
+	setRestorePhaseConditions(restore, c.clock.Now())
 
 	c.logger.Debug("Updating restore's final status")
 	if _, err = patchRestore(original, restore, c.restoreClient); err != nil {
 		c.logger.WithError(errors.WithStack(err)).Info("Error updating restore's final status")
 	}
+	c.recordPhaseEvent(restore)
+
+	switch restore.Status.Phase {
+	case api.RestorePhaseCompleted:
+		c.notifier.Notify(notifications.RestoreEvent(notifications.EventRestoreCompleted, restore))
+	case api.RestorePhasePartiallyFailed:
+		c.notifier.Notify(notifications.RestoreEvent(notifications.EventRestorePartiallyFailed, restore))
+	case api.RestorePhaseFailed:
+		c.notifier.Notify(notifications.RestoreEvent(notifications.EventRestoreFailed, restore))
+	}
 
 	return nil
 }
@@ -281,39 +313,39 @@ func (c *restoreController) processRestore(restore *api.Restore) error {
 type backupInfo struct {
 	backup      *api.Backup
 	backupStore persistence.BackupStore
+
+	// resultsStore is the backup store to upload the restore's log and
+	// results to. It's the same as backupStore unless the restore has a
+	// credential, in which case it uses the location's usual credentials.
+	resultsStore persistence.BackupStore
+
+	// credentialsFile is the temp file that the restore's credential is
+	// written to, if it has one.
+	credentialsFile string
+
+	// resourcePolicies are the resource policies from the restore's
+	// spec.resourcePolicy ConfigMap, if it has one.
+	resourcePolicies *resourcepolicies.Policies
 }
 
-func (c *restoreController) validateAndComplete(restore *api.Restore, pluginManager clientmgmt.Manager) backupInfo {
+// validateAndComplete validates the restore and fetches the backup to restore
+// from, recording any problems in the restore's validation errors. It only
+// returns an error if the backup store is unavailable or denied permission,
+// in which case the restore should be validated again later.
+func (c *restoreController) validateAndComplete(restore *api.Restore, pluginManager clientmgmt.Manager) (backupInfo, error) {
 	// add non-restorable resources to restore's excluded resources
 	excludedResources := sets.NewString(restore.Spec.ExcludedResources...)
-	for _, nonrestorable := range nonRestorableResources {
+	for _, nonrestorable := range validation.NonRestorableResources {
 		if !excludedResources.Has(nonrestorable) {
 			restore.Spec.ExcludedResources = append(restore.Spec.ExcludedResources, nonrestorable)
 		}
 	}
 
-	// validate that included resources don't contain any non-restorable resources
-	includedResources := sets.NewString(restore.Spec.IncludedResources...)
-	for _, nonRestorableResource := range nonRestorableResources {
-		if includedResources.Has(nonRestorableResource) {
-			restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, fmt.Sprintf("%v are non-restorable resources", nonRestorableResource))
-		}
-	}
-
-	// validate included/excluded resources
-	for _, err := range collections.ValidateIncludesExcludes(restore.Spec.IncludedResources, restore.Spec.ExcludedResources) {
-		restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, fmt.Sprintf("Invalid included/excluded resource lists: %v", err))
-	}
-
-	// validate included/excluded namespaces
-	for _, err := range collections.ValidateIncludesExcludes(restore.Spec.IncludedNamespaces, restore.Spec.ExcludedNamespaces) {
-		restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, fmt.Sprintf("Invalid included/excluded namespace lists: %v", err))
-	}
+	restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, validation.ValidateRestoreSpec(&restore.Spec)...)
 
 	// validate that exactly one of BackupName and ScheduleName have been specified
 	if !backupXorScheduleProvided(restore) {
-		restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, "Either a backup or schedule must be specified as a source for the restore, but not both")
-		return backupInfo{}
+		return backupInfo{}, nil
 	}
 
 	// if ScheduleName is specified, fill in BackupName with the most recent successful backup from
@@ -326,7 +358,7 @@ func (c *restoreController) validateAndComplete(restore *api.Restore, pluginMana
 		backups, err := c.backupLister.Backups(c.namespace).List(selector)
 		if err != nil {
 			restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, "Unable to list backups for schedule")
-			return backupInfo{}
+			return backupInfo{}, nil
 		}
 		if len(backups) == 0 {
 			restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, "No backups found for schedule")
@@ -336,14 +368,41 @@ func (c *restoreController) validateAndComplete(restore *api.Restore, pluginMana
 			restore.Spec.BackupName = backup.Name
 		} else {
 			restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, "No completed backups found for schedule")
-			return backupInfo{}
+			return backupInfo{}, nil
 		}
 	}
 
-	info, err := c.fetchBackupInfo(restore.Spec.BackupName, pluginManager)
+	var (
+		info backupInfo
+		err  error
+	)
+	if restore.Spec.BackupVersion != "" {
+		info, err = c.fetchBackupVersionInfo(restore.Spec.BackupName, restore.Spec.BackupVersion, restore.Spec.BackupStorageLocation, restore.Spec.Credential, pluginManager)
+	} else {
+		info, err = c.fetchBackupInfo(restore.Spec.BackupName, restore.Spec.BackupStorageLocation, restore.Spec.Credential, pluginManager)
+	}
+	if persistence.IsTransient(err) {
+		return backupInfo{}, errors.Wrap(err, "error retrieving backup")
+	}
 	if err != nil {
 		restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, fmt.Sprintf("Error retrieving backup: %v", err))
-		return backupInfo{}
+		return backupInfo{}, nil
+	}
+
+	if info.backup.Spec.Mode == api.BackupModeVolumeSnapshotOnly {
+		restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, fmt.Sprintf("Backup %s can't be restored because it's a %s backup, which doesn't contain any resources", info.backup.Name, api.BackupModeVolumeSnapshotOnly))
+		// return the info so that the credentials file, if any, is removed.
+		return info, nil
+	}
+
+	// get the restore's resource policies, if it has any
+	if restore.Spec.ResourcePolicy != "" {
+		policies, err := resourcepolicies.Get(c.configMapsClient.ConfigMaps(c.namespace), restore.Spec.ResourcePolicy)
+		if err != nil {
+			restore.Status.ValidationErrors = append(restore.Status.ValidationErrors, fmt.Sprintf("Invalid resource policies: %v", err))
+			return info, nil
+		}
+		info.resourcePolicies = policies
 	}
 
 	// Fill in the ScheduleName so it's easier to consume for metrics.
@@ -351,7 +410,7 @@ func (c *restoreController) validateAndComplete(restore *api.Restore, pluginMana
 		restore.Spec.ScheduleName = info.backup.GetLabels()[velerov1api.ScheduleNameLabel]
 	}
 
-	return info
+	return info, nil
 }
 
 // backupXorScheduleProvided returns true if exactly one of BackupName and
@@ -386,27 +445,197 @@ func mostRecentCompletedBackup(backups []*api.Backup) *api.Backup {
 }
 
 // fetchBackupInfo checks the backup lister for a backup that matches the given name. If it doesn't
-// find it, it returns an error.
-func (c *restoreController) fetchBackupInfo(backupName string, pluginManager clientmgmt.Manager) (backupInfo, error) {
+// find it, it returns an error. If locationName is non-empty, the backup is read from that storage
+// location, which must be either the backup's own storage location or one of its completed replicas.
+// Otherwise, the backup's own storage location is used, falling back to a completed replica if the
+// backup's storage location no longer exists.
+func (c *restoreController) fetchBackupInfo(backupName, locationName string, credential *corev1api.SecretKeySelector, pluginManager clientmgmt.Manager) (backupInfo, error) {
 	backup, err := c.backupLister.Backups(c.namespace).Get(backupName)
 	if err != nil {
 		return backupInfo{}, err
 	}
 
-	location, err := c.backupLocationLister.BackupStorageLocations(c.namespace).Get(backup.Spec.StorageLocation)
+	var location *api.BackupStorageLocation
+	switch {
+	case locationName != "":
+		if locationName != backup.Spec.StorageLocation && !hasCompletedReplica(backup, locationName) {
+			return backupInfo{}, errors.Errorf("backup %s does not have a completed replica in backup storage location %s", backup.Name, locationName)
+		}
+
+		location, err = c.backupLocationLister.BackupStorageLocations(c.namespace).Get(locationName)
+		if err != nil {
+			return backupInfo{}, errors.WithStack(err)
+		}
+	default:
+		location, err = c.backupLocationLister.BackupStorageLocations(c.namespace).Get(backup.Spec.StorageLocation)
+		if apierrors.IsNotFound(err) {
+			for _, replica := range backup.Status.Replicas {
+				if replica.Phase != api.BackupReplicaPhaseCompleted {
+					continue
+				}
+
+				replicaLocation, replicaErr := c.backupLocationLister.BackupStorageLocations(c.namespace).Get(replica.StorageLocation)
+				if replicaErr == nil {
+					c.logger.WithField("backup", backup.Name).Infof("Backup storage location %s not found, using replica in %s", backup.Spec.StorageLocation, replica.StorageLocation)
+					location, err = replicaLocation, nil
+					break
+				}
+			}
+		}
+		if err != nil {
+			return backupInfo{}, errors.WithStack(err)
+		}
+	}
+
+	info, err := c.newBackupStores(location, credential, pluginManager)
+	if err != nil {
+		return backupInfo{}, err
+	}
+	info.backup = backup
+
+	return info, nil
+}
+
+// fetchBackupVersionInfo reads the given version of a backup from a backup storage location
+// whose bucket has versioning enabled, so that a backup that was later overwritten or deleted
+// can be restored. If locationName is empty, the backup must exist, and its own storage location
+// is used.
+func (c *restoreController) fetchBackupVersionInfo(backupName, versionID, locationName string, credential *corev1api.SecretKeySelector, pluginManager clientmgmt.Manager) (backupInfo, error) {
+	if locationName == "" {
+		backup, err := c.backupLister.Backups(c.namespace).Get(backupName)
+		if apierrors.IsNotFound(err) {
+			return backupInfo{}, errors.Errorf("backup %s doesn't exist, so the backup storage location to read its version from must be specified", backupName)
+		}
+		if err != nil {
+			return backupInfo{}, errors.WithStack(err)
+		}
+		locationName = backup.Spec.StorageLocation
+	}
+
+	location, err := c.backupLocationLister.BackupStorageLocations(c.namespace).Get(locationName)
 	if err != nil {
 		return backupInfo{}, errors.WithStack(err)
 	}
 
-	backupStore, err := c.newBackupStore(location, pluginManager, c.logger)
+	info, err := c.newBackupStores(location, credential, pluginManager)
+	if err != nil {
+		return backupInfo{}, err
+	}
+
+	versionStore, err := info.backupStore.AtBackupVersion(backupName, versionID)
+	if err != nil {
+		c.removeCredentialsFile(info)
+		return backupInfo{}, err
+	}
+	if info.resultsStore == info.backupStore {
+		info.resultsStore = versionStore
+	}
+	info.backupStore = versionStore
+
+	if info.backup, err = versionStore.GetBackupMetadata(backupName); err != nil {
+		c.removeCredentialsFile(info)
+		return backupInfo{}, errors.Wrapf(err, "error getting version %s of backup %s", versionID, backupName)
+	}
+
+	return info, nil
+}
+
+// newBackupStores returns a backupInfo with the backup stores for a restore from a backup
+// storage location. If the restore has a credential, it's written to a temp file, and the
+// backup is read from a backup store whose object store is initialized with that file
+// instead of the location's usual credentials. The restore's log and results are uploaded
+// with the usual credentials, or with the credential if the usual ones don't work.
+func (c *restoreController) newBackupStores(location *api.BackupStorageLocation, credential *corev1api.SecretKeySelector, pluginManager clientmgmt.Manager) (backupInfo, error) {
+	if credential == nil {
+		backupStore, err := c.newBackupStore(location, pluginManager, c.logger)
+		if err != nil {
+			return backupInfo{}, err
+		}
+
+		return backupInfo{backupStore: backupStore, resultsStore: backupStore}, nil
+	}
+
+	credentialsFile, err := c.writeCredentialsFile(credential)
 	if err != nil {
 		return backupInfo{}, err
 	}
+	info := backupInfo{credentialsFile: credentialsFile}
+
+	credentialLocation := location.DeepCopy()
+	if credentialLocation.Spec.Config == nil {
+		credentialLocation.Spec.Config = make(map[string]string)
+	}
+	credentialLocation.Spec.Config[cloudprovider.CredentialsFileConfigKey] = credentialsFile
+
+	if info.backupStore, err = c.newBackupStore(credentialLocation, pluginManager, c.logger); err != nil {
+		c.removeCredentialsFile(info)
+		return backupInfo{}, errors.WithMessage(err, "error initializing backup storage location with the restore's credential")
+	}
+
+	if info.resultsStore, err = c.newBackupStore(location, pluginManager, c.logger); err != nil {
+		c.logger.WithError(err).WithField("backupLocation", location.Name).Warn("Unable to initialize backup storage location with its usual credentials, the restore's log and results will be uploaded with the restore's credential")
+		info.resultsStore = info.backupStore
+	}
 
-	return backupInfo{
-		backup:      backup,
-		backupStore: backupStore,
-	}, nil
+	return info, nil
+}
+
+// writeCredentialsFile writes the value of a credential's key in a secret in the
+// Velero namespace to a temp file, and returns the file's path.
+func (c *restoreController) writeCredentialsFile(credential *corev1api.SecretKeySelector) (string, error) {
+	secret, err := c.secretsClient.Secrets(c.namespace).Get(credential.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting credential secret %s", credential.Name)
+	}
+
+	data, ok := secret.Data[credential.Key]
+	if !ok {
+		return "", errors.Errorf("credential secret %s doesn't have key %s", credential.Name, credential.Key)
+	}
+
+	file, err := c.fileSystem.TempFile("", "velero-restore-credentials-")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		// nothing we can do about an error closing the file here, and we're
+		// already returning an error about the write failing.
+		file.Close()
+		c.fileSystem.RemoveAll(file.Name())
+		return "", errors.WithStack(err)
+	}
+
+	if err := file.Close(); err != nil {
+		c.fileSystem.RemoveAll(file.Name())
+		return "", errors.WithStack(err)
+	}
+
+	return file.Name(), nil
+}
+
+// removeCredentialsFile removes the temp file that a restore's credential was
+// written to, if any.
+func (c *restoreController) removeCredentialsFile(info backupInfo) {
+	if info.credentialsFile == "" {
+		return
+	}
+
+	if err := c.fileSystem.RemoveAll(info.credentialsFile); err != nil {
+		c.logger.WithError(errors.WithStack(err)).WithField("file", info.credentialsFile).Error("Error removing restore credentials file")
+	}
+}
+
+// hasCompletedReplica returns true if the backup has been successfully
+// replicated to the named backup storage location.
+func hasCompletedReplica(backup *api.Backup, locationName string) bool {
+	for _, replica := range backup.Status.Replicas {
+		if replica.StorageLocation == locationName && replica.Phase == api.BackupReplicaPhaseCompleted {
+			return true
+		}
+	}
+
+	return false
 }
 
 // runValidatedRestore takes a validated restore API object and executes the restore process.
@@ -416,7 +645,7 @@ func (c *restoreController) fetchBackupInfo(backupName string, pluginManager cli
 func (c *restoreController) runValidatedRestore(restore *api.Restore, info backupInfo) error {
 	// instantiate the per-restore logger that will output both to a temp file
 	// (for upload to object storage) and to stdout.
-	restoreLog, err := newRestoreLogger(restore, c.logger, c.restoreLogLevel, c.logFormat)
+	restoreLog, err := newRestoreLogger(restore, c.logger, c.restoreLogLevel, c.logFormat, c.structuredLogs)
 	if err != nil {
 		return err
 	}
@@ -430,16 +659,28 @@ func (c *restoreController) runValidatedRestore(restore *api.Restore, info backu
 		return errors.Wrap(err, "error getting restore item actions")
 	}
 
-	backupFile, err := downloadToTempFile(restore.Spec.BackupName, info.backupStore, restoreLog)
-	if err != nil {
-		return errors.Wrap(err, "error downloading backup")
+	var backupReader io.Reader
+	if restore.Spec.ExtractionPolicy == api.RestoreExtractionPolicySelective {
+		// the restore only extracts the items it needs from the backup, so
+		// the backup is streamed rather than downloaded.
+		contents, err := info.backupStore.GetBackupContents(restore.Spec.BackupName)
+		if err != nil {
+			return errors.Wrap(err, "error getting backup contents")
+		}
+		defer contents.Close()
+		backupReader = contents
+	} else {
+		backupFile, err := downloadToTempFile(restore.Spec.BackupName, info.backupStore, restoreLog)
+		if err != nil {
+			return errors.Wrap(err, "error downloading backup")
+		}
+		defer closeAndRemoveFile(backupFile, c.logger)
+		backupReader = backupFile
 	}
-	defer closeAndRemoveFile(backupFile, c.logger)
 
-	opts := restic.NewPodVolumeBackupListOptions(restore.Spec.BackupName)
-	podVolumeBackupList, err := c.podVolumeBackupClient.PodVolumeBackups(c.namespace).List(opts)
+	podVolumeBackups, err := c.getPodVolumeBackups(restore, info)
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
 
 	volumeSnapshots, err := info.backupStore.GetBackupVolumeSnapshots(restore.Spec.BackupName)
@@ -449,32 +690,68 @@ func (c *restoreController) runValidatedRestore(restore *api.Restore, info backu
 
 	restoreLog.Info("starting restore")
 
-	var podVolumeBackups []*velerov1api.PodVolumeBackup
-	for i := range podVolumeBackupList.Items {
-		podVolumeBackups = append(podVolumeBackups, &podVolumeBackupList.Items[i])
-	}
 	restoreReq := pkgrestore.Request{
 		Log:              restoreLog,
 		Restore:          restore,
 		Backup:           info.backup,
 		PodVolumeBackups: podVolumeBackups,
 		VolumeSnapshots:  volumeSnapshots,
-		BackupReader:     backupFile,
+		BackupReader:     backupReader,
+		Manifest:         new(pkgrestore.Manifest),
+		ResourcePolicies: info.resourcePolicies,
+	}
+	if restore.Spec.DryRun {
+		restoreReq.DryRunResults = new(pkgrestore.DryRunResults)
+	}
+
+	// a backup that skipped unchanged items needs its item index, and the
+	// backups it refers to, to restore those items.
+	if len(info.backup.Status.ReferencedBackups) > 0 {
+		itemIndex, err := info.backupStore.GetBackupItemIndex(restore.Spec.BackupName)
+		if err != nil {
+			return errors.Wrap(err, "error getting backup item index")
+		}
+		if itemIndex == nil {
+			return errors.New("backup refers to other backups for its unchanged items but doesn't have an item index")
+		}
+		restoreReq.ItemIndex = itemIndex
+		restoreReq.GetReferencedBackupContents = info.backupStore.GetBackupContents
 	}
+
 	restoreWarnings, restoreErrors := c.restorer.Restore(restoreReq, actions, c.snapshotLocationLister, pluginManager)
 	restoreLog.Info("restore completed")
 
+	if !restore.Spec.DryRun {
+		c.metrics.SetRestoreItemsRestored(restore.Spec.ScheduleName, restore.Spec.BackupName, len(restoreReq.Manifest.Items))
+	}
+
+	// uploadErrs are the errors uploading the restore's log and results,
+	// which are recorded in its StoreUploaded condition.
+	var uploadErrs []string
+
 	if logReader, err := restoreLog.done(c.logger); err != nil {
 		restoreErrors.Velero = append(restoreErrors.Velero, fmt.Sprintf("error getting restore log reader: %v", err))
 	} else {
-		if err := info.backupStore.PutRestoreLog(restore.Spec.BackupName, restore.Name, logReader); err != nil {
+		if err := info.resultsStore.PutRestoreLog(restore.Spec.BackupName, restore.Name, logReader); err != nil {
 			restoreErrors.Velero = append(restoreErrors.Velero, fmt.Sprintf("error uploading log file to backup storage: %v", err))
+			uploadErrs = append(uploadErrs, fmt.Sprintf("error uploading log file: %v", err))
+		}
+	}
+
+	if structuredLogReader, err := restoreLog.structuredLog(); err != nil {
+		restoreErrors.Velero = append(restoreErrors.Velero, fmt.Sprintf("error getting structured restore log reader: %v", err))
+	} else if structuredLogReader != nil {
+		if err := info.resultsStore.PutRestoreStructuredLog(restore.Spec.BackupName, restore.Name, structuredLogReader); err != nil {
+			restoreErrors.Velero = append(restoreErrors.Velero, fmt.Sprintf("error uploading structured log file to backup storage: %v", err))
+			uploadErrs = append(uploadErrs, fmt.Sprintf("error uploading structured log file: %v", err))
 		}
 	}
 
 	// At this point, no further logs should be written to restoreLog since it's been uploaded
 	// to object storage.
 
+	restore.Status.PluginCrashes = pluginManager.PluginCrashes()
+
 	restore.Status.Warnings = len(restoreWarnings.Velero) + len(restoreWarnings.Cluster)
 	for _, w := range restoreWarnings.Namespaces {
 		restore.Status.Warnings += len(w)
@@ -489,14 +766,90 @@ func (c *restoreController) runValidatedRestore(restore *api.Restore, info backu
 		"warnings": restoreWarnings,
 		"errors":   restoreErrors,
 	}
+	if restoreReq.DryRunResults != nil {
+		m["created"] = restoreReq.DryRunResults.Created
+		m["skipped"] = restoreReq.DryRunResults.Skipped
+		m["conflicts"] = restoreReq.DryRunResults.Conflicts
+	}
 
-	if err := putResults(restore, m, info.backupStore, c.logger); err != nil {
+	if err := putResults(restore, m, info.resultsStore, c.logger); err != nil {
 		c.logger.WithError(err).Error("Error uploading restore results to backup storage")
+		uploadErrs = append(uploadErrs, err.Error())
+	}
+
+	if err := putManifest(restore, restoreReq.Manifest, info.resultsStore); err != nil {
+		c.logger.WithError(err).Error("Error uploading restore manifest to backup storage")
+		uploadErrs = append(uploadErrs, err.Error())
+	}
+
+	if len(uploadErrs) == 0 {
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionStoreUploaded, corev1api.ConditionTrue, "Uploaded", "", c.clock.Now())
+	} else {
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionStoreUploaded, corev1api.ConditionFalse, "UploadFailed", strings.Join(uploadErrs, "; "), c.clock.Now())
 	}
 
 	return nil
 }
 
+// setRestorePhaseConditions sets the restore's Completed and Failed
+// conditions from its phase.
+func setRestorePhaseConditions(restore *api.Restore, now time.Time) {
+	phase := string(restore.Status.Phase)
+
+	switch restore.Status.Phase {
+	case api.RestorePhaseInProgress:
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionCompleted, corev1api.ConditionFalse, phase, "", now)
+	case api.RestorePhaseCompleted, api.RestorePhasePartiallyFailed:
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionCompleted, corev1api.ConditionTrue, phase, "", now)
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionFailed, corev1api.ConditionFalse, phase, "", now)
+	case api.RestorePhaseFailed:
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionCompleted, corev1api.ConditionTrue, phase, "", now)
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionFailed, corev1api.ConditionTrue, phase, restore.Status.FailureReason, now)
+	case api.RestorePhaseFailedValidation:
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionCompleted, corev1api.ConditionTrue, phase, "", now)
+		restore.Status.Conditions = setCondition(restore.Status.Conditions, api.ConditionFailed, corev1api.ConditionTrue, phase, strings.Join(restore.Status.ValidationErrors, "; "), now)
+	}
+}
+
+// recordPhaseEvent records a Kubernetes event about the restore's phase.
+func (c *restoreController) recordPhaseEvent(restore *api.Restore) {
+	switch restore.Status.Phase {
+	case api.RestorePhaseInProgress:
+		c.eventRecorder.Event(restore, corev1api.EventTypeNormal, "RestoreStarted", "Restore started")
+	case api.RestorePhaseCompleted:
+		c.eventRecorder.Event(restore, corev1api.EventTypeNormal, "RestoreCompleted", "Restore completed")
+	case api.RestorePhasePartiallyFailed:
+		c.eventRecorder.Event(restore, corev1api.EventTypeWarning, "RestorePartiallyFailed", fmt.Sprintf("Restore partially failed with %d errors", restore.Status.Errors))
+	case api.RestorePhaseFailed:
+		c.eventRecorder.Event(restore, corev1api.EventTypeWarning, "RestoreFailed", "Restore failed: "+restore.Status.FailureReason)
+	case api.RestorePhaseFailedValidation:
+		c.eventRecorder.Event(restore, corev1api.EventTypeWarning, "RestoreFailedValidation", "Restore failed validation: "+strings.Join(restore.Status.ValidationErrors, "; "))
+	}
+}
+
+// getPodVolumeBackups returns the restic backups of the backup's pod volumes. When restoring from
+// a version of a backup, the pod volume backups in the cluster, if any, belong to the backup's
+// current version, so the ones that were stored along with the version are used instead.
+func (c *restoreController) getPodVolumeBackups(restore *api.Restore, info backupInfo) ([]*velerov1api.PodVolumeBackup, error) {
+	if restore.Spec.BackupVersion != "" {
+		podVolumeBackups, err := info.backupStore.GetPodVolumeBackups(restore.Spec.BackupName)
+		return podVolumeBackups, errors.Wrap(err, "error getting pod volume backups")
+	}
+
+	opts := podvolume.NewPodVolumeBackupListOptions(restore.Spec.BackupName)
+	podVolumeBackupList, err := c.podVolumeBackupClient.PodVolumeBackups(c.namespace).List(opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var podVolumeBackups []*velerov1api.PodVolumeBackup
+	for i := range podVolumeBackupList.Items {
+		podVolumeBackups = append(podVolumeBackups, &podVolumeBackupList.Items[i])
+	}
+
+	return podVolumeBackups, nil
+}
+
 func putResults(restore *api.Restore, results map[string]pkgrestore.Result, backupStore persistence.BackupStore, log logrus.FieldLogger) error {
 	buf := new(bytes.Buffer)
 	gzw := gzip.NewWriter(buf)
@@ -517,6 +870,24 @@ func putResults(restore *api.Restore, results map[string]pkgrestore.Result, back
 	return nil
 }
 
+// putManifest uploads the manifest of the items that a restore created, which
+// is used to undo the restore.
+func putManifest(restore *api.Restore, manifest *pkgrestore.Manifest, backupStore persistence.BackupStore) error {
+	buf := new(bytes.Buffer)
+	gzw := gzip.NewWriter(buf)
+	defer gzw.Close()
+
+	if err := json.NewEncoder(gzw).Encode(manifest); err != nil {
+		return errors.Wrap(err, "error encoding restore manifest to JSON")
+	}
+
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "error closing gzip writer")
+	}
+
+	return backupStore.PutRestoreManifest(restore.Spec.BackupName, restore.Name, buf)
+}
+
 func downloadToTempFile(backupName string, backupStore persistence.BackupStore, logger logrus.FieldLogger) (*os.File, error) {
 	readCloser, err := backupStore.GetBackupContents(backupName)
 	if err != nil {
@@ -576,23 +947,43 @@ type restoreLogger struct {
 	logrus.FieldLogger
 	file *os.File
 	w    *gzip.Writer
+
+	// structuredFile and structuredW are only set when the restore
+	// log is also being written as JSON lines.
+	structuredFile *os.File
+	structuredW    *gzip.Writer
 }
 
-func newRestoreLogger(restore *api.Restore, baseLogger logrus.FieldLogger, logLevel logrus.Level, logFormat logging.Format) (*restoreLogger, error) {
+func newRestoreLogger(restore *api.Restore, baseLogger logrus.FieldLogger, logLevel logrus.Level, logFormat logging.Format, structured bool) (*restoreLogger, error) {
 	file, err := ioutil.TempFile("", "")
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating temp file")
 	}
 	w := gzip.NewWriter(file)
 
-	logger := logging.DefaultLogger(logLevel, logFormat)
+	logger := logging.DefaultLogger(logging.LevelOrDefault(restore.Spec.LogLevel, logLevel), logFormat)
 	logger.Out = io.MultiWriter(os.Stdout, w)
 
-	return &restoreLogger{
-		FieldLogger: logger.WithField("restore", kubeutil.NamespaceAndName(restore)),
-		file:        file,
-		w:           w,
-	}, nil
+	l := &restoreLogger{
+		file: file,
+		w:    w,
+	}
+
+	if structured {
+		structuredFile, err := ioutil.TempFile("", "")
+		if err != nil {
+			closeAndRemoveFile(file, baseLogger)
+			return nil, errors.Wrap(err, "error creating temp file")
+		}
+		l.structuredFile = structuredFile
+		l.structuredW = gzip.NewWriter(structuredFile)
+
+		logger.Hooks.Add(logging.NewJSONLinesHook(l.structuredW))
+	}
+
+	l.FieldLogger = logger.WithField("restore", kubeutil.NamespaceAndName(restore))
+
+	return l, nil
 }
 
 // done stops the restoreLogger from being able to be written to, and returns
@@ -604,6 +995,11 @@ func (l *restoreLogger) done(log logrus.FieldLogger) (io.Reader, error) {
 	if err := l.w.Close(); err != nil {
 		log.WithError(errors.WithStack(err)).Error("error closing gzip writer")
 	}
+	if l.structuredW != nil {
+		if err := l.structuredW.Close(); err != nil {
+			log.WithError(errors.WithStack(err)).Error("error closing gzip writer")
+		}
+	}
 
 	if _, err := l.file.Seek(0, 0); err != nil {
 		return nil, errors.Wrap(err, "error resetting log file offset to 0")
@@ -612,9 +1008,27 @@ func (l *restoreLogger) done(log logrus.FieldLogger) (io.Reader, error) {
 	return l.file, nil
 }
 
+// structuredLog returns an io.Reader for getting the content of the logger's
+// JSON-lines log, or nil if the logger isn't writing one. It must only be
+// called after done.
+func (l *restoreLogger) structuredLog() (io.Reader, error) {
+	if l.structuredFile == nil {
+		return nil, nil
+	}
+
+	if _, err := l.structuredFile.Seek(0, 0); err != nil {
+		return nil, errors.Wrap(err, "error resetting structured log file offset to 0")
+	}
+
+	return l.structuredFile, nil
+}
+
 // closeAndRemove removes the logger's underlying temporary storage. This
 // method should be called when all logging and reading from the logger is
 // complete.
 func (l *restoreLogger) closeAndRemove(log logrus.FieldLogger) {
 	closeAndRemoveFile(l.file, log)
+	if l.structuredFile != nil {
+		closeAndRemoveFile(l.structuredFile, log)
+	}
 }
@@ -18,8 +18,11 @@ package controller
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,14 +31,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/cloudprovider"
 	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
 	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
@@ -48,6 +55,7 @@ import (
 	pkgrestore "github.com/heptio/velero/pkg/restore"
 	velerotest "github.com/heptio/velero/pkg/test"
 	"github.com/heptio/velero/pkg/util/logging"
+	"github.com/heptio/velero/pkg/validation"
 	"github.com/heptio/velero/pkg/volume"
 )
 
@@ -55,6 +63,7 @@ func TestFetchBackupInfo(t *testing.T) {
 	tests := []struct {
 		name              string
 		backupName        string
+		locationName      string
 		informerLocations []*api.BackupStorageLocation
 		informerBackups   []*api.Backup
 		backupStoreBackup *api.Backup
@@ -77,6 +86,29 @@ func TestFetchBackupInfo(t *testing.T) {
 			informerBackups:   []*api.Backup{defaultBackup().StorageLocation("default").Result()},
 			expectedRes:       defaultBackup().StorageLocation("default").Result(),
 		},
+		{
+			name:              "requested location is a completed replica",
+			backupName:        "backup-1",
+			locationName:      "secondary",
+			informerLocations: []*api.BackupStorageLocation{builder.ForBackupStorageLocation("velero", "secondary").Provider("myCloud").Bucket("bucket-2").Result()},
+			informerBackups:   []*api.Backup{defaultBackup().StorageLocation("default").Replicas(api.BackupReplicaStatus{StorageLocation: "secondary", Phase: api.BackupReplicaPhaseCompleted}).Result()},
+			expectedRes:       defaultBackup().StorageLocation("default").Replicas(api.BackupReplicaStatus{StorageLocation: "secondary", Phase: api.BackupReplicaPhaseCompleted}).Result(),
+		},
+		{
+			name:              "requested location is not a completed replica",
+			backupName:        "backup-1",
+			locationName:      "secondary",
+			informerLocations: []*api.BackupStorageLocation{builder.ForBackupStorageLocation("velero", "secondary").Provider("myCloud").Bucket("bucket-2").Result()},
+			informerBackups:   []*api.Backup{defaultBackup().StorageLocation("default").Replicas(api.BackupReplicaStatus{StorageLocation: "secondary", Phase: api.BackupReplicaPhaseFailed}).Result()},
+			expectedErr:       true,
+		},
+		{
+			name:              "backup's location does not exist, falls back to completed replica",
+			backupName:        "backup-1",
+			informerLocations: []*api.BackupStorageLocation{builder.ForBackupStorageLocation("velero", "secondary").Provider("myCloud").Bucket("bucket-2").Result()},
+			informerBackups:   []*api.Backup{defaultBackup().StorageLocation("default").Replicas(api.BackupReplicaStatus{StorageLocation: "secondary", Phase: api.BackupReplicaPhaseCompleted}).Result()},
+			expectedRes:       defaultBackup().StorageLocation("default").Replicas(api.BackupReplicaStatus{StorageLocation: "secondary", Phase: api.BackupReplicaPhaseCompleted}).Result(),
+		},
 		{
 			name:             "no backup",
 			backupName:       "backup-1",
@@ -106,6 +138,8 @@ func TestFetchBackupInfo(t *testing.T) {
 				sharedInformers.Velero().V1().Restores(),
 				client.VeleroV1(),
 				client.VeleroV1(),
+				kubefake.NewSimpleClientset().CoreV1(),
+				kubefake.NewSimpleClientset().CoreV1(),
 				restorer,
 				sharedInformers.Velero().V1().Backups(),
 				sharedInformers.Velero().V1().BackupStorageLocations(),
@@ -115,7 +149,10 @@ func TestFetchBackupInfo(t *testing.T) {
 				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
 				"default",
 				metrics.NewServerMetrics(),
+				&fakeNotifier{},
+				&fakeEventRecorder{},
 				formatFlag,
+				false, // structuredLogs
 			).(*restoreController)
 
 			c.newBackupStore = func(*api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
@@ -145,7 +182,7 @@ func TestFetchBackupInfo(t *testing.T) {
 				backupStore.On("GetBackupMetadata", test.backupName).Return(test.backupStoreBackup, nil).Maybe()
 			}
 
-			info, err := c.fetchBackupInfo(test.backupName, pluginManager)
+			info, err := c.fetchBackupInfo(test.backupName, test.locationName, nil, pluginManager)
 
 			require.Equal(t, test.expectedErr, err != nil)
 			assert.Equal(t, test.expectedRes, info.backup)
@@ -153,6 +190,195 @@ func TestFetchBackupInfo(t *testing.T) {
 	}
 }
 
+func TestFetchBackupInfoWithCredential(t *testing.T) {
+	tests := []struct {
+		name        string
+		credential  *corev1api.SecretKeySelector
+		expectedErr string
+	}{
+		{
+			name:       "backup is read with the credential, and results are uploaded with the location's credentials",
+			credential: &corev1api.SecretKeySelector{LocalObjectReference: corev1api.LocalObjectReference{Name: "break-glass"}, Key: "cloud"},
+		},
+		{
+			name:        "credential secret doesn't exist",
+			credential:  &corev1api.SecretKeySelector{LocalObjectReference: corev1api.LocalObjectReference{Name: "missing"}, Key: "cloud"},
+			expectedErr: `error getting credential secret missing: secrets "missing" not found`,
+		},
+		{
+			name:        "credential secret doesn't have the key",
+			credential:  &corev1api.SecretKeySelector{LocalObjectReference: corev1api.LocalObjectReference{Name: "break-glass"}, Key: "aws"},
+			expectedErr: "credential secret break-glass doesn't have key aws",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				kubeClient      = kubefake.NewSimpleClientset(builder.ForSecret(api.DefaultNamespace, "break-glass").Data(map[string][]byte{"cloud": []byte("credentials")}).Result())
+				fileSystem      = velerotest.NewFakeFileSystem()
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+				resultsStore    = &persistencemocks.BackupStore{}
+			)
+
+			c := NewRestoreController(
+				api.DefaultNamespace,
+				sharedInformers.Velero().V1().Restores(),
+				client.VeleroV1(),
+				client.VeleroV1(),
+				kubeClient.CoreV1(),
+				kubeClient.CoreV1(),
+				&fakeRestorer{},
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				sharedInformers.Velero().V1().VolumeSnapshotLocations(),
+				velerotest.NewLogger(),
+				logrus.InfoLevel,
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+				"default",
+				metrics.NewServerMetrics(),
+				&fakeNotifier{},
+				&fakeEventRecorder{},
+				logging.FormatText,
+				false, // structuredLogs
+			).(*restoreController)
+			c.fileSystem = fileSystem
+
+			var credentialsFile string
+			c.newBackupStore = func(l *api.BackupStorageLocation, _ persistence.ObjectStoreGetter, _ logrus.FieldLogger) (persistence.BackupStore, error) {
+				if file := l.Spec.Config[cloudprovider.CredentialsFileConfigKey]; file != "" {
+					credentialsFile = file
+					return backupStore, nil
+				}
+				return resultsStore, nil
+			}
+
+			location := builder.ForBackupStorageLocation(api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result()
+			sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location)
+			sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(defaultBackup().StorageLocation("default").Result())
+
+			info, err := c.fetchBackupInfo("backup-1", "", test.credential, pluginManager)
+
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, backupStore, info.backupStore)
+			assert.Equal(t, resultsStore, info.resultsStore)
+			assert.Equal(t, credentialsFile, info.credentialsFile)
+			assert.Empty(t, location.Spec.Config[cloudprovider.CredentialsFileConfigKey], "the location in the informer isn't modified")
+
+			data, err := fileSystem.ReadFile(credentialsFile)
+			require.NoError(t, err)
+			assert.Equal(t, "credentials", string(data))
+
+			c.removeCredentialsFile(info)
+			_, err = fileSystem.Stat(credentialsFile)
+			assert.True(t, os.IsNotExist(err), "the credentials file is removed")
+		})
+	}
+}
+
+func TestFetchBackupVersionInfo(t *testing.T) {
+	tests := []struct {
+		name              string
+		locationName      string
+		informerLocations []*api.BackupStorageLocation
+		informerBackups   []*api.Backup
+		expectedLocation  string
+		expectedErr       string
+	}{
+		{
+			name:              "backup that no longer exists is read from the requested location",
+			locationName:      "default",
+			informerLocations: []*api.BackupStorageLocation{builder.ForBackupStorageLocation("velero", "default").Provider("myCloud").Bucket("bucket").Result()},
+			expectedLocation:  "default",
+		},
+		{
+			name:              "existing backup is read from its own location by default",
+			informerLocations: []*api.BackupStorageLocation{builder.ForBackupStorageLocation("velero", "secondary").Provider("myCloud").Bucket("bucket").Result()},
+			informerBackups:   []*api.Backup{defaultBackup().StorageLocation("secondary").Result()},
+			expectedLocation:  "secondary",
+		},
+		{
+			name:        "backup that no longer exists needs a location",
+			expectedErr: "backup backup-1 doesn't exist, so the backup storage location to read its version from must be specified",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+				versionStore    = &persistencemocks.BackupStore{}
+			)
+
+			defer backupStore.AssertExpectations(t)
+			defer versionStore.AssertExpectations(t)
+
+			c := NewRestoreController(
+				api.DefaultNamespace,
+				sharedInformers.Velero().V1().Restores(),
+				client.VeleroV1(),
+				client.VeleroV1(),
+				kubefake.NewSimpleClientset().CoreV1(),
+				kubefake.NewSimpleClientset().CoreV1(),
+				&fakeRestorer{},
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				sharedInformers.Velero().V1().VolumeSnapshotLocations(),
+				velerotest.NewLogger(),
+				logrus.InfoLevel,
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+				"default",
+				metrics.NewServerMetrics(),
+				&fakeNotifier{},
+				&fakeEventRecorder{},
+				logging.FormatText,
+				false, // structuredLogs
+			).(*restoreController)
+
+			var location string
+			c.newBackupStore = func(l *api.BackupStorageLocation, _ persistence.ObjectStoreGetter, _ logrus.FieldLogger) (persistence.BackupStore, error) {
+				location = l.Name
+				return backupStore, nil
+			}
+
+			for _, itm := range test.informerLocations {
+				sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(itm)
+			}
+			for _, itm := range test.informerBackups {
+				sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(itm)
+			}
+
+			backup := defaultBackup().StorageLocation(test.expectedLocation).Result()
+			if test.expectedErr == "" {
+				backupStore.On("AtBackupVersion", "backup-1", "v1").Return(versionStore, nil)
+				versionStore.On("GetBackupMetadata", "backup-1").Return(backup, nil)
+			}
+
+			info, err := c.fetchBackupVersionInfo("backup-1", "v1", test.locationName, nil, pluginManager)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedLocation, location)
+			assert.Equal(t, backup, info.backup)
+			assert.Equal(t, versionStore, info.backupStore)
+		})
+	}
+}
+
 func TestProcessQueueItemSkips(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -202,6 +428,8 @@ func TestProcessQueueItemSkips(t *testing.T) {
 				sharedInformers.Velero().V1().Restores(),
 				client.VeleroV1(),
 				client.VeleroV1(),
+				kubefake.NewSimpleClientset().CoreV1(),
+				kubefake.NewSimpleClientset().CoreV1(),
 				restorer,
 				sharedInformers.Velero().V1().Backups(),
 				sharedInformers.Velero().V1().BackupStorageLocations(),
@@ -211,7 +439,10 @@ func TestProcessQueueItemSkips(t *testing.T) {
 				nil,
 				"default",
 				metrics.NewServerMetrics(),
+				&fakeNotifier{},
+				&fakeEventRecorder{},
 				formatFlag,
+				false, // structuredLogs
 			).(*restoreController)
 
 			if test.restore != nil {
@@ -225,8 +456,35 @@ func TestProcessQueueItemSkips(t *testing.T) {
 	}
 }
 
+// expectedRestoreConditions returns the conditions of a restore whose phase
+// changed at now. Restores that finish running have uploaded their results.
+func expectedRestoreConditions(phase api.RestorePhase, message string, now time.Time) []api.Condition {
+	condition := func(conditionType api.ConditionType, status corev1api.ConditionStatus, reason, message string) api.Condition {
+		return api.Condition{Type: conditionType, Status: status, LastTransitionTime: metav1.NewTime(now), Reason: reason, Message: message}
+	}
+
+	switch phase {
+	case api.RestorePhaseInProgress:
+		return []api.Condition{
+			condition(api.ConditionCompleted, corev1api.ConditionFalse, string(phase), ""),
+		}
+	case api.RestorePhaseFailedValidation:
+		return []api.Condition{
+			condition(api.ConditionCompleted, corev1api.ConditionTrue, string(phase), ""),
+			condition(api.ConditionFailed, corev1api.ConditionTrue, string(phase), message),
+		}
+	default:
+		return []api.Condition{
+			condition(api.ConditionCompleted, corev1api.ConditionTrue, string(phase), ""),
+			condition(api.ConditionStoreUploaded, corev1api.ConditionTrue, "Uploaded", ""),
+			condition(api.ConditionFailed, corev1api.ConditionFalse, string(phase), ""),
+		}
+	}
+}
+
 func TestProcessQueueItem(t *testing.T) {
 	defaultStorageLocation := builder.ForBackupStorageLocation("velero", "default").Provider("myCloud").Bucket("bucket").Result()
+	now := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
 
 	tests := []struct {
 		name                            string
@@ -263,6 +521,42 @@ func TestProcessQueueItem(t *testing.T) {
 			expectedPhase:            string(api.RestorePhaseFailedValidation),
 			expectedValidationErrors: []string{"Invalid included/excluded resource lists: excludes list cannot contain an item in the includes list: a-resource"},
 		},
+		{
+			name:                     "restore with an invalid mode fails validation",
+			location:                 defaultStorageLocation,
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).Mode("ObjectsOnly").Result(),
+			backup:                   defaultBackup().StorageLocation("default").Result(),
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid restore mode \"ObjectsOnly\", must be one of Full or DataOnly"},
+		},
+		{
+			name:                     "restore with an invalid control-plane configuration policy fails validation",
+			location:                 defaultStorageLocation,
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).ControlPlaneConfigPolicy("Always").Result(),
+			backup:                   defaultBackup().StorageLocation("default").Result(),
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid control-plane configuration policy \"Always\", must be one of Skip or Restore"},
+		},
+		{
+			name:                     "restore from a volume-snapshot-only backup fails validation",
+			location:                 defaultStorageLocation,
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).Result(),
+			backup:                   defaultBackup().StorageLocation("default").Mode(api.BackupModeVolumeSnapshotOnly).Result(),
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Backup backup-1 can't be restored because it's a VolumeSnapshotOnly backup, which doesn't contain any resources"},
+		},
+		{
+			name:                     "restore with a non-existent resource policies ConfigMap fails validation",
+			location:                 defaultStorageLocation,
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).ResourcePolicy("nonexistent").Result(),
+			backup:                   defaultBackup().StorageLocation("default").Result(),
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid resource policies: error getting resource policies ConfigMap nonexistent: configmaps \"nonexistent\" not found"},
+		},
 		{
 			name:                     "new restore with empty backup and schedule names fails validation",
 			restore:                  NewRestore("foo", "bar", "", "ns-1", "", api.RestorePhaseNew).Result(),
@@ -315,6 +609,15 @@ func TestProcessQueueItem(t *testing.T) {
 			expectedPhase:        string(api.RestorePhaseInProgress),
 			expectedRestorerCall: NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseInProgress).Result(),
 		},
+		{
+			name:                 "valid restore with a selective extraction policy gets executed",
+			location:             defaultStorageLocation,
+			restore:              NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).ExtractionPolicy(api.RestoreExtractionPolicySelective).Result(),
+			backup:               defaultBackup().StorageLocation("default").Result(),
+			expectedErr:          false,
+			expectedPhase:        string(api.RestorePhaseInProgress),
+			expectedRestorerCall: NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseInProgress).ExtractionPolicy(api.RestoreExtractionPolicySelective).Result(),
+		},
 		{
 			name:          "restoration of nodes is not supported",
 			location:      defaultStorageLocation,
@@ -407,6 +710,8 @@ func TestProcessQueueItem(t *testing.T) {
 				sharedInformers.Velero().V1().Restores(),
 				client.VeleroV1(),
 				client.VeleroV1(),
+				kubefake.NewSimpleClientset().CoreV1(),
+				kubefake.NewSimpleClientset().CoreV1(),
 				restorer,
 				sharedInformers.Velero().V1().Backups(),
 				sharedInformers.Velero().V1().BackupStorageLocations(),
@@ -416,8 +721,12 @@ func TestProcessQueueItem(t *testing.T) {
 				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
 				"default",
 				metrics.NewServerMetrics(),
+				&fakeNotifier{},
+				&fakeEventRecorder{},
 				formatFlag,
+				false, // structuredLogs
 			).(*restoreController)
+			c.clock = clock.NewFakeClock(now)
 
 			c.newBackupStore = func(*api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
 				return backupStore, nil
@@ -464,6 +773,16 @@ func TestProcessQueueItem(t *testing.T) {
 
 					res.Status.Phase = api.RestorePhase(phase)
 
+					var conditionsPatch struct {
+						Status struct {
+							Conditions []api.Condition `json:"conditions"`
+						} `json:"status"`
+					}
+					if err := json.Unmarshal(patch, &conditionsPatch); err != nil {
+						return false, nil, err
+					}
+					res.Status.Conditions = conditionsPatch.Status.Conditions
+
 					backupName, found, err := unstructured.NestedString(patchMap, "spec", "backupName")
 					if found {
 						res.Spec.BackupName = backupName
@@ -493,6 +812,8 @@ func TestProcessQueueItem(t *testing.T) {
 
 				backupStore.On("PutRestoreResults", test.backup.Name, test.restore.Name, mock.Anything).Return(nil)
 
+				backupStore.On("PutRestoreManifest", test.backup.Name, test.restore.Name, mock.Anything).Return(nil)
+
 				volumeSnapshots := []*volume.Snapshot{
 					{
 						Spec: volume.SnapshotSpec{
@@ -528,6 +849,7 @@ func TestProcessQueueItem(t *testing.T) {
 			if test.restore != nil {
 				pluginManager.On("GetRestoreItemActions").Return(nil, nil)
 				pluginManager.On("CleanupClients")
+				pluginManager.On("PluginCrashes").Return(nil).Maybe()
 			}
 
 			err = c.processQueueItem(key)
@@ -549,6 +871,7 @@ func TestProcessQueueItem(t *testing.T) {
 				Phase            api.RestorePhase `json:"phase"`
 				ValidationErrors []string         `json:"validationErrors"`
 				Errors           int              `json:"errors"`
+				Conditions       []api.Condition  `json:"conditions"`
 			}
 
 			type Patch struct {
@@ -570,6 +893,7 @@ func TestProcessQueueItem(t *testing.T) {
 				Status: StatusPatch{
 					Phase:            api.RestorePhase(test.expectedPhase),
 					ValidationErrors: test.expectedValidationErrors,
+					Conditions:       expectedRestoreConditions(api.RestorePhase(test.expectedPhase), strings.Join(test.expectedValidationErrors, "; "), now),
 				},
 			}
 
@@ -591,28 +915,27 @@ func TestProcessQueueItem(t *testing.T) {
 
 			// validate Patch call 2 (setting phase)
 
+			finalPhase := api.RestorePhaseCompleted
+			// Override our default expectations if the case requires it
+			if test.expectedFinalPhase != "" {
+				finalPhase = api.RestorePhase(test.expectedFinalPhase)
+			}
 			expected = Patch{
 				Status: StatusPatch{
-					Phase:  api.RestorePhaseCompleted,
-					Errors: test.expectedRestoreErrors,
+					Phase:      finalPhase,
+					Errors:     test.expectedRestoreErrors,
+					Conditions: expectedRestoreConditions(finalPhase, "", now),
 				},
 			}
-			// Override our default expectations if the case requires it
-			if test.expectedFinalPhase != "" {
-				expected = Patch{
-					Status: StatusPatch{
-						Phase:  api.RestorePhase(test.expectedFinalPhase),
-						Errors: test.expectedRestoreErrors,
-					},
-				}
-			}
 
 			velerotest.ValidatePatch(t, actions[2], expected, decode)
 
 			// explicitly capturing the argument passed to Restore myself because
 			// I want to validate the called arg as of the time of calling, but
 			// the mock stores the pointer, which gets modified after
-			assert.Equal(t, *test.expectedRestorerCall, restorer.calledWithArg)
+			expectedRestorerCall := test.expectedRestorerCall.DeepCopy()
+			expectedRestorerCall.Status.Conditions = expectedRestoreConditions(api.RestorePhaseInProgress, "", now)
+			velerotest.AssertDeepEqual(t, *expectedRestorerCall, restorer.calledWithArg)
 		})
 	}
 }
@@ -633,6 +956,8 @@ func TestvalidateAndCompleteWhenScheduleNameSpecified(t *testing.T) {
 		client.VeleroV1(),
 		client.VeleroV1(),
 		nil,
+		nil,
+		nil,
 		sharedInformers.Velero().V1().Backups(),
 		sharedInformers.Velero().V1().BackupStorageLocations(),
 		sharedInformers.Velero().V1().VolumeSnapshotLocations(),
@@ -641,7 +966,10 @@ func TestvalidateAndCompleteWhenScheduleNameSpecified(t *testing.T) {
 		nil,
 		"default",
 		nil,
+		nil,
+		nil,
 		formatFlag,
+		false, // structuredLogs
 	).(*restoreController)
 
 	restore := &api.Restore{
@@ -662,7 +990,7 @@ func TestvalidateAndCompleteWhenScheduleNameSpecified(t *testing.T) {
 			Result(),
 	))
 
-	errs := c.validateAndComplete(restore, pluginManager)
+	errs, _ := c.validateAndComplete(restore, pluginManager)
 	assert.Equal(t, []string{"No backups found for schedule"}, errs)
 	assert.Empty(t, restore.Spec.BackupName)
 
@@ -677,7 +1005,7 @@ func TestvalidateAndCompleteWhenScheduleNameSpecified(t *testing.T) {
 			Result(),
 	))
 
-	errs = c.validateAndComplete(restore, pluginManager)
+	errs, _ = c.validateAndComplete(restore, pluginManager)
 	assert.Equal(t, []string{"No completed backups found for schedule"}, errs)
 	assert.Empty(t, restore.Spec.BackupName)
 
@@ -705,7 +1033,7 @@ func TestvalidateAndCompleteWhenScheduleNameSpecified(t *testing.T) {
 			Result(),
 	))
 
-	errs = c.validateAndComplete(restore, pluginManager)
+	errs, _ = c.validateAndComplete(restore, pluginManager)
 	assert.Nil(t, errs)
 	assert.Equal(t, "bar", restore.Spec.BackupName)
 }
@@ -799,6 +1127,43 @@ func TestMostRecentCompletedBackup(t *testing.T) {
 	assert.Equal(t, expected, mostRecentCompletedBackup(backups))
 }
 
+func TestRestoreLoggerStructuredLog(t *testing.T) {
+	restore := builder.ForRestore(api.DefaultNamespace, "restore-1").Result()
+
+	// without structured logs, there's no structured log to upload
+	restoreLog, err := newRestoreLogger(restore, velerotest.NewLogger(), logrus.InfoLevel, logging.FormatText, false)
+	require.NoError(t, err)
+	defer restoreLog.closeAndRemove(velerotest.NewLogger())
+
+	_, err = restoreLog.done(velerotest.NewLogger())
+	require.NoError(t, err)
+	structuredLog, err := restoreLog.structuredLog()
+	require.NoError(t, err)
+	assert.Nil(t, structuredLog)
+
+	// with structured logs, each entry is also written as a JSON line
+	restoreLog, err = newRestoreLogger(restore, velerotest.NewLogger(), logrus.InfoLevel, logging.FormatText, true)
+	require.NoError(t, err)
+	defer restoreLog.closeAndRemove(velerotest.NewLogger())
+
+	restoreLog.Info("starting restore")
+	_, err = restoreLog.done(velerotest.NewLogger())
+	require.NoError(t, err)
+	structuredLog, err = restoreLog.structuredLog()
+	require.NoError(t, err)
+	require.NotNil(t, structuredLog)
+
+	gzr, err := gzip.NewReader(structuredLog)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(gzr)
+	require.NoError(t, err)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &line))
+	assert.Equal(t, "starting restore", line["msg"])
+	assert.Equal(t, "velero/restore-1", line["restore"])
+}
+
 func NewRestore(ns, name, backup, includeNS, includeResource string, phase api.RestorePhase) *builder.RestoreBuilder {
 	restore := builder.ForRestore(ns, name).Phase(phase).Backup(backup)
 
@@ -810,7 +1175,7 @@ func NewRestore(ns, name, backup, includeNS, includeResource string, phase api.R
 		restore = restore.IncludedResources(includeResource)
 	}
 
-	restore.ExcludedResources(nonRestorableResources...)
+	restore.ExcludedResources(validation.NonRestorableResources...)
 
 	return restore
 }
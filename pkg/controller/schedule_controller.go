@@ -39,6 +39,7 @@ import (
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
 	"github.com/heptio/velero/pkg/metrics"
 	kubeutil "github.com/heptio/velero/pkg/util/kube"
+	"github.com/heptio/velero/pkg/validation"
 )
 
 const (
@@ -52,8 +53,13 @@ type scheduleController struct {
 	schedulesClient velerov1client.SchedulesGetter
 	backupsClient   velerov1client.BackupsGetter
 	schedulesLister listers.ScheduleLister
+	backupsLister   listers.BackupLister
 	clock           clock.Clock
 	metrics         *metrics.ServerMetrics
+
+	// deleteProtection is whether the backups that schedules create are
+	// delete-protected, regardless of their schedule's backup template.
+	deleteProtection bool
 }
 
 func NewScheduleController(
@@ -61,6 +67,8 @@ func NewScheduleController(
 	schedulesClient velerov1client.SchedulesGetter,
 	backupsClient velerov1client.BackupsGetter,
 	schedulesInformer informers.ScheduleInformer,
+	backupsInformer informers.BackupInformer,
+	deleteProtection bool,
 	logger logrus.FieldLogger,
 	metrics *metrics.ServerMetrics,
 ) *scheduleController {
@@ -70,12 +78,14 @@ func NewScheduleController(
 		schedulesClient:   schedulesClient,
 		backupsClient:     backupsClient,
 		schedulesLister:   schedulesInformer.Lister(),
+		backupsLister:     backupsInformer.Lister(),
 		clock:             clock.RealClock{},
 		metrics:           metrics,
+		deleteProtection:  deleteProtection,
 	}
 
 	c.syncHandler = c.processSchedule
-	c.cacheSyncWaiters = append(c.cacheSyncWaiters, schedulesInformer.Informer().HasSynced)
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, schedulesInformer.Informer().HasSynced, backupsInformer.Informer().HasSynced)
 	c.resyncFunc = c.enqueueAllEnabledSchedules
 	c.resyncPeriod = scheduleSyncPeriod
 
@@ -171,6 +181,11 @@ func (c *scheduleController) processSchedule(key string) error {
 	currentPhase := schedule.Status.Phase
 
 	cronSchedule, errs := parseCronSchedule(schedule, c.logger)
+	if len(errs) == 0 {
+		// validate the rest of the spec, including the spec of the backups the schedule
+		// creates, so that an invalid template is reported on the schedule rather than on each backup.
+		errs = validation.ValidateScheduleSpec(&schedule.Spec)
+	}
 	if len(errs) > 0 {
 		schedule.Status.Phase = api.SchedulePhaseFailedValidation
 		schedule.Status.ValidationErrors = errs
@@ -200,39 +215,9 @@ func (c *scheduleController) processSchedule(key string) error {
 }
 
 func parseCronSchedule(itm *api.Schedule, logger logrus.FieldLogger) (cron.Schedule, []string) {
-	var validationErrors []string
-	var schedule cron.Schedule
-
-	// cron.Parse panics if schedule is empty
-	if len(itm.Spec.Schedule) == 0 {
-		validationErrors = append(validationErrors, "Schedule must be a non-empty valid Cron expression")
-		return nil, validationErrors
-	}
-
-	log := logger.WithField("schedule", kubeutil.NamespaceAndName(itm))
-
-	// adding a recover() around cron.Parse because it panics on empty string and is possible
-	// that it panics under other scenarios as well.
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.WithFields(logrus.Fields{
-					"schedule": itm.Spec.Schedule,
-					"recover":  r,
-				}).Debug("Panic parsing schedule")
-				validationErrors = append(validationErrors, fmt.Sprintf("invalid schedule: %v", r))
-			}
-		}()
-
-		if res, err := cron.ParseStandard(itm.Spec.Schedule); err != nil {
-			log.WithError(errors.WithStack(err)).WithField("schedule", itm.Spec.Schedule).Debug("Error parsing schedule")
-			validationErrors = append(validationErrors, fmt.Sprintf("invalid schedule: %v", err))
-		} else {
-			schedule = res
-		}
-	}()
-
+	schedule, validationErrors := validation.ParseScheduleSpec(&itm.Spec)
 	if len(validationErrors) > 0 {
+		logger.WithField("schedule", kubeutil.NamespaceAndName(itm)).WithField("errors", validationErrors).Debug("Error parsing schedule")
 		return nil, validationErrors
 	}
 
@@ -252,13 +237,39 @@ func (c *scheduleController) submitBackupIfDue(item *api.Schedule, cronSchedule
 	}
 
 	// Don't attempt to "catch up" if there are any missed or failed runs - simply
-	// trigger a Backup if it's time.
-	//
-	// It might also make sense in the future to explicitly check for currently-running
-	// backups so that we don't overlap runs (for disk snapshots in particular, this can
-	// lead to performance issues).
+	// trigger a Backup if it's time, unless the previous one is still running,
+	// in which case the overlap policy decides.
+	if policy := item.Spec.OverlapPolicy; policy != api.ScheduleOverlapPolicyAllow {
+		running, err := c.runningBackup(item, now, log)
+		if err != nil {
+			return err
+		}
+
+		if running != nil {
+			log := log.WithField("backup", kubeutil.NamespaceAndName(running))
+
+			if policy != api.ScheduleOverlapPolicySkip {
+				log.Info("Schedule is due, but its previous backup is still running, waiting for it to finish")
+				return nil
+			}
+
+			log.WithField("nextRunTime", nextRunTime).Info("Schedule is due, but its previous backup is still running, skipping")
+
+			schedule := item.DeepCopy()
+			skipped := metav1.NewTime(now)
+			schedule.Status.LastSkipped = &skipped
+			if _, err := patchSchedule(item, schedule, c.schedulesClient); err != nil {
+				return errors.Wrapf(err, "error updating Schedule's LastSkipped time to %v", skipped)
+			}
+			return nil
+		}
+	}
+
 	log.WithField("nextRunTime", nextRunTime).Info("Schedule is due, submitting Backup")
 	backup := getBackup(item, now)
+	if c.deleteProtection {
+		backup.Spec.DeleteProtection = true
+	}
 	if _, err := c.backupsClient.Backups(backup.Namespace).Create(backup); err != nil {
 		return errors.Wrap(err, "error creating Backup")
 	}
@@ -275,10 +286,62 @@ func (c *scheduleController) submitBackupIfDue(item *api.Schedule, cronSchedule
 	return nil
 }
 
+// runningBackupTimeout is how long a backup can go without starting or
+// being retried before a schedule no longer treats it as running. Backups
+// that were in progress when the server stopped are never finished, and
+// would otherwise stop their schedule from running.
+const runningBackupTimeout = 24 * time.Hour
+
+// runningBackup returns a backup created by the schedule that's New or
+// InProgress, or nil if there isn't one.
+func (c *scheduleController) runningBackup(schedule *api.Schedule, now time.Time, log logrus.FieldLogger) (*api.Backup, error) {
+	selector := labels.SelectorFromSet(labels.Set{velerov1api.ScheduleNameLabel: schedule.Name})
+	backups, err := c.backupsLister.Backups(schedule.Namespace).List(selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing the schedule's backups")
+	}
+
+	for _, backup := range backups {
+		switch backup.Status.Phase {
+		case "", api.BackupPhaseNew, api.BackupPhaseInProgress:
+		default:
+			continue
+		}
+
+		if lastActive := backupLastActive(backup); now.Sub(lastActive) > runningBackupTimeout {
+			log.WithFields(logrus.Fields{
+				"backup":     kubeutil.NamespaceAndName(backup),
+				"lastActive": lastActive,
+			}).Warn("Ignoring backup that hasn't finished in too long, it was probably interrupted by the server stopping")
+			continue
+		}
+
+		return backup, nil
+	}
+
+	return nil, nil
+}
+
+// backupLastActive returns the latest of when the backup was created, when
+// it was last started, and when it's next retried.
+func backupLastActive(backup *api.Backup) time.Time {
+	lastActive := backup.CreationTimestamp.Time
+	if backup.Status.StartTimestamp.After(lastActive) {
+		lastActive = backup.Status.StartTimestamp.Time
+	}
+	if next := backup.Status.NextAttempt; next != nil && next.After(lastActive) {
+		lastActive = next.Time
+	}
+	return lastActive
+}
+
 func getNextRunTime(schedule *api.Schedule, cronSchedule cron.Schedule, asOf time.Time) (bool, time.Time) {
 	// get the latest run time (if the schedule hasn't run yet, this will be the zero value which will trigger
-	// an immediate backup)
+	// an immediate backup). A skipped run counts as the latest run if it's more recent than the last backup.
 	lastBackupTime := schedule.Status.LastBackup.Time
+	if lastSkipped := schedule.Status.LastSkipped; lastSkipped != nil && lastSkipped.After(lastBackupTime) {
+		lastBackupTime = lastSkipped.Time
+	}
 
 	nextRunTime := cronSchedule.Next(lastBackupTime)
 
@@ -54,6 +54,9 @@ func TestProcessSchedule(t *testing.T) {
 		expectedValidationErrors []string
 		expectedBackupCreate     *velerov1api.Backup
 		expectedLastBackup       string
+		expectedLastSkipped      string
+		deleteProtection         bool
+		backups                  []*velerov1api.Backup
 	}{
 		{
 			name:        "invalid key returns error",
@@ -108,6 +111,15 @@ func TestProcessSchedule(t *testing.T) {
 			expectedBackupCreate: builder.ForBackup("ns", "name-20170101120000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).NoTypeMeta().Result(),
 			expectedLastBackup:   "2017-01-01 12:00:00",
 		},
+		{
+			name:                 "schedule's backup is delete-protected when delete protection is on",
+			schedule:             newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").Result(),
+			fakeClockTime:        "2017-01-01 12:00:00",
+			deleteProtection:     true,
+			expectedErr:          false,
+			expectedBackupCreate: builder.ForBackup("ns", "name-20170101120000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).DeleteProtection(true).NoTypeMeta().Result(),
+			expectedLastBackup:   "2017-01-01 12:00:00",
+		},
 		{
 			name:                 "schedule that's already run gets LastBackup updated",
 			schedule:             newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").LastBackupTime("2000-01-01 00:00:00").Result(),
@@ -116,6 +128,70 @@ func TestProcessSchedule(t *testing.T) {
 			expectedBackupCreate: builder.ForBackup("ns", "name-20170101120000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).NoTypeMeta().Result(),
 			expectedLastBackup:   "2017-01-01 12:00:00",
 		},
+		{
+			name:          "schedule whose previous backup is in progress waits for it by default",
+			schedule:      newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").LastBackupTime("2017-01-01 11:50:00").Result(),
+			fakeClockTime: "2017-01-01 12:00:00",
+			backups: []*velerov1api.Backup{
+				builder.ForBackup("ns", "name-20170101115000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).Phase(velerov1api.BackupPhaseInProgress).StartTimestamp(time.Date(2017, 1, 1, 11, 50, 0, 0, time.UTC)).Result(),
+			},
+		},
+		{
+			name:          "schedule whose previous backup is in progress waits for it with the Queue overlap policy",
+			schedule:      newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").OverlapPolicy(velerov1api.ScheduleOverlapPolicyQueue).LastBackupTime("2017-01-01 11:50:00").Result(),
+			fakeClockTime: "2017-01-01 12:00:00",
+			backups: []*velerov1api.Backup{
+				builder.ForBackup("ns", "name-20170101115000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).Phase(velerov1api.BackupPhaseInProgress).StartTimestamp(time.Date(2017, 1, 1, 11, 50, 0, 0, time.UTC)).Result(),
+			},
+		},
+		{
+			name:                 "schedule whose previous backup has been in progress for too long triggers a backup by default",
+			schedule:             newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").LastBackupTime("2016-12-30 11:50:00").Result(),
+			fakeClockTime:        "2017-01-01 12:00:00",
+			expectedBackupCreate: builder.ForBackup("ns", "name-20170101120000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).NoTypeMeta().Result(),
+			expectedLastBackup:   "2017-01-01 12:00:00",
+			backups: []*velerov1api.Backup{
+				builder.ForBackup("ns", "name-20161230115000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).Phase(velerov1api.BackupPhaseInProgress).StartTimestamp(time.Date(2016, 12, 30, 11, 50, 0, 0, time.UTC)).Result(),
+			},
+		},
+		{
+			name:          "schedule whose previous backup is new waits for it with the Queue overlap policy",
+			schedule:      newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").OverlapPolicy(velerov1api.ScheduleOverlapPolicyQueue).LastBackupTime("2017-01-01 11:50:00").Result(),
+			fakeClockTime: "2017-01-01 12:00:00",
+			backups: []*velerov1api.Backup{
+				builder.ForBackup("ns", "name-20170101115000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name"), builder.WithCreationTimestamp(time.Date(2017, 1, 1, 11, 50, 0, 0, time.UTC))).Phase(velerov1api.BackupPhaseNew).Result(),
+			},
+		},
+		{
+			name:                "schedule whose previous backup is in progress is skipped with the Skip overlap policy",
+			schedule:            newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").OverlapPolicy(velerov1api.ScheduleOverlapPolicySkip).LastBackupTime("2017-01-01 11:50:00").Result(),
+			fakeClockTime:       "2017-01-01 12:00:00",
+			expectedLastSkipped: "2017-01-01 12:00:00",
+			backups: []*velerov1api.Backup{
+				builder.ForBackup("ns", "name-20170101115000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).Phase(velerov1api.BackupPhaseInProgress).StartTimestamp(time.Date(2017, 1, 1, 11, 50, 0, 0, time.UTC)).Result(),
+			},
+		},
+		{
+			name:                 "schedule whose previous backup is in progress triggers a backup with the Allow overlap policy",
+			schedule:             newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").OverlapPolicy(velerov1api.ScheduleOverlapPolicyAllow).LastBackupTime("2017-01-01 11:50:00").Result(),
+			fakeClockTime:        "2017-01-01 12:00:00",
+			expectedBackupCreate: builder.ForBackup("ns", "name-20170101120000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).NoTypeMeta().Result(),
+			expectedLastBackup:   "2017-01-01 12:00:00",
+			backups: []*velerov1api.Backup{
+				builder.ForBackup("ns", "name-20170101115000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).Phase(velerov1api.BackupPhaseInProgress).Result(),
+			},
+		},
+		{
+			name:                 "schedule whose previous backup is completed triggers a backup",
+			schedule:             newScheduleBuilder(velerov1api.SchedulePhaseEnabled).CronSchedule("@every 5m").LastBackupTime("2017-01-01 11:50:00").Result(),
+			fakeClockTime:        "2017-01-01 12:00:00",
+			expectedBackupCreate: builder.ForBackup("ns", "name-20170101120000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).NoTypeMeta().Result(),
+			expectedLastBackup:   "2017-01-01 12:00:00",
+			backups: []*velerov1api.Backup{
+				builder.ForBackup("ns", "name-20170101115000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "name")).Phase(velerov1api.BackupPhaseCompleted).Result(),
+				builder.ForBackup("ns", "other-20170101115000").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "other")).Phase(velerov1api.BackupPhaseInProgress).Result(),
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -131,6 +207,8 @@ func TestProcessSchedule(t *testing.T) {
 				client.VeleroV1(),
 				client.VeleroV1(),
 				sharedInformers.Velero().V1().Schedules(),
+				sharedInformers.Velero().V1().Backups(),
+				test.deleteProtection,
 				logger,
 				metrics.NewServerMetrics(),
 			)
@@ -145,6 +223,10 @@ func TestProcessSchedule(t *testing.T) {
 			}
 			c.clock = clock.NewFakeClock(testTime)
 
+			for _, backup := range test.backups {
+				require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup))
+			}
+
 			if test.schedule != nil {
 				sharedInformers.Velero().V1().Schedules().Informer().GetStore().Add(test.schedule)
 
@@ -198,6 +280,7 @@ func TestProcessSchedule(t *testing.T) {
 				ValidationErrors []string                  `json:"validationErrors"`
 				Phase            velerov1api.SchedulePhase `json:"phase"`
 				LastBackup       time.Time                 `json:"lastBackup"`
+				LastSkipped      *time.Time                `json:"lastSkipped"`
 			}
 
 			type Patch struct {
@@ -249,7 +332,26 @@ func TestProcessSchedule(t *testing.T) {
 				}
 
 				velerotest.ValidatePatch(t, actions[index], expected, decode)
+
+				index++
 			}
+
+			if test.expectedLastSkipped != "" {
+				require.True(t, len(actions) > index, "len(actions) is too small")
+
+				lastSkipped := parseTime(test.expectedLastSkipped)
+				expected := Patch{
+					Status: PatchStatus{
+						LastSkipped: &lastSkipped,
+					},
+				}
+
+				velerotest.ValidatePatch(t, actions[index], expected, decode)
+
+				index++
+			}
+
+			assert.Len(t, actions, index)
 		})
 	}
 }
@@ -268,6 +370,7 @@ func TestGetNextRunTime(t *testing.T) {
 		name                      string
 		schedule                  *velerov1api.Schedule
 		lastRanOffset             string
+		lastSkippedOffset         string
 		expectedDue               bool
 		expectedNextRunTimeOffset string
 	}{
@@ -305,6 +408,22 @@ func TestGetNextRunTime(t *testing.T) {
 			expectedDue:               true,
 			expectedNextRunTimeOffset: "5m",
 		},
+		{
+			name:                      "skipped more recently than the last run",
+			schedule:                  defaultSchedule(),
+			lastRanOffset:             "10m",
+			lastSkippedOffset:         "1m",
+			expectedDue:               false,
+			expectedNextRunTimeOffset: "5m",
+		},
+		{
+			name:                      "skipped before the last run",
+			schedule:                  defaultSchedule(),
+			lastRanOffset:             "5m",
+			lastSkippedOffset:         "10m",
+			expectedDue:               true,
+			expectedNextRunTimeOffset: "5m",
+		},
 	}
 
 	for _, test := range tests {
@@ -321,11 +440,23 @@ func TestGetNextRunTime(t *testing.T) {
 				test.schedule.Status.LastBackup = metav1.Time{Time: testClock.Now().Add(-offsetDuration)}
 			}
 
+			lastRan := test.schedule.Status.LastBackup.Time
+			if test.lastSkippedOffset != "" {
+				offsetDuration, err := time.ParseDuration(test.lastSkippedOffset)
+				require.NoError(t, err, "unable to parse test.lastSkippedOffset: %v", err)
+
+				lastSkipped := metav1.NewTime(testClock.Now().Add(-offsetDuration))
+				test.schedule.Status.LastSkipped = &lastSkipped
+				if lastSkipped.After(lastRan) {
+					lastRan = lastSkipped.Time
+				}
+			}
+
 			nextRunTimeOffset, err := time.ParseDuration(test.expectedNextRunTimeOffset)
 			if err != nil {
 				panic(err)
 			}
-			expectedNextRunTime := test.schedule.Status.LastBackup.Add(nextRunTimeOffset)
+			expectedNextRunTime := lastRan.Add(nextRunTimeOffset)
 
 			due, nextRunTime := getNextRunTime(test.schedule, cronSchedule, testClock.Now())
 
@@ -0,0 +1,239 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	kubeutil "github.com/heptio/velero/pkg/util/kube"
+)
+
+const scheduleRetentionSyncPeriod = time.Hour
+
+// scheduleRetentionController creates DeleteBackupRequests for the completed
+// backups of schedules with a retention policy that the policy no longer
+// keeps.
+type scheduleRetentionController struct {
+	*genericController
+
+	scheduleLister            listers.ScheduleLister
+	backupLister              listers.BackupLister
+	deleteBackupRequestLister listers.DeleteBackupRequestLister
+	deleteBackupRequestClient velerov1client.DeleteBackupRequestsGetter
+	backupLocationLister      listers.BackupStorageLocationLister
+}
+
+// NewScheduleRetentionController constructs a new scheduleRetentionController.
+func NewScheduleRetentionController(
+	logger logrus.FieldLogger,
+	scheduleInformer informers.ScheduleInformer,
+	backupInformer informers.BackupInformer,
+	deleteBackupRequestInformer informers.DeleteBackupRequestInformer,
+	deleteBackupRequestClient velerov1client.DeleteBackupRequestsGetter,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+) Interface {
+	c := &scheduleRetentionController{
+		genericController:         newGenericController("schedule-retention", logger),
+		scheduleLister:            scheduleInformer.Lister(),
+		backupLister:              backupInformer.Lister(),
+		deleteBackupRequestLister: deleteBackupRequestInformer.Lister(),
+		deleteBackupRequestClient: deleteBackupRequestClient,
+		backupLocationLister:      backupLocationInformer.Lister(),
+	}
+
+	c.syncHandler = c.processSchedule
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters,
+		scheduleInformer.Informer().HasSynced,
+		backupInformer.Informer().HasSynced,
+		deleteBackupRequestInformer.Informer().HasSynced,
+		backupLocationInformer.Informer().HasSynced,
+	)
+
+	c.resyncPeriod = scheduleRetentionSyncPeriod
+	c.resyncFunc = c.enqueueAllSchedules
+
+	scheduleInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueue,
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		},
+	)
+
+	// a schedule's backups are only pruned once a newer backup completes,
+	// so check its schedule whenever one does.
+	backupInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) {
+				backup := obj.(*velerov1api.Backup)
+				if backup.Status.Phase != velerov1api.BackupPhaseCompleted {
+					return
+				}
+				if name := backup.Labels[velerov1api.ScheduleNameLabel]; name != "" {
+					c.queue.Add(backup.Namespace + "/" + name)
+				}
+			},
+		},
+	)
+
+	return c
+}
+
+func (c *scheduleRetentionController) enqueueAllSchedules() {
+	schedules, err := c.scheduleLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing schedules")
+		return
+	}
+
+	for _, schedule := range schedules {
+		if schedule.Spec.Retention != nil {
+			c.enqueue(schedule)
+		}
+	}
+}
+
+func (c *scheduleRetentionController) processSchedule(key string) error {
+	log := c.logger.WithField("schedule", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	schedule, err := c.scheduleLister.Schedules(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find schedule")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting schedule")
+	}
+
+	if schedule.Spec.Retention == nil {
+		return nil
+	}
+
+	// don't prune backups using a policy that failed validation.
+	if schedule.Status.Phase != velerov1api.SchedulePhaseEnabled {
+		log.Debug("Schedule is not enabled, skipping its retention policy")
+		return nil
+	}
+
+	location := time.UTC
+	if schedule.Spec.TimeZone != "" {
+		if location, err = time.LoadLocation(schedule.Spec.TimeZone); err != nil {
+			return errors.Wrapf(err, "error loading schedule's time zone %s", schedule.Spec.TimeZone)
+		}
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{velerov1api.ScheduleNameLabel: schedule.Name})
+	backups, err := c.backupLister.Backups(ns).List(selector)
+	if err != nil {
+		return errors.Wrap(err, "error listing schedule's backups")
+	}
+
+	var errs []error
+	for _, backup := range backupsToPrune(schedule.Spec.Retention, backups, location) {
+		log := log.WithField("backup", kubeutil.NamespaceAndName(backup))
+		log.Info("Backup is no longer kept by its schedule's retention policy")
+
+		if err := requestDeletion(backup, c.backupLister, c.backupLocationLister, c.deleteBackupRequestLister, c.deleteBackupRequestClient, log); err != nil {
+			errs = append(errs, errors.Wrapf(err, "error deleting backup %s", backup.Name))
+		}
+	}
+
+	return kubeerrs.NewAggregate(errs)
+}
+
+// backupsToPrune returns the completed backups that the retention policy
+// doesn't keep, with the policy's periods evaluated in location. Backups
+// that haven't completed are never returned.
+func backupsToPrune(retention *velerov1api.ScheduleRetention, backups []*velerov1api.Backup, location *time.Location) []*velerov1api.Backup {
+	var completed []*velerov1api.Backup
+	for _, backup := range backups {
+		if backup.Status.Phase == velerov1api.BackupPhaseCompleted {
+			completed = append(completed, backup)
+		}
+	}
+
+	// newest first, so that the newest backup of each period is kept.
+	sort.Slice(completed, func(i, j int) bool {
+		return backupTime(completed[j]).Before(backupTime(completed[i]))
+	})
+
+	rules := []struct {
+		keep   int
+		period func(time.Time) string
+	}{
+		{retention.KeepHourly, func(t time.Time) string { return t.Format("2006-01-02 15") }},
+		{retention.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{retention.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+		{retention.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+		{retention.KeepYearly, func(t time.Time) string { return t.Format("2006") }},
+	}
+
+	kept := make(map[*velerov1api.Backup]bool)
+	for _, rule := range rules {
+		var (
+			periods    int
+			lastPeriod string
+		)
+		for _, backup := range completed {
+			if periods >= rule.keep {
+				break
+			}
+			if period := rule.period(backupTime(backup).In(location)); period != lastPeriod {
+				kept[backup] = true
+				lastPeriod = period
+				periods++
+			}
+		}
+	}
+
+	var res []*velerov1api.Backup
+	for _, backup := range completed {
+		if !kept[backup] {
+			res = append(res, backup)
+		}
+	}
+	return res
+}
+
+// backupTime returns when a backup was started, or created if it hasn't
+// been started.
+func backupTime(backup *velerov1api.Backup) time.Time {
+	if !backup.Status.StartTimestamp.IsZero() {
+		return backup.Status.StartTimestamp.Time
+	}
+	return backup.CreationTimestamp.Time
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+// dailyBackups returns a completed backup of schedule-1 at 01:00 UTC on
+// each of the given days of July 2019.
+func dailyBackups(days ...int) []*velerov1api.Backup {
+	var backups []*velerov1api.Backup
+	for _, day := range days {
+		backups = append(backups, scheduledBackup(time.Date(2019, 7, day, 1, 0, 0, 0, time.UTC)).Result())
+	}
+	return backups
+}
+
+func scheduledBackup(start time.Time) *builder.BackupBuilder {
+	return builder.ForBackup(velerov1api.DefaultNamespace, fmt.Sprintf("schedule-1-%s", start.Format("20060102150405"))).
+		ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "schedule-1")).
+		StorageLocation("default").
+		Phase(velerov1api.BackupPhaseCompleted).
+		StartTimestamp(start)
+}
+
+func TestBackupsToPrune(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		retention velerov1api.ScheduleRetention
+		backups   []*velerov1api.Backup
+		location  *time.Location
+		want      []string
+	}{
+		{
+			name:      "daily keeps the newest days",
+			retention: velerov1api.ScheduleRetention{KeepDaily: 3},
+			backups:   dailyBackups(1, 2, 3, 4, 5),
+			want:      []string{"schedule-1-20190701010000", "schedule-1-20190702010000"},
+		},
+		{
+			name:      "daily and weekly keep the union of their backups",
+			retention: velerov1api.ScheduleRetention{KeepDaily: 2, KeepWeekly: 2},
+			backups:   dailyBackups(1, 2, 3, 4, 5, 6, 7, 8, 9, 10),
+			// the 7th is the newest backup of the week before the 10th.
+			want: []string{
+				"schedule-1-20190701010000",
+				"schedule-1-20190702010000",
+				"schedule-1-20190703010000",
+				"schedule-1-20190704010000",
+				"schedule-1-20190705010000",
+				"schedule-1-20190706010000",
+				"schedule-1-20190708010000",
+			},
+		},
+		{
+			name:      "monthly and yearly keep periods that have a backup",
+			retention: velerov1api.ScheduleRetention{KeepMonthly: 2, KeepYearly: 2},
+			backups: []*velerov1api.Backup{
+				scheduledBackup(time.Date(2019, 7, 2, 1, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 7, 1, 1, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 5, 1, 1, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 4, 1, 1, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2018, 12, 1, 1, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2017, 12, 1, 1, 0, 0, 0, time.UTC)).Result(),
+			},
+			want: []string{
+				"schedule-1-20190701010000",
+				"schedule-1-20190401010000",
+				"schedule-1-20171201010000",
+			},
+		},
+		{
+			name:      "hourly keeps the newest hours",
+			retention: velerov1api.ScheduleRetention{KeepHourly: 2},
+			backups: []*velerov1api.Backup{
+				scheduledBackup(time.Date(2019, 7, 1, 10, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 7, 1, 10, 30, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 7, 1, 11, 0, 0, 0, time.UTC)).Result(),
+			},
+			want: []string{"schedule-1-20190701100000"},
+		},
+		{
+			name:      "backups that didn't complete are never pruned or kept",
+			retention: velerov1api.ScheduleRetention{KeepDaily: 1},
+			backups: []*velerov1api.Backup{
+				scheduledBackup(time.Date(2019, 7, 3, 1, 0, 0, 0, time.UTC)).Phase(velerov1api.BackupPhaseInProgress).Result(),
+				scheduledBackup(time.Date(2019, 7, 2, 1, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 7, 1, 1, 0, 0, 0, time.UTC)).Phase(velerov1api.BackupPhaseFailed).Result(),
+				scheduledBackup(time.Date(2019, 6, 30, 1, 0, 0, 0, time.UTC)).Result(),
+			},
+			want: []string{"schedule-1-20190630010000"},
+		},
+		{
+			name:      "backups on the same day in UTC are on different days in the location",
+			retention: velerov1api.ScheduleRetention{KeepDaily: 2},
+			backups: []*velerov1api.Backup{
+				scheduledBackup(time.Date(2019, 7, 1, 21, 30, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 7, 1, 22, 30, 0, 0, time.UTC)).Result(),
+			},
+			location: berlin,
+		},
+		{
+			name:      "backups on the same day in UTC",
+			retention: velerov1api.ScheduleRetention{KeepDaily: 2},
+			backups: []*velerov1api.Backup{
+				scheduledBackup(time.Date(2019, 7, 1, 21, 30, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 7, 1, 22, 30, 0, 0, time.UTC)).Result(),
+			},
+			want: []string{"schedule-1-20190701213000"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location := test.location
+			if location == nil {
+				location = time.UTC
+			}
+
+			var got []string
+			for _, backup := range backupsToPrune(&test.retention, test.backups, location) {
+				got = append(got, backup.Name)
+			}
+
+			sort.Strings(got)
+			sort.Strings(test.want)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestScheduleRetentionControllerProcessSchedule(t *testing.T) {
+	tests := []struct {
+		name            string
+		schedule        *velerov1api.Schedule
+		backups         []*velerov1api.Backup
+		expectDeletions []string
+	}{
+		{
+			name:     "schedule without a retention policy is skipped",
+			schedule: builder.ForSchedule(velerov1api.DefaultNamespace, "schedule-1").Phase(velerov1api.SchedulePhaseEnabled).Result(),
+			backups:  dailyBackups(1, 2, 3),
+		},
+		{
+			name: "schedule that isn't enabled is skipped",
+			schedule: builder.ForSchedule(velerov1api.DefaultNamespace, "schedule-1").Phase(velerov1api.SchedulePhaseFailedValidation).
+				Retention(velerov1api.ScheduleRetention{KeepDaily: 1}).Result(),
+			backups: dailyBackups(1, 2, 3),
+		},
+		{
+			name: "backups the policy doesn't keep are deleted",
+			schedule: builder.ForSchedule(velerov1api.DefaultNamespace, "schedule-1").Phase(velerov1api.SchedulePhaseEnabled).
+				Retention(velerov1api.ScheduleRetention{KeepDaily: 1}).Result(),
+			backups:         dailyBackups(1, 2, 3),
+			expectDeletions: []string{"schedule-1-20190701010000", "schedule-1-20190702010000"},
+		},
+		{
+			name: "delete-protected backups aren't deleted",
+			schedule: builder.ForSchedule(velerov1api.DefaultNamespace, "schedule-1").Phase(velerov1api.SchedulePhaseEnabled).
+				Retention(velerov1api.ScheduleRetention{KeepDaily: 1}).Result(),
+			backups: []*velerov1api.Backup{
+				scheduledBackup(time.Date(2019, 7, 3, 1, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 7, 2, 1, 0, 0, 0, time.UTC)).DeleteProtection(true).Result(),
+				scheduledBackup(time.Date(2019, 7, 1, 1, 0, 0, 0, time.UTC)).Result(),
+			},
+			expectDeletions: []string{"schedule-1-20190701010000"},
+		},
+		{
+			name: "other schedules' backups aren't deleted",
+			schedule: builder.ForSchedule(velerov1api.DefaultNamespace, "schedule-1").Phase(velerov1api.SchedulePhaseEnabled).
+				Retention(velerov1api.ScheduleRetention{KeepDaily: 1}).Result(),
+			backups: []*velerov1api.Backup{
+				scheduledBackup(time.Date(2019, 7, 3, 1, 0, 0, 0, time.UTC)).Result(),
+				scheduledBackup(time.Date(2019, 7, 1, 1, 0, 0, 0, time.UTC)).ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "schedule-2")).Result(),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			controller := NewScheduleRetentionController(
+				velerotest.NewLogger(),
+				sharedInformers.Velero().V1().Schedules(),
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().DeleteBackupRequests(),
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+			).(*scheduleRetentionController)
+
+			// the fake clientset doesn't generate names, so creating more than one
+			// DeleteBackupRequest would fail.
+			client.PrependReactor("create", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+				return true, action.(core.CreateAction).GetObject(), nil
+			})
+
+			require.NoError(t, sharedInformers.Velero().V1().Schedules().Informer().GetStore().Add(test.schedule))
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Result()))
+			for _, backup := range test.backups {
+				require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup))
+			}
+
+			require.NoError(t, controller.processSchedule(velerov1api.DefaultNamespace+"/schedule-1"))
+
+			var deleted []string
+			for _, action := range client.Actions() {
+				createAction, ok := action.(core.CreateAction)
+				require.True(t, ok)
+				dbr := createAction.GetObject().(*velerov1api.DeleteBackupRequest)
+				deleted = append(deleted, dbr.Spec.BackupName)
+			}
+
+			sort.Strings(deleted)
+			assert.Equal(t, test.expectDeletions, deleted)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/credentials"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/metrics"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+// snapshotBackupTagKey is the tag that the backup's name is stored in on
+// the volume snapshots it takes.
+const snapshotBackupTagKey = "velero.io/backup"
+
+// snapshotGCController periodically deletes the volume snapshots taken by
+// Velero whose backups no longer exist, which are left behind when deleting
+// a backup fails to delete its snapshots. A snapshot is kept if its backup
+// exists in the cluster or in any backup storage location, or if any
+// backup's volume snapshots refer to it.
+type snapshotGCController struct {
+	*genericController
+
+	namespace              string
+	backupLister           listers.BackupLister
+	backupLocationLister   listers.BackupStorageLocationLister
+	snapshotLocationLister listers.VolumeSnapshotLocationLister
+	gracePeriod            time.Duration
+	dryRun                 bool
+	newPluginManager       func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore         func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	metrics                *metrics.ServerMetrics
+	clock                  clock.Clock
+
+	// firstSeen is when each orphaned snapshot, keyed by provider and
+	// snapshot ID, was first found.
+	firstSeen map[string]time.Time
+}
+
+// NewSnapshotGCController constructs a new snapshotGCController.
+func NewSnapshotGCController(
+	namespace string,
+	backupInformer informers.BackupInformer,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+	snapshotLocationInformer informers.VolumeSnapshotLocationInformer,
+	frequency time.Duration,
+	gracePeriod time.Duration,
+	dryRun bool,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+	metrics *metrics.ServerMetrics,
+	logger logrus.FieldLogger,
+) Interface {
+	c := &snapshotGCController{
+		genericController:      newGenericController("snapshot-gc", logger),
+		namespace:              namespace,
+		backupLister:           backupInformer.Lister(),
+		backupLocationLister:   backupLocationInformer.Lister(),
+		snapshotLocationLister: snapshotLocationInformer.Lister(),
+		gracePeriod:            gracePeriod,
+		dryRun:                 dryRun,
+		newPluginManager:       newPluginManager,
+		newBackupStore:         persistence.NewBackupStore,
+		metrics:                metrics,
+		clock:                  clock.RealClock{},
+		firstSeen:              make(map[string]time.Time),
+	}
+
+	c.resyncFunc = c.run
+	c.resyncPeriod = frequency
+	c.cacheSyncWaiters = []cache.InformerSynced{
+		backupInformer.Informer().HasSynced,
+		backupLocationInformer.Informer().HasSynced,
+		snapshotLocationInformer.Informer().HasSynced,
+	}
+
+	return c
+}
+
+func (c *snapshotGCController) run() {
+	backupNames, snapshotIDs, err := c.existingBackups()
+	if err != nil {
+		c.logger.WithError(err).Error("Error getting existing backups, not garbage-collecting volume snapshots")
+		return
+	}
+
+	locations, err := c.snapshotLocationLister.VolumeSnapshotLocations(c.namespace).List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing volume snapshot locations")
+		return
+	}
+
+	found := sets.NewString()
+	for _, location := range locations {
+		log := c.logger.WithField("volumeSnapshotLocation", location.Name)
+
+		keys, err := c.processLocation(location, backupNames, snapshotIDs, log)
+		if err != nil {
+			log.WithError(err).Error("Error garbage-collecting volume snapshots")
+			continue
+		}
+		found.Insert(keys...)
+	}
+
+	// forget the orphaned snapshots that have been deleted.
+	for key := range c.firstSeen {
+		if !found.Has(key) {
+			delete(c.firstSeen, key)
+		}
+	}
+}
+
+// existingBackups returns the names of the backups in the cluster and in
+// every backup storage location, and the IDs of the volume snapshots they
+// refer to. It returns an error if any location can't be read, since a
+// snapshot can't be known to be orphaned without all of them.
+func (c *snapshotGCController) existingBackups() (sets.String, sets.String, error) {
+	backupNames, snapshotIDs := sets.NewString(), sets.NewString()
+
+	backups, err := c.backupLister.Backups(c.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error listing backups")
+	}
+	for _, backup := range backups {
+		backupNames.Insert(backup.Name)
+	}
+
+	locations, err := c.backupLocationLister.BackupStorageLocations(c.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error listing backup storage locations")
+	}
+
+	for _, location := range locations {
+		if err := c.addLocationBackups(location, backupNames, snapshotIDs); err != nil {
+			return nil, nil, errors.Wrapf(err, "error reading backup storage location %s", location.Name)
+		}
+	}
+
+	return backupNames, snapshotIDs, nil
+}
+
+func (c *snapshotGCController) addLocationBackups(location *velerov1api.BackupStorageLocation, backupNames, snapshotIDs sets.String) error {
+	log := c.logger.WithField("backupLocation", location.Name)
+
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		return errors.Wrap(err, "error getting backup store")
+	}
+
+	backups, err := backupStore.ListBackups()
+	if err != nil {
+		return errors.Wrap(err, "error listing backups in backup store")
+	}
+
+	for _, name := range backups {
+		backupNames.Insert(name)
+
+		snapshots, err := backupStore.GetBackupVolumeSnapshots(name)
+		if err != nil {
+			return errors.Wrapf(err, "error getting volume snapshots for backup %s", name)
+		}
+		for _, snapshot := range snapshots {
+			if snapshot.Status.ProviderSnapshotID != "" {
+				snapshotIDs.Insert(snapshot.Status.ProviderSnapshotID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// processLocation deletes the orphaned snapshots in the location that are
+// older than the grace period, and returns the keys of those that are left.
+func (c *snapshotGCController) processLocation(location *velerov1api.VolumeSnapshotLocation, backupNames, snapshotIDs sets.String, log logrus.FieldLogger) ([]string, error) {
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	volumeSnapshotter, err := pluginManager.GetVolumeSnapshotter(location.Spec.Provider)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting volume snapshotter")
+	}
+	config, err := credentials.WithCredentialsFile(location.Spec.Config, location.Spec.Credential)
+	if err != nil {
+		return nil, err
+	}
+	if err := volumeSnapshotter.Init(config); err != nil {
+		return nil, errors.Wrap(err, "error initializing volume snapshotter")
+	}
+
+	lister, ok := volumeSnapshotter.(velero.SnapshotLister)
+	if !ok {
+		log.Debug("Skipping volume snapshot location because its volume snapshotter doesn't support listing snapshots")
+		return nil, nil
+	}
+
+	snapshots, err := lister.ListSnapshots(snapshotBackupTagKey)
+	if err == velero.ErrSnapshotListingNotSupported {
+		log.Debug("Skipping volume snapshot location because its volume snapshotter doesn't support listing snapshots")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing volume snapshots")
+	}
+
+	var (
+		now  = c.clock.Now()
+		keys []string
+	)
+	for snapshotID, backupName := range snapshots {
+		if backupNames.Has(backupName) || snapshotIDs.Has(snapshotID) {
+			continue
+		}
+
+		log := log.WithFields(logrus.Fields{
+			"snapshotID": snapshotID,
+			"backup":     backupName,
+		})
+
+		key := location.Spec.Provider + "/" + snapshotID
+		firstSeen, ok := c.firstSeen[key]
+		if !ok {
+			log.Info("Found volume snapshot whose backup no longer exists")
+			firstSeen = now
+			c.firstSeen[key] = now
+		}
+
+		if now.Sub(firstSeen) < c.gracePeriod {
+			keys = append(keys, key)
+			continue
+		}
+
+		if c.dryRun {
+			log.Info("Not deleting orphaned volume snapshot because snapshot garbage collection is in dry-run mode")
+			keys = append(keys, key)
+			continue
+		}
+
+		log.Info("Deleting orphaned volume snapshot")
+		if err := volumeSnapshotter.DeleteSnapshot(snapshotID); err != nil {
+			log.WithError(err).Error("Error deleting orphaned volume snapshot")
+			c.metrics.RegisterOrphanedSnapshotDeletionFailure(location.Name)
+			keys = append(keys, key)
+			continue
+		}
+		c.metrics.RegisterOrphanedSnapshotDeletion(location.Name)
+	}
+
+	c.metrics.SetOrphanedSnapshots(location.Name, len(keys))
+
+	return keys, nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/metrics"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+	"github.com/heptio/velero/pkg/volume"
+)
+
+func TestSnapshotGCControllerRun(t *testing.T) {
+	tests := []struct {
+		name            string
+		dryRun          bool
+		listBackupsErr  error
+		expectedDeleted bool
+	}{
+		{
+			name:            "orphaned snapshot is deleted after the grace period",
+			expectedDeleted: true,
+		},
+		{
+			name:   "orphaned snapshot isn't deleted in dry-run mode",
+			dryRun: true,
+		},
+		{
+			name:           "nothing is deleted when a backup storage location can't be read",
+			listBackupsErr: errors.New("bucket not found"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client            = fake.NewSimpleClientset()
+				sharedInformers   = informers.NewSharedInformerFactory(client, 0)
+				pluginManager     = &pluginmocks.Manager{}
+				backupStore       = &persistencemocks.BackupStore{}
+				volumeSnapshotter = &velerotest.FakeVolumeSnapshotter{
+					SnapshotsTaken: sets.NewString("snap-1", "snap-2", "snap-3", "snap-4", "snap-5"),
+					SnapshotTags: map[string]map[string]string{
+						"snap-1": {snapshotBackupTagKey: "in-cluster"},
+						"snap-2": {snapshotBackupTagKey: "in-store"},
+						"snap-3": {snapshotBackupTagKey: "renamed"},
+						"snap-4": {snapshotBackupTagKey: "deleted"},
+						"snap-5": {"other-tag": "value"},
+					},
+				}
+				fakeClock = clock.NewFakeClock(time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC))
+			)
+
+			c := NewSnapshotGCController(
+				velerov1api.DefaultNamespace,
+				sharedInformers.Velero().V1().Backups(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				sharedInformers.Velero().V1().VolumeSnapshotLocations(),
+				time.Hour,
+				time.Hour,
+				test.dryRun,
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+				metrics.NewServerMetrics(),
+				velerotest.NewLogger(),
+			).(*snapshotGCController)
+			c.clock = fakeClock
+			c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+				return backupStore, nil
+			}
+
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(
+				builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result(),
+			))
+			require.NoError(t, sharedInformers.Velero().V1().VolumeSnapshotLocations().Informer().GetStore().Add(
+				builder.ForVolumeSnapshotLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Result(),
+			))
+			require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(
+				builder.ForBackup(velerov1api.DefaultNamespace, "in-cluster").Phase(velerov1api.BackupPhaseInProgress).Result(),
+			))
+
+			pluginManager.On("CleanupClients").Return(nil)
+			pluginManager.On("GetVolumeSnapshotter", "myCloud").Return(volumeSnapshotter, nil)
+			if test.listBackupsErr != nil {
+				backupStore.On("ListBackups").Return(nil, test.listBackupsErr)
+			} else {
+				backupStore.On("ListBackups").Return([]string{"in-store"}, nil)
+			}
+			backupStore.On("GetBackupVolumeSnapshots", "in-store").Return([]*volume.Snapshot{
+				{Status: volume.SnapshotStatus{ProviderSnapshotID: "snap-2"}},
+				{Status: volume.SnapshotStatus{ProviderSnapshotID: "snap-3"}},
+			}, nil)
+
+			// the orphaned snapshot is only found on the first run, so it's
+			// within the grace period.
+			c.run()
+			assert.True(t, volumeSnapshotter.SnapshotsTaken.Has("snap-4"))
+
+			fakeClock.Step(2 * time.Hour)
+			c.run()
+
+			if test.expectedDeleted {
+				assert.Equal(t, sets.NewString("snap-1", "snap-2", "snap-3", "snap-5"), volumeSnapshotter.SnapshotsTaken)
+				assert.Empty(t, c.firstSeen)
+			} else {
+				assert.Equal(t, sets.NewString("snap-1", "snap-2", "snap-3", "snap-4", "snap-5"), volumeSnapshotter.SnapshotsTaken)
+			}
+		})
+	}
+}
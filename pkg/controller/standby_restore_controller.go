@@ -0,0 +1,296 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/label"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+)
+
+const standbyRestoreSyncPeriod = time.Hour
+
+// standbyRestoreController keeps a warm copy of the backups of read-only
+// backup storage locations with a standby policy, by restoring the newest
+// backup that matches the policy's selector whenever a newer one is synced
+// from the location.
+type standbyRestoreController struct {
+	*genericController
+
+	backupLister         listers.BackupLister
+	restoreClient        velerov1client.RestoresGetter
+	restoreLister        listers.RestoreLister
+	backupLocationClient velerov1client.BackupStorageLocationsGetter
+	backupLocationLister listers.BackupStorageLocationLister
+	newPluginManager     func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore       func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	clock                clock.Clock
+}
+
+// NewStandbyRestoreController constructs a new standbyRestoreController.
+func NewStandbyRestoreController(
+	logger logrus.FieldLogger,
+	backupInformer informers.BackupInformer,
+	restoreClient velerov1client.RestoresGetter,
+	restoreInformer informers.RestoreInformer,
+	backupLocationClient velerov1client.BackupStorageLocationsGetter,
+	backupLocationInformer informers.BackupStorageLocationInformer,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+) Interface {
+	c := &standbyRestoreController{
+		genericController:    newGenericController("standby-restore", logger),
+		backupLister:         backupInformer.Lister(),
+		restoreClient:        restoreClient,
+		restoreLister:        restoreInformer.Lister(),
+		backupLocationClient: backupLocationClient,
+		backupLocationLister: backupLocationInformer.Lister(),
+		newPluginManager:     newPluginManager,
+		newBackupStore:       persistence.NewBackupStore,
+		clock:                clock.RealClock{},
+	}
+
+	c.syncHandler = c.processLocation
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters,
+		backupInformer.Informer().HasSynced,
+		restoreInformer.Informer().HasSynced,
+		backupLocationInformer.Informer().HasSynced,
+	)
+
+	c.resyncPeriod = standbyRestoreSyncPeriod
+	c.resyncFunc = c.enqueueStandbyLocations
+
+	// backups are synced from the location into the cluster, so a new
+	// backup is an add.
+	backupInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				backup := obj.(*velerov1api.Backup)
+				if backup.Spec.StorageLocation != "" {
+					c.queue.Add(backup.Namespace + "/" + backup.Spec.StorageLocation)
+				}
+			},
+		},
+	)
+
+	// the next backup is restored once the current restore finishes.
+	restoreInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) {
+				restore := obj.(*velerov1api.Restore)
+				if _, ok := restore.Labels[velerov1api.StandbyLocationLabel]; !ok {
+					return
+				}
+				backup, err := c.backupLister.Backups(restore.Namespace).Get(restore.Spec.BackupName)
+				if err != nil {
+					return
+				}
+				c.queue.Add(backup.Namespace + "/" + backup.Spec.StorageLocation)
+			},
+		},
+	)
+
+	enqueueStandbyLocation := func(obj interface{}) {
+		if obj.(*velerov1api.BackupStorageLocation).Spec.Standby != nil {
+			c.enqueue(obj)
+		}
+	}
+	backupLocationInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: enqueueStandbyLocation,
+			UpdateFunc: func(_, obj interface{}) {
+				enqueueStandbyLocation(obj)
+			},
+		},
+	)
+
+	return c
+}
+
+func (c *standbyRestoreController) enqueueStandbyLocations() {
+	locations, err := c.backupLocationLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing backup storage locations")
+		return
+	}
+
+	for _, location := range locations {
+		if location.Spec.Standby != nil {
+			c.enqueue(location)
+		}
+	}
+}
+
+// standbyRestoreName returns the name of the restore that restores the
+// named backup into standby namespaces.
+func standbyRestoreName(backup string) string {
+	return backup + "-standby"
+}
+
+func (c *standbyRestoreController) processLocation(key string) error {
+	log := c.logger.WithField("backupLocation", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	location, err := c.backupLocationLister.BackupStorageLocations(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find backup storage location")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup storage location")
+	}
+
+	standby := location.Spec.Standby
+	if standby == nil {
+		return nil
+	}
+
+	// restoring the backups of a location that this cluster also backs up
+	// into would restore the cluster's own backups over it.
+	if location.Spec.AccessMode != velerov1api.BackupStorageLocationAccessModeReadOnly {
+		log.Warn("Backup storage location has a standby policy but isn't read-only, so its backups won't be restored")
+		return nil
+	}
+
+	selector := labels.Everything()
+	if standby.BackupSelector != nil {
+		if selector, err = metav1.LabelSelectorAsSelector(standby.BackupSelector); err != nil {
+			log.WithError(err).Warn("Backup storage location's standby policy has an invalid backup selector")
+			return nil
+		}
+	}
+
+	restores, err := c.restoreLister.Restores(ns).List(labels.SelectorFromSet(labels.Set{velerov1api.StandbyLocationLabel: label.GetValidName(name)}))
+	if err != nil {
+		return errors.Wrap(err, "error listing standby restores")
+	}
+	for _, restore := range restores {
+		switch restore.Status.Phase {
+		case "", velerov1api.RestorePhaseNew, velerov1api.RestorePhaseInProgress:
+			log.WithField("restore", restore.Name).Debug("Waiting for the current standby restore to finish")
+			return nil
+		}
+	}
+
+	backups, err := c.backupLister.Backups(ns).List(selector)
+	if err != nil {
+		return errors.Wrap(err, "error listing backups")
+	}
+
+	var newest *velerov1api.Backup
+	for _, backup := range backups {
+		if backup.Spec.StorageLocation != name || backup.Status.Phase != velerov1api.BackupPhaseCompleted {
+			continue
+		}
+		if newest == nil || backup.Status.StartTimestamp.After(newest.Status.StartTimestamp.Time) {
+			newest = backup
+		}
+	}
+
+	// only the newest backup is restored, so backups that are synced
+	// while a standby restore runs are skipped if a newer one arrives.
+	if newest == nil || newest.Name == location.Status.LastStandbyBackup {
+		return nil
+	}
+	if last, err := c.backupLister.Backups(ns).Get(location.Status.LastStandbyBackup); err == nil && !newest.Status.StartTimestamp.After(last.Status.StartTimestamp.Time) {
+		return nil
+	}
+
+	log = log.WithField("backup", newest.Name)
+
+	restore := &velerov1api.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      standbyRestoreName(newest.Name),
+			Labels: map[string]string{
+				velerov1api.StandbyLocationLabel: label.GetValidName(name),
+			},
+		},
+		Spec: velerov1api.RestoreSpec{
+			BackupName: newest.Name,
+		},
+	}
+
+	if standby.NamespacePrefix != "" {
+		namespaces, err := c.backupNamespaces(location, newest.Name, log)
+		if err != nil {
+			return err
+		}
+
+		restore.Spec.IncludedNamespaces = namespaces
+		restore.Spec.NamespaceMapping = make(map[string]string)
+		for _, namespace := range namespaces {
+			restore.Spec.NamespaceMapping[namespace] = standby.NamespacePrefix + namespace
+		}
+	}
+
+	log.WithField("restore", restore.Name).Info("Creating restore to update standby namespaces")
+	if _, err := c.restoreClient.Restores(ns).Create(restore); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "error creating standby restore")
+	}
+
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"lastStandbyBackup":      newest.Name,
+			"lastStandbyRestoreTime": c.clock.Now().UTC(),
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling backup storage location status patch to JSON")
+	}
+
+	if _, err := c.backupLocationClient.BackupStorageLocations(ns).Patch(name, types.MergePatchType, patchBytes); err != nil {
+		return errors.Wrap(err, "error patching backup storage location status")
+	}
+
+	return nil
+}
+
+// backupNamespaces returns the sorted namespaces of the named backup's
+// items, read from its resource list in the location.
+func (c *standbyRestoreController) backupNamespaces(location *velerov1api.BackupStorageLocation, backupName string, log logrus.FieldLogger) ([]string, error) {
+	pluginManager := c.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := c.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting backup store")
+	}
+
+	return resourceListNamespaces(backupStore, backupName)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestStandbyRestoreControllerProcessLocation(t *testing.T) {
+	now := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	syncedBackup := func(name string, start time.Time) *builder.BackupBuilder {
+		return builder.ForBackup(velerov1api.DefaultNamespace, name).
+			ObjectMeta(builder.WithLabels("app", "db")).
+			StorageLocation("primary").
+			Phase(velerov1api.BackupPhaseCompleted).
+			StartTimestamp(start)
+	}
+
+	backups := []*velerov1api.Backup{
+		syncedBackup("backup-1", now.Add(-3*time.Hour)).Result(),
+		syncedBackup("backup-2", now.Add(-2*time.Hour)).Result(),
+		// newer, but failed, in another location, or not selected.
+		syncedBackup("backup-3", now.Add(-time.Hour)).Phase(velerov1api.BackupPhaseFailed).Result(),
+		syncedBackup("backup-4", now.Add(-time.Hour)).StorageLocation("other").Result(),
+		syncedBackup("backup-5", now.Add(-time.Hour)).ObjectMeta(builder.WithLabels("app", "web")).Result(),
+	}
+
+	standby := velerov1api.BackupStorageLocationStandby{
+		BackupSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+	}
+
+	location := func() *builder.BackupStorageLocationBuilder {
+		return builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "primary").
+			Provider("myCloud").
+			Bucket("bucket").
+			AccessMode(velerov1api.BackupStorageLocationAccessModeReadOnly)
+	}
+
+	standbyRestore := func(backup string, phase velerov1api.RestorePhase) *velerov1api.Restore {
+		return builder.ForRestore(velerov1api.DefaultNamespace, standbyRestoreName(backup)).
+			ObjectMeta(builder.WithLabels(velerov1api.StandbyLocationLabel, "primary")).
+			Backup(backup).
+			Phase(phase).
+			Result()
+	}
+
+	tests := []struct {
+		name                   string
+		location               *velerov1api.BackupStorageLocation
+		restores               []*velerov1api.Restore
+		resourceList           map[string][]string
+		expectRestoredBackup   string
+		expectNamespaceMapping map[string]string
+	}{
+		{
+			name:     "location without a standby policy isn't restored",
+			location: location().Result(),
+		},
+		{
+			name:     "read-write location isn't restored",
+			location: location().Standby(standby).AccessMode(velerov1api.BackupStorageLocationAccessModeReadWrite).Result(),
+		},
+		{
+			name:                 "newest completed backup matching the selector is restored",
+			location:             location().Standby(standby).Result(),
+			expectRestoredBackup: "backup-2",
+		},
+		{
+			name: "namespaces are restored with the prefix",
+			location: location().Standby(velerov1api.BackupStorageLocationStandby{
+				BackupSelector:  standby.BackupSelector,
+				NamespacePrefix: "standby-",
+			}).Result(),
+			resourceList: map[string][]string{
+				"v1/ConfigMap":        {"ns-1/cm-1", "ns-2/cm-1"},
+				"v1/PersistentVolume": {"pv-1"},
+			},
+			expectRestoredBackup:   "backup-2",
+			expectNamespaceMapping: map[string]string{"ns-1": "standby-ns-1", "ns-2": "standby-ns-2"},
+		},
+		{
+			name:     "nothing is restored while a standby restore is running",
+			location: location().Standby(standby).Result(),
+			restores: []*velerov1api.Restore{standbyRestore("backup-1", velerov1api.RestorePhaseInProgress)},
+		},
+		{
+			name: "newest backup isn't restored again",
+			location: func() *velerov1api.BackupStorageLocation {
+				l := location().Standby(standby).Result()
+				l.Status.LastStandbyBackup = "backup-2"
+				return l
+			}(),
+			restores: []*velerov1api.Restore{standbyRestore("backup-2", velerov1api.RestorePhaseCompleted)},
+		},
+		{
+			name: "next backup is restored once the previous standby restore finishes",
+			location: func() *velerov1api.BackupStorageLocation {
+				l := location().Standby(standby).Result()
+				l.Status.LastStandbyBackup = "backup-1"
+				return l
+			}(),
+			restores:             []*velerov1api.Restore{standbyRestore("backup-1", velerov1api.RestorePhasePartiallyFailed)},
+			expectRestoredBackup: "backup-2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset(test.location)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+			)
+
+			c := NewStandbyRestoreController(
+				velerotest.NewLogger(),
+				sharedInformers.Velero().V1().Backups(),
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().Restores(),
+				client.VeleroV1(),
+				sharedInformers.Velero().V1().BackupStorageLocations(),
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+			).(*standbyRestoreController)
+			c.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+				return backupStore, nil
+			}
+			c.clock = clock.NewFakeClock(now)
+
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(test.location))
+			for _, backup := range backups {
+				require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup))
+			}
+			for _, restore := range test.restores {
+				require.NoError(t, sharedInformers.Velero().V1().Restores().Informer().GetStore().Add(restore))
+			}
+
+			pluginManager.On("CleanupClients").Return(nil)
+			if test.resourceList != nil {
+				target := velerov1api.DownloadTarget{Kind: velerov1api.DownloadTargetKindBackupResourceList, Name: test.expectRestoredBackup}
+				backupStore.On("GetDownload", target).Return(ioutil.NopCloser(bytes.NewReader(gzippedResourceList(t, test.resourceList))), nil)
+			}
+
+			require.NoError(t, c.processLocation(velerov1api.DefaultNamespace+"/primary"))
+
+			var created []*velerov1api.Restore
+			for _, action := range client.Actions() {
+				if createAction, ok := action.(core.CreateAction); ok {
+					created = append(created, createAction.GetObject().(*velerov1api.Restore))
+				}
+			}
+
+			if test.expectRestoredBackup == "" {
+				assert.Empty(t, client.Actions())
+				return
+			}
+
+			require.Len(t, created, 1)
+			assert.Equal(t, standbyRestoreName(test.expectRestoredBackup), created[0].Name)
+			assert.Equal(t, test.expectRestoredBackup, created[0].Spec.BackupName)
+			assert.Equal(t, "primary", created[0].Labels[velerov1api.StandbyLocationLabel])
+			assert.Equal(t, test.expectNamespaceMapping, created[0].Spec.NamespaceMapping)
+
+			res, err := client.VeleroV1().BackupStorageLocations(velerov1api.DefaultNamespace).Get("primary", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectRestoredBackup, res.Status.LastStandbyBackup)
+			require.NotNil(t, res.Status.LastStandbyRestoreTime)
+			assert.True(t, res.Status.LastStandbyRestoreTime.Time.Equal(now))
+		})
+	}
+}
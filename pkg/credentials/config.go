@@ -0,0 +1,97 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	corev1api "k8s.io/api/core/v1"
+
+	"github.com/heptio/velero/pkg/cloudprovider"
+)
+
+// defaultFileStore is the FileStore that locations' credentials are read
+// from. Backup stores and volume snapshotters are created by many
+// controllers and commands, so it's kept here rather than passed to each
+// of them.
+var defaultFileStore FileStore
+
+// defaultCredential is the credential of locations that don't have their
+// own, if it's set.
+var defaultCredential *corev1api.SecretKeySelector
+
+// generation counts the times that a FileStore has rewritten a credential's
+// file with a new value, so that plugins that were initialized with the
+// file can be reinitialized.
+var generation uint64
+
+// SetDefaultFileStore sets the FileStore that locations' credentials are
+// read from. The Velero server sets it when it starts; until it's set,
+// locations with a credential can't be used.
+func SetDefaultFileStore(store FileStore) {
+	defaultFileStore = store
+}
+
+// SetDefaultCredential sets the credential of locations that don't have
+// their own. If it's not set, plugins use their own default credentials,
+// e.g. from their environment.
+func SetDefaultCredential(credential *corev1api.SecretKeySelector) {
+	defaultCredential = credential
+}
+
+// Generation returns a number that changes whenever a credential's file is
+// rewritten with a new value.
+func Generation() uint64 {
+	return atomic.LoadUint64(&generation)
+}
+
+func credentialsChanged() {
+	atomic.AddUint64(&generation, 1)
+}
+
+// WithCredentialsFile returns a copy of a backup storage or volume snapshot
+// location's config, with cloudprovider.CredentialsFileConfigKey set to the
+// path of a file holding the location's credential, or the default
+// credential if it doesn't have one. If there's neither, or the config
+// already sets the key (e.g. to a restore's credential), the config is
+// returned unchanged.
+func WithCredentialsFile(config map[string]string, credential *corev1api.SecretKeySelector) (map[string]string, error) {
+	if credential == nil {
+		credential = defaultCredential
+	}
+	if credential == nil || config[cloudprovider.CredentialsFileConfigKey] != "" {
+		return config, nil
+	}
+
+	if defaultFileStore == nil {
+		return nil, errors.New("location has a credential, but credentials can only be used by the Velero server")
+	}
+
+	path, err := defaultFileStore.Path(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string, len(config)+1)
+	for k, v := range config {
+		res[k] = v
+	}
+	res[cloudprovider.CredentialsFileConfigKey] = path
+
+	return res, nil
+}
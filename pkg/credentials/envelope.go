@@ -0,0 +1,167 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/heptio/velero/pkg/cloudprovider/aws"
+	"github.com/heptio/velero/pkg/cloudprovider/gcp"
+)
+
+// dataKeySize is the size of the AES-256 keys that credentials are
+// encrypted with.
+const dataKeySize = 32
+
+// KMS encrypts and decrypts data keys with a key management service's
+// keys.
+type KMS interface {
+	// Encrypt encrypts plaintext with the given key.
+	Encrypt(keyID string, plaintext []byte) ([]byte, error)
+
+	// Decrypt decrypts ciphertext that was encrypted with the given key.
+	Decrypt(keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// NewKMS returns the KMS of a cloud provider. Its credentials are the
+// provider's default credentials where it runs.
+func NewKMS(provider string) (KMS, error) {
+	switch provider {
+	case "aws":
+		return aws.NewKMS(), nil
+	case "gcp":
+		return gcp.NewKMS(), nil
+	default:
+		return nil, errors.Errorf("KMS provider %q is not supported", provider)
+	}
+}
+
+// Envelope is a credential encrypted with a random data key, which is
+// itself encrypted with a KMS key, so that the credential can only be read
+// by whoever's allowed to use the KMS key.
+type Envelope struct {
+	// Provider is the cloud provider whose KMS encrypted the data key.
+	Provider string `json:"provider"`
+
+	// KeyID identifies the KMS key that encrypted the data key.
+	KeyID string `json:"keyID"`
+
+	// EncryptedKey is the encrypted data key.
+	EncryptedKey []byte `json:"encryptedKey"`
+
+	// Nonce is the AES-GCM nonce that the credential was encrypted with.
+	Nonce []byte `json:"nonce"`
+
+	// Ciphertext is the credential, encrypted with AES-256-GCM with the
+	// data key.
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// SealEnvelope encrypts plaintext with a new data key, which it encrypts
+// with the given KMS key, and returns the JSON envelope.
+func SealEnvelope(kms KMS, provider, keyID string, plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	encryptedKey, err := kms.Encrypt(keyID, dataKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error encrypting data key with KMS key %s", keyID)
+	}
+
+	envelope, err := json.Marshal(Envelope{
+		Provider:     provider,
+		KeyID:        keyID,
+		EncryptedKey: encryptedKey,
+		Nonce:        nonce,
+		Ciphertext:   aead.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return envelope, nil
+}
+
+// OpenEnvelope decrypts a JSON envelope's data key with the KMS returned
+// by newKMS for its provider, and returns the credential that it holds.
+func OpenEnvelope(data []byte, newKMS func(provider string) (KMS, error)) ([]byte, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, errors.Wrap(err, "error decoding KMS envelope")
+	}
+
+	kms, err := newKMS(envelope.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := kms.Decrypt(envelope.KeyID, envelope.EncryptedKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decrypting data key with KMS key %s", envelope.KeyID)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, errors.New("KMS envelope has an invalid nonce")
+	}
+
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decrypting KMS envelope")
+	}
+
+	return plaintext, nil
+}
+
+func newAEAD(dataKey []byte) (cipher.AEAD, error) {
+	if len(dataKey) != dataKeySize {
+		return nil, errors.Errorf("data key is %d bytes, not %d", len(dataKey), dataKeySize)
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return aead, nil
+}
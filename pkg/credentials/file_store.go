@@ -0,0 +1,184 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"crypto/sha256"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/util/filesystem"
+)
+
+// FileStore provides files holding the values of keys in secrets, for
+// plugins that read their credentials from a file.
+type FileStore interface {
+	// Path returns the path of a file holding the current value of the
+	// given key in a secret.
+	Path(selector *corev1api.SecretKeySelector) (string, error)
+
+	// Refresh rewrites the files holding keys of the given secret whose
+	// values have changed, and returns whether any of them had.
+	Refresh(secret *corev1api.Secret) (bool, error)
+}
+
+type namespacedFileStore struct {
+	secretsClient corev1client.SecretsGetter
+	namespace     string
+	dir           string
+	fileSystem    filesystem.Interface
+	newKMS        func(provider string) (KMS, error)
+
+	// lock guards written, and serializes writing files.
+	lock sync.Mutex
+	// written holds the hash of the value that each key's file was last
+	// written with, by secret name and key.
+	written map[string]map[string][sha256.Size]byte
+}
+
+// NewNamespacedFileStore returns a FileStore for the secrets in the given
+// namespace, which writes the values of their keys to files under dir.
+// Values of secrets with the KMS envelope annotation are decrypted before
+// they're written.
+func NewNamespacedFileStore(secretsClient corev1client.SecretsGetter, namespace, dir string, fileSystem filesystem.Interface) FileStore {
+	return &namespacedFileStore{
+		secretsClient: secretsClient,
+		namespace:     namespace,
+		dir:           dir,
+		fileSystem:    fileSystem,
+		newKMS:        NewKMS,
+		written:       make(map[string]map[string][sha256.Size]byte),
+	}
+}
+
+func (s *namespacedFileStore) Path(selector *corev1api.SecretKeySelector) (string, error) {
+	secret, err := s.secretsClient.Secrets(s.namespace).Get(selector.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting credential secret %s", selector.Name)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.writeLH(secret, selector.Key)
+}
+
+func (s *namespacedFileStore) Refresh(secret *corev1api.Secret) (bool, error) {
+	if secret.Namespace != s.namespace {
+		return false, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var changed bool
+	for key, sum := range s.written[secret.Name] {
+		data, ok := secret.Data[key]
+		if !ok || sha256.Sum256(data) == sum {
+			continue
+		}
+
+		if _, err := s.writeLH(secret, key); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// writeLH writes the value of a key in a secret to the key's file, unless
+// the file already holds it, and returns the file's path.
+//
+// Callers of writeLH *must* acquire the lock before calling it.
+func (s *namespacedFileStore) writeLH(secret *corev1api.Secret, key string) (string, error) {
+	data, ok := secret.Data[key]
+	if !ok {
+		return "", errors.Errorf("credential secret %s doesn't have key %s", secret.Name, key)
+	}
+
+	// each key's file has the same path whatever its value, so that
+	// plugins that are reinitialized with the same config read the
+	// key's current value.
+	secretDir := filepath.Join(s.dir, secret.Name)
+	path := filepath.Join(secretDir, key)
+
+	sum := sha256.Sum256(data)
+	previous, found := s.written[secret.Name][key]
+	if found && previous == sum {
+		if _, err := s.fileSystem.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	if secret.Annotations[velerov1api.KMSEnvelopeAnnotation] == "true" {
+		plaintext, err := OpenEnvelope(data, s.newKMS)
+		if err != nil {
+			return "", errors.Wrapf(err, "error decrypting key %s of credential secret %s", key, secret.Name)
+		}
+		data = plaintext
+	}
+
+	if err := s.fileSystem.MkdirAll(secretDir, 0700); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// the value is written to a temp file that's renamed to the key's
+	// file, so that a file that a plugin might be reading from is never
+	// partially written.
+	file, err := s.fileSystem.TempFile(secretDir, "."+key+"-")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		// nothing we can do about an error closing the file here, and we're
+		// already returning an error about the write failing.
+		file.Close()
+		s.fileSystem.RemoveAll(file.Name())
+		return "", errors.WithStack(err)
+	}
+
+	if err := file.Close(); err != nil {
+		s.fileSystem.RemoveAll(file.Name())
+		return "", errors.WithStack(err)
+	}
+
+	if err := s.fileSystem.Rename(file.Name(), path); err != nil {
+		s.fileSystem.RemoveAll(file.Name())
+		return "", errors.WithStack(err)
+	}
+
+	if s.written[secret.Name] == nil {
+		s.written[secret.Name] = make(map[string][sha256.Size]byte)
+	}
+	s.written[secret.Name][key] = sum
+
+	// plugins that were initialized with the key's previous value need to
+	// be reinitialized to use the new one.
+	if found && previous != sum {
+		credentialsChanged()
+	}
+
+	return path, nil
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/cloudprovider"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestNamespacedFileStorePath(t *testing.T) {
+	secret := &corev1api.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "bucket-2-credentials"},
+		Data:       map[string][]byte{"cloud": []byte("creds-1")},
+	}
+	selector := &corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: "bucket-2-credentials"},
+		Key:                  "cloud",
+	}
+
+	client := kubefake.NewSimpleClientset(secret)
+	fileSystem := velerotest.NewFakeFileSystem()
+	store := NewNamespacedFileStore(client.CoreV1(), "velero", "/credentials", fileSystem)
+
+	path, err := store.Path(selector)
+	require.NoError(t, err)
+	data, err := fileSystem.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "creds-1", string(data))
+
+	// the same file is used, and isn't rewritten, until the secret changes.
+	samePath, err := store.Path(selector)
+	require.NoError(t, err)
+	assert.Equal(t, path, samePath)
+
+	secret.Data["cloud"] = []byte("creds-2")
+	_, err = client.CoreV1().Secrets("velero").Update(secret)
+	require.NoError(t, err)
+
+	// the file is rewritten when the secret changes, and the change is
+	// counted so that plugins that use the file are reinitialized.
+	gen := Generation()
+	newPath, err := store.Path(selector)
+	require.NoError(t, err)
+	assert.Equal(t, path, newPath)
+	data, err = fileSystem.ReadFile(newPath)
+	require.NoError(t, err)
+	assert.Equal(t, "creds-2", string(data))
+	assert.Equal(t, gen+1, Generation())
+
+	// no temp files are left behind.
+	files, err := fileSystem.ReadDir("/credentials/bucket-2-credentials")
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	_, err = store.Path(&corev1api.SecretKeySelector{LocalObjectReference: selector.LocalObjectReference, Key: "missing"})
+	assert.EqualError(t, err, "credential secret bucket-2-credentials doesn't have key missing")
+
+	_, err = store.Path(&corev1api.SecretKeySelector{LocalObjectReference: corev1api.LocalObjectReference{Name: "missing"}, Key: "cloud"})
+	assert.Error(t, err)
+}
+
+func TestNamespacedFileStoreRefresh(t *testing.T) {
+	secret := &corev1api.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "cloud-credentials"},
+		Data:       map[string][]byte{"cloud": []byte("creds-1"), "other": []byte("other-1")},
+	}
+
+	client := kubefake.NewSimpleClientset(secret)
+	fileSystem := velerotest.NewFakeFileSystem()
+	store := NewNamespacedFileStore(client.CoreV1(), "velero", "/credentials", fileSystem)
+
+	// secrets that haven't been written to files aren't.
+	changed, err := store.Refresh(secret)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	_, err = fileSystem.Stat("/credentials/cloud-credentials")
+	assert.Error(t, err)
+
+	path, err := store.Path(&corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: "cloud-credentials"},
+		Key:                  "cloud",
+	})
+	require.NoError(t, err)
+
+	changed, err = store.Refresh(secret.DeepCopy())
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	updated := secret.DeepCopy()
+	updated.Data["cloud"] = []byte("creds-2")
+	updated.Data["other"] = []byte("other-2")
+	changed, err = store.Refresh(updated)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	data, err := fileSystem.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "creds-2", string(data))
+	_, err = fileSystem.Stat("/credentials/cloud-credentials/other")
+	assert.Error(t, err)
+
+	// secrets in other namespaces are ignored.
+	otherNamespace := updated.DeepCopy()
+	otherNamespace.Namespace = "default"
+	otherNamespace.Data["cloud"] = []byte("creds-3")
+	changed, err = store.Refresh(otherNamespace)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+// fakeKMS "encrypts" by prefixing data with the key ID.
+type fakeKMS struct{}
+
+func (fakeKMS) Encrypt(keyID string, plaintext []byte) ([]byte, error) {
+	return append([]byte(keyID+":"), plaintext...), nil
+}
+
+func (fakeKMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, []byte(keyID+":")) {
+		return nil, errors.New("wrong key")
+	}
+	return ciphertext[len(keyID)+1:], nil
+}
+
+func TestNamespacedFileStorePathKMSEnvelope(t *testing.T) {
+	envelope, err := SealEnvelope(fakeKMS{}, "fake", "key-1", []byte("creds-1"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(envelope), "creds-1")
+
+	secret := &corev1api.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "velero",
+			Name:        "cloud-credentials",
+			Annotations: map[string]string{velerov1api.KMSEnvelopeAnnotation: "true"},
+		},
+		Data: map[string][]byte{"cloud": envelope, "invalid": []byte("not an envelope")},
+	}
+
+	client := kubefake.NewSimpleClientset(secret)
+	fileSystem := velerotest.NewFakeFileSystem()
+	store := NewNamespacedFileStore(client.CoreV1(), "velero", "/credentials", fileSystem).(*namespacedFileStore)
+	store.newKMS = func(provider string) (KMS, error) {
+		if provider != "fake" {
+			return nil, errors.Errorf("KMS provider %q is not supported", provider)
+		}
+		return fakeKMS{}, nil
+	}
+
+	path, err := store.Path(&corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: "cloud-credentials"},
+		Key:                  "cloud",
+	})
+	require.NoError(t, err)
+	data, err := fileSystem.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "creds-1", string(data))
+
+	_, err = store.Path(&corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: "cloud-credentials"},
+		Key:                  "invalid",
+	})
+	assert.Error(t, err)
+}
+
+func TestOpenEnvelope(t *testing.T) {
+	newKMS := func(string) (KMS, error) { return fakeKMS{}, nil }
+
+	envelope, err := SealEnvelope(fakeKMS{}, "fake", "key-1", []byte("creds-1"))
+	require.NoError(t, err)
+
+	plaintext, err := OpenEnvelope(envelope, newKMS)
+	require.NoError(t, err)
+	assert.Equal(t, "creds-1", string(plaintext))
+
+	// an envelope whose data key was encrypted with a different key, or
+	// whose ciphertext was changed, can't be opened.
+	var decoded Envelope
+	require.NoError(t, json.Unmarshal(envelope, &decoded))
+	decoded.KeyID = "key-2"
+	wrongKey, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	_, err = OpenEnvelope(wrongKey, newKMS)
+	assert.EqualError(t, err, "error decrypting data key with KMS key key-2: wrong key")
+
+	require.NoError(t, json.Unmarshal(envelope, &decoded))
+	decoded.Ciphertext[0] ^= 1
+	tampered, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	_, err = OpenEnvelope(tampered, newKMS)
+	assert.EqualError(t, err, "error decrypting KMS envelope: cipher: message authentication failed")
+
+	_, err = NewKMS("azure")
+	assert.EqualError(t, err, `KMS provider "azure" is not supported`)
+}
+
+type fakeFileStore map[string]string
+
+func (s fakeFileStore) Path(selector *corev1api.SecretKeySelector) (string, error) {
+	return s[selector.Name+"/"+selector.Key], nil
+}
+
+func (s fakeFileStore) Refresh(*corev1api.Secret) (bool, error) {
+	return false, nil
+}
+
+func TestWithCredentialsFile(t *testing.T) {
+	selector := &corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: "bucket-2-credentials"},
+		Key:                  "cloud",
+	}
+	config := map[string]string{"region": "us-east-1"}
+
+	// without a store, only locations without a credential can be used.
+	res, err := WithCredentialsFile(config, nil)
+	require.NoError(t, err)
+	assert.Equal(t, config, res)
+
+	_, err = WithCredentialsFile(config, selector)
+	assert.Error(t, err)
+
+	SetDefaultFileStore(fakeFileStore{"bucket-2-credentials/cloud": "/credentials/cloud"})
+	defer SetDefaultFileStore(nil)
+
+	res, err = WithCredentialsFile(config, selector)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"region": "us-east-1", cloudprovider.CredentialsFileConfigKey: "/credentials/cloud"}, res)
+	assert.Equal(t, map[string]string{"region": "us-east-1"}, config)
+
+	// a credentials file that's already set, e.g. a restore's, is kept.
+	config[cloudprovider.CredentialsFileConfigKey] = "/restore-credentials"
+	res, err = WithCredentialsFile(config, selector)
+	require.NoError(t, err)
+	assert.Equal(t, "/restore-credentials", res[cloudprovider.CredentialsFileConfigKey])
+
+	// locations without a credential use the default one, if it's set.
+	SetDefaultCredential(&corev1api.SecretKeySelector{
+		LocalObjectReference: corev1api.LocalObjectReference{Name: "cloud-credentials"},
+		Key:                  "cloud",
+	})
+	defer SetDefaultCredential(nil)
+	store := fakeFileStore{"bucket-2-credentials/cloud": "/credentials/cloud", "cloud-credentials/cloud": "/credentials/default"}
+	SetDefaultFileStore(store)
+
+	res, err = WithCredentialsFile(map[string]string{"region": "us-east-1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/credentials/default", res[cloudprovider.CredentialsFileConfigKey])
+
+	res, err = WithCredentialsFile(map[string]string{"region": "us-east-1"}, selector)
+	require.NoError(t, err)
+	assert.Equal(t, "/credentials/cloud", res[cloudprovider.CredentialsFileConfigKey])
+}
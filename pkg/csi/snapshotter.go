@@ -0,0 +1,386 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package csi takes and restores snapshots of CSI persistent volumes through
+// the Kubernetes VolumeSnapshot API, so that volumes of any CSI driver with
+// snapshot support can be snapshotted without a Velero VolumeSnapshotter
+// plugin.
+package csi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/volume"
+)
+
+const (
+	// GroupName is the API group of the Kubernetes VolumeSnapshot API.
+	GroupName = "snapshot.storage.k8s.io"
+
+	// ClassLabel is the label that marks the VolumeSnapshotClass to use
+	// for a CSI driver when the driver has more than one.
+	ClassLabel = "velero.io/csi-volumesnapshot-class"
+
+	// DefaultTimeout is how long to wait for a VolumeSnapshot to be ready
+	// to use by default.
+	DefaultTimeout = 10 * time.Minute
+
+	deletionPolicyRetain = "Retain"
+	deletionPolicyDelete = "Delete"
+)
+
+var (
+	// GroupVersion is the version of the VolumeSnapshot API that Velero uses.
+	GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1beta1"}
+
+	// VolumeSnapshots, VolumeSnapshotContents and VolumeSnapshotClasses
+	// are the resources of the VolumeSnapshot API.
+	VolumeSnapshots        = metav1.APIResource{Name: "volumesnapshots", Kind: "VolumeSnapshot", Namespaced: true}
+	VolumeSnapshotContents = metav1.APIResource{Name: "volumesnapshotcontents", Kind: "VolumeSnapshotContent"}
+	VolumeSnapshotClasses  = metav1.APIResource{Name: "volumesnapshotclasses", Kind: "VolumeSnapshotClass"}
+)
+
+// Snapshot is a snapshot of a CSI volume that's been taken through the
+// VolumeSnapshot API.
+type Snapshot struct {
+	// Driver is the name of the CSI driver of the volume.
+	Driver string
+
+	// Class is the name of the VolumeSnapshotClass of the snapshot.
+	Class string
+
+	// ContentName is the name of the VolumeSnapshotContent that holds the
+	// snapshot in the cluster it was taken in.
+	ContentName string
+
+	// Handle is the CSI driver's ID for the snapshot.
+	Handle string
+}
+
+// SnapshotFor returns the CSI snapshot recorded in a Velero volume snapshot,
+// or nil if the volume snapshot wasn't taken through the VolumeSnapshot API.
+func SnapshotFor(snapshot *volume.Snapshot) *Snapshot {
+	if snapshot.Spec.CSIDriver == "" {
+		return nil
+	}
+
+	return &Snapshot{
+		Driver:      snapshot.Spec.CSIDriver,
+		Class:       snapshot.Spec.CSIVolumeSnapshotClass,
+		ContentName: snapshot.Status.CSIVolumeSnapshotContent,
+		Handle:      snapshot.Status.ProviderSnapshotID,
+	}
+}
+
+// Snapshotter takes, restores and deletes snapshots of CSI volumes.
+type Snapshotter struct {
+	dynamicFactory client.DynamicFactory
+	timeout        time.Duration
+	pollInterval   time.Duration
+}
+
+// NewSnapshotter returns a Snapshotter that waits up to timeout for the
+// snapshots it takes to be ready to use. If timeout is zero, DefaultTimeout
+// is used.
+func NewSnapshotter(dynamicFactory client.DynamicFactory, timeout time.Duration) *Snapshotter {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Snapshotter{
+		dynamicFactory: dynamicFactory,
+		timeout:        timeout,
+		pollInterval:   time.Second,
+	}
+}
+
+// SnapshotClass returns the name of the VolumeSnapshotClass to use for
+// snapshots of the driver's volumes: the driver's class labeled with
+// ClassLabel=true if there is one, and otherwise the driver's only class.
+// It returns an empty name if the driver has no class or the cluster doesn't
+// serve the VolumeSnapshot API, since the driver's volumes can't be
+// snapshotted through it.
+func (s *Snapshotter) SnapshotClass(driver string) (string, error) {
+	classClient, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, VolumeSnapshotClasses, "")
+	if err != nil {
+		return "", err
+	}
+
+	list, err := classClient.List(metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "error listing volume snapshot classes")
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var classes []string
+	for _, item := range items {
+		class, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			return "", errors.Errorf("unexpected type %T", item)
+		}
+
+		if d, _, _ := unstructured.NestedString(class.Object, "driver"); d != driver {
+			continue
+		}
+		if class.GetLabels()[ClassLabel] == "true" {
+			return class.GetName(), nil
+		}
+		classes = append(classes, class.GetName())
+	}
+
+	switch len(classes) {
+	case 0:
+		return "", nil
+	case 1:
+		return classes[0], nil
+	default:
+		return "", errors.Errorf("found %d volume snapshot classes for CSI driver %s, label one of them with %s=true", len(classes), driver, ClassLabel)
+	}
+}
+
+// CreateSnapshot snapshots the volume bound to a persistent volume claim
+// with a VolumeSnapshotClass of the volume's CSI driver. It creates a
+// VolumeSnapshot named name for the claim, waits for the
+// snapshot to be ready to use, sets its VolumeSnapshotContent's deletion
+// policy to Retain, and then deletes the VolumeSnapshot, leaving the
+// snapshot itself in place until DeleteSnapshot is called.
+func (s *Snapshotter) CreateSnapshot(namespace, claimName, driver, class, name string, labels map[string]string) (*Snapshot, error) {
+	snapshotClient, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, VolumeSnapshots, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeSnapshot := newObject(VolumeSnapshots, namespace, name, labels)
+	volumeSnapshot.Object["spec"] = map[string]interface{}{
+		"volumeSnapshotClassName": class,
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": claimName,
+		},
+	}
+
+	if _, err := snapshotClient.Create(volumeSnapshot); err != nil {
+		return nil, errors.Wrapf(err, "error creating volume snapshot %s/%s", namespace, name)
+	}
+
+	var contentName string
+	err = wait.PollImmediate(s.pollInterval, s.timeout, func() (bool, error) {
+		obj, err := snapshotClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "error getting volume snapshot %s/%s", namespace, name)
+		}
+
+		if message, found, _ := unstructured.NestedString(obj.Object, "status", "error", "message"); found {
+			return false, errors.Errorf("volume snapshot %s/%s failed: %s", namespace, name, message)
+		}
+
+		ready, _, _ := unstructured.NestedBool(obj.Object, "status", "readyToUse")
+		contentName, _, _ = unstructured.NestedString(obj.Object, "status", "boundVolumeSnapshotContentName")
+
+		return ready && contentName != "", nil
+	})
+	if err == wait.ErrWaitTimeout {
+		err = errors.Errorf("timed out after %v waiting for volume snapshot %s/%s to be ready", s.timeout, namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	contentClient, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, VolumeSnapshotContents, "")
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := contentClient.Get(contentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting volume snapshot content %s", contentName)
+	}
+
+	handle, _, _ := unstructured.NestedString(content.Object, "status", "snapshotHandle")
+	if handle == "" {
+		return nil, errors.Errorf("volume snapshot content %s has no snapshot handle", contentName)
+	}
+
+	// The snapshot has to outlive the VolumeSnapshot, which belongs to the
+	// backed-up namespace and may be deleted along with it.
+	if err := setDeletionPolicy(contentClient, contentName, deletionPolicyRetain); err != nil {
+		return nil, err
+	}
+
+	if err := snapshotClient.Delete(name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "error deleting volume snapshot %s/%s", namespace, name)
+	}
+
+	return &Snapshot{
+		Driver:      driver,
+		Class:       class,
+		ContentName: contentName,
+		Handle:      handle,
+	}, nil
+}
+
+// ImportSnapshot makes a snapshot available in a namespace as a
+// VolumeSnapshot named name, which persistent volume claims can use as
+// their data source. The VolumeSnapshot is bound to a new, statically
+// provisioned VolumeSnapshotContent of the same name for the snapshot's
+// handle, so the snapshot can be imported into any cluster that has the
+// snapshot's CSI driver.
+func (s *Snapshotter) ImportSnapshot(namespace, name string, snapshot *Snapshot, labels map[string]string) error {
+	contentClient, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, VolumeSnapshotContents, "")
+	if err != nil {
+		return err
+	}
+
+	content := newContent(name, namespace, name, snapshot, deletionPolicyRetain, labels)
+	if _, err := contentClient.Create(content); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "error creating volume snapshot content %s", name)
+	}
+
+	snapshotClient, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, VolumeSnapshots, namespace)
+	if err != nil {
+		return err
+	}
+
+	volumeSnapshot := newObject(VolumeSnapshots, namespace, name, labels)
+	volumeSnapshot.Object["spec"] = map[string]interface{}{
+		"volumeSnapshotClassName": snapshot.Class,
+		"source": map[string]interface{}{
+			"volumeSnapshotContentName": name,
+		},
+	}
+
+	if _, err := snapshotClient.Create(volumeSnapshot); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "error creating volume snapshot %s/%s", namespace, name)
+	}
+
+	return nil
+}
+
+// RemoveImportedSnapshot deletes the VolumeSnapshot and
+// VolumeSnapshotContent created by ImportSnapshot. The snapshot itself is
+// retained.
+func (s *Snapshotter) RemoveImportedSnapshot(namespace, name string) error {
+	snapshotClient, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, VolumeSnapshots, namespace)
+	if err != nil {
+		return err
+	}
+
+	if err := snapshotClient.Delete(name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error deleting volume snapshot %s/%s", namespace, name)
+	}
+
+	contentClient, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, VolumeSnapshotContents, "")
+	if err != nil {
+		return err
+	}
+
+	if err := contentClient.Delete(name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error deleting volume snapshot content %s", name)
+	}
+
+	return nil
+}
+
+// DeleteSnapshot deletes a snapshot taken by CreateSnapshot. If the
+// snapshot's VolumeSnapshotContent no longer exists, e.g. because the
+// snapshot was taken in another cluster, a VolumeSnapshotContent for the
+// snapshot's handle is created so the CSI driver deletes the snapshot.
+func (s *Snapshotter) DeleteSnapshot(snapshot *Snapshot) error {
+	contentClient, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, VolumeSnapshotContents, "")
+	if err != nil {
+		return err
+	}
+
+	_, err = contentClient.Get(snapshot.ContentName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		// The content has to refer to a VolumeSnapshot, but deleting
+		// it doesn't need the VolumeSnapshot to exist.
+		content := newContent(snapshot.ContentName, "default", snapshot.ContentName, snapshot, deletionPolicyDelete, nil)
+		if _, err := contentClient.Create(content); err != nil {
+			return errors.Wrapf(err, "error creating volume snapshot content %s", snapshot.ContentName)
+		}
+	case err != nil:
+		return errors.Wrapf(err, "error getting volume snapshot content %s", snapshot.ContentName)
+	default:
+		if err := setDeletionPolicy(contentClient, snapshot.ContentName, deletionPolicyDelete); err != nil {
+			return err
+		}
+	}
+
+	if err := contentClient.Delete(snapshot.ContentName, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error deleting volume snapshot content %s", snapshot.ContentName)
+	}
+
+	return nil
+}
+
+func setDeletionPolicy(contentClient client.Dynamic, name, policy string) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"deletionPolicy":%q}}`, policy))
+	if _, err := contentClient.Patch(name, patch); err != nil {
+		return errors.Wrapf(err, "error setting deletion policy of volume snapshot content %s to %s", name, policy)
+	}
+	return nil
+}
+
+func newObject(resource metav1.APIResource, namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(GroupVersion.String())
+	obj.SetKind(resource.Kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(labels)
+
+	return obj
+}
+
+// newContent returns a statically provisioned VolumeSnapshotContent for a
+// snapshot, bound to the VolumeSnapshot snapshotName in snapshotNamespace.
+func newContent(name, snapshotNamespace, snapshotName string, snapshot *Snapshot, deletionPolicy string, labels map[string]string) *unstructured.Unstructured {
+	content := newObject(VolumeSnapshotContents, "", name, labels)
+	content.Object["spec"] = map[string]interface{}{
+		"deletionPolicy":          deletionPolicy,
+		"driver":                  snapshot.Driver,
+		"volumeSnapshotClassName": snapshot.Class,
+		"source": map[string]interface{}{
+			"snapshotHandle": snapshot.Handle,
+		},
+		"volumeSnapshotRef": map[string]interface{}{
+			"apiVersion": GroupVersion.String(),
+			"kind":       VolumeSnapshots.Kind,
+			"namespace":  snapshotNamespace,
+			"name":       snapshotName,
+		},
+	}
+
+	return content
+}
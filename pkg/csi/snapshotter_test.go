@@ -0,0 +1,254 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/heptio/velero/pkg/client"
+)
+
+func newClass(name, driver string, preferred bool) *unstructured.Unstructured {
+	class := newObject(VolumeSnapshotClasses, "", name, nil)
+	class.Object["driver"] = driver
+	if preferred {
+		class.SetLabels(map[string]string{ClassLabel: "true"})
+	}
+	return class
+}
+
+func newTestSnapshotter(objects ...runtime.Object) (*Snapshotter, *dynamicfake.FakeDynamicClient) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+
+	s := NewSnapshotter(client.NewDynamicFactory(dynamicClient), time.Second)
+	s.pollInterval = time.Millisecond
+
+	return s, dynamicClient
+}
+
+func getObject(t *testing.T, dynamicClient *dynamicfake.FakeDynamicClient, resource metav1.APIResource, namespace, name string) *unstructured.Unstructured {
+	t.Helper()
+
+	obj, err := dynamicClient.Resource(GroupVersion.WithResource(resource.Name)).Namespace(namespace).Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return obj
+}
+
+func TestSnapshotClass(t *testing.T) {
+	tests := []struct {
+		name        string
+		classes     []runtime.Object
+		driver      string
+		want        string
+		expectedErr string
+	}{
+		{
+			name:    "the driver's only class is used",
+			classes: []runtime.Object{newClass("class-1", "driver-1", false), newClass("class-2", "driver-2", false)},
+			driver:  "driver-1",
+			want:    "class-1",
+		},
+		{
+			name:    "the driver's labeled class is used",
+			classes: []runtime.Object{newClass("class-1", "driver-1", false), newClass("class-2", "driver-1", true)},
+			driver:  "driver-1",
+			want:    "class-2",
+		},
+		{
+			name:        "a driver with several unlabeled classes is an error",
+			classes:     []runtime.Object{newClass("class-1", "driver-1", false), newClass("class-2", "driver-1", false)},
+			driver:      "driver-1",
+			expectedErr: "found 2 volume snapshot classes for CSI driver driver-1, label one of them with velero.io/csi-volumesnapshot-class=true",
+		},
+		{
+			name:    "a driver without a class has no class",
+			classes: []runtime.Object{newClass("class-2", "driver-2", false)},
+			driver:  "driver-1",
+			want:    "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := newTestSnapshotter(tc.classes...)
+
+			got, err := s.SnapshotClass(tc.driver)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	content := newObject(VolumeSnapshotContents, "", "snapcontent-1", nil)
+	content.Object["spec"] = map[string]interface{}{"deletionPolicy": deletionPolicyDelete}
+	content.Object["status"] = map[string]interface{}{"snapshotHandle": "handle-1"}
+
+	s, dynamicClient := newTestSnapshotter(content)
+
+	// Stand in for the snapshot controller, which binds new volume
+	// snapshots to their content once they're ready to use.
+	dynamicClient.PrependReactor("create", "volumesnapshots", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		obj := action.(kubetesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		obj.Object["status"] = map[string]interface{}{
+			"readyToUse":                     true,
+			"boundVolumeSnapshotContentName": "snapcontent-1",
+		}
+		return false, nil, nil
+	})
+
+	snapshot, err := s.CreateSnapshot("ns-1", "pvc-1", "driver-1", "class-1", "velero-backup-1-pvc-1", map[string]string{"velero.io/backup-name": "backup-1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, &Snapshot{Driver: "driver-1", Class: "class-1", ContentName: "snapcontent-1", Handle: "handle-1"}, snapshot)
+
+	var created *unstructured.Unstructured
+	for _, action := range dynamicClient.Actions() {
+		if action.Matches("create", "volumesnapshots") {
+			created = action.(kubetesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		}
+	}
+	require.NotNil(t, created)
+	assert.Equal(t, "ns-1", created.GetNamespace())
+	claimName, _, _ := unstructured.NestedString(created.Object, "spec", "source", "persistentVolumeClaimName")
+	assert.Equal(t, "pvc-1", claimName)
+
+	// the content is retained and the volume snapshot is deleted
+	policy, _, _ := unstructured.NestedString(getObject(t, dynamicClient, VolumeSnapshotContents, "", "snapcontent-1").Object, "spec", "deletionPolicy")
+	assert.Equal(t, deletionPolicyRetain, policy)
+
+	_, err = dynamicClient.Resource(GroupVersion.WithResource(VolumeSnapshots.Name)).Namespace("ns-1").Get("velero-backup-1-pvc-1", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestCreateSnapshotFailed(t *testing.T) {
+	s, dynamicClient := newTestSnapshotter()
+
+	dynamicClient.PrependReactor("create", "volumesnapshots", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		obj := action.(kubetesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		obj.Object["status"] = map[string]interface{}{
+			"error": map[string]interface{}{"message": "disk not found"},
+		}
+		return false, nil, nil
+	})
+
+	_, err := s.CreateSnapshot("ns-1", "pvc-1", "driver-1", "class-1", "snap-1", nil)
+	assert.EqualError(t, err, "volume snapshot ns-1/snap-1 failed: disk not found")
+}
+
+func TestCreateSnapshotTimeout(t *testing.T) {
+	s, _ := newTestSnapshotter()
+	s.timeout = 10 * time.Millisecond
+
+	_, err := s.CreateSnapshot("ns-1", "pvc-1", "driver-1", "class-1", "snap-1", nil)
+	assert.EqualError(t, err, "timed out after 10ms waiting for volume snapshot ns-1/snap-1 to be ready")
+}
+
+func TestImportSnapshot(t *testing.T) {
+	s, dynamicClient := newTestSnapshotter()
+
+	snapshot := &Snapshot{Driver: "driver-1", Class: "class-1", ContentName: "snapcontent-1", Handle: "handle-1"}
+	require.NoError(t, s.ImportSnapshot("ns-2", "restore-1-pv-1", snapshot, nil))
+
+	content := getObject(t, dynamicClient, VolumeSnapshotContents, "", "restore-1-pv-1")
+	handle, _, _ := unstructured.NestedString(content.Object, "spec", "source", "snapshotHandle")
+	assert.Equal(t, "handle-1", handle)
+	refNamespace, _, _ := unstructured.NestedString(content.Object, "spec", "volumeSnapshotRef", "namespace")
+	assert.Equal(t, "ns-2", refNamespace)
+	policy, _, _ := unstructured.NestedString(content.Object, "spec", "deletionPolicy")
+	assert.Equal(t, deletionPolicyRetain, policy)
+
+	volumeSnapshot := getObject(t, dynamicClient, VolumeSnapshots, "ns-2", "restore-1-pv-1")
+	contentName, _, _ := unstructured.NestedString(volumeSnapshot.Object, "spec", "source", "volumeSnapshotContentName")
+	assert.Equal(t, "restore-1-pv-1", contentName)
+}
+
+func TestRemoveImportedSnapshot(t *testing.T) {
+	s, dynamicClient := newTestSnapshotter()
+
+	snapshot := &Snapshot{Driver: "driver-1", Class: "class-1", ContentName: "snapcontent-1", Handle: "handle-1"}
+	require.NoError(t, s.ImportSnapshot("ns-1", "velero-backup-1-pvc-1", snapshot, nil))
+	require.NoError(t, s.RemoveImportedSnapshot("ns-1", "velero-backup-1-pvc-1"))
+
+	_, err := dynamicClient.Resource(GroupVersion.WithResource(VolumeSnapshots.Name)).Namespace("ns-1").Get("velero-backup-1-pvc-1", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = dynamicClient.Resource(GroupVersion.WithResource(VolumeSnapshotContents.Name)).Get("velero-backup-1-pvc-1", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// the content was retained, so the snapshot was never patched for deletion
+	for _, action := range dynamicClient.Actions() {
+		assert.False(t, action.Matches("patch", "volumesnapshotcontents"))
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	snapshot := &Snapshot{Driver: "driver-1", Class: "class-1", ContentName: "snapcontent-1", Handle: "handle-1"}
+
+	t.Run("existing content is deleted along with the snapshot", func(t *testing.T) {
+		content := newContent("snapcontent-1", "ns-1", "snap-1", snapshot, deletionPolicyRetain, nil)
+		s, dynamicClient := newTestSnapshotter(content)
+
+		require.NoError(t, s.DeleteSnapshot(snapshot))
+
+		var patched bool
+		for _, action := range dynamicClient.Actions() {
+			if action.Matches("patch", "volumesnapshotcontents") {
+				assert.JSONEq(t, `{"spec":{"deletionPolicy":"Delete"}}`, string(action.(kubetesting.PatchAction).GetPatch()))
+				patched = true
+			}
+		}
+		assert.True(t, patched)
+
+		_, err := dynamicClient.Resource(GroupVersion.WithResource(VolumeSnapshotContents.Name)).Get("snapcontent-1", metav1.GetOptions{})
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("missing content is created to delete the snapshot", func(t *testing.T) {
+		s, dynamicClient := newTestSnapshotter()
+
+		require.NoError(t, s.DeleteSnapshot(snapshot))
+
+		var created *unstructured.Unstructured
+		for _, action := range dynamicClient.Actions() {
+			if action.Matches("create", "volumesnapshotcontents") {
+				created = action.(kubetesting.CreateAction).GetObject().(*unstructured.Unstructured)
+			}
+		}
+		require.NotNil(t, created)
+		policy, _, _ := unstructured.NestedString(created.Object, "spec", "deletionPolicy")
+		assert.Equal(t, deletionPolicyDelete, policy)
+		handle, _, _ := unstructured.NestedString(created.Object, "spec", "source", "snapshotHandle")
+		assert.Equal(t, "handle-1", handle)
+
+		_, err := dynamicClient.Resource(GroupVersion.WithResource(VolumeSnapshotContents.Name)).Get("snapcontent-1", metav1.GetOptions{})
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloadproxy
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+)
+
+// Handler serves downloads of backup and restore artifacts from their
+// backup storage locations, for clients that can't use a pre-signed URL
+// from the location's object store.
+type Handler struct {
+	signer               *Signer
+	backupLocationLister listers.BackupStorageLocationLister
+	newPluginManager     func(logrus.FieldLogger) clientmgmt.Manager
+	newBackupStore       func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error)
+	logger               logrus.FieldLogger
+}
+
+// NewHandler returns a Handler that serves the downloads referred to by
+// URLs created by the given signer.
+func NewHandler(
+	signer *Signer,
+	backupLocationLister listers.BackupStorageLocationLister,
+	newPluginManager func(logrus.FieldLogger) clientmgmt.Manager,
+	logger logrus.FieldLogger,
+) *Handler {
+	return &Handler{
+		signer:               signer,
+		backupLocationLister: backupLocationLister,
+		newPluginManager:     newPluginManager,
+		newBackupStore:       persistence.NewBackupStore,
+		logger:               logger,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != DownloadPath {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	download, err := h.signer.Verify(req.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	log := h.logger.WithFields(logrus.Fields{
+		"backupLocation": download.Namespace + "/" + download.Location,
+		"kind":           download.Target.Kind,
+		"name":           download.Target.Name,
+	})
+
+	location, err := h.backupLocationLister.BackupStorageLocations(download.Namespace).Get(download.Location)
+	if apierrors.IsNotFound(err) {
+		http.Error(w, "backup storage location not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Error getting backup storage location")
+		http.Error(w, "error getting backup storage location", http.StatusInternalServerError)
+		return
+	}
+
+	pluginManager := h.newPluginManager(log)
+	defer pluginManager.CleanupClients()
+
+	backupStore, err := h.newBackupStore(location, pluginManager, log)
+	if err != nil {
+		log.WithError(err).Error("Error getting backup store")
+		http.Error(w, "error getting backup store", http.StatusInternalServerError)
+		return
+	}
+
+	// object stores don't distinguish missing objects from other errors,
+	// so report any error as not found, which is what clients expect when
+	// e.g. an older backup doesn't have a given file.
+	body, err := backupStore.GetDownload(download.Target)
+	if err != nil {
+		log.WithError(err).Info("Unable to get download target from backup store")
+		http.Error(w, "download target not found", http.StatusNotFound)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := io.Copy(w, body); err != nil {
+		log.WithError(err).Warn("Error streaming download")
+	}
+}
+
+// NewServer returns a server for the download proxy. It must be started
+// with ListenAndServeTLS, since proxy URLs are bearer credentials.
+func NewServer(address string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:    address,
+		Handler: handler,
+	}
+}
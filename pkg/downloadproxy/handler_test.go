@@ -0,0 +1,180 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloadproxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	"github.com/heptio/velero/pkg/persistence"
+	persistencemocks "github.com/heptio/velero/pkg/persistence/mocks"
+	"github.com/heptio/velero/pkg/plugin/clientmgmt"
+	pluginmocks "github.com/heptio/velero/pkg/plugin/mocks"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestSignerVerify(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	download := Download{
+		Namespace: velerov1api.DefaultNamespace,
+		Location:  "default",
+		Target:    velerov1api.DownloadTarget{Kind: velerov1api.DownloadTargetKindBackupLog, Name: "backup-1"},
+	}
+
+	signer := NewSigner("https://velero.example.com:8443/", []byte("key"))
+	fakeClock := clock.NewFakeClock(now)
+	signer.clock = fakeClock
+
+	rawURL := signer.URL(download, time.Minute)
+	assert.True(t, strings.HasPrefix(rawURL, "https://velero.example.com:8443/download?"))
+
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+
+	res, err := signer.Verify(parsed.Query())
+	require.NoError(t, err)
+	assert.Equal(t, download, res)
+
+	// a URL signed with a different key is rejected
+	_, err = NewSigner("https://velero.example.com:8443", []byte("other-key")).Verify(parsed.Query())
+	assert.EqualError(t, err, "invalid signature")
+
+	// changing any of the signed fields invalidates the signature
+	tampered := parsed.Query()
+	tampered.Set(nameParam, "backup-2")
+	_, err = signer.Verify(tampered)
+	assert.EqualError(t, err, "invalid signature")
+
+	// the URL stops working once it has expired
+	fakeClock.Step(time.Minute)
+	_, err = signer.Verify(parsed.Query())
+	assert.EqualError(t, err, "download URL has expired")
+}
+
+func TestHandler(t *testing.T) {
+	target := velerov1api.DownloadTarget{Kind: velerov1api.DownloadTargetKindBackupLog, Name: "backup-1"}
+
+	tests := []struct {
+		name         string
+		path         string
+		method       string
+		download     Download
+		getErr       error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "valid URL streams the download target",
+			download:     Download{Namespace: velerov1api.DefaultNamespace, Location: "default", Target: target},
+			expectedCode: http.StatusOK,
+			expectedBody: "log contents",
+		},
+		{
+			name:         "missing download target is not found",
+			download:     Download{Namespace: velerov1api.DefaultNamespace, Location: "default", Target: target},
+			getErr:       errors.New("key not found"),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "missing backup storage location is not found",
+			download:     Download{Namespace: velerov1api.DefaultNamespace, Location: "missing", Target: target},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid signature is forbidden",
+			path:         DownloadPath + "?namespace=velero&location=default&kind=BackupLog&name=backup-1&expires=9999999999&signature=00",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "other methods aren't allowed",
+			method:       http.MethodPost,
+			download:     Download{Namespace: velerov1api.DefaultNamespace, Location: "default", Target: target},
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "other paths are not found",
+			path:         "/other",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				sharedInformers = informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+				pluginManager   = &pluginmocks.Manager{}
+				backupStore     = &persistencemocks.BackupStore{}
+				signer          = NewSigner("https://velero.example.com:8443", []byte("key"))
+			)
+
+			location := builder.ForBackupStorageLocation(velerov1api.DefaultNamespace, "default").Provider("myCloud").Bucket("bucket").Result()
+			require.NoError(t, sharedInformers.Velero().V1().BackupStorageLocations().Informer().GetStore().Add(location))
+
+			h := NewHandler(
+				signer,
+				sharedInformers.Velero().V1().BackupStorageLocations().Lister(),
+				func(logrus.FieldLogger) clientmgmt.Manager { return pluginManager },
+				velerotest.NewLogger(),
+			)
+			h.newBackupStore = func(*velerov1api.BackupStorageLocation, persistence.ObjectStoreGetter, logrus.FieldLogger) (persistence.BackupStore, error) {
+				return backupStore, nil
+			}
+
+			pluginManager.On("CleanupClients").Return(nil)
+			if tc.getErr != nil {
+				backupStore.On("GetDownload", target).Return(nil, tc.getErr)
+			} else {
+				backupStore.On("GetDownload", target).Return(ioutil.NopCloser(strings.NewReader("log contents")), nil)
+			}
+
+			path := tc.path
+			if path == "" {
+				proxyURL, err := url.Parse(signer.URL(tc.download, time.Minute))
+				require.NoError(t, err)
+				path = proxyURL.RequestURI()
+			}
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			res := httptest.NewRecorder()
+			h.ServeHTTP(res, httptest.NewRequest(method, path, nil))
+
+			assert.Equal(t, tc.expectedCode, res.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, res.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloadproxy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+// DownloadPath is the path the download proxy serves downloads on.
+const DownloadPath = "/download"
+
+const (
+	namespaceParam = "namespace"
+	locationParam  = "location"
+	kindParam      = "kind"
+	nameParam      = "name"
+	expiresParam   = "expires"
+	signatureParam = "signature"
+)
+
+// Signer creates download proxy URLs, and verifies the URLs it created.
+// Like an object store's pre-signed URLs, a proxy URL grants access to a
+// single download target until it expires, without any further
+// authentication.
+type Signer struct {
+	baseURL string
+	key     []byte
+	clock   clock.Clock
+}
+
+// NewSigner returns a Signer that creates URLs under the given base URL,
+// which must be the download proxy's address as seen by Velero clients,
+// and signs them with the given key.
+func NewSigner(baseURL string, key []byte) *Signer {
+	return &Signer{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		key:     key,
+		clock:   clock.RealClock{},
+	}
+}
+
+// NewRandomKey returns a random key for signing download proxy URLs.
+func NewRandomKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "error generating download proxy signing key")
+	}
+	return key, nil
+}
+
+// Download identifies an object to stream through the download proxy.
+type Download struct {
+	Namespace string
+	Location  string
+	Target    velerov1api.DownloadTarget
+}
+
+// URL returns a proxy URL for the download that expires after ttl.
+func (s *Signer) URL(download Download, ttl time.Duration) string {
+	expires := strconv.FormatInt(s.clock.Now().Add(ttl).Unix(), 10)
+
+	query := url.Values{}
+	query.Set(namespaceParam, download.Namespace)
+	query.Set(locationParam, download.Location)
+	query.Set(kindParam, string(download.Target.Kind))
+	query.Set(nameParam, download.Target.Name)
+	query.Set(expiresParam, expires)
+	query.Set(signatureParam, s.sign(download, expires))
+
+	return s.baseURL + DownloadPath + "?" + query.Encode()
+}
+
+// Verify checks that the query of a proxy URL was signed by this Signer
+// and hasn't expired, and returns the download it refers to.
+func (s *Signer) Verify(query url.Values) (Download, error) {
+	download := Download{
+		Namespace: query.Get(namespaceParam),
+		Location:  query.Get(locationParam),
+		Target: velerov1api.DownloadTarget{
+			Kind: velerov1api.DownloadTargetKind(query.Get(kindParam)),
+			Name: query.Get(nameParam),
+		},
+	}
+	expires := query.Get(expiresParam)
+
+	signature, err := hex.DecodeString(query.Get(signatureParam))
+	if err != nil || !hmac.Equal(signature, s.mac(download, expires)) {
+		return Download{}, errors.New("invalid signature")
+	}
+
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return Download{}, errors.Wrap(err, "invalid expiration time")
+	}
+	if !s.clock.Now().Before(time.Unix(expiresUnix, 0)) {
+		return Download{}, errors.New("download URL has expired")
+	}
+
+	return download, nil
+}
+
+func (s *Signer) sign(download Download, expires string) string {
+	return hex.EncodeToString(s.mac(download, expires))
+}
+
+func (s *Signer) mac(download Download, expires string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	// the fields are newline-separated since none of them can contain a
+	// newline, so different downloads can't produce the same message.
+	mac.Write([]byte(strings.Join([]string{
+		download.Namespace,
+		download.Location,
+		string(download.Target.Kind),
+		download.Target.Name,
+		expires,
+	}, "\n")))
+	return mac.Sum(nil)
+}
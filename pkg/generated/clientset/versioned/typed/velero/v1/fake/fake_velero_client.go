@@ -36,6 +36,10 @@ func (c *FakeVeleroV1) BackupStorageLocations(namespace string) v1.BackupStorage
 	return &FakeBackupStorageLocations{c, namespace}
 }
 
+func (c *FakeVeleroV1) ComplianceReports(namespace string) v1.ComplianceReportInterface {
+	return &FakeComplianceReports{c, namespace}
+}
+
 func (c *FakeVeleroV1) DeleteBackupRequests(namespace string) v1.DeleteBackupRequestInterface {
 	return &FakeDeleteBackupRequests{c, namespace}
 }
@@ -44,6 +48,10 @@ func (c *FakeVeleroV1) DownloadRequests(namespace string) v1.DownloadRequestInte
 	return &FakeDownloadRequests{c, namespace}
 }
 
+func (c *FakeVeleroV1) OperationHistories(namespace string) v1.OperationHistoryInterface {
+	return &FakeOperationHistories{c, namespace}
+}
+
 func (c *FakeVeleroV1) PodVolumeBackups(namespace string) v1.PodVolumeBackupInterface {
 	return &FakePodVolumeBackups{c, namespace}
 }
@@ -22,10 +22,14 @@ type BackupExpansion interface{}
 
 type BackupStorageLocationExpansion interface{}
 
+type ComplianceReportExpansion interface{}
+
 type DeleteBackupRequestExpansion interface{}
 
 type DownloadRequestExpansion interface{}
 
+type OperationHistoryExpansion interface{}
+
 type PodVolumeBackupExpansion interface{}
 
 type PodVolumeRestoreExpansion interface{}
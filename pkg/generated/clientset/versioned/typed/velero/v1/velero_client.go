@@ -29,8 +29,10 @@ type VeleroV1Interface interface {
 	RESTClient() rest.Interface
 	BackupsGetter
 	BackupStorageLocationsGetter
+	ComplianceReportsGetter
 	DeleteBackupRequestsGetter
 	DownloadRequestsGetter
+	OperationHistoriesGetter
 	PodVolumeBackupsGetter
 	PodVolumeRestoresGetter
 	ResticRepositoriesGetter
@@ -53,6 +55,10 @@ func (c *VeleroV1Client) BackupStorageLocations(namespace string) BackupStorageL
 	return newBackupStorageLocations(c, namespace)
 }
 
+func (c *VeleroV1Client) ComplianceReports(namespace string) ComplianceReportInterface {
+	return newComplianceReports(c, namespace)
+}
+
 func (c *VeleroV1Client) DeleteBackupRequests(namespace string) DeleteBackupRequestInterface {
 	return newDeleteBackupRequests(c, namespace)
 }
@@ -61,6 +67,10 @@ func (c *VeleroV1Client) DownloadRequests(namespace string) DownloadRequestInter
 	return newDownloadRequests(c, namespace)
 }
 
+func (c *VeleroV1Client) OperationHistories(namespace string) OperationHistoryInterface {
+	return newOperationHistories(c, namespace)
+}
+
 func (c *VeleroV1Client) PodVolumeBackups(namespace string) PodVolumeBackupInterface {
 	return newPodVolumeBackups(c, namespace)
 }
@@ -28,10 +28,14 @@ type Interface interface {
 	Backups() BackupInformer
 	// BackupStorageLocations returns a BackupStorageLocationInformer.
 	BackupStorageLocations() BackupStorageLocationInformer
+	// ComplianceReports returns a ComplianceReportInformer.
+	ComplianceReports() ComplianceReportInformer
 	// DeleteBackupRequests returns a DeleteBackupRequestInformer.
 	DeleteBackupRequests() DeleteBackupRequestInformer
 	// DownloadRequests returns a DownloadRequestInformer.
 	DownloadRequests() DownloadRequestInformer
+	// OperationHistories returns a OperationHistoryInformer.
+	OperationHistories() OperationHistoryInformer
 	// PodVolumeBackups returns a PodVolumeBackupInformer.
 	PodVolumeBackups() PodVolumeBackupInformer
 	// PodVolumeRestores returns a PodVolumeRestoreInformer.
@@ -69,6 +73,11 @@ func (v *version) BackupStorageLocations() BackupStorageLocationInformer {
 	return &backupStorageLocationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ComplianceReports returns a ComplianceReportInformer.
+func (v *version) ComplianceReports() ComplianceReportInformer {
+	return &complianceReportInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // DeleteBackupRequests returns a DeleteBackupRequestInformer.
 func (v *version) DeleteBackupRequests() DeleteBackupRequestInformer {
 	return &deleteBackupRequestInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -79,6 +88,11 @@ func (v *version) DownloadRequests() DownloadRequestInformer {
 	return &downloadRequestInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// OperationHistories returns a OperationHistoryInformer.
+func (v *version) OperationHistories() OperationHistoryInformer {
+	return &operationHistoryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // PodVolumeBackups returns a PodVolumeBackupInformer.
 func (v *version) PodVolumeBackups() PodVolumeBackupInformer {
 	return &podVolumeBackupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
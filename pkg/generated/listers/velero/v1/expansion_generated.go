@@ -34,6 +34,14 @@ type BackupStorageLocationListerExpansion interface{}
 // BackupStorageLocationNamespaceLister.
 type BackupStorageLocationNamespaceListerExpansion interface{}
 
+// ComplianceReportListerExpansion allows custom methods to be added to
+// ComplianceReportLister.
+type ComplianceReportListerExpansion interface{}
+
+// ComplianceReportNamespaceListerExpansion allows custom methods to be added to
+// ComplianceReportNamespaceLister.
+type ComplianceReportNamespaceListerExpansion interface{}
+
 // DeleteBackupRequestListerExpansion allows custom methods to be added to
 // DeleteBackupRequestLister.
 type DeleteBackupRequestListerExpansion interface{}
@@ -50,6 +58,14 @@ type DownloadRequestListerExpansion interface{}
 // DownloadRequestNamespaceLister.
 type DownloadRequestNamespaceListerExpansion interface{}
 
+// OperationHistoryListerExpansion allows custom methods to be added to
+// OperationHistoryLister.
+type OperationHistoryListerExpansion interface{}
+
+// OperationHistoryNamespaceListerExpansion allows custom methods to be added to
+// OperationHistoryNamespaceLister.
+type OperationHistoryNamespaceListerExpansion interface{}
+
 // PodVolumeBackupListerExpansion allows custom methods to be added to
 // PodVolumeBackupLister.
 type PodVolumeBackupListerExpansion interface{}
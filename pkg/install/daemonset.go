@@ -24,7 +24,52 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// nodeOS holds the settings of the restic daemonset that depend on the
+// operating system of the nodes it runs on.
+type nodeOS struct {
+	name             string
+	command          string
+	hostPodsPath     string
+	hostPodsMount    string
+	scratchMount     string
+	credentialsMount string
+	credentialsFile  string
+}
+
+var (
+	linuxNodes = nodeOS{
+		name:             "linux",
+		command:          "/velero",
+		hostPodsPath:     "/var/lib/kubelet/pods",
+		hostPodsMount:    "/host_pods",
+		scratchMount:     "/scratch",
+		credentialsMount: "/credentials",
+		credentialsFile:  "/credentials/cloud",
+	}
+
+	windowsNodes = nodeOS{
+		name:             "windows",
+		command:          `C:\velero.exe`,
+		hostPodsPath:     `C:\var\lib\kubelet\pods`,
+		hostPodsMount:    `C:\host_pods`,
+		scratchMount:     `C:\scratch`,
+		credentialsMount: `C:\credentials`,
+		credentialsFile:  `C:\credentials\cloud`,
+	}
+)
+
+// DaemonSet returns the restic daemonset, which runs on Linux nodes.
 func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
+	return resticDaemonSet(namespace, "restic", linuxNodes, opts...)
+}
+
+// WindowsDaemonSet returns the restic daemonset that runs on Windows nodes,
+// whose image must be a Windows image of Velero.
+func WindowsDaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
+	return resticDaemonSet(namespace, "restic-windows", windowsNodes, opts...)
+}
+
+func resticDaemonSet(namespace, name string, os nodeOS, opts ...podTemplateOption) *appsv1.DaemonSet {
 	c := &podTemplateConfig{
 		image: "gcr.io/heptio-images/velero:latest",
 	}
@@ -44,7 +89,7 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 	mountPropagationMode := corev1.MountPropagationHostToContainer
 
 	daemonSet := &appsv1.DaemonSet{
-		ObjectMeta: objectMeta(namespace, "restic"),
+		ObjectMeta: objectMeta(namespace, name),
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "DaemonSet",
 			APIVersion: appsv1.SchemeGroupVersion.String(),
@@ -52,19 +97,22 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 		Spec: appsv1.DaemonSetSpec{
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
-					"name": "restic",
+					"name": name,
 				},
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"name":      "restic",
+						"name":      name,
 						"component": "velero",
 					},
 					Annotations: c.annotations,
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: "velero",
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": os.name,
+					},
 					SecurityContext: &corev1.PodSecurityContext{
 						RunAsUser: &userID,
 					},
@@ -73,7 +121,7 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 							Name: "host-pods",
 							VolumeSource: corev1.VolumeSource{
 								HostPath: &corev1.HostPathVolumeSource{
-									Path: "/var/lib/kubelet/pods",
+									Path: os.hostPodsPath,
 								},
 							},
 						},
@@ -90,7 +138,7 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 							Image:           c.image,
 							ImagePullPolicy: pullPolicy,
 							Command: []string{
-								"/velero",
+								os.command,
 							},
 							Args: []string{
 								"restic",
@@ -100,12 +148,12 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:             "host-pods",
-									MountPath:        "/host_pods",
+									MountPath:        os.hostPodsMount,
 									MountPropagation: &mountPropagationMode,
 								},
 								{
 									Name:      "scratch",
-									MountPath: "/scratch",
+									MountPath: os.scratchMount,
 								},
 							},
 							Env: []corev1.EnvVar{
@@ -127,7 +175,16 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 								},
 								{
 									Name:  "VELERO_SCRATCH_DIR",
-									Value: "/scratch",
+									Value: os.scratchMount,
+								},
+								{
+									Name: "VELERO_MEMORY_LIMIT",
+									ValueFrom: &corev1.EnvVarSource{
+										ResourceFieldRef: &corev1.ResourceFieldSelector{
+											ContainerName: "restic",
+											Resource:      "limits.memory",
+										},
+									},
 								},
 							},
 							Resources: c.resources,
@@ -155,27 +212,37 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 			daemonSet.Spec.Template.Spec.Containers[0].VolumeMounts,
 			corev1.VolumeMount{
 				Name:      "cloud-credentials",
-				MountPath: "/credentials",
+				MountPath: os.credentialsMount,
 			},
 		)
 
 		daemonSet.Spec.Template.Spec.Containers[0].Env = append(daemonSet.Spec.Template.Spec.Containers[0].Env, []corev1.EnvVar{
 			{
 				Name:  "GOOGLE_APPLICATION_CREDENTIALS",
-				Value: "/credentials/cloud",
+				Value: os.credentialsFile,
 			},
 			{
 				Name:  "AWS_SHARED_CREDENTIALS_FILE",
-				Value: "/credentials/cloud",
+				Value: os.credentialsFile,
 			},
 			{
 				Name:  "AZURE_CREDENTIALS_FILE",
-				Value: "/credentials/cloud",
+				Value: os.credentialsFile,
 			},
 		}...)
 	}
 
 	daemonSet.Spec.Template.Spec.Containers[0].Env = append(daemonSet.Spec.Template.Spec.Containers[0].Env, c.envVars...)
 
+	if c.logFormat != "" {
+		daemonSet.Spec.Template.Spec.Containers[0].Args = append(daemonSet.Spec.Template.Spec.Containers[0].Args, "--log-format="+c.logFormat)
+	}
+
+	// Windows containers can't run as a user ID or propagate mounts.
+	if os.name == windowsNodes.name {
+		daemonSet.Spec.Template.Spec.SecurityContext = nil
+		daemonSet.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPropagation = nil
+	}
+
 	return daemonSet
 }
@@ -33,7 +33,27 @@ func TestDaemonSet(t *testing.T) {
 	assert.Equal(t, "gcr.io/heptio-images/velero:v0.11", ds.Spec.Template.Spec.Containers[0].Image)
 	assert.Equal(t, corev1.PullIfNotPresent, ds.Spec.Template.Spec.Containers[0].ImagePullPolicy)
 
+	ds = DaemonSet("velero", WithLogFormat("json"))
+	assert.Equal(t, []string{"restic", "server", "--log-format=json"}, ds.Spec.Template.Spec.Containers[0].Args)
+
 	ds = DaemonSet("velero", WithSecret(true))
-	assert.Equal(t, 6, len(ds.Spec.Template.Spec.Containers[0].Env))
+	assert.Equal(t, 7, len(ds.Spec.Template.Spec.Containers[0].Env))
 	assert.Equal(t, 3, len(ds.Spec.Template.Spec.Volumes))
+	assert.Equal(t, "linux", ds.Spec.Template.Spec.NodeSelector["kubernetes.io/os"])
+}
+
+func TestWindowsDaemonSet(t *testing.T) {
+	ds := WindowsDaemonSet("velero", WithImage("gcr.io/heptio-images/velero-windows:v1.1"), WithSecret(true))
+
+	assert.Equal(t, "restic-windows", ds.Name)
+	assert.Equal(t, "restic-windows", ds.Spec.Selector.MatchLabels["name"])
+	assert.Equal(t, "windows", ds.Spec.Template.Spec.NodeSelector["kubernetes.io/os"])
+	assert.Nil(t, ds.Spec.Template.Spec.SecurityContext)
+
+	container := ds.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "gcr.io/heptio-images/velero-windows:v1.1", container.Image)
+	assert.Equal(t, []string{`C:\velero.exe`}, container.Command)
+	assert.Equal(t, `C:\host_pods`, container.VolumeMounts[0].MountPath)
+	assert.Nil(t, container.VolumeMounts[0].MountPropagation)
+	assert.Equal(t, `C:\var\lib\kubelet\pods`, ds.Spec.Template.Spec.Volumes[0].HostPath.Path)
 }
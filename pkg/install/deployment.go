@@ -33,6 +33,7 @@ type podTemplateConfig struct {
 	annotations map[string]string
 	resources   corev1.ResourceRequirements
 	withSecret  bool
+	logFormat   string
 }
 
 func WithImage(image string) podTemplateOption {
@@ -81,6 +82,13 @@ func WithResources(resources corev1.ResourceRequirements) podTemplateOption {
 	}
 }
 
+// WithLogFormat sets the format of the server's log output.
+func WithLogFormat(logFormat string) podTemplateOption {
+	return func(c *podTemplateConfig) {
+		c.logFormat = logFormat
+	}
+}
+
 func Deployment(namespace string, opts ...podTemplateOption) *appsv1.Deployment {
 	// TODO: Add support for server args
 	c := &podTemplateConfig{
@@ -117,6 +125,9 @@ func Deployment(namespace string, opts ...podTemplateOption) *appsv1.Deployment
 				Spec: corev1.PodSpec{
 					RestartPolicy:      corev1.RestartPolicyAlways,
 					ServiceAccountName: "velero",
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
 					Containers: []corev1.Container{
 						{
 							Name:            "velero",
@@ -210,6 +221,10 @@ func Deployment(namespace string, opts ...podTemplateOption) *appsv1.Deployment
 				Value: "/credentials/cloud",
 			},
 		}...)
+
+		// the server also reads the secret itself, so that locations use its
+		// current value as soon as it changes.
+		deployment.Spec.Template.Spec.Containers[0].Args = append(deployment.Spec.Template.Spec.Containers[0].Args, "--default-credential=cloud-credentials/cloud")
 	}
 
 	deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env, c.envVars...)
@@ -218,5 +233,9 @@ func Deployment(namespace string, opts ...podTemplateOption) *appsv1.Deployment
 		deployment.Spec.Template.Spec.Containers[0].Args = append(deployment.Spec.Template.Spec.Containers[0].Args, "--restore-only")
 	}
 
+	if c.logFormat != "" {
+		deployment.Spec.Template.Spec.Containers[0].Args = append(deployment.Spec.Template.Spec.Containers[0].Args, "--log-format="+c.logFormat)
+	}
+
 	return deployment
 }
@@ -31,6 +31,9 @@ func TestDeployment(t *testing.T) {
 	deploy = Deployment("velero", WithRestoreOnly())
 	assert.Equal(t, "--restore-only", deploy.Spec.Template.Spec.Containers[0].Args[1])
 
+	deploy = Deployment("velero", WithLogFormat("json"))
+	assert.Equal(t, []string{"server", "--log-format=json"}, deploy.Spec.Template.Spec.Containers[0].Args)
+
 	deploy = Deployment("velero", WithEnvFromSecretKey("my-var", "my-secret", "my-key"))
 	envSecret := deploy.Spec.Template.Spec.Containers[0].Env[2]
 	assert.Equal(t, "my-var", envSecret.Name)
@@ -44,4 +47,5 @@ func TestDeployment(t *testing.T) {
 	deploy = Deployment("velero", WithSecret(true))
 	assert.Equal(t, 5, len(deploy.Spec.Template.Spec.Containers[0].Env))
 	assert.Equal(t, 3, len(deploy.Spec.Template.Spec.Volumes))
+	assert.Equal(t, []string{"server", "--default-credential=cloud-credentials/cloud"}, deploy.Spec.Template.Spec.Containers[0].Args)
 }
@@ -88,9 +88,11 @@ func objectMeta(namespace, name string) metav1.ObjectMeta {
 	}
 }
 
-func ServiceAccount(namespace string) *corev1.ServiceAccount {
+func ServiceAccount(namespace string, annotations map[string]string) *corev1.ServiceAccount {
+	objMeta := objectMeta(namespace, "velero")
+	objMeta.Annotations = annotations
 	return &corev1.ServiceAccount{
-		ObjectMeta: objectMeta(namespace, "velero"),
+		ObjectMeta: objMeta,
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ServiceAccount",
 			APIVersion: corev1.SchemeGroupVersion.String(),
@@ -193,20 +195,23 @@ func appendUnstructured(list *unstructured.UnstructuredList, obj runtime.Object)
 }
 
 type VeleroOptions struct {
-	Namespace          string
-	Image              string
-	ProviderName       string
-	Bucket             string
-	Prefix             string
-	PodAnnotations     map[string]string
-	VeleroPodResources corev1.ResourceRequirements
-	ResticPodResources corev1.ResourceRequirements
-	SecretData         []byte
-	RestoreOnly        bool
-	UseRestic          bool
-	UseVolumeSnapshots bool
-	BSLConfig          map[string]string
-	VSLConfig          map[string]string
+	Namespace                 string
+	Image                     string
+	ProviderName              string
+	Bucket                    string
+	Prefix                    string
+	PodAnnotations            map[string]string
+	ServiceAccountAnnotations map[string]string
+	VeleroPodResources        corev1.ResourceRequirements
+	ResticPodResources        corev1.ResourceRequirements
+	SecretData                []byte
+	RestoreOnly               bool
+	UseRestic                 bool
+	ResticWindowsImage        string
+	UseVolumeSnapshots        bool
+	BSLConfig                 map[string]string
+	VSLConfig                 map[string]string
+	LogFormat                 string
 }
 
 // AllResources returns a list of all resources necessary to install Velero, in the appropriate order, into a Kubernetes cluster.
@@ -226,7 +231,7 @@ func AllResources(o *VeleroOptions) (*unstructured.UnstructuredList, error) {
 	crb := ClusterRoleBinding(o.Namespace)
 	appendUnstructured(resources, crb)
 
-	sa := ServiceAccount(o.Namespace)
+	sa := ServiceAccount(o.Namespace, o.ServiceAccountAnnotations)
 	appendUnstructured(resources, sa)
 
 	if o.SecretData != nil {
@@ -250,6 +255,7 @@ func AllResources(o *VeleroOptions) (*unstructured.UnstructuredList, error) {
 		WithImage(o.Image),
 		WithResources(o.VeleroPodResources),
 		WithSecret(secretPresent),
+		WithLogFormat(o.LogFormat),
 	)
 	if o.RestoreOnly {
 		deploy = Deployment(o.Namespace,
@@ -257,6 +263,7 @@ func AllResources(o *VeleroOptions) (*unstructured.UnstructuredList, error) {
 			WithImage(o.Image),
 			WithSecret(secretPresent),
 			WithRestoreOnly(),
+			WithLogFormat(o.LogFormat),
 		)
 	}
 	appendUnstructured(resources, deploy)
@@ -268,8 +275,20 @@ func AllResources(o *VeleroOptions) (*unstructured.UnstructuredList, error) {
 			WithImage(o.Image),
 			WithResources(o.ResticPodResources),
 			WithSecret(secretPresent),
+			WithLogFormat(o.LogFormat),
 		)
 		appendUnstructured(resources, ds)
+
+		if o.ResticWindowsImage != "" {
+			windowsDS := WindowsDaemonSet(o.Namespace,
+				WithAnnotations(o.PodAnnotations),
+				WithImage(o.ResticWindowsImage),
+				WithResources(o.ResticPodResources),
+				WithSecret(secretPresent),
+				WithLogFormat(o.LogFormat),
+			)
+			appendUnstructured(resources, windowsDS)
+		}
 	}
 
 	return resources, nil
@@ -45,6 +45,9 @@ func TestResources(t *testing.T) {
 	assert.Equal(t, "", crb.ObjectMeta.Namespace)
 	assert.Equal(t, "velero", crb.Subjects[0].Namespace)
 
-	sa := ServiceAccount("velero")
+	sa := ServiceAccount("velero", nil)
 	assert.Equal(t, "velero", sa.ObjectMeta.Namespace)
+
+	sa = ServiceAccount("velero", map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/velero"})
+	assert.Equal(t, "arn:aws:iam::123456789012:role/velero", sa.ObjectMeta.Annotations["eks.amazonaws.com/role-arn"])
 }
@@ -0,0 +1,119 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/heptio/velero/pkg/client"
+)
+
+// UninstallResources returns a list of the resources that Uninstall deletes, in the order they're deleted.
+// The Velero deployment and restic daemonsets come first, so that the server is stopped before any of its
+// custom resources are deleted. If keepCRs is true, the namespace and CRDs are left in place, along with
+// the backups, restores, schedules and locations in the namespace.
+func UninstallResources(namespace string, keepCRs bool) *unstructured.UnstructuredList {
+	resources := new(unstructured.UnstructuredList)
+	resources.SetGroupVersionKind(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "List"})
+
+	appendUnstructured(resources, Deployment(namespace))
+	appendUnstructured(resources, DaemonSet(namespace))
+	appendUnstructured(resources, WindowsDaemonSet(namespace))
+	appendUnstructured(resources, ClusterRoleBinding(namespace))
+
+	if keepCRs {
+		return resources
+	}
+
+	appendUnstructured(resources, Namespace(namespace))
+	for _, crd := range CRDs() {
+		appendUnstructured(resources, crd)
+	}
+
+	return resources
+}
+
+// deleteResource deletes a resource from the cluster, along with its dependents, and waits for it to be gone
+// if waitForDeletion is true. If the resource doesn't exist, it's merely logged.
+func deleteResource(r *unstructured.Unstructured, factory client.DynamicFactory, w io.Writer, waitForDeletion bool) error {
+	id := fmt.Sprintf("%s/%s", r.GetKind(), r.GetName())
+
+	gvk := schema.FromAPIVersionAndKind(r.GetAPIVersion(), r.GetKind())
+
+	apiResource := metav1.APIResource{
+		Name:       kindToResource[r.GetKind()],
+		Namespaced: (r.GetNamespace() != ""),
+	}
+
+	c, err := factory.ClientForGroupVersionResource(gvk.GroupVersion(), apiResource, r.GetNamespace())
+	if err != nil {
+		return errors.Wrapf(err, "Error creating client for resource %s", id)
+	}
+
+	propagation := metav1.DeletePropagationForeground
+	if err := c.Delete(r.GetName(), &metav1.DeleteOptions{PropagationPolicy: &propagation}); apierrors.IsNotFound(err) {
+		fmt.Fprintf(w, "%s: not found, proceeding\n", id)
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "Error deleting resource %s", id)
+	}
+
+	if waitForDeletion {
+		// Foreground deletion keeps the resource until its dependents, such as a deployment's pods, are deleted.
+		err = wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
+			if _, err := c.Get(r.GetName(), metav1.GetOptions{}); apierrors.IsNotFound(err) {
+				return true, nil
+			} else if err != nil {
+				return false, errors.Wrapf(err, "error waiting for %s to be deleted", id)
+			}
+			return false, nil
+		})
+		if err == wait.ErrWaitTimeout {
+			return errors.Errorf("timeout reached, %s not deleted", id)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "%s: deleted\n", id)
+	return nil
+}
+
+// Uninstall deletes resources from the Kubernetes cluster, one at a time, in the order given. The Velero deployment and
+// restic daemonsets are waited on until their pods are gone, so that no server is left running to process the custom
+// resources that are deleted afterwards. Nothing is deleted from object storage or the volume snapshot provider.
+// An io.Writer can be used to output to a log or the console.
+func Uninstall(factory client.DynamicFactory, resources *unstructured.UnstructuredList, w io.Writer) error {
+	for i := range resources.Items {
+		r := &resources.Items[i]
+		waitForDeletion := r.GetKind() == "Deployment" || r.GetKind() == "DaemonSet"
+		if err := deleteResource(r, factory, w, waitForDeletion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/heptio/velero/pkg/client"
+)
+
+func TestUninstallResources(t *testing.T) {
+	kinds := func(resources *unstructured.UnstructuredList) []string {
+		var kinds []string
+		for _, r := range resources.Items {
+			kinds = append(kinds, r.GetKind()+"/"+r.GetName())
+		}
+		return kinds
+	}
+
+	assert.Equal(t, []string{
+		"Deployment/velero",
+		"DaemonSet/restic",
+		"DaemonSet/restic-windows",
+		"ClusterRoleBinding/velero",
+	}, kinds(UninstallResources("velero", true)))
+
+	all := kinds(UninstallResources("velero", false))
+	assert.Equal(t, "Deployment/velero", all[0])
+	assert.Equal(t, "Namespace/velero", all[4])
+	assert.Len(t, all, 5+len(CRDs()))
+	assert.Contains(t, all, "CustomResourceDefinition/backups.velero.io")
+}
+
+func TestUninstall(t *testing.T) {
+	resources := UninstallResources("velero", true)
+
+	// the restic daemonsets and cluster role binding don't exist.
+	objects := []runtime.Object{resources.Items[0].DeepCopy()}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Uninstall(client.NewDynamicFactory(dynamicClient), resources, buf))
+
+	_, err := dynamicClient.Resource(appsv1.SchemeGroupVersion.WithResource("deployments")).Namespace("velero").Get("velero", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	assert.Equal(t, "Deployment/velero: deleted\n"+
+		"DaemonSet/restic: not found, proceeding\n"+
+		"DaemonSet/restic-windows: not found, proceeding\n"+
+		"ClusterRoleBinding/velero: not found, proceeding\n", buf.String())
+}
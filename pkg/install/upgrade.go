@@ -0,0 +1,141 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/pkg/errors"
+	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/heptio/velero/pkg/client"
+)
+
+// UpgradeCRDs brings the Velero CRDs in the cluster up to date with CRDs(), creating the ones that don't exist and
+// patching the ones whose labels or spec differ. Fields that the API server defaults, or that CRDs() doesn't set, are
+// left alone. If dryRun is true, the changes are only written to w, and nothing is changed in the cluster.
+// It returns the number of CRDs that were, or would be, created or patched.
+func UpgradeCRDs(factory client.DynamicFactory, dryRun bool, w io.Writer) (int, error) {
+	apiResource := metav1.APIResource{
+		Name:       kindToResource["CustomResourceDefinition"],
+		Namespaced: false,
+	}
+	c, err := factory.ClientForGroupVersionResource(apiextv1beta1.SchemeGroupVersion, apiResource, "")
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error creating client for CustomResourceDefinitions")
+	}
+
+	crds := new(unstructured.UnstructuredList)
+	for _, crd := range CRDs() {
+		appendUnstructured(crds, crd)
+	}
+
+	var changes int
+	for i := range crds.Items {
+		desired := &crds.Items[i]
+		id := fmt.Sprintf("%s/%s", desired.GetKind(), desired.GetName())
+
+		existing, err := c.Get(desired.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			changes++
+			fmt.Fprintf(w, "%s: %s\n", id, outcome(dryRun, "created"))
+			if dryRun {
+				continue
+			}
+			if _, err := c.Create(desired); err != nil {
+				return changes, errors.Wrapf(err, "Error creating resource %s", id)
+			}
+			continue
+		} else if err != nil {
+			return changes, errors.Wrapf(err, "Error getting resource %s", id)
+		}
+
+		patch := map[string]interface{}{}
+		if !containsFields(existing.GetLabels(), desired.GetLabels()) {
+			patch["metadata"] = map[string]interface{}{"labels": desired.GetLabels()}
+		}
+		desiredSpec, _, _ := unstructured.NestedMap(desired.Object, "spec")
+		existingSpec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+		if !containsFields(existingSpec, desiredSpec) {
+			patch["spec"] = desiredSpec
+		}
+
+		if len(patch) == 0 {
+			fmt.Fprintf(w, "%s: up to date\n", id)
+			continue
+		}
+
+		changes++
+		fmt.Fprintf(w, "%s: %s\n", id, outcome(dryRun, "patched"))
+		if dryRun {
+			continue
+		}
+		data, err := json.Marshal(patch)
+		if err != nil {
+			return changes, errors.Wrapf(err, "Error encoding patch for resource %s", id)
+		}
+		if _, err := c.Patch(desired.GetName(), data); err != nil {
+			return changes, errors.Wrapf(err, "Error patching resource %s", id)
+		}
+	}
+
+	return changes, nil
+}
+
+// containsFields returns whether every field in desired is set to the same value in existing. Nested maps are compared
+// the same way, so fields that are only in existing are ignored at every level.
+func containsFields(existing, desired interface{}) bool {
+	switch desired := desired.(type) {
+	case map[string]interface{}:
+		existing, ok := existing.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, val := range desired {
+			if !containsFields(existing[key], val) {
+				return false
+			}
+		}
+		return true
+	case map[string]string:
+		existing, ok := existing.(map[string]string)
+		if !ok {
+			return len(desired) == 0
+		}
+		for key, val := range desired {
+			if existing[key] != val {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(existing, desired)
+	}
+}
+
+func outcome(dryRun bool, change string) string {
+	if dryRun {
+		return "would be " + change
+	}
+	return change
+}
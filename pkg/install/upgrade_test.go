@@ -0,0 +1,96 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/heptio/velero/pkg/client"
+)
+
+func TestUpgradeCRDs(t *testing.T) {
+	crds := new(unstructured.UnstructuredList)
+	for _, crd := range CRDs() {
+		appendUnstructured(crds, crd)
+	}
+
+	var objects []runtime.Object
+	for i := range crds.Items {
+		crd := crds.Items[i].DeepCopy()
+		switch crd.GetName() {
+		case "backups.velero.io":
+			// missing
+			continue
+		case "restores.velero.io":
+			// the label was removed, and a field was defaulted by the API server
+			crd.SetLabels(nil)
+			require.NoError(t, unstructured.SetNestedField(crd.Object, "restore", "spec", "names", "singular"))
+		default:
+			require.NoError(t, unstructured.SetNestedField(crd.Object, "defaulted", "spec", "names", "singular"))
+		}
+		objects = append(objects, crd)
+	}
+
+	crdResource := apiextv1beta1.SchemeGroupVersion.WithResource("customresourcedefinitions")
+
+	t.Run("dry run", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+		buf := new(bytes.Buffer)
+
+		changes, err := UpgradeCRDs(client.NewDynamicFactory(dynamicClient), true, buf)
+		require.NoError(t, err)
+		assert.Equal(t, 2, changes)
+		assert.Contains(t, buf.String(), "CustomResourceDefinition/backups.velero.io: would be created\n")
+		assert.Contains(t, buf.String(), "CustomResourceDefinition/restores.velero.io: would be patched\n")
+		assert.Contains(t, buf.String(), "CustomResourceDefinition/schedules.velero.io: up to date\n")
+
+		_, err = dynamicClient.Resource(crdResource).Get("backups.velero.io", metav1.GetOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("upgrade", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+		buf := new(bytes.Buffer)
+
+		changes, err := UpgradeCRDs(client.NewDynamicFactory(dynamicClient), false, buf)
+		require.NoError(t, err)
+		assert.Equal(t, 2, changes)
+
+		_, err = dynamicClient.Resource(crdResource).Get("backups.velero.io", metav1.GetOptions{})
+		assert.NoError(t, err)
+
+		restores, err := dynamicClient.Resource(crdResource).Get("restores.velero.io", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, labels(), restores.GetLabels())
+		singular, _, _ := unstructured.NestedString(restores.Object, "spec", "names", "singular")
+		assert.Equal(t, "restore", singular)
+
+		// upgrading again is a no-op
+		changes, err = UpgradeCRDs(client.NewDynamicFactory(dynamicClient), false, new(bytes.Buffer))
+		require.NoError(t, err)
+		assert.Equal(t, 0, changes)
+	})
+}
@@ -21,12 +21,22 @@ import (
 )
 
 var (
-	ClusterRoleBindings    = schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"}
-	ClusterRoles           = schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"}
-	Jobs                   = schema.GroupResource{Group: "batch", Resource: "jobs"}
-	Namespaces             = schema.GroupResource{Group: "", Resource: "namespaces"}
-	PersistentVolumeClaims = schema.GroupResource{Group: "", Resource: "persistentvolumeclaims"}
-	PersistentVolumes      = schema.GroupResource{Group: "", Resource: "persistentvolumes"}
-	Pods                   = schema.GroupResource{Group: "", Resource: "pods"}
-	ServiceAccounts        = schema.GroupResource{Group: "", Resource: "serviceaccounts"}
+	APIServices                     = schema.GroupResource{Group: "apiregistration.k8s.io", Resource: "apiservices"}
+	ClusterRoleBindings             = schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"}
+	ClusterRoles                    = schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"}
+	CustomResourceDefinitions       = schema.GroupResource{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}
+	Deployments                     = schema.GroupResource{Group: "apps", Resource: "deployments"}
+	FlowSchemas                     = schema.GroupResource{Group: "flowcontrol.apiserver.k8s.io", Resource: "flowschemas"}
+	Jobs                            = schema.GroupResource{Group: "batch", Resource: "jobs"}
+	MutatingWebhookConfigurations   = schema.GroupResource{Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations"}
+	Namespaces                      = schema.GroupResource{Group: "", Resource: "namespaces"}
+	NetworkPolicies                 = schema.GroupResource{Group: "networking.k8s.io", Resource: "networkpolicies"}
+	PersistentVolumeClaims          = schema.GroupResource{Group: "", Resource: "persistentvolumeclaims"}
+	PersistentVolumes               = schema.GroupResource{Group: "", Resource: "persistentvolumes"}
+	Pods                            = schema.GroupResource{Group: "", Resource: "pods"}
+	PriorityLevelConfigurations     = schema.GroupResource{Group: "flowcontrol.apiserver.k8s.io", Resource: "prioritylevelconfigurations"}
+	RoleBindings                    = schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "rolebindings"}
+	ServiceAccounts                 = schema.GroupResource{Group: "", Resource: "serviceaccounts"}
+	StatefulSets                    = schema.GroupResource{Group: "apps", Resource: "statefulsets"}
+	ValidatingWebhookConfigurations = schema.GroupResource{Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations"}
 )
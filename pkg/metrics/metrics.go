@@ -30,6 +30,7 @@ type ServerMetrics struct {
 const (
 	metricNamespace               = "velero"
 	backupTarballSizeBytesGauge   = "backup_tarball_size_bytes"
+	backupTotalSizeBytesGauge     = "backup_total_size_bytes"
 	backupTotal                   = "backup_total"
 	backupAttemptTotal            = "backup_attempt_total"
 	backupSuccessTotal            = "backup_success_total"
@@ -40,18 +41,31 @@ const (
 	backupDeletionSuccessTotal    = "backup_deletion_success_total"
 	backupDeletionFailureTotal    = "backup_deletion_failure_total"
 	backupLastSuccessfulTimestamp = "backup_last_successful_timestamp"
+	backupItemsTotal              = "backup_items_total"
+	backupItemsBackedUp           = "backup_items_backed_up"
+	scheduleLastSuccessfulBackup  = "schedule_last_successful_backup_timestamp"
 	restoreTotal                  = "restore_total"
 	restoreAttemptTotal           = "restore_attempt_total"
 	restoreValidationFailedTotal  = "restore_validation_failed_total"
 	restoreSuccessTotal           = "restore_success_total"
 	restorePartialFailureTotal    = "restore_partial_failure_total"
 	restoreFailedTotal            = "restore_failed_total"
+	restoreDurationSeconds        = "restore_duration_seconds"
+	restoreItemsRestored          = "restore_items_restored"
 	volumeSnapshotAttemptTotal    = "volume_snapshot_attempt_total"
 	volumeSnapshotSuccessTotal    = "volume_snapshot_success_total"
 	volumeSnapshotFailureTotal    = "volume_snapshot_failure_total"
-
-	scheduleLabel   = "schedule"
-	backupNameLabel = "backupName"
+	partialBackups                = "partial_backups"
+	partialBackupDeletionTotal    = "partial_backup_deletion_total"
+	partialBackupDeletionFailures = "partial_backup_deletion_failure_total"
+	orphanedSnapshots             = "orphaned_volume_snapshots"
+	orphanedSnapshotDeletionTotal = "orphaned_volume_snapshot_deletion_total"
+	orphanedSnapshotDeletionFails = "orphaned_volume_snapshot_deletion_failure_total"
+
+	scheduleLabel       = "schedule"
+	backupNameLabel     = "backupName"
+	backupLocationLabel = "backupLocation"
+	volumeLocationLabel = "volumeSnapshotLocation"
 
 	secondsInMinute = 60.0
 )
@@ -68,6 +82,14 @@ func NewServerMetrics() *ServerMetrics {
 				},
 				[]string{scheduleLabel},
 			),
+			backupTotalSizeBytesGauge: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      backupTotalSizeBytesGauge,
+					Help:      "Size, in bytes, of the files and pod volume data a backup uploaded",
+				},
+				[]string{scheduleLabel},
+			),
 			backupLastSuccessfulTimestamp: prometheus.NewGaugeVec(
 				prometheus.GaugeOpts{
 					Namespace: metricNamespace,
@@ -76,6 +98,30 @@ func NewServerMetrics() *ServerMetrics {
 				},
 				[]string{scheduleLabel},
 			),
+			scheduleLastSuccessfulBackup: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      scheduleLastSuccessfulBackup,
+					Help:      "Completion time of the most recent successful backup of a schedule, Unix timestamp in seconds",
+				},
+				[]string{scheduleLabel},
+			),
+			backupItemsTotal: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      backupItemsTotal,
+					Help:      "Number of items found to back up by the most recent backup",
+				},
+				[]string{scheduleLabel},
+			),
+			backupItemsBackedUp: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      backupItemsBackedUp,
+					Help:      "Number of items backed up by the most recent backup",
+				},
+				[]string{scheduleLabel},
+			),
 			backupTotal: prometheus.NewGauge(
 				prometheus.GaugeOpts{
 					Namespace: metricNamespace,
@@ -197,6 +243,33 @@ func NewServerMetrics() *ServerMetrics {
 				},
 				[]string{scheduleLabel},
 			),
+			restoreDurationSeconds: prometheus.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Namespace: metricNamespace,
+					Name:      restoreDurationSeconds,
+					Help:      "Time taken to complete restore, in seconds",
+					Buckets: []float64{
+						toSeconds(1 * time.Minute),
+						toSeconds(5 * time.Minute),
+						toSeconds(10 * time.Minute),
+						toSeconds(15 * time.Minute),
+						toSeconds(30 * time.Minute),
+						toSeconds(1 * time.Hour),
+						toSeconds(2 * time.Hour),
+						toSeconds(3 * time.Hour),
+						toSeconds(4 * time.Hour),
+					},
+				},
+				[]string{scheduleLabel},
+			),
+			restoreItemsRestored: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      restoreItemsRestored,
+					Help:      "Number of items created by the most recent restore of a backup",
+				},
+				[]string{scheduleLabel, backupNameLabel},
+			),
 			restoreValidationFailedTotal: prometheus.NewCounterVec(
 				prometheus.CounterOpts{
 					Namespace: metricNamespace,
@@ -229,6 +302,54 @@ func NewServerMetrics() *ServerMetrics {
 				},
 				[]string{scheduleLabel},
 			),
+			partialBackups: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      partialBackups,
+					Help:      "Current number of partially uploaded backups in a backup storage location",
+				},
+				[]string{backupLocationLabel},
+			),
+			partialBackupDeletionTotal: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: metricNamespace,
+					Name:      partialBackupDeletionTotal,
+					Help:      "Total number of partially uploaded backups deleted from a backup storage location",
+				},
+				[]string{backupLocationLabel},
+			),
+			partialBackupDeletionFailures: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: metricNamespace,
+					Name:      partialBackupDeletionFailures,
+					Help:      "Total number of failed deletions of partially uploaded backups",
+				},
+				[]string{backupLocationLabel},
+			),
+			orphanedSnapshots: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      orphanedSnapshots,
+					Help:      "Current number of volume snapshots in a volume snapshot location whose backups no longer exist",
+				},
+				[]string{volumeLocationLabel},
+			),
+			orphanedSnapshotDeletionTotal: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: metricNamespace,
+					Name:      orphanedSnapshotDeletionTotal,
+					Help:      "Total number of volume snapshots deleted because their backups no longer exist",
+				},
+				[]string{volumeLocationLabel},
+			),
+			orphanedSnapshotDeletionFails: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: metricNamespace,
+					Name:      orphanedSnapshotDeletionFails,
+					Help:      "Total number of failed deletions of volume snapshots whose backups no longer exist",
+				},
+				[]string{volumeLocationLabel},
+			),
 		},
 	}
 }
@@ -296,6 +417,14 @@ func (m *ServerMetrics) SetBackupTarballSizeBytesGauge(backupSchedule string, si
 	}
 }
 
+// SetBackupTotalSizeBytesGauge records the size, in bytes, of the files and
+// pod volume data that a backup uploaded.
+func (m *ServerMetrics) SetBackupTotalSizeBytesGauge(backupSchedule string, size int64) {
+	if g, ok := m.metrics[backupTotalSizeBytesGauge].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(backupSchedule).Set(float64(size))
+	}
+}
+
 // SetBackupLastSuccessfulTimestamp records the last time a backup ran successfully, Unix timestamp in seconds
 func (m *ServerMetrics) SetBackupLastSuccessfulTimestamp(backupSchedule string) {
 	if g, ok := m.metrics[backupLastSuccessfulTimestamp].(*prometheus.GaugeVec); ok {
@@ -303,6 +432,37 @@ func (m *ServerMetrics) SetBackupLastSuccessfulTimestamp(backupSchedule string)
 	}
 }
 
+// SetScheduleLastSuccessfulBackupTimestamps replaces the completion times of
+// the most recent successful backup of each schedule. Schedules that are not
+// in lastSuccessful no longer report the metric.
+func (m *ServerMetrics) SetScheduleLastSuccessfulBackupTimestamps(lastSuccessful map[string]time.Time) {
+	if g, ok := m.metrics[scheduleLastSuccessfulBackup].(*prometheus.GaugeVec); ok {
+		g.Reset()
+		for schedule, completed := range lastSuccessful {
+			g.WithLabelValues(schedule).Set(float64(completed.Unix()))
+		}
+	}
+}
+
+// SetScheduleLastSuccessfulBackupTimestamp records the completion time of the
+// most recent successful backup of a schedule.
+func (m *ServerMetrics) SetScheduleLastSuccessfulBackupTimestamp(schedule string, completed time.Time) {
+	if g, ok := m.metrics[scheduleLastSuccessfulBackup].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(schedule).Set(float64(completed.Unix()))
+	}
+}
+
+// SetBackupItemsProgress records how many items the most recent backup
+// has found and backed up so far.
+func (m *ServerMetrics) SetBackupItemsProgress(backupSchedule string, totalItems, itemsBackedUp int) {
+	if g, ok := m.metrics[backupItemsTotal].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(backupSchedule).Set(float64(totalItems))
+	}
+	if g, ok := m.metrics[backupItemsBackedUp].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(backupSchedule).Set(float64(itemsBackedUp))
+	}
+}
+
 // SetBackupTotal records the current number of existent backups.
 func (m *ServerMetrics) SetBackupTotal(numberOfBackups int64) {
 	if g, ok := m.metrics[backupTotal].(prometheus.Gauge); ok {
@@ -415,6 +575,29 @@ func (m *ServerMetrics) RegisterRestoreValidationFailed(backupSchedule string) {
 	}
 }
 
+// RegisterRestoreDuration records the number of seconds a restore took.
+func (m *ServerMetrics) RegisterRestoreDuration(backupSchedule string, seconds float64) {
+	if c, ok := m.metrics[restoreDurationSeconds].(*prometheus.HistogramVec); ok {
+		c.WithLabelValues(backupSchedule).Observe(seconds)
+	}
+}
+
+// SetRestoreItemsRestored records the number of items created by the most
+// recent restore of a backup.
+func (m *ServerMetrics) SetRestoreItemsRestored(backupSchedule, backupName string, itemsRestored int) {
+	if g, ok := m.metrics[restoreItemsRestored].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(backupSchedule, backupName).Set(float64(itemsRestored))
+	}
+}
+
+// DeleteRestoreItemsRestored removes the number of items restored from a
+// backup, once the backup has been deleted.
+func (m *ServerMetrics) DeleteRestoreItemsRestored(backupSchedule, backupName string) {
+	if g, ok := m.metrics[restoreItemsRestored].(*prometheus.GaugeVec); ok {
+		g.DeleteLabelValues(backupSchedule, backupName)
+	}
+}
+
 // RegisterVolumeSnapshotAttempts records an attempt to snapshot a volume.
 func (m *ServerMetrics) RegisterVolumeSnapshotAttempts(backupSchedule string, volumeSnapshotsAttempted int) {
 	if c, ok := m.metrics[volumeSnapshotAttemptTotal].(*prometheus.CounterVec); ok {
@@ -435,3 +618,51 @@ func (m *ServerMetrics) RegisterVolumeSnapshotFailures(backupSchedule string, vo
 		c.WithLabelValues(backupSchedule).Add(float64(volumeSnapshotsFailed))
 	}
 }
+
+// SetPartialBackups records the number of partially uploaded backups
+// found in a backup storage location.
+func (m *ServerMetrics) SetPartialBackups(backupLocation string, count int) {
+	if g, ok := m.metrics[partialBackups].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(backupLocation).Set(float64(count))
+	}
+}
+
+// RegisterPartialBackupDeletion records the deletion of a partially
+// uploaded backup.
+func (m *ServerMetrics) RegisterPartialBackupDeletion(backupLocation string) {
+	if c, ok := m.metrics[partialBackupDeletionTotal].(*prometheus.CounterVec); ok {
+		c.WithLabelValues(backupLocation).Inc()
+	}
+}
+
+// SetOrphanedSnapshots records the number of volume snapshots found in a
+// volume snapshot location whose backups no longer exist.
+func (m *ServerMetrics) SetOrphanedSnapshots(snapshotLocation string, count int) {
+	if g, ok := m.metrics[orphanedSnapshots].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(snapshotLocation).Set(float64(count))
+	}
+}
+
+// RegisterOrphanedSnapshotDeletion records the deletion of a volume
+// snapshot whose backup no longer exists.
+func (m *ServerMetrics) RegisterOrphanedSnapshotDeletion(snapshotLocation string) {
+	if c, ok := m.metrics[orphanedSnapshotDeletionTotal].(*prometheus.CounterVec); ok {
+		c.WithLabelValues(snapshotLocation).Inc()
+	}
+}
+
+// RegisterOrphanedSnapshotDeletionFailure records a failed deletion of a
+// volume snapshot whose backup no longer exists.
+func (m *ServerMetrics) RegisterOrphanedSnapshotDeletionFailure(snapshotLocation string) {
+	if c, ok := m.metrics[orphanedSnapshotDeletionFails].(*prometheus.CounterVec); ok {
+		c.WithLabelValues(snapshotLocation).Inc()
+	}
+}
+
+// RegisterPartialBackupDeletionFailure records a failed deletion of a
+// partially uploaded backup.
+func (m *ServerMetrics) RegisterPartialBackupDeletionFailure(backupLocation string) {
+	if c, ok := m.metrics[partialBackupDeletionFailures].(*prometheus.CounterVec); ok {
+		c.WithLabelValues(backupLocation).Inc()
+	}
+}
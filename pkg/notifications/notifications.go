@@ -0,0 +1,416 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notifications posts webhooks when backups start and finish, when
+// restores finish, and when backup storage locations become unavailable. The
+// webhooks are configured in a ConfigMap in the server's namespace.
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/yaml"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the server's
+	// namespace, that configures the webhooks.
+	ConfigMapName = "velero-notifications"
+
+	// Version is the version of the notifications format.
+	Version = "v1"
+
+	// FormatGeneric posts the event as JSON, or the webhook's rendered
+	// template as-is.
+	FormatGeneric = "generic"
+
+	// FormatSlack posts a Slack-compatible message whose text is the
+	// event's summary, or the webhook's rendered template.
+	FormatSlack = "slack"
+
+	// requestTimeout is how long to wait for a webhook to respond.
+	requestTimeout = 10 * time.Second
+)
+
+// EventType is the kind of occurrence a notification is sent for.
+type EventType string
+
+const (
+	EventBackupStarted                    EventType = "BackupStarted"
+	EventBackupCompleted                  EventType = "BackupCompleted"
+	EventBackupPartiallyFailed            EventType = "BackupPartiallyFailed"
+	EventBackupFailed                     EventType = "BackupFailed"
+	EventRestoreCompleted                 EventType = "RestoreCompleted"
+	EventRestorePartiallyFailed           EventType = "RestorePartiallyFailed"
+	EventRestoreFailed                    EventType = "RestoreFailed"
+	EventBackupStorageLocationUnavailable EventType = "BackupStorageLocationUnavailable"
+)
+
+var eventTypes = sets.NewString(
+	string(EventBackupStarted),
+	string(EventBackupCompleted),
+	string(EventBackupPartiallyFailed),
+	string(EventBackupFailed),
+	string(EventRestoreCompleted),
+	string(EventRestorePartiallyFailed),
+	string(EventRestoreFailed),
+	string(EventBackupStorageLocationUnavailable),
+)
+
+// Event is what a notification is sent about. It's the payload of generic
+// webhooks without a template, and what templates are rendered with.
+type Event struct {
+	Type      EventType `json:"type"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Phase     string    `json:"phase,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Errors    int       `json:"errors,omitempty"`
+	Warnings  int       `json:"warnings,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// BackupEvent returns an event of the given type about a backup.
+func BackupEvent(eventType EventType, backup *velerov1api.Backup) Event {
+	message := backup.Status.FailureReason
+	if message == "" {
+		message = strings.Join(backup.Status.ValidationErrors, "; ")
+	}
+
+	return Event{
+		Type:      eventType,
+		Kind:      "Backup",
+		Namespace: backup.Namespace,
+		Name:      backup.Name,
+		Phase:     string(backup.Status.Phase),
+		Message:   message,
+		Errors:    backup.Status.Errors,
+		Warnings:  backup.Status.Warnings,
+		Time:      time.Now().UTC(),
+	}
+}
+
+// RestoreEvent returns an event of the given type about a restore.
+func RestoreEvent(eventType EventType, restore *velerov1api.Restore) Event {
+	message := restore.Status.FailureReason
+	if message == "" {
+		message = strings.Join(restore.Status.ValidationErrors, "; ")
+	}
+
+	return Event{
+		Type:      eventType,
+		Kind:      "Restore",
+		Namespace: restore.Namespace,
+		Name:      restore.Name,
+		Phase:     string(restore.Status.Phase),
+		Message:   message,
+		Errors:    restore.Status.Errors,
+		Warnings:  restore.Status.Warnings,
+		Time:      time.Now().UTC(),
+	}
+}
+
+// LocationUnavailableEvent returns an event about a backup storage location
+// that couldn't be validated, with the reason as its message.
+func LocationUnavailableEvent(location *velerov1api.BackupStorageLocation, message string) Event {
+	return Event{
+		Type:      EventBackupStorageLocationUnavailable,
+		Kind:      "BackupStorageLocation",
+		Namespace: location.Namespace,
+		Name:      location.Name,
+		Phase:     string(velerov1api.BackupStorageLocationPhaseUnavailable),
+		Message:   message,
+		Time:      time.Now().UTC(),
+	}
+}
+
+// Summary returns a one-line, human-readable description of the event,
+// e.g. "Backup velero/nightly failed: error uploading backup".
+func (e Event) Summary() string {
+	var what string
+	switch e.Type {
+	case EventBackupStarted:
+		what = "started"
+	case EventBackupCompleted, EventRestoreCompleted:
+		what = "completed"
+	case EventBackupPartiallyFailed, EventRestorePartiallyFailed:
+		what = "partially failed"
+	case EventBackupFailed, EventRestoreFailed:
+		what = "failed"
+	case EventBackupStorageLocationUnavailable:
+		what = "is unavailable"
+	default:
+		what = string(e.Type)
+	}
+
+	summary := fmt.Sprintf("%s %s/%s %s", e.Kind, e.Namespace, e.Name, what)
+	if e.Message != "" {
+		summary += ": " + e.Message
+	}
+	if e.Errors > 0 || e.Warnings > 0 {
+		summary += fmt.Sprintf(" (%d errors, %d warnings)", e.Errors, e.Warnings)
+	}
+	return summary
+}
+
+// config is the format of the notifications in the ConfigMap.
+type config struct {
+	Version  string          `json:"version"`
+	Webhooks []webhookConfig `json:"webhooks"`
+}
+
+type webhookConfig struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+
+	// Format is FormatGeneric or FormatSlack. It defaults to
+	// FormatGeneric.
+	Format string `json:"format,omitempty"`
+
+	// Events are the event types to post. All events are posted if it's
+	// empty.
+	Events []string `json:"events,omitempty"`
+
+	// Template is a Go template, rendered with the Event, that replaces
+	// the default payload of the format.
+	Template string `json:"template,omitempty"`
+
+	// Headers are added to each request, e.g. for authorization.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type webhook struct {
+	name     string
+	url      string
+	format   string
+	events   sets.String
+	template *template.Template
+	headers  map[string]string
+}
+
+// parse parses the notifications configuration given as YAML.
+func parse(data []byte) ([]*webhook, error) {
+	var cfg config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if cfg.Version != Version {
+		return nil, errors.Errorf("unsupported version %q, must be %s", cfg.Version, Version)
+	}
+
+	var res []*webhook
+	for i, wc := range cfg.Webhooks {
+		name := wc.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i)
+		}
+
+		if wc.URL == "" {
+			return nil, errors.Errorf("webhook %s must have a url", name)
+		}
+		if u, err := url.Parse(wc.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, errors.Errorf("webhook %s has invalid url %q, must be an http or https URL", name, wc.URL)
+		}
+
+		w := &webhook{
+			name:    name,
+			url:     wc.URL,
+			format:  wc.Format,
+			events:  sets.NewString(),
+			headers: wc.Headers,
+		}
+
+		switch w.format {
+		case "":
+			w.format = FormatGeneric
+		case FormatGeneric, FormatSlack:
+		default:
+			return nil, errors.Errorf("webhook %s has unsupported format %q, must be %s or %s", name, wc.Format, FormatGeneric, FormatSlack)
+		}
+
+		for _, event := range wc.Events {
+			if !eventTypes.Has(event) {
+				return nil, errors.Errorf("webhook %s has unsupported event %q, must be one of %s", name, event, strings.Join(eventTypes.List(), ", "))
+			}
+			w.events.Insert(event)
+		}
+
+		if wc.Template != "" {
+			tmpl, err := template.New(name).Option("missingkey=error").Parse(wc.Template)
+			if err != nil {
+				return nil, errors.Wrapf(err, "webhook %s has invalid template", name)
+			}
+			w.template = tmpl
+		}
+
+		res = append(res, w)
+	}
+
+	return res, nil
+}
+
+// matches returns whether the webhook posts events of the given type.
+func (w *webhook) matches(eventType EventType) bool {
+	return w.events.Len() == 0 || w.events.Has(string(eventType))
+}
+
+// payload returns the body to post for the event.
+func (w *webhook) payload(event Event) ([]byte, error) {
+	var rendered string
+	if w.template != nil {
+		buf := new(bytes.Buffer)
+		if err := w.template.Execute(buf, event); err != nil {
+			return nil, errors.Wrap(err, "error rendering template")
+		}
+		rendered = buf.String()
+	}
+
+	switch w.format {
+	case FormatSlack:
+		text := rendered
+		if w.template == nil {
+			text = event.Summary()
+		}
+		body, err := json.Marshal(map[string]string{"text": text})
+		return body, errors.WithStack(err)
+	default:
+		if w.template != nil {
+			return []byte(rendered), nil
+		}
+		body, err := json.Marshal(event)
+		return body, errors.WithStack(err)
+	}
+}
+
+// post sends the event to the webhook.
+func (w *webhook) post(client *http.Client, event Event) error {
+	body, err := w.payload(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("webhook responded with status %s", res.Status)
+	}
+	return nil
+}
+
+// Notifier sends notifications about events.
+type Notifier interface {
+	// Notify sends the event to each webhook configured for its type. It
+	// doesn't wait for the webhooks to respond.
+	Notify(event Event)
+}
+
+type webhookNotifier struct {
+	configMapsClient corev1client.ConfigMapInterface
+	httpClient       *http.Client
+	log              logrus.FieldLogger
+}
+
+// NewNotifier returns a Notifier that posts to the webhooks configured in
+// the ConfigMapName ConfigMap. The ConfigMap is read for each event, so
+// changes take effect without restarting the server, and nothing is posted
+// if it doesn't exist.
+func NewNotifier(configMapsClient corev1client.ConfigMapInterface, log logrus.FieldLogger) Notifier {
+	return &webhookNotifier{
+		configMapsClient: configMapsClient,
+		httpClient:       &http.Client{Timeout: requestTimeout},
+		log:              log,
+	}
+}
+
+func (n *webhookNotifier) Notify(event Event) {
+	go n.notify(event)
+}
+
+func (n *webhookNotifier) notify(event Event) {
+	log := n.log.WithFields(logrus.Fields{
+		"event": event.Type,
+		"name":  event.Namespace + "/" + event.Name,
+	})
+
+	webhooks, err := n.getWebhooks()
+	if err != nil {
+		log.WithError(err).Warn("Error getting notification webhooks")
+		return
+	}
+
+	for _, w := range webhooks {
+		if !w.matches(event.Type) {
+			continue
+		}
+		if err := w.post(n.httpClient, event); err != nil {
+			log.WithError(err).WithField("webhook", w.name).Warn("Error sending notification")
+		}
+	}
+}
+
+func (n *webhookNotifier) getWebhooks() ([]*webhook, error) {
+	configMap, err := n.configMapsClient.Get(ConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting notifications ConfigMap %s", ConfigMapName)
+	}
+
+	if len(configMap.Data) != 1 {
+		return nil, errors.Errorf("notifications ConfigMap %s must have a single data key, but has %d", ConfigMapName, len(configMap.Data))
+	}
+
+	for _, data := range configMap.Data {
+		webhooks, err := parse([]byte(data))
+		if err != nil {
+			return nil, errors.WithMessage(err, "error parsing notifications ConfigMap "+ConfigMapName)
+		}
+		return webhooks, nil
+	}
+
+	return nil, nil
+}
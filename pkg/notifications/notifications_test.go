@@ -0,0 +1,210 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifications
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid webhooks",
+			data: `
+version: v1
+webhooks:
+- name: slack
+  url: https://hooks.slack.com/services/x
+  format: slack
+  events: [BackupFailed, RestoreFailed]
+- url: http://example.com/hook
+  template: '{"backup": "{{ .Name }}"}'
+`,
+		},
+		{
+			name:    "unsupported version",
+			data:    "version: v2\n",
+			wantErr: `unsupported version "v2"`,
+		},
+		{
+			name:    "missing url",
+			data:    "version: v1\nwebhooks:\n- name: a\n",
+			wantErr: "webhook a must have a url",
+		},
+		{
+			name:    "non-http url",
+			data:    "version: v1\nwebhooks:\n- url: ftp://example.com\n",
+			wantErr: "webhook 0 has invalid url",
+		},
+		{
+			name:    "unsupported format",
+			data:    "version: v1\nwebhooks:\n- url: http://example.com\n  format: teams\n",
+			wantErr: `unsupported format "teams"`,
+		},
+		{
+			name:    "unsupported event",
+			data:    "version: v1\nwebhooks:\n- url: http://example.com\n  events: [BackupDeleted]\n",
+			wantErr: `unsupported event "BackupDeleted"`,
+		},
+		{
+			name:    "invalid template",
+			data:    "version: v1\nwebhooks:\n- url: http://example.com\n  template: '{{ .Name'\n",
+			wantErr: "invalid template",
+		},
+		{
+			name:    "unknown field",
+			data:    "version: v1\nwebhooks:\n- url: http://example.com\n  method: PUT\n",
+			wantErr: "unknown field",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parse([]byte(tc.data))
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestPayload(t *testing.T) {
+	event := Event{
+		Type:      EventBackupPartiallyFailed,
+		Kind:      "Backup",
+		Namespace: "velero",
+		Name:      "nightly",
+		Phase:     "PartiallyFailed",
+		Errors:    2,
+		Warnings:  1,
+		Time:      time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "generic without a template posts the event",
+			data: "version: v1\nwebhooks:\n- url: http://example.com\n",
+			want: `{"type":"BackupPartiallyFailed","kind":"Backup","namespace":"velero","name":"nightly","phase":"PartiallyFailed","errors":2,"warnings":1,"time":"2019-06-01T00:00:00Z"}`,
+		},
+		{
+			name: "generic with a template posts the rendered template",
+			data: "version: v1\nwebhooks:\n- url: http://example.com\n  template: '{\"backup\": \"{{ .Name }}\", \"errors\": {{ .Errors }}}'\n",
+			want: `{"backup": "nightly", "errors": 2}`,
+		},
+		{
+			name: "slack without a template posts the summary",
+			data: "version: v1\nwebhooks:\n- url: http://example.com\n  format: slack\n",
+			want: `{"text":"Backup velero/nightly partially failed (2 errors, 1 warnings)"}`,
+		},
+		{
+			name: "slack with a template posts the rendered template as text",
+			data: "version: v1\nwebhooks:\n- url: http://example.com\n  format: slack\n  template: ':warning: {{ .Summary }}'\n",
+			want: `{"text":":warning: Backup velero/nightly partially failed (2 errors, 1 warnings)"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			webhooks, err := parse([]byte(tc.data))
+			require.NoError(t, err)
+			require.Len(t, webhooks, 1)
+
+			body, err := webhooks[0].payload(event)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(body))
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var (
+		bodies  []string
+		headers []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		headers = append(headers, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	configMap := &corev1api.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: velerov1api.DefaultNamespace, Name: ConfigMapName},
+		Data: map[string]string{
+			"notifications.yaml": strings.Join([]string{
+				"version: v1",
+				"webhooks:",
+				"- name: failures",
+				"  url: " + server.URL + "/failures",
+				"  format: slack",
+				"  events: [BackupFailed]",
+				"  headers:",
+				"    Authorization: Bearer token",
+				"- name: all",
+				"  url: " + server.URL + "/all",
+				"  template: '{{ .Type }} {{ .Name }}'",
+			}, "\n"),
+		},
+	}
+
+	backup := &velerov1api.Backup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: velerov1api.DefaultNamespace, Name: "backup-1"},
+		Status:     velerov1api.BackupStatus{Phase: velerov1api.BackupPhaseFailedValidation, ValidationErrors: []string{"boom"}},
+	}
+
+	client := fake.NewSimpleClientset(configMap)
+	n := NewNotifier(client.CoreV1().ConfigMaps(velerov1api.DefaultNamespace), velerotest.NewLogger()).(*webhookNotifier)
+
+	n.notify(BackupEvent(EventBackupStarted, backup))
+	assert.Equal(t, []string{"BackupStarted backup-1"}, bodies)
+
+	bodies, headers = nil, nil
+	n.notify(BackupEvent(EventBackupFailed, backup))
+	assert.Equal(t, []string{`{"text":"Backup velero/backup-1 failed: boom"}`, "BackupFailed backup-1"}, bodies)
+	assert.Equal(t, []string{"Bearer token", ""}, headers)
+
+	// without the ConfigMap, nothing is posted.
+	bodies = nil
+	n = NewNotifier(fake.NewSimpleClientset().CoreV1().ConfigMaps(velerov1api.DefaultNamespace), velerotest.NewLogger()).(*webhookNotifier)
+	n.notify(BackupEvent(EventBackupFailed, backup))
+	assert.Empty(t, bodies)
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/util/kube"
+	"github.com/heptio/velero/pkg/volume"
+)
+
+// Backups taken by Heptio Ark, as Velero was called before v0.11, have an
+// ark-backup.json metadata file with an ark.heptio.com/v1 Backup, and
+// their labels and annotations use Ark's domain. Backups taken before Ark
+// v0.10 are stored at the top level of the backup store rather than in the
+// backups directory, and record their volume snapshots in the backup's
+// status rather than in a volumesnapshots file.
+
+// ArkVolumeSnapshotLocation is the volume snapshot location that the volume
+// snapshots of backups taken before Ark v0.10, which didn't have volume
+// snapshot locations, are assumed to be in when the backup doesn't list
+// any.
+const ArkVolumeSnapshotLocation = "default"
+
+const arkBackupMetadataFile = "ark-backup.json"
+
+// arkBackup holds the fields of an Ark backup's metadata that Velero's
+// Backup type doesn't have.
+type arkBackup struct {
+	Status struct {
+		VolumeBackups map[string]*arkVolumeBackupInfo `json:"volumeBackups"`
+	} `json:"status"`
+}
+
+type arkVolumeBackupInfo struct {
+	SnapshotID       string `json:"snapshotID"`
+	Type             string `json:"type"`
+	AvailabilityZone string `json:"availabilityZone"`
+	Iops             *int64 `json:"iops,omitempty"`
+}
+
+// getArkBackup returns the metadata of a backup that was taken by Ark,
+// migrated to a Velero backup, along with the fields that Velero's Backup
+// type doesn't have. It returns nils if the backup doesn't have an Ark
+// metadata file.
+func (s *objectBackupStore) getArkBackup(name string) (*velerov1api.Backup, *arkBackup, error) {
+	res, err := tryGet(s.objectStore, s.bucket, s.layout.getArkBackupMetadataKey(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	if res == nil {
+		return nil, nil, nil
+	}
+	defer res.Close()
+
+	data, err := ioutil.ReadAll(res)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	backup := new(velerov1api.Backup)
+	if err := json.Unmarshal(data, backup); err != nil {
+		return nil, nil, errors.Wrapf(err, "error decoding Ark metadata for backup %s", name)
+	}
+	backup.APIVersion = velerov1api.SchemeGroupVersion.String()
+	backup.Kind = "Backup"
+	kube.MigrateArkLabelsAndAnnotations(backup)
+
+	legacy := new(arkBackup)
+	if err := json.Unmarshal(data, legacy); err != nil {
+		return nil, nil, errors.Wrapf(err, "error decoding Ark metadata for backup %s", name)
+	}
+
+	return backup, legacy, nil
+}
+
+// getArkVolumeSnapshots returns the volume snapshots that a backup taken
+// before Ark v0.10 recorded in its status, or nil if the backup doesn't have
+// an Ark metadata file.
+func (s *objectBackupStore) getArkVolumeSnapshots(name string) ([]*volume.Snapshot, error) {
+	backup, legacy, err := s.getArkBackup(name)
+	if err != nil || backup == nil {
+		return nil, err
+	}
+
+	location := ArkVolumeSnapshotLocation
+	if len(backup.Spec.VolumeSnapshotLocations) > 0 {
+		location = backup.Spec.VolumeSnapshotLocations[0]
+	}
+
+	// sort the volumes so that the snapshots are in a consistent order.
+	var pvNames []string
+	for pvName := range legacy.Status.VolumeBackups {
+		pvNames = append(pvNames, pvName)
+	}
+	sort.Strings(pvNames)
+
+	var res []*volume.Snapshot
+	for _, pvName := range pvNames {
+		info := legacy.Status.VolumeBackups[pvName]
+		if info == nil {
+			continue
+		}
+
+		res = append(res, &volume.Snapshot{
+			Spec: volume.SnapshotSpec{
+				BackupName:           name,
+				BackupUID:            string(backup.UID),
+				Location:             location,
+				PersistentVolumeName: pvName,
+				VolumeType:           info.Type,
+				VolumeAZ:             info.AvailabilityZone,
+				VolumeIOPS:           info.Iops,
+			},
+			Status: volume.SnapshotStatus{
+				ProviderSnapshotID: info.SnapshotID,
+				Phase:              volume.SnapshotPhaseCompleted,
+			},
+		})
+	}
+
+	return res, nil
+}
+
+// getLegacyBackupDir returns the directory of a backup that's stored at the
+// top level of the backup store, as Ark did before v0.10, or an empty
+// string if the backup isn't. The top level of the backup store is only
+// listed the first time.
+func (s *objectBackupStore) getLegacyBackupDir(name string) string {
+	if s.legacyBackupDirs == nil {
+		dirs, err := s.listLegacyBackupDirs()
+		if err != nil {
+			s.logger.WithError(err).Warn("Error listing backups taken before Ark v0.10")
+			return ""
+		}
+		s.legacyBackupDirs = dirs
+	}
+
+	return s.legacyBackupDirs[name]
+}
+
+// isLegacyBackupDir returns whether a top-level directory of the backup
+// store is a backup taken before Ark v0.10.
+func (s *objectBackupStore) isLegacyBackupDir(dir string) bool {
+	return s.layout.legacyBackupDir != nil && s.getLegacyBackupDir(dir) != ""
+}
+
+// listLegacyBackupDirs returns the directories at the top level of the
+// backup store that have an Ark metadata file, keyed by backup name.
+func (s *objectBackupStore) listLegacyBackupDirs() (map[string]string, error) {
+	dirs, err := s.objectStore.ListCommonPrefixes(s.bucket, s.layout.rootPrefix, "/")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make(map[string]string)
+	for _, dir := range dirs {
+		name := strings.TrimSuffix(strings.TrimPrefix(dir, s.layout.rootPrefix), "/")
+		if s.layout.isValidSubdir(name) {
+			continue
+		}
+
+		exists, err := s.objectStore.ObjectExists(s.bucket, path.Join(dir, arkBackupMetadataFile))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if exists {
+			res[name] = dir
+		}
+	}
+
+	return res, nil
+}
+
+// listLegacyBackups returns the names of the backups that are stored at the
+// top level of the backup store.
+func (s *objectBackupStore) listLegacyBackups() ([]string, error) {
+	if s.layout.legacyBackupDir == nil {
+		return nil, nil
+	}
+
+	dirs, err := s.listLegacyBackupDirs()
+	if err != nil {
+		return nil, err
+	}
+	s.legacyBackupDirs = dirs
+
+	var res []string
+	for name := range dirs {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
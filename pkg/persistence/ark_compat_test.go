@@ -0,0 +1,126 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/volume"
+)
+
+const arkBackupMetadata = `{
+	"apiVersion": "ark.heptio.com/v1",
+	"kind": "Backup",
+	"metadata": {
+		"namespace": "heptio-ark",
+		"name": "%s",
+		"uid": "uid-1",
+		"labels": {"ark.heptio.com/schedule-name": "daily"}
+	},
+	"spec": {"includedNamespaces": ["*"]},
+	"status": {
+		"phase": "Completed",
+		"volumeBackups": {
+			"pv-2": {"snapshotID": "snap-2", "type": "gp2", "availabilityZone": "us-east-1b"},
+			"pv-1": {"snapshotID": "snap-1", "type": "io1", "availabilityZone": "us-east-1a", "iops": 100}
+		}
+	}
+}`
+
+func TestArkBackups(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+	require.NoError(t, harness.setLayout("ark"))
+
+	put := func(key, data string) {
+		require.NoError(t, harness.objectStore.PutObject("test-bucket", key, bytes.NewReader([]byte(data))))
+	}
+
+	// a backup taken before Ark v0.10, at the top level of the backup store
+	put("ark/legacy/ark-backup.json", fmt.Sprintf(arkBackupMetadata, "legacy"))
+	put("ark/legacy/legacy.tar.gz", "contents")
+	// a backup taken by Ark v0.10, in the backups directory
+	put("ark/backups/ark/ark-backup.json", fmt.Sprintf(arkBackupMetadata, "ark"))
+	// a backup taken by Velero
+	put("ark/backups/velero/velero-backup.json", `{"apiVersion": "velero.io/v1", "kind": "Backup"}`)
+
+	assert.NoError(t, harness.IsValid())
+
+	backups, err := harness.ListBackups()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"legacy", "ark", "velero"}, backups)
+
+	for _, name := range []string{"legacy", "ark"} {
+		exists, err := harness.BackupExists("test-bucket", name)
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		backup, err := harness.GetBackupMetadata(name)
+		require.NoError(t, err)
+		assert.Equal(t, velerov1api.SchemeGroupVersion.String(), backup.APIVersion)
+		assert.Equal(t, name, backup.Name)
+		assert.Equal(t, map[string]string{velerov1api.ScheduleNameLabel: "daily"}, backup.Labels)
+		assert.Equal(t, velerov1api.BackupPhaseCompleted, backup.Status.Phase)
+	}
+
+	snapshots, err := harness.GetBackupVolumeSnapshots("legacy")
+	require.NoError(t, err)
+	iops := int64(100)
+	assert.Equal(t, []*volume.Snapshot{
+		{
+			Spec: volume.SnapshotSpec{
+				BackupName:           "legacy",
+				BackupUID:            "uid-1",
+				Location:             ArkVolumeSnapshotLocation,
+				PersistentVolumeName: "pv-1",
+				VolumeType:           "io1",
+				VolumeAZ:             "us-east-1a",
+				VolumeIOPS:           &iops,
+			},
+			Status: volume.SnapshotStatus{
+				ProviderSnapshotID: "snap-1",
+				Phase:              volume.SnapshotPhaseCompleted,
+			},
+		},
+		{
+			Spec: volume.SnapshotSpec{
+				BackupName:           "legacy",
+				BackupUID:            "uid-1",
+				Location:             ArkVolumeSnapshotLocation,
+				PersistentVolumeName: "pv-2",
+				VolumeType:           "gp2",
+				VolumeAZ:             "us-east-1b",
+			},
+			Status: volume.SnapshotStatus{
+				ProviderSnapshotID: "snap-2",
+				Phase:              volume.SnapshotPhaseCompleted,
+			},
+		},
+	}, snapshots)
+
+	contents, err := harness.GetBackupContents("legacy")
+	require.NoError(t, err)
+	contents.Close()
+
+	require.NoError(t, harness.DeleteBackup("legacy"))
+	assert.NotContains(t, harness.objectStore.Data["test-bucket"], "ark/legacy/ark-backup.json")
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+// AtBackupVersion returns a view of the backup store in which the named
+// backup's files are read as they were when the given version of its
+// metadata file was current, using the previous versions of objects that
+// the bucket keeps when it has versioning enabled. This makes it possible
+// to restore a backup that was later overwritten or deleted.
+func (s *objectBackupStore) AtBackupVersion(name, versionID string) (BackupStore, error) {
+	capabilities, err := s.getCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	if !capabilities.Versioning {
+		return nil, errors.New("object store doesn't support reading previous versions of objects")
+	}
+
+	metadataKey := s.layout.getBackupMetadataKey(name)
+
+	versions, err := s.objectStore.ListObjectVersions(s.bucket, metadataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// the backup's other files are read at their newest versions from
+	// before its metadata file was next written or deleted.
+	var (
+		found bool
+		until time.Time
+	)
+	for i, version := range versions {
+		if version.VersionID != versionID {
+			continue
+		}
+		if version.IsDeleteMarker {
+			return nil, newStoreError(ErrBackupNotFound, errors.Errorf("version %s of backup %s is a delete marker", versionID, name))
+		}
+		if i > 0 {
+			until = versions[i-1].LastModified
+		}
+		found = true
+		break
+	}
+	if !found {
+		return nil, newStoreError(ErrBackupNotFound, errors.Errorf("backup %s has no version %s; its versions are: %s", name, versionID, formatBackupVersions(versions)))
+	}
+
+	// the backup's files at this version mustn't be mixed up with the
+	// current ones in the metadata cache.
+	store := *s
+	store.metadataCache = nil
+	store.cacheRevision = nil
+	store.objectStore = &backupVersionObjectStore{
+		ObjectStore: s.objectStore,
+		metadataKey: metadataKey,
+		versionID:   versionID,
+		backupDir:   s.layout.getBackupDir(name),
+		chunksDir:   s.layout.subdirs["chunks"],
+		until:       until,
+		versions:    make(map[string]string),
+	}
+
+	return &store, nil
+}
+
+// formatBackupVersions lists the versions of a backup's metadata file, and
+// when each was written, for an error message.
+func formatBackupVersions(versions []velero.ObjectVersion) string {
+	var res []string
+	for _, version := range versions {
+		if version.IsDeleteMarker {
+			continue
+		}
+		res = append(res, fmt.Sprintf("%s (%s)", version.VersionID, version.LastModified.UTC().Format(time.RFC3339)))
+	}
+	if len(res) == 0 {
+		return "none"
+	}
+
+	return strings.Join(res, ", ")
+}
+
+// backupVersionObjectStore is an ObjectStore that reads the objects in a
+// backup's directory at the versions that were current when a given version
+// of its metadata file was. Deduplicated content chunks that have since been
+// deleted are read at their newest versions, since a chunk's key is the hash
+// of its data. All other objects are read and written as usual.
+type backupVersionObjectStore struct {
+	velero.ObjectStore
+
+	metadataKey string
+	versionID   string
+	backupDir   string
+	chunksDir   string
+
+	// until is when the metadata file was next written or deleted, or
+	// zero if the version is the current one.
+	until time.Time
+
+	// versions caches the version that each object in the backup's
+	// directory is read at, or an empty string if it didn't exist.
+	versions map[string]string
+}
+
+func (o *backupVersionObjectStore) ObjectExists(bucket, key string) (bool, error) {
+	switch {
+	case strings.HasPrefix(key, o.backupDir):
+		versionID, err := o.getVersion(bucket, key)
+		return versionID != "", err
+	case strings.HasPrefix(key, o.chunksDir):
+		exists, err := o.ObjectStore.ObjectExists(bucket, key)
+		if err != nil || exists {
+			return exists, err
+		}
+
+		versionID, err := o.getNewestVersion(bucket, key, time.Time{})
+		return versionID != "", err
+	default:
+		return o.ObjectStore.ObjectExists(bucket, key)
+	}
+}
+
+func (o *backupVersionObjectStore) GetObject(bucket, key string) (io.ReadCloser, error) {
+	var (
+		versionID string
+		err       error
+	)
+	switch {
+	case strings.HasPrefix(key, o.backupDir):
+		versionID, err = o.getVersion(bucket, key)
+	case strings.HasPrefix(key, o.chunksDir):
+		exists, existsErr := o.ObjectStore.ObjectExists(bucket, key)
+		if existsErr != nil {
+			return nil, errors.WithStack(existsErr)
+		}
+		if exists {
+			return o.ObjectStore.GetObject(bucket, key)
+		}
+
+		versionID, err = o.getNewestVersion(bucket, key, time.Time{})
+	default:
+		return o.ObjectStore.GetObject(bucket, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if versionID == "" {
+		return nil, errors.Errorf("object %s didn't exist at version %s of the backup", key, o.versionID)
+	}
+
+	return o.ObjectStore.GetObjectVersion(bucket, key, versionID)
+}
+
+// getVersion returns the version that an object in the backup's directory
+// is read at, or an empty string if it didn't exist then.
+func (o *backupVersionObjectStore) getVersion(bucket, key string) (string, error) {
+	if key == o.metadataKey {
+		return o.versionID, nil
+	}
+
+	if versionID, ok := o.versions[key]; ok {
+		return versionID, nil
+	}
+
+	versionID, err := o.getNewestVersion(bucket, key, o.until)
+	if err != nil {
+		return "", err
+	}
+
+	o.versions[key] = versionID
+	return versionID, nil
+}
+
+// getNewestVersion returns the newest version of an object, other than a
+// delete marker, that was written before until, or at any time if until is
+// zero. It returns an empty string if there isn't one.
+func (o *backupVersionObjectStore) getNewestVersion(bucket, key string, until time.Time) (string, error) {
+	versions, err := o.ObjectStore.ListObjectVersions(bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	for _, version := range versions {
+		if version.IsDeleteMarker {
+			continue
+		}
+		if !until.IsZero() && !version.LastModified.Before(until) {
+			continue
+		}
+		return version.VersionID, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/heptio/velero/pkg/cloudprovider"
+	"github.com/heptio/velero/pkg/plugin/velero"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+// versioningObjectStore is an in-memory object store that keeps every
+// version of each object, like a bucket with versioning enabled.
+type versioningObjectStore struct {
+	*cloudprovider.InMemoryObjectStore
+
+	now      time.Time
+	versions map[string][]velero.ObjectVersion
+	data     map[string][]byte
+}
+
+func newVersioningObjectStore(bucket string) *versioningObjectStore {
+	return &versioningObjectStore{
+		InMemoryObjectStore: cloudprovider.NewInMemoryObjectStore(bucket),
+		now:                 time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		versions:            make(map[string][]velero.ObjectVersion),
+		data:                make(map[string][]byte),
+	}
+}
+
+func (o *versioningObjectStore) addVersion(key string, data []byte) {
+	o.now = o.now.Add(time.Second)
+
+	version := velero.ObjectVersion{
+		VersionID:      fmt.Sprintf("v%d", len(o.data)+1),
+		LastModified:   o.now,
+		IsDeleteMarker: data == nil,
+	}
+	o.data[version.VersionID] = data
+	o.versions[key] = append([]velero.ObjectVersion{version}, o.versions[key]...)
+}
+
+func (o *versioningObjectStore) PutObject(bucket, key string, body io.Reader) error {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	o.addVersion(key, data)
+	return o.InMemoryObjectStore.PutObject(bucket, key, bytes.NewReader(data))
+}
+
+func (o *versioningObjectStore) DeleteObject(bucket, key string) error {
+	o.addVersion(key, nil)
+	return o.InMemoryObjectStore.DeleteObject(bucket, key)
+}
+
+func (o *versioningObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	var res []velero.ObjectVersion
+	for i, version := range o.versions[key] {
+		version.IsLatest = i == 0
+		res = append(res, version)
+	}
+	return res, nil
+}
+
+func (o *versioningObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	data, ok := o.data[versionID]
+	if !ok || data == nil {
+		return nil, errors.New("version not found")
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (o *versioningObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	return velero.ObjectStoreCapabilities{Versioning: true}, nil
+}
+
+func TestAtBackupVersion(t *testing.T) {
+	objectStore := newVersioningObjectStore("test-bucket")
+	store := &objectBackupStore{
+		objectStore: objectStore,
+		bucket:      "test-bucket",
+		layout:      NewObjectStoreLayout("velero"),
+		logger:      velerotest.NewLogger(),
+	}
+
+	putBackup := func(contents string) string {
+		require.NoError(t, store.PutBackup(BackupInfo{
+			Name:     "backup-1",
+			Metadata: strings.NewReader("metadata"),
+			Contents: strings.NewReader(contents),
+		}))
+		return objectStore.versions["velero/backups/backup-1/velero-backup.json"][0].VersionID
+	}
+
+	getContents := func(store BackupStore) string {
+		res, err := store.GetBackupContents("backup-1")
+		require.NoError(t, err)
+		defer res.Close()
+
+		data, err := ioutil.ReadAll(res)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	first := putBackup("contents-1")
+	second := putBackup("contents-2")
+
+	// the backup is overwritten, then deleted, but its versions are kept.
+	require.NoError(t, store.DeleteBackup("backup-1"))
+	exists, err := store.BackupExists("test-bucket", "backup-1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	firstStore, err := store.AtBackupVersion("backup-1", first)
+	require.NoError(t, err)
+	assert.Equal(t, "contents-1", getContents(firstStore))
+
+	secondStore, err := store.AtBackupVersion("backup-1", second)
+	require.NoError(t, err)
+	assert.Equal(t, "contents-2", getContents(secondStore))
+
+	exists, err = secondStore.BackupExists("test-bucket", "backup-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// the backup's files that didn't exist at that version aren't found.
+	snapshots, err := secondStore.GetBackupVolumeSnapshots("backup-1")
+	require.NoError(t, err)
+	assert.Nil(t, snapshots)
+
+	_, err = store.AtBackupVersion("backup-1", "v100")
+	assert.EqualError(t, err, fmt.Sprintf("backup backup-1 has no version v100; its versions are: %s (2019-01-01T00:00:04Z), %s (2019-01-01T00:00:01Z)", second, first))
+}
+
+func TestAtBackupVersionWithoutVersioning(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+
+	_, err := harness.AtBackupVersion("backup-1", "v1")
+	assert.EqualError(t, err, "object store doesn't support reading previous versions of objects")
+}
@@ -0,0 +1,361 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// contentsIndex lists, in order, the hashes of the chunks that a
+// deduplicated backup's uncompressed tarball is made of.
+type contentsIndex struct {
+	Chunks []string `json:"chunks"`
+}
+
+// chunkingConfig holds the sizes used to split a backup's tarball into
+// chunks. A chunk boundary is placed after at least MinSize bytes where
+// the rolling hash of the contents matches Mask, or after MaxSize bytes,
+// so the average chunk size is roughly MinSize + Mask + 1.
+type chunkingConfig struct {
+	MinSize int
+	MaxSize int
+	Mask    uint64
+}
+
+// defaultChunkingConfig produces chunks of about 1MiB.
+var defaultChunkingConfig = chunkingConfig{
+	MinSize: 256 << 10,
+	MaxSize: 4 << 20,
+	Mask:    1<<20 - 1,
+}
+
+// gearTable maps each byte to a pseudo-random value for the rolling gear
+// hash. It must never change, since chunk boundaries, and so which chunks
+// are shared between backups, depend on it.
+var gearTable = func() [256]uint64 {
+	var (
+		table [256]uint64
+		state uint64
+	)
+	// splitmix64
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// chunker splits a stream into content-defined chunks, so that inserting
+// or removing data only changes the chunks around the change.
+type chunker struct {
+	r      *bufio.Reader
+	config chunkingConfig
+}
+
+func newChunker(r io.Reader, config chunkingConfig) *chunker {
+	return &chunker{
+		r:      bufio.NewReader(r),
+		config: config,
+	}
+}
+
+// next returns the next chunk of the stream, or io.EOF if there are no
+// more chunks.
+func (c *chunker) next() ([]byte, error) {
+	var (
+		chunk []byte
+		hash  uint64
+	)
+	for {
+		b, err := c.r.ReadByte()
+		if err == io.EOF {
+			if len(chunk) == 0 {
+				return nil, io.EOF
+			}
+			return chunk, nil
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		chunk = append(chunk, b)
+		hash = (hash << 1) + gearTable[b]
+
+		if len(chunk) >= c.config.MaxSize || (len(chunk) >= c.config.MinSize && hash&c.config.Mask == 0) {
+			return chunk, nil
+		}
+	}
+}
+
+func chunkHash(chunk []byte) string {
+	sum := sha256.Sum256(chunk)
+	return hex.EncodeToString(sum[:])
+}
+
+// putDeduplicatedContents uploads a backup's gzipped tarball as chunks of
+// the uncompressed tarball, skipping the chunks that are already in the
+// backup store, and the index that lists them. The index is uploaded
+// before the chunks so that they're never unreferenced while the backup
+// exists.
+func (s *objectBackupStore) putDeduplicatedContents(name string, contents io.Reader) error {
+	if err := seekToBeginning(contents); err != nil {
+		return errors.WithStack(err)
+	}
+
+	gzr, err := gzip.NewReader(contents)
+	if err != nil {
+		return errors.Wrap(err, "error reading backup contents")
+	}
+	defer gzr.Close()
+
+	// the tarball is chunked twice, once to build the index and once to
+	// upload the chunks, so keep an uncompressed copy of it.
+	tmpFile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return errors.Wrap(err, "error creating temp file")
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	index := new(contentsIndex)
+	chunks := newChunker(io.TeeReader(gzr, tmpFile), s.chunking)
+	for {
+		chunk, err := chunks.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "error chunking backup contents")
+		}
+		index.Chunks = append(index.Chunks, chunkHash(chunk))
+	}
+
+	indexBuf := new(bytes.Buffer)
+	gzw := gzip.NewWriter(indexBuf)
+	if err := json.NewEncoder(gzw).Encode(index); err != nil {
+		return errors.Wrap(err, "error encoding backup contents index")
+	}
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "error closing gzip writer")
+	}
+	if err := s.objectStore.PutObject(s.bucket, s.layout.getBackupContentsIndexKey(name), indexBuf); err != nil {
+		return err
+	}
+
+	if _, err := tmpFile.Seek(0, 0); err != nil {
+		return errors.WithStack(err)
+	}
+
+	uploaded := sets.NewString()
+	chunks = newChunker(tmpFile, s.chunking)
+	for {
+		chunk, err := chunks.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "error chunking backup contents")
+		}
+
+		hash := chunkHash(chunk)
+		if uploaded.Has(hash) {
+			continue
+		}
+		uploaded.Insert(hash)
+
+		if err := s.putChunk(hash, chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// putChunk uploads a gzipped chunk if the backup store doesn't already
+// have it.
+func (s *objectBackupStore) putChunk(hash string, chunk []byte) error {
+	key := s.layout.getChunkKey(hash)
+
+	exists, err := s.objectStore.ObjectExists(s.bucket, key)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if exists {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	gzw := gzip.NewWriter(buf)
+	if _, err := gzw.Write(chunk); err != nil {
+		return errors.Wrap(err, "error compressing chunk")
+	}
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "error closing gzip writer")
+	}
+
+	return s.objectStore.PutObject(s.bucket, key, buf)
+}
+
+// getContentsIndex returns the index of a deduplicated backup's contents,
+// or nil if the backup's contents are stored as a tarball.
+func (s *objectBackupStore) getContentsIndex(name string) (*contentsIndex, error) {
+	res, err := tryGet(s.objectStore, s.bucket, s.layout.getBackupContentsIndexKey(name))
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, nil
+	}
+	defer res.Close()
+
+	index := new(contentsIndex)
+	if err := decode(res, index); err != nil {
+		return nil, newStoreError(ErrBackupCorrupt, err)
+	}
+
+	return index, nil
+}
+
+// getDeduplicatedContents reassembles a deduplicated backup's gzipped
+// tarball from its chunks as it's read.
+func (s *objectBackupStore) getDeduplicatedContents(index *contentsIndex) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		gzw := gzip.NewWriter(pw)
+		for _, hash := range index.Chunks {
+			if err := s.copyChunk(gzw, hash); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(errors.Wrap(gzw.Close(), "error closing gzip writer"))
+	}()
+
+	return pr
+}
+
+func (s *objectBackupStore) copyChunk(w io.Writer, hash string) error {
+	res, err := s.objectStore.GetObject(s.bucket, s.layout.getChunkKey(hash))
+	if err != nil {
+		return errors.Wrapf(err, "error getting chunk %s", hash)
+	}
+	defer res.Close()
+
+	gzr, err := gzip.NewReader(res)
+	if err != nil {
+		return errors.Wrapf(err, "error reading chunk %s", hash)
+	}
+	defer gzr.Close()
+
+	if _, err := io.Copy(w, gzr); err != nil {
+		return errors.Wrapf(err, "error reading chunk %s", hash)
+	}
+
+	return nil
+}
+
+// contentsKey returns the key of the object that's written last when
+// uploading a backup's contents.
+func (s *objectBackupStore) contentsKey(name string) string {
+	if s.deduplicate {
+		return s.layout.getBackupContentsIndexKey(name)
+	}
+	return s.layout.getBackupContentsKey(name)
+}
+
+func (s *objectBackupStore) ListUnreferencedChunks() ([]string, error) {
+	keys, err := s.objectStore.ListObjects(s.bucket, s.layout.getChunksDir())
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	// the chunks are listed before the backups so that the chunks of
+	// backups that are uploaded in the meantime are left alone.
+	referenced, err := s.referencedChunks()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, key := range keys {
+		hash := path.Base(key)
+		if !referenced.Has(hash) {
+			res = append(res, hash)
+		}
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
+
+// referencedChunks returns the hashes of the chunks that the indexes of
+// the deduplicated backups in the backup store reference.
+func (s *objectBackupStore) referencedChunks() (sets.String, error) {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := sets.NewString()
+	for _, backup := range backups {
+		index, err := s.getContentsIndex(backup)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting contents index for backup %s", backup)
+		}
+		if index != nil {
+			referenced.Insert(index.Chunks...)
+		}
+	}
+
+	return referenced, nil
+}
+
+func (s *objectBackupStore) DeleteChunk(hash string) error {
+	// a backup that was uploaded after the chunk was listed as
+	// unreferenced may have reused it rather than uploading it again, so
+	// the backups' references are checked again right before deleting it.
+	referenced, err := s.referencedChunks()
+	if err != nil {
+		return err
+	}
+	if referenced.Has(hash) {
+		s.logger.WithField("chunk", hash).Info("Not deleting chunk because a backup references it")
+		return nil
+	}
+
+	return s.objectStore.DeleteObject(s.bucket, s.layout.getChunkKey(hash))
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+var testChunkingConfig = chunkingConfig{
+	MinSize: 64,
+	MaxSize: 1024,
+	Mask:    1<<8 - 1,
+}
+
+func randomData(seed int64, n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+func gzipData(t *testing.T, data []byte) *bytes.Reader {
+	buf := new(bytes.Buffer)
+	gzw := gzip.NewWriter(buf)
+	_, err := gzw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gzw.Close())
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func chunkAll(t *testing.T, data []byte) [][]byte {
+	var res [][]byte
+	c := newChunker(bytes.NewReader(data), testChunkingConfig)
+	for {
+		chunk, err := c.next()
+		if err == io.EOF {
+			return res
+		}
+		require.NoError(t, err)
+		res = append(res, chunk)
+	}
+}
+
+func TestChunker(t *testing.T) {
+	data := randomData(1, 64*1024)
+
+	chunks := chunkAll(t, data)
+	assert.Equal(t, data, bytes.Join(chunks, nil))
+	for i, chunk := range chunks {
+		assert.True(t, len(chunk) <= testChunkingConfig.MaxSize)
+		if i < len(chunks)-1 {
+			assert.True(t, len(chunk) >= testChunkingConfig.MinSize)
+		}
+	}
+
+	// inserting data only changes the chunks around the insertion.
+	edited := append(append(append([]byte{}, data[:32*1024]...), []byte("inserted")...), data[32*1024:]...)
+	editedHashes := make(map[string]bool)
+	for _, chunk := range chunkAll(t, edited) {
+		editedHashes[chunkHash(chunk)] = true
+	}
+
+	var shared int
+	for _, chunk := range chunks {
+		if editedHashes[chunkHash(chunk)] {
+			shared++
+		}
+	}
+	assert.True(t, shared >= len(chunks)-2, "%d of %d chunks are shared", shared, len(chunks))
+}
+
+func TestDeduplicatedBackupContents(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+	harness.deduplicate = true
+	harness.chunking = testChunkingConfig
+
+	data := randomData(1, 64*1024)
+	require.NoError(t, harness.PutBackup(BackupInfo{
+		Name:     "backup-1",
+		Metadata: newStringReadSeeker("metadata"),
+		Contents: gzipData(t, data),
+	}))
+
+	assert.NotContains(t, harness.objectStore.Data["test-bucket"], "backups/backup-1/backup-1.tar.gz")
+	index, err := harness.getContentsIndex("backup-1")
+	require.NoError(t, err)
+	require.NotNil(t, index)
+	chunks, err := harness.objectStore.ListObjects("test-bucket", "chunks/")
+	require.NoError(t, err)
+
+	rc, err := harness.GetBackupContents("backup-1")
+	require.NoError(t, err)
+	gzr, err := gzip.NewReader(rc)
+	require.NoError(t, err)
+	res, err := ioutil.ReadAll(gzr)
+	require.NoError(t, err)
+	assert.Equal(t, data, res)
+
+	// a second backup with mostly the same contents reuses the chunks.
+	data2 := append(append([]byte{}, data...), randomData(2, 1024)...)
+	require.NoError(t, harness.PutBackup(BackupInfo{
+		Name:     "backup-2",
+		Metadata: newStringReadSeeker("metadata"),
+		Contents: gzipData(t, data2),
+	}))
+	allChunks, err := harness.objectStore.ListObjects("test-bucket", "chunks/")
+	require.NoError(t, err)
+	newChunks := len(allChunks) - len(chunks)
+	assert.True(t, newChunks > 0 && newChunks < len(chunks)/10, "%d new chunks for %d existing chunks", newChunks, len(chunks))
+
+	unreferenced, err := harness.ListUnreferencedChunks()
+	require.NoError(t, err)
+	assert.Empty(t, unreferenced)
+
+	require.NoError(t, harness.DeleteBackup("backup-2"))
+	unreferenced, err = harness.ListUnreferencedChunks()
+	require.NoError(t, err)
+	assert.Len(t, unreferenced, newChunks)
+
+	_, err = harness.GetDownloadURL(velerov1api.DownloadTarget{Kind: velerov1api.DownloadTargetKindBackupContents, Name: "backup-1"}, DefaultDownloadURLTTL)
+	assert.EqualError(t, err, "download URLs are not supported for the contents of deduplicated backup backup-1")
+}
+
+func TestDeleteChunkReusedByLaterBackup(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+	harness.deduplicate = true
+	harness.chunking = testChunkingConfig
+
+	data := randomData(1, 16*1024)
+	require.NoError(t, harness.PutBackup(BackupInfo{
+		Name:     "backup-1",
+		Metadata: newStringReadSeeker("metadata"),
+		Contents: gzipData(t, data),
+	}))
+	require.NoError(t, harness.DeleteBackup("backup-1"))
+
+	// the GC lists the chunks as unreferenced...
+	unreferenced, err := harness.ListUnreferencedChunks()
+	require.NoError(t, err)
+	require.NotEmpty(t, unreferenced)
+
+	// ...then a backup with the same contents reuses them before they're
+	// deleted...
+	require.NoError(t, harness.PutBackup(BackupInfo{
+		Name:     "backup-2",
+		Metadata: newStringReadSeeker("metadata"),
+		Contents: gzipData(t, data),
+	}))
+
+	// ...so the GC must leave them alone.
+	for _, hash := range unreferenced {
+		require.NoError(t, harness.DeleteChunk(hash))
+	}
+
+	chunks, err := harness.objectStore.ListObjects("test-bucket", "chunks/")
+	require.NoError(t, err)
+	assert.Len(t, chunks, len(unreferenced))
+
+	rc, err := harness.GetBackupContents("backup-2")
+	require.NoError(t, err)
+	gzr, err := gzip.NewReader(rc)
+	require.NoError(t, err)
+	res, err := ioutil.ReadAll(gzr)
+	require.NoError(t, err)
+	assert.Equal(t, data, res)
+
+	// once no backup references them, they're deleted.
+	require.NoError(t, harness.DeleteBackup("backup-2"))
+	for _, hash := range unreferenced {
+		require.NoError(t, harness.DeleteChunk(hash))
+	}
+	chunks, err = harness.objectStore.ListObjects("test-bucket", "chunks/")
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+// These are the kinds of errors that BackupStore methods return, so that
+// callers can tell them apart with Is, e.g. to skip a backup that doesn't
+// exist but retry when the backup store can't be reached. Errors that
+// aren't any of these kinds are returned as they are.
+var (
+	// ErrBackupNotFound means the backup doesn't exist in the backup store.
+	ErrBackupNotFound = errors.New("backup not found")
+
+	// ErrBackupCorrupt means one of the backup's files exists but can't
+	// be read, e.g. because it isn't valid gzipped JSON.
+	ErrBackupCorrupt = errors.New("backup is corrupt")
+
+	// ErrStoreUnavailable means the backup store couldn't be reached or
+	// didn't respond in time. Retrying later may succeed.
+	ErrStoreUnavailable = errors.New("backup store is unavailable")
+
+	// ErrPermissionDenied means the backup store rejected the location's
+	// credentials, or they aren't allowed to make the request.
+	ErrPermissionDenied = errors.New("permission denied by backup store")
+)
+
+// Is returns true if err, or any error that it wraps, is of the given
+// kind, which is one of the Err* variables in this package.
+func Is(err, kind error) bool {
+	for err != nil {
+		if err == kind {
+			return true
+		}
+		if e, ok := err.(*storeError); ok && e.kind == kind {
+			return true
+		}
+
+		cause, ok := err.(interface{ Cause() error })
+		if !ok {
+			return false
+		}
+		err = cause.Cause()
+	}
+
+	return false
+}
+
+// IsTransient returns true if err means that the request to the backup
+// store failed, rather than that the backup is missing or corrupt, so it
+// should be retried later.
+func IsTransient(err error) bool {
+	return Is(err, ErrStoreUnavailable) || Is(err, ErrPermissionDenied)
+}
+
+// storeError is an error of one of the kinds that BackupStore methods
+// return. Its message is the one of the error it wraps, so classifying
+// an error doesn't change how it's logged.
+type storeError struct {
+	kind error
+	err  error
+}
+
+func newStoreError(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &storeError{kind: kind, err: err}
+}
+
+func (e *storeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *storeError) Cause() error {
+	return e.err
+}
+
+// Well-known parts of the messages of errors that object storage services
+// return, for plugins that don't return gRPC status codes. These are the
+// error codes of AWS S3, Azure Blob Storage and Google Cloud Storage, and
+// the way their SDKs format HTTP status codes.
+var (
+	permissionDeniedMessages = []string{
+		"AccessDenied",
+		"AuthenticationFailed",
+		"AuthorizationFailure",
+		"ExpiredToken",
+		"Forbidden",
+		"InvalidAccessKeyId",
+		"SignatureDoesNotMatch",
+		"status code: 401",
+		"status code: 403",
+		"StatusCode=403",
+		"Error 401",
+		"Error 403",
+	}
+
+	storeUnavailableMessages = []string{
+		"RequestTimeout",
+		"ServerBusy",
+		"ServiceUnavailable",
+		"SlowDown",
+		"status code: 503",
+		"StatusCode=503",
+		"Error 503",
+		"connection refused",
+		"connection reset by peer",
+		"i/o timeout",
+		"no such host",
+		"TLS handshake timeout",
+	}
+)
+
+// classifyError returns err as an error of the kind it is, if it's a
+// request to the object store that failed because the store couldn't be
+// reached or because it denied permission, or err unchanged otherwise.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if kind := errorKind(err); kind != nil {
+		return newStoreError(kind, err)
+	}
+
+	return err
+}
+
+func errorKind(err error) error {
+	// check the gRPC status codes and Go errors of err and each error it
+	// wraps first, since they're the most reliable.
+	for e := err; e != nil; {
+		if _, ok := e.(*storeError); ok {
+			return nil
+		}
+
+		if s, ok := e.(interface{ GRPCStatus() *status.Status }); ok && s.GRPCStatus() != nil {
+			switch s.GRPCStatus().Code() {
+			case codes.PermissionDenied, codes.Unauthenticated:
+				return ErrPermissionDenied
+			case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+				return ErrStoreUnavailable
+			}
+		}
+
+		if os.IsPermission(e) {
+			return ErrPermissionDenied
+		}
+		if netErr, ok := e.(net.Error); ok && (netErr.Timeout() || netErr.Temporary()) {
+			return ErrStoreUnavailable
+		}
+
+		cause, ok := e.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		e = cause.Cause()
+	}
+
+	msg := err.Error()
+	for _, s := range permissionDeniedMessages {
+		if strings.Contains(msg, s) {
+			return ErrPermissionDenied
+		}
+	}
+	for _, s := range storeUnavailableMessages {
+		if strings.Contains(msg, s) {
+			return ErrStoreUnavailable
+		}
+	}
+
+	return nil
+}
+
+// classifyingObjectStore is an ObjectStore that classifies the errors of
+// all calls that make requests to object storage with classifyError.
+type classifyingObjectStore struct {
+	velero.ObjectStore
+}
+
+func (o *classifyingObjectStore) PutObject(bucket, key string, body io.Reader) error {
+	return classifyError(o.ObjectStore.PutObject(bucket, key, body))
+}
+
+func (o *classifyingObjectStore) ObjectExists(bucket, key string) (bool, error) {
+	exists, err := o.ObjectStore.ObjectExists(bucket, key)
+	return exists, classifyError(err)
+}
+
+func (o *classifyingObjectStore) GetObject(bucket, key string) (io.ReadCloser, error) {
+	res, err := o.ObjectStore.GetObject(bucket, key)
+	return res, classifyError(err)
+}
+
+func (o *classifyingObjectStore) ListCommonPrefixes(bucket, prefix, delimiter string) ([]string, error) {
+	prefixes, err := o.ObjectStore.ListCommonPrefixes(bucket, prefix, delimiter)
+	return prefixes, classifyError(err)
+}
+
+func (o *classifyingObjectStore) ListObjects(bucket, prefix string) ([]string, error) {
+	objects, err := o.ObjectStore.ListObjects(bucket, prefix)
+	return objects, classifyError(err)
+}
+
+func (o *classifyingObjectStore) DeleteObject(bucket, key string) error {
+	return classifyError(o.ObjectStore.DeleteObject(bucket, key))
+}
+
+func (o *classifyingObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	return classifyError(o.ObjectStore.CopyObject(bucket, srcKey, destKey))
+}
+
+func (o *classifyingObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	versions, err := o.ObjectStore.ListObjectVersions(bucket, key)
+	return versions, classifyError(err)
+}
+
+func (o *classifyingObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	res, err := o.ObjectStore.GetObjectVersion(bucket, key, versionID)
+	return res, classifyError(err)
+}
+
+func (o *classifyingObjectStore) LockObject(bucket, key string, until time.Time) error {
+	return classifyError(o.ObjectStore.LockObject(bucket, key, until))
+}
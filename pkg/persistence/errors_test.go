@@ -0,0 +1,124 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/heptio/velero/pkg/cloudprovider"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{
+			name: "gRPC permission denied",
+			err:  status.Error(codes.PermissionDenied, "denied"),
+			kind: ErrPermissionDenied,
+		},
+		{
+			name: "wrapped gRPC unavailable",
+			err:  errors.Wrap(status.Error(codes.Unavailable, "unavailable"), "error getting object"),
+			kind: ErrStoreUnavailable,
+		},
+		{
+			name: "filesystem permission error",
+			err:  &os.PathError{Op: "open", Path: "/backups", Err: os.ErrPermission},
+			kind: ErrPermissionDenied,
+		},
+		{
+			name: "S3 access denied",
+			err:  errors.New("rpc error: code = Unknown desc = AccessDenied: Access Denied\n\tstatus code: 403, request id: 123"),
+			kind: ErrPermissionDenied,
+		},
+		{
+			name: "connection refused",
+			err:  errors.New("rpc error: code = Unknown desc = dial tcp 10.0.0.1:9000: connect: connection refused"),
+			kind: ErrStoreUnavailable,
+		},
+		{
+			name: "other errors aren't classified",
+			err:  errors.New("key not found"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := classifyError(tc.err)
+
+			// classifying an error doesn't change its message.
+			assert.Equal(t, tc.err.Error(), err.Error())
+
+			for _, kind := range []error{ErrBackupNotFound, ErrBackupCorrupt, ErrStoreUnavailable, ErrPermissionDenied} {
+				assert.Equal(t, kind == tc.kind, Is(err, kind), "Is(err, %v)", kind)
+				assert.Equal(t, kind == tc.kind, Is(errors.Wrap(err, "error syncing"), kind), "Is(wrapped err, %v)", kind)
+			}
+			assert.Equal(t, tc.kind != nil, IsTransient(err))
+		})
+	}
+
+	assert.Nil(t, classifyError(nil))
+}
+
+type unavailableObjectStore struct {
+	*cloudprovider.InMemoryObjectStore
+}
+
+func (o *unavailableObjectStore) GetObject(bucket, key string) (io.ReadCloser, error) {
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+func TestBackupStoreErrors(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+	harness.objectBackupStore.objectStore = &classifyingObjectStore{ObjectStore: harness.objectStore}
+
+	_, err := harness.GetBackupMetadata("missing")
+	assert.True(t, Is(err, ErrBackupNotFound))
+	assert.False(t, IsTransient(err))
+
+	_, err = harness.GetBackupContents("missing")
+	assert.True(t, Is(err, ErrBackupNotFound))
+
+	require.NoError(t, harness.objectStore.PutObject(harness.bucket, "backups/corrupt/velero-backup.json", newStringReadSeeker("foo")))
+	_, err = harness.GetBackupMetadata("corrupt")
+	assert.True(t, Is(err, ErrBackupCorrupt))
+
+	require.NoError(t, harness.objectStore.PutObject(harness.bucket, "backups/corrupt/corrupt-podvolumebackups.json.gz", newStringReadSeeker("foo")))
+	_, err = harness.GetPodVolumeBackups("corrupt")
+	assert.True(t, Is(err, ErrBackupCorrupt))
+
+	// backups that can't be read because the store is unavailable aren't
+	// reported as missing.
+	harness.objectBackupStore.objectStore = &classifyingObjectStore{
+		ObjectStore: &unavailableObjectStore{InMemoryObjectStore: harness.objectStore},
+	}
+	_, err = harness.GetBackupMetadata("missing")
+	assert.True(t, Is(err, ErrStoreUnavailable))
+	assert.False(t, Is(err, ErrBackupNotFound))
+	assert.True(t, IsTransient(err))
+}
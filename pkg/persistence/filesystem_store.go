@@ -0,0 +1,270 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+// NewBackupStore returns a BackupStore for the given location, using
+// the location's storage type to determine which implementation to use.
+func NewBackupStore(location *velerov1api.BackupStorageLocation, objectStoreGetter ObjectStoreGetter, logger logrus.FieldLogger) (BackupStore, error) {
+	if location.Spec.Filesystem != nil {
+		return NewFilesystemBackupStore(location, logger)
+	}
+
+	return NewObjectBackupStore(location, objectStoreGetter, logger)
+}
+
+// filesystemBackupStore is a BackupStore that persists backups to a
+// directory on a mounted filesystem (e.g. a PVC or NFS share), using
+// the same layout as an object storage bucket.
+type filesystemBackupStore struct {
+	*objectBackupStore
+}
+
+// NewFilesystemBackupStore returns a BackupStore for a location whose
+// storage type is a mounted filesystem.
+func NewFilesystemBackupStore(location *velerov1api.BackupStorageLocation, logger logrus.FieldLogger) (BackupStore, error) {
+	if location.Spec.Filesystem == nil {
+		return nil, errors.New("backup storage location does not use a filesystem")
+	}
+
+	root := filepath.Clean(location.Spec.Filesystem.Path)
+	if !filepath.IsAbs(root) {
+		return nil, errors.Errorf("backup storage location's filesystem path %q must be absolute", location.Spec.Filesystem.Path)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error accessing backup storage location's filesystem path %q", root)
+	}
+	if !info.IsDir() {
+		return nil, errors.Errorf("backup storage location's filesystem path %q is not a directory", root)
+	}
+
+	prefix := strings.Trim(location.Spec.Filesystem.Prefix, "/")
+
+	log := logger.WithFields(logrus.Fields(map[string]interface{}{
+		"path":   root,
+		"prefix": prefix,
+	}))
+
+	s := &objectBackupStore{
+		objectStore: &classifyingObjectStore{ObjectStore: &filesystemObjectStore{root: root}},
+		bucket:      root,
+		logger:      log,
+		deduplicate: location.Spec.Deduplication,
+		chunking:    defaultChunkingConfig,
+	}
+	if err := s.setLayout(prefix); err != nil {
+		return nil, err
+	}
+
+	return &filesystemBackupStore{objectBackupStore: s}, nil
+}
+
+func (s *filesystemBackupStore) BackupExists(bucket, backupName string) (bool, error) {
+	// the bucket argument is meaningless for a filesystem store, so
+	// always check within the store's own root directory.
+	return s.objectBackupStore.BackupExists(s.bucket, backupName)
+}
+
+func (s *filesystemBackupStore) GetDownloadURL(target velerov1api.DownloadTarget, ttl time.Duration) (string, error) {
+	return "", errors.Errorf("download URLs are not supported for filesystem backup storage locations (target %s/%s)", target.Kind, target.Name)
+}
+
+// filesystemObjectStore adapts a directory tree to the velero.ObjectStore
+// interface so that the object store layout can be reused. Keys are
+// slash-separated paths relative to the root directory; the bucket argument
+// to each method is ignored.
+type filesystemObjectStore struct {
+	root string
+}
+
+func (o *filesystemObjectStore) path(key string) string {
+	return filepath.Join(o.root, filepath.FromSlash(key))
+}
+
+func (o *filesystemObjectStore) Init(config map[string]string) error {
+	return nil
+}
+
+func (o *filesystemObjectStore) PutObject(bucket, key string, body io.Reader) error {
+	path := o.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// write to a temp file and rename it into place so that readers never
+	// observe a partially-written object.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "error writing %s", key)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.WithStack(err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (o *filesystemObjectStore) ObjectExists(bucket, key string) (bool, error) {
+	info, err := os.Stat(o.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return !info.IsDir(), nil
+}
+
+func (o *filesystemObjectStore) GetObject(bucket, key string) (io.ReadCloser, error) {
+	f, err := os.Open(o.path(key))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return f, nil
+}
+
+func (o *filesystemObjectStore) ListCommonPrefixes(bucket, prefix, delimiter string) ([]string, error) {
+	keys, err := o.ListObjects(bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var prefixes []string
+	for _, key := range keys {
+		afterPrefix := key[len(prefix):]
+
+		delimiterStart := strings.Index(afterPrefix, delimiter)
+		if delimiterStart == -1 {
+			continue
+		}
+
+		fullPrefix := prefix + afterPrefix[0:delimiterStart] + delimiter
+		if !seen[fullPrefix] {
+			seen[fullPrefix] = true
+			prefixes = append(prefixes, fullPrefix)
+		}
+	}
+
+	return prefixes, nil
+}
+
+func (o *filesystemObjectStore) ListObjects(bucket, prefix string) ([]string, error) {
+	var keys []string
+
+	err := filepath.Walk(o.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(o.root, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (o *filesystemObjectStore) DeleteObject(bucket, key string) error {
+	path := o.path(key)
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error deleting object %s", key)
+	}
+
+	// clean up any directories left empty by the delete, stopping at the root
+	for dir := filepath.Dir(path); dir != o.root && strings.HasPrefix(dir, o.root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			// directory is not empty, or can't be removed
+			break
+		}
+	}
+
+	return nil
+}
+
+func (o *filesystemObjectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (string, error) {
+	return "", errors.New("signed URLs are not supported for filesystem storage")
+}
+
+func (o *filesystemObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	return errors.New("server-side copy is not supported for filesystem storage")
+}
+
+func (o *filesystemObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	return nil, errors.New("object versioning is not supported for filesystem storage")
+}
+
+func (o *filesystemObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	return nil, errors.New("object versioning is not supported for filesystem storage")
+}
+
+func (o *filesystemObjectStore) LockObject(bucket, key string, until time.Time) error {
+	return errors.New("object lock is not supported for filesystem storage")
+}
+
+func (o *filesystemObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	return velero.ObjectStoreCapabilities{}, nil
+}
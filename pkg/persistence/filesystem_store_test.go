@@ -0,0 +1,119 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func newFilesystemLocation(path, prefix string) *velerov1api.BackupStorageLocation {
+	return &velerov1api.BackupStorageLocation{
+		Spec: velerov1api.BackupStorageLocationSpec{
+			StorageType: velerov1api.StorageType{
+				Filesystem: &velerov1api.FilesystemLocation{
+					Path:   path,
+					Prefix: prefix,
+				},
+			},
+		},
+	}
+}
+
+func TestNewFilesystemBackupStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "velero-fs-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewFilesystemBackupStore(newFilesystemLocation("relative/path", ""), velerotest.NewLogger())
+	assert.EqualError(t, err, `backup storage location's filesystem path "relative/path" must be absolute`)
+
+	_, err = NewFilesystemBackupStore(newFilesystemLocation(filepath.Join(dir, "missing"), ""), velerotest.NewLogger())
+	assert.Error(t, err)
+
+	store, err := NewBackupStore(newFilesystemLocation(dir, ""), nil, velerotest.NewLogger())
+	require.NoError(t, err)
+	assert.IsType(t, &filesystemBackupStore{}, store)
+}
+
+func TestFilesystemBackupStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "velero-fs-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFilesystemBackupStore(newFilesystemLocation(dir, "cluster-1"), velerotest.NewLogger())
+	require.NoError(t, err)
+
+	require.NoError(t, store.IsValid())
+
+	backups, err := store.ListBackups()
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+
+	for _, name := range []string{"backup-1", "backup-2"} {
+		require.NoError(t, store.PutBackup(BackupInfo{
+			Name:     name,
+			Metadata: strings.NewReader("metadata"),
+			Contents: strings.NewReader("contents-" + name),
+			Log:      strings.NewReader("log"),
+		}))
+	}
+
+	// same layout as an object storage bucket
+	_, err = os.Stat(filepath.Join(dir, "cluster-1", "backups", "backup-1", "velero-backup.json"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "cluster-1", "metadata", "revision"))
+	require.NoError(t, err)
+
+	require.NoError(t, store.IsValid())
+
+	backups, err = store.ListBackups()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup-1", "backup-2"}, backups)
+
+	exists, err := store.BackupExists("ignored", "backup-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	rdr, err := store.GetBackupContents("backup-2")
+	require.NoError(t, err)
+	contents, err := ioutil.ReadAll(rdr)
+	rdr.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "contents-backup-2", string(contents))
+
+	require.NoError(t, store.DeleteBackup("backup-1"))
+
+	backups, err = store.ListBackups()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup-2"}, backups)
+
+	_, err = os.Stat(filepath.Join(dir, "cluster-1", "backups", "backup-1"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = store.GetDownloadURL(velerov1api.DownloadTarget{Kind: velerov1api.DownloadTargetKindBackupLog, Name: "backup-2"}, DefaultDownloadURLTTL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import "sort"
+
+// BackupItemIndex records, for each item in a backup, a hash of the item
+// and the backup whose tarball contains the item's content. It's keyed by
+// the path of the item's file in the backup tarball.
+//
+// An item that hadn't changed since the previous backup of a schedule
+// that skips unchanged items isn't written to the backup's tarball, and
+// its entry refers to an earlier backup instead.
+type BackupItemIndex map[string]BackupItemIndexEntry
+
+// BackupItemIndexEntry is an entry in a BackupItemIndex.
+type BackupItemIndexEntry struct {
+	// Hash is the hash of the item's content, as computed by the
+	// backup's item comparer.
+	Hash string `json:"hash"`
+
+	// Backup is the name of the backup whose tarball contains the
+	// item's content.
+	Backup string `json:"backup"`
+}
+
+// References returns the paths of the items whose content is stored in
+// other backups' tarballs than the named backup's, grouped by the backup
+// that contains them.
+func (i BackupItemIndex) References(backup string) map[string][]string {
+	res := make(map[string][]string)
+	for path, entry := range i {
+		if entry.Backup == backup {
+			continue
+		}
+		res[entry.Backup] = append(res[entry.Backup], path)
+	}
+
+	for _, paths := range res {
+		sort.Strings(paths)
+	}
+
+	return res
+}
+
+// ReferencedBackups returns the sorted names of the backups, other than
+// the named backup, that contain the content of items in the index.
+func (i BackupItemIndex) ReferencedBackups(backup string) []string {
+	var res []string
+	for name := range i.References(backup) {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LayoutVersion is the version of the backup store layout that this
+// version of Velero reads and writes, which `velero upgrade` records in each
+// backup store's layout version marker. Backup stores without a marker are
+// at version 0, whose layout is the same as version 1's, so upgrading them
+// only records the marker.
+const LayoutVersion = 1
+
+// GetLayoutVersion returns the version recorded in the backup store's
+// layout version marker, or 0 if the store doesn't have one.
+func (s *objectBackupStore) GetLayoutVersion() (int, error) {
+	res, err := tryGet(s.objectStore, s.bucket, s.layout.getLayoutVersionKey())
+	if err != nil {
+		return 0, errors.Wrap(err, "error getting backup store's layout version marker")
+	}
+	if res == nil {
+		return 0, nil
+	}
+	defer res.Close()
+
+	data, err := ioutil.ReadAll(res)
+	if err != nil {
+		return 0, errors.Wrap(err, "error reading backup store's layout version marker")
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, errors.Wrap(err, "error parsing backup store's layout version marker")
+	}
+
+	return version, nil
+}
+
+// PutLayoutVersion records the version in the backup store's layout
+// version marker.
+func (s *objectBackupStore) PutLayoutVersion(version int) error {
+	if err := s.objectStore.PutObject(s.bucket, s.layout.getLayoutVersionKey(), strings.NewReader(strconv.Itoa(version))); err != nil {
+		return errors.Wrap(err, "error putting backup store's layout version marker")
+	}
+	return nil
+}
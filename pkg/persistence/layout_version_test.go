@@ -0,0 +1,45 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutVersion(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "velero")
+
+	// a backup store without a marker is at version 0
+	version, err := harness.GetLayoutVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	require.NoError(t, harness.PutLayoutVersion(LayoutVersion))
+	assert.Equal(t, "1", string(harness.objectStore.Data["test-bucket"]["velero/metadata/layout-version"]))
+
+	version, err = harness.GetLayoutVersion()
+	require.NoError(t, err)
+	assert.Equal(t, LayoutVersion, version)
+
+	require.NoError(t, harness.objectStore.PutObject("test-bucket", "velero/metadata/layout-version", strings.NewReader("not-a-version")))
+	_, err = harness.GetLayoutVersion()
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	// metadataCacheSize is how many objects the metadata cache holds
+	// before it evicts the least recently used ones.
+	metadataCacheSize = 1000
+
+	// metadataCacheTTL is how long an object stays in the metadata cache
+	// after it's read from object storage.
+	metadataCacheTTL = time.Hour
+)
+
+// backupMetadataCache holds the contents of backups' metadata and volume
+// snapshots files, so that describing and syncing backups doesn't read them
+// from object storage every time. Each controller gets its own backup store,
+// so the cache is kept here so that all of them share it.
+var backupMetadataCache = newMetadataCache(metadataCacheSize, metadataCacheTTL)
+
+// metadataCache is an LRU cache of small objects in backup stores. Object
+// storage plugins don't expose ETags, so each entry records the revision of
+// the backup store it was read at instead. The revision changes whenever a
+// backup in the store is written or deleted, so an entry is only used while
+// the store's revision is unchanged.
+type metadataCache struct {
+	cache *cache.LRUExpireCache
+	ttl   time.Duration
+}
+
+type metadataCacheKey struct {
+	storageID string
+	bucket    string
+	key       string
+}
+
+type metadataCacheEntry struct {
+	revision string
+
+	// data is the object's contents, or nil if it didn't exist.
+	data []byte
+}
+
+func newMetadataCache(size int, ttl time.Duration) *metadataCache {
+	return &metadataCache{
+		cache: cache.NewLRUExpireCache(size),
+		ttl:   ttl,
+	}
+}
+
+func (c *metadataCache) get(key metadataCacheKey, revision string) (*metadataCacheEntry, bool) {
+	obj, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	entry := obj.(*metadataCacheEntry)
+	if entry.revision != revision {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (c *metadataCache) add(key metadataCacheKey, entry *metadataCacheEntry) {
+	c.cache.Add(key, entry, c.ttl)
+}
+
+func (c *metadataCache) remove(key metadataCacheKey) {
+	c.cache.Remove(key)
+}
+
+// getCachedObject returns the contents of an object, or nil if it doesn't
+// exist, using the metadata cache when the store has one and its revision
+// is known. Otherwise, or if the object isn't cached, it's read with get,
+// which returns a nil reader if the object doesn't exist.
+func (s *objectBackupStore) getCachedObject(key string, get func() (io.ReadCloser, error)) ([]byte, error) {
+	cacheKey := metadataCacheKey{storageID: s.storageID, bucket: s.bucket, key: key}
+
+	revision := s.getCacheRevision()
+	if revision != "" {
+		if entry, ok := s.metadataCache.get(cacheKey, revision); ok {
+			return entry.data, nil
+		}
+	}
+
+	res, err := get()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if res != nil {
+		defer res.Close()
+
+		if data, err = ioutil.ReadAll(res); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if revision != "" {
+		s.metadataCache.add(cacheKey, &metadataCacheEntry{revision: revision, data: data})
+	}
+
+	return data, nil
+}
+
+// getCacheRevision returns the store's revision that cached objects are
+// checked against, or an empty string if objects shouldn't be cached. The
+// revision is only read once for each backup store, so a store doesn't see
+// other servers' changes until the next one is created for the location.
+func (s *objectBackupStore) getCacheRevision() string {
+	if s.metadataCache == nil {
+		return ""
+	}
+
+	if s.cacheRevision == nil {
+		// backup stores without a revision file, e.g. those that no
+		// backup has been written to by Velero v0.10+, aren't cached.
+		revision, err := s.GetRevision()
+		if err != nil {
+			revision = ""
+		}
+		s.cacheRevision = &revision
+	}
+
+	return *s.cacheRevision
+}
+
+// invalidateCachedBackup removes a backup's objects from the metadata cache
+// after they're written or deleted, in case the store's revision couldn't
+// be updated.
+func (s *objectBackupStore) invalidateCachedBackup(name string) {
+	if s.metadataCache == nil {
+		return
+	}
+
+	for _, key := range []string{s.layout.getBackupMetadataKey(name), s.layout.getBackupVolumeSnapshotsKey(name)} {
+		s.metadataCache.remove(metadataCacheKey{storageID: s.storageID, bucket: s.bucket, key: key})
+	}
+
+	s.cacheRevision = nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/heptio/velero/pkg/cloudprovider"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+// countingObjectStore is an in-memory object store that counts how many
+// times each object is read.
+type countingObjectStore struct {
+	*cloudprovider.InMemoryObjectStore
+
+	gets map[string]int
+}
+
+func (o *countingObjectStore) GetObject(bucket, key string) (io.ReadCloser, error) {
+	o.gets[key]++
+	return o.InMemoryObjectStore.GetObject(bucket, key)
+}
+
+func TestMetadataCache(t *testing.T) {
+	objectStore := &countingObjectStore{
+		InMemoryObjectStore: cloudprovider.NewInMemoryObjectStore("test-bucket"),
+		gets:                make(map[string]int),
+	}
+	cache := newMetadataCache(10, time.Hour)
+
+	newStore := func() *objectBackupStore {
+		return &objectBackupStore{
+			objectStore:   objectStore,
+			bucket:        "test-bucket",
+			storageID:     "aws:test-bucket",
+			layout:        NewObjectStoreLayout(""),
+			logger:        velerotest.NewLogger(),
+			metadataCache: cache,
+		}
+	}
+
+	const (
+		metadataKey = "backups/backup-1/velero-backup.json"
+		metadata    = `{"apiVersion":"velero.io/v1","kind":"Backup","metadata":{"namespace":"velero","name":"backup-1"}}`
+	)
+
+	store := newStore()
+	require.NoError(t, store.PutBackup(BackupInfo{
+		Name:     "backup-1",
+		Metadata: strings.NewReader(metadata),
+	}))
+
+	// the metadata file is only read from object storage once, by any
+	// backup store for the location.
+	for i := 0; i < 3; i++ {
+		backup, err := newStore().GetBackupMetadata("backup-1")
+		require.NoError(t, err)
+		assert.Equal(t, "backup-1", backup.Name)
+	}
+	assert.Equal(t, 1, objectStore.gets[metadataKey])
+
+	// a volume snapshots file that doesn't exist is cached too.
+	for i := 0; i < 2; i++ {
+		snapshots, err := newStore().GetBackupVolumeSnapshots("backup-1")
+		require.NoError(t, err)
+		assert.Nil(t, snapshots)
+	}
+
+	// writing the backup again changes the revision, so the metadata
+	// file is read again.
+	require.NoError(t, store.PutBackup(BackupInfo{
+		Name:     "backup-1",
+		Metadata: strings.NewReader(strings.Replace(metadata, `"name":"backup-1"`, `"name":"backup-1","labels":{"a":"b"}`, 1)),
+	}))
+
+	backup, err := newStore().GetBackupMetadata("backup-1")
+	require.NoError(t, err)
+	assert.Equal(t, "b", backup.Labels["a"])
+	assert.Equal(t, 2, objectStore.gets[metadataKey])
+
+	// and so is the store that wrote it.
+	backup, err = store.GetBackupMetadata("backup-1")
+	require.NoError(t, err)
+	assert.Equal(t, "b", backup.Labels["a"])
+
+	// deleted backups aren't found in the cache.
+	require.NoError(t, store.DeleteBackup("backup-1"))
+	_, err = store.GetBackupMetadata("backup-1")
+	assert.Error(t, err)
+}
+
+func TestMetadataCacheWithoutRevision(t *testing.T) {
+	objectStore := &countingObjectStore{
+		InMemoryObjectStore: cloudprovider.NewInMemoryObjectStore("test-bucket"),
+		gets:                make(map[string]int),
+	}
+
+	store := &objectBackupStore{
+		objectStore:   objectStore,
+		bucket:        "test-bucket",
+		layout:        NewObjectStoreLayout(""),
+		logger:        velerotest.NewLogger(),
+		metadataCache: newMetadataCache(10, time.Hour),
+	}
+
+	metadataKey := "backups/backup-1/velero-backup.json"
+	require.NoError(t, objectStore.PutObject("test-bucket", metadataKey, strings.NewReader(`{"apiVersion":"velero.io/v1","kind":"Backup","metadata":{"name":"backup-1"}}`)))
+
+	// backup stores without a revision file aren't cached.
+	for i := 0; i < 2; i++ {
+		_, err := store.GetBackupMetadata("backup-1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, objectStore.gets[metadataKey])
+}
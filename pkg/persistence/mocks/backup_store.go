@@ -5,6 +5,7 @@ package mocks
 import io "io"
 import mock "github.com/stretchr/testify/mock"
 import persistence "github.com/heptio/velero/pkg/persistence"
+import time "time"
 import v1 "github.com/heptio/velero/pkg/apis/velero/v1"
 import volume "github.com/heptio/velero/pkg/volume"
 
@@ -13,6 +14,29 @@ type BackupStore struct {
 	mock.Mock
 }
 
+// AtBackupVersion provides a mock function with given fields: name, versionID
+func (_m *BackupStore) AtBackupVersion(name string, versionID string) (persistence.BackupStore, error) {
+	ret := _m.Called(name, versionID)
+
+	var r0 persistence.BackupStore
+	if rf, ok := ret.Get(0).(func(string, string) persistence.BackupStore); ok {
+		r0 = rf(name, versionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(persistence.BackupStore)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(name, versionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BackupExists provides a mock function with given fields: bucket, backupName
 func (_m *BackupStore) BackupExists(bucket string, backupName string) (bool, error) {
 	ret := _m.Called(bucket, backupName)
@@ -34,6 +58,20 @@ func (_m *BackupStore) BackupExists(bucket string, backupName string) (bool, err
 	return r0, r1
 }
 
+// ClaimOwnership provides a mock function with given fields: owner
+func (_m *BackupStore) ClaimOwnership(owner persistence.LocationOwner) error {
+	ret := _m.Called(owner)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(persistence.LocationOwner) error); ok {
+		r0 = rf(owner)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteBackup provides a mock function with given fields: name
 func (_m *BackupStore) DeleteBackup(name string) error {
 	ret := _m.Called(name)
@@ -48,6 +86,20 @@ func (_m *BackupStore) DeleteBackup(name string) error {
 	return r0
 }
 
+// DeleteChunk provides a mock function with given fields: hash
+func (_m *BackupStore) DeleteChunk(hash string) error {
+	ret := _m.Called(hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteRestore provides a mock function with given fields: name
 func (_m *BackupStore) DeleteRestore(name string) error {
 	ret := _m.Called(name)
@@ -85,6 +137,29 @@ func (_m *BackupStore) GetBackupContents(name string) (io.ReadCloser, error) {
 	return r0, r1
 }
 
+// GetBackupItemIndex provides a mock function with given fields: name
+func (_m *BackupStore) GetBackupItemIndex(name string) (persistence.BackupItemIndex, error) {
+	ret := _m.Called(name)
+
+	var r0 persistence.BackupItemIndex
+	if rf, ok := ret.Get(0).(func(string) persistence.BackupItemIndex); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(persistence.BackupItemIndex)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetBackupMetadata provides a mock function with given fields: name
 func (_m *BackupStore) GetBackupMetadata(name string) (*v1.Backup, error) {
 	ret := _m.Called(name)
@@ -131,15 +206,17 @@ func (_m *BackupStore) GetBackupVolumeSnapshots(name string) ([]*volume.Snapshot
 	return r0, r1
 }
 
-// GetDownloadURL provides a mock function with given fields: target
-func (_m *BackupStore) GetDownloadURL(target v1.DownloadTarget) (string, error) {
+// GetDownload provides a mock function with given fields: target
+func (_m *BackupStore) GetDownload(target v1.DownloadTarget) (io.ReadCloser, error) {
 	ret := _m.Called(target)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(v1.DownloadTarget) string); ok {
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(v1.DownloadTarget) io.ReadCloser); ok {
 		r0 = rf(target)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
 	}
 
 	var r1 error
@@ -152,6 +229,48 @@ func (_m *BackupStore) GetDownloadURL(target v1.DownloadTarget) (string, error)
 	return r0, r1
 }
 
+// GetDownloadURL provides a mock function with given fields: target, ttl
+func (_m *BackupStore) GetDownloadURL(target v1.DownloadTarget, ttl time.Duration) (string, error) {
+	ret := _m.Called(target, ttl)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(v1.DownloadTarget, time.Duration) string); ok {
+		r0 = rf(target, ttl)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(v1.DownloadTarget, time.Duration) error); ok {
+		r1 = rf(target, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLayoutVersion provides a mock function with given fields:
+func (_m *BackupStore) GetLayoutVersion() (int, error) {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetPodVolumeBackups provides a mock function with given fields: name
 func (_m *BackupStore) GetPodVolumeBackups(name string) ([]*v1.PodVolumeBackup, error) {
 	ret := _m.Called(name)
@@ -233,6 +352,66 @@ func (_m *BackupStore) ListBackups() ([]string, error) {
 	return r0, r1
 }
 
+// ListResticRepositories provides a mock function with given fields:
+func (_m *BackupStore) ListResticRepositories() ([]string, error) {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUnreferencedChunks provides a mock function with given fields:
+func (_m *BackupStore) ListUnreferencedChunks() ([]string, error) {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockBackup provides a mock function with given fields: name, until
+func (_m *BackupStore) LockBackup(name string, until time.Time) error {
+	ret := _m.Called(name, until)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(name, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PutBackup provides a mock function with given fields: info
 func (_m *BackupStore) PutBackup(info persistence.BackupInfo) error {
 	ret := _m.Called(info)
@@ -247,6 +426,34 @@ func (_m *BackupStore) PutBackup(info persistence.BackupInfo) error {
 	return r0
 }
 
+// PutBackupMetadata provides a mock function with given fields: backup
+func (_m *BackupStore) PutBackupMetadata(backup *v1.Backup) error {
+	ret := _m.Called(backup)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*v1.Backup) error); ok {
+		r0 = rf(backup)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PutLayoutVersion provides a mock function with given fields: version
+func (_m *BackupStore) PutLayoutVersion(version int) error {
+	ret := _m.Called(version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PutRestoreLog provides a mock function with given fields: backup, restore, log
 func (_m *BackupStore) PutRestoreLog(backup string, restore string, log io.Reader) error {
 	ret := _m.Called(backup, restore, log)
@@ -261,6 +468,34 @@ func (_m *BackupStore) PutRestoreLog(backup string, restore string, log io.Reade
 	return r0
 }
 
+// PutRestoreStructuredLog provides a mock function with given fields: backup, restore, log
+func (_m *BackupStore) PutRestoreStructuredLog(backup string, restore string, log io.Reader) error {
+	ret := _m.Called(backup, restore, log)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, io.Reader) error); ok {
+		r0 = rf(backup, restore, log)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PutRestoreManifest provides a mock function with given fields: backup, restore, manifest
+func (_m *BackupStore) PutRestoreManifest(backup string, restore string, manifest io.Reader) error {
+	ret := _m.Called(backup, restore, manifest)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, io.Reader) error); ok {
+		r0 = rf(backup, restore, manifest)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PutRestoreResults provides a mock function with given fields: backup, restore, results
 func (_m *BackupStore) PutRestoreResults(backup string, restore string, results io.Reader) error {
 	ret := _m.Called(backup, restore, results)
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// LockBackup locks each of the named backup's files, and the chunks of its
+// contents if it's deduplicated, so that they can't be deleted or
+// overwritten until the given time, even by someone with write access to
+// the bucket. Chunks that other backups share stay locked until the latest
+// of their backups' lock times.
+func (s *objectBackupStore) LockBackup(name string, until time.Time) error {
+	capabilities, err := s.getCapabilities()
+	if err != nil {
+		return err
+	}
+	if !capabilities.ObjectLock {
+		return errors.New("object store doesn't support locking objects")
+	}
+
+	keys, err := s.objectStore.ListObjects(s.bucket, s.layout.getBackupDir(name))
+	if err != nil {
+		return err
+	}
+
+	index, err := s.getContentsIndex(name)
+	if err != nil {
+		return err
+	}
+	if index != nil {
+		for _, hash := range index.Chunks {
+			keys = append(keys, s.layout.getChunkKey(hash))
+		}
+	}
+
+	var errs []error
+	for _, key := range keys {
+		if err := s.objectStore.LockObject(s.bucket, key, until); err != nil {
+			errs = append(errs, errors.Wrapf(err, "error locking object %s", key))
+		}
+	}
+
+	return kerrors.NewAggregate(errs)
+}
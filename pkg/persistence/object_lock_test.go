@@ -0,0 +1,90 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/heptio/velero/pkg/cloudprovider"
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+// lockingObjectStore is an in-memory object store that records the time
+// each object is locked until.
+type lockingObjectStore struct {
+	*cloudprovider.InMemoryObjectStore
+
+	locks map[string]time.Time
+}
+
+func (o *lockingObjectStore) LockObject(bucket, key string, until time.Time) error {
+	o.locks[key] = until
+	return nil
+}
+
+func (o *lockingObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	return velero.ObjectStoreCapabilities{ObjectLock: true}, nil
+}
+
+func TestLockBackup(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+	harness.deduplicate = true
+	harness.chunking = testChunkingConfig
+
+	objectStore := &lockingObjectStore{
+		InMemoryObjectStore: harness.objectStore,
+		locks:               make(map[string]time.Time),
+	}
+	harness.objectBackupStore.objectStore = objectStore
+
+	require.NoError(t, harness.PutBackup(BackupInfo{
+		Name:     "backup-1",
+		Metadata: newStringReadSeeker("metadata"),
+		Contents: gzipData(t, randomData(1, 16*1024)),
+		Log:      newStringReadSeeker("log"),
+	}))
+	require.NoError(t, harness.PutBackup(BackupInfo{
+		Name:     "backup-2",
+		Metadata: newStringReadSeeker("metadata"),
+	}))
+
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, harness.LockBackup("backup-1", until))
+
+	backupFiles, err := harness.objectStore.ListObjects("test-bucket", "backups/backup-1/")
+	require.NoError(t, err)
+	chunks, err := harness.objectStore.ListObjects("test-bucket", "chunks/")
+	require.NoError(t, err)
+	require.NotEmpty(t, chunks)
+
+	expected := make(map[string]time.Time)
+	for _, key := range append(backupFiles, chunks...) {
+		expected[key] = until
+	}
+	assert.Equal(t, expected, objectStore.locks)
+}
+
+func TestLockBackupWithoutObjectLock(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+
+	err := harness.LockBackup("backup-1", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.EqualError(t, err, "object store doesn't support locking objects")
+}
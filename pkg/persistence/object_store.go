@@ -17,6 +17,7 @@ limitations under the License.
 package persistence
 
 import (
+	"bytes"
 	"compress/gzip"
 	"encoding/json"
 	"io"
@@ -28,8 +29,10 @@ import (
 	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/credentials"
 	"github.com/heptio/velero/pkg/generated/clientset/versioned/scheme"
 	"github.com/heptio/velero/pkg/plugin/velero"
 	"github.com/heptio/velero/pkg/volume"
@@ -37,48 +40,135 @@ import (
 
 type BackupInfo struct {
 	Name string
+	// Backup is the backup being persisted. It's required when the
+	// location's prefix has backup template variables, or the backup has
+	// a storage prefix.
+	Backup *velerov1api.Backup
 	Metadata,
 	Contents,
 	Log,
+	StructuredLog,
 	PodVolumeBackups,
 	VolumeSnapshots,
-	BackupResourceList io.Reader
+	BackupResourceList,
+	VolumeCoverage,
+	ItemIndex io.Reader
 }
 
 // BackupStore defines operations for creating, retrieving, and deleting
 // Velero backup and restore data in/from a persistent backup store.
+// Errors that mean a backup doesn't exist or is corrupt, or that the
+// backup store is unavailable or denied permission, can be checked for
+// with Is.
 type BackupStore interface {
 	IsValid() error
+	// ClaimOwnership records in the backup store that a location writes
+	// to it, and returns an error if another location already does.
+	ClaimOwnership(owner LocationOwner) error
 	GetRevision() (string, error)
+	// GetLayoutVersion returns the layout version recorded in the backup
+	// store, or 0 if none is.
+	GetLayoutVersion() (int, error)
+	// PutLayoutVersion records the layout version in the backup store.
+	PutLayoutVersion(version int) error
 
 	ListBackups() ([]string, error)
+	// ListResticRepositories returns the namespaces that have an
+	// initialized restic repository in the backup store.
+	ListResticRepositories() ([]string, error)
 
 	PutBackup(info BackupInfo) error
+	// PutBackupMetadata replaces the metadata file of a backup that's
+	// already in the backup store, for example when its TTL is changed.
+	PutBackupMetadata(backup *velerov1api.Backup) error
 	GetBackupMetadata(name string) (*velerov1api.Backup, error)
 	GetBackupVolumeSnapshots(name string) ([]*volume.Snapshot, error)
 	GetPodVolumeBackups(name string) ([]*velerov1api.PodVolumeBackup, error)
 	GetBackupContents(name string) (io.ReadCloser, error)
+	// GetBackupItemIndex returns the backup's item index, or nil if the
+	// backup doesn't have one.
+	GetBackupItemIndex(name string) (BackupItemIndex, error)
+
+	// AtBackupVersion returns a view of the backup store in which the
+	// backup's files are read as they were at the given version of its
+	// metadata file, in a bucket that has versioning enabled.
+	AtBackupVersion(name, versionID string) (BackupStore, error)
 
 	// BackupExists checks if the backup metadata file exists in object storage.
 	BackupExists(bucket, backupName string) (bool, error)
 
 	DeleteBackup(name string) error
+	// LockBackup locks the backup's files in object storage so that they
+	// can't be deleted or overwritten until the given time. It returns an
+	// error if the object store doesn't support object lock.
+	LockBackup(name string, until time.Time) error
+
+	// ListUnreferencedChunks returns the hashes of the deduplicated
+	// content chunks that aren't referenced by any backup.
+	ListUnreferencedChunks() ([]string, error)
+	// DeleteChunk deletes a deduplicated content chunk, unless a backup
+	// references it by the time it's deleted.
+	DeleteChunk(hash string) error
 
 	PutRestoreLog(backup, restore string, log io.Reader) error
+	PutRestoreStructuredLog(backup, restore string, log io.Reader) error
 	PutRestoreResults(backup, restore string, results io.Reader) error
+	PutRestoreManifest(backup, restore string, manifest io.Reader) error
 	DeleteRestore(name string) error
 
-	GetDownloadURL(target velerov1api.DownloadTarget) (string, error)
+	GetDownloadURL(target velerov1api.DownloadTarget, ttl time.Duration) (string, error)
+	// GetDownload returns the contents of the object that a download
+	// target refers to, for serving it through the download proxy.
+	GetDownload(target velerov1api.DownloadTarget) (io.ReadCloser, error)
 }
 
-// DownloadURLTTL is how long a download URL is valid for.
-const DownloadURLTTL = 10 * time.Minute
+// DefaultDownloadURLTTL is how long a download URL is valid for when the
+// download request doesn't specify a TTL.
+const DefaultDownloadURLTTL = 10 * time.Minute
 
 type objectBackupStore struct {
 	objectStore velero.ObjectStore
 	bucket      string
 	layout      *ObjectStoreLayout
 	logger      logrus.FieldLogger
+
+	// storageID identifies the object storage bucket, so that objects can
+	// be copied between backup stores in the same bucket without
+	// downloading them.
+	storageID string
+
+	// backupPathTemplate is the part of the location's prefix that has
+	// backup template variables, and backupPaths is where each backup
+	// is stored within the backups directory. They're only set when the
+	// prefix has backup template variables.
+	backupPathTemplate *backupPathTemplate
+	backupPaths        map[string]string
+
+	// legacyBackupDirs is where each backup that's stored at the top level
+	// of the backup store, as Ark did before v0.10, is. It's only listed
+	// when it's first needed.
+	legacyBackupDirs map[string]string
+
+	// prefixedBackupDirs is where each backup that's stored under a
+	// storage prefix is. The prefixes directory is only listed when it's
+	// first needed.
+	prefixedBackupDirs    map[string]string
+	prefixedBackupsListed bool
+
+	// capabilities is what the object store supports. It's only fetched
+	// from the plugin when it's first needed.
+	capabilities *velero.ObjectStoreCapabilities
+
+	// deduplicate is whether the contents of new backups are stored as
+	// chunks rather than as a tarball.
+	deduplicate bool
+	chunking    chunkingConfig
+
+	// metadataCache caches backups' metadata and volume snapshots files,
+	// checked against cacheRevision, the store's revision when it's first
+	// needed. It's nil if the files aren't cached.
+	metadataCache *metadataCache
+	cacheRevision *string
 }
 
 // ObjectStoreGetter is a type that can get a velero.ObjectStore
@@ -122,21 +212,41 @@ func NewObjectBackupStore(location *velerov1api.BackupStorageLocation, objectSto
 		return nil, err
 	}
 
-	if err := objectStore.Init(location.Spec.Config); err != nil {
+	config, err := credentials.WithCredentialsFile(location.Spec.Config, location.Spec.Credential)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := objectStore.Init(config); err != nil {
 		return nil, err
 	}
 
+	if err := ValidateRateLimit(location.Spec.RateLimit); err != nil {
+		return nil, err
+	}
+	objectStore = &classifyingObjectStore{ObjectStore: rateLimit(location, objectStore)}
+
 	log := logger.WithFields(logrus.Fields(map[string]interface{}{
 		"bucket": bucket,
 		"prefix": prefix,
 	}))
 
-	return &objectBackupStore{
-		objectStore: objectStore,
-		bucket:      bucket,
-		layout:      NewObjectStoreLayout(prefix),
-		logger:      log,
-	}, nil
+	storageID, _ := locationStorage(location)
+
+	s := &objectBackupStore{
+		objectStore:   objectStore,
+		bucket:        bucket,
+		logger:        log,
+		storageID:     storageID,
+		deduplicate:   location.Spec.Deduplication,
+		chunking:      defaultChunkingConfig,
+		metadataCache: backupMetadataCache,
+	}
+	if err := s.setLayout(prefix); err != nil {
+		return nil, err
+	}
+
+	return s, nil
 }
 
 func (s *objectBackupStore) IsValid() error {
@@ -148,11 +258,19 @@ func (s *objectBackupStore) IsValid() error {
 	var invalid []string
 	for _, dir := range dirs {
 		subdir := strings.TrimSuffix(strings.TrimPrefix(dir, s.layout.rootPrefix), "/")
-		if !s.layout.isValidSubdir(subdir) {
+		if !s.layout.isValidSubdir(subdir) && !s.isLegacyBackupDir(subdir) {
 			invalid = append(invalid, subdir)
 		}
 	}
 
+	version, err := s.GetLayoutVersion()
+	if err != nil {
+		return err
+	}
+	if version > LayoutVersion {
+		return errors.Errorf("Backup store has layout version %d, which is newer than version %d that this version of Velero supports", version, LayoutVersion)
+	}
+
 	if len(invalid) > 0 {
 		// don't include more than 3 invalid dirs in the error message
 		if len(invalid) > 3 {
@@ -165,15 +283,25 @@ func (s *objectBackupStore) IsValid() error {
 }
 
 func (s *objectBackupStore) ListBackups() ([]string, error) {
+	if s.backupPathTemplate != nil {
+		backups, err := s.listTemplatedBackups()
+		if err != nil {
+			return nil, err
+		}
+		return s.appendPrefixedBackups(backups)
+	}
+
 	prefixes, err := s.objectStore.ListCommonPrefixes(s.bucket, s.layout.subdirs["backups"], "/")
 	if err != nil {
 		return nil, err
 	}
-	if len(prefixes) == 0 {
-		return []string{}, nil
+
+	legacyBackups, err := s.listLegacyBackups()
+	if err != nil {
+		return nil, err
 	}
 
-	output := make([]string, 0, len(prefixes))
+	output := make([]string, 0, len(prefixes)+len(legacyBackups))
 
 	for _, prefix := range prefixes {
 		// values returned from a call to ObjectStore's
@@ -185,16 +313,62 @@ func (s *objectBackupStore) ListBackups() ([]string, error) {
 		output = append(output, backupName)
 	}
 
+	// backups taken before Ark v0.10 are stored at the top level of the
+	// backup store rather than in the backups directory.
+	listed := sets.NewString(output...)
+	for _, backupName := range legacyBackups {
+		if !listed.Has(backupName) {
+			output = append(output, backupName)
+		}
+	}
+
+	return s.appendPrefixedBackups(output)
+}
+
+func (s *objectBackupStore) ListResticRepositories() ([]string, error) {
+	prefixes, err := s.objectStore.ListCommonPrefixes(s.bucket, s.layout.GetResticDir(), "/")
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		volumeNamespace := strings.TrimSuffix(strings.TrimPrefix(prefix, s.layout.GetResticDir()), "/")
+
+		// restic writes the repository's config file when it initializes
+		// the repository, so directories without one are left over from a
+		// failed init and can't be connected to.
+		exists, err := s.objectStore.ObjectExists(s.bucket, s.layout.getResticRepoConfigKey(volumeNamespace))
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		output = append(output, volumeNamespace)
+	}
+
 	return output, nil
 }
 
 func (s *objectBackupStore) PutBackup(info BackupInfo) error {
+	if err := s.setBackupPath(info.Name, info.Backup); err != nil {
+		return err
+	}
+	defer s.invalidateCachedBackup(info.Name)
+
 	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getBackupLogKey(info.Name), info.Log); err != nil {
 		// Uploading the log file is best-effort; if it fails, we log the error but it doesn't impact the
 		// backup's status.
 		s.logger.WithError(err).WithField("backup", info.Name).Error("Error uploading log file")
 	}
 
+	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getBackupStructuredLogKey(info.Name), info.StructuredLog); err != nil {
+		// Uploading the structured log file is best-effort too.
+		s.logger.WithError(err).WithField("backup", info.Name).Error("Error uploading structured log file")
+	}
+
 	if info.Metadata == nil {
 		// If we don't have metadata, something failed, and there's no point in continuing. An object
 		// storage bucket that is missing the metadata file can't be restored, nor can its logs be
@@ -207,7 +381,7 @@ func (s *objectBackupStore) PutBackup(info BackupInfo) error {
 		return err
 	}
 
-	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getBackupContentsKey(info.Name), info.Contents); err != nil {
+	if err := s.putContents(info.Name, info.Contents); err != nil {
 		deleteErr := s.objectStore.DeleteObject(s.bucket, s.layout.getBackupMetadataKey(info.Name))
 		return kerrors.NewAggregate([]error{err, deleteErr})
 	}
@@ -215,7 +389,7 @@ func (s *objectBackupStore) PutBackup(info BackupInfo) error {
 	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getPodVolumeBackupsKey(info.Name), info.PodVolumeBackups); err != nil {
 		errs := []error{err}
 
-		deleteErr := s.objectStore.DeleteObject(s.bucket, s.layout.getBackupContentsKey(info.Name))
+		deleteErr := s.objectStore.DeleteObject(s.bucket, s.contentsKey(info.Name))
 		errs = append(errs, deleteErr)
 
 		deleteErr = s.objectStore.DeleteObject(s.bucket, s.layout.getBackupMetadataKey(info.Name))
@@ -227,7 +401,7 @@ func (s *objectBackupStore) PutBackup(info BackupInfo) error {
 	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getBackupVolumeSnapshotsKey(info.Name), info.VolumeSnapshots); err != nil {
 		errs := []error{err}
 
-		deleteErr := s.objectStore.DeleteObject(s.bucket, s.layout.getBackupContentsKey(info.Name))
+		deleteErr := s.objectStore.DeleteObject(s.bucket, s.contentsKey(info.Name))
 		errs = append(errs, deleteErr)
 
 		deleteErr = s.objectStore.DeleteObject(s.bucket, s.layout.getBackupMetadataKey(info.Name))
@@ -239,7 +413,25 @@ func (s *objectBackupStore) PutBackup(info BackupInfo) error {
 	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getBackupResourceListKey(info.Name), info.BackupResourceList); err != nil {
 		errs := []error{err}
 
-		deleteErr := s.objectStore.DeleteObject(s.bucket, s.layout.getBackupContentsKey(info.Name))
+		deleteErr := s.objectStore.DeleteObject(s.bucket, s.contentsKey(info.Name))
+		errs = append(errs, deleteErr)
+
+		deleteErr = s.objectStore.DeleteObject(s.bucket, s.layout.getBackupMetadataKey(info.Name))
+		errs = append(errs, deleteErr)
+
+		return kerrors.NewAggregate(errs)
+	}
+
+	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getBackupVolumeCoverageKey(info.Name), info.VolumeCoverage); err != nil {
+		// Uploading the volume coverage report is best-effort; if it fails, we log the error but it
+		// doesn't impact the backup's status.
+		s.logger.WithError(err).WithField("backup", info.Name).Error("Error uploading volume coverage report")
+	}
+
+	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getBackupItemIndexKey(info.Name), info.ItemIndex); err != nil {
+		errs := []error{err}
+
+		deleteErr := s.objectStore.DeleteObject(s.bucket, s.contentsKey(info.Name))
 		errs = append(errs, deleteErr)
 
 		deleteErr = s.objectStore.DeleteObject(s.bucket, s.layout.getBackupMetadataKey(info.Name))
@@ -255,29 +447,72 @@ func (s *objectBackupStore) PutBackup(info BackupInfo) error {
 	return nil
 }
 
-func (s *objectBackupStore) GetBackupMetadata(name string) (*velerov1api.Backup, error) {
-	metadataKey := s.layout.getBackupMetadataKey(name)
+func (s *objectBackupStore) PutBackupMetadata(backup *velerov1api.Backup) error {
+	if err := s.setBackupPath(backup.Name, backup); err != nil {
+		return err
+	}
+	defer s.invalidateCachedBackup(backup.Name)
+
+	// backups from a lister don't have their type set, which decoding the
+	// metadata file requires.
+	backup = backup.DeepCopy()
+	backup.APIVersion = velerov1api.SchemeGroupVersion.String()
+	backup.Kind = "Backup"
 
-	res, err := s.objectStore.GetObject(s.bucket, metadataKey)
+	data, err := json.Marshal(backup)
 	if err != nil {
-		return nil, err
+		return errors.Wrap(err, "error encoding backup metadata")
 	}
-	defer res.Close()
 
-	data, err := ioutil.ReadAll(res)
+	if err := s.objectStore.PutObject(s.bucket, s.layout.getBackupMetadataKey(backup.Name), bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	if err := s.putRevision(); err != nil {
+		s.logger.WithField("backup", backup.Name).WithError(err).Warn("Error updating backup store revision")
+	}
+
+	return nil
+}
+
+func (s *objectBackupStore) putContents(name string, contents io.Reader) error {
+	if s.deduplicate && contents != nil {
+		return s.putDeduplicatedContents(name, contents)
+	}
+	return seekAndPutObject(s.objectStore, s.bucket, s.layout.getBackupContentsKey(name), contents)
+}
+
+func (s *objectBackupStore) GetBackupMetadata(name string) (*velerov1api.Backup, error) {
+	metadataKey := s.layout.getBackupMetadataKey(name)
+
+	data, err := s.getCachedObject(metadataKey, func() (io.ReadCloser, error) {
+		return s.objectStore.GetObject(s.bucket, metadataKey)
+	})
 	if err != nil {
-		return nil, errors.WithStack(err)
+		// backups taken by Ark have an Ark metadata file instead, which is
+		// migrated to a Velero backup.
+		if backup, _, arkErr := s.getArkBackup(name); arkErr == nil && backup != nil {
+			return backup, nil
+		}
+		// the error doesn't say whether the metadata file is missing, so
+		// check whether the backup exists to tell.
+		if !IsTransient(err) {
+			if exists, existsErr := s.BackupExists(s.bucket, name); existsErr == nil && !exists {
+				return nil, newStoreError(ErrBackupNotFound, err)
+			}
+		}
+		return nil, err
 	}
 
 	decoder := scheme.Codecs.UniversalDecoder(velerov1api.SchemeGroupVersion)
 	obj, _, err := decoder.Decode(data, nil, nil)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, newStoreError(ErrBackupCorrupt, errors.WithStack(err))
 	}
 
 	backupObj, ok := obj.(*velerov1api.Backup)
 	if !ok {
-		return nil, errors.Errorf("unexpected type for %s/%s: %T", s.bucket, metadataKey, obj)
+		return nil, newStoreError(ErrBackupCorrupt, errors.Errorf("unexpected type for %s/%s: %T", s.bucket, metadataKey, obj))
 	}
 
 	return backupObj, nil
@@ -287,18 +522,23 @@ func (s *objectBackupStore) GetBackupVolumeSnapshots(name string) ([]*volume.Sna
 	// if the volumesnapshots file doesn't exist, we don't want to return an error, since
 	// a legacy backup or a backup with no snapshots would not have this file, so check for
 	// its existence before attempting to get its contents.
-	res, err := tryGet(s.objectStore, s.bucket, s.layout.getBackupVolumeSnapshotsKey(name))
+	key := s.layout.getBackupVolumeSnapshotsKey(name)
+
+	data, err := s.getCachedObject(key, func() (io.ReadCloser, error) {
+		return tryGet(s.objectStore, s.bucket, key)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if res == nil {
-		return nil, nil
+	if data == nil {
+		// backups taken before Ark v0.10 recorded their volume snapshots in
+		// their status.
+		return s.getArkVolumeSnapshots(name)
 	}
-	defer res.Close()
 
 	var volumeSnapshots []*volume.Snapshot
-	if err := decode(res, &volumeSnapshots); err != nil {
-		return nil, err
+	if err := decode(bytes.NewReader(data), &volumeSnapshots); err != nil {
+		return nil, newStoreError(ErrBackupCorrupt, err)
 	}
 
 	return volumeSnapshots, nil
@@ -349,18 +589,59 @@ func (s *objectBackupStore) GetPodVolumeBackups(name string) ([]*velerov1api.Pod
 
 	var podVolumeBackups []*velerov1api.PodVolumeBackup
 	if err := decode(res, &podVolumeBackups); err != nil {
-		return nil, err
+		return nil, newStoreError(ErrBackupCorrupt, err)
 	}
 
 	return podVolumeBackups, nil
 }
 
+func (s *objectBackupStore) GetBackupItemIndex(name string) (BackupItemIndex, error) {
+	// only backups that skip unchanged items have an item index.
+	res, err := tryGet(s.objectStore, s.bucket, s.layout.getBackupItemIndexKey(name))
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, nil
+	}
+	defer res.Close()
+
+	var index BackupItemIndex
+	if err := decode(res, &index); err != nil {
+		return nil, newStoreError(ErrBackupCorrupt, err)
+	}
+
+	return index, nil
+}
+
 func (s *objectBackupStore) GetBackupContents(name string) (io.ReadCloser, error) {
-	return s.objectStore.GetObject(s.bucket, s.layout.getBackupContentsKey(name))
+	// backups that were uploaded to a location that deduplicates backup
+	// contents have a contents index rather than a tarball.
+	index, err := s.getContentsIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		return s.getDeduplicatedContents(index), nil
+	}
+
+	res, err := s.objectStore.GetObject(s.bucket, s.layout.getBackupContentsKey(name))
+	if err != nil && !IsTransient(err) {
+		if exists, existsErr := s.BackupExists(s.bucket, name); existsErr == nil && !exists {
+			return nil, newStoreError(ErrBackupNotFound, err)
+		}
+	}
+	return res, err
 }
 
 func (s *objectBackupStore) BackupExists(bucket, backupName string) (bool, error) {
-	return s.objectStore.ObjectExists(bucket, s.layout.getBackupMetadataKey(backupName))
+	exists, err := s.objectStore.ObjectExists(bucket, s.layout.getBackupMetadataKey(backupName))
+	if err != nil || exists {
+		return exists, err
+	}
+
+	// backups taken by Ark have an Ark metadata file instead.
+	return s.objectStore.ObjectExists(bucket, s.layout.getArkBackupMetadataKey(backupName))
 }
 
 func (s *objectBackupStore) DeleteBackup(name string) error {
@@ -368,16 +649,9 @@ func (s *objectBackupStore) DeleteBackup(name string) error {
 	if err != nil {
 		return err
 	}
+	defer s.invalidateCachedBackup(name)
 
-	var errs []error
-	for _, key := range objects {
-		s.logger.WithFields(logrus.Fields{
-			"key": key,
-		}).Debug("Trying to delete object")
-		if err := s.objectStore.DeleteObject(s.bucket, key); err != nil {
-			errs = append(errs, err)
-		}
-	}
+	errs := s.deleteObjects(objects)
 
 	if err := s.putRevision(); err != nil {
 		s.logger.WithField("backup", name).WithError(err).Warn("Error updating backup store revision")
@@ -392,8 +666,36 @@ func (s *objectBackupStore) DeleteRestore(name string) error {
 		return err
 	}
 
+	errs := s.deleteObjects(objects)
+
+	if err = s.putRevision(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.WithStack(kerrors.NewAggregate(errs))
+}
+
+// deleteObjects deletes the objects with the given keys, in a single call to the object
+// store if it supports deleting objects in batches, or one at a time if it doesn't, and
+// returns the errors deleting them.
+func (s *objectBackupStore) deleteObjects(keys []string) []error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if deleter, ok := s.objectStore.(velero.BatchDeleter); ok {
+		s.logger.WithField("count", len(keys)).Debug("Trying to delete objects")
+		err := deleter.DeleteObjects(s.bucket, keys)
+		if err != velero.ErrBatchDeleteNotSupported {
+			if err != nil {
+				return []error{err}
+			}
+			return nil
+		}
+	}
+
 	var errs []error
-	for _, key := range objects {
+	for _, key := range keys {
 		s.logger.WithFields(logrus.Fields{
 			"key": key,
 		}).Debug("Trying to delete object")
@@ -402,35 +704,106 @@ func (s *objectBackupStore) DeleteRestore(name string) error {
 		}
 	}
 
-	if err = s.putRevision(); err != nil {
-		errs = append(errs, err)
-	}
-
-	return errors.WithStack(kerrors.NewAggregate(errs))
+	return errs
 }
 
 func (s *objectBackupStore) PutRestoreLog(backup string, restore string, log io.Reader) error {
 	return s.objectStore.PutObject(s.bucket, s.layout.getRestoreLogKey(restore), log)
 }
 
+func (s *objectBackupStore) PutRestoreStructuredLog(backup string, restore string, log io.Reader) error {
+	return s.objectStore.PutObject(s.bucket, s.layout.getRestoreStructuredLogKey(restore), log)
+}
+
 func (s *objectBackupStore) PutRestoreResults(backup string, restore string, results io.Reader) error {
 	return s.objectStore.PutObject(s.bucket, s.layout.getRestoreResultsKey(restore), results)
 }
 
-func (s *objectBackupStore) GetDownloadURL(target velerov1api.DownloadTarget) (string, error) {
+func (s *objectBackupStore) PutRestoreManifest(backup string, restore string, manifest io.Reader) error {
+	return s.objectStore.PutObject(s.bucket, s.layout.getRestoreManifestKey(restore), manifest)
+}
+
+func (s *objectBackupStore) GetDownloadURL(target velerov1api.DownloadTarget, ttl time.Duration) (string, error) {
+	if target.Kind == velerov1api.DownloadTargetKindBackupContents {
+		index, err := s.getContentsIndex(target.Name)
+		if err != nil {
+			return "", err
+		}
+		if index != nil {
+			return "", errors.Errorf("download URLs are not supported for the contents of deduplicated backup %s", target.Name)
+		}
+	}
+
+	capabilities, err := s.getCapabilities()
+	if err != nil {
+		return "", err
+	}
+	if !capabilities.SignedURLs {
+		return "", errors.New("download URLs are not supported because the backup storage location's object store doesn't support signed URLs")
+	}
+
+	key, err := s.downloadKey(target)
+	if err != nil {
+		return "", err
+	}
+
+	return s.objectStore.CreateSignedURL(s.bucket, key, ttl)
+}
+
+// getCapabilities returns the optional features that the object store
+// supports, getting them from the plugin the first time.
+func (s *objectBackupStore) getCapabilities() (velero.ObjectStoreCapabilities, error) {
+	if s.capabilities == nil {
+		capabilities, err := s.objectStore.Capabilities()
+		if err != nil {
+			return velero.ObjectStoreCapabilities{}, errors.Wrap(err, "error getting object store capabilities")
+		}
+		s.capabilities = &capabilities
+	}
+
+	return *s.capabilities, nil
+}
+
+func (s *objectBackupStore) GetDownload(target velerov1api.DownloadTarget) (io.ReadCloser, error) {
+	if target.Kind == velerov1api.DownloadTargetKindBackupContents {
+		return s.GetBackupContents(target.Name)
+	}
+
+	key, err := s.downloadKey(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.objectStore.GetObject(s.bucket, key)
+}
+
+// downloadKey returns the key of the object that a download target refers to.
+func (s *objectBackupStore) downloadKey(target velerov1api.DownloadTarget) (string, error) {
 	switch target.Kind {
+	case velerov1api.DownloadTargetKindBackupMetadata:
+		return s.layout.getBackupMetadataKey(target.Name), nil
 	case velerov1api.DownloadTargetKindBackupContents:
-		return s.objectStore.CreateSignedURL(s.bucket, s.layout.getBackupContentsKey(target.Name), DownloadURLTTL)
+		return s.layout.getBackupContentsKey(target.Name), nil
 	case velerov1api.DownloadTargetKindBackupLog:
-		return s.objectStore.CreateSignedURL(s.bucket, s.layout.getBackupLogKey(target.Name), DownloadURLTTL)
+		return s.layout.getBackupLogKey(target.Name), nil
+	case velerov1api.DownloadTargetKindBackupStructuredLog:
+		return s.layout.getBackupStructuredLogKey(target.Name), nil
 	case velerov1api.DownloadTargetKindBackupVolumeSnapshots:
-		return s.objectStore.CreateSignedURL(s.bucket, s.layout.getBackupVolumeSnapshotsKey(target.Name), DownloadURLTTL)
+		return s.layout.getBackupVolumeSnapshotsKey(target.Name), nil
+	case velerov1api.DownloadTargetKindBackupPodVolumeBackups:
+		return s.layout.getPodVolumeBackupsKey(target.Name), nil
 	case velerov1api.DownloadTargetKindBackupResourceList:
-		return s.objectStore.CreateSignedURL(s.bucket, s.layout.getBackupResourceListKey(target.Name), DownloadURLTTL)
+		return s.layout.getBackupResourceListKey(target.Name), nil
+	case velerov1api.DownloadTargetKindBackupVolumeCoverage:
+		return s.layout.getBackupVolumeCoverageKey(target.Name), nil
 	case velerov1api.DownloadTargetKindRestoreLog:
-		return s.objectStore.CreateSignedURL(s.bucket, s.layout.getRestoreLogKey(target.Name), DownloadURLTTL)
+		return s.layout.getRestoreLogKey(target.Name), nil
+	case velerov1api.DownloadTargetKindRestoreStructuredLog:
+		return s.layout.getRestoreStructuredLogKey(target.Name), nil
 	case velerov1api.DownloadTargetKindRestoreResults:
-		return s.objectStore.CreateSignedURL(s.bucket, s.layout.getRestoreResultsKey(target.Name), DownloadURLTTL)
+		return s.layout.getRestoreResultsKey(target.Name), nil
+	case velerov1api.DownloadTargetKindRestoreManifest:
+		return s.layout.getRestoreManifestKey(target.Name), nil
 	default:
 		return "", errors.Errorf("unsupported download target kind %q", target.Kind)
 	}
@@ -442,21 +815,31 @@ func (s *objectBackupStore) GetRevision() (string, error) {
 		return "", err
 	}
 
-	bytes, err := ioutil.ReadAll(rdr)
+	data, err := ioutil.ReadAll(rdr)
 	if err != nil {
 		return "", errors.Wrap(err, "error reading contents of revision file")
 	}
 
-	return string(bytes), nil
+	revision := string(data)
+	if s.metadataCache != nil {
+		s.cacheRevision = &revision
+	}
+
+	return revision, nil
 }
 
 func (s *objectBackupStore) putRevision() error {
-	rdr := strings.NewReader(uuid.NewV4().String())
+	revision := uuid.NewV4().String()
 
-	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getRevisionKey(), rdr); err != nil {
+	if err := seekAndPutObject(s.objectStore, s.bucket, s.layout.getRevisionKey(), strings.NewReader(revision)); err != nil {
+		s.cacheRevision = nil
 		return errors.Wrap(err, "error updating revision file")
 	}
 
+	if s.metadataCache != nil {
+		s.cacheRevision = &revision
+	}
+
 	return nil
 }
 
@@ -27,6 +27,20 @@ import (
 type ObjectStoreLayout struct {
 	rootPrefix string
 	subdirs    map[string]string
+
+	// backupPath returns the path, within the backups directory, of the
+	// directory that contains a backup's directory. It's only set when
+	// the prefix has backup template variables.
+	backupPath func(backup string) string
+
+	// legacyBackupDir returns the directory of a backup that's stored at
+	// the top level of the backup store, as Ark did before v0.10, or an
+	// empty string if the backup isn't.
+	legacyBackupDir func(backup string) string
+
+	// prefixedBackupDir returns the directory of a backup that's stored
+	// under a storage prefix, or an empty string if the backup isn't.
+	prefixedBackupDir func(backup string) string
 }
 
 func NewObjectStoreLayout(prefix string) *ObjectStoreLayout {
@@ -38,7 +52,10 @@ func NewObjectStoreLayout(prefix string) *ObjectStoreLayout {
 		"backups":  path.Join(prefix, "backups") + "/",
 		"restores": path.Join(prefix, "restores") + "/",
 		"restic":   path.Join(prefix, "restic") + "/",
+		"kopia":    path.Join(prefix, "kopia") + "/",
 		"metadata": path.Join(prefix, "metadata") + "/",
+		"chunks":   path.Join(prefix, "chunks") + "/",
+		"prefixes": path.Join(prefix, "prefixes") + "/",
 	}
 
 	return &ObjectStoreLayout{
@@ -54,6 +71,19 @@ func (l *ObjectStoreLayout) GetResticDir() string {
 	return l.subdirs["restic"]
 }
 
+// GetKopiaDir returns the full prefix representing the kopia
+// directory within an object storage bucket containing a backup
+// store.
+func (l *ObjectStoreLayout) GetKopiaDir() string {
+	return l.subdirs["kopia"]
+}
+
+// getResticRepoConfigKey returns the key of the config file that restic
+// writes when it initializes the repository for a namespace.
+func (l *ObjectStoreLayout) getResticRepoConfigKey(volumeNamespace string) string {
+	return path.Join(l.subdirs["restic"], volumeNamespace, "config")
+}
+
 func (l *ObjectStoreLayout) isValidSubdir(name string) bool {
 	_, ok := l.subdirs[name]
 	return ok
@@ -63,42 +93,111 @@ func (l *ObjectStoreLayout) getRevisionKey() string {
 	return path.Join(l.subdirs["metadata"], "revision")
 }
 
+// getLayoutVersionKey returns the key of the marker that records the
+// version of the layout that the backup store was last migrated to.
+func (l *ObjectStoreLayout) getLayoutVersionKey() string {
+	return path.Join(l.subdirs["metadata"], "layout-version")
+}
+
+// getOwnerKey returns the key of the marker that records which location
+// writes to the backup store.
+func (l *ObjectStoreLayout) getOwnerKey() string {
+	return path.Join(l.subdirs["metadata"], "owner.json")
+}
+
 func (l *ObjectStoreLayout) getBackupDir(backup string) string {
+	if l.legacyBackupDir != nil {
+		if dir := l.legacyBackupDir(backup); dir != "" {
+			return dir
+		}
+	}
+	if l.prefixedBackupDir != nil {
+		if dir := l.prefixedBackupDir(backup); dir != "" {
+			return dir
+		}
+	}
+	if l.backupPath != nil {
+		return path.Join(l.subdirs["backups"], l.backupPath(backup), backup) + "/"
+	}
 	return path.Join(l.subdirs["backups"], backup) + "/"
 }
 
+// getStoragePrefixBackupsDir returns the directory that the backups with
+// a storage prefix are stored in.
+func (l *ObjectStoreLayout) getStoragePrefixBackupsDir(storagePrefix string) string {
+	return path.Join(l.subdirs["prefixes"], storagePrefix, "backups") + "/"
+}
+
 func (l *ObjectStoreLayout) getRestoreDir(restore string) string {
 	return path.Join(l.subdirs["restores"], restore) + "/"
 }
 
 func (l *ObjectStoreLayout) getBackupMetadataKey(backup string) string {
-	return path.Join(l.subdirs["backups"], backup, "velero-backup.json")
+	return path.Join(l.getBackupDir(backup), "velero-backup.json")
+}
+
+// getArkBackupMetadataKey returns the key of the metadata file of a backup
+// that was taken by Ark.
+func (l *ObjectStoreLayout) getArkBackupMetadataKey(backup string) string {
+	return path.Join(l.getBackupDir(backup), "ark-backup.json")
 }
 
 func (l *ObjectStoreLayout) getBackupContentsKey(backup string) string {
-	return path.Join(l.subdirs["backups"], backup, fmt.Sprintf("%s.tar.gz", backup))
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s.tar.gz", backup))
 }
 
 func (l *ObjectStoreLayout) getBackupLogKey(backup string) string {
-	return path.Join(l.subdirs["backups"], backup, fmt.Sprintf("%s-logs.gz", backup))
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s-logs.gz", backup))
+}
+
+func (l *ObjectStoreLayout) getBackupStructuredLogKey(backup string) string {
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s-logs.jsonl.gz", backup))
 }
 
 func (l *ObjectStoreLayout) getPodVolumeBackupsKey(backup string) string {
-	return path.Join(l.subdirs["backups"], backup, fmt.Sprintf("%s-podvolumebackups.json.gz", backup))
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s-podvolumebackups.json.gz", backup))
 }
 
 func (l *ObjectStoreLayout) getBackupVolumeSnapshotsKey(backup string) string {
-	return path.Join(l.subdirs["backups"], backup, fmt.Sprintf("%s-volumesnapshots.json.gz", backup))
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s-volumesnapshots.json.gz", backup))
 }
 
 func (l *ObjectStoreLayout) getBackupResourceListKey(backup string) string {
-	return path.Join(l.subdirs["backups"], backup, fmt.Sprintf("%s-resource-list.json.gz", backup))
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s-resource-list.json.gz", backup))
+}
+
+func (l *ObjectStoreLayout) getBackupVolumeCoverageKey(backup string) string {
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s-volume-coverage.json.gz", backup))
+}
+
+func (l *ObjectStoreLayout) getBackupItemIndexKey(backup string) string {
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s-item-index.json.gz", backup))
+}
+
+func (l *ObjectStoreLayout) getBackupContentsIndexKey(backup string) string {
+	return path.Join(l.getBackupDir(backup), fmt.Sprintf("%s-contents-index.json.gz", backup))
+}
+
+func (l *ObjectStoreLayout) getChunksDir() string {
+	return l.subdirs["chunks"]
+}
+
+func (l *ObjectStoreLayout) getChunkKey(hash string) string {
+	return path.Join(l.subdirs["chunks"], hash)
 }
 
 func (l *ObjectStoreLayout) getRestoreLogKey(restore string) string {
 	return path.Join(l.subdirs["restores"], restore, fmt.Sprintf("restore-%s-logs.gz", restore))
 }
 
+func (l *ObjectStoreLayout) getRestoreStructuredLogKey(restore string) string {
+	return path.Join(l.subdirs["restores"], restore, fmt.Sprintf("restore-%s-logs.jsonl.gz", restore))
+}
+
 func (l *ObjectStoreLayout) getRestoreResultsKey(restore string) string {
 	return path.Join(l.subdirs["restores"], restore, fmt.Sprintf("restore-%s-results.gz", restore))
 }
+
+func (l *ObjectStoreLayout) getRestoreManifestKey(restore string) string {
+	return path.Join(l.subdirs["restores"], restore, fmt.Sprintf("restore-%s-manifest.json.gz", restore))
+}
@@ -27,10 +27,12 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -38,6 +40,7 @@ import (
 	"github.com/heptio/velero/pkg/builder"
 	"github.com/heptio/velero/pkg/cloudprovider"
 	cloudprovidermocks "github.com/heptio/velero/pkg/cloudprovider/mocks"
+	"github.com/heptio/velero/pkg/credentials"
 	"github.com/heptio/velero/pkg/plugin/velero"
 	velerotest "github.com/heptio/velero/pkg/test"
 	"github.com/heptio/velero/pkg/util/encode"
@@ -141,6 +144,20 @@ func TestIsValid(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "backup store at the current layout version is valid",
+			storageData: map[string][]byte{
+				"metadata/layout-version": []byte("1"),
+			},
+			expectErr: false,
+		},
+		{
+			name: "backup store at a newer layout version is invalid",
+			storageData: map[string][]byte{
+				"metadata/layout-version": []byte("2"),
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -208,6 +225,26 @@ func TestListBackups(t *testing.T) {
 	}
 }
 
+func TestListResticRepositories(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("foo", "velero-backups/")
+
+	for _, key := range []string{
+		"velero-backups/restic/app-1/config",
+		"velero-backups/restic/app-1/keys/abc",
+		"velero-backups/restic/app-2/config",
+		// app-3's repository wasn't initialized, so it doesn't have a config file.
+		"velero-backups/restic/app-3/keys/abc",
+	} {
+		require.NoError(t, harness.objectStore.PutObject(harness.bucket, key, newStringReadSeeker("foo")))
+	}
+
+	res, err := harness.ListResticRepositories()
+	require.NoError(t, err)
+
+	sort.Strings(res)
+	assert.Equal(t, []string{"app-1", "app-2"}, res)
+}
+
 func TestPutBackup(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -218,6 +255,7 @@ func TestPutBackup(t *testing.T) {
 		podVolumeBackup io.Reader
 		snapshots       io.Reader
 		resourceList    io.Reader
+		volumeCoverage  io.Reader
 		expectedErr     string
 		expectedKeys    []string
 	}{
@@ -229,6 +267,7 @@ func TestPutBackup(t *testing.T) {
 			podVolumeBackup: newStringReadSeeker("podVolumeBackup"),
 			snapshots:       newStringReadSeeker("snapshots"),
 			resourceList:    newStringReadSeeker("resourceList"),
+			volumeCoverage:  newStringReadSeeker("volumeCoverage"),
 			expectedErr:     "",
 			expectedKeys: []string{
 				"backups/backup-1/velero-backup.json",
@@ -237,6 +276,7 @@ func TestPutBackup(t *testing.T) {
 				"backups/backup-1/backup-1-podvolumebackups.json.gz",
 				"backups/backup-1/backup-1-volumesnapshots.json.gz",
 				"backups/backup-1/backup-1-resource-list.json.gz",
+				"backups/backup-1/backup-1-volume-coverage.json.gz",
 				"metadata/revision",
 			},
 		},
@@ -324,6 +364,7 @@ func TestPutBackup(t *testing.T) {
 				PodVolumeBackups:   tc.podVolumeBackup,
 				VolumeSnapshots:    tc.snapshots,
 				BackupResourceList: tc.resourceList,
+				VolumeCoverage:     tc.volumeCoverage,
 			}
 			err := harness.PutBackup(backupInfo)
 
@@ -343,6 +384,7 @@ func TestGetBackupMetadata(t *testing.T) {
 		key        string
 		obj        metav1.Object
 		wantErr    error
+		wantKind   error
 	}{
 		{
 			name:       "metadata file returns correctly",
@@ -354,6 +396,7 @@ func TestGetBackupMetadata(t *testing.T) {
 			name:       "no metadata file returns an error",
 			backupName: "foo",
 			wantErr:    errors.New("key not found"),
+			wantKind:   ErrBackupNotFound,
 		},
 	}
 
@@ -370,7 +413,8 @@ func TestGetBackupMetadata(t *testing.T) {
 
 			res, err := harness.GetBackupMetadata(tc.backupName)
 			if tc.wantErr != nil {
-				assert.Equal(t, tc.wantErr, err)
+				assert.EqualError(t, err, tc.wantErr.Error())
+				assert.True(t, Is(err, tc.wantKind))
 			} else {
 				require.NoError(t, err)
 
@@ -381,6 +425,29 @@ func TestGetBackupMetadata(t *testing.T) {
 	}
 }
 
+func TestPutBackupMetadata(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+
+	backup := builder.ForBackup(velerov1api.DefaultNamespace, "foo").TTL(time.Hour).Result()
+	jsonBytes, err := json.Marshal(backup)
+	require.NoError(t, err)
+	require.NoError(t, harness.objectStore.PutObject(harness.bucket, "backups/foo/velero-backup.json", bytes.NewReader(jsonBytes)))
+
+	// read it first, so that replacing it must invalidate any cached copy.
+	res, err := harness.GetBackupMetadata("foo")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, res.Spec.TTL.Duration)
+
+	// backups from a lister don't have their type set.
+	backup.TypeMeta = metav1.TypeMeta{}
+	backup.Spec.TTL.Duration = 90 * 24 * time.Hour
+	require.NoError(t, harness.PutBackupMetadata(backup))
+
+	res, err = harness.GetBackupMetadata("foo")
+	require.NoError(t, err)
+	assert.Equal(t, 90*24*time.Hour, res.Spec.TTL.Duration)
+}
+
 func TestGetBackupVolumeSnapshots(t *testing.T) {
 	harness := newObjectBackupStoreTestHarness("test-bucket", "")
 
@@ -423,6 +490,32 @@ func TestGetBackupVolumeSnapshots(t *testing.T) {
 	assert.EqualValues(t, snapshots, res)
 }
 
+func TestGetBackupItemIndex(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+
+	// item index file not found should not error
+	res, err := harness.GetBackupItemIndex("test-backup")
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	index := BackupItemIndex{
+		"resources/pods/namespaces/ns-1/pod-1.json": {Hash: "hash-1", Backup: "test-backup"},
+		"resources/pods/namespaces/ns-1/pod-2.json": {Hash: "hash-2", Backup: "other-backup"},
+	}
+
+	obj := new(bytes.Buffer)
+	gzw := gzip.NewWriter(obj)
+
+	require.NoError(t, json.NewEncoder(gzw).Encode(index))
+	require.NoError(t, gzw.Close())
+	require.NoError(t, harness.objectStore.PutObject(harness.bucket, "backups/test-backup/test-backup-item-index.json.gz", obj))
+
+	res, err = harness.GetBackupItemIndex("test-backup")
+	assert.NoError(t, err)
+	assert.Equal(t, index, res)
+	assert.Equal(t, []string{"other-backup"}, res.ReferencedBackups("test-backup"))
+}
+
 func TestGetBackupContents(t *testing.T) {
 	harness := newObjectBackupStoreTestHarness("test-bucket", "")
 
@@ -490,6 +583,45 @@ func TestDeleteBackup(t *testing.T) {
 	}
 }
 
+// batchDeleterObjectStore is an object store that supports deleting objects in batches.
+type batchDeleterObjectStore struct {
+	*cloudprovidermocks.ObjectStore
+}
+
+func (o *batchDeleterObjectStore) DeleteObjects(bucket string, keys []string) error {
+	return o.Called(bucket, keys).Error(0)
+}
+
+func TestDeleteBackupWithBatchDeleter(t *testing.T) {
+	objectStore := &batchDeleterObjectStore{ObjectStore: new(cloudprovidermocks.ObjectStore)}
+	defer objectStore.AssertExpectations(t)
+
+	backupStore := &objectBackupStore{
+		objectStore: objectStore,
+		bucket:      "test-bucket",
+		layout:      NewObjectStoreLayout(""),
+		logger:      velerotest.NewLogger(),
+	}
+
+	objects := []string{"backups/bak/velero-backup.json", "backups/bak/bak.tar.gz"}
+	objectStore.On("ListObjects", "test-bucket", "backups/bak/").Return(objects, nil)
+	objectStore.On("PutObject", "test-bucket", "metadata/revision", mock.Anything).Return(nil)
+
+	// the objects are deleted in a single call.
+	objectStore.On("DeleteObjects", "test-bucket", objects).Return(nil).Once()
+	require.NoError(t, backupStore.DeleteBackup("bak"))
+
+	// object stores whose plugins don't support deleting objects in
+	// batches delete them one at a time.
+	objectStore.On("DeleteObjects", "test-bucket", objects).Return(velero.ErrBatchDeleteNotSupported).Once()
+	objectStore.On("DeleteObject", "test-bucket", objects[0]).Return(nil).Once()
+	objectStore.On("DeleteObject", "test-bucket", objects[1]).Return(nil).Once()
+	require.NoError(t, backupStore.DeleteBackup("bak"))
+
+	objectStore.On("DeleteObjects", "test-bucket", objects).Return(errors.New("delete error")).Once()
+	assert.EqualError(t, backupStore.DeleteBackup("bak"), "delete error")
+}
+
 func TestGetDownloadURL(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -505,6 +637,7 @@ func TestGetDownloadURL(t *testing.T) {
 				velerov1api.DownloadTargetKindBackupLog:             "backups/my-backup/my-backup-logs.gz",
 				velerov1api.DownloadTargetKindBackupVolumeSnapshots: "backups/my-backup/my-backup-volumesnapshots.json.gz",
 				velerov1api.DownloadTargetKindBackupResourceList:    "backups/my-backup/my-backup-resource-list.json.gz",
+				velerov1api.DownloadTargetKindBackupVolumeCoverage:  "backups/my-backup/my-backup-volume-coverage.json.gz",
 			},
 		},
 		{
@@ -516,6 +649,7 @@ func TestGetDownloadURL(t *testing.T) {
 				velerov1api.DownloadTargetKindBackupLog:             "velero-backups/backups/my-backup/my-backup-logs.gz",
 				velerov1api.DownloadTargetKindBackupVolumeSnapshots: "velero-backups/backups/my-backup/my-backup-volumesnapshots.json.gz",
 				velerov1api.DownloadTargetKindBackupResourceList:    "velero-backups/backups/my-backup/my-backup-resource-list.json.gz",
+				velerov1api.DownloadTargetKindBackupVolumeCoverage:  "velero-backups/backups/my-backup/my-backup-volume-coverage.json.gz",
 			},
 		},
 		{
@@ -526,16 +660,21 @@ func TestGetDownloadURL(t *testing.T) {
 				velerov1api.DownloadTargetKindBackupLog:             "backups/b-cool-20170913154901-20170913154902/b-cool-20170913154901-20170913154902-logs.gz",
 				velerov1api.DownloadTargetKindBackupVolumeSnapshots: "backups/b-cool-20170913154901-20170913154902/b-cool-20170913154901-20170913154902-volumesnapshots.json.gz",
 				velerov1api.DownloadTargetKindBackupResourceList:    "backups/b-cool-20170913154901-20170913154902/b-cool-20170913154901-20170913154902-resource-list.json.gz",
+				velerov1api.DownloadTargetKindBackupVolumeCoverage:  "backups/b-cool-20170913154901-20170913154902/b-cool-20170913154901-20170913154902-volume-coverage.json.gz",
 			},
 		},
 		{
 			name:       "scheduled backup",
 			targetName: "my-backup-20170913154901",
 			expectedKeyByKind: map[velerov1api.DownloadTargetKind]string{
-				velerov1api.DownloadTargetKindBackupContents:        "backups/my-backup-20170913154901/my-backup-20170913154901.tar.gz",
-				velerov1api.DownloadTargetKindBackupLog:             "backups/my-backup-20170913154901/my-backup-20170913154901-logs.gz",
-				velerov1api.DownloadTargetKindBackupVolumeSnapshots: "backups/my-backup-20170913154901/my-backup-20170913154901-volumesnapshots.json.gz",
-				velerov1api.DownloadTargetKindBackupResourceList:    "backups/my-backup-20170913154901/my-backup-20170913154901-resource-list.json.gz",
+				velerov1api.DownloadTargetKindBackupContents:         "backups/my-backup-20170913154901/my-backup-20170913154901.tar.gz",
+				velerov1api.DownloadTargetKindBackupLog:              "backups/my-backup-20170913154901/my-backup-20170913154901-logs.gz",
+				velerov1api.DownloadTargetKindBackupStructuredLog:    "backups/my-backup-20170913154901/my-backup-20170913154901-logs.jsonl.gz",
+				velerov1api.DownloadTargetKindBackupVolumeSnapshots:  "backups/my-backup-20170913154901/my-backup-20170913154901-volumesnapshots.json.gz",
+				velerov1api.DownloadTargetKindBackupPodVolumeBackups: "backups/my-backup-20170913154901/my-backup-20170913154901-podvolumebackups.json.gz",
+				velerov1api.DownloadTargetKindBackupMetadata:         "backups/my-backup-20170913154901/velero-backup.json",
+				velerov1api.DownloadTargetKindBackupResourceList:     "backups/my-backup-20170913154901/my-backup-20170913154901-resource-list.json.gz",
+				velerov1api.DownloadTargetKindBackupVolumeCoverage:   "backups/my-backup-20170913154901/my-backup-20170913154901-volume-coverage.json.gz",
 			},
 		},
 		{
@@ -543,18 +682,23 @@ func TestGetDownloadURL(t *testing.T) {
 			targetName: "my-backup-20170913154901",
 			prefix:     "velero-backups/",
 			expectedKeyByKind: map[velerov1api.DownloadTargetKind]string{
-				velerov1api.DownloadTargetKindBackupContents:        "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901.tar.gz",
-				velerov1api.DownloadTargetKindBackupLog:             "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901-logs.gz",
-				velerov1api.DownloadTargetKindBackupVolumeSnapshots: "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901-volumesnapshots.json.gz",
-				velerov1api.DownloadTargetKindBackupResourceList:    "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901-resource-list.json.gz",
+				velerov1api.DownloadTargetKindBackupContents:         "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901.tar.gz",
+				velerov1api.DownloadTargetKindBackupLog:              "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901-logs.gz",
+				velerov1api.DownloadTargetKindBackupVolumeSnapshots:  "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901-volumesnapshots.json.gz",
+				velerov1api.DownloadTargetKindBackupPodVolumeBackups: "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901-podvolumebackups.json.gz",
+				velerov1api.DownloadTargetKindBackupMetadata:         "velero-backups/backups/my-backup-20170913154901/velero-backup.json",
+				velerov1api.DownloadTargetKindBackupResourceList:     "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901-resource-list.json.gz",
+				velerov1api.DownloadTargetKindBackupVolumeCoverage:   "velero-backups/backups/my-backup-20170913154901/my-backup-20170913154901-volume-coverage.json.gz",
 			},
 		},
 		{
 			name:       "restore",
 			targetName: "my-backup",
 			expectedKeyByKind: map[velerov1api.DownloadTargetKind]string{
-				velerov1api.DownloadTargetKindRestoreLog:     "restores/my-backup/restore-my-backup-logs.gz",
-				velerov1api.DownloadTargetKindRestoreResults: "restores/my-backup/restore-my-backup-results.gz",
+				velerov1api.DownloadTargetKindRestoreLog:           "restores/my-backup/restore-my-backup-logs.gz",
+				velerov1api.DownloadTargetKindRestoreResults:       "restores/my-backup/restore-my-backup-results.gz",
+				velerov1api.DownloadTargetKindRestoreStructuredLog: "restores/my-backup/restore-my-backup-logs.jsonl.gz",
+				velerov1api.DownloadTargetKindRestoreManifest:      "restores/my-backup/restore-my-backup-manifest.json.gz",
 			},
 		},
 		{
@@ -584,15 +728,41 @@ func TestGetDownloadURL(t *testing.T) {
 				t.Run(string(kind), func(t *testing.T) {
 					require.NoError(t, harness.objectStore.PutObject("test-bucket", expectedKey, newStringReadSeeker("foo")))
 
-					url, err := harness.GetDownloadURL(velerov1api.DownloadTarget{Kind: kind, Name: test.targetName})
+					url, err := harness.GetDownloadURL(velerov1api.DownloadTarget{Kind: kind, Name: test.targetName}, DefaultDownloadURLTTL)
 					require.NoError(t, err)
 					assert.Equal(t, "a-url", url)
+
+					rc, err := harness.GetDownload(velerov1api.DownloadTarget{Kind: kind, Name: test.targetName})
+					require.NoError(t, err)
+					defer rc.Close()
+					contents, err := ioutil.ReadAll(rc)
+					require.NoError(t, err)
+					assert.Equal(t, "foo", string(contents))
 				})
 			}
 		})
 	}
 }
 
+func TestGetDownloadURLWithoutSignedURLs(t *testing.T) {
+	objectStore := new(cloudprovidermocks.ObjectStore)
+	defer objectStore.AssertExpectations(t)
+
+	store := &objectBackupStore{
+		objectStore: objectStore,
+		bucket:      "test-bucket",
+		layout:      NewObjectStoreLayout(""),
+		logger:      velerotest.NewLogger(),
+	}
+
+	objectStore.On("Capabilities").Return(velero.ObjectStoreCapabilities{}, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		_, err := store.GetDownloadURL(velerov1api.DownloadTarget{Kind: velerov1api.DownloadTargetKindBackupLog, Name: "backup-1"}, DefaultDownloadURLTTL)
+		assert.EqualError(t, err, "download URLs are not supported because the backup storage location's object store doesn't support signed URLs")
+	}
+}
+
 type objectStoreGetter map[string]velero.ObjectStore
 
 func (osg objectStoreGetter) GetObjectStore(provider string) (velero.ObjectStore, error) {
@@ -677,6 +847,47 @@ func TestNewObjectBackupStore(t *testing.T) {
 	}
 }
 
+type credentialFileStore map[string]string
+
+func (s credentialFileStore) Path(selector *corev1api.SecretKeySelector) (string, error) {
+	res, ok := s[selector.Name+"/"+selector.Key]
+	if !ok {
+		return "", errors.New("credential not found")
+	}
+
+	return res, nil
+}
+
+func (s credentialFileStore) Refresh(*corev1api.Secret) (bool, error) {
+	return false, nil
+}
+
+func TestNewObjectBackupStoreWithCredential(t *testing.T) {
+	credentials.SetDefaultFileStore(credentialFileStore{"bucket-2-credentials/cloud": "/tmp/credentials/cloud"})
+	defer credentials.SetDefaultFileStore(nil)
+
+	objectStore := new(cloudprovidermocks.ObjectStore)
+	defer objectStore.AssertExpectations(t)
+	objectStore.On("Init", map[string]string{
+		"bucket":                               "bucket",
+		"region":                               "us-east-1",
+		cloudprovider.CredentialsFileConfigKey: "/tmp/credentials/cloud",
+	}).Return(nil)
+
+	location := builder.ForBackupStorageLocation("", "").Provider("provider-1").Bucket("bucket").Credential("bucket-2-credentials", "cloud").Result()
+	location.Spec.Config = map[string]string{"region": "us-east-1"}
+
+	_, err := NewObjectBackupStore(location, objectStoreGetter{"provider-1": objectStore}, velerotest.NewLogger())
+	require.NoError(t, err)
+
+	// the credentials file isn't added to the location itself.
+	assert.NotContains(t, location.Spec.Config, cloudprovider.CredentialsFileConfigKey)
+
+	location.Spec.Credential.Name = "other-credentials"
+	_, err = NewObjectBackupStore(location, objectStoreGetter{"provider-1": objectStore}, velerotest.NewLogger())
+	assert.EqualError(t, err, "credential not found")
+}
+
 func encodeToBytes(obj runtime.Object) []byte {
 	res, err := encode.Encode(obj, "json")
 	if err != nil {
@@ -0,0 +1,128 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+// bucketConfigKeys are the provider config keys that, along with the
+// bucket name, identify a bucket, e.g. a bucket in an S3-compatible
+// object store other than AWS's, or a container in an Azure storage
+// account.
+var bucketConfigKeys = []string{"s3Url", "storageAccount"}
+
+// LocationsOverlap returns whether two backup storage locations store
+// their data in the same bucket or directory, with one's prefix equal to
+// or containing the other's, so that each would see the other's data.
+func LocationsOverlap(a, b *velerov1api.BackupStorageLocation) bool {
+	aStorage, aPrefix := locationStorage(a)
+	bStorage, bPrefix := locationStorage(b)
+	if aStorage == "" || aStorage != bStorage {
+		return false
+	}
+
+	return aPrefix == "" || bPrefix == "" ||
+		strings.HasPrefix(aPrefix+"/", bPrefix+"/") ||
+		strings.HasPrefix(bPrefix+"/", aPrefix+"/")
+}
+
+// locationStorage returns an identifier of the bucket or directory that a
+// backup storage location stores its data in, and the part of its prefix
+// that's the same for all of its backups.
+func locationStorage(location *velerov1api.BackupStorageLocation) (string, string) {
+	var storage, prefix string
+	switch {
+	case location.Spec.ObjectStorage != nil:
+		storage = fmt.Sprintf("%s:%s", location.Spec.Provider, strings.Trim(location.Spec.ObjectStorage.Bucket, "/"))
+		for _, key := range bucketConfigKeys {
+			if val := location.Spec.Config[key]; val != "" {
+				storage += fmt.Sprintf(",%s=%s", key, val)
+			}
+		}
+		prefix = location.Spec.ObjectStorage.Prefix
+	case location.Spec.Filesystem != nil:
+		storage = "filesystem:" + filepath.Clean(location.Spec.Filesystem.Path)
+		prefix = location.Spec.Filesystem.Prefix
+	default:
+		return "", ""
+	}
+
+	prefix = strings.Trim(prefix, "/")
+	if expanded, err := ExpandPrefix(prefix); err == nil {
+		prefix = expanded
+	}
+
+	return storage, prefix
+}
+
+// LocationOwner identifies the backup storage location, and the cluster
+// that it's in, that writes to a backup store.
+type LocationOwner struct {
+	// ClusterID is the UID of the cluster's kube-system namespace.
+	ClusterID string `json:"clusterID"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+func (o LocationOwner) String() string {
+	return fmt.Sprintf("backup storage location %s/%s in cluster %s", o.Namespace, o.Name, o.ClusterID)
+}
+
+// ClaimOwnership records in the backup store's ownership marker that a
+// location writes to it, unless the marker says that another location
+// does, in which case it returns an error.
+func (s *objectBackupStore) ClaimOwnership(owner LocationOwner) error {
+	key := s.layout.getOwnerKey()
+
+	res, err := tryGet(s.objectStore, s.bucket, key)
+	if err != nil {
+		return errors.Wrap(err, "error getting backup store's ownership marker")
+	}
+	if res != nil {
+		defer res.Close()
+
+		var existing LocationOwner
+		if err := json.NewDecoder(res).Decode(&existing); err != nil {
+			return errors.Wrap(err, "error decoding backup store's ownership marker")
+		}
+
+		if existing != owner {
+			return errors.Errorf("backup store is owned by %s; delete its ownership marker, %s, if that location no longer writes to it", existing, key)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(owner)
+	if err != nil {
+		return errors.Wrap(err, "error encoding backup store's ownership marker")
+	}
+
+	if err := s.objectStore.PutObject(s.bucket, key, bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "error putting backup store's ownership marker")
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+)
+
+func TestLocationsOverlap(t *testing.T) {
+	newLocation := func(provider, bucket, prefix string, config ...string) *velerov1api.BackupStorageLocation {
+		location := builder.ForBackupStorageLocation("velero", "location").Provider(provider).Bucket(bucket).Prefix(prefix).Result()
+		location.Spec.Config = map[string]string{}
+		for i := 0; i+1 < len(config); i += 2 {
+			location.Spec.Config[config[i]] = config[i+1]
+		}
+		return location
+	}
+
+	tests := []struct {
+		name     string
+		a, b     *velerov1api.BackupStorageLocation
+		expected bool
+	}{
+		{
+			name:     "same bucket and prefix overlap",
+			a:        newLocation("aws", "bucket", "velero"),
+			b:        newLocation("aws", "bucket", "/velero/"),
+			expected: true,
+		},
+		{
+			name:     "empty prefix overlaps with any prefix",
+			a:        newLocation("aws", "bucket", ""),
+			b:        newLocation("aws", "bucket", "velero"),
+			expected: true,
+		},
+		{
+			name:     "nested prefixes overlap",
+			a:        newLocation("aws", "bucket", "velero"),
+			b:        newLocation("aws", "bucket", "velero/cluster-1"),
+			expected: true,
+		},
+		{
+			name: "prefixes that share a string prefix don't overlap",
+			a:    newLocation("aws", "bucket", "velero"),
+			b:    newLocation("aws", "bucket", "velero-2"),
+		},
+		{
+			name: "different buckets don't overlap",
+			a:    newLocation("aws", "bucket-1", ""),
+			b:    newLocation("aws", "bucket-2", ""),
+		},
+		{
+			name: "different providers don't overlap",
+			a:    newLocation("aws", "bucket", ""),
+			b:    newLocation("gcp", "bucket", ""),
+		},
+		{
+			name: "different storage accounts don't overlap",
+			a:    newLocation("azure", "container", "", "storageAccount", "account-1"),
+			b:    newLocation("azure", "container", "", "storageAccount", "account-2"),
+		},
+		{
+			name:     "same filesystem directory overlaps",
+			a:        newFilesystemLocation("/mnt/velero/", ""),
+			b:        newFilesystemLocation("/mnt/velero", "cluster-1"),
+			expected: true,
+		},
+		{
+			name: "filesystem and object storage don't overlap",
+			a:    newFilesystemLocation("/mnt/velero", ""),
+			b:    newLocation("aws", "bucket", ""),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, LocationsOverlap(test.a, test.b))
+			assert.Equal(t, test.expected, LocationsOverlap(test.b, test.a))
+		})
+	}
+}
+
+func TestClaimOwnership(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "velero")
+
+	owner := LocationOwner{ClusterID: "cluster-1", Namespace: "velero", Name: "default"}
+	require.NoError(t, harness.ClaimOwnership(owner))
+	assert.Contains(t, harness.objectStore.Data["test-bucket"], "velero/metadata/owner.json")
+
+	// claiming it again is a no-op
+	require.NoError(t, harness.ClaimOwnership(owner))
+
+	foreign := LocationOwner{ClusterID: "cluster-2", Namespace: "velero", Name: "default"}
+	assert.EqualError(t, harness.ClaimOwnership(foreign), "backup store is owned by backup storage location velero/default in cluster cluster-1; delete its ownership marker, velero/metadata/owner.json, if that location no longer writes to it")
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+// ClusterNameEnvVar is the environment variable that holds the value of
+// the {{.Cluster}} template variable in backup storage location prefixes.
+const ClusterNameEnvVar = "VELERO_CLUSTER_NAME"
+
+// UnscheduledBackupPath is the value of the {{.ScheduleName}} template
+// variable for backups that weren't created by a schedule.
+const UnscheduledBackupPath = "unscheduled"
+
+// prefixData holds the values of the template variables that a backup
+// storage location's prefix can contain. Cluster is the same for all of
+// a location's backups; the others are backup template variables, which
+// depend on the backup.
+type prefixData struct {
+	Cluster      string
+	ScheduleName string
+	Year         string
+	Month        string
+	Day          string
+}
+
+var backupTemplateVariable = regexp.MustCompile(`\.(ScheduleName|Year|Month|Day)\b`)
+
+// backupPrefixData returns the values of the template variables for a
+// backup.
+func backupPrefixData(backup *velerov1api.Backup) prefixData {
+	data := prefixData{
+		Cluster:      os.Getenv(ClusterNameEnvVar),
+		ScheduleName: backup.Labels[velerov1api.ScheduleNameLabel],
+		Year:         backup.CreationTimestamp.UTC().Format("2006"),
+		Month:        backup.CreationTimestamp.UTC().Format("01"),
+		Day:          backup.CreationTimestamp.UTC().Format("02"),
+	}
+	if data.ScheduleName == "" {
+		data.ScheduleName = UnscheduledBackupPath
+	}
+
+	return data
+}
+
+// backupPathTemplate is the part of a prefix that has backup template
+// variables.
+type backupPathTemplate struct {
+	tmpl *template.Template
+	// depth is the number of path segments that the template expands to.
+	depth int
+}
+
+func (t *backupPathTemplate) expand(data prefixData) (string, error) {
+	res, err := expandPrefixTemplate(t.tmpl, data)
+	if err != nil {
+		return "", err
+	}
+	if n := len(strings.Split(res, "/")); n != t.depth {
+		return "", errors.Errorf("prefix template %q expands to %q, which has %d path segments instead of %d", t.tmpl.Root.String(), res, n, t.depth)
+	}
+
+	return res, nil
+}
+
+// ExpandPrefix returns the part of a backup storage location's prefix that's
+// the same for all of the location's backups, with its template variables
+// expanded. The backup store's directories, other than the backups
+// themselves, are under it.
+func ExpandPrefix(prefix string) (string, error) {
+	root, _, err := splitPrefix(prefix)
+	return root, err
+}
+
+// splitPrefix expands the template variables in a backup storage location's
+// prefix. It returns the part of the prefix that's the same for all of the
+// location's backups, which is where the backup store's directories are, and
+// the template for the rest of it, starting from the first path segment with
+// a backup template variable, if any. Backups are stored under that path
+// within the backups directory, so that it can be listed.
+func splitPrefix(prefix string) (string, *backupPathTemplate, error) {
+	segments := strings.Split(strings.Trim(prefix, "/"), "/")
+
+	i := 0
+	for ; i < len(segments); i++ {
+		if backupTemplateVariable.MatchString(segments[i]) {
+			break
+		}
+	}
+
+	rootTmpl, err := parsePrefixTemplate(strings.Join(segments[:i], "/"))
+	if err != nil {
+		return "", nil, err
+	}
+	root, err := expandPrefixTemplate(rootTmpl, prefixData{Cluster: os.Getenv(ClusterNameEnvVar)})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if i == len(segments) {
+		return root, nil, nil
+	}
+
+	backupTmpl, err := parsePrefixTemplate(strings.Join(segments[i:], "/"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return root, &backupPathTemplate{tmpl: backupTmpl, depth: len(segments) - i}, nil
+}
+
+func parsePrefixTemplate(prefix string) (*template.Template, error) {
+	tmpl, err := template.New("prefix").Option("missingkey=error").Parse(prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing prefix %q", prefix)
+	}
+	return tmpl, nil
+}
+
+func expandPrefixTemplate(tmpl *template.Template, data prefixData) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", errors.Wrapf(err, "error expanding prefix %q", tmpl.Root.String())
+	}
+
+	if tmpl.Root.String() == "" {
+		return "", nil
+	}
+
+	res := buf.String()
+	// an empty path segment, e.g. from {{.Cluster}} when the cluster name
+	// isn't set, would silently change where the backups are stored.
+	for _, segment := range strings.Split(res, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", errors.Errorf("prefix %q expands to %q, which has an empty or relative path segment", tmpl.Root.String(), res)
+		}
+	}
+
+	return path.Clean(res), nil
+}
+
+// setLayout sets the backup store's layout for a location's prefix.
+func (s *objectBackupStore) setLayout(prefix string) error {
+	root, backupPath, err := splitPrefix(prefix)
+	if err != nil {
+		return err
+	}
+
+	s.layout = NewObjectStoreLayout(root)
+	s.layout.prefixedBackupDir = s.getPrefixedBackupDir
+	if backupPath != nil {
+		s.backupPathTemplate = backupPath
+		s.backupPaths = make(map[string]string)
+		s.layout.backupPath = s.getBackupPath
+	} else {
+		// a location with backup template variables in its prefix can't
+		// have any backups from before Ark v0.10.
+		s.layout.legacyBackupDir = s.getLegacyBackupDir
+	}
+
+	return nil
+}
+
+// getBackupPath returns the path within the backups directory of the
+// directory that a backup is stored in, listing the backups if it isn't
+// known yet.
+func (s *objectBackupStore) getBackupPath(name string) string {
+	if res, ok := s.backupPaths[name]; ok {
+		return res
+	}
+
+	if _, err := s.listTemplatedBackups(); err != nil {
+		s.logger.WithError(err).WithField("backup", name).Warn("Error listing backups to find where backup is stored")
+	}
+
+	return s.backupPaths[name]
+}
+
+// setBackupPath records where a backup that's being written to the backup
+// store is stored, which is under its storage prefix if it has one, or else
+// found by expanding the backup template variables in the location's prefix.
+func (s *objectBackupStore) setBackupPath(name string, backup *velerov1api.Backup) error {
+	if prefixed, err := s.setStoragePrefix(name, backup); prefixed || err != nil {
+		return err
+	}
+
+	if s.backupPathTemplate == nil {
+		return nil
+	}
+	if backup == nil {
+		return errors.Errorf("backup %s is required to expand the backup storage location's prefix", name)
+	}
+
+	res, err := s.backupPathTemplate.expand(backupPrefixData(backup))
+	if err != nil {
+		return err
+	}
+	s.backupPaths[name] = res
+
+	return nil
+}
+
+// listTemplatedBackups lists the backups in a backup store whose prefix has
+// backup template variables, by walking the directories that the template
+// expands to within the backups directory, and records where each backup
+// is stored.
+func (s *objectBackupStore) listTemplatedBackups() ([]string, error) {
+	backupsDir := s.layout.subdirs["backups"]
+
+	dirs := []string{backupsDir}
+	for i := 0; i <= s.backupPathTemplate.depth; i++ {
+		next := sets.NewString()
+		for _, dir := range dirs {
+			prefixes, err := s.objectStore.ListCommonPrefixes(s.bucket, dir, "/")
+			if err != nil {
+				return nil, err
+			}
+			next.Insert(prefixes...)
+		}
+		dirs = next.List()
+	}
+
+	output := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		dir = strings.TrimSuffix(strings.TrimPrefix(dir, backupsDir), "/")
+		backupPath, name := path.Split(dir)
+		backupPath = strings.TrimSuffix(backupPath, "/")
+
+		if existing, ok := s.backupPaths[name]; ok && existing != backupPath {
+			s.logger.WithField("backup", name).Warnf("Ignoring backup in %s because there's another backup with the same name in %s", backupPath, existing)
+			continue
+		}
+		s.backupPaths[name] = backupPath
+
+		output = append(output, name)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestSplitPrefix(t *testing.T) {
+	tests := []struct {
+		name               string
+		prefix             string
+		cluster            string
+		expectedRoot       string
+		expectedBackupPath string
+		expectedErr        string
+	}{
+		{
+			name: "empty prefix",
+		},
+		{
+			name:         "prefix without template variables",
+			prefix:       "/velero/backups/",
+			expectedRoot: "velero/backups",
+		},
+		{
+			name:         "cluster is expanded",
+			prefix:       "clusters/{{.Cluster}}",
+			cluster:      "cluster-1",
+			expectedRoot: "clusters/cluster-1",
+		},
+		{
+			name:        "unset cluster is invalid",
+			prefix:      "clusters/{{.Cluster}}",
+			expectedErr: `prefix "clusters/{{.Cluster}}" expands to "clusters/", which has an empty or relative path segment`,
+		},
+		{
+			name:               "backup template variables are split off",
+			prefix:             "{{.Cluster}}/{{.ScheduleName}}/{{.Year}}-{{.Month}}",
+			cluster:            "cluster-1",
+			expectedRoot:       "cluster-1",
+			expectedBackupPath: "{{.ScheduleName}}/{{.Year}}-{{.Month}}",
+		},
+		{
+			name:        "unknown variable is invalid",
+			prefix:      "{{.Namespace}}",
+			expectedErr: `error expanding prefix "{{.Namespace}}": template: prefix:1:2: executing "prefix" at <.Namespace>: can't evaluate field Namespace in type persistence.prefixData`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer os.Unsetenv(ClusterNameEnvVar)
+			os.Setenv(ClusterNameEnvVar, test.cluster)
+
+			root, backupPath, err := splitPrefix(test.prefix)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRoot, root)
+
+			if test.expectedBackupPath == "" {
+				assert.Nil(t, backupPath)
+			} else {
+				require.NotNil(t, backupPath)
+				assert.Equal(t, test.expectedBackupPath, backupPath.tmpl.Root.String())
+			}
+		})
+	}
+}
+
+func TestTemplatedPrefix(t *testing.T) {
+	defer os.Unsetenv(ClusterNameEnvVar)
+	os.Setenv(ClusterNameEnvVar, "cluster-1")
+
+	const prefix = "clusters/{{.Cluster}}/{{.ScheduleName}}/{{.Year}}/{{.Month}}"
+
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+	require.NoError(t, harness.setLayout(prefix))
+
+	created := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	backups := []*velerov1api.Backup{
+		builder.ForBackup("velero", "scheduled").ObjectMeta(builder.WithLabels(velerov1api.ScheduleNameLabel, "daily")).Result(),
+		builder.ForBackup("velero", "manual").Result(),
+	}
+	for _, backup := range backups {
+		backup.CreationTimestamp = metav1.NewTime(created)
+		require.NoError(t, harness.PutBackup(BackupInfo{
+			Name:     backup.Name,
+			Backup:   backup,
+			Metadata: newStringReadSeeker("metadata"),
+			Contents: newStringReadSeeker("contents"),
+		}))
+	}
+
+	assert.Contains(t, harness.objectStore.Data["test-bucket"], "clusters/cluster-1/backups/daily/2019/10/scheduled/velero-backup.json")
+	assert.Contains(t, harness.objectStore.Data["test-bucket"], "clusters/cluster-1/backups/unscheduled/2019/10/manual/velero-backup.json")
+	assert.NoError(t, harness.IsValid())
+
+	// a new backup store finds the backups by listing them.
+	store := &objectBackupStore{
+		objectStore: harness.objectStore,
+		bucket:      "test-bucket",
+		logger:      velerotest.NewLogger(),
+	}
+	require.NoError(t, store.setLayout(prefix))
+
+	exists, err := store.BackupExists("test-bucket", "scheduled")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	res, err := store.ListBackups()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"scheduled", "manual"}, res)
+
+	require.NoError(t, store.DeleteBackup("manual"))
+	assert.NotContains(t, harness.objectStore.Data["test-bucket"], "clusters/cluster-1/backups/unscheduled/2019/10/manual/velero-backup.json")
+
+	assert.EqualError(t, store.PutBackup(BackupInfo{Name: "backup-1"}), "backup backup-1 is required to expand the backup storage location's prefix")
+}
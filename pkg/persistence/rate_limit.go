@@ -0,0 +1,173 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/flowcontrol"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+// ValidateRateLimit returns an error if a backup storage location's rate
+// limit is invalid.
+func ValidateRateLimit(limit *velerov1api.BackupStorageLocationRateLimit) error {
+	if limit == nil {
+		return nil
+	}
+	if limit.RequestsPerSecond <= 0 {
+		return errors.Errorf("rate limit's requests per second must be greater than zero, not %d", limit.RequestsPerSecond)
+	}
+	if limit.Burst < 0 {
+		return errors.Errorf("rate limit's burst must not be negative, not %d", limit.Burst)
+	}
+	return nil
+}
+
+// rateLimiters holds the rate limiter of each backup storage location that
+// has a rate limit. Each controller gets its own backup store, so they're
+// kept here so that all of them share a location's limit.
+var rateLimiters = newRateLimiterRegistry()
+
+type rateLimiterRegistry struct {
+	lock     sync.Mutex
+	limiters map[string]*locationRateLimiter
+}
+
+type locationRateLimiter struct {
+	limit   velerov1api.BackupStorageLocationRateLimit
+	limiter flowcontrol.RateLimiter
+}
+
+func newRateLimiterRegistry() *rateLimiterRegistry {
+	return &rateLimiterRegistry{
+		limiters: make(map[string]*locationRateLimiter),
+	}
+}
+
+// get returns the rate limiter of a backup storage location, or nil if the
+// location doesn't have a rate limit. A location's rate limiter is replaced
+// when its rate limit changes.
+func (r *rateLimiterRegistry) get(location *velerov1api.BackupStorageLocation) flowcontrol.RateLimiter {
+	key := location.Namespace + "/" + location.Name
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if location.Spec.RateLimit == nil {
+		delete(r.limiters, key)
+		return nil
+	}
+
+	limit := *location.Spec.RateLimit
+	if existing, ok := r.limiters[key]; ok && existing.limit == limit {
+		return existing.limiter
+	}
+
+	burst := limit.Burst
+	if burst == 0 {
+		burst = limit.RequestsPerSecond
+	}
+
+	limiter := &locationRateLimiter{
+		limit:   limit,
+		limiter: flowcontrol.NewTokenBucketRateLimiter(float32(limit.RequestsPerSecond), burst),
+	}
+	r.limiters[key] = limiter
+
+	return limiter.limiter
+}
+
+// rateLimitedObjectStore is an ObjectStore that waits for its rate limiter
+// before each call that makes requests to object storage. Each call counts
+// as one request, even if the plugin makes several, e.g. to list a large
+// number of objects or to upload an object in parts. Signing URLs and
+// getting capabilities don't make requests, so they aren't limited.
+type rateLimitedObjectStore struct {
+	velero.ObjectStore
+	limiter flowcontrol.RateLimiter
+}
+
+// rateLimit returns an ObjectStore that limits the rate of requests to a
+// backup storage location's object storage, if the location has a rate
+// limit.
+func rateLimit(location *velerov1api.BackupStorageLocation, objectStore velero.ObjectStore) velero.ObjectStore {
+	limiter := rateLimiters.get(location)
+	if limiter == nil {
+		return objectStore
+	}
+
+	return &rateLimitedObjectStore{
+		ObjectStore: objectStore,
+		limiter:     limiter,
+	}
+}
+
+func (o *rateLimitedObjectStore) PutObject(bucket, key string, body io.Reader) error {
+	o.limiter.Accept()
+	return o.ObjectStore.PutObject(bucket, key, body)
+}
+
+func (o *rateLimitedObjectStore) ObjectExists(bucket, key string) (bool, error) {
+	o.limiter.Accept()
+	return o.ObjectStore.ObjectExists(bucket, key)
+}
+
+func (o *rateLimitedObjectStore) GetObject(bucket, key string) (io.ReadCloser, error) {
+	o.limiter.Accept()
+	return o.ObjectStore.GetObject(bucket, key)
+}
+
+func (o *rateLimitedObjectStore) ListCommonPrefixes(bucket, prefix, delimiter string) ([]string, error) {
+	o.limiter.Accept()
+	return o.ObjectStore.ListCommonPrefixes(bucket, prefix, delimiter)
+}
+
+func (o *rateLimitedObjectStore) ListObjects(bucket, prefix string) ([]string, error) {
+	o.limiter.Accept()
+	return o.ObjectStore.ListObjects(bucket, prefix)
+}
+
+func (o *rateLimitedObjectStore) DeleteObject(bucket, key string) error {
+	o.limiter.Accept()
+	return o.ObjectStore.DeleteObject(bucket, key)
+}
+
+func (o *rateLimitedObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	o.limiter.Accept()
+	return o.ObjectStore.CopyObject(bucket, srcKey, destKey)
+}
+
+func (o *rateLimitedObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	o.limiter.Accept()
+	return o.ObjectStore.ListObjectVersions(bucket, key)
+}
+
+func (o *rateLimitedObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	o.limiter.Accept()
+	return o.ObjectStore.GetObjectVersion(bucket, key, versionID)
+}
+
+func (o *rateLimitedObjectStore) LockObject(bucket, key string, until time.Time) error {
+	o.limiter.Accept()
+	return o.ObjectStore.LockObject(bucket, key, until)
+}
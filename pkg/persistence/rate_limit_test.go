@@ -0,0 +1,98 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/cloudprovider"
+)
+
+type countingRateLimiter struct {
+	accepted int
+}
+
+func (l *countingRateLimiter) TryAccept() bool { return true }
+func (l *countingRateLimiter) Accept()         { l.accepted++ }
+func (l *countingRateLimiter) Stop()           {}
+func (l *countingRateLimiter) QPS() float32    { return 0 }
+
+func TestValidateRateLimit(t *testing.T) {
+	assert.NoError(t, ValidateRateLimit(nil))
+	assert.NoError(t, ValidateRateLimit(&velerov1api.BackupStorageLocationRateLimit{RequestsPerSecond: 10}))
+	assert.EqualError(t, ValidateRateLimit(&velerov1api.BackupStorageLocationRateLimit{}), "rate limit's requests per second must be greater than zero, not 0")
+	assert.EqualError(t, ValidateRateLimit(&velerov1api.BackupStorageLocationRateLimit{RequestsPerSecond: 10, Burst: -1}), "rate limit's burst must not be negative, not -1")
+}
+
+func TestRateLimiterRegistry(t *testing.T) {
+	registry := newRateLimiterRegistry()
+
+	newLocation := func(name string, limit *velerov1api.BackupStorageLocationRateLimit) *velerov1api.BackupStorageLocation {
+		location := builder.ForBackupStorageLocation("velero", name).Provider("aws").Bucket("bucket").Result()
+		location.Spec.RateLimit = limit
+		return location
+	}
+
+	assert.Nil(t, registry.get(newLocation("default", nil)))
+
+	limit := &velerov1api.BackupStorageLocationRateLimit{RequestsPerSecond: 5}
+	limiter := registry.get(newLocation("default", limit))
+	require.NotNil(t, limiter)
+	assert.Equal(t, float32(5), limiter.QPS())
+
+	// every backup store for the location shares its limiter
+	assert.True(t, limiter == registry.get(newLocation("default", limit)))
+	assert.False(t, limiter == registry.get(newLocation("other", limit)))
+
+	// the limiter is replaced when the limit changes
+	changed := registry.get(newLocation("default", &velerov1api.BackupStorageLocationRateLimit{RequestsPerSecond: 10, Burst: 20}))
+	assert.False(t, limiter == changed)
+	assert.Equal(t, float32(10), changed.QPS())
+
+	assert.Nil(t, registry.get(newLocation("default", nil)))
+}
+
+func TestRateLimitedObjectStore(t *testing.T) {
+	limiter := new(countingRateLimiter)
+	objectStore := &rateLimitedObjectStore{
+		ObjectStore: cloudprovider.NewInMemoryObjectStore("bucket"),
+		limiter:     limiter,
+	}
+
+	require.NoError(t, objectStore.PutObject("bucket", "key", strings.NewReader("data")))
+	_, err := objectStore.ObjectExists("bucket", "key")
+	require.NoError(t, err)
+	_, err = objectStore.GetObject("bucket", "key")
+	require.NoError(t, err)
+	_, err = objectStore.ListObjects("bucket", "")
+	require.NoError(t, err)
+	require.NoError(t, objectStore.DeleteObject("bucket", "key"))
+	assert.Equal(t, 5, limiter.accepted)
+
+	// signing a URL doesn't make a request
+	_, _ = objectStore.CreateSignedURL("bucket", "key", time.Minute)
+	_, err = objectStore.Capabilities()
+	require.NoError(t, err)
+	assert.Equal(t, 5, limiter.accepted)
+}
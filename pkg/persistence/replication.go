@@ -0,0 +1,170 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// objectStoreBacked is implemented by BackupStores that are built on
+// top of an objectBackupStore, giving access to the underlying files.
+type objectStoreBacked interface {
+	getObjectBackupStore() *objectBackupStore
+}
+
+func (s *objectBackupStore) getObjectBackupStore() *objectBackupStore {
+	return s
+}
+
+// CopyBackup copies all of the files for the named backup from one
+// BackupStore to another. The backup's metadata file is copied last so
+// that the backup is not visible to the destination's backup sync until
+// all of its other files are in place.
+func CopyBackup(src, dst BackupStore, name string) error {
+	srcBacked, ok := src.(objectStoreBacked)
+	if !ok {
+		return errors.Errorf("backup store type %T does not support copying backups", src)
+	}
+	dstBacked, ok := dst.(objectStoreBacked)
+	if !ok {
+		return errors.Errorf("backup store type %T does not support copying backups", dst)
+	}
+	from, to := srcBacked.getObjectBackupStore(), dstBacked.getObjectBackupStore()
+
+	if to.backupPathTemplate != nil || from.getPrefixedBackupDir(name) != "" {
+		backup, err := from.GetBackupMetadata(name)
+		if err != nil {
+			return errors.Wrapf(err, "error getting metadata for backup %s", name)
+		}
+		if err := to.setBackupPath(name, backup); err != nil {
+			return err
+		}
+	}
+
+	srcDir := from.layout.getBackupDir(name)
+	keys, err := from.objectStore.ListObjects(from.bucket, srcDir)
+	if err != nil {
+		return errors.Wrapf(err, "error listing files for backup %s", name)
+	}
+	defer to.invalidateCachedBackup(name)
+
+	metadataKey := from.layout.getBackupMetadataKey(name)
+	var foundMetadata bool
+	for _, key := range keys {
+		if key == metadataKey {
+			foundMetadata = true
+			continue
+		}
+		if err := copyObject(from, to, key, to.layout.getBackupDir(name)+strings.TrimPrefix(key, srcDir)); err != nil {
+			return err
+		}
+	}
+
+	if !foundMetadata {
+		return errors.Errorf("backup %s does not have a metadata file in its backup storage location", name)
+	}
+
+	if err := copyChunks(from, to, name); err != nil {
+		return err
+	}
+
+	if err := copyObject(from, to, metadataKey, to.layout.getBackupMetadataKey(name)); err != nil {
+		return err
+	}
+
+	if err := to.putRevision(); err != nil {
+		to.logger.WithField("backup", name).WithError(err).Warn("Error updating backup store revision")
+	}
+
+	return nil
+}
+
+// copyChunks copies the chunks that a deduplicated backup's contents are
+// made of, and that the destination doesn't already have.
+func copyChunks(from, to *objectBackupStore, name string) error {
+	index, err := from.getContentsIndex(name)
+	if err != nil {
+		return errors.Wrapf(err, "error getting contents index for backup %s", name)
+	}
+	if index == nil {
+		return nil
+	}
+
+	copied := sets.NewString()
+	for _, hash := range index.Chunks {
+		if copied.Has(hash) {
+			continue
+		}
+		copied.Insert(hash)
+
+		dstKey := to.layout.getChunkKey(hash)
+		exists, err := to.objectStore.ObjectExists(to.bucket, dstKey)
+		if err != nil {
+			return errors.Wrapf(err, "error checking if %s exists", dstKey)
+		}
+		if exists {
+			continue
+		}
+
+		if err := copyObject(from, to, from.layout.getChunkKey(hash), dstKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyObject(from, to *objectBackupStore, srcKey, dstKey string) error {
+	if canCopyServerSide(from, to) {
+		if err := to.objectStore.CopyObject(to.bucket, srcKey, dstKey); err != nil {
+			return errors.Wrapf(err, "error copying %s to %s", srcKey, dstKey)
+		}
+		return nil
+	}
+
+	rdr, err := from.objectStore.GetObject(from.bucket, srcKey)
+	if err != nil {
+		return errors.Wrapf(err, "error reading %s", srcKey)
+	}
+	defer rdr.Close()
+
+	if err := to.objectStore.PutObject(to.bucket, dstKey, rdr); err != nil {
+		return errors.Wrapf(err, "error writing %s", dstKey)
+	}
+
+	return nil
+}
+
+// canCopyServerSide returns whether objects can be copied from one backup
+// store to another without downloading and re-uploading them, which is when
+// both are in the same bucket and the object store supports it.
+func canCopyServerSide(from, to *objectBackupStore) bool {
+	if from.storageID == "" || from.storageID != to.storageID || from.bucket != to.bucket {
+		return false
+	}
+
+	capabilities, err := to.getCapabilities()
+	if err != nil {
+		to.logger.WithError(err).Warn("Error getting object store capabilities, copying objects by downloading them")
+		return false
+	}
+
+	return capabilities.ServerSideCopy
+}
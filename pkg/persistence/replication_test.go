@@ -0,0 +1,146 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/heptio/velero/pkg/cloudprovider"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestCopyBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "velero-copy-backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src, err := NewFilesystemBackupStore(newFilesystemLocation(dir, "primary"), velerotest.NewLogger())
+	require.NoError(t, err)
+	dst, err := NewFilesystemBackupStore(newFilesystemLocation(dir, "replica"), velerotest.NewLogger())
+	require.NoError(t, err)
+
+	require.NoError(t, src.PutBackup(BackupInfo{
+		Name:     "backup-1",
+		Metadata: strings.NewReader("metadata"),
+		Contents: strings.NewReader("contents"),
+		Log:      strings.NewReader("log"),
+	}))
+
+	require.NoError(t, CopyBackup(src, dst, "backup-1"))
+
+	backups, err := dst.ListBackups()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup-1"}, backups)
+
+	for file, expected := range map[string]string{
+		"velero-backup.json": "metadata",
+		"backup-1.tar.gz":    "contents",
+		"backup-1-logs.gz":   "log",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "replica", "backups", "backup-1", file))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+
+	_, err = dst.GetRevision()
+	assert.NoError(t, err)
+
+	assert.EqualError(t, CopyBackup(src, dst, "backup-2"), "backup backup-2 does not have a metadata file in its backup storage location")
+}
+
+func TestCopyDeduplicatedBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "velero-copy-backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srcLocation := newFilesystemLocation(dir, "primary")
+	srcLocation.Spec.Deduplication = true
+	src, err := NewFilesystemBackupStore(srcLocation, velerotest.NewLogger())
+	require.NoError(t, err)
+	dst, err := NewFilesystemBackupStore(newFilesystemLocation(dir, "replica"), velerotest.NewLogger())
+	require.NoError(t, err)
+
+	data := randomData(1, 1024)
+	require.NoError(t, src.PutBackup(BackupInfo{
+		Name:     "backup-1",
+		Metadata: strings.NewReader("metadata"),
+		Contents: gzipData(t, data),
+	}))
+
+	require.NoError(t, CopyBackup(src, dst, "backup-1"))
+
+	rc, err := dst.GetBackupContents("backup-1")
+	require.NoError(t, err)
+	defer rc.Close()
+	gzr, err := gzip.NewReader(rc)
+	require.NoError(t, err)
+	res, err := ioutil.ReadAll(gzr)
+	require.NoError(t, err)
+	assert.Equal(t, data, res)
+}
+
+// copyRecordingObjectStore records the objects that are copied on the
+// object store side.
+type copyRecordingObjectStore struct {
+	*cloudprovider.InMemoryObjectStore
+	copied []string
+}
+
+func (o *copyRecordingObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	o.copied = append(o.copied, destKey)
+	return o.InMemoryObjectStore.CopyObject(bucket, srcKey, destKey)
+}
+
+func TestCopyBackupWithinBucket(t *testing.T) {
+	objectStore := &copyRecordingObjectStore{InMemoryObjectStore: cloudprovider.NewInMemoryObjectStore("bucket")}
+	newStore := func(prefix, storageID string) *objectBackupStore {
+		return &objectBackupStore{
+			objectStore: objectStore,
+			bucket:      "bucket",
+			layout:      NewObjectStoreLayout(prefix),
+			logger:      velerotest.NewLogger(),
+			storageID:   storageID,
+		}
+	}
+
+	src := newStore("primary", "aws:bucket")
+	require.NoError(t, src.PutBackup(BackupInfo{
+		Name:     "backup-1",
+		Metadata: strings.NewReader("metadata"),
+		Contents: strings.NewReader("contents"),
+	}))
+
+	// backup stores in the same bucket copy objects on the object store side
+	require.NoError(t, CopyBackup(src, newStore("replica", "aws:bucket"), "backup-1"))
+	assert.Equal(t, []string{"replica/backups/backup-1/backup-1.tar.gz", "replica/backups/backup-1/velero-backup.json"}, objectStore.copied)
+	assert.Equal(t, "contents", string(objectStore.Data["bucket"]["replica/backups/backup-1/backup-1.tar.gz"]))
+
+	// backup stores that can't be identified as being in the same bucket
+	// download and re-upload objects
+	objectStore.copied = nil
+	require.NoError(t, CopyBackup(src, newStore("other", ""), "backup-1"))
+	assert.Empty(t, objectStore.copied)
+	assert.Equal(t, "contents", string(objectStore.Data["bucket"]["other/backups/backup-1/backup-1.tar.gz"]))
+}
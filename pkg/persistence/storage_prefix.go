@@ -0,0 +1,162 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+// Backups with a storage prefix are stored in a backups directory under
+// the prefix in the backup store's prefixes directory, e.g. the backup
+// backup-1 with the storage prefix team-a/prod is stored in
+// prefixes/team-a/prod/backups/backup-1/, so that access to a team's
+// backups can be granted by prefix.
+
+var storagePrefixSegment = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ValidateStoragePrefix returns an error if a backup's storage prefix isn't
+// one or more path segments of letters, numbers, dots, dashes and
+// underscores, or has a segment named "backups", which is where the
+// prefix's backups are stored.
+func ValidateStoragePrefix(storagePrefix string) error {
+	for _, segment := range strings.Split(storagePrefix, "/") {
+		if !storagePrefixSegment.MatchString(segment) {
+			return errors.Errorf("invalid storage prefix %q: path segment %q must start with a letter or number and contain only letters, numbers, dots, dashes and underscores", storagePrefix, segment)
+		}
+		if segment == "backups" {
+			return errors.Errorf("invalid storage prefix %q: path segments must not be named backups", storagePrefix)
+		}
+	}
+
+	return nil
+}
+
+// setStoragePrefix records where a backup that's being written to the
+// backup store is stored if it has a storage prefix. It returns false if
+// the backup doesn't have one.
+func (s *objectBackupStore) setStoragePrefix(name string, backup *velerov1api.Backup) (bool, error) {
+	if backup == nil || backup.Spec.StoragePrefix == "" {
+		return false, nil
+	}
+	if err := ValidateStoragePrefix(backup.Spec.StoragePrefix); err != nil {
+		return false, err
+	}
+
+	if s.prefixedBackupDirs == nil {
+		s.prefixedBackupDirs = make(map[string]string)
+	}
+	s.prefixedBackupDirs[name] = path.Join(s.layout.getStoragePrefixBackupsDir(backup.Spec.StoragePrefix), name) + "/"
+
+	return true, nil
+}
+
+// getPrefixedBackupDir returns the directory of a backup that's stored under
+// a storage prefix, or an empty string if the backup isn't. The prefixes
+// directory is only listed the first time.
+func (s *objectBackupStore) getPrefixedBackupDir(name string) string {
+	if !s.prefixedBackupsListed {
+		if _, err := s.listPrefixedBackups(); err != nil {
+			s.logger.WithError(err).WithField("backup", name).Warn("Error listing backups with storage prefixes")
+		}
+	}
+
+	return s.prefixedBackupDirs[name]
+}
+
+// listPrefixedBackups returns the names of the backups that are stored under
+// storage prefixes, by walking the prefixes directory, and records where
+// each backup is stored. A directory named backups is the backups directory
+// of the storage prefix it's in; the backup store's other directories under
+// the prefixes directory are storage prefixes' path segments.
+func (s *objectBackupStore) listPrefixedBackups() ([]string, error) {
+	dirs := []string{s.layout.subdirs["prefixes"]}
+	found := make(map[string]string)
+	var output []string
+
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		children, err := s.objectStore.ListCommonPrefixes(s.bucket, dir, "/")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, child := range children {
+			if path.Base(child) != "backups" {
+				dirs = append(dirs, child)
+				continue
+			}
+
+			backupDirs, err := s.objectStore.ListCommonPrefixes(s.bucket, child, "/")
+			if err != nil {
+				return nil, err
+			}
+			for _, backupDir := range backupDirs {
+				name := path.Base(backupDir)
+				if existing, ok := found[name]; ok {
+					s.logger.WithField("backup", name).Warnf("Ignoring backup in %s because there's another backup with the same name in %s", backupDir, existing)
+					continue
+				}
+				found[name] = backupDir
+				output = append(output, name)
+			}
+		}
+	}
+
+	// keep the directories of backups that are being written, which may
+	// not have been listed.
+	for name, dir := range s.prefixedBackupDirs {
+		if _, ok := found[name]; !ok {
+			found[name] = dir
+		}
+	}
+	s.prefixedBackupDirs = found
+	s.prefixedBackupsListed = true
+
+	return output, nil
+}
+
+// appendPrefixedBackups appends the backups that are stored under storage
+// prefixes to a list of the backup store's other backups. A backup that's
+// stored under a storage prefix and has the same name as another backup is
+// ignored.
+func (s *objectBackupStore) appendPrefixedBackups(output []string) ([]string, error) {
+	prefixed, err := s.listPrefixedBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	listed := sets.NewString(output...)
+	for _, name := range prefixed {
+		if listed.Has(name) {
+			s.logger.WithField("backup", name).Warnf("Ignoring backup in %s because there's another backup with the same name", s.prefixedBackupDirs[name])
+			delete(s.prefixedBackupDirs, name)
+			continue
+		}
+		output = append(output, name)
+	}
+
+	return output, nil
+}
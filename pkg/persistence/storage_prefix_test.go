@@ -0,0 +1,120 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestValidateStoragePrefix(t *testing.T) {
+	tests := []struct {
+		prefix      string
+		expectedErr string
+	}{
+		{prefix: "team-a"},
+		{prefix: "team-a/prod_1.x"},
+		{
+			prefix:      "team-a/",
+			expectedErr: `invalid storage prefix "team-a/": path segment "" must start with a letter or number and contain only letters, numbers, dots, dashes and underscores`,
+		},
+		{
+			prefix:      "/team-a",
+			expectedErr: `invalid storage prefix "/team-a": path segment "" must start with a letter or number and contain only letters, numbers, dots, dashes and underscores`,
+		},
+		{
+			prefix:      "team-a/../team-b",
+			expectedErr: `invalid storage prefix "team-a/../team-b": path segment ".." must start with a letter or number and contain only letters, numbers, dots, dashes and underscores`,
+		},
+		{
+			prefix:      "team a",
+			expectedErr: `invalid storage prefix "team a": path segment "team a" must start with a letter or number and contain only letters, numbers, dots, dashes and underscores`,
+		},
+		{
+			prefix:      "team-a/backups",
+			expectedErr: `invalid storage prefix "team-a/backups": path segments must not be named backups`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.prefix, func(t *testing.T) {
+			err := ValidateStoragePrefix(tc.prefix)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestStoragePrefix(t *testing.T) {
+	harness := newObjectBackupStoreTestHarness("test-bucket", "")
+	require.NoError(t, harness.setLayout("velero"))
+
+	backups := []*velerov1api.Backup{
+		builder.ForBackup("velero", "backup-1").Result(),
+		builder.ForBackup("velero", "backup-2").StoragePrefix("team-a").Result(),
+		builder.ForBackup("velero", "backup-3").StoragePrefix("team-b/prod").Result(),
+	}
+	for _, backup := range backups {
+		require.NoError(t, harness.PutBackup(BackupInfo{
+			Name:     backup.Name,
+			Backup:   backup,
+			Metadata: newStringReadSeeker("metadata"),
+			Contents: newStringReadSeeker("contents"),
+		}))
+	}
+
+	assert.Contains(t, harness.objectStore.Data["test-bucket"], "velero/backups/backup-1/velero-backup.json")
+	assert.Contains(t, harness.objectStore.Data["test-bucket"], "velero/prefixes/team-a/backups/backup-2/velero-backup.json")
+	assert.Contains(t, harness.objectStore.Data["test-bucket"], "velero/prefixes/team-b/prod/backups/backup-3/velero-backup.json")
+	assert.NoError(t, harness.IsValid())
+
+	// a backup under a storage prefix with the same name as another backup
+	// is ignored.
+	harness.objectStore.Data["test-bucket"]["velero/prefixes/team-a/backups/backup-1/velero-backup.json"] = []byte("metadata")
+
+	// a new backup store finds the backups by listing them.
+	store := &objectBackupStore{
+		objectStore: harness.objectStore,
+		bucket:      "test-bucket",
+		logger:      velerotest.NewLogger(),
+	}
+	require.NoError(t, store.setLayout("velero"))
+
+	exists, err := store.BackupExists("test-bucket", "backup-3")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	res, err := store.ListBackups()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"backup-1", "backup-2", "backup-3"}, res)
+	assert.Equal(t, "velero/backups/backup-1/", store.layout.getBackupDir("backup-1"))
+
+	require.NoError(t, store.DeleteBackup("backup-3"))
+	assert.NotContains(t, harness.objectStore.Data["test-bucket"], "velero/prefixes/team-b/prod/backups/backup-3/velero-backup.json")
+
+	invalid := builder.ForBackup("velero", "backup-4").StoragePrefix("team-a/backups").Result()
+	assert.EqualError(t, store.PutBackup(BackupInfo{Name: "backup-4", Backup: invalid}), `invalid storage prefix "team-a/backups": path segments must not be named backups`)
+}
@@ -18,6 +18,7 @@ limitations under the License.
 package clientmgmt
 
 import (
+	"io"
 	"os"
 	"os/exec"
 
@@ -34,6 +35,8 @@ type clientBuilder struct {
 	commandArgs  []string
 	clientLogger logrus.FieldLogger
 	pluginLogger hclog.Logger
+	// stderr, if set, receives the lines that the plugin process writes to stderr.
+	stderr io.Writer
 }
 
 // newClientBuilder returns a new clientBuilder with commandName to name. If the command matches the currently running
@@ -58,24 +61,34 @@ func newLogrusAdapter(pluginLogger logrus.FieldLogger, logLevel logrus.Level) *l
 	return &logrusAdapter{impl: pluginLogger, level: logLevel}
 }
 
+// versionedPlugins returns the plugins for all of Velero's plugin kinds (BackupItemAction, VolumeSnapshotter,
+// ObjectStore, PluginLister, RestoreItemAction), for each plugin API version that Velero supports. go-plugin uses the
+// plugins for the highest version that the plugin process also supports.
+func (b *clientBuilder) versionedPlugins() map[int]hcplugin.PluginSet {
+	versioned := make(map[int]hcplugin.PluginSet)
+	for _, version := range framework.APIVersions() {
+		options := []framework.PluginOption{framework.ClientLogger(b.clientLogger), framework.ClientAPIVersion(version)}
+
+		versioned[version] = hcplugin.PluginSet{
+			string(framework.PluginKindBackupItemAction):  framework.NewBackupItemActionPlugin(options...),
+			string(framework.PluginKindVolumeSnapshotter): framework.NewVolumeSnapshotterPlugin(options...),
+			string(framework.PluginKindObjectStore):       framework.NewObjectStorePlugin(options...),
+			string(framework.PluginKindPluginLister):      &framework.PluginListerPlugin{},
+			string(framework.PluginKindRestoreItemAction): framework.NewRestoreItemActionPlugin(options...),
+		}
+	}
+	return versioned
+}
+
+// clientConfig returns the config for a new go-plugin Client with support for all of Velero's plugin kinds and plugin
+// API versions. Each config has its own unique exec.Cmd for launching the plugin process.
 func (b *clientBuilder) clientConfig() *hcplugin.ClientConfig {
 	return &hcplugin.ClientConfig{
 		HandshakeConfig:  framework.Handshake(),
 		AllowedProtocols: []hcplugin.Protocol{hcplugin.ProtocolGRPC},
-		Plugins: map[string]hcplugin.Plugin{
-			string(framework.PluginKindBackupItemAction):  framework.NewBackupItemActionPlugin(framework.ClientLogger(b.clientLogger)),
-			string(framework.PluginKindVolumeSnapshotter): framework.NewVolumeSnapshotterPlugin(framework.ClientLogger(b.clientLogger)),
-			string(framework.PluginKindObjectStore):       framework.NewObjectStorePlugin(framework.ClientLogger(b.clientLogger)),
-			string(framework.PluginKindPluginLister):      &framework.PluginListerPlugin{},
-			string(framework.PluginKindRestoreItemAction): framework.NewRestoreItemActionPlugin(framework.ClientLogger(b.clientLogger)),
-		},
-		Logger: b.pluginLogger,
-		Cmd:    exec.Command(b.commandName, b.commandArgs...),
+		VersionedPlugins: b.versionedPlugins(),
+		Logger:           b.pluginLogger,
+		Cmd:              exec.Command(b.commandName, b.commandArgs...),
+		Stderr:           b.stderr,
 	}
 }
-
-// client creates a new go-plugin Client with support for all of Velero's plugin kinds (BackupItemAction, VolumeSnapshotter,
-// ObjectStore, PluginLister, RestoreItemAction).
-func (b *clientBuilder) client() *hcplugin.Client {
-	return hcplugin.NewClient(b.clientConfig())
-}
@@ -48,18 +48,24 @@ func TestClientConfig(t *testing.T) {
 	logLevel := logrus.InfoLevel
 	cb := newClientBuilder("velero", logger, logLevel)
 
+	versioned := make(map[int]hcplugin.PluginSet)
+	for _, version := range framework.APIVersions() {
+		options := []framework.PluginOption{framework.ClientLogger(logger), framework.ClientAPIVersion(version)}
+		versioned[version] = hcplugin.PluginSet{
+			string(framework.PluginKindBackupItemAction):  framework.NewBackupItemActionPlugin(options...),
+			string(framework.PluginKindVolumeSnapshotter): framework.NewVolumeSnapshotterPlugin(options...),
+			string(framework.PluginKindObjectStore):       framework.NewObjectStorePlugin(options...),
+			string(framework.PluginKindPluginLister):      &framework.PluginListerPlugin{},
+			string(framework.PluginKindRestoreItemAction): framework.NewRestoreItemActionPlugin(options...),
+		}
+	}
+
 	expected := &hcplugin.ClientConfig{
 		HandshakeConfig:  framework.Handshake(),
 		AllowedProtocols: []hcplugin.Protocol{hcplugin.ProtocolGRPC},
-		Plugins: map[string]hcplugin.Plugin{
-			string(framework.PluginKindBackupItemAction):  framework.NewBackupItemActionPlugin(framework.ClientLogger(logger)),
-			string(framework.PluginKindVolumeSnapshotter): framework.NewVolumeSnapshotterPlugin(framework.ClientLogger(logger)),
-			string(framework.PluginKindObjectStore):       framework.NewObjectStorePlugin(framework.ClientLogger(logger)),
-			string(framework.PluginKindPluginLister):      &framework.PluginListerPlugin{},
-			string(framework.PluginKindRestoreItemAction): framework.NewRestoreItemActionPlugin(framework.ClientLogger(logger)),
-		},
-		Logger: cb.pluginLogger,
-		Cmd:    exec.Command(cb.commandName, cb.commandArgs...),
+		VersionedPlugins: versioned,
+		Logger:           cb.pluginLogger,
+		Cmd:              exec.Command(cb.commandName, cb.commandArgs...),
 	}
 
 	cc := cb.clientConfig()
@@ -17,6 +17,7 @@ limitations under the License.
 package clientmgmt
 
 import (
+	"sort"
 	"strings"
 	"sync"
 
@@ -46,6 +47,9 @@ type Manager interface {
 	// GetRestoreItemAction returns the restore item action plugin for name.
 	GetRestoreItemAction(name string) (velero.RestoreItemAction, error)
 
+	// PluginCrashes describes each time one of the Manager's plugin processes crashed, and why.
+	PluginCrashes() []string
+
 	// CleanupClients terminates all of the Manager's running plugin processes.
 	CleanupClients()
 }
@@ -86,6 +90,24 @@ func (m *manager) CleanupClients() {
 	m.lock.Unlock()
 }
 
+func (m *manager) PluginCrashes() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	commands := make([]string, 0, len(m.restartableProcesses))
+	for command := range m.restartableProcesses {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	var crashes []string
+	for _, command := range commands {
+		crashes = append(crashes, m.restartableProcesses[command].crashes()...)
+	}
+
+	return crashes
+}
+
 // getRestartableProcess returns a restartableProcess for a plugin identified by kind and name, creating a
 // restartableProcess if it is the first time it has been requested.
 func (m *manager) getRestartableProcess(kind framework.PluginKind, name string) (RestartableProcess, error) {
@@ -104,6 +104,19 @@ func (rp *mockRestartableProcess) getByKindAndName(key kindAndName) (interface{}
 	return args.Get(0), args.Error(1)
 }
 
+// pluginError returns err, so that the restartable plugins' calls return their delegates' errors.
+func (rp *mockRestartableProcess) pluginError(err error) error {
+	return err
+}
+
+func (rp *mockRestartableProcess) crashes() []string {
+	args := rp.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]string)
+}
+
 func (rp *mockRestartableProcess) stop() {
 	rp.Called()
 }
@@ -173,6 +186,27 @@ func TestCleanupClients(t *testing.T) {
 	m.CleanupClients()
 }
 
+func TestPluginCrashes(t *testing.T) {
+	m := NewManager(test.NewLogger(), logrus.InfoLevel, &mockRegistry{}).(*manager)
+
+	for command, crashes := range map[string][]string{
+		"/plugins/b": {"/plugins/b: exit status 2: panic: oops"},
+		"/plugins/a": {"/plugins/a: signal: killed", "/plugins/a: exit status 1"},
+		"/plugins/c": nil,
+	} {
+		rp := &mockRestartableProcess{}
+		defer rp.AssertExpectations(t)
+		rp.On("crashes").Return(crashes)
+		m.restartableProcesses[command] = rp
+	}
+
+	assert.Equal(t, []string{
+		"/plugins/a: signal: killed",
+		"/plugins/a: exit status 1",
+		"/plugins/b: exit status 2: panic: oops",
+	}, m.PluginCrashes())
+}
+
 func TestGetObjectStore(t *testing.T) {
 	getPluginTest(t,
 		framework.PluginKindObjectStore,
@@ -17,6 +17,8 @@ limitations under the License.
 package clientmgmt
 
 import (
+	"os/exec"
+
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -42,19 +44,30 @@ func (pf *processFactory) newProcess(command string, logger logrus.FieldLogger,
 type Process interface {
 	dispense(key kindAndName) (interface{}, error)
 	exited() bool
+	// healthy returns an error if the plugin process isn't responding.
+	healthy() error
+	// crashCause describes why the plugin process exited. It's empty if the process hasn't exited.
+	crashCause() string
 	kill()
 }
 
 type process struct {
 	client         *plugin.Client
 	protocolClient plugin.ClientProtocol
+	// cmd is the plugin process's command, whose state is available once the process has exited.
+	cmd    *exec.Cmd
+	stderr *stderrRecorder
 }
 
 func newProcess(command string, logger logrus.FieldLogger, logLevel logrus.Level) (Process, error) {
 	builder := newClientBuilder(command, logger.WithField("cmd", command), logLevel)
 
+	stderr := new(stderrRecorder)
+	builder.stderr = stderr
+
 	// This creates a new go-plugin Client that has its own unique exec.Cmd for launching the plugin process.
-	client := builder.client()
+	config := builder.clientConfig()
+	client := plugin.NewClient(config)
 
 	// This launches the plugin process.
 	protocolClient, err := client.Client()
@@ -65,6 +78,8 @@ func newProcess(command string, logger logrus.FieldLogger, logLevel logrus.Level
 	p := &process{
 		client:         client,
 		protocolClient: protocolClient,
+		cmd:            config.Cmd,
+		stderr:         stderr,
 	}
 
 	return p, nil
@@ -94,6 +109,30 @@ func (r *process) exited() bool {
 	return r.client.Exited()
 }
 
+func (r *process) healthy() error {
+	return r.protocolClient.Ping()
+}
+
+// crashCause returns the plugin process's exit status, e.g. "exit status 2" or "signal: killed", followed by the panic
+// or the last line that the process wrote to stderr, if any.
+func (r *process) crashCause() string {
+	if !r.client.Exited() {
+		return ""
+	}
+
+	// go-plugin only reports that the process has exited once it's waited for it, so its state is set.
+	cause := "exited"
+	if r.cmd.ProcessState != nil {
+		cause = r.cmd.ProcessState.String()
+	}
+
+	if output := r.stderr.cause(); output != "" {
+		cause += ": " + output
+	}
+
+	return cause
+}
+
 func (r *process) kill() {
 	r.client.Kill()
 }
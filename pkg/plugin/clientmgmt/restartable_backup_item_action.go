@@ -75,7 +75,8 @@ func (r *restartableBackupItemAction) AppliesTo() (velero.ResourceSelector, erro
 		return velero.ResourceSelector{}, err
 	}
 
-	return delegate.AppliesTo()
+	selector, err := delegate.AppliesTo()
+	return selector, r.sharedPluginProcess.pluginError(err)
 }
 
 // Execute restarts the plugin's process if needed, then delegates the call.
@@ -85,5 +86,6 @@ func (r *restartableBackupItemAction) Execute(item runtime.Unstructured, backup
 		return nil, nil, err
 	}
 
-	return delegate.Execute(item, backup)
+	updatedItem, additionalItems, err := delegate.Execute(item, backup)
+	return updatedItem, additionalItems, r.sharedPluginProcess.pluginError(err)
 }
@@ -117,7 +117,7 @@ func (r *restartableObjectStore) PutObject(bucket string, key string, body io.Re
 	if err != nil {
 		return err
 	}
-	return delegate.PutObject(bucket, key, body)
+	return r.sharedPluginProcess.pluginError(delegate.PutObject(bucket, key, body))
 }
 
 // ObjectExists restarts the plugin's process if needed, then delegates the call.
@@ -126,7 +126,8 @@ func (r *restartableObjectStore) ObjectExists(bucket, key string) (bool, error)
 	if err != nil {
 		return false, err
 	}
-	return delegate.ObjectExists(bucket, key)
+	exists, err := delegate.ObjectExists(bucket, key)
+	return exists, r.sharedPluginProcess.pluginError(err)
 }
 
 // GetObject restarts the plugin's process if needed, then delegates the call.
@@ -135,7 +136,8 @@ func (r *restartableObjectStore) GetObject(bucket string, key string) (io.ReadCl
 	if err != nil {
 		return nil, err
 	}
-	return delegate.GetObject(bucket, key)
+	object, err := delegate.GetObject(bucket, key)
+	return object, r.sharedPluginProcess.pluginError(err)
 }
 
 // ListCommonPrefixes restarts the plugin's process if needed, then delegates the call.
@@ -144,7 +146,8 @@ func (r *restartableObjectStore) ListCommonPrefixes(bucket string, prefix string
 	if err != nil {
 		return nil, err
 	}
-	return delegate.ListCommonPrefixes(bucket, prefix, delimiter)
+	prefixes, err := delegate.ListCommonPrefixes(bucket, prefix, delimiter)
+	return prefixes, r.sharedPluginProcess.pluginError(err)
 }
 
 // ListObjects restarts the plugin's process if needed, then delegates the call.
@@ -153,7 +156,8 @@ func (r *restartableObjectStore) ListObjects(bucket string, prefix string) ([]st
 	if err != nil {
 		return nil, err
 	}
-	return delegate.ListObjects(bucket, prefix)
+	objects, err := delegate.ListObjects(bucket, prefix)
+	return objects, r.sharedPluginProcess.pluginError(err)
 }
 
 // DeleteObject restarts the plugin's process if needed, then delegates the call.
@@ -162,7 +166,7 @@ func (r *restartableObjectStore) DeleteObject(bucket string, key string) error {
 	if err != nil {
 		return err
 	}
-	return delegate.DeleteObject(bucket, key)
+	return r.sharedPluginProcess.pluginError(delegate.DeleteObject(bucket, key))
 }
 
 // CreateSignedURL restarts the plugin's process if needed, then delegates the call.
@@ -171,5 +175,69 @@ func (r *restartableObjectStore) CreateSignedURL(bucket string, key string, ttl
 	if err != nil {
 		return "", err
 	}
-	return delegate.CreateSignedURL(bucket, key, ttl)
+	url, err := delegate.CreateSignedURL(bucket, key, ttl)
+	return url, r.sharedPluginProcess.pluginError(err)
+}
+
+// Capabilities restarts the plugin's process if needed, then delegates the call.
+func (r *restartableObjectStore) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	delegate, err := r.getDelegate()
+	if err != nil {
+		return velero.ObjectStoreCapabilities{}, err
+	}
+	capabilities, err := delegate.Capabilities()
+	return capabilities, r.sharedPluginProcess.pluginError(err)
+}
+
+// CopyObject restarts the plugin's process if needed, then delegates the call.
+func (r *restartableObjectStore) CopyObject(bucket, srcKey, destKey string) error {
+	delegate, err := r.getDelegate()
+	if err != nil {
+		return err
+	}
+	return r.sharedPluginProcess.pluginError(delegate.CopyObject(bucket, srcKey, destKey))
+}
+
+// ListObjectVersions restarts the plugin's process if needed, then delegates the call.
+func (r *restartableObjectStore) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	delegate, err := r.getDelegate()
+	if err != nil {
+		return nil, err
+	}
+	versions, err := delegate.ListObjectVersions(bucket, key)
+	return versions, r.sharedPluginProcess.pluginError(err)
+}
+
+// GetObjectVersion restarts the plugin's process if needed, then delegates the call.
+func (r *restartableObjectStore) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	delegate, err := r.getDelegate()
+	if err != nil {
+		return nil, err
+	}
+	object, err := delegate.GetObjectVersion(bucket, key, versionID)
+	return object, r.sharedPluginProcess.pluginError(err)
+}
+
+// LockObject restarts the plugin's process if needed, then delegates the call.
+func (r *restartableObjectStore) LockObject(bucket, key string, until time.Time) error {
+	delegate, err := r.getDelegate()
+	if err != nil {
+		return err
+	}
+	return r.sharedPluginProcess.pluginError(delegate.LockObject(bucket, key, until))
+}
+
+// DeleteObjects restarts the plugin's process if needed, then delegates the call if the
+// delegate supports deleting objects in batches.
+func (r *restartableObjectStore) DeleteObjects(bucket string, keys []string) error {
+	delegate, err := r.getDelegate()
+	if err != nil {
+		return err
+	}
+
+	deleter, ok := delegate.(velero.BatchDeleter)
+	if !ok {
+		return velero.ErrBatchDeleteNotSupported
+	}
+	return r.sharedPluginProcess.pluginError(deleter.DeleteObjects(bucket, keys))
 }
@@ -28,6 +28,7 @@ import (
 
 	cloudprovidermocks "github.com/heptio/velero/pkg/cloudprovider/mocks"
 	"github.com/heptio/velero/pkg/plugin/framework"
+	"github.com/heptio/velero/pkg/plugin/velero"
 )
 
 func TestRestartableGetObjectStore(t *testing.T) {
@@ -232,5 +233,79 @@ func TestRestartableObjectStoreDelegatedFunctions(t *testing.T) {
 			expectedErrorOutputs:    []interface{}{"", errors.Errorf("reset error")},
 			expectedDelegateOutputs: []interface{}{"signedURL", errors.Errorf("delegate error")},
 		},
+		restartableDelegateTest{
+			function:                "Capabilities",
+			inputs:                  []interface{}{},
+			expectedErrorOutputs:    []interface{}{velero.ObjectStoreCapabilities{}, errors.Errorf("reset error")},
+			expectedDelegateOutputs: []interface{}{velero.ObjectStoreCapabilities{SignedURLs: true}, errors.Errorf("delegate error")},
+		},
+		restartableDelegateTest{
+			function:                "CopyObject",
+			inputs:                  []interface{}{"bucket", "src", "dest"},
+			expectedErrorOutputs:    []interface{}{errors.Errorf("reset error")},
+			expectedDelegateOutputs: []interface{}{errors.Errorf("delegate error")},
+		},
+		restartableDelegateTest{
+			function:                "ListObjectVersions",
+			inputs:                  []interface{}{"bucket", "key"},
+			expectedErrorOutputs:    []interface{}{([]velero.ObjectVersion)(nil), errors.Errorf("reset error")},
+			expectedDelegateOutputs: []interface{}{[]velero.ObjectVersion{{VersionID: "1"}}, errors.Errorf("delegate error")},
+		},
+		restartableDelegateTest{
+			function:                "GetObjectVersion",
+			inputs:                  []interface{}{"bucket", "key", "1"},
+			expectedErrorOutputs:    []interface{}{nil, errors.Errorf("reset error")},
+			expectedDelegateOutputs: []interface{}{ioutil.NopCloser(strings.NewReader("object")), errors.Errorf("delegate error")},
+		},
+		restartableDelegateTest{
+			function:                "LockObject",
+			inputs:                  []interface{}{"bucket", "key", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+			expectedErrorOutputs:    []interface{}{errors.Errorf("reset error")},
+			expectedDelegateOutputs: []interface{}{errors.Errorf("delegate error")},
+		},
 	)
 }
+
+// batchDeleterObjectStore is an object store that supports deleting objects in batches.
+type batchDeleterObjectStore struct {
+	*cloudprovidermocks.ObjectStore
+}
+
+func (o *batchDeleterObjectStore) DeleteObjects(bucket string, keys []string) error {
+	return o.Called(bucket, keys).Error(0)
+}
+
+func TestRestartableObjectStoreDeleteObjects(t *testing.T) {
+	p := new(mockRestartableProcess)
+	p.Test(t)
+	defer p.AssertExpectations(t)
+
+	key := kindAndName{kind: framework.PluginKindObjectStore, name: "aws"}
+	r := &restartableObjectStore{
+		key:                 key,
+		sharedPluginProcess: p,
+	}
+
+	// Reset error
+	p.On("resetIfNeeded").Return(errors.Errorf("reset error")).Once()
+	err := r.DeleteObjects("bucket", []string{"a", "b"})
+	assert.EqualError(t, err, "reset error")
+
+	// Delegate doesn't support deleting objects in batches
+	p.On("resetIfNeeded").Return(nil)
+	objectStore := new(cloudprovidermocks.ObjectStore)
+	p.On("getByKindAndName", key).Return(objectStore, nil).Once()
+	err = r.DeleteObjects("bucket", []string{"a", "b"})
+	assert.Equal(t, velero.ErrBatchDeleteNotSupported, err)
+
+	// Delegate supports it
+	deleter := &batchDeleterObjectStore{ObjectStore: new(cloudprovidermocks.ObjectStore)}
+	deleter.Test(t)
+	defer deleter.AssertExpectations(t)
+	p.On("getByKindAndName", key).Return(deleter, nil)
+	delegateErr := errors.Errorf("delegate error")
+	deleter.On("DeleteObjects", "bucket", []string{"a", "b"}).Return(delegateErr)
+
+	err = r.DeleteObjects("bucket", []string{"a", "b"})
+	assert.Equal(t, delegateErr, err)
+}
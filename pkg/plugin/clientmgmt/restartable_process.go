@@ -17,10 +17,17 @@ limitations under the License.
 package clientmgmt
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/heptio/velero/pkg/credentials"
 )
 
 type RestartableProcessFactory interface {
@@ -43,16 +50,65 @@ type RestartableProcess interface {
 	reset() error
 	resetIfNeeded() error
 	getByKindAndName(key kindAndName) (interface{}, error)
+	pluginError(err error) error
+	crashes() []string
 	stop()
 }
 
+// restartPolicy controls how a plugin process is supervised and restarted.
+type restartPolicy struct {
+	// healthCheckInterval is how often a plugin process that hasn't exited is checked to still be responding, when
+	// its plugins are used.
+	healthCheckInterval time.Duration
+	// initialBackoff is how long to wait before retrying a failed restart. It doubles with each consecutive failure,
+	// up to maxBackoff.
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	// maxFailures is how many consecutive restarts can fail before no more are attempted.
+	maxFailures int
+}
+
+var defaultRestartPolicy = restartPolicy{
+	healthCheckInterval: time.Minute,
+	initialBackoff:      time.Second,
+	maxBackoff:          30 * time.Second,
+	maxFailures:         10,
+}
+
+// backoff returns how long to wait before a restart after the given number of consecutive failures.
+func (p restartPolicy) backoff(failures int) time.Duration {
+	backoff := p.initialBackoff
+	for i := 1; i < failures && backoff < p.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+	return backoff
+}
+
+const (
+	// crashPollInterval and crashPolls control how long a call that failed because the connection to its plugin
+	// process closed waits for the process to exit, to find out why it crashed.
+	crashPollInterval = 50 * time.Millisecond
+	crashPolls        = 20
+)
+
 // restartableProcess encapsulates the lifecycle for all plugins contained in a single executable file. It is able
 // to restart a plugin process if it is terminated for any reason. If this happens, all plugins are reinitialized using
-// the original configuration data.
+// the original configuration data. A plugin process that stops responding is killed and restarted, and failed restarts
+// are retried with backoff. Plugins are also reinitialized when the credentials files that they may have been
+// initialized with are rewritten.
 type restartableProcess struct {
-	command  string
-	logger   logrus.FieldLogger
-	logLevel logrus.Level
+	command        string
+	logger         logrus.FieldLogger
+	logLevel       logrus.Level
+	processFactory ProcessFactory
+	policy         restartPolicy
+	clock          clock.Clock
+	sleep          func(time.Duration)
+	// credentialsGeneration returns the current generation of the credentials files.
+	credentialsGeneration func() uint64
 
 	// lock guards all of the fields below
 	lock           sync.RWMutex
@@ -60,6 +116,14 @@ type restartableProcess struct {
 	plugins        map[kindAndName]interface{}
 	reinitializers map[kindAndName]reinitializer
 	resetFailures  int
+	// generation is the generation of the credentials files that the plugins were last (re)initialized with.
+	generation uint64
+	// lastHealthCheck is when the plugin process was last checked to be responding.
+	lastHealthCheck time.Time
+	// crashLog describes each time the plugin process crashed, oldest first, and lastCrashed is the process that last
+	// crashed, so that each crash is only recorded once.
+	crashLog    []string
+	lastCrashed Process
 }
 
 // reinitializer is capable of reinitializing a restartable plugin instance using the newly dispensed plugin.
@@ -71,11 +135,16 @@ type reinitializer interface {
 // newRestartableProcess creates a new restartableProcess for the given command and options.
 func newRestartableProcess(command string, logger logrus.FieldLogger, logLevel logrus.Level) (RestartableProcess, error) {
 	p := &restartableProcess{
-		command:        command,
-		logger:         logger,
-		logLevel:       logLevel,
-		plugins:        make(map[kindAndName]interface{}),
-		reinitializers: make(map[kindAndName]reinitializer),
+		command:               command,
+		logger:                logger,
+		logLevel:              logLevel,
+		processFactory:        newProcessFactory(),
+		policy:                defaultRestartPolicy,
+		clock:                 clock.RealClock{},
+		sleep:                 time.Sleep,
+		credentialsGeneration: credentials.Generation,
+		plugins:               make(map[kindAndName]interface{}),
+		reinitializers:        make(map[kindAndName]reinitializer),
 	}
 
 	// This launches the process
@@ -105,16 +174,24 @@ func (p *restartableProcess) reset() error {
 //
 // Callers of resetLH *must* acquire the lock before calling it.
 func (p *restartableProcess) resetLH() error {
-	if p.resetFailures > 10 {
+	if p.resetFailures > p.policy.maxFailures {
 		return errors.Errorf("unable to restart plugin process: execeeded maximum number of reset failures")
 	}
 
-	process, err := newProcess(p.command, p.logger, p.logLevel)
+	if p.resetFailures > 0 {
+		backoff := p.policy.backoff(p.resetFailures)
+		p.logger.WithField("failures", p.resetFailures).Infof("Waiting %s before restarting plugin process", backoff)
+		p.sleep(backoff)
+	}
+
+	process, err := p.processFactory.newProcess(p.command, p.logger, p.logLevel)
 	if err != nil {
 		p.resetFailures++
 		return err
 	}
 	p.process = process
+	p.generation = p.credentialsGeneration()
+	p.lastHealthCheck = p.clock.Now()
 
 	// Redispense any previously dispensed plugins, reinitializing if necessary.
 	// Start by creating a new map to hold the newly dispensed plugins.
@@ -146,16 +223,53 @@ func (p *restartableProcess) resetLH() error {
 	return nil
 }
 
-// resetIfNeeded checks if the plugin process has exited and resets p if it has.
+// resetIfNeeded checks if the plugin process has exited, or has stopped responding, and resets p if it has.
+// Otherwise, if the credentials files have been rewritten since the plugins were initialized, it reinitializes them.
 func (p *restartableProcess) resetIfNeeded() error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	if p.process.exited() {
-		p.logger.Info("Plugin process exited - restarting.")
+		cause := p.process.crashCause()
+		p.logger.WithField("cause", cause).Warn("Plugin process exited - restarting.")
+		p.recordCrashLH(p.process, cause)
 		return p.resetLH()
 	}
 
+	if now := p.clock.Now(); now.Sub(p.lastHealthCheck) >= p.policy.healthCheckInterval {
+		p.lastHealthCheck = now
+
+		if err := p.process.healthy(); err != nil {
+			p.logger.WithError(err).Warn("Plugin process isn't responding - restarting.")
+			p.process.kill()
+			p.recordCrashLH(p.process, fmt.Sprintf("stopped responding: %v", err))
+			return p.resetLH()
+		}
+	}
+
+	if generation := p.credentialsGeneration(); generation != p.generation {
+		p.logger.Info("Credentials changed - reinitializing plugins.")
+		return p.reinitializeLH(generation)
+	}
+
+	return nil
+}
+
+// reinitializeLH reinitializes all the registered reinitializers using the already-dispensed plugins, so that they
+// read their credentials again.
+//
+// Callers of reinitializeLH *must* acquire the lock before calling it.
+func (p *restartableProcess) reinitializeLH(generation uint64) error {
+	for key, dispensed := range p.plugins {
+		if r, found := p.reinitializers[key]; found {
+			if err := r.reinitialize(dispensed); err != nil {
+				return err
+			}
+		}
+	}
+
+	p.generation = generation
+
 	return nil
 }
 
@@ -183,6 +297,61 @@ func (p *restartableProcess) getByKindAndNameLH(key kindAndName) (interface{}, e
 	return p.plugins[key], nil
 }
 
+// pluginError returns err, unless the call that returned it failed because the plugin process crashed while handling
+// it, in which case it returns an error describing why the process crashed, rather than that the connection to it
+// closed.
+func (p *restartableProcess) pluginError(err error) error {
+	if err == nil || status.Code(errors.Cause(err)) != codes.Unavailable {
+		return err
+	}
+
+	p.lock.RLock()
+	process := p.process
+	p.lock.RUnlock()
+
+	// plugins can return Unavailable errors themselves, e.g. if an object store can't be reached, in which case the
+	// process still responds.
+	if process.healthy() == nil {
+		return err
+	}
+
+	// the connection closes before go-plugin finds out that the process has exited.
+	for i := 0; !process.exited(); i++ {
+		if i == crashPolls {
+			return err
+		}
+		p.sleep(crashPollInterval)
+	}
+
+	cause := process.crashCause()
+
+	p.lock.Lock()
+	p.recordCrashLH(process, cause)
+	p.lock.Unlock()
+
+	return errors.Errorf("plugin process %s crashed: %s", p.command, cause)
+}
+
+// recordCrashLH records that process crashed, unless it's already been recorded.
+//
+// Callers of recordCrashLH *must* acquire the lock before calling it.
+func (p *restartableProcess) recordCrashLH(process Process, cause string) {
+	if process == p.lastCrashed {
+		return
+	}
+
+	p.lastCrashed = process
+	p.crashLog = append(p.crashLog, fmt.Sprintf("%s: %s", p.command, cause))
+}
+
+// crashes describes each time the plugin process crashed, oldest first.
+func (p *restartableProcess) crashes() []string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return append([]string(nil), p.crashLog...)
+}
+
 // stop terminates the plugin process.
 func (p *restartableProcess) stop() {
 	p.lock.Lock()
@@ -0,0 +1,232 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientmgmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/heptio/velero/pkg/plugin/framework"
+	"github.com/heptio/velero/pkg/test"
+)
+
+type fakeProcess struct {
+	hasExited   bool
+	healthErr   error
+	healthCalls int
+	cause       string
+	killed      bool
+}
+
+func (p *fakeProcess) dispense(key kindAndName) (interface{}, error) { return key.name, nil }
+func (p *fakeProcess) exited() bool                                  { return p.hasExited }
+func (p *fakeProcess) crashCause() string                            { return p.cause }
+func (p *fakeProcess) kill()                                         { p.killed = true }
+
+func (p *fakeProcess) healthy() error {
+	p.healthCalls++
+	return p.healthErr
+}
+
+// fakeProcessFactory returns its processes in order, or fails to start a process while it has errors.
+type fakeProcessFactory struct {
+	errs      []error
+	processes []*fakeProcess
+}
+
+func (f *fakeProcessFactory) newProcess(command string, logger logrus.FieldLogger, logLevel logrus.Level) (Process, error) {
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+
+	process := f.processes[0]
+	f.processes = f.processes[1:]
+	return process, nil
+}
+
+type fakeReinitializer struct {
+	reinitialized []interface{}
+	err           error
+}
+
+func (r *fakeReinitializer) reinitialize(dispensed interface{}) error {
+	r.reinitialized = append(r.reinitialized, dispensed)
+	return r.err
+}
+
+func TestRestartableProcessReinitializesWhenCredentialsChange(t *testing.T) {
+	var generation uint64
+	key := kindAndName{kind: framework.PluginKindObjectStore, name: "aws"}
+	r := &fakeReinitializer{}
+
+	p := &restartableProcess{
+		logger:                test.NewLogger(),
+		credentialsGeneration: func() uint64 { return generation },
+		clock:                 clock.NewFakeClock(time.Now()),
+		process:               &fakeProcess{},
+		plugins:               map[kindAndName]interface{}{key: "aws"},
+		reinitializers:        map[kindAndName]reinitializer{key: r},
+	}
+
+	require.NoError(t, p.resetIfNeeded())
+	assert.Empty(t, r.reinitialized)
+
+	generation++
+	require.NoError(t, p.resetIfNeeded())
+	assert.Equal(t, []interface{}{"aws"}, r.reinitialized)
+
+	// plugins are only reinitialized once for each change.
+	require.NoError(t, p.resetIfNeeded())
+	assert.Len(t, r.reinitialized, 1)
+
+	// a failed reinitialization is retried.
+	generation++
+	r.err = errors.New("bad credentials")
+	assert.EqualError(t, p.resetIfNeeded(), "bad credentials")
+	r.err = nil
+	require.NoError(t, p.resetIfNeeded())
+	assert.Len(t, r.reinitialized, 3)
+}
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 1, expected: time.Second},
+		{failures: 2, expected: 2 * time.Second},
+		{failures: 3, expected: 4 * time.Second},
+		{failures: 5, expected: 16 * time.Second},
+		{failures: 6, expected: 30 * time.Second},
+		{failures: 100, expected: 30 * time.Second},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, defaultRestartPolicy.backoff(tc.failures), "failures: %d", tc.failures)
+	}
+}
+
+// newTestRestartableProcess returns a restartableProcess for /plugins/a running process, which has dispensed an
+// object store plugin that's reinitialized by r, and the durations that it's slept for.
+func newTestRestartableProcess(process *fakeProcess, factory *fakeProcessFactory, r reinitializer) (*restartableProcess, *[]time.Duration) {
+	key := kindAndName{kind: framework.PluginKindObjectStore, name: "aws"}
+	slept := new([]time.Duration)
+
+	p := &restartableProcess{
+		command:               "/plugins/a",
+		logger:                test.NewLogger(),
+		processFactory:        factory,
+		policy:                defaultRestartPolicy,
+		clock:                 clock.NewFakeClock(time.Now()),
+		sleep:                 func(d time.Duration) { *slept = append(*slept, d) },
+		credentialsGeneration: func() uint64 { return 0 },
+		process:               process,
+		plugins:               map[kindAndName]interface{}{key: "aws"},
+		reinitializers:        map[kindAndName]reinitializer{key: r},
+	}
+	p.lastHealthCheck = p.clock.Now()
+
+	return p, slept
+}
+
+func TestRestartableProcessRestartsCrashedProcessWithBackoff(t *testing.T) {
+	crashed := &fakeProcess{hasExited: true, cause: "exit status 2: panic: oops"}
+	restarted := &fakeProcess{}
+	factory := &fakeProcessFactory{
+		errs:      []error{errors.New("start error"), errors.New("start error")},
+		processes: []*fakeProcess{restarted},
+	}
+	r := &fakeReinitializer{}
+	p, slept := newTestRestartableProcess(crashed, factory, r)
+
+	// the first restart is attempted straight away, and failed restarts are retried with backoff.
+	assert.EqualError(t, p.resetIfNeeded(), "start error")
+	assert.Empty(t, *slept)
+	assert.EqualError(t, p.resetIfNeeded(), "start error")
+	assert.Equal(t, []time.Duration{time.Second}, *slept)
+
+	require.NoError(t, p.resetIfNeeded())
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *slept)
+	assert.Equal(t, restarted, p.process)
+	assert.Equal(t, []interface{}{"aws"}, r.reinitialized)
+	assert.Equal(t, 0, p.resetFailures)
+
+	// the crash is only recorded once, however many times restarting the process is attempted.
+	assert.Equal(t, []string{"/plugins/a: exit status 2: panic: oops"}, p.crashes())
+}
+
+func TestRestartableProcessRestartsUnresponsiveProcess(t *testing.T) {
+	unresponsive := &fakeProcess{healthErr: errors.New("connection refused")}
+	restarted := &fakeProcess{}
+	r := &fakeReinitializer{}
+	p, _ := newTestRestartableProcess(unresponsive, &fakeProcessFactory{processes: []*fakeProcess{restarted}}, r)
+
+	// the process is only health checked once per interval.
+	require.NoError(t, p.resetIfNeeded())
+	assert.Equal(t, 0, unresponsive.healthCalls)
+
+	p.clock.(*clock.FakeClock).Step(defaultRestartPolicy.healthCheckInterval)
+	require.NoError(t, p.resetIfNeeded())
+	assert.Equal(t, 1, unresponsive.healthCalls)
+	assert.True(t, unresponsive.killed)
+	assert.Equal(t, restarted, p.process)
+	assert.Equal(t, []interface{}{"aws"}, r.reinitialized)
+	assert.Equal(t, []string{"/plugins/a: stopped responding: connection refused"}, p.crashes())
+
+	p.clock.(*clock.FakeClock).Step(defaultRestartPolicy.healthCheckInterval)
+	require.NoError(t, p.resetIfNeeded())
+	assert.Equal(t, 1, restarted.healthCalls)
+	assert.False(t, restarted.killed)
+}
+
+func TestRestartableProcessPluginError(t *testing.T) {
+	process := &fakeProcess{cause: "exit status 2: panic: oops"}
+	p, slept := newTestRestartableProcess(process, &fakeProcessFactory{}, &fakeReinitializer{})
+
+	assert.NoError(t, p.pluginError(nil))
+
+	// errors other than the connection to the process closing are returned as is.
+	err := errors.New("bucket not found")
+	assert.Equal(t, err, p.pluginError(err))
+
+	// the plugin returned an Unavailable error itself.
+	unavailable := status.Error(codes.Unavailable, "object store unavailable")
+	assert.Equal(t, unavailable, p.pluginError(unavailable))
+	assert.Empty(t, *slept)
+
+	// the connection closed, but the process didn't exit.
+	process.healthErr = errors.New("transport is closing")
+	closed := status.Error(codes.Unavailable, "transport is closing")
+	assert.Equal(t, closed, p.pluginError(closed))
+	assert.Len(t, *slept, crashPolls)
+	assert.Empty(t, p.crashes())
+
+	// the connection closed because the process crashed.
+	p.sleep = func(time.Duration) { process.hasExited = true }
+	assert.EqualError(t, p.pluginError(closed), "plugin process /plugins/a crashed: exit status 2: panic: oops")
+	assert.Equal(t, []string{"/plugins/a: exit status 2: panic: oops"}, p.crashes())
+}
@@ -74,7 +74,8 @@ func (r *restartableRestoreItemAction) AppliesTo() (velero.ResourceSelector, err
 		return velero.ResourceSelector{}, err
 	}
 
-	return delegate.AppliesTo()
+	selector, err := delegate.AppliesTo()
+	return selector, r.sharedPluginProcess.pluginError(err)
 }
 
 // Execute restarts the plugin's process if needed, then delegates the call.
@@ -84,5 +85,6 @@ func (r *restartableRestoreItemAction) Execute(input *velero.RestoreItemActionEx
 		return nil, err
 	}
 
-	return delegate.Execute(input)
+	output, err := delegate.Execute(input)
+	return output, r.sharedPluginProcess.pluginError(err)
 }
@@ -112,7 +112,8 @@ func (r *restartableVolumeSnapshotter) CreateVolumeFromSnapshot(snapshotID strin
 	if err != nil {
 		return "", err
 	}
-	return delegate.CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ, iops)
+	volumeID, err = delegate.CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ, iops)
+	return volumeID, r.sharedPluginProcess.pluginError(err)
 }
 
 // GetVolumeID restarts the plugin's process if needed, then delegates the call.
@@ -121,7 +122,8 @@ func (r *restartableVolumeSnapshotter) GetVolumeID(pv runtime.Unstructured) (str
 	if err != nil {
 		return "", err
 	}
-	return delegate.GetVolumeID(pv)
+	volumeID, err := delegate.GetVolumeID(pv)
+	return volumeID, r.sharedPluginProcess.pluginError(err)
 }
 
 // SetVolumeID restarts the plugin's process if needed, then delegates the call.
@@ -130,7 +132,8 @@ func (r *restartableVolumeSnapshotter) SetVolumeID(pv runtime.Unstructured, volu
 	if err != nil {
 		return nil, err
 	}
-	return delegate.SetVolumeID(pv, volumeID)
+	updated, err := delegate.SetVolumeID(pv, volumeID)
+	return updated, r.sharedPluginProcess.pluginError(err)
 }
 
 // GetVolumeInfo restarts the plugin's process if needed, then delegates the call.
@@ -139,7 +142,8 @@ func (r *restartableVolumeSnapshotter) GetVolumeInfo(volumeID string, volumeAZ s
 	if err != nil {
 		return "", nil, err
 	}
-	return delegate.GetVolumeInfo(volumeID, volumeAZ)
+	volumeType, iops, err := delegate.GetVolumeInfo(volumeID, volumeAZ)
+	return volumeType, iops, r.sharedPluginProcess.pluginError(err)
 }
 
 // CreateSnapshot restarts the plugin's process if needed, then delegates the call.
@@ -148,7 +152,8 @@ func (r *restartableVolumeSnapshotter) CreateSnapshot(volumeID string, volumeAZ
 	if err != nil {
 		return "", err
 	}
-	return delegate.CreateSnapshot(volumeID, volumeAZ, tags)
+	snapshotID, err = delegate.CreateSnapshot(volumeID, volumeAZ, tags)
+	return snapshotID, r.sharedPluginProcess.pluginError(err)
 }
 
 // DeleteSnapshot restarts the plugin's process if needed, then delegates the call.
@@ -157,5 +162,21 @@ func (r *restartableVolumeSnapshotter) DeleteSnapshot(snapshotID string) error {
 	if err != nil {
 		return err
 	}
-	return delegate.DeleteSnapshot(snapshotID)
+	return r.sharedPluginProcess.pluginError(delegate.DeleteSnapshot(snapshotID))
+}
+
+// ListSnapshots restarts the plugin's process if needed, then delegates the call if the
+// delegate supports listing snapshots.
+func (r *restartableVolumeSnapshotter) ListSnapshots(tagKey string) (map[string]string, error) {
+	delegate, err := r.getDelegate()
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := delegate.(velero.SnapshotLister)
+	if !ok {
+		return nil, velero.ErrSnapshotListingNotSupported
+	}
+	snapshots, err := lister.ListSnapshots(tagKey)
+	return snapshots, r.sharedPluginProcess.pluginError(err)
 }
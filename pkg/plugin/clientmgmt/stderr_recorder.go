@@ -0,0 +1,99 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientmgmt
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxStderrLines is how many of the last lines that a plugin process wrote to stderr are kept.
+	maxStderrLines = 20
+
+	// maxStderrLineLength is how much of each line that a plugin process writes to stderr is kept.
+	maxStderrLineLength = 1024
+)
+
+// stderrRecorder records the lines that a plugin process writes to stderr, other than its logs, so that why the
+// process crashed, e.g. the message of a panic, can be reported.
+type stderrRecorder struct {
+	lock sync.Mutex
+	// partial is the part of the current line that's been written so far.
+	partial []byte
+	// panicLine is the first line that reported a panic or a fatal error.
+	panicLine string
+	// lines are the last lines that were written, oldest first.
+	lines []string
+}
+
+// Write records the complete lines in p. go-plugin writes each line of the process's stderr, then a newline.
+func (r *stderrRecorder) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, b := range p {
+		if b == '\n' {
+			r.addLineLH(string(bytes.TrimSpace(r.partial)))
+			r.partial = r.partial[:0]
+			continue
+		}
+
+		if len(r.partial) < maxStderrLineLength {
+			r.partial = append(r.partial, b)
+		}
+	}
+
+	return len(p), nil
+}
+
+// addLineLH records a line, unless it's empty or it's one of the process's logs, which are JSON-formatted and are
+// already logged by the plugin client.
+//
+// Callers of addLineLH *must* acquire the lock before calling it.
+func (r *stderrRecorder) addLineLH(line string) {
+	if line == "" || strings.HasPrefix(line, "{") {
+		return
+	}
+
+	if r.panicLine == "" && (strings.HasPrefix(line, "panic:") || strings.HasPrefix(line, "fatal error:")) {
+		r.panicLine = line
+	}
+
+	r.lines = append(r.lines, line)
+	if len(r.lines) > maxStderrLines {
+		r.lines = r.lines[len(r.lines)-maxStderrLines:]
+	}
+}
+
+// cause returns the panic or fatal error that the process reported, if it did, or else the last line that it wrote.
+// It's empty if the process didn't write anything other than its logs.
+func (r *stderrRecorder) cause() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.panicLine != "" {
+		return r.panicLine
+	}
+
+	if len(r.lines) > 0 {
+		return r.lines[len(r.lines)-1]
+	}
+
+	return ""
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientmgmt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLines writes lines to r the way go-plugin does: each line, then a newline.
+func writeLines(r *stderrRecorder, lines ...string) {
+	for _, line := range lines {
+		r.Write([]byte(line))
+		r.Write([]byte{'\n'})
+	}
+}
+
+func TestStderrRecorderCause(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected string
+	}{
+		{
+			name:     "only logs",
+			lines:    []string{`{"@level":"info","@message":"starting"}`, ""},
+			expected: "",
+		},
+		{
+			name:     "last line",
+			lines:    []string{"error loading config", `{"@level":"info","@message":"exiting"}`, "exiting with error  "},
+			expected: "exiting with error",
+		},
+		{
+			name: "panic",
+			lines: []string{
+				`{"@level":"info","@message":"backing up"}`,
+				"panic: runtime error: invalid memory address or nil pointer dereference",
+				"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x1234]",
+				"",
+				"goroutine 12 [running]:",
+				"main.(*objectStore).PutObject(...)",
+			},
+			expected: "panic: runtime error: invalid memory address or nil pointer dereference",
+		},
+		{
+			name:     "fatal error",
+			lines:    []string{"fatal error: concurrent map writes", "", "goroutine 7 [running]:"},
+			expected: "fatal error: concurrent map writes",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := new(stderrRecorder)
+			writeLines(r, tc.lines...)
+			assert.Equal(t, tc.expected, r.cause())
+		})
+	}
+}
+
+func TestStderrRecorderLimits(t *testing.T) {
+	r := new(stderrRecorder)
+
+	for i := 0; i < 2*maxStderrLines; i++ {
+		writeLines(r, fmt.Sprintf("line %d", i))
+	}
+	assert.Len(t, r.lines, maxStderrLines)
+	assert.Equal(t, fmt.Sprintf("line %d", 2*maxStderrLines-1), r.cause())
+
+	writeLines(r, strings.Repeat("x", 2*maxStderrLineLength))
+	assert.Len(t, r.cause(), maxStderrLineLength)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	plugin "github.com/hashicorp/go-plugin"
+)
+
+// Plugin API versions. Plugin processes serve every version that the framework they were built with supports, and
+// when Velero starts a plugin process, go-plugin's handshake negotiates the highest version that both support. Methods
+// that are added to the plugin gRPC services are only called on plugins that serve the version that added them, so
+// plugin binaries built with an older framework keep working.
+const (
+	// APIVersion2 is the version that plugins built before plugin API versions were negotiated serve. It's the
+	// handshake's protocol version.
+	APIVersion2 = 2
+
+	// APIVersion3 adds ObjectStore's DeleteObjects method.
+	APIVersion3 = 3
+
+	// LatestAPIVersion is the highest plugin API version that this framework supports.
+	LatestAPIVersion = APIVersion3
+)
+
+// APIVersions returns the plugin API versions that this framework supports, oldest first.
+func APIVersions() []int {
+	var versions []int
+	for version := APIVersion2; version <= LatestAPIVersion; version++ {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// versionedPlugins returns a plugin set for each plugin API version that this framework supports, from pluginSet,
+// which returns the plugin set for a version.
+func versionedPlugins(pluginSet func(version int) plugin.PluginSet) map[int]plugin.PluginSet {
+	versioned := make(map[int]plugin.PluginSet)
+	for _, version := range APIVersions() {
+		versioned[version] = pluginSet(version)
+	}
+	return versioned
+}
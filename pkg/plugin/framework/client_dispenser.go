@@ -25,6 +25,8 @@ import (
 type clientBase struct {
 	plugin string
 	logger logrus.FieldLogger
+	// apiVersion is the plugin API version that was negotiated with the plugin process.
+	apiVersion int
 }
 
 type ClientDispenser interface {
@@ -36,6 +38,8 @@ type ClientDispenser interface {
 type clientDispenser struct {
 	// logger is the log the plugin should use.
 	logger logrus.FieldLogger
+	// apiVersion is the plugin API version that was negotiated with the plugin process.
+	apiVersion int
 	// clienConn is shared among all implementations for this client.
 	clientConn *grpc.ClientConn
 	// initFunc returns a client that implements a plugin interface, such as ObjectStore.
@@ -47,10 +51,11 @@ type clientDispenser struct {
 type clientInitFunc func(base *clientBase, clientConn *grpc.ClientConn) interface{}
 
 // newClientDispenser creates a new clientDispenser.
-func newClientDispenser(logger logrus.FieldLogger, clientConn *grpc.ClientConn, initFunc clientInitFunc) *clientDispenser {
+func newClientDispenser(logger logrus.FieldLogger, apiVersion int, clientConn *grpc.ClientConn, initFunc clientInitFunc) *clientDispenser {
 	return &clientDispenser{
 		clientConn: clientConn,
 		logger:     logger,
+		apiVersion: apiVersion,
 		initFunc:   initFunc,
 		clients:    make(map[string]interface{}),
 	}
@@ -64,8 +69,9 @@ func (cd *clientDispenser) ClientFor(name string) interface{} {
 	}
 
 	base := &clientBase{
-		plugin: name,
-		logger: cd.logger,
+		plugin:     name,
+		logger:     cd.logger,
+		apiVersion: cd.apiVersion,
 	}
 	// Initialize the plugin (e.g. newBackupItemActionGRPCClient())
 	client := cd.initFunc(base, cd.clientConn)
@@ -40,8 +40,9 @@ func TestNewClientDispenser(t *testing.T) {
 		return c
 	}
 
-	cd := newClientDispenser(logger, clientConn, initFunc)
+	cd := newClientDispenser(logger, APIVersion3, clientConn, initFunc)
 	assert.Equal(t, clientConn, cd.clientConn)
+	assert.Equal(t, APIVersion3, cd.apiVersion)
 	assert.NotNil(t, cd.clients)
 	assert.Empty(t, cd.clients)
 }
@@ -59,7 +60,7 @@ func TestClientFor(t *testing.T) {
 		return c
 	}
 
-	cd := newClientDispenser(logger, clientConn, initFunc)
+	cd := newClientDispenser(logger, APIVersion3, clientConn, initFunc)
 
 	actual := cd.ClientFor("pod")
 	require.IsType(t, &fakeClient{}, actual)
@@ -67,8 +68,9 @@ func TestClientFor(t *testing.T) {
 	assert.Equal(t, 1, count)
 	assert.Equal(t, &typed, &c)
 	expectedBase := &clientBase{
-		plugin: "pod",
-		logger: logger,
+		plugin:     "pod",
+		logger:     logger,
+		apiVersion: APIVersion3,
 	}
 	assert.Equal(t, expectedBase, typed.base)
 	assert.Equal(t, clientConn, typed.clientConn)
@@ -54,6 +54,13 @@ type protoStackError struct {
 	stack *proto.Stack
 }
 
+// GRPCStatus returns the status of the gRPC error, so that its code can
+// still be checked with status.FromError.
+func (e *protoStackError) GRPCStatus() *status.Status {
+	statusErr, _ := status.FromError(e.error)
+	return statusErr
+}
+
 func (e *protoStackError) File() string {
 	if e.stack == nil || len(e.stack.Frames) < 1 {
 		return ""
@@ -21,10 +21,10 @@ import plugin "github.com/hashicorp/go-plugin"
 // Handshake returns the configuration information that allows go-plugin clients and servers to perform a handshake.
 func Handshake() plugin.HandshakeConfig {
 	return plugin.HandshakeConfig{
-		// The ProtocolVersion is the version that must match between Velero framework
-		// and Velero client plugins. This should be bumped whenever a change happens in
-		// one or the other that makes it so that they can't safely communicate.
-		ProtocolVersion: 2,
+		// The ProtocolVersion is the plugin API version that's used with plugins that
+		// don't negotiate one. Newer versions are negotiated with go-plugin's versioned
+		// plugins, and must stay compatible with it: see APIVersions.
+		ProtocolVersion: APIVersion2,
 
 		MagicCookieKey:   "VELERO_PLUGIN",
 		MagicCookieValue: "hello",
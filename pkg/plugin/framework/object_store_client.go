@@ -23,8 +23,11 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	proto "github.com/heptio/velero/pkg/plugin/generated"
+	"github.com/heptio/velero/pkg/plugin/velero"
 )
 
 const byteChunkSize = 16384
@@ -126,6 +129,18 @@ func (c *ObjectStoreGRPCClient) GetObject(bucket, key string) (io.ReadCloser, er
 		return nil, fromGRPCError(err)
 	}
 
+	return newBytesStreamReadCloser(stream), nil
+}
+
+// bytesStream is a stream of an object's data from the plugin.
+type bytesStream interface {
+	Recv() (*proto.Bytes, error)
+	CloseSend() error
+}
+
+// newBytesStreamReadCloser returns a ReadCloser that reads an object's data
+// from a stream.
+func newBytesStreamReadCloser(stream bytesStream) io.ReadCloser {
 	receive := func() ([]byte, error) {
 		data, err := stream.Recv()
 		if err == io.EOF {
@@ -148,7 +163,7 @@ func (c *ObjectStoreGRPCClient) GetObject(bucket, key string) (io.ReadCloser, er
 		return nil
 	}
 
-	return &StreamReadCloser{receive: receive, close: close}, nil
+	return &StreamReadCloser{receive: receive, close: close}
 }
 
 // ListCommonPrefixes gets a list of all object key prefixes that come
@@ -218,3 +233,130 @@ func (c *ObjectStoreGRPCClient) CreateSignedURL(bucket, key string, ttl time.Dur
 
 	return res.Url, nil
 }
+
+// Capabilities returns the optional features that the object store supports.
+// Plugins that were built before object stores reported their capabilities
+// don't implement it, so they're assumed to have the legacy capabilities.
+func (c *ObjectStoreGRPCClient) Capabilities() (velero.ObjectStoreCapabilities, error) {
+	req := &proto.ObjectStoreCapabilitiesRequest{
+		Plugin: c.plugin,
+	}
+
+	res, err := c.grpcClient.Capabilities(context.Background(), req)
+	if status.Code(err) == codes.Unimplemented {
+		return velero.LegacyObjectStoreCapabilities, nil
+	}
+	if err != nil {
+		return velero.ObjectStoreCapabilities{}, fromGRPCError(err)
+	}
+
+	return velero.ObjectStoreCapabilities{
+		SignedURLs:      res.SignedURLs,
+		MultipartUpload: res.MultipartUpload,
+		Tagging:         res.Tagging,
+		BatchDelete:     res.BatchDelete,
+		ServerSideCopy:  res.ServerSideCopy,
+		Versioning:      res.Versioning,
+		ObjectLock:      res.ObjectLock,
+	}, nil
+}
+
+// CopyObject copies the object with the given source key to the destination
+// key within the bucket, without downloading it.
+func (c *ObjectStoreGRPCClient) CopyObject(bucket, srcKey, destKey string) error {
+	req := &proto.CopyObjectRequest{
+		Plugin:  c.plugin,
+		Bucket:  bucket,
+		SrcKey:  srcKey,
+		DestKey: destKey,
+	}
+
+	if _, err := c.grpcClient.CopyObject(context.Background(), req); err != nil {
+		return fromGRPCError(err)
+	}
+
+	return nil
+}
+
+// ListObjectVersions lists the versions of the object with the given key in
+// the bucket, including delete markers, newest first.
+func (c *ObjectStoreGRPCClient) ListObjectVersions(bucket, key string) ([]velero.ObjectVersion, error) {
+	req := &proto.ListObjectVersionsRequest{
+		Plugin: c.plugin,
+		Bucket: bucket,
+		Key:    key,
+	}
+
+	res, err := c.grpcClient.ListObjectVersions(context.Background(), req)
+	if err != nil {
+		return nil, fromGRPCError(err)
+	}
+
+	versions := make([]velero.ObjectVersion, 0, len(res.Versions))
+	for _, version := range res.Versions {
+		versions = append(versions, velero.ObjectVersion{
+			VersionID:      version.VersionID,
+			LastModified:   time.Unix(0, version.LastModified).UTC(),
+			IsLatest:       version.IsLatest,
+			IsDeleteMarker: version.IsDeleteMarker,
+		})
+	}
+
+	return versions, nil
+}
+
+// GetObjectVersion retrieves the given version of the object with the given
+// key from the bucket.
+func (c *ObjectStoreGRPCClient) GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error) {
+	req := &proto.GetObjectVersionRequest{
+		Plugin:    c.plugin,
+		Bucket:    bucket,
+		Key:       key,
+		VersionID: versionID,
+	}
+
+	stream, err := c.grpcClient.GetObjectVersion(context.Background(), req)
+	if err != nil {
+		return nil, fromGRPCError(err)
+	}
+
+	return newBytesStreamReadCloser(stream), nil
+}
+
+// LockObject locks the object with the given key in the bucket so that it
+// can't be deleted or overwritten until the given time.
+func (c *ObjectStoreGRPCClient) LockObject(bucket, key string, until time.Time) error {
+	req := &proto.LockObjectRequest{
+		Plugin: c.plugin,
+		Bucket: bucket,
+		Key:    key,
+		Until:  until.UnixNano(),
+	}
+
+	if _, err := c.grpcClient.LockObject(context.Background(), req); err != nil {
+		return fromGRPCError(err)
+	}
+
+	return nil
+}
+
+// DeleteObjects removes the objects with the specified keys from the given
+// bucket. It returns velero.ErrBatchDeleteNotSupported if the plugin only
+// serves a plugin API version older than APIVersion3, which added it.
+func (c *ObjectStoreGRPCClient) DeleteObjects(bucket string, keys []string) error {
+	if c.apiVersion < APIVersion3 {
+		return velero.ErrBatchDeleteNotSupported
+	}
+
+	req := &proto.DeleteObjectsRequest{
+		Plugin: c.plugin,
+		Bucket: bucket,
+		Keys:   keys,
+	}
+
+	if _, err := c.grpcClient.DeleteObjects(context.Background(), req); err != nil {
+		return fromGRPCError(err)
+	}
+
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
+	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
 
 	proto "github.com/heptio/velero/pkg/plugin/generated"
 	"github.com/heptio/velero/pkg/plugin/velero"
@@ -169,6 +170,11 @@ func (s *ObjectStoreGRPCServer) GetObject(req *proto.GetObjectRequest, stream pr
 	}
 	defer rdr.Close()
 
+	return sendBytes(rdr, stream)
+}
+
+// sendBytes sends an object's data to the client in chunks.
+func sendBytes(rdr io.Reader, stream interface{ Send(*proto.Bytes) error }) error {
 	chunk := make([]byte, byteChunkSize)
 	for {
 		n, err := rdr.Read(chunk)
@@ -270,3 +276,164 @@ func (s *ObjectStoreGRPCServer) CreateSignedURL(ctx context.Context, req *proto.
 
 	return &proto.CreateSignedURLResponse{Url: url}, nil
 }
+
+// Capabilities returns the optional features that the object store supports.
+func (s *ObjectStoreGRPCServer) Capabilities(ctx context.Context, req *proto.ObjectStoreCapabilitiesRequest) (response *proto.ObjectStoreCapabilitiesResponse, err error) {
+	defer func() {
+		if recoveredErr := handlePanic(recover()); recoveredErr != nil {
+			err = recoveredErr
+		}
+	}()
+
+	impl, err := s.getImpl(req.Plugin)
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	capabilities, err := impl.Capabilities()
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	return &proto.ObjectStoreCapabilitiesResponse{
+		SignedURLs:      capabilities.SignedURLs,
+		MultipartUpload: capabilities.MultipartUpload,
+		Tagging:         capabilities.Tagging,
+		BatchDelete:     capabilities.BatchDelete,
+		ServerSideCopy:  capabilities.ServerSideCopy,
+		Versioning:      capabilities.Versioning,
+		ObjectLock:      capabilities.ObjectLock,
+	}, nil
+}
+
+// CopyObject copies the object with the given source key to the destination
+// key within the bucket, without downloading it.
+func (s *ObjectStoreGRPCServer) CopyObject(ctx context.Context, req *proto.CopyObjectRequest) (response *proto.Empty, err error) {
+	defer func() {
+		if recoveredErr := handlePanic(recover()); recoveredErr != nil {
+			err = recoveredErr
+		}
+	}()
+
+	impl, err := s.getImpl(req.Plugin)
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	if err := impl.CopyObject(req.Bucket, req.SrcKey, req.DestKey); err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ListObjectVersions lists the versions of the object with the given key in
+// the bucket, including delete markers, newest first.
+func (s *ObjectStoreGRPCServer) ListObjectVersions(ctx context.Context, req *proto.ListObjectVersionsRequest) (response *proto.ListObjectVersionsResponse, err error) {
+	defer func() {
+		if recoveredErr := handlePanic(recover()); recoveredErr != nil {
+			err = recoveredErr
+		}
+	}()
+
+	impl, err := s.getImpl(req.Plugin)
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	versions, err := impl.ListObjectVersions(req.Bucket, req.Key)
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	res := &proto.ListObjectVersionsResponse{}
+	for _, version := range versions {
+		res.Versions = append(res.Versions, &proto.ObjectVersion{
+			VersionID:      version.VersionID,
+			LastModified:   version.LastModified.UnixNano(),
+			IsLatest:       version.IsLatest,
+			IsDeleteMarker: version.IsDeleteMarker,
+		})
+	}
+
+	return res, nil
+}
+
+// GetObjectVersion retrieves the given version of the object with the given
+// key from the bucket.
+func (s *ObjectStoreGRPCServer) GetObjectVersion(req *proto.GetObjectVersionRequest, stream proto.ObjectStore_GetObjectVersionServer) (err error) {
+	defer func() {
+		if recoveredErr := handlePanic(recover()); recoveredErr != nil {
+			err = recoveredErr
+		}
+	}()
+
+	impl, err := s.getImpl(req.Plugin)
+	if err != nil {
+		return newGRPCError(err)
+	}
+
+	rdr, err := impl.GetObjectVersion(req.Bucket, req.Key, req.VersionID)
+	if err != nil {
+		return newGRPCError(err)
+	}
+	defer rdr.Close()
+
+	return sendBytes(rdr, stream)
+}
+
+// LockObject locks the object with the given key in the bucket so that it
+// can't be deleted or overwritten until the given time.
+func (s *ObjectStoreGRPCServer) LockObject(ctx context.Context, req *proto.LockObjectRequest) (response *proto.Empty, err error) {
+	defer func() {
+		if recoveredErr := handlePanic(recover()); recoveredErr != nil {
+			err = recoveredErr
+		}
+	}()
+
+	impl, err := s.getImpl(req.Plugin)
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	if err := impl.LockObject(req.Bucket, req.Key, time.Unix(0, req.Until).UTC()); err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// DeleteObjects removes the objects with the specified keys from the given
+// bucket, in one request if the object store implements velero.BatchDeleter,
+// or else one at a time.
+func (s *ObjectStoreGRPCServer) DeleteObjects(ctx context.Context, req *proto.DeleteObjectsRequest) (response *proto.Empty, err error) {
+	defer func() {
+		if recoveredErr := handlePanic(recover()); recoveredErr != nil {
+			err = recoveredErr
+		}
+	}()
+
+	impl, err := s.getImpl(req.Plugin)
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	if deleter, ok := impl.(velero.BatchDeleter); ok {
+		if err := deleter.DeleteObjects(req.Bucket, req.Keys); err != nil {
+			return nil, newGRPCError(err)
+		}
+		return &proto.Empty{}, nil
+	}
+
+	var errs []error
+	for _, key := range req.Keys {
+		if err := impl.DeleteObject(req.Bucket, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, newGRPCError(kubeerrs.NewAggregate(errs))
+	}
+
+	return &proto.Empty{}, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	cloudprovidermocks "github.com/heptio/velero/pkg/cloudprovider/mocks"
+	proto "github.com/heptio/velero/pkg/plugin/generated"
+	"github.com/heptio/velero/pkg/plugin/velero"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestObjectStoreGRPCClientDeleteObjectsAPIVersion(t *testing.T) {
+	// plugins that only serve a version older than the one that added
+	// DeleteObjects aren't called.
+	c := &ObjectStoreGRPCClient{
+		clientBase: &clientBase{plugin: "xyz", apiVersion: APIVersion2},
+	}
+
+	err := c.DeleteObjects("bucket", []string{"a", "b"})
+	assert.Equal(t, velero.ErrBatchDeleteNotSupported, err)
+}
+
+// batchDeleterObjectStore is an object store that supports deleting objects in batches.
+type batchDeleterObjectStore struct {
+	*cloudprovidermocks.ObjectStore
+}
+
+func (o *batchDeleterObjectStore) DeleteObjects(bucket string, keys []string) error {
+	return o.Called(bucket, keys).Error(0)
+}
+
+func TestObjectStoreGRPCServerDeleteObjects(t *testing.T) {
+	req := &proto.DeleteObjectsRequest{
+		Plugin: "xyz",
+		Bucket: "bucket",
+		Keys:   []string{"a", "b", "c"},
+	}
+
+	newServer := func(impl interface{}) *ObjectStoreGRPCServer {
+		return &ObjectStoreGRPCServer{mux: &serverMux{
+			serverLog: velerotest.NewLogger(),
+			handlers: map[string]interface{}{
+				"xyz": impl,
+			},
+		}}
+	}
+
+	// object stores that don't support deleting objects in batches
+	// delete them one at a time, and as many of them as they can.
+	objectStore := new(cloudprovidermocks.ObjectStore)
+	objectStore.Test(t)
+	defer objectStore.AssertExpectations(t)
+	objectStore.On("DeleteObject", "bucket", "a").Return(errors.New("a"))
+	objectStore.On("DeleteObject", "bucket", "b").Return(nil)
+	objectStore.On("DeleteObject", "bucket", "c").Return(errors.New("c"))
+
+	_, err := newServer(objectStore).DeleteObjects(context.Background(), req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[a, c]")
+
+	deleter := &batchDeleterObjectStore{ObjectStore: new(cloudprovidermocks.ObjectStore)}
+	deleter.Test(t)
+	defer deleter.AssertExpectations(t)
+	deleter.On("DeleteObjects", "bucket", []string{"a", "b", "c"}).Return(nil)
+
+	res, err := newServer(deleter).DeleteObjects(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, &proto.Empty{}, res)
+}
@@ -22,11 +22,13 @@ import (
 
 type pluginBase struct {
 	clientLogger logrus.FieldLogger
+	// clientAPIVersion is the plugin API version that the plugin's clients were negotiated with the plugin process.
+	clientAPIVersion int
 	*serverMux
 }
 
 func newPluginBase(options ...PluginOption) *pluginBase {
-	base := new(pluginBase)
+	base := &pluginBase{clientAPIVersion: APIVersion2}
 	for _, option := range options {
 		option(base)
 	}
@@ -41,6 +43,15 @@ func ClientLogger(logger logrus.FieldLogger) PluginOption {
 	}
 }
 
+// ClientAPIVersion sets the plugin API version that the plugin's clients use, which must be the version that was
+// negotiated with the plugin process. Clients only call methods that were added in later versions than
+// APIVersion2 if it's at least the version that added them.
+func ClientAPIVersion(version int) PluginOption {
+	return func(base *pluginBase) {
+		base.clientAPIVersion = version
+	}
+}
+
 func serverLogger(logger logrus.FieldLogger) PluginOption {
 	return func(base *pluginBase) {
 		base.serverMux = newServerMux(logger)
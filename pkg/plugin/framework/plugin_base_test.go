@@ -38,3 +38,9 @@ func TestServerLogger(t *testing.T) {
 	f(base)
 	assert.Equal(t, newServerMux(logger), base.serverMux)
 }
+
+func TestClientAPIVersion(t *testing.T) {
+	// plugins' clients use the original API version unless they're told otherwise.
+	assert.Equal(t, APIVersion2, newPluginBase().clientAPIVersion)
+	assert.Equal(t, APIVersion3, newPluginBase(ClientAPIVersion(APIVersion3)).clientAPIVersion)
+}
@@ -181,15 +181,18 @@ func (s *server) Serve() {
 
 	pluginLister := NewPluginLister(pluginIdentifiers...)
 
+	plugins := map[string]plugin.Plugin{
+		string(PluginKindBackupItemAction):  s.backupItemAction,
+		string(PluginKindVolumeSnapshotter): s.volumeSnapshotter,
+		string(PluginKindObjectStore):       s.objectStore,
+		string(PluginKindPluginLister):      NewPluginListerPlugin(pluginLister),
+		string(PluginKindRestoreItemAction): s.restoreItemAction,
+	}
+
 	plugin.Serve(&plugin.ServeConfig{
 		HandshakeConfig: Handshake(),
-		Plugins: map[string]plugin.Plugin{
-			string(PluginKindBackupItemAction):  s.backupItemAction,
-			string(PluginKindVolumeSnapshotter): s.volumeSnapshotter,
-			string(PluginKindObjectStore):       s.objectStore,
-			string(PluginKindPluginLister):      NewPluginListerPlugin(pluginLister),
-			string(PluginKindRestoreItemAction): s.restoreItemAction,
-		},
-		GRPCServer: plugin.DefaultGRPCServer,
+		// the same plugins serve every plugin API version, since each version only adds methods.
+		VersionedPlugins: versionedPlugins(func(int) plugin.PluginSet { return plugins }),
+		GRPCServer:       plugin.DefaultGRPCServer,
 	})
 }
@@ -22,10 +22,13 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	proto "github.com/heptio/velero/pkg/plugin/generated"
+	"github.com/heptio/velero/pkg/plugin/velero"
 )
 
 // NewVolumeSnapshotterPlugin constructs a VolumeSnapshotterPlugin.
@@ -186,3 +189,23 @@ func (c *VolumeSnapshotterGRPCClient) SetVolumeID(pv runtime.Unstructured, volum
 
 	return &updatedPV, nil
 }
+
+// ListSnapshots returns the IDs of the snapshots that have a tag with the given key,
+// mapped to the tag's value. It returns velero.ErrSnapshotListingNotSupported if the
+// plugin doesn't support listing snapshots.
+func (c *VolumeSnapshotterGRPCClient) ListSnapshots(tagKey string) (map[string]string, error) {
+	req := &proto.ListSnapshotsRequest{
+		Plugin: c.plugin,
+		TagKey: tagKey,
+	}
+
+	res, err := c.grpcClient.ListSnapshots(context.Background(), req)
+	if status.Code(err) == codes.Unimplemented {
+		return nil, velero.ErrSnapshotListingNotSupported
+	}
+	if err != nil {
+		return nil, fromGRPCError(err)
+	}
+
+	return res.Snapshots, nil
+}
@@ -21,6 +21,8 @@ import (
 
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	proto "github.com/heptio/velero/pkg/plugin/generated"
@@ -227,3 +229,30 @@ func (s *VolumeSnapshotterGRPCServer) SetVolumeID(ctx context.Context, req *prot
 
 	return &proto.SetVolumeIDResponse{PersistentVolume: updatedPVBytes}, nil
 }
+
+// ListSnapshots returns the IDs of the snapshots that have a tag with the given key,
+// mapped to the tag's value, if the volume snapshotter implements velero.SnapshotLister.
+func (s *VolumeSnapshotterGRPCServer) ListSnapshots(ctx context.Context, req *proto.ListSnapshotsRequest) (response *proto.ListSnapshotsResponse, err error) {
+	defer func() {
+		if recoveredErr := handlePanic(recover()); recoveredErr != nil {
+			err = recoveredErr
+		}
+	}()
+
+	impl, err := s.getImpl(req.Plugin)
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	lister, ok := impl.(velero.SnapshotLister)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "%T doesn't support listing snapshots", impl)
+	}
+
+	snapshots, err := lister.ListSnapshots(req.TagKey)
+	if err != nil {
+		return nil, newGRPCError(err)
+	}
+
+	return &proto.ListSnapshotsResponse{Snapshots: snapshots}, nil
+}
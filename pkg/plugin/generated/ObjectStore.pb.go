@@ -369,6 +369,330 @@ func (m *ObjectStoreInitRequest) GetConfig() map[string]string {
 	return nil
 }
 
+type ObjectStoreCapabilitiesRequest struct {
+	Plugin string `protobuf:"bytes,1,opt,name=plugin" json:"plugin,omitempty"`
+}
+
+func (m *ObjectStoreCapabilitiesRequest) Reset()         { *m = ObjectStoreCapabilitiesRequest{} }
+func (m *ObjectStoreCapabilitiesRequest) String() string { return proto.CompactTextString(m) }
+func (*ObjectStoreCapabilitiesRequest) ProtoMessage()    {}
+func (*ObjectStoreCapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor1, []int{13}
+}
+
+func (m *ObjectStoreCapabilitiesRequest) GetPlugin() string {
+	if m != nil {
+		return m.Plugin
+	}
+	return ""
+}
+
+type ObjectStoreCapabilitiesResponse struct {
+	SignedURLs      bool `protobuf:"varint,1,opt,name=signedURLs" json:"signedURLs,omitempty"`
+	MultipartUpload bool `protobuf:"varint,2,opt,name=multipartUpload" json:"multipartUpload,omitempty"`
+	Tagging         bool `protobuf:"varint,3,opt,name=tagging" json:"tagging,omitempty"`
+	BatchDelete     bool `protobuf:"varint,4,opt,name=batchDelete" json:"batchDelete,omitempty"`
+	ServerSideCopy  bool `protobuf:"varint,5,opt,name=serverSideCopy" json:"serverSideCopy,omitempty"`
+	Versioning      bool `protobuf:"varint,6,opt,name=versioning" json:"versioning,omitempty"`
+	ObjectLock      bool `protobuf:"varint,7,opt,name=objectLock" json:"objectLock,omitempty"`
+}
+
+func (m *ObjectStoreCapabilitiesResponse) Reset()         { *m = ObjectStoreCapabilitiesResponse{} }
+func (m *ObjectStoreCapabilitiesResponse) String() string { return proto.CompactTextString(m) }
+func (*ObjectStoreCapabilitiesResponse) ProtoMessage()    {}
+func (*ObjectStoreCapabilitiesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor1, []int{14}
+}
+
+func (m *ObjectStoreCapabilitiesResponse) GetSignedURLs() bool {
+	if m != nil {
+		return m.SignedURLs
+	}
+	return false
+}
+
+func (m *ObjectStoreCapabilitiesResponse) GetMultipartUpload() bool {
+	if m != nil {
+		return m.MultipartUpload
+	}
+	return false
+}
+
+func (m *ObjectStoreCapabilitiesResponse) GetTagging() bool {
+	if m != nil {
+		return m.Tagging
+	}
+	return false
+}
+
+func (m *ObjectStoreCapabilitiesResponse) GetBatchDelete() bool {
+	if m != nil {
+		return m.BatchDelete
+	}
+	return false
+}
+
+func (m *ObjectStoreCapabilitiesResponse) GetServerSideCopy() bool {
+	if m != nil {
+		return m.ServerSideCopy
+	}
+	return false
+}
+
+func (m *ObjectStoreCapabilitiesResponse) GetVersioning() bool {
+	if m != nil {
+		return m.Versioning
+	}
+	return false
+}
+
+func (m *ObjectStoreCapabilitiesResponse) GetObjectLock() bool {
+	if m != nil {
+		return m.ObjectLock
+	}
+	return false
+}
+
+type CopyObjectRequest struct {
+	Plugin  string `protobuf:"bytes,1,opt,name=plugin" json:"plugin,omitempty"`
+	Bucket  string `protobuf:"bytes,2,opt,name=bucket" json:"bucket,omitempty"`
+	SrcKey  string `protobuf:"bytes,3,opt,name=srcKey" json:"srcKey,omitempty"`
+	DestKey string `protobuf:"bytes,4,opt,name=destKey" json:"destKey,omitempty"`
+}
+
+func (m *CopyObjectRequest) Reset()                    { *m = CopyObjectRequest{} }
+func (m *CopyObjectRequest) String() string            { return proto.CompactTextString(m) }
+func (*CopyObjectRequest) ProtoMessage()               {}
+func (*CopyObjectRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{15} }
+
+func (m *CopyObjectRequest) GetPlugin() string {
+	if m != nil {
+		return m.Plugin
+	}
+	return ""
+}
+
+func (m *CopyObjectRequest) GetBucket() string {
+	if m != nil {
+		return m.Bucket
+	}
+	return ""
+}
+
+func (m *CopyObjectRequest) GetSrcKey() string {
+	if m != nil {
+		return m.SrcKey
+	}
+	return ""
+}
+
+func (m *CopyObjectRequest) GetDestKey() string {
+	if m != nil {
+		return m.DestKey
+	}
+	return ""
+}
+
+type ListObjectVersionsRequest struct {
+	Plugin string `protobuf:"bytes,1,opt,name=plugin" json:"plugin,omitempty"`
+	Bucket string `protobuf:"bytes,2,opt,name=bucket" json:"bucket,omitempty"`
+	Key    string `protobuf:"bytes,3,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *ListObjectVersionsRequest) Reset()                    { *m = ListObjectVersionsRequest{} }
+func (m *ListObjectVersionsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListObjectVersionsRequest) ProtoMessage()               {}
+func (*ListObjectVersionsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{16} }
+
+func (m *ListObjectVersionsRequest) GetPlugin() string {
+	if m != nil {
+		return m.Plugin
+	}
+	return ""
+}
+
+func (m *ListObjectVersionsRequest) GetBucket() string {
+	if m != nil {
+		return m.Bucket
+	}
+	return ""
+}
+
+func (m *ListObjectVersionsRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type ObjectVersion struct {
+	VersionID      string `protobuf:"bytes,1,opt,name=versionID" json:"versionID,omitempty"`
+	LastModified   int64  `protobuf:"varint,2,opt,name=lastModified" json:"lastModified,omitempty"`
+	IsLatest       bool   `protobuf:"varint,3,opt,name=isLatest" json:"isLatest,omitempty"`
+	IsDeleteMarker bool   `protobuf:"varint,4,opt,name=isDeleteMarker" json:"isDeleteMarker,omitempty"`
+}
+
+func (m *ObjectVersion) Reset()                    { *m = ObjectVersion{} }
+func (m *ObjectVersion) String() string            { return proto.CompactTextString(m) }
+func (*ObjectVersion) ProtoMessage()               {}
+func (*ObjectVersion) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{17} }
+
+func (m *ObjectVersion) GetVersionID() string {
+	if m != nil {
+		return m.VersionID
+	}
+	return ""
+}
+
+func (m *ObjectVersion) GetLastModified() int64 {
+	if m != nil {
+		return m.LastModified
+	}
+	return 0
+}
+
+func (m *ObjectVersion) GetIsLatest() bool {
+	if m != nil {
+		return m.IsLatest
+	}
+	return false
+}
+
+func (m *ObjectVersion) GetIsDeleteMarker() bool {
+	if m != nil {
+		return m.IsDeleteMarker
+	}
+	return false
+}
+
+type ListObjectVersionsResponse struct {
+	Versions []*ObjectVersion `protobuf:"bytes,1,rep,name=versions" json:"versions,omitempty"`
+}
+
+func (m *ListObjectVersionsResponse) Reset()                    { *m = ListObjectVersionsResponse{} }
+func (m *ListObjectVersionsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListObjectVersionsResponse) ProtoMessage()               {}
+func (*ListObjectVersionsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{18} }
+
+func (m *ListObjectVersionsResponse) GetVersions() []*ObjectVersion {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type GetObjectVersionRequest struct {
+	Plugin    string `protobuf:"bytes,1,opt,name=plugin" json:"plugin,omitempty"`
+	Bucket    string `protobuf:"bytes,2,opt,name=bucket" json:"bucket,omitempty"`
+	Key       string `protobuf:"bytes,3,opt,name=key" json:"key,omitempty"`
+	VersionID string `protobuf:"bytes,4,opt,name=versionID" json:"versionID,omitempty"`
+}
+
+func (m *GetObjectVersionRequest) Reset()                    { *m = GetObjectVersionRequest{} }
+func (m *GetObjectVersionRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetObjectVersionRequest) ProtoMessage()               {}
+func (*GetObjectVersionRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{19} }
+
+func (m *GetObjectVersionRequest) GetPlugin() string {
+	if m != nil {
+		return m.Plugin
+	}
+	return ""
+}
+
+func (m *GetObjectVersionRequest) GetBucket() string {
+	if m != nil {
+		return m.Bucket
+	}
+	return ""
+}
+
+func (m *GetObjectVersionRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *GetObjectVersionRequest) GetVersionID() string {
+	if m != nil {
+		return m.VersionID
+	}
+	return ""
+}
+
+type LockObjectRequest struct {
+	Plugin string `protobuf:"bytes,1,opt,name=plugin" json:"plugin,omitempty"`
+	Bucket string `protobuf:"bytes,2,opt,name=bucket" json:"bucket,omitempty"`
+	Key    string `protobuf:"bytes,3,opt,name=key" json:"key,omitempty"`
+	Until  int64  `protobuf:"varint,4,opt,name=until" json:"until,omitempty"`
+}
+
+func (m *LockObjectRequest) Reset()                    { *m = LockObjectRequest{} }
+func (m *LockObjectRequest) String() string            { return proto.CompactTextString(m) }
+func (*LockObjectRequest) ProtoMessage()               {}
+func (*LockObjectRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{20} }
+
+func (m *LockObjectRequest) GetPlugin() string {
+	if m != nil {
+		return m.Plugin
+	}
+	return ""
+}
+
+func (m *LockObjectRequest) GetBucket() string {
+	if m != nil {
+		return m.Bucket
+	}
+	return ""
+}
+
+func (m *LockObjectRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *LockObjectRequest) GetUntil() int64 {
+	if m != nil {
+		return m.Until
+	}
+	return 0
+}
+
+type DeleteObjectsRequest struct {
+	Plugin string   `protobuf:"bytes,1,opt,name=plugin" json:"plugin,omitempty"`
+	Bucket string   `protobuf:"bytes,2,opt,name=bucket" json:"bucket,omitempty"`
+	Keys   []string `protobuf:"bytes,3,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *DeleteObjectsRequest) Reset()                    { *m = DeleteObjectsRequest{} }
+func (m *DeleteObjectsRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteObjectsRequest) ProtoMessage()               {}
+func (*DeleteObjectsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{21} }
+
+func (m *DeleteObjectsRequest) GetPlugin() string {
+	if m != nil {
+		return m.Plugin
+	}
+	return ""
+}
+
+func (m *DeleteObjectsRequest) GetBucket() string {
+	if m != nil {
+		return m.Bucket
+	}
+	return ""
+}
+
+func (m *DeleteObjectsRequest) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*PutObjectRequest)(nil), "generated.PutObjectRequest")
 	proto.RegisterType((*ObjectExistsRequest)(nil), "generated.ObjectExistsRequest")
@@ -383,6 +707,15 @@ func init() {
 	proto.RegisterType((*CreateSignedURLRequest)(nil), "generated.CreateSignedURLRequest")
 	proto.RegisterType((*CreateSignedURLResponse)(nil), "generated.CreateSignedURLResponse")
 	proto.RegisterType((*ObjectStoreInitRequest)(nil), "generated.ObjectStoreInitRequest")
+	proto.RegisterType((*ObjectStoreCapabilitiesRequest)(nil), "generated.ObjectStoreCapabilitiesRequest")
+	proto.RegisterType((*ObjectStoreCapabilitiesResponse)(nil), "generated.ObjectStoreCapabilitiesResponse")
+	proto.RegisterType((*CopyObjectRequest)(nil), "generated.CopyObjectRequest")
+	proto.RegisterType((*ListObjectVersionsRequest)(nil), "generated.ListObjectVersionsRequest")
+	proto.RegisterType((*ObjectVersion)(nil), "generated.ObjectVersion")
+	proto.RegisterType((*ListObjectVersionsResponse)(nil), "generated.ListObjectVersionsResponse")
+	proto.RegisterType((*GetObjectVersionRequest)(nil), "generated.GetObjectVersionRequest")
+	proto.RegisterType((*LockObjectRequest)(nil), "generated.LockObjectRequest")
+	proto.RegisterType((*DeleteObjectsRequest)(nil), "generated.DeleteObjectsRequest")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -404,6 +737,13 @@ type ObjectStoreClient interface {
 	ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error)
 	DeleteObject(ctx context.Context, in *DeleteObjectRequest, opts ...grpc.CallOption) (*Empty, error)
 	CreateSignedURL(ctx context.Context, in *CreateSignedURLRequest, opts ...grpc.CallOption) (*CreateSignedURLResponse, error)
+	Capabilities(ctx context.Context, in *ObjectStoreCapabilitiesRequest, opts ...grpc.CallOption) (*ObjectStoreCapabilitiesResponse, error)
+	CopyObject(ctx context.Context, in *CopyObjectRequest, opts ...grpc.CallOption) (*Empty, error)
+	ListObjectVersions(ctx context.Context, in *ListObjectVersionsRequest, opts ...grpc.CallOption) (*ListObjectVersionsResponse, error)
+	GetObjectVersion(ctx context.Context, in *GetObjectVersionRequest, opts ...grpc.CallOption) (ObjectStore_GetObjectVersionClient, error)
+	LockObject(ctx context.Context, in *LockObjectRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Added in plugin API version 3.
+	DeleteObjects(ctx context.Context, in *DeleteObjectsRequest, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type objectStoreClient struct {
@@ -534,6 +874,83 @@ func (c *objectStoreClient) CreateSignedURL(ctx context.Context, in *CreateSigne
 	return out, nil
 }
 
+func (c *objectStoreClient) Capabilities(ctx context.Context, in *ObjectStoreCapabilitiesRequest, opts ...grpc.CallOption) (*ObjectStoreCapabilitiesResponse, error) {
+	out := new(ObjectStoreCapabilitiesResponse)
+	err := grpc.Invoke(ctx, "/generated.ObjectStore/Capabilities", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *objectStoreClient) CopyObject(ctx context.Context, in *CopyObjectRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/generated.ObjectStore/CopyObject", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *objectStoreClient) ListObjectVersions(ctx context.Context, in *ListObjectVersionsRequest, opts ...grpc.CallOption) (*ListObjectVersionsResponse, error) {
+	out := new(ListObjectVersionsResponse)
+	err := grpc.Invoke(ctx, "/generated.ObjectStore/ListObjectVersions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *objectStoreClient) GetObjectVersion(ctx context.Context, in *GetObjectVersionRequest, opts ...grpc.CallOption) (ObjectStore_GetObjectVersionClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_ObjectStore_serviceDesc.Streams[2], c.cc, "/generated.ObjectStore/GetObjectVersion", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &objectStoreGetObjectVersionClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ObjectStore_GetObjectVersionClient interface {
+	Recv() (*Bytes, error)
+	grpc.ClientStream
+}
+
+type objectStoreGetObjectVersionClient struct {
+	grpc.ClientStream
+}
+
+func (x *objectStoreGetObjectVersionClient) Recv() (*Bytes, error) {
+	m := new(Bytes)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *objectStoreClient) LockObject(ctx context.Context, in *LockObjectRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/generated.ObjectStore/LockObject", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *objectStoreClient) DeleteObjects(ctx context.Context, in *DeleteObjectsRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/generated.ObjectStore/DeleteObjects", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for ObjectStore service
 
 type ObjectStoreServer interface {
@@ -545,6 +962,13 @@ type ObjectStoreServer interface {
 	ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error)
 	DeleteObject(context.Context, *DeleteObjectRequest) (*Empty, error)
 	CreateSignedURL(context.Context, *CreateSignedURLRequest) (*CreateSignedURLResponse, error)
+	Capabilities(context.Context, *ObjectStoreCapabilitiesRequest) (*ObjectStoreCapabilitiesResponse, error)
+	CopyObject(context.Context, *CopyObjectRequest) (*Empty, error)
+	ListObjectVersions(context.Context, *ListObjectVersionsRequest) (*ListObjectVersionsResponse, error)
+	GetObjectVersion(*GetObjectVersionRequest, ObjectStore_GetObjectVersionServer) error
+	LockObject(context.Context, *LockObjectRequest) (*Empty, error)
+	// Added in plugin API version 3.
+	DeleteObjects(context.Context, *DeleteObjectsRequest) (*Empty, error)
 }
 
 func RegisterObjectStoreServer(s *grpc.Server, srv ObjectStoreServer) {
@@ -706,6 +1130,117 @@ func _ObjectStore_CreateSignedURL_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ObjectStore_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectStoreCapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObjectStoreServer).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.ObjectStore/Capabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObjectStoreServer).Capabilities(ctx, req.(*ObjectStoreCapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ObjectStore_CopyObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObjectStoreServer).CopyObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.ObjectStore/CopyObject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObjectStoreServer).CopyObject(ctx, req.(*CopyObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ObjectStore_ListObjectVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListObjectVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObjectStoreServer).ListObjectVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.ObjectStore/ListObjectVersions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObjectStoreServer).ListObjectVersions(ctx, req.(*ListObjectVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ObjectStore_GetObjectVersion_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetObjectVersionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ObjectStoreServer).GetObjectVersion(m, &objectStoreGetObjectVersionServer{stream})
+}
+
+type ObjectStore_GetObjectVersionServer interface {
+	Send(*Bytes) error
+	grpc.ServerStream
+}
+
+type objectStoreGetObjectVersionServer struct {
+	grpc.ServerStream
+}
+
+func (x *objectStoreGetObjectVersionServer) Send(m *Bytes) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ObjectStore_LockObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObjectStoreServer).LockObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.ObjectStore/LockObject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObjectStoreServer).LockObject(ctx, req.(*LockObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ObjectStore_DeleteObjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteObjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObjectStoreServer).DeleteObjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.ObjectStore/DeleteObjects",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObjectStoreServer).DeleteObjects(ctx, req.(*DeleteObjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ObjectStore_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "generated.ObjectStore",
 	HandlerType: (*ObjectStoreServer)(nil),
@@ -734,6 +1269,26 @@ var _ObjectStore_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateSignedURL",
 			Handler:    _ObjectStore_CreateSignedURL_Handler,
 		},
+		{
+			MethodName: "Capabilities",
+			Handler:    _ObjectStore_Capabilities_Handler,
+		},
+		{
+			MethodName: "CopyObject",
+			Handler:    _ObjectStore_CopyObject_Handler,
+		},
+		{
+			MethodName: "ListObjectVersions",
+			Handler:    _ObjectStore_ListObjectVersions_Handler,
+		},
+		{
+			MethodName: "LockObject",
+			Handler:    _ObjectStore_LockObject_Handler,
+		},
+		{
+			MethodName: "DeleteObjects",
+			Handler:    _ObjectStore_DeleteObjects_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -746,6 +1301,11 @@ var _ObjectStore_serviceDesc = grpc.ServiceDesc{
 			Handler:       _ObjectStore_GetObject_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetObjectVersion",
+			Handler:       _ObjectStore_GetObjectVersion_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "ObjectStore.proto",
 }
@@ -753,42 +1313,66 @@ var _ObjectStore_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("ObjectStore.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 577 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0xcd, 0x6e, 0xd3, 0x40,
-	0x10, 0xd6, 0xc6, 0x69, 0x54, 0x4f, 0x22, 0x61, 0xb6, 0x55, 0x30, 0x2e, 0x94, 0xb0, 0x02, 0x29,
-	0x08, 0x11, 0xa1, 0x72, 0x29, 0xd0, 0x03, 0x22, 0x44, 0x08, 0x29, 0x52, 0x2b, 0x07, 0x04, 0x07,
-	0x2e, 0x4e, 0x3c, 0x4d, 0x4d, 0x1c, 0x3b, 0xd8, 0x6b, 0x54, 0x1f, 0x79, 0x25, 0x9e, 0x89, 0x07,
-	0x41, 0xbb, 0xde, 0x26, 0xde, 0xfc, 0x10, 0xa9, 0xca, 0x6d, 0x66, 0x76, 0xe7, 0x9b, 0x6f, 0x66,
-	0xfd, 0x8d, 0xe1, 0xee, 0xf9, 0xf0, 0x07, 0x8e, 0xf8, 0x80, 0xc7, 0x09, 0x76, 0x66, 0x49, 0xcc,
-	0x63, 0x6a, 0x8e, 0x31, 0xc2, 0xc4, 0xe3, 0xe8, 0x3b, 0x8d, 0xc1, 0x95, 0x97, 0xa0, 0x5f, 0x1c,
-	0xb0, 0x2b, 0xb0, 0x2e, 0x32, 0x5e, 0x24, 0xb8, 0xf8, 0x33, 0xc3, 0x94, 0xd3, 0x26, 0xd4, 0x66,
-	0x61, 0x36, 0x0e, 0x22, 0x9b, 0xb4, 0x48, 0xdb, 0x74, 0x95, 0x27, 0xe2, 0xc3, 0x6c, 0x34, 0x41,
-	0x6e, 0x57, 0x8a, 0x78, 0xe1, 0x51, 0x0b, 0x8c, 0x09, 0xe6, 0xb6, 0x21, 0x83, 0xc2, 0xa4, 0x14,
-	0xaa, 0xc3, 0xd8, 0xcf, 0xed, 0x6a, 0x8b, 0xb4, 0x1b, 0xae, 0xb4, 0xd9, 0x57, 0x38, 0x28, 0xca,
-	0xf4, 0xae, 0x83, 0x94, 0xa7, 0x3b, 0x2b, 0xc6, 0x3a, 0x70, 0xa8, 0x03, 0xa7, 0xb3, 0x38, 0x4a,
-	0x51, 0x20, 0xa0, 0x8c, 0x48, 0xe4, 0x7d, 0x57, 0x79, 0xec, 0x33, 0x58, 0x1f, 0x71, 0xd7, 0x2d,
-	0xb3, 0x23, 0xd8, 0x7b, 0x9f, 0x73, 0x4c, 0x45, 0xef, 0xbe, 0xc7, 0x3d, 0x09, 0xd4, 0x70, 0xa5,
-	0xcd, 0x7e, 0x13, 0xb8, 0xdf, 0x0f, 0x52, 0xde, 0x8d, 0xa7, 0xd3, 0x38, 0xba, 0x48, 0xf0, 0x32,
-	0xb8, 0xc6, 0x5b, 0x8f, 0xe0, 0x01, 0x98, 0x3e, 0x86, 0xc1, 0x34, 0xe0, 0x98, 0x28, 0x0a, 0x8b,
-	0x80, 0x44, 0x93, 0x05, 0xe4, 0xf4, 0x05, 0x9a, 0xf4, 0xd8, 0x29, 0x38, 0xeb, 0x28, 0xa8, 0x61,
-	0x39, 0xb0, 0x3f, 0x53, 0x31, 0x9b, 0xb4, 0x8c, 0xb6, 0xe9, 0xce, 0x7d, 0xf6, 0x1d, 0xa8, 0xc8,
-	0x2c, 0x26, 0x76, 0x6b, 0xd6, 0x0b, 0x5e, 0x86, 0xc6, 0xeb, 0x19, 0x1c, 0x68, 0xe8, 0x8a, 0x10,
-	0x85, 0xea, 0x04, 0xf3, 0x1b, 0x32, 0xd2, 0x16, 0x9f, 0xd0, 0x07, 0x0c, 0x91, 0xe3, 0xae, 0x1f,
-	0x2f, 0x84, 0x66, 0x37, 0x41, 0x8f, 0xe3, 0x20, 0x18, 0x47, 0xe8, 0x7f, 0x71, 0xfb, 0xbb, 0xd3,
-	0x82, 0x05, 0x06, 0xe7, 0xa1, 0x7c, 0x0c, 0xc3, 0x15, 0x26, 0x7b, 0x0e, 0xf7, 0x56, 0xaa, 0xa9,
-	0xae, 0x2d, 0x30, 0xb2, 0x24, 0x54, 0xb5, 0x84, 0xc9, 0xfe, 0x10, 0x68, 0x96, 0xf4, 0xfc, 0x29,
-	0x0a, 0xb6, 0xf6, 0xdd, 0x83, 0xda, 0x28, 0x8e, 0x2e, 0x83, 0xb1, 0x5d, 0x69, 0x19, 0xed, 0xfa,
-	0xc9, 0x8b, 0xce, 0x5c, 0xfd, 0x9d, 0xf5, 0x50, 0x9d, 0xae, 0xbc, 0xdf, 0x8b, 0x78, 0x92, 0xbb,
-	0x2a, 0xd9, 0x79, 0x0d, 0xf5, 0x52, 0xf8, 0xa6, 0x33, 0xb2, 0xe8, 0xec, 0x10, 0xf6, 0x7e, 0x79,
-	0x61, 0x86, 0x6a, 0x04, 0x85, 0xf3, 0xa6, 0x72, 0x4a, 0x4e, 0xfe, 0x56, 0xa1, 0x5e, 0xaa, 0x44,
-	0xdf, 0x42, 0x55, 0x54, 0xa3, 0x8f, 0xb7, 0x32, 0x71, 0xac, 0xd2, 0x95, 0xde, 0x74, 0xc6, 0x73,
-	0x7a, 0x06, 0xe6, 0x7c, 0x45, 0xd1, 0xa3, 0xd2, 0xf1, 0xf2, 0xe2, 0x5a, 0xcd, 0x6d, 0x13, 0x7a,
-	0x0e, 0x8d, 0xf2, 0x76, 0xa0, 0xc7, 0x2b, 0x14, 0xb4, 0x7d, 0xe4, 0x3c, 0xda, 0x78, 0xae, 0x9e,
-	0xe8, 0x0c, 0xcc, 0xf9, 0xfa, 0xd0, 0xe8, 0x2c, 0x2f, 0x15, 0x8d, 0x8e, 0xdc, 0x0d, 0x2f, 0x09,
-	0xf5, 0x0a, 0x2d, 0xe9, 0x2a, 0xa4, 0x4f, 0x4a, 0x37, 0x37, 0xee, 0x09, 0xe7, 0xe9, 0x96, 0x5b,
-	0x8a, 0x60, 0x1f, 0xea, 0x25, 0x41, 0xd1, 0x87, 0x4b, 0x59, 0xba, 0x8c, 0x9d, 0xe3, 0x4d, 0xc7,
-	0x0a, 0xed, 0x1d, 0x34, 0xca, 0x9a, 0xd3, 0xe6, 0xb7, 0x46, 0x8c, 0x6b, 0xde, 0xef, 0x1b, 0xdc,
-	0x59, 0xfa, 0xdc, 0xb5, 0xef, 0x60, 0xbd, 0xf0, 0x1c, 0xf6, 0xbf, 0x2b, 0x05, 0xb7, 0x61, 0x4d,
-	0xfe, 0xc3, 0x5e, 0xfd, 0x0b, 0x00, 0x00, 0xff, 0xff, 0x0b, 0x4e, 0x76, 0xa3, 0xf1, 0x06, 0x00,
-	0x00,
+	// 966 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0x5f, 0x73, 0xdb, 0x44,
+	0x10, 0x1f, 0x45, 0x4e, 0x6a, 0xaf, 0x5d, 0xea, 0x5c, 0x33, 0xa9, 0x50, 0x4b, 0x62, 0x6e, 0x28,
+	0xe3, 0xc2, 0xe0, 0x61, 0x02, 0x0f, 0x01, 0xf2, 0xc0, 0xd4, 0xcd, 0x64, 0x0a, 0xee, 0xb4, 0xa3,
+	0x50, 0x60, 0x18, 0x78, 0x90, 0xad, 0x8d, 0x73, 0x58, 0x96, 0x84, 0x74, 0xca, 0xd4, 0x8f, 0x7c,
+	0x02, 0x9e, 0xf8, 0x22, 0x3c, 0xf2, 0xe9, 0x98, 0xfb, 0x23, 0xeb, 0x8f, 0xe5, 0x9a, 0x49, 0xfd,
+	0x76, 0xbb, 0xb7, 0xb7, 0xf7, 0xdb, 0x3f, 0xfa, 0xed, 0x09, 0xf6, 0x5f, 0x8e, 0x7f, 0xc7, 0x09,
+	0xbf, 0xe4, 0x61, 0x8c, 0x83, 0x28, 0x0e, 0x79, 0x48, 0x5a, 0x53, 0x0c, 0x30, 0x76, 0x39, 0x7a,
+	0x76, 0xe7, 0xf2, 0xda, 0x8d, 0xd1, 0x53, 0x1b, 0xf4, 0x1a, 0xba, 0xaf, 0x52, 0xae, 0x0e, 0x38,
+	0xf8, 0x47, 0x8a, 0x09, 0x27, 0x87, 0xb0, 0x17, 0xf9, 0xe9, 0x94, 0x05, 0x96, 0xd1, 0x33, 0xfa,
+	0x2d, 0x47, 0x4b, 0x42, 0x3f, 0x4e, 0x27, 0x33, 0xe4, 0xd6, 0x8e, 0xd2, 0x2b, 0x89, 0x74, 0xc1,
+	0x9c, 0xe1, 0xc2, 0x32, 0xa5, 0x52, 0x2c, 0x09, 0x81, 0xc6, 0x38, 0xf4, 0x16, 0x56, 0xa3, 0x67,
+	0xf4, 0x3b, 0x8e, 0x5c, 0xd3, 0x9f, 0xe0, 0xbe, 0xba, 0xe6, 0xfc, 0x0d, 0x4b, 0x78, 0xb2, 0xb5,
+	0xcb, 0xe8, 0x00, 0x0e, 0xca, 0x8e, 0x93, 0x28, 0x0c, 0x12, 0x14, 0x1e, 0x50, 0x6a, 0xa4, 0xe7,
+	0xa6, 0xa3, 0x25, 0xfa, 0x03, 0x74, 0x2f, 0x70, 0xdb, 0x21, 0xd3, 0x87, 0xb0, 0xfb, 0x74, 0xc1,
+	0x31, 0x11, 0xb1, 0x7b, 0x2e, 0x77, 0xa5, 0xa3, 0x8e, 0x23, 0xd7, 0xf4, 0x4f, 0x03, 0xde, 0x1f,
+	0xb1, 0x84, 0x0f, 0xc3, 0xf9, 0x3c, 0x0c, 0x5e, 0xc5, 0x78, 0xc5, 0xde, 0xe0, 0xad, 0x53, 0xf0,
+	0x08, 0x5a, 0x1e, 0xfa, 0x6c, 0xce, 0x38, 0xc6, 0x1a, 0x42, 0xae, 0x90, 0xde, 0xe4, 0x05, 0x56,
+	0x43, 0x7b, 0x93, 0x12, 0x3d, 0x05, 0xbb, 0x0e, 0x82, 0x4e, 0x96, 0x0d, 0xcd, 0x48, 0xeb, 0x2c,
+	0xa3, 0x67, 0xf6, 0x5b, 0xce, 0x52, 0xa6, 0xbf, 0x02, 0x11, 0x27, 0x55, 0xc6, 0x6e, 0x8d, 0x3a,
+	0xc7, 0x65, 0x96, 0x70, 0x3d, 0x81, 0xfb, 0x25, 0xef, 0x1a, 0x10, 0x81, 0xc6, 0x0c, 0x17, 0x19,
+	0x18, 0xb9, 0x16, 0x2d, 0xf4, 0x0c, 0x7d, 0xe4, 0xb8, 0xed, 0xe2, 0xf9, 0x70, 0x38, 0x8c, 0xd1,
+	0xe5, 0x78, 0xc9, 0xa6, 0x01, 0x7a, 0xaf, 0x9d, 0xd1, 0xf6, 0xbe, 0x85, 0x2e, 0x98, 0x9c, 0xfb,
+	0xb2, 0x18, 0xa6, 0x23, 0x96, 0xf4, 0x53, 0x78, 0xb0, 0x72, 0x9b, 0x8e, 0xba, 0x0b, 0x66, 0x1a,
+	0xfb, 0xfa, 0x2e, 0xb1, 0xa4, 0xff, 0x18, 0x70, 0x58, 0xf8, 0x9e, 0x9f, 0x07, 0x6c, 0x63, 0xdc,
+	0xe7, 0xb0, 0x37, 0x09, 0x83, 0x2b, 0x36, 0xb5, 0x76, 0x7a, 0x66, 0xbf, 0x7d, 0xf2, 0xd9, 0x60,
+	0xf9, 0xf5, 0x0f, 0xea, 0x5d, 0x0d, 0x86, 0xd2, 0xfe, 0x3c, 0xe0, 0xf1, 0xc2, 0xd1, 0x87, 0xed,
+	0xaf, 0xa0, 0x5d, 0x50, 0x67, 0x91, 0x19, 0x79, 0x64, 0x07, 0xb0, 0x7b, 0xe3, 0xfa, 0x29, 0xea,
+	0x14, 0x28, 0xe1, 0xeb, 0x9d, 0x53, 0x83, 0x9e, 0xc2, 0x51, 0xe1, 0xa2, 0xa1, 0x1b, 0xb9, 0x63,
+	0xe6, 0x33, 0xce, 0x36, 0xf6, 0x3c, 0xfd, 0x6b, 0x07, 0x8e, 0xd7, 0x1e, 0xd5, 0x49, 0x3a, 0x02,
+	0x48, 0xb2, 0xcc, 0x65, 0x1f, 0x77, 0x41, 0x43, 0xfa, 0x70, 0x6f, 0x9e, 0xfa, 0x9c, 0x45, 0x6e,
+	0xcc, 0x5f, 0x47, 0x7e, 0xe8, 0x7a, 0x12, 0x61, 0xd3, 0xa9, 0xaa, 0x89, 0x05, 0x77, 0xb8, 0x3b,
+	0x9d, 0xb2, 0x60, 0x2a, 0x2b, 0xd6, 0x74, 0x32, 0x91, 0xf4, 0xa0, 0x3d, 0x76, 0xf9, 0xe4, 0x5a,
+	0xf5, 0x9b, 0xac, 0x5e, 0xd3, 0x29, 0xaa, 0xc8, 0xc7, 0xf0, 0x5e, 0x82, 0xf1, 0x0d, 0xc6, 0x97,
+	0xcc, 0xc3, 0x61, 0x18, 0x2d, 0xac, 0x5d, 0x69, 0x54, 0xd1, 0x0a, 0xb4, 0x37, 0x18, 0x27, 0x2c,
+	0x0c, 0xc4, 0x35, 0x7b, 0x0a, 0x6d, 0xae, 0x11, 0xfb, 0xa1, 0x0c, 0x78, 0x14, 0x4e, 0x66, 0xd6,
+	0x1d, 0xb5, 0x9f, 0x6b, 0x68, 0x0a, 0xfb, 0xc2, 0xcf, 0xbb, 0xb5, 0xfc, 0x21, 0xec, 0x25, 0xf1,
+	0xe4, 0xfb, 0x65, 0x67, 0x6a, 0x49, 0x24, 0xc0, 0xc3, 0x84, 0x8b, 0x0d, 0xc5, 0x16, 0x99, 0x48,
+	0x7f, 0x53, 0x8c, 0xa5, 0xae, 0xfd, 0x51, 0xc1, 0xdd, 0x22, 0x69, 0xff, 0x6d, 0xc0, 0xdd, 0x92,
+	0x6f, 0xc1, 0x6a, 0x3a, 0x2b, 0xcf, 0x9f, 0x69, 0xb7, 0xb9, 0x82, 0x50, 0xe8, 0xf8, 0x6e, 0xc2,
+	0x5f, 0x84, 0x1e, 0xbb, 0x62, 0xa8, 0x0a, 0x6a, 0x3a, 0x25, 0x9d, 0xe0, 0x30, 0x96, 0x8c, 0x5c,
+	0x8e, 0x09, 0xd7, 0xe5, 0x5c, 0xca, 0xa2, 0x5a, 0x2c, 0x51, 0x95, 0x7b, 0xe1, 0xc6, 0x33, 0x8c,
+	0x75, 0x49, 0x2b, 0x5a, 0xea, 0x28, 0x96, 0xac, 0x86, 0xad, 0x3b, 0xef, 0x4b, 0x68, 0x6a, 0x48,
+	0x8a, 0x98, 0xda, 0x27, 0xd6, 0xca, 0xb7, 0xa5, 0x0f, 0x39, 0x4b, 0x4b, 0xba, 0x80, 0x07, 0xcb,
+	0x81, 0x93, 0xed, 0x6e, 0x8d, 0x5e, 0x4a, 0x69, 0x6b, 0x54, 0xd2, 0x46, 0x67, 0xb0, 0x2f, 0x9a,
+	0x68, 0xdb, 0xf3, 0xfd, 0x00, 0x76, 0xd3, 0x80, 0xb3, 0x8c, 0xd5, 0x94, 0x40, 0x7f, 0x81, 0x83,
+	0x22, 0x3d, 0xdf, 0xba, 0x5b, 0x32, 0xea, 0x37, 0x73, 0xea, 0x3f, 0xf9, 0xb7, 0x09, 0xed, 0x02,
+	0x2f, 0x90, 0x6f, 0xa0, 0x21, 0xf8, 0x8b, 0x7c, 0xb8, 0x91, 0xdb, 0xec, 0x6e, 0xc1, 0xe4, 0x7c,
+	0x1e, 0xf1, 0x05, 0x39, 0x83, 0xd6, 0xf2, 0xd1, 0x43, 0x1e, 0x16, 0xb6, 0xab, 0x4f, 0xa1, 0xd5,
+	0xb3, 0x7d, 0x83, 0xbc, 0x84, 0x4e, 0xf1, 0xbd, 0x41, 0x8e, 0x56, 0x20, 0x94, 0x5e, 0x38, 0xf6,
+	0xf1, 0xda, 0x7d, 0xdd, 0x55, 0x67, 0xd0, 0xba, 0xc0, 0x3a, 0x38, 0x17, 0xf8, 0x16, 0x38, 0xf2,
+	0xb5, 0xf1, 0xb9, 0x41, 0x5c, 0x20, 0xab, 0x73, 0x9d, 0x7c, 0x54, 0xb0, 0x5c, 0xfb, 0xf2, 0xb0,
+	0x1f, 0x6f, 0xb0, 0xd2, 0x00, 0x47, 0xd0, 0x2e, 0x8c, 0x68, 0xf2, 0x41, 0xe5, 0x54, 0xb9, 0xdc,
+	0xf6, 0xd1, 0xba, 0x6d, 0xed, 0xed, 0x5b, 0xe8, 0x14, 0xdb, 0xa4, 0x94, 0xbf, 0x9a, 0xf1, 0x5e,
+	0x53, 0xbf, 0x9f, 0xe1, 0x5e, 0x65, 0x80, 0x96, 0xfa, 0xa0, 0x7e, 0x94, 0xdb, 0xf4, 0x6d, 0x26,
+	0x1a, 0x1b, 0x42, 0xa7, 0x38, 0x72, 0xc8, 0x93, 0xfa, 0xf6, 0xaa, 0x99, 0x68, 0xf6, 0x27, 0xff,
+	0xc7, 0x74, 0x59, 0x71, 0xc8, 0x39, 0x9d, 0x3c, 0x2a, 0x02, 0xab, 0x52, 0x7d, 0x4d, 0xf8, 0x6e,
+	0xf1, 0x3d, 0x96, 0x71, 0xd4, 0x4a, 0xc5, 0x6b, 0x99, 0xdb, 0x7e, 0xbc, 0xc1, 0x4a, 0x03, 0xfc,
+	0xae, 0xf0, 0x46, 0xd6, 0x9b, 0x84, 0xd6, 0x75, 0x66, 0x99, 0xcf, 0x6a, 0x1b, 0xf4, 0x0c, 0x20,
+	0xe7, 0xa0, 0x52, 0xb0, 0x2b, 0xd4, 0x54, 0x13, 0xec, 0x53, 0xb8, 0x5b, 0x22, 0x15, 0x72, 0xbc,
+	0xa6, 0x5d, 0x92, 0xb5, 0x3e, 0xc6, 0x7b, 0xf2, 0x5f, 0xe7, 0x8b, 0xff, 0x06, 0x00, 0x7d, 0x8a,
+	0x50, 0x5a, 0x19, 0x0d, 0x00, 0x00,
 }
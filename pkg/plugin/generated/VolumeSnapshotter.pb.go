@@ -329,6 +329,46 @@ func (m *VolumeSnapshotterInitRequest) GetConfig() map[string]string {
 	return nil
 }
 
+type ListSnapshotsRequest struct {
+	Plugin string `protobuf:"bytes,1,opt,name=plugin" json:"plugin,omitempty"`
+	TagKey string `protobuf:"bytes,2,opt,name=tagKey" json:"tagKey,omitempty"`
+}
+
+func (m *ListSnapshotsRequest) Reset()                    { *m = ListSnapshotsRequest{} }
+func (m *ListSnapshotsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListSnapshotsRequest) ProtoMessage()               {}
+func (*ListSnapshotsRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{12} }
+
+func (m *ListSnapshotsRequest) GetPlugin() string {
+	if m != nil {
+		return m.Plugin
+	}
+	return ""
+}
+
+func (m *ListSnapshotsRequest) GetTagKey() string {
+	if m != nil {
+		return m.TagKey
+	}
+	return ""
+}
+
+type ListSnapshotsResponse struct {
+	Snapshots map[string]string `protobuf:"bytes,1,rep,name=snapshots" json:"snapshots,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *ListSnapshotsResponse) Reset()                    { *m = ListSnapshotsResponse{} }
+func (m *ListSnapshotsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListSnapshotsResponse) ProtoMessage()               {}
+func (*ListSnapshotsResponse) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{13} }
+
+func (m *ListSnapshotsResponse) GetSnapshots() map[string]string {
+	if m != nil {
+		return m.Snapshots
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*CreateVolumeRequest)(nil), "generated.CreateVolumeRequest")
 	proto.RegisterType((*CreateVolumeResponse)(nil), "generated.CreateVolumeResponse")
@@ -342,6 +382,8 @@ func init() {
 	proto.RegisterType((*SetVolumeIDRequest)(nil), "generated.SetVolumeIDRequest")
 	proto.RegisterType((*SetVolumeIDResponse)(nil), "generated.SetVolumeIDResponse")
 	proto.RegisterType((*VolumeSnapshotterInitRequest)(nil), "generated.VolumeSnapshotterInitRequest")
+	proto.RegisterType((*ListSnapshotsRequest)(nil), "generated.ListSnapshotsRequest")
+	proto.RegisterType((*ListSnapshotsResponse)(nil), "generated.ListSnapshotsResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -362,6 +404,7 @@ type VolumeSnapshotterClient interface {
 	DeleteSnapshot(ctx context.Context, in *DeleteSnapshotRequest, opts ...grpc.CallOption) (*Empty, error)
 	GetVolumeID(ctx context.Context, in *GetVolumeIDRequest, opts ...grpc.CallOption) (*GetVolumeIDResponse, error)
 	SetVolumeID(ctx context.Context, in *SetVolumeIDRequest, opts ...grpc.CallOption) (*SetVolumeIDResponse, error)
+	ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error)
 }
 
 type volumeSnapshotterClient struct {
@@ -435,6 +478,15 @@ func (c *volumeSnapshotterClient) SetVolumeID(ctx context.Context, in *SetVolume
 	return out, nil
 }
 
+func (c *volumeSnapshotterClient) ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error) {
+	out := new(ListSnapshotsResponse)
+	err := grpc.Invoke(ctx, "/generated.VolumeSnapshotter/ListSnapshots", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for VolumeSnapshotter service
 
 type VolumeSnapshotterServer interface {
@@ -445,6 +497,7 @@ type VolumeSnapshotterServer interface {
 	DeleteSnapshot(context.Context, *DeleteSnapshotRequest) (*Empty, error)
 	GetVolumeID(context.Context, *GetVolumeIDRequest) (*GetVolumeIDResponse, error)
 	SetVolumeID(context.Context, *SetVolumeIDRequest) (*SetVolumeIDResponse, error)
+	ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error)
 }
 
 func RegisterVolumeSnapshotterServer(s *grpc.Server, srv VolumeSnapshotterServer) {
@@ -577,6 +630,24 @@ func _VolumeSnapshotter_SetVolumeID_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VolumeSnapshotter_ListSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VolumeSnapshotterServer).ListSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.VolumeSnapshotter/ListSnapshots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VolumeSnapshotterServer).ListSnapshots(ctx, req.(*ListSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _VolumeSnapshotter_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "generated.VolumeSnapshotter",
 	HandlerType: (*VolumeSnapshotterServer)(nil),
@@ -609,6 +680,10 @@ var _VolumeSnapshotter_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetVolumeID",
 			Handler:    _VolumeSnapshotter_SetVolumeID_Handler,
 		},
+		{
+			MethodName: "ListSnapshots",
+			Handler:    _VolumeSnapshotter_ListSnapshots_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "VolumeSnapshotter.proto",
@@ -617,41 +692,45 @@ var _VolumeSnapshotter_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("VolumeSnapshotter.proto", fileDescriptor5) }
 
 var fileDescriptor5 = []byte{
-	// 566 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xc1, 0x6e, 0xd3, 0x40,
-	0x10, 0xd5, 0xda, 0x6e, 0x44, 0x26, 0xa5, 0x0a, 0x9b, 0xa4, 0x58, 0x16, 0x04, 0xe3, 0x0b, 0x51,
-	0x0f, 0x96, 0x48, 0x0f, 0x14, 0x0e, 0x48, 0x51, 0x5d, 0x50, 0xd4, 0x4a, 0x48, 0x76, 0x41, 0x08,
-	0x4e, 0x86, 0x6e, 0x5c, 0x8b, 0xc4, 0x36, 0xde, 0x4d, 0xa5, 0x7c, 0x0c, 0xbf, 0x82, 0xf8, 0x14,
-	0x3e, 0x05, 0xc5, 0xde, 0x24, 0xbb, 0xb1, 0x1d, 0x87, 0x43, 0x6f, 0xde, 0x99, 0x9d, 0x37, 0x6f,
-	0x66, 0xdf, 0x8c, 0xe1, 0xf1, 0xa7, 0x78, 0x3a, 0x9f, 0x11, 0x2f, 0xf2, 0x13, 0x7a, 0x1b, 0x33,
-	0x46, 0x52, 0x3b, 0x49, 0x63, 0x16, 0xe3, 0x66, 0x40, 0x22, 0x92, 0xfa, 0x8c, 0xdc, 0x18, 0x87,
-	0xde, 0xad, 0x9f, 0x92, 0x9b, 0xdc, 0x61, 0xfd, 0x42, 0xd0, 0x39, 0x4f, 0x89, 0xcf, 0x48, 0x1e,
-	0xea, 0x92, 0x9f, 0x73, 0x42, 0x19, 0x3e, 0x86, 0x46, 0x32, 0x9d, 0x07, 0x61, 0xa4, 0x23, 0x13,
-	0x0d, 0x9a, 0x2e, 0x3f, 0xe1, 0x3e, 0x00, 0xe5, 0xe8, 0x63, 0x47, 0x57, 0x32, 0x9f, 0x60, 0x59,
-	0xfa, 0xef, 0x32, 0xa0, 0xeb, 0x45, 0x42, 0x74, 0x35, 0xf7, 0x6f, 0x2c, 0xd8, 0x80, 0x07, 0xf9,
-	0x69, 0xf4, 0x45, 0xd7, 0x32, 0xef, 0xfa, 0x8c, 0x31, 0x68, 0x61, 0x9c, 0x50, 0xfd, 0xc0, 0x44,
-	0x03, 0xd5, 0xcd, 0xbe, 0xad, 0x21, 0x74, 0x65, 0x7a, 0x34, 0x89, 0x23, 0x2a, 0xe0, 0x8c, 0x1d,
-	0xce, 0x70, 0x7d, 0xb6, 0x26, 0xd0, 0x7d, 0x4f, 0x58, 0x1e, 0x30, 0x8e, 0x26, 0x71, 0x5d, 0x4d,
-	0x22, 0x96, 0x22, 0x63, 0x49, 0x7c, 0x55, 0x99, 0xaf, 0x75, 0x09, 0xbd, 0xad, 0x3c, 0x9c, 0x9c,
-	0xdc, 0x04, 0x54, 0x68, 0xc2, 0xaa, 0x50, 0x45, 0x28, 0xf4, 0x2f, 0x82, 0x5e, 0x5e, 0xe9, 0xea,
-	0xf5, 0xee, 0x89, 0x36, 0x7e, 0x0b, 0x1a, 0xf3, 0x03, 0xaa, 0x6b, 0xa6, 0x3a, 0x68, 0x0d, 0x4f,
-	0xec, 0xb5, 0x34, 0xec, 0xd2, 0xfc, 0xf6, 0xb5, 0x1f, 0xd0, 0x8b, 0x88, 0xa5, 0x0b, 0x37, 0x8b,
-	0x33, 0x5e, 0x41, 0x73, 0x6d, 0xc2, 0x6d, 0x50, 0x7f, 0x90, 0x05, 0x67, 0xb6, 0xfc, 0xc4, 0x5d,
-	0x38, 0xb8, 0xf3, 0xa7, 0x73, 0xc2, 0x39, 0xe5, 0x87, 0x37, 0xca, 0x19, 0xb2, 0xce, 0xe0, 0x78,
-	0x3b, 0xc3, 0xa6, 0x61, 0x82, 0xaa, 0xd0, 0xb6, 0xaa, 0xac, 0x0f, 0xd0, 0x73, 0xc8, 0x94, 0xec,
-	0xdf, 0x9b, 0x1a, 0x99, 0x5a, 0x9f, 0x01, 0x6f, 0x9e, 0xce, 0xa9, 0x43, 0x3b, 0x81, 0x76, 0x42,
-	0x52, 0x1a, 0x52, 0x46, 0x22, 0x1e, 0x94, 0x61, 0x1e, 0xba, 0x05, 0xbb, 0xf5, 0x12, 0x3a, 0x12,
-	0xf2, 0x1e, 0x7a, 0x65, 0x80, 0xbd, 0x7b, 0x21, 0x23, 0x65, 0x55, 0xb7, 0xb2, 0x8e, 0xa0, 0xe3,
-	0x95, 0x10, 0x2d, 0x83, 0x47, 0x15, 0xb5, 0xfe, 0x46, 0xf0, 0xa4, 0xb0, 0x71, 0xc6, 0x51, 0x58,
-	0xfb, 0x3c, 0x97, 0xd0, 0xf8, 0x1e, 0x47, 0x93, 0x30, 0xd0, 0x95, 0x4c, 0x84, 0xa7, 0x82, 0x08,
-	0x77, 0x01, 0xda, 0xe7, 0x59, 0x54, 0xae, 0x46, 0x0e, 0x61, 0xbc, 0x86, 0x96, 0x60, 0xfe, 0x1f,
-	0x45, 0x0e, 0xff, 0x68, 0xf0, 0xa8, 0x90, 0x0f, 0x8f, 0x40, 0x5b, 0xe6, 0xc4, 0x2f, 0xf6, 0x64,
-	0x65, 0xb4, 0x85, 0x8b, 0x17, 0xb3, 0x84, 0x2d, 0xf0, 0x57, 0xd0, 0xc5, 0xb5, 0xf5, 0x2e, 0x8d,
-	0x67, 0xab, 0x58, 0xdc, 0x2f, 0x4c, 0x9c, 0xb4, 0x7a, 0x8d, 0x67, 0x95, 0x7e, 0xfe, 0x44, 0x2e,
-	0x3c, 0x94, 0xf6, 0x0e, 0x16, 0x23, 0xca, 0x36, 0x9f, 0x61, 0x56, 0x5f, 0xe0, 0x98, 0x1f, 0xe1,
-	0x48, 0x9e, 0x4d, 0x6c, 0xd6, 0x2d, 0x06, 0xe3, 0xf9, 0x8e, 0x1b, 0x1c, 0xd6, 0x81, 0x23, 0x79,
-	0x70, 0x25, 0xd8, 0xd2, 0x99, 0x2e, 0xe9, 0xe6, 0x15, 0xb4, 0x84, 0x99, 0xc2, 0x4f, 0x4b, 0xab,
-	0x59, 0x0d, 0x8e, 0xd1, 0xaf, 0x72, 0x73, 0x4e, 0x57, 0xd0, 0xf2, 0x2a, 0xd0, 0xbc, 0xdd, 0x68,
-	0x25, 0xf3, 0xf2, 0xad, 0x91, 0xfd, 0x47, 0x4f, 0xff, 0x05, 0x00, 0x00, 0xff, 0xff, 0xc3, 0xf2,
-	0xb8, 0x2f, 0x7b, 0x07, 0x00, 0x00,
+	// 640 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0xcf, 0x6e, 0xd3, 0x4e,
+	0x10, 0xd6, 0xc6, 0x69, 0xf4, 0xcb, 0xa4, 0xad, 0xf2, 0xdb, 0xfc, 0xc1, 0xb2, 0x20, 0x18, 0x5f,
+	0x88, 0x7a, 0x30, 0x22, 0x3d, 0x50, 0x10, 0x42, 0x8a, 0x9a, 0x16, 0x45, 0x09, 0x42, 0xb2, 0x0b,
+	0x42, 0x70, 0x32, 0x74, 0xe3, 0x5a, 0x24, 0xb6, 0xf1, 0x6e, 0x2a, 0xe5, 0x3d, 0xb8, 0x72, 0xe3,
+	0x39, 0x78, 0x16, 0x1e, 0x05, 0xc5, 0x5e, 0x27, 0xbb, 0x89, 0x1d, 0xa7, 0x87, 0xde, 0xbc, 0x33,
+	0x3b, 0xdf, 0x7c, 0x33, 0xfb, 0xf5, 0x6b, 0xe0, 0xc1, 0xc7, 0x60, 0x3a, 0x9f, 0x11, 0xdb, 0x77,
+	0x42, 0x7a, 0x13, 0x30, 0x46, 0x22, 0x33, 0x8c, 0x02, 0x16, 0xe0, 0xaa, 0x4b, 0x7c, 0x12, 0x39,
+	0x8c, 0x5c, 0x6b, 0x87, 0xf6, 0x8d, 0x13, 0x91, 0xeb, 0x24, 0x61, 0xfc, 0x42, 0xd0, 0x38, 0x8f,
+	0x88, 0xc3, 0x48, 0x52, 0x6a, 0x91, 0x1f, 0x73, 0x42, 0x19, 0x6e, 0x43, 0x25, 0x9c, 0xce, 0x5d,
+	0xcf, 0x57, 0x91, 0x8e, 0xba, 0x55, 0x8b, 0x9f, 0x70, 0x07, 0x80, 0x72, 0xf4, 0xe1, 0x40, 0x2d,
+	0xc5, 0x39, 0x21, 0xb2, 0xcc, 0xdf, 0xc6, 0x40, 0x57, 0x8b, 0x90, 0xa8, 0x4a, 0x92, 0x5f, 0x47,
+	0xb0, 0x06, 0xff, 0x25, 0xa7, 0xfe, 0x67, 0xb5, 0x1c, 0x67, 0x57, 0x67, 0x8c, 0xa1, 0xec, 0x05,
+	0x21, 0x55, 0x0f, 0x74, 0xd4, 0x55, 0xac, 0xf8, 0xdb, 0xe8, 0x41, 0x53, 0xa6, 0x47, 0xc3, 0xc0,
+	0xa7, 0x02, 0xce, 0x70, 0xc0, 0x19, 0xae, 0xce, 0xc6, 0x04, 0x9a, 0x6f, 0x09, 0x4b, 0x0a, 0x86,
+	0xfe, 0x24, 0x28, 0x9a, 0x49, 0xc4, 0x2a, 0xc9, 0x58, 0x12, 0x5f, 0x45, 0xe6, 0x6b, 0x8c, 0xa0,
+	0xb5, 0xd1, 0x87, 0x93, 0x93, 0x97, 0x80, 0xb6, 0x96, 0x90, 0x0e, 0x5a, 0x12, 0x06, 0xfd, 0x8b,
+	0xa0, 0x95, 0x4c, 0x9a, 0xbe, 0xde, 0x3d, 0xd1, 0xc6, 0x6f, 0xa0, 0xcc, 0x1c, 0x97, 0xaa, 0x65,
+	0x5d, 0xe9, 0xd6, 0x7a, 0x27, 0xe6, 0x4a, 0x1a, 0x66, 0x66, 0x7f, 0xf3, 0xca, 0x71, 0xe9, 0x85,
+	0xcf, 0xa2, 0x85, 0x15, 0xd7, 0x69, 0x2f, 0xa0, 0xba, 0x0a, 0xe1, 0x3a, 0x28, 0xdf, 0xc9, 0x82,
+	0x33, 0x5b, 0x7e, 0xe2, 0x26, 0x1c, 0xdc, 0x3a, 0xd3, 0x39, 0xe1, 0x9c, 0x92, 0xc3, 0xab, 0xd2,
+	0x19, 0x32, 0xce, 0xa0, 0xbd, 0xd9, 0x61, 0xbd, 0x30, 0x41, 0x55, 0x68, 0x53, 0x55, 0xc6, 0x7b,
+	0x68, 0x0d, 0xc8, 0x94, 0xec, 0xbf, 0x9b, 0x02, 0x99, 0x1a, 0x9f, 0x00, 0xaf, 0x9f, 0x6e, 0x50,
+	0x84, 0x76, 0x02, 0xf5, 0x90, 0x44, 0xd4, 0xa3, 0x8c, 0xf8, 0xbc, 0x28, 0xc6, 0x3c, 0xb4, 0xb6,
+	0xe2, 0xc6, 0x73, 0x68, 0x48, 0xc8, 0x7b, 0xe8, 0x95, 0x01, 0xb6, 0xef, 0x85, 0x8c, 0xd4, 0x55,
+	0xd9, 0xe8, 0xda, 0x87, 0x86, 0x9d, 0x41, 0x34, 0x0b, 0x1e, 0xe5, 0xcc, 0xfa, 0x07, 0xc1, 0xc3,
+	0x2d, 0xc7, 0x19, 0xfa, 0x5e, 0xe1, 0xf3, 0x8c, 0xa0, 0xf2, 0x2d, 0xf0, 0x27, 0x9e, 0xab, 0x96,
+	0x62, 0x11, 0x9e, 0x0a, 0x22, 0xdc, 0x05, 0x68, 0x9e, 0xc7, 0x55, 0x89, 0x1a, 0x39, 0x84, 0xf6,
+	0x12, 0x6a, 0x42, 0xf8, 0x4e, 0x8a, 0xbc, 0x84, 0xe6, 0xd8, 0xa3, 0x2c, 0x6d, 0x46, 0x8b, 0x78,
+	0xb7, 0xa1, 0xc2, 0x1c, 0x77, 0x44, 0x16, 0x1c, 0x8a, 0x9f, 0x8c, 0xdf, 0x08, 0x5a, 0x1b, 0x40,
+	0x7c, 0x9d, 0xef, 0xa0, 0x9a, 0xca, 0x8e, 0xaa, 0x28, 0x1e, 0xf6, 0x99, 0x30, 0x6c, 0x66, 0x91,
+	0xb9, 0x8a, 0x24, 0x83, 0xae, 0x11, 0xb4, 0xd7, 0x70, 0x2c, 0x27, 0xef, 0x32, 0x6e, 0xef, 0xe7,
+	0x01, 0xfc, 0xbf, 0xb5, 0x5e, 0xdc, 0x87, 0xf2, 0x72, 0xc5, 0xf8, 0xe9, 0x9e, 0x8f, 0xa0, 0xd5,
+	0x85, 0x8b, 0x17, 0xb3, 0x90, 0x2d, 0xf0, 0x17, 0x50, 0x45, 0x97, 0xbe, 0x8c, 0x82, 0x59, 0x5a,
+	0x8b, 0x3b, 0x5b, 0x06, 0x23, 0xfd, 0xa7, 0xd1, 0x1e, 0xe7, 0xe6, 0xf9, 0x0a, 0x2d, 0x38, 0x92,
+	0x6c, 0x16, 0x8b, 0x15, 0x59, 0x46, 0xaf, 0xe9, 0xf9, 0x17, 0x38, 0xe6, 0x07, 0x38, 0x96, 0xad,
+	0x08, 0xeb, 0x45, 0x3e, 0xa8, 0x3d, 0xd9, 0x71, 0x83, 0xc3, 0x0e, 0xe0, 0x58, 0xf6, 0x29, 0x09,
+	0x36, 0xd3, 0xc2, 0x32, 0xb6, 0x39, 0x86, 0x9a, 0x60, 0x21, 0xf8, 0x51, 0xe6, 0x34, 0xa9, 0x4f,
+	0x68, 0x9d, 0xbc, 0x34, 0xe7, 0x34, 0x86, 0x9a, 0x9d, 0x83, 0x66, 0xef, 0x46, 0xcb, 0xb2, 0x07,
+	0x0b, 0x8e, 0x24, 0xcd, 0x4a, 0x8f, 0x91, 0xf5, 0xb7, 0xa4, 0xe9, 0xf9, 0x17, 0x12, 0xcc, 0xaf,
+	0x95, 0xf8, 0xa7, 0xc8, 0xe9, 0xbf, 0x01, 0x00, 0xe1, 0x23, 0x18, 0xcf, 0xbe, 0x08, 0x00, 0x00,
 }
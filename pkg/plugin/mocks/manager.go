@@ -169,3 +169,19 @@ func (_m *Manager) GetRestoreItemActions() ([]velero.RestoreItemAction, error) {
 
 	return r0, r1
 }
+
+// PluginCrashes provides a mock function with given fields:
+func (_m *Manager) PluginCrashes() []string {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
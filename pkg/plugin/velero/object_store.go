@@ -17,6 +17,7 @@ limitations under the License.
 package velero
 
 import (
+	"errors"
 	"io"
 	"time"
 )
@@ -62,4 +63,95 @@ type ObjectStore interface {
 
 	// CreateSignedURL creates a pre-signed URL for the given bucket and key that expires after ttl.
 	CreateSignedURL(bucket, key string, ttl time.Duration) (string, error)
+
+	// Capabilities returns the optional features that the ObjectStore supports.
+	Capabilities() (ObjectStoreCapabilities, error)
+
+	// CopyObject copies the object with the given source key to the
+	// destination key within the specified bucket, without downloading it.
+	// It's only called if Capabilities reports ServerSideCopy.
+	CopyObject(bucket, srcKey, destKey string) error
+
+	// ListObjectVersions lists the versions of the object with the given
+	// key in the specified bucket, including delete markers, newest first.
+	// It's only called if Capabilities reports Versioning.
+	ListObjectVersions(bucket, key string) ([]ObjectVersion, error)
+
+	// GetObjectVersion retrieves the given version of the object with the
+	// given key from the specified bucket. It's only called if
+	// Capabilities reports Versioning.
+	GetObjectVersion(bucket, key, versionID string) (io.ReadCloser, error)
+
+	// LockObject locks the object with the given key in the specified
+	// bucket so that it can't be deleted or overwritten until the given
+	// time. An object that's already locked until a later time must stay
+	// locked until then. It's only called if Capabilities reports
+	// ObjectLock.
+	LockObject(bucket, key string, until time.Time) error
+}
+
+// BatchDeleter is an optional interface for ObjectStores that can delete
+// several objects in one request. It was added in plugin API version 3.
+type BatchDeleter interface {
+	// DeleteObjects removes the objects with the specified keys from the
+	// given bucket.
+	DeleteObjects(bucket string, keys []string) error
+}
+
+// ErrBatchDeleteNotSupported is returned by DeleteObjects when the
+// ObjectStore, or the plugin API version that its plugin serves, doesn't
+// support deleting objects in batches.
+var ErrBatchDeleteNotSupported = errors.New("object store doesn't support deleting objects in batches")
+
+// ObjectVersion describes a version of an object in a bucket that has
+// versioning enabled.
+type ObjectVersion struct {
+	// VersionID identifies the version to GetObjectVersion.
+	VersionID string
+
+	// LastModified is when the version was created.
+	LastModified time.Time
+
+	// IsLatest is whether the version is the object's current version.
+	IsLatest bool
+
+	// IsDeleteMarker is whether the version records that the object was
+	// deleted, rather than holding the object's data.
+	IsDeleteMarker bool
+}
+
+// ObjectStoreCapabilities describes the optional features that an
+// ObjectStore supports, so that Velero can choose how to use it and give
+// a clear error when a feature it needs isn't supported.
+type ObjectStoreCapabilities struct {
+	// SignedURLs is whether CreateSignedURL is implemented.
+	SignedURLs bool
+
+	// MultipartUpload is whether PutObject uploads large objects in parts.
+	MultipartUpload bool
+
+	// Tagging is whether objects can have tags.
+	Tagging bool
+
+	// BatchDelete is whether the object storage can delete several objects
+	// in one request.
+	BatchDelete bool
+
+	// ServerSideCopy is whether CopyObject is implemented, so that the
+	// object storage can copy objects without downloading them.
+	ServerSideCopy bool
+
+	// Versioning is whether ListObjectVersions and GetObjectVersion are
+	// implemented, and the bucket keeps previous versions of objects.
+	Versioning bool
+
+	// ObjectLock is whether LockObject is implemented, and the bucket
+	// allows objects to be locked against deletion.
+	ObjectLock bool
+}
+
+// LegacyObjectStoreCapabilities are the capabilities that are assumed for
+// plugins that were built before ObjectStores reported their capabilities.
+var LegacyObjectStoreCapabilities = ObjectStoreCapabilities{
+	SignedURLs: true,
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package velero
 
 import (
+	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -51,3 +52,16 @@ type VolumeSnapshotter interface {
 	// DeleteSnapshot deletes the specified volume snapshot.
 	DeleteSnapshot(snapshotID string) error
 }
+
+// SnapshotLister is an optional interface for VolumeSnapshotters that can
+// list the snapshots they've taken, so that Velero can find snapshots whose
+// backups no longer exist.
+type SnapshotLister interface {
+	// ListSnapshots returns the IDs of the snapshots that have a tag with
+	// the given key, mapped to the tag's value.
+	ListSnapshots(tagKey string) (map[string]string, error)
+}
+
+// ErrSnapshotListingNotSupported is returned by ListSnapshots when the
+// VolumeSnapshotter doesn't implement SnapshotLister.
+var ErrSnapshotListingNotSupported = errors.New("volume snapshotter doesn't support listing snapshots")
@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package restic
+package podvolume
 
 import (
 	"context"
@@ -97,21 +97,35 @@ func resultsKey(ns, name string) string {
 }
 
 func (b *backupper) BackupPodVolumes(backup *velerov1api.Backup, pod *corev1api.Pod, log logrus.FieldLogger) ([]*velerov1api.PodVolumeBackup, []error) {
-	// get volumes to backup from pod's annotations
-	volumesToBackup := GetVolumesToBackup(pod)
+	// get volumes to backup from pod's annotations, or all of its
+	// volumes if they're backed up with restic by default
+	volumesToBackup := GetPodVolumesToBackup(pod, DefaultVolumesToRestic(backup))
 	if len(volumesToBackup) == 0 {
 		return nil, nil
 	}
 
-	repo, err := b.repoEnsurer.EnsureRepo(b.ctx, backup.Namespace, pod.Namespace, backup.Spec.StorageLocation)
+	location, err := b.repoManager.backupLocationLister.BackupStorageLocations(backup.Namespace).Get(backup.Spec.StorageLocation)
 	if err != nil {
-		return nil, []error{err}
+		return nil, []error{errors.Wrap(err, "error getting backup storage location")}
 	}
+	uploaderType := GetUploaderType(location)
 
-	// get a single non-exclusive lock since we'll wait for all individual
-	// backups to be complete before releasing it.
-	b.repoManager.repoLocker.Lock(repo.Name)
-	defer b.repoManager.repoLocker.Unlock(repo.Name)
+	// kopia connects to its repository, creating it if needed, when it backs
+	// up a volume, so there's only a restic repository to ensure for restic.
+	var repoIdentifier string
+	if uploaderType == velerov1api.UploaderTypeRestic {
+		repo, err := b.repoEnsurer.EnsureRepo(b.ctx, backup.Namespace, pod.Namespace, backup.Spec.StorageLocation)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		// get a single non-exclusive lock since we'll wait for all individual
+		// backups to be complete before releasing it.
+		b.repoManager.repoLocker.Lock(repo.Name)
+		defer b.repoManager.repoLocker.Unlock(repo.Name)
+
+		repoIdentifier = repo.Spec.ResticIdentifier
+	}
 
 	resultsChan := make(chan *velerov1api.PodVolumeBackup)
 
@@ -150,7 +164,7 @@ func (b *backupper) BackupPodVolumes(backup *velerov1api.Backup, pod *corev1api.
 			continue
 		}
 
-		volumeBackup := newPodVolumeBackup(backup, pod, volume, repo.Spec.ResticIdentifier)
+		volumeBackup := newPodVolumeBackup(backup, pod, volume, repoIdentifier, uploaderType)
 		numVolumeSnapshots++
 		if volumeBackup, err = b.repoManager.veleroClient.VeleroV1().PodVolumeBackups(volumeBackup.Namespace).Create(volumeBackup); err != nil {
 			errs = append(errs, err)
@@ -221,7 +235,7 @@ func isHostPathVolume(volume *corev1api.Volume, pvcGetter pvcGetter, pvGetter pv
 	return pv.Spec.HostPath != nil, nil
 }
 
-func newPodVolumeBackup(backup *velerov1api.Backup, pod *corev1api.Pod, volume corev1api.Volume, repoIdentifier string) *velerov1api.PodVolumeBackup {
+func newPodVolumeBackup(backup *velerov1api.Backup, pod *corev1api.Pod, volume corev1api.Volume, repoIdentifier string, uploaderType velerov1api.UploaderType) *velerov1api.PodVolumeBackup {
 	pvb := &velerov1api.PodVolumeBackup{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    backup.Namespace,
@@ -259,9 +273,15 @@ func newPodVolumeBackup(backup *velerov1api.Backup, pod *corev1api.Pod, volume c
 			},
 			BackupStorageLocation: backup.Spec.StorageLocation,
 			RepoIdentifier:        repoIdentifier,
+			UploaderType:          uploaderType,
 		},
 	}
 
+	// block volumes are backed up by streaming the content of their device.
+	if IsBlockVolume(pod, volume.Name) {
+		pvb.Spec.VolumeMode = corev1api.PersistentVolumeBlock
+	}
+
 	// if the volume is for a PVC, annotate the pod volume backup with its name
 	// for easy identification as a PVC backup during restore.
 	if volume.PersistentVolumeClaim != nil {
@@ -270,6 +290,15 @@ func newPodVolumeBackup(backup *velerov1api.Backup, pod *corev1api.Pod, volume c
 		})
 	}
 
+	// if the pod is moving the data of a PVC's volume snapshot, the pod
+	// volume backup is for the original PVC rather than the pod's own.
+	if claimName := pod.Annotations[MovedPVCAnnotation]; claimName != "" {
+		pvb.SetAnnotations(map[string]string{
+			PVCNameAnnotation:  claimName,
+			MovedPVCAnnotation: claimName,
+		})
+	}
+
 	return pvb
 }
 
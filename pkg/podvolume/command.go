@@ -14,10 +14,11 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package restic
+package podvolume
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -33,6 +34,9 @@ type Command struct {
 	Args           []string
 	ExtraFlags     []string
 	Env            []string
+
+	// Stdin is the command's standard input, if it reads any.
+	Stdin io.Reader
 }
 
 func (c *Command) RepoName() string {
@@ -75,6 +79,7 @@ func (c *Command) Cmd() *exec.Cmd {
 	parts := c.StringSlice()
 	cmd := exec.Command(parts[0], parts[1:]...)
 	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
 
 	if len(c.Env) > 0 {
 		cmd.Env = c.Env
@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package restic
+package podvolume
 
 import (
 	"fmt"
@@ -34,7 +34,19 @@ func BackupCommand(repoIdentifier, passwordFile, path string, tags map[string]st
 		PasswordFile:   passwordFile,
 		Dir:            path,
 		Args:           []string{"."},
-		ExtraFlags:     append(backupTagFlags(tags), "--host=velero"),
+		ExtraFlags:     append(backupTagFlags(tags), "--host=velero", "--json"),
+	}
+}
+
+// BackupBlockCommand returns a Command for running a restic backup of
+// the content of a block device read from the command's stdin.
+func BackupBlockCommand(repoIdentifier, passwordFile string, tags map[string]string) *Command {
+	return &Command{
+		Command:        "backup",
+		RepoIdentifier: repoIdentifier,
+		PasswordFile:   passwordFile,
+		Args:           []string{"--stdin", "--stdin-filename=" + blockDeviceFilename},
+		ExtraFlags:     append(backupTagFlags(tags), "--host=velero", "--json"),
 	}
 }
 
@@ -58,6 +70,17 @@ func RestoreCommand(repoIdentifier, passwordFile, snapshotID, target string) *Co
 	}
 }
 
+// DumpCommand returns a Command for running a restic dump of a file in a
+// snapshot to stdout.
+func DumpCommand(repoIdentifier, passwordFile, snapshotID, filename string) *Command {
+	return &Command{
+		Command:        "dump",
+		RepoIdentifier: repoIdentifier,
+		PasswordFile:   passwordFile,
+		Args:           []string{snapshotID, "/" + filename},
+	}
+}
+
 // GetSnapshotCommand returns a Command for running a restic (get) snapshots.
 func GetSnapshotCommand(repoIdentifier, passwordFile string, tags map[string]string) *Command {
 	return &Command{
@@ -68,6 +91,18 @@ func GetSnapshotCommand(repoIdentifier, passwordFile string, tags map[string]str
 	}
 }
 
+// StatsCommand returns a Command for getting the total size of a
+// snapshot's files.
+func StatsCommand(repoIdentifier, passwordFile, snapshotID string) *Command {
+	return &Command{
+		Command:        "stats",
+		RepoIdentifier: repoIdentifier,
+		PasswordFile:   passwordFile,
+		Args:           []string{snapshotID},
+		ExtraFlags:     []string{"--json"},
+	}
+}
+
 func getSnapshotTagFlag(tags map[string]string) string {
 	var tagFilters []string
 	for k, v := range tags {
@@ -119,3 +154,25 @@ func UnlockCommand(repoIdentifier string) *Command {
 		RepoIdentifier: repoIdentifier,
 	}
 }
+
+// ListLocksCommand returns a Command for listing the IDs of the locks held
+// on a restic repository, without locking it.
+func ListLocksCommand(repoIdentifier string) *Command {
+	return &Command{
+		Command:        "list",
+		RepoIdentifier: repoIdentifier,
+		Args:           []string{"locks"},
+		ExtraFlags:     []string{"--no-lock"},
+	}
+}
+
+// CatLockCommand returns a Command for printing a lock held on a restic
+// repository, without locking it.
+func CatLockCommand(repoIdentifier, lockID string) *Command {
+	return &Command{
+		Command:        "cat",
+		RepoIdentifier: repoIdentifier,
+		Args:           []string{"lock", lockID},
+		ExtraFlags:     []string{"--no-lock"},
+	}
+}
@@ -13,7 +13,7 @@ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 See the License for the specific language governing permissions and
 limitations under the License.
 */
-package restic
+package podvolume
 
 import (
 	"sort"
@@ -32,7 +32,21 @@ func TestBackupCommand(t *testing.T) {
 	assert.Equal(t, "path", c.Dir)
 	assert.Equal(t, []string{"."}, c.Args)
 
-	expected := []string{"--tag=foo=bar", "--tag=c=d", "--host=velero"}
+	expected := []string{"--tag=foo=bar", "--tag=c=d", "--host=velero", "--json"}
+	sort.Strings(expected)
+	sort.Strings(c.ExtraFlags)
+	assert.Equal(t, expected, c.ExtraFlags)
+}
+
+func TestBackupBlockCommand(t *testing.T) {
+	c := BackupBlockCommand("repo-id", "password-file", map[string]string{"foo": "bar", "c": "d"})
+
+	assert.Equal(t, "backup", c.Command)
+	assert.Equal(t, "repo-id", c.RepoIdentifier)
+	assert.Equal(t, "password-file", c.PasswordFile)
+	assert.Equal(t, []string{"--stdin", "--stdin-filename=block-device"}, c.Args)
+
+	expected := []string{"--tag=foo=bar", "--tag=c=d", "--host=velero", "--json"}
 	sort.Strings(expected)
 	sort.Strings(c.ExtraFlags)
 	assert.Equal(t, expected, c.ExtraFlags)
@@ -49,6 +63,15 @@ func TestRestoreCommand(t *testing.T) {
 	assert.Equal(t, []string{"--target=."}, c.ExtraFlags)
 }
 
+func TestDumpCommand(t *testing.T) {
+	c := DumpCommand("repo-id", "password-file", "snapshot-id", "block-device")
+
+	assert.Equal(t, "dump", c.Command)
+	assert.Equal(t, "repo-id", c.RepoIdentifier)
+	assert.Equal(t, "password-file", c.PasswordFile)
+	assert.Equal(t, []string{"snapshot-id", "/block-device"}, c.Args)
+}
+
 func TestGetSnapshotCommand(t *testing.T) {
 	expectedTags := map[string]string{"foo": "bar", "c": "d"}
 	c := GetSnapshotCommand("repo-id", "password-file", expectedTags)
@@ -124,3 +147,21 @@ func TestForgetCommand(t *testing.T) {
 	assert.Equal(t, "repo-id", c.RepoIdentifier)
 	assert.Equal(t, []string{"snapshot-id"}, c.Args)
 }
+
+func TestListLocksCommand(t *testing.T) {
+	c := ListLocksCommand("repo-id")
+
+	assert.Equal(t, "list", c.Command)
+	assert.Equal(t, "repo-id", c.RepoIdentifier)
+	assert.Equal(t, []string{"locks"}, c.Args)
+	assert.Equal(t, []string{"--no-lock"}, c.ExtraFlags)
+}
+
+func TestCatLockCommand(t *testing.T) {
+	c := CatLockCommand("repo-id", "lock-id")
+
+	assert.Equal(t, "cat", c.Command)
+	assert.Equal(t, "repo-id", c.RepoIdentifier)
+	assert.Equal(t, []string{"lock", "lock-id"}, c.Args)
+	assert.Equal(t, []string{"--no-lock"}, c.ExtraFlags)
+}
@@ -0,0 +1,430 @@
+/*
+Copyright 2018 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podvolume
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/cloudprovider/azure"
+	velerov1listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/label"
+	"github.com/heptio/velero/pkg/util/filesystem"
+)
+
+const (
+	// DaemonSet is the name of the Velero restic daemonset.
+	DaemonSet = "restic"
+
+	// InitContainer is the name of the init container added
+	// to workload pods to help with restores.
+	InitContainer = "restic-wait"
+
+	// DefaultMaintenanceFrequency is the default time interval
+	// at which restic check & prune are run.
+	DefaultMaintenanceFrequency = 24 * time.Hour
+
+	// PVCNameAnnotation is the key for the annotation added to
+	// pod volume backups when they're for a PVC.
+	PVCNameAnnotation = "velero.io/pvc-name"
+
+	// MovedPVCAnnotation is the key for the annotation added to pod
+	// volume backups that hold the data of a PVC's volume snapshot,
+	// which are taken of a temporary pod and restored into the PVC
+	// itself.
+	MovedPVCAnnotation = "velero.io/moved-pvc-name"
+
+	// LinuxHostPodsDir is the directory that the kubelet's pods directory
+	// is mounted at in the restic daemonset's pods on Linux nodes.
+	LinuxHostPodsDir = "/host_pods"
+
+	// WindowsHostPodsDir is the directory that the kubelet's pods directory
+	// is mounted at in the restic daemonset's pods on Windows nodes.
+	WindowsHostPodsDir = `C:\host_pods`
+
+	// BlockRestoresVolume is the name of the emptyDir volume that's added
+	// to pods with block volumes to restore, which holds the done files of
+	// their restores since block volumes can't hold files themselves.
+	BlockRestoresVolume = "velero-block-restores"
+
+	// BlockRestoresDir is the directory that the BlockRestoresVolume is
+	// mounted at in the init container.
+	BlockRestoresDir = "/block-restores"
+
+	// Deprecated.
+	//
+	// TODO(2.0): remove
+	podAnnotationPrefix = "snapshot.velero.io/"
+
+	volumesToBackupAnnotation = "backup.velero.io/backup-volumes"
+
+	// volumesToExcludeAnnotation is the annotation listing the volumes of
+	// a pod that aren't backed up with restic when all its volumes are
+	// backed up with restic by default.
+	volumesToExcludeAnnotation = "backup.velero.io/backup-volumes-excludes"
+)
+
+// getPodSnapshotAnnotations returns a map, of volume name -> snapshot id,
+// of all restic snapshots for this pod.
+// TODO(2.0) to remove
+// Deprecated: we will stop using pod annotations to record restic snapshot IDs after they're taken,
+// therefore we won't need to check if these annotations exist.
+func getPodSnapshotAnnotations(obj metav1.Object) map[string]string {
+	var res map[string]string
+
+	insertSafe := func(k, v string) {
+		if res == nil {
+			res = make(map[string]string)
+		}
+		res[k] = v
+	}
+
+	for k, v := range obj.GetAnnotations() {
+		if strings.HasPrefix(k, podAnnotationPrefix) {
+			insertSafe(k[len(podAnnotationPrefix):], v)
+		}
+	}
+
+	return res
+}
+
+// GetVolumeBackupsForPod returns a map, of volume name -> snapshot id,
+// of the PodVolumeBackups that exist for the provided pod.
+func GetVolumeBackupsForPod(podVolumeBackups []*velerov1api.PodVolumeBackup, pod metav1.Object) map[string]string {
+	volumes := make(map[string]string)
+
+	for _, pvb := range podVolumeBackups {
+		if pod.GetName() == pvb.Spec.Pod.Name {
+			volumes[pvb.Spec.Volume] = pvb.Status.SnapshotID
+		}
+	}
+
+	if len(volumes) > 0 {
+		return volumes
+	}
+
+	return getPodSnapshotAnnotations(pod)
+}
+
+// GetBlockVolumesForPod returns the names of the volumes of the
+// PodVolumeBackups that exist for the provided pod that were backed up
+// from block volumes.
+func GetBlockVolumesForPod(podVolumeBackups []*velerov1api.PodVolumeBackup, pod metav1.Object) map[string]bool {
+	volumes := make(map[string]bool)
+
+	for _, pvb := range podVolumeBackups {
+		if pod.GetName() == pvb.Spec.Pod.Name && pvb.Spec.VolumeMode == corev1api.PersistentVolumeBlock {
+			volumes[pvb.Spec.Volume] = true
+		}
+	}
+
+	return volumes
+}
+
+// GetParentPodVolumeBackup returns the most recently completed of the
+// PodVolumeBackups that took a snapshot of the same volume as pvb, into
+// the same repository with the same uploader, or nil if there isn't one.
+// Volumes of PVCs are matched by their PVC, so that the chain of backups
+// of a PVC carries on when its pod is recreated, and other volumes by
+// their pod and name.
+func GetParentPodVolumeBackup(podVolumeBackups []*velerov1api.PodVolumeBackup, pvb *velerov1api.PodVolumeBackup) *velerov1api.PodVolumeBackup {
+	var parent *velerov1api.PodVolumeBackup
+
+	for _, candidate := range podVolumeBackups {
+		if candidate.Name == pvb.Name ||
+			candidate.Status.Phase != velerov1api.PodVolumeBackupPhaseCompleted ||
+			candidate.Status.SnapshotID == "" ||
+			!sameVolume(candidate, pvb) ||
+			candidate.Spec.BackupStorageLocation != pvb.Spec.BackupStorageLocation ||
+			candidate.Spec.RepoIdentifier != pvb.Spec.RepoIdentifier ||
+			candidate.Spec.UploaderType != pvb.Spec.UploaderType ||
+			candidate.Spec.VolumeMode != pvb.Spec.VolumeMode {
+			continue
+		}
+
+		if parent == nil || candidate.Status.CompletionTimestamp.After(parent.Status.CompletionTimestamp.Time) {
+			parent = candidate
+		}
+	}
+
+	return parent
+}
+
+// sameVolume returns true if two PodVolumeBackups are of the same volume,
+// or false otherwise.
+func sameVolume(a, b *velerov1api.PodVolumeBackup) bool {
+	if a.Spec.Pod.Namespace != b.Spec.Pod.Namespace {
+		return false
+	}
+
+	if pvcName := b.Annotations[PVCNameAnnotation]; pvcName != "" {
+		return a.Annotations[PVCNameAnnotation] == pvcName
+	}
+
+	return a.Annotations[PVCNameAnnotation] == "" && a.Spec.Pod.Name == b.Spec.Pod.Name && a.Spec.Volume == b.Spec.Volume
+}
+
+// IsBlockVolume returns true if the pod's containers use the volume as a
+// raw block device rather than mounting it, or false otherwise.
+func IsBlockVolume(pod *corev1api.Pod, volumeName string) bool {
+	for _, containers := range [][]corev1api.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			for _, device := range container.VolumeDevices {
+				if device.Name == volumeName {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// GetVolumesToBackup returns a list of volume names to backup for
+// the provided pod.
+func GetVolumesToBackup(obj metav1.Object) []string {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		return nil
+	}
+
+	backupsValue := annotations[volumesToBackupAnnotation]
+	if backupsValue == "" {
+		return nil
+	}
+
+	return strings.Split(backupsValue, ",")
+}
+
+// getVolumesToExclude returns a list of volume names to exclude from
+// restic backups for the provided pod.
+func getVolumesToExclude(obj metav1.Object) []string {
+	excludesValue := obj.GetAnnotations()[volumesToExcludeAnnotation]
+	if excludesValue == "" {
+		return nil
+	}
+
+	return strings.Split(excludesValue, ",")
+}
+
+// GetPodVolumesToBackup returns a list of volume names to backup with restic
+// for the provided pod. If defaultVolumesToRestic is false, these are the volumes
+// listed in the pod's backup-volumes annotation. Otherwise, they're all of the
+// pod's volumes except those listed in its backup-volumes-excludes annotation
+// and those whose contents come from the API server or the node, i.e.
+// hostPath, secret, config map, projected and downward API volumes, which
+// aren't worth backing up or can't be.
+func GetPodVolumesToBackup(pod *corev1api.Pod, defaultVolumesToRestic bool) []string {
+	if !defaultVolumesToRestic {
+		return GetVolumesToBackup(pod)
+	}
+
+	excluded := sets.NewString(getVolumesToExclude(pod)...)
+
+	var volumes []string
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case excluded.Has(volume.Name):
+			continue
+		case volume.HostPath != nil,
+			volume.Secret != nil,
+			volume.ConfigMap != nil,
+			volume.Projected != nil,
+			volume.DownwardAPI != nil:
+			continue
+		}
+
+		volumes = append(volumes, volume.Name)
+	}
+
+	return volumes
+}
+
+// DefaultVolumesToRestic returns whether all of the pod volumes in
+// the provided backup are backed up with restic by default.
+func DefaultVolumesToRestic(backup *velerov1api.Backup) bool {
+	return backup.Spec.DefaultVolumesToRestic != nil && *backup.Spec.DefaultVolumesToRestic
+}
+
+// HostPodsDir returns the directory that the kubelet's pods directory is
+// mounted at in the restic daemonset's pods on this node's operating system.
+func HostPodsDir() string {
+	if runtime.GOOS == "windows" {
+		return WindowsHostPodsDir
+	}
+	return LinuxHostPodsDir
+}
+
+// PodVolumePathGlob returns a glob matching the directory of a pod volume,
+// given the pod's UID and the volume's directory name, as mounted in the
+// restic daemonset's pods: <host pods dir>/<pod-uid>/volumes/<volume-plugin-name>/<volume-dir>.
+func PodVolumePathGlob(podUID types.UID, volumeDir string) string {
+	return filepath.Join(HostPodsDir(), string(podUID), "volumes", "*", volumeDir)
+}
+
+// PodVolumeDevicePathGlob returns a glob matching the block device of a pod's
+// block volume, given the pod's UID and the volume's device name, as mounted in
+// the restic daemonset's pods: <host pods dir>/<pod-uid>/volumeDevices/<volume-plugin-name>/<device-name>.
+func PodVolumeDevicePathGlob(podUID types.UID, deviceName string) string {
+	return filepath.Join(HostPodsDir(), string(podUID), "volumeDevices", "*", deviceName)
+}
+
+// BlockRestoreDoneDir returns the directory, as mounted in the restic
+// daemonset's pods, that the done file of the restore of a pod's block volume
+// is written under, in the pod's BlockRestoresVolume.
+func BlockRestoreDoneDir(podUID types.UID, volumeName string) string {
+	return filepath.Join(HostPodsDir(), string(podUID), "volumes", "kubernetes.io~empty-dir", BlockRestoresVolume, volumeName)
+}
+
+// SnapshotIdentifier uniquely identifies a restic snapshot
+// taken by Velero.
+type SnapshotIdentifier struct {
+	// VolumeNamespace is the namespace of the pod/volume that
+	// the restic snapshot is for.
+	VolumeNamespace string
+
+	// BackupStorageLocation is the backup's storage location
+	// name.
+	BackupStorageLocation string
+
+	// SnapshotID is the short ID of the restic snapshot, or the ID
+	// of the kopia snapshot.
+	SnapshotID string
+
+	// UploaderType is the uploader that took the snapshot.
+	UploaderType velerov1api.UploaderType
+}
+
+// GetSnapshotsInBackup returns a list of all restic snapshot ids associated with
+// a given Velero backup.
+func GetSnapshotsInBackup(backup *velerov1api.Backup, podVolumeBackupLister velerov1listers.PodVolumeBackupLister) ([]SnapshotIdentifier, error) {
+	selector := labels.Set(map[string]string{
+		velerov1api.BackupNameLabel: label.GetValidName(backup.Name),
+	}).AsSelector()
+
+	podVolumeBackups, err := podVolumeBackupLister.List(selector)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var res []SnapshotIdentifier
+	for _, item := range podVolumeBackups {
+		if item.Status.SnapshotID == "" {
+			continue
+		}
+		res = append(res, SnapshotIdentifier{
+			VolumeNamespace:       item.Spec.Pod.Namespace,
+			BackupStorageLocation: backup.Spec.StorageLocation,
+			SnapshotID:            item.Status.SnapshotID,
+			UploaderType:          item.Spec.UploaderType,
+		})
+	}
+
+	return res, nil
+}
+
+// TempCredentialsFile creates a temp file containing a restic
+// encryption key for the given repo and returns its path. The
+// caller should generally call os.Remove() to remove the file
+// when done with it.
+func TempCredentialsFile(secretLister corev1listers.SecretLister, veleroNamespace, repoName string, fs filesystem.Interface) (string, error) {
+	secretGetter := NewListerSecretGetter(secretLister)
+
+	// For now, all restic repos share the same key so we don't need the repoName to fetch it.
+	// When we move to full-backup encryption, we'll likely have a separate key per restic repo
+	// (all within the Velero server's namespace) so GetRepositoryKey will need to take the repo
+	// name as an argument as well.
+	repoKey, err := GetRepositoryKey(secretGetter, veleroNamespace)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := fs.TempFile("", fmt.Sprintf("%s-%s", CredentialsSecretName, repoName))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if _, err := file.Write(repoKey); err != nil {
+		// nothing we can do about an error closing the file here, and we're
+		// already returning an error about the write failing.
+		file.Close()
+		return "", errors.WithStack(err)
+	}
+
+	name := file.Name()
+
+	if err := file.Close(); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return name, nil
+}
+
+// NewPodVolumeBackupListOptions creates a ListOptions with a label selector configured to
+// find PodVolumeBackups for the backup identified by name.
+func NewPodVolumeBackupListOptions(name string) metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", velerov1api.BackupNameLabel, label.GetValidName(name)),
+	}
+}
+
+// NewPodVolumeRestoreListOptions creates a ListOptions with a label selector configured to
+// find PodVolumeRestores for the restore identified by name.
+func NewPodVolumeRestoreListOptions(name string) metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", velerov1api.RestoreNameLabel, label.GetValidName(name)),
+	}
+}
+
+// AzureCmdEnv returns a list of environment variables (in the format var=val) that
+// should be used when running a restic command for an Azure backend. This list is
+// the current environment, plus the Azure-specific variables restic needs, namely
+// a storage account name and key.
+func AzureCmdEnv(backupLocationLister velerov1listers.BackupStorageLocationLister, namespace, backupLocation string) ([]string, error) {
+	loc, err := backupLocationLister.BackupStorageLocations(namespace).Get(backupLocation)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting backup storage location")
+	}
+
+	return azureCmdEnv(loc)
+}
+
+func azureCmdEnv(loc *velerov1api.BackupStorageLocation) ([]string, error) {
+	azureVars, err := azure.GetResticEnvVars(loc.Spec.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting azure restic env vars")
+	}
+
+	env := os.Environ()
+	for k, v := range azureVars {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return env, nil
+}
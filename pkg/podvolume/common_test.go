@@ -14,11 +14,12 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package restic
+package podvolume
 
 import (
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -80,6 +81,132 @@ func TestGetPodSnapshotAnnotations(t *testing.T) {
 	}
 }
 
+func TestGetPodVolumesToBackup(t *testing.T) {
+	pod := &corev1api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				volumesToBackupAnnotation:  "data",
+				volumesToExcludeAnnotation: "cache,missing",
+			},
+		},
+		Spec: corev1api.PodSpec{
+			Volumes: []corev1api.Volume{
+				{Name: "data", VolumeSource: corev1api.VolumeSource{PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"}}},
+				{Name: "scratch", VolumeSource: corev1api.VolumeSource{EmptyDir: &corev1api.EmptyDirVolumeSource{}}},
+				{Name: "cache", VolumeSource: corev1api.VolumeSource{EmptyDir: &corev1api.EmptyDirVolumeSource{}}},
+				{Name: "host", VolumeSource: corev1api.VolumeSource{HostPath: &corev1api.HostPathVolumeSource{Path: "/tmp"}}},
+				{Name: "token", VolumeSource: corev1api.VolumeSource{Secret: &corev1api.SecretVolumeSource{SecretName: "default-token"}}},
+				{Name: "config", VolumeSource: corev1api.VolumeSource{ConfigMap: &corev1api.ConfigMapVolumeSource{}}},
+				{Name: "projected", VolumeSource: corev1api.VolumeSource{Projected: &corev1api.ProjectedVolumeSource{}}},
+				{Name: "downward", VolumeSource: corev1api.VolumeSource{DownwardAPI: &corev1api.DownwardAPIVolumeSource{}}},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"data"}, GetPodVolumesToBackup(pod, false))
+	assert.Equal(t, []string{"data", "scratch"}, GetPodVolumesToBackup(pod, true))
+}
+
+func TestPodVolumePathGlob(t *testing.T) {
+	assert.Equal(t, "/host_pods/pod-uid/volumes/*/pvc-1", PodVolumePathGlob("pod-uid", "pvc-1"))
+	assert.Equal(t, "/host_pods/pod-uid/volumeDevices/*/pv-1", PodVolumeDevicePathGlob("pod-uid", "pv-1"))
+	assert.Equal(t, "/host_pods/pod-uid/volumes/kubernetes.io~empty-dir/velero-block-restores/data", BlockRestoreDoneDir("pod-uid", "data"))
+}
+
+func TestIsBlockVolume(t *testing.T) {
+	pod := &corev1api.Pod{
+		Spec: corev1api.PodSpec{
+			Containers: []corev1api.Container{
+				{
+					Name:          "db",
+					VolumeMounts:  []corev1api.VolumeMount{{Name: "config", MountPath: "/config"}},
+					VolumeDevices: []corev1api.VolumeDevice{{Name: "data", DevicePath: "/dev/xvda"}},
+				},
+			},
+		},
+	}
+
+	assert.True(t, IsBlockVolume(pod, "data"))
+	assert.False(t, IsBlockVolume(pod, "config"))
+}
+
+func TestGetBlockVolumesForPod(t *testing.T) {
+	pod := &corev1api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1"}}
+	pvbs := []*velerov1api.PodVolumeBackup{
+		{Spec: velerov1api.PodVolumeBackupSpec{Pod: corev1api.ObjectReference{Name: "pod-1"}, Volume: "data", VolumeMode: corev1api.PersistentVolumeBlock}},
+		{Spec: velerov1api.PodVolumeBackupSpec{Pod: corev1api.ObjectReference{Name: "pod-1"}, Volume: "config"}},
+		{Spec: velerov1api.PodVolumeBackupSpec{Pod: corev1api.ObjectReference{Name: "pod-2"}, Volume: "other", VolumeMode: corev1api.PersistentVolumeBlock}},
+	}
+
+	assert.Equal(t, map[string]bool{"data": true}, GetBlockVolumesForPod(pvbs, pod))
+}
+
+func TestGetParentPodVolumeBackup(t *testing.T) {
+	pvb := func(name, pod, pvc string, phase velerov1api.PodVolumeBackupPhase, completed int) *velerov1api.PodVolumeBackup {
+		res := &velerov1api.PodVolumeBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: velerov1api.PodVolumeBackupSpec{
+				Pod:                   corev1api.ObjectReference{Namespace: "ns-1", Name: pod},
+				Volume:                "data",
+				BackupStorageLocation: "default",
+				RepoIdentifier:        "repo-1",
+			},
+			Status: velerov1api.PodVolumeBackupStatus{
+				Phase:               phase,
+				SnapshotID:          "snapshot-" + name,
+				CompletionTimestamp: metav1.NewTime(time.Date(2019, 10, completed, 0, 0, 0, 0, time.UTC)),
+			},
+		}
+		if pvc != "" {
+			res.Annotations = map[string]string{PVCNameAnnotation: pvc}
+		}
+		return res
+	}
+
+	tests := []struct {
+		name     string
+		pvbs     []*velerov1api.PodVolumeBackup
+		pvb      *velerov1api.PodVolumeBackup
+		expected string
+	}{
+		{
+			name:     "the most recently completed backup of the same PVC is the parent, even from another pod",
+			pvbs:     []*velerov1api.PodVolumeBackup{pvb("pvb-1", "pod-1", "pvc-1", velerov1api.PodVolumeBackupPhaseCompleted, 1), pvb("pvb-2", "pod-2", "pvc-1", velerov1api.PodVolumeBackupPhaseCompleted, 2)},
+			pvb:      pvb("pvb-3", "pod-3", "pvc-1", velerov1api.PodVolumeBackupPhaseNew, 3),
+			expected: "pvb-2",
+		},
+		{
+			name:     "failed backups and backups of other PVCs aren't parents",
+			pvbs:     []*velerov1api.PodVolumeBackup{pvb("pvb-1", "pod-1", "pvc-1", velerov1api.PodVolumeBackupPhaseCompleted, 1), pvb("pvb-2", "pod-1", "pvc-1", velerov1api.PodVolumeBackupPhaseFailed, 2), pvb("pvb-3", "pod-1", "pvc-2", velerov1api.PodVolumeBackupPhaseCompleted, 3)},
+			pvb:      pvb("pvb-4", "pod-1", "pvc-1", velerov1api.PodVolumeBackupPhaseNew, 4),
+			expected: "pvb-1",
+		},
+		{
+			name:     "volumes without a PVC are matched by pod and volume",
+			pvbs:     []*velerov1api.PodVolumeBackup{pvb("pvb-1", "pod-1", "", velerov1api.PodVolumeBackupPhaseCompleted, 1), pvb("pvb-2", "pod-2", "", velerov1api.PodVolumeBackupPhaseCompleted, 2)},
+			pvb:      pvb("pvb-3", "pod-1", "", velerov1api.PodVolumeBackupPhaseNew, 3),
+			expected: "pvb-1",
+		},
+		{
+			name: "no backup of the volume means there's no parent",
+			pvbs: []*velerov1api.PodVolumeBackup{pvb("pvb-1", "pod-1", "pvc-2", velerov1api.PodVolumeBackupPhaseCompleted, 1)},
+			pvb:  pvb("pvb-2", "pod-1", "pvc-1", velerov1api.PodVolumeBackupPhaseNew, 2),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parent := GetParentPodVolumeBackup(test.pvbs, test.pvb)
+			if test.expected == "" {
+				assert.Nil(t, parent)
+				return
+			}
+			require.NotNil(t, parent)
+			assert.Equal(t, test.expected, parent.Name)
+		})
+	}
+}
+
 func TestGetVolumesToBackup(t *testing.T) {
 	tests := []struct {
 		name        string
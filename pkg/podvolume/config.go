@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package restic
+package podvolume
 
 import (
 	"fmt"
@@ -38,13 +38,32 @@ const (
 // replaced when unit-testing
 var getAWSBucketRegion = aws.GetBucketRegion
 
+// expandPrefix expands the template variables in a backup storage
+// location's prefix. Backup stores can't be created for locations with an
+// invalid prefix, so there are no restic repositories for them, and the
+// prefix is used as is.
+func expandPrefix(prefix string) string {
+	if res, err := persistence.ExpandPrefix(prefix); err == nil {
+		return res
+	}
+	return prefix
+}
+
 // getRepoPrefix returns the prefix of the value of the --repo flag for
 // restic commands, i.e. everything except the "/<repo-name>".
 func getRepoPrefix(location *velerov1api.BackupStorageLocation) string {
 	var provider, bucket, prefix, bucketAndPrefix string
 
+	// restic natively supports repositories on a local filesystem, so the
+	// repo identifier is just the path of the restic dir.
+	if location.Spec.Filesystem != nil {
+		layout := persistence.NewObjectStoreLayout(expandPrefix(location.Spec.Filesystem.Prefix))
+
+		return path.Join(location.Spec.Filesystem.Path, layout.GetResticDir())
+	}
+
 	if location.Spec.ObjectStorage != nil {
-		layout := persistence.NewObjectStoreLayout(location.Spec.ObjectStorage.Prefix)
+		layout := persistence.NewObjectStoreLayout(expandPrefix(location.Spec.ObjectStorage.Prefix))
 
 		bucket = location.Spec.ObjectStorage.Bucket
 		prefix = layout.GetResticDir()
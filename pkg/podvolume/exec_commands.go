@@ -0,0 +1,393 @@
+/*
+Copyright 2018 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podvolume
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/util/exec"
+)
+
+// progressCheckInterval is how often the progress of restic backups and
+// restores is reported while they run.
+var progressCheckInterval = 10 * time.Second
+
+// GetSnapshotID runs a 'restic snapshots' command to get the ID of the snapshot
+// in the specified repo matching the set of provided tags, or an error if a
+// unique snapshot cannot be identified.
+func GetSnapshotID(repoIdentifier, passwordFile string, tags map[string]string, env []string) (string, error) {
+	cmd := GetSnapshotCommand(repoIdentifier, passwordFile, tags)
+	if len(env) > 0 {
+		cmd.Env = env
+	}
+
+	stdout, stderr, err := exec.RunCommand(cmd.Cmd())
+	if err != nil {
+		return "", errors.Wrapf(err, "error running command, stderr=%s", stderr)
+	}
+
+	type snapshotID struct {
+		ShortID string `json:"short_id"`
+	}
+
+	var snapshots []snapshotID
+	if err := json.Unmarshal([]byte(stdout), &snapshots); err != nil {
+		return "", errors.Wrap(err, "error unmarshalling restic snapshots result")
+	}
+
+	if len(snapshots) != 1 {
+		return "", errors.Errorf("expected one matching snapshot, got %d", len(snapshots))
+	}
+
+	return snapshots[0].ShortID, nil
+}
+
+// transientErrors are the messages restic prints for failures that are
+// likely to go away if the command is run again.
+var transientErrors = []string{
+	"repository is already locked",
+	"unable to create lock",
+	"connection reset by peer",
+	"connection refused",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"unexpected EOF",
+	"no such host",
+	"Service Unavailable",
+	"Internal Server Error",
+	"SlowDown",
+}
+
+// IsTransientError returns true if a restic command's stderr indicates
+// that it failed for a reason that's likely to be transient, such as a
+// network error or a lock held on the repository.
+func IsTransientError(stderr string) bool {
+	for _, msg := range transientErrors {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// backupStatusLine is a line of 'restic backup --json' output. restic
+// prints status lines while it runs, and a summary line when it's done.
+type backupStatusLine struct {
+	MessageType string `json:"message_type"`
+
+	// TotalBytes and BytesDone are set in status lines.
+	TotalBytes int64 `json:"total_bytes"`
+	BytesDone  int64 `json:"bytes_done"`
+
+	// TotalBytesProcessed, FilesNew, FilesChanged, FilesUnmodified and
+	// DataAdded are set in the summary line.
+	TotalBytesProcessed int64 `json:"total_bytes_processed"`
+	FilesNew            int   `json:"files_new"`
+	FilesChanged        int   `json:"files_changed"`
+	FilesUnmodified     int   `json:"files_unmodified"`
+	DataAdded           int64 `json:"data_added"`
+}
+
+// RunBackup runs a 'restic backup' command with JSON output, and calls
+// updateFunc with the progress restic reports while it runs. It returns
+// the last line of restic's stdout, which is its summary of the backup,
+// rather than all of its status lines, and its stderr.
+func RunBackup(backupCmd *Command, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (string, string, error) {
+	stdoutBuf := new(syncBuffer)
+	stderrBuf := new(bytes.Buffer)
+
+	cmd := backupCmd.Cmd()
+	cmd.Stdout = stdoutBuf
+	cmd.Stderr = stderrBuf
+
+	if err := cmd.Start(); err != nil {
+		return "", "", err
+	}
+
+	stop := runEvery(progressCheckInterval, func() {
+		line := lastCompleteLine(stdoutBuf.Bytes())
+		if len(line) == 0 {
+			return
+		}
+
+		status := new(backupStatusLine)
+		if err := json.Unmarshal(line, status); err != nil {
+			log.WithError(errors.WithStack(err)).Warn("Error decoding restic backup progress")
+			return
+		}
+
+		if status.MessageType == "status" && status.TotalBytes > 0 {
+			updateFunc(velerov1api.PodVolumeOperationProgress{
+				TotalBytes: status.TotalBytes,
+				BytesDone:  status.BytesDone,
+			})
+		}
+	})
+	err := cmd.Wait()
+	stop()
+
+	summary := string(lastCompleteLine(stdoutBuf.Bytes()))
+	if err != nil {
+		return summary, stderrBuf.String(), err
+	}
+
+	status := new(backupStatusLine)
+	if err := json.Unmarshal([]byte(summary), status); err != nil {
+		log.WithError(errors.WithStack(err)).Warn("Error decoding restic backup summary")
+	} else if status.MessageType == "summary" {
+		updateFunc(velerov1api.PodVolumeOperationProgress{
+			TotalBytes: status.TotalBytesProcessed,
+			BytesDone:  status.TotalBytesProcessed,
+		})
+	}
+
+	return summary, stderrBuf.String(), nil
+}
+
+// getBackupChanges returns how much of a volume changed since the parent
+// snapshot of a restic backup, from the summary line of its output.
+func getBackupChanges(summary string, log logrus.FieldLogger) velerov1api.PodVolumeBackupChanges {
+	status := new(backupStatusLine)
+	if err := json.Unmarshal([]byte(summary), status); err != nil || status.MessageType != "summary" {
+		log.Warn("Error decoding restic backup summary, not reporting the volume's changes")
+		return velerov1api.PodVolumeBackupChanges{}
+	}
+
+	return velerov1api.PodVolumeBackupChanges{
+		FilesChanged:    status.FilesNew + status.FilesChanged,
+		FilesUnmodified: status.FilesUnmodified,
+		BytesAdded:      status.DataAdded,
+	}
+}
+
+// RunRestore runs a 'restic restore' command, and calls updateFunc with
+// the size of the files restored into its target directory so far, out of
+// the size of the snapshot, while it runs. restic doesn't report the
+// progress of restores itself.
+func RunRestore(restoreCmd *Command, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (string, string, error) {
+	snapshotSize, err := getSnapshotSize(restoreCmd.RepoIdentifier, restoreCmd.PasswordFile, restoreCmd.Args[0], restoreCmd.Env)
+	if err != nil {
+		// progress is nice to have, so run the restore without it.
+		log.WithError(err).Warn("Error getting snapshot size, not reporting restic restore progress")
+		return exec.RunCommand(restoreCmd.Cmd())
+	}
+
+	updateFunc(velerov1api.PodVolumeOperationProgress{TotalBytes: snapshotSize})
+
+	stop := runEvery(progressCheckInterval, func() {
+		volumeSize, err := getVolumeSize(restoreCmd.Dir)
+		if err != nil {
+			log.WithError(err).Warn("Error getting restic restore progress")
+			return
+		}
+
+		// the volume may have had files in it before the restore.
+		if volumeSize > snapshotSize {
+			volumeSize = snapshotSize
+		}
+
+		updateFunc(velerov1api.PodVolumeOperationProgress{
+			TotalBytes: snapshotSize,
+			BytesDone:  volumeSize,
+		})
+	})
+	stdout, stderr, err := exec.RunCommand(restoreCmd.Cmd())
+	stop()
+
+	if err == nil {
+		updateFunc(velerov1api.PodVolumeOperationProgress{
+			TotalBytes: snapshotSize,
+			BytesDone:  snapshotSize,
+		})
+	}
+
+	return stdout, stderr, err
+}
+
+// RunDump runs a 'restic dump' command, writing the dumped file to w, and
+// calls updateFunc with the number of bytes written so far, out of the size
+// of the snapshot, while it runs. It returns the command's stderr.
+func RunDump(dumpCmd *Command, w io.Writer, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (string, error) {
+	stderrBuf := new(bytes.Buffer)
+	counter := &countingWriter{w: w}
+
+	cmd := dumpCmd.Cmd()
+	cmd.Stdout = counter
+	cmd.Stderr = stderrBuf
+
+	snapshotSize, err := getSnapshotSize(dumpCmd.RepoIdentifier, dumpCmd.PasswordFile, dumpCmd.Args[0], dumpCmd.Env)
+	if err != nil {
+		// progress is nice to have, so run the dump without it.
+		log.WithError(err).Warn("Error getting snapshot size, not reporting restic restore progress")
+		err := cmd.Run()
+		return stderrBuf.String(), err
+	}
+
+	updateFunc(velerov1api.PodVolumeOperationProgress{TotalBytes: snapshotSize})
+
+	stop := runEvery(progressCheckInterval, func() {
+		updateFunc(velerov1api.PodVolumeOperationProgress{
+			TotalBytes: snapshotSize,
+			BytesDone:  counter.Count(),
+		})
+	})
+	err = cmd.Run()
+	stop()
+
+	if err == nil {
+		updateFunc(velerov1api.PodVolumeOperationProgress{
+			TotalBytes: snapshotSize,
+			BytesDone:  snapshotSize,
+		})
+	}
+
+	return stderrBuf.String(), err
+}
+
+// getSnapshotSize runs a 'restic stats' command to get the total size of
+// the files in a snapshot.
+func getSnapshotSize(repoIdentifier, passwordFile, snapshotID string, env []string) (int64, error) {
+	cmd := StatsCommand(repoIdentifier, passwordFile, snapshotID)
+	if len(env) > 0 {
+		cmd.Env = env
+	}
+
+	stdout, stderr, err := exec.RunCommand(cmd.Cmd())
+	if err != nil {
+		return 0, errors.Wrapf(err, "error running command, stderr=%s", stderr)
+	}
+
+	var stats struct {
+		TotalSize int64 `json:"total_size"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &stats); err != nil {
+		return 0, errors.Wrap(err, "error unmarshalling restic stats result")
+	}
+
+	return stats.TotalSize, nil
+}
+
+// getVolumeSize returns the total size of the regular files in a directory.
+func getVolumeSize(path string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "error getting size of directory %s", path)
+	}
+
+	return size, nil
+}
+
+// runEvery calls f every interval in a separate goroutine until the
+// returned function is called. Once it returns, f isn't running and won't
+// be called again.
+func runEvery(interval time.Duration, f func()) func() {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				f()
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(quit)
+		<-done
+	}
+}
+
+// lastCompleteLine returns the last newline-terminated line in b, without
+// the newline, so that a line that's still being written is ignored.
+func lastCompleteLine(b []byte) []byte {
+	end := bytes.LastIndexByte(b, '\n')
+	if end < 0 {
+		return nil
+	}
+
+	start := bytes.LastIndexByte(b[:end], '\n') + 1
+	return b[start:end]
+}
+
+// syncBuffer is a bytes.Buffer that can be written to by a command while
+// it's being read.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// Bytes returns a copy of the buffer's contents.
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// countingWriter is an io.Writer that counts the bytes written through it
+// to another writer, so they can be counted while it's being written to.
+type countingWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddInt64(&c.count, int64(n))
+	return n, err
+}
+
+// Count returns the number of bytes written so far.
+func (c *countingWriter) Count() int64 {
+	return atomic.LoadInt64(&c.count)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podvolume
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError("unable to create lock in backend: repository is already locked by PID 42 on velero-abc"))
+	assert.True(t, IsTransientError("Save(<data/1234>) returned error, retrying after 552ms: Put https://bucket.s3.amazonaws.com/data: read tcp: connection reset by peer"))
+	assert.False(t, IsTransientError("Fatal: wrong password or no key found"))
+	assert.False(t, IsTransientError(""))
+}
+
+func TestLastCompleteLine(t *testing.T) {
+	assert.Nil(t, lastCompleteLine(nil))
+	assert.Nil(t, lastCompleteLine([]byte(`{"message_type":"status"`)))
+	assert.Equal(t, `{"message_type":"status","bytes_done":1}`, string(lastCompleteLine([]byte(`{"message_type":"status","bytes_done":1}`+"\n"))))
+
+	// a line that's still being written is ignored.
+	output := `{"message_type":"status","bytes_done":1}` + "\n" + `{"message_type":"status","bytes_done":2}` + "\n" + `{"message_type":"sta`
+	assert.Equal(t, `{"message_type":"status","bytes_done":2}`, string(lastCompleteLine([]byte(output))))
+}
+
+func TestGetVolumeSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "restic-volume")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "subdir"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file-1"), make([]byte, 10), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "subdir", "file-2"), make([]byte, 5), 0644))
+
+	size, err := getVolumeSize(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), size)
+
+	_, err = getVolumeSize(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestCountingWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := &countingWriter{w: buf}
+
+	_, err := w.Write([]byte("abc"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("de"))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(5), w.Count())
+	assert.Equal(t, "abcde", buf.String())
+}
+
+func TestGetBackupChanges(t *testing.T) {
+	summary := `{"message_type":"summary","files_new":2,"files_changed":3,"files_unmodified":10,"data_added":1024,"total_bytes_processed":4096}`
+
+	assert.Equal(t, velerov1api.PodVolumeBackupChanges{FilesChanged: 5, FilesUnmodified: 10, BytesAdded: 1024}, getBackupChanges(summary, velerotest.NewLogger()))
+	assert.Equal(t, velerov1api.PodVolumeBackupChanges{}, getBackupChanges("not json", velerotest.NewLogger()))
+}
@@ -0,0 +1,378 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podvolume
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/cloudprovider/azure"
+	"github.com/heptio/velero/pkg/persistence"
+	veleroexec "github.com/heptio/velero/pkg/util/exec"
+)
+
+// kopiaUploader backs up pod volumes with kopia. The repository of a volume
+// namespace is stored under the kopia/<namespace>/ prefix of the backup
+// storage location. kopia creates it if it doesn't exist, and maintains it
+// itself.
+type kopiaUploader struct {
+	storageArgs  []string
+	env          []string
+	passwordFile string
+}
+
+func newKopiaUploader(location *velerov1api.BackupStorageLocation, volumeNamespace, passwordFile string) (*kopiaUploader, error) {
+	storageArgs, env, err := kopiaStorage(location, volumeNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kopiaUploader{
+		storageArgs:  storageArgs,
+		env:          env,
+		passwordFile: passwordFile,
+	}, nil
+}
+
+// kopiaStorage returns the arguments of the kopia repository create and
+// connect commands that specify where the repository of a volume namespace
+// is stored in a backup storage location, and the environment to run kopia
+// commands against it with.
+func kopiaStorage(location *velerov1api.BackupStorageLocation, volumeNamespace string) ([]string, []string, error) {
+	env := os.Environ()
+
+	if location.Spec.Filesystem != nil {
+		layout := persistence.NewObjectStoreLayout(expandPrefix(location.Spec.Filesystem.Prefix))
+		return []string{"filesystem", "--path=" + path.Join(location.Spec.Filesystem.Path, layout.GetKopiaDir(), volumeNamespace)}, env, nil
+	}
+
+	if location.Spec.ObjectStorage == nil {
+		return nil, nil, errors.New("backup storage location has no object storage")
+	}
+
+	layout := persistence.NewObjectStoreLayout(expandPrefix(location.Spec.ObjectStorage.Prefix))
+	bucket := location.Spec.ObjectStorage.Bucket
+	prefix := path.Join(layout.GetKopiaDir(), volumeNamespace) + "/"
+
+	provider := location.Spec.Provider
+	if !strings.Contains(provider, "/") {
+		provider = "velero.io/" + provider
+	}
+
+	switch BackendType(provider) {
+	case AWSBackend:
+		args := []string{"s3", "--bucket=" + bucket, "--prefix=" + prefix}
+
+		endpoint := "s3.amazonaws.com"
+		if s3URL := location.Spec.Config["s3Url"]; s3URL != "" {
+			u, err := url.Parse(s3URL)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "error parsing s3Url %q", s3URL)
+			}
+			endpoint = u.Host
+			if u.Scheme == "http" {
+				args = append(args, "--disable-tls")
+			}
+		}
+		args = append(args, "--endpoint="+endpoint)
+
+		if region := location.Spec.Config["region"]; region != "" {
+			args = append(args, "--region="+region)
+		}
+
+		return args, env, nil
+	case GCPBackend:
+		args := []string{"gcs", "--bucket=" + bucket, "--prefix=" + prefix}
+		if credentialsFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); credentialsFile != "" {
+			args = append(args, "--credentials-file="+credentialsFile)
+		}
+
+		return args, env, nil
+	case AzureBackend:
+		azureVars, err := azure.GetResticEnvVars(location.Spec.Config)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error getting azure storage account key")
+		}
+
+		args := []string{"azure", "--container=" + bucket, "--prefix=" + prefix, "--storage-account=" + azureVars["AZURE_ACCOUNT_NAME"]}
+		env = append(env, "AZURE_STORAGE_KEY="+azureVars["AZURE_ACCOUNT_KEY"])
+
+		return args, env, nil
+	default:
+		return nil, nil, errors.Errorf("backup storage location provider %q isn't supported by kopia", location.Spec.Provider)
+	}
+}
+
+// kopiaSnapshot is the part of the manifest of a kopia snapshot that's
+// printed by 'kopia snapshot create --json' that Velero uses.
+type kopiaSnapshot struct {
+	ID    string `json:"id"`
+	Stats struct {
+		TotalSize      int64 `json:"totalSize"`
+		CachedFiles    int   `json:"cachedFiles"`
+		NonCachedFiles int   `json:"nonCachedFiles"`
+	} `json:"stats"`
+}
+
+// kopiaSnapshotSource is the source of a kopia snapshot, as printed by
+// 'kopia snapshot list --json'.
+type kopiaSnapshotSource struct {
+	Host     string `json:"host"`
+	UserName string `json:"userName"`
+	Path     string `json:"path"`
+}
+
+func (s kopiaSnapshotSource) String() string {
+	return fmt.Sprintf("%s@%s:%s", s.UserName, s.Host, s.Path)
+}
+
+// Backup backs up the directory at path. kopia reuses the hashes of
+// unmodified files from the previous snapshot of the same source, and only
+// uploads content the repository doesn't already have. A volume's path
+// contains its pod's UID, so it changes when the pod is recreated; the
+// snapshot is taken as the source of the parent snapshot, if there is one,
+// so that kopia finds it.
+func (u *kopiaUploader) Backup(path, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (BackupResult, error) {
+	return u.createSnapshot(nil, []string{path}, parentSnapshotID, tags, log, updateFunc)
+}
+
+// createSnapshot runs a kopia snapshot create command of source, reading
+// stdin if it's non-nil, and returns the snapshot's ID. If parentSnapshotID
+// is set, the snapshot is taken as the parent snapshot's source.
+func (u *kopiaUploader) createSnapshot(stdin io.Reader, source []string, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (BackupResult, error) {
+	var result BackupResult
+
+	err := u.withRepo(log, func(run kopiaRunner) error {
+		args := append([]string{"snapshot", "create"}, source...)
+		args = append(args, "--json")
+		args = append(args, kopiaTagFlags(tags)...)
+
+		if parentSnapshotID != "" {
+			// without the parent's source the snapshot is still taken,
+			// but kopia reads all of the volume's files again.
+			parentSource, err := getKopiaSnapshotSource(run, parentSnapshotID)
+			if err != nil {
+				log.WithError(err).WithField("parentSnapshotID", parentSnapshotID).Warn("Error getting source of parent snapshot")
+			} else {
+				args = append(args, "--override-source="+parentSource.String())
+			}
+		}
+
+		stdout, err := run(stdin, nil, args...)
+		if err != nil {
+			return errors.Wrap(err, "error running kopia snapshot create")
+		}
+
+		var snapshot kopiaSnapshot
+		if err := json.Unmarshal([]byte(stdout), &snapshot); err != nil {
+			return errors.Wrapf(err, "error parsing kopia snapshot create output, stdout=%s", stdout)
+		}
+
+		// kopia doesn't report progress in a form that can be parsed, so
+		// the volume's progress is only updated once it's backed up.
+		updateFunc(velerov1api.PodVolumeOperationProgress{
+			TotalBytes: snapshot.Stats.TotalSize,
+			BytesDone:  snapshot.Stats.TotalSize,
+		})
+
+		// kopia doesn't report the size of the content it uploaded, only
+		// how many files it had to read again.
+		result = BackupResult{
+			SnapshotID: snapshot.ID,
+			Changes: velerov1api.PodVolumeBackupChanges{
+				FilesChanged:    snapshot.Stats.NonCachedFiles,
+				FilesUnmodified: snapshot.Stats.CachedFiles,
+			},
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func (u *kopiaUploader) Restore(snapshotID, path string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) error {
+	return u.withRepo(log, func(run kopiaRunner) error {
+		if _, err := run(nil, nil, "snapshot", "restore", snapshotID, path); err != nil {
+			return errors.Wrap(err, "error running kopia snapshot restore")
+		}
+		return nil
+	})
+}
+
+func (u *kopiaUploader) BackupBlock(devicePath, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (BackupResult, error) {
+	device, err := os.Open(devicePath)
+	if err != nil {
+		return BackupResult{}, errors.Wrap(err, "error opening block device")
+	}
+	defer device.Close()
+
+	// '-' snapshots stdin as a file named by --stdin-file.
+	// the source of a stdin snapshot doesn't depend on the device's path,
+	// so the parent's source doesn't need to be looked up.
+	return u.createSnapshot(device, []string{"-", "--stdin-file=" + blockDeviceFilename}, "", tags, log, updateFunc)
+}
+
+func (u *kopiaUploader) RestoreBlock(snapshotID, devicePath string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) error {
+	device, err := os.OpenFile(devicePath, os.O_WRONLY, 0)
+	if err != nil {
+		return errors.Wrap(err, "error opening block device")
+	}
+	defer device.Close()
+
+	return u.withRepo(log, func(run kopiaRunner) error {
+		if _, err := run(nil, device, "show", snapshotID+"/"+blockDeviceFilename); err != nil {
+			return errors.Wrap(err, "error running kopia show")
+		}
+		return nil
+	})
+}
+
+// Forget deletes the snapshot with the given ID from the repository.
+func (u *kopiaUploader) Forget(snapshotID string, log logrus.FieldLogger) error {
+	return u.withRepo(log, func(run kopiaRunner) error {
+		if _, err := run(nil, nil, "snapshot", "delete", snapshotID, "--delete"); err != nil {
+			return errors.Wrap(err, "error running kopia snapshot delete")
+		}
+		return nil
+	})
+}
+
+// kopiaRunner runs a kopia command against a connected repository, with
+// stdin as its standard input if it's non-nil. If stdout is non-nil, the
+// command's stdout is written to it, otherwise it's returned.
+type kopiaRunner func(stdin io.Reader, stdout io.Writer, args ...string) (string, error)
+
+// withRepo connects to the repository, creating it if it doesn't exist,
+// with a temporary kopia config, and calls f to run commands against it.
+func (u *kopiaUploader) withRepo(log logrus.FieldLogger, f func(kopiaRunner) error) error {
+	password, err := ioutil.ReadFile(u.passwordFile)
+	if err != nil {
+		return errors.Wrap(err, "error reading repository password")
+	}
+
+	configDir, err := ioutil.TempDir("", "kopia")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// ignore error since there's nothing we can do and it's a temp dir.
+	defer os.RemoveAll(configDir)
+
+	env := append(append([]string{}, u.env...),
+		"KOPIA_PASSWORD="+string(password),
+		"KOPIA_CHECK_FOR_UPDATES=false",
+	)
+
+	run := func(stdin io.Reader, stdout io.Writer, args ...string) (string, error) {
+		cmd := exec.Command("kopia", append(args, "--config-file="+filepath.Join(configDir, "repository.config"))...)
+		cmd.Env = env
+		cmd.Stdin = stdin
+
+		if stdout != nil {
+			stderrBuf := new(bytes.Buffer)
+			cmd.Stdout = stdout
+			cmd.Stderr = stderrBuf
+
+			err := cmd.Run()
+			log.Debugf("Ran command=kopia %s, stderr=%s", strings.Join(args, " "), stderrBuf.String())
+			if err != nil {
+				return "", errors.Wrapf(err, "stderr=%s", stderrBuf.String())
+			}
+			return "", nil
+		}
+
+		stdoutStr, stderr, err := veleroexec.RunCommand(cmd)
+		log.Debugf("Ran command=kopia %s, stdout=%s, stderr=%s", strings.Join(args, " "), stdoutStr, stderr)
+		if err != nil {
+			return stdoutStr, errors.Wrapf(err, "stderr=%s", stderr)
+		}
+		return stdoutStr, nil
+	}
+
+	// use the same user and host for all snapshots, so kopia finds
+	// previous snapshots of a volume from any node and its maintenance
+	// is owned by one user.
+	connectArgs := append(append([]string{}, u.storageArgs...), "--override-username=velero", "--override-hostname=velero")
+	if scratch := os.Getenv("VELERO_SCRATCH_DIR"); scratch != "" {
+		connectArgs = append(connectArgs, "--cache-directory="+filepath.Join(scratch, ".cache", "kopia"))
+	}
+
+	if _, err := run(nil, nil, append([]string{"repository", "connect"}, connectArgs...)...); err != nil {
+		if !strings.Contains(err.Error(), "repository not initialized") {
+			return errors.Wrap(err, "error connecting to kopia repository")
+		}
+
+		if _, err := run(nil, nil, append([]string{"repository", "create"}, connectArgs...)...); err != nil {
+			return errors.Wrap(err, "error creating kopia repository")
+		}
+	}
+
+	return f(run)
+}
+
+// getKopiaSnapshotSource returns the source of the snapshot with the given
+// ID.
+func getKopiaSnapshotSource(run kopiaRunner, snapshotID string) (kopiaSnapshotSource, error) {
+	stdout, err := run(nil, nil, "snapshot", "list", "--all", "--json")
+	if err != nil {
+		return kopiaSnapshotSource{}, errors.Wrap(err, "error running kopia snapshot list")
+	}
+
+	return parseKopiaSnapshotSource(stdout, snapshotID)
+}
+
+// parseKopiaSnapshotSource returns the source of the snapshot with the given
+// ID from the output of 'kopia snapshot list --json'.
+func parseKopiaSnapshotSource(stdout, snapshotID string) (kopiaSnapshotSource, error) {
+	var snapshots []struct {
+		ID     string              `json:"id"`
+		Source kopiaSnapshotSource `json:"source"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &snapshots); err != nil {
+		return kopiaSnapshotSource{}, errors.Wrapf(err, "error parsing kopia snapshot list output, stdout=%s", stdout)
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.ID == snapshotID {
+			return snapshot.Source, nil
+		}
+	}
+
+	return kopiaSnapshotSource{}, errors.Errorf("snapshot %s not found", snapshotID)
+}
+
+func kopiaTagFlags(tags map[string]string) []string {
+	var flags []string
+	for k, v := range tags {
+		flags = append(flags, fmt.Sprintf("--tags=%s:%s", k, v))
+	}
+	sort.Strings(flags)
+	return flags
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podvolume
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+func TestKopiaStorage(t *testing.T) {
+	objectStorageLocation := func(provider string, config map[string]string) *velerov1api.BackupStorageLocation {
+		return &velerov1api.BackupStorageLocation{
+			Spec: velerov1api.BackupStorageLocationSpec{
+				Provider: provider,
+				StorageType: velerov1api.StorageType{
+					ObjectStorage: &velerov1api.ObjectStorageLocation{
+						Bucket: "bucket",
+						Prefix: "prefix",
+					},
+				},
+				Config: config,
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		location    *velerov1api.BackupStorageLocation
+		credentials string
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:     "aws location uses the default S3 endpoint",
+			location: objectStorageLocation("aws", nil),
+			want:     []string{"s3", "--bucket=bucket", "--prefix=prefix/kopia/ns-1/", "--endpoint=s3.amazonaws.com"},
+		},
+		{
+			name:     "aws location with an http s3Url and a region disables TLS and sets the region",
+			location: objectStorageLocation("velero.io/aws", map[string]string{"s3Url": "http://minio.velero.svc:9000", "region": "minio"}),
+			want:     []string{"s3", "--bucket=bucket", "--prefix=prefix/kopia/ns-1/", "--disable-tls", "--endpoint=minio.velero.svc:9000", "--region=minio"},
+		},
+		{
+			name:        "gcp location uses the server's credentials file",
+			location:    objectStorageLocation("gcp", nil),
+			credentials: "/credentials/cloud",
+			want:        []string{"gcs", "--bucket=bucket", "--prefix=prefix/kopia/ns-1/", "--credentials-file=/credentials/cloud"},
+		},
+		{
+			name: "filesystem location stores the repository under the directory",
+			location: &velerov1api.BackupStorageLocation{
+				Spec: velerov1api.BackupStorageLocationSpec{
+					StorageType: velerov1api.StorageType{
+						Filesystem: &velerov1api.FilesystemLocation{
+							Path:   "/mnt/backups",
+							Prefix: "prefix",
+						},
+					},
+				},
+			},
+			want: []string{"filesystem", "--path=/mnt/backups/prefix/kopia/ns-1"},
+		},
+		{
+			name:     "unsupported provider returns an error",
+			location: objectStorageLocation("example.com/unsupported", nil),
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.credentials != "" {
+				os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", tc.credentials)
+				defer os.Unsetenv("GOOGLE_APPLICATION_CREDENTIALS")
+			}
+
+			args, _, err := kopiaStorage(tc.location, "ns-1")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, args)
+		})
+	}
+}
+
+func TestKopiaTagFlags(t *testing.T) {
+	tags := map[string]string{
+		"volume": "pvc-1",
+		"backup": "backup-1",
+		"pod":    "pod-1",
+	}
+
+	assert.Equal(t, []string{"--tags=backup:backup-1", "--tags=pod:pod-1", "--tags=volume:pvc-1"}, kopiaTagFlags(tags))
+}
+
+func TestParseKopiaSnapshotSource(t *testing.T) {
+	stdout := `[
+  {"id": "k1", "source": {"host": "velero", "userName": "velero", "path": "/host_pods/uid-1/volumes/kubernetes.io~empty-dir/data"}},
+  {"id": "k2", "source": {"host": "velero", "userName": "velero", "path": "/host_pods/uid-2/volumes/kubernetes.io~empty-dir/data"}}
+]`
+
+	source, err := parseKopiaSnapshotSource(stdout, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "velero@velero:/host_pods/uid-1/volumes/kubernetes.io~empty-dir/data", source.String())
+
+	_, err = parseKopiaSnapshotSource(stdout, "k3")
+	assert.EqualError(t, err, "snapshot k3 not found")
+
+	_, err = parseKopiaSnapshotSource("not json", "k1")
+	assert.Error(t, err)
+}
@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package restic
+package podvolume
 
 import (
 	"context"
@@ -83,7 +83,7 @@ func newRepositoryEnsurer(repoInformer velerov1informers.ResticRepositoryInforme
 				r.repoChansLock.Lock()
 				defer r.repoChansLock.Unlock()
 
-				key := repoLabels(newObj.Spec.VolumeNamespace, newObj.Spec.BackupStorageLocation).String()
+				key := RepoLabels(newObj.Spec.VolumeNamespace, newObj.Spec.BackupStorageLocation).String()
 				repoChan, ok := r.repoChans[key]
 				if !ok {
 					log.Debugf("No ready channel found for repository %s/%s", newObj.Namespace, newObj.Name)
@@ -98,13 +98,31 @@ func newRepositoryEnsurer(repoInformer velerov1informers.ResticRepositoryInforme
 	return r
 }
 
-func repoLabels(volumeNamespace, backupLocation string) labels.Set {
+// RepoLabels returns the labels that identify the ResticRepository for a
+// workload namespace and backup storage location.
+func RepoLabels(volumeNamespace, backupLocation string) labels.Set {
 	return map[string]string{
 		velerov1api.ResticVolumeNamespaceLabel: label.GetValidName(volumeNamespace),
 		velerov1api.StorageLocationLabel:       label.GetValidName(backupLocation),
 	}
 }
 
+// NewRepository returns a new ResticRepository for a workload namespace's
+// repository in a backup storage location.
+func NewRepository(namespace, volumeNamespace, backupLocation string) *velerov1api.ResticRepository {
+	return &velerov1api.ResticRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    namespace,
+			GenerateName: fmt.Sprintf("%s-%s-", volumeNamespace, backupLocation),
+			Labels:       RepoLabels(volumeNamespace, backupLocation),
+		},
+		Spec: velerov1api.ResticRepositorySpec{
+			VolumeNamespace:       volumeNamespace,
+			BackupStorageLocation: backupLocation,
+		},
+	}
+}
+
 func (r *repositoryEnsurer) EnsureRepo(ctx context.Context, namespace, volumeNamespace, backupLocation string) (*velerov1api.ResticRepository, error) {
 	log := r.log.WithField("volumeNamespace", volumeNamespace).WithField("backupLocation", backupLocation)
 
@@ -130,7 +148,7 @@ func (r *repositoryEnsurer) EnsureRepo(ctx context.Context, namespace, volumeNam
 
 	log.Debug("Acquired lock")
 
-	selector := labels.SelectorFromSet(repoLabels(volumeNamespace, backupLocation))
+	selector := labels.SelectorFromSet(RepoLabels(volumeNamespace, backupLocation))
 
 	repos, err := r.repoLister.ResticRepositories(namespace).List(selector)
 	if err != nil {
@@ -151,18 +169,7 @@ func (r *repositoryEnsurer) EnsureRepo(ctx context.Context, namespace, volumeNam
 	log.Debug("No repository found, creating one")
 
 	// no repo found: create one and wait for it to be ready
-	repo := &velerov1api.ResticRepository{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:    namespace,
-			GenerateName: fmt.Sprintf("%s-%s-", volumeNamespace, backupLocation),
-			Labels:       repoLabels(volumeNamespace, backupLocation),
-		},
-		Spec: velerov1api.ResticRepositorySpec{
-			VolumeNamespace:       volumeNamespace,
-			BackupStorageLocation: backupLocation,
-			MaintenanceFrequency:  metav1.Duration{Duration: DefaultMaintenanceFrequency},
-		},
-	}
+	repo := NewRepository(namespace, volumeNamespace, backupLocation)
 
 	repoChan := r.getRepoChan(selector.String())
 	defer func() {
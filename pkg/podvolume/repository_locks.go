@@ -0,0 +1,68 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podvolume
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+// StaleLockTimeout is how long after it was last refreshed restic
+// considers a lock to be stale, so that `restic unlock` removes it.
+const StaleLockTimeout = 30 * time.Minute
+
+// resticLock is the JSON representation of a lock printed by
+// `restic cat lock`.
+type resticLock struct {
+	Time      time.Time `json:"time"`
+	Exclusive bool      `json:"exclusive"`
+	Hostname  string    `json:"hostname"`
+	PID       int       `json:"pid"`
+}
+
+// parseLockIDs parses the output of `restic list locks`.
+func parseLockIDs(stdout string) []string {
+	var ids []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if id := strings.TrimSpace(line); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseLock parses the output of `restic cat lock`.
+func parseLock(id, stdout string) (velerov1api.ResticRepositoryLock, error) {
+	var lock resticLock
+	if err := json.Unmarshal([]byte(stdout), &lock); err != nil {
+		return velerov1api.ResticRepositoryLock{}, errors.Wrapf(err, "error parsing lock %s", id)
+	}
+
+	return velerov1api.ResticRepositoryLock{
+		ID:        id,
+		Exclusive: lock.Exclusive,
+		Hostname:  lock.Hostname,
+		PID:       lock.PID,
+		Time:      metav1.NewTime(lock.Time),
+	}, nil
+}
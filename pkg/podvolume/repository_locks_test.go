@@ -0,0 +1,48 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podvolume
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+func TestParseLockIDs(t *testing.T) {
+	assert.Nil(t, parseLockIDs(""))
+	assert.Equal(t, []string{"lock-1", "lock-2"}, parseLockIDs("lock-1\nlock-2\n"))
+}
+
+func TestParseLock(t *testing.T) {
+	lock, err := parseLock("lock-1", `{"time":"2019-10-01T12:00:00.123456789Z","exclusive":true,"hostname":"velero-abc","username":"root","pid":42,"uid":0,"gid":0}`)
+	require.NoError(t, err)
+	assert.Equal(t, velerov1api.ResticRepositoryLock{
+		ID:        "lock-1",
+		Exclusive: true,
+		Hostname:  "velero-abc",
+		PID:       42,
+		Time:      metav1.NewTime(time.Date(2019, 10, 1, 12, 0, 0, 123456789, time.UTC)),
+	}, lock)
+
+	_, err = parseLock("lock-2", "not json")
+	assert.Error(t, err)
+}
@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package restic
+package podvolume
 
 import (
 	"context"
@@ -58,6 +58,13 @@ type RepositoryManager interface {
 	// UnlockRepo removes stale locks from a repo.
 	UnlockRepo(repo *velerov1api.ResticRepository) error
 
+	// RemoveAllRepoLocks removes all locks from a repo, including the
+	// ones held by running restic processes.
+	RemoveAllRepoLocks(repo *velerov1api.ResticRepository) error
+
+	// GetRepoLocks returns the locks currently held on a repo.
+	GetRepoLocks(repo *velerov1api.ResticRepository) ([]velerov1api.ResticRepositoryLock, error)
+
 	// Forget removes a snapshot from the list of
 	// available snapshots in a repo.
 	Forget(context.Context, SnapshotIdentifier) error
@@ -224,6 +231,44 @@ func (rm *repositoryManager) UnlockRepo(repo *velerov1api.ResticRepository) erro
 	return rm.exec(UnlockCommand(repo.Spec.ResticIdentifier), repo.Spec.BackupStorageLocation)
 }
 
+func (rm *repositoryManager) RemoveAllRepoLocks(repo *velerov1api.ResticRepository) error {
+	// restic unlock requires a non-exclusive lock
+	rm.repoLocker.Lock(repo.Name)
+	defer rm.repoLocker.Unlock(repo.Name)
+
+	unlockCmd := UnlockCommand(repo.Spec.ResticIdentifier)
+	unlockCmd.ExtraFlags = append(unlockCmd.ExtraFlags, "--remove-all")
+
+	return rm.exec(unlockCmd, repo.Spec.BackupStorageLocation)
+}
+
+func (rm *repositoryManager) GetRepoLocks(repo *velerov1api.ResticRepository) ([]velerov1api.ResticRepositoryLock, error) {
+	// the lock commands don't lock the repo, so they don't need to
+	// take a lock here either.
+	stdout, err := rm.run(ListLocksCommand(repo.Spec.ResticIdentifier), repo.Spec.BackupStorageLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	var locks []velerov1api.ResticRepositoryLock
+	for _, id := range parseLockIDs(stdout) {
+		stdout, err := rm.run(CatLockCommand(repo.Spec.ResticIdentifier, id), repo.Spec.BackupStorageLocation)
+		if err != nil {
+			// the lock may have been removed since it was listed.
+			rm.log.WithError(err).WithField("lock", id).Debug("Error getting restic repository lock")
+			continue
+		}
+
+		lock, err := parseLock(id, stdout)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+
+	return locks, nil
+}
+
 func (rm *repositoryManager) Forget(ctx context.Context, snapshot SnapshotIdentifier) error {
 	// We can't wait for this in the constructor, because this informer is coming
 	// from the shared informer factory, which isn't started until *after* the repo
@@ -233,6 +278,10 @@ func (rm *repositoryManager) Forget(ctx context.Context, snapshot SnapshotIdenti
 		return errors.New("timed out waiting for cache to sync")
 	}
 
+	if snapshot.UploaderType == velerov1api.UploaderTypeKopia {
+		return rm.forgetKopiaSnapshot(snapshot)
+	}
+
 	repo, err := rm.repoEnsurer.EnsureRepo(ctx, rm.namespace, snapshot.VolumeNamespace, snapshot.BackupStorageLocation)
 	if err != nil {
 		return err
@@ -245,10 +294,42 @@ func (rm *repositoryManager) Forget(ctx context.Context, snapshot SnapshotIdenti
 	return rm.exec(ForgetCommand(repo.Spec.ResticIdentifier, snapshot.SnapshotID), repo.Spec.BackupStorageLocation)
 }
 
+// forgetKopiaSnapshot deletes a kopia snapshot from its repository.
+func (rm *repositoryManager) forgetKopiaSnapshot(snapshot SnapshotIdentifier) error {
+	if !cache.WaitForCacheSync(rm.ctx.Done(), rm.backupLocationInformerSynced) {
+		return errors.New("timed out waiting for cache to sync")
+	}
+
+	location, err := rm.backupLocationLister.BackupStorageLocations(rm.namespace).Get(snapshot.BackupStorageLocation)
+	if err != nil {
+		return errors.Wrap(err, "error getting backup storage location")
+	}
+
+	file, err := TempCredentialsFile(rm.secretsLister, rm.namespace, snapshot.VolumeNamespace, rm.fileSystem)
+	if err != nil {
+		return err
+	}
+	// ignore error since there's nothing we can do and it's a temp file.
+	defer os.Remove(file)
+
+	uploader, err := newKopiaUploader(location, snapshot.VolumeNamespace, file)
+	if err != nil {
+		return err
+	}
+
+	return uploader.Forget(snapshot.SnapshotID, rm.log)
+}
+
 func (rm *repositoryManager) exec(cmd *Command, backupLocation string) error {
+	_, err := rm.run(cmd, backupLocation)
+	return err
+}
+
+// run runs a restic command against a repo and returns its stdout.
+func (rm *repositoryManager) run(cmd *Command, backupLocation string) (string, error) {
 	file, err := TempCredentialsFile(rm.secretsLister, rm.namespace, cmd.RepoName(), rm.fileSystem)
 	if err != nil {
-		return err
+		return "", err
 	}
 	// ignore error since there's nothing we can do and it's a temp file.
 	defer os.Remove(file)
@@ -257,12 +338,12 @@ func (rm *repositoryManager) exec(cmd *Command, backupLocation string) error {
 
 	if strings.HasPrefix(cmd.RepoIdentifier, "azure") {
 		if !cache.WaitForCacheSync(rm.ctx.Done(), rm.backupLocationInformerSynced) {
-			return errors.New("timed out waiting for cache to sync")
+			return "", errors.New("timed out waiting for cache to sync")
 		}
 
 		env, err := AzureCmdEnv(rm.backupLocationLister, rm.namespace, backupLocation)
 		if err != nil {
-			return err
+			return "", err
 		}
 		cmd.Env = env
 	}
@@ -275,8 +356,8 @@ func (rm *repositoryManager) exec(cmd *Command, backupLocation string) error {
 		"stderr":     stderr,
 	}).Debugf("Ran restic command")
 	if err != nil {
-		return errors.Wrapf(err, "error running command=%s, stdout=%s, stderr=%s", cmd.String(), stdout, stderr)
+		return "", errors.Wrapf(err, "error running command=%s, stdout=%s, stderr=%s", cmd.String(), stdout, stderr)
 	}
 
-	return nil
+	return stdout, nil
 }
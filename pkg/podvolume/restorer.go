@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package restic
+package podvolume
 
 import (
 	"context"
@@ -97,15 +97,31 @@ func (r *restorer) RestorePodVolumes(data RestoreData) []error {
 		return nil
 	}
 
-	repo, err := r.repoEnsurer.EnsureRepo(r.ctx, data.Restore.Namespace, data.SourceNamespace, data.BackupLocation)
-	if err != nil {
-		return []error{err}
+	uploaderTypes := getUploaderTypes(data.PodVolumeBackups, data.Pod)
+	blockVolumes := GetBlockVolumesForPod(data.PodVolumeBackups, data.Pod)
+
+	// only volumes backed up by restic need a restic repository.
+	var usesRestic bool
+	for _, uploaderType := range uploaderTypes {
+		if uploaderType == velerov1api.UploaderTypeRestic {
+			usesRestic = true
+		}
 	}
 
-	// get a single non-exclusive lock since we'll wait for all individual
-	// restores to be complete before releasing it.
-	r.repoManager.repoLocker.Lock(repo.Name)
-	defer r.repoManager.repoLocker.Unlock(repo.Name)
+	var repoIdentifier string
+	if usesRestic {
+		repo, err := r.repoEnsurer.EnsureRepo(r.ctx, data.Restore.Namespace, data.SourceNamespace, data.BackupLocation)
+		if err != nil {
+			return []error{err}
+		}
+
+		// get a single non-exclusive lock since we'll wait for all individual
+		// restores to be complete before releasing it.
+		r.repoManager.repoLocker.Lock(repo.Name)
+		defer r.repoManager.repoLocker.Unlock(repo.Name)
+
+		repoIdentifier = repo.Spec.ResticIdentifier
+	}
 
 	resultsChan := make(chan *velerov1api.PodVolumeRestore)
 
@@ -119,7 +135,10 @@ func (r *restorer) RestorePodVolumes(data RestoreData) []error {
 	)
 
 	for volume, snapshot := range volumesToRestore {
-		volumeRestore := newPodVolumeRestore(data.Restore, data.Pod, data.BackupLocation, volume, snapshot, repo.Spec.ResticIdentifier)
+		volumeRestore := newPodVolumeRestore(data.Restore, data.Pod, data.BackupLocation, volume, snapshot, repoIdentifier, uploaderTypes[volume])
+		if blockVolumes[volume] {
+			volumeRestore.Spec.VolumeMode = corev1api.PersistentVolumeBlock
+		}
 
 		if err := errorOnly(r.repoManager.veleroClient.VeleroV1().PodVolumeRestores(volumeRestore.Namespace).Create(volumeRestore)); err != nil {
 			errs = append(errs, errors.WithStack(err))
@@ -148,7 +167,26 @@ ForEachVolume:
 	return errs
 }
 
-func newPodVolumeRestore(restore *velerov1api.Restore, pod *corev1api.Pod, backupLocation, volume, snapshot, repoIdentifier string) *velerov1api.PodVolumeRestore {
+// getUploaderTypes returns a map, of volume name -> uploader type, of the
+// uploaders that backed up the volumes of the PodVolumeBackups that exist
+// for the provided pod. Volumes whose snapshots are recorded in the pod's
+// annotations were backed up by restic.
+func getUploaderTypes(podVolumeBackups []*velerov1api.PodVolumeBackup, pod metav1.Object) map[string]velerov1api.UploaderType {
+	res := make(map[string]velerov1api.UploaderType)
+	for volume := range GetVolumeBackupsForPod(podVolumeBackups, pod) {
+		res[volume] = velerov1api.UploaderTypeRestic
+	}
+
+	for _, pvb := range podVolumeBackups {
+		if pod.GetName() == pvb.Spec.Pod.Name && pvb.Spec.UploaderType != "" {
+			res[pvb.Spec.Volume] = pvb.Spec.UploaderType
+		}
+	}
+
+	return res
+}
+
+func newPodVolumeRestore(restore *velerov1api.Restore, pod *corev1api.Pod, backupLocation, volume, snapshot, repoIdentifier string, uploaderType velerov1api.UploaderType) *velerov1api.PodVolumeRestore {
 	return &velerov1api.PodVolumeRestore{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    restore.Namespace,
@@ -179,6 +217,7 @@ func newPodVolumeRestore(restore *velerov1api.Restore, pod *corev1api.Pod, backu
 			SnapshotID:            snapshot,
 			BackupStorageLocation: backupLocation,
 			RepoIdentifier:        repoIdentifier,
+			UploaderType:          uploaderType,
 		},
 	}
 }
@@ -0,0 +1,188 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podvolume
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+// blockDeviceFilename is the name that the content of a block device is
+// stored under in its snapshot.
+const blockDeviceFilename = "block-device"
+
+// Uploader backs up the contents of pod volumes into a repository in a
+// backup storage location, and restores them from it.
+type Uploader interface {
+	// Backup backs up the directory at path into the repository, tagging
+	// the snapshot with tags. If parentSnapshotID is non-empty, the backup
+	// is incremental from that snapshot of the volume's previous backup,
+	// so only files that changed since it are read and uploaded. The
+	// result's snapshot ID is empty if the directory was empty and no
+	// snapshot was taken.
+	Backup(path, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (BackupResult, error)
+
+	// Restore restores the snapshot with the given ID from the repository
+	// into the directory at path.
+	Restore(snapshotID, path string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) error
+
+	// BackupBlock backs up the raw content of the block device at
+	// devicePath into the repository, tagging the snapshot with tags,
+	// incrementally from parentSnapshotID if it's non-empty.
+	BackupBlock(devicePath, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (BackupResult, error)
+
+	// RestoreBlock writes the content of the block device snapshot with
+	// the given ID from the repository to the block device at devicePath.
+	RestoreBlock(snapshotID, devicePath string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) error
+}
+
+// BackupResult is the result of backing up a volume with an Uploader.
+type BackupResult struct {
+	// SnapshotID is the ID of the volume's snapshot.
+	SnapshotID string
+
+	// Changes holds how much of the volume changed since the parent
+	// snapshot, or since nothing for full backups.
+	Changes velerov1api.PodVolumeBackupChanges
+}
+
+// NewUploader returns an Uploader of the given type for the repository of a
+// volume namespace in a backup storage location. repoIdentifier is the
+// restic repository identifier, which only restic uploaders use, and
+// passwordFile is the path of a file containing the repository's password.
+func NewUploader(uploaderType velerov1api.UploaderType, location *velerov1api.BackupStorageLocation, volumeNamespace, repoIdentifier, passwordFile string) (Uploader, error) {
+	switch uploaderType {
+	case "", velerov1api.UploaderTypeRestic:
+		var env []string
+		if strings.HasPrefix(repoIdentifier, "azure") {
+			var err error
+			if env, err = azureCmdEnv(location); err != nil {
+				return nil, errors.Wrap(err, "error setting restic cmd env")
+			}
+		}
+
+		return &resticUploader{
+			repoIdentifier: repoIdentifier,
+			passwordFile:   passwordFile,
+			env:            env,
+		}, nil
+	case velerov1api.UploaderTypeKopia:
+		return newKopiaUploader(location, volumeNamespace, passwordFile)
+	default:
+		return nil, errors.Errorf("unsupported uploader type %q", uploaderType)
+	}
+}
+
+// GetUploaderType returns the type of the uploader that backs up pod
+// volumes into a backup storage location.
+func GetUploaderType(location *velerov1api.BackupStorageLocation) velerov1api.UploaderType {
+	if location.Spec.UploaderType == "" {
+		return velerov1api.UploaderTypeRestic
+	}
+	return location.Spec.UploaderType
+}
+
+// resticUploader backs up pod volumes with restic.
+type resticUploader struct {
+	repoIdentifier string
+	passwordFile   string
+	env            []string
+}
+
+func (u *resticUploader) Backup(path, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (BackupResult, error) {
+	return u.backup(BackupCommand(u.repoIdentifier, u.passwordFile, path, tags), parentSnapshotID, tags, log, updateFunc)
+}
+
+func (u *resticUploader) BackupBlock(devicePath, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (BackupResult, error) {
+	device, err := os.Open(devicePath)
+	if err != nil {
+		return BackupResult{}, errors.Wrap(err, "error opening block device")
+	}
+	defer device.Close()
+
+	cmd := BackupBlockCommand(u.repoIdentifier, u.passwordFile, tags)
+	cmd.Stdin = device
+
+	return u.backup(cmd, parentSnapshotID, tags, log, updateFunc)
+}
+
+func (u *resticUploader) backup(cmd *Command, parentSnapshotID string, tags map[string]string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) (BackupResult, error) {
+	cmd.Env = u.env
+
+	// restic picks the parent snapshot by host and path itself, but the
+	// path includes the pod's UID, so it's given explicitly to keep backups
+	// incremental when the pod is recreated.
+	if parentSnapshotID != "" {
+		cmd.ExtraFlags = append(cmd.ExtraFlags, "--parent="+parentSnapshotID)
+	}
+
+	stdout, stderr, err := RunBackup(cmd, log, updateFunc)
+	if err != nil {
+		if strings.Contains(stderr, "snapshot is empty") {
+			return BackupResult{}, nil
+		}
+		return BackupResult{}, errors.Wrapf(err, "error running restic backup, stderr=%s", stderr)
+	}
+	log.Debugf("Ran command=%s, stdout=%s, stderr=%s", cmd.String(), stdout, stderr)
+
+	snapshotID, err := GetSnapshotID(u.repoIdentifier, u.passwordFile, tags, u.env)
+	if err != nil {
+		return BackupResult{}, errors.Wrap(err, "error getting snapshot id")
+	}
+
+	return BackupResult{
+		SnapshotID: snapshotID,
+		Changes:    getBackupChanges(stdout, log),
+	}, nil
+}
+
+func (u *resticUploader) Restore(snapshotID, path string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) error {
+	cmd := RestoreCommand(u.repoIdentifier, u.passwordFile, snapshotID, path)
+	cmd.Env = u.env
+
+	stdout, stderr, err := RunRestore(cmd, log, updateFunc)
+	if err != nil {
+		return errors.Wrapf(err, "error running restic restore, cmd=%s, stdout=%s, stderr=%s", cmd.String(), stdout, stderr)
+	}
+	log.Debugf("Ran command=%s, stdout=%s, stderr=%s", cmd.String(), stdout, stderr)
+
+	return nil
+}
+
+func (u *resticUploader) RestoreBlock(snapshotID, devicePath string, log logrus.FieldLogger, updateFunc func(velerov1api.PodVolumeOperationProgress)) error {
+	device, err := os.OpenFile(devicePath, os.O_WRONLY, 0)
+	if err != nil {
+		return errors.Wrap(err, "error opening block device")
+	}
+	defer device.Close()
+
+	cmd := DumpCommand(u.repoIdentifier, u.passwordFile, snapshotID, blockDeviceFilename)
+	cmd.Env = u.env
+
+	stderr, err := RunDump(cmd, device, log, updateFunc)
+	if err != nil {
+		return errors.Wrapf(err, "error running restic dump, cmd=%s, stderr=%s", cmd.String(), stderr)
+	}
+	log.Debugf("Ran command=%s, stderr=%s", cmd.String(), stderr)
+
+	return nil
+}
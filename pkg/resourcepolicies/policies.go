@@ -0,0 +1,264 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resourcepolicies reads resource policies from a ConfigMap. Backups
+// and restores consult them to skip items that match their conditions, e.g.
+// persistent volumes provisioned by certain drivers or below a size.
+package resourcepolicies
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/heptio/velero/pkg/kuberesource"
+)
+
+const (
+	// Version is the version of the resource policies format.
+	Version = "v1"
+
+	// ActionSkip is the action of a policy that skips the items matching
+	// its conditions.
+	ActionSkip = "skip"
+
+	// provisionerAnnotation is the annotation on a dynamically provisioned
+	// persistent volume claim with the name of its provisioner, which is
+	// the driver's name for CSI volumes.
+	provisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+)
+
+// config is the format of the resource policies in a ConfigMap.
+type config struct {
+	Version          string         `json:"version"`
+	ResourcePolicies []policyConfig `json:"resourcePolicies"`
+}
+
+type policyConfig struct {
+	Conditions conditionsConfig `json:"conditions"`
+	Action     actionConfig     `json:"action"`
+}
+
+type conditionsConfig struct {
+	// Resources are the fully-qualified group-resources of the items the
+	// policy applies to, e.g. "persistentvolumes" or "deployments.apps".
+	Resources []string `json:"resources,omitempty"`
+
+	// Annotations are the annotations that items must all have. An empty
+	// value matches any value of the annotation.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// StorageClasses, CSIDrivers and Capacity only match persistent
+	// volumes and persistent volume claims.
+	StorageClasses []string `json:"storageClasses,omitempty"`
+	CSIDrivers     []string `json:"csiDrivers,omitempty"`
+
+	// Capacity is a range of sizes, given as "min,max". The min is
+	// inclusive, the max is exclusive, and either may be left out.
+	Capacity string `json:"capacity,omitempty"`
+}
+
+type actionConfig struct {
+	Type string `json:"type"`
+}
+
+// Policies are a set of resource policies. A nil *Policies doesn't skip
+// any items.
+type Policies struct {
+	policies []*policy
+}
+
+// policy is a resource policy, parsed so that it can be matched against
+// items.
+type policy struct {
+	resources      sets.String
+	annotations    map[string]string
+	storageClasses sets.String
+	csiDrivers     sets.String
+	minCapacity    *resource.Quantity
+	maxCapacity    *resource.Quantity
+}
+
+// Get reads the resource policies in the ConfigMap with the given name. The
+// ConfigMap must have a single data key, whose value is the policies.
+func Get(client corev1client.ConfigMapInterface, name string) (*Policies, error) {
+	configMap, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting resource policies ConfigMap %s", name)
+	}
+
+	if len(configMap.Data) != 1 {
+		return nil, errors.Errorf("resource policies ConfigMap %s must have a single data key, but has %d", name, len(configMap.Data))
+	}
+
+	for _, data := range configMap.Data {
+		policies, err := Parse([]byte(data))
+		if err != nil {
+			return nil, errors.WithMessage(err, "error parsing resource policies ConfigMap "+name)
+		}
+		return policies, nil
+	}
+
+	return nil, nil
+}
+
+// Parse parses resource policies given as YAML.
+func Parse(data []byte) (*Policies, error) {
+	var cfg config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if cfg.Version != Version {
+		return nil, errors.Errorf("unsupported version %q, must be %s", cfg.Version, Version)
+	}
+
+	res := new(Policies)
+	for i, pc := range cfg.ResourcePolicies {
+		if pc.Action.Type != ActionSkip {
+			return nil, errors.Errorf("resource policy %d has unsupported action %q, must be %s", i, pc.Action.Type, ActionSkip)
+		}
+
+		p := &policy{
+			resources:      sets.NewString(),
+			annotations:    pc.Conditions.Annotations,
+			storageClasses: sets.NewString(pc.Conditions.StorageClasses...),
+			csiDrivers:     sets.NewString(pc.Conditions.CSIDrivers...),
+		}
+		for _, r := range pc.Conditions.Resources {
+			p.resources.Insert(schema.ParseGroupResource(r).String())
+		}
+
+		if pc.Conditions.Capacity != "" {
+			var err error
+			if p.minCapacity, p.maxCapacity, err = parseCapacity(pc.Conditions.Capacity); err != nil {
+				return nil, errors.WithMessage(err, fmt.Sprintf("resource policy %d has invalid capacity", i))
+			}
+		}
+
+		res.policies = append(res.policies, p)
+	}
+
+	return res, nil
+}
+
+// parseCapacity parses a range of sizes, given as "min,max".
+func parseCapacity(capacity string) (*resource.Quantity, *resource.Quantity, error) {
+	parts := strings.Split(capacity, ",")
+	if len(parts) != 2 {
+		return nil, nil, errors.Errorf("%q must be given as min,max", capacity)
+	}
+
+	var quantities [2]*resource.Quantity
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		q, err := resource.ParseQuantity(part)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error parsing %q", part)
+		}
+		quantities[i] = &q
+	}
+
+	if quantities[0] != nil && quantities[1] != nil && quantities[0].Cmp(*quantities[1]) >= 0 {
+		return nil, nil, errors.Errorf("%q has a min that isn't less than its max", capacity)
+	}
+
+	return quantities[0], quantities[1], nil
+}
+
+// ShouldSkip returns whether the item, which is of the given group-resource,
+// matches the conditions of any of the policies, and should be skipped.
+func (p *Policies) ShouldSkip(groupResource schema.GroupResource, obj runtime.Unstructured) bool {
+	if p == nil {
+		return false
+	}
+
+	item := &unstructured.Unstructured{Object: obj.UnstructuredContent()}
+	for _, policy := range p.policies {
+		if policy.matches(groupResource, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns whether the item matches all of the policy's conditions.
+func (p *policy) matches(groupResource schema.GroupResource, item *unstructured.Unstructured) bool {
+	if p.resources.Len() > 0 && !p.resources.Has(groupResource.String()) {
+		return false
+	}
+
+	annotations := item.GetAnnotations()
+	for key, value := range p.annotations {
+		actual, ok := annotations[key]
+		if !ok || (value != "" && value != actual) {
+			return false
+		}
+	}
+
+	if p.storageClasses.Len() == 0 && p.csiDrivers.Len() == 0 && p.minCapacity == nil && p.maxCapacity == nil {
+		return true
+	}
+
+	var storageClass, driver, capacity string
+	switch groupResource {
+	case kuberesource.PersistentVolumes:
+		storageClass, _, _ = unstructured.NestedString(item.Object, "spec", "storageClassName")
+		driver, _, _ = unstructured.NestedString(item.Object, "spec", "csi", "driver")
+		capacity, _, _ = unstructured.NestedString(item.Object, "spec", "capacity", "storage")
+	case kuberesource.PersistentVolumeClaims:
+		storageClass, _, _ = unstructured.NestedString(item.Object, "spec", "storageClassName")
+		driver = annotations[provisionerAnnotation]
+		capacity, _, _ = unstructured.NestedString(item.Object, "spec", "resources", "requests", "storage")
+	default:
+		return false
+	}
+
+	if p.storageClasses.Len() > 0 && !p.storageClasses.Has(storageClass) {
+		return false
+	}
+	if p.csiDrivers.Len() > 0 && !p.csiDrivers.Has(driver) {
+		return false
+	}
+
+	if p.minCapacity != nil || p.maxCapacity != nil {
+		q, err := resource.ParseQuantity(capacity)
+		if err != nil {
+			return false
+		}
+		if p.minCapacity != nil && q.Cmp(*p.minCapacity) < 0 {
+			return false
+		}
+		if p.maxCapacity != nil && q.Cmp(*p.maxCapacity) >= 0 {
+			return false
+		}
+	}
+
+	return true
+}
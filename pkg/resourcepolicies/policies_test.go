@@ -0,0 +1,260 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcepolicies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/heptio/velero/pkg/kuberesource"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid policies",
+			data: `
+version: v1
+resourcePolicies:
+- conditions:
+    csiDrivers: [ebs.csi.aws.com]
+    capacity: "0,10Gi"
+  action:
+    type: skip
+`,
+		},
+		{
+			name:    "unsupported version",
+			data:    "version: v2\n",
+			wantErr: `unsupported version "v2", must be v1`,
+		},
+		{
+			name: "unsupported action",
+			data: `
+version: v1
+resourcePolicies:
+- action:
+    type: delete
+`,
+			wantErr: `resource policy 0 has unsupported action "delete", must be skip`,
+		},
+		{
+			name: "capacity without a max",
+			data: `
+version: v1
+resourcePolicies:
+- conditions:
+    capacity: "10Gi"
+  action:
+    type: skip
+`,
+			wantErr: `resource policy 0 has invalid capacity: "10Gi" must be given as min,max`,
+		},
+		{
+			name: "capacity with a min that isn't less than its max",
+			data: `
+version: v1
+resourcePolicies:
+- conditions:
+    capacity: "10Gi,1Gi"
+  action:
+    type: skip
+`,
+			wantErr: `resource policy 0 has invalid capacity: "10Gi,1Gi" has a min that isn't less than its max`,
+		},
+		{
+			name: "unknown field",
+			data: `
+version: v1
+resourcePolicies:
+- conditions:
+    drivers: [foo]
+  action:
+    type: skip
+`,
+			wantErr: `error unmarshaling JSON: while decoding JSON: json: unknown field "drivers"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.data))
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestShouldSkip(t *testing.T) {
+	pv := func(storageClass, driver, capacity string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolume",
+			"metadata":   map[string]interface{}{"name": "pv-1"},
+			"spec": map[string]interface{}{
+				"storageClassName": storageClass,
+				"csi":              map[string]interface{}{"driver": driver},
+				"capacity":         map[string]interface{}{"storage": capacity},
+			},
+		}}
+	}
+	pvc := func(provisioner, capacity string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{
+				"namespace":   "ns-1",
+				"name":        "pvc-1",
+				"annotations": map[string]interface{}{provisionerAnnotation: provisioner},
+			},
+			"spec": map[string]interface{}{
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"storage": capacity},
+				},
+			},
+		}}
+	}
+	configMap := func(annotations map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"namespace":   "ns-1",
+				"name":        "cm-1",
+				"annotations": annotations,
+			},
+		}}
+	}
+
+	policies, err := Parse([]byte(`
+version: v1
+resourcePolicies:
+- conditions:
+    csiDrivers: [nfs.csi.k8s.io]
+  action:
+    type: skip
+- conditions:
+    storageClasses: [scratch]
+    capacity: ",1Gi"
+  action:
+    type: skip
+- conditions:
+    resources: [configmaps]
+    annotations:
+      example.com/skip-backup: ""
+  action:
+    type: skip
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want bool
+	}{
+		{
+			name: "PV of a skipped CSI driver is skipped",
+			obj:  pv("standard", "nfs.csi.k8s.io", "100Gi"),
+			want: true,
+		},
+		{
+			name: "PV of another CSI driver isn't skipped",
+			obj:  pv("standard", "ebs.csi.aws.com", "100Gi"),
+		},
+		{
+			name: "PV of a skipped storage class below the max capacity is skipped",
+			obj:  pv("scratch", "ebs.csi.aws.com", "512Mi"),
+			want: true,
+		},
+		{
+			name: "PV of a skipped storage class at the max capacity isn't skipped",
+			obj:  pv("scratch", "ebs.csi.aws.com", "1Gi"),
+		},
+		{
+			name: "PVC provisioned by a skipped CSI driver is skipped",
+			obj:  pvc("nfs.csi.k8s.io", "10Gi"),
+			want: true,
+		},
+		{
+			name: "PVC provisioned by another driver isn't skipped",
+			obj:  pvc("ebs.csi.aws.com", "10Gi"),
+		},
+		{
+			name: "item with a skipped annotation is skipped, whatever its value",
+			obj:  configMap(map[string]interface{}{"example.com/skip-backup": "yes"}),
+			want: true,
+		},
+		{
+			name: "item without a skipped annotation isn't skipped",
+			obj:  configMap(map[string]interface{}{"example.com/other": "yes"}),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gr := kuberesource.PersistentVolumes
+			switch tc.obj.GetKind() {
+			case "PersistentVolumeClaim":
+				gr = kuberesource.PersistentVolumeClaims
+			case "ConfigMap":
+				gr.Resource = "configmaps"
+			}
+
+			assert.Equal(t, tc.want, policies.ShouldSkip(gr, tc.obj))
+		})
+	}
+
+	t.Run("nil policies don't skip anything", func(t *testing.T) {
+		var policies *Policies
+		assert.False(t, policies.ShouldSkip(kuberesource.PersistentVolumes, pv("standard", "nfs.csi.k8s.io", "100Gi")))
+	})
+}
+
+func TestGet(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1api.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "policies"},
+			Data:       map[string]string{"policies.yaml": "version: v1\n"},
+		},
+		&corev1api.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "empty"},
+		},
+	)
+
+	policies, err := Get(client.CoreV1().ConfigMaps("velero"), "policies")
+	require.NoError(t, err)
+	assert.NotNil(t, policies)
+
+	_, err = Get(client.CoreV1().ConfigMaps("velero"), "empty")
+	assert.EqualError(t, err, "resource policies ConfigMap empty must have a single data key, but has 0")
+
+	_, err = Get(client.CoreV1().ConfigMaps("velero"), "missing")
+	assert.EqualError(t, err, `error getting resource policies ConfigMap missing: configmaps "missing" not found`)
+}
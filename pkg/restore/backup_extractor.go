@@ -22,7 +22,9 @@ import (
 	"io"
 	"path/filepath"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/heptio/velero/pkg/util/filesystem"
 )
@@ -34,8 +36,9 @@ type backupExtractor struct {
 	fileSystem filesystem.Interface
 }
 
-// unzipAndExtractBackup extracts a reader on a gzipped tarball to a local temp directory
-func (e *backupExtractor) unzipAndExtractBackup(src io.Reader) (string, error) {
+// unzipAndExtractBackup extracts a reader on a gzipped tarball to a local temp directory.
+// If include is non-nil, only the entries it returns true for are extracted.
+func (e *backupExtractor) unzipAndExtractBackup(src io.Reader, include func(*tar.Header) bool) (string, error) {
 	gzr, err := gzip.NewReader(src)
 	if err != nil {
 		e.log.Infof("error creating gzip reader: %v", err)
@@ -43,7 +46,7 @@ func (e *backupExtractor) unzipAndExtractBackup(src io.Reader) (string, error) {
 	}
 	defer gzr.Close()
 
-	return e.readBackup(tar.NewReader(gzr))
+	return e.readBackup(tar.NewReader(gzr), include)
 }
 
 func (e *backupExtractor) writeFile(target string, tarRdr *tar.Reader) error {
@@ -59,13 +62,55 @@ func (e *backupExtractor) writeFile(target string, tarRdr *tar.Reader) error {
 	return nil
 }
 
-func (e *backupExtractor) readBackup(tarRdr *tar.Reader) (string, error) {
+func (e *backupExtractor) readBackup(tarRdr *tar.Reader, include func(*tar.Header) bool) (string, error) {
 	dir, err := e.fileSystem.TempDir("", "")
 	if err != nil {
 		e.log.Infof("error creating temp dir: %v", err)
 		return "", err
 	}
 
+	if err := e.extractEntries(tarRdr, dir, include); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// unzipAndExtractItems extracts the regular files with the given paths from
+// a reader on a gzipped tarball into dir, which a backup has already been
+// extracted to. It returns an error if any of the files aren't found.
+func (e *backupExtractor) unzipAndExtractItems(src io.Reader, dir string, paths sets.String) error {
+	gzr, err := gzip.NewReader(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer gzr.Close()
+
+	remaining := sets.NewString(paths.UnsortedList()...)
+	include := func(header *tar.Header) bool {
+		name := filepath.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || !remaining.Has(name) {
+			return false
+		}
+		remaining.Delete(name)
+		return true
+	}
+
+	if err := e.extractEntries(tar.NewReader(gzr), dir, include); err != nil {
+		return err
+	}
+
+	if remaining.Len() > 0 {
+		return errors.Errorf("%d items not found, including %s", remaining.Len(), remaining.List()[0])
+	}
+
+	return nil
+}
+
+// extractEntries writes the tarball's directories and regular files under
+// dir. If include is non-nil, only the entries it returns true for are
+// written.
+func (e *backupExtractor) extractEntries(tarRdr *tar.Reader, dir string, include func(*tar.Header) bool) error {
 	for {
 		header, err := tarRdr.Next()
 
@@ -74,7 +119,11 @@ func (e *backupExtractor) readBackup(tarRdr *tar.Reader) (string, error) {
 		}
 		if err != nil {
 			e.log.Infof("error reading tar: %v", err)
-			return "", err
+			return err
+		}
+
+		if include != nil && !include(header) {
+			continue
 		}
 
 		target := filepath.Join(dir, header.Name)
@@ -84,7 +133,7 @@ func (e *backupExtractor) readBackup(tarRdr *tar.Reader) (string, error) {
 			err := e.fileSystem.MkdirAll(target, header.FileInfo().Mode())
 			if err != nil {
 				e.log.Infof("mkdirall error: %v", err)
-				return "", err
+				return err
 			}
 
 		case tar.TypeReg:
@@ -92,16 +141,16 @@ func (e *backupExtractor) readBackup(tarRdr *tar.Reader) (string, error) {
 			err := e.fileSystem.MkdirAll(filepath.Dir(target), header.FileInfo().Mode())
 			if err != nil {
 				e.log.Infof("mkdirall error: %v", err)
-				return "", err
+				return err
 			}
 
 			// create the file
 			if err := e.writeFile(target, tarRdr); err != nil {
 				e.log.Infof("error copying: %v", err)
-				return "", err
+				return err
 			}
 		}
 	}
 
-	return dir, nil
+	return nil
 }
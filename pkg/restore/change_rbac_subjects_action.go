@@ -0,0 +1,189 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/heptio/velero/pkg/plugin/framework"
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+// The keys of the ChangeRBACSubjectsAction's config map. Each key's value is a
+// YAML map from the names of the subjects in the backup to the names to
+// restore them as, or to "" to remove them from the bindings. Service
+// accounts are named <namespace>/<name>.
+const (
+	rbacSubjectsUsersKey           = "users"
+	rbacSubjectsGroupsKey          = "groups"
+	rbacSubjectsServiceAccountsKey = "serviceAccounts"
+)
+
+// ChangeRBACSubjectsAction updates the users, groups and service accounts that
+// role bindings and cluster role bindings grant access to, if a mapping is
+// found for them in the plugin's config map.
+type ChangeRBACSubjectsAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewChangeRBACSubjectsAction is the constructor for ChangeRBACSubjectsAction.
+func NewChangeRBACSubjectsAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) *ChangeRBACSubjectsAction {
+	return &ChangeRBACSubjectsAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+// AppliesTo returns the resources that ChangeRBACSubjectsAction should be run
+// for.
+func (a *ChangeRBACSubjectsAction) AppliesTo() (velero.ResourceSelector, error) {
+	return velero.ResourceSelector{
+		IncludedResources: []string{"rolebindings.rbac.authorization.k8s.io", "clusterrolebindings.rbac.authorization.k8s.io"},
+	}, nil
+}
+
+// rbacSubjectMappings are the mappings of each kind of subject, from the
+// plugin's config map.
+type rbacSubjectMappings map[string]map[string]string
+
+// Execute replaces or removes the item's subjects that have a mapping in the
+// config map for the plugin.
+func (a *ChangeRBACSubjectsAction) Execute(input *velero.RestoreItemActionExecuteInput) (*velero.RestoreItemActionExecuteOutput, error) {
+	a.logger.Info("Executing ChangeRBACSubjectsAction")
+	defer a.logger.Info("Done executing ChangeRBACSubjectsAction")
+
+	a.logger.Debug("Getting plugin config")
+	config, err := getPluginConfig(framework.PluginKindRestoreItemAction, "velero.io/change-rbac-subjects", a.configMapClient)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		a.logger.Debug("No RBAC subject mappings found")
+		return velero.NewRestoreItemActionExecuteOutput(input.Item), nil
+	}
+
+	mappings, err := parseRBACSubjectMappings(config.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := input.Item.(*unstructured.Unstructured)
+	if !ok {
+		return nil, errors.Errorf("object was of unexpected type %T", input.Item)
+	}
+
+	log := a.logger.WithFields(map[string]interface{}{
+		"kind":      obj.GetKind(),
+		"namespace": obj.GetNamespace(),
+		"name":      obj.GetName(),
+	})
+
+	subjects, _ := obj.Object["subjects"].([]interface{})
+	var updated []interface{}
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok {
+			updated = append(updated, s)
+			continue
+		}
+
+		kind, _ := subject["kind"].(string)
+		name, _ := subject["name"].(string)
+		namespace, _ := subject["namespace"].(string)
+
+		from := name
+		if kind == rbacv1.ServiceAccountKind {
+			from = namespace + "/" + name
+		}
+
+		to, ok := mappings[kind][from]
+		if !ok {
+			updated = append(updated, subject)
+			continue
+		}
+
+		if to == "" {
+			log.Infof("Removing %s %s from the item's subjects", kind, from)
+			continue
+		}
+
+		log.Infof("Changing %s %s in the item's subjects to %s", kind, from, to)
+		if kind == rbacv1.ServiceAccountKind {
+			parts := strings.SplitN(to, "/", 2)
+			subject["namespace"], subject["name"] = parts[0], parts[1]
+		} else {
+			subject["name"] = to
+		}
+		updated = append(updated, subject)
+	}
+
+	if updated == nil {
+		delete(obj.Object, "subjects")
+	} else {
+		obj.Object["subjects"] = updated
+	}
+
+	return velero.NewRestoreItemActionExecuteOutput(obj), nil
+}
+
+// parseRBACSubjectMappings parses the mappings of each kind of subject from
+// the data of the plugin's config map.
+func parseRBACSubjectMappings(data map[string]string) (rbacSubjectMappings, error) {
+	kinds := map[string]string{
+		rbacSubjectsUsersKey:           rbacv1.UserKind,
+		rbacSubjectsGroupsKey:          rbacv1.GroupKind,
+		rbacSubjectsServiceAccountsKey: rbacv1.ServiceAccountKind,
+	}
+
+	mappings := make(rbacSubjectMappings)
+	for key, value := range data {
+		kind, ok := kinds[key]
+		if !ok {
+			return nil, errors.Errorf("invalid key %q in RBAC subject mappings, must be one of %s, %s or %s", key, rbacSubjectsUsersKey, rbacSubjectsGroupsKey, rbacSubjectsServiceAccountsKey)
+		}
+
+		mapping := make(map[string]string)
+		if err := yaml.Unmarshal([]byte(value), &mapping); err != nil {
+			return nil, errors.Wrapf(err, "error parsing %s in RBAC subject mappings", key)
+		}
+
+		if kind == rbacv1.ServiceAccountKind {
+			for from, to := range mapping {
+				if !isNamespacedName(from) || (to != "" && !isNamespacedName(to)) {
+					return nil, errors.Errorf("invalid service account mapping %q to %q, service accounts must be named <namespace>/<name>", from, to)
+				}
+			}
+		}
+
+		mappings[kind] = mapping
+	}
+
+	return mappings, nil
+}
+
+func isNamespacedName(s string) bool {
+	parts := strings.Split(s, "/")
+	return len(parts) == 2 && parts[0] != "" && parts[1] != ""
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+func TestChangeRBACSubjectsActionExecute(t *testing.T) {
+	configMap := func(data ...string) *corev1api.ConfigMap {
+		return builder.ForConfigMap("velero", "change-rbac-subjects").
+			ObjectMeta(builder.WithLabels("velero.io/plugin-config", "true", "velero.io/change-rbac-subjects", "RestoreItemAction")).
+			Data(data...).
+			Result()
+	}
+
+	roleBinding := func(subjects ...rbacv1.Subject) *rbacv1.RoleBinding {
+		return &rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "rolebinding-1"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "role-1"},
+			Subjects:   subjects,
+		}
+	}
+
+	clusterRoleBinding := func(subjects ...rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: "clusterrolebinding-1"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "clusterrole-1"},
+			Subjects:   subjects,
+		}
+	}
+
+	user := func(name string) rbacv1.Subject {
+		return rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: name}
+	}
+	group := func(name string) rbacv1.Subject {
+		return rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.GroupKind, Name: name}
+	}
+	serviceAccount := func(ns, name string) rbacv1.Subject {
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: ns, Name: name}
+	}
+
+	tests := []struct {
+		name      string
+		item      interface{}
+		configMap *corev1api.ConfigMap
+		want      interface{}
+		wantErr   string
+	}{
+		{
+			name: "when there's no config map, the item is returned as-is",
+			item: roleBinding(user("alice"), group("oidc:old-admins")),
+			want: roleBinding(user("alice"), group("oidc:old-admins")),
+		},
+		{
+			name:      "users and groups with mappings are changed",
+			item:      clusterRoleBinding(user("alice"), group("oidc:old-admins"), group("oidc:viewers")),
+			configMap: configMap("users", "alice: alice@example.com", "groups", `"oidc:old-admins": "oidc:new-admins"`),
+			want:      clusterRoleBinding(user("alice@example.com"), group("oidc:new-admins"), group("oidc:viewers")),
+		},
+		{
+			name:      "service accounts with mappings are changed",
+			item:      roleBinding(serviceAccount("ns-1", "sa-1"), serviceAccount("ns-1", "sa-2")),
+			configMap: configMap("serviceAccounts", "ns-1/sa-1: ns-2/sa-3"),
+			want:      roleBinding(serviceAccount("ns-2", "sa-3"), serviceAccount("ns-1", "sa-2")),
+		},
+		{
+			name:      "a user's mapping doesn't apply to a group of the same name",
+			item:      roleBinding(group("alice")),
+			configMap: configMap("users", "alice: bob"),
+			want:      roleBinding(group("alice")),
+		},
+		{
+			name:      "subjects mapped to an empty name are removed",
+			item:      clusterRoleBinding(user("alice"), group("oidc:old-admins")),
+			configMap: configMap("groups", `"oidc:old-admins": ""`),
+			want:      clusterRoleBinding(user("alice")),
+		},
+		{
+			name:      "a binding whose subjects are all removed has no subjects",
+			item:      clusterRoleBinding(user("alice")),
+			configMap: configMap("users", `alice: ""`),
+			want:      clusterRoleBinding(),
+		},
+		{
+			name:      "an unknown config map key is an error",
+			item:      roleBinding(user("alice")),
+			configMap: configMap("people", "alice: bob"),
+			wantErr:   `invalid key "people" in RBAC subject mappings, must be one of users, groups or serviceAccounts`,
+		},
+		{
+			name:      "a service account mapping that isn't namespaced is an error",
+			item:      roleBinding(serviceAccount("ns-1", "sa-1")),
+			configMap: configMap("serviceAccounts", "sa-1: sa-2"),
+			wantErr:   `invalid service account mapping "sa-1" to "sa-2", service accounts must be named <namespace>/<name>`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			a := NewChangeRBACSubjectsAction(logrus.StandardLogger(), clientset.CoreV1().ConfigMaps("velero"))
+
+			if tc.configMap != nil {
+				_, err := clientset.CoreV1().ConfigMaps(tc.configMap.Namespace).Create(tc.configMap)
+				require.NoError(t, err)
+			}
+
+			unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(tc.item)
+			require.NoError(t, err)
+
+			res, err := a.Execute(&velero.RestoreItemActionExecuteInput{
+				Item: &unstructured.Unstructured{Object: unstructuredMap},
+			})
+
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			wantUnstructured, err := runtime.DefaultUnstructuredConverter.ToUnstructured(tc.want)
+			require.NoError(t, err)
+
+			assert.Equal(t, &unstructured.Unstructured{Object: wantUnstructured}, res.UpdatedItem)
+		})
+	}
+}
@@ -29,7 +29,8 @@ import (
 )
 
 // ChangeStorageClassAction updates a PV or PVC's storage class name
-// if a mapping is found in the plugin's config map.
+// if a mapping is found in the restore's storage class mapping or in the
+// plugin's config map.
 type ChangeStorageClassAction struct {
 	logger             logrus.FieldLogger
 	configMapClient    corev1client.ConfigMapInterface
@@ -57,8 +58,14 @@ func (a *ChangeStorageClassAction) AppliesTo() (velero.ResourceSelector, error)
 	}, nil
 }
 
-// Execute updates the item's spec.storageClassName if a mapping is found
-// in the config map for the plugin.
+// storageClassAnnotation is the annotation that specified a PV or PVC's
+// storage class before spec.storageClassName was added.
+const storageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+
+// Execute updates the item's spec.storageClassName and storage class
+// annotation if a mapping is found in the restore's storage class mapping
+// or in the config map for the plugin. The restore's mapping takes
+// precedence.
 func (a *ChangeStorageClassAction) Execute(input *velero.RestoreItemActionExecuteInput) (*velero.RestoreItemActionExecuteOutput, error) {
 	a.logger.Info("Executing ChangeStorageClassAction")
 	defer a.logger.Info("Done executing ChangeStorageClassAction")
@@ -69,7 +76,19 @@ func (a *ChangeStorageClassAction) Execute(input *velero.RestoreItemActionExecut
 		return nil, err
 	}
 
-	if config == nil || len(config.Data) == 0 {
+	mapping := make(map[string]string)
+	if config != nil {
+		for from, to := range config.Data {
+			mapping[from] = to
+		}
+	}
+	if input.Restore != nil {
+		for from, to := range input.Restore.Spec.StorageClassMapping {
+			mapping[from] = to
+		}
+	}
+
+	if len(mapping) == 0 {
 		a.logger.Debug("No storage class mappings found")
 		return velero.NewRestoreItemActionExecuteOutput(input.Item), nil
 	}
@@ -91,27 +110,55 @@ func (a *ChangeStorageClassAction) Execute(input *velero.RestoreItemActionExecut
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting item's spec.storageClassName")
 	}
-	if storageClass == "" {
+	annotations := obj.GetAnnotations()
+	annotationStorageClass := annotations[storageClassAnnotation]
+
+	if storageClass == "" && annotationStorageClass == "" {
 		log.Debug("Item has no storage class specified")
 		return velero.NewRestoreItemActionExecuteOutput(input.Item), nil
 	}
 
-	newStorageClass, ok := config.Data[storageClass]
-	if !ok {
-		log.Debugf("No mapping found for storage class %s", storageClass)
-		return velero.NewRestoreItemActionExecuteOutput(input.Item), nil
+	if storageClass != "" {
+		newStorageClass, ok := mapping[storageClass]
+		if !ok {
+			log.Debugf("No mapping found for storage class %s", storageClass)
+		} else {
+			if err := a.validateStorageClass(newStorageClass); err != nil {
+				return nil, err
+			}
+
+			log.Infof("Updating item's storage class name to %s", newStorageClass)
+
+			if err := unstructured.SetNestedField(obj.UnstructuredContent(), newStorageClass, "spec", "storageClassName"); err != nil {
+				return nil, errors.Wrap(err, "unable to set item's spec.storageClassName")
+			}
+		}
 	}
 
-	// validate that new storage class exists
-	if _, err := a.storageClassClient.Get(newStorageClass, metav1.GetOptions{}); err != nil {
-		return nil, errors.Wrapf(err, "error getting storage class %s from API", newStorageClass)
-	}
+	if annotationStorageClass != "" {
+		newStorageClass, ok := mapping[annotationStorageClass]
+		if !ok {
+			log.Debugf("No mapping found for storage class %s in annotation %s", annotationStorageClass, storageClassAnnotation)
+		} else {
+			if err := a.validateStorageClass(newStorageClass); err != nil {
+				return nil, err
+			}
 
-	log.Infof("Updating item's storage class name to %s", newStorageClass)
+			log.Infof("Updating item's %s annotation to %s", storageClassAnnotation, newStorageClass)
 
-	if err := unstructured.SetNestedField(obj.UnstructuredContent(), newStorageClass, "spec", "storageClassName"); err != nil {
-		return nil, errors.Wrap(err, "unable to set item's spec.storageClassName")
+			annotations[storageClassAnnotation] = newStorageClass
+			obj.SetAnnotations(annotations)
+		}
 	}
 
 	return velero.NewRestoreItemActionExecuteOutput(obj), nil
 }
+
+// validateStorageClass returns an error if a storage class that an item is
+// mapped to doesn't exist.
+func (a *ChangeStorageClassAction) validateStorageClass(name string) error {
+	if _, err := a.storageClassClient.Get(name, metav1.GetOptions{}); err != nil {
+		return errors.Wrapf(err, "error getting storage class %s from API", name)
+	}
+	return nil
+}
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/builder"
 	"github.com/heptio/velero/pkg/plugin/velero"
 )
@@ -41,6 +42,7 @@ func TestChangeStorageClassActionExecute(t *testing.T) {
 	tests := []struct {
 		name         string
 		pvOrPVC      interface{}
+		restore      *velerov1api.Restore
 		configMap    *corev1api.ConfigMap
 		storageClass *storagev1api.StorageClass
 		want         interface{}
@@ -66,6 +68,35 @@ func TestChangeStorageClassActionExecute(t *testing.T) {
 			storageClass: builder.ForStorageClass("storageclass-2").Result(),
 			want:         builder.ForPersistentVolumeClaim("velero", "pvc-1").StorageClass("storageclass-2").Result(),
 		},
+		{
+			name:         "a mapping in the restore is applied when there's no config map",
+			pvOrPVC:      builder.ForPersistentVolumeClaim("velero", "pvc-1").StorageClass("storageclass-1").Result(),
+			restore:      builder.ForRestore("velero", "restore-1").StorageClassMappings("storageclass-1", "storageclass-2").Result(),
+			storageClass: builder.ForStorageClass("storageclass-2").Result(),
+			want:         builder.ForPersistentVolumeClaim("velero", "pvc-1").StorageClass("storageclass-2").Result(),
+		},
+		{
+			name:    "a mapping in the restore takes precedence over the config map",
+			pvOrPVC: builder.ForPersistentVolume("pv-1").StorageClass("storageclass-1").Result(),
+			restore: builder.ForRestore("velero", "restore-1").StorageClassMappings("storageclass-1", "storageclass-3").Result(),
+			configMap: builder.ForConfigMap("velero", "change-storage-classs").
+				ObjectMeta(builder.WithLabels("velero.io/plugin-config", "true", "velero.io/change-storage-class", "RestoreItemAction")).
+				Data("storageclass-1", "storageclass-2").
+				Result(),
+			storageClass: builder.ForStorageClass("storageclass-3").Result(),
+			want:         builder.ForPersistentVolume("pv-1").StorageClass("storageclass-3").Result(),
+		},
+		{
+			name: "the storage class annotation of a persistent volume is mapped",
+			pvOrPVC: builder.ForPersistentVolume("pv-1").
+				ObjectMeta(builder.WithAnnotations("volume.beta.kubernetes.io/storage-class", "storageclass-1")).
+				Result(),
+			restore:      builder.ForRestore("velero", "restore-1").StorageClassMappings("storageclass-1", "storageclass-2").Result(),
+			storageClass: builder.ForStorageClass("storageclass-2").Result(),
+			want: builder.ForPersistentVolume("pv-1").
+				ObjectMeta(builder.WithAnnotations("volume.beta.kubernetes.io/storage-class", "storageclass-2")).
+				Result(),
+		},
 		{
 			name:    "when no config map exists for the plugin, the item is returned as-is",
 			pvOrPVC: builder.ForPersistentVolume("pv-1").StorageClass("storageclass-1").Result(),
@@ -166,6 +197,7 @@ func TestChangeStorageClassActionExecute(t *testing.T) {
 				Item: &unstructured.Unstructured{
 					Object: unstructuredMap,
 				},
+				Restore: tc.restore,
 			}
 
 			// execute method under test
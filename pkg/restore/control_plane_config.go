@@ -0,0 +1,59 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/kuberesource"
+)
+
+// controlPlaneConfigResources are the resources that configure how the API
+// server admits and prioritizes requests. Restoring a FlowSchema that starves
+// Velero's requests, or a webhook configuration whose backing service hasn't
+// been restored yet, can lock clients out of the cluster part-way through a
+// restore, so these are only restored when a restore explicitly opts in.
+var controlPlaneConfigResources = map[schema.GroupResource]bool{
+	kuberesource.FlowSchemas:                     true,
+	kuberesource.PriorityLevelConfigurations:     true,
+	kuberesource.MutatingWebhookConfigurations:   true,
+	kuberesource.ValidatingWebhookConfigurations: true,
+}
+
+// shouldRestoreControlPlaneConfig returns whether the restore has opted in to
+// restoring control-plane configuration.
+func shouldRestoreControlPlaneConfig(restore *velerov1api.Restore) bool {
+	return restore.Spec.ControlPlaneConfigPolicy == velerov1api.ControlPlaneConfigPolicyRestore
+}
+
+// controlPlaneConfigLast returns the resources with any control-plane
+// configuration moved to the end, preserving the relative order of the rest,
+// so that everything it may depend on (e.g. webhook services) is restored
+// before it takes effect.
+func controlPlaneConfigLast(resources []schema.GroupResource) []schema.GroupResource {
+	var ret, last []schema.GroupResource
+	for _, resource := range resources {
+		if controlPlaneConfigResources[resource] {
+			last = append(last, resource)
+			continue
+		}
+		ret = append(ret, resource)
+	}
+
+	return append(ret, last...)
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeerrs "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/kuberesource"
+	"github.com/heptio/velero/pkg/label"
+	"github.com/heptio/velero/pkg/podvolume"
+)
+
+// dataRestoreContainer is the name of the main container in the helper
+// pods used by data-only restores.
+const dataRestoreContainer = "restore-data"
+
+// restoreVolumeData executes a data-only restore: the restic backups of the
+// persistent volume claims in the backup are restored into existing claims
+// with the same name in the target namespaces, and none of the Kubernetes
+// resources in the backup are created or modified. Each claim's data is
+// restored through a helper pod that mounts the claim and is deleted once
+// its restic restore has finished.
+func (ctx *context) restoreVolumeData() (Result, Result) {
+	warnings, errs := Result{}, Result{}
+
+	// a volume snapshot can only be restored by provisioning a new persistent
+	// volume from it, which would mean changing which volume the existing claim
+	// is bound to.
+	for _, snapshot := range ctx.volumeSnapshots {
+		addVeleroError(&warnings, errors.Errorf("not restored: volume snapshot of persistent volume %s can't be restored into an existing claim", snapshot.Spec.PersistentVolumeName))
+	}
+
+	if !ctx.resourceIncludesExcludes.ShouldInclude(kuberesource.PersistentVolumeClaims.String()) {
+		ctx.log.Info("Skipping volume data because persistent volume claims are excluded")
+		return warnings, errs
+	}
+	if ctx.guardrails.deniesResource(kuberesource.PersistentVolumeClaims) {
+		ctx.log.Info("Skipping volume data because persistent volume claims are denied by the server's restore guardrails")
+		addVeleroError(&warnings, errors.Errorf("not restored: %s is denied by the server's restore guardrails", kuberesource.PersistentVolumeClaims))
+		return warnings, errs
+	}
+
+	restoredClaims := sets.NewString()
+	for _, pvb := range ctx.podVolumeBackups {
+		claimName := pvb.Annotations[podvolume.PVCNameAnnotation]
+		namespace := pvb.Spec.Pod.Namespace
+
+		log := ctx.log.WithField("podVolumeBackup", pvb.Name)
+		if claimName == "" {
+			log.Debug("Skipping pod volume backup because it's not for a persistent volume claim")
+			continue
+		}
+		if pvb.Status.Phase != velerov1api.PodVolumeBackupPhaseCompleted || pvb.Status.SnapshotID == "" {
+			log.Debug("Skipping pod volume backup because it didn't complete")
+			continue
+		}
+		if !ctx.namespaceIncludesExcludes.ShouldInclude(namespace) {
+			log.Infof("Skipping namespace %s", namespace)
+			continue
+		}
+
+		// a claim that's mounted by several pods is backed up once per pod, so
+		// only restore the first backup of each claim.
+		claimKey := namespace + "/" + claimName
+		if restoredClaims.Has(claimKey) {
+			continue
+		}
+
+		// match the label selector against the claim as it was backed up, since
+		// the existing claim may be labeled differently.
+		var claimLabels map[string]string
+		if obj, err := ctx.unmarshal(getItemFilePath(ctx.restoreDir, kuberesource.PersistentVolumeClaims.String(), namespace, claimName)); err == nil {
+			claimLabels = obj.GetLabels()
+		}
+		if !ctx.selector.Matches(labels.Set(claimLabels)) {
+			continue
+		}
+
+		restoredClaims.Insert(claimKey)
+
+		targetNamespace := namespace
+		if target, ok := ctx.restore.Spec.NamespaceMapping[namespace]; ok {
+			targetNamespace = target
+		}
+
+		if ctx.guardrails.protectsNamespace(namespace) || ctx.guardrails.protectsNamespace(targetNamespace) {
+			log.Infof("Skipping namespace %s because it's protected by the server's restore guardrails", namespace)
+			addToResult(&warnings, namespace, errors.Errorf("not restored: data of persistent volume claim %s/%s because namespace %s is protected by the server's restore guardrails", namespace, claimName, protectedNamespace(ctx.guardrails, namespace, targetNamespace)))
+			continue
+		}
+
+		if err := ctx.restoreClaimData(pvb, targetNamespace, claimName); err != nil {
+			addToResult(&errs, targetNamespace, err)
+		}
+	}
+
+	ctx.log.Debug("Waiting on global wait group")
+	for _, err := range ctx.globalWaitGroup.Wait() {
+		errs.Velero = append(errs.Velero, err.Error())
+	}
+	ctx.log.Debug("Done waiting on global wait group")
+
+	return warnings, errs
+}
+
+// restoreClaimData creates a helper pod that mounts the existing claim and
+// starts the restic restore of the pod volume backup into it. The restore runs
+// on the global wait group, which also deletes the helper pod when it's done.
+func (ctx *context) restoreClaimData(pvb *velerov1api.PodVolumeBackup, namespace, claimName string) error {
+	if ctx.resticRestorer == nil {
+		return errors.Errorf("unable to restore data into persistent volume claim %s/%s: no restic restorer", namespace, claimName)
+	}
+
+	pvcClient, err := ctx.dynamicFactory.ClientForGroupVersionResource(
+		schema.GroupVersion{Group: "", Version: "v1"},
+		metav1.APIResource{Name: "persistentvolumeclaims", Namespaced: true},
+		namespace,
+	)
+	if err != nil {
+		return errors.Wrap(err, "error getting persistent volume claim client")
+	}
+
+	if _, err := pvcClient.Get(claimName, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return errors.Errorf("persistent volume claim %s/%s not found: data-only restores only restore into existing claims", namespace, claimName)
+		}
+		return errors.Wrapf(err, "error getting persistent volume claim %s/%s", namespace, claimName)
+	}
+
+	podClient, err := ctx.dynamicFactory.ClientForGroupVersionResource(
+		schema.GroupVersion{Group: "", Version: "v1"},
+		metav1.APIResource{Name: "pods", Namespaced: true},
+		namespace,
+	)
+	if err != nil {
+		return errors.Wrap(err, "error getting pod client")
+	}
+
+	res, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newDataRestorePod(ctx.restore, pvb, namespace, claimName))
+	if err != nil {
+		return errors.Wrap(err, "error converting data restore pod to unstructured")
+	}
+
+	created, err := podClient.Create(&unstructured.Unstructured{Object: res})
+	if err != nil {
+		return errors.Wrapf(err, "error creating pod to restore data into persistent volume claim %s/%s", namespace, claimName)
+	}
+
+	pod := new(v1.Pod)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(created.UnstructuredContent(), pod); err != nil {
+		return errors.Wrap(err, "error converting data restore pod from unstructured")
+	}
+
+	ctx.log.Infof("Restoring data into persistent volume claim %s/%s using pod %s", namespace, claimName, pod.Name)
+
+	// the restic restorer finds a pod's volume backups by the name of the pod
+	// that was backed up, so point the backup at the helper pod instead.
+	podVolumeBackup := pvb.DeepCopy()
+	podVolumeBackup.Spec.Pod.Name = pod.Name
+
+	ctx.globalWaitGroup.GoErrorSlice(func() []error {
+		errs := ctx.resticRestorer.RestorePodVolumes(podvolume.RestoreData{
+			Restore:          ctx.restore,
+			Pod:              pod,
+			PodVolumeBackups: []*velerov1api.PodVolumeBackup{podVolumeBackup},
+			SourceNamespace:  pvb.Spec.Pod.Namespace,
+			BackupLocation:   ctx.backup.Spec.StorageLocation,
+		})
+		if errs != nil {
+			ctx.log.WithError(kubeerrs.NewAggregate(errs)).Errorf("unable to successfully complete restic restore into persistent volume claim %s/%s", namespace, claimName)
+		}
+
+		if err := deleteDataRestorePod(podClient, pod.Name); err != nil {
+			errs = append(errs, err)
+		}
+
+		return errs
+	})
+
+	return nil
+}
+
+// restoreMovedClaimData restores the moved snapshot data of a claim that was
+// created by the restore, if the backup moved its volume snapshot's data and
+// the claim's volume is dynamically provisioned rather than restored from a
+// snapshot the backup kept.
+func (ctx *context) restoreMovedClaimData(claim *unstructured.Unstructured, originalNamespace, namespace string) error {
+	if volumeName, _, _ := unstructured.NestedString(claim.Object, "spec", "volumeName"); volumeName != "" {
+		return nil
+	}
+
+	claimName := claim.GetName()
+	for _, pvb := range ctx.podVolumeBackups {
+		if pvb.Spec.Pod.Namespace != originalNamespace || pvb.Annotations[podvolume.MovedPVCAnnotation] != claimName {
+			continue
+		}
+		if pvb.Status.Phase != velerov1api.PodVolumeBackupPhaseCompleted || pvb.Status.SnapshotID == "" {
+			continue
+		}
+
+		ctx.log.Infof("Restoring moved snapshot data into persistent volume claim %s/%s", namespace, claimName)
+		return ctx.restoreClaimData(pvb, namespace, claimName)
+	}
+
+	return nil
+}
+
+func deleteDataRestorePod(podClient client.Dynamic, name string) error {
+	if err := podClient.Delete(name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error deleting data restore pod %s", name)
+	}
+	return nil
+}
+
+// newDataRestorePod returns a pod that mounts the given claim under the
+// volume name used by the pod volume backup. The pod's restic-wait init
+// container blocks until the restic restore has written into the volume, and
+// its main container exits straight afterwards.
+func newDataRestorePod(restore *velerov1api.Restore, pvb *velerov1api.PodVolumeBackup, namespace, claimName string) *v1.Pod {
+	mount := &v1.VolumeMount{
+		Name:      pvb.Spec.Volume,
+		MountPath: "/restores/" + pvb.Spec.Volume,
+	}
+
+	image := initContainerImage(defaultImageBase)
+	initContainer := newResticInitContainerBuilder(image, string(restore.UID)).VolumeMounts(mount).Result()
+
+	container := initContainer.DeepCopy()
+	container.Name = dataRestoreContainer
+
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1.SchemeGroupVersion.String(),
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      label.GetValidName(restore.Name + "-" + pvb.Name),
+			Labels: map[string]string{
+				velerov1api.RestoreNameLabel: label.GetValidName(restore.Name),
+				velerov1api.RestoreUIDLabel:  string(restore.UID),
+			},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy:  v1.RestartPolicyNever,
+			InitContainers: []v1.Container{*initContainer},
+			Containers:     []v1.Container{*container},
+			Volumes: []v1.Volume{
+				{
+					Name: pvb.Spec.Volume,
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+							ClaimName: claimName,
+						},
+					},
+				},
+			},
+		},
+	}
+}
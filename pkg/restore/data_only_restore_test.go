@@ -0,0 +1,211 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	go_context "context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/test"
+	"github.com/heptio/velero/pkg/volume"
+)
+
+type fakeResticRestorerFactory struct {
+	restorer *fakeResticRestorer
+}
+
+func (f *fakeResticRestorerFactory) NewRestorer(go_context.Context, *velerov1api.Restore) (podvolume.Restorer, error) {
+	return f.restorer, nil
+}
+
+type fakeResticRestorer struct {
+	sync.Mutex
+	restores []podvolume.RestoreData
+}
+
+func (r *fakeResticRestorer) RestorePodVolumes(data podvolume.RestoreData) []error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.restores = append(r.restores, data)
+	return nil
+}
+
+func TestRestoreVolumeData(t *testing.T) {
+	pvb := func(name, ns, claim string) *velerov1api.PodVolumeBackup {
+		return builder.ForPodVolumeBackup(velerov1api.DefaultNamespace, name).
+			ObjectMeta(builder.WithAnnotations(podvolume.PVCNameAnnotation, claim)).
+			Pod(ns, "pod-1").
+			Volume("data").
+			SnapshotID("snapshot-" + name).
+			Phase(velerov1api.PodVolumeBackupPhaseCompleted).
+			Result()
+	}
+
+	tests := []struct {
+		name             string
+		restore          *velerov1api.Restore
+		tarball          *test.APIResource
+		apiResources     []*test.APIResource
+		podVolumeBackups []*velerov1api.PodVolumeBackup
+		volumeSnapshots  []*volume.Snapshot
+		wantClaims       []string
+		wantWarnings     Result
+		wantErrs         Result
+	}{
+		{
+			name:    "restic backups are restored into existing claims with the same name",
+			restore: defaultRestore().Mode(velerov1api.RestoreModeDataOnly).Result(),
+			apiResources: []*test.APIResource{
+				test.PVCs(builder.ForPersistentVolumeClaim("ns-1", "pvc-1").Result()),
+			},
+			podVolumeBackups: []*velerov1api.PodVolumeBackup{
+				pvb("pvb-1", "ns-1", "pvc-1"),
+				// a second backup of the same claim from another pod is ignored
+				pvb("pvb-2", "ns-1", "pvc-1"),
+				// backups of volumes that aren't claims are ignored
+				pvb("pvb-3", "ns-1", ""),
+			},
+			wantClaims: []string{"ns-1/pvc-1"},
+		},
+		{
+			name:    "namespace mappings are applied to the target claims",
+			restore: defaultRestore().Mode(velerov1api.RestoreModeDataOnly).NamespaceMappings("ns-1", "ns-2").Result(),
+			apiResources: []*test.APIResource{
+				test.PVCs(builder.ForPersistentVolumeClaim("ns-2", "pvc-1").Result()),
+			},
+			podVolumeBackups: []*velerov1api.PodVolumeBackup{pvb("pvb-1", "ns-1", "pvc-1")},
+			wantClaims:       []string{"ns-2/pvc-1"},
+		},
+		{
+			name:    "claims whose backed-up labels don't match the label selector are skipped",
+			restore: defaultRestore().Mode(velerov1api.RestoreModeDataOnly).LabelSelector(&metav1.LabelSelector{MatchLabels: map[string]string{"a": "b"}}).Result(),
+			tarball: test.PVCs(
+				builder.ForPersistentVolumeClaim("ns-1", "pvc-1").ObjectMeta(builder.WithLabels("a", "b")).Result(),
+				builder.ForPersistentVolumeClaim("ns-1", "pvc-2").Result(),
+			),
+			apiResources: []*test.APIResource{
+				test.PVCs(
+					builder.ForPersistentVolumeClaim("ns-1", "pvc-1").Result(),
+					builder.ForPersistentVolumeClaim("ns-1", "pvc-2").Result(),
+				),
+			},
+			podVolumeBackups: []*velerov1api.PodVolumeBackup{
+				pvb("pvb-1", "ns-1", "pvc-1"),
+				pvb("pvb-2", "ns-1", "pvc-2"),
+			},
+			wantClaims: []string{"ns-1/pvc-1"},
+		},
+		{
+			name:    "excluded namespaces are skipped",
+			restore: defaultRestore().Mode(velerov1api.RestoreModeDataOnly).ExcludedNamespaces("ns-2").Result(),
+			apiResources: []*test.APIResource{
+				test.PVCs(
+					builder.ForPersistentVolumeClaim("ns-1", "pvc-1").Result(),
+					builder.ForPersistentVolumeClaim("ns-2", "pvc-1").Result(),
+				),
+			},
+			podVolumeBackups: []*velerov1api.PodVolumeBackup{
+				pvb("pvb-1", "ns-1", "pvc-1"),
+				pvb("pvb-2", "ns-2", "pvc-1"),
+			},
+			wantClaims: []string{"ns-1/pvc-1"},
+		},
+		{
+			name:             "a missing claim is an error",
+			restore:          defaultRestore().Mode(velerov1api.RestoreModeDataOnly).Result(),
+			apiResources:     []*test.APIResource{test.PVCs()},
+			podVolumeBackups: []*velerov1api.PodVolumeBackup{pvb("pvb-1", "ns-1", "pvc-1")},
+			wantErrs: Result{
+				Namespaces: map[string][]string{
+					"ns-1": {"persistent volume claim ns-1/pvc-1 not found: data-only restores only restore into existing claims"},
+				},
+			},
+		},
+		{
+			name:    "volume snapshots aren't restored",
+			restore: defaultRestore().Mode(velerov1api.RestoreModeDataOnly).Result(),
+			volumeSnapshots: []*volume.Snapshot{
+				{Spec: volume.SnapshotSpec{PersistentVolumeName: "pv-1"}},
+			},
+			wantWarnings: Result{
+				Velero: []string{"not restored: volume snapshot of persistent volume pv-1 can't be restored into an existing claim"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHarness(t)
+			restorer := &fakeResticRestorer{}
+			h.restorer.resticRestorerFactory = &fakeResticRestorerFactory{restorer: restorer}
+
+			for _, r := range tc.apiResources {
+				h.addItems(t, r)
+			}
+
+			tarball := newTarWriter(t)
+			if tc.tarball != nil {
+				tarball.addItems(tc.tarball.GVR().Resource, tc.tarball.Items...)
+			}
+
+			warnings, errs := h.restorer.Restore(
+				Request{
+					Log:              h.log,
+					Restore:          tc.restore,
+					Backup:           defaultBackup().Result(),
+					PodVolumeBackups: tc.podVolumeBackups,
+					VolumeSnapshots:  tc.volumeSnapshots,
+					BackupReader:     tarball.done(),
+				},
+				nil, // actions
+				nil, // snapshot location lister
+				nil, // volume snapshotter getter
+			)
+
+			assert.Equal(t, tc.wantWarnings, warnings)
+			assert.Equal(t, tc.wantErrs, errs)
+
+			var gotClaims []string
+			for _, data := range restorer.restores {
+				require.Len(t, data.Pod.Spec.Volumes, 1)
+				require.NotNil(t, data.Pod.Spec.Volumes[0].PersistentVolumeClaim)
+				gotClaims = append(gotClaims, data.Pod.Namespace+"/"+data.Pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+
+				assert.Equal(t, podvolume.InitContainer, data.Pod.Spec.InitContainers[0].Name)
+				require.Len(t, data.PodVolumeBackups, 1)
+				assert.Equal(t, data.Pod.Name, data.PodVolumeBackups[0].Spec.Pod.Name)
+				assert.Equal(t, map[string]string{"data": data.PodVolumeBackups[0].Status.SnapshotID}, podvolume.GetVolumeBackupsForPod(data.PodVolumeBackups, data.Pod))
+
+				// the helper pod is deleted once the restore is done
+				_, err := h.DynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).Namespace(data.Pod.Namespace).Get(data.Pod.Name, metav1.GetOptions{})
+				assert.True(t, apierrors.IsNotFound(err))
+			}
+			assert.Equal(t, tc.wantClaims, gotClaims)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/velero/pkg/kuberesource"
+)
+
+// DryRunResults are what a dry-run restore found it would do with the items
+// in the backup. Each is a Result whose messages are the IDs of the items.
+type DryRunResults struct {
+	// Created are the items that would be created, or updated if the
+	// restore uses server-side apply or merges service accounts.
+	Created Result
+
+	// Skipped are the items that already exist in the cluster unchanged
+	// from the backed-up version, or that a restore item action discarded.
+	Skipped Result
+
+	// Conflicts are the items that already exist in the cluster and differ
+	// from the backed-up version, or have fields managed by another client.
+	Conflicts Result
+
+	// lock guards the results, which are added to concurrently when a
+	// restore's items are restored concurrently.
+	lock sync.Mutex
+}
+
+func (r *DryRunResults) created(namespace, id string) {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	addToResult(&r.Created, namespace, errors.New(id))
+}
+
+func (r *DryRunResults) skipped(namespace, id string) {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	addToResult(&r.Skipped, namespace, errors.New(id))
+}
+
+func (r *DryRunResults) conflicts(namespace, id string) {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	addToResult(&r.Conflicts, namespace, errors.New(id))
+}
+
+// dryRun returns whether the restore is a dry run.
+func (ctx *context) dryRun() bool {
+	return ctx.restore.Spec.DryRun
+}
+
+// dryRunNamespace sends a dry-run create for a namespace that the restore
+// would create before restoring items into it, unless it already exists.
+func (ctx *context) dryRunNamespace(ns *v1.Namespace) error {
+	_, err := ctx.namespaceClient.Get(ns.Name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error getting namespace %s", ns.Name)
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ns)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Namespace")
+
+	nsClient, err := ctx.dynamicFactory.ClientForGroupVersionResource(schema.GroupVersion{Version: "v1"}, metav1.APIResource{Name: "namespaces"}, "")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := nsClient.Create(obj); err != nil {
+		return errors.Wrapf(err, "error creating namespace %s", ns.Name)
+	}
+
+	ctx.dryRunResults.created("", getResourceID(kuberesource.Namespaces, "", ns.Name))
+	ctx.dryRunNamespaces.Insert(ns.Name)
+
+	return nil
+}
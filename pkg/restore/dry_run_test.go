@@ -0,0 +1,120 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/test"
+)
+
+func TestDryRunResults(t *testing.T) {
+	// a restore that isn't a dry run has no results to add to
+	var nilResults *DryRunResults
+	nilResults.created("ns-1", "pods/ns-1/pod-1")
+	nilResults.skipped("ns-1", "pods/ns-1/pod-1")
+	nilResults.conflicts("ns-1", "pods/ns-1/pod-1")
+
+	results := new(DryRunResults)
+	results.created("", "namespaces/ns-1")
+	results.created("ns-1", "pods/ns-1/pod-1")
+	results.skipped("ns-1", "configmaps/ns-1/cm-1")
+	results.conflicts("ns-2", "secrets/ns-2/secret-1")
+
+	assert.Equal(t, &DryRunResults{
+		Created: Result{
+			Cluster:    []string{"namespaces/ns-1"},
+			Namespaces: map[string][]string{"ns-1": {"pods/ns-1/pod-1"}},
+		},
+		Skipped: Result{
+			Namespaces: map[string][]string{"ns-1": {"configmaps/ns-1/cm-1"}},
+		},
+		Conflicts: Result{
+			Namespaces: map[string][]string{"ns-2": {"secrets/ns-2/secret-1"}},
+		},
+	}, results)
+}
+
+func TestDryRunNamespace(t *testing.T) {
+	tests := []struct {
+		name              string
+		existing          *corev1api.Namespace
+		wantCreate        bool
+		wantResults       *DryRunResults
+		wantDryRunCreated []string
+	}{
+		{
+			name:        "a namespace that exists isn't created",
+			existing:    builder.ForNamespace("ns-1").Result(),
+			wantResults: new(DryRunResults),
+		},
+		{
+			name:       "a namespace that doesn't exist is created with a dry run",
+			wantCreate: true,
+			wantResults: &DryRunResults{
+				Created: Result{Cluster: []string{"namespaces/ns-1"}},
+			},
+			wantDryRunCreated: []string{"ns-1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			if tc.existing != nil {
+				kubeClient = kubefake.NewSimpleClientset(tc.existing)
+			}
+
+			dynamicFactory := &test.FakeDynamicFactory{}
+			nsClient := &test.FakeDynamicClient{}
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Version: "v1"}, metav1.APIResource{Name: "namespaces"}, "").Return(nsClient, nil)
+			nsClient.On("Create", mock.Anything).Return(&unstructured.Unstructured{}, nil)
+
+			ctx := &context{
+				namespaceClient:  kubeClient.CoreV1().Namespaces(),
+				dynamicFactory:   dynamicFactory,
+				dryRunResults:    new(DryRunResults),
+				dryRunNamespaces: sets.NewString(),
+			}
+
+			require.NoError(t, ctx.dryRunNamespace(builder.ForNamespace("ns-1").Result()))
+
+			if tc.wantCreate {
+				nsClient.AssertNumberOfCalls(t, "Create", 1)
+				created := nsClient.Calls[0].Arguments.Get(0).(*unstructured.Unstructured)
+				assert.Equal(t, "Namespace", created.GetKind())
+				assert.Equal(t, "ns-1", created.GetName())
+			} else {
+				nsClient.AssertNotCalled(t, "Create", mock.Anything)
+			}
+
+			assert.Equal(t, tc.wantResults, ctx.dryRunResults)
+			assert.Equal(t, sets.NewString(tc.wantDryRunCreated...), ctx.dryRunNamespaces)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/heptio/velero/pkg/discovery"
+	"github.com/heptio/velero/pkg/kuberesource"
+)
+
+// guardrails are the namespaces and resources that the server never allows
+// restores to touch, regardless of what the restore's spec includes.
+type guardrails struct {
+	protectedNamespaces sets.String
+	deniedResources     sets.String
+}
+
+// newGuardrails resolves the denied resources to fully-qualified group-resource
+// names using the discovery helper. Resources that can't be resolved, e.g. because
+// their CRD hasn't been restored yet, are matched by the name they're given as.
+func newGuardrails(helper discovery.Helper, protectedNamespaces, deniedResources []string) *guardrails {
+	g := &guardrails{
+		protectedNamespaces: sets.NewString(protectedNamespaces...),
+		deniedResources:     sets.NewString(),
+	}
+
+	for _, resource := range deniedResources {
+		gr := schema.ParseGroupResource(resource)
+		if gvr, _, err := helper.ResourceFor(gr.WithVersion("")); err == nil {
+			gr = gvr.GroupResource()
+		}
+		g.deniedResources.Insert(gr.String())
+	}
+
+	return g
+}
+
+// protectsNamespace returns true if items may not be restored into or
+// from the namespace.
+func (g *guardrails) protectsNamespace(namespace string) bool {
+	return g != nil && g.protectedNamespaces.Has(namespace)
+}
+
+// deniesResource returns true if items of the group-resource may not be
+// restored.
+func (g *guardrails) deniesResource(groupResource schema.GroupResource) bool {
+	return g != nil && g.deniedResources.Has(groupResource.String())
+}
+
+// deniesItem returns true if the item, which is being restored into the
+// given namespace, may not be restored. Protected namespaces themselves
+// may not be restored either.
+func (g *guardrails) deniesItem(groupResource schema.GroupResource, namespace, name string) bool {
+	if g.deniesResource(groupResource) || g.protectsNamespace(namespace) {
+		return true
+	}
+
+	return groupResource == kuberesource.Namespaces && g.protectsNamespace(name)
+}
+
+// protectedNamespace returns whichever of an item's backed-up and target
+// namespaces is protected, for reporting.
+func protectedNamespace(g *guardrails, namespace, targetNamespace string) string {
+	if g.protectsNamespace(namespace) {
+		return namespace
+	}
+	return targetNamespace
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Manifest is a record of the items that a restore created, so that the
+// restore can be undone by deleting them.
+type Manifest struct {
+	// Items are the items that the restore created, in the order they
+	// were created.
+	Items []ManifestItem `json:"items"`
+
+	// lock guards Items, which are added to concurrently when a restore's
+	// items are restored concurrently.
+	lock sync.Mutex
+}
+
+// ManifestItem identifies an item that a restore created.
+type ManifestItem struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Resource   string    `json:"resource"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name"`
+	UID        types.UID `json:"uid"`
+}
+
+// GroupVersionResource returns the group, version and resource of the item.
+func (i ManifestItem) GroupVersionResource() (schema.GroupVersionResource, error) {
+	gv, err := schema.ParseGroupVersion(i.APIVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, errors.WithStack(err)
+	}
+	return gv.WithResource(i.Resource), nil
+}
+
+// add records that the restore created obj. It's a no-op if m is nil.
+func (m *Manifest) add(groupResource schema.GroupResource, apiVersion, kind string, obj metav1.Object) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.Items = append(m.Items, ManifestItem{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Resource:   groupResource.Resource,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	})
+}
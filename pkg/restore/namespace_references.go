@@ -0,0 +1,196 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/velero/pkg/kuberesource"
+)
+
+// namespaceNameLabel is the label that the API server sets on each namespace
+// to the namespace's name, so that it can be selected by name.
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// namespaceReferences are the functions that rewrite the references to
+// namespaces in items of each group-resource, for the known references
+// that point across namespaces or from cluster-scoped items to namespaces.
+// Each function rewrites the references to the namespaces in the mapping,
+// and returns the paths of the fields it changed.
+var namespaceReferences = map[schema.GroupResource]func(obj map[string]interface{}, mapping map[string]string) []string{
+	kuberesource.RoleBindings:                    rewriteSubjectNamespaces,
+	kuberesource.ClusterRoleBindings:             rewriteSubjectNamespaces,
+	kuberesource.ValidatingWebhookConfigurations: rewriteWebhookNamespaces,
+	kuberesource.MutatingWebhookConfigurations:   rewriteWebhookNamespaces,
+	kuberesource.NetworkPolicies:                 rewriteNetworkPolicyNamespaces,
+	kuberesource.APIServices:                     rewriteAPIServiceNamespaces,
+	kuberesource.CustomResourceDefinitions:       rewriteCRDNamespaces,
+}
+
+// rewriteNamespaceReferences rewrites the known references to namespaces
+// in the item to the namespaces that they're mapped to, and returns the
+// paths of the fields it changed.
+func rewriteNamespaceReferences(groupResource schema.GroupResource, obj *unstructured.Unstructured, mapping map[string]string) []string {
+	rewrite, ok := namespaceReferences[groupResource]
+	if !ok || len(mapping) == 0 {
+		return nil
+	}
+
+	return rewrite(obj.Object, mapping)
+}
+
+// rewriteSubjectNamespaces rewrites the namespaces of the subjects of a
+// RoleBinding or ClusterRoleBinding, which are set for ServiceAccounts.
+func rewriteSubjectNamespaces(obj map[string]interface{}, mapping map[string]string) []string {
+	var changed []string
+
+	subjects, _ := obj["subjects"].([]interface{})
+	for i, subject := range subjects {
+		if rewriteNamespaceField(subject, mapping, "namespace") {
+			changed = append(changed, fieldPath("subjects", i, "namespace"))
+		}
+	}
+
+	return changed
+}
+
+// rewriteWebhookNamespaces rewrites the namespaces of the services that
+// the webhooks of a ValidatingWebhookConfiguration or a
+// MutatingWebhookConfiguration call, and their namespace selectors.
+func rewriteWebhookNamespaces(obj map[string]interface{}, mapping map[string]string) []string {
+	var changed []string
+
+	webhooks, _ := obj["webhooks"].([]interface{})
+	for i, webhook := range webhooks {
+		if rewriteNamespaceField(webhook, mapping, "clientConfig", "service", "namespace") {
+			changed = append(changed, fieldPath("webhooks", i, "clientConfig.service.namespace"))
+		}
+		if webhook, ok := webhook.(map[string]interface{}); ok && rewriteNamespaceSelector(webhook["namespaceSelector"], mapping) {
+			changed = append(changed, fieldPath("webhooks", i, "namespaceSelector"))
+		}
+	}
+
+	return changed
+}
+
+// rewriteNetworkPolicyNamespaces rewrites the namespace selectors of the
+// peers of a NetworkPolicy's ingress and egress rules that select
+// namespaces by name.
+func rewriteNetworkPolicyNamespaces(obj map[string]interface{}, mapping map[string]string) []string {
+	var changed []string
+
+	spec, _ := obj["spec"].(map[string]interface{})
+	for _, rules := range []struct{ direction, peers string }{{"ingress", "from"}, {"egress", "to"}} {
+		ruleList, _ := spec[rules.direction].([]interface{})
+		for i, rule := range ruleList {
+			rule, _ := rule.(map[string]interface{})
+			peers, _ := rule[rules.peers].([]interface{})
+			for j, peer := range peers {
+				peer, _ := peer.(map[string]interface{})
+				if rewriteNamespaceSelector(peer["namespaceSelector"], mapping) {
+					changed = append(changed, fieldPath("spec."+rules.direction, i, fieldPath(rules.peers, j, "namespaceSelector")))
+				}
+			}
+		}
+	}
+
+	return changed
+}
+
+// rewriteAPIServiceNamespaces rewrites the namespace of the service that
+// an APIService proxies to.
+func rewriteAPIServiceNamespaces(obj map[string]interface{}, mapping map[string]string) []string {
+	if rewriteNamespaceField(obj, mapping, "spec", "service", "namespace") {
+		return []string{"spec.service.namespace"}
+	}
+	return nil
+}
+
+// rewriteCRDNamespaces rewrites the namespace of the service of a
+// CustomResourceDefinition's conversion webhook, which is in a different
+// field in the v1 and v1beta1 versions of the API.
+func rewriteCRDNamespaces(obj map[string]interface{}, mapping map[string]string) []string {
+	var changed []string
+
+	if rewriteNamespaceField(obj, mapping, "spec", "conversion", "webhook", "clientConfig", "service", "namespace") {
+		changed = append(changed, "spec.conversion.webhook.clientConfig.service.namespace")
+	}
+	if rewriteNamespaceField(obj, mapping, "spec", "conversion", "webhookClientConfig", "service", "namespace") {
+		changed = append(changed, "spec.conversion.webhookClientConfig.service.namespace")
+	}
+
+	return changed
+}
+
+// rewriteNamespaceSelector rewrites the namespace names that a label selector
+// selects with the namespace name label, in its matchLabels or its
+// matchExpressions, and returns true if it changed any of them.
+func rewriteNamespaceSelector(selector interface{}, mapping map[string]string) bool {
+	selectorMap, ok := selector.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	changed := rewriteNamespaceField(selectorMap, mapping, "matchLabels", namespaceNameLabel)
+
+	expressions, _ := selectorMap["matchExpressions"].([]interface{})
+	for _, expression := range expressions {
+		expression, ok := expression.(map[string]interface{})
+		if !ok || expression["key"] != namespaceNameLabel {
+			continue
+		}
+
+		values, _ := expression["values"].([]interface{})
+		for i, value := range values {
+			name, _ := value.(string)
+			if target, ok := mapping[name]; ok {
+				values[i] = target
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// rewriteNamespaceField rewrites the namespace at the given path in obj,
+// if it's mapped, and returns true if it changed it.
+func rewriteNamespaceField(obj interface{}, mapping map[string]string, fields ...string) bool {
+	objMap, ok := obj.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	namespace, found, err := unstructured.NestedString(objMap, fields...)
+	if err != nil || !found {
+		return false
+	}
+
+	target, ok := mapping[namespace]
+	if !ok {
+		return false
+	}
+
+	return unstructured.SetNestedField(objMap, target, fields...) == nil
+}
+
+func fieldPath(list string, index int, field string) string {
+	return list + "[" + strconv.Itoa(index) + "]." + field
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/velero/pkg/kuberesource"
+)
+
+func TestRewriteNamespaceReferences(t *testing.T) {
+	mapping := map[string]string{"ns-1": "ns-2", "monitoring": "monitoring-restored"}
+
+	tests := []struct {
+		name          string
+		groupResource schema.GroupResource
+		obj           map[string]interface{}
+		want          map[string]interface{}
+		wantChanged   []string
+	}{
+		{
+			name:          "role binding subjects in mapped namespaces are rewritten",
+			groupResource: kuberesource.RoleBindings,
+			obj: map[string]interface{}{
+				"subjects": []interface{}{
+					map[string]interface{}{"kind": "ServiceAccount", "namespace": "ns-1", "name": "sa-1"},
+					map[string]interface{}{"kind": "ServiceAccount", "namespace": "ns-3", "name": "sa-2"},
+					map[string]interface{}{"kind": "User", "name": "user-1"},
+				},
+			},
+			want: map[string]interface{}{
+				"subjects": []interface{}{
+					map[string]interface{}{"kind": "ServiceAccount", "namespace": "ns-2", "name": "sa-1"},
+					map[string]interface{}{"kind": "ServiceAccount", "namespace": "ns-3", "name": "sa-2"},
+					map[string]interface{}{"kind": "User", "name": "user-1"},
+				},
+			},
+			wantChanged: []string{"subjects[0].namespace"},
+		},
+		{
+			name:          "cluster role binding subjects in mapped namespaces are rewritten",
+			groupResource: kuberesource.ClusterRoleBindings,
+			obj: map[string]interface{}{
+				"subjects": []interface{}{
+					map[string]interface{}{"kind": "ServiceAccount", "namespace": "monitoring", "name": "prometheus"},
+				},
+			},
+			want: map[string]interface{}{
+				"subjects": []interface{}{
+					map[string]interface{}{"kind": "ServiceAccount", "namespace": "monitoring-restored", "name": "prometheus"},
+				},
+			},
+			wantChanged: []string{"subjects[0].namespace"},
+		},
+		{
+			name:          "webhook services and namespace selectors are rewritten",
+			groupResource: kuberesource.ValidatingWebhookConfigurations,
+			obj: map[string]interface{}{
+				"webhooks": []interface{}{
+					map[string]interface{}{
+						"clientConfig": map[string]interface{}{
+							"service": map[string]interface{}{"namespace": "ns-1", "name": "webhook"},
+						},
+						"namespaceSelector": map[string]interface{}{
+							"matchExpressions": []interface{}{
+								map[string]interface{}{"key": namespaceNameLabel, "operator": "NotIn", "values": []interface{}{"kube-system", "ns-1"}},
+								map[string]interface{}{"key": "team", "operator": "In", "values": []interface{}{"ns-1"}},
+							},
+						},
+					},
+					map[string]interface{}{
+						"clientConfig": map[string]interface{}{"url": "https://example.com"},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"webhooks": []interface{}{
+					map[string]interface{}{
+						"clientConfig": map[string]interface{}{
+							"service": map[string]interface{}{"namespace": "ns-2", "name": "webhook"},
+						},
+						"namespaceSelector": map[string]interface{}{
+							"matchExpressions": []interface{}{
+								map[string]interface{}{"key": namespaceNameLabel, "operator": "NotIn", "values": []interface{}{"kube-system", "ns-2"}},
+								map[string]interface{}{"key": "team", "operator": "In", "values": []interface{}{"ns-1"}},
+							},
+						},
+					},
+					map[string]interface{}{
+						"clientConfig": map[string]interface{}{"url": "https://example.com"},
+					},
+				},
+			},
+			wantChanged: []string{"webhooks[0].clientConfig.service.namespace", "webhooks[0].namespaceSelector"},
+		},
+		{
+			name:          "network policy peers that select mapped namespaces by name are rewritten",
+			groupResource: kuberesource.NetworkPolicies,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ingress": []interface{}{
+						map[string]interface{}{
+							"from": []interface{}{
+								map[string]interface{}{"podSelector": map[string]interface{}{}},
+								map[string]interface{}{
+									"namespaceSelector": map[string]interface{}{
+										"matchLabels": map[string]interface{}{namespaceNameLabel: "monitoring"},
+									},
+								},
+							},
+						},
+					},
+					"egress": []interface{}{
+						map[string]interface{}{
+							"to": []interface{}{
+								map[string]interface{}{
+									"namespaceSelector": map[string]interface{}{
+										"matchLabels": map[string]interface{}{"team": "monitoring"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ingress": []interface{}{
+						map[string]interface{}{
+							"from": []interface{}{
+								map[string]interface{}{"podSelector": map[string]interface{}{}},
+								map[string]interface{}{
+									"namespaceSelector": map[string]interface{}{
+										"matchLabels": map[string]interface{}{namespaceNameLabel: "monitoring-restored"},
+									},
+								},
+							},
+						},
+					},
+					"egress": []interface{}{
+						map[string]interface{}{
+							"to": []interface{}{
+								map[string]interface{}{
+									"namespaceSelector": map[string]interface{}{
+										"matchLabels": map[string]interface{}{"team": "monitoring"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantChanged: []string{"spec.ingress[0].from[1].namespaceSelector"},
+		},
+		{
+			name:          "API service's service is rewritten",
+			groupResource: kuberesource.APIServices,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"service": map[string]interface{}{"namespace": "monitoring", "name": "metrics"},
+				},
+			},
+			want: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"service": map[string]interface{}{"namespace": "monitoring-restored", "name": "metrics"},
+				},
+			},
+			wantChanged: []string{"spec.service.namespace"},
+		},
+		{
+			name:          "CRD conversion webhook's service is rewritten",
+			groupResource: kuberesource.CustomResourceDefinitions,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"conversion": map[string]interface{}{
+						"strategy": "Webhook",
+						"webhookClientConfig": map[string]interface{}{
+							"service": map[string]interface{}{"namespace": "ns-1", "name": "converter"},
+						},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"conversion": map[string]interface{}{
+						"strategy": "Webhook",
+						"webhookClientConfig": map[string]interface{}{
+							"service": map[string]interface{}{"namespace": "ns-2", "name": "converter"},
+						},
+					},
+				},
+			},
+			wantChanged: []string{"spec.conversion.webhookClientConfig.service.namespace"},
+		},
+		{
+			name:          "other resources aren't changed",
+			groupResource: kuberesource.Pods,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{"serviceAccountName": "ns-1"},
+			},
+			want: map[string]interface{}{
+				"spec": map[string]interface{}{"serviceAccountName": "ns-1"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: tc.obj}
+
+			changed := rewriteNamespaceReferences(tc.groupResource, obj, mapping)
+
+			assert.Equal(t, tc.wantChanged, changed)
+			assert.Equal(t, tc.want, obj.Object)
+		})
+	}
+}
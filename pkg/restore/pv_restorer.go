@@ -22,6 +22,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/credentials"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
 	"github.com/heptio/velero/pkg/util/boolptr"
 	"github.com/heptio/velero/pkg/volume"
@@ -87,7 +88,12 @@ func (r *pvRestorer) executePVAction(obj *unstructured.Unstructured) (*unstructu
 		return nil, errors.WithStack(err)
 	}
 
-	if err := volumeSnapshotter.Init(snapshotInfo.location.Spec.Config); err != nil {
+	config, err := credentials.WithCredentialsFile(snapshotInfo.location.Spec.Config, snapshotInfo.location.Spec.Credential)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := volumeSnapshotter.Init(config); err != nil {
 		return nil, errors.WithStack(err)
 	}
 
@@ -0,0 +1,175 @@
+/*
+Copyright 2017 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/discovery"
+)
+
+// resourceModifier is a restore's resource modifier rule, resolved so that
+// it can be matched against items.
+type resourceModifier struct {
+	groupResource schema.GroupResource
+	namespaces    sets.String
+	name          string
+	selector      labels.Selector
+
+	// tests are the rule's test operations, which are checked before
+	// the rest of its operations, in patch, are applied.
+	tests jsonpatch.Patch
+	patch jsonpatch.Patch
+}
+
+// newResourceModifiers resolves the group-resources of a restore's resource
+// modifier rules to fully-qualified names using the discovery helper, and
+// decodes their patches. Resources that can't be resolved, e.g. because
+// their CRD hasn't been restored yet, are matched by the name they're given as.
+func newResourceModifiers(helper discovery.Helper, rules []velerov1api.RestoreResourceModifier) ([]*resourceModifier, error) {
+	var modifiers []*resourceModifier
+
+	for i, rule := range rules {
+		gr := schema.ParseGroupResource(rule.GroupResource)
+		if gvr, _, err := helper.ResourceFor(gr.WithVersion("")); err == nil {
+			gr = gvr.GroupResource()
+		}
+
+		// a nil LabelSelector would otherwise be converted to a selector
+		// that matches nothing.
+		selector := labels.Everything()
+		if rule.LabelSelector != nil {
+			var err error
+			if selector, err = metav1.LabelSelectorAsSelector(rule.LabelSelector); err != nil {
+				return nil, errors.Wrapf(err, "error parsing label selector of resource modifier %d", i)
+			}
+		}
+
+		var tests, operations []velerov1api.JSONPatchOperation
+		for _, operation := range rule.Patches {
+			if operation.Operation == "test" {
+				tests = append(tests, operation)
+			} else {
+				operations = append(operations, operation)
+			}
+		}
+
+		modifier := &resourceModifier{
+			groupResource: gr,
+			namespaces:    sets.NewString(rule.Namespaces...),
+			name:          rule.Name,
+			selector:      selector,
+		}
+
+		var err error
+		if modifier.tests, err = decodePatch(tests); err != nil {
+			return nil, errors.Wrapf(err, "error decoding patches of resource modifier %d", i)
+		}
+		if modifier.patch, err = decodePatch(operations); err != nil {
+			return nil, errors.Wrapf(err, "error decoding patches of resource modifier %d", i)
+		}
+
+		modifiers = append(modifiers, modifier)
+	}
+
+	return modifiers, nil
+}
+
+// decodePatch converts JSON patch operations to a jsonpatch.Patch. The
+// operations' fields have the names that RFC 6902 gives them, so they're
+// decoded from their JSON encoding.
+func decodePatch(operations []velerov1api.JSONPatchOperation) (jsonpatch.Patch, error) {
+	if len(operations) == 0 {
+		return nil, nil
+	}
+
+	patchJSON, err := json.Marshal(operations)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return patch, nil
+}
+
+// matches returns true if the rule applies to the item, which is of the
+// given group-resource and is in the given namespace in the backup.
+func (m *resourceModifier) matches(groupResource schema.GroupResource, namespace string, obj *unstructured.Unstructured) bool {
+	if m.groupResource != groupResource {
+		return false
+	}
+	if m.namespaces.Len() > 0 && !m.namespaces.Has(namespace) {
+		return false
+	}
+	if m.name != "" && m.name != obj.GetName() {
+		return false
+	}
+	return m.selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// applyResourceModifiers applies the rules that match the item to it, in
+// order, and returns the modified item. The item's namespace is its
+// namespace in the backup.
+func applyResourceModifiers(modifiers []*resourceModifier, groupResource schema.GroupResource, namespace string, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	for i, modifier := range modifiers {
+		if !modifier.matches(groupResource, namespace, obj) {
+			continue
+		}
+
+		itemJSON, err := obj.MarshalJSON()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		// a failed test means the rule doesn't apply to the item.
+		if len(modifier.tests) > 0 {
+			if _, err := modifier.tests.Apply(itemJSON); err != nil {
+				continue
+			}
+		}
+
+		if len(modifier.patch) == 0 {
+			continue
+		}
+
+		patchedJSON, err := modifier.patch.Apply(itemJSON)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error applying resource modifier %d", i)
+		}
+
+		patched := new(unstructured.Unstructured)
+		if err := patched.UnmarshalJSON(patchedJSON); err != nil {
+			return nil, errors.Wrapf(err, "error applying resource modifier %d", i)
+		}
+		obj = patched
+	}
+
+	return obj, nil
+}
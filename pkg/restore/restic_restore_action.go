@@ -34,14 +34,15 @@ import (
 	velerov1client "github.com/heptio/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	"github.com/heptio/velero/pkg/plugin/framework"
 	"github.com/heptio/velero/pkg/plugin/velero"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podvolume"
 	"github.com/heptio/velero/pkg/util/kube"
 )
 
 const (
-	defaultImageBase       = "gcr.io/heptio-images/velero-restic-restore-helper"
-	defaultCPURequestLimit = "100m"
-	defaultMemRequestLimit = "128Mi"
+	defaultImageBase        = "gcr.io/heptio-images/velero-restic-restore-helper"
+	defaultWindowsImageBase = "gcr.io/heptio-images/velero-restic-restore-helper-windows"
+	defaultCPURequestLimit  = "100m"
+	defaultMemRequestLimit  = "128Mi"
 )
 
 type ResticRestoreAction struct {
@@ -75,7 +76,7 @@ func (a *ResticRestoreAction) Execute(input *velero.RestoreItemActionExecuteInpu
 
 	log := a.logger.WithField("pod", kube.NamespaceAndName(&pod))
 
-	opts := restic.NewPodVolumeBackupListOptions(input.Restore.Spec.BackupName)
+	opts := podvolume.NewPodVolumeBackupListOptions(input.Restore.Spec.BackupName)
 	podVolumeBackupList, err := a.podVolumeBackupClient.List(opts)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -85,7 +86,7 @@ func (a *ResticRestoreAction) Execute(input *velero.RestoreItemActionExecuteInpu
 	for i := range podVolumeBackupList.Items {
 		podVolumeBackups = append(podVolumeBackups, &podVolumeBackupList.Items[i])
 	}
-	volumeSnapshots := restic.GetVolumeBackupsForPod(podVolumeBackups, &pod)
+	volumeSnapshots := podvolume.GetVolumeBackupsForPod(podVolumeBackups, &pod)
 	if len(volumeSnapshots) == 0 {
 		log.Debug("No restic backups found for pod")
 		return velero.NewRestoreItemActionExecuteOutput(input.Item), nil
@@ -101,7 +102,16 @@ func (a *ResticRestoreAction) Execute(input *velero.RestoreItemActionExecuteInpu
 		return nil, err
 	}
 
+	// pods on Windows nodes need a Windows image for the init container, and
+	// Windows paths to mount their volumes at
+	windows := isWindowsPod(&pod)
+
 	image := getImage(log, config)
+	restoresDir := "/restores/"
+	if windows {
+		image = getWindowsImage(log, config)
+		restoresDir = `C:\restores\`
+	}
 	log.Infof("Using image %q", image)
 
 	cpuRequest, memRequest := getResourceRequests(log, config)
@@ -119,16 +129,43 @@ func (a *ResticRestoreAction) Execute(input *velero.RestoreItemActionExecuteInpu
 	initContainerBuilder := newResticInitContainerBuilder(image, string(input.Restore.UID))
 	initContainerBuilder.Resources(&resourceReqs)
 
+	// block volumes are added to the init container as devices, and the
+	// done files of their restores are written to an emptyDir volume.
+	blockVolumes := podvolume.GetBlockVolumesForPod(podVolumeBackups, &pod)
+
 	for volumeName := range volumeSnapshots {
+		if blockVolumes[volumeName] {
+			initContainerBuilder.VolumeDevices(&corev1.VolumeDevice{
+				Name:       volumeName,
+				DevicePath: restoresDir + volumeName,
+			})
+			continue
+		}
+
 		mount := &corev1.VolumeMount{
 			Name:      volumeName,
-			MountPath: "/restores/" + volumeName,
+			MountPath: restoresDir + volumeName,
 		}
 		initContainerBuilder.VolumeMounts(mount)
 	}
 
+	if len(blockVolumes) > 0 {
+		initContainerBuilder.VolumeMounts(&corev1.VolumeMount{
+			Name:      podvolume.BlockRestoresVolume,
+			MountPath: podvolume.BlockRestoresDir,
+		})
+		if !hasVolume(&pod, podvolume.BlockRestoresVolume) {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+				Name: podvolume.BlockRestoresVolume,
+				VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{},
+				},
+			})
+		}
+	}
+
 	initContainer := *initContainerBuilder.Result()
-	if len(pod.Spec.InitContainers) == 0 || pod.Spec.InitContainers[0].Name != restic.InitContainer {
+	if len(pod.Spec.InitContainers) == 0 || pod.Spec.InitContainers[0].Name != podvolume.InitContainer {
 		pod.Spec.InitContainers = append([]corev1.Container{initContainer}, pod.Spec.InitContainers...)
 	} else {
 		pod.Spec.InitContainers[0] = initContainer
@@ -142,13 +179,45 @@ func (a *ResticRestoreAction) Execute(input *velero.RestoreItemActionExecuteInpu
 	return velero.NewRestoreItemActionExecuteOutput(&unstructured.Unstructured{Object: res}), nil
 }
 
+// hasVolume returns true if the pod has a volume with the given name, or
+// false otherwise.
+func hasVolume(pod *corev1.Pod, name string) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isWindowsPod returns true if the pod is scheduled onto Windows nodes
+// by its node selector, or false otherwise.
+func isWindowsPod(pod *corev1.Pod) bool {
+	for _, key := range []string{"kubernetes.io/os", "beta.kubernetes.io/os"} {
+		if pod.Spec.NodeSelector[key] == "windows" {
+			return true
+		}
+	}
+	return false
+}
+
 func getImage(log logrus.FieldLogger, config *corev1.ConfigMap) string {
+	return getConfiguredImage(log, config, "image", defaultImageBase)
+}
+
+// getWindowsImage returns the image of the init container added to pods
+// on Windows nodes.
+func getWindowsImage(log logrus.FieldLogger, config *corev1.ConfigMap) string {
+	return getConfiguredImage(log, config, "windowsImage", defaultWindowsImageBase)
+}
+
+func getConfiguredImage(log logrus.FieldLogger, config *corev1.ConfigMap, key, defaultImageBase string) string {
 	if config == nil {
 		log.Debug("No config found for plugin")
 		return initContainerImage(defaultImageBase)
 	}
 
-	image := config.Data["image"]
+	image := config.Data[key]
 	if image == "" {
 		log.Debugf("No custom image configured")
 		return initContainerImage(defaultImageBase)
@@ -225,7 +294,7 @@ func getPluginConfig(kind framework.PluginKind, name string, client corev1client
 }
 
 func newResticInitContainerBuilder(image, restoreUID string) *builder.ContainerBuilder {
-	return builder.ForContainer(restic.InitContainer, image).
+	return builder.ForContainer(podvolume.InitContainer, image).
 		Args(restoreUID).
 		Env([]*corev1.EnvVar{
 			{
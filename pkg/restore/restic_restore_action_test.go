@@ -99,9 +99,10 @@ func TestResticRestoreActionExecute(t *testing.T) {
 	)
 
 	tests := []struct {
-		name string
-		pod  *corev1api.Pod
-		want *corev1api.Pod
+		name             string
+		pod              *corev1api.Pod
+		podVolumeBackups []*api.PodVolumeBackup
+		want             *corev1api.Pod
 	}{
 		{
 			name: "Restoring pod with no other initContainers adds the restic initContainer",
@@ -133,6 +134,48 @@ func TestResticRestoreActionExecute(t *testing.T) {
 					builder.ForContainer("first-container", "").Result()).
 				Result(),
 		},
+		{
+			name: "Restoring pod on Windows nodes adds the Windows restic initContainer",
+			pod: builder.ForPod("ns-1", "pod").ObjectMeta(
+				builder.WithAnnotations("snapshot.velero.io/myvol", "")).
+				NodeSelector(map[string]string{"kubernetes.io/os": "windows"}).
+				Result(),
+			want: builder.ForPod("ns-1", "pod").
+				ObjectMeta(
+					builder.WithAnnotations("snapshot.velero.io/myvol", "")).
+				NodeSelector(map[string]string{"kubernetes.io/os": "windows"}).
+				InitContainers(
+					newResticInitContainerBuilder(initContainerImage(defaultWindowsImageBase), "").
+						Resources(&resourceReqs).
+						VolumeMounts(builder.ForVolumeMount("myvol", `C:\restores\myvol`).Result()).Result()).
+				Result(),
+		},
+		{
+			name: "Restoring pod with a block volume adds it to the restic initContainer as a device",
+			pod:  builder.ForPod("ns-1", "pod").Result(),
+			podVolumeBackups: []*api.PodVolumeBackup{
+				builder.ForPodVolumeBackup("velero", "pvb-1").
+					ObjectMeta(builder.WithLabels(api.BackupNameLabel, "backup-1")).
+					Pod("ns-1", "pod").
+					Volume("myvol").
+					VolumeMode(corev1api.PersistentVolumeBlock).
+					SnapshotID("snapshot-1").
+					Result(),
+			},
+			want: builder.ForPod("ns-1", "pod").
+				Volumes(&corev1api.Volume{
+					Name: "velero-block-restores",
+					VolumeSource: corev1api.VolumeSource{
+						EmptyDir: &corev1api.EmptyDirVolumeSource{},
+					},
+				}).
+				InitContainers(
+					newResticInitContainerBuilder(initContainerImage(defaultImageBase), "").
+						Resources(&resourceReqs).
+						VolumeDevices(&corev1api.VolumeDevice{Name: "myvol", DevicePath: "/restores/myvol"}).
+						VolumeMounts(builder.ForVolumeMount("velero-block-restores", "/block-restores").Result()).Result()).
+				Result(),
+		},
 	}
 
 	for _, tc := range tests {
@@ -146,11 +189,16 @@ func TestResticRestoreActionExecute(t *testing.T) {
 				},
 				Restore: builder.ForRestore("velero", "my-restore").
 					Phase(api.RestorePhaseInProgress).
+					Backup("backup-1").
 					Result(),
 			}
 
 			clientset := fake.NewSimpleClientset()
 			clientsetVelero := velerofake.NewSimpleClientset()
+			for _, pvb := range tc.podVolumeBackups {
+				_, err := clientsetVelero.VeleroV1().PodVolumeBackups(pvb.Namespace).Create(pvb)
+				require.NoError(t, err)
+			}
 			a := NewResticRestoreAction(
 				logrus.StandardLogger(),
 				clientset.CoreV1().ConfigMaps("velero"),
@@ -17,6 +17,7 @@ limitations under the License.
 package restore
 
 import (
+	"archive/tar"
 	go_context "context"
 	"encoding/json"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -45,12 +47,16 @@ import (
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/csi"
 	"github.com/heptio/velero/pkg/discovery"
 	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
 	"github.com/heptio/velero/pkg/kuberesource"
 	"github.com/heptio/velero/pkg/label"
+	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/velero"
-	"github.com/heptio/velero/pkg/restic"
+	"github.com/heptio/velero/pkg/podexec"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/resourcepolicies"
 	"github.com/heptio/velero/pkg/util/boolptr"
 	"github.com/heptio/velero/pkg/util/collections"
 	"github.com/heptio/velero/pkg/util/filesystem"
@@ -71,6 +77,25 @@ type Request struct {
 	PodVolumeBackups []*velerov1api.PodVolumeBackup
 	VolumeSnapshots  []*volume.Snapshot
 	BackupReader     io.Reader
+
+	// ItemIndex is the backup's item index. It's only needed for backups
+	// that refer to unchanged items stored in other backups' tarballs.
+	ItemIndex persistence.BackupItemIndex
+	// GetReferencedBackupContents returns the contents of a backup that the
+	// backup being restored refers to for its unchanged items.
+	GetReferencedBackupContents func(backup string) (io.ReadCloser, error)
+
+	// Manifest, if non-nil, has the items that the restore creates added
+	// to it.
+	Manifest *Manifest
+
+	// DryRunResults, if non-nil, has what a dry-run restore would do with
+	// the items in the backup added to it.
+	DryRunResults *DryRunResults
+
+	// ResourcePolicies are the resource policies from the restore's
+	// spec.resourcePolicy ConfigMap, if it has one.
+	ResourcePolicies *resourcepolicies.Policies
 }
 
 // Restorer knows how to restore a backup.
@@ -87,17 +112,29 @@ type Restorer interface {
 type kubernetesRestorer struct {
 	discoveryHelper            discovery.Helper
 	dynamicFactory             client.DynamicFactory
+	dryRunDynamicFactory       client.DynamicFactory
 	namespaceClient            corev1.NamespaceInterface
-	resticRestorerFactory      restic.RestorerFactory
+	resticRestorerFactory      podvolume.RestorerFactory
 	resticTimeout              time.Duration
 	resourceTerminatingTimeout time.Duration
 	resourcePriorities         []string
+	podCommandExecutor         podexec.PodCommandExecutor
+	useServerSideApply         bool
+	protectedNamespaces        []string
+	deniedResources            []string
+	itemConcurrency            int
 	fileSystem                 filesystem.Interface
 	logger                     logrus.FieldLogger
 }
 
+// restoreFieldManager is the field manager name used when restoring
+// items with server-side apply.
+const restoreFieldManager = "velero-restore"
+
 // prioritizeResources returns an ordered, fully-resolved list of resources to restore based on
-// the provided discovery helper, resource priorities, and included/excluded resources.
+// the provided discovery helper, resource priorities, and included/excluded resources. Priorities
+// that can't be resolved, e.g. custom resources whose definitions haven't been restored yet, are
+// skipped.
 func prioritizeResources(helper discovery.Helper, priorities []string, includedResources *collections.IncludesExcludes, logger logrus.FieldLogger) ([]schema.GroupResource, error) {
 	var ret []schema.GroupResource
 
@@ -108,7 +145,8 @@ func prioritizeResources(helper discovery.Helper, priorities []string, includedR
 	for _, r := range priorities {
 		gvr, _, err := helper.ResourceFor(schema.ParseGroupResource(r).WithVersion(""))
 		if err != nil {
-			return nil, err
+			logger.WithError(err).WithField("resource", r).Info("Skipping resource priority that can't be resolved")
+			continue
 		}
 		gr := gvr.GroupResource()
 
@@ -158,21 +196,33 @@ func prioritizeResources(helper discovery.Helper, priorities []string, includedR
 func NewKubernetesRestorer(
 	discoveryHelper discovery.Helper,
 	dynamicFactory client.DynamicFactory,
+	dryRunDynamicFactory client.DynamicFactory,
 	resourcePriorities []string,
 	namespaceClient corev1.NamespaceInterface,
-	resticRestorerFactory restic.RestorerFactory,
+	resticRestorerFactory podvolume.RestorerFactory,
 	resticTimeout time.Duration,
 	resourceTerminatingTimeout time.Duration,
+	useServerSideApply bool,
+	protectedNamespaces []string,
+	deniedResources []string,
+	podCommandExecutor podexec.PodCommandExecutor,
+	itemConcurrency int,
 	logger logrus.FieldLogger,
 ) (Restorer, error) {
 	return &kubernetesRestorer{
 		discoveryHelper:            discoveryHelper,
 		dynamicFactory:             dynamicFactory,
+		dryRunDynamicFactory:       dryRunDynamicFactory,
 		namespaceClient:            namespaceClient,
 		resticRestorerFactory:      resticRestorerFactory,
 		resticTimeout:              resticTimeout,
 		resourceTerminatingTimeout: resourceTerminatingTimeout,
 		resourcePriorities:         resourcePriorities,
+		useServerSideApply:         useServerSideApply,
+		protectedNamespaces:        protectedNamespaces,
+		deniedResources:            deniedResources,
+		podCommandExecutor:         podCommandExecutor,
+		itemConcurrency:            itemConcurrency,
 		logger:                     logger,
 		fileSystem:                 filesystem.NewFileSystem(),
 	}, nil
@@ -203,10 +253,18 @@ func (kr *kubernetesRestorer) Restore(
 
 	// get resource includes-excludes
 	resourceIncludesExcludes := getResourceIncludesExcludes(kr.discoveryHelper, req.Restore.Spec.IncludedResources, req.Restore.Spec.ExcludedResources)
-	prioritizedResources, err := prioritizeResources(kr.discoveryHelper, kr.resourcePriorities, resourceIncludesExcludes, req.Log)
+	resourcePriorities := kr.resourcePriorities
+	if len(req.Restore.Spec.ResourcePriorities) > 0 {
+		resourcePriorities = req.Restore.Spec.ResourcePriorities
+	}
+	prioritizedResources, err := prioritizeResources(kr.discoveryHelper, resourcePriorities, resourceIncludesExcludes, req.Log)
 	if err != nil {
 		return Result{}, Result{Velero: []string{err.Error()}}
 	}
+	prioritizedResources = controlPlaneConfigLast(prioritizedResources)
+
+	// get included and excluded cluster-scoped items
+	clusterResourceNames := collections.GetClusterResourceNames(kr.discoveryHelper, req.Restore.Spec.IncludedClusterResourceNames, req.Restore.Spec.ExcludedClusterResourceNames)
 
 	// get namespace includes-excludes
 	namespaceIncludesExcludes := collections.NewIncludesExcludes().
@@ -218,6 +276,16 @@ func (kr *kubernetesRestorer) Restore(
 		return Result{}, Result{Velero: []string{err.Error()}}
 	}
 
+	resourceModifiers, err := newResourceModifiers(kr.discoveryHelper, req.Restore.Spec.ResourceModifiers)
+	if err != nil {
+		return Result{}, Result{Velero: []string{err.Error()}}
+	}
+
+	restoreHooks, err := newRestoreHooks(kr.discoveryHelper, req.Restore.Spec.Hooks.Resources)
+	if err != nil {
+		return Result{}, Result{Velero: []string{err.Error()}}
+	}
+
 	podVolumeTimeout := kr.resticTimeout
 	if val := req.Restore.Annotations[velerov1api.PodVolumeOperationTimeoutAnnotation]; val != "" {
 		parsed, err := time.ParseDuration(val)
@@ -231,7 +299,7 @@ func (kr *kubernetesRestorer) Restore(
 	ctx, cancelFunc := go_context.WithTimeout(go_context.Background(), podVolumeTimeout)
 	defer cancelFunc()
 
-	var resticRestorer restic.Restorer
+	var resticRestorer podvolume.Restorer
 	if kr.resticRestorerFactory != nil {
 		resticRestorer, err = kr.resticRestorerFactory.NewRestorer(ctx, req.Restore)
 		if err != nil {
@@ -239,11 +307,34 @@ func (kr *kubernetesRestorer) Restore(
 		}
 	}
 
+	dynamicFactory := kr.dynamicFactory
+	manifest := req.Manifest
+	restorePVs := req.Restore.Spec.RestorePVs
+	var dryRunResults *DryRunResults
+	if req.Restore.Spec.DryRun {
+		// a dry run doesn't create anything, so nothing is added to the
+		// manifest, and volumes aren't created from snapshots.
+		dynamicFactory = kr.dryRunDynamicFactory
+		manifest = nil
+		restorePVs = boolptr.False()
+		dryRunResults = req.DryRunResults
+		if dryRunResults == nil {
+			dryRunResults = new(DryRunResults)
+		}
+	}
+
+	// volumes snapshotted through the VolumeSnapshot API are restored by
+	// provisioning new volumes from their snapshots.
+	var csiSnapshotter *csi.Snapshotter
+	if !boolptr.IsSetToFalse(restorePVs) {
+		csiSnapshotter = csi.NewSnapshotter(dynamicFactory, 0)
+	}
+
 	pvRestorer := &pvRestorer{
 		logger:                  req.Log,
 		backup:                  req.Backup,
 		snapshotVolumes:         req.Backup.Spec.SnapshotVolumes,
-		restorePVs:              req.Restore.Spec.RestorePVs,
+		restorePVs:              restorePVs,
 		volumeSnapshots:         req.VolumeSnapshots,
 		volumeSnapshotterGetter: volumeSnapshotterGetter,
 		snapshotLocationLister:  snapshotLocationLister,
@@ -252,29 +343,48 @@ func (kr *kubernetesRestorer) Restore(
 	restoreCtx := &context{
 		backup:                     req.Backup,
 		backupReader:               req.BackupReader,
+		itemIndex:                  req.ItemIndex,
+		getReferencedBackup:        req.GetReferencedBackupContents,
+		manifest:                   manifest,
+		dryRunResults:              dryRunResults,
+		dryRunNamespaces:           sets.NewString(),
 		restore:                    req.Restore,
 		resourceIncludesExcludes:   resourceIncludesExcludes,
 		namespaceIncludesExcludes:  namespaceIncludesExcludes,
+		clusterResourceNames:       clusterResourceNames,
+		resourcePolicies:           req.ResourcePolicies,
 		prioritizedResources:       prioritizedResources,
+		resourcePriorities:         resourcePriorities,
+		discoveryHelper:            kr.discoveryHelper,
 		selector:                   selector,
 		log:                        req.Log,
-		dynamicFactory:             kr.dynamicFactory,
+		dynamicFactory:             dynamicFactory,
 		fileSystem:                 kr.fileSystem,
 		namespaceClient:            kr.namespaceClient,
 		actions:                    resolvedActions,
+		resourceModifiers:          resourceModifiers,
+		restoreHooks:               restoreHooks,
+		podCommandExecutor:         kr.podCommandExecutor,
 		volumeSnapshotterGetter:    volumeSnapshotterGetter,
 		resticRestorer:             resticRestorer,
 		pvsToProvision:             sets.NewString(),
+		translatedPVs:              sets.NewString(),
 		pvRestorer:                 pvRestorer,
+		csiSnapshotter:             csiSnapshotter,
+		snapshotLocationLister:     snapshotLocationLister,
 		volumeSnapshots:            req.VolumeSnapshots,
 		podVolumeBackups:           req.PodVolumeBackups,
 		resourceTerminatingTimeout: kr.resourceTerminatingTimeout,
+		useServerSideApply:         kr.useServerSideApply,
+		itemConcurrency:            kr.itemConcurrency,
+		guardrails:                 newGuardrails(kr.discoveryHelper, kr.protectedNamespaces, kr.deniedResources),
 		extractor: &backupExtractor{
 			log:        req.Log,
 			fileSystem: kr.fileSystem,
 		},
 		resourceClients: make(map[resourceClientKey]client.Dynamic),
 		restoredItems:   make(map[velero.ResourceIdentifier]struct{}),
+		restoringItems:  make(map[velero.ResourceIdentifier]*itemRestore),
 	}
 
 	return restoreCtx.execute()
@@ -348,7 +458,11 @@ type context struct {
 	restoreDir                 string
 	resourceIncludesExcludes   *collections.IncludesExcludes
 	namespaceIncludesExcludes  *collections.IncludesExcludes
+	clusterResourceNames       *collections.ResourceNameIncludesExcludes
+	resourcePolicies           *resourcepolicies.Policies
 	prioritizedResources       []schema.GroupResource
+	resourcePriorities         []string
+	discoveryHelper            discovery.Helper
 	selector                   labels.Selector
 	log                        logrus.FieldLogger
 	dynamicFactory             client.DynamicFactory
@@ -356,16 +470,38 @@ type context struct {
 	namespaceClient            corev1.NamespaceInterface
 	actions                    []resolvedAction
 	volumeSnapshotterGetter    VolumeSnapshotterGetter
-	resticRestorer             restic.Restorer
+	resticRestorer             podvolume.Restorer
 	globalWaitGroup            velerosync.ErrorGroup
-	pvsToProvision             sets.String
 	pvRestorer                 PVRestorer
+	csiSnapshotter             *csi.Snapshotter
+	snapshotLocationLister     listers.VolumeSnapshotLocationLister
 	volumeSnapshots            []*volume.Snapshot
 	podVolumeBackups           []*velerov1api.PodVolumeBackup
 	resourceTerminatingTimeout time.Duration
+	useServerSideApply         bool
+	guardrails                 *guardrails
+	resourceModifiers          []*resourceModifier
+	restoreHooks               []restoreHook
+	podCommandExecutor         podexec.PodCommandExecutor
 	extractor                  *backupExtractor
-	resourceClients            map[resourceClientKey]client.Dynamic
-	restoredItems              map[velero.ResourceIdentifier]struct{}
+	itemIndex                  persistence.BackupItemIndex
+	getReferencedBackup        func(string) (io.ReadCloser, error)
+	manifest                   *Manifest
+	dryRunResults              *DryRunResults
+	dryRunNamespaces           sets.String
+	startTime                  metav1.Time
+	itemConcurrency            int
+
+	// lock guards resourceClients, restoredItems, restoringItems,
+	// pvsToProvision, translatedPVs and the restore chains' waitingFor,
+	// which are written as items are restored, and namespaced items may be
+	// restored concurrently.
+	lock            sync.Mutex
+	resourceClients map[resourceClientKey]client.Dynamic
+	restoredItems   map[velero.ResourceIdentifier]struct{}
+	restoringItems  map[velero.ResourceIdentifier]*itemRestore
+	pvsToProvision  sets.String
+	translatedPVs   sets.String
 }
 
 type resourceClientKey struct {
@@ -376,7 +512,17 @@ type resourceClientKey struct {
 func (ctx *context) execute() (Result, Result) {
 	ctx.log.Infof("Starting restore of backup %s", kube.NamespaceAndName(ctx.backup))
 
-	dir, err := ctx.extractor.unzipAndExtractBackup(ctx.backupReader)
+	// creation timestamps have a resolution of seconds.
+	ctx.startTime = metav1.NewTime(time.Now().Truncate(time.Second))
+
+	var include func(*tar.Header) bool
+	if ctx.selectiveExtraction() {
+		include = func(header *tar.Header) bool {
+			return ctx.shouldExtract(header.Name)
+		}
+	}
+
+	dir, err := ctx.extractor.unzipAndExtractBackup(ctx.backupReader, include)
 	if err != nil {
 		ctx.log.Infof("error unzipping and extracting: %v", err)
 		return Result{}, Result{Velero: []string{err.Error()}}
@@ -386,9 +532,93 @@ func (ctx *context) execute() (Result, Result) {
 	// need to set this for additionalItems to be restored
 	ctx.restoreDir = dir
 
+	if err := ctx.extractReferencedItems(); err != nil {
+		ctx.log.Infof("error extracting referenced items: %v", err)
+		return Result{}, Result{Velero: []string{err.Error()}}
+	}
+
+	if ctx.restore.Spec.Mode == velerov1api.RestoreModeDataOnly {
+		return ctx.restoreVolumeData()
+	}
+
 	return ctx.restoreFromDir()
 }
 
+// extractReferencedItems extracts the unchanged items that the backup refers
+// to from the tarballs of the backups that contain them into ctx.restoreDir,
+// so that they're restored as if they were in the backup's own tarball.
+func (ctx *context) extractReferencedItems() error {
+	for backup, paths := range ctx.itemIndex.References(ctx.backup.Name) {
+		if ctx.getReferencedBackup == nil {
+			return errors.Errorf("unable to get the contents of referenced backup %s", backup)
+		}
+
+		if ctx.selectiveExtraction() {
+			var included []string
+			for _, path := range paths {
+				if ctx.shouldExtract(path) {
+					included = append(included, path)
+				}
+			}
+			if len(included) == 0 {
+				continue
+			}
+			paths = included
+		}
+
+		ctx.log.Infof("Extracting %d unchanged items from referenced backup %s", len(paths), backup)
+
+		contents, err := ctx.getReferencedBackup(backup)
+		if err != nil {
+			return errors.Wrapf(err, "error getting the contents of referenced backup %s", backup)
+		}
+
+		err = ctx.extractor.unzipAndExtractItems(contents, ctx.restoreDir, sets.NewString(paths...))
+		contents.Close()
+		if err != nil {
+			return errors.Wrapf(err, "error extracting items from referenced backup %s", backup)
+		}
+	}
+
+	return nil
+}
+
+func (ctx *context) selectiveExtraction() bool {
+	return ctx.restore.Spec.ExtractionPolicy == velerov1api.RestoreExtractionPolicySelective
+}
+
+// shouldExtract returns whether the file with the given path in the backup's
+// tarball is needed by a restore with a selective extraction policy. Items are
+// only needed if they match the restore's included and excluded resources,
+// namespaces and cluster-scoped items, since other items aren't restored, even
+// as additional items.
+// Namespaces are needed to create the namespaces that items are restored
+// into, and files outside of the resources directory are always needed.
+func (ctx *context) shouldExtract(path string) bool {
+	parts := strings.Split(filepath.Clean(path), string(filepath.Separator))
+	if len(parts) < 2 || parts[0] != velerov1api.ResourcesDir {
+		return true
+	}
+
+	resource := parts[1]
+	if resource == kuberesource.Namespaces.String() {
+		return true
+	}
+	if !ctx.resourceIncludesExcludes.ShouldInclude(resource) {
+		return false
+	}
+
+	if len(parts) > 3 && parts[2] == velerov1api.NamespaceScopedDir {
+		return ctx.namespaceIncludesExcludes.ShouldInclude(parts[3])
+	}
+
+	if len(parts) > 3 && parts[2] == velerov1api.ClusterScopedDir {
+		return ctx.clusterResourceNames.ShouldInclude(resource, strings.TrimSuffix(parts[3], ".json"))
+	}
+
+	return true
+}
+
 // restoreFromDir executes a restore based on backup data contained within a local
 // directory, ctx.restoreDir.
 func (ctx *context) restoreFromDir() (Result, Result) {
@@ -419,8 +649,14 @@ func (ctx *context) restoreFromDir() (Result, Result) {
 	}
 
 	existingNamespaces := sets.NewString()
+	guardedNamespaces := sets.NewString()
+
+	// ctx.prioritizedResources can grow while restoring, when restored custom
+	// resource definitions are waited for, so it's indexed rather than ranged
+	// over.
+	for i := 0; i < len(ctx.prioritizedResources); i++ {
+		resource := ctx.prioritizedResources[i]
 
-	for _, resource := range ctx.prioritizedResources {
 		// we don't want to explicitly restore namespace API objs because we'll handle
 		// them as a special case prior to restoring anything into them
 		if resource == kuberesource.Namespaces {
@@ -432,6 +668,18 @@ func (ctx *context) restoreFromDir() (Result, Result) {
 			continue
 		}
 
+		if controlPlaneConfigResources[resource] && !shouldRestoreControlPlaneConfig(ctx.restore) {
+			ctx.log.WithField("resource", resource.String()).Info("Skipping control-plane configuration because the restore's controlPlaneConfigPolicy is not Restore")
+			addVeleroError(&warnings, errors.Errorf("not restored: %s is control-plane configuration, which is only restored when controlPlaneConfigPolicy is Restore", resource))
+			continue
+		}
+
+		if ctx.guardrails.deniesResource(resource) {
+			ctx.log.WithField("resource", resource.String()).Info("Skipping resource because it's denied by the server's restore guardrails")
+			addVeleroError(&warnings, errors.Errorf("not restored: %s is denied by the server's restore guardrails", resource))
+			continue
+		}
+
 		resourcePath := filepath.Join(resourcesDir, rscDir.Name())
 
 		clusterSubDir := filepath.Join(resourcePath, velerov1api.ClusterScopedDir)
@@ -444,6 +692,9 @@ func (ctx *context) restoreFromDir() (Result, Result) {
 			w, e := ctx.restoreResource(resource.String(), "", clusterSubDir)
 			merge(&warnings, &w)
 			merge(&errs, &e)
+
+			w = ctx.waitForReady(resource, i)
+			merge(&warnings, &w)
 			continue
 		}
 
@@ -481,6 +732,15 @@ func (ctx *context) restoreFromDir() (Result, Result) {
 				mappedNsName = target
 			}
 
+			if ctx.guardrails.protectsNamespace(nsName) || ctx.guardrails.protectsNamespace(mappedNsName) {
+				if !guardedNamespaces.Has(nsName) {
+					ctx.log.Infof("Skipping namespace %s because it's protected by the server's restore guardrails", nsName)
+					addToResult(&warnings, nsName, errors.Errorf("not restored: namespace %s is protected by the server's restore guardrails", protectedNamespace(ctx.guardrails, nsName, mappedNsName)))
+					guardedNamespaces.Insert(nsName)
+				}
+				continue
+			}
+
 			// if we don't know whether this namespace exists yet, attempt to create
 			// it in order to ensure it exists. Try to get it from the backup tarball
 			// (in order to get any backed-up metadata), but if we don't find it there,
@@ -488,9 +748,21 @@ func (ctx *context) restoreFromDir() (Result, Result) {
 			if !existingNamespaces.Has(mappedNsName) {
 				logger := ctx.log.WithField("namespace", nsName)
 				ns := getNamespace(logger, getItemFilePath(ctx.restoreDir, "namespaces", "", nsName), mappedNsName)
-				if _, err := kube.EnsureNamespaceExistsAndIsReady(ns, ctx.namespaceClient, ctx.resourceTerminatingTimeout); err != nil {
-					addVeleroError(&errs, err)
-					continue
+				addRestoreLabels(ns, ctx.restore.Name, ctx.restore.Spec.BackupName)
+				if ctx.dryRun() {
+					if err := ctx.dryRunNamespace(ns); err != nil {
+						addVeleroError(&errs, err)
+						continue
+					}
+				} else {
+					_, created, err := kube.EnsureNamespaceExistsAndIsReady(ns, ctx.namespaceClient, ctx.resourceTerminatingTimeout)
+					if err != nil {
+						addVeleroError(&errs, err)
+						continue
+					}
+					if created != nil {
+						ctx.manifest.add(kuberesource.Namespaces, "v1", "Namespace", created)
+					}
 				}
 
 				// keep track of namespaces that we know exist so we don't
@@ -502,6 +774,9 @@ func (ctx *context) restoreFromDir() (Result, Result) {
 			merge(&warnings, &w)
 			merge(&errs, &e)
 		}
+
+		w := ctx.waitForReady(resource, i)
+		merge(&warnings, &w)
 	}
 
 	// TODO timeout?
@@ -554,6 +829,12 @@ func getNamespace(logger logrus.FieldLogger, path, remappedName string) *v1.Name
 		}
 	}
 
+	// the namespace name label is set by the API server to the namespace's
+	// name, so it has the backed-up name if the namespace is remapped.
+	if _, ok := backupNS.Labels[namespaceNameLabel]; ok {
+		backupNS.Labels[namespaceNameLabel] = remappedName
+	}
+
 	return &v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        remappedName,
@@ -737,6 +1018,7 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (R
 
 	groupResource := schema.ParseGroupResource(resource)
 
+	var items []*unstructured.Unstructured
 	for _, file := range files {
 		fullPath := filepath.Join(resourcePath, file.Name())
 		obj, err := ctx.unmarshal(fullPath)
@@ -749,11 +1031,62 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (R
 			continue
 		}
 
-		w, e := ctx.restoreItem(obj, groupResource, namespace)
-		merge(&warnings, &w)
-		merge(&errs, &e)
+		items = append(items, obj)
 	}
 
+	w, e := ctx.restoreItems(items, groupResource, namespace)
+	merge(&warnings, &w)
+	merge(&errs, &e)
+
+	return warnings, errs
+}
+
+// restoreItems restores the items of a resource. Cluster-scoped items are
+// restored one at a time, since other cluster-scoped items may depend on
+// them. Namespaced items are restored up to the restore's item concurrency
+// at a time, since their namespace already exists, and it returns once
+// they've all been restored, so that the items of higher priority resources
+// are created before those of lower priority ones. The additional items
+// that an item's restore item actions return are restored by the same
+// worker before the item, and an item that depends on an additional item
+// that another worker is restoring waits for it to be created.
+func (ctx *context) restoreItems(items []*unstructured.Unstructured, groupResource schema.GroupResource, namespace string) (Result, Result) {
+	warnings, errs := Result{}, Result{}
+
+	workers := ctx.itemConcurrency
+	if workers < 1 || namespace == "" {
+		workers = 1
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	var (
+		lock  sync.Mutex
+		wg    sync.WaitGroup
+		queue = make(chan *unstructured.Unstructured)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range queue {
+				w, e := ctx.restoreItem(obj, groupResource, namespace, new(restoreChain))
+
+				lock.Lock()
+				merge(&warnings, &w)
+				merge(&errs, &e)
+				lock.Unlock()
+			}
+		}()
+	}
+
+	for _, obj := range items {
+		queue <- obj
+	}
+	close(queue)
+	wg.Wait()
+
 	return warnings, errs
 }
 
@@ -763,6 +1096,9 @@ func (ctx *context) getResourceClient(groupResource schema.GroupResource, obj *u
 		namespace: namespace,
 	}
 
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
 	if client, ok := ctx.resourceClients[key]; ok {
 		return client, nil
 	}
@@ -785,6 +1121,101 @@ func (ctx *context) getResourceClient(groupResource schema.GroupResource, obj *u
 	return client, nil
 }
 
+// restoreChain is the restore of an item by one of restoreItems' workers,
+// along with the restores of the additional items that its restore item
+// actions return, which all run on the worker's goroutine.
+type restoreChain struct {
+	// waitingFor is the restore of an item by another chain that this one
+	// is waiting for, if any.
+	waitingFor *itemRestore
+}
+
+// itemRestore is an item being restored by a restore chain.
+type itemRestore struct {
+	chain *restoreChain
+	// done is closed once the item has been restored, or hasn't been
+	// because it couldn't be.
+	done chan struct{}
+}
+
+// markRestored records that the item is being restored by the given chain,
+// and returns a function to call once it's finished, or nil if the item has
+// already been restored. If another chain is still restoring the item, e.g.
+// because two pods that share a PVC return it as an additional item,
+// markRestored waits for it to finish, so that the items that depend on it
+// aren't created before it is. It doesn't wait for an item that the chain
+// itself is restoring, or for a chain that's waiting for this one, which
+// would never finish.
+func (ctx *context) markRestored(item velero.ResourceIdentifier, chain *restoreChain) func() {
+	ctx.lock.Lock()
+
+	if _, exists := ctx.restoredItems[item]; !exists {
+		ctx.restoredItems[item] = struct{}{}
+		restore := &itemRestore{chain: chain, done: make(chan struct{})}
+		ctx.restoringItems[item] = restore
+		ctx.lock.Unlock()
+
+		return func() {
+			ctx.lock.Lock()
+			delete(ctx.restoringItems, item)
+			ctx.lock.Unlock()
+
+			close(restore.done)
+		}
+	}
+
+	restore, ok := ctx.restoringItems[item]
+	if !ok || waitsForLH(restore.chain, chain) {
+		ctx.lock.Unlock()
+		return nil
+	}
+	chain.waitingFor = restore
+	ctx.lock.Unlock()
+
+	<-restore.done
+
+	ctx.lock.Lock()
+	chain.waitingFor = nil
+	ctx.lock.Unlock()
+
+	return nil
+}
+
+// waitsForLH returns whether the from chain is the to chain, or is waiting
+// for it, directly or through other chains. Chains only wait for chains
+// that don't wait for them, so this always returns.
+//
+// Callers of waitsForLH *must* acquire the context's lock before calling it.
+func waitsForLH(from, to *restoreChain) bool {
+	for chain := from; chain != nil; chain = chain.waitingFor.chain {
+		if chain == to {
+			return true
+		}
+		if chain.waitingFor == nil {
+			return false
+		}
+	}
+	return false
+}
+
+// provisionPV records that the persistent volume with the given name is
+// dynamically re-provisioned rather than restored.
+func (ctx *context) provisionPV(name string) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.pvsToProvision.Insert(name)
+}
+
+// shouldProvisionPV returns whether the persistent volume with the given name
+// is dynamically re-provisioned rather than restored.
+func (ctx *context) shouldProvisionPV(name string) bool {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	return ctx.pvsToProvision.Has(name)
+}
+
 func getResourceID(groupResource schema.GroupResource, namespace, name string) string {
 	if namespace == "" {
 		return fmt.Sprintf("%s/%s", groupResource.String(), name)
@@ -793,7 +1224,9 @@ func getResourceID(groupResource schema.GroupResource, namespace, name string) s
 	return fmt.Sprintf("%s/%s/%s", groupResource.String(), namespace, name)
 }
 
-func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource schema.GroupResource, namespace string) (Result, Result) {
+// restoreItem restores an item, and the additional items that its restore
+// item actions return, as part of the given restore chain.
+func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource schema.GroupResource, namespace string, chain *restoreChain) (Result, Result) {
 	warnings, errs := Result{}, Result{}
 	resourceID := getResourceID(groupResource, namespace, obj.GetName())
 
@@ -831,6 +1264,36 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 			}).Info("Not restoring item because it's cluster-scoped")
 			return warnings, errs
 		}
+
+		if groupResource != kuberesource.Namespaces && !ctx.clusterResourceNames.ShouldInclude(groupResource.String(), obj.GetName()) {
+			ctx.log.WithFields(logrus.Fields{
+				"namespace":     obj.GetNamespace(),
+				"name":          obj.GetName(),
+				"groupResource": groupResource.String(),
+			}).Info("Not restoring item because it's not an included cluster-scoped item")
+			return warnings, errs
+		}
+	}
+
+	if ctx.resourcePolicies.ShouldSkip(groupResource, obj) {
+		ctx.log.WithFields(logrus.Fields{
+			"namespace":     obj.GetNamespace(),
+			"name":          obj.GetName(),
+			"groupResource": groupResource.String(),
+		}).Info("Not restoring item because it matches a skip policy in the restore's resource policies")
+		return warnings, errs
+	}
+
+	// Check the server's restore guardrails, which apply regardless of the
+	// restore's spec.
+	if ctx.guardrails.deniesItem(groupResource, namespace, obj.GetName()) {
+		ctx.log.WithFields(logrus.Fields{
+			"namespace":     obj.GetNamespace(),
+			"name":          obj.GetName(),
+			"groupResource": groupResource.String(),
+		}).Info("Not restoring item because it's denied by the server's restore guardrails")
+		addToResult(&warnings, namespace, errors.Errorf("not restored: %s is denied by the server's restore guardrails", resourceID))
+		return warnings, errs
 	}
 
 	// make a copy of object retrieved from backup
@@ -855,11 +1318,12 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 		Namespace:     namespace,
 		Name:          name,
 	}
-	if _, exists := ctx.restoredItems[itemKey]; exists {
+	finished := ctx.markRestored(itemKey, chain)
+	if finished == nil {
 		ctx.log.Infof("Skipping %s because it's already been restored.", resourceID)
 		return warnings, errs
 	}
-	ctx.restoredItems[itemKey] = struct{}{}
+	defer finished()
 
 	// TODO: move to restore item action if/when we add a ShouldRestore() method to the interface
 	if groupResource == kuberesource.Pods && obj.GetAnnotations()[v1.MirrorPodAnnotationKey] != "" {
@@ -874,7 +1338,33 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 	}
 
 	if groupResource == kuberesource.PersistentVolumes {
+		// a snapshot that can't be restored in this cluster, e.g. because it
+		// was taken in another cloud, is translated into a restic restore of
+		// the volume's data, if the backup has one.
+		reason, err := ctx.untranslatableSnapshotReason(name)
+		if err != nil {
+			addToResult(&errs, namespace, errors.Wrapf(err, "error checking whether the snapshot of %s can be restored", resourceID))
+			return warnings, errs
+		}
+		if reason != "" {
+			if !hasResticBackup(obj, ctx) {
+				addToResult(&errs, namespace, errors.Errorf("not restored: %s: its snapshot can't be restored in this cluster because %s, and its data wasn't backed up with restic", resourceID, reason))
+				return warnings, errs
+			}
+
+			ctx.log.Infof("Dynamically re-provisioning persistent volume and restoring its data with restic because its snapshot can't be restored in this cluster: %s", reason)
+			ctx.translateSnapshot(name)
+			return warnings, errs
+		}
+
 		switch {
+		case ctx.csiSnapshotter != nil && getCSISnapshot(name, ctx.volumeSnapshots) != nil:
+			ctx.log.Infof("Dynamically re-provisioning persistent volume from its CSI snapshot.")
+			ctx.provisionPV(name)
+
+			// return early because we don't want to restore the PV itself, we want its PVC to be
+			// provisioned with a new volume from the snapshot.
+			return warnings, errs
 		case hasSnapshot(name, ctx.volumeSnapshots):
 			// Check if the PV exists in the cluster before attempting to create
 			// a volume from the snapshot, in order to avoid orphaned volumes (GH #609)
@@ -895,13 +1385,13 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 			}
 		case hasResticBackup(obj, ctx):
 			ctx.log.Infof("Dynamically re-provisioning persistent volume because it has a restic backup to be restored.")
-			ctx.pvsToProvision.Insert(name)
+			ctx.provisionPV(name)
 
 			// return early because we don't want to restore the PV itself, we want to dynamically re-provision it.
 			return warnings, errs
 		case hasDeleteReclaimPolicy(obj.Object):
 			ctx.log.Infof("Dynamically re-provisioning persistent volume because it doesn't have a snapshot and its reclaim policy is Delete.")
-			ctx.pvsToProvision.Insert(name)
+			ctx.provisionPV(name)
 
 			// return early because we don't want to restore the PV itself, we want to dynamically re-provision it.
 			return warnings, errs
@@ -944,6 +1434,7 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 
 		if executeOutput.SkipRestore {
 			ctx.log.Infof("Skipping restore of %s: %v because a registered plugin discarded it", obj.GroupVersionKind().Kind, name)
+			ctx.dryRunResults.skipped(namespace, resourceID)
 			return warnings, errs
 		}
 		unstructuredObj, ok := executeOutput.UpdatedItem.(*unstructured.Unstructured)
@@ -957,6 +1448,17 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 		for _, additionalItem := range executeOutput.AdditionalItems {
 			itemPath := getItemFilePath(ctx.restoreDir, additionalItem.GroupResource.String(), additionalItem.Namespace, additionalItem.Name)
 
+			// additional items that are excluded from the restore aren't
+			// restored, and aren't extracted by a selective extraction.
+			if ctx.selectiveExtraction() && !ctx.shouldExtract(getItemFilePath("", additionalItem.GroupResource.String(), additionalItem.Namespace, additionalItem.Name)) {
+				ctx.log.WithFields(logrus.Fields{
+					"additionalResource":          additionalItem.GroupResource.String(),
+					"additionalResourceNamespace": additionalItem.Namespace,
+					"additionalResourceName":      additionalItem.Name,
+				}).Info("Not restoring additional item because it's excluded")
+				continue
+			}
+
 			if _, err := ctx.fileSystem.Stat(itemPath); err != nil {
 				ctx.log.WithError(err).WithFields(logrus.Fields{
 					"additionalResource":          additionalItem.GroupResource.String(),
@@ -981,7 +1483,7 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 				}
 			}
 
-			w, e := ctx.restoreItem(additionalObj, additionalItem.GroupResource, additionalItemNamespace)
+			w, e := ctx.restoreItem(additionalObj, additionalItem.GroupResource, additionalItemNamespace, chain)
 			merge(&warnings, &w)
 			merge(&errs, &e)
 		}
@@ -1002,7 +1504,7 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 			return warnings, errs
 		}
 
-		if pvc.Spec.VolumeName != "" && ctx.pvsToProvision.Has(pvc.Spec.VolumeName) {
+		if pvc.Spec.VolumeName != "" && ctx.shouldProvisionPV(pvc.Spec.VolumeName) {
 			ctx.log.Infof("Resetting PersistentVolumeClaim %s/%s for dynamic provisioning because its PV %v has a reclaim policy of Delete", namespace, name, pvc.Spec.VolumeName)
 
 			// use the unstructured helpers here since we're only deleting and
@@ -1013,6 +1515,40 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 			delete(annotations, "pv.kubernetes.io/bind-completed")
 			delete(annotations, "pv.kubernetes.io/bound-by-controller")
 			obj.SetAnnotations(annotations)
+
+			if snapshot := getCSISnapshot(pvc.Spec.VolumeName, ctx.volumeSnapshots); ctx.csiSnapshotter != nil && snapshot != nil && !ctx.isTranslated(pvc.Spec.VolumeName) {
+				if err := ctx.restoreFromCSISnapshot(obj, namespace, pvc.Spec.VolumeName, snapshot); err != nil {
+					addToResult(&errs, namespace, errors.Wrapf(err, "error restoring %s from its CSI snapshot", resourceID))
+					return warnings, errs
+				}
+			}
+		}
+	}
+
+	// point the known references to namespaces in the item at the namespaces
+	// they're mapped to, so that e.g. a RoleBinding's subjects are the
+	// service accounts in the new namespace.
+	if changed := rewriteNamespaceReferences(groupResource, obj, ctx.restore.Spec.NamespaceMapping); len(changed) > 0 {
+		ctx.log.WithFields(logrus.Fields{
+			"namespace":     obj.GetNamespace(),
+			"name":          obj.GetName(),
+			"groupResource": groupResource.String(),
+		}).Infof("Rewrote references to mapped namespaces in %s", strings.Join(changed, ", "))
+	}
+
+	// apply the restore's resource modifier rules, which match items by
+	// their namespace in the backup, before the namespace is remapped.
+	if obj, err = applyResourceModifiers(ctx.resourceModifiers, groupResource, obj.GetNamespace(), obj); err != nil {
+		addToResult(&errs, namespace, errors.Wrapf(err, "error modifying %s", resourceID))
+		return warnings, errs
+	}
+
+	// add the init containers of the restore hooks that apply to the pod,
+	// which also match pods by their namespace in the backup.
+	if groupResource == kuberesource.Pods {
+		if obj, err = addInitRestoreHooks(ctx.restoreHooks, obj.GetNamespace(), obj, ctx.log); err != nil {
+			addToResult(&errs, namespace, errors.Wrapf(err, "error adding init restore hooks to %s", resourceID))
+			return warnings, errs
 		}
 	}
 
@@ -1028,6 +1564,15 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 	// and which backup they came from
 	addRestoreLabels(obj, ctx.restore.Name, ctx.restore.Spec.BackupName)
 
+	// Pods with restic volumes to restore must be newly created so that the restic
+	// init container runs, so they always go through the create path.
+	if ctx.useServerSideApply && !(groupResource == kuberesource.Pods && len(podvolume.GetVolumeBackupsForPod(ctx.podVolumeBackups, obj)) > 0) {
+		applyWarnings, applyErrs, applied := ctx.applyItem(obj, groupResource, resourceClient, namespace, originalNamespace, resourceID)
+		if applied {
+			return applyWarnings, applyErrs
+		}
+	}
+
 	ctx.log.Infof("Attempting to restore %s: %v", obj.GroupVersionKind().Kind, name)
 	createdObj, restoreErr := resourceClient.Create(obj)
 	if apierrors.IsAlreadyExists(restoreErr) {
@@ -1077,15 +1622,27 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 					addToResult(&warnings, namespace, err)
 				} else {
 					ctx.log.Infof("ServiceAccount %s successfully updated", kube.NamespaceAndName(obj))
+					ctx.dryRunResults.created(namespace, resourceID+" (updated)")
 				}
 			default:
 				e := errors.Errorf("not restored: %s and is different from backed up version.", restoreErr)
 				addToResult(&warnings, namespace, e)
+				ctx.dryRunResults.conflicts(namespace, resourceID)
 			}
 			return warnings, errs
 		}
 
 		ctx.log.Infof("Skipping restore of %s: %v because it already exists in the cluster and is unchanged from the backed up version", obj.GroupVersionKind().Kind, name)
+		ctx.dryRunResults.skipped(namespace, resourceID)
+		return warnings, errs
+	}
+
+	// a dry-run create of an item in a namespace that would be created by
+	// the restore fails because the namespace doesn't exist yet, so the
+	// item can only be assumed to be created.
+	if apierrors.IsNotFound(restoreErr) && ctx.dryRunNamespaces.Has(namespace) {
+		ctx.log.Infof("Assuming %s would be created because its namespace would be created", resourceID)
+		ctx.dryRunResults.created(namespace, resourceID)
 		return warnings, errs
 	}
 
@@ -1096,13 +1653,77 @@ func (ctx *context) restoreItem(obj *unstructured.Unstructured, groupResource sc
 		return warnings, errs
 	}
 
-	if groupResource == kuberesource.Pods && len(restic.GetVolumeBackupsForPod(ctx.podVolumeBackups, obj)) > 0 {
+	if ctx.dryRun() {
+		ctx.dryRunResults.created(namespace, resourceID)
+		return warnings, errs
+	}
+
+	ctx.manifest.add(groupResource, createdObj.GetAPIVersion(), createdObj.GetKind(), createdObj)
+
+	if groupResource == kuberesource.Pods && len(podvolume.GetVolumeBackupsForPod(ctx.podVolumeBackups, obj)) > 0 {
 		restorePodVolumeBackups(ctx, createdObj, originalNamespace)
 	}
 
+	if groupResource == kuberesource.PersistentVolumeClaims {
+		if err := ctx.restoreMovedClaimData(createdObj, originalNamespace, namespace); err != nil {
+			addToResult(&errs, namespace, err)
+		}
+	}
+
+	if groupResource == kuberesource.Pods {
+		ctx.runExecRestoreHooks(createdObj, originalNamespace)
+	}
+
 	return warnings, errs
 }
 
+// applyItem restores obj using server-side apply, which creates the object if it doesn't
+// exist or updates the fields owned by Velero if it does, in a single API call. Re-running
+// a restore therefore converges on the backed-up state rather than reporting the object as
+// already existing. The returned bool is false if the API server doesn't support
+// server-side apply, in which case the caller should fall back to creating the object.
+func (ctx *context) applyItem(obj *unstructured.Unstructured, groupResource schema.GroupResource, resourceClient client.Dynamic, namespace, originalNamespace, resourceID string) (Result, Result, bool) {
+	warnings, errs := Result{}, Result{}
+
+	ctx.log.Infof("Attempting to apply %s: %v", obj.GroupVersionKind().Kind, obj.GetName())
+	appliedObj, err := resourceClient.Apply(obj.GetName(), obj, restoreFieldManager, false)
+	switch {
+	case apierrors.IsUnsupportedMediaType(err):
+		ctx.log.Infof("Server-side apply not supported for %s, falling back to create", resourceID)
+		return warnings, errs, false
+	case apierrors.IsConflict(err):
+		// another field manager owns some of the fields being restored; leave
+		// the in-cluster object alone, as for the create path.
+		addToResult(&warnings, namespace, errors.Errorf("not restored: %s has fields managed by another client: %v", resourceID, err))
+		ctx.dryRunResults.conflicts(namespace, resourceID)
+	case err != nil:
+		ctx.log.Infof("error applying %s: %v", resourceID, err)
+		addToResult(&errs, namespace, fmt.Errorf("error restoring %s: %v", resourceID, err))
+	default:
+		// server-side apply doesn't say whether the object was created, so
+		// it's assumed to have been if it was created after the restore
+		// started.
+		if creationTimestamp := appliedObj.GetCreationTimestamp(); !creationTimestamp.Before(&ctx.startTime) {
+			ctx.manifest.add(groupResource, appliedObj.GetAPIVersion(), appliedObj.GetKind(), appliedObj)
+			ctx.dryRunResults.created(namespace, resourceID)
+
+			if groupResource == kuberesource.Pods && !ctx.dryRun() {
+				ctx.runExecRestoreHooks(appliedObj, originalNamespace)
+			}
+
+			if groupResource == kuberesource.PersistentVolumeClaims && !ctx.dryRun() {
+				if err := ctx.restoreMovedClaimData(appliedObj, originalNamespace, namespace); err != nil {
+					addToResult(&errs, namespace, err)
+				}
+			}
+		} else {
+			ctx.dryRunResults.created(namespace, resourceID+" (updated)")
+		}
+	}
+
+	return warnings, errs, true
+}
+
 // restorePodVolumeBackups restores the PodVolumeBackups for the given restored pod
 func restorePodVolumeBackups(ctx *context, createdObj *unstructured.Unstructured, originalNamespace string) {
 	if ctx.resticRestorer == nil {
@@ -1115,7 +1736,7 @@ func restorePodVolumeBackups(ctx *context, createdObj *unstructured.Unstructured
 				return []error{err}
 			}
 
-			data := restic.RestoreData{
+			data := podvolume.RestoreData{
 				Restore:          ctx.restore,
 				Pod:              pod,
 				PodVolumeBackups: ctx.podVolumeBackups,
@@ -1132,6 +1753,40 @@ func restorePodVolumeBackups(ctx *context, createdObj *unstructured.Unstructured
 	}
 }
 
+// getCSISnapshot returns the snapshot of the persistent volume with the
+// given name if it was taken through the VolumeSnapshot API, or nil.
+func getCSISnapshot(pvName string, snapshots []*volume.Snapshot) *csi.Snapshot {
+	for _, snapshot := range snapshots {
+		if snapshot.Spec.PersistentVolumeName == pvName {
+			return csi.SnapshotFor(snapshot)
+		}
+	}
+
+	return nil
+}
+
+// restoreFromCSISnapshot makes a persistent volume's CSI snapshot available
+// as a VolumeSnapshot in the namespace of its claim, and sets the VolumeSnapshot
+// as the claim's data source so the claim's new volume is provisioned from it.
+func (ctx *context) restoreFromCSISnapshot(pvc *unstructured.Unstructured, namespace, pvName string, snapshot *csi.Snapshot) error {
+	name := fmt.Sprintf("%s-%s", ctx.restore.Name, pvName)
+
+	labels := map[string]string{
+		velerov1api.BackupNameLabel:  label.GetValidName(ctx.backup.Name),
+		velerov1api.RestoreNameLabel: label.GetValidName(ctx.restore.Name),
+	}
+	if err := ctx.csiSnapshotter.ImportSnapshot(namespace, name, snapshot, labels); err != nil {
+		return err
+	}
+
+	dataSource := map[string]interface{}{
+		"apiGroup": csi.GroupName,
+		"kind":     csi.VolumeSnapshots.Kind,
+		"name":     name,
+	}
+	return unstructured.SetNestedMap(pvc.Object, dataSource, "spec", "dataSource")
+}
+
 func hasSnapshot(pvName string, snapshots []*volume.Snapshot) bool {
 	for _, snapshot := range snapshots {
 		if snapshot.Spec.PersistentVolumeName == pvName {
@@ -1159,7 +1814,7 @@ func hasResticBackup(unstructuredPV *unstructured.Unstructured, ctx *context) bo
 
 	var found bool
 	for _, pvb := range ctx.podVolumeBackups {
-		if pvb.Spec.Pod.Namespace == pv.Spec.ClaimRef.Namespace && pvb.GetAnnotations()[restic.PVCNameAnnotation] == pv.Spec.ClaimRef.Name {
+		if pvb.Spec.Pod.Namespace == pv.Spec.ClaimRef.Namespace && pvb.GetAnnotations()[podvolume.PVCNameAnnotation] == pv.Spec.ClaimRef.Name {
 			found = true
 			break
 		}
@@ -1253,5 +1908,9 @@ func (ctx *context) unmarshal(filePath string) (*unstructured.Unstructured, erro
 		return nil, err
 	}
 
+	// items in backups taken by Ark have Ark's labels and annotations,
+	// e.g. restic snapshot annotations, so rename them to Velero's.
+	kube.MigrateArkLabelsAndAnnotations(&obj)
+
 	return &obj, nil
 }
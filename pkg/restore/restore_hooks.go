@@ -0,0 +1,392 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/discovery"
+	"github.com/heptio/velero/pkg/kuberesource"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/util/collections"
+	"github.com/heptio/velero/pkg/util/kube"
+)
+
+const (
+	podRestoreHookInitContainerImageAnnotationKey   = "init.hook.restore.velero.io/container-image"
+	podRestoreHookInitContainerNameAnnotationKey    = "init.hook.restore.velero.io/container-name"
+	podRestoreHookInitContainerCommandAnnotationKey = "init.hook.restore.velero.io/command"
+
+	podRestoreHookContainerAnnotationKey   = "post.hook.restore.velero.io/container"
+	podRestoreHookCommandAnnotationKey     = "post.hook.restore.velero.io/command"
+	podRestoreHookOnErrorAnnotationKey     = "post.hook.restore.velero.io/on-error"
+	podRestoreHookExecTimeoutAnnotationKey = "post.hook.restore.velero.io/exec-timeout"
+	podRestoreHookWaitTimeoutAnnotationKey = "post.hook.restore.velero.io/wait-timeout"
+
+	// defaultRestoreHookContainerName is the name of an init container
+	// added from a pod's annotations if they don't name it.
+	defaultRestoreHookContainerName = "restore-hook"
+
+	// defaultHookWaitTimeout is how long an exec restore hook waits for its
+	// container to be running if its WaitTimeout isn't set.
+	defaultHookWaitTimeout = 10 * time.Minute
+)
+
+// hookPollInterval is how often a restored pod is checked while waiting
+// for a container to be running.
+var hookPollInterval = 5 * time.Second
+
+// restoreHook is a RestoreResourceHookSpec resolved for matching restored
+// pods.
+type restoreHook struct {
+	name          string
+	namespaces    *collections.IncludesExcludes
+	resources     *collections.IncludesExcludes
+	labelSelector labels.Selector
+	postHooks     []velerov1api.RestoreResourceHook
+}
+
+// newRestoreHooks resolves a restore's hook specs, using discovery to
+// resolve their resources.
+func newRestoreHooks(helper discovery.Helper, specs []velerov1api.RestoreResourceHookSpec) ([]restoreHook, error) {
+	var hooks []restoreHook
+
+	for _, spec := range specs {
+		hook := restoreHook{
+			name:       spec.Name,
+			namespaces: collections.NewIncludesExcludes().Includes(spec.IncludedNamespaces...).Excludes(spec.ExcludedNamespaces...),
+			resources:  getResourceIncludesExcludes(helper, spec.IncludedResources, spec.ExcludedResources),
+			postHooks:  spec.PostHooks,
+		}
+
+		if spec.LabelSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(spec.LabelSelector)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing label selector of restore hook %q", spec.Name)
+			}
+			hook.labelSelector = selector
+		}
+
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, nil
+}
+
+// applicableTo returns whether the hook applies to a pod in namespace, in
+// the backup, with the given labels.
+func (h restoreHook) applicableTo(namespace string, podLabels labels.Set) bool {
+	if !h.namespaces.ShouldInclude(namespace) {
+		return false
+	}
+	if !h.resources.ShouldInclude(kuberesource.Pods.String()) {
+		return false
+	}
+	if h.labelSelector != nil && !h.labelSelector.Matches(podLabels) {
+		return false
+	}
+	return true
+}
+
+// addInitRestoreHooks adds the init containers of the init hooks that apply
+// to pod, which is in namespace in the backup. If the pod has an init hook
+// in its annotations, that's used instead of the restore's hooks.
+func addInitRestoreHooks(hooks []restoreHook, namespace string, pod *unstructured.Unstructured, log logrus.FieldLogger) (*unstructured.Unstructured, error) {
+	var initContainers []corev1api.Container
+
+	if hook := getPodInitRestoreHookFromAnnotations(pod.GetAnnotations()); hook != nil {
+		initContainers = hook.InitContainers
+	} else {
+		for _, hook := range hooks {
+			if !hook.applicableTo(namespace, pod.GetLabels()) {
+				continue
+			}
+			for _, postHook := range hook.postHooks {
+				if postHook.Init != nil {
+					initContainers = append(initContainers, postHook.Init.InitContainers...)
+				}
+			}
+		}
+	}
+
+	if len(initContainers) == 0 {
+		return pod, nil
+	}
+
+	typedPod := new(corev1api.Pod)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(pod.UnstructuredContent(), typedPod); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the hooks' init containers run after restic's, so that the pod's
+	// volumes are restored before them.
+	i := 0
+	if len(typedPod.Spec.InitContainers) > 0 && typedPod.Spec.InitContainers[0].Name == podvolume.InitContainer {
+		i = 1
+	}
+
+	containers := append([]corev1api.Container{}, typedPod.Spec.InitContainers[:i]...)
+	containers = append(containers, initContainers...)
+	typedPod.Spec.InitContainers = append(containers, typedPod.Spec.InitContainers[i:]...)
+
+	log.WithField("pod", kube.NamespaceAndName(pod)).Infof("Adding %d init containers from restore hooks", len(initContainers))
+
+	res, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typedPod)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &unstructured.Unstructured{Object: res}, nil
+}
+
+// namedExecRestoreHook is an exec restore hook with the name of the hook
+// spec it comes from.
+type namedExecRestoreHook struct {
+	name string
+	hook *velerov1api.ExecRestoreHook
+}
+
+// getExecRestoreHooks returns the exec hooks that apply to pod, which is in
+// namespace in the backup. If the pod has an exec hook in its annotations,
+// only that is returned.
+func getExecRestoreHooks(hooks []restoreHook, namespace string, pod *unstructured.Unstructured) []namedExecRestoreHook {
+	if hook := getPodExecRestoreHookFromAnnotations(pod.GetAnnotations()); hook != nil {
+		return []namedExecRestoreHook{{name: "<from-annotation>", hook: hook}}
+	}
+
+	var res []namedExecRestoreHook
+	for _, hook := range hooks {
+		if !hook.applicableTo(namespace, pod.GetLabels()) {
+			continue
+		}
+		for _, postHook := range hook.postHooks {
+			if postHook.Exec != nil {
+				res = append(res, namedExecRestoreHook{name: hook.name, hook: postHook.Exec})
+			}
+		}
+	}
+
+	return res
+}
+
+// runExecRestoreHooks runs the exec hooks that apply to a restored pod, which
+// was in originalNamespace in the backup, in the background, once their
+// containers are running. Errors from hooks whose OnError is Fail are added
+// to the restore's errors once the restore's other work is done.
+func (ctx *context) runExecRestoreHooks(pod *unstructured.Unstructured, originalNamespace string) {
+	hooks := getExecRestoreHooks(ctx.restoreHooks, originalNamespace, pod)
+	if len(hooks) == 0 {
+		return
+	}
+
+	log := ctx.log.WithField("pod", kube.NamespaceAndName(pod))
+	if ctx.podCommandExecutor == nil {
+		log.Warn("No pod command executor, not running pod's exec restore hooks")
+		return
+	}
+
+	podClient, err := ctx.getResourceClient(kuberesource.Pods, pod, pod.GetNamespace())
+	if err != nil {
+		log.WithError(err).Error("Error getting client for pods, not running pod's exec restore hooks")
+		return
+	}
+
+	ctx.globalWaitGroup.GoErrorSlice(func() []error {
+		var errs []error
+
+		for _, namedHook := range hooks {
+			hook := namedHook.hook
+			hookLog := log.WithFields(logrus.Fields{
+				"hookSource": "restoreSpec",
+				"hookType":   "exec",
+				"hookName":   namedHook.name,
+			})
+
+			err := ctx.runExecRestoreHook(podClient, pod.GetName(), namedHook.name, hook, hookLog)
+			if err == nil {
+				continue
+			}
+
+			hookLog.WithError(err).Error("Error executing hook")
+			if hook.OnError != velerov1api.HookErrorModeContinue {
+				errs = append(errs, errors.Wrapf(err, "error executing hook %q in pod %s", namedHook.name, kube.NamespaceAndName(pod)))
+			}
+		}
+
+		return errs
+	})
+}
+
+// runExecRestoreHook waits for hook's container in the named pod to be
+// running, then executes the hook in it.
+func (ctx *context) runExecRestoreHook(podClient podGetter, name, hookName string, hook *velerov1api.ExecRestoreHook, log logrus.FieldLogger) error {
+	waitTimeout := hook.WaitTimeout.Duration
+	if waitTimeout <= 0 {
+		waitTimeout = defaultHookWaitTimeout
+	}
+
+	pod, err := waitForContainerRunning(podClient, name, hook.Container, waitTimeout)
+	if err != nil {
+		return err
+	}
+
+	execHook := &velerov1api.ExecHook{
+		Container: hook.Container,
+		Command:   hook.Command,
+		OnError:   hook.OnError,
+		Timeout:   hook.ExecTimeout,
+	}
+
+	return ctx.podCommandExecutor.ExecutePodCommand(log, pod.UnstructuredContent(), pod.GetNamespace(), pod.GetName(), hookName, execHook)
+}
+
+// podGetter gets a restored pod.
+type podGetter interface {
+	Get(name string, opts metav1.GetOptions) (*unstructured.Unstructured, error)
+}
+
+// waitForContainerRunning waits for the named container of a pod, or its
+// first container if container is empty, to be running, and returns the
+// pod. It returns an error if the pod fails or succeeds first, or the
+// container isn't running within timeout.
+func waitForContainerRunning(podClient podGetter, name, container string, timeout time.Duration) (*unstructured.Unstructured, error) {
+	var res *unstructured.Unstructured
+
+	err := wait.PollImmediate(hookPollInterval, timeout, func() (bool, error) {
+		obj, err := podClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		pod := new(corev1api.Pod)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), pod); err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		if pod.Status.Phase == corev1api.PodFailed || pod.Status.Phase == corev1api.PodSucceeded {
+			return false, errors.Errorf("pod is %s", pod.Status.Phase)
+		}
+
+		name := container
+		if name == "" && len(pod.Spec.Containers) > 0 {
+			name = pod.Spec.Containers[0].Name
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == name && status.State.Running != nil {
+				res = obj
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return nil, errors.Errorf("timed out after %v waiting for container to be running", timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// getPodInitRestoreHookFromAnnotations returns an InitRestoreHook based on
+// the annotations, as long as the container image annotation is present. If
+// it's absent, this returns nil.
+func getPodInitRestoreHookFromAnnotations(annotations map[string]string) *velerov1api.InitRestoreHook {
+	image := annotations[podRestoreHookInitContainerImageAnnotationKey]
+	if image == "" {
+		return nil
+	}
+
+	name := annotations[podRestoreHookInitContainerNameAnnotationKey]
+	if name == "" {
+		name = defaultRestoreHookContainerName
+	}
+
+	return &velerov1api.InitRestoreHook{
+		InitContainers: []corev1api.Container{
+			{
+				Name:    name,
+				Image:   image,
+				Command: parseHookCommand(annotations[podRestoreHookInitContainerCommandAnnotationKey]),
+			},
+		},
+	}
+}
+
+// getPodExecRestoreHookFromAnnotations returns an ExecRestoreHook based on
+// the annotations, as long as the command annotation is present. If it's
+// absent, this returns nil.
+func getPodExecRestoreHookFromAnnotations(annotations map[string]string) *velerov1api.ExecRestoreHook {
+	command := parseHookCommand(annotations[podRestoreHookCommandAnnotationKey])
+	if len(command) == 0 {
+		return nil
+	}
+
+	onError := velerov1api.HookErrorMode(annotations[podRestoreHookOnErrorAnnotationKey])
+	if onError != velerov1api.HookErrorModeContinue && onError != velerov1api.HookErrorModeFail {
+		onError = ""
+	}
+
+	return &velerov1api.ExecRestoreHook{
+		Container:   annotations[podRestoreHookContainerAnnotationKey],
+		Command:     command,
+		OnError:     onError,
+		ExecTimeout: metav1.Duration{Duration: parseHookDuration(annotations[podRestoreHookExecTimeoutAnnotationKey])},
+		WaitTimeout: metav1.Duration{Duration: parseHookDuration(annotations[podRestoreHookWaitTimeoutAnnotationKey])},
+	}
+}
+
+// parseHookCommand parses a command annotation, which is either a JSON
+// array of the command and its arguments or a single command.
+func parseHookCommand(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var command []string
+	if value[0] == '[' {
+		if err := json.Unmarshal([]byte(value), &command); err == nil {
+			return command
+		}
+	}
+
+	return []string{value}
+}
+
+// parseHookDuration parses a timeout annotation, returning zero if it's
+// empty or invalid so that the default is used.
+func parseHookDuration(value string) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
@@ -0,0 +1,312 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/test"
+)
+
+func TestAddInitRestoreHooks(t *testing.T) {
+	hookContainer := *builder.ForContainer("hook", "hook-image").Result()
+
+	tests := []struct {
+		name     string
+		hooks    []velerov1api.RestoreResourceHookSpec
+		pod      *corev1api.Pod
+		expected []string
+	}{
+		{
+			name: "pod without matching hooks isn't changed",
+			hooks: []velerov1api.RestoreResourceHookSpec{
+				{
+					Name:               "hook-1",
+					IncludedNamespaces: []string{"ns-2"},
+					PostHooks:          []velerov1api.RestoreResourceHook{{Init: &velerov1api.InitRestoreHook{InitContainers: []corev1api.Container{hookContainer}}}},
+				},
+			},
+			pod:      builder.ForPod("ns-1", "pod-1").InitContainers(builder.ForContainer("init", "image").Result()).Result(),
+			expected: []string{"init"},
+		},
+		{
+			name: "matching hook's init containers are added before the pod's",
+			hooks: []velerov1api.RestoreResourceHookSpec{
+				{
+					Name:          "hook-1",
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+					PostHooks:     []velerov1api.RestoreResourceHook{{Init: &velerov1api.InitRestoreHook{InitContainers: []corev1api.Container{hookContainer}}}},
+				},
+			},
+			pod: builder.ForPod("ns-1", "pod-1").
+				ObjectMeta(builder.WithLabels("app", "db")).
+				InitContainers(builder.ForContainer("init", "image").Result()).
+				Result(),
+			expected: []string{"hook", "init"},
+		},
+		{
+			name: "matching hook's init containers are added after restic's",
+			hooks: []velerov1api.RestoreResourceHookSpec{
+				{
+					Name:      "hook-1",
+					PostHooks: []velerov1api.RestoreResourceHook{{Init: &velerov1api.InitRestoreHook{InitContainers: []corev1api.Container{hookContainer}}}},
+				},
+			},
+			pod: builder.ForPod("ns-1", "pod-1").
+				InitContainers(builder.ForContainer(podvolume.InitContainer, "restic-image").Result(), builder.ForContainer("init", "image").Result()).
+				Result(),
+			expected: []string{podvolume.InitContainer, "hook", "init"},
+		},
+		{
+			name: "hook that excludes pods isn't applied",
+			hooks: []velerov1api.RestoreResourceHookSpec{
+				{
+					Name:              "hook-1",
+					ExcludedResources: []string{"pods"},
+					PostHooks:         []velerov1api.RestoreResourceHook{{Init: &velerov1api.InitRestoreHook{InitContainers: []corev1api.Container{hookContainer}}}},
+				},
+			},
+			pod: builder.ForPod("ns-1", "pod-1").Result(),
+		},
+		{
+			name: "hook from annotations is used instead of the restore's hooks",
+			hooks: []velerov1api.RestoreResourceHookSpec{
+				{
+					Name:      "hook-1",
+					PostHooks: []velerov1api.RestoreResourceHook{{Init: &velerov1api.InitRestoreHook{InitContainers: []corev1api.Container{hookContainer}}}},
+				},
+			},
+			pod: builder.ForPod("ns-1", "pod-1").
+				ObjectMeta(builder.WithAnnotations(
+					podRestoreHookInitContainerImageAnnotationKey, "annotation-image",
+					podRestoreHookInitContainerNameAnnotationKey, "from-annotation",
+				)).
+				Result(),
+			expected: []string{"from-annotation"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hooks, err := newRestoreHooks(test.NewFakeDiscoveryHelper(true, nil), tc.hooks)
+			require.NoError(t, err)
+
+			res, err := addInitRestoreHooks(hooks, "ns-1", toUnstructuredOrFail(t, tc.pod), test.NewLogger())
+			require.NoError(t, err)
+
+			pod := new(corev1api.Pod)
+			require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(res.UnstructuredContent(), pod))
+
+			var names []string
+			for _, container := range pod.Spec.InitContainers {
+				names = append(names, container.Name)
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestGetExecRestoreHooks(t *testing.T) {
+	execHook := &velerov1api.ExecRestoreHook{Command: []string{"recover"}}
+
+	hooks, err := newRestoreHooks(test.NewFakeDiscoveryHelper(true, nil), []velerov1api.RestoreResourceHookSpec{
+		{
+			Name:               "hook-1",
+			IncludedNamespaces: []string{"ns-1"},
+			PostHooks:          []velerov1api.RestoreResourceHook{{Exec: execHook}},
+		},
+		{
+			Name:          "hook-2",
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			PostHooks: []velerov1api.RestoreResourceHook{
+				{Init: &velerov1api.InitRestoreHook{}},
+				{Exec: execHook},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	pod := toUnstructuredOrFail(t, builder.ForPod("ns-2", "pod-1").ObjectMeta(builder.WithLabels("app", "db")).Result())
+	assert.Equal(t, []namedExecRestoreHook{{name: "hook-1", hook: execHook}, {name: "hook-2", hook: execHook}}, getExecRestoreHooks(hooks, "ns-1", pod))
+	assert.Equal(t, []namedExecRestoreHook{{name: "hook-2", hook: execHook}}, getExecRestoreHooks(hooks, "ns-2", pod))
+
+	pod.SetAnnotations(map[string]string{
+		podRestoreHookCommandAnnotationKey:     `["/bin/sh", "-c", "recover"]`,
+		podRestoreHookContainerAnnotationKey:   "db",
+		podRestoreHookOnErrorAnnotationKey:     "Continue",
+		podRestoreHookExecTimeoutAnnotationKey: "1m",
+		podRestoreHookWaitTimeoutAnnotationKey: "not-a-duration",
+	})
+	expected := []namedExecRestoreHook{
+		{
+			name: "<from-annotation>",
+			hook: &velerov1api.ExecRestoreHook{
+				Container:   "db",
+				Command:     []string{"/bin/sh", "-c", "recover"},
+				OnError:     velerov1api.HookErrorModeContinue,
+				ExecTimeout: metav1.Duration{Duration: time.Minute},
+			},
+		},
+	}
+	assert.Equal(t, expected, getExecRestoreHooks(hooks, "ns-1", pod))
+}
+
+type fakePodGetter struct {
+	pods []*corev1api.Pod
+	gets int
+}
+
+func (g *fakePodGetter) Get(name string, opts metav1.GetOptions) (*unstructured.Unstructured, error) {
+	pod := g.pods[g.gets]
+	if g.gets < len(g.pods)-1 {
+		g.gets++
+	}
+
+	res, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: res}, nil
+}
+
+func podWithContainerStatuses(phase corev1api.PodPhase, statuses ...corev1api.ContainerStatus) *corev1api.Pod {
+	pod := builder.ForPod("ns-1", "pod-1").
+		Containers(builder.ForContainer("app", "app-image").Result(), builder.ForContainer("db", "db-image").Result()).
+		Result()
+	pod.Status.Phase = phase
+	pod.Status.ContainerStatuses = statuses
+	return pod
+}
+
+func TestWaitForContainerRunning(t *testing.T) {
+	defer func(interval time.Duration) { hookPollInterval = interval }(hookPollInterval)
+	hookPollInterval = time.Millisecond
+
+	running := corev1api.ContainerState{Running: &corev1api.ContainerStateRunning{}}
+	waiting := corev1api.ContainerState{Waiting: &corev1api.ContainerStateWaiting{}}
+
+	tests := []struct {
+		name        string
+		container   string
+		pods        []*corev1api.Pod
+		expectedErr string
+	}{
+		{
+			name:      "named container that becomes running is waited for",
+			container: "db",
+			pods: []*corev1api.Pod{
+				podWithContainerStatuses(corev1api.PodPending),
+				podWithContainerStatuses(corev1api.PodRunning, corev1api.ContainerStatus{Name: "db", State: waiting}),
+				podWithContainerStatuses(corev1api.PodRunning, corev1api.ContainerStatus{Name: "db", State: running}),
+			},
+		},
+		{
+			name: "first container is waited for if none is named",
+			pods: []*corev1api.Pod{
+				podWithContainerStatuses(corev1api.PodRunning, corev1api.ContainerStatus{Name: "app", State: running}),
+			},
+		},
+		{
+			name:      "failed pod returns an error",
+			container: "db",
+			pods: []*corev1api.Pod{
+				podWithContainerStatuses(corev1api.PodFailed),
+			},
+			expectedErr: "pod is Failed",
+		},
+		{
+			name:      "container that isn't running in time returns an error",
+			container: "db",
+			pods: []*corev1api.Pod{
+				podWithContainerStatuses(corev1api.PodRunning, corev1api.ContainerStatus{Name: "app", State: running}),
+			},
+			expectedErr: "timed out after 10ms waiting for container to be running",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := waitForContainerRunning(&fakePodGetter{pods: tc.pods}, "pod-1", tc.container, 10*time.Millisecond)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "pod-1", res.GetName())
+		})
+	}
+}
+
+func TestRunExecRestoreHook(t *testing.T) {
+	defer func(interval time.Duration) { hookPollInterval = interval }(hookPollInterval)
+	hookPollInterval = time.Millisecond
+
+	pod := podWithContainerStatuses(corev1api.PodRunning, corev1api.ContainerStatus{
+		Name:  "db",
+		State: corev1api.ContainerState{Running: &corev1api.ContainerStateRunning{}},
+	})
+	podExecutor := new(test.MockPodCommandExecutor)
+	defer podExecutor.AssertExpectations(t)
+
+	expectedHook := &velerov1api.ExecHook{
+		Container: "db",
+		Command:   []string{"recover"},
+		OnError:   velerov1api.HookErrorModeFail,
+		Timeout:   metav1.Duration{Duration: time.Minute},
+	}
+	podExecutor.On("ExecutePodCommand", mock.Anything, mock.Anything, "ns-1", "pod-1", "hook-1", expectedHook).Return(errors.New("exec failed"))
+
+	ctx := &context{podCommandExecutor: podExecutor}
+	err := ctx.runExecRestoreHook(&fakePodGetter{pods: []*corev1api.Pod{pod}}, "pod-1", "hook-1", &velerov1api.ExecRestoreHook{
+		Container:   "db",
+		Command:     []string{"recover"},
+		OnError:     velerov1api.HookErrorModeFail,
+		ExecTimeout: metav1.Duration{Duration: time.Minute},
+		WaitTimeout: metav1.Duration{Duration: time.Second},
+	}, test.NewLogger())
+
+	assert.EqualError(t, err, "exec failed")
+}
+
+func TestNewRestoreHooksResolvesResources(t *testing.T) {
+	helper := test.NewFakeDiscoveryHelper(false, map[schema.GroupVersionResource]schema.GroupVersionResource{
+		{Resource: "po"}: {Version: "v1", Resource: "pods"},
+	})
+
+	hooks, err := newRestoreHooks(helper, []velerov1api.RestoreResourceHookSpec{{Name: "hook-1", IncludedResources: []string{"po"}}})
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+
+	assert.True(t, hooks[0].applicableTo("ns-1", nil))
+	assert.Equal(t, sets.NewString("pods"), sets.NewString(hooks[0].resources.GetIncludes()...))
+}
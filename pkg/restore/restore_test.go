@@ -23,7 +23,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -32,6 +35,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -46,14 +50,19 @@ import (
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/builder"
 	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/csi"
 	"github.com/heptio/velero/pkg/discovery"
 	velerov1informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
 	"github.com/heptio/velero/pkg/kuberesource"
+	"github.com/heptio/velero/pkg/persistence"
 	"github.com/heptio/velero/pkg/plugin/velero"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/resourcepolicies"
 	"github.com/heptio/velero/pkg/test"
 	testutil "github.com/heptio/velero/pkg/test"
 	"github.com/heptio/velero/pkg/util/collections"
 	"github.com/heptio/velero/pkg/util/encode"
+	"github.com/heptio/velero/pkg/util/filesystem"
 	kubeutil "github.com/heptio/velero/pkg/util/kube"
 	"github.com/heptio/velero/pkg/volume"
 )
@@ -591,6 +600,1135 @@ func TestRestoreNamespaceMapping(t *testing.T) {
 	}
 }
 
+// TestRestoreReferencedItems runs a restore of a backup that refers to
+// unchanged items stored in another backup's tarball, and verifies that
+// those items are restored along with the backup's own items.
+func TestRestoreReferencedItems(t *testing.T) {
+	pod := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"namespace": "ns-1",
+				"name":      name,
+			},
+		}
+	}
+	path := func(name string) string {
+		return "resources/pods/namespaces/ns-1/" + name + ".json"
+	}
+
+	tests := []struct {
+		name       string
+		referenced map[string]io.Reader
+		want       map[*test.APIResource][]string
+		wantErr    bool
+	}{
+		{
+			name: "referenced items are restored",
+			referenced: map[string]io.Reader{
+				"backup-0": newTarWriter(t).add(path("pod-1"), pod("pod-1")).add(path("pod-3"), pod("pod-3")).done(),
+			},
+			want: map[*test.APIResource][]string{
+				test.Pods(): {"ns-1/pod-1", "ns-1/pod-2"},
+			},
+		},
+		{
+			name: "a referenced item that's missing from the referenced backup is an error",
+			referenced: map[string]io.Reader{
+				"backup-0": newTarWriter(t).add(path("pod-3"), pod("pod-3")).done(),
+			},
+			want: map[*test.APIResource][]string{
+				test.Pods(): {},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "a missing referenced backup is an error",
+			referenced: map[string]io.Reader{},
+			want: map[*test.APIResource][]string{
+				test.Pods(): {},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHarness(t)
+			h.DiscoveryClient.WithAPIResource(test.Pods())
+			require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+			data := Request{
+				Log:          h.log,
+				Restore:      defaultRestore().Result(),
+				Backup:       defaultBackup().ReferencedBackups("backup-0").Result(),
+				BackupReader: newTarWriter(t).add(path("pod-2"), pod("pod-2")).done(),
+				ItemIndex: persistence.BackupItemIndex{
+					path("pod-1"): {Hash: "hash-1", Backup: "backup-0"},
+					path("pod-2"): {Hash: "hash-2", Backup: "backup-1"},
+				},
+				GetReferencedBackupContents: func(backup string) (io.ReadCloser, error) {
+					contents, ok := tc.referenced[backup]
+					if !ok {
+						return nil, errors.Errorf("backup %s not found", backup)
+					}
+					return ioutil.NopCloser(contents), nil
+				},
+			}
+			warnings, errs := h.restorer.Restore(
+				data,
+				nil, // actions
+				nil, // snapshot location lister
+				nil, // volume snapshotter getter
+			)
+
+			if tc.wantErr {
+				assert.NotEmpty(t, errs.Velero)
+			} else {
+				assertEmptyResults(t, warnings, errs)
+			}
+			assertAPIContents(t, h, tc.want)
+		})
+	}
+}
+
+// extractionRecordingFileSystem records the paths of the files that are
+// created in it, relative to the directory a backup is extracted to.
+type extractionRecordingFileSystem struct {
+	filesystem.Interface
+	created []string
+}
+
+func (fs *extractionRecordingFileSystem) Create(name string) (io.WriteCloser, error) {
+	if i := strings.Index(name, velerov1api.ResourcesDir+"/"); i >= 0 {
+		fs.created = append(fs.created, name[i:])
+	}
+	return fs.Interface.Create(name)
+}
+
+// TestRestoreSelectiveExtraction runs restores with different extraction
+// policies, and verifies that only the items that are needed are extracted
+// from the backup for a selective extraction.
+func TestRestoreSelectiveExtraction(t *testing.T) {
+	item := func(kind, namespace, name string) map[string]interface{} {
+		metadata := map[string]interface{}{"name": name}
+		if namespace != "" {
+			metadata["namespace"] = namespace
+		}
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata":   metadata,
+		}
+	}
+	tarball := func() io.Reader {
+		return newTarWriter(t).
+			add("metadata/version", []byte("1")).
+			add("resources/namespaces/cluster/ns-1.json", item("Namespace", "", "ns-1")).
+			add("resources/namespaces/cluster/ns-2.json", item("Namespace", "", "ns-2")).
+			add("resources/pods/namespaces/ns-1/pod-1.json", item("Pod", "ns-1", "pod-1")).
+			add("resources/pods/namespaces/ns-2/pod-2.json", item("Pod", "ns-2", "pod-2")).
+			add("resources/secrets/namespaces/ns-1/secret-1.json", item("Secret", "ns-1", "secret-1")).
+			done()
+	}
+
+	tests := []struct {
+		name          string
+		restore       *velerov1api.Restore
+		actions       []velero.RestoreItemAction
+		wantExtracted []string
+		want          map[*test.APIResource][]string
+	}{
+		{
+			name:    "a full extraction extracts all items",
+			restore: defaultRestore().IncludedNamespaces("ns-1").Result(),
+			wantExtracted: []string{
+				"resources/namespaces/cluster/ns-1.json",
+				"resources/namespaces/cluster/ns-2.json",
+				"resources/pods/namespaces/ns-1/pod-1.json",
+				"resources/pods/namespaces/ns-2/pod-2.json",
+				"resources/secrets/namespaces/ns-1/secret-1.json",
+			},
+			want: map[*test.APIResource][]string{
+				test.Pods():    {"ns-1/pod-1"},
+				test.Secrets(): {"ns-1/secret-1"},
+			},
+		},
+		{
+			name:    "a selective extraction only extracts items in included namespaces, and namespaces",
+			restore: defaultRestore().IncludedNamespaces("ns-1").ExtractionPolicy(velerov1api.RestoreExtractionPolicySelective).Result(),
+			wantExtracted: []string{
+				"resources/namespaces/cluster/ns-1.json",
+				"resources/namespaces/cluster/ns-2.json",
+				"resources/pods/namespaces/ns-1/pod-1.json",
+				"resources/secrets/namespaces/ns-1/secret-1.json",
+			},
+			want: map[*test.APIResource][]string{
+				test.Pods():    {"ns-1/pod-1"},
+				test.Secrets(): {"ns-1/secret-1"},
+			},
+		},
+		{
+			name:    "a selective extraction only extracts items of included resources, and namespaces",
+			restore: defaultRestore().IncludedResources("pods").ExtractionPolicy(velerov1api.RestoreExtractionPolicySelective).Result(),
+			wantExtracted: []string{
+				"resources/namespaces/cluster/ns-1.json",
+				"resources/namespaces/cluster/ns-2.json",
+				"resources/pods/namespaces/ns-1/pod-1.json",
+				"resources/pods/namespaces/ns-2/pod-2.json",
+			},
+			want: map[*test.APIResource][]string{
+				test.Pods():    {"ns-1/pod-1", "ns-2/pod-2"},
+				test.Secrets(): {},
+			},
+		},
+		{
+			name:    "excluded additional items aren't restored from a selective extraction",
+			restore: defaultRestore().IncludedResources("pods").ExtractionPolicy(velerov1api.RestoreExtractionPolicySelective).Result(),
+			actions: []velero.RestoreItemAction{
+				&pluggableAction{
+					selector: velero.ResourceSelector{IncludedNamespaces: []string{"ns-1"}},
+					executeFunc: func(input *velero.RestoreItemActionExecuteInput) (*velero.RestoreItemActionExecuteOutput, error) {
+						return &velero.RestoreItemActionExecuteOutput{
+							UpdatedItem: input.Item,
+							AdditionalItems: []velero.ResourceIdentifier{
+								{GroupResource: schema.GroupResource{Resource: "secrets"}, Namespace: "ns-1", Name: "secret-1"},
+							},
+						}, nil
+					},
+				},
+			},
+			wantExtracted: []string{
+				"resources/namespaces/cluster/ns-1.json",
+				"resources/namespaces/cluster/ns-2.json",
+				"resources/pods/namespaces/ns-1/pod-1.json",
+				"resources/pods/namespaces/ns-2/pod-2.json",
+			},
+			want: map[*test.APIResource][]string{
+				test.Pods():    {"ns-1/pod-1", "ns-2/pod-2"},
+				test.Secrets(): {},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHarness(t)
+			h.DiscoveryClient.WithAPIResource(test.Pods()).WithAPIResource(test.Secrets())
+			require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+			fileSystem := &extractionRecordingFileSystem{Interface: h.restorer.fileSystem}
+			h.restorer.fileSystem = fileSystem
+
+			data := Request{
+				Log:          h.log,
+				Restore:      tc.restore,
+				Backup:       defaultBackup().Result(),
+				BackupReader: tarball(),
+			}
+			warnings, errs := h.restorer.Restore(
+				data,
+				tc.actions,
+				nil, // snapshot location lister
+				nil, // volume snapshotter getter
+			)
+
+			assertEmptyResults(t, warnings, errs)
+			sort.Strings(fileSystem.created)
+			assert.Equal(t, tc.wantExtracted, fileSystem.created)
+			assertAPIContents(t, h, tc.want)
+		})
+	}
+}
+
+// TestRestoreManifest runs a restore and verifies that the items it created,
+// and only those, are added to its manifest.
+func TestRestoreManifest(t *testing.T) {
+	pod := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"namespace": "ns-1",
+				"name":      name,
+			},
+		}
+	}
+
+	h := newHarness(t)
+	h.addItems(t, test.Pods(builder.ForPod("ns-1", "pod-2").Result()))
+
+	manifest := new(Manifest)
+	data := Request{
+		Log:     h.log,
+		Restore: defaultRestore().Result(),
+		Backup:  defaultBackup().Result(),
+		BackupReader: newTarWriter(t).
+			add("resources/pods/namespaces/ns-1/pod-1.json", pod("pod-1")).
+			add("resources/pods/namespaces/ns-1/pod-2.json", pod("pod-2")).
+			done(),
+		Manifest: manifest,
+	}
+	warnings, errs := h.restorer.Restore(
+		data,
+		nil, // actions
+		nil, // snapshot location lister
+		nil, // volume snapshotter getter
+	)
+
+	assertEmptyResults(t, errs)
+	assert.Len(t, warnings.Namespaces["ns-1"], 1, "the existing pod that's different from the backed up one is a warning")
+
+	want := []ManifestItem{
+		{APIVersion: "v1", Kind: "Namespace", Resource: "namespaces", Name: "ns-1"},
+		{APIVersion: "v1", Kind: "Pod", Resource: "pods", Namespace: "ns-1", Name: "pod-1"},
+	}
+	assert.Equal(t, want, manifest.Items)
+}
+
+// TestRestoreWithItemConcurrency runs a restore whose namespaced items are
+// restored several at a time, and verifies that every item is created and
+// recorded in the restore's manifest.
+func TestRestoreWithItemConcurrency(t *testing.T) {
+	h := newHarness(t)
+	h.restorer.itemConcurrency = 4
+	h.addItems(t, test.Pods())
+
+	var (
+		tarball = newTarWriter(t)
+		want    []string
+	)
+	for _, ns := range []string{"ns-1", "ns-2"} {
+		for i := 0; i < 10; i++ {
+			name := fmt.Sprintf("pod-%d", i)
+			tarball.add("resources/pods/namespaces/"+ns+"/"+name+".json", map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"namespace": ns,
+					"name":      name,
+				},
+			})
+			want = append(want, ns+"/"+name)
+		}
+	}
+
+	manifest := new(Manifest)
+	data := Request{
+		Log:          h.log,
+		Restore:      defaultRestore().Result(),
+		Backup:       defaultBackup().Result(),
+		BackupReader: tarball.done(),
+		Manifest:     manifest,
+	}
+	warnings, errs := h.restorer.Restore(
+		data,
+		nil, // actions
+		nil, // snapshot location lister
+		nil, // volume snapshotter getter
+	)
+
+	assertEmptyResults(t, warnings, errs)
+	assertAPIContents(t, h, map[*test.APIResource][]string{test.Pods(): want})
+	assert.Len(t, manifest.Items, 22, "the manifest has both namespaces and every pod")
+}
+
+// waitForExecutions waits for a restore item action to have been executed
+// for the given number of items, so that items being restored concurrently
+// reach the same point.
+func waitForExecutions(t *testing.T, executions *sync.WaitGroup) {
+	done := make(chan struct{})
+	go func() {
+		executions.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Error("timed out waiting for items to be restored concurrently")
+	}
+}
+
+// TestRestoreWithItemConcurrencySharedAdditionalItem runs a restore whose
+// pods are restored concurrently and return the same PVC as an additional
+// item, and verifies that neither pod is created before the PVC is.
+func TestRestoreWithItemConcurrencySharedAdditionalItem(t *testing.T) {
+	h := newHarness(t)
+	h.restorer.itemConcurrency = 2
+	h.restorer.resourcePriorities = []string{"pods"}
+	h.addItems(t, test.Pods())
+	h.addItems(t, test.PVCs())
+
+	recorder := &createRecorder{t: t}
+	h.DynamicClient.PrependReactor("create", "*", recorder.reactor())
+
+	// the PVC isn't created until both pods' actions have returned it, so
+	// that the second pod's worker finds it still being restored.
+	var podExecutions sync.WaitGroup
+	podExecutions.Add(2)
+	actions := []velero.RestoreItemAction{
+		&pluggableAction{
+			selector: velero.ResourceSelector{IncludedResources: []string{"pods"}},
+			executeFunc: func(input *velero.RestoreItemActionExecuteInput) (*velero.RestoreItemActionExecuteOutput, error) {
+				podExecutions.Done()
+				return &velero.RestoreItemActionExecuteOutput{
+					UpdatedItem: input.Item,
+					AdditionalItems: []velero.ResourceIdentifier{
+						{GroupResource: kuberesource.PersistentVolumeClaims, Namespace: "ns-1", Name: "pvc-1"},
+					},
+				}, nil
+			},
+		},
+		&pluggableAction{
+			selector: velero.ResourceSelector{IncludedResources: []string{"persistentvolumeclaims"}},
+			executeFunc: func(input *velero.RestoreItemActionExecuteInput) (*velero.RestoreItemActionExecuteOutput, error) {
+				waitForExecutions(t, &podExecutions)
+				return &velero.RestoreItemActionExecuteOutput{UpdatedItem: input.Item}, nil
+			},
+		},
+	}
+
+	data := Request{
+		Log:     h.log,
+		Restore: defaultRestore().Result(),
+		Backup:  defaultBackup().Result(),
+		BackupReader: newTarWriter(t).
+			addItems("pods", builder.ForPod("ns-1", "pod-1").Result(), builder.ForPod("ns-1", "pod-2").Result()).
+			addItems("persistentvolumeclaims", builder.ForPersistentVolumeClaim("ns-1", "pvc-1").Result()).
+			done(),
+	}
+	warnings, errs := h.restorer.Restore(
+		data,
+		actions,
+		nil, // snapshot location lister
+		nil, // volume snapshotter getter
+	)
+
+	assertEmptyResults(t, warnings, errs)
+	assertAPIContents(t, h, map[*test.APIResource][]string{
+		test.Pods(): {"ns-1/pod-1", "ns-1/pod-2"},
+		test.PVCs(): {"ns-1/pvc-1"},
+	})
+
+	var created []string
+	for _, resource := range recorder.resources {
+		if resource.groupResource != "namespaces" {
+			created = append(created, resource.groupResource)
+		}
+	}
+	assert.Equal(t, []string{"persistentvolumeclaims", "pods", "pods"}, created)
+}
+
+// TestRestoreWithItemConcurrencyMutualAdditionalItems runs a restore whose
+// pods are restored concurrently and return each other as additional items,
+// and verifies that the workers don't wait for each other forever.
+func TestRestoreWithItemConcurrencyMutualAdditionalItems(t *testing.T) {
+	h := newHarness(t)
+	h.restorer.itemConcurrency = 2
+	h.addItems(t, test.Pods())
+
+	var executions sync.WaitGroup
+	executions.Add(2)
+	actions := []velero.RestoreItemAction{
+		&pluggableAction{
+			executeFunc: func(input *velero.RestoreItemActionExecuteInput) (*velero.RestoreItemActionExecuteOutput, error) {
+				other := "pod-1"
+				if input.Item.(*unstructured.Unstructured).GetName() == "pod-1" {
+					other = "pod-2"
+				}
+
+				executions.Done()
+				waitForExecutions(t, &executions)
+
+				return &velero.RestoreItemActionExecuteOutput{
+					UpdatedItem: input.Item,
+					AdditionalItems: []velero.ResourceIdentifier{
+						{GroupResource: kuberesource.Pods, Namespace: "ns-1", Name: other},
+					},
+				}, nil
+			},
+		},
+	}
+
+	data := Request{
+		Log:          h.log,
+		Restore:      defaultRestore().Result(),
+		Backup:       defaultBackup().Result(),
+		BackupReader: newTarWriter(t).addItems("pods", builder.ForPod("ns-1", "pod-1").Result(), builder.ForPod("ns-1", "pod-2").Result()).done(),
+	}
+	warnings, errs := h.restorer.Restore(
+		data,
+		actions,
+		nil, // snapshot location lister
+		nil, // volume snapshotter getter
+	)
+
+	assertEmptyResults(t, warnings, errs)
+	assertAPIContents(t, h, map[*test.APIResource][]string{test.Pods(): {"ns-1/pod-1", "ns-1/pod-2"}})
+}
+
+// TestRestoreResourceModifiers runs restores with resource modifier rules,
+// and verifies that the items matching each rule are patched before they're
+// created.
+func TestRestoreResourceModifiers(t *testing.T) {
+	pvc := func(namespace, name, storageClass string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+			},
+			"spec": map[string]interface{}{
+				"storageClassName": storageClass,
+			},
+		}
+	}
+	pod := func(namespace, name, image string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+				"labels":    map[string]interface{}{"app": name},
+			},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "app", "image": image},
+				},
+			},
+		}
+	}
+	value := func(v string) *runtime.RawExtension {
+		return &runtime.RawExtension{Raw: []byte(v)}
+	}
+
+	h := newHarness(t)
+	h.DiscoveryClient.WithAPIResource(test.PVCs()).WithAPIResource(test.Pods())
+	require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+	restore := defaultRestore().
+		NamespaceMappings("ns-1", "ns-3").
+		ResourceModifiers(
+			velerov1api.RestoreResourceModifier{
+				GroupResource: "persistentvolumeclaims",
+				Namespaces:    []string{"ns-1"},
+				Patches: []velerov1api.JSONPatchOperation{
+					{Operation: "test", Path: "/spec/storageClassName", Value: value(`"gp2"`)},
+					{Operation: "replace", Path: "/spec/storageClassName", Value: value(`"gp3"`)},
+				},
+			},
+			velerov1api.RestoreResourceModifier{
+				GroupResource: "pods",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "pod-1"}},
+				Patches: []velerov1api.JSONPatchOperation{
+					{Operation: "replace", Path: "/spec/containers/0/image", Value: value(`"registry.example.com/app:1.0"`)},
+				},
+			},
+			velerov1api.RestoreResourceModifier{
+				GroupResource: "pods",
+				Name:          "pod-3",
+				Patches: []velerov1api.JSONPatchOperation{
+					{Operation: "replace", Path: "/spec/nodeName", Value: value(`"node-1"`)},
+				},
+			},
+		).
+		Result()
+
+	data := Request{
+		Log:     h.log,
+		Restore: restore,
+		Backup:  defaultBackup().Result(),
+		BackupReader: newTarWriter(t).
+			add("resources/persistentvolumeclaims/namespaces/ns-1/pvc-1.json", pvc("ns-1", "pvc-1", "gp2")).
+			add("resources/persistentvolumeclaims/namespaces/ns-1/pvc-2.json", pvc("ns-1", "pvc-2", "standard")).
+			add("resources/persistentvolumeclaims/namespaces/ns-2/pvc-3.json", pvc("ns-2", "pvc-3", "gp2")).
+			add("resources/pods/namespaces/ns-1/pod-1.json", pod("ns-1", "pod-1", "docker.io/app:1.0")).
+			add("resources/pods/namespaces/ns-1/pod-2.json", pod("ns-1", "pod-2", "docker.io/app:1.0")).
+			add("resources/pods/namespaces/ns-1/pod-3.json", pod("ns-1", "pod-3", "docker.io/app:1.0")).
+			done(),
+	}
+	warnings, errs := h.restorer.Restore(
+		data,
+		nil, // actions
+		nil, // snapshot location lister
+		nil, // volume snapshotter getter
+	)
+
+	assertEmptyResults(t, warnings)
+	assert.Equal(t, Result{
+		Namespaces: map[string][]string{
+			"ns-3": {"error modifying pods/ns-3/pod-3: error applying resource modifier 2: jsonpatch replace operation does not apply: doc is missing key: /spec/nodeName"},
+		},
+	}, errs)
+
+	get := func(resource *test.APIResource, namespace, name string, fields ...string) string {
+		t.Helper()
+
+		obj, err := h.DynamicClient.Resource(resource.GVR()).Namespace(namespace).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+
+		val, _, err := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+		require.NoError(t, err)
+		return fmt.Sprint(val)
+	}
+
+	assert.Equal(t, "gp3", get(test.PVCs(), "ns-3", "pvc-1", "spec", "storageClassName"))
+	assert.Equal(t, "standard", get(test.PVCs(), "ns-3", "pvc-2", "spec", "storageClassName"))
+	assert.Equal(t, "gp2", get(test.PVCs(), "ns-2", "pvc-3", "spec", "storageClassName"))
+
+	containers, _, err := unstructured.NestedSlice(pod("", "", "registry.example.com/app:1.0"), "spec", "containers")
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprint(containers), get(test.Pods(), "ns-3", "pod-1", "spec", "containers"))
+	assert.NotEqual(t, fmt.Sprint(containers), get(test.Pods(), "ns-3", "pod-2", "spec", "containers"))
+
+	_, err = h.DynamicClient.Resource(test.Pods().GVR()).Namespace("ns-3").Get("pod-3", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "a pod that couldn't be modified isn't restored")
+}
+
+// TestRestoreGuardrails runs restores on a server with restore guardrails
+// configured, and verifies that protected namespaces and denied resources
+// aren't restored, whatever the restore's spec says.
+func TestRestoreGuardrails(t *testing.T) {
+	item := func(kind, namespace, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+			},
+		}
+	}
+	tarball := func() io.Reader {
+		return newTarWriter(t).
+			add("resources/pods/namespaces/ns-1/pod-1.json", item("Pod", "ns-1", "pod-1")).
+			add("resources/pods/namespaces/kube-system/pod-2.json", item("Pod", "kube-system", "pod-2")).
+			add("resources/secrets/namespaces/ns-1/secret-1.json", item("Secret", "ns-1", "secret-1")).
+			done()
+	}
+
+	tests := []struct {
+		name                string
+		restore             *velerov1api.Restore
+		protectedNamespaces []string
+		deniedResources     []string
+		want                map[*test.APIResource][]string
+		wantWarnings        Result
+	}{
+		{
+			name:    "no guardrails restores everything",
+			restore: defaultRestore().Result(),
+			want: map[*test.APIResource][]string{
+				test.Pods():    {"kube-system/pod-2", "ns-1/pod-1"},
+				test.Secrets(): {"ns-1/secret-1"},
+			},
+		},
+		{
+			name:                "items in a protected namespace aren't restored, even if the namespace is included",
+			restore:             defaultRestore().IncludedNamespaces("*").Result(),
+			protectedNamespaces: []string{"kube-system"},
+			want: map[*test.APIResource][]string{
+				test.Pods():    {"ns-1/pod-1"},
+				test.Secrets(): {"ns-1/secret-1"},
+			},
+			wantWarnings: Result{
+				Namespaces: map[string][]string{
+					"kube-system": {"not restored: namespace kube-system is protected by the server's restore guardrails"},
+				},
+			},
+		},
+		{
+			name:                "items aren't restored into a protected namespace using a namespace mapping",
+			restore:             defaultRestore().NamespaceMappings("ns-1", "kube-system").Result(),
+			protectedNamespaces: []string{"kube-system"},
+			want: map[*test.APIResource][]string{
+				test.Pods():    {},
+				test.Secrets(): {},
+			},
+			wantWarnings: Result{
+				Namespaces: map[string][]string{
+					"kube-system": {"not restored: namespace kube-system is protected by the server's restore guardrails"},
+					"ns-1":        {"not restored: namespace kube-system is protected by the server's restore guardrails"},
+				},
+			},
+		},
+		{
+			name:            "denied resources aren't restored, even if they're included",
+			restore:         defaultRestore().IncludedResources("pods", "secrets").Result(),
+			deniedResources: []string{"secrets"},
+			want: map[*test.APIResource][]string{
+				test.Pods():    {"kube-system/pod-2", "ns-1/pod-1"},
+				test.Secrets(): {},
+			},
+			wantWarnings: Result{
+				Velero: []string{"not restored: secrets is denied by the server's restore guardrails"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHarness(t)
+			h.restorer.protectedNamespaces = tc.protectedNamespaces
+			h.restorer.deniedResources = tc.deniedResources
+			h.DiscoveryClient.WithAPIResource(test.Pods()).WithAPIResource(test.Secrets())
+			require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+			data := Request{
+				Log:          h.log,
+				Restore:      tc.restore,
+				Backup:       defaultBackup().Result(),
+				BackupReader: tarball(),
+			}
+			warnings, errs := h.restorer.Restore(
+				data,
+				nil, // actions
+				nil, // snapshot location lister
+				nil, // volume snapshotter getter
+			)
+
+			assertEmptyResults(t, errs)
+			assert.Equal(t, tc.wantWarnings.Velero, warnings.Velero)
+			assert.Equal(t, tc.wantWarnings.Namespaces, warnings.Namespaces)
+			assertAPIContents(t, h, tc.want)
+		})
+	}
+}
+
+// TestRestoreClusterResourceNames runs restores with included and excluded
+// cluster-scoped items, and verifies that only the selected cluster-scoped
+// items are restored while namespaced items are unaffected.
+func TestRestoreClusterResourceNames(t *testing.T) {
+	item := func(kind, namespace, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+			},
+		}
+	}
+	pv := func(name string) map[string]interface{} {
+		obj := item("PersistentVolume", "", name)
+		obj["spec"] = map[string]interface{}{"persistentVolumeReclaimPolicy": "Retain"}
+		return obj
+	}
+	tarball := func() io.Reader {
+		return newTarWriter(t).
+			add("resources/pods/namespaces/ns-1/pod-1.json", item("Pod", "ns-1", "pod-1")).
+			add("resources/persistentvolumes/cluster/pv-1.json", pv("pv-1")).
+			add("resources/persistentvolumes/cluster/pv-2.json", pv("pv-2")).
+			add("resources/persistentvolumes/cluster/pv-20.json", pv("pv-20")).
+			done()
+	}
+
+	tests := []struct {
+		name    string
+		restore *velerov1api.Restore
+		want    map[*test.APIResource][]string
+	}{
+		{
+			name:    "only included cluster-scoped items of a resource are restored",
+			restore: defaultRestore().IncludedClusterResourceNames("persistentvolumes/pv-1").Result(),
+			want: map[*test.APIResource][]string{
+				test.Pods(): {"ns-1/pod-1"},
+				test.PVs():  {"/pv-1"},
+			},
+		},
+		{
+			name:    "excluded cluster-scoped items aren't restored",
+			restore: defaultRestore().ExcludedClusterResourceNames("persistentvolumes/pv-2*").Result(),
+			want: map[*test.APIResource][]string{
+				test.Pods(): {"ns-1/pod-1"},
+				test.PVs():  {"/pv-1"},
+			},
+		},
+		{
+			name: "only included cluster-scoped items are extracted with a selective extraction policy",
+			restore: defaultRestore().
+				IncludedClusterResourceNames("persistentvolumes/pv-2").
+				ExtractionPolicy(velerov1api.RestoreExtractionPolicySelective).
+				Result(),
+			want: map[*test.APIResource][]string{
+				test.Pods(): {"ns-1/pod-1"},
+				test.PVs():  {"/pv-2"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHarness(t)
+			h.DiscoveryClient.WithAPIResource(test.Pods()).WithAPIResource(test.PVs())
+			require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+			data := Request{
+				Log:          h.log,
+				Restore:      tc.restore,
+				Backup:       defaultBackup().Result(),
+				BackupReader: tarball(),
+			}
+			warnings, errs := h.restorer.Restore(
+				data,
+				nil, // actions
+				nil, // snapshot location lister
+				nil, // volume snapshotter getter
+			)
+
+			assertEmptyResults(t, warnings, errs)
+			assertAPIContents(t, h, tc.want)
+		})
+	}
+}
+
+// TestRestoreWithResourcePolicies runs a restore with resource policies and
+// verifies that the items matching a skip policy aren't restored.
+func TestRestoreWithResourcePolicies(t *testing.T) {
+	policies, err := resourcepolicies.Parse([]byte(`
+version: v1
+resourcePolicies:
+- conditions:
+    csiDrivers: [nfs.csi.k8s.io]
+  action:
+    type: skip
+- conditions:
+    annotations:
+      example.com/skip: ""
+  action:
+    type: skip
+`))
+	require.NoError(t, err)
+
+	pod := func(name string, annotations map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"namespace":   "ns-1",
+				"name":        name,
+				"annotations": annotations,
+			},
+		}
+	}
+	pv := func(name, driver string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolume",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				"persistentVolumeReclaimPolicy": "Retain",
+				"csi":                           map[string]interface{}{"driver": driver, "volumeHandle": name},
+			},
+		}
+	}
+
+	h := newHarness(t)
+	h.DiscoveryClient.WithAPIResource(test.Pods()).WithAPIResource(test.PVs())
+	require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+	data := Request{
+		Log:     h.log,
+		Restore: defaultRestore().Result(),
+		Backup:  defaultBackup().Result(),
+		BackupReader: newTarWriter(t).
+			add("resources/pods/namespaces/ns-1/pod-1.json", pod("pod-1", nil)).
+			add("resources/pods/namespaces/ns-1/pod-2.json", pod("pod-2", map[string]interface{}{"example.com/skip": "true"})).
+			add("resources/persistentvolumes/cluster/pv-1.json", pv("pv-1", "ebs.csi.aws.com")).
+			add("resources/persistentvolumes/cluster/pv-2.json", pv("pv-2", "nfs.csi.k8s.io")).
+			done(),
+		ResourcePolicies: policies,
+	}
+	warnings, errs := h.restorer.Restore(
+		data,
+		nil, // actions
+		nil, // snapshot location lister
+		nil, // volume snapshotter getter
+	)
+
+	assertEmptyResults(t, warnings, errs)
+	assertAPIContents(t, h, map[*test.APIResource][]string{
+		test.Pods(): {"ns-1/pod-1"},
+		test.PVs():  {"/pv-1"},
+	})
+}
+
+// TestRestoreCSISnapshot runs a restore of a backup with a CSI snapshot and
+// verifies that the snapshot is imported into the claim's namespace and that
+// the claim is provisioned from it instead of the PV being restored.
+func TestRestoreCSISnapshot(t *testing.T) {
+	tarball := newTarWriter(t).
+		add("resources/persistentvolumes/cluster/pv-1.json", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolume",
+			"metadata":   map[string]interface{}{"name": "pv-1"},
+			"spec": map[string]interface{}{
+				"persistentVolumeReclaimPolicy": "Delete",
+				"csi":                           map[string]interface{}{"driver": "driver-1", "volumeHandle": "vol-1"},
+			},
+		}).
+		add("resources/persistentvolumeclaims/namespaces/ns-1/pvc-1.json", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata":   map[string]interface{}{"namespace": "ns-1", "name": "pvc-1"},
+			"spec":       map[string]interface{}{"volumeName": "pv-1"},
+		}).
+		done()
+
+	h := newHarness(t)
+	h.restorer.resourcePriorities = []string{"persistentvolumes", "persistentvolumeclaims"}
+	h.DiscoveryClient.WithAPIResource(test.PVs()).WithAPIResource(test.PVCs())
+	require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+	class := &unstructured.Unstructured{Object: map[string]interface{}{"driver": "driver-1"}}
+	class.SetAPIVersion(csi.GroupVersion.String())
+	class.SetKind(csi.VolumeSnapshotClasses.Kind)
+	class.SetName("class-1")
+	_, err := h.DynamicClient.Resource(csi.GroupVersion.WithResource(csi.VolumeSnapshotClasses.Name)).Create(class, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	data := Request{
+		Log:          h.log,
+		Restore:      defaultRestore().Result(),
+		Backup:       defaultBackup().Result(),
+		BackupReader: tarball,
+		VolumeSnapshots: []*volume.Snapshot{{
+			Spec: volume.SnapshotSpec{
+				PersistentVolumeName:   "pv-1",
+				ProviderVolumeID:       "vol-1",
+				CSIDriver:              "driver-1",
+				CSIVolumeSnapshotClass: "class-1",
+			},
+			Status: volume.SnapshotStatus{
+				ProviderSnapshotID:       "handle-1",
+				CSIVolumeSnapshotContent: "snapcontent-1",
+				Phase:                    volume.SnapshotPhaseCompleted,
+			},
+		}},
+	}
+	warnings, errs := h.restorer.Restore(
+		data,
+		nil, // actions
+		nil, // snapshot location lister
+		nil, // volume snapshotter getter
+	)
+
+	assertEmptyResults(t, warnings, errs)
+	assertAPIContents(t, h, map[*test.APIResource][]string{
+		test.PVs():  {},
+		test.PVCs(): {"ns-1/pvc-1"},
+	})
+
+	pvc, err := h.DynamicClient.Resource(corev1api.SchemeGroupVersion.WithResource("persistentvolumeclaims")).Namespace("ns-1").Get("pvc-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	dataSource, _, _ := unstructured.NestedMap(pvc.Object, "spec", "dataSource")
+	assert.Equal(t, map[string]interface{}{"apiGroup": "snapshot.storage.k8s.io", "kind": "VolumeSnapshot", "name": "restore-1-pv-1"}, dataSource)
+	_, found, _ := unstructured.NestedString(pvc.Object, "spec", "volumeName")
+	assert.False(t, found)
+
+	content, err := h.DynamicClient.Resource(csi.GroupVersion.WithResource("volumesnapshotcontents")).Get("restore-1-pv-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	handle, _, _ := unstructured.NestedString(content.Object, "spec", "source", "snapshotHandle")
+	assert.Equal(t, "handle-1", handle)
+
+	_, err = h.DynamicClient.Resource(csi.GroupVersion.WithResource("volumesnapshots")).Namespace("ns-1").Get("restore-1-pv-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+// TestRestoreMovedSnapshotData runs a restore of a claim whose snapshot data was
+// moved with restic, and verifies that its volume is dynamically provisioned and
+// the data is restored into the restored claim.
+func TestRestoreMovedSnapshotData(t *testing.T) {
+	tarball := newTarWriter(t).
+		add("resources/persistentvolumes/cluster/pv-1.json", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolume",
+			"metadata":   map[string]interface{}{"name": "pv-1"},
+			"spec": map[string]interface{}{
+				"persistentVolumeReclaimPolicy": "Retain",
+				"claimRef":                      map[string]interface{}{"namespace": "ns-1", "name": "pvc-1"},
+			},
+		}).
+		add("resources/persistentvolumeclaims/namespaces/ns-1/pvc-1.json", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata":   map[string]interface{}{"namespace": "ns-1", "name": "pvc-1"},
+			"spec":       map[string]interface{}{"volumeName": "pv-1"},
+		}).
+		done()
+
+	h := newHarness(t)
+	h.restorer.resourcePriorities = []string{"persistentvolumes", "persistentvolumeclaims"}
+	h.DiscoveryClient.WithAPIResource(test.PVs()).WithAPIResource(test.PVCs())
+	require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+	restorer := &fakeResticRestorer{}
+	h.restorer.resticRestorerFactory = &fakeResticRestorerFactory{restorer: restorer}
+
+	pvb := builder.ForPodVolumeBackup(velerov1api.DefaultNamespace, "pvb-1").
+		ObjectMeta(builder.WithAnnotations(podvolume.PVCNameAnnotation, "pvc-1", podvolume.MovedPVCAnnotation, "pvc-1")).
+		Pod("ns-1", "velero-backup-1-pvc-1").
+		Volume("data").
+		SnapshotID("snapshot-1").
+		Phase(velerov1api.PodVolumeBackupPhaseCompleted).
+		Result()
+
+	warnings, errs := h.restorer.Restore(
+		Request{
+			Log:              h.log,
+			Restore:          defaultRestore().Result(),
+			Backup:           defaultBackup().Result(),
+			PodVolumeBackups: []*velerov1api.PodVolumeBackup{pvb},
+			BackupReader:     tarball,
+		},
+		nil, // actions
+		nil, // snapshot location lister
+		nil, // volume snapshotter getter
+	)
+
+	assertEmptyResults(t, warnings, errs)
+	assertAPIContents(t, h, map[*test.APIResource][]string{
+		test.PVs():  {},
+		test.PVCs(): {"ns-1/pvc-1"},
+	})
+
+	require.Len(t, restorer.restores, 1)
+	data := restorer.restores[0]
+	require.Len(t, data.Pod.Spec.Volumes, 1)
+	require.NotNil(t, data.Pod.Spec.Volumes[0].PersistentVolumeClaim)
+	assert.Equal(t, "pvc-1", data.Pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	require.Len(t, data.PodVolumeBackups, 1)
+	assert.Equal(t, "snapshot-1", data.PodVolumeBackups[0].Status.SnapshotID)
+}
+
+// TestRestoreTranslatedSnapshots runs restores of persistent volumes whose snapshots
+// can't be restored in the target cluster, and verifies that their data is restored
+// with restic instead, if the backup has it.
+func TestRestoreTranslatedSnapshots(t *testing.T) {
+	providerSnapshot := &volume.Snapshot{
+		Spec: volume.SnapshotSpec{
+			PersistentVolumeName: "pv-1",
+			Location:             "default",
+			Provider:             "aws",
+			ProviderVolumeID:     "vol-1",
+		},
+		Status: volume.SnapshotStatus{
+			ProviderSnapshotID: "snap-1",
+			Phase:              volume.SnapshotPhaseCompleted,
+		},
+	}
+	csiSnapshot := &volume.Snapshot{
+		Spec: volume.SnapshotSpec{
+			PersistentVolumeName:   "pv-1",
+			ProviderVolumeID:       "vol-1",
+			CSIDriver:              "driver-1",
+			CSIVolumeSnapshotClass: "class-1",
+		},
+		Status: volume.SnapshotStatus{
+			ProviderSnapshotID:       "handle-1",
+			CSIVolumeSnapshotContent: "snapcontent-1",
+			Phase:                    volume.SnapshotPhaseCompleted,
+		},
+	}
+	movedData := builder.ForPodVolumeBackup(velerov1api.DefaultNamespace, "pvb-1").
+		ObjectMeta(builder.WithAnnotations(podvolume.PVCNameAnnotation, "pvc-1", podvolume.MovedPVCAnnotation, "pvc-1")).
+		Pod("ns-1", "velero-backup-1-pvc-1").
+		Volume("data").
+		SnapshotID("snapshot-1").
+		Phase(velerov1api.PodVolumeBackupPhaseCompleted).
+		Result()
+
+	tests := []struct {
+		name             string
+		snapshot         *volume.Snapshot
+		podVolumeBackups []*velerov1api.PodVolumeBackup
+		wantErrs         []string
+		wantRestores     int
+	}{
+		{
+			name:             "snapshot taken with another provider is restored with restic",
+			snapshot:         providerSnapshot,
+			podVolumeBackups: []*velerov1api.PodVolumeBackup{movedData},
+			wantRestores:     1,
+		},
+		{
+			name:     "snapshot taken with another provider without restic data isn't restored",
+			snapshot: providerSnapshot,
+			wantErrs: []string{"not restored: persistentvolumes/pv-1: its snapshot can't be restored in this cluster because it was taken with provider aws, but volume snapshot location default uses provider gcp, and its data wasn't backed up with restic"},
+		},
+		{
+			name:             "CSI snapshot whose driver has no volume snapshot class is restored with restic",
+			snapshot:         csiSnapshot,
+			podVolumeBackups: []*velerov1api.PodVolumeBackup{movedData},
+			wantRestores:     1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tarball := newTarWriter(t).
+				add("resources/persistentvolumes/cluster/pv-1.json", map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "PersistentVolume",
+					"metadata":   map[string]interface{}{"name": "pv-1"},
+					"spec": map[string]interface{}{
+						"persistentVolumeReclaimPolicy": "Retain",
+						"claimRef":                      map[string]interface{}{"namespace": "ns-1", "name": "pvc-1"},
+					},
+				}).
+				add("resources/persistentvolumeclaims/namespaces/ns-1/pvc-1.json", map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "PersistentVolumeClaim",
+					"metadata":   map[string]interface{}{"namespace": "ns-1", "name": "pvc-1"},
+					"spec":       map[string]interface{}{"volumeName": "pv-1"},
+				}).
+				done()
+
+			h := newHarness(t)
+			h.restorer.resourcePriorities = []string{"persistentvolumes", "persistentvolumeclaims"}
+			h.DiscoveryClient.WithAPIResource(test.PVs()).WithAPIResource(test.PVCs())
+			require.NoError(t, h.restorer.discoveryHelper.Refresh())
+
+			restorer := &fakeResticRestorer{}
+			h.restorer.resticRestorerFactory = &fakeResticRestorerFactory{restorer: restorer}
+
+			vslInformer := velerov1informers.NewSharedInformerFactory(h.VeleroClient, 0).Velero().V1().VolumeSnapshotLocations()
+			require.NoError(t, vslInformer.Informer().GetStore().Add(builder.ForVolumeSnapshotLocation(velerov1api.DefaultNamespace, "default").Provider("gcp").Result()))
+
+			warnings, errs := h.restorer.Restore(
+				Request{
+					Log:              h.log,
+					Restore:          defaultRestore().Result(),
+					Backup:           defaultBackup().Result(),
+					PodVolumeBackups: tc.podVolumeBackups,
+					VolumeSnapshots:  []*volume.Snapshot{tc.snapshot},
+					BackupReader:     tarball,
+				},
+				nil, // actions
+				vslInformer.Lister(),
+				nil, // volume snapshotter getter
+			)
+
+			assertEmptyResults(t, warnings)
+			assert.Equal(t, tc.wantErrs, errs.Cluster)
+			assert.Len(t, restorer.restores, tc.wantRestores)
+
+			// the persistent volume isn't restored itself in any case
+			_, err := h.DynamicClient.Resource(corev1api.SchemeGroupVersion.WithResource("persistentvolumes")).Get("pv-1", metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err))
+
+			pvc, err := h.DynamicClient.Resource(corev1api.SchemeGroupVersion.WithResource("persistentvolumeclaims")).Namespace("ns-1").Get("pvc-1", metav1.GetOptions{})
+			require.NoError(t, err)
+			_, found, _ := unstructured.NestedMap(pvc.Object, "spec", "dataSource")
+			assert.False(t, found)
+		})
+	}
+}
+
 // TestRestoreResourcePriorities runs restores with resource priorities specified,
 // and verifies that the set of items created in the API are created in the expected
 // order. Validation is done by adding a Reactor to the fake dynamic client that records
@@ -672,6 +1810,94 @@ func TestRestoreResourcePriorities(t *testing.T) {
 	}
 }
 
+// TestRestoreControlPlaneConfig runs restores of backups containing control-plane
+// configuration, and verifies that it's only restored when the restore opts in, and
+// that it's then restored after all other resources.
+func TestRestoreControlPlaneConfig(t *testing.T) {
+	flowSchema := test.UnstructuredOrDie(`{"apiVersion":"flowcontrol.apiserver.k8s.io/v1alpha1","kind":"FlowSchema","metadata":{"name":"fs-1"}}`)
+	webhookConfig := test.UnstructuredOrDie(`{"apiVersion":"admissionregistration.k8s.io/v1beta1","kind":"ValidatingWebhookConfiguration","metadata":{"name":"webhook-1"}}`)
+	pod := test.UnstructuredOrDie(`{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"ns-1","name":"pod-1"}}`)
+
+	tests := []struct {
+		name         string
+		restore      *velerov1api.Restore
+		want         map[*test.APIResource][]string
+		wantWarnings Result
+		wantLastTwo  []string
+	}{
+		{
+			name:    "control-plane configuration is skipped by default",
+			restore: defaultRestore().Result(),
+			want: map[*test.APIResource][]string{
+				test.Pods():                            {"ns-1/pod-1"},
+				test.FlowSchemas():                     {},
+				test.ValidatingWebhookConfigurations(): {},
+			},
+			wantWarnings: Result{
+				Velero: []string{
+					"not restored: flowschemas.flowcontrol.apiserver.k8s.io is control-plane configuration, which is only restored when controlPlaneConfigPolicy is Restore",
+					"not restored: validatingwebhookconfigurations.admissionregistration.k8s.io is control-plane configuration, which is only restored when controlPlaneConfigPolicy is Restore",
+				},
+			},
+		},
+		{
+			name:    "control-plane configuration is restored last when the restore opts in",
+			restore: defaultRestore().ControlPlaneConfigPolicy(velerov1api.ControlPlaneConfigPolicyRestore).Result(),
+			want: map[*test.APIResource][]string{
+				test.Pods():                            {"ns-1/pod-1"},
+				test.FlowSchemas():                     {"/fs-1"},
+				test.ValidatingWebhookConfigurations(): {"/webhook-1"},
+			},
+			wantLastTwo: []string{
+				"flowschemas.flowcontrol.apiserver.k8s.io",
+				"validatingwebhookconfigurations.admissionregistration.k8s.io",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHarness(t)
+
+			recorder := &createRecorder{t: t}
+			h.DynamicClient.PrependReactor("create", "*", recorder.reactor())
+
+			for r := range tc.want {
+				h.addItems(t, r)
+			}
+
+			warnings, errs := h.restorer.Restore(
+				Request{
+					Log:     h.log,
+					Restore: tc.restore,
+					Backup:  defaultBackup().Result(),
+					BackupReader: newTarWriter(t).
+						addItems("flowschemas.flowcontrol.apiserver.k8s.io", flowSchema).
+						addItems("validatingwebhookconfigurations.admissionregistration.k8s.io", webhookConfig).
+						addItems("pods", pod).
+						done(),
+				},
+				nil, // actions
+				nil, // snapshot location lister
+				nil, // volume snapshotter getter
+			)
+
+			assert.Equal(t, tc.wantWarnings, warnings)
+			assertEmptyResults(t, errs)
+			assertAPIContents(t, h, tc.want)
+
+			if len(tc.wantLastTwo) > 0 {
+				require.True(t, len(recorder.resources) >= 2)
+				var lastTwo []string
+				for _, r := range recorder.resources[len(recorder.resources)-2:] {
+					lastTwo = append(lastTwo, r.groupResource)
+				}
+				assert.Equal(t, tc.wantLastTwo, lastTwo)
+			}
+		})
+	}
+}
+
 // TestInvalidTarballContents runs restores for tarballs that are invalid in some way, and
 // verifies that the set of items created in the API and the errors returned are correct.
 // Validation is done by looking at the namespaces/names of the items in the API and the
@@ -1622,6 +2848,84 @@ func TestShouldRestore(t *testing.T) {
 	}
 }
 
+func TestApplyItem(t *testing.T) {
+	startTime := metav1.NewTime(time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name              string
+		applyErr          error
+		creationTimestamp metav1.Time
+		wantApplied       bool
+		wantWarnings      Result
+		wantErrs          Result
+		wantManifest      []ManifestItem
+	}{
+		{
+			name:              "successful apply returns no warnings or errors",
+			creationTimestamp: metav1.NewTime(startTime.Add(-time.Hour)),
+			wantApplied:       true,
+		},
+		{
+			name:              "item created by a successful apply is added to the manifest",
+			creationTimestamp: startTime,
+			wantApplied:       true,
+			wantManifest: []ManifestItem{
+				{APIVersion: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "ns-1", Name: "cm-1", UID: "uid-1"},
+			},
+		},
+		{
+			name:        "unsupported media type falls back to create",
+			applyErr:    apierrors.NewGenericServerResponse(415, "PATCH", schema.GroupResource{Resource: "configmaps"}, "cm-1", "", 0, false),
+			wantApplied: false,
+		},
+		{
+			name:        "conflict is a warning",
+			applyErr:    apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "cm-1", errors.New("field managed by kubectl")),
+			wantApplied: true,
+			wantWarnings: Result{
+				Namespaces: map[string][]string{
+					"ns-1": {`not restored: configmaps/namespaces/ns-1/cm-1 has fields managed by another client: Operation cannot be fulfilled on configmaps "cm-1": field managed by kubectl`},
+				},
+			},
+		},
+		{
+			name:        "other errors are errors",
+			applyErr:    errors.New("boom"),
+			wantApplied: true,
+			wantErrs: Result{
+				Namespaces: map[string][]string{
+					"ns-1": {"error restoring configmaps/namespaces/ns-1/cm-1: boom"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := test.UnstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-1"}}`)
+			appliedObj := obj.DeepCopy()
+			appliedObj.SetUID("uid-1")
+			appliedObj.SetCreationTimestamp(tc.creationTimestamp)
+
+			resourceClient := &test.FakeDynamicClient{}
+			resourceClient.On("Apply", "cm-1", obj, restoreFieldManager, false).Return(appliedObj, tc.applyErr)
+
+			ctx := &context{
+				log:       test.NewLogger(),
+				manifest:  new(Manifest),
+				startTime: startTime,
+			}
+
+			warnings, errs, applied := ctx.applyItem(obj, schema.GroupResource{Resource: "configmaps"}, resourceClient, "ns-1", "ns-1", "configmaps/namespaces/ns-1/cm-1")
+			assert.Equal(t, tc.wantApplied, applied)
+			assert.Equal(t, tc.wantWarnings, warnings)
+			assert.Equal(t, tc.wantErrs, errs)
+			assert.Equal(t, tc.wantManifest, ctx.manifest.Items)
+			resourceClient.AssertExpectations(t)
+		})
+	}
+}
+
 func assertRestoredItems(t *testing.T, h *harness, want []*test.APIResource) {
 	t.Helper()
 
@@ -2150,6 +3454,15 @@ func TestPrioritizeResources(t *testing.T) {
 			excludes:   []string{"ooo", "pods"},
 			expected:   []string{"namespaces", "configmaps", "aaa", "bbb", "ddd", "sss"},
 		},
+		{
+			name: "priorities that can't be resolved are skipped",
+			apiResources: map[string][]string{
+				"v1": {"aaa", "configmaps", "namespaces"},
+			},
+			priorities: []string{"namespaces", "foos.example.com", "configmaps"},
+			includes:   []string{"*"},
+			expected:   []string{"namespaces", "configmaps", "aaa"},
+		},
 	}
 
 	logger := testutil.NewLogger()
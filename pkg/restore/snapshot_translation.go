@@ -0,0 +1,116 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/heptio/velero/pkg/util/boolptr"
+	"github.com/heptio/velero/pkg/volume"
+)
+
+// untranslatableSnapshotReason returns why the snapshot of the persistent
+// volume with the given name can't be restored in this cluster, or "" if it
+// can or the persistent volume has no snapshot. A provider snapshot can't be
+// restored if its volume snapshot location doesn't exist or now uses a
+// different provider, e.g. when a backup taken on AWS is restored on GCP,
+// and a CSI snapshot can't be restored if there's no VolumeSnapshotClass for
+// its driver.
+func (ctx *context) untranslatableSnapshotReason(pvName string) (string, error) {
+	if boolptr.IsSetToFalse(ctx.restore.Spec.RestorePVs) || boolptr.IsSetToFalse(ctx.backup.Spec.SnapshotVolumes) {
+		return "", nil
+	}
+
+	var snapshot *volume.Snapshot
+	for _, s := range ctx.volumeSnapshots {
+		if s.Spec.PersistentVolumeName == pvName {
+			snapshot = s
+			break
+		}
+	}
+	if snapshot == nil {
+		return "", nil
+	}
+
+	if snapshot.Spec.CSIDriver != "" {
+		if ctx.csiSnapshotter == nil {
+			return "", nil
+		}
+
+		class, err := ctx.csiSnapshotter.SnapshotClass(snapshot.Spec.CSIDriver)
+		if err != nil {
+			return "", err
+		}
+		if class == "" {
+			return fmt.Sprintf("there's no volume snapshot class for CSI driver %s", snapshot.Spec.CSIDriver), nil
+		}
+		return "", nil
+	}
+
+	if ctx.snapshotLocationLister == nil {
+		return "", nil
+	}
+
+	location, err := ctx.snapshotLocationLister.VolumeSnapshotLocations(ctx.backup.Namespace).Get(snapshot.Spec.Location)
+	if apierrors.IsNotFound(err) {
+		return fmt.Sprintf("volume snapshot location %s doesn't exist", snapshot.Spec.Location), nil
+	}
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// snapshots from backups taken before providers were recorded are assumed
+	// to be restorable.
+	if snapshot.Spec.Provider != "" && normalizeProvider(snapshot.Spec.Provider) != normalizeProvider(location.Spec.Provider) {
+		return fmt.Sprintf("it was taken with provider %s, but volume snapshot location %s uses provider %s", snapshot.Spec.Provider, location.Name, location.Spec.Provider), nil
+	}
+
+	return "", nil
+}
+
+// translateSnapshot records that the persistent volume with the given name is
+// dynamically re-provisioned, and its data restored with restic, instead of
+// being restored from its snapshot.
+func (ctx *context) translateSnapshot(pvName string) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.pvsToProvision.Insert(pvName)
+	ctx.translatedPVs.Insert(pvName)
+}
+
+// isTranslated returns whether the persistent volume with the given name is
+// restored with restic instead of from its snapshot.
+func (ctx *context) isTranslated(pvName string) bool {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	return ctx.translatedPVs.Has(pvName)
+}
+
+// normalizeProvider returns the fully-qualified name of a provider, as plugins
+// are registered: names without a namespace are in the velero.io namespace.
+func normalizeProvider(provider string) string {
+	if !strings.Contains(provider, "/") {
+		return "velero.io/" + provider
+	}
+	return provider
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/heptio/velero/pkg/kuberesource"
+	"github.com/heptio/velero/pkg/plugin/velero"
+)
+
+// DefaultReadyTimeout is how long a restore waits for each resource's restored
+// items to be ready if its ReadyTimeout isn't set.
+const DefaultReadyTimeout = 10 * time.Minute
+
+// readyPollInterval is how often restored items are checked while waiting
+// for them to be ready.
+var readyPollInterval = time.Second
+
+// readyCheck returns whether a restored item is ready for the items that
+// depend on it to be restored.
+type readyCheck func(ctx *context, obj *unstructured.Unstructured) (bool, error)
+
+// readyChecks are the resources whose restored items are waited for when a
+// restore's WaitForReady is set.
+var readyChecks = map[schema.GroupResource]readyCheck{
+	kuberesource.CustomResourceDefinitions: crdEstablished,
+	kuberesource.PersistentVolumeClaims:    pvcBound,
+}
+
+// waitForReady waits, if the restore's WaitForReady is set, for the restored
+// items of the resource at index i of ctx.prioritizedResources to be ready,
+// returning a warning for each item that isn't ready in time. Once custom
+// resource definitions are established, discovery is refreshed and the
+// resources that haven't been restored yet are prioritized again, so that the
+// new custom resources are restored.
+func (ctx *context) waitForReady(resource schema.GroupResource, i int) Result {
+	warnings := Result{}
+
+	check, ok := readyChecks[resource]
+	if !ok || !ctx.restore.Spec.WaitForReady {
+		return warnings
+	}
+
+	var items []velero.ResourceIdentifier
+	for item := range ctx.restoredItems {
+		if item.GroupResource == resource {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return warnings
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	timeout := ctx.restore.Spec.ReadyTimeout.Duration
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	for _, item := range items {
+		if err := ctx.waitForItem(item, check, deadline); err != nil {
+			addToResult(&warnings, item.Namespace, err)
+		}
+	}
+
+	if resource == kuberesource.CustomResourceDefinitions {
+		if err := ctx.reprioritizeResources(i); err != nil {
+			addVeleroError(&warnings, err)
+		}
+	}
+
+	return warnings
+}
+
+// waitForItem polls a restored item until the check returns that it's ready,
+// it no longer exists, or the deadline passes.
+func (ctx *context) waitForItem(item velero.ResourceIdentifier, check readyCheck, deadline time.Time) error {
+	client, ok := ctx.resourceClients[resourceClientKey{resource: item.GroupResource, namespace: item.Namespace}]
+	if !ok {
+		return nil
+	}
+
+	log := ctx.log.WithField("resource", item.GroupResource.String()).WithField("namespace", item.Namespace).WithField("name", item.Name)
+	log.Info("Waiting for restored item to be ready")
+
+	for {
+		obj, err := client.Get(item.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			// the item wasn't created, which is already recorded as an error
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error waiting for %s to be ready", itemDescription(item))
+		}
+
+		ready, err := check(ctx, obj)
+		if err != nil {
+			return errors.Wrapf(err, "error waiting for %s to be ready", itemDescription(item))
+		}
+		if ready {
+			log.Info("Restored item is ready")
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return errors.Errorf("%s wasn't ready within the restore's ready timeout", itemDescription(item))
+		}
+		time.Sleep(readyPollInterval)
+	}
+}
+
+// reprioritizeResources refreshes discovery and replaces the resources after
+// index i of ctx.prioritizedResources with the resources that haven't been
+// restored yet, prioritized again.
+func (ctx *context) reprioritizeResources(i int) error {
+	if err := ctx.discoveryHelper.Refresh(); err != nil {
+		return errors.Wrap(err, "error refreshing discovery after restoring custom resource definitions")
+	}
+
+	ctx.resourceIncludesExcludes = getResourceIncludesExcludes(ctx.discoveryHelper, ctx.restore.Spec.IncludedResources, ctx.restore.Spec.ExcludedResources)
+	prioritized, err := prioritizeResources(ctx.discoveryHelper, ctx.resourcePriorities, ctx.resourceIncludesExcludes, ctx.log)
+	if err != nil {
+		return errors.Wrap(err, "error prioritizing resources after restoring custom resource definitions")
+	}
+
+	done := sets.NewString()
+	for _, resource := range ctx.prioritizedResources[:i+1] {
+		done.Insert(resource.String())
+	}
+
+	remaining := append([]schema.GroupResource{}, ctx.prioritizedResources[:i+1]...)
+	for _, resource := range controlPlaneConfigLast(prioritized) {
+		if !done.Has(resource.String()) {
+			remaining = append(remaining, resource)
+		}
+	}
+	ctx.prioritizedResources = remaining
+
+	return nil
+}
+
+func itemDescription(item velero.ResourceIdentifier) string {
+	if item.Namespace == "" {
+		return item.GroupResource.String() + " " + item.Name
+	}
+	return item.GroupResource.String() + " " + item.Namespace + "/" + item.Name
+}
+
+// crdEstablished returns whether a custom resource definition is established,
+// i.e. its custom resources can be created.
+func crdEstablished(_ *context, obj *unstructured.Unstructured) (bool, error) {
+	conditions, _, err := unstructured.NestedSlice(obj.UnstructuredContent(), "status", "conditions")
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	for _, condition := range conditions {
+		c, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if c["type"] == "Established" && c["status"] == "True" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// pvcBound returns whether a persistent volume claim is bound to a persistent
+// volume. Claims whose storage class only binds them once a pod uses them are
+// ready straight away, since they'd otherwise never be.
+func pvcBound(ctx *context, obj *unstructured.Unstructured) (bool, error) {
+	phase, _, err := unstructured.NestedString(obj.UnstructuredContent(), "status", "phase")
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if phase == "Bound" {
+		return true, nil
+	}
+
+	storageClass, _, err := unstructured.NestedString(obj.UnstructuredContent(), "spec", "storageClassName")
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if storageClass == "" {
+		storageClass = obj.GetAnnotations()[storageClassAnnotation]
+	}
+	if storageClass == "" {
+		return false, nil
+	}
+
+	client, err := ctx.dynamicFactory.ClientForGroupVersionResource(
+		schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"},
+		metav1.APIResource{Name: "storageclasses"},
+		"",
+	)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	sc, err := client.Get(storageClass, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	bindingMode, _, err := unstructured.NestedString(sc.UnstructuredContent(), "volumeBindingMode")
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return bindingMode == "WaitForFirstConsumer", nil
+}
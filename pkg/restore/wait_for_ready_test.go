@@ -0,0 +1,279 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/client"
+	"github.com/heptio/velero/pkg/discovery"
+	"github.com/heptio/velero/pkg/kuberesource"
+	"github.com/heptio/velero/pkg/plugin/velero"
+	"github.com/heptio/velero/pkg/test"
+	"github.com/heptio/velero/pkg/util/collections"
+)
+
+func toUnstructuredOrFail(t *testing.T, obj interface{}) *unstructured.Unstructured {
+	t.Helper()
+
+	res, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: res}
+}
+
+func crd(conditions ...map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion("apiextensions.k8s.io/v1beta1")
+	obj.SetKind("CustomResourceDefinition")
+	obj.SetName("foos.example.com")
+
+	var list []interface{}
+	for _, condition := range conditions {
+		list = append(list, condition)
+	}
+	if list != nil {
+		obj.Object["status"] = map[string]interface{}{"conditions": list}
+	}
+
+	return obj
+}
+
+func TestCRDEstablished(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want bool
+	}{
+		{
+			name: "a CRD without conditions isn't established",
+			obj:  crd(),
+			want: false,
+		},
+		{
+			name: "a CRD whose Established condition isn't true isn't established",
+			obj: crd(
+				map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+				map[string]interface{}{"type": "Established", "status": "False"},
+			),
+			want: false,
+		},
+		{
+			name: "a CRD whose Established condition is true is established",
+			obj: crd(
+				map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+				map[string]interface{}{"type": "Established", "status": "True"},
+			),
+			want: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := crdEstablished(nil, tc.obj)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPVCBound(t *testing.T) {
+	storageClass := func(name, bindingMode string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"volumeBindingMode": bindingMode}}
+		obj.SetAPIVersion("storage.k8s.io/v1")
+		obj.SetKind("StorageClass")
+		obj.SetName(name)
+		return obj
+	}
+
+	tests := []struct {
+		name         string
+		pvc          *corev1api.PersistentVolumeClaim
+		storageClass *unstructured.Unstructured
+		want         bool
+	}{
+		{
+			name: "a bound claim is ready",
+			pvc:  builder.ForPersistentVolumeClaim("ns-1", "pvc-1").StorageClass("sc-1").Phase(corev1api.ClaimBound).Result(),
+			want: true,
+		},
+		{
+			name: "a pending claim without a storage class isn't ready",
+			pvc:  builder.ForPersistentVolumeClaim("ns-1", "pvc-1").Phase(corev1api.ClaimPending).Result(),
+			want: false,
+		},
+		{
+			name:         "a pending claim whose storage class binds immediately isn't ready",
+			pvc:          builder.ForPersistentVolumeClaim("ns-1", "pvc-1").StorageClass("sc-1").Phase(corev1api.ClaimPending).Result(),
+			storageClass: storageClass("sc-1", "Immediate"),
+			want:         false,
+		},
+		{
+			name:         "a pending claim whose storage class waits for the first consumer is ready",
+			pvc:          builder.ForPersistentVolumeClaim("ns-1", "pvc-1").StorageClass("sc-1").Phase(corev1api.ClaimPending).Result(),
+			storageClass: storageClass("sc-1", "WaitForFirstConsumer"),
+			want:         true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicFactory := &test.FakeDynamicFactory{}
+			storageClassClient := &test.FakeDynamicClient{}
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"}, metav1.APIResource{Name: "storageclasses"}, "").Return(storageClassClient, nil)
+			if tc.storageClass != nil {
+				storageClassClient.On("Get", tc.storageClass.GetName(), metav1.GetOptions{}).Return(tc.storageClass, nil)
+			}
+
+			ctx := &context{dynamicFactory: dynamicFactory}
+
+			got, err := pvcBound(ctx, toUnstructuredOrFail(t, tc.pvc))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestWaitForReady(t *testing.T) {
+	defer func(interval time.Duration) { readyPollInterval = interval }(readyPollInterval)
+	readyPollInterval = time.Millisecond
+
+	boundPVC := builder.ForPersistentVolumeClaim("ns-1", "pvc-1").Phase(corev1api.ClaimBound).Result()
+	pendingPVC := builder.ForPersistentVolumeClaim("ns-1", "pvc-2").Phase(corev1api.ClaimPending).Result()
+
+	pvcItem := func(name string) velero.ResourceIdentifier {
+		return velero.ResourceIdentifier{GroupResource: kuberesource.PersistentVolumeClaims, Namespace: "ns-1", Name: name}
+	}
+
+	tests := []struct {
+		name         string
+		waitForReady bool
+		resource     schema.GroupResource
+		items        []velero.ResourceIdentifier
+		want         Result
+	}{
+		{
+			name:         "restored items aren't waited for when the restore doesn't wait for ready",
+			waitForReady: false,
+			resource:     kuberesource.PersistentVolumeClaims,
+			items:        []velero.ResourceIdentifier{pvcItem("pvc-2")},
+			want:         Result{},
+		},
+		{
+			name:         "resources without a ready check aren't waited for",
+			waitForReady: true,
+			resource:     kuberesource.Pods,
+			items:        []velero.ResourceIdentifier{pvcItem("pvc-2")},
+			want:         Result{},
+		},
+		{
+			name:         "ready and deleted items don't add warnings",
+			waitForReady: true,
+			resource:     kuberesource.PersistentVolumeClaims,
+			items:        []velero.ResourceIdentifier{pvcItem("pvc-1"), pvcItem("pvc-3")},
+			want:         Result{},
+		},
+		{
+			name:         "items that aren't ready in time add warnings",
+			waitForReady: true,
+			resource:     kuberesource.PersistentVolumeClaims,
+			items:        []velero.ResourceIdentifier{pvcItem("pvc-1"), pvcItem("pvc-2")},
+			want: Result{
+				Namespaces: map[string][]string{
+					"ns-1": {"persistentvolumeclaims ns-1/pvc-2 wasn't ready within the restore's ready timeout"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pvcClient := &test.FakeDynamicClient{}
+			pvcClient.On("Get", "pvc-1", metav1.GetOptions{}).Return(toUnstructuredOrFail(t, boundPVC), nil)
+			pvcClient.On("Get", "pvc-2", metav1.GetOptions{}).Return(toUnstructuredOrFail(t, pendingPVC), nil)
+			pvcClient.On("Get", "pvc-3", metav1.GetOptions{}).Return((*unstructured.Unstructured)(nil), apierrors.NewNotFound(schema.GroupResource{Resource: "persistentvolumeclaims"}, "pvc-3"))
+
+			ctx := &context{
+				restore: defaultRestore().WaitForReady(tc.waitForReady).ReadyTimeout(10 * time.Millisecond).Result(),
+				log:     test.NewLogger(),
+				resourceClients: map[resourceClientKey]client.Dynamic{
+					{resource: kuberesource.PersistentVolumeClaims, namespace: "ns-1"}: pvcClient,
+				},
+				restoredItems: make(map[velero.ResourceIdentifier]struct{}),
+			}
+			for _, item := range tc.items {
+				ctx.restoredItems[item] = struct{}{}
+			}
+
+			assert.Equal(t, tc.want, ctx.waitForReady(tc.resource, 0))
+		})
+	}
+}
+
+func TestReprioritizeResources(t *testing.T) {
+	logger := test.NewLogger()
+
+	discoveryClient := &test.DiscoveryClient{
+		FakeDiscovery: kubefake.NewSimpleClientset().Discovery().(*discoveryfake.FakeDiscovery),
+	}
+	discoveryClient.
+		WithAPIResource(&test.APIResource{Group: "apiextensions.k8s.io", Version: "v1beta1", Name: "customresourcedefinitions"}).
+		WithAPIResource(test.Pods()).
+		WithAPIResource(test.Secrets())
+
+	helper, err := discovery.NewHelper(discoveryClient, logger)
+	require.NoError(t, err)
+
+	priorities := []string{"customresourcedefinitions", "foos.example.com", "secrets"}
+	includesExcludes := collections.NewIncludesExcludes().Includes("*")
+
+	prioritized, err := prioritizeResources(helper, priorities, includesExcludes, logger)
+	require.NoError(t, err)
+	require.Equal(t, []schema.GroupResource{kuberesource.CustomResourceDefinitions, {Resource: "secrets"}, kuberesource.Pods}, prioritized)
+
+	// the restored CRD's custom resources are now served
+	discoveryClient.WithAPIResource(&test.APIResource{Group: "example.com", Version: "v1", Name: "foos", Namespaced: true})
+
+	ctx := &context{
+		restore:                  defaultRestore().Result(),
+		log:                      logger,
+		discoveryHelper:          helper,
+		resourcePriorities:       priorities,
+		resourceIncludesExcludes: includesExcludes,
+		prioritizedResources:     prioritized,
+	}
+	require.NoError(t, ctx.reprioritizeResources(0))
+
+	assert.Equal(t, []schema.GroupResource{
+		kuberesource.CustomResourceDefinitions,
+		{Group: "example.com", Resource: "foos"},
+		{Resource: "secrets"},
+		kuberesource.Pods,
+	}, ctx.prioritizedResources)
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	pkgbackup "github.com/heptio/velero/pkg/backup"
+	"github.com/heptio/velero/pkg/cmd/util/downloadrequest"
+	clientset "github.com/heptio/velero/pkg/generated/clientset/versioned"
+	pkgrestore "github.com/heptio/velero/pkg/restore"
+)
+
+const (
+	// DefaultPollInterval is how often a Client checks whether a backup or
+	// restore it's waiting for has finished, if not set with
+	// WithPollInterval.
+	DefaultPollInterval = 2 * time.Second
+
+	// DefaultDownloadTimeout is how long a Client waits for the Velero
+	// server to process a request for a backup's or restore's logs or
+	// results, if not set with WithDownloadTimeout.
+	DefaultDownloadTimeout = time.Minute
+)
+
+// Client creates and manages the backups and restores in the namespace that
+// the Velero server runs in.
+type Client struct {
+	veleroClient    clientset.Interface
+	namespace       string
+	pollInterval    time.Duration
+	downloadTimeout time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithPollInterval sets how often the Client checks whether a backup or
+// restore it's waiting for has finished.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// WithDownloadTimeout sets how long the Client waits for the Velero server
+// to process a request for a backup's or restore's logs or results.
+func WithDownloadTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.downloadTimeout = timeout
+	}
+}
+
+// New returns a Client that uses the Velero clientset to manage the backups
+// and restores in namespace, which is the namespace the Velero server runs
+// in, usually "velero".
+func New(veleroClient clientset.Interface, namespace string, opts ...Option) *Client {
+	c := &Client{
+		veleroClient:    veleroClient,
+		namespace:       namespace,
+		pollInterval:    DefaultPollInterval,
+		downloadTimeout: DefaultDownloadTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// NewForConfig returns a Client that connects to the cluster with the REST
+// config to manage the backups and restores in namespace.
+func NewForConfig(config *rest.Config, namespace string, opts ...Option) (*Client, error) {
+	veleroClient, err := clientset.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating Velero clientset")
+	}
+
+	return New(veleroClient, namespace, opts...), nil
+}
+
+// Clientset returns the generated Velero clientset the Client uses, for the
+// operations the Client doesn't have helpers for.
+func (c *Client) Clientset() clientset.Interface {
+	return c.veleroClient
+}
+
+// Namespace returns the namespace of the Client's backups and restores.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// CreateBackup creates a backup in the Client's namespace, which the Velero
+// server then runs. Use the builder package, e.g. builder.ForBackup, to
+// build it.
+func (c *Client) CreateBackup(backup *velerov1api.Backup) (*velerov1api.Backup, error) {
+	backup = backup.DeepCopy()
+	backup.Namespace = c.namespace
+
+	created, err := c.veleroClient.VeleroV1().Backups(c.namespace).Create(backup)
+	return created, errors.Wrapf(err, "error creating backup %s", backup.Name)
+}
+
+// GetBackup gets a backup in the Client's namespace.
+func (c *Client) GetBackup(name string) (*velerov1api.Backup, error) {
+	backup, err := c.veleroClient.VeleroV1().Backups(c.namespace).Get(name, metav1.GetOptions{})
+	return backup, errors.Wrapf(err, "error getting backup %s", name)
+}
+
+// DeleteBackup requests the deletion of a backup, along with its data in
+// backup storage and its volume snapshots. The Velero server deletes it
+// asynchronously.
+func (c *Client) DeleteBackup(name string) error {
+	backup, err := c.GetBackup(name)
+	if err != nil {
+		return err
+	}
+
+	req := pkgbackup.NewDeleteBackupRequest(backup.Name, string(backup.UID))
+	_, err = c.veleroClient.VeleroV1().DeleteBackupRequests(c.namespace).Create(req)
+	return errors.Wrapf(err, "error creating delete backup request for backup %s", name)
+}
+
+// WaitForBackup waits for a backup to finish, i.e. to be in the Completed,
+// PartiallyFailed, Failed or FailedValidation phase, and returns it. It
+// returns an error if ctx is done first.
+func (c *Client) WaitForBackup(ctx context.Context, name string) (*velerov1api.Backup, error) {
+	var backup *velerov1api.Backup
+	err := wait.PollImmediateUntil(c.pollInterval, func() (bool, error) {
+		var err error
+		if backup, err = c.GetBackup(name); err != nil {
+			return false, err
+		}
+		return BackupFinished(backup), nil
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		return nil, errors.Wrapf(ctx.Err(), "error waiting for backup %s to finish", name)
+	}
+	return backup, err
+}
+
+// BackupFinished returns whether the Velero server has finished processing a
+// backup.
+func BackupFinished(backup *velerov1api.Backup) bool {
+	switch backup.Status.Phase {
+	case velerov1api.BackupPhaseCompleted,
+		velerov1api.BackupPhasePartiallyFailed,
+		velerov1api.BackupPhaseFailed,
+		velerov1api.BackupPhaseFailedValidation:
+		return true
+	default:
+		return false
+	}
+}
+
+// BackupLogs writes a finished backup's log, from backup storage, to w.
+func (c *Client) BackupLogs(name string, w io.Writer) error {
+	err := downloadrequest.Stream(c.veleroClient.VeleroV1(), c.namespace, name, velerov1api.DownloadTargetKindBackupLog, w, c.downloadTimeout)
+	return errors.Wrapf(err, "error getting logs of backup %s", name)
+}
+
+// CreateRestore creates a restore in the Client's namespace, which the
+// Velero server then runs. Use the builder package, e.g. builder.ForRestore,
+// to build it.
+func (c *Client) CreateRestore(restore *velerov1api.Restore) (*velerov1api.Restore, error) {
+	restore = restore.DeepCopy()
+	restore.Namespace = c.namespace
+
+	created, err := c.veleroClient.VeleroV1().Restores(c.namespace).Create(restore)
+	return created, errors.Wrapf(err, "error creating restore %s", restore.Name)
+}
+
+// GetRestore gets a restore in the Client's namespace.
+func (c *Client) GetRestore(name string) (*velerov1api.Restore, error) {
+	restore, err := c.veleroClient.VeleroV1().Restores(c.namespace).Get(name, metav1.GetOptions{})
+	return restore, errors.Wrapf(err, "error getting restore %s", name)
+}
+
+// WaitForRestore waits for a restore to finish, i.e. to be in the Completed,
+// PartiallyFailed, Failed or FailedValidation phase, and returns it. It
+// returns an error if ctx is done first.
+func (c *Client) WaitForRestore(ctx context.Context, name string) (*velerov1api.Restore, error) {
+	var restore *velerov1api.Restore
+	err := wait.PollImmediateUntil(c.pollInterval, func() (bool, error) {
+		var err error
+		if restore, err = c.GetRestore(name); err != nil {
+			return false, err
+		}
+		return RestoreFinished(restore), nil
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		return nil, errors.Wrapf(ctx.Err(), "error waiting for restore %s to finish", name)
+	}
+	return restore, err
+}
+
+// RestoreFinished returns whether the Velero server has finished processing
+// a restore.
+func RestoreFinished(restore *velerov1api.Restore) bool {
+	switch restore.Status.Phase {
+	case velerov1api.RestorePhaseCompleted,
+		velerov1api.RestorePhasePartiallyFailed,
+		velerov1api.RestorePhaseFailed,
+		velerov1api.RestorePhaseFailedValidation:
+		return true
+	default:
+		return false
+	}
+}
+
+// RestoreLogs writes a finished restore's log, from backup storage, to w.
+func (c *Client) RestoreLogs(name string, w io.Writer) error {
+	err := downloadrequest.Stream(c.veleroClient.VeleroV1(), c.namespace, name, velerov1api.DownloadTargetKindRestoreLog, w, c.downloadTimeout)
+	return errors.Wrapf(err, "error getting logs of restore %s", name)
+}
+
+// RestoreResults are the warnings and errors of a finished restore, and, for
+// a dry run, what it would have done.
+type RestoreResults struct {
+	Warnings pkgrestore.Result
+	Errors   pkgrestore.Result
+
+	// DryRun is nil unless the restore is a dry run.
+	DryRun *pkgrestore.DryRunResults
+}
+
+// RestoreResults gets a finished restore's results from backup storage.
+func (c *Client) RestoreResults(name string) (*RestoreResults, error) {
+	var buf bytes.Buffer
+	if err := downloadrequest.Stream(c.veleroClient.VeleroV1(), c.namespace, name, velerov1api.DownloadTargetKindRestoreResults, &buf, c.downloadTimeout); err != nil {
+		return nil, errors.Wrapf(err, "error getting results of restore %s", name)
+	}
+
+	var resultMap map[string]pkgrestore.Result
+	if err := json.NewDecoder(&buf).Decode(&resultMap); err != nil {
+		return nil, errors.Wrapf(err, "error decoding results of restore %s", name)
+	}
+
+	results := &RestoreResults{
+		Warnings: resultMap["warnings"],
+		Errors:   resultMap["errors"],
+	}
+
+	_, created := resultMap["created"]
+	_, skipped := resultMap["skipped"]
+	_, conflicts := resultMap["conflicts"]
+	if created || skipped || conflicts {
+		results.DryRun = &pkgrestore.DryRunResults{
+			Created:   resultMap["created"],
+			Skipped:   resultMap["skipped"],
+			Conflicts: resultMap["conflicts"],
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+)
+
+func TestCreateBackup(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := New(client, velerov1api.DefaultNamespace)
+
+	created, err := c.CreateBackup(builder.ForBackup("other-ns", "backup-1").IncludedNamespaces("ns-1").Result())
+	require.NoError(t, err)
+	assert.Equal(t, velerov1api.DefaultNamespace, created.Namespace)
+
+	backup, err := client.VeleroV1().Backups(velerov1api.DefaultNamespace).Get("backup-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns-1"}, backup.Spec.IncludedNamespaces)
+}
+
+func TestDeleteBackup(t *testing.T) {
+	backup := builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").ObjectMeta(builder.WithUID("uid-1")).Result()
+	client := fake.NewSimpleClientset(backup)
+	c := New(client, velerov1api.DefaultNamespace)
+
+	require.NoError(t, c.DeleteBackup("backup-1"))
+
+	reqs, err := client.VeleroV1().DeleteBackupRequests(velerov1api.DefaultNamespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, reqs.Items, 1)
+	assert.Equal(t, "backup-1", reqs.Items[0].Spec.BackupName)
+	assert.Equal(t, "uid-1", reqs.Items[0].Labels[velerov1api.BackupUIDLabel])
+
+	assert.Error(t, c.DeleteBackup("backup-2"))
+}
+
+func TestWaitForBackup(t *testing.T) {
+	t.Run("returns the backup once it's finished", func(t *testing.T) {
+		client := fake.NewSimpleClientset(builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").Phase(velerov1api.BackupPhaseInProgress).Result())
+
+		// the backup is finished on the third get
+		var gets int
+		client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			gets++
+			if gets < 3 {
+				return false, nil, nil
+			}
+			return true, builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").Phase(velerov1api.BackupPhasePartiallyFailed).Result(), nil
+		})
+
+		c := New(client, velerov1api.DefaultNamespace, WithPollInterval(time.Millisecond))
+
+		backup, err := c.WaitForBackup(context.Background(), "backup-1")
+		require.NoError(t, err)
+		assert.Equal(t, velerov1api.BackupPhasePartiallyFailed, backup.Status.Phase)
+		assert.Equal(t, 3, gets)
+	})
+
+	t.Run("returns an error if the context is done first", func(t *testing.T) {
+		client := fake.NewSimpleClientset(builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").Phase(velerov1api.BackupPhaseInProgress).Result())
+		c := New(client, velerov1api.DefaultNamespace, WithPollInterval(time.Millisecond))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := c.WaitForBackup(ctx, "backup-1")
+		assert.EqualError(t, err, "error waiting for backup backup-1 to finish: context deadline exceeded")
+	})
+
+	t.Run("returns an error if the backup doesn't exist", func(t *testing.T) {
+		c := New(fake.NewSimpleClientset(), velerov1api.DefaultNamespace, WithPollInterval(time.Millisecond))
+
+		_, err := c.WaitForBackup(context.Background(), "backup-1")
+		assert.Error(t, err)
+	})
+}
+
+func TestWaitForRestore(t *testing.T) {
+	client := fake.NewSimpleClientset(builder.ForRestore(velerov1api.DefaultNamespace, "restore-1").Phase(velerov1api.RestorePhaseCompleted).Result())
+	c := New(client, velerov1api.DefaultNamespace, WithPollInterval(time.Millisecond))
+
+	restore, err := c.WaitForRestore(context.Background(), "restore-1")
+	require.NoError(t, err)
+	assert.Equal(t, velerov1api.RestorePhaseCompleted, restore.Status.Phase)
+}
+
+func TestBackupFinished(t *testing.T) {
+	tests := []struct {
+		phase velerov1api.BackupPhase
+		want  bool
+	}{
+		{phase: "", want: false},
+		{phase: velerov1api.BackupPhaseNew, want: false},
+		{phase: velerov1api.BackupPhaseInProgress, want: false},
+		{phase: velerov1api.BackupPhaseDeleting, want: false},
+		{phase: velerov1api.BackupPhaseCompleted, want: true},
+		{phase: velerov1api.BackupPhasePartiallyFailed, want: true},
+		{phase: velerov1api.BackupPhaseFailed, want: true},
+		{phase: velerov1api.BackupPhaseFailedValidation, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.phase), func(t *testing.T) {
+			assert.Equal(t, tc.want, BackupFinished(builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").Phase(tc.phase).Result()))
+		})
+	}
+}
+
+func TestRestoreFinished(t *testing.T) {
+	tests := []struct {
+		phase velerov1api.RestorePhase
+		want  bool
+	}{
+		{phase: "", want: false},
+		{phase: velerov1api.RestorePhaseNew, want: false},
+		{phase: velerov1api.RestorePhaseInProgress, want: false},
+		{phase: velerov1api.RestorePhaseCompleted, want: true},
+		{phase: velerov1api.RestorePhasePartiallyFailed, want: true},
+		{phase: velerov1api.RestorePhaseFailed, want: true},
+		{phase: velerov1api.RestorePhaseFailedValidation, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.phase), func(t *testing.T) {
+			assert.Equal(t, tc.want, RestoreFinished(builder.ForRestore(velerov1api.DefaultNamespace, "restore-1").Phase(tc.phase).Result()))
+		})
+	}
+}
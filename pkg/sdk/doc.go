@@ -0,0 +1,26 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sdk is a Go client library for using Velero programmatically, e.g.
+// from an operator that backs up and restores the applications it manages.
+// It wraps the generated Velero clientset with helpers for creating backups
+// and restores, waiting for them to finish, and fetching their logs and
+// results from backup storage.
+//
+// The functions and types in this package are kept backwards-compatible
+// across Velero minor releases. The generated clientset, available from
+// Client.Clientset, follows the compatibility of the Velero API types.
+package sdk
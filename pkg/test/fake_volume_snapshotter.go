@@ -34,6 +34,9 @@ type FakeVolumeSnapshotter struct {
 	// SnapshotID->VolumeID
 	SnapshotsTaken sets.String
 
+	// SnapshotID -> tags
+	SnapshotTags map[string]map[string]string
+
 	// VolumeID -> (SnapshotID, Type, Iops)
 	SnapshottableVolumes map[string]VolumeBackupInfo
 
@@ -64,6 +67,11 @@ func (bs *FakeVolumeSnapshotter) CreateSnapshot(volumeID, volumeAZ string, tags
 	}
 	bs.SnapshotsTaken.Insert(bs.SnapshottableVolumes[volumeID].SnapshotID)
 
+	if bs.SnapshotTags == nil {
+		bs.SnapshotTags = make(map[string]map[string]string)
+	}
+	bs.SnapshotTags[bs.SnapshottableVolumes[volumeID].SnapshotID] = tags
+
 	return bs.SnapshottableVolumes[volumeID].SnapshotID, nil
 }
 
@@ -96,6 +104,21 @@ func (bs *FakeVolumeSnapshotter) DeleteSnapshot(snapshotID string) error {
 	return nil
 }
 
+func (bs *FakeVolumeSnapshotter) ListSnapshots(tagKey string) (map[string]string, error) {
+	if bs.Error != nil {
+		return nil, bs.Error
+	}
+
+	snapshots := make(map[string]string)
+	for snapshotID := range bs.SnapshotsTaken {
+		if val, ok := bs.SnapshotTags[snapshotID][tagKey]; ok {
+			snapshots[snapshotID] = val
+		}
+	}
+
+	return snapshots, nil
+}
+
 func (bs *FakeVolumeSnapshotter) GetVolumeInfo(volumeID, volumeAZ string) (string, *int64, error) {
 	if bs.Error != nil {
 		return "", nil, bs.Error
@@ -129,3 +129,23 @@ func ServiceAccounts(items ...metav1.Object) *APIResource {
 		Items:      items,
 	}
 }
+
+func FlowSchemas(items ...metav1.Object) *APIResource {
+	return &APIResource{
+		Group:      "flowcontrol.apiserver.k8s.io",
+		Version:    "v1alpha1",
+		Name:       "flowschemas",
+		Namespaced: false,
+		Items:      items,
+	}
+}
+
+func ValidatingWebhookConfigurations(items ...metav1.Object) *APIResource {
+	return &APIResource{
+		Group:      "admissionregistration.k8s.io",
+		Version:    "v1beta1",
+		Name:       "validatingwebhookconfigurations",
+		Namespaced: false,
+		Items:      items,
+	}
+}
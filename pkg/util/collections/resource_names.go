@@ -0,0 +1,153 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collections
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/velero/pkg/discovery"
+)
+
+// ResourceNameIncludesExcludes manages lists of included and excluded
+// items, each given as <resource>/<name>. For each resource that has
+// included items, only those items are included; resources that don't
+// have any are unaffected. Items in the excluded list are never included.
+// Names may contain wildcards, e.g. clusterroles/team-a-*. A nil
+// ResourceNameIncludesExcludes includes everything.
+type ResourceNameIncludesExcludes struct {
+	includes map[string]globStringSet
+	excludes map[string]globStringSet
+}
+
+// ShouldInclude returns whether the item with the given resource and name
+// should be included.
+func (ie *ResourceNameIncludesExcludes) ShouldInclude(resource, name string) bool {
+	if ie == nil {
+		return true
+	}
+
+	if excludes, ok := ie.excludes[resource]; ok && excludes.match(name) {
+		return false
+	}
+
+	if includes, ok := ie.includes[resource]; ok {
+		return includes.match(name)
+	}
+
+	return true
+}
+
+// IncludesResource returns whether the included list has any items of the
+// given resource.
+func (ie *ResourceNameIncludesExcludes) IncludesResource(resource string) bool {
+	if ie == nil {
+		return false
+	}
+
+	_, ok := ie.includes[resource]
+	return ok
+}
+
+// splitResourceName splits an item given as <resource>/<name>.
+func splitResourceName(item string) (string, string, error) {
+	parts := strings.SplitN(item, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.Contains(parts[1], "/") {
+		return "", "", errors.Errorf("%q must be given as <resource>/<name>", item)
+	}
+	return parts[0], parts[1], nil
+}
+
+// ValidateResourceNameIncludesExcludes checks provided lists of included
+// and excluded items, each given as <resource>/<name>.
+func ValidateResourceNameIncludesExcludes(includesList, excludesList []string) []error {
+	var errs []error
+
+	for _, item := range append(append([]string{}, includesList...), excludesList...) {
+		if _, _, err := splitResourceName(item); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, item := range excludesList {
+		for _, included := range includesList {
+			if item == included {
+				errs = append(errs, errors.Errorf("excludes list cannot contain an item in the includes list: %v", item))
+			}
+		}
+	}
+
+	return errs
+}
+
+// GenerateResourceNameIncludesExcludes constructs a
+// ResourceNameIncludesExcludes from the provided include/exclude slices of
+// items given as <resource>/<name>, applying the specified mapping function
+// to each item's resource. If the mapping function returns an empty string
+// for a resource, or an item isn't valid, the item is omitted from the
+// result.
+func GenerateResourceNameIncludesExcludes(includes, excludes []string, mapFunc func(string) string) *ResourceNameIncludesExcludes {
+	res := &ResourceNameIncludesExcludes{
+		includes: make(map[string]globStringSet),
+		excludes: make(map[string]globStringSet),
+	}
+
+	add := func(sets map[string]globStringSet, items []string) {
+		for _, item := range items {
+			resource, name, err := splitResourceName(item)
+			if err != nil {
+				continue
+			}
+
+			key := mapFunc(resource)
+			if key == "" {
+				continue
+			}
+
+			if _, ok := sets[key]; !ok {
+				sets[key] = newGlobStringSet()
+			}
+			sets[key].Insert(name)
+		}
+	}
+	add(res.includes, includes)
+	add(res.excludes, excludes)
+
+	return res
+}
+
+// GetClusterResourceNames takes the lists of cluster-scoped items to include
+// and exclude, each given as <resource>/<name>, uses the discovery helper to
+// resolve their resources to fully-qualified group-resource names, and
+// returns a ResourceNameIncludesExcludes list.
+func GetClusterResourceNames(helper discovery.Helper, includes, excludes []string) *ResourceNameIncludesExcludes {
+	return GenerateResourceNameIncludesExcludes(
+		includes,
+		excludes,
+		func(item string) string {
+			gvr, _, err := helper.ResourceFor(schema.ParseGroupResource(item).WithVersion(""))
+			if err != nil {
+				return ""
+			}
+
+			gr := gvr.GroupResource()
+			return gr.String()
+		},
+	)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collections
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestResourceNameShouldInclude(t *testing.T) {
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		resource string
+		item     string
+		should   bool
+	}{
+		{
+			name:     "empty - include everything",
+			resource: "clusterroles",
+			item:     "admin",
+			should:   true,
+		},
+		{
+			name:     "include specific - found",
+			includes: []string{"clusterroles/admin", "clusterroles/edit"},
+			resource: "clusterroles",
+			item:     "admin",
+			should:   true,
+		},
+		{
+			name:     "include specific - not found",
+			includes: []string{"clusterroles/admin", "clusterroles/edit"},
+			resource: "clusterroles",
+			item:     "view",
+			should:   false,
+		},
+		{
+			name:     "include specific - other resource is unaffected",
+			includes: []string{"clusterroles/admin"},
+			resource: "storageclasses",
+			item:     "gp2",
+			should:   true,
+		},
+		{
+			name:     "exclude specific",
+			excludes: []string{"storageclasses/gp2"},
+			resource: "storageclasses",
+			item:     "gp2",
+			should:   false,
+		},
+		{
+			name:     "wildcard include",
+			includes: []string{"clusterroles/team-a-*"},
+			resource: "clusterroles",
+			item:     "team-a-admin",
+			should:   true,
+		},
+		{
+			name:     "wildcard include, exclude specific",
+			includes: []string{"clusterroles/team-a-*"},
+			excludes: []string{"clusterroles/team-a-admin"},
+			resource: "clusterroles",
+			item:     "team-a-admin",
+			should:   false,
+		},
+		{
+			name:     "resources are mapped",
+			includes: []string{"CLUSTERROLES/admin"},
+			resource: "clusterroles",
+			item:     "edit",
+			should:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ie := GenerateResourceNameIncludesExcludes(test.includes, test.excludes, func(resource string) string {
+				if resource == "CLUSTERROLES" {
+					return "clusterroles"
+				}
+				return resource
+			})
+
+			assert.Equal(t, test.should, ie.ShouldInclude(test.resource, test.item))
+		})
+	}
+}
+
+func TestGetClusterResourceNames(t *testing.T) {
+	helper := velerotest.NewFakeDiscoveryHelper(false, map[schema.GroupVersionResource]schema.GroupVersionResource{
+		{Resource: "clusterroles"}:                            {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+		{Resource: "crds"}:                                    {Group: "apiextensions.k8s.io", Version: "v1beta1", Resource: "customresourcedefinitions"},
+		{Group: "storage.k8s.io", Resource: "storageclasses"}: {Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"},
+	})
+
+	ie := GetClusterResourceNames(helper, []string{"clusterroles/admin", "storageclasses.storage.k8s.io/*", "unknown/foo"}, []string{"crds/backups.velero.io"})
+
+	assert.True(t, ie.ShouldInclude("clusterroles.rbac.authorization.k8s.io", "admin"))
+	assert.False(t, ie.ShouldInclude("clusterroles.rbac.authorization.k8s.io", "edit"))
+	assert.True(t, ie.ShouldInclude("storageclasses.storage.k8s.io", "gp2"))
+	assert.False(t, ie.IncludesResource("unknown"))
+	assert.False(t, ie.ShouldInclude("customresourcedefinitions.apiextensions.k8s.io", "backups.velero.io"))
+}
+
+func TestNilResourceNameIncludesExcludes(t *testing.T) {
+	var ie *ResourceNameIncludesExcludes
+
+	assert.True(t, ie.ShouldInclude("clusterroles", "admin"))
+	assert.False(t, ie.IncludesResource("clusterroles"))
+}
+
+func TestValidateResourceNameIncludesExcludes(t *testing.T) {
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		expected []string
+	}{
+		{
+			name:     "valid items",
+			includes: []string{"clusterroles/admin"},
+			excludes: []string{"storageclasses.storage.k8s.io/gp2"},
+		},
+		{
+			name:     "items without a name",
+			includes: []string{"clusterroles", "clusterroles/"},
+			excludes: []string{"/gp2"},
+			expected: []string{
+				`"clusterroles" must be given as <resource>/<name>`,
+				`"clusterroles/" must be given as <resource>/<name>`,
+				`"/gp2" must be given as <resource>/<name>`,
+			},
+		},
+		{
+			name:     "item in both lists",
+			includes: []string{"clusterroles/admin"},
+			excludes: []string{"clusterroles/admin"},
+			expected: []string{"excludes list cannot contain an item in the includes list: clusterroles/admin"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var errs []string
+			for _, err := range ValidateResourceNameIncludesExcludes(test.includes, test.excludes) {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, test.expected, errs)
+		})
+	}
+}
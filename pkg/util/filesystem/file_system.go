@@ -34,6 +34,7 @@ type Interface interface {
 	DirExists(path string) (bool, error)
 	TempFile(dir, prefix string) (NameWriteCloser, error)
 	Stat(path string) (os.FileInfo, error)
+	Rename(oldpath, newpath string) error
 }
 
 type NameWriteCloser interface {
@@ -90,3 +91,7 @@ func (fs *osFileSystem) TempFile(dir, prefix string) (NameWriteCloser, error) {
 func (fs *osFileSystem) Stat(path string) (os.FileInfo, error) {
 	return os.Stat(path)
 }
+
+func (fs *osFileSystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
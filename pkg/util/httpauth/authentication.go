@@ -0,0 +1,228 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package httpauth authenticates and authorizes requests to the HTTP
+// endpoints that the Velero server serves, using the Kubernetes API
+// server's authentication and authorization.
+package httpauth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+)
+
+// The methods that an endpoint can authenticate requests with.
+const (
+	// MethodToken authenticates requests with a Kubernetes bearer token in
+	// the Authorization header, which is checked with a TokenReview.
+	MethodToken = "token"
+
+	// MethodClientCert authenticates requests with a client certificate
+	// signed by the endpoint's client CA. The certificate's common name is
+	// the user's name, and its organizations are the user's groups.
+	MethodClientCert = "client-cert"
+
+	// MethodRequestHeader authenticates requests forwarded by the
+	// Kubernetes API aggregator, which presents a client certificate
+	// signed by the endpoint's client CA and identifies the user with
+	// X-Remote-* headers.
+	MethodRequestHeader = "request-header"
+)
+
+const (
+	remoteUserHeader        = "X-Remote-User"
+	remoteGroupHeader       = "X-Remote-Group"
+	remoteExtraHeaderPrefix = "X-Remote-Extra-"
+)
+
+// Authenticator identifies the user making a request.
+type Authenticator interface {
+	// AuthenticateRequest returns the user making the request, or nil if
+	// the request isn't authenticated.
+	AuthenticateRequest(req *http.Request) (*authenticationv1.UserInfo, error)
+}
+
+// TokenAuthenticator identifies the user a bearer token belongs to.
+type TokenAuthenticator interface {
+	// AuthenticateToken returns the user that the token belongs to, or
+	// nil if the token isn't valid.
+	AuthenticateToken(token string) (*authenticationv1.UserInfo, error)
+}
+
+// ValidateMethods returns an error if any of the authentication methods
+// isn't one of the allowed ones.
+func ValidateMethods(methods []string, allowed ...string) error {
+	for _, method := range methods {
+		found := false
+		for _, a := range allowed {
+			if method == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("invalid authentication method %q, must be one of: %s", method, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// NewAuthenticator returns an Authenticator that tries each of the given
+// methods in turn. Requests are only authenticated with client
+// certificates if the certificates have been verified, so the server must
+// be configured with TLSConfig.
+func NewAuthenticator(methods []string, tokens TokenAuthenticator) Authenticator {
+	var authenticators unionAuthenticator
+	for _, method := range methods {
+		switch method {
+		case MethodToken:
+			authenticators = append(authenticators, &bearerTokenAuthenticator{tokens: tokens})
+		case MethodClientCert:
+			authenticators = append(authenticators, clientCertAuthenticator{})
+		case MethodRequestHeader:
+			authenticators = append(authenticators, requestHeaderAuthenticator{})
+		}
+	}
+	return authenticators
+}
+
+// unionAuthenticator authenticates requests with the first of its
+// authenticators that identifies the user.
+type unionAuthenticator []Authenticator
+
+func (a unionAuthenticator) AuthenticateRequest(req *http.Request) (*authenticationv1.UserInfo, error) {
+	for _, authenticator := range a {
+		user, err := authenticator.AuthenticateRequest(req)
+		if err != nil || user != nil {
+			return user, err
+		}
+	}
+	return nil, nil
+}
+
+// NewTokenReviewAuthenticator returns a TokenAuthenticator that uses
+// TokenReviews, so that users authenticate with the same tokens they use
+// with the Kubernetes API.
+func NewTokenReviewAuthenticator(client authenticationv1client.TokenReviewInterface) TokenAuthenticator {
+	return &tokenReviewAuthenticator{client: client}
+}
+
+type tokenReviewAuthenticator struct {
+	client authenticationv1client.TokenReviewInterface
+}
+
+func (a *tokenReviewAuthenticator) AuthenticateToken(token string) (*authenticationv1.UserInfo, error) {
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: token,
+		},
+	}
+
+	res, err := a.client.Create(review)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating TokenReview")
+	}
+	if !res.Status.Authenticated {
+		return nil, nil
+	}
+
+	return &res.Status.User, nil
+}
+
+// BearerToken returns the bearer token in a request's Authorization
+// header, or an empty string if there isn't one.
+func BearerToken(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+}
+
+type bearerTokenAuthenticator struct {
+	tokens TokenAuthenticator
+}
+
+func (a *bearerTokenAuthenticator) AuthenticateRequest(req *http.Request) (*authenticationv1.UserInfo, error) {
+	token := BearerToken(req)
+	if token == "" {
+		return nil, nil
+	}
+	return a.tokens.AuthenticateToken(token)
+}
+
+// hasVerifiedClientCert returns whether the request's connection presented
+// a client certificate that was verified against the server's client CA.
+func hasVerifiedClientCert(req *http.Request) bool {
+	return req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0
+}
+
+type clientCertAuthenticator struct{}
+
+func (clientCertAuthenticator) AuthenticateRequest(req *http.Request) (*authenticationv1.UserInfo, error) {
+	if !hasVerifiedClientCert(req) {
+		return nil, nil
+	}
+
+	cert := req.TLS.VerifiedChains[0][0]
+	if cert.Subject.CommonName == "" {
+		return nil, nil
+	}
+
+	return &authenticationv1.UserInfo{
+		Username: cert.Subject.CommonName,
+		Groups:   cert.Subject.Organization,
+	}, nil
+}
+
+type requestHeaderAuthenticator struct{}
+
+func (requestHeaderAuthenticator) AuthenticateRequest(req *http.Request) (*authenticationv1.UserInfo, error) {
+	// the headers can only be trusted once the aggregator's client
+	// certificate has been verified.
+	if !hasVerifiedClientCert(req) {
+		return nil, nil
+	}
+
+	user := req.Header.Get(remoteUserHeader)
+	if user == "" {
+		return nil, nil
+	}
+
+	extra := make(map[string]authenticationv1.ExtraValue)
+	for header, values := range req.Header {
+		if !strings.HasPrefix(header, remoteExtraHeaderPrefix) {
+			continue
+		}
+
+		key, err := url.PathUnescape(strings.ToLower(strings.TrimPrefix(header, remoteExtraHeaderPrefix)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding header %s", header)
+		}
+		extra[key] = values
+	}
+
+	return &authenticationv1.UserInfo{
+		Username: user,
+		Groups:   req.Header[remoteGroupHeader],
+		Extra:    extra,
+	}, nil
+}
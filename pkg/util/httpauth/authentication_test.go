@@ -0,0 +1,165 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpauth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+type fakeTokenAuthenticator struct{}
+
+func (fakeTokenAuthenticator) AuthenticateToken(token string) (*authenticationv1.UserInfo, error) {
+	if token != "valid-token" {
+		return nil, nil
+	}
+	return &authenticationv1.UserInfo{Username: "alice"}, nil
+}
+
+// withClientCert returns the request as if its connection had presented a
+// client certificate that was verified.
+func withClientCert(req *http.Request, commonName string, organizations ...string) *http.Request {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName, Organization: organizations}}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return req
+}
+
+func TestValidateMethods(t *testing.T) {
+	assert.NoError(t, ValidateMethods(nil, MethodToken))
+	assert.NoError(t, ValidateMethods([]string{MethodToken, MethodClientCert}, MethodToken, MethodClientCert))
+	assert.EqualError(t, ValidateMethods([]string{MethodRequestHeader}, MethodToken, MethodClientCert), `invalid authentication method "request-header", must be one of: token, client-cert`)
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/", nil)
+	}
+
+	bearer := func(token string) *http.Request {
+		req := newRequest()
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	remoteUser := func(req *http.Request) *http.Request {
+		req.Header.Set("X-Remote-User", "carol")
+		req.Header.Add("X-Remote-Group", "dashboards")
+		req.Header.Set("X-Remote-Extra-Scopes%2fa", "view")
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		methods  []string
+		req      *http.Request
+		expected *authenticationv1.UserInfo
+	}{
+		{
+			name:     "valid bearer token",
+			methods:  []string{MethodToken},
+			req:      bearer("valid-token"),
+			expected: &authenticationv1.UserInfo{Username: "alice"},
+		},
+		{
+			name:    "invalid bearer token",
+			methods: []string{MethodToken},
+			req:     bearer("invalid-token"),
+		},
+		{
+			name:    "bearer token without the token method",
+			methods: []string{MethodClientCert},
+			req:     bearer("valid-token"),
+		},
+		{
+			name:     "client certificate",
+			methods:  []string{MethodClientCert},
+			req:      withClientCert(newRequest(), "bob", "admins"),
+			expected: &authenticationv1.UserInfo{Username: "bob", Groups: []string{"admins"}},
+		},
+		{
+			name:    "unverified client certificate",
+			methods: []string{MethodClientCert},
+			req:     newRequest(),
+		},
+		{
+			name:    "request headers",
+			methods: []string{MethodRequestHeader},
+			req:     remoteUser(withClientCert(newRequest(), "front-proxy-client")),
+			expected: &authenticationv1.UserInfo{
+				Username: "carol",
+				Groups:   []string{"dashboards"},
+				Extra:    map[string]authenticationv1.ExtraValue{"scopes/a": {"view"}},
+			},
+		},
+		{
+			name:    "request headers without a verified client certificate aren't trusted",
+			methods: []string{MethodRequestHeader},
+			req:     remoteUser(newRequest()),
+		},
+		{
+			name:     "the first method that authenticates the request is used",
+			methods:  []string{MethodToken, MethodClientCert},
+			req:      withClientCert(newRequest(), "bob"),
+			expected: &authenticationv1.UserInfo{Username: "bob"},
+		},
+		{
+			name: "no methods",
+			req:  bearer("valid-token"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := NewAuthenticator(tc.methods, fakeTokenAuthenticator{}).AuthenticateRequest(tc.req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, user)
+		})
+	}
+}
+
+func TestTokenReviewAuthenticator(t *testing.T) {
+	client := kubefake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authenticationv1.TokenReview).DeepCopy()
+		if review.Spec.Token == "valid-token" {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: "alice", Groups: []string{"system:authenticated"}}
+		}
+		return true, review, nil
+	})
+
+	authenticator := NewTokenReviewAuthenticator(client.AuthenticationV1().TokenReviews())
+
+	user, err := authenticator.AuthenticateToken("valid-token")
+	require.NoError(t, err)
+	assert.Equal(t, &authenticationv1.UserInfo{Username: "alice", Groups: []string{"system:authenticated"}}, user)
+
+	user, err = authenticator.AuthenticateToken("invalid-token")
+	require.NoError(t, err)
+	assert.Nil(t, user)
+}
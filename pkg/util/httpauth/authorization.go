@@ -0,0 +1,91 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpauth
+
+import (
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+// Attributes is what a request does, in terms of the Kubernetes API. If
+// Resource is empty, the request is for the non-resource URL in Path.
+type Attributes struct {
+	Verb      string
+	Group     string
+	Version   string
+	Resource  string
+	Namespace string
+	Name      string
+	Path      string
+}
+
+// Authorizer decides whether a user is allowed to make a request.
+type Authorizer interface {
+	Authorize(user *authenticationv1.UserInfo, attrs Attributes) (allowed bool, reason string, err error)
+}
+
+// NewSubjectAccessReviewAuthorizer returns an Authorizer that uses
+// SubjectAccessReviews, so that users need the same RBAC permissions as
+// they would through the Kubernetes API.
+func NewSubjectAccessReviewAuthorizer(client authorizationv1client.SubjectAccessReviewInterface) Authorizer {
+	return &subjectAccessReviewAuthorizer{client: client}
+}
+
+type subjectAccessReviewAuthorizer struct {
+	client authorizationv1client.SubjectAccessReviewInterface
+}
+
+func (a *subjectAccessReviewAuthorizer) Authorize(user *authenticationv1.UserInfo, attrs Attributes) (bool, string, error) {
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for key, values := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(values)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+		},
+	}
+
+	if attrs.Resource == "" {
+		review.Spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{
+			Path: attrs.Path,
+			Verb: attrs.Verb,
+		}
+	} else {
+		review.Spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace: attrs.Namespace,
+			Verb:      attrs.Verb,
+			Group:     attrs.Group,
+			Version:   attrs.Version,
+			Resource:  attrs.Resource,
+			Name:      attrs.Name,
+		}
+	}
+
+	res, err := a.client.Create(review)
+	if err != nil {
+		return false, "", errors.Wrap(err, "error creating SubjectAccessReview")
+	}
+
+	return res.Status.Allowed, res.Status.Reason, nil
+}
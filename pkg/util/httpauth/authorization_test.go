@@ -0,0 +1,73 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestSubjectAccessReviewAuthorizer(t *testing.T) {
+	client := kubefake.NewSimpleClientset()
+
+	var review *authorizationv1.SubjectAccessReview
+	client.PrependReactor("create", "subjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review = action.(core.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		res := review.DeepCopy()
+		res.Status.Allowed = true
+		return true, res, nil
+	})
+
+	authorizer := NewSubjectAccessReviewAuthorizer(client.AuthorizationV1().SubjectAccessReviews())
+
+	user := &authenticationv1.UserInfo{
+		Username: "alice",
+		UID:      "uid-1",
+		Groups:   []string{"admins"},
+		Extra:    map[string]authenticationv1.ExtraValue{"scopes": {"view"}},
+	}
+	allowed, _, err := authorizer.Authorize(user, Attributes{
+		Verb:      "create",
+		Group:     "velero.io",
+		Version:   "v1",
+		Resource:  "restores",
+		Namespace: "velero",
+	})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	require.NotNil(t, review)
+	assert.Equal(t, "alice", review.Spec.User)
+	assert.Equal(t, "uid-1", review.Spec.UID)
+	assert.Equal(t, []string{"admins"}, review.Spec.Groups)
+	assert.Equal(t, authorizationv1.ExtraValue{"view"}, review.Spec.Extra["scopes"])
+	assert.Equal(t, &authorizationv1.ResourceAttributes{
+		Namespace: "velero",
+		Verb:      "create",
+		Group:     "velero.io",
+		Version:   "v1",
+		Resource:  "restores",
+	}, review.Spec.ResourceAttributes)
+	assert.Nil(t, review.Spec.NonResourceAttributes)
+}
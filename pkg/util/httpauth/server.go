@@ -0,0 +1,133 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpauth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func hasMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// TLSConfig returns the TLS configuration for a server that authenticates
+// requests with the given methods. Methods that use client certificates
+// need the CA that signs them, in clientCAFile. Clients must present a
+// certificate unless requests can also be authenticated with tokens.
+func TLSConfig(methods []string, clientCAFile string) (*tls.Config, error) {
+	if !hasMethod(methods, MethodClientCert) && !hasMethod(methods, MethodRequestHeader) {
+		return &tls.Config{}, nil
+	}
+
+	if clientCAFile == "" {
+		return nil, errors.Errorf("a client CA file is required to authenticate requests with the %s or %s methods", MethodClientCert, MethodRequestHeader)
+	}
+
+	caBytes, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading client CA file %s", clientCAFile)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caBytes) {
+		return nil, errors.Errorf("no certificates found in client CA file %s", clientCAFile)
+	}
+
+	clientAuth := tls.RequireAndVerifyClientCert
+	if hasMethod(methods, MethodToken) {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return &tls.Config{
+		ClientAuth: clientAuth,
+		ClientCAs:  clientCAs,
+	}, nil
+}
+
+// WithAuth returns a handler that only passes a request on to handler if
+// it's made by an authenticated user who is allowed to do what attributes
+// returns for it.
+func WithAuth(handler http.Handler, authenticator Authenticator, authorizer Authorizer, attributes func(*http.Request) Attributes, logger logrus.FieldLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, err := authenticator.AuthenticateRequest(req)
+		if err != nil {
+			logger.WithError(err).WithField("path", req.URL.Path).Error("Error authenticating request")
+			http.Error(w, "error authenticating request", http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		attrs := attributes(req)
+		allowed, reason, err := authorizer.Authorize(user, attrs)
+		if err != nil {
+			logger.WithError(err).WithField("path", req.URL.Path).Error("Error authorizing request")
+			http.Error(w, "error authorizing request", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			msg := fmt.Sprintf("user %q is not allowed to %s %s", user.Username, attrs.Verb, describe(attrs))
+			if reason != "" {
+				msg += ": " + reason
+			}
+			http.Error(w, msg, http.StatusForbidden)
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// NonResource returns attributes for a request to a non-resource URL,
+// such as /metrics, using the request's path and the given verb.
+func NonResource(verb string) func(*http.Request) Attributes {
+	return func(req *http.Request) Attributes {
+		return Attributes{Verb: verb, Path: req.URL.Path}
+	}
+}
+
+func describe(attrs Attributes) string {
+	if attrs.Resource == "" {
+		return attrs.Path
+	}
+
+	resource := attrs.Resource
+	if attrs.Group != "" {
+		resource += "." + attrs.Group
+	}
+	if attrs.Name != "" {
+		resource += " " + attrs.Name
+	}
+	if attrs.Namespace != "" {
+		resource += " in namespace " + attrs.Namespace
+	}
+	return resource
+}
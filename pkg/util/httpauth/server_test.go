@@ -0,0 +1,139 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func writeCAFile(t *testing.T, dir string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "ca.crt")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return path
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpauth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	caFile := writeCAFile(t, dir)
+
+	// tokens don't need client certificates
+	config, err := TLSConfig([]string{MethodToken}, "")
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, config.ClientAuth)
+
+	config, err = TLSConfig([]string{MethodClientCert}, caFile)
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+	assert.NotNil(t, config.ClientCAs)
+
+	// clients that authenticate with tokens don't need to present a
+	// certificate
+	config, err = TLSConfig([]string{MethodRequestHeader, MethodToken}, caFile)
+	require.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, config.ClientAuth)
+
+	_, err = TLSConfig([]string{MethodClientCert}, "")
+	assert.EqualError(t, err, "a client CA file is required to authenticate requests with the client-cert or request-header methods")
+
+	emptyFile := filepath.Join(dir, "empty.crt")
+	require.NoError(t, ioutil.WriteFile(emptyFile, nil, 0600))
+	_, err = TLSConfig([]string{MethodClientCert}, emptyFile)
+	assert.EqualError(t, err, "no certificates found in client CA file "+emptyFile)
+}
+
+func TestWithAuth(t *testing.T) {
+	client := kubefake.NewSimpleClientset()
+
+	var review *authorizationv1.SubjectAccessReview
+	client.PrependReactor("create", "subjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review = action.(core.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		res := review.DeepCopy()
+		res.Status.Allowed = review.Spec.User == "alice"
+		return true, res, nil
+	})
+
+	handler := WithAuth(
+		http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("metrics")) }),
+		NewAuthenticator([]string{MethodToken, MethodClientCert}, fakeTokenAuthenticator{}),
+		NewSubjectAccessReviewAuthorizer(client.AuthorizationV1().SubjectAccessReviews()),
+		NonResource("get"),
+		velerotest.NewLogger(),
+	)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+	res = serve(req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "metrics", res.Body.String())
+
+	require.NotNil(t, review)
+	assert.Nil(t, review.Spec.ResourceAttributes)
+	assert.Equal(t, &authorizationv1.NonResourceAttributes{Path: "/metrics", Verb: "get"}, review.Spec.NonResourceAttributes)
+
+	res = serve(withClientCert(httptest.NewRequest(http.MethodGet, "/metrics", nil), "bob"))
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Contains(t, res.Body.String(), `user "bob" is not allowed to get /metrics`)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	arkDomain    = "ark.heptio.com"
+	veleroDomain = "velero.io"
+)
+
+// MigrateArkLabelsAndAnnotations renames the labels and annotations that
+// Heptio Ark, as Velero was called before v0.11, used to the keys that
+// Velero uses, e.g. ark.heptio.com/backup-name to velero.io/backup-name and
+// backup.ark.heptio.com/backup-volumes to backup.velero.io/backup-volumes.
+// Keys that are already set with Velero's name are left alone. It returns
+// whether any keys were renamed.
+func MigrateArkLabelsAndAnnotations(obj metav1.Object) bool {
+	labels, migratedLabels := migrateArkKeys(obj.GetLabels())
+	if migratedLabels {
+		obj.SetLabels(labels)
+	}
+
+	annotations, migratedAnnotations := migrateArkKeys(obj.GetAnnotations())
+	if migratedAnnotations {
+		obj.SetAnnotations(annotations)
+	}
+
+	return migratedLabels || migratedAnnotations
+}
+
+func migrateArkKeys(m map[string]string) (map[string]string, bool) {
+	var migrated bool
+	for key, val := range m {
+		newKey, ok := migrateArkKey(key)
+		if !ok {
+			continue
+		}
+
+		if _, exists := m[newKey]; !exists {
+			m[newKey] = val
+		}
+		delete(m, key)
+		migrated = true
+	}
+
+	return m, migrated
+}
+
+// migrateArkKey returns the Velero name of a label or annotation key whose
+// prefix is in Ark's domain.
+func migrateArkKey(key string) (string, bool) {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return "", false
+	}
+
+	prefix, name := key[:i], key[i+1:]
+	if prefix != arkDomain && !strings.HasSuffix(prefix, "."+arkDomain) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(prefix, arkDomain) + veleroDomain + "/" + name, true
+}
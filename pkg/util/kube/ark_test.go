@@ -0,0 +1,48 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/heptio/velero/pkg/builder"
+)
+
+func TestMigrateArkLabelsAndAnnotations(t *testing.T) {
+	pod := builder.ForPod("ns-1", "pod-1").
+		ObjectMeta(
+			builder.WithLabels("ark.heptio.com/backup-name", "backup-1", "app", "foo", "velero.io/restore-name", "restore-2", "ark.heptio.com/restore-name", "restore-1"),
+			builder.WithAnnotations("backup.ark.heptio.com/backup-volumes", "data", "snapshot.ark.heptio.com/data", "abc123", "notark.heptio.com/foo", "bar"),
+		).
+		Result()
+
+	assert.True(t, MigrateArkLabelsAndAnnotations(pod))
+	assert.Equal(t, map[string]string{
+		"velero.io/backup-name":  "backup-1",
+		"velero.io/restore-name": "restore-2",
+		"app":                    "foo",
+	}, pod.Labels)
+	assert.Equal(t, map[string]string{
+		"backup.velero.io/backup-volumes": "data",
+		"snapshot.velero.io/data":         "abc123",
+		"notark.heptio.com/foo":           "bar",
+	}, pod.Annotations)
+
+	assert.False(t, MigrateArkLabelsAndAnnotations(pod))
+}
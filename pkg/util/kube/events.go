@@ -0,0 +1,105 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// EventRecorder records Kubernetes events about objects, so that they're
+// shown by `kubectl describe` and can be watched by other tools.
+type EventRecorder interface {
+	// Event records an event about the object. eventType is
+	// corev1api.EventTypeNormal or corev1api.EventTypeWarning, and reason
+	// is a short, CamelCase reason for the event.
+	Event(obj runtime.Object, eventType, reason, message string)
+}
+
+type eventRecorder struct {
+	client    corev1client.EventsGetter
+	scheme    *runtime.Scheme
+	component string
+	log       logrus.FieldLogger
+}
+
+// NewEventRecorder returns an EventRecorder that creates events with the
+// given component as their source. The scheme is used to look up the kinds
+// of the objects that events are recorded about.
+func NewEventRecorder(client corev1client.EventsGetter, scheme *runtime.Scheme, component string, log logrus.FieldLogger) EventRecorder {
+	return &eventRecorder{
+		client:    client,
+		scheme:    scheme,
+		component: component,
+		log:       log,
+	}
+}
+
+// Event records the event, logging rather than returning any error, since
+// failing to record an event shouldn't fail what it's about.
+func (r *eventRecorder) Event(obj runtime.Object, eventType, reason, message string) {
+	if err := r.event(obj, eventType, reason, message); err != nil {
+		r.log.WithError(err).WithField("reason", reason).Warn("Error recording event")
+	}
+}
+
+func (r *eventRecorder) event(obj runtime.Object, eventType, reason, message string) error {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	gvks, _, err := r.scheme.ObjectKinds(obj)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	apiVersion, kind := gvks[0].ToAPIVersionAndKind()
+
+	now := metav1.Now()
+	event := &corev1api.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: objMeta.GetNamespace(),
+			// the same naming scheme as client-go's event recorder
+			Name: fmt.Sprintf("%s.%x", objMeta.GetName(), now.UnixNano()),
+		},
+		InvolvedObject: corev1api.ObjectReference{
+			APIVersion:      apiVersion,
+			Kind:            kind,
+			Namespace:       objMeta.GetNamespace(),
+			Name:            objMeta.GetName(),
+			UID:             objMeta.GetUID(),
+			ResourceVersion: objMeta.GetResourceVersion(),
+		},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         corev1api.EventSource{Component: r.component},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	_, err = r.client.Events(event.Namespace).Create(event)
+	return errors.Wrap(err, "error creating event")
+}
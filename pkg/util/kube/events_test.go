@@ -0,0 +1,60 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/scheme"
+	velerotest "github.com/heptio/velero/pkg/test"
+)
+
+func TestEventRecorder(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	recorder := NewEventRecorder(client.CoreV1(), scheme.Scheme, "velero", velerotest.NewLogger())
+
+	backup := builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").Result()
+	backup.UID = "uid-1"
+
+	recorder.Event(backup, corev1api.EventTypeWarning, "BackupFailed", "Backup failed: boom")
+
+	events, err := client.CoreV1().Events(velerov1api.DefaultNamespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+
+	event := events.Items[0]
+	assert.Equal(t, corev1api.ObjectReference{
+		APIVersion: "velero.io/v1",
+		Kind:       "Backup",
+		Namespace:  velerov1api.DefaultNamespace,
+		Name:       "backup-1",
+		UID:        "uid-1",
+	}, event.InvolvedObject)
+	assert.Equal(t, corev1api.EventTypeWarning, event.Type)
+	assert.Equal(t, "BackupFailed", event.Reason)
+	assert.Equal(t, "Backup failed: boom", event.Message)
+	assert.Equal(t, "velero", event.Source.Component)
+	assert.EqualValues(t, 1, event.Count)
+}
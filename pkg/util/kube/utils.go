@@ -37,12 +37,13 @@ func NamespaceAndName(objMeta metav1.Object) string {
 	return fmt.Sprintf("%s/%s", objMeta.GetNamespace(), objMeta.GetName())
 }
 
-// EnsureNamespaceExistsAndIsReady attempts to create the provided Kubernetes namespace. It returns two values:
-// a bool indicating whether or not the namespace is ready, and an error if the create failed
-// for a reason other than that the namespace already exists. Note that in the case where the
-// namespace already exists and is not ready, this function will return (false, nil).
+// EnsureNamespaceExistsAndIsReady attempts to create the provided Kubernetes namespace. It returns three values:
+// a bool indicating whether or not the namespace is ready, the namespace if this function created it, or nil
+// if it already existed, and an error if the create failed for a reason other than that the namespace already
+// exists. Note that in the case where the namespace already exists and is not ready, this function will return
+// (false, nil, nil).
 // If the namespace exists and is marked for deletion, this function will wait up to the timeout for it to fully delete.
-func EnsureNamespaceExistsAndIsReady(namespace *corev1api.Namespace, client corev1client.NamespaceInterface, timeout time.Duration) (bool, error) {
+func EnsureNamespaceExistsAndIsReady(namespace *corev1api.Namespace, client corev1client.NamespaceInterface, timeout time.Duration) (bool, *corev1api.Namespace, error) {
 	var ready bool
 	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
 		clusterNS, err := client.Get(namespace.Name, metav1.GetOptions{})
@@ -69,26 +70,27 @@ func EnsureNamespaceExistsAndIsReady(namespace *corev1api.Namespace, client core
 
 	// err will be set if we timed out or encountered issues retrieving the namespace,
 	if err != nil {
-		return false, errors.Wrapf(err, "error getting namespace %s", namespace.Name)
+		return false, nil, errors.Wrapf(err, "error getting namespace %s", namespace.Name)
 	}
 
 	// In the case the namespace already exists and isn't marked for deletion, assume it's ready for use.
 	if ready {
-		return true, nil
+		return true, nil, nil
 	}
 
 	clusterNS, err := client.Create(namespace)
 	if apierrors.IsAlreadyExists(err) {
 		if clusterNS != nil && (clusterNS.GetDeletionTimestamp() != nil || clusterNS.Status.Phase == corev1api.NamespaceTerminating) {
 			// Somehow created after all our polling and marked for deletion, return an error
-			return false, errors.Errorf("namespace %s created and marked for termination after timeout", namespace.Name)
+			return false, nil, errors.Errorf("namespace %s created and marked for termination after timeout", namespace.Name)
 		}
+		return true, nil, nil
 	} else if err != nil {
-		return false, errors.Wrapf(err, "error creating namespace %s", namespace.Name)
+		return false, nil, errors.Wrapf(err, "error creating namespace %s", namespace.Name)
 	}
 
 	// The namespace created successfully
-	return true, nil
+	return true, clusterNS, nil
 }
 
 // GetVolumeDirectory gets the name of the directory on the host, under /var/lib/kubelet/pods/<podUID>/volumes/,
@@ -135,3 +137,27 @@ func GetVolumeDirectory(pod *corev1api.Pod, volumeName string, pvcLister corev1l
 
 	return pvc.Spec.VolumeName, nil
 }
+
+// GetVolumeDeviceName gets the name of the block device on the host, under
+// /var/lib/kubelet/pods/<podUID>/volumeDevices/, of the specified block volume,
+// which is the name of its PV for PVC volumes.
+func GetVolumeDeviceName(pod *corev1api.Pod, volumeName string, pvcLister corev1listers.PersistentVolumeClaimLister) (string, error) {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name != volumeName {
+			continue
+		}
+
+		if volume.PersistentVolumeClaim == nil {
+			return volume.Name, nil
+		}
+
+		pvc, err := pvcLister.PersistentVolumeClaims(pod.Namespace).Get(volume.PersistentVolumeClaim.ClaimName)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		return pvc.Spec.VolumeName, nil
+	}
+
+	return "", errors.New("volume not found in pod")
+}
@@ -47,6 +47,7 @@ func TestEnsureNamespaceExistsAndIsReady(t *testing.T) {
 		expectCreate   bool
 		alreadyExists  bool
 		expectedResult bool
+		expectCreated  bool
 	}{
 		{
 			name:           "namespace found, not deleting",
@@ -69,6 +70,7 @@ func TestEnsureNamespaceExistsAndIsReady(t *testing.T) {
 			name:           "namespace not found, successfully created",
 			expectCreate:   true,
 			expectedResult: true,
+			expectCreated:  true,
 		},
 		{
 			name:           "namespace not found initially, create returns already exists error, returned namespace is ready",
@@ -118,9 +120,14 @@ func TestEnsureNamespaceExistsAndIsReady(t *testing.T) {
 				nsClient.On("Create", namespace).Return(namespace, nil)
 			}
 
-			result, _ := EnsureNamespaceExistsAndIsReady(namespace, nsClient, timeout)
+			result, created, _ := EnsureNamespaceExistsAndIsReady(namespace, nsClient, timeout)
 
 			assert.Equal(t, test.expectedResult, result)
+			if test.expectCreated {
+				assert.Equal(t, namespace, created)
+			} else {
+				assert.Nil(t, created)
+			}
 		})
 	}
 
@@ -197,3 +204,50 @@ func TestGetVolumeDirectorySuccess(t *testing.T) {
 		assert.Equal(t, tc.want, dir)
 	}
 }
+
+func TestGetVolumeDeviceName(t *testing.T) {
+	tests := []struct {
+		name    string
+		pod     *corev1.Pod
+		pvc     *corev1.PersistentVolumeClaim
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "volume with a PVC returns the PV's name",
+			pod:  builder.ForPod("ns-1", "my-pod").Volumes(builder.ForVolume("my-vol").PersistentVolumeClaimSource("my-pvc").Result()).Result(),
+			pvc:  builder.ForPersistentVolumeClaim("ns-1", "my-pvc").VolumeName("a-pv").Result(),
+			want: "a-pv",
+		},
+		{
+			name: "volume without a PVC returns the volume name",
+			pod:  builder.ForPod("ns-1", "my-pod").Volumes(builder.ForVolume("my-vol").Result()).Result(),
+			want: "my-vol",
+		},
+		{
+			name:    "volume with a missing PVC returns an error",
+			pod:     builder.ForPod("ns-1", "my-pod").Volumes(builder.ForVolume("my-vol").PersistentVolumeClaimSource("my-pvc").Result()).Result(),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHarness(t)
+
+			pvcInformer := kubeinformers.NewSharedInformerFactoryWithOptions(h.KubeClient, 0, kubeinformers.WithNamespace("ns-1")).Core().V1().PersistentVolumeClaims()
+			if tc.pvc != nil {
+				require.NoError(t, pvcInformer.Informer().GetStore().Add(tc.pvc))
+			}
+
+			name, err := GetVolumeDeviceName(tc.pod, "my-vol", pvcInformer.Lister())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, name)
+		})
+	}
+}
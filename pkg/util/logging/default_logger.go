@@ -51,3 +51,17 @@ func DefaultLogger(level logrus.Level, format Format) *logrus.Logger {
 
 	return logger
 }
+
+// LevelOrDefault returns the log level named by level, or defaultLevel if
+// level is empty or isn't a valid log level.
+func LevelOrDefault(level string, defaultLevel logrus.Level) logrus.Level {
+	if level == "" {
+		return defaultLevel
+	}
+
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return defaultLevel
+	}
+	return parsed
+}
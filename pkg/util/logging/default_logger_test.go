@@ -38,3 +38,10 @@ func TestDefaultLogger(t *testing.T) {
 		}
 	}
 }
+
+func TestLevelOrDefault(t *testing.T) {
+	assert.Equal(t, logrus.InfoLevel, LevelOrDefault("", logrus.InfoLevel))
+	assert.Equal(t, logrus.DebugLevel, LevelOrDefault("debug", logrus.InfoLevel))
+	assert.Equal(t, logrus.WarnLevel, LevelOrDefault("warning", logrus.InfoLevel))
+	assert.Equal(t, logrus.InfoLevel, LevelOrDefault("verbose", logrus.InfoLevel))
+}
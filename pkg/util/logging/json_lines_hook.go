@@ -0,0 +1,70 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// JSONLinesHook is a logrus hook that writes each log statement to a
+// writer as a single line of JSON, regardless of the logger's own
+// format. Each object has "time", "level" and "msg" keys, plus a key for
+// each of the statement's fields.
+type JSONLinesHook struct {
+	mu        sync.Mutex
+	w         io.Writer
+	formatter logrus.Formatter
+}
+
+// NewJSONLinesHook returns a JSONLinesHook that writes to w.
+func NewJSONLinesHook(w io.Writer) *JSONLinesHook {
+	return &JSONLinesHook{
+		w: w,
+		formatter: &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime:  "time",
+				logrus.FieldKeyLevel: "level",
+				logrus.FieldKeyMsg:   "msg",
+			},
+		},
+	}
+}
+
+// Levels returns the logrus levels that the hook should be fired for.
+func (h *JSONLinesHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire executes the hook's logic.
+func (h *JSONLinesHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err = h.w.Write(line)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLinesHook(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	logger := DefaultLogger(logrus.InfoLevel, FormatText)
+	logger.Out = ioutil.Discard
+	logger.Hooks.Add(NewJSONLinesHook(buf))
+
+	log := logger.WithField("backup", "velero/backup-1")
+	log.WithField("resource", "pods").Info("Backing up item")
+	log.WithError(errors.New("boom")).Error("Error backing up item")
+	log.Debug("not logged at info level")
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+
+	assert.Equal(t, "info", lines[0]["level"])
+	assert.Equal(t, "Backing up item", lines[0]["msg"])
+	assert.Equal(t, "velero/backup-1", lines[0]["backup"])
+	assert.Equal(t, "pods", lines[0]["resource"])
+	assert.NotEmpty(t, lines[0]["time"])
+
+	assert.Equal(t, "error", lines[1]["level"])
+	assert.Equal(t, "boom", lines[1]["error"])
+}
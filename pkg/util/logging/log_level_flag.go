@@ -54,6 +54,12 @@ func (f *LevelFlag) Parse() logrus.Level {
 	return f.defaultValue
 }
 
+// LogLevels returns the names of the valid log levels, sorted in ascending
+// order of severity.
+func LogLevels() []string {
+	return append([]string(nil), sortedLogLevels...)
+}
+
 // sortLogLevels returns a string slice containing all of the valid logrus
 // log levels (based on logrus.AllLevels), sorted in ascending order of severity.
 func sortLogLevels() []string {
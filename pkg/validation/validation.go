@@ -0,0 +1,665 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation validates the specs of Backups, Restores and Schedules
+// the same way the Velero server does. The server's controllers use it to
+// set the validation errors of the objects they process, and tools outside
+// of Velero, such as CI pipelines and operators, can use it to validate
+// manifests before they're applied to a cluster.
+//
+// Each function returns the validation errors as human-readable messages,
+// in the same format as an object's status.validationErrors. A spec is
+// valid if no messages are returned.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/persistence"
+	"github.com/heptio/velero/pkg/util/boolptr"
+	"github.com/heptio/velero/pkg/util/collections"
+	"github.com/heptio/velero/pkg/util/logging"
+)
+
+// NonRestorableResources is a blacklist for the restoration process. Any resources
+// included here are explicitly excluded from the restoration process.
+var NonRestorableResources = []string{
+	"nodes",
+	"events",
+	"events.events.k8s.io",
+
+	// Don't ever restore backups - if appropriate, they'll be synced in from object storage.
+	// https://github.com/heptio/velero/issues/622
+	"backups.velero.io",
+
+	// Restores are cluster-specific, and don't have value moving across clusters.
+	// https://github.com/heptio/velero/issues/622
+	"restores.velero.io",
+
+	// Restic repositories are automatically managed by Velero and will be automatically
+	// created as needed if they don't exist.
+	// https://github.com/heptio/velero/issues/1113
+	"resticrepositories.velero.io",
+
+	// Backups taken by Heptio Ark, as Velero was called before v0.11, have
+	// Ark's versions of the resources above.
+	"backups.ark.heptio.com",
+	"restores.ark.heptio.com",
+	"resticrepositories.ark.heptio.com",
+}
+
+// ValidateBackupSpec validates the parts of a backup spec that don't depend
+// on any other objects: the resource and namespace filters, the backup mode,
+// the pod volume failure policy, the lists of replica and failover storage
+// locations and the retry policy.
+func ValidateBackupSpec(spec *velerov1api.BackupSpec) []string {
+	var errs []string
+
+	// validate the included/excluded resources
+	for _, err := range collections.ValidateIncludesExcludes(spec.IncludedResources, spec.ExcludedResources) {
+		errs = append(errs, fmt.Sprintf("Invalid included/excluded resource lists: %v", err))
+	}
+
+	// validate the included/excluded namespaces
+	for _, err := range collections.ValidateIncludesExcludes(spec.IncludedNamespaces, spec.ExcludedNamespaces) {
+		errs = append(errs, fmt.Sprintf("Invalid included/excluded namespace lists: %v", err))
+	}
+
+	errs = append(errs, validateClusterResourceNames(spec.IncludedClusterResourceNames, spec.ExcludedClusterResourceNames, spec.IncludeClusterResources)...)
+
+	// validate the label selectors
+	if spec.LabelSelector != nil && len(spec.OrLabelSelectors) > 0 {
+		errs = append(errs, "labelSelector and orLabelSelectors can't both be set")
+	}
+	for i := range spec.OrLabelSelectors {
+		if _, err := metav1.LabelSelectorAsSelector(&spec.OrLabelSelectors[i]); err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid label selector %d in orLabelSelectors: %v", i, err))
+		}
+	}
+
+	if spec.SnapshotMoveData && boolptr.IsSetToFalse(spec.SnapshotVolumes) {
+		errs = append(errs, "snapshotMoveData can't be set when snapshotVolumes is false")
+	}
+
+	// validate the backup mode
+	switch spec.Mode {
+	case "", velerov1api.BackupModeFull:
+	case velerov1api.BackupModeObjectsOnly:
+		if boolptr.IsSetToTrue(spec.SnapshotVolumes) {
+			errs = append(errs, fmt.Sprintf("snapshotVolumes can't be true for a backup in %s mode", velerov1api.BackupModeObjectsOnly))
+		}
+		if spec.SnapshotMoveData {
+			errs = append(errs, fmt.Sprintf("snapshotMoveData can't be set for a backup in %s mode", velerov1api.BackupModeObjectsOnly))
+		}
+	case velerov1api.BackupModeVolumeSnapshotOnly:
+		if boolptr.IsSetToFalse(spec.SnapshotVolumes) {
+			errs = append(errs, fmt.Sprintf("snapshotVolumes can't be false for a backup in %s mode", velerov1api.BackupModeVolumeSnapshotOnly))
+		}
+		if boolptr.IsSetToFalse(spec.IncludeClusterResources) {
+			errs = append(errs, fmt.Sprintf("includeClusterResources can't be false for a backup in %s mode", velerov1api.BackupModeVolumeSnapshotOnly))
+		}
+		if len(spec.IncludedResources) > 0 || len(spec.ExcludedResources) > 0 {
+			errs = append(errs, fmt.Sprintf("includedResources and excludedResources can't be set for a backup in %s mode", velerov1api.BackupModeVolumeSnapshotOnly))
+		}
+		if spec.SkipUnchangedItems {
+			errs = append(errs, fmt.Sprintf("skipUnchangedItems can't be set for a backup in %s mode", velerov1api.BackupModeVolumeSnapshotOnly))
+		}
+		if spec.SnapshotMoveData {
+			errs = append(errs, fmt.Sprintf("snapshotMoveData can't be set for a backup in %s mode", velerov1api.BackupModeVolumeSnapshotOnly))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("Invalid backup mode %q, must be one of %s, %s or %s", spec.Mode, velerov1api.BackupModeFull, velerov1api.BackupModeObjectsOnly, velerov1api.BackupModeVolumeSnapshotOnly))
+	}
+
+	// validate the pod volume failure policy
+	switch spec.PodVolumeFailurePolicy {
+	case "", velerov1api.PodVolumeFailurePolicyPartiallyFail, velerov1api.PodVolumeFailurePolicyFail:
+	default:
+		errs = append(errs, fmt.Sprintf("Invalid pod volume failure policy %q, must be one of %s or %s", spec.PodVolumeFailurePolicy, velerov1api.PodVolumeFailurePolicyPartiallyFail, velerov1api.PodVolumeFailurePolicyFail))
+	}
+
+	// validate the hooks
+	for i := range spec.Hooks.Resources {
+		errs = append(errs, validateBackupResourceHookSpec(&spec.Hooks.Resources[i])...)
+	}
+
+	// validate the replica storage locations
+	replicaLocations := sets.NewString()
+	for _, locationName := range spec.ReplicaStorageLocations {
+		switch {
+		case locationName == spec.StorageLocation:
+			errs = append(errs, fmt.Sprintf("replica storage location %s must be different from the backup's storage location", locationName))
+		case replicaLocations.Has(locationName):
+			errs = append(errs, fmt.Sprintf("replica storage location %s is specified more than once", locationName))
+		}
+		replicaLocations.Insert(locationName)
+	}
+
+	// validate the failover storage locations
+	failoverLocations := sets.NewString()
+	for _, locationName := range spec.FailoverStorageLocations {
+		switch {
+		case locationName == spec.StorageLocation:
+			errs = append(errs, fmt.Sprintf("failover storage location %s must be different from the backup's storage location", locationName))
+		case replicaLocations.Has(locationName):
+			errs = append(errs, fmt.Sprintf("failover storage location %s can't also be a replica storage location", locationName))
+		case failoverLocations.Has(locationName):
+			errs = append(errs, fmt.Sprintf("failover storage location %s is specified more than once", locationName))
+		}
+		failoverLocations.Insert(locationName)
+	}
+
+	// validate the storage prefix
+	if spec.StoragePrefix != "" {
+		if err := persistence.ValidateStoragePrefix(spec.StoragePrefix); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	errs = append(errs, validateLogLevel(spec.LogLevel)...)
+
+	// validate the retry policy
+	if policy := spec.RetryPolicy; policy != nil {
+		if policy.MaxAttempts < 1 {
+			errs = append(errs, "Invalid retry policy: maxAttempts must be at least 1")
+		}
+		if policy.Backoff.Duration < 0 {
+			errs = append(errs, "Invalid retry policy: backoff must not be negative")
+		}
+	}
+
+	return errs
+}
+
+// validateLogLevel validates the log level of a backup or restore.
+func validateLogLevel(level string) []string {
+	if level == "" {
+		return nil
+	}
+	if _, err := logrus.ParseLevel(level); err != nil {
+		return []string{fmt.Sprintf("Invalid log level %q, must be one of %s", level, strings.Join(logging.LogLevels(), ", "))}
+	}
+	return nil
+}
+
+// validateBackupResourceHookSpec validates the commands and pod selection of
+// a backup hook spec's exec hooks.
+func validateBackupResourceHookSpec(spec *velerov1api.BackupResourceHookSpec) []string {
+	var errs []string
+
+	phases := []struct {
+		name  string
+		hooks []velerov1api.BackupResourceHook
+	}{
+		{"pre", spec.PreHooks},
+		{"post", spec.PostHooks},
+	}
+
+	for _, phase := range phases {
+		for i, hook := range phase.hooks {
+			if hook.Exec == nil {
+				continue
+			}
+
+			switch hook.Exec.PodSelection {
+			case "", velerov1api.HookPodSelectionFirst, velerov1api.HookPodSelectionAll:
+			default:
+				errs = append(errs, fmt.Sprintf("Invalid backup hook %q: %s hook %d has invalid podSelection %q, must be one of %s or %s", spec.Name, phase.name, i, hook.Exec.PodSelection, velerov1api.HookPodSelectionFirst, velerov1api.HookPodSelectionAll))
+			}
+
+			for j, command := range hook.Exec.Commands {
+				if len(command.Command) == 0 {
+					errs = append(errs, fmt.Sprintf("Invalid backup hook %q: %s hook %d command %d must have a command", spec.Name, phase.name, i, j))
+				}
+				switch command.OnError {
+				case "", velerov1api.HookErrorModeContinue, velerov1api.HookErrorModeFail:
+				default:
+					errs = append(errs, fmt.Sprintf("Invalid backup hook %q: %s hook %d command %d has invalid onError %q, must be one of %s or %s", spec.Name, phase.name, i, j, command.OnError, velerov1api.HookErrorModeContinue, velerov1api.HookErrorModeFail))
+				}
+				if command.Timeout.Duration < 0 {
+					errs = append(errs, fmt.Sprintf("Invalid backup hook %q: %s hook %d command %d timeout must not be negative", spec.Name, phase.name, i, j))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateClusterResourceNames validates the lists of included and excluded
+// cluster-scoped items of a backup or restore, which can't be set when
+// cluster-scoped resources are excluded.
+func validateClusterResourceNames(includes, excludes []string, includeClusterResources *bool) []string {
+	var errs []string
+
+	for _, err := range collections.ValidateResourceNameIncludesExcludes(includes, excludes) {
+		errs = append(errs, fmt.Sprintf("Invalid included/excluded cluster resource name lists: %v", err))
+	}
+
+	if boolptr.IsSetToFalse(includeClusterResources) && (len(includes) > 0 || len(excludes) > 0) {
+		errs = append(errs, "includedClusterResourceNames and excludedClusterResourceNames can't be set when includeClusterResources is false")
+	}
+
+	return errs
+}
+
+// ValidateBackupLocations validates that the backup storage location and
+// the replica and failover storage locations of a backup in the given
+// namespace exist.
+// If the spec doesn't name a storage location, the server's default
+// location is used, so it isn't validated.
+func ValidateBackupLocations(namespace string, spec *velerov1api.BackupSpec, lister listers.BackupStorageLocationLister) []string {
+	var errs []string
+
+	if spec.StorageLocation != "" {
+		if _, err := lister.BackupStorageLocations(namespace).Get(spec.StorageLocation); err != nil {
+			if apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Sprintf("a BackupStorageLocation CRD with the name specified in the backup spec needs to be created before this backup can be executed. Error: %v", err))
+			} else {
+				errs = append(errs, fmt.Sprintf("error getting backup storage location: %v", err))
+			}
+		}
+	}
+
+	errs = append(errs, ValidateReplicaStorageLocations(namespace, spec, lister)...)
+	return append(errs, ValidateFailoverStorageLocations(namespace, spec, lister)...)
+}
+
+// ValidateReplicaStorageLocations validates that the replica storage locations
+// of a backup in the given namespace exist. Replica locations that aren't
+// valid according to ValidateBackupSpec are skipped.
+func ValidateReplicaStorageLocations(namespace string, spec *velerov1api.BackupSpec, lister listers.BackupStorageLocationLister) []string {
+	var errs []string
+
+	replicaLocations := sets.NewString()
+	for _, locationName := range spec.ReplicaStorageLocations {
+		if locationName == spec.StorageLocation || replicaLocations.Has(locationName) {
+			continue
+		}
+		replicaLocations.Insert(locationName)
+
+		if _, err := lister.BackupStorageLocations(namespace).Get(locationName); err != nil {
+			errs = append(errs, fmt.Sprintf("error getting replica storage location %s: %v", locationName, err))
+		}
+	}
+
+	return errs
+}
+
+// ValidateFailoverStorageLocations validates that the failover storage
+// locations of a backup in the given namespace exist. Failover locations
+// that aren't valid according to ValidateBackupSpec are skipped.
+func ValidateFailoverStorageLocations(namespace string, spec *velerov1api.BackupSpec, lister listers.BackupStorageLocationLister) []string {
+	var errs []string
+
+	failoverLocations := sets.NewString()
+	for _, locationName := range spec.FailoverStorageLocations {
+		if locationName == spec.StorageLocation || failoverLocations.Has(locationName) {
+			continue
+		}
+		failoverLocations.Insert(locationName)
+
+		if _, err := lister.BackupStorageLocations(namespace).Get(locationName); err != nil {
+			errs = append(errs, fmt.Sprintf("error getting failover storage location %s: %v", locationName, err))
+		}
+	}
+
+	return errs
+}
+
+// ValidateRestoreSpec validates the parts of a restore spec that don't depend
+// on any other objects: the resource and namespace filters, the restore mode,
+// the control-plane configuration policy, the resource modifiers, the
+// credential and the restore's source. Resources in NonRestorableResources
+// can't be included, and are always excluded.
+func ValidateRestoreSpec(spec *velerov1api.RestoreSpec) []string {
+	var errs []string
+
+	// validate that included resources don't contain any non-restorable resources
+	includedResources := sets.NewString(spec.IncludedResources...)
+	for _, nonRestorableResource := range NonRestorableResources {
+		if includedResources.Has(nonRestorableResource) {
+			errs = append(errs, fmt.Sprintf("%v are non-restorable resources", nonRestorableResource))
+		}
+	}
+
+	// validate included/excluded resources
+	excludedResources := sets.NewString(spec.ExcludedResources...)
+	excludedResources.Insert(NonRestorableResources...)
+	for _, err := range collections.ValidateIncludesExcludes(spec.IncludedResources, excludedResources.List()) {
+		errs = append(errs, fmt.Sprintf("Invalid included/excluded resource lists: %v", err))
+	}
+
+	// validate included/excluded namespaces
+	for _, err := range collections.ValidateIncludesExcludes(spec.IncludedNamespaces, spec.ExcludedNamespaces) {
+		errs = append(errs, fmt.Sprintf("Invalid included/excluded namespace lists: %v", err))
+	}
+
+	errs = append(errs, validateClusterResourceNames(spec.IncludedClusterResourceNames, spec.ExcludedClusterResourceNames, spec.IncludeClusterResources)...)
+
+	// validate the restore mode
+	switch spec.Mode {
+	case "", velerov1api.RestoreModeFull, velerov1api.RestoreModeDataOnly:
+	default:
+		errs = append(errs, fmt.Sprintf("Invalid restore mode %q, must be one of %s or %s", spec.Mode, velerov1api.RestoreModeFull, velerov1api.RestoreModeDataOnly))
+	}
+
+	// validate the control-plane configuration policy
+	switch spec.ControlPlaneConfigPolicy {
+	case "", velerov1api.ControlPlaneConfigPolicySkip, velerov1api.ControlPlaneConfigPolicyRestore:
+	default:
+		errs = append(errs, fmt.Sprintf("Invalid control-plane configuration policy %q, must be one of %s or %s", spec.ControlPlaneConfigPolicy, velerov1api.ControlPlaneConfigPolicySkip, velerov1api.ControlPlaneConfigPolicyRestore))
+	}
+
+	// validate the extraction policy
+	switch spec.ExtractionPolicy {
+	case "", velerov1api.RestoreExtractionPolicyFull, velerov1api.RestoreExtractionPolicySelective:
+	default:
+		errs = append(errs, fmt.Sprintf("Invalid extraction policy %q, must be one of %s or %s", spec.ExtractionPolicy, velerov1api.RestoreExtractionPolicyFull, velerov1api.RestoreExtractionPolicySelective))
+	}
+
+	// validate the storage class mapping
+	for from, to := range spec.StorageClassMapping {
+		if from == "" || to == "" {
+			errs = append(errs, fmt.Sprintf("Invalid storage class mapping %q to %q: storage class names must not be empty", from, to))
+		}
+	}
+
+	// a dry run doesn't restore volume data, so there's nothing to do in
+	// data-only mode
+	if spec.DryRun && spec.Mode == velerov1api.RestoreModeDataOnly {
+		errs = append(errs, fmt.Sprintf("A dry run can't be used with the %s restore mode", velerov1api.RestoreModeDataOnly))
+	}
+
+	// validate the resource priorities
+	for _, resource := range spec.ResourcePriorities {
+		if resource == "" || resource == "*" {
+			errs = append(errs, fmt.Sprintf("Invalid resource priority %q: must be a resource name", resource))
+		}
+	}
+
+	// validate the ready timeout
+	if spec.ReadyTimeout.Duration < 0 {
+		errs = append(errs, fmt.Sprintf("Invalid ready timeout %s, must not be negative", spec.ReadyTimeout.Duration))
+	}
+
+	// validate the resource modifiers
+	for i := range spec.ResourceModifiers {
+		errs = append(errs, validateResourceModifier(i, &spec.ResourceModifiers[i])...)
+	}
+
+	// validate the hooks
+	for i := range spec.Hooks.Resources {
+		errs = append(errs, validateRestoreResourceHookSpec(&spec.Hooks.Resources[i])...)
+	}
+
+	// validate that exactly one of BackupName and ScheduleName have been specified
+	if (spec.BackupName == "") == (spec.ScheduleName == "") {
+		errs = append(errs, "Either a backup or schedule must be specified as a source for the restore, but not both")
+	}
+
+	// a credential is a key in a secret
+	if spec.Credential != nil && (spec.Credential.Name == "" || spec.Credential.Key == "") {
+		errs = append(errs, "A credential must have a secret name and key")
+	}
+
+	// a backup version identifies a version of a single backup
+	if spec.BackupVersion != "" && spec.BackupName == "" {
+		errs = append(errs, "A backup version can only be specified along with a backup name")
+	}
+
+	errs = append(errs, validateLogLevel(spec.LogLevel)...)
+
+	return errs
+}
+
+// validateResourceModifier validates the i'th resource modifier rule of a
+// restore spec, and the JSON patch operations in it.
+func validateResourceModifier(i int, modifier *velerov1api.RestoreResourceModifier) []string {
+	var errs []string
+
+	if modifier.GroupResource == "" {
+		errs = append(errs, fmt.Sprintf("Invalid resource modifier %d: groupResource must be specified", i))
+	}
+
+	if modifier.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(modifier.LabelSelector); err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid resource modifier %d: invalid label selector: %v", i, err))
+		}
+	}
+
+	if len(modifier.Patches) == 0 {
+		errs = append(errs, fmt.Sprintf("Invalid resource modifier %d: at least one patch must be specified", i))
+	}
+
+	for j, patch := range modifier.Patches {
+		if !strings.HasPrefix(patch.Path, "/") {
+			errs = append(errs, fmt.Sprintf("Invalid resource modifier %d: patch %d has invalid path %q, must start with /", i, j, patch.Path))
+		}
+
+		switch patch.Operation {
+		case "add", "replace", "test":
+			if patch.Value == nil {
+				errs = append(errs, fmt.Sprintf("Invalid resource modifier %d: patch %d must have a value for a %s operation", i, j, patch.Operation))
+			}
+		case "move", "copy":
+			if !strings.HasPrefix(patch.From, "/") {
+				errs = append(errs, fmt.Sprintf("Invalid resource modifier %d: patch %d has invalid from %q, must start with /", i, j, patch.From))
+			}
+		case "remove":
+		default:
+			errs = append(errs, fmt.Sprintf("Invalid resource modifier %d: patch %d has invalid operation %q, must be one of add, remove, replace, move, copy or test", i, j, patch.Operation))
+		}
+	}
+
+	return errs
+}
+
+// validateRestoreResourceHookSpec validates a restore hook spec of a
+// restore spec, and the hooks in it.
+func validateRestoreResourceHookSpec(spec *velerov1api.RestoreResourceHookSpec) []string {
+	var errs []string
+
+	if spec.Name == "" {
+		errs = append(errs, "Invalid restore hook: name must be specified")
+	}
+
+	for _, err := range collections.ValidateIncludesExcludes(spec.IncludedNamespaces, spec.ExcludedNamespaces) {
+		errs = append(errs, fmt.Sprintf("Invalid restore hook %q: invalid included/excluded namespace lists: %v", spec.Name, err))
+	}
+
+	for _, err := range collections.ValidateIncludesExcludes(spec.IncludedResources, spec.ExcludedResources) {
+		errs = append(errs, fmt.Sprintf("Invalid restore hook %q: invalid included/excluded resource lists: %v", spec.Name, err))
+	}
+
+	if spec.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(spec.LabelSelector); err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid restore hook %q: invalid label selector: %v", spec.Name, err))
+		}
+	}
+
+	for i, hook := range spec.PostHooks {
+		switch {
+		case (hook.Exec == nil) == (hook.Init == nil):
+			errs = append(errs, fmt.Sprintf("Invalid restore hook %q: post hook %d must have exactly one of exec or init", spec.Name, i))
+		case hook.Exec != nil:
+			if len(hook.Exec.Command) == 0 {
+				errs = append(errs, fmt.Sprintf("Invalid restore hook %q: post hook %d must have a command", spec.Name, i))
+			}
+			switch hook.Exec.OnError {
+			case "", velerov1api.HookErrorModeContinue, velerov1api.HookErrorModeFail:
+			default:
+				errs = append(errs, fmt.Sprintf("Invalid restore hook %q: post hook %d has invalid onError %q, must be one of %s or %s", spec.Name, i, hook.Exec.OnError, velerov1api.HookErrorModeContinue, velerov1api.HookErrorModeFail))
+			}
+			if hook.Exec.ExecTimeout.Duration < 0 || hook.Exec.WaitTimeout.Duration < 0 {
+				errs = append(errs, fmt.Sprintf("Invalid restore hook %q: post hook %d timeouts must not be negative", spec.Name, i))
+			}
+		case hook.Init != nil:
+			if len(hook.Init.InitContainers) == 0 {
+				errs = append(errs, fmt.Sprintf("Invalid restore hook %q: post hook %d must have at least one init container", spec.Name, i))
+			}
+			for _, container := range hook.Init.InitContainers {
+				if container.Name == "" || container.Image == "" {
+					errs = append(errs, fmt.Sprintf("Invalid restore hook %q: post hook %d init containers must have a name and image", spec.Name, i))
+					break
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidateScheduleSpec validates a schedule's cron expressions and time
+// zone, its overlap policy, its retention policy and the spec of the
+// backups it creates.
+func ValidateScheduleSpec(spec *velerov1api.ScheduleSpec) []string {
+	_, errs := ParseScheduleSpec(spec)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	switch spec.OverlapPolicy {
+	case "", velerov1api.ScheduleOverlapPolicyQueue, velerov1api.ScheduleOverlapPolicySkip, velerov1api.ScheduleOverlapPolicyAllow:
+	default:
+		errs = append(errs, fmt.Sprintf("Invalid overlap policy %q, must be one of %s, %s or %s", spec.OverlapPolicy, velerov1api.ScheduleOverlapPolicyQueue, velerov1api.ScheduleOverlapPolicySkip, velerov1api.ScheduleOverlapPolicyAllow))
+	}
+
+	if r := spec.Retention; r != nil {
+		switch {
+		case r.KeepHourly < 0 || r.KeepDaily < 0 || r.KeepWeekly < 0 || r.KeepMonthly < 0 || r.KeepYearly < 0:
+			errs = append(errs, "Invalid retention policy: the numbers of backups to keep must not be negative")
+		case r.KeepHourly+r.KeepDaily+r.KeepWeekly+r.KeepMonthly+r.KeepYearly == 0:
+			errs = append(errs, "Invalid retention policy: it must keep at least one backup")
+		}
+	}
+
+	if v := spec.Verification; v != nil {
+		switch v.Mode {
+		case "", velerov1api.ScheduleVerificationModeDryRun, velerov1api.ScheduleVerificationModeScratchNamespace:
+		default:
+			errs = append(errs, fmt.Sprintf("Invalid verification mode %q, must be one of %s or %s", v.Mode, velerov1api.ScheduleVerificationModeDryRun, velerov1api.ScheduleVerificationModeScratchNamespace))
+		}
+		if v.SampleNamespaces < 0 {
+			errs = append(errs, "Invalid verification policy: the number of namespaces to sample must not be negative")
+		}
+		if spec.Template.Mode == velerov1api.BackupModeVolumeSnapshotOnly {
+			errs = append(errs, fmt.Sprintf("Backups in %s mode can't be restored, so they can't be verified", velerov1api.BackupModeVolumeSnapshotOnly))
+		}
+	}
+
+	return append(errs, ValidateBackupSpec(&spec.Template)...)
+}
+
+// ParseScheduleSpec parses all of a schedule's cron expressions, returning
+// a cron.Schedule whose next run time is the earliest of theirs, evaluated
+// in the schedule's time zone.
+func ParseScheduleSpec(spec *velerov1api.ScheduleSpec) (cron.Schedule, []string) {
+	schedule, errs := ParseCronSchedule(spec.Schedule)
+	res := &multiSchedule{
+		schedules: []cron.Schedule{schedule},
+		location:  time.UTC,
+	}
+
+	for i, expression := range spec.Schedules {
+		schedule, scheduleErrs := ParseCronSchedule(expression)
+		for _, err := range scheduleErrs {
+			errs = append(errs, fmt.Sprintf("schedules[%d]: %s", i, err))
+		}
+		res.schedules = append(res.schedules, schedule)
+	}
+
+	if spec.TimeZone != "" {
+		location, err := time.LoadLocation(spec.TimeZone)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid time zone %q: %v", spec.TimeZone, err))
+		}
+		res.location = location
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return res, nil
+}
+
+// multiSchedule is a cron.Schedule that's due whenever any of its schedules
+// is, evaluated in location.
+type multiSchedule struct {
+	schedules []cron.Schedule
+	location  *time.Location
+}
+
+// Next returns the earliest next activation time of the schedules, or the
+// zero time if none of them activates again.
+func (s *multiSchedule) Next(t time.Time) time.Time {
+	var next time.Time
+	for _, schedule := range s.schedules {
+		// the cron library evaluates a schedule in the location of the time
+		// it's given, and returns a time in that location.
+		n := schedule.Next(t.In(s.location))
+		if !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+	return next
+}
+
+// ParseCronSchedule parses a schedule's cron expression, which uses the
+// standard format where the first field is minutes, not seconds.
+func ParseCronSchedule(schedule string) (cron.Schedule, []string) {
+	// cron.Parse panics if schedule is empty
+	if len(schedule) == 0 {
+		return nil, []string{"Schedule must be a non-empty valid Cron expression"}
+	}
+
+	var (
+		res  cron.Schedule
+		errs []string
+	)
+
+	// adding a recover() around cron.Parse because it panics on empty string and is possible
+	// that it panics under other scenarios as well.
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errs = append(errs, fmt.Sprintf("invalid schedule: %v", r))
+			}
+		}()
+
+		var err error
+		if res, err = cron.ParseStandard(schedule); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid schedule: %v", err))
+		}
+	}()
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return res, nil
+}
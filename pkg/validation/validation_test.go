@@ -0,0 +1,529 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+)
+
+func TestValidateBackupSpec(t *testing.T) {
+	tests := []struct {
+		name   string
+		backup *velerov1api.Backup
+		want   []string
+	}{
+		{
+			name:   "empty spec is valid",
+			backup: builder.ForBackup("velero", "backup-1").Result(),
+		},
+		{
+			name:   "valid log level",
+			backup: builder.ForBackup("velero", "backup-1").LogLevel("debug").Result(),
+		},
+		{
+			name:   "invalid log level",
+			backup: builder.ForBackup("velero", "backup-1").LogLevel("verbose").Result(),
+			want:   []string{`Invalid log level "verbose", must be one of debug, info, warning, error, fatal, panic`},
+		},
+		{
+			name:   "valid retry policy",
+			backup: builder.ForBackup("velero", "backup-1").RetryPolicy(3, time.Minute).Result(),
+		},
+		{
+			name:   "retry policy with no attempts is invalid",
+			backup: builder.ForBackup("velero", "backup-1").RetryPolicy(0, time.Minute).Result(),
+			want:   []string{"Invalid retry policy: maxAttempts must be at least 1"},
+		},
+		{
+			name:   "retry policy with a negative backoff is invalid",
+			backup: builder.ForBackup("velero", "backup-1").RetryPolicy(3, -time.Minute).Result(),
+			want:   []string{"Invalid retry policy: backoff must not be negative"},
+		},
+		{
+			name:   "resource in both includes and excludes is invalid",
+			backup: builder.ForBackup("velero", "backup-1").IncludedResources("foo").ExcludedResources("foo").Result(),
+			want:   []string{"Invalid included/excluded resource lists: excludes list cannot contain an item in the includes list: foo"},
+		},
+		{
+			name:   "excluding all namespaces is invalid",
+			backup: builder.ForBackup("velero", "backup-1").ExcludedNamespaces("*").Result(),
+			want:   []string{"Invalid included/excluded namespace lists: excludes list cannot contain '*'"},
+		},
+		{
+			name: "label selector and OR-able label selectors can't both be set",
+			backup: builder.ForBackup("velero", "backup-1").
+				LabelSelector(&metav1.LabelSelector{MatchLabels: map[string]string{"a": "b"}}).
+				OrLabelSelectors(metav1.LabelSelector{MatchLabels: map[string]string{"c": "d"}}).
+				Result(),
+			want: []string{"labelSelector and orLabelSelectors can't both be set"},
+		},
+		{
+			name: "invalid OR-able label selector is invalid",
+			backup: builder.ForBackup("velero", "backup-1").
+				OrLabelSelectors(
+					metav1.LabelSelector{MatchLabels: map[string]string{"c": "d"}},
+					metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "a", Operator: "bad"}}},
+				).
+				Result(),
+			want: []string{`Invalid label selector 1 in orLabelSelectors: "bad" is not a valid pod selector operator`},
+		},
+		{
+			name:   "cluster-scoped items must be given as resource/name",
+			backup: builder.ForBackup("velero", "backup-1").IncludedClusterResourceNames("clusterroles/admin", "storageclasses").Result(),
+			want:   []string{`Invalid included/excluded cluster resource name lists: "storageclasses" must be given as <resource>/<name>`},
+		},
+		{
+			name:   "cluster-scoped items can't be named when cluster-scoped resources are excluded",
+			backup: builder.ForBackup("velero", "backup-1").IncludeClusterResources(false).ExcludedClusterResourceNames("clusterroles/admin").Result(),
+			want:   []string{"includedClusterResourceNames and excludedClusterResourceNames can't be set when includeClusterResources is false"},
+		},
+		{
+			name:   "snapshotting volumes in ObjectsOnly mode is invalid",
+			backup: builder.ForBackup("velero", "backup-1").Mode(velerov1api.BackupModeObjectsOnly).SnapshotVolumes(true).Result(),
+			want:   []string{"snapshotVolumes can't be true for a backup in ObjectsOnly mode"},
+		},
+		{
+			name:   "moving snapshot data in ObjectsOnly mode is invalid",
+			backup: builder.ForBackup("velero", "backup-1").Mode(velerov1api.BackupModeObjectsOnly).SnapshotMoveData(true).Result(),
+			want:   []string{"snapshotMoveData can't be set for a backup in ObjectsOnly mode"},
+		},
+		{
+			name:   "moving snapshot data without snapshots is invalid",
+			backup: builder.ForBackup("velero", "backup-1").SnapshotVolumes(false).SnapshotMoveData(true).Result(),
+			want:   []string{"snapshotMoveData can't be set when snapshotVolumes is false"},
+		},
+		{
+			name:   "unknown mode is invalid",
+			backup: builder.ForBackup("velero", "backup-1").Mode("Partial").Result(),
+			want:   []string{`Invalid backup mode "Partial", must be one of Full, ObjectsOnly or VolumeSnapshotOnly`},
+		},
+		{
+			name:   "unknown pod volume failure policy is invalid",
+			backup: builder.ForBackup("velero", "backup-1").PodVolumeFailurePolicy("Ignore").Result(),
+			want:   []string{`Invalid pod volume failure policy "Ignore", must be one of PartiallyFail or Fail`},
+		},
+		{
+			name: "backup hooks must have a valid pod selection and commands",
+			backup: builder.ForBackup("velero", "backup-1").Hooks(velerov1api.BackupHooks{
+				Resources: []velerov1api.BackupResourceHookSpec{{
+					Name: "freeze",
+					PreHooks: []velerov1api.BackupResourceHook{{
+						Exec: &velerov1api.ExecHook{
+							PodSelection: "Random",
+							Commands: []velerov1api.ExecHookCommand{
+								{Command: []string{"fsfreeze"}, OnError: "Ignore"},
+								{Timeout: metav1.Duration{Duration: -time.Second}},
+							},
+						},
+					}},
+				}},
+			}).Result(),
+			want: []string{
+				`Invalid backup hook "freeze": pre hook 0 has invalid podSelection "Random", must be one of First or All`,
+				`Invalid backup hook "freeze": pre hook 0 command 0 has invalid onError "Ignore", must be one of Continue or Fail`,
+				`Invalid backup hook "freeze": pre hook 0 command 1 must have a command`,
+				`Invalid backup hook "freeze": pre hook 0 command 1 timeout must not be negative`,
+			},
+		},
+		{
+			name:   "replica locations must be distinct from each other and the storage location",
+			backup: builder.ForBackup("velero", "backup-1").StorageLocation("default").ReplicaStorageLocations("default", "replica", "replica").Result(),
+			want: []string{
+				"replica storage location default must be different from the backup's storage location",
+				"replica storage location replica is specified more than once",
+			},
+		},
+		{
+			name:   "failover locations must be distinct from each other, the storage location and the replica locations",
+			backup: builder.ForBackup("velero", "backup-1").StorageLocation("default").ReplicaStorageLocations("replica").FailoverStorageLocations("default", "replica", "failover", "failover").Result(),
+			want: []string{
+				"failover storage location default must be different from the backup's storage location",
+				"failover storage location replica can't also be a replica storage location",
+				"failover storage location failover is specified more than once",
+			},
+		},
+		{
+			name:   "storage prefix with a relative path segment is invalid",
+			backup: builder.ForBackup("velero", "backup-1").StoragePrefix("team-a/..").Result(),
+			want:   []string{`invalid storage prefix "team-a/..": path segment ".." must start with a letter or number and contain only letters, numbers, dots, dashes and underscores`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, ValidateBackupSpec(&tc.backup.Spec))
+		})
+	}
+}
+
+func TestValidateBackupLocations(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(builder.ForBackupStorageLocation("velero", "default").Result()))
+	require.NoError(t, indexer.Add(builder.ForBackupStorageLocation("velero", "replica").Result()))
+	lister := listers.NewBackupStorageLocationLister(indexer)
+
+	backup := builder.ForBackup("velero", "backup-1").StorageLocation("default").ReplicaStorageLocations("replica").Result()
+	assert.Empty(t, ValidateBackupLocations("velero", &backup.Spec, lister))
+
+	backup = builder.ForBackup("velero", "backup-1").ReplicaStorageLocations("replica").Result()
+	assert.Empty(t, ValidateBackupLocations("velero", &backup.Spec, lister))
+
+	backup = builder.ForBackup("velero", "backup-1").StorageLocation("missing").ReplicaStorageLocations("replica", "missing-replica").FailoverStorageLocations("missing-failover").Result()
+	assert.Equal(t, []string{
+		`a BackupStorageLocation CRD with the name specified in the backup spec needs to be created before this backup can be executed. Error: backupstoragelocation.velero.io "missing" not found`,
+		`error getting replica storage location missing-replica: backupstoragelocation.velero.io "missing-replica" not found`,
+		`error getting failover storage location missing-failover: backupstoragelocation.velero.io "missing-failover" not found`,
+	}, ValidateBackupLocations("velero", &backup.Spec, lister))
+}
+
+func TestValidateRestoreSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore *velerov1api.Restore
+		want    []string
+	}{
+		{
+			name:    "restore from a backup is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").Result(),
+		},
+		{
+			name:    "restore with an invalid log level is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").LogLevel("verbose").Result(),
+			want:    []string{`Invalid log level "verbose", must be one of debug, info, warning, error, fatal, panic`},
+		},
+		{
+			name:    "restore without a backup or schedule is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Result(),
+			want:    []string{"Either a backup or schedule must be specified as a source for the restore, but not both"},
+		},
+		{
+			name:    "restore with a backup and a schedule is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").Schedule("schedule-1").Result(),
+			want:    []string{"Either a backup or schedule must be specified as a source for the restore, but not both"},
+		},
+		{
+			name:    "restore of named cluster-scoped items is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").IncludedClusterResourceNames("clusterroles/admin").ExcludedClusterResourceNames("storageclasses/gp2").Result(),
+		},
+		{
+			name:    "cluster-scoped item in both includes and excludes is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").IncludedClusterResourceNames("clusterroles/admin").ExcludedClusterResourceNames("clusterroles/admin").Result(),
+			want:    []string{"Invalid included/excluded cluster resource name lists: excludes list cannot contain an item in the includes list: clusterroles/admin"},
+		},
+		{
+			name:    "restore from a backup version is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").BackupVersion("v1").Result(),
+		},
+		{
+			name:    "restore from a schedule with a backup version is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Schedule("schedule-1").BackupVersion("v1").Result(),
+			want:    []string{"A backup version can only be specified along with a backup name"},
+		},
+		{
+			name:    "including a non-restorable resource is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").IncludedResources("nodes").Result(),
+			want: []string{
+				"nodes are non-restorable resources",
+				"Invalid included/excluded resource lists: excludes list cannot contain an item in the includes list: nodes",
+			},
+		},
+		{
+			name:    "unknown mode and control-plane configuration policy are invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").Mode("ObjectsOnly").ControlPlaneConfigPolicy("Always").Result(),
+			want: []string{
+				`Invalid restore mode "ObjectsOnly", must be one of Full or DataOnly`,
+				`Invalid control-plane configuration policy "Always", must be one of Skip or Restore`,
+			},
+		},
+		{
+			name:    "selective extraction policy is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").ExtractionPolicy(velerov1api.RestoreExtractionPolicySelective).Result(),
+		},
+		{
+			name:    "unknown extraction policy is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").ExtractionPolicy("Streaming").Result(),
+			want:    []string{`Invalid extraction policy "Streaming", must be one of Full or Selective`},
+		},
+		{
+			name:    "restore with a storage class mapping is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").StorageClassMappings("gp2", "standard").Result(),
+		},
+		{
+			name:    "storage class mapping to an empty name is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").StorageClassMappings("gp2", "").Result(),
+			want:    []string{`Invalid storage class mapping "gp2" to "": storage class names must not be empty`},
+		},
+		{
+			name:    "restore with resource priorities and a ready timeout is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").ResourcePriorities("customresourcedefinitions", "foos.example.com").WaitForReady(true).ReadyTimeout(time.Minute).Result(),
+		},
+		{
+			name:    "empty and wildcard resource priorities are invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").ResourcePriorities("", "*").Result(),
+			want: []string{
+				`Invalid resource priority "": must be a resource name`,
+				`Invalid resource priority "*": must be a resource name`,
+			},
+		},
+		{
+			name:    "dry run restore is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").DryRun(true).Result(),
+		},
+		{
+			name:    "dry run data-only restore is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").DryRun(true).Mode(velerov1api.RestoreModeDataOnly).Result(),
+			want:    []string{"A dry run can't be used with the DataOnly restore mode"},
+		},
+		{
+			name:    "negative ready timeout is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").WaitForReady(true).ReadyTimeout(-time.Minute).Result(),
+			want:    []string{"Invalid ready timeout -1m0s, must not be negative"},
+		},
+		{
+			name:    "restore with a credential is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").Credential("break-glass", "cloud").Result(),
+		},
+		{
+			name:    "credential without a key is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").Credential("break-glass", "").Result(),
+			want:    []string{"A credential must have a secret name and key"},
+		},
+		{
+			name: "resource modifier with valid patches is valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").ResourceModifiers(velerov1api.RestoreResourceModifier{
+				GroupResource: "persistentvolumeclaims",
+				Patches: []velerov1api.JSONPatchOperation{
+					{Operation: "test", Path: "/spec/storageClassName", Value: &runtime.RawExtension{Raw: []byte(`"gp2"`)}},
+					{Operation: "replace", Path: "/spec/storageClassName", Value: &runtime.RawExtension{Raw: []byte(`"gp3"`)}},
+					{Operation: "remove", Path: "/metadata/annotations/foo"},
+				},
+			}).Result(),
+		},
+		{
+			name: "resource modifier without a group-resource or patches is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").ResourceModifiers(velerov1api.RestoreResourceModifier{
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Matches"}}},
+			}).Result(),
+			want: []string{
+				"Invalid resource modifier 0: groupResource must be specified",
+				`Invalid resource modifier 0: invalid label selector: "Matches" is not a valid pod selector operator`,
+				"Invalid resource modifier 0: at least one patch must be specified",
+			},
+		},
+		{
+			name: "resource modifier with invalid patches is invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").ResourceModifiers(velerov1api.RestoreResourceModifier{
+				GroupResource: "deployments.apps",
+				Patches: []velerov1api.JSONPatchOperation{
+					{Operation: "replace", Path: "spec/replicas"},
+					{Operation: "move", Path: "/spec/b"},
+					{Operation: "merge", Path: "/spec"},
+				},
+			}).Result(),
+			want: []string{
+				`Invalid resource modifier 0: patch 0 has invalid path "spec/replicas", must start with /`,
+				"Invalid resource modifier 0: patch 0 must have a value for a replace operation",
+				`Invalid resource modifier 0: patch 1 has invalid from "", must start with /`,
+				`Invalid resource modifier 0: patch 2 has invalid operation "merge", must be one of add, remove, replace, move, copy or test`,
+			},
+		},
+		{
+			name: "restore hooks with exec and init hooks are valid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").Hooks(velerov1api.RestoreResourceHookSpec{
+				Name: "hook-1",
+				PostHooks: []velerov1api.RestoreResourceHook{
+					{Init: &velerov1api.InitRestoreHook{InitContainers: []corev1api.Container{{Name: "init", Image: "image"}}}},
+					{Exec: &velerov1api.ExecRestoreHook{Command: []string{"recover"}, OnError: velerov1api.HookErrorModeContinue}},
+				},
+			}).Result(),
+		},
+		{
+			name: "invalid restore hooks are invalid",
+			restore: builder.ForRestore("velero", "restore-1").Backup("backup-1").Hooks(velerov1api.RestoreResourceHookSpec{
+				Name: "hook-1",
+				PostHooks: []velerov1api.RestoreResourceHook{
+					{},
+					{Exec: &velerov1api.ExecRestoreHook{OnError: "Retry"}},
+					{Init: &velerov1api.InitRestoreHook{InitContainers: []corev1api.Container{{Name: "init"}}}},
+				},
+			}).Result(),
+			want: []string{
+				`Invalid restore hook "hook-1": post hook 0 must have exactly one of exec or init`,
+				`Invalid restore hook "hook-1": post hook 1 must have a command`,
+				`Invalid restore hook "hook-1": post hook 1 has invalid onError "Retry", must be one of Continue or Fail`,
+				`Invalid restore hook "hook-1": post hook 2 init containers must have a name and image`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, ValidateRestoreSpec(&tc.restore.Spec))
+		})
+	}
+}
+
+func TestValidateScheduleSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *velerov1api.Schedule
+		want     []string
+	}{
+		{
+			name:     "valid schedule",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Result(),
+		},
+		{
+			name:     "empty cron expression is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").Result(),
+			want:     []string{"Schedule must be a non-empty valid Cron expression"},
+		},
+		{
+			name:     "cron expression with seconds is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 0 9 * * *").Result(),
+			want:     []string{"invalid schedule: Expected exactly 5 fields, found 6: 0 0 9 * * *"},
+		},
+		{
+			name:     "additional cron expressions and time zone are valid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 * * * 1-5").AdditionalCronSchedules("0 0 * * 0,6").TimeZone("Europe/Berlin").Result(),
+		},
+		{
+			name:     "invalid additional cron expression is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").AdditionalCronSchedules("0 0 * * 0,6", "").Result(),
+			want:     []string{"schedules[1]: Schedule must be a non-empty valid Cron expression"},
+		},
+		{
+			name:     "invalid time zone is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").TimeZone("Mars/Olympus_Mons").Result(),
+			want:     []string{`Invalid time zone "Mars/Olympus_Mons": unknown time zone Mars/Olympus_Mons`},
+		},
+		{
+			name:     "valid overlap policy",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").OverlapPolicy(velerov1api.ScheduleOverlapPolicySkip).Result(),
+		},
+		{
+			name:     "invalid overlap policy is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").OverlapPolicy("Forbid").Result(),
+			want:     []string{`Invalid overlap policy "Forbid", must be one of Queue, Skip or Allow`},
+		},
+		{
+			name:     "valid retention policy",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Retention(velerov1api.ScheduleRetention{KeepDaily: 7, KeepMonthly: 12}).Result(),
+		},
+		{
+			name:     "retention policy with a negative number is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Retention(velerov1api.ScheduleRetention{KeepDaily: 7, KeepWeekly: -1}).Result(),
+			want:     []string{"Invalid retention policy: the numbers of backups to keep must not be negative"},
+		},
+		{
+			name:     "retention policy that keeps nothing is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Retention(velerov1api.ScheduleRetention{}).Result(),
+			want:     []string{"Invalid retention policy: it must keep at least one backup"},
+		},
+		{
+			name:     "valid verification policy",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Verification(velerov1api.ScheduleVerification{Mode: velerov1api.ScheduleVerificationModeScratchNamespace, SampleNamespaces: 2}).Result(),
+		},
+		{
+			name:     "invalid verification mode is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Verification(velerov1api.ScheduleVerification{Mode: "Restore"}).Result(),
+			want:     []string{`Invalid verification mode "Restore", must be one of DryRun or ScratchNamespace`},
+		},
+		{
+			name:     "verification policy with a negative sample is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Verification(velerov1api.ScheduleVerification{SampleNamespaces: -1}).Result(),
+			want:     []string{"Invalid verification policy: the number of namespaces to sample must not be negative"},
+		},
+		{
+			name: "verification policy for volume-snapshot-only backups is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Verification(velerov1api.ScheduleVerification{}).Template(velerov1api.BackupSpec{
+				Mode: velerov1api.BackupModeVolumeSnapshotOnly,
+			}).Result(),
+			want: []string{"Backups in VolumeSnapshotOnly mode can't be restored, so they can't be verified"},
+		},
+		{
+			name: "invalid template is invalid",
+			schedule: builder.ForSchedule("velero", "schedule-1").CronSchedule("0 9 * * *").Template(velerov1api.BackupSpec{
+				IncludedNamespaces: []string{"ns-1"},
+				ExcludedNamespaces: []string{"ns-1"},
+			}).Result(),
+			want: []string{"Invalid included/excluded namespace lists: excludes list cannot contain an item in the includes list: ns-1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, ValidateScheduleSpec(&tc.schedule.Spec))
+		})
+	}
+}
+
+func TestParseScheduleSpec(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// hourly on weekdays and at midnight on weekends, Berlin time.
+	spec := &builder.ForSchedule("velero", "schedule-1").CronSchedule("0 * * * 1-5").AdditionalCronSchedules("0 0 * * 0,6").TimeZone("Europe/Berlin").Result().Spec
+
+	schedule, errs := ParseScheduleSpec(spec)
+	require.Empty(t, errs)
+
+	tests := []struct {
+		name string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "weekday is hourly",
+			from: time.Date(2019, 7, 5, 10, 30, 0, 0, berlin),
+			want: time.Date(2019, 7, 5, 11, 0, 0, 0, berlin),
+		},
+		{
+			name: "weekend is daily",
+			from: time.Date(2019, 7, 6, 0, 30, 0, 0, berlin),
+			want: time.Date(2019, 7, 7, 0, 0, 0, 0, berlin),
+		},
+		{
+			name: "last run of the weekend is followed by hourly runs",
+			from: time.Date(2019, 7, 7, 0, 0, 0, 0, berlin),
+			want: time.Date(2019, 7, 8, 0, 0, 0, 0, berlin),
+		},
+		{
+			name: "time in UTC is evaluated in the time zone",
+			from: time.Date(2019, 7, 5, 22, 30, 0, 0, time.UTC),
+			want: time.Date(2019, 7, 7, 0, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, tc.want.Equal(schedule.Next(tc.from)), "want %v, got %v", tc.want, schedule.Next(tc.from))
+		})
+	}
+}
@@ -36,6 +36,10 @@ type SnapshotSpec struct {
 	// Location is the name of the VolumeSnapshotLocation where this snapshot is stored.
 	Location string `json:"location"`
 
+	// Provider is the provider of the VolumeSnapshotLocation where this
+	// snapshot is stored, as of when the snapshot was taken.
+	Provider string `json:"provider,omitempty"`
+
 	// PersistentVolumeName is the Kubernetes name for the volume.
 	PersistentVolumeName string `json:persistentVolumeName`
 
@@ -53,6 +57,15 @@ type SnapshotSpec struct {
 	// VolumeIOPS is the optional value of provisioned IOPS for the
 	// disk/volume in the cloud provider API.
 	VolumeIOPS *int64 `json:"volumeIOPS,omitempty"`
+
+	// CSIDriver is the name of the volume's CSI driver for snapshots
+	// taken through the Kubernetes VolumeSnapshot API rather than a
+	// VolumeSnapshotter plugin. Location is empty for these snapshots.
+	CSIDriver string `json:"csiDriver,omitempty"`
+
+	// CSIVolumeSnapshotClass is the name of the VolumeSnapshotClass
+	// of a snapshot taken through the Kubernetes VolumeSnapshot API.
+	CSIVolumeSnapshotClass string `json:"csiVolumeSnapshotClass,omitempty"`
 }
 
 type SnapshotStatus struct {
@@ -62,6 +75,10 @@ type SnapshotStatus struct {
 
 	// Phase is the current state of the VolumeSnapshot.
 	Phase SnapshotPhase `json:"phase,omitempty"`
+
+	// CSIVolumeSnapshotContent is the name of the VolumeSnapshotContent
+	// holding a snapshot taken through the Kubernetes VolumeSnapshot API.
+	CSIVolumeSnapshotContent string `json:"csiVolumeSnapshotContent,omitempty"`
 }
 
 // SnapshotPhase is the lifecyle phase of a Velero volume snapshot.
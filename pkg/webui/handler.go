@@ -0,0 +1,565 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webui
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	pkgbackup "github.com/heptio/velero/pkg/backup"
+	"github.com/heptio/velero/pkg/cmd/util/downloadrequest"
+	"github.com/heptio/velero/pkg/cmd/util/output"
+	clientset "github.com/heptio/velero/pkg/generated/clientset/versioned"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
+	"github.com/heptio/velero/pkg/podvolume"
+	"github.com/heptio/velero/pkg/util/httpauth"
+)
+
+const (
+	// tokenCookie holds the bearer token that a user signed in with.
+	tokenCookie = "velero-ui-token"
+
+	// logsTimeout is how long to wait for the server to process the
+	// download request for a backup's or restore's logs.
+	logsTimeout = time.Minute
+)
+
+// Handler serves the web UI, which lists backups, restores and schedules,
+// shows the same details as `velero describe`, streams logs, and creates
+// restores from backups. Users sign in with a Kubernetes bearer token or a
+// client certificate, and every page checks that the user is allowed to
+// access the resources on it. The UI reads and creates resources with the
+// server's own credentials, so it only does so for users that could do the
+// same through the Kubernetes API.
+type Handler struct {
+	namespace      string
+	backupLister   listers.BackupLister
+	restoreLister  listers.RestoreLister
+	scheduleLister listers.ScheduleLister
+	veleroClient   clientset.Interface
+	authenticator  httpauth.Authenticator
+	tokens         httpauth.TokenAuthenticator
+	authorizer     httpauth.Authorizer
+	csrfKey        []byte
+	templates      map[string]*template.Template
+	logger         logrus.FieldLogger
+}
+
+// NewHandler returns a Handler for the Velero resources in namespace, that
+// authenticates users with the given httpauth methods. If they include
+// tokens, users can sign in with a token, which is kept in a cookie.
+// csrfKey is used to derive the tokens that forms are submitted with.
+func NewHandler(
+	namespace string,
+	backupLister listers.BackupLister,
+	restoreLister listers.RestoreLister,
+	scheduleLister listers.ScheduleLister,
+	veleroClient clientset.Interface,
+	authMethods []string,
+	tokens httpauth.TokenAuthenticator,
+	authorizer httpauth.Authorizer,
+	csrfKey []byte,
+	logger logrus.FieldLogger,
+) *Handler {
+	h := &Handler{
+		namespace:      namespace,
+		backupLister:   backupLister,
+		restoreLister:  restoreLister,
+		scheduleLister: scheduleLister,
+		veleroClient:   veleroClient,
+		authenticator:  httpauth.NewAuthenticator(authMethods, tokens),
+		authorizer:     authorizer,
+		csrfKey:        csrfKey,
+		templates:      parseTemplates(),
+		logger:         logger,
+	}
+
+	for _, method := range authMethods {
+		if method == httpauth.MethodToken {
+			h.tokens = tokens
+		}
+	}
+
+	return h
+}
+
+// page is what's passed to the templates.
+type page struct {
+	Title     string
+	User      string
+	CSRFToken string
+	Error     string
+	Data      interface{}
+}
+
+// session is a signed-in user's request. credential is what the user
+// authenticated with, which their CSRF token is derived from.
+type session struct {
+	credential string
+	user       *authenticationv1.UserInfo
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'")
+
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	if path == "/login" && h.tokens != nil {
+		h.login(w, req)
+		return
+	}
+
+	s, err := h.authenticate(req)
+	if err != nil {
+		h.logger.WithError(err).Error("Error authenticating web UI request")
+		http.Error(w, "error authenticating request", http.StatusInternalServerError)
+		return
+	}
+	if s == nil {
+		if h.tokens == nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, req, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if req.Method == http.MethodPost && !hmac.Equal([]byte(req.FormValue("csrf")), []byte(h.csrfToken(s.credential))) {
+		http.Error(w, "invalid or missing CSRF token", http.StatusForbidden)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	switch {
+	case path == "":
+		http.Redirect(w, req, "/backups", http.StatusSeeOther)
+	case path == "/logout" && req.Method == http.MethodPost:
+		http.SetCookie(w, &http.Cookie{Name: tokenCookie, Path: "/", MaxAge: -1, Secure: true, HttpOnly: true})
+		http.Redirect(w, req, "/login", http.StatusSeeOther)
+	case req.Method == http.MethodPost && len(parts) == 3 && parts[0] == "backups" && parts[2] == "restore":
+		h.createRestore(w, req, s, parts[1])
+	case req.Method != http.MethodGet:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	case len(parts) == 1 && parts[0] == "backups":
+		h.listBackups(w, s)
+	case len(parts) == 2 && parts[0] == "backups":
+		h.getBackup(w, s, parts[1])
+	case len(parts) == 3 && parts[0] == "backups" && parts[2] == "logs":
+		h.streamLogs(w, s, "backups", parts[1], velerov1api.DownloadTargetKindBackupLog)
+	case len(parts) == 1 && parts[0] == "restores":
+		h.listRestores(w, s)
+	case len(parts) == 2 && parts[0] == "restores":
+		h.getRestore(w, s, parts[1])
+	case len(parts) == 3 && parts[0] == "restores" && parts[2] == "logs":
+		h.streamLogs(w, s, "restores", parts[1], velerov1api.DownloadTargetKindRestoreLog)
+	case len(parts) == 1 && parts[0] == "schedules":
+		h.listSchedules(w, s)
+	case len(parts) == 2 && parts[0] == "schedules":
+		h.getSchedule(w, s, parts[1])
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// authenticate returns the session of the user making the request, or nil
+// if they haven't signed in. Users are authenticated with the handler's
+// methods, e.g. a token in the Authorization header set by an
+// authenticating proxy in front of the UI, or a client certificate, and
+// then with the cookie set when they signed in.
+func (h *Handler) authenticate(req *http.Request) (*session, error) {
+	user, err := h.authenticator.AuthenticateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		credential := httpauth.BearerToken(req)
+		if credential == "" {
+			credential = "user:" + user.Username
+		}
+		return &session{credential: credential, user: user}, nil
+	}
+
+	if h.tokens == nil {
+		return nil, nil
+	}
+
+	cookie, err := req.Cookie(tokenCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, nil
+	}
+
+	user, err = h.tokens.AuthenticateToken(cookie.Value)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	return &session{credential: cookie.Value, user: user}, nil
+}
+
+func (h *Handler) login(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		h.render(w, http.StatusOK, "login", &page{Title: "Sign in"})
+		return
+	}
+
+	token := strings.TrimSpace(req.FormValue("token"))
+	if token == "" {
+		h.render(w, http.StatusBadRequest, "login", &page{Title: "Sign in", Error: "A token is required"})
+		return
+	}
+
+	user, err := h.tokens.AuthenticateToken(token)
+	if err != nil {
+		h.logger.WithError(err).Error("Error authenticating web UI user")
+		h.render(w, http.StatusInternalServerError, "login", &page{Title: "Sign in", Error: "Unable to verify the token"})
+		return
+	}
+	if user == nil {
+		h.render(w, http.StatusUnauthorized, "login", &page{Title: "Sign in", Error: "The token isn't valid"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookie,
+		Value:    token,
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, req, "/backups", http.StatusSeeOther)
+}
+
+// NewRandomKey returns a random key for deriving CSRF tokens.
+func NewRandomKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "error generating web UI CSRF key")
+	}
+	return key, nil
+}
+
+// csrfToken returns the token that forms must be submitted with, which
+// is derived from the user's credential so that other sites can't forge
+// requests with the user's cookie or client certificate.
+func (h *Handler) csrfToken(credential string) string {
+	mac := hmac.New(sha256.New, h.csrfKey)
+	mac.Write([]byte(credential))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// authorize writes a Forbidden response and returns false if the user isn't
+// allowed to perform the verb on the resource.
+func (h *Handler) authorize(w http.ResponseWriter, s *session, verb, resource, name string) bool {
+	allowed, _, err := h.authorizer.Authorize(s.user, httpauth.Attributes{
+		Verb:      verb,
+		Group:     velerov1api.SchemeGroupVersion.Group,
+		Version:   velerov1api.SchemeGroupVersion.Version,
+		Resource:  resource,
+		Namespace: h.namespace,
+		Name:      name,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("Error authorizing web UI request")
+		http.Error(w, "error authorizing request", http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		http.Error(w, fmt.Sprintf("user %q cannot %s %s in namespace %q", s.user.Username, verb, resource, h.namespace), http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+func (h *Handler) render(w http.ResponseWriter, code int, name string, p *page) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+
+	if err := h.templates[name].ExecuteTemplate(w, "layout", p); err != nil {
+		h.logger.WithError(errors.WithStack(err)).WithField("template", name).Error("Error rendering web UI page")
+	}
+}
+
+func (h *Handler) renderPage(w http.ResponseWriter, s *session, name, title string, data interface{}) {
+	h.render(w, http.StatusOK, name, &page{
+		Title:     title,
+		User:      s.user.Username,
+		CSRFToken: h.csrfToken(s.credential),
+		Data:      data,
+	})
+}
+
+func (h *Handler) writeGetError(w http.ResponseWriter, resource, name string, err error) {
+	if apierrors.IsNotFound(err) {
+		http.Error(w, fmt.Sprintf("%s %q not found", resource, name), http.StatusNotFound)
+		return
+	}
+
+	h.logger.WithError(err).WithField("name", name).Errorf("Error getting %s for web UI", resource)
+	http.Error(w, fmt.Sprintf("error getting %s", resource), http.StatusInternalServerError)
+}
+
+func (h *Handler) listBackups(w http.ResponseWriter, s *session) {
+	if !h.authorize(w, s, "list", "backups", "") {
+		return
+	}
+
+	backups, err := h.backupLister.Backups(h.namespace).List(labels.Everything())
+	if err != nil {
+		h.writeGetError(w, "backups", "", err)
+		return
+	}
+
+	// newest first, like `velero backup get`
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].CreationTimestamp.Equal(&backups[j].CreationTimestamp) {
+			return backups[j].CreationTimestamp.Before(&backups[i].CreationTimestamp)
+		}
+		return backups[i].Name < backups[j].Name
+	})
+
+	h.renderPage(w, s, "backups", "Backups", backups)
+}
+
+func (h *Handler) getBackup(w http.ResponseWriter, s *session, name string) {
+	if !h.authorize(w, s, "get", "backups", name) {
+		return
+	}
+
+	backup, err := h.backupLister.Backups(h.namespace).Get(name)
+	if err != nil {
+		h.writeGetError(w, "backup", name, err)
+		return
+	}
+
+	var deleteRequests []velerov1api.DeleteBackupRequest
+	deleteRequestList, err := h.veleroClient.VeleroV1().DeleteBackupRequests(h.namespace).List(pkgbackup.NewDeleteBackupRequestListOptions(backup.Name, string(backup.UID)))
+	if err != nil {
+		h.logger.WithError(err).WithField("backup", name).Warn("Error getting DeleteBackupRequests for web UI")
+	} else {
+		deleteRequests = deleteRequestList.Items
+	}
+
+	var podVolumeBackups []velerov1api.PodVolumeBackup
+	podVolumeBackupList, err := h.veleroClient.VeleroV1().PodVolumeBackups(h.namespace).List(podvolume.NewPodVolumeBackupListOptions(backup.Name))
+	if err != nil {
+		h.logger.WithError(err).WithField("backup", name).Warn("Error getting PodVolumeBackups for web UI")
+	} else {
+		podVolumeBackups = podVolumeBackupList.Items
+	}
+
+	h.renderPage(w, s, "backup", "Backup "+name, struct {
+		Backup      *velerov1api.Backup
+		Description string
+	}{
+		Backup:      backup,
+		Description: output.DescribeBackup(backup, deleteRequests, podVolumeBackups, false, h.veleroClient),
+	})
+}
+
+func (h *Handler) createRestore(w http.ResponseWriter, req *http.Request, s *session, backupName string) {
+	if !h.authorize(w, s, "create", "restores", "") {
+		return
+	}
+
+	if _, err := h.backupLister.Backups(h.namespace).Get(backupName); err != nil {
+		h.writeGetError(w, "backup", backupName, err)
+		return
+	}
+
+	name := strings.TrimSpace(req.FormValue("name"))
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", backupName, time.Now().Format("20060102150405"))
+	}
+
+	restore := &velerov1api.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: h.namespace,
+			Name:      name,
+		},
+		Spec: velerov1api.RestoreSpec{
+			BackupName:         backupName,
+			IncludedNamespaces: splitList(req.FormValue("includedNamespaces")),
+			ExcludedNamespaces: splitList(req.FormValue("excludedNamespaces")),
+		},
+	}
+
+	if _, err := h.veleroClient.VeleroV1().Restores(h.namespace).Create(restore); err != nil {
+		h.logger.WithError(err).WithField("restore", name).Error("Error creating restore from web UI")
+		http.Error(w, fmt.Sprintf("error creating restore: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"restore": name,
+		"backup":  backupName,
+		"user":    s.user.Username,
+	}).Info("Restore created from web UI")
+
+	http.Redirect(w, req, "/restores/"+name, http.StatusSeeOther)
+}
+
+// splitList splits a comma-separated form value, ignoring empty items.
+func splitList(value string) []string {
+	var res []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+func (h *Handler) listRestores(w http.ResponseWriter, s *session) {
+	if !h.authorize(w, s, "list", "restores", "") {
+		return
+	}
+
+	restores, err := h.restoreLister.Restores(h.namespace).List(labels.Everything())
+	if err != nil {
+		h.writeGetError(w, "restores", "", err)
+		return
+	}
+
+	sort.Slice(restores, func(i, j int) bool {
+		if !restores[i].CreationTimestamp.Equal(&restores[j].CreationTimestamp) {
+			return restores[j].CreationTimestamp.Before(&restores[i].CreationTimestamp)
+		}
+		return restores[i].Name < restores[j].Name
+	})
+
+	h.renderPage(w, s, "restores", "Restores", restores)
+}
+
+func (h *Handler) getRestore(w http.ResponseWriter, s *session, name string) {
+	if !h.authorize(w, s, "get", "restores", name) {
+		return
+	}
+
+	restore, err := h.restoreLister.Restores(h.namespace).Get(name)
+	if err != nil {
+		h.writeGetError(w, "restore", name, err)
+		return
+	}
+
+	var podVolumeRestores []velerov1api.PodVolumeRestore
+	podVolumeRestoreList, err := h.veleroClient.VeleroV1().PodVolumeRestores(h.namespace).List(podvolume.NewPodVolumeRestoreListOptions(restore.Name))
+	if err != nil {
+		h.logger.WithError(err).WithField("restore", name).Warn("Error getting PodVolumeRestores for web UI")
+	} else {
+		podVolumeRestores = podVolumeRestoreList.Items
+	}
+
+	h.renderPage(w, s, "restore", "Restore "+name, struct {
+		Restore     *velerov1api.Restore
+		Description string
+	}{
+		Restore:     restore,
+		Description: output.DescribeRestore(restore, podVolumeRestores, false, h.veleroClient),
+	})
+}
+
+func (h *Handler) listSchedules(w http.ResponseWriter, s *session) {
+	if !h.authorize(w, s, "list", "schedules", "") {
+		return
+	}
+
+	schedules, err := h.scheduleLister.Schedules(h.namespace).List(labels.Everything())
+	if err != nil {
+		h.writeGetError(w, "schedules", "", err)
+		return
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].Name < schedules[j].Name
+	})
+
+	h.renderPage(w, s, "schedules", "Schedules", schedules)
+}
+
+func (h *Handler) getSchedule(w http.ResponseWriter, s *session, name string) {
+	if !h.authorize(w, s, "get", "schedules", name) {
+		return
+	}
+
+	schedule, err := h.scheduleLister.Schedules(h.namespace).Get(name)
+	if err != nil {
+		h.writeGetError(w, "schedule", name, err)
+		return
+	}
+
+	h.renderPage(w, s, "schedule", "Schedule "+name, struct {
+		Schedule    *velerov1api.Schedule
+		Description string
+	}{
+		Schedule:    schedule,
+		Description: output.DescribeSchedule(schedule),
+	})
+}
+
+// streamLogs writes a backup's or restore's logs, which are fetched with a
+// download request, so they come from the download proxy when the object
+// store can't create pre-signed URLs or the server always proxies downloads.
+func (h *Handler) streamLogs(w http.ResponseWriter, s *session, resource, name string, kind velerov1api.DownloadTargetKind) {
+	// reading logs requires the same permission as `velero backup logs`
+	// and `velero restore logs`.
+	if !h.authorize(w, s, "get", resource, name) || !h.authorize(w, s, "create", "downloadrequests", "") {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if err := downloadrequest.Stream(h.veleroClient.VeleroV1(), h.namespace, name, kind, w, logsTimeout); err != nil {
+		// if some of the logs have already been written, the error is
+		// appended to them.
+		h.logger.WithError(err).WithField("name", name).Errorf("Error streaming %s logs for web UI", strings.TrimSuffix(resource, "s"))
+		if err == downloadrequest.ErrNotFound {
+			http.Error(w, "logs not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("error getting logs: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// NewServer returns a server for the web UI. It must be started with
+// ListenAndServeTLS, since users' bearer tokens are sent to it.
+func NewServer(address string, tlsConfig *tls.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:      address,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+}
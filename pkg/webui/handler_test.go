@@ -0,0 +1,290 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webui
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/builder"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	velerotest "github.com/heptio/velero/pkg/test"
+	"github.com/heptio/velero/pkg/util/httpauth"
+)
+
+type fakeAuthenticator struct{}
+
+func (a *fakeAuthenticator) AuthenticateToken(token string) (*authenticationv1.UserInfo, error) {
+	if token != "valid-token" {
+		return nil, nil
+	}
+	return &authenticationv1.UserInfo{Username: "alice"}, nil
+}
+
+type fakeAuthorizer struct {
+	denied   map[string]bool
+	requests []string
+}
+
+func (a *fakeAuthorizer) Authorize(user *authenticationv1.UserInfo, attrs httpauth.Attributes) (bool, string, error) {
+	request := attrs.Verb + " " + attrs.Resource
+	a.requests = append(a.requests, request)
+	return !a.denied[request], "", nil
+}
+
+type testHarness struct {
+	*Handler
+	client     *fake.Clientset
+	authorizer *fakeAuthorizer
+}
+
+func newTestHarness(t *testing.T, authMethods ...string) *testHarness {
+	if len(authMethods) == 0 {
+		authMethods = []string{httpauth.MethodToken}
+	}
+
+	client := fake.NewSimpleClientset()
+	sharedInformers := informers.NewSharedInformerFactory(client, 0)
+
+	backups := []*velerov1api.Backup{
+		builder.ForBackup(velerov1api.DefaultNamespace, "backup-1").Phase(velerov1api.BackupPhaseCompleted).Result(),
+		builder.ForBackup(velerov1api.DefaultNamespace, "backup-2").Result(),
+		builder.ForBackup("other-ns", "backup-3").Result(),
+	}
+	for _, backup := range backups {
+		require.NoError(t, sharedInformers.Velero().V1().Backups().Informer().GetStore().Add(backup))
+	}
+	require.NoError(t, sharedInformers.Velero().V1().Restores().Informer().GetStore().Add(
+		builder.ForRestore(velerov1api.DefaultNamespace, "restore-1").Backup("backup-1").Result(),
+	))
+	require.NoError(t, sharedInformers.Velero().V1().Schedules().Informer().GetStore().Add(
+		builder.ForSchedule(velerov1api.DefaultNamespace, "daily").CronSchedule("0 0 * * *").Result(),
+	))
+
+	authorizer := &fakeAuthorizer{denied: make(map[string]bool)}
+
+	return &testHarness{
+		Handler: NewHandler(
+			velerov1api.DefaultNamespace,
+			sharedInformers.Velero().V1().Backups().Lister(),
+			sharedInformers.Velero().V1().Restores().Lister(),
+			sharedInformers.Velero().V1().Schedules().Lister(),
+			client,
+			authMethods,
+			&fakeAuthenticator{},
+			authorizer,
+			[]byte("key"),
+			velerotest.NewLogger(),
+		),
+		client:     client,
+		authorizer: authorizer,
+	}
+}
+
+func (h *testHarness) serve(method, path, token string, form url.Values) *httptest.ResponseRecorder {
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+
+	req := httptest.NewRequest(method, path, body)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: tokenCookie, Value: token})
+	}
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	return res
+}
+
+func TestLogin(t *testing.T) {
+	h := newTestHarness(t)
+
+	res := h.serve(http.MethodGet, "/backups", "", nil)
+	assert.Equal(t, http.StatusSeeOther, res.Code)
+	assert.Equal(t, "/login", res.Header().Get("Location"))
+
+	res = h.serve(http.MethodGet, "/backups", "invalid-token", nil)
+	assert.Equal(t, http.StatusSeeOther, res.Code)
+	assert.Equal(t, "/login", res.Header().Get("Location"))
+
+	res = h.serve(http.MethodPost, "/login", "", url.Values{"token": {"invalid-token"}})
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Contains(t, res.Body.String(), "The token isn&#39;t valid")
+
+	res = h.serve(http.MethodPost, "/login", "", url.Values{"token": {"valid-token"}})
+	assert.Equal(t, http.StatusSeeOther, res.Code)
+	assert.Equal(t, "/backups", res.Header().Get("Location"))
+
+	cookies := res.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, tokenCookie, cookies[0].Name)
+	assert.Equal(t, "valid-token", cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+
+	// an authenticating proxy can send the token in a header instead.
+	req := httptest.NewRequest(http.MethodGet, "/backups", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestClientCertAuthentication(t *testing.T) {
+	h := newTestHarness(t, httpauth.MethodClientCert)
+
+	// without the token method, users can't sign in with a token.
+	res := h.serve(http.MethodGet, "/backups", "valid-token", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	res = h.serve(http.MethodPost, "/login", "", url.Values{"token": {"valid-token"}})
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "bob"}}
+	req := httptest.NewRequest(http.MethodGet, "/backups", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Sign out bob")
+	assert.Contains(t, rec.Body.String(), h.csrfToken("user:bob"))
+}
+
+func TestPages(t *testing.T) {
+	tests := []struct {
+		path     string
+		contains []string
+		excludes []string
+		requests []string
+	}{
+		{
+			path:     "/backups",
+			contains: []string{"backup-1", "backup-2", "Completed", "New"},
+			excludes: []string{"backup-3"},
+			requests: []string{"list backups"},
+		},
+		{
+			path:     "/backups/backup-1",
+			contains: []string{"Backup backup-1", "Phase:", "Completed", "Create restore"},
+			requests: []string{"get backups"},
+		},
+		{
+			path:     "/restores",
+			contains: []string{"restore-1", "backup-1"},
+			requests: []string{"list restores"},
+		},
+		{
+			path:     "/restores/restore-1",
+			contains: []string{"Restore restore-1", "Backup:", "backup-1"},
+			requests: []string{"get restores"},
+		},
+		{
+			path:     "/schedules",
+			contains: []string{"daily", "0 0 * * *"},
+			requests: []string{"list schedules"},
+		},
+		{
+			path:     "/schedules/daily",
+			contains: []string{"Schedule daily", "0 0 * * *"},
+			requests: []string{"get schedules"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			h := newTestHarness(t)
+
+			res := h.serve(http.MethodGet, tc.path, "valid-token", nil)
+			require.Equal(t, http.StatusOK, res.Code)
+			for _, s := range tc.contains {
+				assert.Contains(t, res.Body.String(), s)
+			}
+			for _, s := range tc.excludes {
+				assert.NotContains(t, res.Body.String(), s)
+			}
+			assert.Equal(t, tc.requests, h.authorizer.requests)
+
+			// users that aren't allowed to access the resources can't
+			// see the page.
+			h.authorizer.denied[tc.requests[0]] = true
+			res = h.serve(http.MethodGet, tc.path, "valid-token", nil)
+			assert.Equal(t, http.StatusForbidden, res.Code)
+		})
+	}
+}
+
+func TestPageNotFound(t *testing.T) {
+	h := newTestHarness(t)
+
+	assert.Equal(t, http.StatusNotFound, h.serve(http.MethodGet, "/backups/backup-3", "valid-token", nil).Code)
+	assert.Equal(t, http.StatusNotFound, h.serve(http.MethodGet, "/backups/backup-1/contents", "valid-token", nil).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, h.serve(http.MethodDelete, "/backups/backup-1", "valid-token", nil).Code)
+}
+
+func TestCreateRestore(t *testing.T) {
+	h := newTestHarness(t)
+	csrf := h.csrfToken("valid-token")
+
+	// forms must be submitted with a CSRF token.
+	res := h.serve(http.MethodPost, "/backups/backup-1/restore", "valid-token", url.Values{"name": {"restore-2"}})
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = h.serve(http.MethodPost, "/backups/backup-1/restore", "valid-token", url.Values{
+		"csrf":               {csrf},
+		"name":               {"restore-2"},
+		"includedNamespaces": {"ns-1, ns-2"},
+	})
+	require.Equal(t, http.StatusSeeOther, res.Code)
+	assert.Equal(t, "/restores/restore-2", res.Header().Get("Location"))
+	assert.Equal(t, []string{"create restores"}, h.authorizer.requests)
+
+	restore, err := h.client.VeleroV1().Restores(velerov1api.DefaultNamespace).Get("restore-2", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "backup-1", restore.Spec.BackupName)
+	assert.Equal(t, []string{"ns-1", "ns-2"}, restore.Spec.IncludedNamespaces)
+	assert.Empty(t, restore.Spec.ExcludedNamespaces)
+
+	// restore names default to the backup name and a timestamp.
+	res = h.serve(http.MethodPost, "/backups/backup-1/restore", "valid-token", url.Values{"csrf": {csrf}})
+	require.Equal(t, http.StatusSeeOther, res.Code)
+	assert.True(t, strings.HasPrefix(res.Header().Get("Location"), "/restores/backup-1-"))
+
+	res = h.serve(http.MethodPost, "/backups/backup-3/restore", "valid-token", url.Values{"csrf": {csrf}})
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	h.authorizer.denied["create restores"] = true
+	res = h.serve(http.MethodPost, "/backups/backup-1/restore", "valid-token", url.Values{"csrf": {csrf}})
+	assert.Equal(t, http.StatusForbidden, res.Code)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2019 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webui
+
+import (
+	"fmt"
+	"html/template"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The UI is a handful of server-rendered pages, so that it doesn't need
+// any assets beyond what's compiled into the server.
+const layoutTemplate = `{{define "layout"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Velero{{if .Title}} - {{.Title}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; color: #333; }
+nav { background: #325078; padding: 0.75em 1.5em; }
+nav a, nav button { color: #fff; margin-right: 1.5em; text-decoration: none; background: none; border: none; font: inherit; cursor: pointer; padding: 0; }
+nav form { display: inline; float: right; }
+main { padding: 1em 1.5em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: 0.3em 1em 0.3em 0; border-bottom: 1px solid #ddd; }
+pre { background: #f5f5f5; padding: 1em; overflow-x: auto; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+{{if .User}}<nav>
+<a href="/backups">Backups</a>
+<a href="/restores">Restores</a>
+<a href="/schedules">Schedules</a>
+<form method="post" action="/logout"><input type="hidden" name="csrf" value="{{.CSRFToken}}"><button type="submit">Sign out {{.User}}</button></form>
+</nav>{{end}}
+<main>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{template "content" .}}
+</main>
+</body>
+</html>{{end}}`
+
+var pageTemplates = map[string]string{
+	"login": `{{define "content"}}<h1>Velero</h1>
+<p>Sign in with a Kubernetes bearer token, such as a service account token.</p>
+<form method="post" action="/login">
+<input type="password" name="token" size="60" autofocus>
+<button type="submit">Sign in</button>
+</form>{{end}}`,
+
+	"backups": `{{define "content"}}<h1>Backups</h1>
+<table>
+<tr><th>Name</th><th>Status</th><th>Created</th><th>Expires</th><th>Storage location</th><th>Schedule</th></tr>
+{{range .Data}}<tr>
+<td><a href="/backups/{{.Name}}">{{.Name}}</a></td>
+<td>{{phase .Status.Phase}}</td>
+<td>{{formatTime .CreationTimestamp}}</td>
+<td>{{formatTime .Status.Expiration}}</td>
+<td>{{.Spec.StorageLocation}}</td>
+<td>{{index .Labels "velero.io/schedule-name"}}</td>
+</tr>{{else}}<tr><td colspan="6">No backups</td></tr>{{end}}
+</table>{{end}}`,
+
+	"backup": `{{define "content"}}<h1>Backup {{.Data.Backup.Name}}</h1>
+<p><a href="/backups/{{.Data.Backup.Name}}/logs">View logs</a></p>
+<pre>{{.Data.Description}}</pre>
+<h2>Restore</h2>
+<form method="post" action="/backups/{{.Data.Backup.Name}}/restore">
+<input type="hidden" name="csrf" value="{{.CSRFToken}}">
+<p><label>Restore name <input type="text" name="name" placeholder="{{.Data.Backup.Name}}-&lt;timestamp&gt;"></label></p>
+<p><label>Included namespaces <input type="text" name="includedNamespaces" placeholder="*"></label></p>
+<p><label>Excluded namespaces <input type="text" name="excludedNamespaces"></label></p>
+<button type="submit">Create restore</button>
+</form>{{end}}`,
+
+	"restores": `{{define "content"}}<h1>Restores</h1>
+<table>
+<tr><th>Name</th><th>Backup</th><th>Status</th><th>Warnings</th><th>Errors</th><th>Created</th></tr>
+{{range .Data}}<tr>
+<td><a href="/restores/{{.Name}}">{{.Name}}</a></td>
+<td>{{.Spec.BackupName}}</td>
+<td>{{phase .Status.Phase}}</td>
+<td>{{.Status.Warnings}}</td>
+<td>{{.Status.Errors}}</td>
+<td>{{formatTime .CreationTimestamp}}</td>
+</tr>{{else}}<tr><td colspan="6">No restores</td></tr>{{end}}
+</table>{{end}}`,
+
+	"restore": `{{define "content"}}<h1>Restore {{.Data.Restore.Name}}</h1>
+<p><a href="/restores/{{.Data.Restore.Name}}/logs">View logs</a></p>
+<pre>{{.Data.Description}}</pre>{{end}}`,
+
+	"schedules": `{{define "content"}}<h1>Schedules</h1>
+<table>
+<tr><th>Name</th><th>Status</th><th>Schedule</th><th>Backup TTL</th><th>Last backup</th></tr>
+{{range .Data}}<tr>
+<td><a href="/schedules/{{.Name}}">{{.Name}}</a></td>
+<td>{{phase .Status.Phase}}</td>
+<td>{{.Spec.Schedule}}</td>
+<td>{{.Spec.Template.TTL.Duration}}</td>
+<td>{{formatTime .Status.LastBackup}}</td>
+</tr>{{else}}<tr><td colspan="5">No schedules</td></tr>{{end}}
+</table>{{end}}`,
+
+	"schedule": `{{define "content"}}<h1>Schedule {{.Data.Schedule.Name}}</h1>
+<pre>{{.Data.Description}}</pre>{{end}}`,
+}
+
+var templateFuncs = template.FuncMap{
+	"formatTime": formatTime,
+	"phase":      phase,
+}
+
+func formatTime(t metav1.Time) string {
+	if t.IsZero() {
+		return "<n/a>"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// phase returns a resource's phase, or New if it hasn't been processed yet.
+func phase(p interface{}) string {
+	s := fmt.Sprint(p)
+	if s == "" {
+		return "New"
+	}
+	return s
+}
+
+// parseTemplates returns each page's template, combined with the layout.
+func parseTemplates() map[string]*template.Template {
+	layout := template.Must(template.New("layout").Funcs(templateFuncs).Parse(layoutTemplate))
+
+	templates := make(map[string]*template.Template, len(pageTemplates))
+	for name, text := range pageTemplates {
+		templates[name] = template.Must(template.Must(layout.Clone()).Parse(text))
+	}
+
+	return templates
+}